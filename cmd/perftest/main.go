@@ -40,6 +40,8 @@ standalone testing and distributed cluster testing.`,
 
 		http.PerfTestCollectorCMD(),
 		http.PerfTestAgentCMD(),
+		http.PerfTestDeployAgentsCMD(),
+		http.PerfTestComposeCMD(),
 	)
 
 	return cmd
@@ -0,0 +1,228 @@
+// Package client is to generate the typed http client code.
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/go-dev-frame/sponge/cmd/protoc-gen-go-gin/internal/parse"
+)
+
+// GenerateFiles generate typed http client code, the http methods GET, POST, PUT, PATCH and DELETE are supported,
+// if a rpc method has additional bindings, only the main binding is used, the path of request is prefixed
+// with the route group of routePrefix and the api version of proto file.
+func GenerateFiles(file *protogen.File, routePrefix string) ([]byte, error) {
+	if len(file.Services) == 0 {
+		return nil, nil
+	}
+
+	pss := parse.ParseHTTPPbServices(file, routePrefix)
+	var services []*clientService
+	for i, s := range file.Services {
+		cs, err := parseClientService(s, pss[i])
+		if err != nil {
+			return nil, err
+		}
+		if len(cs.Methods) > 0 {
+			services = append(services, cs)
+		}
+	}
+	if len(services) == 0 {
+		return nil, nil
+	}
+
+	return genClientFile(services, parse.HTTPPbServices(pss).MergeImportPkgPath(), string(file.GoPackageName))
+}
+
+type clientService struct {
+	Name      string // Greeter
+	LowerName string // greeter first character to lower
+	Methods   []*clientMethod
+}
+
+type clientMethod struct {
+	Name       string // GetByID
+	Comment    string // e.g. // GetByID get a record by id
+	Method     string // http method
+	Path       string // e.g. /api/v1/user/:id
+	PathExpr   string // go expression of path, e.g. "/api/v1/user/" + url.PathEscape(fmt.Sprint(req.GetId()))
+	Request    string // e.g. userV1.GetByIDRequest
+	Reply      string // e.g. userV1.GetByIDReply
+	HasPathArg bool
+}
+
+func parseClientService(s *protogen.Service, ps *parse.HTTPPbService) (*clientService, error) {
+	protoMethods := map[string]*protogen.Method{}
+	for _, m := range s.Methods {
+		protoMethods[m.GoName] = m
+	}
+
+	// the main binding is behind the additional bindings, it overrides them
+	var names []string
+	rpcMethods := map[string]*parse.RPCMethod{}
+	for _, m := range ps.Methods {
+		if m.InvokeType != 0 || m.Path == "" || !isSupportedMethod(m.Method) {
+			continue
+		}
+		if _, ok := rpcMethods[m.Name]; !ok {
+			names = append(names, m.Name)
+		}
+		rpcMethods[m.Name] = m
+	}
+
+	cs := &clientService{
+		Name:      ps.Name,
+		LowerName: ps.LowerName,
+	}
+	for _, name := range names {
+		m := rpcMethods[name]
+		pm := protoMethods[name]
+		path := ps.RoutePrefix + m.Path
+		pathExpr, hasPathArg, err := toPathExpr(path, pm.Input)
+		if err != nil {
+			return nil, fmt.Errorf("protoc-gen-go-gin: rpc method %s.%s, %v", s.GoName, name, err)
+		}
+		cs.Methods = append(cs.Methods, &clientMethod{
+			Name:       name,
+			Comment:    getComment(pm),
+			Method:     m.Method,
+			Path:       path,
+			PathExpr:   pathExpr,
+			Request:    m.RequestImportPkgName + m.Request,
+			Reply:      m.ReplyImportPkgName + m.Reply,
+			HasPathArg: hasPathArg,
+		})
+	}
+
+	return cs, nil
+}
+
+func isSupportedMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// convert the path to go expression, the path parameter is replaced with the field value of request,
+// e.g. /api/v1/user/:id --> "/api/v1/user/" + url.PathEscape(fmt.Sprint(req.GetId()))
+func toPathExpr(path string, input *protogen.Message) (string, bool, error) {
+	var (
+		exprs      []string
+		literal    string
+		hasPathArg bool
+	)
+	for i, segment := range strings.Split(path, "/") {
+		if i > 0 {
+			literal += "/"
+		}
+		if !strings.HasPrefix(segment, ":") {
+			literal += segment
+			continue
+		}
+
+		valueExpr, err := getFieldValueExpr(input, segment[1:])
+		if err != nil {
+			return "", false, err
+		}
+		if literal != "" {
+			exprs = append(exprs, strconv.Quote(literal))
+			literal = ""
+		}
+		exprs = append(exprs, "url.PathEscape("+valueExpr+")")
+		hasPathArg = true
+	}
+	if literal != "" {
+		exprs = append(exprs, strconv.Quote(literal))
+	}
+
+	return strings.Join(exprs, " + "), hasPathArg, nil
+}
+
+// get the go expression of field value by name, nested field is supported, e.g. user.id --> req.GetUser().GetId()
+func getFieldValueExpr(msg *protogen.Message, name string) (string, error) {
+	expr := "req"
+	names := strings.Split(name, ".")
+	for i, n := range names {
+		var field *protogen.Field
+		for _, f := range msg.Fields {
+			if string(f.Desc.Name()) == n || f.Desc.JSONName() == n {
+				field = f
+				break
+			}
+		}
+		if field == nil {
+			return "", fmt.Errorf("path parameter '%s' is not found in the fields of %s", name, msg.GoIdent.GoName)
+		}
+		expr += ".Get" + field.GoName + "()"
+
+		if i < len(names)-1 {
+			if field.Message == nil || field.Desc.IsList() || field.Desc.IsMap() {
+				return "", fmt.Errorf("path parameter '%s', the field %s is not a message", name, field.GoName)
+			}
+			msg = field.Message
+			continue
+		}
+
+		if field.Desc.IsList() || field.Desc.IsMap() {
+			return "", fmt.Errorf("path parameter '%s' can not be repeated field or map", name)
+		}
+		switch field.Desc.Kind() {
+		case protoreflect.StringKind:
+			return expr, nil
+		case protoreflect.MessageKind, protoreflect.GroupKind, protoreflect.BytesKind:
+			return "", fmt.Errorf("path parameter '%s' must be scalar type", name)
+		case protoreflect.EnumKind:
+			return "fmt.Sprint(int32(" + expr + "))", nil
+		}
+		return "fmt.Sprint(" + expr + ")", nil
+	}
+
+	return "", fmt.Errorf("invalid path parameter '%s'", name)
+}
+
+func getComment(m *protogen.Method) string {
+	comment := strings.TrimSpace(m.Comments.Leading.String())
+	if comment == "" {
+		return "// " + m.GoName + " ......"
+	}
+	lines := strings.Split(comment, "\n")
+	lines[0] = "// " + m.GoName + " " + strings.TrimSpace(strings.TrimPrefix(lines[0], "//"))
+	return strings.Join(lines, "\n\t")
+}
+
+func genClientFile(services []*clientService, packagePaths string, goPackageName string) ([]byte, error) {
+	needFmt := false
+	needURL := false
+	for _, s := range services {
+		for _, m := range s.Methods {
+			if m.HasPathArg {
+				needURL = true
+				if strings.Contains(m.PathExpr, "fmt.") {
+					needFmt = true
+				}
+			}
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	err := clientTmpl.Execute(buf, map[string]interface{}{
+		"PackageName":  goPackageName,
+		"PackagePaths": packagePaths,
+		"NeedFmt":      needFmt,
+		"NeedURL":      needURL,
+		"Services":     services,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
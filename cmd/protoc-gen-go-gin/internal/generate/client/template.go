@@ -0,0 +1,79 @@
+package client
+
+import (
+	"text/template"
+)
+
+func init() {
+	var err error
+	clientTmpl, err = template.New("client").Parse(clientTmplRaw)
+	if err != nil {
+		panic(err)
+	}
+}
+
+var (
+	clientTmpl    *template.Template
+	clientTmplRaw = `// Code generated by https://github.com/go-dev-frame/sponge, DO NOT EDIT.
+
+package {{.PackageName}}
+
+import (
+	"context"
+{{- if .NeedFmt}}
+	"fmt"
+{{- end}}
+{{- if .NeedURL}}
+	"net/url"
+{{- end}}
+	"strings"
+
+	"github.com/go-dev-frame/sponge/pkg/httpcli"
+{{- if .PackagePaths}}
+
+	{{.PackagePaths}}
+{{- end}}
+)
+{{range $s := .Services}}
+// {{.Name}}HTTPClient is the typed http client of {{.Name}}, if the code of response is not 0,
+// *httpcli.APIError is returned, the error code can be parsed by errcode.ParseError.
+type {{.Name}}HTTPClient interface {
+{{- range .Methods}}
+	{{.Comment}}
+	{{.Name}}(ctx context.Context, req *{{.Request}}, opts ...httpcli.Option) (*{{.Reply}}, error)
+{{- end}}
+}
+
+type {{.LowerName}}HTTPClient struct {
+	baseURL string
+	opts    []httpcli.Option
+}
+
+// New{{.Name}}HTTPClient create a typed http client of {{.Name}}, baseURL is the address of http server,
+// e.g. http://localhost:8080, the opts are used by all requests, e.g. httpcli.WithClient(httpcli.NewClient()).
+func New{{.Name}}HTTPClient(baseURL string, opts ...httpcli.Option) {{.Name}}HTTPClient {
+	return &{{.LowerName}}HTTPClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		opts:    opts,
+	}
+}
+
+func (c *{{.LowerName}}HTTPClient) options(opts []httpcli.Option) []httpcli.Option {
+	if len(opts) == 0 {
+		return c.opts
+	}
+	return append(append(make([]httpcli.Option, 0, len(c.opts)+len(opts)), c.opts...), opts...)
+}
+{{range .Methods}}
+// {{.Name}} {{.Method}} {{.Path}}
+func (c *{{$s.LowerName}}HTTPClient) {{.Name}}(ctx context.Context, req *{{.Request}}, opts ...httpcli.Option) (*{{.Reply}}, error) {
+	reply := &{{.Reply}}{}
+	err := httpcli.Invoke(ctx, "{{.Method}}", c.baseURL+{{.PathExpr}}, req, reply, c.options(opts)...)
+	if err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+{{end}}
+{{- end}}`
+)
@@ -9,13 +9,15 @@ import (
 	"github.com/go-dev-frame/sponge/cmd/protoc-gen-go-gin/internal/parse"
 )
 
-// GenerateFiles generate handler logic, router, error code files.
-func GenerateFiles(file *protogen.File, isMixType bool, moduleName string) (logicContent []byte, routerFileContent []byte, errCodeFileContent []byte) {
+// GenerateFiles generate handler logic, router, error code files,
+// isGateway is valid only for mix type, the http errors are responded in grpc-gateway style,
+// routePrefix is the route group of all routes, e.g. /api.
+func GenerateFiles(file *protogen.File, isMixType bool, isGateway bool, moduleName string, routePrefix string) (logicContent []byte, routerFileContent []byte, errCodeFileContent []byte) {
 	if len(file.Services) == 0 {
 		return nil, nil, nil
 	}
 
-	pss := parse.GetServices(file, moduleName)
+	pss := parse.GetServices(file, moduleName, routePrefix)
 
 	if !isMixType {
 		logicContent = genHandlerLogicFile(pss)
@@ -23,7 +25,7 @@ func GenerateFiles(file *protogen.File, isMixType bool, moduleName string) (logi
 		errCodeFileContent = genErrCodeFile(pss)
 	} else {
 		logicContent = genMixLogicFile(pss)
-		routerFileContent = genMixRouterFile(pss)
+		routerFileContent = genMixRouterFile(pss, isGateway)
 	}
 
 	return logicContent, routerFileContent, errCodeFileContent
@@ -49,8 +51,8 @@ func genMixLogicFile(fields []*parse.PbService) []byte {
 	return mlf.execute()
 }
 
-func genMixRouterFile(fields []*parse.PbService) []byte {
-	mrf := &mixRouterFields{PbServices: fields}
+func genMixRouterFile(fields []*parse.PbService, isGateway bool) []byte {
+	mrf := &mixRouterFields{PbServices: fields, IsGateway: isGateway}
 	return mrf.execute()
 }
 
@@ -114,6 +116,7 @@ func (f *mixLogicFields) execute() []byte {
 
 type mixRouterFields struct {
 	PbServices []*parse.PbService
+	IsGateway  bool
 }
 
 func (f *mixRouterFields) execute() []byte {
@@ -1,9 +1,7 @@
 package handler
 
 import (
-	"math/rand"
 	"text/template"
-	"time"
 )
 
 func init() {
@@ -29,8 +27,6 @@ func init() {
 	if err != nil {
 		panic(err)
 	}
-
-	rand.Seed(time.Now().UnixNano()) //nolint
 }
 
 var (
@@ -228,7 +224,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"google.golang.org/grpc/metadata"
-
+{{if .IsGateway}}
+	"github.com/go-dev-frame/sponge/pkg/errcode"{{end}}
 	"github.com/go-dev-frame/sponge/pkg/gin/middleware"
 	"github.com/go-dev-frame/sponge/pkg/logger"
 
@@ -271,6 +268,12 @@ func {{.LowerName}}Router(
 		singlePathMiddlewares,
 		iService,
 		{{.ProtoPkgName}}.With{{.Name}}Logger(logger.Get()),
+{{- if $.IsGateway}}
+		// the grpc codes are converted to standard http codes in grpc-gateway style,
+		// the error codes defined in internal/ecode are shared by grpc and http
+		{{.ProtoPkgName}}.With{{.Name}}Responser(errcode.NewGatewayResponser()),
+		{{.ProtoPkgName}}.With{{.Name}}WrapCtx(ctxFn),
+{{- else}}
 		{{.ProtoPkgName}}.With{{.Name}}RPCResponse(),
 		{{.ProtoPkgName}}.With{{.Name}}WrapCtx(ctxFn),
 		{{.ProtoPkgName}}.With{{.Name}}ErrorToHTTPCode(
@@ -279,6 +282,7 @@ func {{.LowerName}}Router(
 			// example:
 			// 	ecode.Forbidden, ecode.LimitExceed,
 		),
+{{- end}}
 	)
 }
 
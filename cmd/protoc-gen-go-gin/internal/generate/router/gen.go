@@ -9,25 +9,35 @@ import (
 	"github.com/go-dev-frame/sponge/cmd/protoc-gen-go-gin/internal/parse"
 )
 
-// GenerateFiles generate gin router code.
-func GenerateFiles(file *protogen.File) []byte {
+// GenerateFiles generate gin router code, the routes are registered under the group of routePrefix
+// and the api version of proto file, e.g. routePrefix=/api and option (gin.api_version) = "v2" --> /api/v2.
+func GenerateFiles(file *protogen.File, routePrefix string) []byte {
 	if len(file.Services) == 0 {
 		return nil
 	}
 
-	pss := parse.ParseHTTPPbServices(file)
+	pss := parse.ParseHTTPPbServices(file, routePrefix)
 	return genGinRouterFile(pss, string(file.GoPackageName))
 }
 
 func genGinRouterFile(services parse.HTTPPbServices, goPackageName string) []byte {
 	pkg := &importPkg{
-		PackageName:  goPackageName,
-		PackagePaths: services.MergeImportPkgPath(),
+		PackageName:    goPackageName,
+		PackagePaths:   services.MergeImportPkgPath(),
+		HasQueryBinder: services.HasQueryBinder(),
 	}
 	content := pkg.execute()
 
+	queryBinders := make(map[string]struct{}) // the binder of the same request is generated once in a file
 	for _, service := range services {
-		rf := &ginRouterFields{service}
+		var binders []*parse.QueryBinder
+		for _, binder := range service.QueryBinders {
+			if _, ok := queryBinders[binder.FuncName]; !ok {
+				queryBinders[binder.FuncName] = struct{}{}
+				binders = append(binders, binder)
+			}
+		}
+		rf := &ginRouterFields{HTTPPbService: service, QueryBinders: binders}
 		content = append(content, rf.execute()...)
 	}
 	return content
@@ -35,6 +45,7 @@ func genGinRouterFile(services parse.HTTPPbServices, goPackageName string) []byt
 
 type ginRouterFields struct {
 	*parse.HTTPPbService
+	QueryBinders []*parse.QueryBinder // not generated by the previous services of file
 }
 
 func (f *ginRouterFields) execute() []byte {
@@ -46,8 +57,9 @@ func (f *ginRouterFields) execute() []byte {
 }
 
 type importPkg struct {
-	PackageName  string
-	PackagePaths string
+	PackageName    string
+	PackagePaths   string
+	HasQueryBinder bool
 }
 
 func (f *importPkg) execute() []byte {
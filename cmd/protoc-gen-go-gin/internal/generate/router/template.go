@@ -25,13 +25,22 @@ package {{$.PackageName}}
 import (
 	"context"
 	"errors"
+{{- if $.HasQueryBinder}}
+	"net/url"
+{{- end}}
 	"strings"
 
 	"github.com/gin-gonic/gin"
+{{- if $.HasQueryBinder}}
+	"github.com/gin-gonic/gin/binding"
+{{- end}}
 	"go.uber.org/zap"
 
 	"github.com/go-dev-frame/sponge/pkg/errcode"
 	"github.com/go-dev-frame/sponge/pkg/gin/middleware"
+{{- if $.HasQueryBinder}}
+	"github.com/go-dev-frame/sponge/pkg/gin/querybind"
+{{- end}}
 
 	{{$.PackagePaths}}
 )
@@ -44,6 +53,17 @@ type {{$.Name}}Logicer interface {
 	{{if eq .InvokeType 0}}{{if .Path}}{{.Name}}(ctx context.Context, req *{{.RequestImportPkgName}}{{.Request}}) (*{{.ReplyImportPkgName}}{{.Reply}}, error){{end}}{{end}}
 {{- end}}
 }
+{{- if $.RoutePrefix}}
+
+// {{$.Name}}RoutePrefix all routes of {{$.Name}} are registered under this route group,
+// it can be changed by With{{$.Name}}RoutePrefix.
+const {{$.Name}}RoutePrefix = "{{$.RoutePrefix}}"
+{{- end}}
+{{- if $.APIVersion}}
+
+// {{$.Name}}APIVersion the api version of {{$.Name}}.
+const {{$.Name}}APIVersion = "{{$.APIVersion}}"
+{{- end}}
 
 type {{$.Name}}Option func(*{{$.LowerName}}Options)
 
@@ -54,6 +74,9 @@ type {{$.LowerName}}Options struct {
 	httpErrors []*errcode.Error
 	rpcStatus  []*errcode.RPCStatus
 	wrapCtxFn  func(c *gin.Context) context.Context
+{{- if $.RoutePrefix}}
+	routePrefix string
+{{- end}}
 }
 
 func (o *{{$.LowerName}}Options) apply(opts ...{{$.Name}}Option) {
@@ -103,6 +126,15 @@ func With{{$.Name}}WrapCtx(wrapCtxFn func(c *gin.Context) context.Context) {{$.N
 		o.wrapCtxFn = wrapCtxFn
 	}
 }
+{{- if $.RoutePrefix}}
+
+// With{{$.Name}}RoutePrefix replace the route group {{$.Name}}RoutePrefix, "/" means no route group.
+func With{{$.Name}}RoutePrefix(routePrefix string) {{$.Name}}Option {
+	return func(o *{{$.LowerName}}Options) {
+		o.routePrefix = routePrefix
+	}
+}
+{{- end}}
 
 func Register{{$.Name}}Router(
 	iRouter gin.IRouter,
@@ -120,6 +152,16 @@ func Register{{$.Name}}Router(
 	if o.zapLog == nil {
 		o.zapLog,_ = zap.NewProduction()
 	}
+{{- if $.RoutePrefix}}
+	if o.routePrefix == "" {
+		o.routePrefix = {{$.Name}}RoutePrefix
+	}
+	routePrefix := strings.TrimRight(o.routePrefix, "/")
+	if routePrefix != "" && !strings.HasPrefix(routePrefix, "/") {
+		routePrefix = "/" + routePrefix
+	}
+	iRouter = iRouter.Group(routePrefix)
+{{- end}}
 
 	r := &{{$.LowerName}}Router {
 		iRouter:               iRouter,
@@ -129,9 +171,25 @@ func Register{{$.Name}}Router(
 		iResponse:             o.responser,
 		zapLog:                o.zapLog,
 		wrapCtxFn:             o.wrapCtxFn,
+{{- if $.RoutePrefix}}
+		routePrefix:           routePrefix,
+{{- end}}
 	}
 	r.register()
 }
+{{- if $.APIVersion}}
+
+// Register{{$.Name}}Router{{$.VersionName}} register the {{$.APIVersion}} routes of {{$.Name}} under the route group {{$.Name}}RoutePrefix,
+// it is the same as Register{{$.Name}}Router, the version suffix makes it explicit when multiple api versions are registered.
+func Register{{$.Name}}Router{{$.VersionName}}(
+	iRouter gin.IRouter,
+	groupPathMiddlewares map[string][]gin.HandlerFunc,
+	singlePathMiddlewares map[string][]gin.HandlerFunc,
+	iLogic {{$.Name}}Logicer,
+	opts ...{{$.Name}}Option) {
+	Register{{$.Name}}Router(iRouter, groupPathMiddlewares, singlePathMiddlewares, iLogic, opts...)
+}
+{{- end}}
 
 type {{$.LowerName}}Router struct {
 	iRouter               gin.IRouter
@@ -141,10 +199,13 @@ type {{$.LowerName}}Router struct {
 	iResponse             errcode.Responser
 	zapLog                *zap.Logger
 	wrapCtxFn             func(c *gin.Context) context.Context
+{{- if $.RoutePrefix}}
+	routePrefix           string // the full path of route is routePrefix + path
+{{- end}}
 }
 
 func (r *{{$.LowerName}}Router) register() {
-{{range .Methods}}	{{if eq .InvokeType 0}}{{if .Path}}r.iRouter.Handle("{{.Method}}", "{{.Path}}", r.withMiddleware("{{.Method}}", "{{.Path}}", r.{{ .HandlerName }})...){{end}}{{end}}
+{{range .Methods}}	{{if eq .InvokeType 0}}{{if .Path}}r.iRouter.Handle("{{.Method}}", "{{.Path}}", r.withMiddleware("{{.Method}}", {{if $.RoutePrefix}}r.routePrefix+{{end}}"{{.Path}}", r.{{ .HandlerName }})...){{end}}{{end}}
 {{end}}
 }
 
@@ -187,7 +248,16 @@ func (r *{{$.LowerName}}Router) withMiddleware(method string, path string, fn gi
 	}
 {{end}}
 
-{{if eq .Method "GET" "DELETE" }}
+{{if and (eq .Method "GET" "DELETE") .QueryBinder}}
+	if err = {{.QueryBinder.FuncName}}(c.Request.URL.Query(), req); err == nil {
+		err = binding.Validator.ValidateStruct(req)
+	}
+	if err != nil {
+		r.zapLog.Warn("bind query error", zap.Error(err), middleware.GCtxRequestIDField(c))
+		r.iResponse.ParamError(c, err)
+		return
+	}
+{{else if eq .Method "GET" "DELETE" }}
 	if err = c.ShouldBindQuery(req); err != nil {
 		r.zapLog.Warn("ShouldBindQuery error", zap.Error(err), middleware.GCtxRequestIDField(c))
 		r.iResponse.ParamError(c, err)
@@ -231,5 +301,25 @@ func (r *{{$.LowerName}}Router) withMiddleware(method string, path string, fn gi
 	r.iResponse.Success(c, out)
 }{{end}}{{end}}
 {{end}}
+{{- range .QueryBinders}}
+// {{.FuncName}} bind the query parameters to {{.Request}}, the names of parameter are the form tag, proto name
+// and json name of field, repeated fields are bound by multiple values, e.g. ?ids=1&ids=2, nested fields are
+// bound by dotted names, e.g. ?filter.name=foo, Timestamp is RFC3339, date or unix seconds.
+func {{.FuncName}}(values url.Values, req *{{.Request}}) error {
+{{- range .Fields}}
+	if vs := querybind.Values(values, {{.Names}}); len(vs) > 0 {
+		v, err := {{.Parse}}
+		if err != nil {
+			return querybind.Error("{{.Name}}", err)
+		}
+{{- range .Allocs}}
+		{{.}}
+{{- end}}
+		{{.Target}} = {{.Value}}
+	}
+{{- end}}
+	return nil
+}
+{{end}}
 `
 )
@@ -9,14 +9,14 @@ import (
 	"github.com/go-dev-frame/sponge/cmd/protoc-gen-go-gin/internal/parse"
 )
 
-// GenerateFiles generate service logic, router, error code files.
-func GenerateFiles(file *protogen.File, moduleName string) (logicContent []byte,
+// GenerateFiles generate service logic, router, error code files, routePrefix is the route group of all routes, e.g. /api.
+func GenerateFiles(file *protogen.File, moduleName string, routePrefix string) (logicContent []byte,
 	routerFileContent []byte, errCodeFileContent []byte) {
 	if len(file.Services) == 0 {
 		return nil, nil, nil
 	}
 
-	pss := parse.GetServices(file, moduleName)
+	pss := parse.GetServices(file, moduleName, routePrefix)
 	logicContent = genServiceLogicFile(pss)
 	routerFileContent = genRouterFile(pss)
 	errCodeFileContent = genErrCodeFile(pss)
@@ -98,6 +98,9 @@ func buildMethodDesc(m *protogen.Method, httpMethod, path string, customKind str
 	md.checkCustomKind()
 	md.checkSelector()
 	md.InitPathParams()
+	if httpMethod == http.MethodGet || httpMethod == http.MethodDelete {
+		md.QueryBinder = getQueryBinder(m.Input, requestImportPkgName, protoSelfPkgPath, importPkgPaths)
+	}
 	return md
 }
 
@@ -127,6 +130,10 @@ type RPCMethod struct {
 	RequestImportPkgName string // e.g. empty or userV1
 	ReplyImportPkgName   string // e.g. empty or userV1
 
+	// the request of GET or DELETE method is bound by the generated function instead of c.ShouldBindQuery,
+	// nil if the request has no repeated fields, nested messages and well-known types
+	QueryBinder *QueryBinder
+
 	ProtoSelfPkgPath string              // e.g. "module/api/user/v1"
 	ImportPkgPaths   map[string]struct{} // exclude ProtoSelfPkgPath
 }
@@ -0,0 +1,31 @@
+package parse
+
+import (
+	"path"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+
+	ginAnnotations "github.com/go-dev-frame/sponge/pkg/gin/annotations"
+)
+
+// GetAPIVersion get the api version from the file option, e.g. option (gin.api_version) = "v2";
+func GetAPIVersion(file *protogen.File) string {
+	opts := file.Desc.Options()
+	if opts == nil || !proto.HasExtension(opts, ginAnnotations.E_ApiVersion) {
+		return ""
+	}
+	version, _ := proto.GetExtension(opts, ginAnnotations.E_ApiVersion).(string)
+	return strings.Trim(strings.TrimSpace(version), "/")
+}
+
+// JoinRoutePrefix join the route prefix and api version to the route group path,
+// e.g. (/api, v2) --> /api/v2, ("", v2) --> /v2, (/api/, "") --> /api, ("", "") --> ""
+func JoinRoutePrefix(routePrefix string, apiVersion string) string {
+	p := path.Join("/", strings.TrimSpace(routePrefix), apiVersion)
+	if p == "/" {
+		return ""
+	}
+	return p
+}
@@ -3,8 +3,9 @@ package parse
 
 import (
 	"fmt"
-	"math/rand"
+	"hash/fnv"
 	"regexp"
+	"sort"
 	"strings"
 
 	"google.golang.org/genproto/googleapis/api/annotations"
@@ -83,12 +84,14 @@ type PbService struct {
 	ModuleName   string
 }
 
-// RandNumber rand number 1~100
+// RandNumber number 1~99 derived from the service name, the same service always gets the same number
 func (s *PbService) RandNumber() int {
-	return rand.Intn(99) + 1
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(s.Name))
+	return int(h.Sum32()%99) + 1
 }
 
-func parsePbService(s *protogen.Service, protoFileDir string, moduleName string) *PbService {
+func parsePbService(s *protogen.Service, protoFileDir string, moduleName string, routePrefix string) *PbService {
 	protoPkgName := convertToPkgName(protoFileDir)
 	cutServiceName := getCutServiceName(s.GoName)
 	importPkgMap := map[string]string{}
@@ -99,6 +102,9 @@ func parsePbService(s *protogen.Service, protoFileDir string, moduleName string)
 		rule, ok := proto.GetExtension(m.Desc.Options(), annotations.E_Http).(*annotations.HttpRule)
 		if rule != nil && ok {
 			rpcMethod = buildHTTPRule(m, rule, protoPkgName)
+			if rpcMethod.Path != "" {
+				rpcMethod.Path = routePrefix + rpcMethod.Path
+			}
 		} /*else {
 			// if the http method and path is not set, set default value.
 			//rpcMethod = defaultMethod(m)
@@ -154,12 +160,14 @@ func parsePbService(s *protogen.Service, protoFileDir string, moduleName string)
 	}
 }
 
-// GetServices parse protobuf services
-func GetServices(file *protogen.File, moduleName string) []*PbService {
+// GetServices parse protobuf services, the path of method is prefixed with the route group of
+// routePrefix and the api version of proto file, e.g. /api/v2/user/:id
+func GetServices(file *protogen.File, moduleName string, routePrefix string) []*PbService {
 	protoFileDir := getProtoFileDir(file.GeneratedFilenamePrefix)
+	routePrefix = JoinRoutePrefix(routePrefix, GetAPIVersion(file))
 	var pss []*PbService
 	for _, s := range file.Services {
-		pss = append(pss, parsePbService(s, protoFileDir, moduleName))
+		pss = append(pss, parsePbService(s, protoFileDir, moduleName, routePrefix))
 	}
 	return pss
 }
@@ -315,6 +323,7 @@ func GetImportPkg(services []*PbService) []byte {
 	for _, v := range pkgMap {
 		importPkg = append(importPkg, v)
 	}
+	sort.Strings(importPkg)
 	if len(importPkg) == 0 {
 		return []byte("")
 	}
@@ -350,15 +359,31 @@ type HTTPPbService struct {
 
 	Methods       []*RPCMethod // service methods
 	UniqueMethods []*RPCMethod
+	QueryBinders  []*QueryBinder // unique query binders of methods
 
 	ImportPkgMap map[string]string // [userV1]:[userV1 "user/api/user/v1"]
+
+	APIVersion  string // e.g. v2, from option (gin.api_version) = "v2";
+	RoutePrefix string // route group of all methods, e.g. /api/v2, it is empty if no prefix and api version
+}
+
+// VersionName the api version used in the function name, e.g. v2 --> V2, v1beta --> V1beta
+func (s *HTTPPbService) VersionName() string {
+	name := strings.NewReplacer(".", "_", "-", "_", "/", "_").Replace(s.APIVersion)
+	if name == "" {
+		return ""
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
 }
 
 type HTTPPbServices []*HTTPPbService
 
-// ParseHTTPPbServices parse protobuf services
-func ParseHTTPPbServices(file *protogen.File) []*HTTPPbService {
+// ParseHTTPPbServices parse protobuf services, the routes are registered under the group of
+// routePrefix and the api version of proto file, e.g. routePrefix=/api, api_version=v2 --> /api/v2
+func ParseHTTPPbServices(file *protogen.File, routePrefix string) []*HTTPPbService {
 	goImportPath := file.GoImportPath.String()
+	apiVersion := GetAPIVersion(file)
+	routePrefix = JoinRoutePrefix(routePrefix, apiVersion)
 
 	var pss []*HTTPPbService
 	for _, s := range file.Services {
@@ -380,13 +405,26 @@ func ParseHTTPPbServices(file *protogen.File) []*HTTPPbService {
 			LowerName:     strings.ToLower(s.GoName[:1]) + s.GoName[1:],
 			Methods:       methods,
 			UniqueMethods: removeDuplicates(methods),
+			QueryBinders:  getQueryBinders(methods),
 			ImportPkgMap:  importPkgMap,
+			APIVersion:    apiVersion,
+			RoutePrefix:   routePrefix,
 		})
 	}
 
 	return pss
 }
 
+// HasQueryBinder whether the query parameters of any method are bound by the generated function
+func (services HTTPPbServices) HasQueryBinder() bool {
+	for _, service := range services {
+		if len(service.QueryBinders) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 // MergeImportPkgPath merge import package path
 func (services HTTPPbServices) MergeImportPkgPath() string {
 	pkgMap := make(map[string]string)
@@ -401,6 +439,7 @@ func (services HTTPPbServices) MergeImportPkgPath() string {
 	for _, v := range pkgMap {
 		importPkg = append(importPkg, v)
 	}
+	sort.Strings(importPkg)
 
 	if len(importPkg) == 0 {
 		return ""
@@ -420,3 +459,18 @@ func removeDuplicates(methods []*RPCMethod) []*RPCMethod {
 	}
 	return uniqueMethods
 }
+
+func getQueryBinders(methods []*RPCMethod) []*QueryBinder {
+	var binders []*QueryBinder
+	funcNames := make(map[string]struct{})
+	for _, method := range methods {
+		if method.QueryBinder == nil {
+			continue
+		}
+		if _, ok := funcNames[method.QueryBinder.FuncName]; !ok {
+			funcNames[method.QueryBinder.FuncName] = struct{}{}
+			binders = append(binders, method.QueryBinder)
+		}
+	}
+	return binders
+}
@@ -0,0 +1,220 @@
+package parse
+
+import (
+	"regexp"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// the maximum depth of nested messages bound by query parameters, e.g. filter.user.name
+const maxQueryDepth = 3
+
+var formTagRegexp = regexp.MustCompile(`form:"([^",]*)`)
+
+// well-known types supported by pkg/gin/querybind
+var wellKnownParsers = map[protoreflect.FullName]string{
+	"google.protobuf.Timestamp":   "Timestamp",
+	"google.protobuf.Duration":    "Duration",
+	"google.protobuf.FieldMask":   "FieldMask",
+	"google.protobuf.DoubleValue": "DoubleValue",
+	"google.protobuf.FloatValue":  "FloatValue",
+	"google.protobuf.Int64Value":  "Int64Value",
+	"google.protobuf.UInt64Value": "UInt64Value",
+	"google.protobuf.Int32Value":  "Int32Value",
+	"google.protobuf.UInt32Value": "UInt32Value",
+	"google.protobuf.BoolValue":   "BoolValue",
+	"google.protobuf.StringValue": "StringValue",
+	"google.protobuf.BytesValue":  "BytesValue",
+}
+
+// QueryBinder is the generated function of binding query parameters to the request of GET or DELETE method,
+// it is generated if the request has repeated fields, nested messages or well-known types (e.g. Timestamp),
+// which are not bound by c.ShouldBindQuery.
+type QueryBinder struct {
+	FuncName string        // e.g. bindListUserRequestQuery
+	Request  string        // e.g. ListUserRequest or userV1.ListUserRequest
+	Fields   []*QueryField // fields bound by query parameters
+}
+
+// QueryField is a field of request bound by query parameter.
+type QueryField struct {
+	Name   string   // the name of query parameter in error message, e.g. filter.user_id
+	Names  string   // quoted names of query parameter, e.g. "filter.user_id", "filter.userId"
+	Parse  string   // e.g. querybind.Int64s(vs)
+	Allocs []string // allocate the nested messages, e.g. if req.Filter == nil { req.Filter = &Filter{} }
+	Target string   // e.g. req.Filter.UserId
+	Value  string   // v or querybind.Ptr(v)
+}
+
+type queryBinderBuilder struct {
+	protoSelfPkgPath string
+	importPkgPaths   map[string]struct{}
+	fields           []*QueryField
+	needBinder       bool
+}
+
+// getQueryBinder returns nil if the request can be bound by c.ShouldBindQuery.
+func getQueryBinder(m *protogen.Message, requestImportPkgName string, protoSelfPkgPath string, importPkgPaths map[string]struct{}) *QueryBinder {
+	b := &queryBinderBuilder{protoSelfPkgPath: protoSelfPkgPath, importPkgPaths: map[string]struct{}{}}
+	b.walk(m, nil, nil, "req", map[protoreflect.FullName]bool{m.Desc.FullName(): true})
+	if !b.needBinder {
+		return nil
+	}
+
+	for pkgPath := range b.importPkgPaths {
+		importPkgPaths[pkgPath] = struct{}{}
+	}
+	pkgName := strings.TrimSuffix(requestImportPkgName, ".")
+	if pkgName != "" {
+		pkgName = strings.ToUpper(pkgName[:1]) + pkgName[1:]
+	}
+	return &QueryBinder{
+		FuncName: "bind" + pkgName + m.GoIdent.GoName + "Query",
+		Request:  requestImportPkgName + m.GoIdent.GoName,
+		Fields:   b.fields,
+	}
+}
+
+// walk the fields of message, prefixes are the names of parent fields, allocs are the code of allocating parent messages.
+func (b *queryBinderBuilder) walk(m *protogen.Message, prefixes [][]string, allocs []string, target string, visited map[protoreflect.FullName]bool) {
+	for _, field := range m.Fields {
+		if field.Desc.IsMap() || (field.Oneof != nil && !field.Oneof.Desc.IsSynthetic()) {
+			continue // not supported
+		}
+
+		names := append(prefixes[:len(prefixes):len(prefixes)], queryFieldNames(field))
+		fieldTarget := target + "." + field.GoName
+
+		if field.Desc.Kind() == protoreflect.MessageKind || field.Desc.Kind() == protoreflect.GroupKind {
+			if field.Desc.IsList() {
+				continue // repeated messages are not supported
+			}
+			if parser, ok := wellKnownParsers[field.Message.Desc.FullName()]; ok {
+				b.needBinder = true
+				b.addField(names, "querybind."+parser+"(vs)", allocs, fieldTarget, "v")
+				continue
+			}
+			if strings.HasPrefix(string(field.Message.Desc.FullName()), "google.protobuf.") ||
+				visited[field.Message.Desc.FullName()] || len(prefixes)+1 >= maxQueryDepth {
+				continue
+			}
+			b.needBinder = true
+			visited[field.Message.Desc.FullName()] = true
+			alloc := "if " + fieldTarget + " == nil {\n\t\t\t" + fieldTarget + " = &" + b.goIdent(field.Message.GoIdent) + "{}\n\t\t}"
+			b.walk(field.Message, names, append(allocs[:len(allocs):len(allocs)], alloc), fieldTarget, visited)
+			delete(visited, field.Message.Desc.FullName())
+			continue
+		}
+
+		parse := scalarParser(field.Desc.Kind(), field.Desc.IsList())
+		if field.Desc.Kind() == protoreflect.EnumKind {
+			enum := b.goIdent(field.Enum.GoIdent)
+			parse = "querybind.Enum[" + enum + "](vs, " + enum + "_value)"
+			if field.Desc.IsList() {
+				parse = "querybind.Enums[" + enum + "](vs, " + enum + "_value)"
+			}
+		}
+		if parse == "" {
+			continue
+		}
+		if field.Desc.IsList() {
+			b.needBinder = true
+		}
+		value := "v"
+		if field.Desc.HasPresence() {
+			value = "querybind.Ptr(v)" // proto3 optional
+		}
+		b.addField(names, parse, allocs, fieldTarget, value)
+	}
+}
+
+func (b *queryBinderBuilder) addField(names [][]string, parse string, allocs []string, target string, value string) {
+	paths := joinQueryNames(names)
+	quoted := make([]string, 0, len(paths))
+	for _, p := range paths {
+		quoted = append(quoted, `"`+p+`"`)
+	}
+	b.fields = append(b.fields, &QueryField{
+		Name:   paths[0],
+		Names:  strings.Join(quoted, ", "),
+		Parse:  parse,
+		Allocs: allocs,
+		Target: target,
+		Value:  value,
+	})
+}
+
+// the type name of message or enum, the package of other proto files is imported
+func (b *queryBinderBuilder) goIdent(ident protogen.GoIdent) string {
+	if ident.GoImportPath.String() == b.protoSelfPkgPath {
+		return ident.GoName
+	}
+	b.importPkgPaths[ident.GoImportPath.String()] = struct{}{}
+	return convertToPkgName(ident.GoImportPath.String()) + "." + ident.GoName
+}
+
+// the names of field are the form tag (set by @gotags comment, the proto name if not set),
+// the proto name (the json tag of generated struct) and the json name
+func queryFieldNames(field *protogen.Field) []string {
+	formName := string(field.Desc.Name())
+	comments := string(field.Comments.Leading) + string(field.Comments.Trailing)
+	if idx := strings.Index(comments, "@gotags:"); idx >= 0 {
+		if match := formTagRegexp.FindStringSubmatch(comments[idx:]); len(match) == 2 && match[1] != "" && match[1] != "-" {
+			formName = match[1]
+		}
+	}
+	return []string{formName, string(field.Desc.Name()), field.Desc.JSONName()}
+}
+
+// join the same kind of names of each level by dot, e.g. filter.user_id, filter.userId
+func joinQueryNames(names [][]string) []string {
+	var paths []string
+	exists := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		parts := make([]string, 0, len(names))
+		for _, ns := range names {
+			parts = append(parts, ns[i])
+		}
+		path := strings.Join(parts, ".")
+		if !exists[path] {
+			exists[path] = true
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+func scalarParser(kind protoreflect.Kind, isList bool) string {
+	var name string
+	switch kind {
+	case protoreflect.StringKind:
+		name = "String"
+	case protoreflect.BoolKind:
+		name = "Bool"
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		name = "Int32"
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		name = "Int64"
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		name = "Uint32"
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		name = "Uint64"
+	case protoreflect.FloatKind:
+		name = "Float32"
+	case protoreflect.DoubleKind:
+		name = "Float64"
+	case protoreflect.BytesKind:
+		if isList {
+			return "querybind.BytesList(vs)"
+		}
+		return "querybind.Bytes(vs)"
+	default:
+		return ""
+	}
+	if isList {
+		name += "s"
+	}
+	return "querybind." + name + "(vs)"
+}
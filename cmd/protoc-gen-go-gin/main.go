@@ -1,4 +1,4 @@
-// Package main generate *.go(tmpl), *_router.go, *_http.go, *_router.pb.go code based on proto files.
+// Package main generate *.go(tmpl), *_router.go, *_http.go, *_router.pb.go, *_client.pb.go code based on proto files.
 package main
 
 import (
@@ -13,6 +13,7 @@ import (
 	"google.golang.org/protobuf/compiler/protogen"
 	"google.golang.org/protobuf/types/pluginpb"
 
+	"github.com/go-dev-frame/sponge/cmd/protoc-gen-go-gin/internal/generate/client"
 	"github.com/go-dev-frame/sponge/cmd/protoc-gen-go-gin/internal/generate/handler"
 	"github.com/go-dev-frame/sponge/cmd/protoc-gen-go-gin/internal/generate/router"
 	"github.com/go-dev-frame/sponge/cmd/protoc-gen-go-gin/internal/generate/service"
@@ -41,6 +42,12 @@ protoc --proto_path=. --proto_path=./third_party --go-gin_out=. --go-gin_opt=pat
   --go-gin_opt=moduleName=yourModuleName --go-gin_opt=serverName=yourServerName *.proto
 
 # if you want the generated code to suited to mono-repo, you need to set the parameter --go-gin_opt=suitedMonoRepo=true
+# if you want the http errors of mix plugin to be responded in grpc-gateway style, you need to set the parameter --go-gin_opt=gateway=true
+# if you want to check whether the generated code is up to date without writing files, you need to set the parameter --go-gin_opt=checkOnly=true,
+# it exits with non-zero status if the *_router.pb.go files differ from the generated code or the template code files do not exist.
+# if you want to generate the typed http client code *_client.pb.go for other go services, you need to set the parameter --go-gin_opt=client=true
+# if you want to register all routes under a route group, you need to set the parameter --go-gin_opt=routePrefix=/api, the api version
+# of proto file option (gin.api_version) = "v2" is appended to the route group, e.g. /api/v2, import "gin/annotations.proto" to use it.
 
 Tip:
     If you want to merge the code, after generating the code, execute the command "sponge merge http-pb" or
@@ -70,8 +77,8 @@ func main() {
 
 	var flags flag.FlagSet
 
-	var plugin, moduleName, serverName, logicOut, routerOut, ecodeOut string
-	var suitedMonoRepo bool
+	var plugin, moduleName, serverName, logicOut, routerOut, ecodeOut, routePrefix string
+	var suitedMonoRepo, isGateway, isClient bool
 	flags.StringVar(&plugin, "plugin", "", "plugin name, supported values: handler, service and mix")
 	flags.StringVar(&moduleName, "moduleName", "", "module name for plugin")
 	flags.StringVar(&serverName, "serverName", "", "server name for plugin")
@@ -80,6 +87,10 @@ func main() {
 	flags.StringVar(&routerOut, "routerOut", "", "directory of routing code generated by the plugin, default is internal/routers")
 	flags.StringVar(&ecodeOut, "ecodeOut", "", "directory of error code generated by the plugin, default is internal/ecode")
 	flags.BoolVar(&suitedMonoRepo, "suitedMonoRepo", false, "whether the generated code is suitable for mono-repo")
+	flags.BoolVar(&isGateway, "gateway", false, "whether the grpc codes are converted to standard http codes in grpc-gateway style, valid only for mix plugin")
+	flags.BoolVar(&isClient, "client", false, "whether to generate the typed http client code *_client.pb.go, it is saved in the same directory as *_router.pb.go")
+	flags.StringVar(&routePrefix, "routePrefix", "", "route group of all routes, e.g. /api, it is combined with the proto file option (gin.api_version), e.g. /api/v2")
+	flags.BoolVar(&checkOnly, "checkOnly", false, "check whether the generated code is up to date without writing files, exit with non-zero status if it is not")
 
 	options := protogen.Options{
 		ParamFunc: flags.Set,
@@ -138,28 +149,38 @@ func main() {
 				return fmt.Errorf(`the proto file name (%s) suffix "_test" is not supported for code generation, please delete suffix "_test" or change it to another name. `, checkFilename)
 			}
 
-			if err := saveGinRouterFiles(f); err != nil {
+			if err := saveGinRouterFiles(f, routePrefix); err != nil {
 				return err
 			}
+			if isClient {
+				if err := saveClientFiles(f, routePrefix); err != nil {
+					return err
+				}
+			}
 
 			if handlerFlag {
-				err := saveHandlerAndRouterFiles(f, moduleName, serverName, logicOut, routerOut, ecodeOut, suitedMonoRepo, mixFlag)
+				err := saveHandlerAndRouterFiles(f, moduleName, serverName, logicOut, routerOut, ecodeOut, routePrefix, suitedMonoRepo, mixFlag, isGateway)
 				if err != nil {
 					return err
 				}
 			} else if serviceFlag {
-				err := saveServiceAndRouterFiles(f, moduleName, serverName, logicOut, routerOut, ecodeOut, suitedMonoRepo)
+				err := saveServiceAndRouterFiles(f, moduleName, serverName, logicOut, routerOut, ecodeOut, routePrefix, suitedMonoRepo)
 				if err != nil {
 					return err
 				}
 			}
 		}
+
+		if checkOnly && len(outdatedFiles) > 0 {
+			return fmt.Errorf("protoc-gen-go-gin: the generated code is not up to date, please regenerate the code:\n    %s",
+				strings.Join(outdatedFiles, "\n    "))
+		}
 		return nil
 	})
 }
 
-func saveGinRouterFiles(f *protogen.File) error {
-	ginRouterFileContent := router.GenerateFiles(f)
+func saveGinRouterFiles(f *protogen.File, routePrefix string) error {
+	ginRouterFileContent := router.GenerateFiles(f, routePrefix)
 	if len(ginRouterFileContent) == 0 {
 		return nil
 	}
@@ -170,13 +191,25 @@ func saveGinRouterFiles(f *protogen.File) error {
 		ginRouterFileContent = bytes.Replace(ginRouterFileContent, []byte(`"github.com/go-dev-frame/sponge/pkg/gin/middleware"`), []byte(""), 1)
 	}
 	filePath := f.GeneratedFilenamePrefix + "_router.pb.go"
-	return os.WriteFile(filePath, ginRouterFileContent, 0666)
+	return writeFile(filePath, ginRouterFileContent, true)
+}
+
+func saveClientFiles(f *protogen.File, routePrefix string) error {
+	clientFileContent, err := client.GenerateFiles(f, routePrefix)
+	if err != nil {
+		return err
+	}
+	if len(clientFileContent) == 0 {
+		return nil
+	}
+	filePath := f.GeneratedFilenamePrefix + "_client.pb.go"
+	return writeFile(filePath, clientFileContent, true)
 }
 
 func saveHandlerAndRouterFiles(f *protogen.File, moduleName string, serverName string,
-	logicOut string, routerOut string, ecodeOut string, suitedMonoRepo bool, isMixType bool) error {
+	logicOut string, routerOut string, ecodeOut string, routePrefix string, suitedMonoRepo bool, isMixType bool, isGateway bool) error {
 	filenamePrefix := f.GeneratedFilenamePrefix
-	handlerLogicContent, routerContent, errCodeFileContent := handler.GenerateFiles(f, isMixType, moduleName)
+	handlerLogicContent, routerContent, errCodeFileContent := handler.GenerateFiles(f, isMixType, isGateway, moduleName, routePrefix)
 
 	filePath := filenamePrefix + ".go"
 	err := saveFile(moduleName, serverName, logicOut, filePath, handlerLogicContent, false, handlerPlugin, suitedMonoRepo)
@@ -202,9 +235,9 @@ func saveHandlerAndRouterFiles(f *protogen.File, moduleName string, serverName s
 }
 
 func saveServiceAndRouterFiles(f *protogen.File, moduleName string, serverName string,
-	logicOut string, routerOut string, ecodeOut string, suitedMonoRepo bool) error {
+	logicOut string, routerOut string, ecodeOut string, routePrefix string, suitedMonoRepo bool) error {
 	filenamePrefix := f.GeneratedFilenamePrefix
-	serviceLogicContent, routerContent, errCodeFileContent := service.GenerateFiles(f, moduleName)
+	serviceLogicContent, routerContent, errCodeFileContent := service.GenerateFiles(f, moduleName, routePrefix)
 
 	filePath := filenamePrefix + ".go"
 	err := saveFile(moduleName, serverName, logicOut, filePath, serviceLogicContent, false, servicePlugin, suitedMonoRepo)
@@ -239,13 +272,8 @@ func saveFile(moduleName string, serverName string, out string, filePath string,
 		panic(fmt.Sprintf(optErrFormat, "serverName", pluginName))
 	}
 
-	_ = os.MkdirAll(out, 0766)
 	_, name := filepath.Split(filePath)
 	file := out + "/" + name
-	if !isNeedCovered && isExists(file) {
-		removeOldGenFile(file)
-		file += ".gen" + time.Now().Format("20060102T150405")
-	}
 
 	content = bytes.ReplaceAll(content, []byte("moduleNameExample"), []byte(moduleName))
 	content = bytes.ReplaceAll(content, []byte("serverNameExample"), []byte(serverName))
@@ -254,7 +282,7 @@ func saveFile(moduleName string, serverName string, out string, filePath string,
 		content = adaptMonoRepo(moduleName, serverName, content)
 	}
 
-	return os.WriteFile(file, content, 0666)
+	return writeFile(file, content, isNeedCovered)
 }
 
 func saveFileSimple(out string, filePath string, content []byte, isNeedCovered bool) error {
@@ -262,14 +290,37 @@ func saveFileSimple(out string, filePath string, content []byte, isNeedCovered b
 		return nil
 	}
 
-	_ = os.MkdirAll(out, 0766)
 	_, name := filepath.Split(filePath)
 	file := out + "/" + name
+
+	return writeFile(file, content, isNeedCovered)
+}
+
+var (
+	checkOnly     bool
+	outdatedFiles []string // the files that differ from the generated code in check mode
+)
+
+// writeFile write the generated code to file, if the file exists and does not need to be covered, the code is
+// written to a new file with suffix .gen+time for merging. in check mode, no file is written, the file is recorded
+// as outdated if it differs from the generated code, the template code files that exist are not checked because
+// they have been modified by the user.
+func writeFile(file string, content []byte, isNeedCovered bool) error {
+	if checkOnly {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			outdatedFiles = append(outdatedFiles, file+" (not exist)")
+		} else if isNeedCovered && !bytes.Equal(data, content) {
+			outdatedFiles = append(outdatedFiles, file)
+		}
+		return nil
+	}
+
+	_ = os.MkdirAll(filepath.Dir(file), 0766)
 	if !isNeedCovered && isExists(file) {
 		removeOldGenFile(file)
 		file += ".gen" + time.Now().Format("20060102T150405")
 	}
-
 	return os.WriteFile(file, content, 0666)
 }
 
@@ -297,13 +348,14 @@ func firstLetterToUpper(s string) []byte {
 }
 
 func adaptMonoRepo(moduleName string, serverName string, data []byte) []byte {
-	matchStr := map[string]string{
-		fmt.Sprintf("\"%s/internal/", moduleName): fmt.Sprintf("\"%s/internal/", moduleName+"/"+serverName),
-		fmt.Sprintf("\"%s/configs", moduleName):   fmt.Sprintf("\"%s/configs", moduleName+"/"+serverName),
-		fmt.Sprintf("\"%s/api", moduleName):       fmt.Sprintf("\"%s/api", moduleName+"/"+serverName),
+	// replace in fixed order to keep the output deterministic
+	matchStr := [][2]string{
+		{fmt.Sprintf("\"%s/internal/", moduleName), fmt.Sprintf("\"%s/internal/", moduleName+"/"+serverName)},
+		{fmt.Sprintf("\"%s/configs", moduleName), fmt.Sprintf("\"%s/configs", moduleName+"/"+serverName)},
+		{fmt.Sprintf("\"%s/api", moduleName), fmt.Sprintf("\"%s/api", moduleName+"/"+serverName)},
 	}
-	for oldStr, newStr := range matchStr {
-		data = bytes.ReplaceAll(data, []byte(oldStr), []byte(newStr))
+	for _, ms := range matchStr {
+		data = bytes.ReplaceAll(data, []byte(ms[0]), []byte(ms[1]))
 	}
 	return data
 }
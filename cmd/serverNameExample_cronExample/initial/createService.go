@@ -0,0 +1,21 @@
+package initial
+
+import (
+	"github.com/go-dev-frame/sponge/pkg/app"
+
+	"github.com/go-dev-frame/sponge/internal/config"
+	"github.com/go-dev-frame/sponge/internal/server"
+)
+
+// CreateServices create services
+func CreateServices() []app.IServer {
+	var cfg = config.Get()
+	var servers []app.IServer
+
+	// the jobs are registered in internal/job, the settings of jobs can be overridden in the configuration
+	cronServer := server.NewCronServer(cfg.Cron)
+
+	servers = append(servers, cronServer)
+
+	return servers
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/go-dev-frame/sponge/pkg/app"
 
 	"github.com/go-dev-frame/sponge/internal/config"
+	"github.com/go-dev-frame/sponge/internal/database"
 	"github.com/go-dev-frame/sponge/internal/server"
 )
 
@@ -15,14 +16,21 @@ func CreateServices() []app.IServer {
 	var servers []app.IServer
 	var grpcAddr = ":" + strconv.Itoa(cfg.Grpc.Port)
 
+	// dependency probes of grpc health service, the health status is NOT_SERVING if a dependency is unreachable
+	grpcOptions := []server.GrpcOption{
+		server.WithGrpcHealthProbe("database", database.PingDB),
+	}
+	if cfg.App.CacheType == "redis" {
+		grpcOptions = append(grpcOptions, server.WithGrpcHealthProbe("redis", database.PingRedis))
+	}
+
 	// case 1, create a grpc service without registry
-	grpcServer := server.NewGRPCServer(grpcAddr)
+	grpcServer := server.NewGRPCServer(grpcAddr, grpcOptions...)
 
 	// case 2, create a grpc service and register it with consul or etcd or nacos
 	//grpcRegistry, grpcInstance := registerService("grpc", cfg.App.Host, cfg.Grpc.Port)
-	//grpcServer := server.NewGRPCServer(grpcAddr,
-	//	server.WithGrpcRegistry(grpcRegistry, grpcInstance),
-	//)
+	//grpcOptions = append(grpcOptions, server.WithGrpcRegistry(grpcRegistry, grpcInstance))
+	//grpcServer := server.NewGRPCServer(grpcAddr, grpcOptions...)
 
 	servers = append(servers, grpcServer)
 
@@ -19,6 +19,7 @@ func CreateServices() []app.IServer {
 	httpServer := server.NewHTTPServer_pbExample(httpAddr,
 		server.WithHTTPIsProd(cfg.App.Env == "prod"),
 		server.WithHTTPTLS(cfg.HTTP.TLS),
+		server.WithHTTPConnLimit(cfg.HTTP.MaxConns, cfg.HTTP.MaxRequests),
 	)
 
 	// case 2, Create a http service and register it with consul or etcd or nacos
@@ -27,6 +28,7 @@ func CreateServices() []app.IServer {
 	//	server.WithHTTPRegistry(httpRegistry, httpInstance),
 	//	server.WithHTTPIsProd(cfg.App.Env == "prod"),
 	//	server.WithHTTPTLS(cfg.HTTP.TLS),
+	//	server.WithHTTPConnLimit(cfg.HTTP.MaxConns, cfg.HTTP.MaxRequests),
 	//)
 
 	servers = append(servers, httpServer)
@@ -6,6 +6,7 @@ package initial
 import (
 	"flag"
 	"strconv"
+	"time"
 
 	"github.com/go-dev-frame/sponge/pkg/logger"
 	"github.com/go-dev-frame/sponge/pkg/stat"
@@ -38,6 +39,9 @@ func InitApp() {
 			//logger.WithFileMaxAge(cfg.Logger.LogFileConfig.MaxAge),
 			//logger.WithFileIsCompression(cfg.Logger.LogFileConfig.IsCompression),
 		),
+		logger.WithSampling(time.Second, cfg.Logger.Sampling.First, cfg.Logger.Sampling.Thereafter),
+		logger.WithErrorRateLimit(cfg.Logger.ErrorRateLimit),
+		logger.WithRedactFields(cfg.Logger.RedactFields...),
 	)
 	if err != nil {
 		panic(err)
@@ -54,6 +58,19 @@ func InitApp() {
 			cfg.Jaeger.AgentHost,
 			strconv.Itoa(cfg.Jaeger.AgentPort),
 			cfg.App.TracingSamplingRate,
+			tracer.WithExporter(cfg.Tracer.Exporter),
+			tracer.WithOTLP(
+				cfg.Tracer.Otlp.Protocol,
+				cfg.Tracer.Otlp.Endpoint,
+				tracer.WithOTLPInsecure(cfg.Tracer.Otlp.Insecure),
+				tracer.WithOTLPCAFile(cfg.Tracer.Otlp.CaFile),
+				tracer.WithOTLPHeaders(cfg.Tracer.Otlp.Headers),
+			),
+			tracer.WithSampling(
+				tracer.WithKeepErrors(cfg.Tracer.Sampling.KeepErrors),
+				tracer.WithKeepSlow(time.Duration(cfg.Tracer.Sampling.SlowThreshold)*time.Millisecond),
+				tracer.WithRouteRateLimits(cfg.Tracer.Sampling.RouteRateLimits),
+			),
 		)
 		logger.Info("[tracer] was initialized")
 	}
@@ -6,6 +6,9 @@ import (
 	"github.com/go-dev-frame/sponge/pkg/app"
 
 	"github.com/go-dev-frame/sponge/internal/config"
+	// sponge:if db
+	"github.com/go-dev-frame/sponge/internal/database"
+	// sponge:end
 	"github.com/go-dev-frame/sponge/internal/server"
 )
 
@@ -16,24 +19,34 @@ func CreateServices() []app.IServer {
 	var httpAddr = ":" + strconv.Itoa(cfg.HTTP.Port)
 	var grpcAddr = ":" + strconv.Itoa(cfg.Grpc.Port)
 
-	// case 1, create http and grpc services without registry
-	httpServer := server.NewHTTPServer(httpAddr,
+	grpcOptions := []server.GrpcOption{}
+	httpOptions := []server.HTTPOption{
 		server.WithHTTPIsProd(cfg.App.Env == "prod"),
 		server.WithHTTPTLS(cfg.HTTP.TLS),
-	)
-	grpcServer := server.NewGRPCServer(grpcAddr)
+		server.WithHTTPConnLimit(cfg.HTTP.MaxConns, cfg.HTTP.MaxRequests),
+	}
+	// sponge:if db
+	// dependency probes of grpc health service and http /health, the health status is NOT_SERVING (grpc)
+	// or DOWN (http) if a dependency is unreachable
+	grpcOptions = append(grpcOptions, server.WithGrpcHealthProbe("database", database.PingDB))
+	httpOptions = append(httpOptions, server.WithHTTPHealthProbe("database", database.PingDB))
+	if cfg.App.CacheType == "redis" {
+		grpcOptions = append(grpcOptions, server.WithGrpcHealthProbe("redis", database.PingRedis))
+		httpOptions = append(httpOptions, server.WithHTTPHealthProbe("redis", database.PingRedis))
+	}
+	// sponge:end
+
+	// case 1, create http and grpc services without registry
+	httpServer := server.NewHTTPServer(httpAddr, httpOptions...)
+	grpcServer := server.NewGRPCServer(grpcAddr, grpcOptions...)
 
 	// case 2, create http and grpc services and register them with consul or etcd or nacos
 	//httpRegistry, httpInstance := registerService("http", cfg.App.Host, cfg.HTTP.Port)
-	//httpServer := server.NewHTTPServer(httpAddr,
-	//	server.WithHTTPRegistry(httpRegistry, httpInstance),
-	//	server.WithHTTPIsProd(cfg.App.Env == "prod"),
-	//	server.WithHTTPTLS(cfg.HTTP.TLS),
-	//)
+	//httpOptions = append(httpOptions, server.WithHTTPRegistry(httpRegistry, httpInstance))
+	//httpServer := server.NewHTTPServer(httpAddr, httpOptions...)
 	//grpcRegistry, grpcInstance := registerService("grpc", cfg.App.Host, cfg.Grpc.Port)
-	//grpcServer := server.NewGRPCServer(grpcAddr,
-	//	server.WithGrpcRegistry(grpcRegistry, grpcInstance),
-	//)
+	//grpcOptions = append(grpcOptions, server.WithGrpcRegistry(grpcRegistry, grpcInstance))
+	//grpcServer := server.NewGRPCServer(grpcAddr, grpcOptions...)
 
 	servers = append(servers, httpServer, grpcServer)
 
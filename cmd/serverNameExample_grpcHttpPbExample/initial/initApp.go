@@ -6,6 +6,7 @@ package initial
 import (
 	"flag"
 	"strconv"
+	"time"
 
 	"github.com/go-dev-frame/sponge/pkg/logger"
 	"github.com/go-dev-frame/sponge/pkg/stat"
@@ -13,7 +14,9 @@ import (
 
 	"github.com/go-dev-frame/sponge/configs"
 	"github.com/go-dev-frame/sponge/internal/config"
-	//"github.com/go-dev-frame/sponge/internal/database"
+	// sponge:if db
+	"github.com/go-dev-frame/sponge/internal/database"
+	// sponge:end
 )
 
 var (
@@ -38,6 +41,9 @@ func InitApp() {
 			//logger.WithFileMaxAge(cfg.Logger.LogFileConfig.MaxAge),
 			//logger.WithFileIsCompression(cfg.Logger.LogFileConfig.IsCompression),
 		),
+		logger.WithSampling(time.Second, cfg.Logger.Sampling.First, cfg.Logger.Sampling.Thereafter),
+		logger.WithErrorRateLimit(cfg.Logger.ErrorRateLimit),
+		logger.WithRedactFields(cfg.Logger.RedactFields...),
 	)
 	if err != nil {
 		panic(err)
@@ -54,6 +60,19 @@ func InitApp() {
 			cfg.Jaeger.AgentHost,
 			strconv.Itoa(cfg.Jaeger.AgentPort),
 			cfg.App.TracingSamplingRate,
+			tracer.WithExporter(cfg.Tracer.Exporter),
+			tracer.WithOTLP(
+				cfg.Tracer.Otlp.Protocol,
+				cfg.Tracer.Otlp.Endpoint,
+				tracer.WithOTLPInsecure(cfg.Tracer.Otlp.Insecure),
+				tracer.WithOTLPCAFile(cfg.Tracer.Otlp.CaFile),
+				tracer.WithOTLPHeaders(cfg.Tracer.Otlp.Headers),
+			),
+			tracer.WithSampling(
+				tracer.WithKeepErrors(cfg.Tracer.Sampling.KeepErrors),
+				tracer.WithKeepSlow(time.Duration(cfg.Tracer.Sampling.SlowThreshold)*time.Millisecond),
+				tracer.WithRouteRateLimits(cfg.Tracer.Sampling.RouteRateLimits),
+			),
 		)
 		logger.Info("[tracer] was initialized")
 	}
@@ -67,14 +86,16 @@ func InitApp() {
 		)
 		logger.Info("[resource statistics] was initialized")
 	}
+	// sponge:if db
 
 	// initializing database
-	//database.InitDB()
-	//logger.Infof("[%s] was initialized", cfg.Database.Driver)
-	//database.InitCache(cfg.App.CacheType)
-	//if cfg.App.CacheType != "" {
-	//	logger.Infof("[%s] was initialized", cfg.App.CacheType)
-	//}
+	database.InitDB()
+	logger.Infof("[%s] was initialized", cfg.Database.Driver)
+	database.InitCache(cfg.App.CacheType)
+	if cfg.App.CacheType != "" {
+		logger.Infof("[%s] was initialized", cfg.App.CacheType)
+	}
+	// sponge:end
 }
 
 func initConfig() {
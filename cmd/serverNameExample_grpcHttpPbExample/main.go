@@ -2,9 +2,12 @@
 package main
 
 import (
+	"strconv"
+
 	"github.com/go-dev-frame/sponge/pkg/app"
 
 	"github.com/go-dev-frame/sponge/cmd/serverNameExample_grpcHttpPbExample/initial"
+	"github.com/go-dev-frame/sponge/internal/config"
 )
 
 func main() {
@@ -12,6 +15,10 @@ func main() {
 	services := initial.CreateServices()
 	closes := initial.Close(services)
 
-	a := app.New(services, closes)
+	var opts []app.Option
+	if port := config.Get().App.HealthPort; port > 0 {
+		opts = append(opts, app.WithHealthServer(":"+strconv.Itoa(port))) // kubernetes probes
+	}
+	a := app.New(services, closes, opts...)
 	a.Run()
 }
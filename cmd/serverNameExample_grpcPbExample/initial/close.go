@@ -9,7 +9,9 @@ import (
 	"github.com/go-dev-frame/sponge/pkg/tracer"
 
 	"github.com/go-dev-frame/sponge/internal/config"
-	//"github.com/go-dev-frame/sponge/internal/database"
+	// sponge:if db
+	"github.com/go-dev-frame/sponge/internal/database"
+	// sponge:end
 )
 
 // Close releasing resources after service exit
@@ -20,18 +22,20 @@ func Close(servers []app.IServer) []app.Close {
 	for _, s := range servers {
 		closes = append(closes, s.Stop)
 	}
+	// sponge:if db
 
 	// close database
-	//closes = append(closes, func() error {
-	//	return database.CloseDB()
-	//})
+	closes = append(closes, func() error {
+		return database.CloseDB()
+	})
 
 	// close redis
-	//if config.Get().App.CacheType == "redis" {
-	//	closes = append(closes, func() error {
-	//		return database.CloseRedis()
-	//	})
-	//}
+	if config.Get().App.CacheType == "redis" {
+		closes = append(closes, func() error {
+			return database.CloseRedis()
+		})
+	}
+	// sponge:end
 
 	// close tracing
 	if config.Get().App.EnableTrace {
@@ -6,6 +6,9 @@ import (
 	"github.com/go-dev-frame/sponge/pkg/app"
 
 	"github.com/go-dev-frame/sponge/internal/config"
+	// sponge:if db
+	"github.com/go-dev-frame/sponge/internal/database"
+	// sponge:end
 	"github.com/go-dev-frame/sponge/internal/server"
 )
 
@@ -15,14 +18,22 @@ func CreateServices() []app.IServer {
 	var servers []app.IServer
 	var grpcAddr = ":" + strconv.Itoa(cfg.Grpc.Port)
 
+	grpcOptions := []server.GrpcOption{}
+	// sponge:if db
+	// dependency probes of grpc health service, the health status is NOT_SERVING if a dependency is unreachable
+	grpcOptions = append(grpcOptions, server.WithGrpcHealthProbe("database", database.PingDB))
+	if cfg.App.CacheType == "redis" {
+		grpcOptions = append(grpcOptions, server.WithGrpcHealthProbe("redis", database.PingRedis))
+	}
+	// sponge:end
+
 	// case 1, create a grpc service without registry
-	grpcServer := server.NewGRPCServer(grpcAddr)
+	grpcServer := server.NewGRPCServer(grpcAddr, grpcOptions...)
 
 	// case 2, create a grpc service and register it with consul or etcd or nacos
 	//grpcRegistry, grpcInstance := registerService("grpc", cfg.App.Host, cfg.Grpc.Port)
-	//grpcServer := server.NewGRPCServer(grpcAddr,
-	//	server.WithGrpcRegistry(grpcRegistry, grpcInstance),
-	//)
+	//grpcOptions = append(grpcOptions, server.WithGrpcRegistry(grpcRegistry, grpcInstance))
+	//grpcServer := server.NewGRPCServer(grpcAddr, grpcOptions...)
 
 	servers = append(servers, grpcServer)
 
@@ -4,6 +4,7 @@ import (
 	"strconv"
 
 	"github.com/go-dev-frame/sponge/internal/config"
+	"github.com/go-dev-frame/sponge/internal/database"
 	"github.com/go-dev-frame/sponge/internal/server"
 
 	"github.com/go-dev-frame/sponge/pkg/app"
@@ -16,10 +17,17 @@ func CreateServices() []app.IServer {
 
 	// create a http service
 	httpAddr := ":" + strconv.Itoa(cfg.HTTP.Port)
-	httpServer := server.NewHTTPServer(httpAddr,
+	// the status of /health is DOWN if a dependency of probes is unreachable, /health?verbose=true lists the status of dependencies
+	httpOptions := []server.HTTPOption{
 		server.WithHTTPIsProd(cfg.App.Env == "prod"),
 		server.WithHTTPTLS(cfg.HTTP.TLS),
-	)
+		server.WithHTTPConnLimit(cfg.HTTP.MaxConns, cfg.HTTP.MaxRequests),
+		server.WithHTTPHealthProbe("database", database.PingDB),
+	}
+	if cfg.App.CacheType == "redis" {
+		httpOptions = append(httpOptions, server.WithHTTPHealthProbe("redis", database.PingRedis))
+	}
+	httpServer := server.NewHTTPServer(httpAddr, httpOptions...)
 	servers = append(servers, httpServer)
 
 	return servers
@@ -4,6 +4,9 @@ import (
 	"strconv"
 
 	"github.com/go-dev-frame/sponge/internal/config"
+	// sponge:if db
+	"github.com/go-dev-frame/sponge/internal/database"
+	// sponge:end
 	"github.com/go-dev-frame/sponge/internal/server"
 
 	"github.com/go-dev-frame/sponge/pkg/app"
@@ -16,10 +19,19 @@ func CreateServices() []app.IServer {
 
 	// create a http service
 	httpAddr := ":" + strconv.Itoa(cfg.HTTP.Port)
-	httpServer := server.NewHTTPServer_pbExample(httpAddr,
+	httpOptions := []server.HTTPOption{
 		server.WithHTTPIsProd(cfg.App.Env == "prod"),
 		server.WithHTTPTLS(cfg.HTTP.TLS),
-	)
+		server.WithHTTPConnLimit(cfg.HTTP.MaxConns, cfg.HTTP.MaxRequests),
+	}
+	// sponge:if db
+	// the status of /health is DOWN if a dependency of probes is unreachable, /health?verbose=true lists the status of dependencies
+	httpOptions = append(httpOptions, server.WithHTTPHealthProbe("database", database.PingDB))
+	if cfg.App.CacheType == "redis" {
+		httpOptions = append(httpOptions, server.WithHTTPHealthProbe("redis", database.PingRedis))
+	}
+	// sponge:end
+	httpServer := server.NewHTTPServer_pbExample(httpAddr, httpOptions...)
 	servers = append(servers, httpServer)
 
 	return servers
@@ -12,6 +12,7 @@ import (
 	"github.com/go-dev-frame/sponge/pkg/servicerd/registry/nacos"
 
 	"github.com/go-dev-frame/sponge/internal/config"
+	"github.com/go-dev-frame/sponge/internal/database"
 	"github.com/go-dev-frame/sponge/internal/server"
 )
 
@@ -23,19 +24,32 @@ func CreateServices() []app.IServer {
 	// create a http service
 	httpAddr := ":" + strconv.Itoa(cfg.HTTP.Port)
 	httpRegistry, httpInstance := registerService("http", cfg.App.Host, cfg.HTTP.Port)
-	httpServer := server.NewHTTPServer(httpAddr,
+	// the status of /health is DOWN if a dependency of probes is unreachable, /health?verbose=true lists the status of dependencies
+	httpOptions := []server.HTTPOption{
 		server.WithHTTPRegistry(httpRegistry, httpInstance),
 		server.WithHTTPIsProd(cfg.App.Env == "prod"),
 		server.WithHTTPTLS(cfg.HTTP.TLS),
-	)
+		server.WithHTTPConnLimit(cfg.HTTP.MaxConns, cfg.HTTP.MaxRequests),
+		server.WithHTTPHealthProbe("database", database.PingDB),
+	}
+	if cfg.App.CacheType == "redis" {
+		httpOptions = append(httpOptions, server.WithHTTPHealthProbe("redis", database.PingRedis))
+	}
+	httpServer := server.NewHTTPServer(httpAddr, httpOptions...)
 	servers = append(servers, httpServer)
 
 	// create a grpc service
 	grpcAddr := ":" + strconv.Itoa(cfg.Grpc.Port)
 	grpcRegistry, grpcInstance := registerService("grpc", cfg.App.Host, cfg.Grpc.Port)
-	grpcServer := server.NewGRPCServer(grpcAddr,
+	// the grpc health status is NOT_SERVING if a dependency of probes is unreachable
+	grpcOptions := []server.GrpcOption{
 		server.WithGrpcRegistry(grpcRegistry, grpcInstance),
-	)
+		server.WithGrpcHealthProbe("database", database.PingDB),
+	}
+	if cfg.App.CacheType == "redis" {
+		grpcOptions = append(grpcOptions, server.WithGrpcHealthProbe("redis", database.PingRedis))
+	}
+	grpcServer := server.NewGRPCServer(grpcAddr, grpcOptions...)
 	servers = append(servers, grpcServer)
 
 	return servers
@@ -7,6 +7,7 @@ import (
 	"flag"
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/go-dev-frame/sponge/pkg/conf"
 	"github.com/go-dev-frame/sponge/pkg/copier"
@@ -43,6 +44,9 @@ func InitApp() {
 			//logger.WithFileMaxAge(cfg.Logger.LogFileConfig.MaxAge),
 			//logger.WithFileIsCompression(cfg.Logger.LogFileConfig.IsCompression),
 		),
+		logger.WithSampling(time.Second, cfg.Logger.Sampling.First, cfg.Logger.Sampling.Thereafter),
+		logger.WithErrorRateLimit(cfg.Logger.ErrorRateLimit),
+		logger.WithRedactFields(cfg.Logger.RedactFields...),
 	)
 	if err != nil {
 		panic(err)
@@ -59,6 +63,19 @@ func InitApp() {
 			cfg.Jaeger.AgentHost,
 			strconv.Itoa(cfg.Jaeger.AgentPort),
 			cfg.App.TracingSamplingRate,
+			tracer.WithExporter(cfg.Tracer.Exporter),
+			tracer.WithOTLP(
+				cfg.Tracer.Otlp.Protocol,
+				cfg.Tracer.Otlp.Endpoint,
+				tracer.WithOTLPInsecure(cfg.Tracer.Otlp.Insecure),
+				tracer.WithOTLPCAFile(cfg.Tracer.Otlp.CaFile),
+				tracer.WithOTLPHeaders(cfg.Tracer.Otlp.Headers),
+			),
+			tracer.WithSampling(
+				tracer.WithKeepErrors(cfg.Tracer.Sampling.KeepErrors),
+				tracer.WithKeepSlow(time.Duration(cfg.Tracer.Sampling.SlowThreshold)*time.Millisecond),
+				tracer.WithRouteRateLimits(cfg.Tracer.Sampling.RouteRateLimits),
+			),
 		)
 		logger.Info("[tracer] was initialized")
 	}
@@ -0,0 +1,22 @@
+package initial
+
+import (
+	"github.com/go-dev-frame/sponge/pkg/app"
+
+	"github.com/go-dev-frame/sponge/internal/config"
+	"github.com/go-dev-frame/sponge/internal/server"
+)
+
+// CreateServices create services
+func CreateServices() []app.IServer {
+	var cfg = config.Get()
+	var servers []app.IServer
+
+	// the handlers of topics are registered in internal/worker, the messages that fail after
+	// the maximum attempts can be saved by server.WithWorkerDeadLetterHandler
+	workerServer := server.NewWorkerServer(cfg.Worker)
+
+	servers = append(servers, workerServer)
+
+	return servers
+}
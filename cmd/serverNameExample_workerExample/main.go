@@ -0,0 +1,24 @@
+// Package main is the worker service of the application, it consumes messages from message queues.
+package main
+
+import (
+	"strconv"
+
+	"github.com/go-dev-frame/sponge/pkg/app"
+
+	"github.com/go-dev-frame/sponge/cmd/serverNameExample_workerExample/initial"
+	"github.com/go-dev-frame/sponge/internal/config"
+)
+
+func main() {
+	initial.InitApp()
+	services := initial.CreateServices()
+	closes := initial.Close(services)
+
+	var opts []app.Option
+	if port := config.Get().App.HealthPort; port > 0 {
+		opts = append(opts, app.WithHealthServer(":"+strconv.Itoa(port))) // kubernetes probes
+	}
+	a := app.New(services, closes, opts...)
+	a.Run()
+}
@@ -0,0 +1,25 @@
+package commands
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/go-dev-frame/sponge/cmd/sponge/commands/ecode"
+)
+
+// EcodeCommand error code commands
+func EcodeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ecode",
+		Short: "Export the error codes for clients",
+		Long: `Export the error codes for clients, the error codes defined in the service are exported as json,
+or the error code constants of TypeScript and Java are generated.`,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	cmd.AddCommand(
+		ecode.ExportCommand(),
+	)
+
+	return cmd
+}
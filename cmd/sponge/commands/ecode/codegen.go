@@ -0,0 +1,148 @@
+package ecode
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/go-dev-frame/sponge/pkg/errcode"
+)
+
+const generatedComment = "// Code generated by sponge ecode export, DO NOT EDIT.\n"
+
+func newJSONEncoder(w io.Writer, indent string) *json.Encoder {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", indent)
+	return encoder
+}
+
+// quote the string with json escape, it is also a valid string literal of TypeScript and Java
+func quoteString(s string) string {
+	buf := &bytes.Buffer{}
+	_ = newJSONEncoder(buf, "").Encode(s)
+	return strings.TrimSpace(buf.String())
+}
+
+func toTypeScript(infos []*errcode.CodeInfo) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteString(generatedComment)
+	buf.WriteString(`
+export type ErrCodeType = "http" | "grpc";
+
+export interface ErrCodeInfo {
+  code: number;
+  msg: string;
+  type: ErrCodeType;
+  httpStatus: number;
+  grpcCode?: string;
+}
+
+export const ErrCodes = {
+`)
+	for _, info := range infos {
+		fmt.Fprintf(buf, "  %s: { code: %d, msg: %s, type: %s, httpStatus: %d",
+			info.Name, info.Code, quoteString(info.Msg), quoteString(info.Type), info.HTTPStatus)
+		if info.GRPCCode != "" {
+			fmt.Fprintf(buf, ", grpcCode: %s", quoteString(info.GRPCCode))
+		}
+		buf.WriteString(" },\n")
+	}
+	buf.WriteString(`} as const;
+
+export type ErrCodeName = keyof typeof ErrCodes;
+
+// getErrCode get the error code info by code, the type is http by default
+export function getErrCode(code: number, type: ErrCodeType = "http"): ErrCodeInfo | undefined {
+  return (Object.values(ErrCodes) as ErrCodeInfo[]).find((e) => e.code === code && e.type === type);
+}
+`)
+	return buf.Bytes(), nil
+}
+
+func toJava(infos []*errcode.CodeInfo, javaPackage string) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteString(generatedComment)
+	fmt.Fprintf(buf, "\npackage %s;\n\npublic enum ErrCode {\n", javaPackage)
+	for i, info := range infos {
+		grpcCode := "null"
+		if info.GRPCCode != "" {
+			grpcCode = quoteString(info.GRPCCode)
+		}
+		sep := ","
+		if i == len(infos)-1 {
+			sep = ";"
+		}
+		fmt.Fprintf(buf, "    %s(%s, %d, %s, %d, %s)%s\n", toUpperSnakeCase(info.Name),
+			quoteString(info.Type), info.Code, quoteString(info.Msg), info.HTTPStatus, grpcCode, sep)
+	}
+	buf.WriteString(`
+    private final String type;
+    private final int code;
+    private final String msg;
+    private final int httpStatus;
+    private final String grpcCode;
+
+    ErrCode(String type, int code, String msg, int httpStatus, String grpcCode) {
+        this.type = type;
+        this.code = code;
+        this.msg = msg;
+        this.httpStatus = httpStatus;
+        this.grpcCode = grpcCode;
+    }
+
+    public String getType() {
+        return type;
+    }
+
+    public int getCode() {
+        return code;
+    }
+
+    public String getMsg() {
+        return msg;
+    }
+
+    public int getHttpStatus() {
+        return httpStatus;
+    }
+
+    public String getGrpcCode() {
+        return grpcCode;
+    }
+
+    /**
+     * get the error code by type and code, type is http or grpc, return null if not found
+     */
+    public static ErrCode of(String type, int code) {
+        for (ErrCode e : values()) {
+            if (e.type.equals(type) && e.code == code) {
+                return e;
+            }
+        }
+        return null;
+    }
+}
+`)
+	return buf.Bytes(), nil
+}
+
+// convert the go variable name to upper snake case, e.g. ErrGetByIDUser --> ERR_GET_BY_ID_USER
+func toUpperSnakeCase(name string) string {
+	runes := []rune(name)
+	var sb strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prev != '_' && (unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower)) {
+				sb.WriteRune('_')
+			}
+		}
+		sb.WriteRune(unicode.ToUpper(r))
+	}
+	return strings.TrimLeft(sb.String(), "_")
+}
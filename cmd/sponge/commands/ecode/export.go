@@ -0,0 +1,139 @@
+// Package ecode is used to export the error codes defined in the service.
+package ecode
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/go-dev-frame/sponge/pkg/errcode"
+	"github.com/go-dev-frame/sponge/pkg/gofile"
+)
+
+const (
+	formatJSON       = "json"
+	formatTypeScript = "ts"
+	formatJava       = "java"
+
+	defaultEcodeDir    = "internal/ecode"
+	defaultJavaPackage = "ecode"
+)
+
+// ExportCommand export the error codes as json, or generate the error code constants of client languages
+func ExportCommand() *cobra.Command {
+	var (
+		dir         string
+		format      string
+		outFile     string
+		javaPackage string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the error codes as json or client constants",
+		Long: `Export the error codes as json or client constants.
+
+The error codes defined in the directory internal/ecode are parsed, including the name, code, message,
+the mappings of http status code and grpc code, then they are exported as json, or the constants of
+TypeScript and Java are generated, so that the clients don't need to hard-code the error codes.`,
+		Example: color.HiBlackString(`  # Export the error codes as json, print to the terminal.
+  sponge ecode export
+
+  # Generate the error code constants of TypeScript.
+  sponge ecode export --format=ts --out=web/src/errCodes.ts
+
+  # Generate the error code enum of Java, and specify the package name.
+  sponge ecode export --format=java --java-package=com.example.ecode --out=ErrCode.java`),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !gofile.IsExists(dir) {
+				return fmt.Errorf("not found the error code directory %s", dir)
+			}
+			infos, err := parseCodeInfos(dir)
+			if err != nil {
+				return err
+			}
+			if len(infos) == 0 {
+				return fmt.Errorf("not found error codes in the directory %s", dir)
+			}
+
+			var data []byte
+			switch strings.ToLower(format) {
+			case formatJSON:
+				data, err = toJSON(infos)
+			case formatTypeScript, "typescript":
+				data, err = toTypeScript(infos)
+			case formatJava:
+				if !isValidJavaPackage(javaPackage) {
+					return fmt.Errorf("invalid java package name '%s'", javaPackage)
+				}
+				data, err = toJava(infos, javaPackage)
+			default:
+				return errors.New("unsupported format: " + format + ", only json, ts, java are supported")
+			}
+			if err != nil {
+				return err
+			}
+
+			if outFile == "" {
+				fmt.Print(string(data))
+				return nil
+			}
+			if err = os.MkdirAll(filepath.Dir(outFile), 0766); err != nil {
+				return err
+			}
+			if err = os.WriteFile(outFile, data, 0666); err != nil {
+				return err
+			}
+			fmt.Printf("export %d error codes successfully, out = %s\n", len(infos), outFile)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&dir, "dir", "d", defaultEcodeDir, "directory of the error code files")
+	cmd.Flags().StringVarP(&format, "format", "f", formatJSON, "export format, support json, ts, java")
+	cmd.Flags().StringVarP(&outFile, "out", "o", "", "output file, print to the terminal if empty")
+	cmd.Flags().StringVarP(&javaPackage, "java-package", "p", defaultJavaPackage, "package name of the generated java file")
+
+	return cmd
+}
+
+func isValidJavaPackage(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, s := range strings.Split(name, ".") {
+		if !isIdentifier(s) {
+			return false
+		}
+	}
+	return true
+}
+
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		if c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 0 && c >= '0' && c <= '9') {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+func toJSON(infos []*errcode.CodeInfo) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := newJSONEncoder(buf, "  ").Encode(infos); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
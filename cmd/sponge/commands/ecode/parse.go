@@ -0,0 +1,280 @@
+package ecode
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-dev-frame/sponge/pkg/errcode"
+)
+
+const errcodePkgName = "errcode"
+
+// codeParser evaluates the package level variables and constants of error code files statically,
+// the supported expressions are the literals, variables, +, -, *, errcode.HCode, errcode.RCode,
+// errcode.NewError, errcode.NewRPCStatus and the system error codes of errcode, e.g. errcode.InvalidParams.
+type codeParser struct {
+	fset       *token.FileSet
+	exprs      map[string]ast.Expr // variable name --> value expression
+	positions  map[string]token.Pos
+	values     map[string]interface{}
+	evaluating map[string]bool
+}
+
+func parseCodeInfos(dir string) ([]*errcode.CodeInfo, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	p := &codeParser{
+		fset:       token.NewFileSet(),
+		exprs:      map[string]ast.Expr{},
+		positions:  map[string]token.Pos{},
+		values:     map[string]interface{}{},
+		evaluating: map[string]bool{},
+	}
+	var names []string
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		f, err := parser.ParseFile(p.fset, file, data, 0)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, p.collect(f)...)
+	}
+
+	var infos []*errcode.CodeInfo
+	existCodes := map[string]string{}
+	for _, name := range names {
+		v, err := p.eval(name)
+		if err != nil {
+			if isCodeExpr(p.exprs[name]) {
+				return nil, fmt.Errorf("%s: parse error code %s failed, %v", p.fset.Position(p.positions[name]), name, err)
+			}
+			continue // not error code variable
+		}
+		info, ok := v.(*errcode.CodeInfo)
+		if !ok {
+			continue
+		}
+		if isCodeExpr(p.exprs[name]) {
+			key := fmt.Sprintf("%s:%d", info.Type, info.Code)
+			if existName, ok := existCodes[key]; ok {
+				return nil, fmt.Errorf("%s error code %d is duplicated, %s and %s", info.Type, info.Code, existName, name)
+			}
+			existCodes[key] = name
+		}
+		codeInfo := *info // the variable may be a reference of another error code
+		codeInfo.Name = name
+		infos = append(infos, &codeInfo)
+	}
+
+	errcode.SortCodeInfos(infos)
+	return infos, nil
+}
+
+// collect the package level variables and constants, return the names in the order of declaration
+func (p *codeParser) collect(f *ast.File) []string {
+	var names []string
+	for _, decl := range f.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || (genDecl.Tok != token.VAR && genDecl.Tok != token.CONST) {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			valueSpec, ok := spec.(*ast.ValueSpec)
+			if !ok {
+				continue
+			}
+			for i, ident := range valueSpec.Names {
+				if ident.Name == "_" || i >= len(valueSpec.Values) {
+					continue
+				}
+				p.exprs[ident.Name] = valueSpec.Values[i]
+				p.positions[ident.Name] = ident.Pos()
+				names = append(names, ident.Name)
+			}
+		}
+	}
+	return names
+}
+
+func (p *codeParser) eval(name string) (interface{}, error) {
+	if v, ok := p.values[name]; ok {
+		return v, nil
+	}
+	expr, ok := p.exprs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown identifier %s", name)
+	}
+	if p.evaluating[name] {
+		return nil, fmt.Errorf("circular reference of %s", name)
+	}
+	p.evaluating[name] = true
+	defer delete(p.evaluating, name)
+
+	v, err := p.evalExpr(expr)
+	if err != nil {
+		return nil, err
+	}
+	p.values[name] = v
+	return v, nil
+}
+
+func (p *codeParser) evalExpr(expr ast.Expr) (interface{}, error) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		switch e.Kind {
+		case token.INT:
+			return strconv.ParseInt(e.Value, 0, 64)
+		case token.STRING:
+			return strconv.Unquote(e.Value)
+		}
+	case *ast.Ident:
+		return p.eval(e.Name)
+	case *ast.ParenExpr:
+		return p.evalExpr(e.X)
+	case *ast.BinaryExpr:
+		return p.evalBinaryExpr(e)
+	case *ast.SelectorExpr:
+		if isErrcodeSelector(e) {
+			if info, ok := errcode.GetSystemCodeInfo(e.Sel.Name); ok {
+				return info, nil
+			}
+		}
+	case *ast.CallExpr:
+		return p.evalCallExpr(e)
+	}
+	return nil, fmt.Errorf("unsupported expression %s", p.exprString(expr))
+}
+
+func (p *codeParser) evalBinaryExpr(e *ast.BinaryExpr) (interface{}, error) {
+	x, err := p.evalExpr(e.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := p.evalExpr(e.Y)
+	if err != nil {
+		return nil, err
+	}
+
+	switch xv := x.(type) {
+	case int64:
+		yv, ok := y.(int64)
+		if !ok {
+			break
+		}
+		switch e.Op {
+		case token.ADD:
+			return xv + yv, nil
+		case token.SUB:
+			return xv - yv, nil
+		case token.MUL:
+			return xv * yv, nil
+		}
+	case string:
+		if yv, ok := y.(string); ok && e.Op == token.ADD {
+			return xv + yv, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported expression %s", p.exprString(e))
+}
+
+func (p *codeParser) evalCallExpr(e *ast.CallExpr) (interface{}, error) {
+	funcName := ""
+	switch fn := e.Fun.(type) {
+	case *ast.Ident: // type conversion, e.g. int(x)
+		funcName = fn.Name
+	case *ast.SelectorExpr:
+		if x, ok := fn.X.(*ast.Ident); ok {
+			funcName = x.Name + "." + fn.Sel.Name
+		}
+	}
+
+	args := make([]interface{}, 0, len(e.Args))
+	for _, arg := range e.Args {
+		v, err := p.evalExpr(arg)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, v)
+	}
+	intArg := func(i int) (int64, bool) {
+		if i >= len(args) {
+			return 0, false
+		}
+		v, ok := args[i].(int64)
+		return v, ok
+	}
+	stringArg := func(i int) (string, bool) {
+		if i >= len(args) {
+			return "", false
+		}
+		v, ok := args[i].(string)
+		return v, ok
+	}
+
+	switch funcName {
+	case "int", "int32", "int64", "uint32", "codes.Code":
+		if v, ok := intArg(0); ok && len(args) == 1 {
+			return v, nil
+		}
+	case errcodePkgName + ".HCode":
+		if v, ok := intArg(0); ok {
+			return 200000 + v*100, nil
+		}
+	case errcodePkgName + ".RCode":
+		if v, ok := intArg(0); ok {
+			return 400000 + v*100, nil
+		}
+	case errcodePkgName + ".NewError":
+		code, ok1 := intArg(0)
+		msg, ok2 := stringArg(1)
+		if ok1 && ok2 {
+			return errcode.NewHTTPCodeInfo("", int(code), msg), nil
+		}
+	case errcodePkgName + ".NewRPCStatus":
+		code, ok1 := intArg(0)
+		msg, ok2 := stringArg(1)
+		if ok1 && ok2 {
+			return errcode.NewGRPCCodeInfo("", int(code), msg), nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported expression %s", p.exprString(e))
+}
+
+func (p *codeParser) exprString(expr ast.Expr) string {
+	start, end := p.fset.Position(expr.Pos()), p.fset.Position(expr.End())
+	data, err := os.ReadFile(start.Filename)
+	if err != nil || end.Offset > len(data) {
+		return fmt.Sprintf("%T", expr)
+	}
+	return string(data[start.Offset:end.Offset])
+}
+
+func isErrcodeSelector(e *ast.SelectorExpr) bool {
+	x, ok := e.X.(*ast.Ident)
+	return ok && x.Name == errcodePkgName
+}
+
+// the expression is errcode.NewError or errcode.NewRPCStatus
+func isCodeExpr(expr ast.Expr) bool {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	fn, ok := call.Fun.(*ast.SelectorExpr)
+	return ok && isErrcodeSelector(fn) && (fn.Sel.Name == "NewError" || fn.Sel.Name == "NewRPCStatus")
+}
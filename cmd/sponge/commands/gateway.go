@@ -0,0 +1,160 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/go-dev-frame/sponge/pkg/httpsrv"
+	"github.com/go-dev-frame/sponge/pkg/proxykit"
+)
+
+// GatewayCommand run a standalone api gateway based on proxykit
+func GatewayCommand() *cobra.Command {
+	var (
+		configFile string
+		port       int
+		adminPort  int
+		certFile   string
+		keyFile    string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "gateway",
+		Short: "Run a standalone api gateway from a yaml configuration file",
+		Long: `Run a standalone api gateway from a yaml configuration file, routes are proxied on the proxy port,
+the management api and prometheus metrics are served on the admin port, send SIGHUP to reload the configuration file.`,
+		Example: color.HiBlackString(`  # Running gateway with default configuration file gateway.yml
+  sponge run gateway
+
+  # Running gateway with specified configuration file and ports
+  sponge run gateway -c /etc/gateway.yml -p 8080 --admin-port=8081
+
+  # Running gateway with TLS termination
+  sponge run gateway -c gateway.yml --tls-cert=server.crt --tls-key=server.key
+
+  # Reload configuration file
+  kill -HUP <gateway pid>`),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGateway(configFile, port, adminPort, certFile, keyFile)
+		},
+	}
+	cmd.Flags().StringVarP(&configFile, "config", "c", "gateway.yml", "gateway configuration file")
+	cmd.Flags().IntVarP(&port, "port", "p", 8080, "port on which the gateway proxies requests")
+	cmd.Flags().IntVarP(&adminPort, "admin-port", "m", 8081, "port on which the management api and metrics are served")
+	cmd.Flags().StringVarP(&certFile, "tls-cert", "", "", "tls certificate file, enable https when set together with tls-key")
+	cmd.Flags().StringVarP(&keyFile, "tls-key", "", "", "tls private key file")
+	return cmd
+}
+
+func runGateway(configFile string, port int, adminPort int, certFile string, keyFile string) error {
+	if port == adminPort {
+		return errors.New("port and admin-port cannot be the same")
+	}
+	if (certFile == "") != (keyFile == "") {
+		return errors.New("tls-cert and tls-key must be set together")
+	}
+
+	manager := proxykit.NewRouteManager()
+	cfg, err := proxykit.ParseConfigFile(configFile)
+	if err != nil {
+		return fmt.Errorf("parse config file '%s' error: %v", configFile, err)
+	}
+	if err = manager.ApplyConfig(cfg); err != nil {
+		return fmt.Errorf("apply config file '%s' error: %v", configFile, err)
+	}
+
+	var tlser httpsrv.TLSer
+	if certFile != "" {
+		tlser = httpsrv.NewTLSExternalConfig(certFile, keyFile)
+	}
+	proxyServer := &http.Server{
+		Addr:              fmt.Sprintf(":%d", port),
+		Handler:           proxykit.GRPCHandler(manager),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	adminServer := &http.Server{
+		Addr:              fmt.Sprintf(":%d", adminPort),
+		Handler:           gatewayAdminHandler(manager),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 2)
+	go func() {
+		errCh <- proxykit.ListenAndServe(proxyServer, tlser)
+	}()
+	go func() {
+		if err := adminServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- fmt.Errorf("admin server error: %v", err)
+			return
+		}
+		errCh <- nil
+	}()
+	fmt.Printf("Gateway %s is running, proxy listening on %s, management api listening on %s.\n",
+		getVersion(), color.HiCyanString(proxyServer.Addr), color.HiCyanString(adminServer.Addr))
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(quit)
+
+	for {
+		select {
+		case err = <-errCh:
+			shutdownGateway(proxyServer, adminServer)
+			return err
+		case sig := <-quit:
+			if sig == syscall.SIGHUP {
+				reloadGatewayConfig(configFile, manager)
+				continue
+			}
+			fmt.Printf("Received signal %s, shutting down gateway.\n", sig)
+			shutdownGateway(proxyServer, adminServer)
+			return nil
+		}
+	}
+}
+
+// gatewayAdminHandler serves the management api and prometheus metrics
+func gatewayAdminHandler(manager *proxykit.RouteManager) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/endpoints/add", manager.HandleAddBackends)
+	mux.HandleFunc("/endpoints/remove", manager.HandleRemoveBackends)
+	mux.HandleFunc("/endpoints/drain", manager.HandleDrainBackends)
+	mux.HandleFunc("/endpoints/list", manager.HandleListBackends)
+	mux.HandleFunc("/endpoints/get", manager.HandleGetBackend)
+	mux.HandleFunc("/endpoints/split", manager.HandleSplit)
+	mux.Handle("/metrics", proxykit.MetricsHandler())
+	return mux
+}
+
+// reloadGatewayConfig applies the configuration file again, the running routes are kept if it is invalid
+func reloadGatewayConfig(configFile string, manager *proxykit.RouteManager) {
+	cfg, err := proxykit.ParseConfigFile(configFile)
+	if err == nil {
+		err = manager.ApplyConfig(cfg)
+	}
+	if err != nil {
+		fmt.Printf("Reload config file '%s' failed, keep the running configuration: %v\n", configFile, err)
+		return
+	}
+	fmt.Printf("Reload config file '%s' successfully.\n", configFile)
+}
+
+func shutdownGateway(servers ...*http.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	for _, server := range servers {
+		_ = server.Shutdown(ctx)
+	}
+}
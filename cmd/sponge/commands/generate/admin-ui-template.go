@@ -0,0 +1,274 @@
+package generate
+
+var (
+	// adminUIRouterCode registers the routes of admin ui, moduleNameExample is replaced by the module name.
+	adminUIRouterCode = `package routers
+
+import (
+	"embed"
+	"encoding/json"
+	"io/fs"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/go-dev-frame/sponge/pkg/gin/frontend"
+	"github.com/go-dev-frame/sponge/pkg/gin/response"
+	"github.com/go-dev-frame/sponge/pkg/logger"
+
+	"moduleNameExample/internal/config"
+)
+
+// the pages of the admin ui are driven by the json files of tables in the directory admin/tables,
+// generate them by the command: sponge web handler --admin-ui=true
+//
+//go:embed admin
+var adminUIFS embed.FS
+
+func init() {
+	engineRouterFns = append(engineRouterFns, func(r *gin.Engine) {
+		adminUIRouter(r)
+	})
+}
+
+func adminUIRouter(r *gin.Engine) {
+	// the admin ui can operate all the data of tables, it is not available in prod environment
+	if config.Get().App.Env == "prod" {
+		return
+	}
+
+	// access path /admin/index.html
+	f := frontend.New("admin", frontend.WithEmbedFS(adminUIFS))
+	if err := f.SetRouter(r); err != nil {
+		logger.Warn("set admin ui router error", logger.Err(err))
+		return
+	}
+	r.GET("/api/v1/adminUI/tables", listAdminUITables)
+}
+
+func listAdminUITables(c *gin.Context) {
+	tables := []json.RawMessage{}
+	files, _ := fs.Glob(adminUIFS, "admin/tables/*.json")
+	for _, file := range files {
+		data, err := adminUIFS.ReadFile(file)
+		if err != nil || !json.Valid(data) {
+			logger.Warn("invalid admin ui table file", logger.String("file", file))
+			continue
+		}
+		tables = append(tables, data)
+	}
+
+	response.Success(c, gin.H{"tables": tables})
+}
+`
+
+	// adminUIIndexHTML is a vue single page without building, the pages of all tables are rendered by it.
+	adminUIIndexHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="UTF-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1.0">
+  <title>Admin</title>
+  <script src="https://unpkg.com/vue@3/dist/vue.global.prod.js"></script>
+  <style>
+    body { margin: 0; font-family: -apple-system, "Segoe UI", Roboto, Arial, sans-serif; font-size: 14px; color: #303133; }
+    .layout { display: flex; min-height: 100vh; }
+    .menu { width: 200px; background: #304156; color: #bfcbd9; }
+    .menu h3 { color: #fff; padding: 0 16px; }
+    .menu a { display: block; padding: 10px 16px; color: inherit; cursor: pointer; text-decoration: none; }
+    .menu a.active, .menu a:hover { background: #263445; color: #409eff; }
+    .main { flex: 1; padding: 16px 24px; overflow-x: auto; }
+    .toolbar { display: flex; gap: 8px; margin-bottom: 12px; align-items: center; }
+    table { border-collapse: collapse; width: 100%; }
+    th, td { border: 1px solid #ebeef5; padding: 8px; text-align: left; white-space: nowrap; }
+    th { background: #f5f7fa; }
+    button { padding: 5px 12px; border: 1px solid #dcdfe6; border-radius: 3px; background: #fff; cursor: pointer; }
+    button.primary { background: #409eff; border-color: #409eff; color: #fff; }
+    button.danger { color: #f56c6c; }
+    input, select { padding: 5px 8px; border: 1px solid #dcdfe6; border-radius: 3px; }
+    .form-item { display: flex; margin-bottom: 12px; align-items: center; }
+    .form-item label { width: 160px; color: #606266; }
+    .form-item input { width: 360px; }
+    .error { color: #f56c6c; margin-bottom: 12px; }
+  </style>
+</head>
+<body>
+<div id="app" class="layout">
+  <div class="menu">
+    <h3>Admin</h3>
+    <a v-for="t in tables" :key="t.name" :class="{active: current && current.name === t.name}" @click="selectTable(t)" v-text="t.label"></a>
+  </div>
+
+  <div class="main">
+    <div class="error" v-if="error" v-text="error"></div>
+    <div v-if="!current">Select a table on the left, table pages are generated by the command: sponge web handler --admin-ui=true</div>
+
+    <!-- list page -->
+    <div v-if="current && mode === 'list'">
+      <div class="toolbar">
+        <button class="primary" @click="openForm(null)">Create</button>
+        <select v-model="search.column">
+          <option v-for="f in current.fields" :key="f.column" :value="f.column" v-text="f.label"></option>
+        </select>
+        <input v-model="search.value" placeholder="value" @keyup.enter="loadList(0)">
+        <button @click="loadList(0)">Search</button>
+        <button @click="search.value = ''; loadList(0)">Reset</button>
+      </div>
+      <table>
+        <thead>
+        <tr>
+          <th v-for="f in current.fields" :key="f.name" v-text="f.label"></th>
+          <th>Operation</th>
+        </tr>
+        </thead>
+        <tbody>
+        <tr v-for="row in rows" :key="row[current.primaryKey]">
+          <td v-for="f in current.fields" :key="f.name" v-text="display(row[f.name])"></td>
+          <td>
+            <button @click="openDetail(row)">Detail</button>
+            <button @click="openForm(row)">Edit</button>
+            <button class="danger" @click="remove(row)">Delete</button>
+          </td>
+        </tr>
+        </tbody>
+      </table>
+      <div class="toolbar" style="margin-top: 12px">
+        <button :disabled="page === 0" @click="loadList(page - 1)">Prev</button>
+        <span v-text="'page ' + (page + 1) + ' / ' + pageCount + ', total ' + total"></span>
+        <button :disabled="page + 1 >= pageCount" @click="loadList(page + 1)">Next</button>
+      </div>
+    </div>
+
+    <!-- detail page -->
+    <div v-if="current && mode === 'detail'">
+      <div class="form-item" v-for="f in current.fields" :key="f.name">
+        <label v-text="f.label"></label>
+        <span v-text="display(record[f.name])"></span>
+      </div>
+      <button @click="mode = 'list'">Back</button>
+    </div>
+
+    <!-- create and edit page -->
+    <div v-if="current && mode === 'form'">
+      <div class="form-item" v-for="f in editableFields" :key="f.name">
+        <label v-text="f.label"></label>
+        <input v-if="f.type === 'boolean'" type="checkbox" v-model="record[f.name]" style="width: auto">
+        <input v-else-if="f.type === 'number'" type="number" v-model.number="record[f.name]">
+        <input v-else v-model="record[f.name]" :placeholder="f.type === 'time' ? '2006-01-02T15:04:05+08:00' : ''">
+      </div>
+      <button class="primary" @click="save">Save</button>
+      <button @click="mode = 'list'">Cancel</button>
+    </div>
+  </div>
+</div>
+
+<script>
+  const apiPrefix = '/api/v1/';
+  const limit = 20;
+
+  async function request(method, path, body) {
+    const resp = await fetch(apiPrefix + path, {
+      method: method,
+      headers: {'Content-Type': 'application/json'},
+      body: body === undefined ? undefined : JSON.stringify(body),
+    });
+    const result = await resp.json();
+    if (result.code !== 0) {
+      throw new Error(result.msg || ('request failed, status ' + resp.status));
+    }
+    return result.data;
+  }
+
+  Vue.createApp({
+    data() {
+      return {tables: [], current: null, mode: 'list', rows: [], total: 0, page: 0, record: {}, editID: null, search: {column: '', value: ''}, error: ''};
+    },
+    computed: {
+      editableFields() {
+        return this.current.fields.filter(f => f.editable);
+      },
+      pageCount() {
+        return Math.max(1, Math.ceil(this.total / limit));
+      },
+    },
+    async mounted() {
+      await this.call(async () => {
+        this.tables = (await request('GET', 'adminUI/tables')).tables;
+      });
+    },
+    methods: {
+      async call(fn) {
+        this.error = '';
+        try {
+          await fn();
+        } catch (e) {
+          this.error = e.message;
+        }
+      },
+      display(value) {
+        if (value === null || value === undefined) {
+          return '';
+        }
+        return typeof value === 'object' ? JSON.stringify(value) : String(value);
+      },
+      selectTable(t) {
+        this.current = t;
+        this.search = {column: t.fields.length > 0 ? t.fields[0].column : '', value: ''};
+        this.loadList(0);
+      },
+      loadList(page) {
+        return this.call(async () => {
+          const pk = this.current.fields.find(f => f.name === this.current.primaryKey);
+          const params = {page: page, limit: limit, sort: '-' + pk.column};
+          if (this.search.value !== '') {
+            const field = this.current.fields.find(f => f.column === this.search.column);
+            const value = field && field.type === 'number' ? Number(this.search.value) : this.search.value;
+            params.columns = [{name: this.search.column, value: value}];
+          }
+          const data = await request('POST', this.current.name + '/list', params);
+          this.rows = data[this.current.listKey] || [];
+          this.total = data.total;
+          this.page = page;
+          this.mode = 'list';
+        });
+      },
+      openDetail(row) {
+        return this.call(async () => {
+          const data = await request('GET', this.current.name + '/' + row[this.current.primaryKey]);
+          this.record = data[this.current.name];
+          this.mode = 'detail';
+        });
+      },
+      openForm(row) {
+        this.record = {};
+        this.editID = row ? row[this.current.primaryKey] : null;
+        for (const f of this.editableFields) {
+          this.record[f.name] = row ? row[f.name] : (f.type === 'boolean' ? false : (f.type === 'number' ? 0 : ''));
+        }
+        this.mode = 'form';
+      },
+      save() {
+        return this.call(async () => {
+          if (this.editID === null) {
+            await request('POST', this.current.name, this.record);
+          } else {
+            await request('PUT', this.current.name + '/' + this.editID, this.record);
+          }
+          await this.loadList(this.editID === null ? 0 : this.page);
+        });
+      },
+      remove(row) {
+        if (!confirm('Delete this record?')) {
+          return;
+        }
+        return this.call(async () => {
+          await request('DELETE', this.current.name + '/' + row[this.current.primaryKey]);
+          await this.loadList(this.page);
+        });
+      },
+    },
+  }).mount('#app');
+</script>
+</body>
+</html>
+`
+)
@@ -0,0 +1,140 @@
+package generate
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-dev-frame/sponge/pkg/sql2code"
+	"github.com/go-dev-frame/sponge/pkg/sql2code/parser"
+)
+
+type adminUIField struct {
+	Name     string `json:"name"`     // json name of the field
+	Column   string `json:"column"`   // column name, used by the query conditions of list api
+	Label    string `json:"label"`    // display name
+	Type     string `json:"type"`     // input type, support string, number, boolean, time
+	Editable bool   `json:"editable"` // whether the field is in the create and edit forms
+}
+
+// adminUITable is the json data of a table in the admin ui, the pages of the table are driven by it.
+type adminUITable struct {
+	Name       string         `json:"name"`       // route name of the api, e.g. /api/v1/userExample
+	Label      string         `json:"label"`      // display name
+	ListKey    string         `json:"listKey"`    // the key of the list api response data, e.g. userExamples
+	PrimaryKey string         `json:"primaryKey"` // json name of the primary key
+	Fields     []adminUIField `json:"fields"`
+}
+
+func newAdminUITable(tableInfoJSON string, jsonNamedType int) (*adminUITable, error) {
+	info := parser.TableInfo{}
+	if err := json.Unmarshal([]byte(tableInfoJSON), &info); err != nil {
+		return nil, err
+	}
+	if info.PrimaryKey == nil {
+		return nil, fmt.Errorf("table '%s' has no primary key", info.TableName)
+	}
+
+	jsonName := func(column parser.Field) string {
+		if jsonNamedType == 0 {
+			return column.ColumnName
+		}
+		return column.ColumnNameCamelFCL
+	}
+
+	t := &adminUITable{
+		Name:    info.TableNameCamelFCL,
+		Label:   adminUILabel(info.TableComment, info.TableNameCamelFCL),
+		ListKey: info.TableNamePluralCamelFCL,
+	}
+	for _, column := range info.Columns {
+		if column.ColumnName == "deleted_at" {
+			continue
+		}
+		field := adminUIField{
+			Name:     jsonName(column),
+			Column:   column.ColumnName,
+			Label:    adminUILabel(column.ColumnComment, column.ColumnNameCamelFCL),
+			Type:     goTypeToAdminUI(column.GoType),
+			Editable: !column.IsPrimaryKey && column.ColumnName != "created_at" && column.ColumnName != "updated_at",
+		}
+		if column.IsPrimaryKey {
+			t.PrimaryKey = field.Name
+		}
+		t.Fields = append(t.Fields, field)
+	}
+
+	return t, nil
+}
+
+func goTypeToAdminUI(goType string) string {
+	switch goType = strings.TrimPrefix(goType, "*"); goType {
+	case "bool":
+		return "boolean"
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "float32", "float64":
+		return "number"
+	case "time.Time":
+		return "time"
+	}
+	return "string"
+}
+
+// adminUILabel use the first part of the comment as label, e.g. "gender, 1:Male, 2:Female" --> "gender".
+func adminUILabel(comment string, defaultLabel string) string {
+	comment = strings.Join(strings.Fields(comment), " ")
+	if i := strings.IndexAny(comment, ",，(（:："); i >= 0 {
+		comment = strings.TrimSpace(comment[:i])
+	}
+	if comment == "" {
+		return defaultLabel
+	}
+	return comment
+}
+
+// generateAdminUI generate the admin ui code of the tables to the server directory, the admin ui
+// is embedded in the binary and served by pkg/gin/frontend, it calls the CRUD api of the handler.
+func generateAdminUI(sqlArgs sql2code.Args, tableNames []string, moduleName string, serverName string,
+	suitedMonoRepo bool, outPath string) error {
+	sqlArgs.IsCustomTemplate = true
+	importPath := moduleName
+	if suitedMonoRepo {
+		importPath += "/" + serverName
+	}
+
+	commonFiles := map[string]string{
+		"internal/routers/admin_ui.go":      strings.ReplaceAll(adminUIRouterCode, "moduleNameExample", importPath),
+		"internal/routers/admin/index.html": adminUIIndexHTML,
+	}
+	for file, content := range commonFiles {
+		if err := saveCodeFile(filepath.Join(outPath, file), []byte(content), true); err != nil {
+			return err
+		}
+	}
+
+	for _, tableName := range tableNames {
+		if tableName == "" {
+			continue
+		}
+
+		sqlArgs.DBTable = tableName
+		codes, err := sql2code.Generate(&sqlArgs)
+		if err != nil {
+			return err
+		}
+		table, err := newAdminUITable(codes[parser.CodeTypeTableInfo], sqlArgs.JSONNamedType)
+		if err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(table, "", "  ")
+		if err != nil {
+			return err
+		}
+		file := filepath.Join(outPath, "internal/routers/admin/tables", table.Name+".json")
+		if err = saveCodeFile(file, data, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
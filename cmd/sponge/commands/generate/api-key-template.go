@@ -0,0 +1,593 @@
+package generate
+
+var (
+	// apiKeyModelCode the model of api key table
+	apiKeyModelCode = `package model
+
+import (
+	"time"
+)
+
+// APIKey the api keys of public api, only the hash of secret is saved, the plaintext key is returned
+// to the caller only once when it is issued or rotated.
+type APIKey struct {
+	ID             uint64     ` + "`" + `gorm:"column:id;primary_key;AUTO_INCREMENT" json:"id"` + "`" + `
+	KeyID          string     ` + "`" + `gorm:"column:key_id;type:varchar(32);uniqueIndex;NOT NULL" json:"keyID"` + "`" + `                    // public id of key
+	Name           string     ` + "`" + `gorm:"column:name;type:varchar(64);NOT NULL" json:"name"` + "`" + `                                    // name of key
+	Owner          string     ` + "`" + `gorm:"column:owner;type:varchar(64);index;NOT NULL" json:"owner"` + "`" + `                            // owner of key, e.g. user id or app id
+	SecretHash     string     ` + "`" + `gorm:"column:secret_hash;type:char(64);NOT NULL" json:"-"` + "`" + `                                   // sha256 of secret
+	PrevSecretHash string     ` + "`" + `gorm:"column:prev_secret_hash;type:char(64);NOT NULL" json:"-"` + "`" + `                              // sha256 of previous secret, it is valid until prev_expired_at after rotation
+	PrevExpiredAt  *time.Time ` + "`" + `gorm:"column:prev_expired_at" json:"-"` + "`" + `                                                      // expiration time of previous secret
+	Scopes         string     ` + "`" + `gorm:"column:scopes;type:varchar(255);NOT NULL" json:"scopes"` + "`" + `                              // scopes separated by commas, * means all scopes
+	ExpiredAt      *time.Time ` + "`" + `gorm:"column:expired_at" json:"expiredAt"` + "`" + `                                                   // expiration time, null means never expires
+	RevokedAt      *time.Time ` + "`" + `gorm:"column:revoked_at" json:"revokedAt"` + "`" + `                                                   // revocation time
+	CreatedAt      time.Time  ` + "`" + `gorm:"column:created_at" json:"createdAt"` + "`" + `
+	UpdatedAt      time.Time  ` + "`" + `gorm:"column:updated_at" json:"updatedAt"` + "`" + `
+}
+
+// TableName get table name
+func (table *APIKey) TableName() string {
+	return "api_key"
+}
+`
+
+	// apiKeyDaoCode the dao of api key table, it also provides the store of api key middleware
+	apiKeyDaoCode = `package dao
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/go-dev-frame/sponge/pkg/gin/apikey"
+
+	"moduleNameExample/internal/database"
+	"moduleNameExample/internal/model"
+)
+
+var _ APIKeyDao = (*apiKeyDao)(nil)
+
+// APIKeyDao defining the dao interface of api key
+type APIKeyDao interface {
+	Create(ctx context.Context, table *model.APIKey) error
+	GetByKeyID(ctx context.Context, keyID string) (*model.APIKey, error)
+	ListByOwner(ctx context.Context, owner string) ([]*model.APIKey, error)
+	Rotate(ctx context.Context, keyID string, secretHash string, gracePeriod time.Duration) error
+	Revoke(ctx context.Context, keyID string) error
+}
+
+type apiKeyDao struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyDao creating the dao interface of api key
+func NewAPIKeyDao(db *gorm.DB) APIKeyDao {
+	return &apiKeyDao{db: db}
+}
+
+// Create a new api key
+func (d *apiKeyDao) Create(ctx context.Context, table *model.APIKey) error {
+	return d.db.WithContext(ctx).Create(table).Error
+}
+
+// GetByKeyID get an api key by key id
+func (d *apiKeyDao) GetByKeyID(ctx context.Context, keyID string) (*model.APIKey, error) {
+	record := &model.APIKey{}
+	err := d.db.WithContext(ctx).Where("key_id = ?", keyID).First(record).Error
+	return record, err
+}
+
+// ListByOwner get the api keys of the owner, all api keys are returned if owner is empty
+func (d *apiKeyDao) ListByOwner(ctx context.Context, owner string) ([]*model.APIKey, error) {
+	var records []*model.APIKey
+	db := d.db.WithContext(ctx)
+	if owner != "" {
+		db = db.Where("owner = ?", owner)
+	}
+	err := db.Order("id DESC").Find(&records).Error
+	return records, err
+}
+
+// Rotate replace the secret of api key, the previous secret is still valid in the grace period
+func (d *apiKeyDao) Rotate(ctx context.Context, keyID string, secretHash string, gracePeriod time.Duration) error {
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		record := &model.APIKey{}
+		err := tx.Where("key_id = ? AND revoked_at IS NULL", keyID).First(record).Error
+		if err != nil {
+			return err
+		}
+		prevExpiredAt := time.Now().Add(gracePeriod)
+		result := tx.Model(record).Where("secret_hash = ?", record.SecretHash).Updates(map[string]interface{}{
+			"secret_hash":      secretHash,
+			"prev_secret_hash": record.SecretHash,
+			"prev_expired_at":  &prevExpiredAt,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("the api key has been rotated by another request")
+		}
+		return nil
+	})
+}
+
+// Revoke an api key, the revoked api key can not be used anymore
+func (d *apiKeyDao) Revoke(ctx context.Context, keyID string) error {
+	result := d.db.WithContext(ctx).Model(&model.APIKey{}).Where("key_id = ? AND revoked_at IS NULL", keyID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return database.ErrRecordNotFound
+	}
+	return nil
+}
+
+type apiKeyStore struct {
+	iDao APIKeyDao
+}
+
+// NewAPIKeyStore creating the store of api key middleware, the api key of request is found by dao.
+func NewAPIKeyStore(iDao APIKeyDao) apikey.Store {
+	return &apiKeyStore{iDao: iDao}
+}
+
+// GetByKeyID get the api key for verification
+func (s *apiKeyStore) GetByKeyID(ctx context.Context, keyID string) (*apikey.Key, error) {
+	record, err := s.iDao.GetByKeyID(ctx, keyID)
+	if err != nil {
+		if errors.Is(err, database.ErrRecordNotFound) {
+			return nil, apikey.ErrKeyNotFound
+		}
+		return nil, err
+	}
+
+	key := &apikey.Key{
+		KeyID:          record.KeyID,
+		SecretHash:     record.SecretHash,
+		Owner:          record.Owner,
+		Revoked:        record.RevokedAt != nil,
+		PrevSecretHash: record.PrevSecretHash,
+	}
+	if record.Scopes != "" {
+		key.Scopes = strings.Split(record.Scopes, ",")
+	}
+	if record.ExpiredAt != nil {
+		key.ExpiredAt = *record.ExpiredAt
+	}
+	if record.PrevExpiredAt != nil {
+		key.PrevExpiredAt = *record.PrevExpiredAt
+	}
+	return key, nil
+}
+`
+
+	// apiKeyTypesCode the request and response of api key handler
+	apiKeyTypesCode = `package types
+
+import (
+	"time"
+)
+
+// CreateAPIKeyRequest request params
+type CreateAPIKeyRequest struct {
+	Name          string   ` + "`" + `json:"name" binding:"required,max=64"` + "`" + `          // name of key
+	Owner         string   ` + "`" + `json:"owner" binding:"required,max=64"` + "`" + `         // owner of key, e.g. user id or app id
+	Scopes        []string ` + "`" + `json:"scopes" binding:"dive,required,excludesall=0x2C"` + "`" + ` // scopes of key, * means all scopes
+	ExpiresInDays int      ` + "`" + `json:"expiresInDays" binding:"gte=0"` + "`" + `          // the key expires after the days, 0 means never expires
+}
+
+// RotateAPIKeyRequest request params
+type RotateAPIKeyRequest struct {
+	GracePeriodSeconds int ` + "`" + `json:"gracePeriodSeconds" binding:"gte=0,lte=2592000"` + "`" + ` // the previous key is still valid in the grace period, max 30 days
+}
+
+// APIKeyObjDetail detail, the secret is not included
+type APIKeyObjDetail struct {
+	KeyID     string     ` + "`" + `json:"keyID"` + "`" + `     // public id of key
+	Name      string     ` + "`" + `json:"name"` + "`" + `      // name of key
+	Owner     string     ` + "`" + `json:"owner"` + "`" + `     // owner of key
+	Scopes    []string   ` + "`" + `json:"scopes"` + "`" + `    // scopes of key
+	ExpiredAt *time.Time ` + "`" + `json:"expiredAt"` + "`" + ` // expiration time, null means never expires
+	RevokedAt *time.Time ` + "`" + `json:"revokedAt"` + "`" + ` // revocation time
+	CreatedAt time.Time  ` + "`" + `json:"createdAt"` + "`" + ` // create time
+}
+
+// CreateAPIKeyReply only for api docs
+type CreateAPIKeyReply struct {
+	Code int    ` + "`" + `json:"code"` + "`" + ` // return code
+	Msg  string ` + "`" + `json:"msg"` + "`" + `  // return information description
+	Data struct {
+		KeyID string ` + "`" + `json:"keyID"` + "`" + ` // public id of key
+		Key   string ` + "`" + `json:"key"` + "`" + `   // the plaintext key, it is returned only once
+	} ` + "`" + `json:"data"` + "`" + ` // return data
+}
+
+// RotateAPIKeyReply only for api docs
+type RotateAPIKeyReply struct {
+	Code int    ` + "`" + `json:"code"` + "`" + ` // return code
+	Msg  string ` + "`" + `json:"msg"` + "`" + `  // return information description
+	Data struct {
+		KeyID string ` + "`" + `json:"keyID"` + "`" + ` // public id of key
+		Key   string ` + "`" + `json:"key"` + "`" + `   // the new plaintext key, it is returned only once
+	} ` + "`" + `json:"data"` + "`" + ` // return data
+}
+
+// RevokeAPIKeyReply only for api docs
+type RevokeAPIKeyReply struct {
+	Code int      ` + "`" + `json:"code"` + "`" + ` // return code
+	Msg  string   ` + "`" + `json:"msg"` + "`" + `  // return information description
+	Data struct{} ` + "`" + `json:"data"` + "`" + ` // return data
+}
+
+// ListAPIKeysReply only for api docs
+type ListAPIKeysReply struct {
+	Code int    ` + "`" + `json:"code"` + "`" + ` // return code
+	Msg  string ` + "`" + `json:"msg"` + "`" + `  // return information description
+	Data struct {
+		APIKeys []APIKeyObjDetail ` + "`" + `json:"apiKeys"` + "`" + `
+	} ` + "`" + `json:"data"` + "`" + ` // return data
+}
+
+// VerifyAPIKeyReply only for api docs
+type VerifyAPIKeyReply struct {
+	Code int    ` + "`" + `json:"code"` + "`" + ` // return code
+	Msg  string ` + "`" + `json:"msg"` + "`" + `  // return information description
+	Data struct {
+		KeyID  string   ` + "`" + `json:"keyID"` + "`" + `  // public id of key
+		Owner  string   ` + "`" + `json:"owner"` + "`" + `  // owner of key
+		Scopes []string ` + "`" + `json:"scopes"` + "`" + ` // scopes of key
+	} ` + "`" + `json:"data"` + "`" + ` // return data
+}
+`
+
+	// apiKeyHandlerCode the handler of api key management
+	apiKeyHandlerCode = `package handler
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/go-dev-frame/sponge/pkg/gin/apikey"
+	"github.com/go-dev-frame/sponge/pkg/gin/middleware"
+	"github.com/go-dev-frame/sponge/pkg/gin/response"
+	"github.com/go-dev-frame/sponge/pkg/logger"
+
+	"moduleNameExample/internal/dao"
+	"moduleNameExample/internal/database"
+	"moduleNameExample/internal/ecode"
+	"moduleNameExample/internal/model"
+	"moduleNameExample/internal/types"
+)
+
+// APIKeyPrefix the prefix of api key, the format of key is <prefix>_<key id>_<secret>
+const APIKeyPrefix = "sk"
+
+var _ APIKeyHandler = (*apiKeyHandler)(nil)
+
+// APIKeyHandler defining the handler interface of api key
+type APIKeyHandler interface {
+	Create(c *gin.Context)
+	Rotate(c *gin.Context)
+	Revoke(c *gin.Context)
+	List(c *gin.Context)
+	Verify(c *gin.Context)
+}
+
+type apiKeyHandler struct {
+	iDao dao.APIKeyDao
+}
+
+// NewAPIKeyHandler creating the handler interface of api key
+func NewAPIKeyHandler() APIKeyHandler {
+	return &apiKeyHandler{
+		iDao: dao.NewAPIKeyDao(database.GetDB()),
+	}
+}
+
+// Create issue a new api key
+// @Summary Issue a new api key
+// @Description Issues a new api key, the plaintext key is returned only once, only the hash of secret is saved.
+// @Tags apiKey
+// @Accept json
+// @Produce json
+// @Param data body types.CreateAPIKeyRequest true "api key information"
+// @Success 200 {object} types.CreateAPIKeyReply{}
+// @Router /api/v1/apiKey [post]
+// @Security BearerAuth
+func (h *apiKeyHandler) Create(c *gin.Context) {
+	form := &types.CreateAPIKeyRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	key, keyID, hash, err := apikey.Generate(APIKeyPrefix)
+	if err != nil {
+		logger.Error("apikey.Generate error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+	record := &model.APIKey{
+		KeyID:      keyID,
+		Name:       form.Name,
+		Owner:      form.Owner, // Note: the owner can be set by the uid of jwt claims, e.g. claims, _ := middleware.GetClaims(c)
+		SecretHash: hash,
+		Scopes:     strings.Join(form.Scopes, ","),
+	}
+	if form.ExpiresInDays > 0 {
+		expiredAt := time.Now().AddDate(0, 0, form.ExpiresInDays)
+		record.ExpiredAt = &expiredAt
+	}
+
+	ctx := middleware.WrapCtx(c)
+	err = h.iDao.Create(ctx, record)
+	if err != nil {
+		logger.Error("Create error", logger.Err(err), logger.String("keyID", keyID), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+
+	response.Success(c, gin.H{"keyID": keyID, "key": key})
+}
+
+// Rotate generate a new secret of api key
+// @Summary Rotate an api key
+// @Description Generates a new secret of the api key, the key id is unchanged, the previous key is still valid in the grace period.
+// @Tags apiKey
+// @Accept json
+// @Produce json
+// @Param keyID path string true "key id"
+// @Param data body types.RotateAPIKeyRequest true "rotation parameters"
+// @Success 200 {object} types.RotateAPIKeyReply{}
+// @Router /api/v1/apiKey/{keyID}/rotate [post]
+// @Security BearerAuth
+func (h *apiKeyHandler) Rotate(c *gin.Context) {
+	keyID := c.Param("keyID")
+	form := &types.RotateAPIKeyRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	key, hash, err := apikey.Rotate(APIKeyPrefix, keyID)
+	if err != nil {
+		logger.Warn("apikey.Rotate error", logger.Err(err), logger.String("keyID", keyID), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	err = h.iDao.Rotate(ctx, keyID, hash, time.Duration(form.GracePeriodSeconds)*time.Second)
+	if err != nil {
+		if errors.Is(err, database.ErrRecordNotFound) {
+			logger.Warn("Rotate not found", logger.Err(err), logger.String("keyID", keyID), middleware.GCtxRequestIDField(c))
+			response.Error(c, ecode.NotFound)
+		} else {
+			logger.Error("Rotate error", logger.Err(err), logger.String("keyID", keyID), middleware.GCtxRequestIDField(c))
+			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		}
+		return
+	}
+
+	response.Success(c, gin.H{"keyID": keyID, "key": key})
+}
+
+// Revoke an api key
+// @Summary Revoke an api key
+// @Description Revokes the api key, it can not be used anymore.
+// @Tags apiKey
+// @Accept json
+// @Produce json
+// @Param keyID path string true "key id"
+// @Success 200 {object} types.RevokeAPIKeyReply{}
+// @Router /api/v1/apiKey/{keyID} [delete]
+// @Security BearerAuth
+func (h *apiKeyHandler) Revoke(c *gin.Context) {
+	keyID := c.Param("keyID")
+
+	ctx := middleware.WrapCtx(c)
+	err := h.iDao.Revoke(ctx, keyID)
+	if err != nil {
+		if errors.Is(err, database.ErrRecordNotFound) {
+			logger.Warn("Revoke not found", logger.Err(err), logger.String("keyID", keyID), middleware.GCtxRequestIDField(c))
+			response.Error(c, ecode.NotFound)
+		} else {
+			logger.Error("Revoke error", logger.Err(err), logger.String("keyID", keyID), middleware.GCtxRequestIDField(c))
+			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		}
+		return
+	}
+
+	response.Success(c)
+}
+
+// List get the api keys of owner
+// @Summary Get the api keys of owner
+// @Description Returns the api keys of the owner, all api keys are returned if owner is empty, the secrets are not included.
+// @Tags apiKey
+// @Accept json
+// @Produce json
+// @Param owner query string false "owner of key"
+// @Success 200 {object} types.ListAPIKeysReply{}
+// @Router /api/v1/apiKey/list [get]
+// @Security BearerAuth
+func (h *apiKeyHandler) List(c *gin.Context) {
+	owner := c.Query("owner")
+
+	ctx := middleware.WrapCtx(c)
+	records, err := h.iDao.ListByOwner(ctx, owner)
+	if err != nil {
+		logger.Error("ListByOwner error", logger.Err(err), logger.String("owner", owner), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+
+	data := make([]*types.APIKeyObjDetail, 0, len(records))
+	for _, record := range records {
+		detail := &types.APIKeyObjDetail{
+			KeyID:     record.KeyID,
+			Name:      record.Name,
+			Owner:     record.Owner,
+			Scopes:    []string{},
+			ExpiredAt: record.ExpiredAt,
+			RevokedAt: record.RevokedAt,
+			CreatedAt: record.CreatedAt,
+		}
+		if record.Scopes != "" {
+			detail.Scopes = strings.Split(record.Scopes, ",")
+		}
+		data = append(data, detail)
+	}
+
+	response.Success(c, gin.H{"apiKeys": data})
+}
+
+// Verify return the api key of request, it is used to check whether the api key is valid
+// @Summary Verify the api key of request
+// @Description Returns the key id, owner and scopes of the api key in the header X-API-Key.
+// @Tags apiKey
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "api key"
+// @Success 200 {object} types.VerifyAPIKeyReply{}
+// @Router /public/v1/apiKey/verify [get]
+func (h *apiKeyHandler) Verify(c *gin.Context) {
+	key, ok := apikey.FromContext(c)
+	if !ok {
+		response.Error(c, ecode.Unauthorized)
+		return
+	}
+
+	response.Success(c, gin.H{"keyID": key.KeyID, "owner": key.Owner, "scopes": key.Scopes})
+}
+`
+
+	// apiKeyRouterCode the router of api key management and the public api authenticated by api key
+	apiKeyRouterCode = `package routers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/go-dev-frame/sponge/pkg/gin/apikey"
+
+	"moduleNameExample/internal/dao"
+	"moduleNameExample/internal/database"
+	"moduleNameExample/internal/handler"
+)
+
+// the routes of public api, all of them are authenticated by api key, the route prefix is /public/v1
+// example:
+//
+//	publicAPIV1RouterFns = append(publicAPIV1RouterFns, func(group *gin.RouterGroup) {
+//		group.GET("/orders", h.ListOrders)
+//		group.POST("/orders", apikey.RequireScopes("orders:write"), h.CreateOrder)
+//	})
+var publicAPIV1RouterFns []func(r *gin.RouterGroup)
+
+func init() {
+	apiV1RouterFns = append(apiV1RouterFns, func(group *gin.RouterGroup) {
+		apiKeyRouter(group, handler.NewAPIKeyHandler())
+	})
+
+	engineRouterFns = append(engineRouterFns, func(r *gin.Engine) {
+		h := handler.NewAPIKeyHandler()
+		store := dao.NewAPIKeyStore(dao.NewAPIKeyDao(database.GetDB()))
+		group := r.Group("/public/v1", apikey.Auth(store,
+			apikey.WithPrefix(handler.APIKeyPrefix),
+			//apikey.WithHeader("X-API-Key"), // default X-API-Key, the header "Authorization: ApiKey <key>" is also supported
+			//apikey.WithScopes("read"), // the scopes required by all public api
+		))
+
+		group.GET("/apiKey/verify", h.Verify) // [get] /public/v1/apiKey/verify
+		for _, fn := range publicAPIV1RouterFns {
+			fn(group)
+		}
+	})
+}
+
+func apiKeyRouter(group *gin.RouterGroup, h handler.APIKeyHandler) {
+	g := group.Group("/apiKey")
+
+	// the api keys are managed by the administrators or the owners, the routes must be authenticated,
+	// JWT authentication reference: https://go-sponge.com/component/transport/gin.html#jwt-authorization-middleware
+	//g.Use(middleware.Auth())
+
+	g.POST("/", h.Create)              // [post] /api/v1/apiKey
+	g.POST("/:keyID/rotate", h.Rotate) // [post] /api/v1/apiKey/:keyID/rotate
+	g.DELETE("/:keyID", h.Revoke)      // [delete] /api/v1/apiKey/:keyID
+	g.GET("/list", h.List)             // [get] /api/v1/apiKey/list
+}
+`
+
+	// the migration files of api key table, the key is the db driver
+	apiKeyMigrationUpCodes = map[string]string{
+		DBDriverMysql: "CREATE TABLE IF NOT EXISTS `api_key` (\n" +
+			"  `id` bigint unsigned NOT NULL AUTO_INCREMENT,\n" +
+			"  `key_id` varchar(32) NOT NULL COMMENT 'public id of key',\n" +
+			"  `name` varchar(64) NOT NULL DEFAULT '' COMMENT 'name of key',\n" +
+			"  `owner` varchar(64) NOT NULL DEFAULT '' COMMENT 'owner of key, e.g. user id or app id',\n" +
+			"  `secret_hash` char(64) NOT NULL COMMENT 'sha256 of secret',\n" +
+			"  `prev_secret_hash` char(64) NOT NULL DEFAULT '' COMMENT 'sha256 of previous secret, it is valid until prev_expired_at after rotation',\n" +
+			"  `prev_expired_at` datetime DEFAULT NULL COMMENT 'expiration time of previous secret',\n" +
+			"  `scopes` varchar(255) NOT NULL DEFAULT '' COMMENT 'scopes separated by commas, * means all scopes',\n" +
+			"  `expired_at` datetime DEFAULT NULL COMMENT 'expiration time, null means never expires',\n" +
+			"  `revoked_at` datetime DEFAULT NULL COMMENT 'revocation time',\n" +
+			"  `created_at` datetime DEFAULT NULL,\n" +
+			"  `updated_at` datetime DEFAULT NULL,\n" +
+			"  PRIMARY KEY (`id`),\n" +
+			"  UNIQUE KEY `idx_api_key_key_id` (`key_id`),\n" +
+			"  KEY `idx_api_key_owner` (`owner`)\n" +
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='api keys of public api';\n",
+
+		DBDriverPostgresql: `CREATE TABLE IF NOT EXISTS api_key (
+  id bigserial PRIMARY KEY,
+  key_id varchar(32) NOT NULL,
+  name varchar(64) NOT NULL DEFAULT '',
+  owner varchar(64) NOT NULL DEFAULT '',
+  secret_hash char(64) NOT NULL,
+  prev_secret_hash char(64) NOT NULL DEFAULT '',
+  prev_expired_at timestamptz,
+  scopes varchar(255) NOT NULL DEFAULT '',
+  expired_at timestamptz,
+  revoked_at timestamptz,
+  created_at timestamptz,
+  updated_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_key_key_id ON api_key (key_id);
+CREATE INDEX IF NOT EXISTS idx_api_key_owner ON api_key (owner);
+`,
+
+		DBDriverSqlite: `CREATE TABLE IF NOT EXISTS api_key (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  key_id varchar(32) NOT NULL,
+  name varchar(64) NOT NULL DEFAULT '',
+  owner varchar(64) NOT NULL DEFAULT '',
+  secret_hash char(64) NOT NULL,
+  prev_secret_hash char(64) NOT NULL DEFAULT '',
+  prev_expired_at datetime,
+  scopes varchar(255) NOT NULL DEFAULT '',
+  expired_at datetime,
+  revoked_at datetime,
+  created_at datetime,
+  updated_at datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_key_key_id ON api_key (key_id);
+CREATE INDEX IF NOT EXISTS idx_api_key_owner ON api_key (owner);
+`,
+	}
+
+	apiKeyMigrationDownCode = "DROP TABLE IF EXISTS api_key;\n"
+)
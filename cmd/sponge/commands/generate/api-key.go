@@ -0,0 +1,59 @@
+package generate
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// generateAPIKey generate the api key management code to the server directory, includes the model, dao,
+// handler, the migration files of table api_key, and the router of management api and public api, the
+// public api under /public/v1 are authenticated by api key middleware of pkg/gin/apikey.
+func generateAPIKey(dbDriver string, moduleName string, serverName string, suitedMonoRepo bool, outPath string) error {
+	dbDriver = strings.ToLower(dbDriver)
+	if dbDriver == DBDriverTidb {
+		dbDriver = DBDriverMysql
+	}
+	migrationUpCode, ok := apiKeyMigrationUpCodes[dbDriver]
+	if !ok {
+		return fmt.Errorf("the api key does not support %s", dbDriver)
+	}
+
+	importPath := moduleName
+	if suitedMonoRepo {
+		importPath += "/" + serverName
+	}
+
+	files := map[string]string{
+		"internal/model/apiKey.go":       apiKeyModelCode,
+		"internal/dao/apiKey.go":         apiKeyDaoCode,
+		"internal/types/apiKey_types.go": apiKeyTypesCode,
+		"internal/handler/apiKey.go":     apiKeyHandlerCode,
+		"internal/routers/apiKey.go":     apiKeyRouterCode,
+	}
+	for file, content := range files {
+		content = strings.ReplaceAll(content, "moduleNameExample", importPath)
+		if err := saveCodeFile(filepath.Join(outPath, file), []byte(content), false); err != nil {
+			return err
+		}
+	}
+
+	// the migration files are generated only once, the table is created when the migrations are applied
+	migrationDir := filepath.Join(outPath, "internal/database/migrations")
+	if existed, _ := filepath.Glob(filepath.Join(migrationDir, "*_create_api_key.up.sql")); len(existed) > 0 {
+		return nil
+	}
+	prefix := filepath.Join(migrationDir, time.Now().Format("20060102150405")+"_create_api_key")
+	if err := saveCodeFile(prefix+".up.sql", []byte(migrationUpCode), true); err != nil {
+		return err
+	}
+	return saveCodeFile(prefix+".down.sql", []byte(apiKeyMigrationDownCode), true)
+}
+
+// the usage tip of api key
+func apiKeyTip(number int) string {
+	return fmt.Sprintf(`
+  %d. apply the migration file of table api_key by "make migrate-up", issue an api key by POST /api/v1/apiKey, the public api
+     registered in "publicAPIV1RouterFns" of "internal/routers/apiKey.go" require the header "X-API-Key: <key>".`, number)
+}
@@ -0,0 +1,219 @@
+package generate
+
+// the placeholders in the templates are replaced by generateCIFiles
+var (
+	githubActionsTmpl = `# ci/cd pipeline of serverNameExample, the environment to deploy is determined by the branch or tag:
+#   develop branch --> dev, tag test-x.y.z --> test, tag vx.y.z --> prod.
+# set the following secrets and variables in the environments (dev, test, prod) of github repository settings:
+#   vars.REPO_HOST: image repository address, default is repo-addr-example
+#   secrets.REPO_USERNAME, secrets.REPO_PASSWORD: account of the image repository
+#   secrets.KUBE_CONFIG: base64 encoded kubeconfig of the k8s cluster, e.g. cat ~/.kube/config | base64 -w 0
+name: serverNameExample
+
+on:
+  push:
+    branches: [ develop ]
+    tags: [ 'v*.*.*', 'test-*.*.*' ]
+    paths: [ 'service-dir-example/**', 'go.mod', 'go.sum' ]
+  pull_request:
+    paths: [ 'service-dir-example/**', 'go.mod', 'go.sum' ]
+
+defaults:
+  run:
+    working-directory: service-dir-example
+
+jobs:
+  check:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - uses: actions/setup-go@v5
+        with:
+          go-version-file: go.mod
+
+      - name: Check code
+        run: |
+          go install github.com/golangci/golangci-lint/cmd/golangci-lint@latest
+          make ci-lint
+
+      - name: Unit testing
+        run: make test
+
+      - name: Compile code
+        run: make build
+
+  deploy:
+    needs: check
+    if: github.event_name == 'push'
+    runs-on: ubuntu-latest
+    environment: ${{ startsWith(github.ref_name, 'v') && 'prod' || (startsWith(github.ref_name, 'test-') && 'test' || 'dev') }}
+    env:
+      REPO_HOST: ${{ vars.REPO_HOST || 'repo-addr-example' }}
+      IMAGE_NAME: project-name-example/server-name-example
+    steps:
+      - uses: actions/checkout@v4
+      - uses: actions/setup-go@v5
+        with:
+          go-version-file: go.mod
+
+      - name: Set environment
+        run: |
+          if [[ "${GITHUB_REF_NAME}" =~ ^v[0-9]+\.[0-9]+\.[0-9]+ ]]; then
+            echo "DEPLOY_ENV=prod" >> $GITHUB_ENV
+            echo "TAG=${GITHUB_REF_NAME}" >> $GITHUB_ENV
+          elif [[ "${GITHUB_REF_NAME}" =~ ^test-[0-9]+\.[0-9]+\.[0-9]+ ]]; then
+            echo "DEPLOY_ENV=test" >> $GITHUB_ENV
+            echo "TAG=${GITHUB_REF_NAME}" >> $GITHUB_ENV
+          else
+            echo "DEPLOY_ENV=dev" >> $GITHUB_ENV
+            echo "TAG=dev-${GITHUB_SHA::8}" >> $GITHUB_ENV
+          fi
+
+      - name: Build and push image
+        run: |
+          echo "${{ secrets.REPO_PASSWORD }}" | docker login "${REPO_HOST%%/*}" -u "${{ secrets.REPO_USERNAME }}" --password-stdin
+          make image-build REPO_HOST=${REPO_HOST} TAG=${TAG}
+          docker push ${REPO_HOST}/${IMAGE_NAME}:${TAG}
+
+      - name: Deploy to k8s
+        run: |
+          mkdir -p $HOME/.kube && echo "${{ secrets.KUBE_CONFIG }}" | base64 -d > $HOME/.kube/config
+          cd deployments/kustomize/overlays/${DEPLOY_ENV}
+          kustomize edit set image image-name-example=${REPO_HOST}/${IMAGE_NAME}:${TAG}
+          kubectl apply -k .
+          kubectl rollout status deployment/server-name-example-dm -n project-name-example --timeout=300s
+`
+
+	gitlabCITmpl = `# ci/cd pipeline of serverNameExample, the environment to deploy is determined by the branch or tag:
+#   develop branch --> dev, tag test-x.y.z --> test, tag vx.y.z --> prod.
+# set the following variables in the CI/CD settings of gitlab project, scope them to the environments (dev, test, prod):
+#   REPO_HOST: image repository address, default is repo-addr-example
+#   REPO_USERNAME, REPO_PASSWORD: account of the image repository
+#   KUBE_CONFIG: variable of type File, the kubeconfig of the k8s cluster
+
+variables:
+  SERVICE_DIR: service-dir-example
+  REPO_HOST: repo-addr-example
+  IMAGE_NAME: project-name-example/server-name-example
+
+workflow:
+  rules:
+    - if: $CI_COMMIT_TAG =~ /^v\d+\.\d+\.\d+/
+      variables:
+        DEPLOY_ENV: prod
+        TAG: $CI_COMMIT_TAG
+    - if: $CI_COMMIT_TAG =~ /^test-\d+\.\d+\.\d+/
+      variables:
+        DEPLOY_ENV: test
+        TAG: $CI_COMMIT_TAG
+    - if: $CI_COMMIT_BRANCH == "develop"
+      variables:
+        DEPLOY_ENV: dev
+        TAG: dev-$CI_COMMIT_SHORT_SHA
+    - if: $CI_PIPELINE_SOURCE == "merge_request_event"
+
+stages:
+  - check
+  - build
+  - deploy
+
+.go-job:
+  image: golang:1.23
+  before_script:
+    - cd $SERVICE_DIR
+
+serverNameExample-lint:
+  extends: .go-job
+  stage: check
+  script:
+    - go install github.com/golangci/golangci-lint/cmd/golangci-lint@latest
+    - make ci-lint
+
+serverNameExample-test:
+  extends: .go-job
+  stage: check
+  script:
+    - make test
+
+serverNameExample-image:
+  extends: .go-job
+  stage: build
+  services:
+    - docker:dind
+  variables:
+    DOCKER_HOST: tcp://docker:2375
+    DOCKER_TLS_CERTDIR: ""
+  rules:
+    - if: $DEPLOY_ENV
+  environment:
+    name: $DEPLOY_ENV
+    action: prepare
+  script:
+    - apt-get update && apt-get install -y docker.io
+    - echo "$REPO_PASSWORD" | docker login "${REPO_HOST%%/*}" -u "$REPO_USERNAME" --password-stdin
+    - make image-build REPO_HOST=${REPO_HOST} TAG=${TAG}
+    - docker push ${REPO_HOST}/${IMAGE_NAME}:${TAG}
+
+serverNameExample-deploy:
+  stage: deploy
+  image:
+    name: alpine/k8s:1.30.2
+    entrypoint: [""]
+  rules:
+    - if: $DEPLOY_ENV
+  environment:
+    name: $DEPLOY_ENV
+  script:
+    - export KUBECONFIG=$KUBE_CONFIG
+    - cd $SERVICE_DIR/deployments/kustomize/overlays/$DEPLOY_ENV
+    - kustomize edit set image image-name-example=${REPO_HOST}/${IMAGE_NAME}:${TAG}
+    - kubectl apply -k .
+    - kubectl rollout status deployment/server-name-example-dm -n project-name-example --timeout=300s
+`
+
+	kustomizeBaseTmpl = `# kustomize base of serverNameExample, the overlays of environments are in the directory deployments/kustomize/overlays
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+`
+
+	kustomizeOverlayTmpl = `# the env-example environment of serverNameExample, each environment is deployed to its own k8s cluster,
+# if multiple environments are deployed to one cluster, set different namespaces, e.g. namespace: project-name-example-env-example
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+  - ../../../kubernetes
+labels:
+  - pairs:
+      env: env-example
+replicas:
+  - name: server-name-example-dm
+    count: replicas-example
+# the image tag is set by ci/cd pipeline, e.g. kustomize edit set image image-name-example=image-name-example:v1.0.0
+images:
+  - name: image-name-example
+    newTag: latest
+`
+
+	kustomizeReadmeTmpl = `## kustomize
+
+The overlays of environments (dev, test, prod) are based on the k8s files in the directory deployments/kubernetes,
+they are deployed by the ci/cd pipeline automatically, you can also deploy them manually.
+
+<br>
+
+Deploy the dev environment, replace the image tag with the actual tag:
+
+` + "```bash" + `
+cd deployments/kustomize/overlays/dev
+kustomize edit set image image-name-example=image-name-example:latest
+kubectl apply -k .
+` + "```" + `
+
+<br>
+
+View the rendered k8s resources of the environment:
+
+> kubectl kustomize deployments/kustomize/overlays/prod
+`
+)
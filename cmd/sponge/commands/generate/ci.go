@@ -0,0 +1,102 @@
+package generate
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/huandu/xstrings"
+)
+
+const (
+	ciGitHub = "github"
+	ciGitLab = "gitlab"
+)
+
+var ciEnvironments = []struct {
+	name     string
+	replicas int
+}{
+	{"dev", 1},
+	{"test", 1},
+	{"prod", 2},
+}
+
+func checkCIType(ciType string) error {
+	switch ciType {
+	case "", ciGitHub, ciGitLab:
+		return nil
+	}
+	return fmt.Errorf("unsupported ci type '%s', only github and gitlab are supported", ciType)
+}
+
+// generateCIFiles generate the ci/cd pipeline file and the kustomize overlays of environments, the pipeline
+// checks, tests and builds the code, pushes the image, and deploys it to the environment of the branch or tag,
+// it does nothing if ciType is empty.
+func generateCIFiles(ciType string, serverName string, projectName string, repoAddr string, suitedMonoRepo bool, outPath string) error {
+	if ciType == "" {
+		return nil
+	}
+
+	serviceDir, servicePaths, rootDir := ".", "**", outPath
+	if suitedMonoRepo {
+		serviceDir, rootDir = filepath.Base(outPath), filepath.Dir(outPath)
+		servicePaths = serviceDir + "/**"
+	}
+	serverNameKebab := xstrings.ToKebabCase(serverName)
+	r := strings.NewReplacer(
+		"image-name-example", repoAddr+"/"+projectName+"/"+serverNameKebab, // image name in the k8s deployment file
+		"server-name-example", serverNameKebab,
+		"project-name-example", projectName,
+		"repo-addr-example", repoAddr,
+		"service-dir-example/**", servicePaths,
+		"service-dir-example", serviceDir,
+		"serverNameExample", serverName,
+	)
+
+	// pipeline file, the pipeline file of mono-repo is placed in the root directory of the repository
+	var err error
+	switch ciType {
+	case ciGitHub:
+		file := filepath.Join(rootDir, ".github", "workflows", serverName+".yml")
+		err = saveCodeFile(file, []byte(r.Replace(githubActionsTmpl)), false)
+	case ciGitLab:
+		err = saveCodeFile(filepath.Join(outPath, ".gitlab-ci.yml"), []byte(r.Replace(gitlabCITmpl)), false)
+	}
+	if err != nil {
+		return err
+	}
+
+	// kustomize base, includes all the k8s files of the service
+	k8sDir := filepath.Join(outPath, "deployments", "kubernetes")
+	k8sFiles, err := filepath.Glob(filepath.Join(k8sDir, "*.yml"))
+	if err != nil {
+		return err
+	}
+	base := kustomizeBaseTmpl
+	for _, file := range k8sFiles {
+		base += "  - " + filepath.Base(file) + "\n"
+	}
+	if err = saveCodeFile(filepath.Join(k8sDir, "kustomization.yaml"), []byte(base), false); err != nil {
+		return err
+	}
+
+	// kustomize overlays of environments
+	overlaysDir := filepath.Join(outPath, "deployments", "kustomize")
+	for _, env := range ciEnvironments {
+		content := strings.NewReplacer("env-example", env.name, "replicas-example", fmt.Sprint(env.replicas)).
+			Replace(r.Replace(kustomizeOverlayTmpl))
+		if err = saveCodeFile(filepath.Join(overlaysDir, "overlays", env.name, "kustomization.yaml"), []byte(content), false); err != nil {
+			return err
+		}
+	}
+	if err = saveCodeFile(filepath.Join(overlaysDir, "README.md"), []byte(r.Replace(kustomizeReadmeTmpl)), true); err != nil {
+		return err
+	}
+
+	if suitedMonoRepo && ciType == ciGitLab {
+		fmt.Printf("\nci tip: include the pipeline file in the .gitlab-ci.yml of the repository root directory:\n"+
+			"  include:\n    - local: %s/.gitlab-ci.yml\n", serviceDir)
+	}
+	return nil
+}
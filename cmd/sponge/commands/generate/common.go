@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"go/format"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -45,22 +46,25 @@ const (
 	DBDriverMongodb = "mongodb"
 
 	// code name
-	codeNameHTTP        = "http"
-	codeNameGRPC        = "grpc"
-	codeNameHTTPPb      = "http-pb"
-	codeNameGRPCPb      = "grpc-pb"
-	codeNameGRPCGW      = "grpc-gw-pb"
-	codeNameGRPCHTTP    = "grpc-http"
-	codeNameGRPCHTTPPb  = "grpc-http-pb"
-	codeNameHandler     = "handler"
-	codeNameHandlerPb   = "handler-pb"
-	codeNameService     = "service"
-	codeNameServiceHTTP = "service-handler"
-	codeNameDao         = "dao"
-	codeNameProtobuf    = "protobuf"
-	codeNameModel       = "model"
-	codeNameGRPCConn    = "grpc-conn"
-	codeNameCache       = "cache"
+	codeNameHTTP          = "http"
+	codeNameGRPC          = "grpc"
+	codeNameHTTPPb        = "http-pb"
+	codeNameGRPCPb        = "grpc-pb"
+	codeNameGRPCGW        = "grpc-gw-pb"
+	codeNameGRPCHTTP      = "grpc-http"
+	codeNameGRPCHTTPPb    = "grpc-http-pb"
+	codeNameGRPCGatewayPb = "grpc-gateway-pb"
+	codeNameHandler       = "handler"
+	codeNameHandlerPb     = "handler-pb"
+	codeNameService       = "service"
+	codeNameServiceHTTP   = "service-handler"
+	codeNameDao           = "dao"
+	codeNameProtobuf      = "protobuf"
+	codeNameModel         = "model"
+	codeNameGRPCConn      = "grpc-conn"
+	codeNameCache         = "cache"
+	codeNameWorker        = "worker"
+	codeNameCron          = "cron"
 
 	wellPrefix    = "## "
 	mgoSuffix     = ".mgo"
@@ -1019,16 +1023,29 @@ func SetSelectFiles(dbDriver string, selectFiles map[string][]string) error {
 	dbDriver = strings.ToLower(dbDriver)
 	switch dbDriver {
 	case DBDriverMysql, DBDriverTidb:
-		selectFiles["internal/database"] = []string{"init.go", "redis.go", "mysql.go"}
+		selectFiles["internal/database"] = []string{"init.go", "redis.go", "mysql.go", "migrate.go"}
 	case DBDriverPostgresql:
-		selectFiles["internal/database"] = []string{"init.go", "redis.go", "postgresql.go"}
+		selectFiles["internal/database"] = []string{"init.go", "redis.go", "postgresql.go", "migrate.go"}
 	case DBDriverSqlite:
-		selectFiles["internal/database"] = []string{"init.go", "redis.go", "sqlite.go"}
+		selectFiles["internal/database"] = []string{"init.go", "redis.go", "sqlite.go", "migrate.go"}
 	case DBDriverMongodb:
 		selectFiles["internal/database"] = []string{"init.go.mgo", "redis.go", "mongodb.go.mgo"}
+		return nil
 	default:
 		return errors.New("unsupported db driver: " + dbDriver)
 	}
+	// the migration files are embedded by migrate.go, the directory must not be empty
+	selectFiles["internal/database/migrations"] = []string{"README.md"}
+	return nil
+}
+
+// AddOutboxFile add the outbox relay code of the transactional outbox pattern to the selected files,
+// mongodb is not supported.
+func AddOutboxFile(dbDriver string, selectFiles map[string][]string) error {
+	if strings.ToLower(dbDriver) == DBDriverMongodb {
+		return errors.New("outbox is not supported for db driver " + dbDriver)
+	}
+	selectFiles["internal/database"] = append(selectFiles["internal/database"], "outbox.go")
 	return nil
 }
 
@@ -1079,3 +1096,27 @@ func getGRPCServiceFields() []replacer.Field {
 		},
 	}
 }
+
+// saveCodeFile save the generated code to file, go code is formatted, if the file already exists,
+// it is skipped when isSkipExists is true, otherwise it is saved as a new file with the suffix .gen<time>.
+func saveCodeFile(file string, content []byte, isSkipExists bool) error {
+	if gofile.IsExists(file) {
+		if isSkipExists {
+			return nil
+		}
+		file += ".gen" + time.Now().Format("20060102T150405")
+	}
+
+	if strings.HasSuffix(file, ".go") {
+		formatted, err := format.Source(content)
+		if err != nil {
+			return fmt.Errorf("format %s error: %v", file, err)
+		}
+		content = formatted
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0766); err != nil {
+		return err
+	}
+	return os.WriteFile(file, content, 0666)
+}
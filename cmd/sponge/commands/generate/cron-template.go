@@ -0,0 +1,48 @@
+package generate
+
+var (
+	// cronServerConfigCode the configuration of cron service
+	cronServerConfigCode = `# cron settings, the jobs are registered in internal/job, the settings of registered jobs
+# can be overridden by name in jobs
+cron:
+  httpPort: 8283            # port of prometheus metrics /metrics, health check /health and admin api /jobs, if 0, the http server is not started
+  adminToken: ""            # token of admin api, request header "Authorization: Bearer <token>", if empty, the admin api is not authenticated
+  shutdownTimeout: 30       # maximum time to wait for the running jobs when the service exits, unit(second)
+  jobs:
+    #- name: "userExampleStat"
+    #  schedule: "0 */10 * * * *"   # cron expression with seconds, e.g. "0 */10 * * * *" or "@every 10m", if empty, the registered schedule is used
+    #  timeout: 60                  # timeout of a run, unit(second), if 0, the registered timeout is used
+    #  paused: false                # whether the job is paused when the service starts, it can be resumed by admin api`
+
+	// cronJobCode the file of a job, jobNameExample and JobNameExample are replaced
+	cronJobCode = `package job
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-dev-frame/sponge/pkg/logger"
+)
+
+func init() {
+	register(Job{
+		Name:      "jobNameExample",
+		Schedule:  "0 */5 * * * *", // cron expression with seconds, e.g. "0 30 2 * * *" means 02:30 every day
+		Timeout:   time.Minute,
+		Singleton: true,
+		Fn:        runJobNameExample,
+	})
+}
+
+// runJobNameExample the job jobNameExample, if an error is returned, the run is recorded as failure,
+// the job is run again at the next scheduled time.
+func runJobNameExample(ctx context.Context) error {
+	logger.Info("run job", logger.String("job", "jobNameExample"))
+
+	// fill in the business logic code here, e.g. count the data of yesterday and save it to database,
+	// the job should check ctx.Done() in long loops, so that it can be stopped by the timeout
+
+	return nil
+}
+`
+)
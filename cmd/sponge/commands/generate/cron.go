@@ -0,0 +1,321 @@
+package generate
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/huandu/xstrings"
+	"github.com/spf13/cobra"
+
+	"github.com/go-dev-frame/sponge/pkg/replacer"
+)
+
+// CronCommand generate cron service code
+func CronCommand() *cobra.Command {
+	var (
+		moduleName  string // module name for go.mod
+		serverName  string // server name
+		projectName string // project name for deployment name
+		repoAddr    string // image repo address
+		outPath     string // output directory
+		jobs        string // job names
+
+		suitedMonoRepo bool   // whether the generated code is suitable for mono-repo
+		ciType         string // ci/cd pipeline type, support github, gitlab
+	)
+
+	//nolint
+	cmd := &cobra.Command{
+		Use:   "cron",
+		Short: "Generate cron service code that runs scheduled jobs",
+		Long:  "Generate cron service code that runs scheduled jobs, includes the job registry (name, schedule, timeout, singleton), the admin api of listing, triggering, pausing and resuming jobs, metrics and logging of each job.",
+		Example: color.HiBlackString(`  # Generate cron service code.
+  sponge micro cron --module-name=yourModuleName --server-name=yourServerName --project-name=yourProjectName --jobs=statDaily,cleanExpiredOrders
+
+  # Generate cron service code and specify the output directory, Note: code generation will be canceled when the latest generated file already exists.
+  sponge micro cron --module-name=yourModuleName --server-name=yourServerName --project-name=yourProjectName --jobs=statDaily --out=./yourServerDir
+
+  # Generate cron service code and specify the docker image repository address.
+  sponge micro cron --module-name=yourModuleName --server-name=yourServerName --project-name=yourProjectName --repo-addr=192.168.3.37:9443/user-name --jobs=statDaily
+
+  # Generate code with the ci/cd pipeline of github actions (or gitlab ci), the environments are deployed to k8s by kustomize overlays.
+  sponge micro cron --module-name=yourModuleName --server-name=yourServerName --project-name=yourProjectName --repo-addr=192.168.3.37:9443/user-name --jobs=statDaily --ci=github
+
+  # If you want the generated code to suited to mono-repo, you need to set the parameter --suited-mono-repo=true`),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkCIType(ciType); err != nil {
+				return err
+			}
+			jobNames, err := parseNameList(jobs, "jobs", "sponge micro cron")
+			if err != nil {
+				return err
+			}
+
+			projectName, serverName, err = convertProjectAndServerName(projectName, serverName)
+			if err != nil {
+				return err
+			}
+
+			if suitedMonoRepo {
+				outPath = changeOutPath(outPath, serverName)
+			}
+
+			g := &cronGenerator{
+				moduleName:     moduleName,
+				serverName:     serverName,
+				projectName:    projectName,
+				repoAddr:       repoAddr,
+				outPath:        outPath,
+				jobs:           jobNames,
+				suitedMonoRepo: suitedMonoRepo,
+			}
+			outPath, err = g.generateCode()
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf(`
+using help:
+  1. fill in the business logic code and schedules of the jobs in the directory "internal/job", one file per job,
+     the schedules and timeouts can be overridden in the configuration file "configs/%s.yml".
+  2. compile and run server: make run
+  3. list the jobs by GET http://localhost:8283/jobs, trigger, pause or resume a job by
+     POST http://localhost:8283/jobs/<name>/trigger, /pause or /resume, and view the metrics of jobs at /metrics.
+
+`, serverName)
+			fmt.Printf("generate %s's cron service code successfully, out = %s\n", serverName, outPath)
+
+			_ = generateConfigmap(serverName, outPath)
+			return generateCIFiles(ciType, serverName, projectName, repoAddr, suitedMonoRepo, outPath)
+		},
+	}
+
+	cmd.Flags().StringVarP(&moduleName, "module-name", "m", "", "module-name is the name of the module in the go.mod file")
+	_ = cmd.MarkFlagRequired("module-name")
+	cmd.Flags().StringVarP(&serverName, "server-name", "s", "", "server name")
+	_ = cmd.MarkFlagRequired("server-name")
+	cmd.Flags().StringVarP(&projectName, "project-name", "p", "", "project name")
+	_ = cmd.MarkFlagRequired("project-name")
+	cmd.Flags().StringVarP(&jobs, "jobs", "j", "", "job names, multiple names separated by commas, a job file is generated for each job")
+	_ = cmd.MarkFlagRequired("jobs")
+	cmd.Flags().BoolVarP(&suitedMonoRepo, "suited-mono-repo", "l", false, "whether the generated code is suitable for mono-repo")
+	cmd.Flags().StringVarP(&repoAddr, "repo-addr", "r", "", "docker image repository address, excluding http and repository names")
+	cmd.Flags().StringVarP(&ciType, "ci", "", "", "generate the ci/cd pipeline file and kustomize overlays of environments (dev, test, prod), support github, gitlab")
+	cmd.Flags().StringVarP(&outPath, "out", "o", "", "output directory, default is ./serverName_cron_<time>, if suited-mono-repo = true, output directory is serverName")
+
+	return cmd
+}
+
+type cronGenerator struct {
+	moduleName     string
+	serverName     string
+	projectName    string
+	repoAddr       string
+	outPath        string
+	jobs           []string
+	suitedMonoRepo bool
+}
+
+func (g *cronGenerator) generateCode() (string, error) {
+	subTplName := codeNameCron
+	r, _ := replacer.New(SpongeDir)
+	if r == nil {
+		return "", errors.New("replacer is nil")
+	}
+
+	// specify the subdirectory and files
+	subDirs := []string{
+		"cmd/serverNameExample_cronExample", "sponge/configs", "sponge/deployments", "sponge/scripts",
+	}
+	subFiles := []string{
+		"sponge/.gitignore", "sponge/.golangci.yml", "sponge/go.mod", "sponge/go.sum",
+		"sponge/Jenkinsfile", "sponge/Makefile-for-http", "sponge/README.md",
+	}
+	if g.suitedMonoRepo {
+		subFiles = removeElements(subFiles, "sponge/go.mod", "sponge/go.sum")
+	}
+
+	selectFiles := map[string][]string{
+		"internal/config": {
+			"serverNameExample.go",
+		},
+		"internal/job": {
+			"job.go", "job_test.go",
+		},
+		"internal/server": {
+			"cron.go", "cron_option.go", "cron_test.go",
+		},
+	}
+	subFiles = append(subFiles, getSubFiles(selectFiles, nil)...)
+
+	// ignore some directories and files
+	ignoreDirs := []string{"cmd/sponge"}
+	ignoreFiles := []string{"scripts/image-rpc-test.sh", "scripts/patch.sh", "scripts/protoc.sh",
+		"scripts/proto-doc.sh", "scripts/swag-docs.sh", "configs/serverNameExample_cc.yml"}
+
+	r.SetSubDirsAndFiles(subDirs, subFiles...)
+	r.SetIgnoreSubDirs(ignoreDirs...)
+	r.SetIgnoreSubFiles(ignoreFiles...)
+	_ = r.SetOutputDir(g.outPath, g.serverName+"_"+subTplName)
+	fields := g.addFields(r)
+	r.SetReplacementFields(fields)
+	if err := r.SaveFiles(); err != nil {
+		return "", err
+	}
+
+	// generate a file for each job
+	for _, job := range g.jobs {
+		name := strings.NewReplacer(".", "_", "-", "_").Replace(job)
+		funcName := xstrings.ToCamelCase(name)
+		content := strings.ReplaceAll(cronJobCode, "JobNameExample", funcName)
+		content = strings.ReplaceAll(content, "jobNameExample", job)
+		file := filepath.Join(r.GetOutputDir(), "internal/job", xstrings.ToSnakeCase(name)+".go")
+		if err := saveCodeFile(file, []byte(content), false); err != nil {
+			return "", err
+		}
+	}
+
+	_ = saveGenInfo(g.moduleName, g.serverName, g.suitedMonoRepo, r.GetOutputDir())
+
+	return r.GetOutputDir(), nil
+}
+
+func (g *cronGenerator) addFields(r replacer.Replacer) []replacer.Field {
+	repoHost, _ := parseImageRepoAddr(g.repoAddr)
+
+	var fields []replacer.Field
+	fields = append(fields, deleteFieldsMark(r, dockerFile, wellStartMark, wellEndMark)...)
+	fields = append(fields, deleteFieldsMark(r, dockerFileBuild, wellStartMark, wellEndMark)...)
+	fields = append(fields, deleteFieldsMark(r, dockerComposeFile, wellStartMark, wellEndMark)...)
+	fields = append(fields, deleteFieldsMark(r, k8sDeploymentFile, wellStartMark, wellEndMark)...)
+	fields = append(fields, deleteFieldsMark(r, k8sServiceFile, wellStartMark, wellEndMark)...)
+	fields = append(fields, deleteFieldsMark(r, imageBuildFile, wellStartMark, wellEndMark)...)
+	fields = append(fields, deleteFieldsMark(r, imageBuildLocalFile, wellStartMark, wellEndMark)...)
+	fields = append(fields, deleteFieldsMark(r, gitIgnoreFile, wellStartMark, wellEndMark)...)
+	fields = append(fields, deleteAllFieldsMark(r, protoShellFile, wellStartMark, wellEndMark)...)
+	fields = append(fields, deleteAllFieldsMark(r, appConfigFile, wellStartMark, wellEndMark)...)
+	fields = append(fields, replaceFileContentMark(r, readmeFile,
+		getReadmeContent(g.moduleName, g.serverName, codeNameCron, "", g.suitedMonoRepo))...)
+	fields = append(fields, []replacer.Field{
+		{ // replace the configuration of the *.yml file
+			Old: appConfigFileMark,
+			New: cronServerConfigCode,
+		},
+		{ // replace the configuration of the *.yml file
+			Old: appConfigFileMark2,
+			New: "",
+		},
+		{ // replace the contents of the Dockerfile file
+			Old: dockerFileMark,
+			New: dockerFileMetricsCode,
+		},
+		{ // replace the contents of the Dockerfile_build file
+			Old: dockerFileBuildMark,
+			New: dockerFileBuildMetricsCode,
+		},
+		{ // replace the contents of the image-build.sh file
+			Old: imageBuildFileMark,
+			New: imageBuildFileHTTPCode,
+		},
+		{ // replace the contents of the image-build-local.sh file
+			Old: imageBuildLocalFileMark,
+			New: imageBuildLocalFileHTTPCode,
+		},
+		{ // replace the contents of the docker-compose.yml file
+			Old: dockerComposeFileMark,
+			New: dockerComposeFileMetricsCode,
+		},
+		{ // replace the contents of the *-deployment.yml file
+			Old: k8sDeploymentFileMark,
+			New: k8sDeploymentFileMetricsCode,
+		},
+		{ // replace the contents of the *-svc.yml file
+			Old: k8sServiceFileMark,
+			New: k8sServiceFileMetricsCode,
+		},
+		{ // replace github.com/go-dev-frame/sponge/templates/sponge
+			Old: selfPackageName + "/" + r.GetSourcePath(),
+			New: g.moduleName,
+		},
+		{
+			Old: protoShellFileGRPCMark,
+			New: "",
+		},
+		{
+			Old: protoShellFileMark,
+			New: "",
+		},
+		{
+			Old: "github.com/go-dev-frame/sponge",
+			New: g.moduleName,
+		},
+		{
+			Old: g.moduleName + pkgPathSuffix,
+			New: "github.com/go-dev-frame/sponge/pkg",
+		},
+		{ // replace the sponge version of the go.mod file
+			Old: spongeTemplateVersionMark,
+			New: getLocalSpongeTemplateVersion(),
+		},
+		{
+			Old: defaultGoModVersion,
+			New: getLocalGoVersion(),
+		},
+		{
+			Old: defaultImageGoModVersion,
+			New: extractImageGoVersion(),
+		},
+		{
+			Old: "serverNameExample",
+			New: g.serverName,
+		},
+		// docker image and k8s deployment script replacement
+		{
+			Old: "server-name-example",
+			New: xstrings.ToKebabCase(g.serverName), // snake_case to kebab_case
+		},
+		// docker image and k8s deployment script replacement
+		{
+			Old: "project-name-example",
+			New: g.projectName,
+		},
+		{
+			Old: "projectNameExample",
+			New: g.projectName,
+		},
+		{
+			Old: "repo-addr-example",
+			New: g.repoAddr,
+		},
+		{
+			Old: "image-repo-host",
+			New: repoHost,
+		},
+		{
+			Old: "_cronExample",
+			New: "",
+		},
+		{
+			Old: "_mixExample",
+			New: "",
+		},
+		{
+			Old: "Makefile-for-http",
+			New: "Makefile",
+		},
+	}...)
+
+	fields = append(fields, getHTTPServiceFields()...)
+
+	if g.suitedMonoRepo {
+		fs := serverCodeFields(codeNameCron, g.moduleName, g.serverName)
+		fields = append(fields, fs...)
+	}
+
+	return fields
+}
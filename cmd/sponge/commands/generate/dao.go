@@ -29,6 +29,7 @@ func DaoCommand(parentName string) *cobra.Command {
 
 		serverName     string // server name
 		suitedMonoRepo bool   // whether the generated code is suitable for mono-repo
+		tenantColumn   string // tenant id column, if not empty, the records are scoped by tenant
 	)
 
 	cmd := &cobra.Command{
@@ -47,8 +48,14 @@ func DaoCommand(parentName string) *cobra.Command {
   # Generate dao code and specify the server directory, Note: code generation will be canceled when the latest generated file already exists.
   sponge %s dao --db-driver=mysql --db-dsn=root:123456@(192.168.3.37:3306)/test --db-table=user --out=./yourServerDir
 
+  # Generate dao code with multi-tenancy, the records are scoped by the tenant id column of table.
+  sponge %s dao --module-name=yourModuleName --db-driver=mysql --db-dsn=root:123456@(192.168.3.37:3306)/test --db-table=user --tenant-column=tenant_id
+
+  # Generate dao code with the associations of model by the foreign keys between tables.
+  sponge %s dao --module-name=yourModuleName --db-driver=mysql --db-dsn=root:123456@(192.168.3.37:3306)/test --db-table=user,order --relation
+
   # If you want the generated code to suited to mono-repo, you need to set the parameter --suited-mono-repo=true --server-name=yourServerName`,
-			parentName, parentName, parentName, parentName)),
+			parentName, parentName, parentName, parentName, parentName, parentName)),
 		SilenceErrors: true,
 		SilenceUsage:  true,
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -67,6 +74,9 @@ func DaoCommand(parentName string) *cobra.Command {
 				serverName = convertServerName(serverName)
 				outPath = changeOutPath(outPath, serverName)
 			}
+			if tenantColumn != "" && sqlArgs.DBDriver == DBDriverMongodb {
+				return errors.New("multi-tenancy (--tenant-column) does not support mongodb")
+			}
 
 			tableNames := strings.Split(dbTables, ",")
 			for count, tableName := range tableNames {
@@ -78,10 +88,14 @@ func DaoCommand(parentName string) *cobra.Command {
 					sqlArgs.IsEmbed = false
 				}
 				sqlArgs.DBTable = tableName
+				sqlArgs.RelationTables = dbTables
 				codes, err := sql2code.Generate(&sqlArgs)
 				if err != nil {
 					return err
 				}
+				if tenantColumn != "" && !hasModelColumn(codes[parser.CodeTypeModel], tenantColumn) {
+					return fmt.Errorf("tenant column %q not found in table %s", tenantColumn, tableName)
+				}
 
 				// control to generate the initialization db code only once
 				if count == 0 && isIncludeInitDB {
@@ -100,6 +114,7 @@ func DaoCommand(parentName string) *cobra.Command {
 					isEmbed:         sqlArgs.IsEmbed,
 					isExtendedAPI:   sqlArgs.IsExtendedAPI,
 					suitedMonoRepo:  suitedMonoRepo,
+					tenantColumn:    tenantColumn,
 				}
 				outPath, err = g.generateCode()
 				if err != nil {
@@ -131,6 +146,10 @@ using help:
 	cmd.Flags().IntVarP(&sqlArgs.JSONNamedType, "json-name-type", "j", 1, "json tags name type, 0:snake case, 1:camel case")
 	cmd.Flags().StringVarP(&outPath, "out", "o", "", "output directory, default is ./dao_<time>, "+flagTip("module-name"))
 	cmd.Flags().BoolVarP(&isIncludeInitDB, "include-init-db", "i", false, "if true, includes mysql and redis initialization code")
+	cmd.Flags().StringVarP(&tenantColumn, "tenant-column", "", "", "tenant id column of table, if set, the records are scoped by the tenant id of context, e.g. tenant_id")
+	cmd.Flags().StringVarP(&sqlArgs.EncryptColumns, "encrypt-columns", "", "", "columns encrypted at rest, multiple names separated by commas, the suffix :deterministic supports equality queries, e.g. phone,email:deterministic, register the plugin of pkg/sgorm/encrypt at startup")
+	cmd.Flags().StringVarP(&sqlArgs.IDGenerator, "id-generator", "", "", "generate the primary key before creating a record if it is empty, support ulid, ksuid (primary key of string type) and snowflake (primary key of bigint type), the worker id of snowflake can be allocated by pkg/krand/workerid")
+	cmd.Flags().BoolVarP(&sqlArgs.IsRelation, "relation", "", false, "generate the associations of model by the foreign keys between the tables of db-table, e.g. has one, has many, many to many, the associated records are preloaded by the dao methods with suffix WithPreload")
 
 	return cmd
 }
@@ -145,6 +164,7 @@ type daoGenerator struct {
 	isExtendedAPI   bool
 	serverName      string
 	suitedMonoRepo  bool
+	tenantColumn    string
 
 	fields []replacer.Field
 }
@@ -165,7 +185,7 @@ func (g *daoGenerator) generateCode() (string, error) {
 			"userExample.go", "userExample_test.go",
 		},
 		"internal/dao": {
-			"userExample.go", "userExample_test.go",
+			"userExample.go", "userExample_test.go", "userExample_integration_test.go",
 		},
 		"internal/model": {
 			"userExample.go",
@@ -254,6 +274,9 @@ func (g *daoGenerator) addFields(r replacer.Replacer) []replacer.Field {
 	fields = append(fields, deleteFieldsMark(r, daoFile, startMark, endMark)...)
 	fields = append(fields, deleteFieldsMark(r, daoMgoFile, startMark, endMark)...)
 	fields = append(fields, deleteFieldsMark(r, daoTestFile, startMark, endMark)...)
+	if g.tenantColumn != "" {
+		fields = append(fields, daoTenantFields(g.tenantColumn)...)
+	}
 	fields = append(fields, []replacer.Field{
 		{ // replace the contents of the model/userExample.go file
 			Old: modelFileMark,
@@ -301,7 +324,7 @@ func (g *daoGenerator) addFields(r replacer.Replacer) []replacer.Field {
 func daoExtendedAPI(r replacer.Replacer) (map[string][]string, []replacer.Field) {
 	replaceFiles := map[string][]string{
 		"internal/dao": {
-			"userExample.go.exp", "userExample_test.go.exp",
+			"userExample.go.exp", "userExample_test.go.exp", "userExample_integration_test.go",
 		},
 	}
 	var fields []replacer.Field
@@ -386,3 +409,50 @@ func commonDaoExtendedFields(r replacer.Replacer) []replacer.Field {
 
 	return fields
 }
+
+// register the tenant plugin in the constructor of dao, the records are scoped by the tenant id of context
+func daoTenantFields(column string) []replacer.Field {
+	return []replacer.Field{
+		{ // dao file
+			Old: `"github.com/go-dev-frame/sponge/pkg/sgorm/optlock"
+	"github.com/go-dev-frame/sponge/pkg/sgorm/query"`,
+			New: `"github.com/go-dev-frame/sponge/pkg/sgorm/optlock"
+	"github.com/go-dev-frame/sponge/pkg/sgorm/query"
+	"github.com/go-dev-frame/sponge/pkg/sgorm/tenant"`,
+		},
+		{
+			Old: "\tif xCache == nil {\n",
+			New: fmt.Sprintf(`	// the records are scoped by the tenant id of ctx, see tenant.NewContext and middleware.Tenant
+	_ = tenant.Register(db, tenant.WithColumn("%s"))
+	// the cache is keyed by id and shared by all tenants, so it is disabled
+	xCache = nil
+	if xCache == nil {
+`, column),
+		},
+		{ // dao test file
+			Old: `"github.com/go-dev-frame/sponge/pkg/sgorm/query"
+	"github.com/go-dev-frame/sponge/pkg/utils"
+	"github.com/stretchr/testify/assert"`,
+			New: `"github.com/go-dev-frame/sponge/pkg/sgorm/query"
+	"github.com/go-dev-frame/sponge/pkg/sgorm/tenant"
+	"github.com/go-dev-frame/sponge/pkg/utils"
+	"github.com/stretchr/testify/assert"`,
+		},
+		{
+			Old: `"github.com/go-dev-frame/sponge/pkg/gotest"
+	"github.com/go-dev-frame/sponge/pkg/utils"`,
+			New: `"github.com/go-dev-frame/sponge/pkg/gotest"
+	"github.com/go-dev-frame/sponge/pkg/sgorm/tenant"
+	"github.com/go-dev-frame/sponge/pkg/utils"`,
+		},
+		{
+			Old: "d.IDao = NewUserExampleDao(d.DB, c.ICache.(cache.UserExampleCache))\n",
+			New: "d.IDao = NewUserExampleDao(d.DB, c.ICache.(cache.UserExampleCache))\n" +
+				"\td.Ctx = tenant.SkipContext(d.Ctx) // the sql of mock is not scoped by tenant\n",
+		},
+	}
+}
+
+func hasModelColumn(modelCode string, column string) bool {
+	return strings.Contains(modelCode, "column:"+column+";") || strings.Contains(modelCode, "column:"+column+`"`)
+}
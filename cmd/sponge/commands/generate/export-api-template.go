@@ -0,0 +1,181 @@
+package generate
+
+var (
+	// exportAPIHandlerCode the handler of export api, it is rendered by exportAPITable.
+	exportAPIHandlerCode = `package handler
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/go-dev-frame/sponge/pkg/gin/export"
+	"github.com/go-dev-frame/sponge/pkg/gin/middleware"
+	"github.com/go-dev-frame/sponge/pkg/gin/response"
+	"github.com/go-dev-frame/sponge/pkg/logger"
+	"github.com/go-dev-frame/sponge/pkg/sgorm/query"
+
+	"{{.ImportPath}}/internal/database"
+	"{{.ImportPath}}/internal/ecode"
+	"{{.ImportPath}}/internal/model"
+)
+
+// the max number of rows exported at a time
+const {{.RouteName}}ExportMaxRows = 10000
+
+// the columns that can be exported and filtered, you can remove the sensitive columns here
+var {{.RouteName}}ExportColumns = []struct {
+	name   string // column name
+	header string // header of the exported file
+}{
+{{- range .Columns}}
+	{"{{.Name}}", {{printf "%q" .Header}}},
+{{- end}}
+}
+
+var _ {{.TableNameCamel}}ExportHandler = (*{{.RouteName}}ExportHandler)(nil)
+
+// {{.TableNameCamel}}ExportHandler defining the export handler interface
+type {{.TableNameCamel}}ExportHandler interface {
+	Export(c *gin.Context)
+}
+
+type {{.RouteName}}ExportHandler struct{}
+
+// New{{.TableNameCamel}}ExportHandler creating the export handler interface
+func New{{.TableNameCamel}}ExportHandler() {{.TableNameCamel}}ExportHandler {
+	return &{{.RouteName}}ExportHandler{}
+}
+
+// Export export the list of {{.RouteName}} to csv or xlsx file
+// @Summary Export the list of {{.RouteName}} to csv or xlsx file
+// @Description Streams the list of {{.RouteName}} filtered by the query conditions as csv or xlsx file, the number of rows is limited.
+// @Tags {{.RouteName}}
+// @Produce octet-stream
+// @Param format query string false "file format, csv (default) or xlsx"
+// @Param fields query string false "exported columns separated by commas, default is all columns, e.g. id,name"
+// @Param sort query string false "sort by columns separated by commas, the prefix - means descending, e.g. -id"
+// @Param limit query int false "max number of rows"
+// @Param conditions query string false "query conditions, json array of the columns of list api, e.g. [{\"name\":\"id\",\"exp\":\">\",\"value\":1}]"
+// @Success 200 {file} file
+// @Router /api/v1/{{.RouteName}}/export [get]
+// @Security BearerAuth
+func (h *{{.RouteName}}ExportHandler) Export(c *gin.Context) {
+	format := c.DefaultQuery("format", export.FormatCSV)
+	if !export.IsSupported(format) {
+		logger.Warn("export format error", logger.String("format", format), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	whitelist := make(map[string]bool, len({{.RouteName}}ExportColumns))
+	headers := make(map[string]string, len({{.RouteName}}ExportColumns))
+	var names []string
+	for _, column := range {{.RouteName}}ExportColumns {
+		whitelist[column.name] = true
+		headers[column.name] = column.header
+		names = append(names, column.name)
+	}
+	if fields := c.Query("fields"); fields != "" {
+		names = strings.Split(fields, ",")
+	}
+	header := make([]string, 0, len(names))
+	for _, name := range names {
+		if !whitelist[name] {
+			logger.Warn("export field error", logger.String("field", name), middleware.GCtxRequestIDField(c))
+			response.Error(c, ecode.InvalidParams)
+			return
+		}
+		header = append(header, headers[name])
+	}
+
+	// the query conditions are the same as the list api
+	params := &query.Params{Sort: c.DefaultQuery("sort", "-{{.PrimaryKey}}")}
+	for _, name := range strings.Split(params.Sort, ",") {
+		if !whitelist[strings.TrimPrefix(strings.TrimSpace(name), "-")] {
+			logger.Warn("export sort error", logger.String("sort", params.Sort), middleware.GCtxRequestIDField(c))
+			response.Error(c, ecode.InvalidParams)
+			return
+		}
+	}
+	if conditions := c.Query("conditions"); conditions != "" {
+		if err := json.Unmarshal([]byte(conditions), &params.Columns); err != nil {
+			logger.Warn("export conditions error", logger.Err(err), middleware.GCtxRequestIDField(c))
+			response.Error(c, ecode.InvalidParams)
+			return
+		}
+	}
+	queryStr, args, err := params.ConvertToGormConditions(query.WithWhitelistNames(whitelist))
+	if err != nil {
+		logger.Warn("ConvertToGormConditions error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+	order, _, _ := params.ConvertToPage()
+
+	limit := {{.RouteName}}ExportMaxRows
+	if v, err := strconv.Atoi(c.Query("limit")); err == nil && v > 0 && v < limit {
+		limit = v
+	}
+
+	ctx := middleware.WrapCtx(c)
+	db := database.GetDB().WithContext(ctx).Model(&model.{{.TableNameCamel}}{}).Select(names)
+	if queryStr != "" {
+		db = db.Where(queryStr, args...)
+	}
+	rows, err := db.Order(order).Limit(limit).Rows()
+	if err != nil {
+		logger.Error("export query error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+	defer rows.Close() //nolint
+
+	w, err := export.New(c, format, "{{.TableName}}")
+	if err != nil {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+	count := 0
+	err = w.Write(header)
+	if err == nil {
+		count, err = w.WriteSQLRows(rows)
+	}
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		// the file has been partially written, the status code can not be changed
+		logger.Error("export error", logger.Err(err), logger.Int("rows", count), middleware.GCtxRequestIDField(c))
+		_ = c.Error(err)
+	}
+}
+`
+
+	// exportAPIRouterCode the router of export api, it is rendered by exportAPITable.
+	exportAPIRouterCode = `package routers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"{{.ImportPath}}/internal/handler"
+)
+
+func init() {
+	apiV1RouterFns = append(apiV1RouterFns, func(group *gin.RouterGroup) {
+		{{.RouteName}}ExportRouter(group, handler.New{{.TableNameCamel}}ExportHandler())
+	})
+}
+
+func {{.RouteName}}ExportRouter(group *gin.RouterGroup, h handler.{{.TableNameCamel}}ExportHandler) {
+	g := group.Group("/{{.RouteName}}")
+
+	// JWT authentication reference: https://go-sponge.com/component/transport/gin.html#jwt-authorization-middleware
+	//g.Use(middleware.Auth())
+
+	g.GET("/export", h.Export) // [get] /api/v1/{{.RouteName}}/export
+}
+`
+)
@@ -0,0 +1,121 @@
+package generate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/go-dev-frame/sponge/pkg/sql2code"
+	"github.com/go-dev-frame/sponge/pkg/sql2code/parser"
+)
+
+type exportAPIColumn struct {
+	Name   string // column name
+	Header string // header of the exported file
+}
+
+type exportAPITable struct {
+	ImportPath     string // import path of the internal packages, e.g. moduleName or moduleName/serverName
+	TableName      string // the original table name, it is used as the file name
+	TableNameCamel string
+	RouteName      string // e.g. userExample --> /api/v1/userExample/export
+	PrimaryKey     string // column name of the primary key, the default sort is descending by it
+	Columns        []exportAPIColumn
+}
+
+var (
+	exportAPIHandlerTmpl = template.Must(template.New("exportHandler").Parse(exportAPIHandlerCode))
+	exportAPIRouterTmpl  = template.Must(template.New("exportRouter").Parse(exportAPIRouterCode))
+)
+
+func newExportAPITable(tableInfoJSON string, importPath string) (*exportAPITable, error) {
+	info := parser.TableInfo{}
+	if err := json.Unmarshal([]byte(tableInfoJSON), &info); err != nil {
+		return nil, err
+	}
+	if info.PrimaryKey == nil {
+		return nil, fmt.Errorf("table '%s' has no primary key", info.TableName)
+	}
+
+	t := &exportAPITable{
+		ImportPath:     importPath,
+		TableName:      info.TableName,
+		TableNameCamel: info.TableNameCamel,
+		RouteName:      info.TableNameCamelFCL,
+		PrimaryKey:     info.PrimaryKey.Name,
+	}
+	for _, column := range info.Columns {
+		if column.ColumnName == "deleted_at" {
+			continue
+		}
+		t.Columns = append(t.Columns, exportAPIColumn{
+			Name:   column.ColumnName,
+			Header: adminUILabel(column.ColumnComment, column.ColumnNameCamelFCL),
+		})
+	}
+	return t, nil
+}
+
+// generateExportAPI generate the api GET /api/v1/<table>/export of the tables to the server directory,
+// it streams the filtered list as csv or xlsx file, the code is saved in separate files, so it can be
+// added to the existing server.
+func generateExportAPI(sqlArgs sql2code.Args, tableNames []string, moduleName string, serverName string,
+	suitedMonoRepo bool, outPath string) error {
+	if sqlArgs.DBDriver == DBDriverMongodb {
+		return fmt.Errorf("the export api does not support %s", DBDriverMongodb)
+	}
+
+	sqlArgs.IsCustomTemplate = true
+	importPath := moduleName
+	if suitedMonoRepo {
+		importPath += "/" + serverName
+	}
+
+	for _, tableName := range tableNames {
+		if tableName == "" {
+			continue
+		}
+
+		sqlArgs.DBTable = tableName
+		codes, err := sql2code.Generate(&sqlArgs)
+		if err != nil {
+			return err
+		}
+		table, err := newExportAPITable(codes[parser.CodeTypeTableInfo], importPath)
+		if err != nil {
+			return err
+		}
+
+		files := map[string]*template.Template{
+			"internal/handler/" + table.RouteName + "_export.go": exportAPIHandlerTmpl,
+			"internal/routers/" + table.RouteName + "_export.go": exportAPIRouterTmpl,
+		}
+		for file, tmpl := range files {
+			buf := new(bytes.Buffer)
+			if err = tmpl.Execute(buf, table); err != nil {
+				return err
+			}
+			if err = saveCodeFile(filepath.Join(outPath, file), buf.Bytes(), false); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// the usage tip of export api
+func exportAPITip(tableNames []string, number int) string {
+	var names []string
+	for _, name := range tableNames {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return fmt.Sprintf(`
+  %d. export the list of %s by GET /api/v1/<table>/export?format=xlsx, see the comments of the export handler for the parameters.`,
+		number, strings.Join(names, ", "))
+}
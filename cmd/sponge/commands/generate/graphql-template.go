@@ -0,0 +1,382 @@
+package generate
+
+import (
+	"text/template"
+)
+
+func init() {
+	graphqlConfigTmpl = template.Must(template.New("graphqlConfig").Parse(graphqlConfigTmplRaw))
+	graphqlCommonSchemaTmpl = template.Must(template.New("graphqlCommonSchema").Parse(graphqlCommonSchemaTmplRaw))
+	graphqlSchemaTmpl = template.Must(template.New("graphqlSchema").Parse(graphqlSchemaTmplRaw))
+	graphqlResolverTmpl = template.Must(template.New("graphqlResolver").Parse(graphqlResolverTmplRaw))
+	graphqlCommonResolverTmpl = template.Must(template.New("graphqlCommonResolver").Parse(graphqlCommonResolverTmplRaw))
+	graphqlTableResolverTmpl = template.Must(template.New("graphqlTableResolver").Parse(graphqlTableResolverTmplRaw))
+	graphqlParamsTmpl = template.Must(template.New("graphqlParams").Parse(graphqlParamsTmplRaw))
+	graphqlRouterTmpl = template.Must(template.New("graphqlRouter").Parse(graphqlRouterTmplRaw))
+}
+
+var (
+	graphqlConfigTmpl    *template.Template
+	graphqlConfigTmplRaw = `# gqlgen configuration, generate code: go run github.com/99designs/gqlgen generate
+schema:
+  - api/graphql/*.graphqls
+
+exec:
+  filename: internal/graphql/generated/generated.go
+  package: generated
+
+model:
+  filename: internal/graphql/generated/models_gen.go
+  package: generated
+
+# the resolvers that have been implemented are kept when the code is regenerated
+resolver:
+  layout: follow-schema
+  dir: internal/graphql
+  package: graphql
+  filename_template: "{name}.resolvers.go"
+
+models:
+  Any:
+    model:
+      - github.com/99designs/gqlgen/graphql.Any
+`
+
+	graphqlCommonSchemaTmpl    *template.Template
+	graphqlCommonSchemaTmplRaw = `# Code generated by https://github.com/go-dev-frame/sponge
+
+scalar Any
+
+type Query {
+  """check if the graphql server is available"""
+  ping: String!
+}
+
+type Mutation {
+  """check if the graphql server is available"""
+  ping: String!
+}
+
+"""query conditions of a column, it is converted to query.Params of the dao layer"""
+input ColumnInput {
+  """column name"""
+  name: String!
+  """expression, default is =, support =, !=, >, >=, <, <=, like, in, notin, isnull, isnotnull"""
+  exp: String
+  """column value"""
+  value: Any
+  """logical type, default is and, support and(&), or(||)"""
+  logic: String
+}
+
+"""paging and query conditions"""
+input ListParams {
+  """page number, starting from page 0"""
+  page: Int!
+  """number of rows per page"""
+  limit: Int!
+  """sorted fields, multi-column sorting separated by commas, e.g. -id"""
+  sort: String
+  """query conditions"""
+  columns: [ColumnInput!]
+}
+`
+
+	graphqlSchemaTmpl    *template.Template
+	graphqlSchemaTmplRaw = `# Code generated by https://github.com/go-dev-frame/sponge
+
+{{if .Comment}}"""{{.Comment}}"""
+{{end -}}
+type {{.TableNameCamel}} {
+{{- range .Fields}}
+{{- if .Comment}}
+  """{{.Comment}}"""{{end}}
+  {{.Name}}: {{.Type}}!
+{{- end}}
+}
+
+input Create{{.TableNameCamel}}Input {
+{{- range .InputFields}}
+{{- if .Comment}}
+  """{{.Comment}}"""{{end}}
+  {{.Name}}: {{.Type}}!
+{{- end}}
+}
+
+"""the fields that are not set are not updated"""
+input Update{{.TableNameCamel}}Input {
+  {{.PrimaryKey.Name}}: {{.PrimaryKey.Type}}!
+{{- range .InputFields}}
+{{- if .Comment}}
+  """{{.Comment}}"""{{end}}
+  {{.Name}}: {{.Type}}
+{{- end}}
+}
+
+type {{.TableNameCamel}}List {
+  {{.TableNamePluralCamelFCL}}: [{{.TableNameCamel}}!]!
+  total: Int!
+}
+
+extend type Query {
+  """get {{.TableNameCamelFCL}} by {{.PrimaryKey.Name}}"""
+  {{.TableNameCamelFCL}}({{.PrimaryKey.Name}}: {{.PrimaryKey.Type}}!): {{.TableNameCamel}}!
+  """list of {{.TableNamePluralCamelFCL}} by paging and conditions"""
+  {{.TableNamePluralCamelFCL}}(params: ListParams!): {{.TableNameCamel}}List!
+}
+
+extend type Mutation {
+  """create a new {{.TableNameCamelFCL}}"""
+  create{{.TableNameCamel}}(input: Create{{.TableNameCamel}}Input!): {{.TableNameCamel}}!
+  """update {{.TableNameCamelFCL}} by {{.PrimaryKey.Name}}"""
+  update{{.TableNameCamel}}(input: Update{{.TableNameCamel}}Input!): {{.TableNameCamel}}!
+  """delete {{.TableNameCamelFCL}} by {{.PrimaryKey.Name}}"""
+  delete{{.TableNameCamel}}({{.PrimaryKey.Name}}: {{.PrimaryKey.Type}}!): Boolean!
+}
+`
+
+	graphqlResolverTmpl    *template.Template
+	graphqlResolverTmplRaw = `// Package graphql is the resolvers of graphql server, the resolvers delegate to the dao layer.
+package graphql
+
+import (
+	"moduleNameExample/internal/cache"
+	"moduleNameExample/internal/dao"
+	"moduleNameExample/internal/database"
+)
+
+//go:generate go run github.com/99designs/gqlgen generate --config ../../gqlgen.yml
+
+// Resolver is the root resolver, add the dependencies of resolvers here.
+type Resolver struct {
+{{- range .}}
+	{{.TableNameCamelFCL}}Dao dao.{{.TableNameCamel}}Dao
+{{- end}}
+}
+
+// NewResolver create a root resolver
+func NewResolver() *Resolver {
+	return &Resolver{
+{{- range .}}
+		{{.TableNameCamelFCL}}Dao: dao.New{{.TableNameCamel}}Dao(
+			database.GetDB(),
+			cache.New{{.TableNameCamel}}Cache(database.GetCacheType()),
+		),
+{{- end}}
+	}
+}
+`
+
+	graphqlCommonResolverTmpl    *template.Template
+	graphqlCommonResolverTmplRaw = `package graphql
+
+import (
+	"context"
+
+	"moduleNameExample/internal/graphql/generated"
+)
+
+// Ping is the resolver for the ping field.
+func (r *mutationResolver) Ping(ctx context.Context) (string, error) {
+	return "pong", nil
+}
+
+// Ping is the resolver for the ping field.
+func (r *queryResolver) Ping(ctx context.Context) (string, error) {
+	return "pong", nil
+}
+
+// Mutation returns generated.MutationResolver implementation.
+func (r *Resolver) Mutation() generated.MutationResolver { return &mutationResolver{r} }
+
+// Query returns generated.QueryResolver implementation.
+func (r *Resolver) Query() generated.QueryResolver { return &queryResolver{r} }
+
+type mutationResolver struct{ *Resolver }
+type queryResolver struct{ *Resolver }
+`
+
+	graphqlTableResolverTmpl    *template.Template
+	graphqlTableResolverTmplRaw = `package graphql
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-dev-frame/sponge/pkg/copier"
+	"github.com/go-dev-frame/sponge/pkg/logger"
+
+	"moduleNameExample/internal/database"
+	"moduleNameExample/internal/ecode"
+	"moduleNameExample/internal/graphql/generated"
+	"moduleNameExample/internal/model"
+)
+
+// Create{{.TableNameCamel}} is the resolver for the create{{.TableNameCamel}} field.
+func (r *mutationResolver) Create{{.TableNameCamel}}(ctx context.Context, input generated.Create{{.TableNameCamel}}Input) (*generated.{{.TableNameCamel}}, error) {
+	record := &model.{{.TableNameCamel}}{}
+	err := copier.Copy(record, &input)
+	if err != nil {
+		return nil, ecode.ErrCreate{{.TableNameCamel}}.Err()
+	}
+	// Note: if copier.Copy cannot assign a value to a field, add it here
+
+	err = r.{{.TableNameCamelFCL}}Dao.Create(ctx, record)
+	if err != nil {
+		logger.Error("Create error", logger.Err(err), logger.Any("input", input))
+		return nil, ecode.InternalServerError.Err()
+	}
+
+	return convert{{.TableNameCamel}}(record)
+}
+
+// Update{{.TableNameCamel}} is the resolver for the update{{.TableNameCamel}} field.
+func (r *mutationResolver) Update{{.TableNameCamel}}(ctx context.Context, input generated.Update{{.TableNameCamel}}Input) (*generated.{{.TableNameCamel}}, error) {
+	record := &model.{{.TableNameCamel}}{}
+	err := copier.Copy(record, &input)
+	if err != nil {
+		return nil, ecode.ErrUpdateBy{{.PrimaryKey.NameCamel}}{{.TableNameCamel}}.Err()
+	}
+	// Note: if copier.Copy cannot assign a value to a field, add it here
+
+	err = r.{{.TableNameCamelFCL}}Dao.UpdateBy{{.PrimaryKey.NameCamel}}(ctx, record)
+	if err != nil {
+		logger.Error("UpdateBy{{.PrimaryKey.NameCamel}} error", logger.Err(err), logger.Any("input", input))
+		return nil, ecode.InternalServerError.Err()
+	}
+
+	record, err = r.{{.TableNameCamelFCL}}Dao.GetBy{{.PrimaryKey.NameCamel}}(ctx, record.{{.PrimaryKey.NameCamel}})
+	if err != nil {
+		logger.Error("GetBy{{.PrimaryKey.NameCamel}} error", logger.Err(err), logger.Any("input", input))
+		return nil, ecode.InternalServerError.Err()
+	}
+
+	return convert{{.TableNameCamel}}(record)
+}
+
+// Delete{{.TableNameCamel}} is the resolver for the delete{{.TableNameCamel}} field.
+func (r *mutationResolver) Delete{{.TableNameCamel}}(ctx context.Context, {{.PrimaryKey.Name}} {{.PrimaryKey.GraphQLGoType}}) (bool, error) {
+	err := r.{{.TableNameCamelFCL}}Dao.DeleteBy{{.PrimaryKey.NameCamel}}(ctx, {{.PrimaryKey.GoValue}})
+	if err != nil {
+		logger.Error("DeleteBy{{.PrimaryKey.NameCamel}} error", logger.Err(err), logger.Any("{{.PrimaryKey.Name}}", {{.PrimaryKey.Name}}))
+		return false, ecode.InternalServerError.Err()
+	}
+
+	return true, nil
+}
+
+// {{.TableNameCamel}} is the resolver for the {{.TableNameCamelFCL}} field.
+func (r *queryResolver) {{.TableNameCamel}}(ctx context.Context, {{.PrimaryKey.Name}} {{.PrimaryKey.GraphQLGoType}}) (*generated.{{.TableNameCamel}}, error) {
+	record, err := r.{{.TableNameCamelFCL}}Dao.GetBy{{.PrimaryKey.NameCamel}}(ctx, {{.PrimaryKey.GoValue}})
+	if err != nil {
+		if errors.Is(err, database.ErrRecordNotFound) {
+			return nil, ecode.NotFound.Err()
+		}
+		logger.Error("GetBy{{.PrimaryKey.NameCamel}} error", logger.Err(err), logger.Any("{{.PrimaryKey.Name}}", {{.PrimaryKey.Name}}))
+		return nil, ecode.InternalServerError.Err()
+	}
+
+	return convert{{.TableNameCamel}}(record)
+}
+
+// {{.TableNamePluralCamel}} is the resolver for the {{.TableNamePluralCamelFCL}} field.
+func (r *queryResolver) {{.TableNamePluralCamel}}(ctx context.Context, params generated.ListParams) (*generated.{{.TableNameCamel}}List, error) {
+	records, total, err := r.{{.TableNameCamelFCL}}Dao.GetByColumns(ctx, toQueryParams(params))
+	if err != nil {
+		logger.Error("GetByColumns error", logger.Err(err), logger.Any("params", params))
+		return nil, ecode.InternalServerError.Err()
+	}
+
+	list := make([]*generated.{{.TableNameCamel}}, 0, len(records))
+	for _, record := range records {
+		data, err := convert{{.TableNameCamel}}(record)
+		if err != nil {
+			return nil, ecode.ErrList{{.TableNameCamel}}.Err()
+		}
+		list = append(list, data)
+	}
+
+	return &generated.{{.TableNameCamel}}List{
+		{{.TableNamePluralCamel}}: list,
+		Total: int(total),
+	}, nil
+}
+
+func convert{{.TableNameCamel}}(record *model.{{.TableNameCamel}}) (*generated.{{.TableNameCamel}}, error) {
+	data := &generated.{{.TableNameCamel}}{}
+	err := copier.Copy(data, record)
+	if err != nil {
+		return nil, err
+	}
+	// Note: if copier.Copy cannot assign a value to a field, add it here
+
+	return data, nil
+}
+`
+
+	graphqlParamsTmpl    *template.Template
+	graphqlParamsTmplRaw = `package graphql
+
+import (
+	"github.com/go-dev-frame/sponge/pkg/sgorm/query"
+
+	"moduleNameExample/internal/graphql/generated"
+)
+
+// toQueryParams convert the paging and query conditions of graphql to query.Params of the dao layer,
+// the column names are checked by the whitelist of the model in the dao layer.
+func toQueryParams(params generated.ListParams) *query.Params {
+	p := &query.Params{
+		Page:  params.Page,
+		Limit: params.Limit,
+	}
+	if params.Sort != nil {
+		p.Sort = *params.Sort
+	}
+	for _, column := range params.Columns {
+		if column == nil {
+			continue
+		}
+		c := query.Column{
+			Name:  column.Name,
+			Value: column.Value,
+		}
+		if column.Exp != nil {
+			c.Exp = *column.Exp
+		}
+		if column.Logic != nil {
+			c.Logic = *column.Logic
+		}
+		p.Columns = append(p.Columns, c)
+	}
+	return p
+}
+`
+
+	graphqlRouterTmpl    *template.Template
+	graphqlRouterTmplRaw = `package routers
+
+import (
+	gqlhandler "github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/playground"
+	"github.com/gin-gonic/gin"
+
+	"moduleNameExample/internal/graphql"
+	"moduleNameExample/internal/graphql/generated"
+)
+
+func init() {
+	apiV1RouterFns = append(apiV1RouterFns, func(group *gin.RouterGroup) {
+		graphqlRouter(group)
+	})
+}
+
+func graphqlRouter(group *gin.RouterGroup) {
+	srv := gqlhandler.NewDefaultServer(generated.NewExecutableSchema(generated.Config{Resolvers: graphql.NewResolver()}))
+
+	// All the middlewares of the route group are valid for graphql, e.g. middleware.Auth()
+	group.POST("/graphql", gin.WrapH(srv))
+	group.GET("/graphql/playground", gin.WrapH(playground.Handler("GraphQL playground", "/api/v1/graphql")))
+}
+`
+)
@@ -0,0 +1,284 @@
+package generate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/go-dev-frame/sponge/pkg/gofile"
+	"github.com/go-dev-frame/sponge/pkg/sql2code"
+	"github.com/go-dev-frame/sponge/pkg/sql2code/parser"
+)
+
+// GraphQLCommand generate graphql server code based on sql
+func GraphQLCommand() *cobra.Command {
+	var (
+		moduleName string // module name for go.mod
+		outPath    string // output directory
+		dbTables   string // table names
+
+		sqlArgs = sql2code.Args{
+			JSONTag:          true,
+			JSONNamedType:    1,
+			IsCustomTemplate: true,
+		}
+	)
+
+	cmd := &cobra.Command{
+		Use:   "graphql",
+		Short: "Generate graphql server code based on sql",
+		Long: `Generate graphql server code based on sql, the code is based on gqlgen, the resolvers delegate to the dao layer,
+the paging and query conditions of graphql are converted to query.Params of the dao layer.`,
+		Example: color.HiBlackString(`  # Generate graphql server code.
+  sponge graphql --module-name=yourModuleName --db-driver=mysql --db-dsn=root:123456@(192.168.3.37:3306)/test --db-table=user
+
+  # Generate graphql server code with multiple table names.
+  sponge graphql --module-name=yourModuleName --db-driver=mysql --db-dsn=root:123456@(192.168.3.37:3306)/test --db-table=t1,t2
+
+  # Generate graphql server code and specify the server directory, Note: the files that already exist are not overwritten.
+  sponge graphql --db-driver=mysql --db-dsn=root:123456@(192.168.3.37:3306)/test --db-table=user --out=./yourServerDir`),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mdName, _, _ := getNamesFromOutDir(outPath)
+			if mdName != "" {
+				moduleName = mdName
+			} else if moduleName == "" {
+				return errors.New(`required flag(s) "module-name" not set, use "sponge graphql -h" for help`)
+			}
+			if sqlArgs.DBDriver == DBDriverMongodb {
+				return errors.New("mongodb is not supported, only mysql, postgresql, tidb and sqlite are supported")
+			}
+
+			var tables []*graphqlTable
+			for _, tableName := range strings.Split(dbTables, ",") {
+				if tableName == "" {
+					continue
+				}
+
+				sqlArgs.DBTable = tableName
+				codes, err := sql2code.Generate(&sqlArgs)
+				if err != nil {
+					return err
+				}
+				table, err := newGraphqlTable(codes[parser.CodeTypeTableInfo])
+				if err != nil {
+					return err
+				}
+				tables = append(tables, table)
+			}
+			if len(tables) == 0 {
+				return errors.New("no table name is specified")
+			}
+
+			g := &graphqlGenerator{
+				moduleName: moduleName,
+				outPath:    outPath,
+				tables:     tables,
+			}
+			var err error
+			outPath, err = g.generateCode()
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf(`
+using help:
+  1. move the folders "api", "internal" and the file "gqlgen.yml" to your project code folder, the dao code of the tables
+     is required, if not, generate it by the command "sponge web dao".
+  2. open a terminal and execute the command to generate graphql code: go run github.com/99designs/gqlgen generate
+  3. compile and run server: make run
+  4. access http://localhost:8080/api/v1/graphql/playground in your browser, and test the graphql api.
+
+`)
+			fmt.Printf("generate \"graphql\" code successfully, out = %s\n", outPath)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&moduleName, "module-name", "m", "", "module-name is the name of the module in the go.mod file")
+	cmd.Flags().StringVarP(&sqlArgs.DBDriver, "db-driver", "k", "mysql", "database driver, support mysql, postgresql, sqlite")
+	cmd.Flags().StringVarP(&sqlArgs.DBDsn, "db-dsn", "d", "", "database content address, e.g. user:password@(host:port)/database. Note: if db-driver=sqlite, db-dsn must be a local sqlite db file, e.g. --db-dsn=/tmp/sponge_sqlite.db") //nolint
+	_ = cmd.MarkFlagRequired("db-dsn")
+	cmd.Flags().StringVarP(&dbTables, "db-table", "t", "", "table name, multiple names separated by commas")
+	_ = cmd.MarkFlagRequired("db-table")
+	cmd.Flags().StringVarP(&sqlArgs.TablePrefix, "table-prefix", "p", "", "table name prefix, e.g. t_")
+	cmd.Flags().StringVarP(&outPath, "out", "o", "", "output directory, default is ./graphql_<time>, "+flagTip("module-name"))
+
+	return cmd
+}
+
+type graphqlField struct {
+	Name    string // field name, camel case and first character lower
+	Type    string // graphql type
+	Comment string
+}
+
+type graphqlPrimaryKey struct {
+	Name          string // graphql argument name
+	NameCamel     string // used by the method names of dao, e.g. GetByID
+	Type          string // graphql type
+	GraphQLGoType string // go type of the graphql argument
+	GoValue       string // the graphql argument converted to the go type of dao
+}
+
+type graphqlTable struct {
+	TableNameCamel          string
+	TableNameCamelFCL       string
+	TableNamePluralCamel    string
+	TableNamePluralCamelFCL string
+	TableNameSnake          string
+	Comment                 string
+
+	Fields      []graphqlField // fields of the graphql type
+	InputFields []graphqlField // fields of create and update input
+	PrimaryKey  graphqlPrimaryKey
+}
+
+func newGraphqlTable(tableInfoJSON string) (*graphqlTable, error) {
+	info := parser.TableInfo{}
+	if err := json.Unmarshal([]byte(tableInfoJSON), &info); err != nil {
+		return nil, err
+	}
+	if info.PrimaryKey == nil {
+		return nil, fmt.Errorf("table '%s' has no primary key", info.TableName)
+	}
+
+	pk := graphqlPrimaryKey{
+		Name:          info.PrimaryKey.NameCamelFCL,
+		NameCamel:     info.PrimaryKey.NameCamel,
+		Type:          "String",
+		GraphQLGoType: "string",
+		GoValue:       info.PrimaryKey.NameCamelFCL,
+	}
+	if !info.PrimaryKey.IsStringType {
+		goType := info.PrimaryKey.GoType
+		if info.PrimaryKey.Name == "id" {
+			goType = "uint64" // the id of model is always uint64
+		}
+		pk.Type = "Int"
+		pk.GraphQLGoType = "int"
+		pk.GoValue = fmt.Sprintf("%s(%s)", goType, pk.Name)
+	}
+
+	t := &graphqlTable{
+		TableNameCamel:          info.TableNameCamel,
+		TableNameCamelFCL:       info.TableNameCamelFCL,
+		TableNamePluralCamel:    info.TableNamePluralCamel,
+		TableNamePluralCamelFCL: info.TableNamePluralCamelFCL,
+		TableNameSnake:          info.TableNameSnake,
+		Comment:                 graphqlComment(info.TableComment),
+		PrimaryKey:              pk,
+	}
+	for _, column := range info.Columns {
+		if column.ColumnName == "deleted_at" {
+			continue
+		}
+		field := graphqlField{
+			Name:    column.ColumnNameCamelFCL,
+			Type:    goTypeToGraphql(column.GoType),
+			Comment: graphqlComment(column.ColumnComment),
+		}
+		if column.IsPrimaryKey {
+			field.Type = pk.Type
+		}
+		t.Fields = append(t.Fields, field)
+		if !column.IsPrimaryKey && column.ColumnName != "created_at" && column.ColumnName != "updated_at" {
+			t.InputFields = append(t.InputFields, field)
+		}
+	}
+
+	return t, nil
+}
+
+// goTypeToGraphql convert go type to graphql type, time is expressed as a string
+// in the same format as the http api, e.g. 2006-01-02T15:04:05+08:00.
+func goTypeToGraphql(goType string) string {
+	switch strings.TrimPrefix(goType, "*") {
+	case "bool":
+		return "Boolean"
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		return "Int"
+	case "float32", "float64":
+		return "Float"
+	}
+	return "String"
+}
+
+func graphqlComment(comment string) string {
+	comment = strings.ReplaceAll(comment, `"`, "'")
+	return strings.Join(strings.Fields(comment), " ")
+}
+
+type graphqlGenerator struct {
+	moduleName string
+	outPath    string
+	tables     []*graphqlTable
+}
+
+func (g *graphqlGenerator) generateCode() (string, error) {
+	outPath := g.outPath
+	if outPath == "" {
+		pwd, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		outPath = pwd + gofile.GetPathDelimiter() + "graphql_" + time.Now().Format("150405")
+	}
+	outPath, err := filepath.Abs(outPath)
+	if err != nil {
+		return "", err
+	}
+
+	// common files are generated only once
+	commonFiles := []struct {
+		file string
+		tmpl *template.Template
+	}{
+		{"gqlgen.yml", graphqlConfigTmpl},
+		{"api/graphql/common.graphqls", graphqlCommonSchemaTmpl},
+		{"internal/graphql/common.resolvers.go", graphqlCommonResolverTmpl},
+		{"internal/graphql/params.go", graphqlParamsTmpl},
+		{"internal/routers/graphql.go", graphqlRouterTmpl},
+	}
+	for _, f := range commonFiles {
+		if err = g.saveFile(outPath, f.file, f.tmpl, nil, true); err != nil {
+			return "", err
+		}
+	}
+
+	if err = g.saveFile(outPath, "internal/graphql/resolver.go", graphqlResolverTmpl, g.tables, false); err != nil {
+		return "", err
+	}
+	for _, table := range g.tables {
+		err = g.saveFile(outPath, "api/graphql/"+table.TableNameSnake+".graphqls", graphqlSchemaTmpl, table, false)
+		if err != nil {
+			return "", err
+		}
+		err = g.saveFile(outPath, "internal/graphql/"+table.TableNameSnake+".resolvers.go", graphqlTableResolverTmpl, table, false)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return outPath, nil
+}
+
+// saveFile execute the template and save to file, see saveCodeFile for handling existing files.
+func (g *graphqlGenerator) saveFile(outPath string, file string, tmpl *template.Template, data interface{}, isSkipExists bool) error {
+	buf := new(bytes.Buffer)
+	if err := tmpl.Execute(buf, data); err != nil {
+		return err
+	}
+	content := bytes.ReplaceAll(buf.Bytes(), []byte("moduleNameExample"), []byte(g.moduleName))
+	return saveCodeFile(filepath.Join(outPath, file), content, isSkipExists)
+}
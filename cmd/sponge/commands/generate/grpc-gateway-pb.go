@@ -0,0 +1,110 @@
+package generate
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// GRPCGatewayPbCommand generate grpc server with translated http interface (grpc-gateway style) code based on protobuf file
+func GRPCGatewayPbCommand() *cobra.Command {
+	var (
+		moduleName   string // module name for go.mod
+		serverName   string // server name
+		projectName  string // project name for deployment name
+		repoAddr     string // image repo address
+		outPath      string // output directory
+		protobufFile string // protobuf file, support * matching
+
+		suitedMonoRepo bool   // whether the generated code is suitable for mono-repo
+		ciType         string // ci/cd pipeline type, support github, gitlab
+	)
+
+	cmd := &cobra.Command{
+		Use:   "grpc-gateway-pb",
+		Short: "Generate grpc server with translated http interface code based on protobuf file",
+		Long: `Generate grpc server with translated http interface code based on protobuf file, grpc and http are served in one binary.
+
+The http interface is translated from the http options of the protobuf file (grpc-gateway style) and calls the grpc
+service in the same process, the logic code only needs to be written in the grpc service, the grpc codes are converted
+to standard http codes, and the error codes defined in internal/ecode are shared by grpc and http. Unlike rpc-gw-pb,
+there is no need to run a separate gateway service.`,
+		Example: color.HiBlackString(`  # Generate grpc server with translated http interface code.
+  sponge micro grpc-gateway-pb --module-name=yourModuleName --server-name=yourServerName --project-name=yourProjectName --protobuf-file=./demo.proto
+
+  # Generate grpc server with translated http interface code and specify the output directory, Note: code generation will be canceled when the latest generated file already exists.
+  sponge micro grpc-gateway-pb --module-name=yourModuleName --server-name=yourServerName --project-name=yourProjectName --protobuf-file=./demo.proto --out=./yourServerDir
+
+  # Generate grpc server with translated http interface code and specify the docker image repository address.
+  sponge micro grpc-gateway-pb --module-name=yourModuleName --server-name=yourServerName --project-name=yourProjectName --repo-addr=192.168.3.37:9443/user-name --protobuf-file=./demo.proto
+
+  # Generate code with the ci/cd pipeline of github actions (or gitlab ci), the environments are deployed to k8s by kustomize overlays.
+  sponge micro grpc-gateway-pb --module-name=yourModuleName --server-name=yourServerName --project-name=yourProjectName --repo-addr=192.168.3.37:9443/user-name --protobuf-file=./demo.proto --ci=github
+
+  # If you want the generated code to suited to mono-repo, you need to set the parameter --suited-mono-repo=true`),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkCIType(ciType); err != nil {
+				return err
+			}
+
+			var err error
+			projectName, serverName, err = convertProjectAndServerName(projectName, serverName)
+			if err != nil {
+				return err
+			}
+
+			if suitedMonoRepo {
+				outPath = changeOutPath(outPath, serverName)
+			}
+
+			g := &httpAndGRPCPbGenerator{
+				moduleName:        moduleName,
+				serverName:        serverName,
+				projectName:       projectName,
+				protobufFile:      protobufFile,
+				repoAddr:          repoAddr,
+				outPath:           outPath,
+				suitedMonoRepo:    suitedMonoRepo,
+				isHandleProtoFile: true,
+				isGateway:         true,
+			}
+			outPath, err = g.generateCode()
+			if err != nil {
+				return err
+			}
+
+			_ = generateConfigmap(serverName, outPath)
+
+			fmt.Printf(`
+using help:
+  1. open a terminal and execute the command to generate code: make proto
+  2. open file internal/service/xxx.go, replace panic("implement me") according to template code example.
+  3. compile and run server: make run
+  4. access http://localhost:8080/apis/swagger/index.html in your browser, and test the http api.
+     open the file "internal/service/xxx_client_test.go" using Goland or VSCode, and test the grpc api.
+
+`)
+			fmt.Printf("generate %s's grpc server with translated http interface code successfully, out = %s\n", g.serverName, outPath)
+
+			return generateCIFiles(ciType, serverName, projectName, repoAddr, suitedMonoRepo, outPath)
+		},
+	}
+
+	cmd.Flags().StringVarP(&moduleName, "module-name", "m", "", "module-name is the name of the module in the go.mod file")
+	_ = cmd.MarkFlagRequired("module-name")
+	cmd.Flags().StringVarP(&serverName, "server-name", "s", "", "server name")
+	_ = cmd.MarkFlagRequired("server-name")
+	cmd.Flags().StringVarP(&projectName, "project-name", "p", "", "project name")
+	_ = cmd.MarkFlagRequired("project-name")
+	cmd.Flags().StringVarP(&protobufFile, "protobuf-file", "f", "", "proto file")
+	_ = cmd.MarkFlagRequired("protobuf-file")
+	cmd.Flags().BoolVarP(&suitedMonoRepo, "suited-mono-repo", "l", false, "whether the generated code is suitable for mono-repo")
+	cmd.Flags().StringVarP(&repoAddr, "repo-addr", "r", "", "docker image repository address, excluding http and repository names")
+	cmd.Flags().StringVarP(&ciType, "ci", "", "", "generate the ci/cd pipeline file and kustomize overlays of environments (dev, test, prod), support github, gitlab")
+	cmd.Flags().StringVarP(&outPath, "out", "o", "", "output directory, default is ./serverName_grpc-gateway-pb_<time>")
+
+	return cmd
+}
@@ -3,6 +3,7 @@ package generate
 import (
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/fatih/color"
 	"github.com/huandu/xstrings"
@@ -21,7 +22,8 @@ func GRPCAndHTTPPbCommand() *cobra.Command {
 		outPath      string // output directory
 		protobufFile string // protobuf file, support * matching
 
-		suitedMonoRepo bool // whether the generated code is suitable for mono-repo
+		suitedMonoRepo bool   // whether the generated code is suitable for mono-repo
+		ciType         string // ci/cd pipeline type, support github, gitlab
 	)
 
 	cmd := &cobra.Command{
@@ -37,10 +39,17 @@ func GRPCAndHTTPPbCommand() *cobra.Command {
   # Generate grpc+http servers code and specify the docker image repository address.
   sponge micro grpc-http-pb --module-name=yourModuleName --server-name=yourServerName --project-name=yourProjectName --repo-addr=192.168.3.37:9443/user-name --protobuf-file=./demo.proto
 
+  # Generate code with the ci/cd pipeline of github actions (or gitlab ci), the environments are deployed to k8s by kustomize overlays.
+  sponge micro grpc-http-pb --module-name=yourModuleName --server-name=yourServerName --project-name=yourProjectName --repo-addr=192.168.3.37:9443/user-name --protobuf-file=./demo.proto --ci=github
+
   # If you want the generated code to suited to mono-repo, you need to set the parameter --suited-mono-repo=true`),
 		SilenceErrors: true,
 		SilenceUsage:  true,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkCIType(ciType); err != nil {
+				return err
+			}
+
 			var err error
 			projectName, serverName, err = convertProjectAndServerName(projectName, serverName)
 			if err != nil {
@@ -79,7 +88,7 @@ using help:
 `)
 			fmt.Printf("generate %s's grpc+http servers code successfully, out = %s\n", g.serverName, outPath)
 
-			return nil
+			return generateCIFiles(ciType, serverName, projectName, repoAddr, suitedMonoRepo, outPath)
 		},
 	}
 
@@ -93,6 +102,7 @@ using help:
 	_ = cmd.MarkFlagRequired("protobuf-file")
 	cmd.Flags().BoolVarP(&suitedMonoRepo, "suited-mono-repo", "l", false, "whether the generated code is suitable for mono-repo")
 	cmd.Flags().StringVarP(&repoAddr, "repo-addr", "r", "", "docker image repository address, excluding http and repository names")
+	cmd.Flags().StringVarP(&ciType, "ci", "", "", "generate the ci/cd pipeline file and kustomize overlays of environments (dev, test, prod), support github, gitlab")
 	cmd.Flags().StringVarP(&outPath, "out", "o", "", "output directory, default is ./serverName_grpc-http-pb_<time>")
 
 	return cmd
@@ -107,9 +117,11 @@ type httpAndGRPCPbGenerator struct {
 	outPath           string
 	suitedMonoRepo    bool
 	isHandleProtoFile bool
+	isGateway         bool // the grpc codes are converted to standard http codes in grpc-gateway style
 
 	// grpc+http servers code generation related
 	isAddDBInitCode    bool
+	isOutbox           bool
 	dbDriver           string
 	extraReplaceFields []replacer.Field
 }
@@ -132,6 +144,8 @@ func (g *httpAndGRPCPbGenerator) generateCode() (string, error) {
 	subTplName := codeNameGRPCHTTPPb
 	if g.isAddDBInitCode {
 		subTplName = codeNameGRPCHTTP
+	} else if g.isGateway {
+		subTplName = codeNameGRPCGatewayPb
 	}
 	r := Replacers[TplNameSponge]
 	if r == nil {
@@ -177,6 +191,11 @@ func (g *httpAndGRPCPbGenerator) generateCode() (string, error) {
 		if err != nil {
 			return "", err
 		}
+		if g.isOutbox {
+			if err = AddOutboxFile(g.dbDriver, selectFiles); err != nil {
+				return "", err
+			}
+		}
 	}
 
 	if g.suitedMonoRepo {
@@ -197,6 +216,11 @@ func (g *httpAndGRPCPbGenerator) generateCode() (string, error) {
 	_ = r.SetOutputDir(g.outPath, g.serverName+"_"+subTplName)
 	fields := g.addFields(r)
 	r.SetReplacementFields(fields)
+	features := map[string]string{}
+	if g.isAddDBInitCode {
+		features["db"] = g.dbDriver
+	}
+	r.SetFeatures(features)
 	if err = r.SaveFiles(); err != nil {
 		return "", err
 	}
@@ -216,6 +240,11 @@ func (g *httpAndGRPCPbGenerator) addFields(r replacer.Replacer) []replacer.Field
 	var fields []replacer.Field
 
 	repoHost, _ := parseImageRepoAddr(g.repoAddr)
+	protoShellCode := protoShellServiceAndHandlerCode
+	if g.isGateway {
+		protoShellCode = strings.Replace(protoShellCode, "--go-gin_opt=plugin=mix",
+			"--go-gin_opt=plugin=mix --go-gin_opt=gateway=true", 1)
+	}
 
 	fields = append(fields, deleteFieldsMark(r, httpFile, startMark, endMark)...)
 	fields = append(fields, deleteFieldsMark(r, dockerFile, wellStartMark, wellEndMark)...)
@@ -280,7 +309,7 @@ func (g *httpAndGRPCPbGenerator) addFields(r replacer.Replacer) []replacer.Field
 		},
 		{ // replace the contents of the proto.sh file
 			Old: protoShellFileMark,
-			New: protoShellServiceAndHandlerCode,
+			New: protoShellCode,
 		},
 		{
 			Old: "github.com/go-dev-frame/sponge",
@@ -27,7 +27,9 @@ func GRPCAndHTTPCommand() *cobra.Command {
 			IsWebProto: true,
 		}
 
-		suitedMonoRepo bool // whether the generated code is suitable for mono-repo
+		suitedMonoRepo bool   // whether the generated code is suitable for mono-repo
+		ciType         string // ci/cd pipeline type, support github, gitlab
+		isOutbox       bool   // whether to generate the outbox relay code
 	)
 
 	//nolint
@@ -44,16 +46,26 @@ func GRPCAndHTTPCommand() *cobra.Command {
   # Generate grpc+http servers code with extended api.
   sponge micro grpc-http --module-name=yourModuleName --server-name=yourServerName --project-name=yourProjectName --db-driver=mysql --db-dsn=root:123456@(192.168.3.37:3306)/test --db-table=user --extended-api=true
 
+  # Generate grpc+http servers code with the relay code of transactional outbox.
+  sponge micro grpc-http --module-name=yourModuleName --server-name=yourServerName --project-name=yourProjectName --db-driver=mysql --db-dsn=root:123456@(192.168.3.37:3306)/test --db-table=user --outbox=true
+
   # Generate grpc+http servers code and specify the output directory, Note: code generation will be canceled when the latest generated file already exists.
   sponge micro grpc-http --module-name=yourModuleName --server-name=yourServerName --project-name=yourProjectName --db-driver=mysql --db-dsn=root:123456@(192.168.3.37:3306)/test --db-table=user --out=./yourServerDir
 
   # Generate grpc+http servers code and specify the docker image repository address.
   sponge micro grpc-http --module-name=yourModuleName --server-name=yourServerName --project-name=yourProjectName --repo-addr=192.168.3.37:9443/user-name --db-driver=mysql --db-dsn=root:123456@(192.168.3.37:3306)/test --db-table=user
 
+  # Generate code with the ci/cd pipeline of github actions (or gitlab ci), the environments are deployed to k8s by kustomize overlays.
+  sponge micro grpc-http --module-name=yourModuleName --server-name=yourServerName --project-name=yourProjectName --repo-addr=192.168.3.37:9443/user-name --db-driver=mysql --db-dsn=root:123456@(192.168.3.37:3306)/test --db-table=user --ci=github
+
   # If you want the generated code to suited to mono-repo, you need to set the parameter --suited-mono-repo=true`),
 		SilenceErrors: true,
 		SilenceUsage:  true,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkCIType(ciType); err != nil {
+				return err
+			}
+
 			var err error
 			projectName, serverName, err = convertProjectAndServerName(projectName, serverName)
 			if err != nil {
@@ -74,6 +86,7 @@ func GRPCAndHTTPCommand() *cobra.Command {
 				isHandleProtoFile: false,
 
 				isAddDBInitCode:    true,
+				isOutbox:           isOutbox,
 				dbDriver:           sqlArgs.DBDriver,
 				extraReplaceFields: extraFields(sqlArgs.DBDriver, sqlArgs.DBDsn),
 			}
@@ -122,9 +135,7 @@ using help:
 `)
 			fmt.Printf("generate %s's grpc+http servers code successfully, out = %s\n", serverName, outPath)
 
-			_ = generateConfigmap(serverName, outPath)
-			// generate database
-			return nil
+			return generateCIFiles(ciType, serverName, projectName, repoAddr, suitedMonoRepo, outPath)
 		},
 	}
 
@@ -142,8 +153,10 @@ using help:
 	cmd.Flags().BoolVarP(&sqlArgs.IsEmbed, "embed", "e", false, "whether to embed gorm.model struct")
 	cmd.Flags().BoolVarP(&sqlArgs.IsExtendedAPI, "extended-api", "a", false, "whether to generate extended crud api, additional includes: DeleteByIDs, GetByCondition, ListByIDs, ListByLatestID")
 	cmd.Flags().BoolVarP(&suitedMonoRepo, "suited-mono-repo", "l", false, "whether the generated code is suitable for mono-repo")
+	cmd.Flags().BoolVarP(&isOutbox, "outbox", "", false, "whether to generate the relay code of transactional outbox, messages are saved in the business transaction and published to message queues, mongodb is not supported")
 	cmd.Flags().IntVarP(&sqlArgs.JSONNamedType, "json-name-type", "j", 1, "json tags name type, 0:snake case, 1:camel case")
 	cmd.Flags().StringVarP(&repoAddr, "repo-addr", "r", "", "docker image repository address, excluding http and repository names")
+	cmd.Flags().StringVarP(&ciType, "ci", "", "", "generate the ci/cd pipeline file and kustomize overlays of environments (dev, test, prod), support github, gitlab")
 	cmd.Flags().StringVarP(&outPath, "out", "o", "", "output directory, default is ./serverName_rpc_<time>")
 
 	return cmd
@@ -169,7 +169,7 @@ func (g *handlerPbGenerator) generateCode() (string, error) {
 			"userExample.go", "userExample_test.go",
 		},
 		"internal/dao": {
-			"userExample.go", "userExample_test.go",
+			"userExample.go", "userExample_test.go", "userExample_integration_test.go",
 		},
 		"internal/ecode": {
 			"userExample_http.go",
@@ -380,7 +380,7 @@ func (g *handlerPbGenerator) addFields(r replacer.Replacer) []replacer.Field {
 func handlerPbExtendedAPI(r replacer.Replacer) (map[string][]string, []replacer.Field) {
 	replaceFiles := map[string][]string{
 		"internal/dao": {
-			"userExample.go.exp", "userExample_test.go.exp",
+			"userExample.go.exp", "userExample_test.go.exp", "userExample_integration_test.go",
 		},
 		"internal/ecode": {
 			"userExample_http.go.exp",
@@ -29,6 +29,9 @@ func HandlerCommand() *cobra.Command {
 
 		serverName     string // server name
 		suitedMonoRepo bool   // whether the generated code is suitable for mono-repo
+		isAdminUI      bool   // whether to generate the admin ui
+		isExportAPI    bool   // whether to generate the export api
+		isAPIKey       bool   // whether to generate the api key management
 	)
 
 	cmd := &cobra.Command{
@@ -44,6 +47,15 @@ func HandlerCommand() *cobra.Command {
   # Generate handler code with extended api.
   sponge web handler --module-name=yourModuleName --db-driver=mysql --db-dsn=root:123456@(192.168.3.37:3306)/test --db-table=user --extended-api=true
 
+  # Generate handler code with the admin ui, the admin ui is embedded in the binary, access path /admin/index.html
+  sponge web handler --module-name=yourModuleName --db-driver=mysql --db-dsn=root:123456@(192.168.3.37:3306)/test --db-table=user --admin-ui=true
+
+  # Generate handler code with the export api, GET /api/v1/user/export exports the filtered list to csv or xlsx file.
+  sponge web handler --module-name=yourModuleName --db-driver=mysql --db-dsn=root:123456@(192.168.3.37:3306)/test --db-table=user --export-api=true
+
+  # Generate handler code with the api key management, the public api under /public/v1 are authenticated by api key.
+  sponge web handler --module-name=yourModuleName --db-driver=mysql --db-dsn=root:123456@(192.168.3.37:3306)/test --db-table=user --api-key=true
+
   # Generate handler code and specify the server directory, Note: code generation will be canceled when the latest generated file already exists.
   sponge web handler --db-driver=mysql --db-dsn=root:123456@(192.168.3.37:3306)/test --db-table=user --out=./yourServerDir
 
@@ -69,6 +81,12 @@ func HandlerCommand() *cobra.Command {
 
 			if sqlArgs.DBDriver == DBDriverMongodb {
 				sqlArgs.IsEmbed = false
+				if isExportAPI {
+					return errors.New("the export api does not support mongodb")
+				}
+				if isAPIKey {
+					return errors.New("the api key does not support mongodb")
+				}
 			}
 
 			tableNames := strings.Split(dbTables, ",")
@@ -99,14 +117,53 @@ func HandlerCommand() *cobra.Command {
 				}
 			}
 
+			adminUITip := ""
+			if isAdminUI {
+				err := generateAdminUI(sqlArgs, tableNames, moduleName, serverName, suitedMonoRepo, outPath)
+				if err != nil {
+					return err
+				}
+				adminUITip = `
+  5. access http://localhost:8080/admin/index.html in your browser, and manage the data of tables. if the variable
+     "engineRouterFns" is not defined in "internal/routers/routers.go", add it by referring to the latest web server code.`
+			}
+			exportAPITipStr := ""
+			if isExportAPI {
+				err := generateExportAPI(sqlArgs, tableNames, moduleName, serverName, suitedMonoRepo, outPath)
+				if err != nil {
+					return err
+				}
+				number := 5
+				if isAdminUI {
+					number = 6
+				}
+				exportAPITipStr = exportAPITip(tableNames, number)
+			}
+			apiKeyTipStr := ""
+			if isAPIKey {
+				err := generateAPIKey(sqlArgs.DBDriver, moduleName, serverName, suitedMonoRepo, outPath)
+				if err != nil {
+					return err
+				}
+				number := 5
+				if isAdminUI {
+					number++
+				}
+				if isExportAPI {
+					number++
+				}
+				apiKeyTipStr = apiKeyTip(number) + `
+     if the variable "engineRouterFns" is not defined in "internal/routers/routers.go", add it by referring to the latest web server code.`
+			}
+
 			fmt.Printf(`
 using help:
   1. move the folder "internal" to your project code folder.
   2. open a terminal and execute the command: make docs
   3. compile and run server: make run
-  4. access http://localhost:8080/swagger/index.html in your browser, and test the CRUD api interface.
+  4. access http://localhost:8080/swagger/index.html in your browser, and test the CRUD api interface.%s%s%s
 
-`)
+`, adminUITip, exportAPITipStr, apiKeyTipStr)
 			fmt.Printf("generate \"handler\" code successfully, out = %s\n", outPath)
 			return nil
 		},
@@ -123,6 +180,9 @@ using help:
 	cmd.Flags().BoolVarP(&sqlArgs.IsEmbed, "embed", "e", false, "whether to embed gorm.model struct")
 	cmd.Flags().BoolVarP(&sqlArgs.IsExtendedAPI, "extended-api", "a", false, "whether to generate extended crud api, additional includes: DeleteByIDs, GetByCondition, ListByIDs, ListByLatestID")
 	cmd.Flags().BoolVarP(&suitedMonoRepo, "suited-mono-repo", "l", false, "whether the generated code is suitable for mono-repo")
+	cmd.Flags().BoolVarP(&isAdminUI, "admin-ui", "u", false, "whether to generate the admin ui of tables, it is embedded in the binary and calls the CRUD api")
+	cmd.Flags().BoolVarP(&isExportAPI, "export-api", "", false, "whether to generate the api that exports the filtered list to csv or xlsx file, GET /api/v1/<table>/export, mongodb is not supported")
+	cmd.Flags().BoolVarP(&isAPIKey, "api-key", "", false, "whether to generate the api key management, includes the table api_key with hashed secrets, the api of issuing, rotating and revoking keys, and the api key middleware of public api, mongodb is not supported")
 	cmd.Flags().IntVarP(&sqlArgs.JSONNamedType, "json-name-type", "j", 1, "json tags name type, 0:snake case, 1:camel case")
 	cmd.Flags().StringVarP(&outPath, "out", "o", "", "output directory, default is ./handler_<time>, "+flagTip("module-name"))
 
@@ -159,7 +219,7 @@ func (g *handlerGenerator) generateCode() (string, error) {
 			"userExample.go", "userExample_test.go",
 		},
 		"internal/dao": {
-			"userExample.go", "userExample_test.go",
+			"userExample.go", "userExample_test.go", "userExample_integration_test.go",
 		},
 		"internal/ecode": {
 			"userExample_http.go",
@@ -344,7 +404,7 @@ func (g *handlerGenerator) addFields(r replacer.Replacer) []replacer.Field {
 func handlerExtendedAPI(r replacer.Replacer, codeName string) (map[string][]string, []replacer.Field) {
 	replaceFiles := map[string][]string{
 		"internal/dao": {
-			"userExample.go.exp", "userExample_test.go.exp",
+			"userExample.go.exp", "userExample_test.go.exp", "userExample_integration_test.go",
 		},
 		"internal/ecode": {
 			"systemCode_http.go", "userExample_http.go.exp",
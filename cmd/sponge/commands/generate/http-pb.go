@@ -21,7 +21,8 @@ func HTTPPbCommand() *cobra.Command {
 		outPath      string // output directory
 		protobufFile string // protobuf file, support * matching
 
-		suitedMonoRepo bool // whether the generated code is suitable for mono-repo
+		suitedMonoRepo bool   // whether the generated code is suitable for mono-repo
+		ciType         string // ci/cd pipeline type, support github, gitlab
 	)
 
 	cmd := &cobra.Command{
@@ -37,10 +38,17 @@ func HTTPPbCommand() *cobra.Command {
   # Generate web server code and specify the docker image repository address.
   sponge web http-pb --module-name=yourModuleName --server-name=yourServerName --project-name=yourProjectName --repo-addr=192.168.3.37:9443/user-name --protobuf-file=./test.proto
 
+  # Generate code with the ci/cd pipeline of github actions (or gitlab ci), the environments are deployed to k8s by kustomize overlays.
+  sponge web http-pb --module-name=yourModuleName --server-name=yourServerName --project-name=yourProjectName --repo-addr=192.168.3.37:9443/user-name --protobuf-file=./test.proto --ci=github
+
   # If you want the generated code to suited to mono-repo, you need to set the parameter --suited-mono-repo=true`),
 		SilenceErrors: true,
 		SilenceUsage:  true,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkCIType(ciType); err != nil {
+				return err
+			}
+
 			var err error
 			projectName, serverName, err = convertProjectAndServerName(projectName, serverName)
 			if err != nil {
@@ -67,7 +75,7 @@ func HTTPPbCommand() *cobra.Command {
 			}
 
 			_ = generateConfigmap(serverName, outPath)
-			return nil
+			return generateCIFiles(ciType, serverName, projectName, repoAddr, suitedMonoRepo, outPath)
 		},
 	}
 
@@ -81,6 +89,7 @@ func HTTPPbCommand() *cobra.Command {
 	_ = cmd.MarkFlagRequired("protobuf-file")
 	cmd.Flags().BoolVarP(&suitedMonoRepo, "suited-mono-repo", "l", false, "whether the generated code is suitable for mono-repo")
 	cmd.Flags().StringVarP(&repoAddr, "repo-addr", "r", "", "docker image repository address, excluding http and repository names")
+	cmd.Flags().StringVarP(&ciType, "ci", "", "", "generate the ci/cd pipeline file and kustomize overlays of environments (dev, test, prod), support github, gitlab")
 	cmd.Flags().StringVarP(&outPath, "out", "o", "", "output directory, default is ./serverName_http-pb_<time>")
 
 	return cmd
@@ -159,6 +168,7 @@ func (g *httpPbGenerator) generateCode() (string, error) {
 	_ = r.SetOutputDir(g.outPath, g.serverName+"_"+subTplName)
 	fields := g.addFields(r)
 	r.SetReplacementFields(fields)
+	r.SetFeatures(nil) // no optional feature is selected, the database code is not generated
 	if err = r.SaveFiles(); err != nil {
 		return "", err
 	}
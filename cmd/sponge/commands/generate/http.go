@@ -30,7 +30,14 @@ func HTTPCommand() *cobra.Command {
 			GormType: true,
 		}
 
-		suitedMonoRepo bool // whether the generated code is suitable for mono-repo
+		suitedMonoRepo bool   // whether the generated code is suitable for mono-repo
+		ciType         string // ci/cd pipeline type, support github, gitlab
+		isOutbox       bool   // whether to generate the outbox relay code
+		isAdminUI      bool   // whether to generate the admin ui
+		isExportAPI    bool   // whether to generate the export api
+		isAPIKey       bool   // whether to generate the api key management
+
+		openapiFile string // openapi3 file, generate code based on it instead of sql
 	)
 
 	//nolint
@@ -47,16 +54,45 @@ func HTTPCommand() *cobra.Command {
   # Generate web server code with extended api.
   sponge web http --module-name=yourModuleName --server-name=yourServerName --project-name=yourProjectName --db-driver=mysql --db-dsn=root:123456@(192.168.3.37:3306)/test --db-table=user --extended-api=true
 
+  # Generate web server code with the relay code of transactional outbox.
+  sponge web http --module-name=yourModuleName --server-name=yourServerName --project-name=yourProjectName --db-driver=mysql --db-dsn=root:123456@(192.168.3.37:3306)/test --db-table=user --outbox=true
+
+  # Generate web server code with the admin ui, the admin ui is embedded in the binary, access path /admin/index.html
+  sponge web http --module-name=yourModuleName --server-name=yourServerName --project-name=yourProjectName --db-driver=mysql --db-dsn=root:123456@(192.168.3.37:3306)/test --db-table=user --admin-ui=true
+
+  # Generate web server code with the export api, GET /api/v1/user/export exports the filtered list to csv or xlsx file.
+  sponge web http --module-name=yourModuleName --server-name=yourServerName --project-name=yourProjectName --db-driver=mysql --db-dsn=root:123456@(192.168.3.37:3306)/test --db-table=user --export-api=true
+
+  # Generate web server code with the api key management, the public api under /public/v1 are authenticated by api key.
+  sponge web http --module-name=yourModuleName --server-name=yourServerName --project-name=yourProjectName --db-driver=mysql --db-dsn=root:123456@(192.168.3.37:3306)/test --db-table=user --api-key=true
+
   # Generate web server code and specify the output directory, Note: code generation will be canceled when the latest generated file already exists.
   sponge web http --module-name=yourModuleName --server-name=yourServerName --project-name=yourProjectName --db-driver=mysql --db-dsn=root:123456@(192.168.3.37:3306)/test --db-table=user --out=./yourServerDir
 
   # Generate web server code and specify the docker image repository address.
   sponge web http --module-name=yourModuleName --server-name=yourServerName --project-name=yourProjectName --repo-addr=192.168.3.37:9443/user-name --db-driver=mysql --db-dsn=root:123456@(192.168.3.37:3306)/test --db-table=user
 
+  # Generate web server code based on openapi3 file, the openapi file is converted to protobuf file, the code is the same as the command "sponge web http-pb".
+  sponge web http --module-name=yourModuleName --server-name=yourServerName --project-name=yourProjectName --openapi=./api.yaml
+
+  # Generate code with the ci/cd pipeline of github actions (or gitlab ci), the environments are deployed to k8s by kustomize overlays.
+  sponge web http --module-name=yourModuleName --server-name=yourServerName --project-name=yourProjectName --repo-addr=192.168.3.37:9443/user-name --db-driver=mysql --db-dsn=root:123456@(192.168.3.37:3306)/test --db-table=user --ci=github
+
   # If you want the generated code to suited to mono-repo, you need to set the parameter --suited-mono-repo=true`),
 		SilenceErrors: true,
 		SilenceUsage:  true,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkCIType(ciType); err != nil {
+				return err
+			}
+
+			if openapiFile != "" {
+				return generateHTTPFromOpenAPI(openapiFile, moduleName, serverName, projectName, repoAddr, outPath, suitedMonoRepo, ciType)
+			}
+			if sqlArgs.DBDsn == "" || dbTables == "" {
+				return errors.New(`required flag(s) "db-dsn" and "db-table" not set, or use "openapi" instead, use "sponge web http -h" for help`)
+			}
+
 			var err error
 			var firstTable string
 			var handlerTableNames []string
@@ -75,6 +111,12 @@ func HTTPCommand() *cobra.Command {
 
 			if sqlArgs.DBDriver == DBDriverMongodb {
 				sqlArgs.IsEmbed = false
+				if isExportAPI {
+					return errors.New("the export api does not support mongodb")
+				}
+				if isAPIKey {
+					return errors.New("the api key does not support mongodb")
+				}
 			}
 
 			if suitedMonoRepo {
@@ -98,6 +140,7 @@ func HTTPCommand() *cobra.Command {
 				isExtendedAPI:  sqlArgs.IsExtendedAPI,
 				isEmbed:        sqlArgs.IsEmbed,
 				suitedMonoRepo: suitedMonoRepo,
+				isOutbox:       isOutbox,
 			}
 			outPath, err = g.generateCode()
 			if err != nil {
@@ -132,17 +175,54 @@ func HTTPCommand() *cobra.Command {
 				}
 			}
 
+			adminUITip := ""
+			if isAdminUI {
+				err = generateAdminUI(sqlArgs, tableNames, moduleName, serverName, suitedMonoRepo, outPath)
+				if err != nil {
+					return err
+				}
+				adminUITip = `
+  4. access http://localhost:8080/admin/index.html in your browser, and manage the data of tables.`
+			}
+			exportAPITipStr := ""
+			if isExportAPI {
+				err = generateExportAPI(sqlArgs, tableNames, moduleName, serverName, suitedMonoRepo, outPath)
+				if err != nil {
+					return err
+				}
+				number := 4
+				if isAdminUI {
+					number = 5
+				}
+				exportAPITipStr = exportAPITip(tableNames, number)
+			}
+			apiKeyTipStr := ""
+			if isAPIKey {
+				err = generateAPIKey(sqlArgs.DBDriver, moduleName, serverName, suitedMonoRepo, outPath)
+				if err != nil {
+					return err
+				}
+				number := 4
+				if isAdminUI {
+					number++
+				}
+				if isExportAPI {
+					number++
+				}
+				apiKeyTipStr = apiKeyTip(number)
+			}
+
 			fmt.Printf(`
 using help:
   1. open a terminal and execute the command to generate the swagger documentation: make docs
   2. compile and run server: make run
-  3. access http://localhost:8080/swagger/index.html in your browser, and test the http CRUD api.
+  3. access http://localhost:8080/swagger/index.html in your browser, and test the http CRUD api.%s%s%s
 
-`)
+`, adminUITip, exportAPITipStr, apiKeyTipStr)
 			fmt.Printf("generate %s's web server code successfully, out = %s\n", serverName, outPath)
 
 			_ = generateConfigmap(serverName, outPath)
-			return nil
+			return generateCIFiles(ciType, serverName, projectName, repoAddr, suitedMonoRepo, outPath)
 		},
 	}
 
@@ -154,14 +234,18 @@ using help:
 	_ = cmd.MarkFlagRequired("project-name")
 	cmd.Flags().StringVarP(&sqlArgs.DBDriver, "db-driver", "k", "mysql", "database driver, support mysql, mongodb, postgresql, sqlite")
 	cmd.Flags().StringVarP(&sqlArgs.DBDsn, "db-dsn", "d", "", "database content address, e.g. user:password@(host:port)/database. Note: if db-driver=sqlite, db-dsn must be a local sqlite db file, e.g. --db-dsn=/tmp/sponge_sqlite.db") //nolint
-	_ = cmd.MarkFlagRequired("db-dsn")
 	cmd.Flags().StringVarP(&dbTables, "db-table", "t", "", "table name, multiple names separated by commas")
-	_ = cmd.MarkFlagRequired("db-table")
+	cmd.Flags().StringVarP(&openapiFile, "openapi", "", "", "openapi3 file (json or yaml), generate code based on it instead of sql, the operations are converted to protobuf services")
 	cmd.Flags().BoolVarP(&sqlArgs.IsEmbed, "embed", "e", false, "whether to embed gorm.model struct")
 	cmd.Flags().BoolVarP(&sqlArgs.IsExtendedAPI, "extended-api", "a", false, "whether to generate extended crud api, additional includes: DeleteByIDs, GetByCondition, ListByIDs, ListByLatestID")
 	cmd.Flags().BoolVarP(&suitedMonoRepo, "suited-mono-repo", "l", false, "whether the generated code is suitable for mono-repo")
+	cmd.Flags().BoolVarP(&isOutbox, "outbox", "", false, "whether to generate the relay code of transactional outbox, messages are saved in the business transaction and published to message queues, mongodb is not supported")
+	cmd.Flags().BoolVarP(&isAdminUI, "admin-ui", "u", false, "whether to generate the admin ui of tables, it is embedded in the binary and calls the CRUD api")
+	cmd.Flags().BoolVarP(&isExportAPI, "export-api", "", false, "whether to generate the api that exports the filtered list to csv or xlsx file, GET /api/v1/<table>/export, mongodb is not supported")
+	cmd.Flags().BoolVarP(&isAPIKey, "api-key", "", false, "whether to generate the api key management, includes the table api_key with hashed secrets, the api of issuing, rotating and revoking keys, and the api key middleware of public api, mongodb is not supported")
 	cmd.Flags().IntVarP(&sqlArgs.JSONNamedType, "json-name-type", "j", 1, "json tags name type, 0:snake case, 1:camel case")
 	cmd.Flags().StringVarP(&repoAddr, "repo-addr", "r", "", "docker image repository address, excluding http and repository names")
+	cmd.Flags().StringVarP(&ciType, "ci", "", "", "generate the ci/cd pipeline file and kustomize overlays of environments (dev, test, prod), support github, gitlab")
 	cmd.Flags().StringVarP(&outPath, "out", "o", "", "output directory, default is ./serverName_http_<time>, if suited-mono-repo = true, output directory is serverName")
 
 	return cmd
@@ -179,6 +263,7 @@ type httpGenerator struct {
 	isEmbed        bool
 	isExtendedAPI  bool
 	suitedMonoRepo bool
+	isOutbox       bool
 
 	fields        []replacer.Field
 	isCommonStyle bool
@@ -214,7 +299,7 @@ func (g *httpGenerator) generateCode() (string, error) {
 			"serverNameExample.go",
 		},
 		"internal/dao": {
-			"userExample.go", "userExample_test.go",
+			"userExample.go", "userExample_test.go", "userExample_integration_test.go",
 		},
 		"internal/database": {
 			"init.go",
@@ -242,6 +327,11 @@ func (g *httpGenerator) generateCode() (string, error) {
 	if err != nil {
 		return "", err
 	}
+	if g.isOutbox {
+		if err = AddOutboxFile(g.dbDriver, selectFiles); err != nil {
+			return "", err
+		}
+	}
 
 	info := g.codes[parser.CodeTypeCrudInfo]
 	crudInfo, _ := unmarshalCrudInfo(info)
@@ -3,6 +3,8 @@ package generate
 import (
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/fatih/color"
@@ -11,6 +13,7 @@ import (
 	"github.com/go-dev-frame/sponge/pkg/replacer"
 	"github.com/go-dev-frame/sponge/pkg/sql2code"
 	"github.com/go-dev-frame/sponge/pkg/sql2code/parser"
+	"github.com/go-dev-frame/sponge/pkg/struct2dto"
 )
 
 // ModelCommand generate model code
@@ -37,8 +40,20 @@ func ModelCommand(parentName string) *cobra.Command {
   sponge %s model --db-driver=mysql --db-dsn=root:123456@(192.168.3.37:3306)/test --db-table=t1,t2
 
   # Generate model code and specify the server directory, Note: code generation will be canceled when the latest generated file already exists.
-  sponge %s model --db-driver=mysql --db-dsn=root:123456@(192.168.3.37:3306)/test --db-table=user --out=./yourServerDir`,
-			parentName, parentName, parentName)),
+  sponge %s model --db-driver=mysql --db-dsn=root:123456@(192.168.3.37:3306)/test --db-table=user --out=./yourServerDir
+
+  # Generate model code with the columns encrypted at rest, the column email can be used for equality queries.
+  sponge %s model --db-driver=mysql --db-dsn=root:123456@(192.168.3.37:3306)/test --db-table=user --encrypt-columns=phone,email:deterministic
+
+  # Generate model code, the primary key is generated by snowflake before creating a record.
+  sponge %s model --db-driver=mysql --db-dsn=root:123456@(192.168.3.37:3306)/test --db-table=user --id-generator=snowflake
+
+  # Generate model code and the front-end DTO code of typescript and kotlin, the DTO files are saved in the directory dto.
+  sponge %s model --db-driver=mysql --db-dsn=root:123456@(192.168.3.37:3306)/test --db-table=user --dto-lang=typescript,kotlin
+
+  # Generate model code with the associations by the foreign keys between tables.
+  sponge %s model --db-driver=mysql --db-dsn=root:123456@(192.168.3.37:3306)/test --db-table=user,order,role,user_role --relation`,
+			parentName, parentName, parentName, parentName, parentName, parentName, parentName)),
 		SilenceErrors: true,
 		SilenceUsage:  true,
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -52,6 +67,7 @@ func ModelCommand(parentName string) *cobra.Command {
 					sqlArgs.IsEmbed = false
 				}
 				sqlArgs.DBTable = tableName
+				sqlArgs.RelationTables = dbTables
 				codes, err := sql2code.Generate(&sqlArgs)
 				if err != nil {
 					return err
@@ -65,11 +81,15 @@ func ModelCommand(parentName string) *cobra.Command {
 				if err != nil {
 					return err
 				}
+				if err = saveDTOFiles(codes, sqlArgs.DTOLanguages, outPath); err != nil {
+					return err
+				}
 			}
 
 			fmt.Printf(`
 using help:
   move the folder "internal" to your project code folder.
+  after modifying the model structs, execute the command "make migrate-diff" in the project to generate the migration files.
 
 `)
 			fmt.Printf("generate \"model\" code successfully, out = %s\n", outPath)
@@ -85,6 +105,10 @@ using help:
 	cmd.Flags().BoolVarP(&sqlArgs.IsEmbed, "embed", "e", false, "whether to embed gorm.model struct")
 	cmd.Flags().IntVarP(&sqlArgs.JSONNamedType, "json-name-type", "j", 1, "json tags name type, 0:snake case, 1:camel case")
 	cmd.Flags().StringVarP(&outPath, "out", "o", "", "output directory, default is ./model_<time>")
+	cmd.Flags().StringVarP(&sqlArgs.DTOLanguages, "dto-lang", "", "", "generate front-end DTO code alongside model, multiple languages separated by commas, support typescript, swift, kotlin")
+	cmd.Flags().StringVarP(&sqlArgs.EncryptColumns, "encrypt-columns", "", "", "columns encrypted at rest, multiple names separated by commas, the suffix :deterministic supports equality queries, e.g. phone,email:deterministic, register the plugin of pkg/sgorm/encrypt at startup")
+	cmd.Flags().StringVarP(&sqlArgs.IDGenerator, "id-generator", "", "", "generate the primary key before creating a record if it is empty, support ulid, ksuid (primary key of string type) and snowflake (primary key of bigint type), the worker id of snowflake can be allocated by pkg/krand/workerid")
+	cmd.Flags().BoolVarP(&sqlArgs.IsRelation, "relation", "", false, "generate the associations of model by the foreign keys between the tables of db-table, e.g. has one, has many, many to many, the associated records are preloaded by the dao methods with suffix WithPreload")
 
 	return cmd
 }
@@ -134,3 +158,28 @@ func (g *modelGenerator) addFields(r replacer.Replacer) []replacer.Field {
 
 	return fields
 }
+
+// save the front-end DTO code to the directory dto, e.g. dto/user.ts
+func saveDTOFiles(codes map[string]string, languages string, outPath string) error {
+	for _, language := range strings.Split(languages, ",") {
+		language, err := struct2dto.ParseLanguage(language)
+		if err != nil {
+			continue
+		}
+		code, ok := codes[language]
+		if !ok {
+			continue
+		}
+		name := codes[parser.TableName]
+		if name == "" {
+			continue
+		}
+		dir := filepath.Join(outPath, "dto")
+		_ = os.MkdirAll(dir, 0766)
+		file := filepath.Join(dir, strings.ToLower(name[:1])+name[1:]+struct2dto.FileExt(language))
+		if err = os.WriteFile(file, []byte(code), 0666); err != nil {
+			return fmt.Errorf("save file %s error, %v", file, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,586 @@
+package generate
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/huandu/xstrings"
+)
+
+// the http methods supported by google.api.http
+var openapiMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+type protoField struct {
+	Type    string
+	Name    string
+	Options []string
+	Comment string
+}
+
+type protoMessage struct {
+	Name    string
+	Comment string
+	Fields  []*protoField
+}
+
+type protoRPC struct {
+	Name     string
+	Comment  string
+	Request  string
+	Reply    string
+	Method   string
+	Path     string
+	HasBody  bool
+	Summary  string
+	Describe string
+}
+
+type protoService struct {
+	Name string
+	RPCs []*protoRPC
+}
+
+// openapiConverter convert the openapi3 specification to protobuf, the schemas of components are converted
+// to messages, the operations are converted to rpc methods with google.api.http options, and they are grouped
+// into services by the first tag of operations.
+type openapiConverter struct {
+	doc        *openapi3.T
+	serverName string
+
+	messages       []*protoMessage
+	messageNames   map[string]bool             // names that have been used
+	schemaMessages map[*openapi3.Schema]string // object schema --> message name, the referenced schemas are the same pointer
+	isUseStruct    bool                        // whether to import google/protobuf/struct.proto
+}
+
+// generateHTTPFromOpenAPI convert the openapi file to protobuf file, and generate web server code based on
+// the protobuf file, the handlers, routers and error codes are generated by the command "make proto".
+func generateHTTPFromOpenAPI(openapiFile string, moduleName string, serverName string, projectName string,
+	repoAddr string, outPath string, suitedMonoRepo bool, ciType string) error {
+	projectName, serverName, err := convertProjectAndServerName(projectName, serverName)
+	if err != nil {
+		return err
+	}
+	if suitedMonoRepo {
+		outPath = changeOutPath(outPath, serverName)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "sponge_openapi_")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir) //nolint
+	protobufFile, err := openapiToProto(openapiFile, serverName, tmpDir)
+	if err != nil {
+		return err
+	}
+
+	g := &httpPbGenerator{
+		moduleName:   moduleName,
+		serverName:   serverName,
+		projectName:  projectName,
+		protobufFile: protobufFile,
+		repoAddr:     repoAddr,
+		outPath:      outPath,
+
+		suitedMonoRepo: suitedMonoRepo,
+	}
+	outPath, err = g.generateCode()
+	if err != nil {
+		return err
+	}
+
+	_ = generateConfigmap(serverName, outPath)
+	return generateCIFiles(ciType, serverName, projectName, repoAddr, suitedMonoRepo, outPath)
+}
+
+// openapiToProto convert the openapi3 file (json or yaml) to protobuf file, the protobuf file is saved in outDir.
+func openapiToProto(openapiFile string, serverName string, outDir string) (string, error) {
+	loader := openapi3.NewLoader()
+	loader.IsExternalRefsAllowed = true
+	doc, err := loader.LoadFromFile(openapiFile)
+	if err != nil {
+		return "", fmt.Errorf("load openapi file error: %v", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3") {
+		return "", errors.New("only openapi 3 is supported, swagger 2.0 can be converted by the command: " +
+			"sponge web swagger --enable-to-openapi3 --file=yourSwagger.json")
+	}
+	if err = doc.Validate(loader.Context); err != nil {
+		return "", fmt.Errorf("invalid openapi file: %v", err)
+	}
+
+	c := &openapiConverter{
+		doc:            doc,
+		serverName:     serverName,
+		messageNames:   make(map[string]bool),
+		schemaMessages: make(map[*openapi3.Schema]string),
+	}
+	services, err := c.convert()
+	if err != nil {
+		return "", err
+	}
+
+	name := filepath.Base(openapiFile)
+	name = xstrings.ToSnakeCase(protoIdentifier(strings.TrimSuffix(name, filepath.Ext(name))))
+	protoFile := filepath.Join(outDir, name+".proto")
+	if err = os.MkdirAll(outDir, 0766); err != nil {
+		return "", err
+	}
+	return protoFile, os.WriteFile(protoFile, []byte(c.protoContent(services)), 0666)
+}
+
+func (c *openapiConverter) convert() ([]*protoService, error) {
+	// messages of components are generated even if they are not referenced
+	if c.doc.Components != nil {
+		for _, name := range sortedKeys(c.doc.Components.Schemas) {
+			c.protoType(c.doc.Components.Schemas[name], name)
+		}
+	}
+
+	basePath := ""
+	if len(c.doc.Servers) > 0 {
+		if u, err := url.Parse(c.doc.Servers[0].URL); err == nil {
+			basePath = strings.TrimSuffix(u.Path, "/")
+		}
+	}
+
+	var services []*protoService
+	serviceMap := make(map[string]*protoService)
+	rpcNames := make(map[string]bool)
+	paths := c.doc.Paths.Map()
+	for _, path := range sortedKeys(paths) {
+		pathItem := paths[path]
+		operations := pathItem.Operations()
+		for _, method := range openapiMethods {
+			op, ok := operations[method]
+			if !ok {
+				continue
+			}
+
+			tag := c.serverName
+			if len(op.Tags) > 0 {
+				tag = op.Tags[0]
+			}
+			serviceName := xstrings.FirstRuneToLower(xstrings.ToCamelCase(protoIdentifier(tag)))
+			service, ok := serviceMap[serviceName]
+			if !ok {
+				service = &protoService{Name: serviceName}
+				serviceMap[serviceName] = service
+				services = append(services, service)
+			}
+
+			rpcName := op.OperationID
+			if rpcName == "" {
+				rpcName = strings.ToLower(method) + "_" + path
+			}
+			rpcName = uniqueName(rpcNames, xstrings.ToCamelCase(protoIdentifier(rpcName)))
+
+			rpc := c.convertOperation(rpcName, method, basePath+path, pathItem.Parameters, op)
+			service.RPCs = append(service.RPCs, rpc)
+		}
+	}
+	if len(services) == 0 {
+		return nil, errors.New("no operation found in the openapi file, at least one operation is required")
+	}
+
+	return services, nil
+}
+
+func (c *openapiConverter) convertOperation(rpcName string, method string, path string,
+	pathParams openapi3.Parameters, op *openapi3.Operation) *protoRPC {
+	rpc := &protoRPC{
+		Name:     rpcName,
+		Comment:  op.Summary,
+		Method:   strings.ToLower(method),
+		Summary:  op.Summary,
+		Describe: op.Description,
+	}
+	if rpc.Comment == "" {
+		rpc.Comment = rpcName
+	}
+
+	// request message, includes path parameters, query parameters and the properties of body
+	req := c.newMessage(rpcName + "Request")
+	req.Comment = rpcName + " request"
+	fieldNames := make(map[string]bool)
+	for _, p := range mergeParameters(pathParams, op.Parameters) {
+		if p.Value.In != openapi3.ParameterInPath && p.Value.In != openapi3.ParameterInQuery {
+			continue
+		}
+		fieldName := uniqueName(fieldNames, protoFieldName(p.Value.Name))
+		field := &protoField{
+			Type:    c.protoType(p.Value.Schema, req.Name+xstrings.ToCamelCase(fieldName)),
+			Name:    fieldName,
+			Comment: p.Value.Description,
+		}
+		if p.Value.In == openapi3.ParameterInPath {
+			// the variable of path template must be the field name
+			path = strings.ReplaceAll(path, "{"+p.Value.Name+"}", "{"+fieldName+"}")
+			field.Options = append(field.Options, fmt.Sprintf(`(tagger.tags) = "uri:\"%s\""`, fieldName))
+		} else {
+			field.Options = append(field.Options, fmt.Sprintf(`(tagger.tags) = "form:\"%s\""`, p.Value.Name))
+		}
+		req.Fields = append(req.Fields, field)
+	}
+	if op.RequestBody != nil && op.RequestBody.Value != nil {
+		schema := contentSchema(op.RequestBody.Value.Content)
+		if schema != nil {
+			rpc.HasBody = true
+			if props, required, ok := objectProperties(schema); ok {
+				c.addFields(req, props, required, fieldNames)
+			} else {
+				req.Fields = append(req.Fields, &protoField{
+					Type:    c.protoType(schema, req.Name+"Body"),
+					Name:    uniqueName(fieldNames, "body"),
+					Comment: "the request body is not an object, it is wrapped in the field body",
+				})
+			}
+		}
+	}
+	rpc.Request = req.Name
+	rpc.Path = path
+
+	// reply message, the message of the component schema is used directly
+	schema := successSchema(op.Responses)
+	if schema != nil && c.schemaMessages[schema.Value] != "" {
+		rpc.Reply = c.schemaMessages[schema.Value]
+		return rpc
+	}
+	reply := c.newMessage(rpcName + "Reply")
+	rpc.Reply = reply.Name
+	if schema == nil {
+		return rpc
+	}
+	if props, required, ok := objectProperties(schema); ok {
+		c.addFields(reply, props, required, make(map[string]bool))
+	} else {
+		fieldName := "value"
+		if schema.Value != nil && schema.Value.Type.Includes(openapi3.TypeArray) {
+			fieldName = "list"
+		}
+		reply.Fields = append(reply.Fields, &protoField{Type: c.protoType(schema, reply.Name+"Value"), Name: fieldName})
+	}
+
+	return rpc
+}
+
+// protoType returns the protobuf type of the schema, the object schema is converted to a new message named by name.
+func (c *openapiConverter) protoType(ref *openapi3.SchemaRef, name string) string {
+	if ref == nil || ref.Value == nil {
+		return c.wellKnownType("Value")
+	}
+	s := ref.Value
+	if msgName, ok := c.schemaMessages[s]; ok {
+		return msgName
+	}
+	if ref.Ref != "" {
+		name = ref.Ref[strings.LastIndex(ref.Ref, "/")+1:]
+	}
+
+	if props, required, ok := objectProperties(ref); ok {
+		msg := c.newMessage(name)
+		msg.Comment = s.Description
+		c.schemaMessages[s] = msg.Name // register before adding fields, the schema may refer to itself
+		c.addFields(msg, props, required, make(map[string]bool))
+		return msg.Name
+	}
+
+	switch {
+	case s.Type.Includes(openapi3.TypeInteger):
+		if s.Format == "int32" {
+			return "int32"
+		}
+		return "int64"
+	case s.Type.Includes(openapi3.TypeNumber):
+		if s.Format == "float" {
+			return "float"
+		}
+		return "double"
+	case s.Type.Includes(openapi3.TypeBoolean):
+		return "bool"
+	case s.Type.Includes(openapi3.TypeString):
+		if s.Format == "binary" || s.Format == "byte" {
+			return "bytes"
+		}
+		return "string"
+	case s.Type.Includes(openapi3.TypeArray):
+		itemType := c.protoType(s.Items, name+"Item")
+		if isRepeatedOrMap(itemType) { // nested repeated is not supported by protobuf
+			return "repeated " + c.wellKnownType("ListValue")
+		}
+		return "repeated " + itemType
+	case s.Type.Includes(openapi3.TypeObject) || s.Type == nil && s.AdditionalProperties.Schema != nil:
+		if s.AdditionalProperties.Schema != nil {
+			valueType := c.protoType(s.AdditionalProperties.Schema, name+"Value")
+			if isRepeatedOrMap(valueType) {
+				valueType = c.wellKnownType("Value")
+			}
+			return "map<string, " + valueType + ">"
+		}
+		return c.wellKnownType("Struct")
+	}
+
+	// oneOf, anyOf and the schema without type
+	return c.wellKnownType("Value")
+}
+
+func (c *openapiConverter) addFields(msg *protoMessage, props openapi3.Schemas, required []string, fieldNames map[string]bool) {
+	for _, name := range sortedKeys(props) {
+		prop := props[name]
+		fieldName := uniqueName(fieldNames, protoFieldName(name))
+		field := &protoField{
+			Type: c.protoType(prop, msg.Name+xstrings.ToCamelCase(fieldName)),
+			Name: fieldName,
+		}
+		if prop.Value != nil {
+			field.Comment = prop.Value.Description
+		}
+		if fieldName != name {
+			field.Options = append(field.Options, fmt.Sprintf(`json_name = "%s"`, name),
+				fmt.Sprintf(`(tagger.tags) = "json:\"%s\""`, name))
+		}
+		if isRequired(required, name) {
+			if field.Type == "string" {
+				field.Options = append(field.Options, "(validate.rules).string.min_len = 1")
+			} else if c.isMessage(field.Type) {
+				field.Options = append(field.Options, "(validate.rules).message.required = true")
+			}
+		}
+		msg.Fields = append(msg.Fields, field)
+	}
+}
+
+func (c *openapiConverter) newMessage(name string) *protoMessage {
+	msg := &protoMessage{Name: uniqueName(c.messageNames, xstrings.ToCamelCase(protoIdentifier(name)))}
+	c.messages = append(c.messages, msg)
+	return msg
+}
+
+func (c *openapiConverter) isMessage(typ string) bool {
+	return c.messageNames[typ]
+}
+
+func (c *openapiConverter) wellKnownType(name string) string {
+	c.isUseStruct = true
+	return "google.protobuf." + name
+}
+
+func (c *openapiConverter) protoContent(services []*protoService) string {
+	title, version := c.serverName+" api docs", "v1.0.0"
+	if c.doc.Info != nil {
+		if c.doc.Info.Title != "" {
+			title = c.doc.Info.Title
+		}
+		if c.doc.Info.Version != "" {
+			version = c.doc.Info.Version
+		}
+	}
+
+	b := &strings.Builder{}
+	b.WriteString(`// Code converted from the openapi file, it is the same as the handwritten protobuf file, it can be modified.
+
+syntax = "proto3";
+
+package api.serverNameExample.v1;
+
+import "validate/validate.proto";
+import "google/api/annotations.proto";
+import "protoc-gen-openapiv2/options/annotations.proto";
+import "tagger/tagger.proto";
+`)
+	if c.isUseStruct {
+		b.WriteString("import \"google/protobuf/struct.proto\";\n")
+	}
+	fmt.Fprintf(b, `
+option go_package = "github.com/go-dev-frame/sponge/api/serverNameExample/v1;v1";
+
+option (grpc.gateway.protoc_gen_openapiv2.options.openapiv2_swagger) = {
+  host: "localhost:8080"
+  base_path: ""
+  info: {
+    title: %s;
+    version: %s;
+  }
+  schemes: HTTP;
+  schemes: HTTPS;
+  consumes: "application/json";
+  produces: "application/json";
+};
+`, strconv.Quote(title), strconv.Quote(version))
+
+	for _, service := range services {
+		fmt.Fprintf(b, "\nservice %s {\n", service.Name)
+		for i, rpc := range service.RPCs {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			writeProtoComment(b, "  ", rpc.Comment)
+			fmt.Fprintf(b, "  rpc %s(%s) returns (%s) {\n", rpc.Name, rpc.Request, rpc.Reply)
+			fmt.Fprintf(b, "    option (google.api.http) = {\n      %s: %s\n", rpc.Method, strconv.Quote(rpc.Path))
+			if rpc.HasBody && rpc.Method != "get" && rpc.Method != "delete" {
+				b.WriteString("      body: \"*\"\n")
+			}
+			b.WriteString("    };\n")
+			if rpc.Summary != "" || rpc.Describe != "" {
+				b.WriteString("    option (grpc.gateway.protoc_gen_openapiv2.options.openapiv2_operation) = {\n")
+				if rpc.Summary != "" {
+					fmt.Fprintf(b, "      summary: %s;\n", strconv.Quote(rpc.Summary))
+				}
+				if rpc.Describe != "" {
+					fmt.Fprintf(b, "      description: %s;\n", strconv.Quote(rpc.Describe))
+				}
+				b.WriteString("    };\n")
+			}
+			b.WriteString("  }\n")
+		}
+		b.WriteString("}\n")
+	}
+
+	for _, msg := range c.messages {
+		b.WriteString("\n")
+		writeProtoComment(b, "", msg.Comment)
+		fmt.Fprintf(b, "message %s {\n", msg.Name)
+		for i, field := range msg.Fields {
+			writeProtoComment(b, "  ", field.Comment)
+			options := ""
+			if len(field.Options) > 0 {
+				options = " [" + strings.Join(field.Options, ", ") + "]"
+			}
+			fmt.Fprintf(b, "  %s %s = %d%s;\n", field.Type, field.Name, i+1, options)
+		}
+		b.WriteString("}\n")
+	}
+
+	return b.String()
+}
+
+func writeProtoComment(b *strings.Builder, indent string, comment string) {
+	for _, line := range strings.Split(strings.TrimSpace(comment), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			fmt.Fprintf(b, "%s// %s\n", indent, line)
+		}
+	}
+}
+
+// objectProperties returns the properties of the object schema, the properties of allOf are merged.
+func objectProperties(ref *openapi3.SchemaRef) (openapi3.Schemas, []string, bool) {
+	if ref == nil || ref.Value == nil {
+		return nil, nil, false
+	}
+	s := ref.Value
+	props := make(openapi3.Schemas)
+	required := append([]string{}, s.Required...)
+	for name, prop := range s.Properties {
+		props[name] = prop
+	}
+	for _, sub := range s.AllOf {
+		if subProps, subRequired, ok := objectProperties(sub); ok {
+			for name, prop := range subProps {
+				props[name] = prop
+			}
+			required = append(required, subRequired...)
+		}
+	}
+	return props, required, len(props) > 0
+}
+
+// mergeParameters merge the parameters of path item and operation, the parameters of operation take precedence.
+func mergeParameters(pathParams openapi3.Parameters, opParams openapi3.Parameters) openapi3.Parameters {
+	var params openapi3.Parameters
+	for _, p := range pathParams {
+		if p.Value != nil && opParams.GetByInAndName(p.Value.In, p.Value.Name) == nil {
+			params = append(params, p)
+		}
+	}
+	for _, p := range opParams {
+		if p.Value != nil {
+			params = append(params, p)
+		}
+	}
+	return params
+}
+
+// contentSchema returns the schema of json content, if not found, returns the schema of the first content.
+func contentSchema(content openapi3.Content) *openapi3.SchemaRef {
+	if mt := content.Get("application/json"); mt != nil {
+		return mt.Schema
+	}
+	for _, mime := range sortedKeys(content) {
+		return content[mime].Schema
+	}
+	return nil
+}
+
+// successSchema returns the schema of the first 2xx response, if not found, returns the schema of default response.
+func successSchema(responses *openapi3.Responses) *openapi3.SchemaRef {
+	if responses == nil {
+		return nil
+	}
+	m := responses.Map()
+	for _, code := range sortedKeys(m) {
+		if strings.HasPrefix(code, "2") && m[code].Value != nil {
+			return contentSchema(m[code].Value.Content)
+		}
+	}
+	if r := responses.Default(); r != nil && r.Value != nil {
+		return contentSchema(r.Value.Content)
+	}
+	return nil
+}
+
+var nonIdentifierReg = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+// protoIdentifier replace the characters that can not be used in protobuf identifiers with underscores.
+func protoIdentifier(name string) string {
+	name = strings.Trim(nonIdentifierReg.ReplaceAllString(name, "_"), "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "x_" + name
+	}
+	return name
+}
+
+func protoFieldName(name string) string {
+	return xstrings.ToSnakeCase(protoIdentifier(name))
+}
+
+// uniqueName add a number suffix to the name if it has been used.
+func uniqueName(used map[string]bool, name string) string {
+	newName := name
+	for i := 2; used[newName]; i++ {
+		newName = name + strconv.Itoa(i)
+	}
+	used[newName] = true
+	return newName
+}
+
+func isRequired(required []string, name string) bool {
+	for _, v := range required {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isRepeatedOrMap(typ string) bool {
+	return strings.HasPrefix(typ, "repeated ") || strings.HasPrefix(typ, "map<")
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -571,6 +571,131 @@ make run
 
 点击查看详细的 [**开发指南**](https://go-sponge.com/zh/guide/grpc-gateway/based-on-protobuf.html)。
 
+`
+
+	//nolint
+	workerServerReadmeTmplRaw = `## 技术栈
+
+- 编程语言: go
+- 消息队列: kafka(sarama)
+- 配置管理: viper
+- 日志: zap
+- 监控: prometheus+grafana
+- 链路追踪: opentracing+jaeger
+- 其他: ...
+
+## 目录结构
+
+<BQ><BQ><BQ>text
+.
+├─ cmd                          # 应用程序入口目录
+│   └─ {{.ServerName}}                     # 服务名称
+│       ├─ initial              # 初始化逻辑(如配置加载、服务初始化等)
+│       └─ main.go              # 主程序入口文件
+├─ configs                      # 配置文件目录(yaml 格式配置模板)
+├─ deployments                  # 部署相关脚本(二进制、Docker、K8S 部署)
+├─ internal                     # 内部实现代码(对外不可见)
+│   ├─ config                   # 配置解析和结构体定义
+│   ├─ server                   # 服务启动(消费者组、重试、死信队列、优雅退出)
+│   └─ worker                   # 每个 topic 的消息处理函数
+├─ scripts                      # 实用脚本(如构建、运行、部署等)
+├─ go.mod                       # Go 模块定义文件(声明依赖)
+├─ go.sum                       # Go 模块校验文件(自动生成)
+├─ Makefile                     # 项目构建自动化脚本
+└─ README.md                    # 项目说明文档
+<BQ><BQ><BQ>
+
+服务没有对外 API，只消费消息队列的消息，完整调用链路如下：
+
+<BQ>cmd/{{.ServerName}}/main.go<BQ> → <BQ>internal/server/worker.go<BQ> → <BQ>internal/worker<BQ>
+
+- 每个 topic 的处理函数在 <BQ>internal/worker<BQ> 目录下通过 <BQ>register<BQ> 注册，新增 topic 时添加一个处理函数文件即可。
+- 处理函数返回错误时，消息发送到重试 topic(默认 <BQ><groupID>.retry<BQ>)，按退避时间重试，超过最大次数后发送到死信 topic(默认 <BQ><groupID>.dlq<BQ>)。
+- 处理函数需要保证幂等，消息可能被重复投递。
+- 服务退出时停止拉取消息，并等待正在处理的消息完成(最长 <BQ>shutdownTimeout<BQ> 秒)。
+
+## 快速开始
+
+### 1. 修改配置
+
+在配置文件 <BQ>configs/{{.ServerName}}.yml<BQ> 中修改 <BQ>worker.kafka<BQ> 的 kafka 地址和消费者组。
+
+### 2. 编译和运行
+
+<BQ><BQ><BQ>bash
+make run
+<BQ><BQ><BQ>
+
+### 3. 查看监控指标
+
+在浏览器访问 [http://localhost:8283/metrics](http://localhost:8283/metrics)，查看消息处理的监控指标，健康检查地址为 [http://localhost:8283/health](http://localhost:8283/health)。
+
+`
+
+	//nolint
+	cronServerReadmeTmplRaw = `## 技术栈
+
+- 编程语言: go
+- 定时任务: robfig/cron
+- 配置管理: viper
+- 日志: zap
+- 监控: prometheus+grafana
+- 链路追踪: opentracing+jaeger
+- 其他: ...
+
+## 目录结构
+
+<BQ><BQ><BQ>text
+.
+├─ cmd                          # 应用程序入口目录
+│   └─ {{.ServerName}}                     # 服务名称
+│       ├─ initial              # 初始化逻辑(如配置加载、服务初始化等)
+│       └─ main.go              # 主程序入口文件
+├─ configs                      # 配置文件目录(yaml 格式配置模板)
+├─ deployments                  # 部署相关脚本(二进制、Docker、K8S 部署)
+├─ internal                     # 内部实现代码(对外不可见)
+│   ├─ config                   # 配置解析和结构体定义
+│   ├─ job                      # 定时任务，每个任务一个文件
+│   └─ server                   # 服务启动(任务调度、管理接口、监控指标、优雅退出)
+├─ scripts                      # 实用脚本(如构建、运行、部署等)
+├─ go.mod                       # Go 模块定义文件(声明依赖)
+├─ go.sum                       # Go 模块校验文件(自动生成)
+├─ Makefile                     # 项目构建自动化脚本
+└─ README.md                    # 项目说明文档
+<BQ><BQ><BQ>
+
+服务按时间表执行定时任务，完整调用链路如下：
+
+<BQ>cmd/{{.ServerName}}/main.go<BQ> → <BQ>internal/server/cron.go<BQ> → <BQ>internal/job<BQ>
+
+- 每个任务在 <BQ>internal/job<BQ> 目录下通过 <BQ>register<BQ> 注册，包括任务名称、执行时间表(支持秒级 cron 表达式)、超时时间和是否单例运行。
+- 单例任务在上一次执行未完成时跳过本次执行。
+- 配置文件中的 <BQ>cron.jobs<BQ> 可以按任务名称覆盖时间表、超时时间和暂停状态，无需修改代码。
+- 服务退出时停止调度，并等待正在执行的任务完成(最长 <BQ>shutdownTimeout<BQ> 秒)，超时后通过 ctx 取消任务。
+
+## 快速开始
+
+### 1. 编译和运行
+
+<BQ><BQ><BQ>bash
+make run
+<BQ><BQ><BQ>
+
+### 2. 管理任务
+
+| 接口 | 说明 |
+| --- | --- |
+| <BQ>GET /jobs<BQ> | 查看任务列表，包括下次执行时间、上次执行时间、耗时和错误信息 |
+| <BQ>POST /jobs/{name}/trigger<BQ> | 立即执行一次任务，暂停的任务也可以手动执行 |
+| <BQ>POST /jobs/{name}/pause<BQ> | 暂停任务的定时执行 |
+| <BQ>POST /jobs/{name}/resume<BQ> | 恢复任务的定时执行 |
+
+管理接口的端口为 8283，如果设置了 <BQ>cron.adminToken<BQ>，请求头需要携带 <BQ>Authorization: Bearer <token><BQ>。
+
+### 3. 查看监控指标
+
+在浏览器访问 [http://localhost:8283/metrics](http://localhost:8283/metrics)，查看任务的执行次数、耗时和上次执行时间等监控指标。
+
 `
 )
 
@@ -656,6 +781,16 @@ func (r *readmeTemp) genReadmeContent() (string, error) {
 		if err != nil {
 			return readmeContent, err
 		}
+	case codeNameWorker:
+		readmeTemplate, err = template.New(r.ServerType).Parse(workerServerReadmeTmplRaw)
+		if err != nil {
+			return readmeContent, err
+		}
+	case codeNameCron:
+		readmeTemplate, err = template.New(r.ServerType).Parse(cronServerReadmeTmplRaw)
+		if err != nil {
+			return readmeContent, err
+		}
 	}
 
 	builder := strings.Builder{}
@@ -31,7 +31,9 @@ func RPCCommand() *cobra.Command {
 			GormType: true,
 		}
 
-		suitedMonoRepo bool // whether the generated code is suitable for mono-repo
+		suitedMonoRepo bool   // whether the generated code is suitable for mono-repo
+		ciType         string // ci/cd pipeline type, support github, gitlab
+		isOutbox       bool   // whether to generate the outbox relay code
 	)
 
 	//nolint
@@ -48,16 +50,26 @@ func RPCCommand() *cobra.Command {
   # Generate grpc server code with extended api.
   sponge micro rpc --module-name=yourModuleName --server-name=yourServerName --project-name=yourProjectName --db-driver=mysql --db-dsn=root:123456@(192.168.3.37:3306)/test --db-table=user --extended-api=true
 
+  # Generate grpc server code with the relay code of transactional outbox.
+  sponge micro rpc --module-name=yourModuleName --server-name=yourServerName --project-name=yourProjectName --db-driver=mysql --db-dsn=root:123456@(192.168.3.37:3306)/test --db-table=user --outbox=true
+
   # Generate grpc server code and specify the output directory, Note: code generation will be canceled when the latest generated file already exists.
   sponge micro rpc --module-name=yourModuleName --server-name=yourServerName --project-name=yourProjectName --db-driver=mysql --db-dsn=root:123456@(192.168.3.37:3306)/test --db-table=user --out=./yourServerDir
 
   # Generate grpc server code and specify the docker image repository address.
   sponge micro rpc --module-name=yourModuleName --server-name=yourServerName --project-name=yourProjectName --repo-addr=192.168.3.37:9443/user-name --db-driver=mysql --db-dsn=root:123456@(192.168.3.37:3306)/test --db-table=user
 
+  # Generate code with the ci/cd pipeline of github actions (or gitlab ci), the environments are deployed to k8s by kustomize overlays.
+  sponge micro rpc --module-name=yourModuleName --server-name=yourServerName --project-name=yourProjectName --repo-addr=192.168.3.37:9443/user-name --db-driver=mysql --db-dsn=root:123456@(192.168.3.37:3306)/test --db-table=user --ci=github
+
   # If you want the generated code to suited to mono-repo, you need to set the parameter --suited-mono-repo=true`),
 		SilenceErrors: true,
 		SilenceUsage:  true,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkCIType(ciType); err != nil {
+				return err
+			}
+
 			var err error
 			var firstTable string
 			var servicesTableNames []string
@@ -99,6 +111,7 @@ func RPCCommand() *cobra.Command {
 				outPath:       outPath,
 
 				suitedMonoRepo: suitedMonoRepo,
+				isOutbox:       isOutbox,
 			}
 			outPath, err = g.generateCode()
 			if err != nil {
@@ -142,7 +155,7 @@ using help:
 			fmt.Printf("generate %s's grpc server code successfully, out = %s\n", serverName, outPath)
 
 			_ = generateConfigmap(serverName, outPath)
-			return nil
+			return generateCIFiles(ciType, serverName, projectName, repoAddr, suitedMonoRepo, outPath)
 		},
 	}
 
@@ -160,8 +173,10 @@ using help:
 	cmd.Flags().BoolVarP(&sqlArgs.IsEmbed, "embed", "e", false, "whether to embed gorm.model struct")
 	cmd.Flags().BoolVarP(&sqlArgs.IsExtendedAPI, "extended-api", "a", false, "whether to generate extended crud api, additional includes: DeleteByIDs, GetByCondition, ListByIDs, ListByLatestID")
 	cmd.Flags().BoolVarP(&suitedMonoRepo, "suited-mono-repo", "l", false, "whether the generated code is suitable for mono-repo")
+	cmd.Flags().BoolVarP(&isOutbox, "outbox", "", false, "whether to generate the relay code of transactional outbox, messages are saved in the business transaction and published to message queues, mongodb is not supported")
 	cmd.Flags().IntVarP(&sqlArgs.JSONNamedType, "json-name-type", "j", 1, "json tags name type, 0:snake case, 1:camel case")
 	cmd.Flags().StringVarP(&repoAddr, "repo-addr", "r", "", "docker image repository address, excluding http and repository names")
+	cmd.Flags().StringVarP(&ciType, "ci", "", "", "generate the ci/cd pipeline file and kustomize overlays of environments (dev, test, prod), support github, gitlab")
 	cmd.Flags().StringVarP(&outPath, "out", "o", "", "output directory, default is ./serverName_rpc_<time>")
 
 	return cmd
@@ -179,6 +194,7 @@ type rpcGenerator struct {
 	codes          map[string]string
 	outPath        string
 	suitedMonoRepo bool
+	isOutbox       bool
 
 	fields        []replacer.Field
 	isCommonStyle bool
@@ -215,7 +231,7 @@ func (g *rpcGenerator) generateCode() (string, error) {
 			"serverNameExample.go",
 		},
 		"internal/dao": {
-			"userExample.go", "userExample_test.go",
+			"userExample.go", "userExample_test.go", "userExample_integration_test.go",
 		},
 		"internal/database": {
 			"init.go",
@@ -230,13 +246,18 @@ func (g *rpcGenerator) generateCode() (string, error) {
 			"grpc.go", "grpc_option.go",
 		},
 		"internal/service": {
-			"service.go", "service_test.go", "userExample.go", /*"userExample_client_test.go",*/
+			"service.go", "service_test.go", "tx.go", "userExample.go", /*"userExample_client_test.go",*/
 		},
 	}
 	err := SetSelectFiles(g.dbDriver, selectFiles)
 	if err != nil {
 		return "", err
 	}
+	if g.isOutbox {
+		if err = AddOutboxFile(g.dbDriver, selectFiles); err != nil {
+			return "", err
+		}
+	}
 
 	info := g.codes[parser.CodeTypeCrudInfo]
 	crudInfo, _ := unmarshalCrudInfo(info)
@@ -245,12 +266,12 @@ func (g *rpcGenerator) generateCode() (string, error) {
 		selectFiles["internal/cache"] = []string{"userExample.go.tpl"}
 		selectFiles["internal/dao"] = []string{"userExample.go.tpl"}
 		selectFiles["internal/ecode"] = []string{"systemCode_rpc.go", "userExample_rpc.go.tpl"}
-		selectFiles["internal/service"] = []string{"service.go", "service_test.go", "userExample.go.tpl"}
+		selectFiles["internal/service"] = []string{"service.go", "service_test.go", "tx.go", "userExample.go.tpl"}
 		var fields []replacer.Field
 		if g.isExtendedAPI {
 			selectFiles["internal/dao"] = []string{"userExample.go.exp.tpl"}
 			selectFiles["internal/ecode"] = []string{"systemCode_rpc.go", "userExample_rpc.go.exp.tpl"}
-			selectFiles["internal/service"] = []string{"service.go", "service_test.go", "userExample.go.exp.tpl"}
+			selectFiles["internal/service"] = []string{"service.go", "service_test.go", "tx.go", "userExample.go.exp.tpl"}
 			fields = commonGRPCExtendedFields(r)
 		} else {
 			fields = commonGRPCFields(r)
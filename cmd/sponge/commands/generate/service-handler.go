@@ -172,7 +172,7 @@ func (g *serviceAndHandlerGenerator) generateCode() (string, error) {
 			"userExample.go", "userExample_test.go",
 		},
 		"internal/dao": {
-			"userExample.go", "userExample_test.go",
+			"userExample.go", "userExample_test.go", "userExample_integration_test.go",
 		},
 		"internal/handler": {
 			"userExample.go.service",
@@ -181,7 +181,7 @@ func (g *serviceAndHandlerGenerator) generateCode() (string, error) {
 			"userExample.go",
 		},
 		"internal/service": {
-			"userExample.go", /*"userExample_client_test.go",*/
+			"tx.go", "userExample.go", /*"userExample_client_test.go",*/
 		},
 	}
 
@@ -206,14 +206,14 @@ func (g *serviceAndHandlerGenerator) generateCode() (string, error) {
 				"userExample.go",
 			},
 			"internal/service": {
-				"userExample.go.tpl",
+				"tx.go", "userExample.go.tpl",
 			},
 		}
 		var fields []replacer.Field
 		if g.isExtendedAPI {
 			selectFiles["internal/dao"] = []string{"userExample.go.exp.tpl"}
 			selectFiles["internal/handler"] = []string{"userExample.go.service.exp.tpl"}
-			selectFiles["internal/service"] = []string{"userExample.go.exp.tpl"}
+			selectFiles["internal/service"] = []string{"tx.go", "userExample.go.exp.tpl"}
 			fields = commonServiceHandlerExtendedFields(r)
 		} else {
 			fields = commonServiceHandlerFields(r)
@@ -399,7 +399,7 @@ func (g *serviceAndHandlerGenerator) addFields(r replacer.Replacer) []replacer.F
 func serviceHandlerExtendedAPI(r replacer.Replacer) (map[string][]string, []replacer.Field) {
 	replaceFiles := map[string][]string{
 		"internal/dao": {
-			"userExample.go.exp", "userExample_test.go.exp",
+			"userExample.go.exp", "userExample_test.go.exp", "userExample_integration_test.go",
 		},
 		"internal/ecode": {
 			"userExample_rpc.go.exp",
@@ -408,7 +408,7 @@ func serviceHandlerExtendedAPI(r replacer.Replacer) (map[string][]string, []repl
 			"userExample.go.service.exp",
 		},
 		"internal/service": {
-			"userExample.go.exp", /*"userExample_client_test.go.exp",*/
+			"tx.go", "userExample.go.exp", /*"userExample_client_test.go.exp",*/
 		},
 	}
 
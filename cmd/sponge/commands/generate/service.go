@@ -170,7 +170,7 @@ func (g *serviceGenerator) generateCode() (string, error) {
 			"userExample.go", "userExample_test.go",
 		},
 		"internal/dao": {
-			"userExample.go", "userExample_test.go",
+			"userExample.go", "userExample_test.go", "userExample_integration_test.go",
 		},
 		"internal/ecode": {
 			"userExample_rpc.go",
@@ -179,7 +179,7 @@ func (g *serviceGenerator) generateCode() (string, error) {
 			"userExample.go",
 		},
 		"internal/service": {
-			"userExample.go", /*"userExample_client_test.go",*/
+			"tx.go", "userExample.go", /*"userExample_client_test.go",*/
 		},
 	}
 
@@ -204,14 +204,14 @@ func (g *serviceGenerator) generateCode() (string, error) {
 				"userExample.go",
 			},
 			"internal/service": {
-				"userExample.go.tpl",
+				"tx.go", "userExample.go.tpl",
 			},
 		}
 		var fields []replacer.Field
 		if g.isExtendedAPI {
 			selectFiles["internal/dao"] = []string{"userExample.go.exp.tpl"}
 			selectFiles["internal/ecode"] = []string{"userExample_rpc.go.exp.tpl"}
-			selectFiles["internal/service"] = []string{"userExample.go.exp.tpl"}
+			selectFiles["internal/service"] = []string{"tx.go", "userExample.go.exp.tpl"}
 			fields = commonServiceExtendedFields(r)
 		} else {
 			fields = commonServiceFields(r)
@@ -376,18 +376,18 @@ func (g *serviceGenerator) addFields(r replacer.Replacer) []replacer.Field {
 func serviceExtendedAPI(r replacer.Replacer, codeName string) (map[string][]string, []replacer.Field) {
 	replaceFiles := map[string][]string{
 		"internal/dao": {
-			"userExample.go.exp", "userExample_test.go.exp",
+			"userExample.go.exp", "userExample_test.go.exp", "userExample_integration_test.go",
 		},
 		"internal/ecode": {
 			"systemCode_rpc.go", "userExample_rpc.go.exp",
 		},
 		"internal/service": {
-			"service.go", "service_test.go", "userExample.go.exp", /*"userExample_client_test.go.exp",*/
+			"service.go", "service_test.go", "tx.go", "userExample.go.exp", /*"userExample_client_test.go.exp",*/
 		},
 	}
 	if codeName == codeNameService {
 		replaceFiles["internal/ecode"] = []string{"userExample_rpc.go.exp"}
-		replaceFiles["internal/service"] = []string{"userExample.go.exp" /*"userExample_client_test.go.exp"*/}
+		replaceFiles["internal/service"] = []string{"tx.go", "userExample.go.exp" /*"userExample_client_test.go.exp"*/}
 	}
 
 	var fields []replacer.Field
@@ -182,6 +182,64 @@ fi`
       port: 8282
       targetPort: 8282
     - name: server-name-example-svc-grpc-metrics-port
+      port: 8283
+      targetPort: 8283`
+
+	// the deployment of the services without api, e.g. worker and cron, only the port of metrics,
+	// health check and admin api is exposed
+	dockerFileMetricsCode = `# add curl, used for http service checking, can be installed without it if deployed in k8s
+RUN apk add curl
+
+COPY configs/ /app/configs/
+COPY serverNameExample /app/serverNameExample
+RUN chmod +x /app/serverNameExample
+
+# metrics and health check port
+EXPOSE 8283`
+
+	dockerFileBuildMetricsCode = `# compressing binary files
+#cd /
+#upx -9 serverNameExample
+
+
+# building images with binary
+FROM alpine:latest
+MAINTAINER zhufuyi "g.zhufuyi@gmail.com"
+
+# add curl, used for http service checking, can be installed without it if deployed in k8s
+RUN apk add curl
+
+COPY --from=build /serverNameExample /app/serverNameExample
+COPY --from=build /go/src/serverNameExample/configs/serverNameExample.yml /app/configs/serverNameExample.yml
+
+# metrics and health check port
+EXPOSE 8283`
+
+	dockerComposeFileMetricsCode = `    ports:
+      - "8283:8283"   # metrics and health check port
+    healthcheck:
+      test: ["CMD", "curl", "-f", "http://localhost:8283/health"]   # http health check, note: mirror must contain curl command`
+
+	k8sDeploymentFileMetricsCode = `
+          ports:
+            - name: metrics-port
+              containerPort: 8283
+          readinessProbe:
+            httpGet:
+              port: metrics-port
+              path: /health
+            initialDelaySeconds: 10
+            timeoutSeconds: 2
+            periodSeconds: 10
+            successThreshold: 1
+            failureThreshold: 3
+          livenessProbe:
+            httpGet:
+              port: metrics-port
+              path: /health`
+
+	k8sServiceFileMetricsCode = `  ports:
+    - name: server-name-example-svc-metrics-port
       port: 8283
       targetPort: 8283`
 
@@ -190,14 +248,26 @@ fi`
 package config
 
 import (
+	"flag"
+	"os"
+
 	"github.com/go-dev-frame/sponge/pkg/conf"
 )
 
 var config *Config
 
+// Init loads the configuration file, values can be overridden by environment variables
+// (e.g. SPONGE_HTTP_PORT), a command line flag overrides the value of the same path only if
+// it is defined before flag.Parse (e.g. flag.Int("http.port", 8080, "")), flags are not
+// registered for the configuration paths, the effective values are printed at startup.
 func Init(configFile string, fs ...func()) error {
 	config = &Config{}
-	return conf.Parse(configFile, config, fs...)
+	return conf.Load(config,
+		conf.WithConfigFile(configFile),
+		conf.WithFlags(flag.CommandLine),
+		conf.WithWatch(fs...),
+		conf.WithDiagnostics(os.Stdout),
+	)
 }
 
 func Show(hiddenFields ...string) string {
@@ -359,6 +429,8 @@ func NewCenter(configFile string) (*Center, error) {
 http:
   port: 8080                # listen port
   timeout: 0                 # request timeout, unit(second), if 0 means not set, if greater than 0 means set timeout, if enableHTTPProfile is true, it needs to set 0 or greater than 60s
+  maxConns: 0               # maximum number of concurrent connections, the requests over the limit are rejected with 503 and Retry-After, if 0 means no limit
+  maxRequests: 0            # maximum number of requests handled concurrently, the requests over the limit are rejected with 503 and Retry-After, if 0 means no limit
   tls:
     # TLS mode options:
     #   self-signed  - Use localhost self-signed certificate
@@ -368,13 +440,17 @@ http:
     domain: ""        # Required if enableMode = encrypt
     email: ""         # Required if enableMode = encrypt
     certFile: ""      # Required if enableMode = external, absolute path of cert file
-    keyFile: ""       # Required if enableMode = external, absolute path of key file`
+    keyFile: ""       # Required if enableMode = external, absolute path of key file
+    httpPort: 0       # If greater than 0 and enableMode is not empty, also listen on this http port
+    httpRedirect: false # If true, requests to httpPort are redirected to https, otherwise served over plain http`
 
 	rpcServerConfigCode = `# grpc server settings
 grpc:
   port: 8282                # listen port
   httpPort: 8283            # profile and metrics ports
   enableToken: false        # whether to enable server-side token authentication, default appID=grpc, appKey=123456
+  disableHealth: false      # whether to disable the grpc health service, it is registered by default, the status is NOT_SERVING if the database or redis is unreachable
+  enableReflection: false   # whether to enable server reflection for tools such as grpcurl, it is recommended to disable it in production
   # serverSecure parameter setting
   # if type="", it means no secure connection, no need to fill in any parameters
   # if type="one-way", it means server-side certification, only the fields 'certFile' and 'keyFile' should be filled in
@@ -392,7 +468,17 @@ grpcClient:
     host: "127.0.0.1"            # grpc service address, used for direct connection
     port: 8282                   # grpc service port
     timeout: 0                   # request timeout, unit(second), if 0 means not set, if greater than 0 means set timeout, valid only for unary grpc type
-    registryDiscoveryType: ""    # registration and discovery types: consul, etcd, nacos, if empty, connecting to server using host and port
+    registryDiscoveryType: ""    # registration and discovery types: consul, etcd, nacos, kubernetes, if empty, connecting to server using host and port
+    loadBalance: ""              # client-side load balancing policy: round_robin, least_request, pick_first, if empty, round_robin is used with service discovery
+    # per-method request timeouts, they override the above timeout, valid only for unary grpc type
+    methodTimeouts:
+      #- name: "/api.serverNameExample.v1.UserExample/GetByID"  # full method name or service name
+      #  timeout: 500        # unit(millisecond)
+    # keepalive settings, if time is 0 means not set
+    keepalive:
+      time: 0               # send a ping to server after this period of no activity, unit(second), it should not be less than the server's minimum ping interval
+      timeout: 20           # close the connection if the ping ack is not received within this period, unit(second)
+      permitWithoutStream: false  # whether to send pings even if there are no active streams
     # clientSecure parameter setting
     # if type="", it means no secure connection, no need to fill in any parameters
     # if type="one-way", it means server-side certification, only the fields 'serverName' and 'certFile' should be filled in
@@ -406,12 +492,26 @@ grpcClient:
     clientToken:
       enable: false         # whether to enable token authentication
       appID: ""             # app id
-      appKey: ""            # app key`
+      appKey: ""            # app key
+    # retry settings, valid only for unary grpc type, requests failed with retryable codes are retried with exponential backoff
+    retry:
+      enable: false         # whether to enable retry
+      maxAttempts: 3        # maximum number of attempts, including the first request, max 10
+      initialBackoff: 100   # backoff before the first retry, unit(millisecond), the backoff is doubled for each retry
+      maxBackoff: 1000      # maximum backoff, unit(millisecond)
+      retryableCodes: ["UNAVAILABLE"]  # grpc status codes that trigger a retry
+      # per-method policies, the settings not filled in are inherited from the above, name is the full method name or service name
+      methods:
+        #- name: "/api.serverNameExample.v1.UserExample/GetByID"
+        #  maxAttempts: 3
+        #  hedgingDelay: 50  # if greater than 0, send a hedged request every hedgingDelay milliseconds until a response succeeds, only for idempotent methods, unit(millisecond)`
 
 	rpcGwServerConfigCode = `# http server settings
 http:
   port: 8080                # listen port
   timeout: 0                 # request timeout, unit(second), if 0 means not set, if greater than 0 means set timeout, if enableHTTPProfile is true, it needs to set 0 or greater than 60s
+  maxConns: 0               # maximum number of concurrent connections, the requests over the limit are rejected with 503 and Retry-After, if 0 means no limit
+  maxRequests: 0            # maximum number of requests handled concurrently, the requests over the limit are rejected with 503 and Retry-After, if 0 means no limit
   tls:
     # TLS mode options:
     #   self-signed  - Use localhost self-signed certificate
@@ -422,6 +522,8 @@ http:
     email: ""         # Required if enableMode = encrypt
     certFile: ""      # Required if enableMode = external, absolute path of cert file
     keyFile: ""       # Required if enableMode = external, absolute path of key file
+    httpPort: 0       # If greater than 0 and enableMode is not empty, also listen on this http port
+    httpRedirect: false # If true, requests to httpPort are redirected to https, otherwise served over plain http
 
 
 # grpc client-side settings, support for setting up multiple grpc clients.
@@ -430,7 +532,17 @@ grpcClient:
     host: "127.0.0.1"            # grpc service address, used for direct connection
     port: 8282                   # grpc service port
     timeout: 0                   # request timeout, unit(second), if 0 means not set, if greater than 0 means set timeout, valid only for unary grpc type
-    registryDiscoveryType: ""    # registration and discovery types: consul, etcd, nacos, if empty, connecting to server using host and port
+    registryDiscoveryType: ""    # registration and discovery types: consul, etcd, nacos, kubernetes, if empty, connecting to server using host and port
+    loadBalance: ""              # client-side load balancing policy: round_robin, least_request, pick_first, if empty, round_robin is used with service discovery
+    # per-method request timeouts, they override the above timeout, valid only for unary grpc type
+    methodTimeouts:
+      #- name: "/api.serverNameExample.v1.UserExample/GetByID"  # full method name or service name
+      #  timeout: 500        # unit(millisecond)
+    # keepalive settings, if time is 0 means not set
+    keepalive:
+      time: 0               # send a ping to server after this period of no activity, unit(second), it should not be less than the server's minimum ping interval
+      timeout: 20           # close the connection if the ping ack is not received within this period, unit(second)
+      permitWithoutStream: false  # whether to send pings even if there are no active streams
     # clientSecure parameter setting
     # if type="", it means no secure connection, no need to fill in any parameters
     # if type="one-way", it means server-side certification, only the fields 'serverName' and 'certFile' should be filled in
@@ -444,12 +556,26 @@ grpcClient:
     clientToken:
       enable: false         # whether to enable token authentication
       appID: ""             # app id
-      appKey: ""            # app key`
+      appKey: ""            # app key
+    # retry settings, valid only for unary grpc type, requests failed with retryable codes are retried with exponential backoff
+    retry:
+      enable: false         # whether to enable retry
+      maxAttempts: 3        # maximum number of attempts, including the first request, max 10
+      initialBackoff: 100   # backoff before the first retry, unit(millisecond), the backoff is doubled for each retry
+      maxBackoff: 1000      # maximum backoff, unit(millisecond)
+      retryableCodes: ["UNAVAILABLE"]  # grpc status codes that trigger a retry
+      # per-method policies, the settings not filled in are inherited from the above, name is the full method name or service name
+      methods:
+        #- name: "/api.serverNameExample.v1.UserExample/GetByID"
+        #  maxAttempts: 3
+        #  hedgingDelay: 50  # if greater than 0, send a hedged request every hedgingDelay milliseconds until a response succeeds, only for idempotent methods, unit(millisecond)`
 
 	grpcAndHTTPServerConfigCode = `# http server settings
 http:
   port: 8080                # listen port
   timeout: 0                 # request timeout, unit(second), if 0 means not set, if greater than 0 means set timeout, if enableHTTPProfile is true, it needs to set 0 or greater than 60s
+  maxConns: 0               # maximum number of concurrent connections, the requests over the limit are rejected with 503 and Retry-After, if 0 means no limit
+  maxRequests: 0            # maximum number of requests handled concurrently, the requests over the limit are rejected with 503 and Retry-After, if 0 means no limit
   tls:
     # TLS mode options:
     #   self-signed  - Use localhost self-signed certificate
@@ -460,6 +586,8 @@ http:
     email: ""         # Required if enableMode = encrypt
     certFile: ""      # Required if enableMode = external, absolute path of cert file
     keyFile: ""       # Required if enableMode = external, absolute path of key file
+    httpPort: 0       # If greater than 0 and enableMode is not empty, also listen on this http port
+    httpRedirect: false # If true, requests to httpPort are redirected to https, otherwise served over plain http
 
 
 # grpc server settings
@@ -467,6 +595,8 @@ grpc:
   port: 8282                # listen port
   httpPort: 8283            # profile and metrics ports
   enableToken: false        # whether to enable server-side token authentication, default appID=grpc, appKey=123456
+  disableHealth: false      # whether to disable the grpc health service, it is registered by default, the status is NOT_SERVING if the database or redis is unreachable
+  enableReflection: false   # whether to enable server reflection for tools such as grpcurl, it is recommended to disable it in production
   # serverSecure parameter setting
   # if type="", it means no secure connection, no need to fill in any parameters
   # if type="one-way", it means server-side certification, only the fields 'certFile' and 'keyFile' should be filled in
@@ -484,7 +614,17 @@ grpcClient:
     host: "127.0.0.1"            # grpc service address, used for direct connection
     port: 8282                   # grpc service port
     timeout: 0                   # request timeout, unit(second), if 0 means not set, if greater than 0 means set timeout, valid only for unary grpc type
-    registryDiscoveryType: ""    # registration and discovery types: consul, etcd, nacos, if empty, connecting to server using host and port
+    registryDiscoveryType: ""    # registration and discovery types: consul, etcd, nacos, kubernetes, if empty, connecting to server using host and port
+    loadBalance: ""              # client-side load balancing policy: round_robin, least_request, pick_first, if empty, round_robin is used with service discovery
+    # per-method request timeouts, they override the above timeout, valid only for unary grpc type
+    methodTimeouts:
+      #- name: "/api.serverNameExample.v1.UserExample/GetByID"  # full method name or service name
+      #  timeout: 500        # unit(millisecond)
+    # keepalive settings, if time is 0 means not set
+    keepalive:
+      time: 0               # send a ping to server after this period of no activity, unit(second), it should not be less than the server's minimum ping interval
+      timeout: 20           # close the connection if the ping ack is not received within this period, unit(second)
+      permitWithoutStream: false  # whether to send pings even if there are no active streams
     # clientSecure parameter setting
     # if type="", it means no secure connection, no need to fill in any parameters
     # if type="one-way", it means server-side certification, only the fields 'serverName' and 'certFile' should be filled in
@@ -498,11 +638,24 @@ grpcClient:
     clientToken:
       enable: false         # whether to enable token authentication
       appID: ""             # app id
-      appKey: ""            # app key`
+      appKey: ""            # app key
+    # retry settings, valid only for unary grpc type, requests failed with retryable codes are retried with exponential backoff
+    retry:
+      enable: false         # whether to enable retry
+      maxAttempts: 3        # maximum number of attempts, including the first request, max 10
+      initialBackoff: 100   # backoff before the first retry, unit(millisecond), the backoff is doubled for each retry
+      maxBackoff: 1000      # maximum backoff, unit(millisecond)
+      retryableCodes: ["UNAVAILABLE"]  # grpc status codes that trigger a retry
+      # per-method policies, the settings not filled in are inherited from the above, name is the full method name or service name
+      methods:
+        #- name: "/api.serverNameExample.v1.UserExample/GetByID"
+        #  maxAttempts: 3
+        #  hedgingDelay: 50  # if greater than 0, send a hedged request every hedgingDelay milliseconds until a response succeeds, only for idempotent methods, unit(millisecond)`
 
 	mysqlConfigCode = `# database setting
 database:
   driver: "mysql"           # database driver
+  autoMigrate: false        # whether to apply the migration files in internal/database/migrations when the service starts
   # mysql settings
   mysql:
     # dsn format,  <username>:<password>@(<hostname>:<port>)/<db>?[k=v& ......]
@@ -519,6 +672,7 @@ database:
 
 	postgresqlConfigCode = `database:
   driver: "postgresql"      # database driver
+  autoMigrate: false        # whether to apply the migration files in internal/database/migrations when the service starts
   # postgresql settings
   postgresql:
     # dsn format,  <username>:<password>@<hostname>:<port>/<db>?[k=v& ......]
@@ -530,6 +684,7 @@ database:
 
 	sqliteConfigCode = `database:
   driver: "sqlite"      # database driver
+  autoMigrate: false        # whether to apply the migration files in internal/database/migrations when the service starts
   # sqlite settings
   sqlite:
     dbFile: "test/sql/sqlite/sponge.db"
@@ -550,6 +705,7 @@ database:
 	undeterminedDatabaseConfigCode = `# set database configuration. reference-db-config-url
 database:
   driver: "mysql"           # database driver
+  autoMigrate: false        # whether to apply the migration files in internal/database/migrations when the service starts
   # mysql settings
   mysql:
     # dsn format,  <username>:<password>@(<hostname>:<port>)/<db>?[k=v& ......]
@@ -570,6 +726,8 @@ func InitDB() {
 		panic("InitDB error, please modify the correct 'database' configuration at yaml file. " +
 			"Refer to https://github.com/go-dev-frame/sponge/blob/main/configs/serverNameExample.yml#L85")
 	}
+
+	autoMigrate()
 }`
 
 	modelInitDBFilePostgresqlCode = `// InitDB connect database
@@ -582,6 +740,8 @@ func InitDB() {
 		panic("InitDB error, please modify the correct 'database' configuration at yaml file. " +
 			"Refer to https://github.com/go-dev-frame/sponge/blob/main/configs/serverNameExample.yml#L85")
 	}
+
+	autoMigrate()
 }`
 
 	modelInitDBFileSqliteCode = `// InitDB connect database
@@ -594,6 +754,8 @@ func InitDB() {
 		panic("InitDB error, please modify the correct 'database' configuration at yaml file. " +
 			"Refer to https://github.com/go-dev-frame/sponge/blob/main/configs/serverNameExample.yml#L85")
 	}
+
+	autoMigrate()
 }`
 
 	embedTimeCode = `value.CreatedAt = record.CreatedAt.Format(time.RFC3339)
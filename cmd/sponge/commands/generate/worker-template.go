@@ -0,0 +1,48 @@
+package generate
+
+var (
+	// workerServerConfigCode the configuration of worker service
+	workerServerConfigCode = `# worker settings, the messages of topics are consumed from kafka by consumer group,
+# the handlers of topics are registered in internal/worker
+worker:
+  httpPort: 8283            # port of prometheus metrics /metrics and health check /health, if 0, the http server is not started
+  concurrency: 10           # maximum number of messages handled concurrently
+  maxAttempts: 3            # maximum number of attempts of handling a message, including the first attempt, then the message is routed to the dead-letter topic
+  initialBackoff: 1000      # backoff before the first retry, unit(millisecond), the backoff is doubled for each retry
+  maxBackoff: 60000         # maximum backoff, unit(millisecond)
+  handleTimeout: 0          # timeout of handling a message, unit(millisecond), if 0 means not set
+  retryTopic: ""            # topic of the retry queue, it is consumed by the worker too, if empty, default is <groupID>.retry
+  deadLetterTopic: ""       # topic of the dead-letter queue, if empty, default is <groupID>.dlq
+  shutdownTimeout: 30       # maximum time to wait for the messages being handled when the service exits, unit(second)
+  kafka:
+    addrs: ["192.168.3.37:9092"]    # kafka broker addresses
+    groupID: "serverNameExample"    # consumer group id
+    offsetsInitial: "newest"        # where to start consuming if there is no committed offset, newest or oldest`
+
+	// workerHandlerCode the handler of a topic, topicNameExample and TopicNameExample are replaced
+	workerHandlerCode = `package worker
+
+import (
+	"context"
+
+	"github.com/go-dev-frame/sponge/pkg/logger"
+	"github.com/go-dev-frame/sponge/pkg/mq/consumer"
+)
+
+func init() {
+	register("topicNameExample", handleTopicNameExample)
+}
+
+// handleTopicNameExample handle the message of topic topicNameExample, if an error is returned,
+// the message is retried after the backoff, and routed to the dead-letter topic after the maximum attempts.
+func handleTopicNameExample(ctx context.Context, msg *consumer.Message) error {
+	logger.Info("handle message", logger.String("topic", msg.Topic), logger.String("key", msg.Key),
+		logger.Int("attempt", msg.Attempt()), logger.Int("size", len(msg.Body)))
+
+	// fill in the business logic code here, e.g. decode the message body and save it to database,
+	// the handler should be idempotent, the message may be delivered more than once
+
+	return nil
+}
+`
+)
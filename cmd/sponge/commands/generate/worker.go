@@ -0,0 +1,344 @@
+package generate
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/huandu/xstrings"
+	"github.com/spf13/cobra"
+
+	"github.com/go-dev-frame/sponge/pkg/replacer"
+)
+
+var nameListRegexp = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+
+// WorkerCommand generate worker service code
+func WorkerCommand() *cobra.Command {
+	var (
+		moduleName  string // module name for go.mod
+		serverName  string // server name
+		projectName string // project name for deployment name
+		repoAddr    string // image repo address
+		outPath     string // output directory
+		topics      string // topic names
+
+		suitedMonoRepo bool   // whether the generated code is suitable for mono-repo
+		ciType         string // ci/cd pipeline type, support github, gitlab
+	)
+
+	//nolint
+	cmd := &cobra.Command{
+		Use:   "worker",
+		Short: "Generate worker service code that consumes messages from kafka",
+		Long:  "Generate worker service code that consumes messages from kafka, includes consumer group setup, handler of each topic, retry and dead-letter topics, metrics and graceful shutdown.",
+		Example: color.HiBlackString(`  # Generate worker service code.
+  sponge micro worker --module-name=yourModuleName --server-name=yourServerName --project-name=yourProjectName --topics=order.created,order.paid
+
+  # Generate worker service code and specify the output directory, Note: code generation will be canceled when the latest generated file already exists.
+  sponge micro worker --module-name=yourModuleName --server-name=yourServerName --project-name=yourProjectName --topics=order.created --out=./yourServerDir
+
+  # Generate worker service code and specify the docker image repository address.
+  sponge micro worker --module-name=yourModuleName --server-name=yourServerName --project-name=yourProjectName --repo-addr=192.168.3.37:9443/user-name --topics=order.created
+
+  # Generate code with the ci/cd pipeline of github actions (or gitlab ci), the environments are deployed to k8s by kustomize overlays.
+  sponge micro worker --module-name=yourModuleName --server-name=yourServerName --project-name=yourProjectName --repo-addr=192.168.3.37:9443/user-name --topics=order.created --ci=github
+
+  # If you want the generated code to suited to mono-repo, you need to set the parameter --suited-mono-repo=true`),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkCIType(ciType); err != nil {
+				return err
+			}
+			topicNames, err := parseNameList(topics, "topics", "sponge micro worker")
+			if err != nil {
+				return err
+			}
+
+			projectName, serverName, err = convertProjectAndServerName(projectName, serverName)
+			if err != nil {
+				return err
+			}
+
+			if suitedMonoRepo {
+				outPath = changeOutPath(outPath, serverName)
+			}
+
+			g := &workerGenerator{
+				moduleName:     moduleName,
+				serverName:     serverName,
+				projectName:    projectName,
+				repoAddr:       repoAddr,
+				outPath:        outPath,
+				topics:         topicNames,
+				suitedMonoRepo: suitedMonoRepo,
+			}
+			outPath, err = g.generateCode()
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf(`
+using help:
+  1. modify the kafka addresses and consumer group of "worker.kafka" in the configuration file "configs/%s.yml".
+  2. fill in the business logic code of the handlers in the directory "internal/worker", one file per topic.
+  3. compile and run server: make run
+  4. access http://localhost:8283/metrics in your browser, and view the metrics of consumed messages.
+
+`, serverName)
+			fmt.Printf("generate %s's worker service code successfully, out = %s\n", serverName, outPath)
+
+			_ = generateConfigmap(serverName, outPath)
+			return generateCIFiles(ciType, serverName, projectName, repoAddr, suitedMonoRepo, outPath)
+		},
+	}
+
+	cmd.Flags().StringVarP(&moduleName, "module-name", "m", "", "module-name is the name of the module in the go.mod file")
+	_ = cmd.MarkFlagRequired("module-name")
+	cmd.Flags().StringVarP(&serverName, "server-name", "s", "", "server name")
+	_ = cmd.MarkFlagRequired("server-name")
+	cmd.Flags().StringVarP(&projectName, "project-name", "p", "", "project name")
+	_ = cmd.MarkFlagRequired("project-name")
+	cmd.Flags().StringVarP(&topics, "topics", "t", "", "topic names consumed by the worker, multiple names separated by commas, a handler file is generated for each topic")
+	_ = cmd.MarkFlagRequired("topics")
+	cmd.Flags().BoolVarP(&suitedMonoRepo, "suited-mono-repo", "l", false, "whether the generated code is suitable for mono-repo")
+	cmd.Flags().StringVarP(&repoAddr, "repo-addr", "r", "", "docker image repository address, excluding http and repository names")
+	cmd.Flags().StringVarP(&ciType, "ci", "", "", "generate the ci/cd pipeline file and kustomize overlays of environments (dev, test, prod), support github, gitlab")
+	cmd.Flags().StringVarP(&outPath, "out", "o", "", "output directory, default is ./serverName_worker_<time>, if suited-mono-repo = true, output directory is serverName")
+
+	return cmd
+}
+
+type workerGenerator struct {
+	moduleName     string
+	serverName     string
+	projectName    string
+	repoAddr       string
+	outPath        string
+	topics         []string
+	suitedMonoRepo bool
+}
+
+func (g *workerGenerator) generateCode() (string, error) {
+	subTplName := codeNameWorker
+	r, _ := replacer.New(SpongeDir)
+	if r == nil {
+		return "", errors.New("replacer is nil")
+	}
+
+	// specify the subdirectory and files
+	subDirs := []string{
+		"cmd/serverNameExample_workerExample", "sponge/configs", "sponge/deployments", "sponge/scripts",
+	}
+	subFiles := []string{
+		"sponge/.gitignore", "sponge/.golangci.yml", "sponge/go.mod", "sponge/go.sum",
+		"sponge/Jenkinsfile", "sponge/Makefile-for-http", "sponge/README.md",
+	}
+	if g.suitedMonoRepo {
+		subFiles = removeElements(subFiles, "sponge/go.mod", "sponge/go.sum")
+	}
+
+	selectFiles := map[string][]string{
+		"internal/config": {
+			"serverNameExample.go",
+		},
+		"internal/server": {
+			"worker.go", "worker_option.go", "worker_test.go",
+		},
+		"internal/worker": {
+			"worker.go", "worker_test.go",
+		},
+	}
+	subFiles = append(subFiles, getSubFiles(selectFiles, nil)...)
+
+	// ignore some directories and files
+	ignoreDirs := []string{"cmd/sponge"}
+	ignoreFiles := []string{"scripts/image-rpc-test.sh", "scripts/patch.sh", "scripts/protoc.sh",
+		"scripts/proto-doc.sh", "scripts/swag-docs.sh", "configs/serverNameExample_cc.yml"}
+
+	r.SetSubDirsAndFiles(subDirs, subFiles...)
+	r.SetIgnoreSubDirs(ignoreDirs...)
+	r.SetIgnoreSubFiles(ignoreFiles...)
+	_ = r.SetOutputDir(g.outPath, g.serverName+"_"+subTplName)
+	fields := g.addFields(r)
+	r.SetReplacementFields(fields)
+	if err := r.SaveFiles(); err != nil {
+		return "", err
+	}
+
+	// generate a handler file for each topic
+	for _, topic := range g.topics {
+		name := strings.NewReplacer(".", "_", "-", "_").Replace(topic)
+		funcName := xstrings.ToCamelCase(name)
+		content := strings.ReplaceAll(workerHandlerCode, "TopicNameExample", funcName)
+		content = strings.ReplaceAll(content, "topicNameExample", topic)
+		file := filepath.Join(r.GetOutputDir(), "internal/worker", xstrings.ToSnakeCase(name)+".go")
+		if err := saveCodeFile(file, []byte(content), false); err != nil {
+			return "", err
+		}
+	}
+
+	_ = saveGenInfo(g.moduleName, g.serverName, g.suitedMonoRepo, r.GetOutputDir())
+
+	return r.GetOutputDir(), nil
+}
+
+func (g *workerGenerator) addFields(r replacer.Replacer) []replacer.Field {
+	repoHost, _ := parseImageRepoAddr(g.repoAddr)
+
+	var fields []replacer.Field
+	fields = append(fields, deleteFieldsMark(r, dockerFile, wellStartMark, wellEndMark)...)
+	fields = append(fields, deleteFieldsMark(r, dockerFileBuild, wellStartMark, wellEndMark)...)
+	fields = append(fields, deleteFieldsMark(r, dockerComposeFile, wellStartMark, wellEndMark)...)
+	fields = append(fields, deleteFieldsMark(r, k8sDeploymentFile, wellStartMark, wellEndMark)...)
+	fields = append(fields, deleteFieldsMark(r, k8sServiceFile, wellStartMark, wellEndMark)...)
+	fields = append(fields, deleteFieldsMark(r, imageBuildFile, wellStartMark, wellEndMark)...)
+	fields = append(fields, deleteFieldsMark(r, imageBuildLocalFile, wellStartMark, wellEndMark)...)
+	fields = append(fields, deleteFieldsMark(r, gitIgnoreFile, wellStartMark, wellEndMark)...)
+	fields = append(fields, deleteAllFieldsMark(r, protoShellFile, wellStartMark, wellEndMark)...)
+	fields = append(fields, deleteAllFieldsMark(r, appConfigFile, wellStartMark, wellEndMark)...)
+	fields = append(fields, replaceFileContentMark(r, readmeFile,
+		getReadmeContent(g.moduleName, g.serverName, codeNameWorker, "", g.suitedMonoRepo))...)
+	fields = append(fields, []replacer.Field{
+		{ // replace the configuration of the *.yml file
+			Old: appConfigFileMark,
+			New: workerServerConfigCode,
+		},
+		{ // replace the configuration of the *.yml file
+			Old: appConfigFileMark2,
+			New: "",
+		},
+		{ // replace the contents of the Dockerfile file
+			Old: dockerFileMark,
+			New: dockerFileMetricsCode,
+		},
+		{ // replace the contents of the Dockerfile_build file
+			Old: dockerFileBuildMark,
+			New: dockerFileBuildMetricsCode,
+		},
+		{ // replace the contents of the image-build.sh file
+			Old: imageBuildFileMark,
+			New: imageBuildFileHTTPCode,
+		},
+		{ // replace the contents of the image-build-local.sh file
+			Old: imageBuildLocalFileMark,
+			New: imageBuildLocalFileHTTPCode,
+		},
+		{ // replace the contents of the docker-compose.yml file
+			Old: dockerComposeFileMark,
+			New: dockerComposeFileMetricsCode,
+		},
+		{ // replace the contents of the *-deployment.yml file
+			Old: k8sDeploymentFileMark,
+			New: k8sDeploymentFileMetricsCode,
+		},
+		{ // replace the contents of the *-svc.yml file
+			Old: k8sServiceFileMark,
+			New: k8sServiceFileMetricsCode,
+		},
+		{ // replace github.com/go-dev-frame/sponge/templates/sponge
+			Old: selfPackageName + "/" + r.GetSourcePath(),
+			New: g.moduleName,
+		},
+		{
+			Old: protoShellFileGRPCMark,
+			New: "",
+		},
+		{
+			Old: protoShellFileMark,
+			New: "",
+		},
+		{
+			Old: "github.com/go-dev-frame/sponge",
+			New: g.moduleName,
+		},
+		{
+			Old: g.moduleName + pkgPathSuffix,
+			New: "github.com/go-dev-frame/sponge/pkg",
+		},
+		{ // replace the sponge version of the go.mod file
+			Old: spongeTemplateVersionMark,
+			New: getLocalSpongeTemplateVersion(),
+		},
+		{
+			Old: defaultGoModVersion,
+			New: getLocalGoVersion(),
+		},
+		{
+			Old: defaultImageGoModVersion,
+			New: extractImageGoVersion(),
+		},
+		{
+			Old: "serverNameExample",
+			New: g.serverName,
+		},
+		// docker image and k8s deployment script replacement
+		{
+			Old: "server-name-example",
+			New: xstrings.ToKebabCase(g.serverName), // snake_case to kebab_case
+		},
+		// docker image and k8s deployment script replacement
+		{
+			Old: "project-name-example",
+			New: g.projectName,
+		},
+		{
+			Old: "projectNameExample",
+			New: g.projectName,
+		},
+		{
+			Old: "repo-addr-example",
+			New: g.repoAddr,
+		},
+		{
+			Old: "image-repo-host",
+			New: repoHost,
+		},
+		{
+			Old: "_workerExample",
+			New: "",
+		},
+		{
+			Old: "_mixExample",
+			New: "",
+		},
+		{
+			Old: "Makefile-for-http",
+			New: "Makefile",
+		},
+	}...)
+
+	fields = append(fields, getHTTPServiceFields()...)
+
+	if g.suitedMonoRepo {
+		fs := serverCodeFields(codeNameWorker, g.moduleName, g.serverName)
+		fields = append(fields, fs...)
+	}
+
+	return fields
+}
+
+// parse the names separated by commas, e.g. topic names and job names, the duplicate names are removed
+func parseNameList(list string, flagName string, command string) ([]string, error) {
+	var names []string
+	exists := make(map[string]bool)
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || exists[name] {
+			continue
+		}
+		if !nameListRegexp.MatchString(name) {
+			return nil, fmt.Errorf("invalid name %q of flag %q, only letters, digits, '.', '_' and '-' are allowed", name, flagName)
+		}
+		exists[name] = true
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf(`flag %q is empty, use "%s -h" for help`, flagName, command)
+	}
+	return names, nil
+}
@@ -1,6 +1,8 @@
 package commands
 
 import (
+	"fmt"
+
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
@@ -12,15 +14,37 @@ const (
 
 // InitCommand initial sponge
 func InitCommand() *cobra.Command {
+	var (
+		archiveFile string
+		pluginDir   string
+	)
+
 	cmd := &cobra.Command{
 		Use:   "init",
 		Short: "Initialize sponge",
 		Long:  "Initialize sponge.",
 		Example: color.HiBlackString(`  # Run init, download code and install plugins.
-  sponge init`),
+  sponge init
+
+  # Run init offline, the archive is generated by the command "sponge pack-templates".
+  sponge init --from-archive=sponge-templates.tar.gz`),
 		SilenceErrors: true,
 		SilenceUsage:  true,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if archiveFile != "" {
+				manifest, err := initFromArchive(archiveFile, pluginDir)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("initialize sponge from archive successfully, template code version %s, %d plugins installed.\n",
+					manifest.Version, len(manifest.Plugins))
+				installedNames, lackNames := checkInstallPlugins()
+				if len(lackNames) > 0 {
+					showDependencyPlugins(installedNames, lackNames)
+				}
+				return nil
+			}
+
 			targetVersion := latestVersion
 			// download sponge template code
 			_, err := runUpgrade(targetVersion)
@@ -36,5 +60,8 @@ func InitCommand() *cobra.Command {
 		},
 	}
 
+	cmd.Flags().StringVarP(&archiveFile, "from-archive", "f", "", "initialize offline from the archive generated by the command pack-templates")
+	cmd.Flags().StringVarP(&pluginDir, "plugin-dir", "p", "", "directory to install the plugins in the archive, default is $GOBIN or $GOPATH/bin")
+
 	return cmd
 }
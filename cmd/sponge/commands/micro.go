@@ -10,8 +10,8 @@ import (
 func GenMicroCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:           "micro",
-		Short:         "Generate protobuf, model, cache, dao, service, grpc, grpc-gw, grpc+http, grpc-cli code",
-		Long:          "Generate protobuf, model, cache, dao, service, grpc, grpc-gw, grpc+http, grpc-cli code.",
+		Short:         "Generate protobuf, model, cache, dao, service, grpc, grpc-gw, grpc+http, grpc-gateway, grpc-cli, worker, cron code",
+		Long:          "Generate protobuf, model, cache, dao, service, grpc, grpc-gw, grpc+http, grpc-gateway, grpc-cli, worker, cron code.",
 		SilenceErrors: true,
 		SilenceUsage:  true,
 	}
@@ -28,7 +28,10 @@ func GenMicroCommand() *cobra.Command {
 		generate.GRPCConnectionCommand(),
 		generate.GRPCAndHTTPCommand(),
 		generate.GRPCAndHTTPPbCommand(),
+		generate.GRPCGatewayPbCommand(),
 		generate.ServiceAndHandlerCRUDCommand(),
+		generate.WorkerCommand(),
+		generate.CronCommand(),
 	)
 
 	return cmd
@@ -0,0 +1,29 @@
+package commands
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/go-dev-frame/sponge/cmd/sponge/commands/migrate"
+)
+
+// MigrateCommand database migration commands
+func MigrateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Generate and apply database migration files",
+		Long: `Generate and apply database migration files, the migration files are generated by diffing
+the model structs against the database schema, and they are compatible with golang-migrate and goose.`,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	cmd.AddCommand(
+		migrate.DiffCommand(),
+		migrate.CreateCommand(),
+		migrate.UpCommand(),
+		migrate.DownCommand(),
+		migrate.ForceCommand(),
+	)
+
+	return cmd
+}
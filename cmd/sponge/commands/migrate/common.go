@@ -0,0 +1,177 @@
+// Package migrate is the database migration commands, the migration files are generated by diffing the model structs
+// against the database schema, and they are compatible with golang-migrate and goose.
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/go-dev-frame/sponge/pkg/sgorm/mysql"
+	"github.com/go-dev-frame/sponge/pkg/sgorm/postgresql"
+	"github.com/go-dev-frame/sponge/pkg/sgorm/sqlite"
+	"github.com/go-dev-frame/sponge/pkg/utils"
+)
+
+const (
+	defaultMigrationDir = "internal/database/migrations"
+
+	formatGolangMigrate = "golang-migrate"
+	formatGoose         = "goose"
+)
+
+// database connection flags, if dsn is empty, the database settings are read from the yaml configuration file.
+type dbFlags struct {
+	driver     string
+	dsn        string
+	configFile string
+}
+
+// the database settings of the service configuration file, e.g. configs/serverNameExample.yml
+type dbConfig struct {
+	Database struct {
+		Driver string `yaml:"driver"`
+		Mysql  struct {
+			Dsn string `yaml:"dsn"`
+		} `yaml:"mysql"`
+		Postgresql struct {
+			Dsn string `yaml:"dsn"`
+		} `yaml:"postgresql"`
+		Sqlite struct {
+			DBFile string `yaml:"dbFile"`
+		} `yaml:"sqlite"`
+	} `yaml:"database"`
+}
+
+func (f *dbFlags) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&f.driver, "db-driver", "k", "mysql", "database driver, support mysql, tidb, postgresql, sqlite")
+	cmd.Flags().StringVarP(&f.dsn, "db-dsn", "d", "", "database content address, e.g. user:password@(host:port)/database. Note: if db-driver=sqlite, db-dsn must be a local sqlite db file")
+	cmd.Flags().StringVarP(&f.configFile, "config", "c", "", "yaml configuration file of the service, the database settings are read from it if db-dsn is empty")
+}
+
+func (f *dbFlags) getDriverAndDsn() (string, string, error) {
+	if f.dsn != "" {
+		return f.driver, f.dsn, nil
+	}
+	if f.configFile == "" {
+		return "", "", errors.New(`required flag(s) "db-dsn" or "config" not set`)
+	}
+
+	data, err := os.ReadFile(f.configFile)
+	if err != nil {
+		return "", "", err
+	}
+	cfg := &dbConfig{}
+	if err = yaml.Unmarshal(data, cfg); err != nil {
+		return "", "", fmt.Errorf("parse config file %s error: %v", f.configFile, err)
+	}
+
+	driver := strings.ToLower(cfg.Database.Driver)
+	switch driver {
+	case dbDriverMysql, dbDriverTidb:
+		return driver, cfg.Database.Mysql.Dsn, nil
+	case dbDriverPostgresql:
+		return driver, cfg.Database.Postgresql.Dsn, nil
+	case dbDriverSqlite:
+		return driver, cfg.Database.Sqlite.DBFile, nil
+	}
+	return "", "", fmt.Errorf("unsupported db driver '%s' in config file %s", cfg.Database.Driver, f.configFile)
+}
+
+func (f *dbFlags) openDB() (*gorm.DB, string, error) {
+	driver, dsn, err := f.getDriverAndDsn()
+	if err != nil {
+		return nil, "", err
+	}
+
+	var db *gorm.DB
+	switch strings.ToLower(driver) {
+	case dbDriverMysql, dbDriverTidb:
+		db, err = mysql.Init(utils.AdaptiveMysqlDsn(dsn))
+	case dbDriverPostgresql:
+		db, err = postgresql.Init(utils.AdaptivePostgresqlDsn(dsn))
+	case dbDriverSqlite:
+		if _, err = os.Stat(dsn); err != nil {
+			return nil, "", fmt.Errorf("sqlite db file %s error: %v", dsn, err)
+		}
+		db, err = sqlite.Init(utils.AdaptiveSqlite(dsn))
+	default:
+		return nil, "", fmt.Errorf("unsupported db driver '%s', only mysql, tidb, postgresql, sqlite are supported", driver)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	db.Logger = logger.Default.LogMode(logger.Silent)
+	return db, driver, nil
+}
+
+func closeDB(db *gorm.DB) {
+	if sqlDB, err := db.DB(); err == nil {
+		_ = sqlDB.Close()
+	}
+}
+
+func checkFormat(format string) error {
+	if format != formatGolangMigrate && format != formatGoose {
+		return fmt.Errorf("unsupported format '%s', only golang-migrate and goose are supported", format)
+	}
+	return nil
+}
+
+// writeMigrationFiles write the up and down statements to the migration files, the version is the current time,
+// e.g. 20240101120000_create_user.up.sql and 20240101120000_create_user.down.sql
+func writeMigrationFiles(dir string, name string, format string, up []string, down []string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0766); err != nil {
+		return nil, err
+	}
+
+	prefix := filepath.Join(dir, time.Now().Format("20060102150405")+"_"+name)
+	header := "-- generated by sponge migrate, check the statements before applying them\n"
+	var files []string
+	var contents []string
+	if format == formatGoose {
+		files = append(files, prefix+".sql")
+		contents = append(contents, header+"\n-- +goose Up\n"+joinStatements(up)+"\n-- +goose Down\n"+joinStatements(down))
+	} else {
+		files = append(files, prefix+".up.sql", prefix+".down.sql")
+		contents = append(contents, header+"\n"+joinStatements(up), header+"\n"+joinStatements(down))
+	}
+
+	for i, file := range files {
+		if _, err := os.Stat(file); err == nil {
+			return nil, fmt.Errorf("migration file %s already exists", file)
+		}
+		if err := os.WriteFile(file, []byte(contents[i]), 0666); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+func joinStatements(statements []string) string {
+	if len(statements) == 0 {
+		return ""
+	}
+	return strings.Join(statements, "\n\n") + "\n"
+}
+
+// convert the name to the part of migration file name, e.g. "Add User Age" --> add_user_age
+func formatMigrationName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+	return strings.Trim(name, "_")
+}
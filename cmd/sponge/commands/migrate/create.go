@@ -0,0 +1,54 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// CreateCommand create empty migration files
+func CreateCommand() *cobra.Command {
+	var (
+		outDir string
+		name   string
+		format string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create empty migration files",
+		Long:  "Create empty migration files, write the statements of up and down manually.",
+		Example: color.HiBlackString(`  # Create empty migration files in golang-migrate format.
+  sponge migrate create --name=add_user_index
+
+  # Create empty migration file in goose format, and specify the directory.
+  sponge migrate create --name=add_user_index --format=goose --dir=./migrations`),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkFormat(format); err != nil {
+				return err
+			}
+			if name = formatMigrationName(name); name == "" {
+				return errors.New("the migration name is empty")
+			}
+
+			files, err := writeMigrationFiles(outDir, name, format, nil, nil)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("create migration files successfully, out = %s\n", strings.Join(files, ", "))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&name, "name", "n", "", "migration name, it is a part of the file name")
+	_ = cmd.MarkFlagRequired("name")
+	cmd.Flags().StringVarP(&outDir, "dir", "o", defaultMigrationDir, "directory of the migration files")
+	cmd.Flags().StringVarP(&format, "format", "f", formatGolangMigrate, "format of the migration files, support golang-migrate, goose")
+
+	return cmd
+}
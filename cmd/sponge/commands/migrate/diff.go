@@ -0,0 +1,164 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+// DiffCommand generate migration files by diffing the model structs against the database schema
+func DiffCommand() *cobra.Command {
+	var (
+		flags    dbFlags
+		modelDir string
+		outDir   string
+		name     string
+		format   string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Generate migration files by diffing the model structs against the database schema",
+		Long: `Generate migration files by diffing the model structs against the database schema.
+
+The tables of model structs that don't exist in the database are created, the columns and indexes
+that don't exist in the tables are added, the columns that are in the database but not in the model
+structs are not dropped automatically, the drop statements are generated as comments.`,
+		Example: color.HiBlackString(`  # Generate migration files of the models in the directory internal/model.
+  sponge migrate diff --db-driver=mysql --db-dsn=root:123456@(192.168.3.37:3306)/account
+
+  # Generate migration files, the database settings are read from the yaml configuration file.
+  sponge migrate diff --config=configs/user.yml --name=add_user_age
+
+  # Generate migration files in goose format.
+  sponge migrate diff --db-driver=sqlite --db-dsn=./test.db --format=goose`),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkFormat(format); err != nil {
+				return err
+			}
+			tables, err := parseModels(modelDir)
+			if err != nil {
+				return err
+			}
+			if len(tables) == 0 {
+				return fmt.Errorf("no model struct with the method TableName found in the directory %s", modelDir)
+			}
+
+			db, driver, err := flags.openDB()
+			if err != nil {
+				return err
+			}
+			defer closeDB(db)
+			dialect, err := newSQLDialect(driver)
+			if err != nil {
+				return err
+			}
+
+			up, down, err := diffTables(db, dialect, tables)
+			if err != nil {
+				return err
+			}
+			if !hasChanges(up) {
+				for _, statement := range up {
+					fmt.Println(statement)
+				}
+				fmt.Println("no changes between the model structs and the database schema, no migration files are generated.")
+				return nil
+			}
+
+			if name = formatMigrationName(name); name == "" {
+				return errors.New("the migration name is empty")
+			}
+			files, err := writeMigrationFiles(outDir, name, format, up, down)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("generate migration files successfully, out = %s\n", strings.Join(files, ", "))
+			return nil
+		},
+	}
+
+	flags.addFlags(cmd)
+	cmd.Flags().StringVarP(&modelDir, "model-dir", "m", "internal/model", "directory of the model structs")
+	cmd.Flags().StringVarP(&outDir, "dir", "o", defaultMigrationDir, "directory of the migration files")
+	cmd.Flags().StringVarP(&name, "name", "n", "update_schema", "migration name, it is a part of the file name")
+	cmd.Flags().StringVarP(&format, "format", "f", formatGolangMigrate, "format of the migration files, support golang-migrate, goose")
+
+	return cmd
+}
+
+// diffTables generate the statements to change the database schema to the model structs,
+// the down statements are in reverse order of the up statements.
+func diffTables(db *gorm.DB, dialect *sqlDialect, tables []*modelTable) (up []string, down []string, err error) {
+	migrator := db.Migrator()
+	addDown := func(statements ...string) {
+		down = append(statements, down...)
+	}
+
+	for _, table := range tables {
+		if !migrator.HasTable(table.name) {
+			up = append(up, dialect.createTable(table))
+			up = append(up, dialect.columnComments(table.name, table.columns)...)
+			for _, index := range table.indexes {
+				up = append(up, dialect.createIndex(table.name, index))
+			}
+			addDown(dialect.dropTable(table.name))
+			continue
+		}
+
+		columnTypes, err := migrator.ColumnTypes(table.name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("get columns of table %s error: %v", table.name, err)
+		}
+		existingColumns := map[string]bool{}
+		for _, columnType := range columnTypes {
+			existingColumns[columnType.Name()] = true
+		}
+
+		var addedColumns []*modelColumn
+		for _, column := range table.columns {
+			if existingColumns[column.name] {
+				continue
+			}
+			up = append(up, dialect.addColumn(table.name, column))
+			addDown(dialect.dropColumn(table.name, column.name))
+			addedColumns = append(addedColumns, column)
+		}
+		up = append(up, dialect.columnComments(table.name, addedColumns)...)
+
+		for _, index := range table.indexes {
+			if migrator.HasIndex(table.name, index.name) {
+				continue
+			}
+			up = append(up, dialect.createIndex(table.name, index))
+			addDown(dialect.dropIndex(table.name, index.name))
+		}
+
+		// the columns are not dropped automatically to avoid losing data
+		for _, columnType := range columnTypes {
+			if table.getColumn(columnType.Name()) != nil {
+				continue
+			}
+			up = append(up, fmt.Sprintf("-- the column %s is not in the model %s, uncomment the statement to drop it\n-- %s",
+				columnType.Name(), table.model, dialect.dropColumn(table.name, columnType.Name())))
+		}
+	}
+
+	return up, down, nil
+}
+
+// the columns to be dropped are comments, they are not changes
+func hasChanges(statements []string) bool {
+	for _, statement := range statements {
+		if !strings.HasPrefix(statement, "--") {
+			return true
+		}
+	}
+	return false
+}
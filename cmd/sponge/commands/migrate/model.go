@@ -0,0 +1,310 @@
+package migrate
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/huandu/xstrings"
+)
+
+// modelColumn is a column parsed from the field of model struct and its gorm tag.
+type modelColumn struct {
+	name            string
+	goType          string // e.g. int64, string, time.Time, []byte
+	sqlType         string // the type specified by gorm tag type, it has higher priority than goType
+	size            int
+	isPrimaryKey    bool
+	isAutoIncrement bool
+	isNotNull       bool
+	isUnique        bool
+	defaultValue    string
+	comment         string
+}
+
+type modelIndex struct {
+	name     string
+	isUnique bool
+	columns  []string
+}
+
+// modelTable is the table of model struct which has the method TableName.
+type modelTable struct {
+	name    string
+	model   string // struct name
+	columns []*modelColumn
+	indexes []*modelIndex
+}
+
+func (t *modelTable) getColumn(name string) *modelColumn {
+	for _, column := range t.columns {
+		if column.name == name {
+			return column
+		}
+	}
+	return nil
+}
+
+// columns of the embedded struct sgorm.Model, sgorm.Model2 and gorm.Model
+func embeddedModelColumns() []*modelColumn {
+	return []*modelColumn{
+		{name: "id", goType: "uint64", isPrimaryKey: true, isAutoIncrement: true, isNotNull: true},
+		{name: "created_at", goType: "time.Time"},
+		{name: "updated_at", goType: "time.Time"},
+		{name: "deleted_at", goType: "gorm.DeletedAt"},
+	}
+}
+
+// parseModels parse the model structs in the directory, only the structs with the method TableName are parsed.
+func parseModels(dir string) ([]*modelTable, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, nil, parser.ParseComments) //nolint
+	if err != nil {
+		return nil, err
+	}
+
+	structs := map[string]*ast.StructType{}
+	tableNames := map[string]string{} // struct name --> table name
+	for _, pkg := range pkgs {
+		for filename, file := range pkg.Files {
+			if strings.HasSuffix(filename, "_test.go") {
+				continue
+			}
+			for _, decl := range file.Decls {
+				switch d := decl.(type) {
+				case *ast.GenDecl:
+					for _, spec := range d.Specs {
+						if ts, ok := spec.(*ast.TypeSpec); ok {
+							if st, ok := ts.Type.(*ast.StructType); ok {
+								structs[ts.Name.Name] = st
+							}
+						}
+					}
+				case *ast.FuncDecl:
+					if structName, tableName := getTableName(d); structName != "" {
+						tableNames[structName] = tableName
+					}
+				}
+			}
+		}
+	}
+
+	var tables []*modelTable
+	for structName, tableName := range tableNames {
+		st, ok := structs[structName]
+		if !ok {
+			continue
+		}
+		table := &modelTable{name: tableName, model: structName}
+		if err = table.addFields(st, structs); err != nil {
+			return nil, fmt.Errorf("parse model %s error: %v", structName, err)
+		}
+		if len(table.columns) == 0 {
+			continue
+		}
+		table.setPrimaryKey()
+		tables = append(tables, table)
+	}
+
+	sort.Slice(tables, func(i, j int) bool {
+		return tables[i].name < tables[j].name
+	})
+	return tables, nil
+}
+
+// get the table name from the method, e.g. func (table *User) TableName() string { return "user" }
+func getTableName(fn *ast.FuncDecl) (structName string, tableName string) {
+	if fn.Name.Name != "TableName" || fn.Recv == nil || len(fn.Recv.List) != 1 || fn.Body == nil || len(fn.Body.List) != 1 {
+		return "", ""
+	}
+	ret, ok := fn.Body.List[0].(*ast.ReturnStmt)
+	if !ok || len(ret.Results) != 1 {
+		return "", ""
+	}
+	lit, ok := ret.Results[0].(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", ""
+	}
+	tableName, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return "", ""
+	}
+
+	recvType := fn.Recv.List[0].Type
+	if star, ok := recvType.(*ast.StarExpr); ok {
+		recvType = star.X
+	}
+	ident, ok := recvType.(*ast.Ident)
+	if !ok {
+		return "", ""
+	}
+	return ident.Name, tableName
+}
+
+func (t *modelTable) addFields(st *ast.StructType, structs map[string]*ast.StructType) error {
+	for _, field := range st.Fields.List {
+		var tag reflect.StructTag
+		if field.Tag != nil {
+			tag = reflect.StructTag(strings.Trim(field.Tag.Value, "`"))
+		}
+		settings := parseGormTag(tag.Get("gorm"))
+		if _, ok := settings["-"]; ok {
+			continue
+		}
+		goType := exprToString(field.Type)
+
+		// embedded struct
+		if len(field.Names) == 0 {
+			typeName := strings.TrimPrefix(goType, "*")
+			switch typeName {
+			case "sgorm.Model", "sgorm.Model2", "gorm.Model":
+				t.columns = append(t.columns, embeddedModelColumns()...)
+				t.addIndex(&modelIndex{name: "idx_" + t.name + "_deleted_at", columns: []string{"deleted_at"}})
+			default:
+				est, ok := structs[typeName]
+				if !ok {
+					return fmt.Errorf("the embedded struct %s is not found", typeName)
+				}
+				if err := t.addFields(est, structs); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		for _, name := range field.Names {
+			if !name.IsExported() {
+				continue
+			}
+			column := newModelColumn(name.Name, goType, settings)
+			if column.sqlType == "" && isAssociation(column.goType, structs) {
+				continue
+			}
+			if field.Comment != nil && column.comment == "" {
+				column.comment = strings.TrimSpace(field.Comment.Text())
+			}
+			t.columns = append(t.columns, column)
+			t.addColumnIndexes(column.name, settings)
+		}
+	}
+
+	return nil
+}
+
+// the fields of other model structs are associations, they are not columns
+func isAssociation(goType string, structs map[string]*ast.StructType) bool {
+	typeName := strings.TrimLeft(goType, "[]*")
+	_, ok := structs[typeName]
+	return ok
+}
+
+// if there is no primary key, the field ID is the primary key, which is the same as gorm
+func (t *modelTable) setPrimaryKey() {
+	for _, column := range t.columns {
+		if column.isPrimaryKey {
+			return
+		}
+	}
+	if column := t.getColumn("id"); column != nil {
+		column.isPrimaryKey = true
+		column.isNotNull = true
+		column.isAutoIncrement = isIntegerType(column.goType)
+	}
+}
+
+func newModelColumn(fieldName string, goType string, settings map[string]string) *modelColumn {
+	column := &modelColumn{
+		name:         settings["COLUMN"],
+		goType:       goType,
+		sqlType:      settings["TYPE"],
+		defaultValue: settings["DEFAULT"],
+	}
+	if column.name == "" {
+		column.name = xstrings.ToSnakeCase(fieldName)
+	}
+	if size, err := strconv.Atoi(settings["SIZE"]); err == nil {
+		column.size = size
+	}
+	if comment, ok := settings["COMMENT"]; ok {
+		column.comment = comment
+	}
+	if _, ok := settings["SERIALIZER"]; ok {
+		column.goType = "json"
+	}
+	_, column.isPrimaryKey = settings["PRIMARYKEY"]
+	_, column.isAutoIncrement = settings["AUTOINCREMENT"]
+	_, column.isNotNull = settings["NOTNULL"]
+	_, column.isUnique = settings["UNIQUE"]
+	if column.isPrimaryKey {
+		column.isNotNull = true
+	}
+	return column
+}
+
+func (t *modelTable) addColumnIndexes(column string, settings map[string]string) {
+	for _, key := range []string{"INDEX", "UNIQUEINDEX"} {
+		value, ok := settings[key]
+		if !ok {
+			continue
+		}
+		name := strings.TrimSpace(strings.Split(value, ",")[0])
+		if name == "" {
+			name = "idx_" + t.name + "_" + column
+		}
+		t.addIndex(&modelIndex{name: name, isUnique: key == "UNIQUEINDEX", columns: []string{column}})
+	}
+}
+
+// the indexes with the same name are composite index
+func (t *modelTable) addIndex(index *modelIndex) {
+	for _, idx := range t.indexes {
+		if idx.name == index.name {
+			idx.columns = append(idx.columns, index.columns...)
+			idx.isUnique = idx.isUnique || index.isUnique
+			return
+		}
+	}
+	t.indexes = append(t.indexes, index)
+}
+
+// parseGormTag parse the gorm tag, the keys are converted to upper case without underscores and spaces,
+// e.g. primary_key --> PRIMARYKEY, NOT NULL --> NOTNULL, autoIncrement --> AUTOINCREMENT.
+func parseGormTag(tag string) map[string]string {
+	settings := map[string]string{}
+	for _, item := range strings.Split(tag, ";") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		key, value := item, ""
+		if i := strings.Index(item, ":"); i >= 0 {
+			key, value = item[:i], strings.TrimSpace(item[i+1:])
+		}
+		key = strings.ToUpper(strings.NewReplacer("_", "", " ", "").Replace(strings.TrimSpace(key)))
+		settings[key] = value
+	}
+	return settings
+}
+
+func exprToString(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.SelectorExpr:
+		return exprToString(e.X) + "." + e.Sel.Name
+	case *ast.StarExpr:
+		return "*" + exprToString(e.X)
+	case *ast.ArrayType:
+		return "[]" + exprToString(e.Elt)
+	case *ast.MapType:
+		return "map[" + exprToString(e.Key) + "]" + exprToString(e.Value)
+	case *ast.InterfaceType:
+		return "interface{}"
+	}
+	return ""
+}
@@ -0,0 +1,296 @@
+package migrate
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	dbDriverMysql      = "mysql"
+	dbDriverTidb       = "tidb"
+	dbDriverPostgresql = "postgresql"
+	dbDriverSqlite     = "sqlite"
+)
+
+// sqlDialect generates the statements of the database driver.
+type sqlDialect struct {
+	driver string
+}
+
+func newSQLDialect(driver string) (*sqlDialect, error) {
+	driver = strings.ToLower(driver)
+	switch driver {
+	case dbDriverMysql, dbDriverTidb:
+		return &sqlDialect{driver: dbDriverMysql}, nil
+	case dbDriverPostgresql, dbDriverSqlite:
+		return &sqlDialect{driver: driver}, nil
+	}
+	return nil, fmt.Errorf("unsupported db driver '%s', only mysql, tidb, postgresql, sqlite are supported", driver)
+}
+
+func (d *sqlDialect) quote(name string) string {
+	if d.driver == dbDriverMysql {
+		return "`" + name + "`"
+	}
+	return `"` + name + `"`
+}
+
+func isIntegerType(goType string) bool {
+	switch strings.TrimPrefix(goType, "*") {
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		return true
+	}
+	return false
+}
+
+// columnType convert the go type to the column type of database, the type of gorm tag has higher priority
+func (d *sqlDialect) columnType(column *modelColumn) string {
+	if column.sqlType != "" {
+		return column.sqlType
+	}
+
+	goType := strings.TrimPrefix(column.goType, "*")
+	switch goType {
+	case "sgorm.Bool", "sgorm.TinyBool", "sql.NullBool":
+		goType = "bool"
+	case "sql.NullInt64":
+		goType = "int64"
+	case "sql.NullInt32":
+		goType = "int32"
+	case "sql.NullInt16":
+		goType = "int16"
+	case "sql.NullFloat64":
+		goType = "float64"
+	case "sql.NullString":
+		goType = "string"
+	case "sql.NullTime", "gorm.DeletedAt":
+		goType = "time.Time"
+	case "datatypes.JSON", "json.RawMessage":
+		goType = "json"
+	case "decimal.Decimal":
+		goType = "decimal"
+	}
+
+	switch d.driver {
+	case dbDriverMysql:
+		return d.mysqlType(goType, column)
+	case dbDriverPostgresql:
+		return d.postgresqlType(goType, column)
+	default:
+		return d.sqliteType(goType, column)
+	}
+}
+
+func (d *sqlDialect) varcharSize(column *modelColumn) int {
+	if column.size > 0 {
+		return column.size
+	}
+	return 255
+}
+
+func (d *sqlDialect) mysqlType(goType string, column *modelColumn) string {
+	switch goType {
+	case "bool":
+		return "tinyint(1)"
+	case "int8":
+		return "tinyint"
+	case "int16":
+		return "smallint"
+	case "int", "int32":
+		return "int"
+	case "int64":
+		return "bigint"
+	case "uint8":
+		return "tinyint unsigned"
+	case "uint16":
+		return "smallint unsigned"
+	case "uint", "uint32":
+		return "int unsigned"
+	case "uint64":
+		return "bigint unsigned"
+	case "float32":
+		return "float"
+	case "float64":
+		return "double"
+	case "string":
+		return fmt.Sprintf("varchar(%d)", d.varcharSize(column))
+	case "time.Time":
+		return "datetime"
+	case "[]byte", "[]uint8":
+		return "longblob"
+	case "decimal":
+		return "decimal(10,2)"
+	case "json":
+		return "json"
+	}
+	return "longtext"
+}
+
+func (d *sqlDialect) postgresqlType(goType string, column *modelColumn) string {
+	if column.isAutoIncrement && column.isPrimaryKey {
+		switch goType {
+		case "int64", "uint", "uint32", "uint64":
+			return "bigserial"
+		default:
+			return "serial"
+		}
+	}
+
+	switch goType {
+	case "bool":
+		return "boolean"
+	case "int8", "int16", "uint8":
+		return "smallint"
+	case "int", "int32", "uint16":
+		return "integer"
+	case "int64", "uint", "uint32", "uint64":
+		return "bigint"
+	case "float32":
+		return "real"
+	case "float64":
+		return "double precision"
+	case "string":
+		return fmt.Sprintf("varchar(%d)", d.varcharSize(column))
+	case "time.Time":
+		return "timestamptz"
+	case "[]byte", "[]uint8":
+		return "bytea"
+	case "decimal":
+		return "numeric(10,2)"
+	case "json":
+		return "jsonb"
+	}
+	return "text"
+}
+
+func (d *sqlDialect) sqliteType(goType string, _ *modelColumn) string {
+	switch goType {
+	case "bool", "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		return "integer"
+	case "float32", "float64":
+		return "real"
+	case "time.Time":
+		return "datetime"
+	case "[]byte", "[]uint8":
+		return "blob"
+	case "decimal":
+		return "numeric"
+	}
+	return "text"
+}
+
+// columnDefinition e.g. `name` varchar(50) NOT NULL DEFAULT 'foo' COMMENT 'username'
+func (d *sqlDialect) columnDefinition(column *modelColumn) string {
+	def := d.quote(column.name) + " " + d.columnType(column)
+
+	if column.isPrimaryKey && d.driver == dbDriverSqlite && column.isAutoIncrement {
+		// the auto increment column of sqlite must be integer primary key
+		return d.quote(column.name) + " integer PRIMARY KEY AUTOINCREMENT"
+	}
+	if column.isNotNull {
+		def += " NOT NULL"
+	}
+	if column.isAutoIncrement && d.driver == dbDriverMysql {
+		def += " AUTO_INCREMENT"
+	}
+	if column.defaultValue != "" {
+		def += " DEFAULT " + column.defaultValue
+	}
+	if column.isUnique {
+		def += " UNIQUE"
+	}
+	if column.comment != "" && d.driver == dbDriverMysql {
+		def += " COMMENT " + quoteString(column.comment)
+	}
+	return def
+}
+
+func (d *sqlDialect) createTable(table *modelTable) string {
+	var lines []string
+	var primaryKeys []string
+	for _, column := range table.columns {
+		lines = append(lines, "  "+d.columnDefinition(column))
+		if column.isPrimaryKey && !(d.driver == dbDriverSqlite && column.isAutoIncrement) {
+			primaryKeys = append(primaryKeys, d.quote(column.name))
+		}
+	}
+	if len(primaryKeys) > 0 {
+		lines = append(lines, "  PRIMARY KEY ("+strings.Join(primaryKeys, ", ")+")")
+	}
+
+	statement := "CREATE TABLE " + d.quote(table.name) + " (\n" + strings.Join(lines, ",\n") + "\n)"
+	if d.driver == dbDriverMysql {
+		statement += " ENGINE=InnoDB DEFAULT CHARSET=utf8mb4"
+	}
+	return statement + ";"
+}
+
+func (d *sqlDialect) dropTable(tableName string) string {
+	return "DROP TABLE " + d.quote(tableName) + ";"
+}
+
+// the existing rows need a default value when adding a NOT NULL column, the zero value is used if no default is specified
+func (d *sqlDialect) addColumn(tableName string, column *modelColumn) string {
+	if column.isNotNull && column.defaultValue == "" && !column.isPrimaryKey {
+		c := *column
+		c.defaultValue = d.zeroValue(d.columnType(column))
+		column = &c
+	}
+	return "ALTER TABLE " + d.quote(tableName) + " ADD COLUMN " + d.columnDefinition(column) + ";"
+}
+
+func (d *sqlDialect) zeroValue(columnType string) string {
+	columnType = strings.ToLower(columnType)
+	switch {
+	case columnType == "boolean":
+		return "false"
+	case strings.Contains(columnType, "int"), strings.Contains(columnType, "float"), strings.Contains(columnType, "double"),
+		strings.Contains(columnType, "real"), strings.Contains(columnType, "decimal"), strings.Contains(columnType, "numeric"):
+		return "0"
+	case strings.Contains(columnType, "char"), strings.Contains(columnType, "text"):
+		return "''"
+	}
+	return ""
+}
+
+func (d *sqlDialect) dropColumn(tableName string, columnName string) string {
+	return "ALTER TABLE " + d.quote(tableName) + " DROP COLUMN " + d.quote(columnName) + ";"
+}
+
+// the comments of postgresql columns are set by separate statements
+func (d *sqlDialect) columnComments(tableName string, columns []*modelColumn) []string {
+	if d.driver != dbDriverPostgresql {
+		return nil
+	}
+	var statements []string
+	for _, column := range columns {
+		if column.comment != "" {
+			statements = append(statements, fmt.Sprintf("COMMENT ON COLUMN %s.%s IS %s;",
+				d.quote(tableName), d.quote(column.name), quoteString(column.comment)))
+		}
+	}
+	return statements
+}
+
+func (d *sqlDialect) createIndex(tableName string, index *modelIndex) string {
+	columns := make([]string, 0, len(index.columns))
+	for _, column := range index.columns {
+		columns = append(columns, d.quote(column))
+	}
+	unique := ""
+	if index.isUnique {
+		unique = "UNIQUE "
+	}
+	return fmt.Sprintf("CREATE %sINDEX %s ON %s (%s);", unique, d.quote(index.name), d.quote(tableName), strings.Join(columns, ", "))
+}
+
+func (d *sqlDialect) dropIndex(tableName string, indexName string) string {
+	if d.driver == dbDriverMysql {
+		return "DROP INDEX " + d.quote(indexName) + " ON " + d.quote(tableName) + ";"
+	}
+	return "DROP INDEX " + d.quote(indexName) + ";"
+}
+
+func quoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
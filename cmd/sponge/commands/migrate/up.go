@@ -0,0 +1,135 @@
+package migrate
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+
+	"github.com/go-dev-frame/sponge/pkg/sgorm/migrate"
+)
+
+// UpCommand apply the migrations to the database
+func UpCommand() *cobra.Command {
+	var (
+		flags dbFlags
+		dir   string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "up",
+		Short: "Apply the migrations to the database",
+		Long:  "Apply the migrations whose version is greater than the current version of the database.",
+		Example: color.HiBlackString(`  # Apply the migrations, the database settings are read from the yaml configuration file.
+  sponge migrate up --config=configs/user.yml
+
+  # Apply the migrations to the specified database.
+  sponge migrate up --db-driver=mysql --db-dsn=root:123456@(192.168.3.37:3306)/account`),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db, _, err := flags.openDB()
+			if err != nil {
+				return err
+			}
+			defer closeDB(db)
+
+			if err = migrate.Up(db, os.DirFS(dir)); err != nil {
+				return err
+			}
+			return printVersion(db)
+		},
+	}
+
+	flags.addFlags(cmd)
+	cmd.Flags().StringVarP(&dir, "dir", "o", defaultMigrationDir, "directory of the migration files")
+
+	return cmd
+}
+
+// DownCommand roll back the applied migrations
+func DownCommand() *cobra.Command {
+	var (
+		flags dbFlags
+		dir   string
+		steps int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "down",
+		Short: "Roll back the applied migrations",
+		Long:  "Roll back the latest applied migrations by executing the down statements.",
+		Example: color.HiBlackString(`  # Roll back the latest migration, the database settings are read from the yaml configuration file.
+  sponge migrate down --config=configs/user.yml
+
+  # Roll back all the applied migrations.
+  sponge migrate down --config=configs/user.yml --steps=0`),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db, _, err := flags.openDB()
+			if err != nil {
+				return err
+			}
+			defer closeDB(db)
+
+			if err = migrate.Down(db, os.DirFS(dir), steps); err != nil {
+				return err
+			}
+			return printVersion(db)
+		},
+	}
+
+	flags.addFlags(cmd)
+	cmd.Flags().StringVarP(&dir, "dir", "o", defaultMigrationDir, "directory of the migration files")
+	cmd.Flags().IntVarP(&steps, "steps", "s", 1, "number of migrations to roll back, 0 means all")
+
+	return cmd
+}
+
+// ForceCommand set the version of the database
+func ForceCommand() *cobra.Command {
+	var (
+		flags   dbFlags
+		version int64
+	)
+
+	cmd := &cobra.Command{
+		Use:   "force",
+		Short: "Set the version of the database and clear the dirty state",
+		Long:  "Set the version of the database and clear the dirty state without running migrations, it is used after fixing a failed migration manually.",
+		Example: color.HiBlackString(`  # Set the version of the database.
+  sponge migrate force --config=configs/user.yml --version=20240101120000`),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db, _, err := flags.openDB()
+			if err != nil {
+				return err
+			}
+			defer closeDB(db)
+
+			if err = migrate.Force(db, version); err != nil {
+				return err
+			}
+			return printVersion(db)
+		},
+	}
+
+	flags.addFlags(cmd)
+	cmd.Flags().Int64VarP(&version, "version", "v", 0, "migration version, 0 means no migration has been applied")
+	_ = cmd.MarkFlagRequired("version")
+
+	return cmd
+}
+
+func printVersion(db *gorm.DB) error {
+	version, dirty, err := migrate.Version(db)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("the current version of the database is %d, dirty = %t\n", version, dirty)
+	return nil
+}
@@ -0,0 +1,561 @@
+package commands
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"go/format"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/go-dev-frame/sponge/cmd/sponge/server"
+	"github.com/go-dev-frame/sponge/pkg/gofile"
+)
+
+var errWizardCanceled = errors.New("canceled")
+
+// the server types of wizard, the code is generated by the same commands as the UI interface
+var wizardServerTypes = []wizardServerType{
+	{label: "web service based on sql, CRUD api of tables (gin)", commands: []string{"web", "http"}, isSQL: true, isJWT: true},
+	{label: "grpc service based on sql, CRUD api of tables", commands: []string{"micro", "rpc"}, isSQL: true},
+	{label: "grpc+http service based on sql, CRUD api of tables", commands: []string{"micro", "grpc-http"}, isSQL: true},
+	{label: "web service based on protobuf (gin)", commands: []string{"web", "http-pb"}},
+	{label: "grpc service based on protobuf", commands: []string{"micro", "rpc-pb"}},
+	{label: "grpc+http service based on protobuf", commands: []string{"micro", "grpc-http-pb"}},
+}
+
+var dsnExamples = map[string]string{
+	"mysql":      "root:123456@(127.0.0.1:3306)/test",
+	"tidb":       "root:123456@(127.0.0.1:4000)/test",
+	"postgresql": "root:123456@127.0.0.1:5432/test?sslmode=disable",
+	"sqlite":     "/path/to/sponge.db",
+	"mongodb":    "root:123456@127.0.0.1:27017/test",
+}
+
+// NewCommand create a project by the interactive wizard in terminal
+func NewCommand() *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "new",
+		Short: "Create a project by the interactive wizard in terminal",
+		Long: `Create a project by the interactive wizard in terminal, it walks through the server type, database,
+tables and features (cache, tracing, jwt) step by step, previews the equivalent command, and generates
+the same code as the UI interface (sponge run), it is suitable for working over SSH without browser access.`,
+		Example: color.HiBlackString(`  # Create a project step by step.
+  sponge new
+
+  # Only preview the equivalent command, the code is not generated.
+  sponge new --dry-run`),
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			w := &wizard{reader: bufio.NewReader(os.Stdin), out: os.Stdout}
+			p, err := w.run()
+			if err != nil {
+				if errors.Is(err, errWizardCanceled) {
+					fmt.Println("\nCanceled, no code is generated.")
+					return nil
+				}
+				return err
+			}
+
+			w.preview(p)
+			if dryRun {
+				return nil
+			}
+			ok, err := w.confirm("Generate code now?", true)
+			if err != nil || !ok {
+				fmt.Println("\nCanceled, no code is generated.")
+				return nil
+			}
+			fmt.Println()
+
+			root := NewRootCMD()
+			root.SetArgs(p.args())
+			if err = root.Execute(); err != nil {
+				return err
+			}
+			return p.applyFeatures()
+		},
+	}
+
+	cmd.Flags().BoolVarP(&dryRun, "dry-run", "", false, "only preview the equivalent command, the code is not generated")
+
+	return cmd
+}
+
+type wizardServerType struct {
+	label    string
+	commands []string // sub commands of sponge, e.g. web http
+	isSQL    bool     // generate code based on the tables of database, otherwise based on protobuf file
+	isJWT    bool     // the routes of tables can use jwt authentication
+}
+
+type wizardProject struct {
+	serverType   wizardServerType
+	serverName   string
+	moduleName   string
+	projectName  string
+	protobufFile string
+	dbDriver     string
+	dbDSN        string
+	tables       []string
+	extendedAPI  bool
+	cacheType    string // empty, memory or redis
+	enableTrace  bool
+	enableJWT    bool
+	ciType       string
+	outPath      string
+}
+
+// args returns the arguments of the equivalent command
+func (p *wizardProject) args() []string {
+	args := append([]string{}, p.serverType.commands...)
+	args = append(args,
+		"--module-name="+p.moduleName,
+		"--server-name="+p.serverName,
+		"--project-name="+p.projectName,
+	)
+	if p.protobufFile != "" {
+		args = append(args, "--protobuf-file="+p.protobufFile)
+	}
+	if p.dbDriver != "" {
+		args = append(args, "--db-driver="+p.dbDriver)
+	}
+	if p.dbDSN != "" {
+		args = append(args, "--db-dsn="+p.dbDSN)
+	}
+	if len(p.tables) > 0 {
+		args = append(args, "--db-table="+strings.Join(p.tables, ","))
+	}
+	if p.extendedAPI {
+		args = append(args, "--extended-api=true")
+	}
+	if p.ciType != "" {
+		args = append(args, "--ci="+p.ciType)
+	}
+	return append(args, "--out="+p.outPath)
+}
+
+// features returns the descriptions of features applied to the generated code
+func (p *wizardProject) features() []string {
+	var features []string
+	if p.cacheType != "" {
+		features = append(features, fmt.Sprintf(`cache: set cacheType: "%s" in configs/%s.yml`, p.cacheType, p.serverName))
+	}
+	if p.enableTrace {
+		features = append(features, fmt.Sprintf("tracing: set enableTrace: true in configs/%s.yml", p.serverName))
+	}
+	if p.enableJWT {
+		features = append(features, "jwt: the routes of tables use jwt authentication middleware.Auth() in internal/routers")
+	}
+	return features
+}
+
+var (
+	cacheTypeRegexp   = regexp.MustCompile(`(?m)^(\s*cacheType:\s*)""`)
+	enableTraceRegexp = regexp.MustCompile(`(?m)^(\s*enableTrace:\s*)false`)
+)
+
+// applyFeatures apply the features that are not the parameters of command to the generated code
+func (p *wizardProject) applyFeatures() error {
+	if p.cacheType != "" || p.enableTrace {
+		for _, dir := range []string{"configs", "deployments"} {
+			err := walkFiles(filepath.Join(p.outPath, dir), ".yml", func(data []byte) []byte {
+				if p.cacheType != "" {
+					data = cacheTypeRegexp.ReplaceAll(data, []byte(`${1}"`+p.cacheType+`"`))
+				}
+				if p.enableTrace {
+					data = enableTraceRegexp.ReplaceAll(data, []byte("${1}true"))
+				}
+				return data
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if p.enableJWT {
+		err := walkFiles(filepath.Join(p.outPath, "internal", "routers"), ".go", func(data []byte) []byte {
+			return enableJWTAuth(data)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, feature := range p.features() {
+		fmt.Println(installedSymbol + feature)
+	}
+	return nil
+}
+
+const (
+	jwtAuthCommentCode = "\t//g.Use(middleware.Auth())\n"
+	jwtAuthCode        = "\tg.Use(middleware.Auth())\n"
+	ginImportCode      = "\t\"github.com/gin-gonic/gin\"\n"
+	middlewareImport   = "\"github.com/go-dev-frame/sponge/pkg/gin/middleware\""
+)
+
+// enableJWTAuth uncomment the jwt authentication of the routes of table
+func enableJWTAuth(data []byte) []byte {
+	code := string(data)
+	if !strings.Contains(code, jwtAuthCommentCode) {
+		return data
+	}
+	code = strings.Replace(code, jwtAuthCommentCode, jwtAuthCode, 1)
+	if !strings.Contains(code, middlewareImport) {
+		code = strings.Replace(code, ginImportCode, ginImportCode+"\n\t"+middlewareImport+"\n", 1)
+	}
+	if formatted, err := format.Source([]byte(code)); err == nil {
+		return formatted
+	}
+	return []byte(code)
+}
+
+func walkFiles(dir string, ext string, fn func(data []byte) []byte) error {
+	if !gofile.IsExists(dir) {
+		return nil
+	}
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || filepath.Ext(path) != ext {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		newData := fn(data)
+		if string(newData) == string(data) {
+			return nil
+		}
+		return os.WriteFile(path, newData, info.Mode())
+	})
+}
+
+type wizard struct {
+	reader *bufio.Reader
+	out    io.Writer
+}
+
+func (w *wizard) run() (*wizardProject, error) {
+	fmt.Fprintln(w.out, "Create a project step by step, press Enter to use the default value in [], Ctrl+C to exit.")
+	p := &wizardProject{}
+
+	// server type
+	labels := make([]string, 0, len(wizardServerTypes))
+	for _, t := range wizardServerTypes {
+		labels = append(labels, t.label)
+	}
+	i, err := w.selectOne("Server type", labels, 0)
+	if err != nil {
+		return nil, err
+	}
+	p.serverType = wizardServerTypes[i]
+
+	// names
+	if p.serverName, err = w.input("Server name", "", "e.g. user"); err != nil {
+		return nil, err
+	}
+	if p.moduleName, err = w.input("Module name of go.mod", p.serverName, "e.g. github.com/your-name/user"); err != nil {
+		return nil, err
+	}
+	if p.projectName, err = w.input("Project name", p.serverName, "used in the names of deployment"); err != nil {
+		return nil, err
+	}
+
+	// database and tables, or protobuf file
+	if p.serverType.isSQL {
+		if err = w.selectTables(p); err != nil {
+			return nil, err
+		}
+		if p.extendedAPI, err = w.confirm("Generate the extended api (DeleteByIDs, GetByCondition, ListByIDs, ListByLatestID)?", false); err != nil {
+			return nil, err
+		}
+	} else {
+		if err = w.inputProtobufFile(p); err != nil {
+			return nil, err
+		}
+	}
+
+	// features
+	if p.dbDriver != "" {
+		cacheTypes := []string{"none", "memory", "redis"}
+		if i, err = w.selectOne("Cache", cacheTypes, 0); err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			p.cacheType = cacheTypes[i]
+		}
+	}
+	if p.enableTrace, err = w.confirm("Enable tracing (opentelemetry)?", false); err != nil {
+		return nil, err
+	}
+	if p.serverType.isJWT {
+		if p.enableJWT, err = w.confirm("Use jwt authentication in the routes of tables?", false); err != nil {
+			return nil, err
+		}
+	}
+	ciTypes := []string{"none", "github", "gitlab"}
+	if i, err = w.selectOne("CI/CD pipeline", ciTypes, 0); err != nil {
+		return nil, err
+	}
+	if i > 0 {
+		p.ciType = ciTypes[i]
+	}
+
+	// output
+	defaultOut := "./" + strings.ReplaceAll(p.serverName, "-", "_") + "_" + p.serverType.commands[1]
+	if p.outPath, err = w.input("Output directory", defaultOut, ""); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (w *wizard) selectTables(p *wizardProject) error {
+	i, err := w.selectOne("Database driver", server.DBDrivers, 0)
+	if err != nil {
+		return err
+	}
+	p.dbDriver = server.DBDrivers[i]
+
+	var tables []string
+	for {
+		if p.dbDSN, err = w.input("Database dsn", "", "e.g. "+dsnExamples[p.dbDriver]); err != nil {
+			return err
+		}
+		fmt.Fprintln(w.out, color.HiBlackString("connecting to database ..."))
+		tables, err = server.GetTableNames(p.dbDriver, p.dbDSN)
+		if err != nil {
+			fmt.Fprintf(w.out, "%s%v, please check the dsn.\n", lackSymbol, err)
+			continue
+		}
+		if len(tables) == 0 {
+			fmt.Fprintf(w.out, "%sno tables found in the database, please check the dsn.\n", lackSymbol)
+			continue
+		}
+		break
+	}
+
+	p.tables, err = w.selectMulti("Tables", tables)
+	return err
+}
+
+func (w *wizard) inputProtobufFile(p *wizardProject) error {
+	for {
+		file, err := w.input("Protobuf file, support * matching", "", "e.g. ./api/user/v1/*.proto")
+		if err != nil {
+			return err
+		}
+		files, _ := filepath.Glob(file)
+		if len(files) == 0 {
+			fmt.Fprintf(w.out, "%snot found protobuf file %s\n", lackSymbol, file)
+			continue
+		}
+		p.protobufFile = file
+		return nil
+	}
+}
+
+func (w *wizard) preview(p *wizardProject) {
+	fmt.Fprintln(w.out, "\n"+color.HiCyanString("Preview"))
+	rows := [][2]string{
+		{"server type", p.serverType.label},
+		{"server name", p.serverName},
+		{"module name", p.moduleName},
+		{"project name", p.projectName},
+	}
+	if p.protobufFile != "" {
+		rows = append(rows, [2]string{"protobuf file", p.protobufFile})
+	}
+	if p.dbDriver != "" {
+		rows = append(rows, [2]string{"database", p.dbDriver})
+	}
+	if len(p.tables) > 0 {
+		rows = append(rows, [2]string{"tables", strings.Join(p.tables, ", ")})
+	}
+	rows = append(rows, [2]string{"output", p.outPath})
+	for _, row := range rows {
+		fmt.Fprintf(w.out, "  %-14s %s\n", row[0], row[1])
+	}
+
+	fmt.Fprintln(w.out, "\n"+color.HiCyanString("Equivalent command"))
+	args := p.args()
+	for i, arg := range args {
+		if strings.ContainsAny(arg, " \t'\"*?()&;|<>$") {
+			if k, v, ok := strings.Cut(arg, "="); ok {
+				args[i] = k + "=" + strconv.Quote(v)
+			}
+		}
+	}
+	fmt.Fprintln(w.out, "  sponge "+strings.Join(args, " "))
+
+	if features := p.features(); len(features) > 0 {
+		fmt.Fprintln(w.out, "\n"+color.HiCyanString("Features applied to the generated code"))
+		for _, feature := range features {
+			fmt.Fprintln(w.out, "  - "+feature)
+		}
+	}
+	fmt.Fprintln(w.out)
+}
+
+func (w *wizard) readLine() (string, error) {
+	line, err := w.reader.ReadString('\n')
+	if err != nil {
+		if errors.Is(err, io.EOF) && line != "" {
+			return strings.TrimSpace(line), nil
+		}
+		if errors.Is(err, io.EOF) {
+			return "", errWizardCanceled
+		}
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// input read a value, the default value is used if it is empty, the value is required if there is no default value.
+func (w *wizard) input(label string, defaultValue string, hint string) (string, error) {
+	for {
+		prompt := color.HiCyanString("? ") + label
+		if hint != "" {
+			prompt += " " + color.HiBlackString("("+hint+")")
+		}
+		if defaultValue != "" {
+			prompt += " [" + defaultValue + "]"
+		}
+		fmt.Fprint(w.out, prompt+": ")
+
+		value, err := w.readLine()
+		if err != nil {
+			return "", err
+		}
+		if value == "" {
+			value = defaultValue
+		}
+		if value != "" {
+			return value, nil
+		}
+		fmt.Fprintf(w.out, "%s%s cannot be empty\n", warnSymbol, label)
+	}
+}
+
+// selectOne select one of the options by number, returns the index of option.
+func (w *wizard) selectOne(label string, options []string, defaultIndex int) (int, error) {
+	fmt.Fprintln(w.out, color.HiCyanString("? ")+label)
+	for i, option := range options {
+		fmt.Fprintf(w.out, "  %d) %s\n", i+1, option)
+	}
+	for {
+		fmt.Fprintf(w.out, "  select [%d]: ", defaultIndex+1)
+		value, err := w.readLine()
+		if err != nil {
+			return 0, err
+		}
+		if value == "" {
+			return defaultIndex, nil
+		}
+		n, err := strconv.Atoi(value)
+		if err == nil && n >= 1 && n <= len(options) {
+			return n - 1, nil
+		}
+		fmt.Fprintf(w.out, "%splease enter a number from 1 to %d\n", warnSymbol, len(options))
+	}
+}
+
+// selectMulti select multiple options by numbers and ranges, e.g. 1,3,5-7, "all" selects all options.
+func (w *wizard) selectMulti(label string, options []string) ([]string, error) {
+	fmt.Fprintln(w.out, color.HiCyanString("? ")+label)
+	for i, option := range options {
+		fmt.Fprintf(w.out, "  %d) %s\n", i+1, option)
+	}
+	for {
+		fmt.Fprint(w.out, "  select, e.g. 1,3,5-7 or all: ")
+		value, err := w.readLine()
+		if err != nil {
+			return nil, err
+		}
+		selected, err := parseSelection(value, len(options))
+		if err != nil {
+			fmt.Fprintf(w.out, "%s%v\n", warnSymbol, err)
+			continue
+		}
+		values := make([]string, 0, len(selected))
+		for _, i := range selected {
+			values = append(values, options[i])
+		}
+		return values, nil
+	}
+}
+
+// parseSelection parse the numbers and ranges of selection to the indexes of options.
+func parseSelection(value string, n int) ([]int, error) {
+	if strings.EqualFold(value, "all") {
+		indexes := make([]int, n)
+		for i := range indexes {
+			indexes[i] = i
+		}
+		return indexes, nil
+	}
+
+	var indexes []int
+	seen := make(map[int]bool)
+	for _, s := range strings.Split(value, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		start, end, isRange := strings.Cut(s, "-")
+		if !isRange {
+			end = start
+		}
+		from, err1 := strconv.Atoi(strings.TrimSpace(start))
+		to, err2 := strconv.Atoi(strings.TrimSpace(end))
+		if err1 != nil || err2 != nil || from < 1 || to > n || from > to {
+			return nil, fmt.Errorf("invalid selection %q, please enter numbers from 1 to %d", s, n)
+		}
+		for i := from - 1; i < to; i++ {
+			if !seen[i] {
+				seen[i] = true
+				indexes = append(indexes, i)
+			}
+		}
+	}
+	if len(indexes) == 0 {
+		return nil, errors.New("select at least one")
+	}
+	return indexes, nil
+}
+
+func (w *wizard) confirm(label string, defaultValue bool) (bool, error) {
+	options := "y/N"
+	if defaultValue {
+		options = "Y/n"
+	}
+	for {
+		fmt.Fprintf(w.out, "%s%s [%s]: ", color.HiCyanString("? "), label, options)
+		value, err := w.readLine()
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(value) {
+		case "":
+			return defaultValue, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+	}
+}
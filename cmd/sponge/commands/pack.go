@@ -0,0 +1,326 @@
+package commands
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/go-dev-frame/sponge/pkg/gobash"
+	"github.com/go-dev-frame/sponge/pkg/gofile"
+)
+
+const (
+	archiveManifestFile    = "manifest.json"
+	archiveTemplatesDir    = "templates/"
+	archivePluginsDir      = "plugins/"
+	defaultTemplateArchive = "sponge-templates.tar.gz"
+)
+
+// the manifest of template archive, it is used to check whether the archive matches the offline environment
+type templateArchiveManifest struct {
+	Version   string   `json:"version"`
+	GOOS      string   `json:"goos"`
+	GOARCH    string   `json:"goarch"`
+	Plugins   []string `json:"plugins"`
+	CreatedAt string   `json:"createdAt"`
+}
+
+// PackTemplatesCommand pack the template code and plugins into an archive for offline initialization
+func PackTemplatesCommand() *cobra.Command {
+	var (
+		outFile     string
+		skipPlugins bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "pack-templates",
+		Short: "Pack the template code and plugins into an archive for offline initialization",
+		Long: `Pack the template code and plugins into an archive for offline initialization.
+
+The archive contains the template code of the current sponge version and the installed dependency plugin binaries,
+copy it to the air-gapped environment and initialize sponge by the command: sponge init --from-archive=archive-file.
+Note: the plugin binaries only work on the same operating system and architecture as the current environment.`,
+		Example: color.HiBlackString(`  # Pack the template code and plugins, execute "sponge init" first if the template code does not exist.
+  sponge pack-templates
+
+  # Pack to the specified file and skip the plugins.
+  sponge pack-templates --out=/tmp/sponge-templates.tar.gz --skip-plugins`),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			templateDir := adaptPathDelimiter(GetSpongeDir() + "/.sponge")
+			if !gofile.IsExists(templateDir) {
+				return errors.New("not found the template code, please execute the command \"sponge init\" first")
+			}
+
+			plugins := map[string]string{} // plugin name --> binary file
+			if !skipPlugins {
+				installedNames, lackNames := checkInstallPlugins()
+				for _, name := range installedNames {
+					if name == "go" {
+						continue
+					}
+					if file, err := exec.LookPath(name); err == nil {
+						plugins[name] = file
+					}
+				}
+				if len(lackNames) > 0 {
+					fmt.Printf("%s the plugins %s are not installed, they are not packed into the archive.\n",
+						warnSymbol, strings.Join(lackNames, ", "))
+				}
+			}
+
+			if err := packTemplates(outFile, templateDir, plugins); err != nil {
+				return err
+			}
+			fmt.Printf("pack the template code (version %s) and %d plugins successfully, out = %s\n",
+				getVersion(), len(plugins), outFile)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&outFile, "out", "o", defaultTemplateArchive, "output archive file")
+	cmd.Flags().BoolVarP(&skipPlugins, "skip-plugins", "s", false, "don't pack the plugin binaries")
+
+	return cmd
+}
+
+func packTemplates(outFile string, templateDir string, plugins map[string]string) error {
+	file, err := os.Create(outFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close() //nolint
+	gw := gzip.NewWriter(file)
+	tw := tar.NewWriter(gw)
+
+	manifest := &templateArchiveManifest{
+		Version:   getVersion(),
+		GOOS:      runtime.GOOS,
+		GOARCH:    runtime.GOARCH,
+		Plugins:   []string{},
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	for name := range plugins {
+		manifest.Plugins = append(manifest.Plugins, name)
+	}
+	data, _ := json.MarshalIndent(manifest, "", "  ")
+	err = tw.WriteHeader(&tar.Header{Name: archiveManifestFile, Mode: 0644, Size: int64(len(data)), ModTime: time.Now()})
+	if err != nil {
+		return err
+	}
+	if _, err = tw.Write(data); err != nil {
+		return err
+	}
+
+	err = filepath.WalkDir(templateDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == templateDir {
+			return err
+		}
+		relPath, err := filepath.Rel(templateDir, path)
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && !d.Type().IsRegular() {
+			return nil // skip symbolic links and other special files
+		}
+		return addFileToTar(tw, path, archiveTemplatesDir+filepath.ToSlash(relPath))
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, binFile := range plugins {
+		if err = addFileToTar(tw, binFile, archivePluginsDir+filepath.Base(binFile)); err != nil {
+			return err
+		}
+	}
+
+	if err = tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+func addFileToTar(tw *tar.Writer, file string, name string) error {
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	if info.IsDir() {
+		header.Name += "/"
+		return tw.WriteHeader(header)
+	}
+	if err = tw.WriteHeader(header); err != nil {
+		return err
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close() //nolint
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// initFromArchive initialize sponge from the archive generated by the command pack-templates,
+// the template code is extracted to the sponge directory, and the plugins are installed to the plugin directory.
+func initFromArchive(archiveFile string, pluginDir string) (*templateArchiveManifest, error) {
+	if pluginDir == "" {
+		dir, err := getGoBinDir()
+		if err != nil {
+			return nil, err
+		}
+		pluginDir = dir
+	}
+	if err := os.MkdirAll(pluginDir, 0755); err != nil {
+		return nil, err
+	}
+
+	targetDir := adaptPathDelimiter(GetSpongeDir() + "/.sponge")
+	tmpDir := targetDir + ".tmp"
+	_ = os.RemoveAll(tmpDir)
+	defer os.RemoveAll(tmpDir) //nolint
+
+	manifest, err := extractTemplateArchive(archiveFile, tmpDir, pluginDir)
+	if err != nil {
+		return nil, err
+	}
+	if manifest == nil {
+		return nil, fmt.Errorf("%s is not a template archive generated by the command \"sponge pack-templates\"", archiveFile)
+	}
+	if !gofile.IsExists(tmpDir) {
+		return nil, fmt.Errorf("not found the template code in the archive %s", archiveFile)
+	}
+
+	if err = os.RemoveAll(targetDir); err != nil {
+		return nil, err
+	}
+	if err = os.Rename(tmpDir, targetDir); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func extractTemplateArchive(archiveFile string, templateDir string, pluginDir string) (*templateArchiveManifest, error) {
+	file, err := os.Open(archiveFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close() //nolint
+	gr, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("read archive %s error: %v", archiveFile, err)
+	}
+	defer gr.Close() //nolint
+
+	var manifest *templateArchiveManifest
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read archive %s error: %v", archiveFile, err)
+		}
+
+		switch {
+		case header.Name == archiveManifestFile:
+			manifest = &templateArchiveManifest{}
+			if err = json.NewDecoder(tr).Decode(manifest); err != nil {
+				return nil, fmt.Errorf("parse %s error: %v", archiveManifestFile, err)
+			}
+			if manifest.GOOS != runtime.GOOS || manifest.GOARCH != runtime.GOARCH {
+				fmt.Printf("%s the plugins in the archive are built for %s/%s, they may not work on %s/%s.\n",
+					warnSymbol, manifest.GOOS, manifest.GOARCH, runtime.GOOS, runtime.GOARCH)
+			}
+
+		case strings.HasPrefix(header.Name, archiveTemplatesDir):
+			err = extractTarEntry(tr, header, templateDir, strings.TrimPrefix(header.Name, archiveTemplatesDir), 0)
+
+		case strings.HasPrefix(header.Name, archivePluginsDir) && header.Typeflag == tar.TypeReg:
+			err = extractTarEntry(tr, header, pluginDir, strings.TrimPrefix(header.Name, archivePluginsDir), 0755)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return manifest, nil
+}
+
+// extract the file or directory to the target directory, the paths outside the target directory are rejected
+func extractTarEntry(tr *tar.Reader, header *tar.Header, targetDir string, name string, mode os.FileMode) error {
+	if name == "" {
+		return nil
+	}
+	targetDir = filepath.Clean(targetDir)
+	path := filepath.Join(targetDir, filepath.FromSlash(name))
+	if !strings.HasPrefix(path, targetDir+string(os.PathSeparator)) {
+		return fmt.Errorf("illegal file path %s in the archive", header.Name)
+	}
+
+	switch header.Typeflag {
+	case tar.TypeDir:
+		return os.MkdirAll(path, 0755)
+	case tar.TypeReg:
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if mode == 0 {
+			mode = os.FileMode(header.Mode).Perm() | 0600
+		}
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(f, tr) //nolint
+		_ = f.Close()
+		if err != nil {
+			return err
+		}
+		return os.Chmod(path, mode)
+	}
+	return nil
+}
+
+// get the directory where go install puts the binaries, $GOBIN or the first $GOPATH/bin
+func getGoBinDir() (string, error) {
+	result, err := gobash.Exec("go", "env", "GOBIN")
+	if err != nil {
+		return "", fmt.Errorf("get GOBIN failed, %v, please specify the plugin directory by --plugin-dir", err)
+	}
+	if dir := strings.TrimSpace(string(result)); dir != "" {
+		return dir, nil
+	}
+
+	result, err = gobash.Exec("go", "env", "GOPATH")
+	if err != nil {
+		return "", fmt.Errorf("get GOPATH failed, %v, please specify the plugin directory by --plugin-dir", err)
+	}
+	gopath := strings.TrimSpace(string(result))
+	if gopath == "" {
+		return "", errors.New("$GOPATH is empty, please specify the plugin directory by --plugin-dir")
+	}
+	return filepath.Join(filepath.SplitList(gopath)[0], "bin"), nil
+}
@@ -27,6 +27,7 @@ func PatchCommand() *cobra.Command {
 		patch.ModifyDuplicateErrorCodeOffsetCommand(),
 		patch.AdaptMonoRepoCommand(),
 		patch.ModifyProtoPackageCommand(),
+		patch.GenMockCommand(),
 	)
 
 	return cmd
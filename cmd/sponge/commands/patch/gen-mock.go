@@ -0,0 +1,514 @@
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"go/importer"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/go-dev-frame/sponge/pkg/gofile"
+)
+
+const mockCodeHeader = "// Code generated by sponge patch gen-mock. DO NOT EDIT.\n\n"
+
+// GenMockCommand generate gomock mocks of the interfaces
+func GenMockCommand() *cobra.Command {
+	var (
+		dir    string // directories of the interfaces
+		outDir string // output directory
+	)
+
+	cmd := &cobra.Command{
+		Use:   "gen-mock",
+		Short: "Generate gomock mocks of the dao, cache and rpc client interfaces",
+		Long: `Generate gomock mocks of the dao, cache and rpc client interfaces.
+
+All the exported interfaces in the directories are mocked, only the interfaces whose names end with Client
+are mocked in the api directory. The mocks are generated in the package mocks, the function mocks.New creates
+all the mocks with the same gomock controller, it is convenient to use them in the handler and service tests.`,
+		Example: color.HiBlackString(`  # Generate mocks of the interfaces in the internal/dao, internal/cache and api directories
+  sponge patch gen-mock
+
+  # Generate mocks of the interfaces in the specified directories
+  sponge patch gen-mock --dir=internal/dao,internal/service --out=internal/mocks`),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			files, err := loadMockFiles(strings.Split(dir, ","), outDir)
+			if err != nil {
+				return err
+			}
+			if len(files) == 0 {
+				fmt.Println("not found interfaces to mock, no mock files are generated.")
+				return nil
+			}
+
+			if err = os.MkdirAll(outDir, 0766); err != nil {
+				return err
+			}
+			var outFiles []string
+			for _, file := range files {
+				outFile := filepath.Join(outDir, file.name)
+				if err = writeGoFile(outFile, file.generate()); err != nil {
+					return err
+				}
+				outFiles = append(outFiles, outFile)
+			}
+			outFile := filepath.Join(outDir, "mocks.go")
+			if err = writeGoFile(outFile, generateMocksHelper(files)); err != nil {
+				return err
+			}
+			outFiles = append(outFiles, outFile)
+
+			fmt.Printf("generate mock files successfully, out = %s\n", strings.Join(outFiles, ", "))
+			fmt.Println("if the package github.com/golang/mock/gomock is not in go.mod, please execute the command 'go mod tidy'.")
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&dir, "dir", "d", "internal/dao,internal/cache,api", "directories of the interfaces, multiple directories separated by commas")
+	cmd.Flags().StringVarP(&outDir, "out", "o", "internal/mocks", "output directory of the mock files")
+
+	return cmd
+}
+
+type mockInterface struct {
+	name  string // interface name
+	iface *types.Interface
+}
+
+// mockFile the mocks of the interfaces in one package
+type mockFile struct {
+	name       string // e.g. dao.go
+	pkgPath    string
+	interfaces []*mockInterface
+}
+
+// goListPackage is the package information output by go list
+type goListPackage struct {
+	ImportPath string
+	Dir        string
+	Export     string // the file of export data
+	DepOnly    bool
+	Error      *struct{ Err string }
+}
+
+// load the interfaces in the directories and their sub directories, the types are read from
+// the export data of packages compiled by go list.
+func loadMockFiles(dirs []string, outDir string) ([]*mockFile, error) {
+	var patterns []string
+	for _, dir := range dirs {
+		dir = strings.Trim(strings.TrimSpace(dir), "/")
+		if dir == "" || !gofile.IsExists(dir) {
+			continue
+		}
+		patterns = append(patterns, "./"+dir+"/...")
+	}
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+
+	args := append([]string{"list", "-e", "-export", "-deps", "-json=ImportPath,Dir,Export,DepOnly,Error"}, patterns...)
+	stderr := &bytes.Buffer{}
+	cmd := exec.Command("go", args...)
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list error: %v, %s", err, stderr.String())
+	}
+
+	exports := map[string]string{} // import path --> export data file
+	var targets []*goListPackage
+	decoder := json.NewDecoder(bytes.NewReader(out))
+	for decoder.More() {
+		pkg := &goListPackage{}
+		if err = decoder.Decode(pkg); err != nil {
+			return nil, err
+		}
+		exports[pkg.ImportPath] = pkg.Export
+		if pkg.DepOnly {
+			continue
+		}
+		if pkg.Error != nil {
+			return nil, fmt.Errorf("load package %s error: %s", pkg.ImportPath, pkg.Error.Err)
+		}
+		if pkg.Export == "" {
+			return nil, fmt.Errorf("compile package %s failed, please check the code by executing the command 'go build ./...'", pkg.ImportPath)
+		}
+		targets = append(targets, pkg)
+	}
+
+	imp := importer.ForCompiler(token.NewFileSet(), "gc", func(path string) (io.ReadCloser, error) {
+		file, ok := exports[path]
+		if !ok || file == "" {
+			return nil, fmt.Errorf("not found export data of package %s", path)
+		}
+		return os.Open(file)
+	})
+
+	currentDir, _ := filepath.Abs(".")
+	absOutDir, _ := filepath.Abs(outDir)
+	var files []*mockFile
+	for _, target := range targets {
+		if target.Dir == absOutDir {
+			continue
+		}
+		pkg, err := imp.Import(target.ImportPath)
+		if err != nil {
+			return nil, err
+		}
+		relDir, err := filepath.Rel(currentDir, target.Dir)
+		if err != nil {
+			return nil, err
+		}
+		relDir = filepath.ToSlash(relDir)
+
+		file := &mockFile{name: mockFileName(relDir), pkgPath: target.ImportPath}
+		onlyClient := relDir == "api" || strings.HasPrefix(relDir, "api/")
+		scope := pkg.Scope()
+		for _, name := range scope.Names() {
+			iface := getMockableInterface(scope.Lookup(name))
+			if iface == nil || (onlyClient && !strings.HasSuffix(name, "Client")) {
+				continue
+			}
+			file.interfaces = append(file.interfaces, &mockInterface{name: name, iface: iface})
+		}
+		if len(file.interfaces) > 0 {
+			files = append(files, file)
+		}
+	}
+
+	return files, checkMockNames(files)
+}
+
+// only the exported non-generic interfaces with methods can be mocked
+func getMockableInterface(obj types.Object) *types.Interface {
+	typeName, ok := obj.(*types.TypeName)
+	if !ok || !typeName.Exported() || typeName.IsAlias() {
+		return nil
+	}
+	named, ok := typeName.Type().(*types.Named)
+	if !ok || named.TypeParams().Len() > 0 {
+		return nil
+	}
+	iface, ok := named.Underlying().(*types.Interface)
+	if !ok || !iface.IsMethodSet() || iface.NumMethods() == 0 {
+		return nil
+	}
+	return iface
+}
+
+// e.g. internal/dao --> dao.go, api/user/v1 --> user_v1.go
+func mockFileName(relDir string) string {
+	name := strings.TrimPrefix(relDir, "internal/")
+	name = strings.TrimPrefix(name, "api/")
+	return strings.ReplaceAll(name, "/", "_") + ".go"
+}
+
+// the mocks are in the same package, so the interface names must be unique
+func checkMockNames(files []*mockFile) error {
+	names := map[string]string{}
+	for _, file := range files {
+		for _, iface := range file.interfaces {
+			if pkgPath, ok := names[iface.name]; ok {
+				return fmt.Errorf("the interface %s exists in both %s and %s, please generate their mocks separately with different --out",
+					iface.name, pkgPath, file.pkgPath)
+			}
+			names[iface.name] = file.pkgPath
+		}
+	}
+	return nil
+}
+
+func writeGoFile(file string, code []byte) error {
+	data, err := format.Source(code)
+	if err != nil {
+		return fmt.Errorf("format code of %s error: %v", file, err)
+	}
+	return os.WriteFile(file, data, 0666)
+}
+
+var versionPkgNameRegexp = regexp.MustCompile(`^v\d+$`)
+
+// mockImports records the imported packages of a mock file and their aliases.
+type mockImports struct {
+	aliases map[string]string // package path --> alias
+	used    map[string]bool
+}
+
+func (im *mockImports) typeString(t types.Type) string {
+	return types.TypeString(resolveUnexportedAlias(t), im.qualifier)
+}
+
+// the unexported alias types can't be referenced by mocks, they are replaced by the actual types,
+// e.g. type keyType = string
+func resolveUnexportedAlias(t types.Type) types.Type {
+	switch tt := t.(type) {
+	case *types.Alias:
+		if !tt.Obj().Exported() {
+			return resolveUnexportedAlias(types.Unalias(tt))
+		}
+	case *types.Pointer:
+		return types.NewPointer(resolveUnexportedAlias(tt.Elem()))
+	case *types.Slice:
+		return types.NewSlice(resolveUnexportedAlias(tt.Elem()))
+	case *types.Array:
+		return types.NewArray(resolveUnexportedAlias(tt.Elem()), tt.Len())
+	case *types.Map:
+		return types.NewMap(resolveUnexportedAlias(tt.Key()), resolveUnexportedAlias(tt.Elem()))
+	case *types.Chan:
+		return types.NewChan(tt.Dir(), resolveUnexportedAlias(tt.Elem()))
+	}
+	return t
+}
+
+func newMockImports() *mockImports {
+	return &mockImports{
+		aliases: map[string]string{},
+		used:    map[string]bool{"gomock": true, "reflect": true},
+	}
+}
+
+// qualifier get the alias of package, the alias of version package is prefixed with the parent directory name,
+// e.g. github.com/foo/api/user/v1 --> userV1
+func (im *mockImports) qualifier(pkg *types.Package) string {
+	switch pkg.Path() {
+	case "reflect", "github.com/golang/mock/gomock":
+		return pkg.Name()
+	}
+	if alias, ok := im.aliases[pkg.Path()]; ok {
+		return alias
+	}
+
+	alias := pkg.Name()
+	if versionPkgNameRegexp.MatchString(alias) {
+		ss := strings.Split(pkg.Path(), "/")
+		if len(ss) > 1 {
+			alias = strings.NewReplacer("-", "", ".", "").Replace(ss[len(ss)-2]) + strings.ToUpper(alias[:1]) + alias[1:]
+		}
+	}
+	name := alias
+	for i := 2; im.used[alias]; i++ {
+		alias = fmt.Sprintf("%s%d", name, i)
+	}
+
+	im.aliases[pkg.Path()] = alias
+	im.used[alias] = true
+	return alias
+}
+
+// the standard library packages are in the first group, the alias is omitted if it is the same as the package name
+func (im *mockImports) code() string {
+	paths := []string{"reflect", "github.com/golang/mock/gomock"}
+	for path := range im.aliases {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var stdPkgs, otherPkgs []string
+	for _, path := range paths {
+		line := strconv.Quote(path)
+		if alias, ok := im.aliases[path]; ok && alias != path[strings.LastIndex(path, "/")+1:] {
+			line = alias + " " + line
+		}
+		if strings.Contains(strings.Split(path, "/")[0], ".") {
+			otherPkgs = append(otherPkgs, line)
+		} else {
+			stdPkgs = append(stdPkgs, line)
+		}
+	}
+
+	return "import (\n\t" + strings.Join(stdPkgs, "\n\t") + "\n\n\t" + strings.Join(otherPkgs, "\n\t") + "\n)\n"
+}
+
+func (f *mockFile) generate() []byte {
+	im := newMockImports()
+	body := &bytes.Buffer{}
+	for _, iface := range f.interfaces {
+		iface.generate(body, im)
+	}
+
+	buf := &bytes.Buffer{}
+	buf.WriteString(mockCodeHeader)
+	fmt.Fprintf(buf, "package mocks\n\n%s\n", im.code())
+	buf.Write(body.Bytes())
+	return buf.Bytes()
+}
+
+func (i *mockInterface) generate(buf *bytes.Buffer, im *mockImports) {
+	mockName := "Mock" + i.name
+	recorderName := mockName + "MockRecorder"
+
+	fmt.Fprintf(buf, `
+// %[1]s is a mock of %[3]s interface.
+type %[1]s struct {
+	ctrl     *gomock.Controller
+	recorder *%[2]s
+}
+
+// %[2]s is the mock recorder for %[1]s.
+type %[2]s struct {
+	mock *%[1]s
+}
+
+// New%[1]s creates a new mock instance.
+func New%[1]s(ctrl *gomock.Controller) *%[1]s {
+	mock := &%[1]s{ctrl: ctrl}
+	mock.recorder = &%[2]s{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *%[1]s) EXPECT() *%[2]s {
+	return m.recorder
+}
+`, mockName, recorderName, i.name)
+
+	for j := 0; j < i.iface.NumMethods(); j++ {
+		method := i.iface.Method(j)
+		generateMockMethod(buf, im, mockName, recorderName, method.Name(), method.Type().(*types.Signature))
+	}
+}
+
+func generateMockMethod(buf *bytes.Buffer, im *mockImports, mockName string, recorderName string, methodName string, sig *types.Signature) {
+	params := sig.Params()
+	names := make([]string, params.Len())
+	paramList := make([]string, params.Len())
+	for j := 0; j < params.Len(); j++ {
+		param := params.At(j)
+		typeStr := im.typeString(param.Type())
+		if sig.Variadic() && j == params.Len()-1 {
+			typeStr = "..." + im.typeString(param.Type().(*types.Slice).Elem())
+		}
+		names[j] = param.Name()
+		if !isValidMockParamName(names[j], im) {
+			names[j] = fmt.Sprintf("arg%d", j)
+		}
+		paramList[j] = names[j] + " " + typeStr
+	}
+
+	results := sig.Results()
+	resultList := make([]string, results.Len())
+	for j := 0; j < results.Len(); j++ {
+		resultList[j] = im.typeString(results.At(j).Type())
+	}
+	resultStr := strings.Join(resultList, ", ")
+	if results.Len() > 1 {
+		resultStr = "(" + resultStr + ")"
+	}
+
+	// mock method
+	callArgs := ""
+	fmt.Fprintf(buf, "\n// %s mocks base method.\nfunc (m *%s) %s(%s) %s {\n\tm.ctrl.T.Helper()\n",
+		methodName, mockName, methodName, strings.Join(paramList, ", "), resultStr)
+	if sig.Variadic() {
+		fixedNames := names[:len(names)-1]
+		fmt.Fprintf(buf, "\tvarargs := []interface{}{%s}\n\tfor _, a := range %s {\n\t\tvarargs = append(varargs, a)\n\t}\n",
+			strings.Join(fixedNames, ", "), names[len(names)-1])
+		callArgs = ", varargs..."
+	} else if len(names) > 0 {
+		callArgs = ", " + strings.Join(names, ", ")
+	}
+	if results.Len() == 0 {
+		fmt.Fprintf(buf, "\tm.ctrl.Call(m, %q%s)\n}\n", methodName, callArgs)
+	} else {
+		fmt.Fprintf(buf, "\tret := m.ctrl.Call(m, %q%s)\n", methodName, callArgs)
+		retNames := make([]string, results.Len())
+		for j := range resultList {
+			retNames[j] = fmt.Sprintf("ret%d", j)
+			fmt.Fprintf(buf, "\t%s, _ := ret[%d].(%s)\n", retNames[j], j, resultList[j])
+		}
+		fmt.Fprintf(buf, "\treturn %s\n}\n", strings.Join(retNames, ", "))
+	}
+
+	// recorder method
+	recorderParams := ""
+	recordArgs := ""
+	if sig.Variadic() {
+		fixedNames := names[:len(names)-1]
+		if len(fixedNames) > 0 {
+			recorderParams = strings.Join(fixedNames, ", ") + " interface{}, "
+		}
+		recorderParams += names[len(names)-1] + " ...interface{}"
+		recordArgs = ", varargs..."
+	} else if len(names) > 0 {
+		recorderParams = strings.Join(names, ", ") + " interface{}"
+		recordArgs = ", " + strings.Join(names, ", ")
+	}
+	fmt.Fprintf(buf, "\n// %s indicates an expected call of %s.\nfunc (mr *%s) %s(%s) *gomock.Call {\n\tmr.mock.ctrl.T.Helper()\n",
+		methodName, methodName, recorderName, methodName, recorderParams)
+	if sig.Variadic() {
+		fmt.Fprintf(buf, "\tvarargs := append([]interface{}{%s}, %s...)\n",
+			strings.Join(names[:len(names)-1], ", "), names[len(names)-1])
+	}
+	fmt.Fprintf(buf, "\treturn mr.mock.ctrl.RecordCallWithMethodType(mr.mock, %q, reflect.TypeOf((*%s)(nil).%s)%s)\n}\n",
+		methodName, mockName, methodName, recordArgs)
+}
+
+// the parameter name can't be empty or conflict with the receivers, local variables and imported packages
+func isValidMockParamName(name string, im *mockImports) bool {
+	switch name {
+	case "", "_", "m", "mr", "ret", "varargs", "a":
+		return false
+	}
+	if strings.HasPrefix(name, "ret") || im.used[name] {
+		return false
+	}
+	return true
+}
+
+// generate the helper to create all the mocks
+func generateMocksHelper(files []*mockFile) []byte {
+	var names []string
+	for _, file := range files {
+		for _, iface := range file.interfaces {
+			names = append(names, iface.name)
+		}
+	}
+	sort.Strings(names)
+
+	buf := &bytes.Buffer{}
+	buf.WriteString(mockCodeHeader)
+	buf.WriteString(`// Package mocks is the gomock mocks of the dao, cache and rpc client interfaces.
+package mocks
+
+import "github.com/golang/mock/gomock"
+
+// Mocks is the collection of all the mocks, they share the same gomock controller.
+type Mocks struct {
+	Ctrl *gomock.Controller
+
+`)
+	for _, name := range names {
+		fmt.Fprintf(buf, "\t%s *Mock%s\n", name, name)
+	}
+	buf.WriteString(`}
+
+// New create all the mocks, if t is *testing.T, the expected calls are verified when the test finishes.
+func New(t gomock.TestReporter) *Mocks {
+	ctrl := gomock.NewController(t)
+	return &Mocks{
+		Ctrl: ctrl,
+
+`)
+	for _, name := range names {
+		fmt.Fprintf(buf, "\t\t%s: NewMock%s(ctrl),\n", name, name)
+	}
+	buf.WriteString("\t}\n}\n")
+
+	return buf.Bytes()
+}
@@ -28,6 +28,8 @@ to be pushed to a custom HTTP endpoints or Prometheus, and supports two testing
 
 		http.PerfTestCollectorCMD(),
 		http.PerfTestAgentCMD(),
+		http.PerfTestDeployAgentsCMD(),
+		http.PerfTestComposeCMD(),
 	)
 
 	return cmd
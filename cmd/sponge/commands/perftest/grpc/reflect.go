@@ -0,0 +1,522 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	reflectionV1 "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	reflectionV1Alpha "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// max depth of expanding nested message fields
+const maxMessageDepth = 8
+
+// ReflectRequest the target gRPC server to be introspected by reflection
+type ReflectRequest struct {
+	Target   string            `json:"target" binding:"required"` // host:port
+	TLS      bool              `json:"tls"`                       // connect with tls, the certificate of server is not verified
+	Metadata map[string]string `json:"metadata"`                  // request metadata, e.g. authorization
+	Timeout  int               `json:"timeout"`                   // unit: second, default is 10s
+}
+
+// InvokeRequest call a unary method of target gRPC server with json body
+type InvokeRequest struct {
+	ReflectRequest
+	Method string          `json:"method" binding:"required"` // full method name, e.g. api.user.v1.User/GetByID
+	Body   json.RawMessage `json:"body"`                      // request message in json format
+}
+
+// ServiceInfo service of gRPC server
+type ServiceInfo struct {
+	Name    string        `json:"name"`
+	Methods []*MethodInfo `json:"methods"`
+}
+
+// MethodInfo method of service
+type MethodInfo struct {
+	Name            string       `json:"name"`
+	FullName        string       `json:"fullName"` // e.g. api.user.v1.User/GetByID
+	ClientStreaming bool         `json:"clientStreaming"`
+	ServerStreaming bool         `json:"serverStreaming"`
+	Input           *MessageInfo `json:"input"`
+	Output          *MessageInfo `json:"output"`
+	Example         string       `json:"example"` // json example of input message
+}
+
+// MessageInfo message type
+type MessageInfo struct {
+	Name      string       `json:"name"`
+	Fields    []*FieldInfo `json:"fields"`
+	Recursive bool         `json:"recursive,omitempty"` // the message is recursive or too deep, its fields are not expanded
+}
+
+// FieldInfo field of message
+type FieldInfo struct {
+	Name       string       `json:"name"` // json name
+	Number     int32        `json:"number"`
+	Type       string       `json:"type"` // scalar type, enum, message, or json for well-known types such as google.protobuf.Timestamp
+	Repeated   bool         `json:"repeated,omitempty"`
+	Map        bool         `json:"map,omitempty"`
+	MapKey     string       `json:"mapKey,omitempty"` // type of map key
+	OneOf      string       `json:"oneOf,omitempty"`  // name of oneof group
+	EnumValues []string     `json:"enumValues,omitempty"`
+	Message    *MessageInfo `json:"message,omitempty"` // message type or map value of message type
+	TypeName   string       `json:"typeName,omitempty"`
+}
+
+func (r *ReflectRequest) timeout() time.Duration {
+	if r.Timeout <= 0 {
+		return 10 * time.Second
+	}
+	return time.Duration(r.Timeout) * time.Second
+}
+
+func (r *ReflectRequest) dial() (*grpc.ClientConn, error) {
+	if r.Target == "" {
+		return nil, errors.New("target is empty")
+	}
+	creds := insecure.NewCredentials()
+	if r.TLS {
+		creds = credentials.NewTLS(&tls.Config{InsecureSkipVerify: true}) //nolint
+	}
+	return grpc.NewClient(r.Target, grpc.WithTransportCredentials(creds))
+}
+
+func (r *ReflectRequest) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout())
+	if len(r.Metadata) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(r.Metadata))
+	}
+	return ctx, cancel
+}
+
+// ListServices introspect the services, methods and fields of target gRPC server by server reflection,
+// the reflection service of server is excluded.
+func ListServices(ctx context.Context, req *ReflectRequest) ([]*ServiceInfo, error) {
+	conn, err := req.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close() //nolint
+
+	ctx, cancel := req.context(ctx)
+	defer cancel()
+	files, names, err := resolveServices(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	var services []*ServiceInfo
+	for _, name := range names {
+		if strings.HasPrefix(name, "grpc.reflection.") {
+			continue
+		}
+		d, err := files.FindDescriptorByName(protoreflect.FullName(name))
+		if err != nil {
+			return nil, fmt.Errorf("not found descriptor of service %s, %v", name, err)
+		}
+		sd, ok := d.(protoreflect.ServiceDescriptor)
+		if !ok {
+			continue
+		}
+		services = append(services, newServiceInfo(sd))
+	}
+	return services, nil
+}
+
+// Invoke call a unary method of target gRPC server, the request and response messages are in json format.
+func Invoke(ctx context.Context, req *InvokeRequest) (json.RawMessage, error) {
+	conn, err := req.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close() //nolint
+
+	ctx, cancel := req.context(ctx)
+	defer cancel()
+	serviceName, methodName, ok := strings.Cut(strings.TrimPrefix(req.Method, "/"), "/")
+	if !ok {
+		return nil, fmt.Errorf("invalid method %q, e.g. api.user.v1.User/GetByID", req.Method)
+	}
+	files, err := resolveSymbol(ctx, conn, serviceName)
+	if err != nil {
+		return nil, err
+	}
+	d, err := files.FindDescriptorByName(protoreflect.FullName(serviceName))
+	if err != nil {
+		return nil, fmt.Errorf("not found service %s, %v", serviceName, err)
+	}
+	sd, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", serviceName)
+	}
+	md := sd.Methods().ByName(protoreflect.Name(methodName))
+	if md == nil {
+		return nil, fmt.Errorf("not found method %s in service %s", methodName, serviceName)
+	}
+	if md.IsStreamingClient() || md.IsStreamingServer() {
+		return nil, errors.New("only unary method is supported")
+	}
+
+	in := dynamicpb.NewMessage(md.Input())
+	if len(req.Body) > 0 && string(req.Body) != "null" {
+		if err = (protojson.UnmarshalOptions{Resolver: dynamicpb.NewTypes(files)}).Unmarshal(req.Body, in); err != nil {
+			return nil, fmt.Errorf("invalid request body, %v", err)
+		}
+	}
+	out := dynamicpb.NewMessage(md.Output())
+	if err = conn.Invoke(ctx, "/"+serviceName+"/"+methodName, in, out); err != nil {
+		return nil, err
+	}
+	return protojson.MarshalOptions{EmitUnpopulated: true, Resolver: dynamicpb.NewTypes(files)}.Marshal(out)
+}
+
+// ------------------------------------------------------------------------------------------
+
+func newServiceInfo(sd protoreflect.ServiceDescriptor) *ServiceInfo {
+	info := &ServiceInfo{Name: string(sd.FullName())}
+	methods := sd.Methods()
+	for i := 0; i < methods.Len(); i++ {
+		md := methods.Get(i)
+		mi := &MethodInfo{
+			Name:            string(md.Name()),
+			FullName:        string(sd.FullName()) + "/" + string(md.Name()),
+			ClientStreaming: md.IsStreamingClient(),
+			ServerStreaming: md.IsStreamingServer(),
+			Input:           newMessageInfo(md.Input(), nil),
+			Output:          newMessageInfo(md.Output(), nil),
+		}
+		example, _ := protojson.MarshalOptions{EmitUnpopulated: true, Multiline: true}.Marshal(dynamicpb.NewMessage(md.Input()))
+		mi.Example = string(example)
+		info.Methods = append(info.Methods, mi)
+	}
+	return info
+}
+
+func newMessageInfo(md protoreflect.MessageDescriptor, parents []protoreflect.FullName) *MessageInfo {
+	info := &MessageInfo{Name: string(md.FullName())}
+	for _, p := range parents {
+		if p == md.FullName() {
+			info.Recursive = true
+			return info
+		}
+	}
+	if len(parents) >= maxMessageDepth {
+		info.Recursive = true
+		return info
+	}
+	parents = append(parents, md.FullName())
+
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		info.Fields = append(info.Fields, newFieldInfo(fields.Get(i), parents))
+	}
+	return info
+}
+
+func newFieldInfo(fd protoreflect.FieldDescriptor, parents []protoreflect.FullName) *FieldInfo {
+	fi := &FieldInfo{
+		Name:     fd.JSONName(),
+		Number:   int32(fd.Number()),
+		Repeated: fd.IsList(),
+	}
+	if oneOf := fd.ContainingOneof(); oneOf != nil && !oneOf.IsSynthetic() {
+		fi.OneOf = string(oneOf.Name())
+	}
+	if fd.IsMap() {
+		fi.Map = true
+		fi.MapKey = fd.MapKey().Kind().String()
+		fd = fd.MapValue()
+	}
+
+	switch fd.Kind() {
+	case protoreflect.EnumKind:
+		fi.Type = "enum"
+		fi.TypeName = string(fd.Enum().FullName())
+		values := fd.Enum().Values()
+		for i := 0; i < values.Len(); i++ {
+			fi.EnumValues = append(fi.EnumValues, string(values.Get(i).Name()))
+		}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		fi.TypeName = string(fd.Message().FullName())
+		if strings.HasPrefix(fi.TypeName, "google.protobuf.") {
+			fi.Type = "json" // well-known types have special json mapping, e.g. Timestamp is a string
+		} else {
+			fi.Type = "message"
+			fi.Message = newMessageInfo(fd.Message(), parents)
+		}
+	default:
+		fi.Type = fd.Kind().String()
+	}
+	return fi
+}
+
+// ------------------------------------------------------------------------------------------
+
+// the client of reflection service, v1 is preferred, and v1alpha is used for the old servers
+type reflectionClient interface {
+	listServices() ([]string, error)
+	fileContainingSymbol(symbol string) ([][]byte, error)
+	fileByFilename(name string) ([][]byte, error)
+	close()
+}
+
+func newReflectionClient(ctx context.Context, conn *grpc.ClientConn) (reflectionClient, error) {
+	stream, err := reflectionV1.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err == nil {
+		c := &reflectionClientV1{stream: stream}
+		if _, err = c.listServices(); err == nil {
+			return c, nil
+		}
+		c.close()
+	}
+	if status.Code(err) != codes.Unimplemented {
+		return nil, fmt.Errorf("server reflection error, %v", err)
+	}
+
+	alphaStream, err := reflectionV1Alpha.NewServerReflectionClient(conn).ServerReflectionInfo(ctx) //nolint
+	if err != nil {
+		return nil, fmt.Errorf("server reflection error, %v", err)
+	}
+	return &reflectionClientV1Alpha{stream: alphaStream}, nil
+}
+
+type reflectionClientV1 struct {
+	stream reflectionV1.ServerReflection_ServerReflectionInfoClient
+}
+
+func (c *reflectionClientV1) send(req *reflectionV1.ServerReflectionRequest) (*reflectionV1.ServerReflectionResponse, error) {
+	if err := c.stream.Send(req); err != nil {
+		return nil, err
+	}
+	resp, err := c.stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	if e := resp.GetErrorResponse(); e != nil {
+		return nil, status.Error(codes.Code(e.GetErrorCode()), e.GetErrorMessage())
+	}
+	return resp, nil
+}
+
+func (c *reflectionClientV1) listServices() ([]string, error) {
+	resp, err := c.send(&reflectionV1.ServerReflectionRequest{
+		MessageRequest: &reflectionV1.ServerReflectionRequest_ListServices{},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, s := range resp.GetListServicesResponse().GetService() {
+		names = append(names, s.GetName())
+	}
+	return names, nil
+}
+
+func (c *reflectionClientV1) fileContainingSymbol(symbol string) ([][]byte, error) {
+	resp, err := c.send(&reflectionV1.ServerReflectionRequest{
+		MessageRequest: &reflectionV1.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: symbol},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetFileDescriptorResponse().GetFileDescriptorProto(), nil
+}
+
+func (c *reflectionClientV1) fileByFilename(name string) ([][]byte, error) {
+	resp, err := c.send(&reflectionV1.ServerReflectionRequest{
+		MessageRequest: &reflectionV1.ServerReflectionRequest_FileByFilename{FileByFilename: name},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetFileDescriptorResponse().GetFileDescriptorProto(), nil
+}
+
+func (c *reflectionClientV1) close() {
+	_ = c.stream.CloseSend()
+}
+
+type reflectionClientV1Alpha struct {
+	stream reflectionV1Alpha.ServerReflection_ServerReflectionInfoClient //nolint
+}
+
+func (c *reflectionClientV1Alpha) send(req *reflectionV1Alpha.ServerReflectionRequest) (*reflectionV1Alpha.ServerReflectionResponse, error) { //nolint
+	if err := c.stream.Send(req); err != nil {
+		return nil, err
+	}
+	resp, err := c.stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	if e := resp.GetErrorResponse(); e != nil {
+		return nil, status.Error(codes.Code(e.GetErrorCode()), e.GetErrorMessage())
+	}
+	return resp, nil
+}
+
+func (c *reflectionClientV1Alpha) listServices() ([]string, error) {
+	resp, err := c.send(&reflectionV1Alpha.ServerReflectionRequest{ //nolint
+		MessageRequest: &reflectionV1Alpha.ServerReflectionRequest_ListServices{}, //nolint
+	})
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, s := range resp.GetListServicesResponse().GetService() {
+		names = append(names, s.GetName())
+	}
+	return names, nil
+}
+
+func (c *reflectionClientV1Alpha) fileContainingSymbol(symbol string) ([][]byte, error) {
+	resp, err := c.send(&reflectionV1Alpha.ServerReflectionRequest{ //nolint
+		MessageRequest: &reflectionV1Alpha.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: symbol}, //nolint
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetFileDescriptorResponse().GetFileDescriptorProto(), nil
+}
+
+func (c *reflectionClientV1Alpha) fileByFilename(name string) ([][]byte, error) {
+	resp, err := c.send(&reflectionV1Alpha.ServerReflectionRequest{ //nolint
+		MessageRequest: &reflectionV1Alpha.ServerReflectionRequest_FileByFilename{FileByFilename: name}, //nolint
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetFileDescriptorResponse().GetFileDescriptorProto(), nil
+}
+
+func (c *reflectionClientV1Alpha) close() {
+	_ = c.stream.CloseSend()
+}
+
+// ------------------------------------------------------------------------------------------
+
+// collect the file descriptors of symbols and their dependencies
+type fileResolver struct {
+	client reflectionClient
+	files  map[string]*descriptorpb.FileDescriptorProto
+}
+
+func (r *fileResolver) add(data [][]byte) error {
+	for _, b := range data {
+		fdp := &descriptorpb.FileDescriptorProto{}
+		if err := proto.Unmarshal(b, fdp); err != nil {
+			return err
+		}
+		r.files[fdp.GetName()] = fdp
+	}
+	return nil
+}
+
+func (r *fileResolver) addSymbol(symbol string) error {
+	data, err := r.client.fileContainingSymbol(symbol)
+	if err != nil {
+		return fmt.Errorf("get file descriptor of %s error, %v", symbol, err)
+	}
+	return r.add(data)
+}
+
+// the dependencies which are not sent by server are requested by filename
+func (r *fileResolver) build() (*protoregistry.Files, error) {
+	for {
+		var missing []string
+		for _, fdp := range r.files {
+			for _, dep := range fdp.GetDependency() {
+				if _, ok := r.files[dep]; !ok {
+					missing = append(missing, dep)
+				}
+			}
+		}
+		if len(missing) == 0 {
+			break
+		}
+		for _, name := range missing {
+			if _, ok := r.files[name]; ok {
+				continue
+			}
+			data, err := r.client.fileByFilename(name)
+			if err != nil {
+				return nil, fmt.Errorf("get file descriptor %s error, %v", name, err)
+			}
+			if err = r.add(data); err != nil {
+				return nil, err
+			}
+			if _, ok := r.files[name]; !ok {
+				return nil, fmt.Errorf("not found file descriptor %s", name)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(r.files))
+	for name := range r.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, name := range names {
+		set.File = append(set.File, r.files[name])
+	}
+	return protodesc.NewFiles(set)
+}
+
+func resolveServices(ctx context.Context, conn *grpc.ClientConn) (*protoregistry.Files, []string, error) {
+	client, err := newReflectionClient(ctx, conn)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer client.close()
+
+	names, err := client.listServices()
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Strings(names)
+	r := &fileResolver{client: client, files: map[string]*descriptorpb.FileDescriptorProto{}}
+	for _, name := range names {
+		if strings.HasPrefix(name, "grpc.reflection.") {
+			continue
+		}
+		if err = r.addSymbol(name); err != nil {
+			return nil, nil, err
+		}
+	}
+	files, err := r.build()
+	return files, names, err
+}
+
+func resolveSymbol(ctx context.Context, conn *grpc.ClientConn, symbol string) (*protoregistry.Files, error) {
+	client, err := newReflectionClient(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	defer client.close()
+
+	r := &fileResolver{client: client, files: map[string]*descriptorpb.FileDescriptorProto{}}
+	if err = r.addSymbol(symbol); err != nil {
+		return nil, err
+	}
+	return r.build()
+}
@@ -0,0 +1,209 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	reflectionV1Alpha "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	_ "google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// run a gRPC server with health service and reflection service, returns the address of server
+func runTestServer(t *testing.T, onlyV1Alpha bool, opts ...grpc.ServerOption) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer(opts...)
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("user", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+	if onlyV1Alpha {
+		reflectionV1Alpha.RegisterServerReflectionServer(server, reflection.NewServer(reflection.ServerOptions{Services: server})) //nolint
+	} else {
+		reflection.Register(server)
+	}
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+	return lis.Addr().String()
+}
+
+func TestListServices(t *testing.T) {
+	for _, onlyV1Alpha := range []bool{false, true} {
+		req := &ReflectRequest{Target: runTestServer(t, onlyV1Alpha), Timeout: 5}
+		services, err := ListServices(context.Background(), req)
+		require.NoError(t, err, "v1alpha=%v", onlyV1Alpha)
+
+		// the reflection service is excluded
+		require.Len(t, services, 1)
+		s := services[0]
+		assert.Equal(t, "grpc.health.v1.Health", s.Name)
+		methods := map[string]*MethodInfo{}
+		for _, m := range s.Methods {
+			methods[m.Name] = m
+		}
+		require.Contains(t, methods, "Check")
+		require.Contains(t, methods, "Watch")
+
+		check := methods["Check"]
+		assert.Equal(t, "grpc.health.v1.Health/Check", check.FullName)
+		assert.False(t, check.ClientStreaming)
+		assert.False(t, check.ServerStreaming)
+		assert.Equal(t, "grpc.health.v1.HealthCheckRequest", check.Input.Name)
+		assert.Equal(t, []*FieldInfo{{Name: "service", Number: 1, Type: "string"}}, check.Input.Fields)
+		assert.JSONEq(t, `{"service": ""}`, check.Example)
+		require.Len(t, check.Output.Fields, 1)
+		status := check.Output.Fields[0]
+		assert.Equal(t, "enum", status.Type)
+		assert.Equal(t, "grpc.health.v1.HealthCheckResponse.ServingStatus", status.TypeName)
+		assert.Contains(t, status.EnumValues, "SERVING")
+
+		assert.True(t, methods["Watch"].ServerStreaming)
+	}
+}
+
+func TestInvoke(t *testing.T) {
+	// the metadata of request is sent to server
+	var md metadata.MD
+	target := runTestServer(t, false, grpc.UnaryInterceptor(func(ctx context.Context, req interface{},
+		info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ = metadata.FromIncomingContext(ctx)
+		return handler(ctx, req)
+	}))
+	invoke := func(method string, body string) ([]byte, error) {
+		return Invoke(context.Background(), &InvokeRequest{
+			ReflectRequest: ReflectRequest{Target: target, Metadata: map[string]string{"authorization": "Bearer token"}},
+			Method:         method,
+			Body:           json.RawMessage(body),
+		})
+	}
+
+	data, err := invoke("grpc.health.v1.Health/Check", `{"service": ""}`)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"status": "SERVING"}`, string(data))
+	assert.Equal(t, []string{"Bearer token"}, md.Get("authorization"))
+
+	data, err = invoke("/grpc.health.v1.Health/Check", `{"service": "user"}`)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"status": "NOT_SERVING"}`, string(data))
+
+	// empty body
+	for _, body := range []string{"", "null"} {
+		data, err = invoke("grpc.health.v1.Health/Check", body)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"status": "SERVING"}`, string(data))
+	}
+
+	tests := []struct {
+		method string
+		body   string
+		errMsg string
+	}{
+		{method: "grpc.health.v1.Health.Check", errMsg: "invalid method"},
+		{method: "grpc.health.v1.Health/Watch", errMsg: "only unary method is supported"},
+		{method: "grpc.health.v1.Health/Ping", errMsg: "not found method"},
+		{method: "grpc.health.v1.Unknown/Check", errMsg: "get file descriptor"},
+		{method: "grpc.health.v1.Health/Check", body: `{"name": "user"}`, errMsg: "invalid request body"},
+		{method: "grpc.health.v1.Health/Check", body: `{"service": "unknown"}`, errMsg: "unknown service"},
+	}
+	for _, tt := range tests {
+		_, err = invoke(tt.method, tt.body)
+		assert.ErrorContains(t, err, tt.errMsg, tt.method)
+	}
+
+	_, err = Invoke(context.Background(), &InvokeRequest{Method: "grpc.health.v1.Health/Check"})
+	assert.ErrorContains(t, err, "target is empty")
+}
+
+func TestReflectRequest_timeout(t *testing.T) {
+	assert.Equal(t, 10*time.Second, (&ReflectRequest{}).timeout())
+	assert.Equal(t, 3*time.Second, (&ReflectRequest{Timeout: 3}).timeout())
+
+	// no reflection service
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	go func() { _ = server.Serve(lis) }()
+	defer server.Stop()
+	_, err = ListServices(context.Background(), &ReflectRequest{Target: lis.Addr().String(), Timeout: 1})
+	assert.Error(t, err)
+}
+
+func TestNewMessageInfo(t *testing.T) {
+	// message Node {
+	//   string name = 1;
+	//   Node parent = 2;
+	//   repeated Node children = 3;
+	//   map<string, Node> labels = 4;
+	//   oneof value { int64 id = 5; google.protobuf.Timestamp created_at = 6; }
+	// }
+	label := func(l descriptorpb.FieldDescriptorProto_Label) *descriptorpb.FieldDescriptorProto_Label { return &l }
+	typ := func(t descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto_Type { return &t }
+	optional, repeated := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL, descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("node.proto"),
+		Package:    proto.String("test"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/timestamp.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Node"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{Name: proto.String("name"), JsonName: proto.String("name"), Number: proto.Int32(1), Label: label(optional), Type: typ(descriptorpb.FieldDescriptorProto_TYPE_STRING)},
+				{Name: proto.String("parent"), JsonName: proto.String("parent"), Number: proto.Int32(2), Label: label(optional), Type: typ(descriptorpb.FieldDescriptorProto_TYPE_MESSAGE), TypeName: proto.String(".test.Node")},
+				{Name: proto.String("children"), JsonName: proto.String("children"), Number: proto.Int32(3), Label: label(repeated), Type: typ(descriptorpb.FieldDescriptorProto_TYPE_MESSAGE), TypeName: proto.String(".test.Node")},
+				{Name: proto.String("labels"), JsonName: proto.String("labels"), Number: proto.Int32(4), Label: label(repeated), Type: typ(descriptorpb.FieldDescriptorProto_TYPE_MESSAGE), TypeName: proto.String(".test.Node.LabelsEntry")},
+				{Name: proto.String("id"), JsonName: proto.String("id"), Number: proto.Int32(5), Label: label(optional), Type: typ(descriptorpb.FieldDescriptorProto_TYPE_INT64), OneofIndex: proto.Int32(0)},
+				{Name: proto.String("created_at"), JsonName: proto.String("createdAt"), Number: proto.Int32(6), Label: label(optional), Type: typ(descriptorpb.FieldDescriptorProto_TYPE_MESSAGE), TypeName: proto.String(".google.protobuf.Timestamp"), OneofIndex: proto.Int32(0)},
+			},
+			NestedType: []*descriptorpb.DescriptorProto{{
+				Name: proto.String("LabelsEntry"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{Name: proto.String("key"), JsonName: proto.String("key"), Number: proto.Int32(1), Label: label(optional), Type: typ(descriptorpb.FieldDescriptorProto_TYPE_STRING)},
+					{Name: proto.String("value"), JsonName: proto.String("value"), Number: proto.Int32(2), Label: label(optional), Type: typ(descriptorpb.FieldDescriptorProto_TYPE_MESSAGE), TypeName: proto.String(".test.Node")},
+				},
+				Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+			}},
+			OneofDecl: []*descriptorpb.OneofDescriptorProto{{Name: proto.String("value")}},
+		}},
+	}
+	fd, err := protodesc.NewFile(fdp, protoregistry.GlobalFiles)
+	require.NoError(t, err)
+
+	info := newMessageInfo(fd.Messages().ByName("Node"), nil)
+	assert.Equal(t, "test.Node", info.Name)
+	assert.False(t, info.Recursive)
+	require.Len(t, info.Fields, 6)
+
+	assert.Equal(t, &FieldInfo{Name: "name", Number: 1, Type: "string"}, info.Fields[0])
+
+	// the recursive message is not expanded
+	parent := info.Fields[1]
+	assert.Equal(t, "message", parent.Type)
+	assert.Equal(t, &MessageInfo{Name: "test.Node", Recursive: true}, parent.Message)
+	children := info.Fields[2]
+	assert.True(t, children.Repeated)
+	assert.True(t, children.Message.Recursive)
+
+	labels := info.Fields[3]
+	assert.True(t, labels.Map)
+	assert.False(t, labels.Repeated)
+	assert.Equal(t, "string", labels.MapKey)
+	assert.Equal(t, "test.Node", labels.TypeName)
+	assert.True(t, labels.Message.Recursive)
+
+	assert.Equal(t, &FieldInfo{Name: "id", Number: 5, Type: "int64", OneOf: "value"}, info.Fields[4])
+	// well-known type
+	assert.Equal(t, &FieldInfo{Name: "createdAt", Number: 6, Type: "json", OneOf: "value", TypeName: "google.protobuf.Timestamp"}, info.Fields[5])
+}
@@ -29,9 +29,11 @@ import (
 // PerfTestAgentCMD is the command for performance testing agent
 func PerfTestAgentCMD() *cobra.Command {
 	var (
-		yamlFile string
-		agentIP  string
-		agentID  string
+		yamlFile      string
+		agentIP       string
+		agentID       string
+		bandwidth     string
+		latencyJitter string
 	)
 
 	cmd := &cobra.Command{
@@ -40,7 +42,10 @@ func PerfTestAgentCMD() *cobra.Command {
 		Long: "Run an agent process to execute API performance tests. The agent supports HTTP/1.1, HTTP/2, and HTTP/3 protocols, " +
 			"and is designed to work as part of a distributed cluster managed by the collector service.",
 		Example: color.HiBlackString(`  # Running agent
-  %s agent --config=/path/to/agent.yml`, common.CommandPrefix),
+  %s agent --config=/path/to/agent.yml
+
+  # Running agent that simulates constrained clients, the flags take precedence over the configuration
+  %s agent --config=/path/to/agent.yml --bandwidth=1mbps --latency-jitter=20ms±10ms`, common.CommandPrefix, common.CommandPrefix),
 		SilenceErrors: true,
 		SilenceUsage:  true,
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -55,7 +60,7 @@ func PerfTestAgentCMD() *cobra.Command {
 
 			// define the core logic for restarting services
 			restartService := func(cfg *agentConfig) {
-				if err := cfg.validate(agentID, agentIP); err != nil {
+				if err := cfg.validate(agentID, agentIP, bandwidth, latencyJitter); err != nil {
 					log.Printf("new configuration is invalid, service will not be started: %v", err)
 					return
 				}
@@ -85,7 +90,7 @@ func PerfTestAgentCMD() *cobra.Command {
 
 				newCfg := *config
 				newCfgPtr := &newCfg
-				if err := newCfgPtr.validate(agentID, agentIP); err != nil {
+				if err := newCfgPtr.validate(agentID, agentIP, bandwidth, latencyJitter); err != nil {
 					log.Printf("new configuration is invalid, agent will not be started: %v", err)
 					return
 				}
@@ -142,6 +147,8 @@ func PerfTestAgentCMD() *cobra.Command {
 	_ = cmd.MarkFlagRequired("config")
 	cmd.Flags().StringVarP(&agentIP, "agent-ip", "i", "", "agent ip address, the IP addresses of each agent in the cluster cannot be duplicated")
 	cmd.Flags().StringVarP(&agentID, "agent-id", "n", "", "agent ID, unique identifier of the agent")
+	cmd.Flags().StringVar(&bandwidth, "bandwidth", "", "limit the bandwidth of each connection in each direction to simulate constrained clients, e.g. 512kbps, 1mbps")
+	cmd.Flags().StringVar(&latencyJitter, "latency-jitter", "", "add latency with random jitter to each round trip of connections, e.g. 20ms±10ms, 20ms+-10ms, 20ms")
 
 	return cmd
 }
@@ -175,6 +182,10 @@ type agentConfig struct {
 	Total    uint64        `yaml:"total"`  // default 5000
 	Duration time.Duration `yaml:"duration"`
 
+	// simulate constrained clients, not supported by http3
+	Bandwidth     string `yaml:"bandwidth"`     // e.g. 512kbps, 1mbps
+	LatencyJitter string `yaml:"latencyJitter"` // e.g. 20ms±10ms
+
 	// push to target
 	PushURL           string        `yaml:"pushURL"`
 	AgentPushInterval time.Duration `yaml:"agentPushInterval"` // default 1s
@@ -188,10 +199,16 @@ type agentConfig struct {
 	LoopTestSession *bool   `yaml:"loopTestSession"` // default true
 }
 
-func (a *agentConfig) validate(agentID, agentIP string) error { //nolint
+func (a *agentConfig) validate(agentID, agentIP, bandwidth, latencyJitter string) error { //nolint
 	if agentID != "" {
 		a.AgentID = &agentID
 	}
+	if bandwidth != "" {
+		a.Bandwidth = bandwidth
+	}
+	if latencyJitter != "" {
+		a.LatencyJitter = latencyJitter
+	}
 	var agentHost = a.AgentHost
 	if agentIP != "" {
 		agentHost = fmt.Sprintf("http://%s:%d", agentIP, 6601) // default port 6601
@@ -228,6 +245,14 @@ func (a *agentConfig) validate(agentID, agentIP string) error { //nolint
 	if a.Total <= 0 && a.Duration <= 0 {
 		a.Total = 5000
 	}
+	if a.Bandwidth != "" || a.LatencyJitter != "" {
+		if a.Protocol == protocolHTTP3 {
+			return fmt.Errorf("invalid 'bandwidth' and 'latencyJitter', not supported by http3")
+		}
+		if _, err = newNetShaper(a.Bandwidth, a.LatencyJitter); err != nil {
+			return err
+		}
+	}
 
 	if a.AgentID == nil || *a.AgentID == "" {
 		return fmt.Errorf("invalid 'agentID', required")
@@ -273,14 +298,19 @@ func runAgent(ctx context.Context, a *agentConfig) error {
 		Body:    bodyBytes,
 	}
 
+	shaper, err := newNetShaper(a.Bandwidth, a.LatencyJitter)
+	if err != nil {
+		return err
+	}
+
 	var httpClient *http.Client
 	switch a.Protocol {
 	case protocolHTTP:
 		params.version = "HTTP/1.1"
-		httpClient = newHTTPClient(*a.Worker)
+		httpClient = newHTTPClient(*a.Worker, shaper)
 	case protocolHTTP2:
 		params.version = "HTTP/2"
-		httpClient = newHTTP2Client(*a.Worker)
+		httpClient = newHTTP2Client(*a.Worker, shaper)
 	case protocolHTTP3:
 		params.version = "HTTP/3"
 		httpClient = newHTTP3Client(*a.Worker)
@@ -37,6 +37,10 @@ func PerfTestCollectorCMD() *cobra.Command {
 		port          int
 		collectorHost string
 		agents        int
+
+		exposeMetrics       bool
+		remoteWriteURL      string
+		remoteWriteInterval time.Duration
 	)
 
 	cmd := &cobra.Command{
@@ -47,8 +51,14 @@ func PerfTestCollectorCMD() *cobra.Command {
   %s collector
 
   # Running the collector service and specify host address
-  %s collector --port=8888 --collector-address=http://<ip or domain name>:8888`,
-			common.CommandPrefix, common.CommandPrefix),
+  %s collector --port=8888 --collector-address=http://<ip or domain name>:8888
+
+  # Running the collector service and expose the aggregated metrics on /metrics for prometheus scraping
+  %s collector --metrics
+
+  # Running the collector service and push the aggregated metrics to prometheus by remote-write
+  %s collector --remote-write-url=http://localhost:9090/api/v1/write --remote-write-interval=5s`,
+			common.CommandPrefix, common.CommandPrefix, common.CommandPrefix, common.CommandPrefix),
 		SilenceErrors: true,
 		SilenceUsage:  true,
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -56,6 +66,16 @@ func PerfTestCollectorCMD() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			if exposeMetrics || remoteWriteURL != "" {
+				err = server.SetMetrics(&MetricsConfig{
+					Expose:              exposeMetrics,
+					RemoteWriteURL:      remoteWriteURL,
+					RemoteWriteInterval: remoteWriteInterval,
+				})
+				if err != nil {
+					return err
+				}
+			}
 			var printHelp func()
 			if agents > 0 {
 				session := server.createTest(agents)
@@ -79,6 +99,9 @@ func PerfTestCollectorCMD() *cobra.Command {
 	cmd.Flags().IntVarP(&port, "port", "p", 8888, "collector server port")
 	cmd.Flags().StringVarP(&collectorHost, "collector-address", "a", "", "the address where the collector service can be accessed in your browser, e.g. http://<ip or domain name>[:port]")
 	cmd.Flags().IntVarP(&agents, "agent_num", "n", 0, "number of agents to test")
+	cmd.Flags().BoolVarP(&exposeMetrics, "metrics", "m", false, "expose the aggregated metrics on /metrics for prometheus scraping")
+	cmd.Flags().StringVarP(&remoteWriteURL, "remote-write-url", "w", "", "prometheus remote-write url, push the live and final aggregated metrics to it, e.g. http://localhost:9090/api/v1/write")
+	cmd.Flags().DurationVarP(&remoteWriteInterval, "remote-write-interval", "i", defaultRemoteWriteInterval, "interval of pushing live aggregated metrics by remote-write")
 
 	return cmd
 }
@@ -103,8 +126,21 @@ type PerfTestData struct {
 	MaxLatency float64 `json:"max_latency"` // unit: ms
 	MinLatency float64 `json:"min_latency"` // unit: ms
 
-	TotalSent     int64 `json:"total_sent"`     // unit: bytes
-	TotalReceived int64 `json:"total_received"` // unit: bytes
+	TotalSent     int64            `json:"total_sent"`     // unit: bytes
+	TotalReceived int64            `json:"total_received"` // unit: bytes
+	SentRate      float64          `json:"sent_rate"`      // unit: bytes/sec
+	ReceivedRate  float64          `json:"received_rate"`  // unit: bytes/sec
+	Bandwidth     []BandwidthPoint `json:"bandwidth"`      // bytes sent and received per second
+
+	AvgRespSize float64 `json:"avg_resp_size"` // unit: bytes
+	MinRespSize int64   `json:"min_resp_size"` // unit: bytes
+	MaxRespSize int64   `json:"max_resp_size"` // unit: bytes
+	P50RespSize int64   `json:"p50_resp_size"` // unit: bytes
+	P95RespSize int64   `json:"p95_resp_size"` // unit: bytes
+	P99RespSize int64   `json:"p99_resp_size"` // unit: bytes
+
+	Phases           []PhaseLatency  `json:"phases"`            // latency breakdown of request phases
+	LatencyHistogram []LatencyBucket `json:"latency_histogram"` // latency distribution, unit: ms
 
 	StatusCodes map[int]int64 `json:"status_codes"`
 	CreatedAt   string        `json:"created_at"`
@@ -169,9 +205,24 @@ func (d *PerfTestData) printReport() {
 	builder.WriteStringf("  • %-19s%s ms\n\n", "P95:", float64ToStringNoRound(d.P95Latency))
 	builder.WriteStringf("  • %-19s%s ms\n\n", "P99:", float64ToStringNoRound(d.P99Latency))
 
+	if len(d.Phases) > 0 {
+		builder.WriteString("[Latency Breakdown]\n")
+		printPhases(&builder, d.Phases)
+	}
+
 	builder.WriteString("[Data Transfer]\n")
 	builder.WriteStringf("  • %-19s%d Bytes\n", "Sent:", d.TotalSent)
-	builder.WriteStringf("  • %-19s%d Bytes\n\n", "Received:", d.TotalReceived)
+	builder.WriteStringf("  • %-19s%d Bytes\n", "Received:", d.TotalReceived)
+	builder.WriteStringf("  • %-19s%s Bytes/sec\n", "Sent Rate:", float64ToStringNoRound(d.SentRate))
+	builder.WriteStringf("  • %-19s%s Bytes/sec\n\n", "Received Rate:", float64ToStringNoRound(d.ReceivedRate))
+
+	builder.WriteString("[Response Size]\n")
+	builder.WriteStringf("  • %-19s%s Bytes\n", "Average:", float64ToStringNoRound(d.AvgRespSize))
+	builder.WriteStringf("  • %-19s%d Bytes\n", "Minimum:", d.MinRespSize)
+	builder.WriteStringf("  • %-19s%d Bytes\n", "Maximum:", d.MaxRespSize)
+	builder.WriteStringf("  • %-19s%d Bytes\n", "P50:", d.P50RespSize)
+	builder.WriteStringf("  • %-19s%d Bytes\n", "P95:", d.P95RespSize)
+	builder.WriteStringf("  • %-19s%d Bytes\n\n", "P99:", d.P99RespSize)
 
 	if len(d.StatusCodes) > 0 {
 		builder.WriteString("[Status Codes]\n")
@@ -258,6 +309,9 @@ type CollectorServer struct {
 	port          int
 	collectorHost string
 	tests         map[string]*TestSession // testID -> TestSession
+
+	metrics       *collectorMetrics
+	exposeMetrics bool
 }
 
 func NewCollectorServer(port int, collectorHost string) (*CollectorServer, error) {
@@ -275,6 +329,21 @@ func NewCollectorServer(port int, collectorHost string) (*CollectorServer, error
 	}, nil
 }
 
+// SetMetrics export the aggregated reports as prometheus metrics, expose them on /metrics
+// or push them to prometheus by remote-write, it must be called before Run.
+func (s *CollectorServer) SetMetrics(cfg *MetricsConfig) error {
+	if cfg == nil || (!cfg.Expose && cfg.RemoteWriteURL == "") {
+		return nil
+	}
+	m, err := newCollectorMetrics(cfg)
+	if err != nil {
+		return err
+	}
+	s.metrics = m
+	s.exposeMetrics = cfg.Expose
+	return nil
+}
+
 // handleCreateTest create test session and return testID and current number of registered agents
 func (s *CollectorServer) handleCreateTest(c *gin.Context) {
 	hasPendingSession := false
@@ -596,6 +665,9 @@ func (s *CollectorServer) handleReport(c *gin.Context) {
 	}
 
 	if session.AggregatedReport != nil {
+		if s.metrics != nil {
+			s.metrics.update(session.AggregatedReport, session.Status == StatusCompleted || session.Status == StatusStopped)
+		}
 		session.AggregatedReport.printReport()
 		if session.Status == StatusCompleted || session.Status == StatusStopped {
 			printCreateTestHelp()
@@ -878,6 +950,12 @@ func (s *CollectorServer) aggregateReports(id string, reports map[string]PerfTes
 		p25Latencies, p50Latencies, p95Latencies, p99Latencies = []float64{}, []float64{}, []float64{}, []float64{}
 		maxDuration                                            float64
 
+		totalWeightedRespSize                    float64
+		p50RespSizes, p95RespSizes, p99RespSizes = []float64{}, []float64{}, []float64{}
+		bandwidthMap                             = make(map[int]*BandwidthPoint) // second --> bandwidth of all agents
+		phaseAgg                                 phaseAggregator
+		latencyHistograms                        = make([][]LatencyBucket, 0, len(reports))
+
 		errMap    = make(map[string][]string) // error message --> agent IDs
 		isFirst   = true
 		agentIDs  = make([]string, 0, len(reports))
@@ -889,6 +967,7 @@ func (s *CollectorServer) aggregateReports(id string, reports map[string]PerfTes
 			aggReport.URL = report.URL
 			aggReport.Method = report.Method
 			aggReport.MinLatency = report.MinLatency
+			aggReport.MinRespSize = report.MinRespSize
 			isFirst = false
 		}
 
@@ -899,6 +978,8 @@ func (s *CollectorServer) aggregateReports(id string, reports map[string]PerfTes
 		aggReport.TotalReceived += report.TotalReceived
 
 		aggReport.QPS += report.QPS
+		aggReport.SentRate += report.SentRate
+		aggReport.ReceivedRate += report.ReceivedRate
 
 		if report.TotalDuration > maxDuration {
 			maxDuration = report.TotalDuration
@@ -922,6 +1003,29 @@ func (s *CollectorServer) aggregateReports(id string, reports map[string]PerfTes
 		p95Latencies = append(p95Latencies, report.P95Latency)
 		p99Latencies = append(p99Latencies, report.P99Latency)
 
+		totalWeightedRespSize += report.AvgRespSize * float64(report.SuccessCount)
+		if report.MaxRespSize > aggReport.MaxRespSize {
+			aggReport.MaxRespSize = report.MaxRespSize
+		}
+		if report.MinRespSize < aggReport.MinRespSize {
+			aggReport.MinRespSize = report.MinRespSize
+		}
+		p50RespSizes = append(p50RespSizes, float64(report.P50RespSize))
+		p95RespSizes = append(p95RespSizes, float64(report.P95RespSize))
+		p99RespSizes = append(p99RespSizes, float64(report.P99RespSize))
+
+		phaseAgg.add(report.Phases)
+		latencyHistograms = append(latencyHistograms, report.LatencyHistogram)
+
+		for _, point := range report.Bandwidth {
+			if bp, ok := bandwidthMap[point.Second]; ok {
+				bp.Sent += point.Sent
+				bp.Received += point.Received
+			} else {
+				bandwidthMap[point.Second] = &BandwidthPoint{Second: point.Second, Sent: point.Sent, Received: point.Received}
+			}
+		}
+
 		for _, errs := range report.Errors {
 			if _, ok := errMap[errs]; !ok {
 				errMap[errs] = []string{agentID}
@@ -950,12 +1054,34 @@ func (s *CollectorServer) aggregateReports(id string, reports map[string]PerfTes
 		aggReport.AvgLatency = math.Round(totalWeightedLatency/float64(aggReport.TotalRequests)*100) / 100
 	}
 
+	// the same as latency, the average value is used to calculate p50, p95, and p99 of response size
+	aggReport.P50RespSize = int64(averageLatency(p50RespSizes))
+	aggReport.P95RespSize = int64(averageLatency(p95RespSizes))
+	aggReport.P99RespSize = int64(averageLatency(p99RespSizes))
+	if aggReport.SuccessCount > 0 {
+		aggReport.AvgRespSize = math.Round(totalWeightedRespSize/float64(aggReport.SuccessCount)*100) / 100
+	}
+
+	aggReport.Phases = phaseAgg.result()
+	aggReport.LatencyHistogram = mergeLatencyHistograms(latencyHistograms...)
+
+	// the agents start testing at the same time, the bandwidth of the same second is summed
+	aggReport.Bandwidth = make([]BandwidthPoint, 0, len(bandwidthMap))
+	for _, bp := range bandwidthMap {
+		aggReport.Bandwidth = append(aggReport.Bandwidth, *bp)
+	}
+	sort.Slice(aggReport.Bandwidth, func(i, j int) bool {
+		return aggReport.Bandwidth[i].Second < aggReport.Bandwidth[j].Second
+	})
+
 	for errStr, aids := range errMap {
 		aggReport.Errors = append(aggReport.Errors, fmt.Sprintf("%s (from agents %s)", errStr, strings.Join(aids, ", ")))
 	}
 
 	aggReport.ID = id
 	aggReport.QPS = math.Round(aggReport.QPS*10) / 10
+	aggReport.SentRate = math.Round(aggReport.SentRate*10) / 10
+	aggReport.ReceivedRate = math.Round(aggReport.ReceivedRate*10) / 10
 	aggReport.CreatedAt = time.Now().Format(time.RFC3339)
 	aggReport.AgentID = strings.Join(agentIDs, ", ")
 	status, _ := json.Marshal(statusMap)
@@ -995,9 +1121,18 @@ func (s *CollectorServer) Run(printHelp func()) error {
 	{
 		testGroup.POST("/report", s.handleReport)
 		testGroup.GET("/report", s.handleGetReport)
+		testGroup.GET("/latency-histogram", s.handleGetLatencyHistogram)
 		testGroup.POST("/stop", s.handleStopTest)
 	}
 	router.POST("/ping/:testID", s.handlePing)
+	grpcGroup := router.Group("/grpc")
+	{
+		grpcGroup.POST("/services", s.handleGRPCServices)
+		grpcGroup.POST("/invoke", s.handleGRPCInvoke)
+	}
+	if s.metrics != nil && s.exposeMetrics {
+		router.GET("/metrics", gin.WrapH(s.metrics.handler()))
+	}
 
 	host := s.collectorHost
 	f := frontend.New("perftest",
@@ -1034,10 +1169,23 @@ func (s *CollectorServer) Run(printHelp func()) error {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
+	metricsCtx, stopMetrics := context.WithCancel(context.Background())
+	metricsDone := make(chan struct{})
+	go func() {
+		defer close(metricsDone)
+		if s.metrics != nil {
+			s.metrics.runRemoteWrite(metricsCtx)
+		}
+	}()
+
 	go func() {
 		<-quit
 		log.Println("server is shutting down...")
 
+		// write the pending metrics before exiting
+		stopMetrics()
+		<-metricsDone
+
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
@@ -1062,6 +1210,7 @@ func (s *CollectorServer) Run(printHelp func()) error {
 	}
 
 	if err = server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		stopMetrics()
 		return fmt.Errorf("failed to start server: %v", err)
 	}
 
@@ -0,0 +1,53 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	perftestGRPC "github.com/go-dev-frame/sponge/cmd/sponge/commands/perftest/grpc"
+)
+
+// the write timeout of collector server is 10s, the request to target gRPC server must be done before it
+const maxGRPCTimeout = 8
+
+func fixGRPCTimeout(timeout int) int {
+	if timeout <= 0 || timeout > maxGRPCTimeout {
+		return maxGRPCTimeout
+	}
+	return timeout
+}
+
+// handleGRPCServices list the services, methods and fields of target gRPC server by server reflection
+func (s *CollectorServer) handleGRPCServices(c *gin.Context) {
+	req := &perftestGRPC.ReflectRequest{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request, " + err.Error()})
+		return
+	}
+	req.Timeout = fixGRPCTimeout(req.Timeout)
+
+	services, err := perftestGRPC.ListServices(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"services": services})
+}
+
+// handleGRPCInvoke call a unary method of target gRPC server, it is used to try the request before performance testing
+func (s *CollectorServer) handleGRPCInvoke(c *gin.Context) {
+	req := &perftestGRPC.InvokeRequest{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request, " + err.Error()})
+		return
+	}
+	req.Timeout = fixGRPCTimeout(req.Timeout)
+
+	data, err := perftestGRPC.Invoke(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": data})
+}
@@ -0,0 +1,67 @@
+package http
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+func TestFixGRPCTimeout(t *testing.T) {
+	assert.Equal(t, maxGRPCTimeout, fixGRPCTimeout(0))
+	assert.Equal(t, maxGRPCTimeout, fixGRPCTimeout(-1))
+	assert.Equal(t, 3, fixGRPCTimeout(3))
+	assert.Equal(t, maxGRPCTimeout, fixGRPCTimeout(maxGRPCTimeout+1))
+}
+
+func TestCollectorServer_handleGRPC(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	reflection.Register(server)
+	go func() { _ = server.Serve(lis) }()
+	defer server.Stop()
+	target := lis.Addr().String()
+
+	gin.SetMode(gin.TestMode)
+	s, err := NewCollectorServer(0, "")
+	require.NoError(t, err)
+	router := gin.New()
+	router.POST("/grpc/services", s.handleGRPCServices)
+	router.POST("/grpc/invoke", s.handleGRPCInvoke)
+	post := func(path string, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		return w
+	}
+
+	tests := []struct {
+		path     string
+		body     string
+		wantCode int
+		wantBody string
+	}{
+		{path: "/grpc/services", body: `{"target": "` + target + `"}`, wantCode: http.StatusOK, wantBody: `"fullName":"grpc.health.v1.Health/Check"`},
+		{path: "/grpc/services", body: `{}`, wantCode: http.StatusBadRequest, wantBody: "invalid request"},
+		{path: "/grpc/invoke", body: `{"target": "` + target + `", "method": "grpc.health.v1.Health/Check", "body": {"service": ""}}`,
+			wantCode: http.StatusOK, wantBody: `"status":"SERVING"`},
+		{path: "/grpc/invoke", body: `{"target": "` + target + `"}`, wantCode: http.StatusBadRequest, wantBody: "invalid request"},
+		{path: "/grpc/invoke", body: `{"target": "` + target + `", "method": "grpc.health.v1.Health/Ping"}`,
+			wantCode: http.StatusBadGateway, wantBody: "not found method"},
+	}
+	for _, tt := range tests {
+		w := post(tt.path, tt.body)
+		assert.Equal(t, tt.wantCode, w.Code, tt.body)
+		assert.Contains(t, w.Body.String(), tt.wantBody, tt.body)
+	}
+}
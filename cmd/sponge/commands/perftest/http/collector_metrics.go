@@ -0,0 +1,270 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const defaultRemoteWriteInterval = 5 * time.Second
+
+// MetricsConfig export the aggregated reports of collector as prometheus metrics,
+// the metrics are labeled by test_id, so that they can be correlated with production metrics.
+type MetricsConfig struct {
+	Expose              bool          // expose metrics on /metrics of collector server
+	RemoteWriteURL      string        // prometheus remote-write url, e.g. http://localhost:9090/api/v1/write
+	RemoteWriteInterval time.Duration // interval of writing live metrics, default 5s, the final metrics are written immediately
+}
+
+// the metrics of aggregated report, they are used by both /metrics and remote-write
+var reportMetricDefs = []struct {
+	name  string
+	help  string
+	value func(r *PerfTestData) float64
+}{
+	{"perftest_total_requests", "Total requests", func(r *PerfTestData) float64 { return float64(r.TotalRequests) }},
+	{"perftest_success_count", "Successful requests", func(r *PerfTestData) float64 { return float64(r.SuccessCount) }},
+	{"perftest_error_count", "Failed requests", func(r *PerfTestData) float64 { return float64(r.ErrorCount) }},
+	{"perftest_duration_seconds", "Test duration (seconds)", func(r *PerfTestData) float64 { return r.TotalDuration }},
+	{"perftest_qps", "Requests per second", func(r *PerfTestData) float64 { return r.QPS }},
+	{"perftest_avg_latency_ms", "Average latency (ms)", func(r *PerfTestData) float64 { return r.AvgLatency }},
+	{"perftest_min_latency_ms", "Minimum latency (ms)", func(r *PerfTestData) float64 { return r.MinLatency }},
+	{"perftest_max_latency_ms", "Maximum latency (ms)", func(r *PerfTestData) float64 { return r.MaxLatency }},
+	{"perftest_p25_latency_ms", "P25 latency (ms)", func(r *PerfTestData) float64 { return r.P25Latency }},
+	{"perftest_p50_latency_ms", "P50 latency (ms)", func(r *PerfTestData) float64 { return r.P50Latency }},
+	{"perftest_p95_latency_ms", "P95 latency (ms)", func(r *PerfTestData) float64 { return r.P95Latency }},
+	{"perftest_p99_latency_ms", "P99 latency (ms)", func(r *PerfTestData) float64 { return r.P99Latency }},
+	{"perftest_sent_bytes", "Total bytes sent", func(r *PerfTestData) float64 { return float64(r.TotalSent) }},
+	{"perftest_received_bytes", "Total bytes received", func(r *PerfTestData) float64 { return float64(r.TotalReceived) }},
+	{"perftest_sent_rate_bytes", "Bytes sent per second", func(r *PerfTestData) float64 { return r.SentRate }},
+	{"perftest_received_rate_bytes", "Bytes received per second", func(r *PerfTestData) float64 { return r.ReceivedRate }},
+	{"perftest_avg_resp_size_bytes", "Average response body size (bytes)", func(r *PerfTestData) float64 { return r.AvgRespSize }},
+	{"perftest_p99_resp_size_bytes", "P99 response body size (bytes)", func(r *PerfTestData) float64 { return float64(r.P99RespSize) }},
+}
+
+const (
+	statusCodeMetricName = "perftest_status_code_count"
+	finishedMetricName   = "perftest_finished"
+)
+
+type collectorMetrics struct {
+	registry       *prometheus.Registry
+	gauges         []*prometheus.GaugeVec
+	statusCodes    *prometheus.GaugeVec
+	finished       *prometheus.GaugeVec
+	remoteWriteURL string
+	interval       time.Duration
+	client         *http.Client
+
+	mu      sync.Mutex
+	pending map[string]*reportSnapshot // testID --> the latest report not yet written
+	flushCh chan struct{}
+}
+
+type reportSnapshot struct {
+	report    PerfTestData
+	finished  bool
+	timestamp int64 // unit: ms
+}
+
+func newCollectorMetrics(cfg *MetricsConfig) (*collectorMetrics, error) {
+	if cfg.RemoteWriteURL != "" {
+		if _, err := url.ParseRequestURI(cfg.RemoteWriteURL); err != nil {
+			return nil, fmt.Errorf("invalid remote-write url: %v", err)
+		}
+	}
+	interval := cfg.RemoteWriteInterval
+	if interval <= 0 {
+		interval = defaultRemoteWriteInterval
+	}
+
+	m := &collectorMetrics{
+		registry:       prometheus.NewRegistry(),
+		remoteWriteURL: cfg.RemoteWriteURL,
+		interval:       interval,
+		client:         &http.Client{Timeout: 10 * time.Second},
+		pending:        make(map[string]*reportSnapshot),
+		flushCh:        make(chan struct{}, 1),
+	}
+	for _, def := range reportMetricDefs {
+		g := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: def.name, Help: def.help}, []string{"test_id"})
+		m.registry.MustRegister(g)
+		m.gauges = append(m.gauges, g)
+	}
+	m.statusCodes = prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: statusCodeMetricName,
+		Help: "Count of responses by HTTP status code"}, []string{"test_id", "status_code"})
+	m.finished = prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: finishedMetricName,
+		Help: "Whether the test is finished, 1: finished or stopped, 0: running"}, []string{"test_id"})
+	m.registry.MustRegister(m.statusCodes, m.finished)
+
+	return m, nil
+}
+
+func (m *collectorMetrics) handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// update the metrics of test by the aggregated report, the final report is written to remote immediately
+func (m *collectorMetrics) update(report *PerfTestData, finished bool) {
+	if report == nil {
+		return
+	}
+
+	for i, def := range reportMetricDefs {
+		m.gauges[i].WithLabelValues(report.ID).Set(def.value(report))
+	}
+	for code, count := range report.StatusCodes {
+		m.statusCodes.WithLabelValues(report.ID, strconv.Itoa(code)).Set(float64(count))
+	}
+	m.finished.WithLabelValues(report.ID).Set(boolToFloat64(finished))
+
+	if m.remoteWriteURL == "" {
+		return
+	}
+	m.mu.Lock()
+	m.pending[report.ID] = &reportSnapshot{report: *report, finished: finished, timestamp: time.Now().UnixMilli()}
+	m.mu.Unlock()
+	if finished {
+		select {
+		case m.flushCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// write the pending reports to remote periodically until ctx is done, the reports not yet written are
+// flushed before returning, the in-flight request is not canceled by ctx, it is limited by the client timeout.
+func (m *collectorMetrics) runRemoteWrite(ctx context.Context) {
+	if m.remoteWriteURL == "" {
+		return
+	}
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			m.flush()
+			return
+		case <-ticker.C:
+			m.flush()
+		case <-m.flushCh:
+			m.flush()
+		}
+	}
+}
+
+func (m *collectorMetrics) flush() {
+	m.mu.Lock()
+	snapshots := make([]*reportSnapshot, 0, len(m.pending))
+	for _, s := range m.pending {
+		snapshots = append(snapshots, s)
+	}
+	m.pending = make(map[string]*reportSnapshot)
+	m.mu.Unlock()
+
+	if len(snapshots) == 0 {
+		return
+	}
+	if err := m.remoteWrite(snapshots); err != nil {
+		log.Printf("prometheus remote-write failed: %v", err)
+	}
+}
+
+func (m *collectorMetrics) remoteWrite(snapshots []*reportSnapshot) error {
+	data := snappy.Encode(nil, encodeWriteRequest(snapshots))
+	req, err := http.NewRequest(http.MethodPost, m.remoteWriteURL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("User-Agent", "sponge-perftest")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf(`post "%s" failed with status code %d`, m.remoteWriteURL, resp.StatusCode)
+	}
+	return nil
+}
+
+// --------------------------------------------------------------------------------
+
+type label struct {
+	name  string
+	value string
+}
+
+// encode the reports to protobuf of prometheus remote-write WriteRequest, see
+// https://github.com/prometheus/prometheus/blob/main/prompb/remote.proto
+//
+//	message WriteRequest { repeated TimeSeries timeseries = 1; }
+//	message TimeSeries { repeated Label labels = 1; repeated Sample samples = 2; }
+//	message Label { string name = 1; string value = 2; }
+//	message Sample { double value = 1; int64 timestamp = 2; }
+func encodeWriteRequest(snapshots []*reportSnapshot) []byte {
+	var buf []byte
+	appendSeries := func(value float64, timestamp int64, labels ...label) {
+		sort.Slice(labels, func(i, j int) bool { return labels[i].name < labels[j].name })
+		var series []byte
+		for _, l := range labels {
+			var lb []byte
+			lb = protowire.AppendTag(lb, 1, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.name)
+			lb = protowire.AppendTag(lb, 2, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.value)
+			series = protowire.AppendTag(series, 1, protowire.BytesType)
+			series = protowire.AppendBytes(series, lb)
+		}
+		var sample []byte
+		sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+		sample = protowire.AppendFixed64(sample, math.Float64bits(value))
+		sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+		sample = protowire.AppendVarint(sample, uint64(timestamp))
+		series = protowire.AppendTag(series, 2, protowire.BytesType)
+		series = protowire.AppendBytes(series, sample)
+
+		buf = protowire.AppendTag(buf, 1, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, series)
+	}
+
+	for _, s := range snapshots {
+		r := &s.report
+		testID := label{"test_id", r.ID}
+		for _, def := range reportMetricDefs {
+			appendSeries(def.value(r), s.timestamp, label{"__name__", def.name}, testID)
+		}
+		for code, count := range r.StatusCodes {
+			appendSeries(float64(count), s.timestamp, label{"__name__", statusCodeMetricName}, testID,
+				label{"status_code", strconv.Itoa(code)})
+		}
+		appendSeries(boolToFloat64(s.finished), s.timestamp, label{"__name__", finishedMetricName}, testID)
+	}
+
+	return buf
+}
+
+func boolToFloat64(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
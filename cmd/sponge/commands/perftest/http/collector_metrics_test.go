@@ -0,0 +1,200 @@
+package http
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+type testSample struct {
+	value     float64
+	timestamp int64
+}
+
+// decode the protobuf of remote-write WriteRequest, returns the samples keyed by labels, e.g. __name__=perftest_qps,test_id=t1
+func decodeWriteRequest(t *testing.T, data []byte) map[string]testSample {
+	consumeFields := func(b []byte, fn func(num protowire.Number, typ protowire.Type, b []byte) int) {
+		for len(b) > 0 {
+			num, typ, n := protowire.ConsumeTag(b)
+			require.GreaterOrEqual(t, n, 0)
+			b = b[n:]
+			n = fn(num, typ, b)
+			require.GreaterOrEqual(t, n, 0)
+			b = b[n:]
+		}
+	}
+
+	samples := make(map[string]testSample)
+	consumeFields(data, func(_ protowire.Number, _ protowire.Type, b []byte) int {
+		series, n := protowire.ConsumeBytes(b)
+		var labels []string
+		var sample testSample
+		consumeFields(series, func(num protowire.Number, _ protowire.Type, b []byte) int {
+			v, n := protowire.ConsumeBytes(b)
+			if num == 1 { // label
+				var kv []string
+				consumeFields(v, func(_ protowire.Number, _ protowire.Type, b []byte) int {
+					s, n := protowire.ConsumeString(b)
+					kv = append(kv, s)
+					return n
+				})
+				labels = append(labels, strings.Join(kv, "="))
+			} else { // sample
+				consumeFields(v, func(num protowire.Number, typ protowire.Type, b []byte) int {
+					if typ == protowire.Fixed64Type {
+						bits, n := protowire.ConsumeFixed64(b)
+						sample.value = math.Float64frombits(bits)
+						return n
+					}
+					ts, n := protowire.ConsumeVarint(b)
+					sample.timestamp = int64(ts)
+					return n
+				})
+			}
+			return n
+		})
+		samples[strings.Join(labels, ",")] = sample
+		return n
+	})
+	return samples
+}
+
+func testReport(id string) *PerfTestData {
+	return &PerfTestData{
+		ID:            id,
+		TotalRequests: 1000,
+		SuccessCount:  990,
+		ErrorCount:    10,
+		QPS:           123.4,
+		P99Latency:    56.78,
+		P99RespSize:   2048,
+		StatusCodes:   map[int]int64{200: 990, 503: 10},
+	}
+}
+
+func TestNewCollectorMetrics(t *testing.T) {
+	m, err := newCollectorMetrics(&MetricsConfig{Expose: true})
+	require.NoError(t, err)
+	assert.Equal(t, defaultRemoteWriteInterval, m.interval)
+	assert.Len(t, m.gauges, len(reportMetricDefs))
+
+	m, err = newCollectorMetrics(&MetricsConfig{RemoteWriteURL: "http://localhost:9090/api/v1/write", RemoteWriteInterval: time.Second})
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, m.interval)
+
+	_, err = newCollectorMetrics(&MetricsConfig{RemoteWriteURL: "api/v1/write"})
+	assert.Error(t, err)
+}
+
+func TestCollectorMetrics_handler(t *testing.T) {
+	m, err := newCollectorMetrics(&MetricsConfig{Expose: true})
+	require.NoError(t, err)
+	m.update(nil, false)
+	m.update(testReport("t1"), false)
+	m.update(testReport("t2"), true)
+	assert.Empty(t, m.pending) // no remote-write
+
+	w := httptest.NewRecorder()
+	m.handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	for _, line := range []string{
+		`perftest_total_requests{test_id="t1"} 1000`,
+		`perftest_qps{test_id="t1"} 123.4`,
+		`perftest_p99_latency_ms{test_id="t2"} 56.78`,
+		`perftest_p99_resp_size_bytes{test_id="t1"} 2048`,
+		`perftest_status_code_count{status_code="503",test_id="t1"} 10`,
+		`perftest_finished{test_id="t1"} 0`,
+		`perftest_finished{test_id="t2"} 1`,
+	} {
+		assert.Contains(t, body, line+"\n")
+	}
+}
+
+func TestCollectorMetrics_remoteWrite(t *testing.T) {
+	requests := make(chan []byte, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+		assert.Equal(t, "snappy", r.Header.Get("Content-Encoding"))
+		assert.Equal(t, "0.1.0", r.Header.Get("X-Prometheus-Remote-Write-Version"))
+		data, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		requests <- data
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	m, err := newCollectorMetrics(&MetricsConfig{RemoteWriteURL: server.URL, RemoteWriteInterval: time.Hour})
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		m.runRemoteWrite(ctx)
+		close(stopped)
+	}()
+
+	// the live report is written by ticker, the final report is written immediately
+	m.update(testReport("t1"), false)
+	select {
+	case <-requests:
+		t.Fatal("the live report is written before the interval")
+	case <-time.After(100 * time.Millisecond):
+	}
+	m.update(testReport("t1"), true)
+	var data []byte
+	select {
+	case data = <-requests:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the final report is not written")
+	}
+
+	body, err := snappy.Decode(nil, data)
+	require.NoError(t, err)
+	samples := decodeWriteRequest(t, body)
+	assert.Len(t, samples, len(reportMetricDefs)+2+1) // metrics of report, 2 status codes, finished
+	assert.Equal(t, 123.4, samples["__name__=perftest_qps,test_id=t1"].value)
+	assert.Equal(t, 990.0, samples["__name__=perftest_success_count,test_id=t1"].value)
+	assert.Equal(t, 10.0, samples["__name__=perftest_status_code_count,status_code=503,test_id=t1"].value)
+	assert.Equal(t, 1.0, samples["__name__=perftest_finished,test_id=t1"].value)
+	ts := samples["__name__=perftest_qps,test_id=t1"].timestamp
+	assert.InDelta(t, time.Now().UnixMilli(), ts, 5000)
+
+	// the pending report is flushed when stopping
+	m.update(testReport("t2"), false)
+	cancel()
+	<-stopped
+	require.Len(t, requests, 1)
+	body, err = snappy.Decode(nil, <-requests)
+	require.NoError(t, err)
+	sample, ok := decodeWriteRequest(t, body)["__name__=perftest_finished,test_id=t2"]
+	assert.True(t, ok)
+	assert.Equal(t, 0.0, sample.value)
+	assert.Empty(t, m.pending)
+}
+
+func TestCollectorMetrics_remoteWriteError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	m, err := newCollectorMetrics(&MetricsConfig{RemoteWriteURL: server.URL})
+	require.NoError(t, err)
+	err = m.remoteWrite([]*reportSnapshot{{report: *testReport("t1")}})
+	assert.ErrorContains(t, err, "status code 400")
+
+	// no remote-write url, return immediately
+	m, err = newCollectorMetrics(&MetricsConfig{Expose: true})
+	require.NoError(t, err)
+	m.runRemoteWrite(context.Background())
+}
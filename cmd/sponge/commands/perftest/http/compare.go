@@ -0,0 +1,319 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+
+	"github.com/go-dev-frame/sponge/cmd/sponge/commands/perftest/common"
+)
+
+const (
+	// CompareModeInterleaved the requests of the two targets are sent alternately by the same workers,
+	// both targets are tested in the same time window.
+	CompareModeInterleaved = "interleaved"
+	// CompareModeSequential the baseline target is tested first, and then the compare target.
+	CompareModeSequential = "sequential"
+)
+
+// CompareStatistics the statistics of comparing two targets with the same workload.
+type CompareStatistics struct {
+	ID        string      `json:"id"`         // performance test ID
+	Mode      string      `json:"mode"`       // interleaved or sequential
+	Baseline  *Statistics `json:"baseline"`   // statistics of --url
+	Compare   *Statistics `json:"compare"`    // statistics of --compare-url
+	CreatedAt time.Time   `json:"created_at"` // created time
+}
+
+// Save saves the comparison data to a JSON file.
+func (s *CompareStatistics) Save(filePath string) error {
+	err := ensureFileExists(filePath)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filePath, data, 0644)
+}
+
+func (p *PerfTestHTTP) checkCompareParams() error {
+	if p.CompareURL == "" {
+		return nil
+	}
+	if p.clusterEnable {
+		return errors.New("'--compare-url' is not supported in cluster mode")
+	}
+	if p.PushURL != "" {
+		return errors.New("'--compare-url' and '--push-url' cannot be set at the same time")
+	}
+	if p.Params != nil && p.Params.selector != nil {
+		return errors.New("'--compare-url' only supports one '--url'")
+	}
+	switch p.CompareMode {
+	case "":
+		p.CompareMode = CompareModeInterleaved
+	case CompareModeInterleaved, CompareModeSequential:
+	default:
+		return fmt.Errorf("'--compare-mode' only supports %s or %s", CompareModeInterleaved, CompareModeSequential)
+	}
+	return nil
+}
+
+// RunCompare runs the same workload against the URL and the compare URL, and prints the differences
+// of QPS, latency percentiles and error rates side by side.
+func (p *PerfTestHTTP) RunCompare(ctx context.Context, out string) error {
+	compareParams := *p.Params
+	compareParams.URL = p.CompareURL
+	targets := []*HTTPReqParams{p.Params, &compareParams}
+
+	var stats []*Statistics
+	if p.CompareMode == CompareModeSequential {
+		for i, target := range targets {
+			fmt.Printf("\n[%d/%d] %s\n", i+1, len(targets), target.URL)
+			st, err := p.runTargets(ctx, []*HTTPReqParams{target})
+			if err != nil {
+				return err
+			}
+			stats = append(stats, st...)
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		if len(stats) < len(targets) {
+			return errors.New("the comparison is stopped before testing the compare URL")
+		}
+	} else {
+		var err error
+		stats, err = p.runTargets(ctx, targets)
+		if err != nil {
+			return err
+		}
+	}
+
+	cs := &CompareStatistics{
+		ID:        p.ID,
+		Mode:      p.CompareMode,
+		Baseline:  stats[0],
+		Compare:   stats[1],
+		CreatedAt: time.Now(),
+	}
+	printCompareReport(cs, p.Params.version)
+
+	if out != "" {
+		if err := cs.Save(out); err != nil {
+			return fmt.Errorf("failed to save statistics to file: %s", err)
+		}
+		fmt.Printf("save statistics to '%s' successfully\n", out)
+	}
+	return nil
+}
+
+// runTargets sends the requests to the targets alternately, the number of requests or the duration
+// applies to each target, returns the statistics in the order of targets.
+func (p *PerfTestHTTP) runTargets(globalCtx context.Context, targets []*HTTPReqParams) ([]*Statistics, error) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if p.Duration > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), p.Duration)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	defer cancel()
+	go func() {
+		select {
+		case <-globalCtx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	resultChs := make([]chan Result, len(targets))
+	collectors := make([]*statsCollector, len(targets))
+	statsDone := make([]chan struct{}, len(targets))
+	for i := range targets {
+		resultChs[i] = make(chan Result, p.Worker*3)
+		statsDone[i] = make(chan struct{})
+		collectors[i] = &statsCollector{durations: make([]float64, 0, 100000)}
+		go collectors[i].collect(resultChs[i], statsDone[i])
+	}
+
+	var wg sync.WaitGroup
+	start := time.Now()
+	if p.Duration > 0 {
+		bar := common.NewTimeBar(p.Duration)
+		bar.Start()
+		for i := 0; i < p.Worker; i++ {
+			wg.Add(1)
+			go func(n int) {
+				defer wg.Done()
+				// each worker starts from a different target, so that no target always goes first
+				for ; ; n++ {
+					select {
+					case <-ctx.Done():
+						return
+					default:
+						idx := n % len(targets)
+						requestOnce(p.Client, targets[idx], resultChs[idx])
+					}
+				}
+			}(i)
+		}
+		<-ctx.Done()
+		wg.Wait()
+		if errors.Is(ctx.Err(), context.Canceled) {
+			bar.Stop()
+		} else {
+			bar.Finish()
+		}
+	} else {
+		jobs := make(chan int, p.Worker)
+		bar := common.NewBar(int64(p.TotalRequests)*int64(len(targets)), start)
+		for i := 0; i < p.Worker; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for idx := range jobs {
+					requestOnce(p.Client, targets[idx], resultChs[idx])
+					bar.Increment()
+				}
+			}()
+		}
+	loop:
+		for i := uint64(0); i < p.TotalRequests; i++ {
+			for idx := range targets {
+				select {
+				case jobs <- idx:
+				case <-ctx.Done():
+					break loop
+				}
+			}
+		}
+		close(jobs)
+		wg.Wait()
+		if ctx.Err() == nil {
+			bar.Finish()
+		} else {
+			bar.Stop()
+		}
+	}
+	totalTime := time.Since(start)
+
+	stats := make([]*Statistics, len(targets))
+	for i, target := range targets {
+		close(resultChs[i])
+		<-statsDone[i]
+		c := collectors[i]
+		stats[i] = c.toStatistics(totalTime, c.successCount+c.errorCount, target)
+		stats[i].ID = p.ID
+	}
+	return stats, nil
+}
+
+type compareRow struct {
+	name           string
+	unit           string
+	baseline       float64
+	compare        float64
+	higherIsBetter bool
+	isRate         bool // the difference of rate is in percentage points
+}
+
+func printCompareReport(cs *CompareStatistics, version string) {
+	a, b := cs.Baseline, cs.Compare
+	rows := []compareRow{
+		{name: "Total Requests", baseline: float64(a.TotalRequests), compare: float64(b.TotalRequests), higherIsBetter: true},
+		{name: "Successful", baseline: float64(a.SuccessCount), compare: float64(b.SuccessCount), higherIsBetter: true},
+		{name: "Failed", baseline: float64(a.ErrorCount), compare: float64(b.ErrorCount)},
+		{name: "Error Rate", unit: "%", baseline: errorRate(a), compare: errorRate(b), isRate: true},
+		{name: "QPS", unit: "req/sec", baseline: a.QPS, compare: b.QPS, higherIsBetter: true},
+		{name: "Avg Latency", unit: "ms", baseline: a.AvgLatency, compare: b.AvgLatency},
+		{name: "Min Latency", unit: "ms", baseline: a.MinLatency, compare: b.MinLatency},
+		{name: "Max Latency", unit: "ms", baseline: a.MaxLatency, compare: b.MaxLatency},
+		{name: "P25 Latency", unit: "ms", baseline: a.P25Latency, compare: b.P25Latency},
+		{name: "P50 Latency", unit: "ms", baseline: a.P50Latency, compare: b.P50Latency},
+		{name: "P95 Latency", unit: "ms", baseline: a.P95Latency, compare: b.P95Latency},
+		{name: "P99 Latency", unit: "ms", baseline: a.P99Latency, compare: b.P99Latency},
+		{name: "Avg Resp Size", unit: "Bytes", baseline: a.AvgRespSize, compare: b.AvgRespSize},
+		{name: "Received Rate", unit: "Bytes/sec", baseline: a.ReceivedRate, compare: b.ReceivedRate},
+	}
+
+	var builder Builder
+	builder.WriteStringf("\n========== %s Performance Comparison Report ==========\n\n", version)
+	builder.WriteString(color.New(color.Bold).Sprint("[Targets]\n"))
+	builder.WriteStringf("  • %-19s%s\n", "Baseline (A):", a.URL)
+	builder.WriteStringf("  • %-19s%s\n", "Compare (B):", b.URL)
+	builder.WriteStringf("  • %-19s%s\n", "Mode:", cs.Mode)
+	builder.WriteStringf("  • %-19s%s s\n\n", "Total Duration:", float64ToStringNoRound(math.Max(a.TotalDuration, b.TotalDuration)))
+
+	builder.WriteString(color.New(color.Bold).Sprint("[Comparison]\n"))
+	builder.WriteStringf("  %-17s%22s%22s%16s\n", "Metric", "A", "B", "Diff (B-A)")
+	for _, row := range rows {
+		builder.WriteStringf("  %-17s%22s%22s%s\n", row.name,
+			formatCompareValue(row.baseline, row.unit), formatCompareValue(row.compare, row.unit), row.diff())
+	}
+	builder.WriteString("\n")
+
+	for _, st := range []*Statistics{a, b} {
+		if len(st.StatusCodes) > 0 {
+			builder.WriteStringf("%s\n", st.URL)
+			printStatusCodeSet(&builder, st.StatusCodes)
+		}
+		if len(st.Errors) > 0 {
+			errSet := make(map[string]struct{}, len(st.Errors))
+			for _, e := range st.Errors {
+				errSet[e] = struct{}{}
+			}
+			printErrorSet(&builder, errSet)
+		}
+	}
+
+	fmt.Print(builder.String())
+}
+
+func errorRate(st *Statistics) float64 {
+	if st.TotalRequests == 0 {
+		return 0
+	}
+	return math.Round(float64(st.ErrorCount)/float64(st.TotalRequests)*10000) / 100
+}
+
+func formatCompareValue(v float64, unit string) string {
+	if unit == "" {
+		return float64ToString(v, 0)
+	}
+	return float64ToString(v, 2) + " " + unit
+}
+
+// the difference is green if B is better than A, red if B is worse than A
+func (r compareRow) diff() string {
+	delta := r.compare - r.baseline
+	var s string
+	switch {
+	case r.isRate:
+		s = fmt.Sprintf("%+.2f pp", delta)
+	case r.baseline == 0 && r.compare == 0:
+		s = "0%"
+	case r.baseline == 0:
+		s = "n/a"
+	default:
+		s = fmt.Sprintf("%+.1f%%", delta/r.baseline*100)
+	}
+	s = fmt.Sprintf("%16s", s)
+
+	if delta == 0 || r.unit == "Bytes" || r.unit == "Bytes/sec" {
+		return s
+	}
+	if (delta > 0) == r.higherIsBetter {
+		return color.GreenString(s)
+	}
+	return color.RedString(s)
+}
@@ -0,0 +1,184 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPerfTestHTTP_checkCompareParams(t *testing.T) {
+	tests := []struct {
+		name     string
+		p        *PerfTestHTTP
+		wantMode string
+		errMsg   string
+	}{
+		{name: "no compare", p: &PerfTestHTTP{}},
+		{name: "default mode", p: &PerfTestHTTP{CompareURL: "http://localhost:8081"}, wantMode: CompareModeInterleaved},
+		{name: "sequential", p: &PerfTestHTTP{CompareURL: "http://localhost:8081", CompareMode: CompareModeSequential}, wantMode: CompareModeSequential},
+		{name: "unknown mode", p: &PerfTestHTTP{CompareURL: "http://localhost:8081", CompareMode: "parallel"}, errMsg: "'--compare-mode' only supports"},
+		{name: "cluster", p: &PerfTestHTTP{CompareURL: "http://localhost:8081", clusterEnable: true}, errMsg: "not supported in cluster mode"},
+		{name: "push", p: &PerfTestHTTP{CompareURL: "http://localhost:8081", PushURL: "http://localhost:9090"}, errMsg: "'--push-url'"},
+		{name: "multiple urls", p: &PerfTestHTTP{CompareURL: "http://localhost:8081",
+			Params: &HTTPReqParams{selector: &roundRobinSelector{urls: testURLs}}}, errMsg: "only supports one '--url'"},
+	}
+	for _, tt := range tests {
+		err := tt.p.checkCompareParams()
+		if tt.errMsg != "" {
+			assert.ErrorContains(t, err, tt.errMsg, tt.name)
+			continue
+		}
+		assert.NoError(t, err, tt.name)
+		assert.Equal(t, tt.wantMode, tt.p.CompareMode, tt.name)
+	}
+}
+
+func TestCompareRow_diff(t *testing.T) {
+	noColor := color.NoColor
+	defer func() { color.NoColor = noColor }()
+
+	color.NoColor = true
+	tests := []struct {
+		row  compareRow
+		want string
+	}{
+		{row: compareRow{unit: "req/sec", baseline: 1000, compare: 1200, higherIsBetter: true}, want: "+20.0%"},
+		{row: compareRow{unit: "ms", baseline: 10, compare: 7.5}, want: "-25.0%"},
+		{row: compareRow{unit: "ms", baseline: 0, compare: 0}, want: "0%"},
+		{row: compareRow{unit: "ms", baseline: 0, compare: 3}, want: "n/a"},
+		{row: compareRow{unit: "%", baseline: 1.5, compare: 0.25, isRate: true}, want: "-1.25 pp"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, fmt.Sprintf("%16s", tt.want), tt.row.diff(), tt.want)
+	}
+
+	// green if B is better than A, red if B is worse than A, no color for sizes
+	color.NoColor = false
+	green, red := color.GreenString("x")[:5], color.RedString("x")[:5]
+	assert.Contains(t, compareRow{unit: "req/sec", baseline: 1000, compare: 1200, higherIsBetter: true}.diff(), green)
+	assert.Contains(t, compareRow{unit: "req/sec", baseline: 1000, compare: 800, higherIsBetter: true}.diff(), red)
+	assert.Contains(t, compareRow{unit: "ms", baseline: 10, compare: 7.5}.diff(), green)
+	assert.Contains(t, compareRow{unit: "ms", baseline: 10, compare: 12}.diff(), red)
+	assert.Contains(t, compareRow{unit: "%", baseline: 1, compare: 2, isRate: true}.diff(), red)
+	assert.NotContains(t, compareRow{unit: "Bytes", baseline: 10, compare: 12}.diff(), "\x1b[")
+	assert.NotContains(t, compareRow{unit: "ms", baseline: 10, compare: 10}.diff(), "\x1b[")
+}
+
+func TestErrorRate(t *testing.T) {
+	assert.Equal(t, 0.0, errorRate(&Statistics{}))
+	assert.Equal(t, 2.5, errorRate(&Statistics{TotalRequests: 200, ErrorCount: 5}))
+	assert.Equal(t, 33.33, errorRate(&Statistics{TotalRequests: 3, ErrorCount: 1}))
+	assert.Equal(t, "1000", formatCompareValue(1000, ""))
+	assert.Equal(t, "12.35 ms", formatCompareValue(12.345, "ms"))
+}
+
+// run two target servers, the compare server returns error for every 5th request
+func runCompareServers(t *testing.T) (*httptest.Server, *httptest.Server, *int64, *int64) {
+	var countA, countB int64
+	serverA := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&countA, 1)
+		_, _ = w.Write([]byte("hello"))
+	}))
+	serverB := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt64(&countB, 1)%5 == 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte("hello world"))
+	}))
+	t.Cleanup(serverA.Close)
+	t.Cleanup(serverB.Close)
+	return serverA, serverB, &countA, &countB
+}
+
+func TestPerfTestHTTP_runTargets(t *testing.T) {
+	serverA, serverB, countA, countB := runCompareServers(t)
+	p := &PerfTestHTTP{ID: "test", Client: &http.Client{}, Worker: 4, TotalRequests: 50}
+	targets := []*HTTPReqParams{{URL: serverA.URL, Method: "GET"}, {URL: serverB.URL, Method: "GET"}}
+
+	// the number of requests applies to each target
+	stats, err := p.runTargets(context.Background(), targets)
+	require.NoError(t, err)
+	require.Len(t, stats, 2)
+	assert.Equal(t, int64(50), atomic.LoadInt64(countA))
+	assert.Equal(t, int64(50), atomic.LoadInt64(countB))
+	assert.Equal(t, serverA.URL, stats[0].URL)
+	assert.Equal(t, uint64(50), stats[0].TotalRequests)
+	assert.Equal(t, uint64(50), stats[0].SuccessCount)
+	assert.Equal(t, 5.0, stats[0].AvgRespSize)
+	assert.Equal(t, serverB.URL, stats[1].URL)
+	assert.Equal(t, uint64(40), stats[1].SuccessCount)
+	assert.Equal(t, uint64(10), stats[1].ErrorCount)
+	assert.Equal(t, 11.0, stats[1].AvgRespSize)
+	assert.Equal(t, "test", stats[1].ID)
+
+	// the requests are sent alternately in the same time window
+	atomic.StoreInt64(countA, 0)
+	atomic.StoreInt64(countB, 0)
+	p.TotalRequests, p.Duration = 0, 300*time.Millisecond
+	stats, err = p.runTargets(context.Background(), targets)
+	require.NoError(t, err)
+	a, b := atomic.LoadInt64(countA), atomic.LoadInt64(countB)
+	assert.Greater(t, a, int64(0))
+	assert.InDelta(t, a, b, float64(p.Worker))
+	assert.Equal(t, uint64(a), stats[0].TotalRequests)
+	assert.Equal(t, uint64(b), stats[1].TotalRequests)
+	assert.Equal(t, stats[0].TotalDuration, stats[1].TotalDuration)
+
+	// stopped by the global context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p.TotalRequests, p.Duration = 1000, 0
+	stats, err = p.runTargets(ctx, targets)
+	require.NoError(t, err)
+	assert.Less(t, stats[0].TotalRequests, uint64(1000))
+}
+
+func TestPerfTestHTTP_RunCompare(t *testing.T) {
+	serverA, serverB, _, _ := runCompareServers(t)
+
+	for _, mode := range []string{CompareModeInterleaved, CompareModeSequential} {
+		p := &PerfTestHTTP{
+			ID:            "test",
+			Client:        &http.Client{},
+			Params:        &HTTPReqParams{URL: serverA.URL, Method: "GET", version: "HTTP/1.1"},
+			Worker:        2,
+			TotalRequests: 20,
+			CompareURL:    serverB.URL,
+			CompareMode:   mode,
+		}
+		require.NoError(t, p.checkParams())
+		out := filepath.Join(t.TempDir(), "compare.json")
+		require.NoError(t, p.RunCompare(context.Background(), out), mode)
+		assert.Equal(t, serverA.URL, p.Params.URL) // the params of baseline are not modified
+
+		data, err := os.ReadFile(out)
+		require.NoError(t, err)
+		cs := &CompareStatistics{}
+		require.NoError(t, json.Unmarshal(data, cs))
+		assert.Equal(t, mode, cs.Mode)
+		assert.Equal(t, serverA.URL, cs.Baseline.URL)
+		assert.Equal(t, uint64(20), cs.Baseline.SuccessCount)
+		assert.Equal(t, serverB.URL, cs.Compare.URL)
+		assert.Equal(t, uint64(20), cs.Compare.TotalRequests)
+		assert.Equal(t, uint64(4), cs.Compare.ErrorCount)
+	}
+
+	// the sequential comparison is stopped before testing the compare URL
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p := &PerfTestHTTP{Client: &http.Client{}, Params: &HTTPReqParams{URL: serverA.URL, Method: "GET"},
+		Worker: 1, TotalRequests: 10, CompareURL: serverB.URL, CompareMode: CompareModeSequential}
+	assert.ErrorContains(t, p.RunCompare(ctx, ""), "stopped before testing the compare URL")
+}
@@ -0,0 +1,363 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/go-dev-frame/sponge/cmd/sponge/commands/perftest/common"
+)
+
+// PerfTestComposeCMD is the command for generating docker-compose.yml of collector and agents
+func PerfTestComposeCMD() *cobra.Command {
+	opts := &composeOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "compose",
+		Short: "Generate docker-compose.yml of collector and agents, reproduce the distributed cluster test locally",
+		Long: "Generate docker-compose.yml of collector, N agents and optional Prometheus/Grafana with dashboard from the agent config file, " +
+			"the agents register with the collector automatically, so the distributed cluster test can be reproduced by one command 'docker-compose up'.",
+		Example: color.HiBlackString(`  # Generate docker-compose.yml of collector and 3 agents
+  %s compose --config=agent.yml --agents=3
+
+  # Generate docker-compose.yml with prometheus and grafana, and specify the output directory
+  %s compose --config=agent.yml --agents=5 --grafana --out=./perftest-compose`,
+			common.CommandPrefix, common.CommandPrefix),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.generate(); err != nil {
+				return err
+			}
+			fmt.Printf("generated docker-compose.yml in directory %s\n\n", color.HiCyanString(opts.outDir))
+			fmt.Printf("Tip: execute the command %s to start the test, visit %s to view the report.\n",
+				color.HiCyanString("cd %s && docker-compose up -d", opts.outDir), color.HiCyanString("http://localhost:%d", opts.port))
+			if opts.grafana {
+				fmt.Printf("     visit %s to view the grafana dashboard, user and password are admin.\n", color.HiCyanString("http://localhost:3000"))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.configFile, "config", "c", "", "agent yaml config file path")
+	_ = cmd.MarkFlagRequired("config")
+	cmd.Flags().IntVarP(&opts.agents, "agents", "n", 3, "number of agents")
+	cmd.Flags().StringVarP(&opts.image, "image", "i", defaultAgentImage, "image of perftest")
+	cmd.Flags().IntVarP(&opts.port, "port", "p", 8888, "port of collector exposed on host")
+	cmd.Flags().BoolVar(&opts.prometheus, "prometheus", false, "add prometheus to scrape the metrics of collector")
+	cmd.Flags().BoolVar(&opts.grafana, "grafana", false, "add prometheus and grafana with perftest dashboard")
+	cmd.Flags().StringVarP(&opts.outDir, "out", "o", "perftest-compose", "output directory")
+
+	return cmd
+}
+
+type composeOptions struct {
+	configFile string
+	agents     int
+	image      string
+	port       int
+	prometheus bool
+	grafana    bool
+	outDir     string
+}
+
+// the collector is accessed by service name in the network of docker-compose
+const composeCollectorHost = "http://collector:8888"
+
+const composeTpl = `# Code generated by perftest compose.
+
+services:
+  collector:
+    image: {{.Image}}
+    container_name: perftest-collector
+    command: ["collector", "--port=8888", "--agent_num={{.Agents}}", "--collector-address=http://localhost:{{.Port}}"{{if .Prometheus}}, "--metrics"{{end}}]
+    ports:
+      - "{{.Port}}:8888"
+{{range .AgentNames}}
+  {{.}}:
+    image: {{$.Image}}
+    container_name: perftest-{{.}}
+    command: ["agent", "--config=/app/configs/agent.yml", "--agent-ip={{.}}", "--agent-id={{.}}"]
+    volumes:
+      - ./configs/agent.yml:/app/configs/agent.yml
+    extra_hosts:
+      - "host.docker.internal:host-gateway"
+    depends_on:
+      - collector
+{{end}}
+{{- if .Prometheus}}
+  prometheus:
+    image: prom/prometheus:v2.53.0
+    container_name: perftest-prometheus
+    command: ["--config.file=/etc/prometheus/prometheus.yml"]
+    ports:
+      - "9090:9090"
+    volumes:
+      - ./prometheus/prometheus.yml:/etc/prometheus/prometheus.yml
+    depends_on:
+      - collector
+{{end}}
+{{- if .Grafana}}
+  grafana:
+    image: grafana/grafana:11.1.0
+    container_name: perftest-grafana
+    environment:
+      - GF_SECURITY_ADMIN_USER=admin
+      - GF_SECURITY_ADMIN_PASSWORD=admin
+    ports:
+      - "3000:3000"
+    volumes:
+      - ./grafana/provisioning:/etc/grafana/provisioning
+      - ./grafana/dashboards:/var/lib/grafana/dashboards
+    depends_on:
+      - prometheus
+{{end}}`
+
+const composePrometheusConfig = `global:
+  scrape_interval: 5s
+
+scrape_configs:
+  - job_name: perftest
+    static_configs:
+      - targets: ["collector:8888"]
+`
+
+const composeGrafanaDatasource = `apiVersion: 1
+
+datasources:
+  - name: Prometheus
+    uid: perftest-prometheus
+    type: prometheus
+    access: proxy
+    url: http://prometheus:9090
+    isDefault: true
+`
+
+const composeGrafanaDashboardProvider = `apiVersion: 1
+
+providers:
+  - name: perftest
+    type: file
+    options:
+      path: /var/lib/grafana/dashboards
+`
+
+func (o *composeOptions) generate() error {
+	if o.agents <= 0 {
+		return errors.New("flag 'agents' must be greater than 0")
+	}
+	if o.grafana {
+		o.prometheus = true
+	}
+	agentConfig, err := o.agentConfig()
+	if err != nil {
+		return err
+	}
+
+	var agentNames []string
+	for i := 1; i <= o.agents; i++ {
+		agentNames = append(agentNames, fmt.Sprintf("agent-%d", i))
+	}
+	tpl, err := template.New("compose").Parse(composeTpl)
+	if err != nil {
+		return err
+	}
+	buf := &bytes.Buffer{}
+	err = tpl.Execute(buf, map[string]interface{}{
+		"Image":      o.image,
+		"Port":       o.port,
+		"Agents":     o.agents,
+		"AgentNames": agentNames,
+		"Prometheus": o.prometheus,
+		"Grafana":    o.grafana,
+	})
+	if err != nil {
+		return err
+	}
+
+	files := map[string][]byte{
+		"docker-compose.yml": buf.Bytes(),
+		"configs/agent.yml":  agentConfig,
+	}
+	if o.prometheus {
+		files["prometheus/prometheus.yml"] = []byte(composePrometheusConfig)
+	}
+	if o.grafana {
+		files["grafana/provisioning/datasources/datasource.yml"] = []byte(composeGrafanaDatasource)
+		files["grafana/provisioning/dashboards/dashboard.yml"] = []byte(composeGrafanaDashboardProvider)
+		files["grafana/dashboards/perftest.json"], err = grafanaDashboard()
+		if err != nil {
+			return err
+		}
+	}
+
+	for name, data := range files {
+		file := filepath.Join(o.outDir, filepath.FromSlash(name))
+		if err = os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return err
+		}
+		if err = os.WriteFile(file, data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// set the collectorHost of agent config to the collector service, the comments of config file are kept
+func (o *composeOptions) agentConfig() ([]byte, error) {
+	data, err := os.ReadFile(o.configFile)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &agentConfig{}
+	if err = yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("parse config file %s error, %v", o.configFile, err)
+	}
+	if cfg.TestURL == "" {
+		return nil, errors.New("'testURL' in config file is required")
+	}
+	if u, e := url.Parse(cfg.TestURL); e == nil {
+		if host := u.Hostname(); host == "localhost" || strings.HasPrefix(host, "127.") {
+			fmt.Printf("%s\n\n", color.YellowString("[Warn]: the testURL %s is not accessible from the agent containers, "+
+				"use host.docker.internal instead of %s to test the service running on host", cfg.TestURL, host))
+		}
+	}
+
+	doc := &yaml.Node{}
+	if err = yaml.Unmarshal(data, doc); err != nil {
+		return nil, err
+	}
+	root := doc.Content[0]
+	setYamlValue(root, "clusterEnabled", "true", "!!bool")
+	setYamlValue(root, "collectorHost", composeCollectorHost, "!!str")
+
+	buf := &bytes.Buffer{}
+	encoder := yaml.NewEncoder(buf)
+	encoder.SetIndent(2)
+	if err = encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func setYamlValue(mapping *yaml.Node, key string, value string, tag string) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content[i+1].Value = value
+			mapping.Content[i+1].Tag = tag
+			mapping.Content[i+1].Style = 0
+			return
+		}
+	}
+	mapping.Content = append(mapping.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Value: key},
+		&yaml.Node{Kind: yaml.ScalarNode, Value: value, Tag: tag},
+	)
+}
+
+// ------------------------------------------------------------------------------------------
+
+type grafanaPanel struct {
+	title   string
+	unit    string
+	exprs   map[string]string // legend --> expr
+	stacked bool
+}
+
+var grafanaPanels = []grafanaPanel{
+	{title: "QPS", unit: "reqps", exprs: map[string]string{"qps": `perftest_qps{test_id=~"$test_id"}`}},
+	{title: "Latency", unit: "ms", exprs: map[string]string{
+		"avg": `perftest_avg_latency_ms{test_id=~"$test_id"}`,
+		"p50": `perftest_p50_latency_ms{test_id=~"$test_id"}`,
+		"p95": `perftest_p95_latency_ms{test_id=~"$test_id"}`,
+		"p99": `perftest_p99_latency_ms{test_id=~"$test_id"}`,
+	}},
+	{title: "Requests", unit: "short", stacked: true, exprs: map[string]string{
+		"success": `perftest_success_count{test_id=~"$test_id"}`,
+		"error":   `perftest_error_count{test_id=~"$test_id"}`,
+	}},
+	{title: "Status Codes", unit: "short", exprs: map[string]string{
+		"{{status_code}}": statusCodeMetricName + `{test_id=~"$test_id"}`,
+	}},
+	{title: "Throughput", unit: "Bps", exprs: map[string]string{
+		"sent":     `perftest_sent_rate_bytes{test_id=~"$test_id"}`,
+		"received": `perftest_received_rate_bytes{test_id=~"$test_id"}`,
+	}},
+	{title: "Response Size", unit: "bytes", exprs: map[string]string{
+		"avg": `perftest_avg_resp_size_bytes{test_id=~"$test_id"}`,
+		"p99": `perftest_p99_resp_size_bytes{test_id=~"$test_id"}`,
+	}},
+}
+
+// the dashboard of collector metrics, filtered by test_id
+func grafanaDashboard() ([]byte, error) {
+	datasource := map[string]string{"type": "prometheus", "uid": "perftest-prometheus"}
+	var panels []interface{}
+	for i, p := range grafanaPanels {
+		var targets []interface{}
+		for _, legend := range sortedKeys(p.exprs) {
+			targets = append(targets, map[string]interface{}{
+				"datasource":   datasource,
+				"expr":         p.exprs[legend],
+				"legendFormat": legend,
+				"refId":        string(rune('A' + len(targets))),
+			})
+		}
+		custom := map[string]interface{}{"fillOpacity": 10}
+		if p.stacked {
+			custom["stacking"] = map[string]string{"mode": "normal"}
+		}
+		panels = append(panels, map[string]interface{}{
+			"id":          i + 1,
+			"type":        "timeseries",
+			"title":       p.title,
+			"datasource":  datasource,
+			"gridPos":     map[string]int{"h": 8, "w": 12, "x": (i % 2) * 12, "y": (i / 2) * 8},
+			"fieldConfig": map[string]interface{}{"defaults": map[string]interface{}{"unit": p.unit, "custom": custom}},
+			"targets":     targets,
+		})
+	}
+
+	dashboard := map[string]interface{}{
+		"uid":           "perftest",
+		"title":         "Perftest",
+		"schemaVersion": 39,
+		"refresh":       "5s",
+		"time":          map[string]string{"from": "now-15m", "to": "now"},
+		"panels":        panels,
+		"templating": map[string]interface{}{
+			"list": []interface{}{map[string]interface{}{
+				"name":       "test_id",
+				"label":      "Test ID",
+				"type":       "query",
+				"datasource": datasource,
+				"query":      "label_values(perftest_qps, test_id)",
+				"refresh":    2,
+				"includeAll": true,
+				"multi":      true,
+				"current":    map[string]interface{}{"text": "All", "value": "$__all"},
+			}},
+		},
+	}
+	return json.MarshalIndent(dashboard, "", "  ")
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
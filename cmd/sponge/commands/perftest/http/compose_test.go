@@ -0,0 +1,180 @@
+package http
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+type testComposeFile struct {
+	Services map[string]struct {
+		Image     string   `yaml:"image"`
+		Command   []string `yaml:"command"`
+		Ports     []string `yaml:"ports"`
+		Volumes   []string `yaml:"volumes"`
+		DependsOn []string `yaml:"depends_on"`
+	} `yaml:"services"`
+}
+
+func readComposeFile(t *testing.T, dir string) *testComposeFile {
+	data, err := os.ReadFile(filepath.Join(dir, "docker-compose.yml"))
+	require.NoError(t, err)
+	compose := &testComposeFile{}
+	require.NoError(t, yaml.Unmarshal(data, compose))
+	return compose
+}
+
+func TestComposeOptions_generate(t *testing.T) {
+	o := &composeOptions{
+		configFile: writeAgentConfig(t, testAgentConfig),
+		agents:     3,
+		image:      defaultAgentImage,
+		port:       9999,
+		outDir:     t.TempDir(),
+	}
+	require.NoError(t, o.generate())
+
+	compose := readComposeFile(t, o.outDir)
+	assert.Len(t, compose.Services, 4)
+	collector := compose.Services["collector"]
+	assert.Equal(t, defaultAgentImage, collector.Image)
+	assert.Equal(t, []string{"collector", "--port=8888", "--agent_num=3", "--collector-address=http://localhost:9999"}, collector.Command)
+	assert.Equal(t, []string{"9999:8888"}, collector.Ports)
+	for _, name := range []string{"agent-1", "agent-2", "agent-3"} {
+		agent, ok := compose.Services[name]
+		require.True(t, ok, name)
+		assert.Equal(t, []string{"agent", "--config=/app/configs/agent.yml", "--agent-ip=" + name, "--agent-id=" + name}, agent.Command)
+		assert.Equal(t, []string{"./configs/agent.yml:/app/configs/agent.yml"}, agent.Volumes)
+		assert.Equal(t, []string{"collector"}, agent.DependsOn)
+	}
+	assert.FileExists(t, filepath.Join(o.outDir, "configs", "agent.yml"))
+	assert.NoDirExists(t, filepath.Join(o.outDir, "prometheus"))
+	assert.NoDirExists(t, filepath.Join(o.outDir, "grafana"))
+
+	// grafana requires prometheus
+	o = &composeOptions{
+		configFile: writeAgentConfig(t, testAgentConfig),
+		agents:     1,
+		image:      defaultAgentImage,
+		port:       8888,
+		grafana:    true,
+		outDir:     t.TempDir(),
+	}
+	require.NoError(t, o.generate())
+	assert.True(t, o.prometheus)
+
+	compose = readComposeFile(t, o.outDir)
+	assert.Len(t, compose.Services, 4)
+	assert.Contains(t, compose.Services["collector"].Command, "--metrics")
+	assert.Equal(t, []string{"collector"}, compose.Services["prometheus"].DependsOn)
+	assert.Equal(t, []string{"prometheus"}, compose.Services["grafana"].DependsOn)
+	for _, file := range []string{
+		"prometheus/prometheus.yml",
+		"grafana/provisioning/datasources/datasource.yml",
+		"grafana/provisioning/dashboards/dashboard.yml",
+		"grafana/dashboards/perftest.json",
+	} {
+		assert.FileExists(t, filepath.Join(o.outDir, filepath.FromSlash(file)))
+	}
+
+	o.agents = 0
+	assert.ErrorContains(t, o.generate(), "'agents' must be greater than 0")
+}
+
+func TestComposeOptions_agentConfig(t *testing.T) {
+	config := `# test target
+testURL: http://localhost:8080/api/v1/user/1 # the service on host
+method: GET
+
+# cluster
+clusterEnabled: false
+collectorHost: "http://192.168.1.20:8888"
+`
+	o := &composeOptions{configFile: writeAgentConfig(t, config)}
+	data, err := o.agentConfig()
+	require.NoError(t, err)
+
+	// the comments are kept, the collector is accessed by service name
+	content := string(data)
+	assert.Contains(t, content, "# test target\n")
+	assert.Contains(t, content, "# the service on host")
+	assert.Contains(t, content, "clusterEnabled: true\n")
+	assert.Contains(t, content, "collectorHost: "+composeCollectorHost+"\n")
+	cfg := &agentConfig{}
+	require.NoError(t, yaml.Unmarshal(data, cfg))
+	assert.Equal(t, "http://localhost:8080/api/v1/user/1", cfg.TestURL)
+	assert.Equal(t, composeCollectorHost, cfg.CollectorHost)
+	require.NotNil(t, cfg.ClusterEnabled)
+	assert.True(t, *cfg.ClusterEnabled)
+
+	// the missing keys are added
+	o = &composeOptions{configFile: writeAgentConfig(t, "testURL: http://host.docker.internal:8080\n")}
+	data, err = o.agentConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "testURL: http://host.docker.internal:8080\nclusterEnabled: true\ncollectorHost: "+composeCollectorHost+"\n", string(data))
+
+	tests := []struct {
+		config string
+		errMsg string
+	}{
+		{config: "method: GET\n", errMsg: "'testURL' in config file is required"},
+		{config: "testURL: [", errMsg: "parse config file"},
+	}
+	for _, tt := range tests {
+		_, err = (&composeOptions{configFile: writeAgentConfig(t, tt.config)}).agentConfig()
+		assert.ErrorContains(t, err, tt.errMsg, tt.config)
+	}
+	_, err = (&composeOptions{configFile: filepath.Join(t.TempDir(), "not-exist.yml")}).agentConfig()
+	assert.Error(t, err)
+}
+
+func TestGrafanaDashboard(t *testing.T) {
+	data, err := grafanaDashboard()
+	require.NoError(t, err)
+
+	dashboard := struct {
+		UID    string `json:"uid"`
+		Panels []struct {
+			Title   string         `json:"title"`
+			GridPos map[string]int `json:"gridPos"`
+			Targets []struct {
+				Expr         string `json:"expr"`
+				LegendFormat string `json:"legendFormat"`
+				RefID        string `json:"refId"`
+			} `json:"targets"`
+		} `json:"panels"`
+	}{}
+	require.NoError(t, json.Unmarshal(data, &dashboard))
+	assert.Equal(t, "perftest", dashboard.UID)
+	require.Len(t, dashboard.Panels, len(grafanaPanels))
+
+	// the metrics in the expressions are exported by collector
+	metricNames := map[string]bool{statusCodeMetricName: true}
+	for _, def := range reportMetricDefs {
+		metricNames[def.name] = true
+	}
+	metricRegexp := regexp.MustCompile(`^(\w+)\{test_id=~"\$test_id"}$`)
+	for i, p := range dashboard.Panels {
+		assert.Equal(t, grafanaPanels[i].title, p.Title)
+		assert.Equal(t, (i%2)*12, p.GridPos["x"], p.Title)
+		assert.Equal(t, (i/2)*8, p.GridPos["y"], p.Title)
+		require.Len(t, p.Targets, len(grafanaPanels[i].exprs), p.Title)
+		for j, target := range p.Targets {
+			assert.Equal(t, string(rune('A'+j)), target.RefID)
+			matches := metricRegexp.FindStringSubmatch(target.Expr)
+			require.Len(t, matches, 2, target.Expr)
+			assert.True(t, metricNames[matches[1]], target.Expr)
+		}
+		// the targets are sorted by legend
+		for j := 1; j < len(p.Targets); j++ {
+			assert.Less(t, strings.Compare(p.Targets[j-1].LegendFormat, p.Targets[j].LegendFormat), 0)
+		}
+	}
+}
@@ -0,0 +1,363 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/template"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/go-dev-frame/sponge/cmd/sponge/commands/perftest/common"
+)
+
+const defaultAgentImage = "zhufuyi/perftest:latest"
+
+// PerfTestDeployAgentsCMD is the command for deploying agents to kubernetes cluster
+func PerfTestDeployAgentsCMD() *cobra.Command {
+	opts := &deployAgentsOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "deploy-agents",
+		Short: "Deploy agents to kubernetes cluster, register them with the collector and tear them down after the test session",
+		Long: "Deploy a fleet of agents to kubernetes cluster by kubectl, the agents are pre-configured to register with the collector. " +
+			"It creates the test session on collector, waits for the agents to be ready, and deletes them after the test session is finished.",
+		Example: color.HiBlackString(`  # Deploy 20 agents, the collectorHost in agent.yml must be accessible from the pods
+  %s deploy-agents --config=agent.yml --replicas=20
+
+  # Deploy agents with specified kubeconfig, namespace and image
+  %s deploy-agents --config=agent.yml --replicas=20 --kubeconfig=~/.kube/config --namespace=perftest --image=zhufuyi/perftest:v1.0.0
+
+  # Keep the agents running after the test session, they can be deleted with the --delete flag
+  %s deploy-agents --config=agent.yml --replicas=20 --keep
+  %s deploy-agents --delete
+
+  # Print the kubernetes manifests only
+  %s deploy-agents --config=agent.yml --replicas=20 --dry-run`,
+			common.CommandPrefix, common.CommandPrefix, common.CommandPrefix, common.CommandPrefix, common.CommandPrefix),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+			return opts.run(ctx)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.configFile, "config", "c", "", "agent yaml config file path")
+	cmd.Flags().IntVarP(&opts.replicas, "replicas", "r", 1, "number of agents")
+	cmd.Flags().StringVarP(&opts.image, "image", "i", defaultAgentImage, "image of perftest")
+	cmd.Flags().StringVarP(&opts.kubeconfig, "kubeconfig", "k", "", "path of kubeconfig file, default is the kubeconfig of kubectl")
+	cmd.Flags().StringVarP(&opts.namespace, "namespace", "n", "default", "kubernetes namespace")
+	cmd.Flags().StringVar(&opts.name, "name", "perftest-agent", "name of the kubernetes deployment and configmap")
+	cmd.Flags().StringVar(&opts.cpu, "cpu", "", "cpu limit of each agent, e.g. 1000m")
+	cmd.Flags().StringVar(&opts.memory, "memory", "", "memory limit of each agent, e.g. 512Mi")
+	cmd.Flags().DurationVarP(&opts.readyTimeout, "ready-timeout", "t", 5*time.Minute, "timeout of waiting for the agents to be ready")
+	cmd.Flags().BoolVar(&opts.keep, "keep", false, "keep the agents running after the test session")
+	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "print the kubernetes manifests only")
+	cmd.Flags().BoolVar(&opts.delete, "delete", false, "delete the deployed agents")
+
+	return cmd
+}
+
+type deployAgentsOptions struct {
+	configFile   string
+	replicas     int
+	image        string
+	kubeconfig   string
+	namespace    string
+	name         string
+	cpu          string
+	memory       string
+	readyTimeout time.Duration
+	keep         bool
+	dryRun       bool
+	delete       bool
+
+	collectorHost string
+	agentConfig   string
+}
+
+func (o *deployAgentsOptions) run(ctx context.Context) error {
+	if o.delete {
+		return o.teardown()
+	}
+	if err := o.validate(); err != nil {
+		return err
+	}
+
+	manifest, err := o.manifest()
+	if err != nil {
+		return err
+	}
+	if o.dryRun {
+		fmt.Println(manifest)
+		return nil
+	}
+
+	testID, err := o.createTestSession(ctx)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("deploying %d agents to namespace '%s' ...\n", o.replicas, o.namespace)
+	if err = o.kubectl(ctx, strings.NewReader(manifest), "apply", "-f", "-"); err != nil {
+		return err
+	}
+	if !o.keep {
+		defer func() {
+			if e := o.teardown(); e != nil {
+				log.Printf("delete agents error: %v\n", e)
+			}
+		}()
+	}
+
+	err = o.kubectl(ctx, nil, "rollout", "status", "deployment/"+o.name, "--timeout="+o.readyTimeout.String())
+	if err != nil {
+		return fmt.Errorf("waiting for agents to be ready failed, %v", err)
+	}
+	log.Printf("all agents are ready, test session '%s' will be started when they are registered.\n", testID)
+	log.Printf("visit %s to view the test report.\n", color.HiCyanString(o.collectorHost))
+	if o.keep {
+		log.Printf("the agents are kept running, run command '%s deploy-agents --delete --namespace=%s --name=%s' to delete them.\n",
+			common.CommandPrefix, o.namespace, o.name)
+		return nil
+	}
+
+	return o.waitTestSession(ctx, testID)
+}
+
+func (o *deployAgentsOptions) validate() error {
+	if o.configFile == "" {
+		return errors.New("flag 'config' is required")
+	}
+	if o.replicas <= 0 {
+		return errors.New("flag 'replicas' must be greater than 0")
+	}
+	data, err := os.ReadFile(o.configFile)
+	if err != nil {
+		return err
+	}
+	cfg := &agentConfig{}
+	if err = yaml.Unmarshal(data, cfg); err != nil {
+		return fmt.Errorf("parse config file %s error, %v", o.configFile, err)
+	}
+	if cfg.ClusterEnabled != nil && !*cfg.ClusterEnabled {
+		return errors.New("'clusterEnabled' in config file must be true")
+	}
+	if cfg.TestURL == "" {
+		return errors.New("'testURL' in config file is required")
+	}
+	u, err := url.Parse(cfg.CollectorHost)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid 'collectorHost' in config file, e.g. http://<ip or domain name>:8888")
+	}
+	if host := u.Hostname(); host == "localhost" || strings.HasPrefix(host, "127.") {
+		return fmt.Errorf("'collectorHost' %s in config file is not accessible from the pods, use the ip or domain name of collector",
+			cfg.CollectorHost)
+	}
+	o.collectorHost = strings.TrimSuffix(cfg.CollectorHost, "/")
+	o.agentConfig = string(data)
+	return nil
+}
+
+// the agent host is specified by the ip of pod, and the agent ID is the name of pod
+const agentManifestTpl = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{.Name}}
+  labels:
+    app: {{.Name}}
+data:
+  agent.yml: |
+{{.Config}}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{.Name}}
+  labels:
+    app: {{.Name}}
+spec:
+  replicas: {{.Replicas}}
+  selector:
+    matchLabels:
+      app: {{.Name}}
+  template:
+    metadata:
+      labels:
+        app: {{.Name}}
+      annotations:
+        checksum/config: "{{.Checksum}}"
+    spec:
+      containers:
+        - name: agent
+          image: {{.Image}}
+          args: ["agent", "--config=/app/configs/agent.yml", "--agent-ip=$(POD_IP)", "--agent-id=$(POD_NAME)"]
+          env:
+            - name: POD_IP
+              valueFrom:
+                fieldRef:
+                  fieldPath: status.podIP
+            - name: POD_NAME
+              valueFrom:
+                fieldRef:
+                  fieldPath: metadata.name
+          ports:
+            - containerPort: 6601
+{{- if or .CPU .Memory}}
+          resources:
+            limits:
+{{- if .CPU}}
+              cpu: {{.CPU}}
+{{- end}}
+{{- if .Memory}}
+              memory: {{.Memory}}
+{{- end}}
+{{- end}}
+          volumeMounts:
+            - name: config
+              mountPath: /app/configs
+      volumes:
+        - name: config
+          configMap:
+            name: {{.Name}}
+`
+
+func (o *deployAgentsOptions) manifest() (string, error) {
+	tpl, err := template.New("agent").Parse(agentManifestTpl)
+	if err != nil {
+		return "", err
+	}
+
+	lines := strings.Split(strings.TrimRight(strings.ReplaceAll(o.agentConfig, "\r\n", "\n"), "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = "    " + line
+	}
+	buf := &bytes.Buffer{}
+	err = tpl.Execute(buf, map[string]interface{}{
+		"Name":     o.name,
+		"Replicas": o.replicas,
+		"Image":    o.image,
+		"CPU":      o.cpu,
+		"Memory":   o.memory,
+		"Config":   strings.Join(lines, "\n"),
+		"Checksum": fmt.Sprintf("%x", sha256.Sum256([]byte(o.agentConfig)))[:16], // restart the agents when the config is changed
+	})
+	return buf.String(), err
+}
+
+func (o *deployAgentsOptions) kubectl(ctx context.Context, stdin *strings.Reader, args ...string) error {
+	if _, err := exec.LookPath("kubectl"); err != nil {
+		return errors.New("not found kubectl, please install it first, see https://kubernetes.io/docs/tasks/tools/")
+	}
+	if o.kubeconfig != "" {
+		args = append(args, "--kubeconfig="+o.kubeconfig)
+	}
+	args = append(args, "--namespace="+o.namespace)
+
+	cmd := exec.CommandContext(ctx, "kubectl", args...)
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// delete the agents, it is called after the context is canceled, so a new context is used
+func (o *deployAgentsOptions) teardown() error {
+	log.Printf("deleting agents in namespace '%s' ...\n", o.namespace)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	return o.kubectl(ctx, nil, "delete", "deployment/"+o.name, "configmap/"+o.name, "--ignore-not-found", "--wait=false")
+}
+
+func (o *deployAgentsOptions) createTestSession(ctx context.Context) (string, error) {
+	reqURL := fmt.Sprintf("%s/tests?agent_num=%d", o.collectorHost, o.replicas)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, nil)
+	resp, err := (&http.Client{Timeout: 5 * time.Second}).Do(req)
+	if err != nil {
+		return "", fmt.Errorf("create test session failed, make sure the collector is running, %v", err)
+	}
+	defer resp.Body.Close() //nolint
+
+	result := map[string]string{}
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("create test session failed, %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("create test session failed, %s", result["error"])
+	}
+
+	testID, agentNum := result["test_id"], result["agent_num"]
+	if expected := fmt.Sprintf("/%d", o.replicas); !strings.HasSuffix(agentNum, expected) {
+		log.Printf("%s\n", color.YellowString("[Warn]: there is a pending test session '%s' with agents %s, the redundant agents will not join the test",
+			testID, agentNum))
+	} else {
+		log.Printf("test session '%s' is created, expected agents: %d\n", testID, o.replicas)
+	}
+	return testID, nil
+}
+
+// wait until the test session is finished, the agents are deleted after returning
+func (o *deployAgentsOptions) waitTestSession(ctx context.Context, testID string) error {
+	reportURL := fmt.Sprintf("%s/tests/%s/report", o.collectorHost, testID)
+	client := &http.Client{Timeout: 5 * time.Second}
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	lastStatus := ""
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("received signal, stop waiting for the test session.")
+			return nil
+		case <-ticker.C:
+		}
+
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, reportURL, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			continue // the collector may be temporarily unavailable
+		}
+		result := struct {
+			Status           TestStatus `json:"status"`
+			RegisteredAgents int        `json:"registered_agents"`
+			ExpectedAgents   int        `json:"expected_agents"`
+			Error            string     `json:"error"`
+		}{}
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		_ = resp.Body.Close()
+		if err != nil {
+			continue
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("test session '%s' not found in collector, %s", testID, result.Error)
+		}
+
+		status := fmt.Sprintf("%s, agents %d/%d", result.Status, result.RegisteredAgents, result.ExpectedAgents)
+		if status != lastStatus {
+			log.Printf("test session '%s': %s\n", testID, status)
+			lastStatus = status
+		}
+		switch result.Status {
+		case StatusCompleted, StatusStopped, StatusAborted:
+			return nil
+		}
+	}
+}
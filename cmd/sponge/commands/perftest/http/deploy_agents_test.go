@@ -0,0 +1,177 @@
+package http
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const testAgentConfig = `testURL: http://192.168.1.10:8080/api/v1/user/1
+method: GET
+total: 5000
+clusterEnabled: true
+collectorHost: http://192.168.1.20:8888/
+`
+
+func writeAgentConfig(t *testing.T, content string) string {
+	file := filepath.Join(t.TempDir(), "agent.yml")
+	require.NoError(t, os.WriteFile(file, []byte(content), 0666))
+	return file
+}
+
+func TestDeployAgentsOptions_validate(t *testing.T) {
+	o := &deployAgentsOptions{configFile: writeAgentConfig(t, testAgentConfig), replicas: 3}
+	require.NoError(t, o.validate())
+	assert.Equal(t, "http://192.168.1.20:8888", o.collectorHost)
+	assert.Equal(t, testAgentConfig, o.agentConfig)
+
+	tests := []struct {
+		name    string
+		config  string
+		opts    *deployAgentsOptions
+		errMsg  string
+		noWrite bool
+	}{
+		{name: "no config", opts: &deployAgentsOptions{replicas: 1}, errMsg: "flag 'config' is required", noWrite: true},
+		{name: "no replicas", config: testAgentConfig, opts: &deployAgentsOptions{}, errMsg: "'replicas' must be greater than 0"},
+		{name: "invalid yaml", config: "testURL: [", opts: &deployAgentsOptions{replicas: 1}, errMsg: "parse config file"},
+		{name: "cluster disabled", config: strings.Replace(testAgentConfig, "clusterEnabled: true", "clusterEnabled: false", 1),
+			opts: &deployAgentsOptions{replicas: 1}, errMsg: "'clusterEnabled' in config file must be true"},
+		{name: "no test url", config: "collectorHost: http://192.168.1.20:8888\n", opts: &deployAgentsOptions{replicas: 1}, errMsg: "'testURL'"},
+		{name: "no collector", config: "testURL: http://192.168.1.10:8080\n", opts: &deployAgentsOptions{replicas: 1}, errMsg: "invalid 'collectorHost'"},
+		{name: "localhost", config: strings.Replace(testAgentConfig, "192.168.1.20", "localhost", 1),
+			opts: &deployAgentsOptions{replicas: 1}, errMsg: "not accessible from the pods"},
+		{name: "loopback", config: strings.Replace(testAgentConfig, "192.168.1.20", "127.0.0.1", 1),
+			opts: &deployAgentsOptions{replicas: 1}, errMsg: "not accessible from the pods"},
+	}
+	for _, tt := range tests {
+		if !tt.noWrite {
+			tt.opts.configFile = writeAgentConfig(t, tt.config)
+		}
+		assert.ErrorContains(t, tt.opts.validate(), tt.errMsg, tt.name)
+	}
+
+	o = &deployAgentsOptions{configFile: filepath.Join(t.TempDir(), "not-exist.yml"), replicas: 1}
+	assert.Error(t, o.validate())
+}
+
+func TestDeployAgentsOptions_manifest(t *testing.T) {
+	o := &deployAgentsOptions{
+		configFile: writeAgentConfig(t, strings.ReplaceAll(testAgentConfig, "\n", "\r\n")),
+		replicas:   20,
+		image:      defaultAgentImage,
+		name:       "perftest-agent",
+	}
+	require.NoError(t, o.validate())
+	manifest, err := o.manifest()
+	require.NoError(t, err)
+
+	// the manifest contains a configmap and a deployment
+	var objects []map[string]interface{}
+	decoder := yaml.NewDecoder(strings.NewReader(manifest))
+	for {
+		obj := map[string]interface{}{}
+		if decoder.Decode(&obj) != nil {
+			break
+		}
+		objects = append(objects, obj)
+	}
+	require.Len(t, objects, 2)
+	assert.Equal(t, "ConfigMap", objects[0]["kind"])
+	assert.Equal(t, "Deployment", objects[1]["kind"])
+
+	// the config of agent is mounted from configmap
+	agentYAML := objects[0]["data"].(map[string]interface{})["agent.yml"].(string)
+	cfg := &agentConfig{}
+	require.NoError(t, yaml.Unmarshal([]byte(agentYAML), cfg))
+	assert.Equal(t, "http://192.168.1.10:8080/api/v1/user/1", cfg.TestURL)
+	assert.Equal(t, uint64(5000), cfg.Total)
+
+	spec := objects[1]["spec"].(map[string]interface{})
+	assert.Equal(t, 20, spec["replicas"])
+	assert.Contains(t, manifest, "image: "+defaultAgentImage)
+	assert.Contains(t, manifest, `"--agent-ip=$(POD_IP)", "--agent-id=$(POD_NAME)"`)
+	assert.NotContains(t, manifest, "resources:")
+
+	// the checksum is changed with the config, so the agents are restarted
+	o.agentConfig += "duration: 10s\n"
+	o.cpu, o.memory = "1000m", "512Mi"
+	manifest2, err := o.manifest()
+	require.NoError(t, err)
+	checksum := func(m string) string {
+		_, after, _ := strings.Cut(m, "checksum/config: ")
+		return strings.SplitN(after, "\n", 2)[0]
+	}
+	assert.Len(t, checksum(manifest), 18) // 16 hex digits with quotes
+	assert.NotEqual(t, checksum(manifest), checksum(manifest2))
+	assert.Contains(t, manifest2, "resources:\n            limits:\n              cpu: 1000m\n              memory: 512Mi\n")
+
+	o.cpu = ""
+	manifest2, err = o.manifest()
+	require.NoError(t, err)
+	assert.Contains(t, manifest2, "limits:\n              memory: 512Mi\n")
+	assert.NotContains(t, manifest2, "cpu:")
+
+	// dry run prints the manifest only, kubectl is not required
+	o.dryRun = true
+	assert.NoError(t, o.run(context.Background()))
+}
+
+func TestDeployAgentsOptions_testSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s, err := NewCollectorServer(0, "")
+	require.NoError(t, err)
+	router := gin.New()
+	router.POST("/tests", s.handleCreateTest)
+	router.GET("/tests/:testID/report", s.handleGetReport)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	o := &deployAgentsOptions{collectorHost: server.URL, replicas: 3}
+	testID, err := o.createTestSession(context.Background())
+	require.NoError(t, err)
+	require.Contains(t, s.tests, testID)
+	assert.Equal(t, 3, s.tests[testID].ExpectedAgents)
+
+	// the pending test session is reused
+	o.replicas = 5
+	testID2, err := o.createTestSession(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, testID, testID2)
+
+	// wait until the test session is finished
+	s.tests[testID].Lock()
+	s.tests[testID].Status = StatusCompleted
+	s.tests[testID].Unlock()
+	start := time.Now()
+	assert.NoError(t, o.waitTestSession(context.Background(), testID))
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	err = o.waitTestSession(context.Background(), "not-exist")
+	assert.ErrorContains(t, err, "not found in collector")
+
+	// stop waiting when the context is canceled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.NoError(t, o.waitTestSession(ctx, testID))
+
+	// invalid replicas and unavailable collector
+	o.replicas = 0
+	s.Lock()
+	delete(s.tests, testID)
+	s.Unlock()
+	_, err = o.createTestSession(context.Background())
+	assert.ErrorContains(t, err, "Invalid 'agents' query parameter")
+	o.collectorHost = "http://127.0.0.1:1"
+	_, err = o.createTestSession(context.Background())
+	assert.ErrorContains(t, err, "make sure the collector is running")
+}
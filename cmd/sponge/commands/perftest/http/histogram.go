@@ -0,0 +1,118 @@
+package http
+
+import (
+	"math"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// upper bounds (ms) of latency histogram buckets, 5 buckets per decade from 0.1ms to 10s, the latencies
+// greater than the last bound are counted in the overflow bucket. The bounds are fixed, so the histograms
+// of agents can be merged by adding the counts of buckets.
+var latencyBucketBounds = func() []float64 {
+	var bounds []float64
+	for decade := 0.1; decade < 10000; decade *= 10 {
+		for _, factor := range []float64{1, 2, 3, 5, 7} {
+			bounds = append(bounds, math.Round(decade*factor*1000)/1000)
+		}
+	}
+	return append(bounds, 10000)
+}()
+
+// LatencyBucket number of requests whose latency is in (Min, Max]
+type LatencyBucket struct {
+	Min   float64 `json:"min"`           // lower bound (ms), exclusive
+	Max   float64 `json:"max,omitempty"` // upper bound (ms), inclusive, empty means unbounded
+	Count int64   `json:"count"`         // number of requests
+}
+
+// record the latency of a successful request into histogram
+func (c *statsCollector) recordLatencyBucket(d float64) {
+	if c.latencyBuckets == nil {
+		c.latencyBuckets = make([]int64, len(latencyBucketBounds)+1)
+	}
+	ms := d / 1e6
+	c.latencyBuckets[sort.SearchFloat64s(latencyBucketBounds, ms)]++
+}
+
+func (c *statsCollector) latencyHistogram() []LatencyBucket {
+	buckets := make([]LatencyBucket, 0, len(c.latencyBuckets))
+	for i, count := range c.latencyBuckets {
+		bucket := LatencyBucket{Count: count}
+		if i > 0 {
+			bucket.Min = latencyBucketBounds[i-1]
+		}
+		if i < len(latencyBucketBounds) {
+			bucket.Max = latencyBucketBounds[i]
+		}
+		buckets = append(buckets, bucket)
+	}
+	return buckets
+}
+
+// mergeLatencyHistograms adds the counts of buckets with the same bounds, the buckets are sorted by bounds.
+func mergeLatencyHistograms(histograms ...[]LatencyBucket) []LatencyBucket {
+	bucketMap := make(map[float64]*LatencyBucket)
+	for _, histogram := range histograms {
+		for _, b := range histogram {
+			if bucket, ok := bucketMap[b.Min]; ok {
+				bucket.Count += b.Count
+			} else {
+				bucket := b
+				bucketMap[b.Min] = &bucket
+			}
+		}
+	}
+
+	buckets := make([]LatencyBucket, 0, len(bucketMap))
+	for _, bucket := range bucketMap {
+		buckets = append(buckets, *bucket)
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].Min < buckets[j].Min
+	})
+	return buckets
+}
+
+// trimLatencyHistogram removes the empty buckets at both ends, so the chart focuses on the range of latencies.
+func trimLatencyHistogram(buckets []LatencyBucket) []LatencyBucket {
+	start, end := 0, len(buckets)
+	for start < end && buckets[start].Count == 0 {
+		start++
+	}
+	for end > start && buckets[end-1].Count == 0 {
+		end--
+	}
+	return buckets[start:end]
+}
+
+// handleGetLatencyHistogram get the latency distribution of the test merged from the histograms of agents,
+// it is used to draw the latency distribution chart.
+func (s *CollectorServer) handleGetLatencyHistogram(c *gin.Context) {
+	session := s.getSession(c)
+	if session == nil {
+		return
+	}
+
+	session.Lock()
+	buckets := []LatencyBucket{}
+	if session.AggregatedReport != nil {
+		buckets = trimLatencyHistogram(session.AggregatedReport.LatencyHistogram)
+	}
+	status := session.Status
+	session.Unlock()
+
+	var total int64
+	for _, b := range buckets {
+		total += b.Count
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  status,
+		"unit":    "ms",
+		"total":   total,
+		"buckets": buckets,
+	})
+}
@@ -0,0 +1,175 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatencyBucketBounds(t *testing.T) {
+	assert.Len(t, latencyBucketBounds, 26)
+	assert.Equal(t, []float64{0.1, 0.2, 0.3, 0.5, 0.7, 1, 2, 3, 5, 7}, latencyBucketBounds[:10])
+	assert.Equal(t, []float64{1000, 2000, 3000, 5000, 7000, 10000}, latencyBucketBounds[20:])
+	for i := 1; i < len(latencyBucketBounds); i++ {
+		assert.Less(t, latencyBucketBounds[i-1], latencyBucketBounds[i])
+	}
+}
+
+func TestStatsCollector_recordLatencyBucket(t *testing.T) {
+	tests := []struct {
+		ms    float64
+		index int
+	}{
+		{ms: 0, index: 0},
+		{ms: 0.05, index: 0},
+		{ms: 0.1, index: 0}, // the upper bound is inclusive
+		{ms: 0.11, index: 1},
+		{ms: 1, index: 5},
+		{ms: 1.5, index: 6},
+		{ms: 7, index: 9},
+		{ms: 7.01, index: 10},
+		{ms: 250, index: 17},
+		{ms: 10000, index: 25},
+		{ms: 10000.01, index: 26}, // overflow
+		{ms: 60000, index: 26},
+	}
+	for _, tt := range tests {
+		c := &statsCollector{}
+		c.recordLatencyBucket(tt.ms * 1e6)
+		require.Len(t, c.latencyBuckets, len(latencyBucketBounds)+1)
+		for i, count := range c.latencyBuckets {
+			if i == tt.index {
+				assert.Equal(t, int64(1), count, "%vms", tt.ms)
+			} else {
+				assert.Equal(t, int64(0), count, "%vms, bucket %d", tt.ms, i)
+			}
+		}
+	}
+}
+
+func TestStatsCollector_latencyHistogram(t *testing.T) {
+	c := &statsCollector{}
+	for _, ms := range []float64{0.05, 0.15, 0.15, 12, 20000} {
+		c.recordLatencyBucket(ms * 1e6)
+	}
+
+	buckets := c.latencyHistogram()
+	require.Len(t, buckets, len(latencyBucketBounds)+1)
+	assert.Equal(t, LatencyBucket{Min: 0, Max: 0.1, Count: 1}, buckets[0])
+	assert.Equal(t, LatencyBucket{Min: 0.1, Max: 0.2, Count: 2}, buckets[1])
+	assert.Equal(t, LatencyBucket{Min: 10, Max: 20, Count: 1}, buckets[11])
+	assert.Equal(t, LatencyBucket{Min: 10000, Max: 0, Count: 1}, buckets[26]) // unbounded
+	var total int64
+	for i, b := range buckets {
+		total += b.Count
+		if i > 0 {
+			assert.Equal(t, buckets[i-1].Max, b.Min)
+		}
+	}
+	assert.Equal(t, int64(5), total)
+
+	// no latency recorded
+	assert.Empty(t, (&statsCollector{}).latencyHistogram())
+}
+
+func TestMergeLatencyHistograms(t *testing.T) {
+	h1 := []LatencyBucket{{Min: 0, Max: 0.1, Count: 1}, {Min: 0.1, Max: 0.2, Count: 2}, {Min: 10000, Count: 3}}
+	h2 := []LatencyBucket{{Min: 10000, Count: 1}, {Min: 0.1, Max: 0.2, Count: 5}, {Min: 5, Max: 7, Count: 4}}
+
+	buckets := mergeLatencyHistograms(h1, h2)
+	assert.Equal(t, []LatencyBucket{
+		{Min: 0, Max: 0.1, Count: 1},
+		{Min: 0.1, Max: 0.2, Count: 7},
+		{Min: 5, Max: 7, Count: 4},
+		{Min: 10000, Count: 4},
+	}, buckets)
+
+	// the input histograms are not modified
+	assert.Equal(t, int64(2), h1[1].Count)
+	assert.Equal(t, int64(1), h2[0].Count)
+
+	// the histograms of agents recorded by the same bounds
+	c1, c2 := &statsCollector{}, &statsCollector{}
+	c1.recordLatencyBucket(1e6)
+	c2.recordLatencyBucket(1e6)
+	c2.recordLatencyBucket(2e9)
+	buckets = mergeLatencyHistograms(c1.latencyHistogram(), c2.latencyHistogram())
+	assert.Len(t, buckets, len(latencyBucketBounds)+1)
+	assert.Equal(t, int64(2), buckets[5].Count)
+	assert.Equal(t, int64(1), buckets[21].Count)
+
+	assert.Empty(t, mergeLatencyHistograms())
+}
+
+func TestTrimLatencyHistogram(t *testing.T) {
+	b1 := LatencyBucket{Min: 0.1, Max: 0.2, Count: 1}
+	b2 := LatencyBucket{Min: 0.2, Max: 0.3}
+	b3 := LatencyBucket{Min: 0.3, Max: 0.5, Count: 2}
+	empty := LatencyBucket{Min: 10000}
+
+	tests := []struct {
+		name    string
+		buckets []LatencyBucket
+		want    []LatencyBucket
+	}{
+		{name: "nil", buckets: nil, want: []LatencyBucket{}},
+		{name: "all empty", buckets: []LatencyBucket{b2, empty}, want: []LatencyBucket{}},
+		{name: "no empty ends", buckets: []LatencyBucket{b1, b2, b3}, want: []LatencyBucket{b1, b2, b3}},
+		{name: "empty ends", buckets: []LatencyBucket{b2, b1, b2, b3, b2, empty}, want: []LatencyBucket{b1, b2, b3}},
+		{name: "one bucket", buckets: []LatencyBucket{empty, b3, empty}, want: []LatencyBucket{b3}},
+	}
+	for _, tt := range tests {
+		got := trimLatencyHistogram(tt.buckets)
+		assert.Equal(t, len(tt.want), len(got), tt.name)
+		for i := range tt.want {
+			assert.Equal(t, tt.want[i], got[i], tt.name)
+		}
+	}
+}
+
+func TestCollectorServer_handleGetLatencyHistogram(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s, err := NewCollectorServer(0, "")
+	require.NoError(t, err)
+	session := NewTestSession(1)
+	session.Status = StatusCompleted
+	session.AggregatedReport = &PerfTestData{LatencyHistogram: []LatencyBucket{
+		{Min: 0, Max: 0.1},
+		{Min: 0.1, Max: 0.2, Count: 3},
+		{Min: 0.2, Max: 0.3, Count: 2},
+		{Min: 10000},
+	}}
+	s.tests[session.TestID] = session
+	pending := NewTestSession(1)
+	s.tests[pending.TestID] = pending
+
+	router := gin.New()
+	router.GET("/tests/:testID/latency-histogram", s.handleGetLatencyHistogram)
+	get := func(testID string) (int, map[string]json.RawMessage) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tests/"+testID+"/latency-histogram", nil))
+		body := map[string]json.RawMessage{}
+		_ = json.Unmarshal(w.Body.Bytes(), &body)
+		return w.Code, body
+	}
+
+	code, body := get(session.TestID)
+	require.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `"ms"`, string(body["unit"]))
+	assert.JSONEq(t, `5`, string(body["total"]))
+	assert.JSONEq(t, `[{"min":0.1,"max":0.2,"count":3},{"min":0.2,"max":0.3,"count":2}]`, string(body["buckets"]))
+
+	// no report yet
+	code, body = get(pending.TestID)
+	require.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `0`, string(body["total"]))
+	assert.JSONEq(t, `[]`, string(body["buckets"]))
+
+	code, _ = get("not-exist")
+	assert.Equal(t, http.StatusNotFound, code)
+}
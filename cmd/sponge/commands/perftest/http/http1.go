@@ -18,11 +18,14 @@ import (
 // PerfTestHTTPCMD creates a new cobra.Command for HTTP/1.1 performance test.
 func PerfTestHTTPCMD() *cobra.Command {
 	var (
-		targetURL string
-		method    string
-		body      string
-		bodyFile  string
-		headers   []string
+		targetURLs []string
+		urlFile    string
+		urlPolicy  string
+		zipfS      float64
+		method     string
+		body       string
+		bodyFile   string
+		headers    []string
 
 		worker   int
 		total    uint64
@@ -33,6 +36,12 @@ func PerfTestHTTPCMD() *cobra.Command {
 		pushInterval      time.Duration
 		prometheusJobName string
 
+		compareURL  string
+		compareMode string
+
+		bandwidth     string
+		latencyJitter string
+
 		// Cluster mode parameters
 		clusterEnable   bool
 		collectorHost   string
@@ -63,12 +72,24 @@ func PerfTestHTTPCMD() *cobra.Command {
     # Fixed duration: 3*CPU workers, duration 10s, POST method with JSON body
     %s http --duration=10s --url=http://192.168.1.200:8080/user --method=POST --body={\"name\":\"Alice\",\"age\":25}
 
+    # Multiple URLs: select the URL of each request with zipfian distribution, simulate the hot and cold keys of cache
+    %s http --duration=10s --url=http://192.168.1.200:8080/user/1 --url=http://192.168.1.200:8080/user/2 --url=http://192.168.1.200:8080/user/3 --url-policy=zipf
+
+    # Multiple URLs in file (one URL per line): select the URL of each request randomly
+    %s http --duration=10s --url-file=urls.txt --url-policy=random
+
     # Fixed number of requests: 3*CPU workers, 500k requests, GET method, push statistics to custom HTTP endpoints every second by default
     %s http --total=500000 --url=http://192.168.1.200:8080/user/1 --push-url=http://localhost:7070/report
 
     # Fixed duration: 3*CPU workers, duration 10s, GET method, push statistics to prometheus (job=xxx) every second by default
     %s http --duration=10s --url=http://192.168.1.200:8080/user/1 --push-url=http://localhost:9090 --prometheus-job-name=perftest-http
 
+    # Compare mode: run the same workload against two URLs, and print the differences of QPS, latency percentiles and error rates
+    %s http --duration=10s --url=http://192.168.1.200:8080/user/1 --compare-url=http://192.168.1.200:8081/user/1
+
+    # Constrained clients: limit the bandwidth of each connection to 1mbps, add 20ms±10ms latency to each round trip
+    %s http --duration=10s --url=http://192.168.1.200:8080/user/1 --bandwidth=1mbps --latency-jitter=20ms±10ms
+
 
   # Cluster Mode, add parameter '--cluster-enable', '--collector-host, --agent-host', '--agent-id' on the basis of standalone mode
 
@@ -77,7 +98,7 @@ func PerfTestHTTPCMD() *cobra.Command {
 
     # Fixed duration: 3*CPU workers, duration 10s, GET method, push statistics to collector (master) every second by default
     %s http --duration=10s --url=http://192.168.1.200:8080/user/1 --cluster-enable=true --collector-host=http://192.168.1.10:8888 --agent-host=http://192.168.1.60:6601 --agent-id=agent-1`,
-			common.CommandPrefix, common.CommandPrefix, common.CommandPrefix, common.CommandPrefix, common.CommandPrefix, common.CommandPrefix, common.CommandPrefix, common.CommandPrefix, common.CommandPrefix),
+			common.CommandPrefix, common.CommandPrefix, common.CommandPrefix, common.CommandPrefix, common.CommandPrefix, common.CommandPrefix, common.CommandPrefix, common.CommandPrefix, common.CommandPrefix, common.CommandPrefix, common.CommandPrefix, common.CommandPrefix, common.CommandPrefix),
 		SilenceErrors: true,
 		SilenceUsage:  true,
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -87,16 +108,23 @@ func PerfTestHTTPCMD() *cobra.Command {
 			}
 
 			params := &HTTPReqParams{
-				URL:     targetURL,
 				Method:  method,
 				Headers: headerMap,
 				Body:    bodyBytes,
 				version: "HTTP/1.1",
 			}
+			if err = setTargetURLs(params, targetURLs, urlFile, urlPolicy, zipfS); err != nil {
+				return err
+			}
+
+			shaper, err := newNetShaper(bandwidth, latencyJitter)
+			if err != nil {
+				return err
+			}
 
 			p := &PerfTestHTTP{
 				ID:                common.NewStringID(),
-				Client:            newHTTPClient(worker),
+				Client:            newHTTPClient(worker, shaper),
 				Params:            params,
 				Worker:            worker,
 				TotalRequests:     total,
@@ -104,6 +132,8 @@ func PerfTestHTTPCMD() *cobra.Command {
 				PushURL:           pushURL,
 				pushInterval:      pushInterval,
 				PrometheusJobName: prometheusJobName,
+				CompareURL:        compareURL,
+				CompareMode:       compareMode,
 
 				clusterEnable: clusterEnable,
 				agentID:       agentID,
@@ -116,7 +146,7 @@ func PerfTestHTTPCMD() *cobra.Command {
 
 			if clusterEnable {
 				var agent *Agent
-				agent, err = NewAgent(agentID, collectorHost, agentHost, targetURL, method)
+				agent, err = NewAgent(agentID, collectorHost, agentHost, params.URL, method)
 				if err != nil {
 					return err
 				}
@@ -128,6 +158,8 @@ func PerfTestHTTPCMD() *cobra.Command {
 					return p.Run(testCtx, duration, out)
 				}
 				err = agent.Run(ctx, loopTestSession)
+			} else if compareURL != "" {
+				err = p.RunCompare(ctx, out)
 			} else {
 				err = p.Run(ctx, duration, out)
 			}
@@ -140,8 +172,10 @@ func PerfTestHTTPCMD() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVarP(&targetURL, "url", "u", "", "request URL")
-	_ = cmd.MarkFlagRequired("url")
+	cmd.Flags().StringArrayVarP(&targetURLs, "url", "u", nil, "request URL, repeat the flag to set multiple URLs")
+	cmd.Flags().StringVar(&urlFile, "url-file", "", "file of request URLs, one URL per line")
+	cmd.Flags().StringVar(&urlPolicy, "url-policy", URLPolicyRoundRobin, "policy of selecting the URL of each request when there are multiple URLs, round-robin, random or zipf")
+	cmd.Flags().Float64Var(&zipfS, "zipf-s", 1.2, "skew of zipf policy, must be greater than 1, the larger the value, the more requests go to the URLs in front")
 	cmd.Flags().StringVarP(&method, "method", "m", "GET", "request method")
 	cmd.Flags().StringSliceVarP(&headers, "header", "e", nil, "request headers")
 	cmd.Flags().StringVarP(&body, "body", "b", "", "request body (priority higher than --body-file)")
@@ -155,6 +189,10 @@ func PerfTestHTTPCMD() *cobra.Command {
 	cmd.Flags().StringVarP(&pushURL, "push-url", "p", "", "push statistics to target URL ")
 	cmd.Flags().DurationVarP(&pushInterval, "push-interval", "i", time.Second, "push statistics interval, ranging from 100ms to 10s")
 	cmd.Flags().StringVarP(&prometheusJobName, "prometheus-job-name", "j", "", "if not empty, the --push-url parameter value indicates prometheus url")
+	cmd.Flags().StringVar(&compareURL, "compare-url", "", "run the same test against this URL and print the differences with --url")
+	cmd.Flags().StringVar(&compareMode, "compare-mode", CompareModeInterleaved, "compare mode, interleaved or sequential, interleaved sends requests to the two URLs alternately in the same time window")
+	cmd.Flags().StringVar(&bandwidth, "bandwidth", "", "limit the bandwidth of each connection in each direction to simulate constrained clients, e.g. 512kbps, 1mbps")
+	cmd.Flags().StringVar(&latencyJitter, "latency-jitter", "", "add latency with random jitter to each round trip of connections, e.g. 20ms±10ms, 20ms+-10ms, 20ms")
 
 	// Cluster mode parameters
 	cmd.Flags().BoolVar(&clusterEnable, "cluster-enable", false, "enable cluster mode")
@@ -166,17 +204,17 @@ func PerfTestHTTPCMD() *cobra.Command {
 	return cmd
 }
 
-func newHTTPClient(worker int) *http.Client {
+func newHTTPClient(worker int, shaper *netShaper) *http.Client {
 	if worker <= 0 {
 		worker = runtime.NumCPU() * 3
 	}
 	return &http.Client{
 		Transport: &http.Transport{
 			Proxy: http.ProxyFromEnvironment,
-			DialContext: (&net.Dialer{
+			DialContext: shaper.wrapDialContext((&net.Dialer{
 				Timeout:   5 * time.Second,
 				KeepAlive: 15 * time.Second,
-			}).DialContext,
+			}).DialContext),
 			MaxIdleConns:          worker + 10,
 			MaxIdleConnsPerHost:   worker,
 			IdleConnTimeout:       90 * time.Second,
@@ -19,11 +19,14 @@ import (
 // PerfTestHTTP3CMD creates a new cobra.Command for HTTP/3 performance test.
 func PerfTestHTTP3CMD() *cobra.Command {
 	var (
-		targetURL string
-		method    string
-		body      string
-		bodyFile  string
-		headers   []string
+		targetURLs []string
+		urlFile    string
+		urlPolicy  string
+		zipfS      float64
+		method     string
+		body       string
+		bodyFile   string
+		headers    []string
 
 		worker   int
 		total    uint64
@@ -34,6 +37,9 @@ func PerfTestHTTP3CMD() *cobra.Command {
 		pushInterval      time.Duration
 		prometheusJobName string
 
+		compareURL  string
+		compareMode string
+
 		// Cluster mode parameters
 		clusterEnable   bool
 		collectorHost   string
@@ -64,12 +70,21 @@ func PerfTestHTTP3CMD() *cobra.Command {
     # Fixed duration: 3*CPU workers, duration 10s, POST method with JSON body
     %s http3 --duration=10s --url=https://l192.168.1.200:8443/user --method=POST --body={\"name\":\"Alice\",\"age\":25}
 
+    # Multiple URLs: select the URL of each request with zipfian distribution, simulate the hot and cold keys of cache
+    %s http3 --duration=10s --url=https://l192.168.1.200:8443/user/1 --url=https://l192.168.1.200:8443/user/2 --url=https://l192.168.1.200:8443/user/3 --url-policy=zipf
+
+    # Multiple URLs in file (one URL per line): select the URL of each request randomly
+    %s http3 --duration=10s --url-file=urls.txt --url-policy=random
+
     # Fixed number of requests: 3*CPU workers, 500k requests, GET method, push statistics to custom HTTP endpoints every second by default
     %s http3 --total=500000 --url=https://l192.168.1.200:8443/user/1 --push-url=http://localhost:7070/report
 
     # Fixed duration: 3*CPU workers, duration 10s, GET method, push statistics to prometheus (job=xxx) every second by default
     %s http3 --duration=10s --url=https://l192.168.1.200:8443/user/1 --push-url=http://localhost:9090 --prometheus-job-name=perftest-http3
 
+    # Compare mode: run the same workload against two URLs, and print the differences of QPS, latency percentiles and error rates
+    %s http3 --duration=10s --url=https://l192.168.1.200:8443/user/1 --compare-url=https://l192.168.1.200:8444/user/1
+
 
   # Cluster Mode, add parameter '--cluster-enable', '--collector-host, --agent-host', '--agent-id' on the basis of standalone mode
 
@@ -78,7 +93,7 @@ func PerfTestHTTP3CMD() *cobra.Command {
 
     # Fixed duration: 3*CPU workers, duration 10s, GET method, push statistics to collector (master) every second by default
     %s http3 --duration=10s --url=https://l192.168.1.200:8443/user/1 --cluster-enable=true --collector-host=http://192.168.1.10:8888 --agent-host=http://192.168.1.60:6601 --agent-id=agent-1`,
-			common.CommandPrefix, common.CommandPrefix, common.CommandPrefix, common.CommandPrefix, common.CommandPrefix, common.CommandPrefix, common.CommandPrefix, common.CommandPrefix, common.CommandPrefix),
+			common.CommandPrefix, common.CommandPrefix, common.CommandPrefix, common.CommandPrefix, common.CommandPrefix, common.CommandPrefix, common.CommandPrefix, common.CommandPrefix, common.CommandPrefix, common.CommandPrefix, common.CommandPrefix, common.CommandPrefix),
 		SilenceErrors: true,
 		SilenceUsage:  true,
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -88,12 +103,14 @@ func PerfTestHTTP3CMD() *cobra.Command {
 			}
 
 			params := &HTTPReqParams{
-				URL:     targetURL,
 				Method:  method,
 				Headers: headerMap,
 				Body:    bodyBytes,
 				version: "HTTP/3",
 			}
+			if err = setTargetURLs(params, targetURLs, urlFile, urlPolicy, zipfS); err != nil {
+				return err
+			}
 
 			p := PerfTestHTTP{
 				ID:                common.NewStringID(),
@@ -105,6 +122,8 @@ func PerfTestHTTP3CMD() *cobra.Command {
 				PushURL:           pushURL,
 				pushInterval:      pushInterval,
 				PrometheusJobName: prometheusJobName,
+				CompareURL:        compareURL,
+				CompareMode:       compareMode,
 
 				clusterEnable: clusterEnable,
 				agentID:       agentID,
@@ -117,7 +136,7 @@ func PerfTestHTTP3CMD() *cobra.Command {
 
 			if clusterEnable {
 				var agent *Agent
-				agent, err = NewAgent(agentID, collectorHost, agentHost, targetURL, method)
+				agent, err = NewAgent(agentID, collectorHost, agentHost, params.URL, method)
 				if err != nil {
 					return err
 				}
@@ -129,6 +148,8 @@ func PerfTestHTTP3CMD() *cobra.Command {
 					return p.Run(testCtx, duration, out)
 				}
 				err = agent.Run(ctx, loopTestSession)
+			} else if compareURL != "" {
+				err = p.RunCompare(ctx, out)
 			} else {
 				err = p.Run(ctx, duration, out)
 			}
@@ -141,8 +162,10 @@ func PerfTestHTTP3CMD() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVarP(&targetURL, "url", "u", "", "request URL")
-	_ = cmd.MarkFlagRequired("url")
+	cmd.Flags().StringArrayVarP(&targetURLs, "url", "u", nil, "request URL, repeat the flag to set multiple URLs")
+	cmd.Flags().StringVar(&urlFile, "url-file", "", "file of request URLs, one URL per line")
+	cmd.Flags().StringVar(&urlPolicy, "url-policy", URLPolicyRoundRobin, "policy of selecting the URL of each request when there are multiple URLs, round-robin, random or zipf")
+	cmd.Flags().Float64Var(&zipfS, "zipf-s", 1.2, "skew of zipf policy, must be greater than 1, the larger the value, the more requests go to the URLs in front")
 	cmd.Flags().StringVarP(&method, "method", "m", "GET", "request method")
 	cmd.Flags().StringSliceVarP(&headers, "header", "e", nil, "request headers")
 	cmd.Flags().StringVarP(&body, "body", "b", "", "request body (priority higher than --body-file)")
@@ -156,6 +179,8 @@ func PerfTestHTTP3CMD() *cobra.Command {
 	cmd.Flags().StringVarP(&pushURL, "push-url", "p", "", "push statistics to target URL ")
 	cmd.Flags().DurationVarP(&pushInterval, "push-interval", "i", time.Second, "push statistics interval, ranging from 100ms to 10s")
 	cmd.Flags().StringVarP(&prometheusJobName, "prometheus-job-name", "j", "", "if not empty, the push-url parameter value indicates prometheus url")
+	cmd.Flags().StringVar(&compareURL, "compare-url", "", "run the same test against this URL and print the differences with --url")
+	cmd.Flags().StringVar(&compareMode, "compare-mode", CompareModeInterleaved, "compare mode, interleaved or sequential, interleaved sends requests to the two URLs alternately in the same time window")
 
 	// Cluster mode parameters
 	cmd.Flags().BoolVar(&clusterEnable, "cluster-enable", false, "enable cluster mode")
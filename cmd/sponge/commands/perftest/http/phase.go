@@ -0,0 +1,220 @@
+package http
+
+import (
+	"crypto/tls"
+	"fmt"
+	"math"
+	"net/http/httptrace"
+	"sort"
+	"sync"
+	"time"
+)
+
+// phases of a request, the dns, connect and tls phases only happen when a new connection is established
+const (
+	phaseDNS = iota
+	phaseConnect
+	phaseTLS
+	phaseTTFB     // from getting a connection to the first response byte, including writing request and server processing
+	phaseTransfer // from the first response byte to the end of reading response body
+	phaseCount
+)
+
+var requestPhases = [phaseCount]struct {
+	name  string
+	title string
+}{
+	phaseDNS:      {name: "dns", title: "DNS Lookup:"},
+	phaseConnect:  {name: "connect", title: "TCP Connect:"},
+	phaseTLS:      {name: "tls", title: "TLS Handshake:"},
+	phaseTTFB:     {name: "ttfb", title: "TTFB:"},
+	phaseTransfer: {name: "transfer", title: "Content Transfer:"},
+}
+
+// PhaseLatency latency statistics of a request phase
+type PhaseLatency struct {
+	Phase string  `json:"phase"` // dns, connect, tls, ttfb, transfer
+	Count uint64  `json:"count"` // number of requests that went through the phase
+	Avg   float64 `json:"avg"`   // average latency (ms)
+	P50   float64 `json:"p50"`   // 50th percentile latency (ms)
+	P95   float64 `json:"p95"`   // 95th percentile latency (ms)
+	P99   float64 `json:"p99"`   // 99th percentile latency (ms)
+	Max   float64 `json:"max"`   // maximum latency (ms)
+}
+
+// phaseTracer records the time spent in each phase of a request by httptrace, the callbacks may be
+// called from the goroutines of dialing, so the fields are protected by mutex.
+type phaseTracer struct {
+	mu           sync.Mutex
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	gotConn      time.Time
+	firstByte    time.Time
+	phases       [phaseCount]time.Duration
+}
+
+func (t *phaseTracer) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mu.Lock()
+			t.dnsStart = time.Now()
+			t.mu.Unlock()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.mu.Lock()
+			t.phases[phaseDNS] = since(t.dnsStart)
+			t.mu.Unlock()
+		},
+		ConnectStart: func(string, string) {
+			t.mu.Lock()
+			if t.connectStart.IsZero() { // there may be multiple attempts of dual-stack addresses
+				t.connectStart = time.Now()
+			}
+			t.mu.Unlock()
+		},
+		ConnectDone: func(_ string, _ string, err error) {
+			if err != nil {
+				return
+			}
+			t.mu.Lock()
+			t.phases[phaseConnect] = since(t.connectStart)
+			t.mu.Unlock()
+		},
+		TLSHandshakeStart: func() {
+			t.mu.Lock()
+			t.tlsStart = time.Now()
+			t.mu.Unlock()
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if err != nil {
+				return
+			}
+			t.mu.Lock()
+			t.phases[phaseTLS] = since(t.tlsStart)
+			t.mu.Unlock()
+		},
+		GotConn: func(httptrace.GotConnInfo) {
+			t.mu.Lock()
+			t.gotConn = time.Now()
+			t.mu.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			t.firstByte = time.Now()
+			t.phases[phaseTTFB] = since(t.gotConn)
+			t.mu.Unlock()
+		},
+	}
+}
+
+// finish is called after reading the response body, returns the durations of phases, 0 means the phase
+// did not happen or was not traced.
+func (t *phaseTracer) finish() [phaseCount]time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.phases[phaseTransfer] = since(t.firstByte)
+	return t.phases
+}
+
+func since(start time.Time) time.Duration {
+	if start.IsZero() {
+		return 0
+	}
+	return time.Since(start)
+}
+
+// record the phase durations of a successful request
+func (c *statsCollector) recordPhases(r Result) {
+	for i, d := range r.Phases {
+		if d > 0 {
+			c.phaseDurations[i] = append(c.phaseDurations[i], float64(d))
+		}
+	}
+}
+
+func (c *statsCollector) phaseStatistics() []PhaseLatency {
+	phases := []PhaseLatency{}
+	for i, durations := range c.phaseDurations {
+		if len(durations) == 0 {
+			continue
+		}
+		sort.Float64s(durations)
+		var total float64
+		for _, d := range durations {
+			total += d
+		}
+		phases = append(phases, PhaseLatency{
+			Phase: requestPhases[i].name,
+			Count: uint64(len(durations)),
+			Avg:   convertToMilliseconds(total / float64(len(durations))),
+			P50:   convertToMilliseconds(percentile(durations, 0.50)),
+			P95:   convertToMilliseconds(percentile(durations, 0.95)),
+			P99:   convertToMilliseconds(percentile(durations, 0.99)),
+			Max:   convertToMilliseconds(durations[len(durations)-1]),
+		})
+	}
+	return phases
+}
+
+// phaseAggregator aggregates the phase latencies of agents, the average is weighted by count, and
+// the same as total latency, the average value is used to calculate p50, p95, and p99.
+type phaseAggregator [phaseCount]struct {
+	count            uint64
+	weightedAvg      float64
+	p50s, p95s, p99s []float64
+	max              float64
+}
+
+func (a *phaseAggregator) add(phases []PhaseLatency) {
+	for _, p := range phases {
+		for i := range requestPhases {
+			if requestPhases[i].name != p.Phase {
+				continue
+			}
+			agg := &a[i]
+			agg.count += p.Count
+			agg.weightedAvg += p.Avg * float64(p.Count)
+			agg.p50s = append(agg.p50s, p.P50)
+			agg.p95s = append(agg.p95s, p.P95)
+			agg.p99s = append(agg.p99s, p.P99)
+			if p.Max > agg.max {
+				agg.max = p.Max
+			}
+		}
+	}
+}
+
+func (a *phaseAggregator) result() []PhaseLatency {
+	phases := []PhaseLatency{}
+	for i, agg := range a {
+		if agg.count == 0 {
+			continue
+		}
+		phases = append(phases, PhaseLatency{
+			Phase: requestPhases[i].name,
+			Count: agg.count,
+			Avg:   math.Round(agg.weightedAvg/float64(agg.count)*100) / 100,
+			P50:   averageLatency(agg.p50s),
+			P95:   averageLatency(agg.p95s),
+			P99:   averageLatency(agg.p99s),
+			Max:   agg.max,
+		})
+	}
+	return phases
+}
+
+func printPhases(builder *Builder, phases []PhaseLatency) {
+	for _, p := range phases {
+		title := p.Phase
+		for _, rp := range requestPhases {
+			if rp.name == p.Phase {
+				title = rp.title
+			}
+		}
+		builder.WriteStringf("  • %-19s%s\n", title, fmt.Sprintf("avg %s ms, p50 %s ms, p95 %s ms, p99 %s ms, max %s ms (%d requests)",
+			float64ToStringNoRound(p.Avg), float64ToStringNoRound(p.P50), float64ToStringNoRound(p.P95),
+			float64ToStringNoRound(p.P99), float64ToStringNoRound(p.Max), p.Count))
+	}
+	builder.WriteString("\n")
+}
@@ -0,0 +1,119 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPhaseTracer(t *testing.T) {
+	const (
+		processTime  = 30 * time.Millisecond
+		transferTime = 30 * time.Millisecond
+	)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(processTime)
+		_, _ = w.Write([]byte("first"))
+		w.(http.Flusher).Flush()
+		time.Sleep(transferTime)
+		_, _ = w.Write([]byte("last"))
+	}))
+	defer server.Close()
+	client := server.Client()
+
+	doRequest := func() [phaseCount]time.Duration {
+		tracer := &phaseTracer{}
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), tracer.clientTrace()))
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		_, err = io.Copy(io.Discard, resp.Body)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return tracer.finish()
+	}
+
+	// new connection, the url is an ip address, so there is no dns lookup
+	phases := doRequest()
+	assert.Zero(t, phases[phaseDNS])
+	assert.Greater(t, phases[phaseConnect], time.Duration(0))
+	assert.Greater(t, phases[phaseTLS], time.Duration(0))
+	assert.GreaterOrEqual(t, phases[phaseTTFB], processTime)
+	assert.GreaterOrEqual(t, phases[phaseTransfer], transferTime)
+	assert.Less(t, phases[phaseTransfer], processTime+transferTime+time.Second)
+
+	// reused connection, only ttfb and transfer happen
+	phases = doRequest()
+	assert.Zero(t, phases[phaseDNS])
+	assert.Zero(t, phases[phaseConnect])
+	assert.Zero(t, phases[phaseTLS])
+	assert.GreaterOrEqual(t, phases[phaseTTFB], processTime)
+	assert.GreaterOrEqual(t, phases[phaseTransfer], transferTime)
+
+	// not traced
+	assert.Equal(t, [phaseCount]time.Duration{}, (&phaseTracer{}).finish())
+	assert.Zero(t, since(time.Time{}))
+	assert.GreaterOrEqual(t, since(time.Now().Add(-time.Second)), time.Second)
+}
+
+func TestStatsCollector_phaseStatistics(t *testing.T) {
+	c := &statsCollector{}
+	assert.Equal(t, []PhaseLatency{}, c.phaseStatistics())
+
+	// ttfb of 100 requests from 100ms to 1ms, the first request also goes through connect phase,
+	// the zero durations are ignored
+	for i := 100; i > 0; i-- {
+		r := Result{}
+		r.Phases[phaseTTFB] = time.Duration(i) * time.Millisecond
+		r.Phases[phaseTransfer] = 500 * time.Microsecond
+		if i == 100 {
+			r.Phases[phaseConnect] = 2500 * time.Microsecond
+		}
+		c.recordPhases(r)
+	}
+
+	assert.Equal(t, []PhaseLatency{
+		{Phase: "connect", Count: 1, Avg: 2.5, P50: 2.5, P95: 2.5, P99: 2.5, Max: 2.5},
+		{Phase: "ttfb", Count: 100, Avg: 50.5, P50: 51, P95: 95, P99: 99, Max: 100},
+		{Phase: "transfer", Count: 100, Avg: 0.5, P50: 0.5, P95: 0.5, P99: 0.5, Max: 0.5},
+	}, c.phaseStatistics())
+}
+
+func TestPhaseAggregator(t *testing.T) {
+	a := &phaseAggregator{}
+	assert.Equal(t, []PhaseLatency{}, a.result())
+
+	// the phases of agents, the average is weighted by count, the percentiles are averaged
+	a.add([]PhaseLatency{
+		{Phase: "ttfb", Count: 300, Avg: 10, P50: 8, P95: 20, P99: 30, Max: 50},
+		{Phase: "transfer", Count: 300, Avg: 1, P50: 1, P95: 2, P99: 3, Max: 4},
+	})
+	a.add([]PhaseLatency{
+		{Phase: "tls", Count: 2, Avg: 5, P50: 5, P95: 6, P99: 6, Max: 6},
+		{Phase: "ttfb", Count: 100, Avg: 20, P50: 18, P95: 40, P99: 50, Max: 60},
+		{Phase: "unknown", Count: 100, Avg: 1},
+	})
+
+	assert.Equal(t, []PhaseLatency{
+		{Phase: "tls", Count: 2, Avg: 5, P50: 5, P95: 6, P99: 6, Max: 6},
+		{Phase: "ttfb", Count: 400, Avg: 12.5, P50: 13, P95: 30, P99: 40, Max: 60},
+		{Phase: "transfer", Count: 300, Avg: 1, P50: 1, P95: 2, P99: 3, Max: 4},
+	}, a.result())
+}
+
+func TestPrintPhases(t *testing.T) {
+	var builder Builder
+	printPhases(&builder, []PhaseLatency{
+		{Phase: "ttfb", Count: 400, Avg: 12.5, P50: 13, P95: 30, P99: 40.25, Max: 60},
+		{Phase: "unknown", Count: 1, Avg: 1, P50: 1, P95: 1, P99: 1, Max: 1},
+	})
+	assert.Equal(t, "  • TTFB:              avg 12.5 ms, p50 13 ms, p95 30 ms, p99 40.25 ms, max 60 ms (400 requests)\n"+
+		"  • unknown            avg 1 ms, p50 1 ms, p95 1 ms, p99 1 ms, max 1 ms (1 requests)\n\n", builder.String())
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptrace"
 	"os"
 	"os/signal"
 	"strings"
@@ -34,6 +35,9 @@ type PerfTestHTTP struct {
 	PrometheusJobName string
 	pushInterval      time.Duration
 
+	CompareURL  string // if not empty, run the same workload against URL and CompareURL
+	CompareMode string // interleaved or sequential
+
 	agentID            string
 	clusterEnable      bool
 	pushToCollectorURL string
@@ -56,7 +60,7 @@ func (p *PerfTestHTTP) checkParams() error {
 		p.pushInterval = time.Second
 	}
 
-	return nil
+	return p.checkCompareParams()
 }
 
 // Run the performance test with fixed number of requests or fixed duration.
@@ -297,6 +301,8 @@ type Result struct {
 	RespSize   int64
 	StatusCode int
 	Err        error
+
+	Phases [phaseCount]time.Duration // durations of request phases, 0 means the phase did not happen
 }
 
 type HTTPReqParams struct {
@@ -305,19 +311,25 @@ type HTTPReqParams struct {
 	Headers map[string]string
 	Body    []byte
 
-	version string
+	version  string
+	selector urlSelector // if not nil, the URL of each request is selected from multiple URLs
 }
 
 func buildRequest(params *HTTPReqParams) (*http.Request, error) {
 	var req *http.Request
 	var err error
 
+	reqURL := params.URL
+	if params.selector != nil {
+		reqURL = params.selector.next()
+	}
+
 	reqMethod := strings.ToUpper(params.Method)
 	if reqMethod == "POST" || reqMethod == "PUT" || reqMethod == "PATCH" || reqMethod == "DELETE" {
 		body := bytes.NewReader(params.Body)
-		req, err = http.NewRequest(reqMethod, params.URL, body)
+		req, err = http.NewRequest(reqMethod, reqURL, body)
 	} else {
-		req, err = http.NewRequest(reqMethod, params.URL, nil)
+		req, err = http.NewRequest(reqMethod, reqURL, nil)
 	}
 
 	for k, v := range params.Headers {
@@ -333,6 +345,8 @@ func requestOnce(client *http.Client, params *HTTPReqParams, ch chan<- Result) {
 		ch <- Result{Err: err}
 		return
 	}
+	tracer := &phaseTracer{}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), tracer.clientTrace()))
 
 	var reqSize int64
 	if req.Body != nil {
@@ -388,6 +402,7 @@ func requestOnce(client *http.Client, params *HTTPReqParams, ch chan<- Result) {
 		ReqSize:    reqSize,
 		RespSize:   respSize,
 		StatusCode: resp.StatusCode,
+		Phases:     tracer.finish(),
 	}
 }
 
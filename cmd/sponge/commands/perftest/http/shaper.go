@@ -0,0 +1,188 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+var bandwidthRegexp = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*(bps|kbps|mbps|gbps)$`)
+
+// netShaper shapes the socket reads and writes of the test client, simulate the clients under
+// constrained network conditions (e.g. mobile or edge clients) without external network emulation tools.
+type netShaper struct {
+	bytesPerSecond int           // bandwidth of each connection in each direction, 0 means no limit
+	latency        time.Duration // extra latency of each round trip
+	jitter         time.Duration // the latency varies randomly within ±jitter
+}
+
+// newNetShaper parse bandwidth (e.g. 1mbps, 512kbps) and latency jitter (e.g. 20ms±10ms, 20ms+-10ms, 20ms),
+// return nil if both of them are empty.
+func newNetShaper(bandwidth string, latencyJitter string) (*netShaper, error) {
+	if bandwidth == "" && latencyJitter == "" {
+		return nil, nil
+	}
+
+	s := &netShaper{}
+	var err error
+	if bandwidth != "" {
+		s.bytesPerSecond, err = parseBandwidth(bandwidth)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if latencyJitter != "" {
+		s.latency, s.jitter, err = parseLatencyJitter(latencyJitter)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// parseBandwidth parse bandwidth in bits per second to bytes per second.
+func parseBandwidth(bandwidth string) (int, error) {
+	matches := bandwidthRegexp.FindStringSubmatch(strings.ToLower(strings.TrimSpace(bandwidth)))
+	if len(matches) != 3 {
+		return 0, fmt.Errorf("invalid '--bandwidth' %q, e.g. 512kbps, 1mbps, 1.5gbps", bandwidth)
+	}
+	value, _ := strconv.ParseFloat(matches[1], 64)
+	switch matches[2] {
+	case "kbps":
+		value *= 1000
+	case "mbps":
+		value *= 1000 * 1000
+	case "gbps":
+		value *= 1000 * 1000 * 1000
+	}
+	bytesPerSecond := int(value / 8)
+	if bytesPerSecond <= 0 {
+		return 0, fmt.Errorf("invalid '--bandwidth' %q, must be at least 8bps", bandwidth)
+	}
+	return bytesPerSecond, nil
+}
+
+// parseLatencyJitter parse latency and jitter, the jitter can be omitted.
+func parseLatencyJitter(latencyJitter string) (time.Duration, time.Duration, error) {
+	str := strings.ReplaceAll(strings.TrimSpace(latencyJitter), "+-", "±")
+	ss := strings.Split(str, "±")
+	if len(ss) > 2 {
+		return 0, 0, fmt.Errorf("invalid '--latency-jitter' %q, e.g. 20ms±10ms, 20ms+-10ms, 20ms", latencyJitter)
+	}
+
+	latency, err := time.ParseDuration(strings.TrimSpace(ss[0]))
+	if err != nil || latency < 0 {
+		return 0, 0, fmt.Errorf("invalid '--latency-jitter' %q, latency must be a non-negative duration", latencyJitter)
+	}
+	var jitter time.Duration
+	if len(ss) == 2 {
+		jitter, err = time.ParseDuration(strings.TrimSpace(ss[1]))
+		if err != nil || jitter < 0 {
+			return 0, 0, fmt.Errorf("invalid '--latency-jitter' %q, jitter must be a non-negative duration", latencyJitter)
+		}
+	}
+	return latency, jitter, nil
+}
+
+// delay returns the latency of a round trip, it is never negative.
+func (s *netShaper) delay() time.Duration {
+	d := s.latency
+	if s.jitter > 0 {
+		d += time.Duration(rand.Int63n(int64(2*s.jitter)+1)) - s.jitter //nolint
+	}
+	if d < 0 {
+		d = 0
+	}
+	return d
+}
+
+// wrapDialContext returns a dial function whose connections are shaped, return dial itself if s is nil.
+func (s *netShaper) wrapDialContext(dial func(ctx context.Context, network, addr string) (net.Conn, error)) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	if s == nil {
+		return dial
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		return s.newConn(conn), nil
+	}
+}
+
+func (s *netShaper) newConn(conn net.Conn) net.Conn {
+	c := &shapedConn{Conn: conn, shaper: s}
+	if s.bytesPerSecond > 0 {
+		// allow bursts of 100ms, the reads and writes larger than the burst are split
+		burst := s.bytesPerSecond / 10
+		if burst < 1 {
+			burst = 1
+		}
+		c.burst = burst
+		c.readLimiter = rate.NewLimiter(rate.Limit(s.bytesPerSecond), burst)
+		c.writeLimiter = rate.NewLimiter(rate.Limit(s.bytesPerSecond), burst)
+	}
+	return c
+}
+
+// shapedConn limits the bandwidth of reads and writes independently, like the downlink and uplink,
+// and delays the first data read after writing to add latency to each round trip.
+type shapedConn struct {
+	net.Conn
+	shaper *netShaper
+
+	burst        int
+	readLimiter  *rate.Limiter
+	writeLimiter *rate.Limiter
+	wrote        atomic.Bool
+}
+
+func (c *shapedConn) Read(p []byte) (int, error) {
+	if c.readLimiter != nil && len(p) > c.burst {
+		p = p[:c.burst]
+	}
+	n, err := c.Conn.Read(p)
+	if n > 0 {
+		// the reading goroutine may be blocked before writing, so the response is delayed after it arrives
+		if c.wrote.Swap(false) {
+			if d := c.shaper.delay(); d > 0 {
+				time.Sleep(d)
+			}
+		}
+		if c.readLimiter != nil {
+			_ = c.readLimiter.WaitN(context.Background(), n)
+		}
+	}
+	return n, err
+}
+
+func (c *shapedConn) Write(p []byte) (int, error) {
+	defer c.wrote.Store(true)
+
+	if c.writeLimiter == nil {
+		return c.Conn.Write(p)
+	}
+	var written int
+	for len(p) > 0 {
+		size := len(p)
+		if size > c.burst {
+			size = c.burst
+		}
+		_ = c.writeLimiter.WaitN(context.Background(), size)
+		n, err := c.Conn.Write(p[:size])
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[size:]
+	}
+	return written, nil
+}
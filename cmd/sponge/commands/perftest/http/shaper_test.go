@@ -0,0 +1,202 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBandwidth(t *testing.T) {
+	tests := []struct {
+		bandwidth string
+		want      int
+		wantErr   bool
+	}{
+		{bandwidth: "800bps", want: 100},
+		{bandwidth: "512kbps", want: 64000},
+		{bandwidth: "1mbps", want: 125000},
+		{bandwidth: " 1.5Mbps ", want: 187500},
+		{bandwidth: "1gbps", want: 125000000},
+		{bandwidth: "4bps", wantErr: true},
+		{bandwidth: "1mb", wantErr: true},
+		{bandwidth: "fast", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseBandwidth(tt.bandwidth)
+		if tt.wantErr {
+			assert.Error(t, err, tt.bandwidth)
+			continue
+		}
+		assert.NoError(t, err, tt.bandwidth)
+		assert.Equal(t, tt.want, got, tt.bandwidth)
+	}
+}
+
+func TestParseLatencyJitter(t *testing.T) {
+	tests := []struct {
+		latencyJitter string
+		latency       time.Duration
+		jitter        time.Duration
+		wantErr       bool
+	}{
+		{latencyJitter: "20ms", latency: 20 * time.Millisecond},
+		{latencyJitter: "20ms±10ms", latency: 20 * time.Millisecond, jitter: 10 * time.Millisecond},
+		{latencyJitter: " 1s +- 100ms ", latency: time.Second, jitter: 100 * time.Millisecond},
+		{latencyJitter: "20ms±10ms±1ms", wantErr: true},
+		{latencyJitter: "-20ms", wantErr: true},
+		{latencyJitter: "20ms±-1ms", wantErr: true},
+		{latencyJitter: "slow", wantErr: true},
+	}
+	for _, tt := range tests {
+		latency, jitter, err := parseLatencyJitter(tt.latencyJitter)
+		if tt.wantErr {
+			assert.Error(t, err, tt.latencyJitter)
+			continue
+		}
+		assert.NoError(t, err, tt.latencyJitter)
+		assert.Equal(t, tt.latency, latency, tt.latencyJitter)
+		assert.Equal(t, tt.jitter, jitter, tt.latencyJitter)
+	}
+}
+
+func TestNewNetShaper(t *testing.T) {
+	s, err := newNetShaper("", "")
+	assert.NoError(t, err)
+	assert.Nil(t, s)
+	dial := (&net.Dialer{}).DialContext
+	assert.NotNil(t, s.wrapDialContext(dial))
+
+	s, err = newNetShaper("1mbps", "20ms±10ms")
+	require.NoError(t, err)
+	assert.Equal(t, &netShaper{bytesPerSecond: 125000, latency: 20 * time.Millisecond, jitter: 10 * time.Millisecond}, s)
+
+	_, err = newNetShaper("1mb", "")
+	assert.Error(t, err)
+	_, err = newNetShaper("", "slow")
+	assert.Error(t, err)
+}
+
+func TestNetShaper_delay(t *testing.T) {
+	tests := []struct {
+		latency time.Duration
+		jitter  time.Duration
+	}{
+		{latency: 0},
+		{latency: 20 * time.Millisecond},
+		{latency: 20 * time.Millisecond, jitter: 10 * time.Millisecond},
+		{latency: 5 * time.Millisecond, jitter: 10 * time.Millisecond}, // never negative
+	}
+	for _, tt := range tests {
+		s := &netShaper{latency: tt.latency, jitter: tt.jitter}
+		minDelay, maxDelay := tt.latency-tt.jitter, tt.latency+tt.jitter
+		if minDelay < 0 {
+			minDelay = 0
+		}
+		var sum time.Duration
+		const n = 2000
+		for i := 0; i < n; i++ {
+			d := s.delay()
+			assert.GreaterOrEqual(t, d, minDelay)
+			assert.LessOrEqual(t, d, maxDelay)
+			sum += d
+		}
+		if tt.latency >= tt.jitter {
+			// the jitter is symmetric around the latency
+			assert.InDelta(t, float64(tt.latency), float64(sum/n), float64(tt.jitter)/5+1)
+		}
+	}
+}
+
+// transfer size bytes through a shaped connection, return the elapsed time
+func shapedTransfer(t *testing.T, s *netShaper, size int, shapeRead bool) time.Duration {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	conn := s.newConn(client)
+
+	data := make([]byte, size)
+	start := time.Now()
+	errCh := make(chan error, 1)
+	if shapeRead {
+		go func() {
+			_, err := server.Write(data)
+			errCh <- err
+		}()
+		_, err := io.ReadFull(conn, make([]byte, size))
+		require.NoError(t, err)
+	} else {
+		go func() {
+			_, err := io.ReadFull(server, make([]byte, size))
+			errCh <- err
+		}()
+		n, err := conn.Write(data)
+		require.NoError(t, err)
+		assert.Equal(t, size, n)
+	}
+	require.NoError(t, <-errCh)
+	return time.Since(start)
+}
+
+func TestShapedConn_rate(t *testing.T) {
+	const bytesPerSecond = 100000 // the burst is 10000 bytes (100ms)
+	s := &netShaper{bytesPerSecond: bytesPerSecond}
+
+	tests := []struct {
+		name       string
+		size       int
+		minElapsed time.Duration
+		maxElapsed time.Duration
+	}{
+		// ramp, the data within the burst is transferred immediately
+		{name: "burst", size: 10000, minElapsed: 0, maxElapsed: 50 * time.Millisecond},
+		// steady state, the data beyond the burst is transferred at the bandwidth, (40000-10000)/100000 = 300ms
+		{name: "steady", size: 40000, minElapsed: 280 * time.Millisecond, maxElapsed: 600 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		for _, shapeRead := range []bool{false, true} {
+			elapsed := shapedTransfer(t, s, tt.size, shapeRead)
+			assert.GreaterOrEqual(t, elapsed, tt.minElapsed, "%s, read=%v", tt.name, shapeRead)
+			assert.LessOrEqual(t, elapsed, tt.maxElapsed, "%s, read=%v", tt.name, shapeRead)
+		}
+	}
+}
+
+func TestShapedConn_latency(t *testing.T) {
+	s := &netShaper{latency: 50 * time.Millisecond}
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	conn := s.wrapDialContext(func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return client, nil
+	})
+	c, err := conn(context.Background(), "tcp", "")
+	require.NoError(t, err)
+
+	go func() {
+		buf := make([]byte, 4)
+		for {
+			if _, err := io.ReadFull(server, buf); err != nil {
+				return
+			}
+			_, _ = server.Write(buf)
+		}
+	}()
+
+	// each round trip is delayed by the latency
+	buf := make([]byte, 4)
+	for i := 0; i < 3; i++ {
+		start := time.Now()
+		_, err = c.Write([]byte("ping"))
+		require.NoError(t, err)
+		_, err = io.ReadFull(c, buf)
+		require.NoError(t, err)
+		elapsed := time.Since(start)
+		assert.GreaterOrEqual(t, elapsed, 50*time.Millisecond)
+		assert.Less(t, elapsed, 200*time.Millisecond)
+	}
+}
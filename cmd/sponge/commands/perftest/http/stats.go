@@ -28,16 +28,25 @@ type statsCollector struct {
 	errorCount     uint64
 	errSet         map[string]struct{}
 	statusCodeSet  map[int]int64
+
+	respSizes      []float64             // response body size of successful requests
+	phaseDurations [phaseCount][]float64 // durations of request phases of successful requests
+	latencyBuckets []int64               // latency histogram of successful requests, aligned with latencyBucketBounds
+	startTime      time.Time             // start time of collecting, it is used to calculate the bandwidth per second
+	bandwidth      []BandwidthPoint      // bytes sent and received per second
 }
 
 func (c *statsCollector) collect(results <-chan Result, done chan<- struct{}) {
 	errSet := make(map[string]struct{})
 	statusCodes := make(map[int]int64)
+	c.startTime = time.Now()
 
 	for r := range results {
 		if r.Err == nil {
 			c.successCount++
 			c.durations = append(c.durations, float64(r.Duration))
+			c.recordPhases(r)
+			c.recordLatencyBucket(float64(r.Duration))
 		} else {
 			c.errorCount++
 			if _, ok := errSet[r.Err.Error()]; !ok {
@@ -50,8 +59,7 @@ func (c *statsCollector) collect(results <-chan Result, done chan<- struct{}) {
 			statusCodes[r.StatusCode]++
 		}
 
-		c.totalReqBytes += r.ReqSize
-		c.totalRespBytes += r.RespSize
+		c.recordTransfer(r)
 	}
 	c.errSet = errSet
 	c.statusCodeSet = statusCodes
@@ -68,11 +76,14 @@ func (c *statsCollector) collectAndPush(ctx context.Context, results <-chan Resu
 	pushTicker := time.NewTicker(p.pushInterval)
 	defer pushTicker.Stop()
 	start = time.Now()
+	c.startTime = start
 
 	for r := range results {
 		if r.Err == nil {
 			c.successCount++
 			c.durations = append(c.durations, float64(r.Duration))
+			c.recordPhases(r)
+			c.recordLatencyBucket(float64(r.Duration))
 		} else {
 			c.errorCount++
 			if _, ok := errSet[r.Err.Error()]; !ok {
@@ -85,8 +96,7 @@ func (c *statsCollector) collectAndPush(ctx context.Context, results <-chan Resu
 			statusCodes[r.StatusCode]++
 		}
 
-		c.totalReqBytes += r.ReqSize
-		c.totalRespBytes += r.RespSize
+		c.recordTransfer(r)
 		c.errSet = errSet
 		c.statusCodeSet = statusCodes
 		select {
@@ -108,6 +118,25 @@ func (c *statsCollector) collectAndPush(ctx context.Context, results <-chan Resu
 	close(done)
 }
 
+// record the bytes sent and received, the response body size and the bandwidth of current second
+func (c *statsCollector) recordTransfer(r Result) {
+	c.totalReqBytes += r.ReqSize
+	c.totalRespBytes += r.RespSize
+	if r.Err == nil {
+		c.respSizes = append(c.respSizes, float64(r.RespSize))
+	}
+
+	if c.startTime.IsZero() {
+		c.startTime = time.Now()
+	}
+	second := int(time.Since(c.startTime) / time.Second)
+	for len(c.bandwidth) <= second {
+		c.bandwidth = append(c.bandwidth, BandwidthPoint{Second: len(c.bandwidth)})
+	}
+	c.bandwidth[second].Sent += r.ReqSize
+	c.bandwidth[second].Received += r.RespSize
+}
+
 func (c *statsCollector) toStatistics(totalTime time.Duration, totalRequests uint64, params *HTTPReqParams) *Statistics {
 	sort.Float64s(c.durations)
 
@@ -133,6 +162,22 @@ func (c *statsCollector) toStatistics(totalTime time.Duration, totalRequests uin
 		p99 = percentile(0.99)
 	}
 
+	sort.Float64s(c.respSizes)
+	var avgRespSize float64
+	if len(c.respSizes) > 0 {
+		var totalRespSize float64
+		for _, size := range c.respSizes {
+			totalRespSize += size
+		}
+		avgRespSize = math.Round(totalRespSize/float64(len(c.respSizes))*100) / 100
+	}
+
+	var sentRate, receivedRate float64
+	if totalTime.Seconds() > 0 {
+		sentRate = math.Round(float64(c.totalReqBytes)/totalTime.Seconds()*10) / 10
+		receivedRate = math.Round(float64(c.totalRespBytes)/totalTime.Seconds()*10) / 10
+	}
+
 	errors := []string{}
 	for errStr := range c.errSet {
 		errors = append(errors, errStr)
@@ -162,10 +207,24 @@ func (c *statsCollector) toStatistics(totalTime time.Duration, totalRequests uin
 		P99Latency: convertToMilliseconds(p99),
 		MinLatency: convertToMilliseconds(minLatency),
 		MaxLatency: convertToMilliseconds(maxLatency),
+		Phases:     c.phaseStatistics(),
+
+		LatencyHistogram: c.latencyHistogram(),
 
 		TotalSent:     c.totalReqBytes,
 		TotalReceived: c.totalRespBytes,
-		StatusCodes:   c.statusCodeSet,
+		SentRate:      sentRate,
+		ReceivedRate:  receivedRate,
+		Bandwidth:     append([]BandwidthPoint{}, c.bandwidth...),
+
+		AvgRespSize: avgRespSize,
+		MinRespSize: int64(percentile(c.respSizes, 0)),
+		MaxRespSize: int64(percentile(c.respSizes, 1)),
+		P50RespSize: int64(percentile(c.respSizes, 0.50)),
+		P95RespSize: int64(percentile(c.respSizes, 0.95)),
+		P99RespSize: int64(percentile(c.respSizes, 0.99)),
+
+		StatusCodes: c.statusCodeSet,
 	}
 }
 
@@ -241,9 +300,24 @@ func (c *statsCollector) printReport(totalDuration time.Duration, totalRequests
 	builder.WriteStringf("  • %-19s%s ms\n", "P95:", float64ToStringNoRound(st.P95Latency))
 	builder.WriteStringf("  • %-19s%s ms\n\n", "P99:", float64ToStringNoRound(st.P99Latency))
 
+	if len(st.Phases) > 0 {
+		builder.WriteString(color.New(color.Bold).Sprint("[Latency Breakdown]\n"))
+		printPhases(&builder, st.Phases)
+	}
+
 	builder.WriteString(color.New(color.Bold).Sprint("[Data Transfer]\n"))
 	builder.WriteStringf("  • %-19s%d Bytes\n", "Sent:", st.TotalSent)
-	builder.WriteStringf("  • %-19s%d Bytes\n\n", "Received:", st.TotalReceived)
+	builder.WriteStringf("  • %-19s%d Bytes\n", "Received:", st.TotalReceived)
+	builder.WriteStringf("  • %-19s%s Bytes/sec\n", "Sent Rate:", float64ToStringNoRound(st.SentRate))
+	builder.WriteStringf("  • %-19s%s Bytes/sec\n\n", "Received Rate:", float64ToStringNoRound(st.ReceivedRate))
+
+	builder.WriteString(color.New(color.Bold).Sprint("[Response Size]\n"))
+	builder.WriteStringf("  • %-19s%s Bytes\n", "Average:", float64ToStringNoRound(st.AvgRespSize))
+	builder.WriteStringf("  • %-19s%d Bytes\n", "Minimum:", st.MinRespSize)
+	builder.WriteStringf("  • %-19s%d Bytes\n", "Maximum:", st.MaxRespSize)
+	builder.WriteStringf("  • %-19s%d Bytes\n", "P50:", st.P50RespSize)
+	builder.WriteStringf("  • %-19s%d Bytes\n", "P95:", st.P95RespSize)
+	builder.WriteStringf("  • %-19s%d Bytes\n\n", "P99:", st.P99RespSize)
 
 	if len(c.statusCodeSet) > 0 {
 		printStatusCodeSet(&builder, st.StatusCodes)
@@ -305,8 +379,21 @@ type Statistics struct {
 	MinLatency float64 `json:"min_latency"` // minimum latency (ms)
 	MaxLatency float64 `json:"max_latency"` // maximum latency (ms)
 
-	TotalSent     int64 `json:"total_sent"`     // total sent (bytes)
-	TotalReceived int64 `json:"total_received"` // total received (bytes)
+	Phases           []PhaseLatency  `json:"phases"`            // latency breakdown of request phases (dns, connect, tls, ttfb, transfer)
+	LatencyHistogram []LatencyBucket `json:"latency_histogram"` // latency distribution of successful requests
+
+	TotalSent     int64            `json:"total_sent"`     // total sent (bytes)
+	TotalReceived int64            `json:"total_received"` // total received (bytes)
+	SentRate      float64          `json:"sent_rate"`      // average sent per second (bytes/sec)
+	ReceivedRate  float64          `json:"received_rate"`  // average received per second (bytes/sec)
+	Bandwidth     []BandwidthPoint `json:"bandwidth"`      // bytes sent and received per second over time
+
+	AvgRespSize float64 `json:"avg_resp_size"` // average response body size (bytes)
+	MinRespSize int64   `json:"min_resp_size"` // minimum response body size (bytes)
+	MaxRespSize int64   `json:"max_resp_size"` // maximum response body size (bytes)
+	P50RespSize int64   `json:"p50_resp_size"` // 50th percentile response body size (bytes)
+	P95RespSize int64   `json:"p95_resp_size"` // 95th percentile response body size (bytes)
+	P99RespSize int64   `json:"p99_resp_size"` // 99th percentile response body size (bytes)
 
 	StatusCodes map[int]int64 `json:"status_codes"` // status code distribution (count)
 
@@ -316,6 +403,13 @@ type Statistics struct {
 	AgentID string `json:"agent_id"` // identify agent
 }
 
+// BandwidthPoint bytes sent and received in one second
+type BandwidthPoint struct {
+	Second   int   `json:"second"`   // elapsed seconds since the test started
+	Sent     int64 `json:"sent"`     // sent (bytes)
+	Received int64 `json:"received"` // received (bytes)
+}
+
 // Save saves the statistics data to a JSON file.
 func (s *Statistics) Save(filePath string) error {
 	err := ensureFileExists(filePath)
@@ -428,7 +522,16 @@ func (spc *statsPrometheusCollector) copyStatsCollector(s *statsCollector) {
 			statusCodeSet[k] = v
 		}
 	}
+	var phaseDurations [phaseCount][]float64
+	for i, durations := range s.phaseDurations {
+		phaseDurations[i] = append([]float64{}, durations...)
+	}
 	spc.statsCollector = &statsCollector{
+		respSizes:      append([]float64{}, s.respSizes...),
+		phaseDurations: phaseDurations,
+		latencyBuckets: append([]int64(nil), s.latencyBuckets...),
+		startTime:      s.startTime,
+		bandwidth:      append([]BandwidthPoint{}, s.bandwidth...),
 		durations:      durations,
 		errSet:         errSet,
 		statusCodeSet:  statusCodeSet,
@@ -0,0 +1,135 @@
+package http
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPercentile(t *testing.T) {
+	sorted := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	tests := []struct {
+		sorted []float64
+		p      float64
+		want   float64
+	}{
+		{sorted: nil, p: 0.5, want: 0},
+		{sorted: []float64{7}, p: 0, want: 7},
+		{sorted: []float64{7}, p: 0.99, want: 7},
+		{sorted: sorted, p: 0, want: 1},
+		{sorted: sorted, p: 0.5, want: 6}, // index 4.5 is rounded to 5
+		{sorted: sorted, p: 0.95, want: 10},
+		{sorted: sorted, p: 0.9, want: 9},
+		{sorted: sorted, p: 1, want: 10},
+		{sorted: sorted, p: -0.1, want: 1},
+		{sorted: sorted, p: 1.5, want: 10},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, percentile(tt.sorted, tt.p), "%v, p=%v", tt.sorted, tt.p)
+	}
+}
+
+func TestStatsCollector_recordTransfer(t *testing.T) {
+	c := &statsCollector{}
+	c.recordTransfer(Result{ReqSize: 10, RespSize: 100})
+	c.recordTransfer(Result{ReqSize: 10, RespSize: 50, Err: errors.New("timeout")})
+	assert.False(t, c.startTime.IsZero())
+	assert.Equal(t, []float64{100}, c.respSizes) // the response of failed request is not counted in sizes
+	assert.Equal(t, []BandwidthPoint{{Second: 0, Sent: 20, Received: 150}}, c.bandwidth)
+
+	// the seconds without transfer are filled with empty points
+	c.startTime = time.Now().Add(-2500 * time.Millisecond)
+	c.recordTransfer(Result{ReqSize: 5, RespSize: 20})
+	c.recordTransfer(Result{ReqSize: 5, RespSize: 30})
+	assert.Equal(t, []BandwidthPoint{
+		{Second: 0, Sent: 20, Received: 150},
+		{Second: 1},
+		{Second: 2, Sent: 10, Received: 50},
+	}, c.bandwidth)
+	assert.Equal(t, int64(30), c.totalReqBytes)
+	assert.Equal(t, int64(200), c.totalRespBytes)
+}
+
+func TestStatsCollector_toStatistics_transfer(t *testing.T) {
+	results := make(chan Result, 200)
+	for i := 100; i > 0; i-- {
+		results <- Result{Duration: time.Millisecond, ReqSize: 10, RespSize: int64(i), StatusCode: 200}
+	}
+	for i := 0; i < 10; i++ {
+		results <- Result{ReqSize: 10, RespSize: 500, StatusCode: 500, Err: errors.New("internal server error")}
+	}
+	close(results)
+
+	c := &statsCollector{}
+	done := make(chan struct{})
+	go c.collect(results, done)
+	<-done
+
+	st := c.toStatistics(2*time.Second, 110, &HTTPReqParams{URL: "http://localhost", Method: "GET"})
+	assert.Equal(t, int64(1100), st.TotalSent)
+	assert.Equal(t, int64(10050), st.TotalReceived)
+	assert.Equal(t, 550.0, st.SentRate)
+	assert.Equal(t, 5025.0, st.ReceivedRate)
+	require.Len(t, st.Bandwidth, 1)
+	assert.Equal(t, BandwidthPoint{Second: 0, Sent: 1100, Received: 10050}, st.Bandwidth[0])
+
+	// the response sizes of successful requests are 1 to 100 bytes
+	assert.Equal(t, 50.5, st.AvgRespSize)
+	assert.Equal(t, int64(1), st.MinRespSize)
+	assert.Equal(t, int64(100), st.MaxRespSize)
+	assert.Equal(t, int64(51), st.P50RespSize)
+	assert.Equal(t, int64(95), st.P95RespSize)
+	assert.Equal(t, int64(99), st.P99RespSize)
+
+	// no successful request
+	st = (&statsCollector{}).toStatistics(0, 0, &HTTPReqParams{})
+	assert.Zero(t, st.SentRate)
+	assert.Zero(t, st.AvgRespSize)
+	assert.Zero(t, st.P99RespSize)
+	assert.Empty(t, st.Bandwidth)
+}
+
+func TestCollectorServer_aggregateReports_transfer(t *testing.T) {
+	s, err := NewCollectorServer(0, "")
+	require.NoError(t, err)
+
+	reports := map[string]PerfTestData{
+		"agent1": {
+			SuccessCount: 100, TotalSent: 1000, TotalReceived: 2000, SentRate: 100.25, ReceivedRate: 200,
+			Bandwidth:   []BandwidthPoint{{Second: 0, Sent: 10, Received: 100}, {Second: 1, Sent: 20, Received: 200}},
+			AvgRespSize: 10, MinRespSize: 2, MaxRespSize: 50, P50RespSize: 8, P95RespSize: 20, P99RespSize: 40,
+		},
+		"agent2": {
+			SuccessCount: 300, TotalSent: 3000, TotalReceived: 6000, SentRate: 50.5, ReceivedRate: 400,
+			Bandwidth:   []BandwidthPoint{{Second: 1, Sent: 5, Received: 50}, {Second: 2, Sent: 1, Received: 1}},
+			AvgRespSize: 20, MinRespSize: 1, MaxRespSize: 80, P50RespSize: 16, P95RespSize: 30, P99RespSize: 60,
+		},
+	}
+	for i := 0; i < 5; i++ { // the reports are iterated in random order
+		report := s.aggregateReports("test", reports)
+		require.NotNil(t, report)
+
+		assert.Equal(t, int64(4000), report.TotalSent)
+		assert.Equal(t, int64(8000), report.TotalReceived)
+		assert.Equal(t, 150.8, report.SentRate)
+		assert.Equal(t, 600.0, report.ReceivedRate)
+		assert.Equal(t, []BandwidthPoint{
+			{Second: 0, Sent: 10, Received: 100},
+			{Second: 1, Sent: 25, Received: 250},
+			{Second: 2, Sent: 1, Received: 1},
+		}, report.Bandwidth)
+
+		// the average is weighted by the number of successful requests, the percentiles are averaged
+		assert.Equal(t, 17.5, report.AvgRespSize)
+		assert.Equal(t, int64(1), report.MinRespSize)
+		assert.Equal(t, int64(80), report.MaxRespSize)
+		assert.Equal(t, int64(12), report.P50RespSize)
+		assert.Equal(t, int64(25), report.P95RespSize)
+		assert.Equal(t, int64(50), report.P99RespSize)
+	}
+
+	assert.Nil(t, s.aggregateReports("test", nil))
+}
@@ -0,0 +1,131 @@
+package http
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// policies of selecting the target URL of each request when there are multiple URLs
+const (
+	// URLPolicyRoundRobin selects the URLs in order, each URL receives the same number of requests.
+	URLPolicyRoundRobin = "round-robin"
+	// URLPolicyRandom selects the URLs randomly with uniform distribution.
+	URLPolicyRandom = "random"
+	// URLPolicyZipf selects the URLs with zipfian distribution, the URLs in front are hot keys,
+	// and the URLs in the back are cold keys, the skew is set by --zipf-s.
+	URLPolicyZipf = "zipf"
+)
+
+type urlSelector interface {
+	next() string
+}
+
+type roundRobinSelector struct {
+	urls    []string
+	counter uint64
+}
+
+func (s *roundRobinSelector) next() string {
+	n := atomic.AddUint64(&s.counter, 1) - 1
+	return s.urls[n%uint64(len(s.urls))]
+}
+
+type randomSelector struct {
+	mu   sync.Mutex
+	rand *rand.Rand
+	urls []string
+}
+
+func (s *randomSelector) next() string {
+	s.mu.Lock()
+	i := s.rand.Intn(len(s.urls))
+	s.mu.Unlock()
+	return s.urls[i]
+}
+
+type zipfSelector struct {
+	mu   sync.Mutex
+	zipf *rand.Zipf
+	urls []string
+}
+
+func (s *zipfSelector) next() string {
+	s.mu.Lock()
+	i := s.zipf.Uint64()
+	s.mu.Unlock()
+	return s.urls[i]
+}
+
+func newURLSelector(urls []string, policy string, zipfS float64) (urlSelector, error) {
+	switch policy {
+	case "", URLPolicyRoundRobin:
+		return &roundRobinSelector{urls: urls}, nil
+	case URLPolicyRandom:
+		return &randomSelector{rand: rand.New(rand.NewSource(time.Now().UnixNano())), urls: urls}, nil //nolint
+	case URLPolicyZipf:
+		if zipfS <= 1 {
+			return nil, errors.New("'--zipf-s' must be greater than 1")
+		}
+		r := rand.New(rand.NewSource(time.Now().UnixNano())) //nolint
+		return &zipfSelector{zipf: rand.NewZipf(r, zipfS, 1, uint64(len(urls)-1)), urls: urls}, nil
+	}
+	return nil, fmt.Errorf("'--url-policy' only supports %s, %s or %s", URLPolicyRoundRobin, URLPolicyRandom, URLPolicyZipf)
+}
+
+// readURLFile reads the URLs from file, one URL per line, the blank lines and the lines starting with # are ignored.
+func readURLFile(file string) ([]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var urls []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		urls = append(urls, line)
+	}
+	return urls, scanner.Err()
+}
+
+// setTargetURLs sets the URLs of --url and --url-file to params, if there are multiple URLs,
+// the target URL of each request is selected by the policy.
+func setTargetURLs(params *HTTPReqParams, urls []string, urlFile string, policy string, zipfS float64) error {
+	if urlFile != "" {
+		fileURLs, err := readURLFile(urlFile)
+		if err != nil {
+			return fmt.Errorf("read url file error: %v", err)
+		}
+		urls = append(urls, fileURLs...)
+	}
+	if len(urls) == 0 {
+		return errors.New("'--url' or '--url-file' must be set")
+	}
+
+	selector, err := newURLSelector(urls, policy, zipfS)
+	if err != nil {
+		return err
+	}
+
+	params.URL = urls[0]
+	if len(urls) == 1 {
+		return nil
+	}
+	if policy == "" {
+		policy = URLPolicyRoundRobin
+	}
+	params.selector = selector
+	params.URL = fmt.Sprintf("%s (and %d more URLs, %s)", urls[0], len(urls)-1, policy)
+	return nil
+}
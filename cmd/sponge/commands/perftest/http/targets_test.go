@@ -0,0 +1,118 @@
+package http
+
+import (
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testURLs = []string{"http://localhost/1", "http://localhost/2", "http://localhost/3", "http://localhost/4"}
+
+func countSelected(s urlSelector, n int) map[string]int {
+	counts := make(map[string]int)
+	for i := 0; i < n; i++ {
+		counts[s.next()]++
+	}
+	return counts
+}
+
+func TestURLSelector_distribution(t *testing.T) {
+	const n = 40000
+
+	// round-robin, each URL receives the same number of requests
+	counts := countSelected(&roundRobinSelector{urls: testURLs}, n)
+	for _, u := range testURLs {
+		assert.Equal(t, n/len(testURLs), counts[u], u)
+	}
+
+	// random, uniform distribution
+	r := rand.New(rand.NewSource(1))
+	counts = countSelected(&randomSelector{rand: r, urls: testURLs}, n)
+	for _, u := range testURLs {
+		assert.InDelta(t, n/len(testURLs), counts[u], n*0.02, u)
+	}
+
+	// zipf, the URLs in front are selected more, the probability of the k-th URL is proportional to (1+k)^-s
+	for _, zipfS := range []float64{1.5, 2, 3} {
+		r = rand.New(rand.NewSource(1))
+		s := &zipfSelector{zipf: rand.NewZipf(r, zipfS, 1, uint64(len(testURLs)-1)), urls: testURLs}
+		counts = countSelected(s, n)
+
+		weights, sum := make([]float64, len(testURLs)), 0.0
+		for k := range weights {
+			weights[k] = math.Pow(float64(1+k), -zipfS)
+			sum += weights[k]
+		}
+		total := 0
+		for k, u := range testURLs {
+			total += counts[u]
+			assert.InDelta(t, weights[k]/sum, float64(counts[u])/n, 0.01, "s=%v, %s", zipfS, u)
+			if k > 0 {
+				assert.Greater(t, counts[testURLs[k-1]], counts[u], "s=%v, %s", zipfS, u)
+			}
+		}
+		assert.Equal(t, n, total)
+	}
+
+	// the same seed selects the same sequence
+	s1 := &zipfSelector{zipf: rand.NewZipf(rand.New(rand.NewSource(7)), 2, 1, 3), urls: testURLs}
+	s2 := &zipfSelector{zipf: rand.NewZipf(rand.New(rand.NewSource(7)), 2, 1, 3), urls: testURLs}
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, s1.next(), s2.next())
+	}
+}
+
+func TestNewURLSelector(t *testing.T) {
+	tests := []struct {
+		policy  string
+		zipfS   float64
+		want    interface{}
+		wantErr bool
+	}{
+		{policy: "", want: &roundRobinSelector{}},
+		{policy: URLPolicyRoundRobin, want: &roundRobinSelector{}},
+		{policy: URLPolicyRandom, want: &randomSelector{}},
+		{policy: URLPolicyZipf, zipfS: 1.2, want: &zipfSelector{}},
+		{policy: URLPolicyZipf, zipfS: 1, wantErr: true},
+		{policy: "unknown", wantErr: true},
+	}
+	for _, tt := range tests {
+		s, err := newURLSelector(testURLs, tt.policy, tt.zipfS)
+		if tt.wantErr {
+			assert.Error(t, err, tt.policy)
+			continue
+		}
+		require.NoError(t, err, tt.policy)
+		assert.IsType(t, tt.want, s, tt.policy)
+		assert.Contains(t, testURLs, s.next())
+	}
+}
+
+func TestSetTargetURLs(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "urls.txt")
+	require.NoError(t, os.WriteFile(file, []byte("# comment\nhttp://localhost/3\n\n  http://localhost/4  \n"), 0666))
+	urls, err := readURLFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, testURLs[2:], urls)
+
+	params := &HTTPReqParams{}
+	require.NoError(t, setTargetURLs(params, testURLs[:1], "", "", 0))
+	assert.Equal(t, testURLs[0], params.URL)
+	assert.Nil(t, params.selector)
+
+	params = &HTTPReqParams{}
+	require.NoError(t, setTargetURLs(params, testURLs[:2], file, "", 0))
+	assert.Equal(t, "http://localhost/1 (and 3 more URLs, round-robin)", params.URL)
+	for _, u := range testURLs {
+		assert.Equal(t, u, params.selector.next())
+	}
+
+	assert.Error(t, setTargetURLs(&HTTPReqParams{}, nil, "", "", 0))
+	assert.Error(t, setTargetURLs(&HTTPReqParams{}, nil, filepath.Join(t.TempDir(), "not-exist.txt"), "", 0))
+	assert.Error(t, setTargetURLs(&HTTPReqParams{}, testURLs, "", URLPolicyZipf, 0.5))
+}
@@ -2,6 +2,7 @@ package websocket
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"sync"
 	"time"
@@ -16,14 +17,18 @@ type Client struct {
 	stats *statsCollector
 	url   string
 
+	dialer *websocket.Dialer
+	header http.Header
+
 	isJSON bool
 	//sendPayloadTemplate map[string]any // isJSON=true, for JSON data
 	sendPayloadBytes []byte // if isJSON = true, sendPayloadBytes is the JSON data, otherwise, it is the binary data
 	sendTicker       *time.Ticker
 }
 
-// NewClient creates a new WebSocket client worker.
-func NewClient(id int, url string, stats *statsCollector, sendInterval time.Duration, payloadData []byte, isJSON bool) *Client {
+// NewClient creates a new WebSocket client worker, dialer and header are used in the handshake, the default dialer is used if dialer is nil.
+func NewClient(id int, url string, stats *statsCollector, sendInterval time.Duration, payloadData []byte, isJSON bool,
+	dialer *websocket.Dialer, header http.Header) *Client {
 	var ticker *time.Ticker
 	if sendInterval > 0 {
 		ticker = time.NewTicker(sendInterval)
@@ -33,6 +38,8 @@ func NewClient(id int, url string, stats *statsCollector, sendInterval time.Dura
 		id:               id,
 		stats:            stats,
 		url:              url,
+		dialer:           dialer,
+		header:           header,
 		isJSON:           isJSON,
 		sendPayloadBytes: payloadData,
 		sendTicker:       ticker,
@@ -41,14 +48,22 @@ func NewClient(id int, url string, stats *statsCollector, sendInterval time.Dura
 
 // Dial establishes a WebSocket connection to the server.
 func (c *Client) Dial(ctx context.Context) error {
-	customDialer := websocket.Dialer{
-		HandshakeTimeout: 5 * time.Second,
-		Proxy:            http.ProxyFromEnvironment,
+	dialer := c.dialer
+	if dialer == nil {
+		dialer = &websocket.Dialer{
+			HandshakeTimeout: 5 * time.Second,
+			Proxy:            http.ProxyFromEnvironment,
+		}
 	}
 
 	dialStartTime := time.Now()
-	conn, _, err := customDialer.DialContext(ctx, c.url, nil)
+	conn, _, err := dialer.DialContext(ctx, c.url, c.header)
 	connectTime := time.Since(dialStartTime)
+	if err == nil && len(dialer.Subprotocols) > 0 && conn.Subprotocol() == "" {
+		// the server accepted the connection without selecting any of the requested subprotocols
+		_ = conn.Close()
+		err = fmt.Errorf("subprotocol negotiation failed, the server does not support %v", dialer.Subprotocols)
+	}
 	if err != nil {
 		c.stats.AddConnectFailure()
 		c.stats.errSet.Add(err.Error())
@@ -0,0 +1,100 @@
+package websocket
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// dialParams the parameters of establishing websocket connections
+type dialParams struct {
+	caFile             string   // CA certificate file used to verify the server certificate
+	certFile           string   // client certificate file, used for mutual TLS
+	keyFile            string   // client private key file, used for mutual TLS
+	serverName         string   // server name used to verify the server certificate
+	insecureSkipVerify bool     // skip verifying the server certificate
+	subprotocols       []string // subprotocols requested in the handshake, in order of preference
+	headers            []string // custom headers of the handshake, format key:value
+}
+
+func (d *dialParams) newTLSConfig() (*tls.Config, error) {
+	if d.caFile == "" && d.certFile == "" && d.keyFile == "" && d.serverName == "" && !d.insecureSkipVerify {
+		return nil, nil // use the default config, verify the server certificate by system root CAs
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName:         d.serverName,
+		InsecureSkipVerify: d.insecureSkipVerify, //nolint
+	}
+
+	if d.caFile != "" {
+		ca, err := os.ReadFile(d.caFile)
+		if err != nil {
+			return nil, fmt.Errorf("read ca file error: %v", err)
+		}
+		certPool := x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no valid certificate found in ca file '%s'", d.caFile)
+		}
+		tlsConfig.RootCAs = certPool
+	}
+
+	if d.certFile != "" || d.keyFile != "" {
+		if d.certFile == "" || d.keyFile == "" {
+			return nil, errors.New("'--cert' and '--key' must be set at the same time")
+		}
+		cert, err := tls.LoadX509KeyPair(d.certFile, d.keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate error: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+func (d *dialParams) newHeader() (http.Header, error) {
+	if len(d.headers) == 0 {
+		return nil, nil
+	}
+	header := http.Header{}
+	for _, h := range d.headers {
+		kvs := strings.SplitN(h, ":", 2)
+		if len(kvs) != 2 || strings.TrimSpace(kvs[0]) == "" {
+			return nil, fmt.Errorf("invalid header '%s', format is key:value", h)
+		}
+		key := strings.TrimSpace(kvs[0])
+		if strings.EqualFold(key, "Sec-WebSocket-Protocol") {
+			return nil, errors.New("use '--subprotocol' to set the header Sec-WebSocket-Protocol")
+		}
+		header.Add(key, strings.TrimSpace(kvs[1]))
+	}
+	return header, nil
+}
+
+// newDialer creates a dialer shared by all clients.
+func (d *dialParams) newDialer() (*websocket.Dialer, http.Header, error) {
+	tlsConfig, err := d.newTLSConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	header, err := d.newHeader()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dialer := &websocket.Dialer{
+		HandshakeTimeout: 5 * time.Second,
+		Proxy:            http.ProxyFromEnvironment,
+		TLSClientConfig:  tlsConfig,
+		Subprotocols:     d.subprotocols,
+	}
+	return dialer, header, nil
+}
@@ -0,0 +1,155 @@
+package websocket
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-dev-frame/sponge/pkg/grpc/gtls/certfile"
+)
+
+var (
+	caFile         = certfile.Path("two-way/ca.pem")
+	serverCertFile = certfile.Path("two-way/server/server.pem")
+	serverKeyFile  = certfile.Path("two-way/server/server.key")
+	clientCertFile = certfile.Path("two-way/client/client.pem")
+	clientKeyFile  = certfile.Path("two-way/client/client.key")
+)
+
+func TestDialParams_newTLSConfig(t *testing.T) {
+	tlsConfig, err := (&dialParams{}).newTLSConfig()
+	assert.NoError(t, err)
+	assert.Nil(t, tlsConfig) // default config
+
+	tlsConfig, err = (&dialParams{insecureSkipVerify: true}).newTLSConfig()
+	require.NoError(t, err)
+	assert.True(t, tlsConfig.InsecureSkipVerify)
+
+	tlsConfig, err = (&dialParams{caFile: caFile, certFile: clientCertFile, keyFile: clientKeyFile, serverName: "localhost"}).newTLSConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "localhost", tlsConfig.ServerName)
+	assert.NotNil(t, tlsConfig.RootCAs)
+	assert.Len(t, tlsConfig.Certificates, 1)
+
+	invalidCAFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(invalidCAFile, []byte("not a certificate"), 0666))
+	tests := []struct {
+		name   string
+		params *dialParams
+		errMsg string
+	}{
+		{name: "no ca file", params: &dialParams{caFile: filepath.Join(t.TempDir(), "not-exist.pem")}, errMsg: "read ca file error"},
+		{name: "invalid ca", params: &dialParams{caFile: invalidCAFile}, errMsg: "no valid certificate found"},
+		{name: "only cert", params: &dialParams{certFile: clientCertFile}, errMsg: "'--cert' and '--key' must be set at the same time"},
+		{name: "only key", params: &dialParams{keyFile: clientKeyFile}, errMsg: "'--cert' and '--key' must be set at the same time"},
+		{name: "mismatched key", params: &dialParams{certFile: clientCertFile, keyFile: caFile}, errMsg: "load client certificate error"},
+	}
+	for _, tt := range tests {
+		_, err = tt.params.newTLSConfig()
+		assert.ErrorContains(t, err, tt.errMsg, tt.name)
+	}
+}
+
+func TestDialParams_newHeader(t *testing.T) {
+	header, err := (&dialParams{}).newHeader()
+	assert.NoError(t, err)
+	assert.Nil(t, header)
+
+	header, err = (&dialParams{headers: []string{"Authorization: Bearer token", " X-Tag :a", "X-Tag: b:c"}}).newHeader()
+	require.NoError(t, err)
+	assert.Equal(t, http.Header{"Authorization": {"Bearer token"}, "X-Tag": {"a", "b:c"}}, header)
+
+	for _, h := range []string{"Authorization", ": value", "sec-websocket-protocol: chat"} {
+		_, err = (&dialParams{headers: []string{h}}).newHeader()
+		assert.Error(t, err, h)
+	}
+}
+
+// run a wss server requiring client certificate, it selects the subprotocol chat.v1 and echoes the authorization header
+func runTLSServer(t *testing.T) (string, *atomic.Value) {
+	var authorization atomic.Value
+	upgrader := websocket.Upgrader{Subprotocols: []string{"chat.v1"}}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization.Store(r.Header.Get("Authorization"))
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		_ = conn.Close()
+	}))
+
+	cert, err := tls.LoadX509KeyPair(serverCertFile, serverKeyFile)
+	require.NoError(t, err)
+	ca, err := os.ReadFile(caFile)
+	require.NoError(t, err)
+	certPool := x509.NewCertPool()
+	require.True(t, certPool.AppendCertsFromPEM(ca))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    certPool,
+	}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return "wss" + strings.TrimPrefix(server.URL, "https"), &authorization
+}
+
+func TestClient_DialTLS(t *testing.T) {
+	url, authorization := runTLSServer(t)
+	mutualTLS := dialParams{caFile: caFile, certFile: clientCertFile, keyFile: clientKeyFile, serverName: "localhost"}
+
+	dial := func(d dialParams) (*Client, *statsCollector, error) {
+		dialer, header, err := d.newDialer()
+		require.NoError(t, err)
+		stats := &statsCollector{errSet: NewErrSet()}
+		c := NewClient(1, url, stats, 0, nil, false, dialer, header)
+		return c, stats, c.Dial(context.Background())
+	}
+
+	// verify the server certificate by CA, authenticate the client by certificate, negotiate the subprotocol
+	d := mutualTLS
+	d.subprotocols = []string{"chat.v2", "chat.v1"}
+	d.headers = []string{"Authorization: Bearer token"}
+	c, stats, err := dial(d)
+	require.NoError(t, err)
+	assert.Equal(t, "chat.v1", c.conn.Subprotocol())
+	assert.Equal(t, "Bearer token", authorization.Load())
+	assert.Equal(t, uint64(1), stats.connectSuccessCount)
+	_ = c.conn.Close()
+
+	// skip verifying the server certificate
+	d = dialParams{certFile: clientCertFile, keyFile: clientKeyFile, insecureSkipVerify: true}
+	c, _, err = dial(d)
+	require.NoError(t, err)
+	assert.Equal(t, "", c.conn.Subprotocol())
+	_ = c.conn.Close()
+
+	tests := []struct {
+		name   string
+		params dialParams
+		errMsg string
+	}{
+		{name: "unsupported subprotocol", params: func() dialParams { d := mutualTLS; d.subprotocols = []string{"chat.v3"}; return d }(),
+			errMsg: "subprotocol negotiation failed"},
+		{name: "unknown authority", params: dialParams{certFile: clientCertFile, keyFile: clientKeyFile}, errMsg: "certificate"},
+		{name: "no client certificate", params: dialParams{caFile: caFile, serverName: "localhost"}},
+	}
+	for _, tt := range tests {
+		_, stats, err = dial(tt.params)
+		require.Error(t, err, tt.name)
+		assert.Contains(t, err.Error(), tt.errMsg, tt.name)
+		assert.Equal(t, uint64(1), stats.connectFailureCount, tt.name)
+		assert.Equal(t, []string{err.Error()}, stats.errSet.List(), tt.name)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"sync"
@@ -11,6 +12,7 @@ import (
 	"time"
 
 	"github.com/fatih/color"
+	"github.com/gorilla/websocket"
 	"github.com/spf13/cobra"
 
 	"github.com/go-dev-frame/sponge/cmd/sponge/commands/perftest/common"
@@ -31,6 +33,8 @@ func PerfTestWebsocketCMD() *cobra.Command {
 		bodyFile   string
 
 		out string
+
+		dp = &dialParams{}
 	)
 
 	cmd := &cobra.Command{
@@ -47,8 +51,14 @@ func PerfTestWebsocketCMD() *cobra.Command {
   %s websocket --worker=10 --duration=10s --body={\"name\":\"Alice\",\"age\":25} --url=ws://localhost:8080/ws
 
   # Send JSON messages, 100 workers, 1m duration, each worker sends messages every 10ms
-  %s websocket --worker=100 --duration=1m --send-interval=10ms --body={\"name\":\"Alice\",\"age\":25} --url=ws://localhost:8080/ws`,
-			common.CommandPrefix, common.CommandPrefix, common.CommandPrefix, common.CommandPrefix),
+  %s websocket --worker=100 --duration=1m --send-interval=10ms --body={\"name\":\"Alice\",\"age\":25} --url=ws://localhost:8080/ws
+
+  # Secure websocket, verify the server certificate by the CA certificate, and authenticate the client by certificate (mutual TLS)
+  %s websocket --ca=ca.pem --cert=client.pem --key=client.key --url=wss://localhost:8443/ws
+
+  # Negotiate the subprotocol and set custom headers in the handshake
+  %s websocket --subprotocol=chat.v2 --subprotocol=chat.v1 --header="Authorization: Bearer token" --url=wss://localhost:8443/ws`,
+			common.CommandPrefix, common.CommandPrefix, common.CommandPrefix, common.CommandPrefix, common.CommandPrefix, common.CommandPrefix),
 		SilenceErrors: true,
 		SilenceUsage:  true,
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -70,6 +80,11 @@ func PerfTestWebsocketCMD() *cobra.Command {
 				}
 			}
 
+			dialer, header, err := dp.newDialer()
+			if err != nil {
+				return err
+			}
+
 			p := &perfTestParams{
 				targetURL:    targetURL,
 				worker:       worker,
@@ -78,6 +93,8 @@ func PerfTestWebsocketCMD() *cobra.Command {
 				rampUp:       rampUp,
 				payloadData:  payloadData,
 				isJSON:       isJSON,
+				dialer:       dialer,
+				header:       header,
 				out:          out,
 			}
 
@@ -96,6 +113,14 @@ func PerfTestWebsocketCMD() *cobra.Command {
 	cmd.Flags().StringVarP(&bodyFile, "body-file", "f", "", "request body file")
 	cmd.Flags().StringVarP(&bodyString, "body-string", "s", "", "request body (String)")
 
+	cmd.Flags().StringSliceVarP(&dp.headers, "header", "e", nil, "custom headers of the handshake, format key:value")
+	cmd.Flags().StringArrayVar(&dp.subprotocols, "subprotocol", nil, "subprotocol requested in the handshake, repeat the flag to request multiple subprotocols in order of preference")
+	cmd.Flags().StringVar(&dp.caFile, "ca", "", "CA certificate file used to verify the server certificate of wss")
+	cmd.Flags().StringVar(&dp.certFile, "cert", "", "client certificate file for mutual TLS, must be set with --key")
+	cmd.Flags().StringVar(&dp.keyFile, "key", "", "client private key file for mutual TLS, must be set with --cert")
+	cmd.Flags().StringVar(&dp.serverName, "server-name", "", "server name used to verify the server certificate, default is the host of URL")
+	cmd.Flags().BoolVar(&dp.insecureSkipVerify, "insecure", false, "skip verifying the server certificate of wss")
+
 	cmd.Flags().StringVarP(&out, "out", "o", "", "save statistics to JSON file")
 
 	return cmd
@@ -111,6 +136,9 @@ type perfTestParams struct {
 	payloadData []byte
 	isJSON      bool
 
+	dialer *websocket.Dialer
+	header http.Header
+
 	out string
 }
 
@@ -150,7 +178,7 @@ func (p *perfTestParams) run() error {
 		}
 
 		wg.Add(1)
-		client := NewClient(i+1, p.targetURL, stats, p.sendInterval, p.payloadData, p.isJSON)
+		client := NewClient(i+1, p.targetURL, stats, p.sendInterval, p.payloadData, p.isJSON, p.dialer, p.header)
 		go client.Run(mainCtx, &wg)
 
 		if rampUpDelay > 0 {
@@ -36,13 +36,18 @@ Docs: %s`,
 		InitCommand(),
 		UpgradeCommand(),
 		PluginsCommand(),
+		PackTemplatesCommand(),
 		GenWebCommand(),
 		GenMicroCommand(),
 		generate.ConfigCommand(),
 		OpenUICommand(),
+		NewCommand(),
 		MergeCommand(),
 		PatchCommand(),
+		MigrateCommand(),
+		EcodeCommand(),
 		GenGraphCommand(),
+		generate.GraphQLCommand(),
 		TemplateCommand(),
 		AssistantCommand(),
 		PerftestCommand(),
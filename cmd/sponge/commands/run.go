@@ -21,21 +21,44 @@ func OpenUICommand() *cobra.Command {
 		port       int
 		spongeAddr string
 		isLog      bool
+		taskOpts   = &taskOptions{}
 	)
 
 	cmd := &cobra.Command{
-		Use:   "run",
-		Short: "Run code generation engine service, generate code in UI interface",
-		Long:  "Run code generation engine service, generate code in UI interface.",
+		Use:   "run [target]",
+		Short: "Run code generation engine service, or run the target of Makefile in the generated project",
+		Long: `Run code generation engine service, generate code in UI interface.
+
+If the target is specified, run the common target of Makefile in the generated project without make and bash,
+it is suitable for windows. The supported targets are build, run, docs, proto, test and clean, the missing
+dependency tools are detected and can be installed automatically.`,
 		Example: color.HiBlackString(`  # Running ui service, local browser access only.
   sponge run
 
   # Running ui service, can be accessed from other host browsers.
-  sponge run -a http://your-host-ip:24631`),
+  sponge run -a http://your-host-ip:24631
+
+  # Running a standalone api gateway.
+  sponge run gateway -c gateway.yml
+
+  # Build and run the service in the current directory, the same as make run.
+  sponge run run
+  sponge run run -c configs/dev.yml
+
+  # Generate code by the specified proto files, the same as make proto FILES=api/user/v1/user.proto.
+  sponge run proto -f api/user/v1/user.proto
+
+  # Generate api docs of the service in the specified directory, install the missing tools without prompting.
+  sponge run docs -d /path/to/project -y`),
+		Args:          cobra.MaximumNArgs(1),
 		SilenceErrors: true,
 		SilenceUsage:  true,
 
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				return runTask(args[0], taskOpts)
+			}
+
 			if spongeAddr == "" {
 				spongeAddr = fmt.Sprintf("http://localhost:%d", port)
 			} else {
@@ -45,6 +68,7 @@ func OpenUICommand() *cobra.Command {
 			}
 			fmt.Printf("Code generation engine service running %s. Access %s in your browser.\n",
 				getVersion(), color.HiCyanString(spongeAddr))
+			fmt.Printf("The service topology view of workspace is at %s.\n", color.HiCyanString(spongeAddr+"/topology"))
 			go func() {
 				_ = open(spongeAddr)
 			}()
@@ -55,6 +79,12 @@ func OpenUICommand() *cobra.Command {
 	cmd.Flags().IntVarP(&port, "port", "p", 24631, "port on which the sponge service listens")
 	cmd.Flags().StringVarP(&spongeAddr, "addr", "a", "", "address of the front-end page requesting the sponge service, e.g. http://192.168.1.10:24631 or https://your-domain.com")
 	cmd.Flags().BoolVarP(&isLog, "log", "l", false, "enable service logging")
+	cmd.Flags().StringVarP(&taskOpts.dir, "dir", "d", ".", "project directory, only for target")
+	cmd.Flags().StringVarP(&taskOpts.configFile, "config", "c", "", "configuration file of service, only for target run")
+	cmd.Flags().StringVarP(&taskOpts.protoFiles, "files", "f", "", "proto files, multiple files separated by commas, only for target proto")
+	cmd.Flags().BoolVarP(&taskOpts.yes, "yes", "y", false, "install the missing dependency tools without prompting, only for target")
+
+	cmd.AddCommand(GatewayCommand())
 	return cmd
 }
 
@@ -0,0 +1,391 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"gopkg.in/yaml.v3"
+
+	"github.com/go-dev-frame/sponge/pkg/gobash"
+)
+
+// the targets of Makefile implemented in go, they can be executed without make and bash, e.g. on windows
+var taskTargets = map[string]func(ctx context.Context, t *taskRunner) error{
+	"build": buildTarget,
+	"run":   runTarget,
+	"docs":  docsTarget,
+	"proto": protoTarget,
+	"test":  testTarget,
+	"clean": cleanTarget,
+}
+
+func taskTargetNames() []string {
+	names := make([]string, 0, len(taskTargets))
+	for name := range taskTargets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type taskOptions struct {
+	dir        string // project directory
+	configFile string // configuration file of run
+	protoFiles string // proto files of proto, multiple files separated by commas
+	yes        bool   // install the missing tools without prompting
+}
+
+type taskRunner struct {
+	opts *taskOptions
+
+	dir            string // absolute path of project directory
+	moduleName     string
+	serverName     string
+	suitedMonoRepo bool
+	spongeBin      string
+}
+
+func newTaskRunner(opts *taskOptions) (*taskRunner, error) {
+	dir, err := filepath.Abs(opts.dir)
+	if err != nil {
+		return nil, err
+	}
+	moduleName, serverName, suitedMonoRepo := getGenInfo(dir)
+	if serverName == "" {
+		return nil, fmt.Errorf("not found file %s, the directory %s is not a project generated by sponge",
+			filepath.Join("docs", "gen.info"), dir)
+	}
+
+	spongeBin, err := os.Executable()
+	if err != nil {
+		spongeBin = "sponge"
+	}
+
+	return &taskRunner{
+		opts:           opts,
+		dir:            dir,
+		moduleName:     moduleName,
+		serverName:     serverName,
+		suitedMonoRepo: suitedMonoRepo,
+		spongeBin:      spongeBin,
+	}, nil
+}
+
+// get moduleName, serverName and suitedMonoRepo from docs/gen.info
+func getGenInfo(dir string) (moduleName string, serverName string, suitedMonoRepo bool) {
+	data, err := os.ReadFile(filepath.Join(dir, "docs", "gen.info"))
+	if err != nil {
+		return "", "", false
+	}
+	ms := strings.Split(strings.TrimSpace(string(data)), ",")
+	if len(ms) < 2 {
+		return "", "", false
+	}
+	if len(ms) >= 3 {
+		suitedMonoRepo = ms[2] == "true"
+	}
+	return ms[0], ms[1], suitedMonoRepo
+}
+
+func runTask(target string, opts *taskOptions) error {
+	fn, ok := taskTargets[target]
+	if !ok {
+		return fmt.Errorf("unsupported target %q, supported targets: %s", target, strings.Join(taskTargetNames(), ", "))
+	}
+	t, err := newTaskRunner(opts)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	return fn(ctx, t)
+}
+
+// the binary file of service, e.g. cmd/user/user.exe
+func (t *taskRunner) binaryFile() string {
+	file := filepath.Join("cmd", t.serverName, t.serverName)
+	if runtime.GOOS == "windows" {
+		file += ".exe"
+	}
+	return file
+}
+
+func (t *taskRunner) configFile() string {
+	return filepath.Join("configs", t.serverName+".yml")
+}
+
+// execute the command in dir and print it
+func (t *taskRunner) exec(ctx context.Context, dir string, name string, args ...string) error {
+	fmt.Println(color.HiBlackString("%s %s", name, strings.Join(args, " ")))
+	return gobash.RunTerminal(ctx, name, args, gobash.WithDir(dir))
+}
+
+// execute the sponge command itself, the output is discarded if quiet is true
+func (t *taskRunner) sponge(ctx context.Context, dir string, quiet bool, args ...string) error {
+	var opts = []gobash.TerminalOption{gobash.WithDir(dir)}
+	if quiet {
+		opts = append(opts, func(cmd *exec.Cmd) {
+			cmd.Stdout = io.Discard
+			cmd.Stderr = io.Discard
+		})
+	} else {
+		fmt.Println(color.HiBlackString("sponge %s", strings.Join(args, " ")))
+	}
+	return gobash.RunTerminal(ctx, t.spongeBin, args, opts...)
+}
+
+func (t *taskRunner) goModTidyAndFmt(ctx context.Context, dir string) error {
+	if err := t.exec(ctx, dir, "go", "mod", "tidy"); err != nil {
+		return err
+	}
+	return t.exec(ctx, dir, "gofmt", "-s", "-w", ".")
+}
+
+// ------------------------------------------------------------------------------------------
+
+func buildTarget(ctx context.Context, t *taskRunner) error {
+	if err := t.ensureTools(ctx, "go"); err != nil {
+		return err
+	}
+
+	binaryFile := t.binaryFile()
+	_ = os.Remove(filepath.Join(t.dir, binaryFile))
+	fmt.Printf("building '%s', binary file will output to '%s'\n", t.serverName, binaryFile)
+	return t.exec(ctx, t.dir, "go", "build", "-o", binaryFile, "./cmd/"+t.serverName)
+}
+
+func runTarget(ctx context.Context, t *taskRunner) error {
+	if err := buildTarget(ctx, t); err != nil {
+		return err
+	}
+
+	var args []string
+	if t.opts.configFile != "" {
+		if _, err := os.Stat(filepath.Join(t.dir, t.opts.configFile)); err != nil {
+			return fmt.Errorf("not found config file %s", t.opts.configFile)
+		}
+		args = append(args, "-c", t.opts.configFile)
+	}
+	err := gobash.RunTerminal(ctx, filepath.Join(t.dir, t.binaryFile()), args, gobash.WithDir(t.dir))
+	if ctx.Err() != nil {
+		return nil // stopped by ctrl+c
+	}
+	return err
+}
+
+var swaggerHostRegexp = regexp.MustCompile(`(?m)^(\s*//\s*@host\s+)(\S+)`)
+
+func docsTarget(ctx context.Context, t *taskRunner) error {
+	mainFile := filepath.Join(t.dir, "cmd", t.serverName, "main.go")
+	data, err := os.ReadFile(mainFile)
+	if err != nil || !swaggerHostRegexp.Match(data) {
+		return errors.New("the target docs only supports the web service created based on sql, " +
+			"the service created based on protobuf uses the target proto to generate api docs")
+	}
+	if err = t.ensureTools(ctx, "go", "gofmt", "swag"); err != nil {
+		return err
+	}
+	if err = t.goModTidyAndFmt(ctx, t.dir); err != nil {
+		return err
+	}
+
+	// keep the address of swagger same as the configuration
+	schemes, configAddr := "http", "localhost:8080"
+	if cfg, e := parseHTTPConfig(filepath.Join(t.dir, t.configFile())); e == nil {
+		configAddr = cfg.addr()
+		if cfg.HTTP.TLS.EnableMode != "" {
+			schemes = "https"
+		}
+	}
+	if data, err = os.ReadFile(mainFile); err != nil {
+		return err
+	}
+	newData := swaggerHostRegexp.ReplaceAll(data, []byte("${1}"+configAddr))
+	if string(newData) != string(data) {
+		if err = os.WriteFile(mainFile, newData, 0666); err != nil {
+			return err
+		}
+	}
+
+	if err = t.exec(ctx, t.dir, "swag", "init", "-g", "cmd/"+t.serverName+"/main.go"); err != nil {
+		return err
+	}
+	if err = t.modifyDuplicateCode(ctx, t.dir, "internal/ecode"); err != nil {
+		return err
+	}
+	_ = t.sponge(ctx, t.dir, true, "web", "swagger", "--enable-to-openapi3", "--file=docs/swagger.json")
+
+	fmt.Printf("\nTip: start the service with %s, and open %s to explore the Swagger API docs.\n\n",
+		color.HiCyanString("sponge run run"), color.HiCyanString("%s://%s/swagger/index.html", schemes, configAddr))
+	fmt.Println(color.HiGreenString("generated api docs done."))
+	return nil
+}
+
+func (t *taskRunner) modifyDuplicateCode(ctx context.Context, dir string, ecodeDir string) error {
+	if err := t.sponge(ctx, dir, false, "patch", "modify-dup-num", "--dir="+ecodeDir); err != nil {
+		return err
+	}
+	return t.sponge(ctx, dir, false, "patch", "modify-dup-err-code", "--dir="+ecodeDir)
+}
+
+type httpConfig struct {
+	App struct {
+		Host string `yaml:"host"`
+	} `yaml:"app"`
+	HTTP struct {
+		Port int `yaml:"port"`
+		TLS  struct {
+			EnableMode string `yaml:"enableMode"`
+		} `yaml:"tls"`
+	} `yaml:"http"`
+}
+
+func (c *httpConfig) addr() string {
+	host := c.App.Host
+	if host == "" || host == "127.0.0.1" {
+		host = "localhost"
+	}
+	return fmt.Sprintf("%s:%d", host, c.HTTP.Port)
+}
+
+func parseHTTPConfig(file string) (*httpConfig, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &httpConfig{}
+	if err = yaml.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+	if cfg.HTTP.Port == 0 {
+		return nil, errors.New("http.port is not set")
+	}
+	return cfg, nil
+}
+
+func testTarget(ctx context.Context, t *taskRunner) error {
+	if err := t.ensureTools(ctx, "go"); err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, "go", "list", "./...")
+	cmd.Dir = t.dir
+	out, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("go list ./... error, %v", err)
+	}
+	args := []string{"test", "-count=1", "-short"}
+	for _, pkg := range strings.Fields(string(out)) {
+		if strings.Contains(pkg, "/vendor/") || strings.Contains(pkg, "/api/") || strings.Contains(pkg, "/cmd/") {
+			continue
+		}
+		args = append(args, pkg)
+	}
+	return t.exec(ctx, t.dir, "go", args...)
+}
+
+func cleanTarget(_ context.Context, t *taskRunner) error {
+	patterns := []string{
+		filepath.Join("cmd", t.serverName, t.serverName+"*"),
+		"cover.out",
+		"main.go",
+		t.serverName + ".gv",
+		filepath.Join("internal", "ecode", "*.go.gen*"),
+		filepath.Join("internal", "routers", "*.go.gen*"),
+		filepath.Join("internal", "handler", "*.go.gen*"),
+		filepath.Join("internal", "service", "*.go.gen*"),
+		t.serverName + "-binary.tar.gz",
+	}
+	for _, pattern := range patterns {
+		files, _ := filepath.Glob(filepath.Join(t.dir, pattern))
+		for _, file := range files {
+			if fi, err := os.Stat(file); err != nil || fi.IsDir() {
+				continue
+			}
+			if err := os.Remove(file); err != nil {
+				return err
+			}
+			fmt.Println("removed " + file)
+		}
+	}
+	fmt.Println("clean finished")
+	return nil
+}
+
+// ------------------------------------------------------------------------------------------
+
+var manualInstallTips = map[string]string{
+	"gofmt": "gofmt: it is installed with go, please add the bin directory of go to the environment variable PATH",
+}
+
+// check whether the tools are installed, the tools which can be installed by go install are installed
+// after confirmation, the others such as go and protoc need to be installed manually.
+func (t *taskRunner) ensureTools(ctx context.Context, names ...string) error {
+	var lackNames []string
+	for _, name := range names {
+		if _, err := gobash.LookPath(name); err != nil {
+			lackNames = append(lackNames, name)
+		}
+	}
+	if len(lackNames) == 0 {
+		return nil
+	}
+
+	var manualTips []string
+	for _, name := range lackNames {
+		pkgAddr, ok := installPluginCommands[name]
+		if !ok || name == "go" || name == "protoc" {
+			tip, has := manualInstallTips[name]
+			if !has {
+				tip = pkgAddr
+				if !ok {
+					tip = name + ": please install manually yourself"
+				}
+			}
+			manualTips = append(manualTips, lackSymbol+tip)
+			continue
+		}
+
+		pkgAddr = adaptInternalCommand(name, pkgAddr)
+		if !t.opts.yes && !confirm(fmt.Sprintf("%s %s is not installed, install it with 'go install %s'? [Y/n] ", warnSymbol, name, pkgAddr)) {
+			manualTips = append(manualTips, lackSymbol+name+": not installed, please install it with 'go install "+pkgAddr+"'")
+			continue
+		}
+		if _, err := gobash.LookPath("go"); err != nil {
+			manualTips = append(manualTips, lackSymbol+installPluginCommands["go"])
+			continue
+		}
+		if err := t.exec(ctx, t.dir, "go", "install", pkgAddr); err != nil {
+			return fmt.Errorf("install %s error, %v", name, err)
+		}
+		fmt.Println(installedSymbol + name)
+	}
+
+	if len(manualTips) > 0 {
+		return errors.New("missing dependency tools:\n    " + strings.Join(manualTips, "\n    "))
+	}
+	return nil
+}
+
+func confirm(prompt string) bool {
+	fmt.Print(prompt)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && answer == "" {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "" || answer == "y" || answer == "yes"
+}
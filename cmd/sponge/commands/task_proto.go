@@ -0,0 +1,307 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+const (
+	protoBasePath  = "api"
+	typesProtoFile = "api/types/types.proto"
+)
+
+// the import of useless packages in the generated *.pb.go code
+var unusedPbImports = [][]byte{
+	[]byte(`_ "github.com/envoyproxy/protoc-gen-validate/validate"`),
+	[]byte(`_ "github.com/grpc-ecosystem/grpc-gateway/v2/protoc-gen-openapiv2/options"`),
+	[]byte(`_ "github.com/srikrsna/protoc-gen-gotag/tagger"`),
+	[]byte(`_ "google.golang.org/genproto/googleapis/api/annotations"`),
+}
+
+var protocPluginRegexp = regexp.MustCompile(`^--([\w-]+)_out=`)
+
+// the commands parsed from scripts/protoc.sh of project, the protoc and sponge commands differ
+// by the type of service, so they are read from the script instead of being hard-coded.
+type protocScript struct {
+	allProtoCmds       [][]string // commands of function generateByAllProto
+	specifiedProtoCmds [][]string // commands of function generateBySpecifiedProto
+	finalCmds          [][]string // commands executed after generating code
+}
+
+// the protoc plugins required by the commands, e.g. protoc-gen-go
+func (s *protocScript) plugins() []string {
+	set := map[string]struct{}{}
+	for _, cmds := range [][][]string{s.allProtoCmds, s.specifiedProtoCmds} {
+		for _, args := range cmds {
+			if args[0] != "protoc" {
+				continue
+			}
+			for _, arg := range args[1:] {
+				if ms := protocPluginRegexp.FindStringSubmatch(arg); len(ms) == 2 {
+					set["protoc-gen-"+ms[1]] = struct{}{}
+				}
+			}
+		}
+	}
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parse the logical lines of shell script, the continuation lines ending with \ are joined, and
+// the protoc and sponge commands are grouped by the function where they are located.
+func parseProtocScript(data []byte) *protocScript {
+	s := &protocScript{}
+	funcName := ""
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		for strings.HasSuffix(strings.TrimSpace(line), `\`) && i+1 < len(lines) {
+			line = strings.TrimSuffix(strings.TrimSpace(line), `\`) + " " + lines[i+1]
+			i++
+		}
+		line = strings.TrimSpace(line)
+
+		if strings.HasPrefix(line, "function ") {
+			funcName = strings.TrimSpace(strings.TrimPrefix(line, "function "))
+			funcName = strings.TrimSpace(strings.Split(funcName, "(")[0])
+			continue
+		}
+		if lines[i] == "}" {
+			funcName = ""
+			continue
+		}
+		if !strings.HasPrefix(line, "protoc ") && !strings.HasPrefix(line, "sponge ") {
+			continue
+		}
+		args := parseShellArgs(line)
+		if len(args) == 0 || (args[0] == "sponge" && len(args) > 2 && args[2] == "adapt-mono-repo") {
+			continue // adapt mono repo is executed by the task runner
+		}
+
+		switch funcName {
+		case "generateByAllProto":
+			s.allProtoCmds = append(s.allProtoCmds, args)
+		case "generateBySpecifiedProto":
+			s.specifiedProtoCmds = append(s.specifiedProtoCmds, args)
+		case "":
+			s.finalCmds = append(s.finalCmds, args)
+		}
+	}
+	return s
+}
+
+// split the command line into arguments, the output redirection is removed
+func parseShellArgs(line string) []string {
+	var args []string
+	for _, field := range strings.Fields(line) {
+		if field == ">" || strings.HasPrefix(field, ">") || strings.HasPrefix(field, "2>") {
+			break
+		}
+		args = append(args, strings.Trim(field, `"'`))
+	}
+	return args
+}
+
+// replace the variables of script, $allProtoFiles and $specifiedProtoFiles are expanded to multiple arguments
+func (t *taskRunner) expandArgs(args []string, protoFiles []string) []string {
+	replacer := strings.NewReplacer(
+		"${moduleName}", t.moduleName, "$moduleName", t.moduleName,
+		"${serverName}", t.serverName, "$serverName", t.serverName,
+		"${suitedMonoRepo}", fmt.Sprint(t.suitedMonoRepo), "$suitedMonoRepo", fmt.Sprint(t.suitedMonoRepo),
+		"${protoBasePath}", protoBasePath, "$protoBasePath", protoBasePath,
+	)
+	var newArgs []string
+	for _, arg := range args {
+		if arg == "$allProtoFiles" || arg == "$specifiedProtoFiles" {
+			newArgs = append(newArgs, protoFiles...)
+			continue
+		}
+		newArgs = append(newArgs, replacer.Replace(arg))
+	}
+	return newArgs
+}
+
+func (t *taskRunner) execScriptCmd(ctx context.Context, dir string, args []string, protoFiles []string) error {
+	args = t.expandArgs(args, protoFiles)
+	if args[0] == "sponge" {
+		// the output of sponge web swagger is not needed
+		return t.sponge(ctx, dir, len(args) > 1 && args[1] == "web", args[1:]...)
+	}
+	return t.exec(ctx, dir, args[0], args[1:]...)
+}
+
+// list the proto files in the directory, the paths are relative to workDir and separated by /
+func listProtoFiles(workDir string, dir string) []string {
+	var files []string
+	_ = filepath.Walk(filepath.Join(workDir, dir), func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || filepath.Ext(path) != ".proto" {
+			return nil
+		}
+		if rel, e := filepath.Rel(workDir, path); e == nil {
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	return files
+}
+
+func protoTarget(ctx context.Context, t *taskRunner) error {
+	data, err := os.ReadFile(filepath.Join(t.dir, "scripts", "protoc.sh"))
+	if err != nil {
+		return fmt.Errorf("not found file scripts/protoc.sh, the target proto only supports the service created based on protobuf")
+	}
+	script := parseProtocScript(data)
+	if err = t.ensureTools(ctx, append([]string{"go", "gofmt", "protoc"}, script.plugins()...)...); err != nil {
+		return err
+	}
+
+	// the commands of mono repo are executed in the parent directory
+	workDir := t.dir
+	if t.suitedMonoRepo {
+		if err = t.patchMonoRepo(ctx); err != nil {
+			return err
+		}
+		workDir = filepath.Dir(t.dir)
+	}
+
+	allProtoFiles := listProtoFiles(workDir, protoBasePath)
+	serverProtoFiles := listProtoFiles(workDir, protoBasePath+"/"+t.serverName)
+	specifiedProtoFiles := serverProtoFiles
+	if t.opts.protoFiles != "" {
+		allProtoFiles, specifiedProtoFiles = nil, nil
+		for _, file := range strings.Split(t.opts.protoFiles, ",") {
+			file = filepath.ToSlash(strings.TrimSpace(file))
+			if _, err = os.Stat(filepath.Join(workDir, file)); err != nil {
+				return fmt.Errorf("not found specified proto file %s, e.g. sponge run proto -f api/user/v1/user.proto,api/types/types.proto", file)
+			}
+			allProtoFiles = append(allProtoFiles, file)
+			if inStrings(serverProtoFiles, file) {
+				specifiedProtoFiles = append(specifiedProtoFiles, file)
+			}
+		}
+	}
+	if len(allProtoFiles) == 0 {
+		return fmt.Errorf("not found proto file in path %s", protoBasePath)
+	}
+
+	// generate the code of types.proto if it is imported, and the code of initializing database
+	if !inStrings(allProtoFiles, typesProtoFile) {
+		for _, file := range allProtoFiles {
+			content, _ := os.ReadFile(filepath.Join(workDir, file))
+			if bytes.Contains(content, []byte(typesProtoFile)) {
+				allProtoFiles = append(allProtoFiles, typesProtoFile)
+				_ = t.sponge(ctx, workDir, true, "patch", "gen-types-pb", "--out=.")
+				break
+			}
+		}
+	}
+	_ = t.sponge(ctx, workDir, true, "patch", "gen-db-init", "--out=.")
+
+	fmt.Printf("generate *.pb.go from .proto files: %s\n\n", color.HiBlackString(strings.Join(allProtoFiles, " ")))
+	for _, args := range script.allProtoCmds {
+		if err = t.execScriptCmd(ctx, workDir, args, allProtoFiles); err != nil {
+			return err
+		}
+	}
+
+	if len(specifiedProtoFiles) > 0 {
+		fmt.Printf("\ngenerate glue code from .proto files: %s\n\n", color.HiMagentaString(strings.Join(specifiedProtoFiles, " ")))
+		for _, args := range script.specifiedProtoCmds {
+			if err = t.execScriptCmd(ctx, workDir, args, specifiedProtoFiles); err != nil {
+				return err
+			}
+		}
+		if t.suitedMonoRepo {
+			if err = t.sponge(ctx, workDir, false, "patch", "adapt-mono-repo", "--dir="+t.serverName); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err = deleteUnusedPbImports(filepath.Join(workDir, protoBasePath)); err != nil {
+		return err
+	}
+	for _, args := range script.finalCmds {
+		if err = t.execScriptCmd(ctx, workDir, args, nil); err != nil {
+			return err
+		}
+	}
+	if err = t.goModTidyAndFmt(ctx, t.dir); err != nil {
+		return err
+	}
+
+	fmt.Println(color.HiGreenString("\ngenerated code done."))
+	fmt.Printf("\nTip: execute the command %s to start the service.\n\n", color.HiCyanString("sponge run run"))
+	return nil
+}
+
+// the same as scripts/patch-mono.sh, the go.mod and third_party of mono repo are in the parent directory
+func (t *taskRunner) patchMonoRepo(ctx context.Context) error {
+	parentDir := filepath.Dir(t.dir)
+	moves := []struct {
+		name string
+		args []string
+	}{
+		{"go.mod", []string{"patch", "copy-go-mod", "-f"}},
+		{"third_party", []string{"patch", "copy-third-party-proto"}},
+	}
+	for _, m := range moves {
+		if _, err := os.Stat(filepath.Join(parentDir, m.name)); err == nil {
+			continue
+		}
+		if err := t.sponge(ctx, t.dir, false, m.args...); err != nil {
+			return err
+		}
+		names := []string{m.name}
+		if m.name == "go.mod" {
+			names = append(names, "go.sum")
+		}
+		for _, name := range names {
+			if err := os.Rename(filepath.Join(t.dir, name), filepath.Join(parentDir, name)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func deleteUnusedPbImports(dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.HasSuffix(path, ".pb.go") {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		newData := data
+		for _, v := range unusedPbImports {
+			newData = bytes.ReplaceAll(newData, v, nil)
+		}
+		if len(newData) == len(data) {
+			return nil
+		}
+		return os.WriteFile(path, newData, info.Mode())
+	})
+}
+
+func inStrings(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/go-dev-frame/sponge/pkg/gin/response"
 	"github.com/go-dev-frame/sponge/pkg/gobash"
 	"github.com/go-dev-frame/sponge/pkg/gofile"
+	"github.com/go-dev-frame/sponge/pkg/jy2struct"
 	"github.com/go-dev-frame/sponge/pkg/krand"
 	"github.com/go-dev-frame/sponge/pkg/mgo"
 	"github.com/go-dev-frame/sponge/pkg/process"
@@ -29,6 +30,8 @@ import (
 	"github.com/go-dev-frame/sponge/pkg/sgorm/mysql"
 	"github.com/go-dev-frame/sponge/pkg/sgorm/postgresql"
 	"github.com/go-dev-frame/sponge/pkg/sgorm/sqlite"
+	"github.com/go-dev-frame/sponge/pkg/sql2code"
+	"github.com/go-dev-frame/sponge/pkg/struct2dto"
 	"github.com/go-dev-frame/sponge/pkg/utils"
 )
 
@@ -47,18 +50,19 @@ type kv struct {
 	Value string `json:"value"`
 }
 
+// DBDrivers the database drivers supported by code generation
+var DBDrivers = []string{
+	sgorm.DBDriverMysql,
+	mgo.DBDriverName,
+	sgorm.DBDriverPostgresql,
+	sgorm.DBDriverTidb,
+	sgorm.DBDriverSqlite,
+}
+
 // ListDbDrivers list db drivers
 func ListDbDrivers(c *gin.Context) {
-	dbDrivers := []string{
-		sgorm.DBDriverMysql,
-		mgo.DBDriverName,
-		sgorm.DBDriverPostgresql,
-		sgorm.DBDriverTidb,
-		sgorm.DBDriverSqlite,
-	}
-
 	data := []kv{}
-	for _, driver := range dbDrivers {
+	for _, driver := range DBDrivers {
 		data = append(data, kv{
 			Label: driver,
 			Value: driver,
@@ -121,16 +125,8 @@ func ListTables(c *gin.Context) {
 		return
 	}
 
-	var tables []string
 	switch strings.ToLower(form.DbDriver) {
-	case sgorm.DBDriverMysql, sgorm.DBDriverTidb:
-		tables, err = getMysqlTables(form.Dsn)
-	case sgorm.DBDriverPostgresql:
-		tables, err = getPostgresqlTables(form.Dsn)
-	case sgorm.DBDriverSqlite:
-		tables, err = getSqliteTables(form.Dsn)
-	case mgo.DBDriverName:
-		tables, err = getMongodbTables(form.Dsn)
+	case sgorm.DBDriverMysql, sgorm.DBDriverTidb, sgorm.DBDriverPostgresql, sgorm.DBDriverSqlite, mgo.DBDriverName:
 	case "":
 		response.Error(c, errcode.InvalidParams.RewriteMsg("database type cannot be empty"))
 		return
@@ -138,6 +134,8 @@ func ListTables(c *gin.Context) {
 		response.Error(c, errcode.InvalidParams.RewriteMsg("unsupported database type: "+form.DbDriver))
 		return
 	}
+
+	tables, err := GetTableNames(form.DbDriver, form.Dsn)
 	if err != nil {
 		response.Error(c, errcode.InternalServerError.RewriteMsg(err.Error()))
 		return
@@ -154,6 +152,68 @@ func ListTables(c *gin.Context) {
 	response.Success(c, data)
 }
 
+// GetTableNames get the table names (collection names of mongodb) of database
+func GetTableNames(dbDriver string, dsn string) ([]string, error) {
+	switch strings.ToLower(dbDriver) {
+	case sgorm.DBDriverMysql, sgorm.DBDriverTidb:
+		return getMysqlTables(dsn)
+	case sgorm.DBDriverPostgresql:
+		return getPostgresqlTables(dsn)
+	case sgorm.DBDriverSqlite:
+		return getSqliteTables(dsn)
+	case mgo.DBDriverName:
+		return getMongodbTables(dsn)
+	}
+	return nil, errors.New("unsupported database type: " + dbDriver)
+}
+
+type convertDTOForm struct {
+	Format   string `json:"format" binding:"required"`   // format of content, json, yaml, sql or go
+	Content  string `json:"content" binding:"required"`  // json, yaml, DDL sql or go struct code
+	Language string `json:"language" binding:"required"` // typescript, swift or kotlin
+	Name     string `json:"name"`                        // name of structure, only for json and yaml
+}
+
+// ConvertDTO convert json, yaml, sql or go struct to the DTO code of front-end language
+func ConvertDTO(c *gin.Context) {
+	form := &convertDTOForm{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		response.Error(c, errcode.InvalidParams.RewriteMsg(err.Error()))
+		return
+	}
+
+	var code string
+	switch strings.ToLower(form.Format) {
+	case "json", "yaml":
+		code, err = jy2struct.Convert(&jy2struct.Args{
+			Format:    strings.ToLower(form.Format),
+			Data:      form.Content,
+			Name:      form.Name,
+			SubStruct: true,
+			Language:  form.Language,
+		})
+	case "sql":
+		code, err = sql2code.GenerateOne(&sql2code.Args{
+			SQL:           form.Content,
+			JSONTag:       true,
+			JSONNamedType: 1,
+			CodeType:      form.Language,
+		})
+	case "go":
+		code, err = struct2dto.Convert(form.Content, form.Language)
+	default:
+		response.Error(c, errcode.InvalidParams.RewriteMsg("unsupported format: "+form.Format))
+		return
+	}
+	if err != nil {
+		response.Error(c, errcode.InvalidParams.RewriteMsg(err.Error()))
+		return
+	}
+
+	response.Success(c, code)
+}
+
 // GenerateCodeForm generate code form
 type GenerateCodeForm struct {
 	Arg  string `json:"arg" binding:"required"`
@@ -54,6 +54,16 @@ func NewRouter(spongeAddr string, isLog bool) *gin.Engine {
 		})
 	}
 
+	// the service topology view of the workspace
+	r.GET("/topology", func(c *gin.Context) {
+		data, err := staticFS.ReadFile("static/topology.html")
+		if err != nil {
+			c.String(http.StatusNotFound, err.Error())
+			return
+		}
+		c.Data(http.StatusOK, "text/html; charset=utf-8", data)
+	})
+
 	apiV1 := r.Group("/api/v1")
 	apiV1.POST("/generate", GenerateCode)
 	apiV1.POST("/getTemplateInfo", GetTemplateInfo)
@@ -62,9 +72,11 @@ func NewRouter(spongeAddr string, isLog bool) *gin.Engine {
 	apiV1.POST("/performanceTest/stop", HandleStopPerformanceTest)
 	apiV1.POST("/uploadFiles", UploadFiles)
 	apiV1.POST("/listTables", ListTables)
+	apiV1.POST("/convertDTO", ConvertDTO)
 	apiV1.GET("/listDrivers", ListDbDrivers)
 	apiV1.GET("/listLLM", ListLLM)
 	apiV1.GET("/record/:path", GetRecord)
+	apiV1.POST("/topology", GetTopology)
 
 	return r
 }
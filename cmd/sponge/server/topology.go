@@ -0,0 +1,346 @@
+package server
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/go-dev-frame/sponge/pkg/errcode"
+	"github.com/go-dev-frame/sponge/pkg/gin/response"
+)
+
+const (
+	edgeTypeRPC   = "rpc"   // call the grpc service by the rpc client
+	edgeTypeProto = "proto" // import or copy the proto files of the grpc service
+
+	formatJSON    = "json"
+	formatMermaid = "mermaid"
+)
+
+var (
+	rpcConnServerNameRegexp = regexp.MustCompile(`serverName\s*:?=\s*"([\w-]+)"`)
+	rpcConnFuncRegexp       = regexp.MustCompile(`func\s+Get(\w+)RPCConn\(`)
+	rpcClientUsageRegexp    = regexp.MustCompile(`New(\w+)Client\(\s*rpcclient\.Get(\w+)RPCConn\(\)\s*\)`)
+	protoServiceRegexp      = regexp.MustCompile(`(?m)^\s*service\s+(\w+)\s*\{`)
+	protoImportRegexp       = regexp.MustCompile(`(?m)^\s*import\s+(?:public\s+|weak\s+)?"api/([\w-]+)/`)
+
+	skipScanDirs = map[string]bool{"node_modules": true, "vendor": true, "third_party": true}
+)
+
+type topologyForm struct {
+	Dir    string `json:"dir" binding:"required"` // workspace directory, e.g. mono-repo directory
+	Format string `json:"format"`                 // json or mermaid, default is json
+}
+
+// TopologyNode is a service in the workspace, the external service is called but not found in the workspace.
+type TopologyNode struct {
+	Name          string   `json:"name"`
+	Dir           string   `json:"dir"`
+	ModuleName    string   `json:"moduleName"`
+	ProtoServices []string `json:"protoServices"`
+	IsExternal    bool     `json:"isExternal"`
+}
+
+// TopologyEdge is the dependency from one service to another service.
+type TopologyEdge struct {
+	From     string   `json:"from"`
+	To       string   `json:"to"`
+	Type     string   `json:"type"`     // rpc or proto
+	Services []string `json:"services"` // the called grpc services of edge type rpc
+}
+
+// Topology is the dependency graph of services in the workspace.
+type Topology struct {
+	Dir   string          `json:"dir"`
+	Nodes []*TopologyNode `json:"nodes"`
+	Edges []*TopologyEdge `json:"edges"`
+}
+
+// GetTopology scan the services generated by sponge in the workspace, and get the dependency graph of the services
+func GetTopology(c *gin.Context) {
+	form := &topologyForm{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		response.Error(c, errcode.InvalidParams.RewriteMsg(err.Error()))
+		return
+	}
+	if form.Format == "" {
+		form.Format = formatJSON
+	}
+	if form.Format != formatJSON && form.Format != formatMermaid {
+		response.Error(c, errcode.InvalidParams.RewriteMsg("unsupported format: "+form.Format+", only json and mermaid are supported"))
+		return
+	}
+
+	topology, err := scanTopology(form.Dir)
+	if err != nil {
+		responseErr(c, err, errcode.InvalidParams)
+		return
+	}
+
+	if form.Format == formatMermaid {
+		response.Success(c, gin.H{"mermaid": topology.toMermaid()})
+		return
+	}
+	response.Success(c, topology)
+}
+
+// serviceInfo is the information of service parsed from the service directory
+type serviceInfo struct {
+	node       *TopologyNode
+	rpcConns   map[string]string   // rpc connection name --> server name, e.g. User --> user
+	rpcCalls   map[string][]string // rpc connection name --> grpc services
+	protoDeps  map[string]bool     // the server names of the imported or copied proto files
+	ownAPIDirs map[string]bool
+}
+
+func scanTopology(dir string) (*Topology, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if stat, err := os.Stat(dir); err != nil || !stat.IsDir() {
+		return nil, fmt.Errorf("workspace directory %s does not exist", dir)
+	}
+
+	var services []*serviceInfo
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		if path != dir && (strings.HasPrefix(d.Name(), ".") || skipScanDirs[d.Name()]) {
+			return filepath.SkipDir
+		}
+		data, err := os.ReadFile(filepath.Join(path, "docs", "gen.info"))
+		if err != nil {
+			return nil //nolint
+		}
+		service, err := parseService(dir, path, string(data))
+		if err != nil {
+			return err
+		}
+		if service != nil {
+			services = append(services, service)
+		}
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(services) == 0 {
+		return nil, fmt.Errorf("not found services generated by sponge in the directory %s", dir)
+	}
+
+	return buildTopology(dir, services), nil
+}
+
+// parse the service directory, the content of gen.info is moduleName,serverName[,suitedMonoRepo]
+func parseService(workspace string, dir string, genInfo string) (*serviceInfo, error) {
+	ss := strings.Split(strings.TrimSpace(genInfo), ",")
+	if len(ss) < 2 || ss[1] == "" {
+		return nil, nil
+	}
+	relDir, _ := filepath.Rel(workspace, dir)
+	service := &serviceInfo{
+		node: &TopologyNode{
+			Name:          ss[1],
+			Dir:           filepath.ToSlash(relDir),
+			ModuleName:    ss[0],
+			ProtoServices: []string{},
+		},
+		rpcConns:   map[string]string{},
+		rpcCalls:   map[string][]string{},
+		protoDeps:  map[string]bool{},
+		ownAPIDirs: map[string]bool{ss[1]: true, "types": true},
+	}
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && (strings.HasPrefix(d.Name(), ".") || skipScanDirs[d.Name()]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		name := d.Name()
+		switch {
+		case strings.HasSuffix(name, ".proto"):
+			return service.parseProtoFile(dir, path)
+		case strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go") && !strings.HasSuffix(name, ".pb.go"):
+			return service.parseGoFile(path)
+		}
+		return nil
+	})
+	return service, err
+}
+
+func (s *serviceInfo) parseProtoFile(serviceDir string, file string) error {
+	relPath, _ := filepath.Rel(serviceDir, file)
+	ss := strings.Split(filepath.ToSlash(relPath), "/")
+	if len(ss) < 3 || ss[0] != "api" {
+		return nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	// the proto files of other services are copied to the api directory for calling them
+	if !s.ownAPIDirs[ss[1]] {
+		s.protoDeps[ss[1]] = true
+		return nil
+	}
+	if ss[1] == s.node.Name {
+		for _, match := range protoServiceRegexp.FindAllSubmatch(data, -1) {
+			s.node.ProtoServices = append(s.node.ProtoServices, string(match[1]))
+		}
+	}
+	for _, match := range protoImportRegexp.FindAllSubmatch(data, -1) {
+		if name := string(match[1]); !s.ownAPIDirs[name] {
+			s.protoDeps[name] = true
+		}
+	}
+	return nil
+}
+
+func (s *serviceInfo) parseGoFile(file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	content := removeCommentLines(string(data))
+
+	// the rpc connection code generated in the directory internal/rpcclient
+	if filepath.Base(filepath.Dir(file)) == "rpcclient" {
+		funcMatch := rpcConnFuncRegexp.FindStringSubmatch(content)
+		nameMatch := rpcConnServerNameRegexp.FindStringSubmatch(content)
+		if len(funcMatch) == 2 && len(nameMatch) == 2 {
+			s.rpcConns[funcMatch[1]] = nameMatch[1]
+		}
+		return nil
+	}
+
+	for _, match := range rpcClientUsageRegexp.FindAllStringSubmatch(content, -1) {
+		s.rpcCalls[match[2]] = appendUnique(s.rpcCalls[match[2]], match[1])
+	}
+	return nil
+}
+
+func removeCommentLines(content string) string {
+	lines := strings.Split(content, "\n")
+	codeLines := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if !strings.HasPrefix(strings.TrimSpace(line), "//") {
+			codeLines = append(codeLines, line)
+		}
+	}
+	return strings.Join(codeLines, "\n")
+}
+
+func appendUnique(ss []string, s string) []string {
+	for _, v := range ss {
+		if v == s {
+			return ss
+		}
+	}
+	return append(ss, s)
+}
+
+func buildTopology(dir string, services []*serviceInfo) *Topology {
+	topology := &Topology{Dir: dir}
+	nodes := map[string]*TopologyNode{}
+	for _, service := range services {
+		if _, ok := nodes[service.node.Name]; ok {
+			continue
+		}
+		sort.Strings(service.node.ProtoServices)
+		nodes[service.node.Name] = service.node
+		topology.Nodes = append(topology.Nodes, service.node)
+	}
+	addNode := func(name string) {
+		if _, ok := nodes[name]; !ok {
+			node := &TopologyNode{Name: name, ProtoServices: []string{}, IsExternal: true}
+			nodes[name] = node
+			topology.Nodes = append(topology.Nodes, node)
+		}
+	}
+
+	edges := map[string]*TopologyEdge{}
+	addEdge := func(from string, to string, edgeType string, calledServices []string) {
+		key := from + "->" + to + ":" + edgeType
+		edge, ok := edges[key]
+		if !ok {
+			edge = &TopologyEdge{From: from, To: to, Type: edgeType, Services: []string{}}
+			edges[key] = edge
+			topology.Edges = append(topology.Edges, edge)
+		}
+		for _, s := range calledServices {
+			edge.Services = appendUnique(edge.Services, s)
+		}
+		sort.Strings(edge.Services)
+	}
+
+	for _, service := range services {
+		from := service.node.Name
+		for connName, serverName := range service.rpcConns {
+			addNode(serverName)
+			addEdge(from, serverName, edgeTypeRPC, service.rpcCalls[connName])
+		}
+		for serverName := range service.protoDeps {
+			addNode(serverName)
+			addEdge(from, serverName, edgeTypeProto, nil)
+		}
+	}
+
+	sort.Slice(topology.Nodes, func(i, j int) bool {
+		return topology.Nodes[i].Name < topology.Nodes[j].Name
+	})
+	sort.Slice(topology.Edges, func(i, j int) bool {
+		ei, ej := topology.Edges[i], topology.Edges[j]
+		if ei.From != ej.From {
+			return ei.From < ej.From
+		}
+		if ei.To != ej.To {
+			return ei.To < ej.To
+		}
+		return ei.Type > ej.Type
+	})
+	return topology
+}
+
+// toMermaid convert the topology to mermaid flowchart, the rpc calls are solid lines,
+// the proto dependencies are dotted lines, and the external services are dashed boxes.
+func (t *Topology) toMermaid() string {
+	ids := map[string]string{}
+	buf := &strings.Builder{}
+	buf.WriteString("flowchart LR\n")
+	for i, node := range t.Nodes {
+		id := fmt.Sprintf("s%d", i)
+		ids[node.Name] = id
+		fmt.Fprintf(buf, "    %s[\"%s\"]\n", id, node.Name)
+		if node.IsExternal {
+			fmt.Fprintf(buf, "    style %s stroke-dasharray: 5 5\n", id)
+		}
+	}
+	for _, edge := range t.Edges {
+		arrow := "-->"
+		if edge.Type == edgeTypeProto {
+			arrow = "-.->"
+		}
+		label := edge.Type
+		if len(edge.Services) > 0 {
+			label += ": " + strings.Join(edge.Services, ", ")
+		}
+		fmt.Fprintf(buf, "    %s %s|\"%s\"| %s\n", ids[edge.From], arrow, label, ids[edge.To])
+	}
+	return buf.String()
+}
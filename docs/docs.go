@@ -366,6 +366,13 @@ const docTemplate = `{
         "handlerfunc.CheckHealthReply": {
             "type": "object",
             "properties": {
+                "dependencies": {
+                    "description": "listed in verbose mode of CheckHealthWithProbes",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlerfunc.DependencyStatus"
+                    }
+                },
                 "hostname": {
                     "type": "string"
                 },
@@ -374,6 +381,28 @@ const docTemplate = `{
                 }
             }
         },
+        "handlerfunc.DependencyStatus": {
+            "type": "object",
+            "properties": {
+                "checkedAt": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "latency": {
+                    "description": "e.g. 1.2ms",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "UP or DOWN",
+                    "type": "string"
+                }
+            }
+        },
         "handlerfunc.PingReply": {
             "type": "object"
         },
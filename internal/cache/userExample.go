@@ -19,6 +19,9 @@ const (
 	userExampleCachePrefixKey = "userExample:"
 	// UserExampleExpireTime expire time
 	UserExampleExpireTime = 5 * time.Minute
+	// UserExampleStaleTime the expired value is kept in cache for the stale time, it is still returned by GetWithStale
+	// while the value is refreshed in background, set it to 0 to disable serving stale value
+	UserExampleStaleTime = time.Minute
 )
 
 var _ UserExampleCache = (*userExampleCache)(nil)
@@ -27,6 +30,7 @@ var _ UserExampleCache = (*userExampleCache)(nil)
 type UserExampleCache interface {
 	Set(ctx context.Context, id uint64, data *model.UserExample, duration time.Duration) error
 	Get(ctx context.Context, id uint64) (*model.UserExample, error)
+	GetWithStale(ctx context.Context, id uint64) (*model.UserExample, bool, error)
 	MultiGet(ctx context.Context, ids []uint64) (map[uint64]*model.UserExample, error)
 	MultiSet(ctx context.Context, data []*model.UserExample, duration time.Duration) error
 	Del(ctx context.Context, id uint64) error
@@ -72,7 +76,7 @@ func (c *userExampleCache) Set(ctx context.Context, id uint64, data *model.UserE
 		return nil
 	}
 	cacheKey := c.GetUserExampleCacheKey(id)
-	err := c.cache.Set(ctx, cacheKey, data, duration)
+	err := c.cache.Set(ctx, cacheKey, data, c.withStaleTime(duration))
 	if err != nil {
 		return err
 	}
@@ -90,6 +94,19 @@ func (c *userExampleCache) Get(ctx context.Context, id uint64) (*model.UserExamp
 	return data, nil
 }
 
+// GetWithStale get cache value, isStale is true if the value has expired and is within the stale time,
+// the caller can return the stale value and refresh the cache in background.
+func (c *userExampleCache) GetWithStale(ctx context.Context, id uint64) (*model.UserExample, bool, error) {
+	var data *model.UserExample
+	cacheKey := c.GetUserExampleCacheKey(id)
+	ttl, err := c.cache.GetWithTTL(ctx, cacheKey, &data)
+	if err != nil {
+		return nil, false, err
+	}
+	isStale := UserExampleStaleTime > 0 && ttl >= 0 && ttl <= UserExampleStaleTime
+	return data, isStale, nil
+}
+
 // MultiSet multiple set cache
 func (c *userExampleCache) MultiSet(ctx context.Context, data []*model.UserExample, duration time.Duration) error {
 	valMap := make(map[string]interface{})
@@ -98,7 +115,7 @@ func (c *userExampleCache) MultiSet(ctx context.Context, data []*model.UserExamp
 		valMap[cacheKey] = v
 	}
 
-	err := c.cache.MultiSet(ctx, valMap, duration)
+	err := c.cache.MultiSet(ctx, valMap, c.withStaleTime(duration))
 	if err != nil {
 		return err
 	}
@@ -151,3 +168,11 @@ func (c *userExampleCache) SetPlaceholder(ctx context.Context, id uint64) error
 func (c *userExampleCache) IsPlaceholderErr(err error) bool {
 	return errors.Is(err, cache.ErrPlaceholder)
 }
+
+// the value is expired after duration, and it is kept in cache for the stale time after expiration
+func (c *userExampleCache) withStaleTime(duration time.Duration) time.Duration {
+	if duration > 0 && UserExampleStaleTime > 0 {
+		return duration + UserExampleStaleTime
+	}
+	return duration
+}
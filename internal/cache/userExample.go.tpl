@@ -19,6 +19,9 @@ const (
 	{{.TableNameCamelFCL}}CachePrefixKey = "{{.TableNameCamelFCL}}:"
 	// {{.TableNameCamel}}ExpireTime expire time
 	{{.TableNameCamel}}ExpireTime = 5 * time.Minute
+	// {{.TableNameCamel}}StaleTime the expired value is kept in cache for the stale time, it is still returned by GetWithStale
+	// while the value is refreshed in background, set it to 0 to disable serving stale value
+	{{.TableNameCamel}}StaleTime = time.Minute
 )
 
 var _ {{.TableNameCamel}}Cache = (*{{.TableNameCamelFCL}}Cache)(nil)
@@ -27,6 +30,7 @@ var _ {{.TableNameCamel}}Cache = (*{{.TableNameCamelFCL}}Cache)(nil)
 type {{.TableNameCamel}}Cache interface {
 	Set(ctx context.Context, {{.ColumnNameCamelFCL}} {{.GoType}}, data *model.{{.TableNameCamel}}, duration time.Duration) error
 	Get(ctx context.Context, {{.ColumnNameCamelFCL}} {{.GoType}}) (*model.{{.TableNameCamel}}, error)
+	GetWithStale(ctx context.Context, {{.ColumnNameCamelFCL}} {{.GoType}}) (*model.{{.TableNameCamel}}, bool, error)
 	MultiGet(ctx context.Context, {{.ColumnNamePluralCamelFCL}} []{{.GoType}}) (map[{{.GoType}}]*model.{{.TableNameCamel}}, error)
 	MultiSet(ctx context.Context, data []*model.{{.TableNameCamel}}, duration time.Duration) error
 	Del(ctx context.Context, {{.ColumnNameCamelFCL}} {{.GoType}}) error
@@ -72,7 +76,7 @@ func (c *{{.TableNameCamelFCL}}Cache) Set(ctx context.Context, {{.ColumnNameCame
 		return nil
 	}
 	cacheKey := c.Get{{.TableNameCamel}}CacheKey({{.ColumnNameCamelFCL}})
-	err := c.cache.Set(ctx, cacheKey, data, duration)
+	err := c.cache.Set(ctx, cacheKey, data, c.withStaleTime(duration))
 	if err != nil {
 		return err
 	}
@@ -90,6 +94,19 @@ func (c *{{.TableNameCamelFCL}}Cache) Get(ctx context.Context, {{.ColumnNameCame
 	return data, nil
 }
 
+// GetWithStale get cache value, isStale is true if the value has expired and is within the stale time,
+// the caller can return the stale value and refresh the cache in background.
+func (c *{{.TableNameCamelFCL}}Cache) GetWithStale(ctx context.Context, {{.ColumnNameCamelFCL}} {{.GoType}}) (*model.{{.TableNameCamel}}, bool, error) {
+	var data *model.{{.TableNameCamel}}
+	cacheKey := c.Get{{.TableNameCamel}}CacheKey({{.ColumnNameCamelFCL}})
+	ttl, err := c.cache.GetWithTTL(ctx, cacheKey, &data)
+	if err != nil {
+		return nil, false, err
+	}
+	isStale := {{.TableNameCamel}}StaleTime > 0 && ttl >= 0 && ttl <= {{.TableNameCamel}}StaleTime
+	return data, isStale, nil
+}
+
 // MultiSet multiple set cache
 func (c *{{.TableNameCamelFCL}}Cache) MultiSet(ctx context.Context, data []*model.{{.TableNameCamel}}, duration time.Duration) error {
 	valMap := make(map[string]interface{})
@@ -98,7 +115,7 @@ func (c *{{.TableNameCamelFCL}}Cache) MultiSet(ctx context.Context, data []*mode
 		valMap[cacheKey] = v
 	}
 
-	err := c.cache.MultiSet(ctx, valMap, duration)
+	err := c.cache.MultiSet(ctx, valMap, c.withStaleTime(duration))
 	if err != nil {
 		return err
 	}
@@ -151,3 +168,11 @@ func (c *{{.TableNameCamelFCL}}Cache) SetPlaceholder(ctx context.Context, {{.Col
 func (c *{{.TableNameCamelFCL}}Cache) IsPlaceholderErr(err error) bool {
 	return errors.Is(err, cache.ErrPlaceholder)
 }
+
+// the value is expired after duration, and it is kept in cache for the stale time after expiration
+func (c *{{.TableNameCamelFCL}}Cache) withStaleTime(duration time.Duration) time.Duration {
+	if duration > 0 && {{.TableNameCamel}}StaleTime > 0 {
+		return duration + {{.TableNameCamel}}StaleTime
+	}
+	return duration
+}
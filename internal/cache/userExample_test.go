@@ -67,6 +67,38 @@ func Test_userExampleCache_Get(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func Test_userExampleCache_GetWithStale(t *testing.T) {
+	c := newUserExampleCache()
+	defer c.Close()
+
+	record := c.TestDataSlice[0].(*model.UserExample)
+	err := c.ICache.(UserExampleCache).Set(c.Ctx, record.ID, record, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, isStale, err := c.ICache.(UserExampleCache).GetWithStale(c.Ctx, record.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, record, got)
+	assert.False(t, isStale)
+
+	// expired, within the stale time
+	cacheKey := c.ICache.(*userExampleCache).GetUserExampleCacheKey(record.ID)
+	c.RedisClient.Expire(c.Ctx, cacheKey, UserExampleStaleTime/2)
+	got, isStale, err = c.ICache.(UserExampleCache).GetWithStale(c.Ctx, record.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, record, got)
+	assert.True(t, isStale)
+
+	// zero key error
+	_, _, err = c.ICache.(UserExampleCache).GetWithStale(c.Ctx, 0)
+	assert.Error(t, err)
+}
+
 func Test_userExampleCache_MultiGet(t *testing.T) {
 	c := newUserExampleCache()
 	defer c.Close()
@@ -3,14 +3,26 @@
 package config
 
 import (
+	"flag"
+	"os"
+
 	"github.com/go-dev-frame/sponge/pkg/conf"
 )
 
 var config *Config
 
+// Init loads the configuration file, values can be overridden by environment variables
+// (e.g. SPONGE_HTTP_PORT), a command line flag overrides the value of the same path only if
+// it is defined before flag.Parse (e.g. flag.Int("http.port", 8080, "")), flags are not
+// registered for the configuration paths, the effective values are printed at startup.
 func Init(configFile string, fs ...func()) error {
 	config = &Config{}
-	return conf.Parse(configFile, config, fs...)
+	return conf.Load(config,
+		conf.WithConfigFile(configFile),
+		conf.WithFlags(flag.CommandLine),
+		conf.WithWatch(fs...),
+		conf.WithDiagnostics(os.Stdout),
+	)
 }
 
 func Show(hiddenFields ...string) string {
@@ -31,6 +43,7 @@ func Set(conf *Config) {
 type Config struct {
 	App        App          `yaml:"app" json:"app"`
 	Consul     Consul       `yaml:"consul" json:"consul"`
+	Cron       Cron         `yaml:"cron" json:"cron"`
 	Database   Database     `yaml:"database" json:"database"`
 	Etcd       Etcd         `yaml:"etcd" json:"etcd"`
 	Grpc       Grpc         `yaml:"grpc" json:"grpc"`
@@ -40,12 +53,28 @@ type Config struct {
 	Logger     Logger       `yaml:"logger" json:"logger"`
 	NacosRd    NacosRd      `yaml:"nacosRd" json:"nacosRd"`
 	Redis      Redis        `yaml:"redis" json:"redis"`
+	Tracer     Tracer       `yaml:"tracer" json:"tracer"`
+	Worker     Worker       `yaml:"worker" json:"worker"`
 }
 
 type Consul struct {
 	Addr string `yaml:"addr" json:"addr"`
 }
 
+type Cron struct {
+	AdminToken      string    `yaml:"adminToken" json:"adminToken"`
+	HTTPPort        int       `yaml:"httpPort" json:"httpPort"`
+	Jobs            []CronJob `yaml:"jobs" json:"jobs"`
+	ShutdownTimeout int       `yaml:"shutdownTimeout" json:"shutdownTimeout"`
+}
+
+type CronJob struct {
+	Name     string `yaml:"name" json:"name"`
+	Paused   bool   `yaml:"paused" json:"paused"`
+	Schedule string `yaml:"schedule" json:"schedule"`
+	Timeout  int    `yaml:"timeout" json:"timeout"`
+}
+
 type Etcd struct {
 	Addrs []string `yaml:"addrs" json:"addrs"`
 }
@@ -55,6 +84,26 @@ type Jaeger struct {
 	AgentPort int    `yaml:"agentPort" json:"agentPort"`
 }
 
+type Otlp struct {
+	CaFile   string            `yaml:"caFile" json:"caFile"`
+	Endpoint string            `yaml:"endpoint" json:"endpoint"`
+	Headers  map[string]string `yaml:"headers" json:"headers"`
+	Insecure bool              `yaml:"insecure" json:"insecure"`
+	Protocol string            `yaml:"protocol" json:"protocol"`
+}
+
+type Sampling struct {
+	KeepErrors      bool               `yaml:"keepErrors" json:"keepErrors"`
+	RouteRateLimits map[string]float64 `yaml:"routeRateLimits" json:"routeRateLimits"`
+	SlowThreshold   int                `yaml:"slowThreshold" json:"slowThreshold"`
+}
+
+type Tracer struct {
+	Exporter string   `yaml:"exporter" json:"exporter"`
+	Otlp     Otlp     `yaml:"otlp" json:"otlp"`
+	Sampling Sampling `yaml:"sampling" json:"sampling"`
+}
+
 type ClientToken struct {
 	AppID  string `yaml:"appID" json:"appID"`
 	AppKey string `yaml:"appKey" json:"appKey"`
@@ -77,11 +126,13 @@ type ServerSecure struct {
 }
 
 type TLS struct {
-	CertFile   string `yaml:"certFile" json:"certFile"`
-	Domain     string `yaml:"domain" json:"domain"`
-	Email      string `yaml:"email" json:"email"`
-	EnableMode string `yaml:"enableMode" json:"enableMode"`
-	KeyFile    string `yaml:"keyFile" json:"keyFile"`
+	CertFile     string `yaml:"certFile" json:"certFile"`
+	Domain       string `yaml:"domain" json:"domain"`
+	Email        string `yaml:"email" json:"email"`
+	EnableMode   string `yaml:"enableMode" json:"enableMode"`
+	HTTPPort     int    `yaml:"httpPort" json:"httpPort"`
+	HTTPRedirect bool   `yaml:"httpRedirect" json:"httpRedirect"`
+	KeyFile      string `yaml:"keyFile" json:"keyFile"`
 }
 
 type App struct {
@@ -93,6 +144,7 @@ type App struct {
 	EnableStat            bool    `yaml:"enableStat" json:"enableStat"`
 	EnableTrace           bool    `yaml:"enableTrace" json:"enableTrace"`
 	Env                   string  `yaml:"env" json:"env"`
+	HealthPort            int     `yaml:"healthPort" json:"healthPort"`
 	Host                  string  `yaml:"host" json:"host"`
 	Name                  string  `yaml:"name" json:"name"`
 	RegistryDiscoveryType string  `yaml:"registryDiscoveryType" json:"registryDiscoveryType"`
@@ -100,14 +152,66 @@ type App struct {
 	Version               string  `yaml:"version" json:"version"`
 }
 
+type RetryMethod struct {
+	HedgingDelay   int      `yaml:"hedgingDelay" json:"hedgingDelay"`
+	InitialBackoff int      `yaml:"initialBackoff" json:"initialBackoff"`
+	MaxAttempts    int      `yaml:"maxAttempts" json:"maxAttempts"`
+	MaxBackoff     int      `yaml:"maxBackoff" json:"maxBackoff"`
+	Name           string   `yaml:"name" json:"name"`
+	RetryableCodes []string `yaml:"retryableCodes" json:"retryableCodes"`
+}
+
+type Retry struct {
+	Enable         bool          `yaml:"enable" json:"enable"`
+	InitialBackoff int           `yaml:"initialBackoff" json:"initialBackoff"`
+	MaxAttempts    int           `yaml:"maxAttempts" json:"maxAttempts"`
+	MaxBackoff     int           `yaml:"maxBackoff" json:"maxBackoff"`
+	Methods        []RetryMethod `yaml:"methods" json:"methods"`
+	RetryableCodes []string      `yaml:"retryableCodes" json:"retryableCodes"`
+}
+
+type MethodTimeout struct {
+	Name    string `yaml:"name" json:"name"`
+	Timeout int    `yaml:"timeout" json:"timeout"`
+}
+
+type Keepalive struct {
+	PermitWithoutStream bool `yaml:"permitWithoutStream" json:"permitWithoutStream"`
+	Time                int  `yaml:"time" json:"time"`
+	Timeout             int  `yaml:"timeout" json:"timeout"`
+}
+
 type GrpcClient struct {
-	ClientSecure          ClientSecure `yaml:"clientSecure" json:"clientSecure"`
-	ClientToken           ClientToken  `yaml:"clientToken" json:"clientToken"`
-	Host                  string       `yaml:"host" json:"host"`
-	Name                  string       `yaml:"name" json:"name"`
-	Port                  int          `yaml:"port" json:"port"`
-	RegistryDiscoveryType string       `yaml:"registryDiscoveryType" json:"registryDiscoveryType"`
-	Timeout               int          `yaml:"timeout" json:"timeout"`
+	ClientSecure          ClientSecure    `yaml:"clientSecure" json:"clientSecure"`
+	ClientToken           ClientToken     `yaml:"clientToken" json:"clientToken"`
+	Host                  string          `yaml:"host" json:"host"`
+	Keepalive             Keepalive       `yaml:"keepalive" json:"keepalive"`
+	LoadBalance           string          `yaml:"loadBalance" json:"loadBalance"`
+	MethodTimeouts        []MethodTimeout `yaml:"methodTimeouts" json:"methodTimeouts"`
+	Name                  string          `yaml:"name" json:"name"`
+	Port                  int             `yaml:"port" json:"port"`
+	RegistryDiscoveryType string          `yaml:"registryDiscoveryType" json:"registryDiscoveryType"`
+	Retry                 Retry           `yaml:"retry" json:"retry"`
+	Timeout               int             `yaml:"timeout" json:"timeout"`
+}
+
+type Kafka struct {
+	Addrs          []string `yaml:"addrs" json:"addrs"`
+	GroupID        string   `yaml:"groupID" json:"groupID"`
+	OffsetsInitial string   `yaml:"offsetsInitial" json:"offsetsInitial"`
+}
+
+type Worker struct {
+	Concurrency     int    `yaml:"concurrency" json:"concurrency"`
+	DeadLetterTopic string `yaml:"deadLetterTopic" json:"deadLetterTopic"`
+	HandleTimeout   int    `yaml:"handleTimeout" json:"handleTimeout"`
+	HTTPPort        int    `yaml:"httpPort" json:"httpPort"`
+	InitialBackoff  int    `yaml:"initialBackoff" json:"initialBackoff"`
+	Kafka           Kafka  `yaml:"kafka" json:"kafka"`
+	MaxAttempts     int    `yaml:"maxAttempts" json:"maxAttempts"`
+	MaxBackoff      int    `yaml:"maxBackoff" json:"maxBackoff"`
+	RetryTopic      string `yaml:"retryTopic" json:"retryTopic"`
+	ShutdownTimeout int    `yaml:"shutdownTimeout" json:"shutdownTimeout"`
 }
 
 type Sqlite struct {
@@ -137,18 +241,25 @@ type Postgresql struct {
 }
 
 type Redis struct {
-	DialTimeout  int    `yaml:"dialTimeout" json:"dialTimeout"`
-	Dsn          string `yaml:"dsn" json:"dsn"`
-	ReadTimeout  int    `yaml:"readTimeout" json:"readTimeout"`
-	WriteTimeout int    `yaml:"writeTimeout" json:"writeTimeout"`
+	Addrs        []string `yaml:"addrs" json:"addrs"`
+	DB           int      `yaml:"db" json:"db"`
+	DialTimeout  int      `yaml:"dialTimeout" json:"dialTimeout"`
+	Dsn          string   `yaml:"dsn" json:"dsn"`
+	MasterName   string   `yaml:"masterName" json:"masterName"`
+	Mode         string   `yaml:"mode" json:"mode"`
+	Password     string   `yaml:"password" json:"password"`
+	ReadTimeout  int      `yaml:"readTimeout" json:"readTimeout"`
+	Username     string   `yaml:"username" json:"username"`
+	WriteTimeout int      `yaml:"writeTimeout" json:"writeTimeout"`
 }
 
 type Database struct {
-	Driver     string     `yaml:"driver" json:"driver"`
-	Mongodb    Mongodb    `yaml:"mongodb" json:"mongodb"`
-	Mysql      Mysql      `yaml:"mysql" json:"mysql"`
-	Postgresql Postgresql `yaml:"postgresql" json:"postgresql"`
-	Sqlite     Sqlite     `yaml:"sqlite" json:"sqlite"`
+	AutoMigrate bool       `yaml:"autoMigrate" json:"autoMigrate"`
+	Driver      string     `yaml:"driver" json:"driver"`
+	Mongodb     Mongodb    `yaml:"mongodb" json:"mongodb"`
+	Mysql       Mysql      `yaml:"mysql" json:"mysql"`
+	Postgresql  Postgresql `yaml:"postgresql" json:"postgresql"`
+	Sqlite      Sqlite     `yaml:"sqlite" json:"sqlite"`
 }
 
 type Mongodb struct {
@@ -156,16 +267,26 @@ type Mongodb struct {
 }
 
 type Grpc struct {
-	EnableToken  bool         `yaml:"enableToken" json:"enableToken"`
-	HTTPPort     int          `yaml:"httpPort" json:"httpPort"`
-	Port         int          `yaml:"port" json:"port"`
-	ServerSecure ServerSecure `yaml:"serverSecure" json:"serverSecure"`
+	DisableHealth    bool         `yaml:"disableHealth" json:"disableHealth"`
+	EnableReflection bool         `yaml:"enableReflection" json:"enableReflection"`
+	EnableToken      bool         `yaml:"enableToken" json:"enableToken"`
+	HTTPPort         int          `yaml:"httpPort" json:"httpPort"`
+	Port             int          `yaml:"port" json:"port"`
+	ServerSecure     ServerSecure `yaml:"serverSecure" json:"serverSecure"`
+}
+
+type LogSampling struct {
+	First      int `yaml:"first" json:"first"`
+	Thereafter int `yaml:"thereafter" json:"thereafter"`
 }
 
 type Logger struct {
-	Format string `yaml:"format" json:"format"`
-	IsSave bool   `yaml:"isSave" json:"isSave"`
-	Level  string `yaml:"level" json:"level"`
+	ErrorRateLimit float64     `yaml:"errorRateLimit" json:"errorRateLimit"`
+	Format         string      `yaml:"format" json:"format"`
+	IsSave         bool        `yaml:"isSave" json:"isSave"`
+	Level          string      `yaml:"level" json:"level"`
+	RedactFields   []string    `yaml:"redactFields" json:"redactFields"`
+	Sampling       LogSampling `yaml:"sampling" json:"sampling"`
 }
 
 type NacosRd struct {
@@ -175,7 +296,9 @@ type NacosRd struct {
 }
 
 type HTTP struct {
-	Port    int `yaml:"port" json:"port"`
-	Timeout int `yaml:"timeout" json:"timeout"`
-	TLS     TLS `yaml:"tls" json:"tls"`
+	MaxConns    int `yaml:"maxConns" json:"maxConns"`
+	MaxRequests int `yaml:"maxRequests" json:"maxRequests"`
+	Port        int `yaml:"port" json:"port"`
+	Timeout     int `yaml:"timeout" json:"timeout"`
+	TLS         TLS `yaml:"tls" json:"tls"`
 }
@@ -3,11 +3,13 @@ package dao
 import (
 	"context"
 	"errors"
+	"time"
 
 	"golang.org/x/sync/singleflight"
 	"gorm.io/gorm"
 
 	"github.com/go-dev-frame/sponge/pkg/logger"
+	"github.com/go-dev-frame/sponge/pkg/sgorm/optlock"
 	"github.com/go-dev-frame/sponge/pkg/sgorm/query"
 	"github.com/go-dev-frame/sponge/pkg/utils"
 
@@ -25,16 +27,21 @@ type UserExampleDao interface {
 	UpdateByID(ctx context.Context, table *model.UserExample) error
 	GetByID(ctx context.Context, id uint64) (*model.UserExample, error)
 	GetByColumns(ctx context.Context, params *query.Params) ([]*model.UserExample, int64, error)
+	GetByIDWithPreload(ctx context.Context, id uint64, preloads ...query.Preload) (*model.UserExample, error)
+	GetByColumnsWithPreload(ctx context.Context, params *query.Params, preloads ...query.Preload) ([]*model.UserExample, int64, error)
 
 	CreateByTx(ctx context.Context, tx *gorm.DB, table *model.UserExample) (uint64, error)
 	DeleteByTx(ctx context.Context, tx *gorm.DB, id uint64) error
 	UpdateByTx(ctx context.Context, tx *gorm.DB, table *model.UserExample) error
+	WithTx(tx *gorm.DB) UserExampleDao
 }
 
 type userExampleDao struct {
 	db    *gorm.DB
 	cache cache.UserExampleCache // if nil, the cache is not used.
 	sfg   *singleflight.Group    // if cache is nil, the sfg is not used.
+
+	txCache cache.UserExampleCache // the cache of dao in transaction, it is only used to delete cache.
 }
 
 // NewUserExampleDao creating the dao interface
@@ -53,6 +60,9 @@ func (d *userExampleDao) deleteCache(ctx context.Context, id uint64) error {
 	if d.cache != nil {
 		return d.cache.Del(ctx, id)
 	}
+	if d.txCache != nil {
+		return d.txCache.Del(ctx, id)
+	}
 	return nil
 }
 
@@ -118,7 +128,10 @@ func (d *userExampleDao) updateDataByID(ctx context.Context, db *gorm.DB, table
 	}
 	// delete the templates code end
 
-	return db.WithContext(ctx).Model(table).Updates(update).Error
+	// if ctx has the expected version (optlock.Expect), the record is updated only if it has not been modified,
+	// otherwise optlock.ErrConflict is returned
+	result := db.WithContext(ctx).Model(table).Scopes(optlock.Scope(ctx)).Updates(update)
+	return optlock.Check(ctx, result)
 }
 
 // GetByID get a userExample by id
@@ -130,9 +143,12 @@ func (d *userExampleDao) GetByID(ctx context.Context, id uint64) (*model.UserExa
 		return record, err
 	}
 
-	// get from cache
-	record, err := d.cache.Get(ctx, id)
+	// get from cache, the stale record is returned and refreshed in background
+	record, isStale, err := d.cache.GetWithStale(ctx, id)
 	if err == nil {
+		if isStale {
+			d.refreshCache(ctx, id)
+		}
 		return record, nil
 	}
 
@@ -140,23 +156,7 @@ func (d *userExampleDao) GetByID(ctx context.Context, id uint64) (*model.UserExa
 	if errors.Is(err, database.ErrCacheNotFound) {
 		// for the same id, prevent high concurrent simultaneous access to database
 		val, err, _ := d.sfg.Do(utils.Uint64ToStr(id), func() (interface{}, error) { //nolint
-			table := &model.UserExample{}
-			err = d.db.WithContext(ctx).Where("id = ?", id).First(table).Error
-			if err != nil {
-				if errors.Is(err, database.ErrRecordNotFound) {
-					// set placeholder cache to prevent cache penetration, default expiration time 10 minutes
-					if err = d.cache.SetPlaceholder(ctx, id); err != nil {
-						logger.Warn("cache.SetPlaceholder error", logger.Err(err), logger.Any("id", id))
-					}
-					return nil, database.ErrRecordNotFound
-				}
-				return nil, err
-			}
-			// set cache
-			if err = d.cache.Set(ctx, id, table, cache.UserExampleExpireTime); err != nil {
-				logger.Warn("cache.Set error", logger.Err(err), logger.Any("id", id))
-			}
-			return table, nil
+			return d.getAndSetCache(ctx, id)
 		})
 		if err != nil {
 			return nil, err
@@ -175,10 +175,68 @@ func (d *userExampleDao) GetByID(ctx context.Context, id uint64) (*model.UserExa
 	return nil, err
 }
 
+// get a userExample from database and set cache, if not found, set placeholder cache
+func (d *userExampleDao) getAndSetCache(ctx context.Context, id uint64) (*model.UserExample, error) {
+	table := &model.UserExample{}
+	err := d.db.WithContext(ctx).Where("id = ?", id).First(table).Error
+	if err != nil {
+		if errors.Is(err, database.ErrRecordNotFound) {
+			// set placeholder cache to prevent cache penetration, default expiration time 10 minutes
+			if err = d.cache.SetPlaceholder(ctx, id); err != nil {
+				logger.Warn("cache.SetPlaceholder error", logger.Err(err), logger.Any("id", id))
+			}
+			return nil, database.ErrRecordNotFound
+		}
+		return nil, err
+	}
+	// set cache
+	if err = d.cache.Set(ctx, id, table, cache.UserExampleExpireTime); err != nil {
+		logger.Warn("cache.Set error", logger.Err(err), logger.Any("id", id))
+	}
+	return table, nil
+}
+
+// refresh the stale cache in background, the caller does not wait for the result,
+// and only one goroutine gets the record from database for the same id.
+func (d *userExampleDao) refreshCache(ctx context.Context, id uint64) {
+	ctx = context.WithoutCancel(ctx) // keep running after the request is done
+	d.sfg.DoChan(utils.Uint64ToStr(id), func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		return d.getAndSetCache(ctx, id)
+	})
+}
+
 // GetByColumns get a paginated list of userExamples by custom conditions.
 // For more details, please refer to https://go-sponge.com/component/data/custom-page-query.html
 func (d *userExampleDao) GetByColumns(ctx context.Context, params *query.Params) ([]*model.UserExample, int64, error) {
-	queryStr, args, err := params.ConvertToGormConditions(query.WithWhitelistNames(model.UserExampleColumnNames))
+	return d.GetByColumnsWithPreload(ctx, params)
+}
+
+// GetByIDWithPreload get a userExample by id and preload the associated records, the cache is not used,
+// e.g. query.Preload{Name: "Orders", Columns: []string{"id", "user_id"}}, query.Preload{Name: "Orders.Items"}
+func (d *userExampleDao) GetByIDWithPreload(ctx context.Context, id uint64, preloads ...query.Preload) (*model.UserExample, error) {
+	scope, err := query.PreloadScope(preloads)
+	if err != nil {
+		return nil, errors.New("preload params error: " + err.Error())
+	}
+	record := &model.UserExample{}
+	err = d.db.WithContext(ctx).Scopes(scope).Where("id = ?", id).First(record).Error
+	return record, err
+}
+
+// GetByColumnsWithPreload get a paginated list of userExamples by custom conditions and preload the associated records
+func (d *userExampleDao) GetByColumnsWithPreload(ctx context.Context, params *query.Params, preloads ...query.Preload) ([]*model.UserExample, int64, error) {
+	scope, err := query.PreloadScope(preloads)
+	if err != nil {
+		return nil, 0, errors.New("preload params error: " + err.Error())
+	}
+	opts := []query.RulerOption{
+		query.WithWhitelistNames(model.UserExampleColumnNames),
+		query.WithSearchColumns(model.UserExampleSearchColumns...), // full-text search by the q parameter
+		query.WithDB(d.db),
+	}
+	queryStr, args, err := params.ConvertToGormConditions(opts...)
 	if err != nil {
 		return nil, 0, errors.New("query params error: " + err.Error())
 	}
@@ -195,8 +253,8 @@ func (d *userExampleDao) GetByColumns(ctx context.Context, params *query.Params)
 	}
 
 	records := []*model.UserExample{}
-	order, limit, offset := params.ConvertToPage()
-	err = d.db.WithContext(ctx).Order(order).Limit(limit).Offset(offset).Where(queryStr, args...).Find(&records).Error
+	_, limit, offset := params.ConvertToPage()
+	err = d.db.WithContext(ctx).Order(params.ConvertToGormOrder(opts...)).Limit(limit).Offset(offset).Where(queryStr, args...).Scopes(scope).Find(&records).Error
 	if err != nil {
 		return nil, 0, err
 	}
@@ -232,3 +290,13 @@ func (d *userExampleDao) UpdateByTx(ctx context.Context, tx *gorm.DB, table *mod
 
 	return err
 }
+
+// WithTx returns a dao executing in the transaction tx, the records are read from tx instead of cache,
+// and the cache of the updated and deleted records is deleted.
+func (d *userExampleDao) WithTx(tx *gorm.DB) UserExampleDao {
+	xCache := d.cache
+	if xCache == nil {
+		xCache = d.txCache
+	}
+	return &userExampleDao{db: tx, txCache: xCache}
+}
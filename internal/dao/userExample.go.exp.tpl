@@ -9,6 +9,7 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/go-dev-frame/sponge/pkg/logger"
+	"github.com/go-dev-frame/sponge/pkg/sgorm/optlock"
 	"github.com/go-dev-frame/sponge/pkg/sgorm/query"
 	"github.com/go-dev-frame/sponge/pkg/utils"
 
@@ -26,6 +27,8 @@ type {{.TableNameCamel}}Dao interface {
 	UpdateBy{{.ColumnNameCamel}}(ctx context.Context, table *model.{{.TableNameCamel}}) error
 	GetBy{{.ColumnNameCamel}}(ctx context.Context, {{.ColumnNameCamelFCL}} {{.GoType}}) (*model.{{.TableNameCamel}}, error)
 	GetByColumns(ctx context.Context, params *query.Params) ([]*model.{{.TableNameCamel}}, int64, error)
+	GetBy{{.ColumnNameCamel}}WithPreload(ctx context.Context, {{.ColumnNameCamelFCL}} {{.GoType}}, preloads ...query.Preload) (*model.{{.TableNameCamel}}, error)
+	GetByColumnsWithPreload(ctx context.Context, params *query.Params, preloads ...query.Preload) ([]*model.{{.TableNameCamel}}, int64, error)
 
 	DeleteBy{{.ColumnNamePluralCamel}}(ctx context.Context, {{.ColumnNamePluralCamelFCL}} []{{.GoType}}) error
 	GetByCondition(ctx context.Context, condition *query.Conditions) (*model.{{.TableNameCamel}}, error)
@@ -35,12 +38,15 @@ type {{.TableNameCamel}}Dao interface {
 	CreateByTx(ctx context.Context, tx *gorm.DB, table *model.{{.TableNameCamel}}) ({{.GoType}}, error)
 	DeleteByTx(ctx context.Context, tx *gorm.DB, {{.ColumnNameCamelFCL}} {{.GoType}}) error
 	UpdateByTx(ctx context.Context, tx *gorm.DB, table *model.{{.TableNameCamel}}) error
+	WithTx(tx *gorm.DB) {{.TableNameCamel}}Dao
 }
 
 type {{.TableNameCamelFCL}}Dao struct {
 	db    *gorm.DB
 	cache cache.{{.TableNameCamel}}Cache // if nil, the cache is not used.
 	sfg   *singleflight.Group    // if cache is nil, the sfg is not used.
+
+	txCache cache.{{.TableNameCamel}}Cache // the cache of dao in transaction, it is only used to delete cache.
 }
 
 // New{{.TableNameCamel}}Dao creating the dao interface
@@ -59,6 +65,9 @@ func (d *{{.TableNameCamelFCL}}Dao) deleteCache(ctx context.Context, {{.ColumnNa
 	if d.cache != nil {
 		return d.cache.Del(ctx, {{.ColumnNameCamelFCL}})
 	}
+	if d.txCache != nil {
+		return d.txCache.Del(ctx, {{.ColumnNameCamelFCL}})
+	}
 	return nil
 }
 
@@ -128,7 +137,10 @@ func (d *{{.TableNameCamelFCL}}Dao) updateDataBy{{.ColumnNameCamel}}(ctx context
 	}
 	// delete the templates code end
 
-	return db.WithContext(ctx).Model(table).Updates(update).Error
+	// if ctx has the expected version (optlock.Expect), the record is updated only if it has not been modified,
+	// otherwise optlock.ErrConflict is returned
+	result := db.WithContext(ctx).Model(table).Scopes(optlock.Scope(ctx)).Updates(update)
+	return optlock.Check(ctx, result)
 }
 
 // GetBy{{.ColumnNameCamel}} get a {{.TableNameCamelFCL}} by {{.ColumnNameCamelFCL}}
@@ -140,9 +152,12 @@ func (d *{{.TableNameCamelFCL}}Dao) GetBy{{.ColumnNameCamel}}(ctx context.Contex
 		return record, err
 	}
 
-	// get from cache
-	record, err := d.cache.Get(ctx, {{.ColumnNameCamelFCL}})
+	// get from cache, the stale record is returned and refreshed in background
+	record, isStale, err := d.cache.GetWithStale(ctx, {{.ColumnNameCamelFCL}})
 	if err == nil {
+		if isStale {
+			d.refreshCache(ctx, {{.ColumnNameCamelFCL}})
+		}
 		return record, nil
 	}
 
@@ -152,23 +167,7 @@ func (d *{{.TableNameCamelFCL}}Dao) GetBy{{.ColumnNameCamel}}(ctx context.Contex
 		{{if .IsStringType}}val, err, _ := d.sfg.Do({{.ColumnNameCamelFCL}}, func() (interface{}, error) {
 {{else}}		val, err, _ := d.sfg.Do(utils.{{.GoTypeFCU}}ToStr({{.ColumnNameCamelFCL}}), func() (interface{}, error) {
 {{end}}
-			table := &model.{{.TableNameCamel}}{}
-			err = d.db.WithContext(ctx).Where("{{.ColumnName}} = ?", {{.ColumnNameCamelFCL}}).First(table).Error
-			if err != nil {
-				// set placeholder cache to prevent cache penetration, default expiration time 10 minutes
-				if errors.Is(err, database.ErrRecordNotFound) {
-					if err = d.cache.SetPlaceholder(ctx, {{.ColumnNameCamelFCL}}); err != nil {
-						logger.Warn("cache.SetPlaceholder error", logger.Err(err), logger.Any("{{.ColumnNameCamelFCL}}", {{.ColumnNameCamelFCL}}))
-					}
-					return nil, database.ErrRecordNotFound
-				}
-				return nil, err
-			}
-			// set cache
-			if err = d.cache.Set(ctx, {{.ColumnNameCamelFCL}}, table, cache.{{.TableNameCamel}}ExpireTime); err != nil {
-				logger.Warn("cache.Set error", logger.Err(err), logger.Any("{{.ColumnNameCamelFCL}}", {{.ColumnNameCamelFCL}}))
-			}
-			return table, nil
+			return d.getAndSetCache(ctx, {{.ColumnNameCamelFCL}})
 		})
 		if err != nil {
 			return nil, err
@@ -187,13 +186,71 @@ func (d *{{.TableNameCamelFCL}}Dao) GetBy{{.ColumnNameCamel}}(ctx context.Contex
 	return nil, err
 }
 
+// get a {{.TableNameCamelFCL}} from database and set cache, if not found, set placeholder cache
+func (d *{{.TableNameCamelFCL}}Dao) getAndSetCache(ctx context.Context, {{.ColumnNameCamelFCL}} {{.GoType}}) (*model.{{.TableNameCamel}}, error) {
+	table := &model.{{.TableNameCamel}}{}
+	err := d.db.WithContext(ctx).Where("{{.ColumnName}} = ?", {{.ColumnNameCamelFCL}}).First(table).Error
+	if err != nil {
+		// set placeholder cache to prevent cache penetration, default expiration time 10 minutes
+		if errors.Is(err, database.ErrRecordNotFound) {
+			if err = d.cache.SetPlaceholder(ctx, {{.ColumnNameCamelFCL}}); err != nil {
+				logger.Warn("cache.SetPlaceholder error", logger.Err(err), logger.Any("{{.ColumnNameCamelFCL}}", {{.ColumnNameCamelFCL}}))
+			}
+			return nil, database.ErrRecordNotFound
+		}
+		return nil, err
+	}
+	// set cache
+	if err = d.cache.Set(ctx, {{.ColumnNameCamelFCL}}, table, cache.{{.TableNameCamel}}ExpireTime); err != nil {
+		logger.Warn("cache.Set error", logger.Err(err), logger.Any("{{.ColumnNameCamelFCL}}", {{.ColumnNameCamelFCL}}))
+	}
+	return table, nil
+}
+
+// refresh the stale cache in background, the caller does not wait for the result,
+// and only one goroutine gets the record from database for the same {{.ColumnNameCamelFCL}}.
+func (d *{{.TableNameCamelFCL}}Dao) refreshCache(ctx context.Context, {{.ColumnNameCamelFCL}} {{.GoType}}) {
+	ctx = context.WithoutCancel(ctx) // keep running after the request is done
+	d.sfg.DoChan({{if .IsStringType}}{{.ColumnNameCamelFCL}}{{else}}utils.{{.GoTypeFCU}}ToStr({{.ColumnNameCamelFCL}}){{end}}, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		return d.getAndSetCache(ctx, {{.ColumnNameCamelFCL}})
+	})
+}
+
 // GetByColumns get a paginated list of {{.TableNamePluralCamelFCL}} by custom conditions.
 // For more details, please refer to https://go-sponge.com/component/data/custom-page-query.html
 func (d *{{.TableNameCamelFCL}}Dao) GetByColumns(ctx context.Context, params *query.Params) ([]*model.{{.TableNameCamel}}, int64, error) {
-	if params.Sort == "" {
+	return d.GetByColumnsWithPreload(ctx, params)
+}
+
+// GetBy{{.ColumnNameCamel}}WithPreload get a {{.TableNameCamelFCL}} by {{.ColumnNameCamelFCL}} and preload the associated records, the cache is not used,
+// e.g. query.Preload{Name: "Orders", Columns: []string{"id", "user_id"}}, query.Preload{Name: "Orders.Items"}
+func (d *{{.TableNameCamelFCL}}Dao) GetBy{{.ColumnNameCamel}}WithPreload(ctx context.Context, {{.ColumnNameCamelFCL}} {{.GoType}}, preloads ...query.Preload) (*model.{{.TableNameCamel}}, error) {
+	scope, err := query.PreloadScope(preloads)
+	if err != nil {
+		return nil, errors.New("preload params error: " + err.Error())
+	}
+	record := &model.{{.TableNameCamel}}{}
+	err = d.db.WithContext(ctx).Scopes(scope).Where("{{.ColumnName}} = ?", {{.ColumnNameCamelFCL}}).First(record).Error
+	return record, err
+}
+
+// GetByColumnsWithPreload get a paginated list of {{.TableNamePluralCamelFCL}} by custom conditions and preload the associated records
+func (d *{{.TableNameCamelFCL}}Dao) GetByColumnsWithPreload(ctx context.Context, params *query.Params, preloads ...query.Preload) ([]*model.{{.TableNameCamel}}, int64, error) {
+	scope, err := query.PreloadScope(preloads)
+	if err != nil {
+		return nil, 0, errors.New("preload params error: " + err.Error())
+	}
+	if params.Sort == "" && params.Q == "" { // the search results are sorted by relevance
 		params.Sort = "-{{.ColumnName}}"
 	}
-	queryStr, args, err := params.ConvertToGormConditions(query.WithWhitelistNames(model.{{.TableNameCamel}}ColumnNames))
+	opts := []query.RulerOption{
+		query.WithWhitelistNames(model.{{.TableNameCamel}}ColumnNames),
+		query.WithSearchColumns(model.{{.TableNameCamel}}SearchColumns...), // full-text search by the q parameter
+		query.WithDB(d.db),
+	}
+	queryStr, args, err := params.ConvertToGormConditions(opts...)
 	if err != nil {
 		return nil, 0, errors.New("query params error: " + err.Error())
 	}
@@ -210,8 +267,8 @@ func (d *{{.TableNameCamelFCL}}Dao) GetByColumns(ctx context.Context, params *qu
 	}
 
 	records := []*model.{{.TableNameCamel}}{}
-	order, limit, offset := params.ConvertToPage()
-	err = d.db.WithContext(ctx).Order(order).Limit(limit).Offset(offset).Where(queryStr, args...).Find(&records).Error
+	_, limit, offset := params.ConvertToPage()
+	err = d.db.WithContext(ctx).Order(params.ConvertToGormOrder(opts...)).Limit(limit).Offset(offset).Where(queryStr, args...).Scopes(scope).Find(&records).Error
 	if err != nil {
 		return nil, 0, err
 	}
@@ -372,3 +429,13 @@ func (d *{{.TableNameCamelFCL}}Dao) UpdateByTx(ctx context.Context, tx *gorm.DB,
 
 	return err
 }
+
+// WithTx returns a dao executing in the transaction tx, the records are read from tx instead of cache,
+// and the cache of the updated and deleted records is deleted.
+func (d *{{.TableNameCamelFCL}}Dao) WithTx(tx *gorm.DB) {{.TableNameCamel}}Dao {
+	xCache := d.cache
+	if xCache == nil {
+		xCache = d.txCache
+	}
+	return &{{.TableNameCamelFCL}}Dao{db: tx, txCache: xCache}
+}
@@ -0,0 +1,93 @@
+//go:build integration
+
+// The integration test runs with the real database and redis, the database driver is read from the configuration file,
+// mysql and postgresql are started by docker, sqlite and redis are embedded, run the test by command:
+//   go test -tags=integration -run Integration ./internal/dao/...
+
+package dao
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-dev-frame/sponge/pkg/container/testcontainer"
+	"github.com/go-dev-frame/sponge/pkg/sgorm"
+	"github.com/go-dev-frame/sponge/pkg/sgorm/query"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-dev-frame/sponge/configs"
+	"github.com/go-dev-frame/sponge/internal/cache"
+	"github.com/go-dev-frame/sponge/internal/config"
+	"github.com/go-dev-frame/sponge/internal/database"
+	"github.com/go-dev-frame/sponge/internal/model"
+)
+
+func newUserExampleIntegrationDao(t *testing.T) (UserExampleDao, func()) {
+	err := config.Init(configs.Path("serverNameExample.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	driver := config.Get().Database.Driver
+	if driver != sgorm.DBDriverSqlite && !testcontainer.IsDockerAvailable() {
+		t.Skipf("docker is not available, skip the integration test of %s", driver)
+	}
+
+	ctx := context.Background()
+	// the schema is loaded from the migration files of project, and the table is created by model if not exists
+	db, err := testcontainer.NewDatabase(ctx, driver, testcontainer.WithSchema("../database/migrations"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !db.DB.Migrator().HasTable(&model.UserExample{}) {
+		if err = db.DB.AutoMigrate(&model.UserExample{}); err != nil {
+			_ = db.Close()
+			t.Fatal(err)
+		}
+	}
+
+	rdb, err := testcontainer.NewRedis(ctx)
+	if err != nil {
+		_ = db.Close()
+		t.Fatal(err)
+	}
+	c := cache.NewUserExampleCache(&database.CacheType{CType: "redis", Rdb: rdb.Client})
+
+	return NewUserExampleDao(db.DB, c), func() {
+		_ = rdb.Close()
+		_ = db.Close()
+	}
+}
+
+func Test_userExampleDao_Integration(t *testing.T) {
+	d, closeFn := newUserExampleIntegrationDao(t)
+	defer closeFn()
+	ctx := context.Background()
+
+	record := &model.UserExample{}
+	record.ID = 1 // set id explicitly, the id column created by AutoMigrate may be not auto increment, e.g. int(11) in sqlite
+	// you can set the other fields of record here, such as the not null and unique columns
+	err := d.Create(ctx, record)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// get from database and set cache, then get from cache
+	for i := 0; i < 2; i++ {
+		actual, err := d.GetByID(ctx, record.ID)
+		assert.NoError(t, err)
+		if assert.NotNil(t, actual) {
+			assert.Equal(t, record.ID, actual.ID)
+		}
+	}
+
+	records, total, err := d.GetByColumns(ctx, &query.Params{Page: 0, Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Len(t, records, 1)
+
+	err = d.DeleteByID(ctx, record.ID)
+	assert.NoError(t, err)
+	_, err = d.GetByID(ctx, record.ID)
+	assert.True(t, errors.Is(err, database.ErrRecordNotFound))
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/go-dev-frame/sponge/pkg/sgorm/query"
 	"github.com/go-dev-frame/sponge/pkg/utils"
 	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
 
 	"github.com/go-dev-frame/sponge/internal/cache"
 	"github.com/go-dev-frame/sponge/internal/database"
@@ -196,6 +197,41 @@ func Test_userExampleDao_GetByColumns(t *testing.T) {
 	t.Log(err)
 }
 
+func Test_userExampleDao_GetWithPreload(t *testing.T) {
+	d := newUserExampleDao()
+	defer d.Close()
+	testData := d.TestData.(*model.UserExample)
+
+	rows := sqlmock.NewRows([]string{"id"}).AddRow(testData.ID)
+	d.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(rows)
+	_, err := d.IDao.(UserExampleDao).GetByIDWithPreload(d.Ctx, testData.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows = sqlmock.NewRows([]string{"id"}).AddRow(testData.ID)
+	d.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(rows)
+	_, _, err = d.IDao.(UserExampleDao).GetByColumnsWithPreload(d.Ctx, &query.Params{
+		Page:  0,
+		Limit: 10,
+		Sort:  "ignore count", // ignore test count(*)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.SQLMock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// error test
+	_, err = d.IDao.(UserExampleDao).GetByIDWithPreload(d.Ctx, testData.ID, query.Preload{Name: "a.b.c.d"})
+	assert.Error(t, err)
+	_, _, err = d.IDao.(UserExampleDao).GetByColumnsWithPreload(d.Ctx, &query.Params{}, query.Preload{Name: "a;b"})
+	assert.Error(t, err)
+}
+
 func Test_userExampleDao_CreateByTx(t *testing.T) {
 	d := newUserExampleDao()
 	defer d.Close()
@@ -248,3 +284,33 @@ func Test_userExampleDao_UpdateByTx(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func Test_userExampleDao_WithTx(t *testing.T) {
+	d := newUserExampleDao()
+	defer d.Close()
+	testData := d.TestData.(*model.UserExample)
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WithArgs(d.AnyTime, testData.ID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testData.ID))
+	d.SQLMock.ExpectCommit()
+
+	err := d.DB.Transaction(func(tx *gorm.DB) error {
+		txDao := d.IDao.(UserExampleDao).WithTx(tx)
+		if err := txDao.UpdateByID(d.Ctx, testData); err != nil {
+			return err
+		}
+		_, err := txDao.GetByID(d.Ctx, testData.ID) // read from transaction instead of cache
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.SQLMock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
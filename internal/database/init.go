@@ -2,6 +2,7 @@
 package database
 
 import (
+	"context"
 	"strings"
 	"sync"
 
@@ -34,6 +35,8 @@ func InitDB() {
 		panic("InitDB error, please modify the correct 'database' configuration at yaml file. " +
 			"Refer to https://github.com/go-dev-frame/sponge/blob/main/configs/serverNameExample.yml#L85")
 	}
+
+	autoMigrate()
 }
 
 // delete the templates code end
@@ -49,6 +52,15 @@ func GetDB() *sgorm.DB {
 	return gdb
 }
 
+// PingDB check whether the database is reachable, used for health checks
+func PingDB(ctx context.Context) error {
+	sqlDB, err := GetDB().DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
 // CloseDB close db
 func CloseDB() error {
 	return sgorm.CloseDB(gdb)
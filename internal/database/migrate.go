@@ -0,0 +1,41 @@
+package database
+
+import (
+	"embed"
+	"io/fs"
+
+	"github.com/go-dev-frame/sponge/pkg/logger"
+	"github.com/go-dev-frame/sponge/pkg/sgorm"
+	"github.com/go-dev-frame/sponge/pkg/sgorm/migrate"
+
+	"github.com/go-dev-frame/sponge/internal/config"
+)
+
+// the migration files are generated by the command "sponge migrate diff" or "sponge migrate create"
+//
+//go:embed migrations
+var migrationFS embed.FS
+
+// MigrateDB apply the migration files in the directory internal/database/migrations to the database,
+// the files are embedded in the binary.
+func MigrateDB() error {
+	return migrateDB(GetDB())
+}
+
+func migrateDB(db *sgorm.DB) error {
+	fsys, err := fs.Sub(migrationFS, "migrations")
+	if err != nil {
+		return err
+	}
+	return migrate.Up(db, fsys, migrate.WithLogger(logger.Get()))
+}
+
+// apply the migration files when connecting the database if database.autoMigrate is true
+func autoMigrate() {
+	if !config.Get().Database.AutoMigrate {
+		return
+	}
+	if err := migrateDB(gdb); err != nil {
+		panic("migrate database error: " + err.Error())
+	}
+}
@@ -0,0 +1,18 @@
+package database
+
+import (
+	"github.com/go-dev-frame/sponge/pkg/logger"
+	"github.com/go-dev-frame/sponge/pkg/mq/outbox"
+)
+
+// NewOutboxRelay create the outbox table and the relay worker, the messages saved by outbox.Save in the business
+// transactions are published by publisher, call Start to run the relay, and Stop to stop it before closing db.
+func NewOutboxRelay(publisher outbox.Publisher, opts ...outbox.RelayOption) (*outbox.Relay, error) {
+	db := GetDB()
+	if err := outbox.AutoMigrate(db); err != nil {
+		return nil, err
+	}
+
+	opts = append([]outbox.RelayOption{outbox.WithRelayLogger(logger.Get())}, opts...)
+	return outbox.NewRelay(db, publisher, opts...), nil
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"sync"
 	"time"
 
@@ -16,7 +17,7 @@ var (
 )
 
 var (
-	redisCli     *goredis.Client
+	redisCli     goredis.UniversalClient
 	redisCliOnce sync.Once
 
 	cacheType     *CacheType
@@ -25,8 +26,8 @@ var (
 
 // CacheType cache type
 type CacheType struct {
-	CType string          // cache type  memory or redis
-	Rdb   *goredis.Client // if CType=redis, Rdb cannot be empty
+	CType string                  // cache type  memory or redis
+	Rdb   goredis.UniversalClient // if CType=redis, Rdb cannot be empty
 }
 
 // InitCache initial cache
@@ -51,7 +52,7 @@ func GetCacheType() *CacheType {
 	return cacheType
 }
 
-// InitRedis connect redis
+// InitRedis connect redis, the mode of redis is single, sentinel or cluster
 func InitRedis() {
 	redisCfg := config.Get().Redis
 	opts := []goredis.Option{
@@ -62,16 +63,27 @@ func InitRedis() {
 	if config.Get().App.EnableTrace {
 		opts = append(opts, goredis.WithTracing(tracer.GetProvider()))
 	}
+	if config.Get().App.EnableMetrics {
+		opts = append(opts, goredis.WithMetrics(config.Get().App.Name))
+	}
 
 	var err error
-	redisCli, err = goredis.Init(redisCfg.Dsn, opts...)
+	redisCli, err = goredis.InitByConfig(&goredis.Config{
+		Mode:       redisCfg.Mode,
+		Dsn:        redisCfg.Dsn,
+		Addrs:      redisCfg.Addrs,
+		MasterName: redisCfg.MasterName,
+		Username:   redisCfg.Username,
+		Password:   redisCfg.Password,
+		DB:         redisCfg.DB,
+	}, opts...)
 	if err != nil {
-		panic("goredis.Init error: " + err.Error())
+		panic("goredis.InitByConfig error: " + err.Error())
 	}
 }
 
 // GetRedisCli get redis client
-func GetRedisCli() *goredis.Client {
+func GetRedisCli() goredis.UniversalClient {
 	if redisCli == nil {
 		redisCliOnce.Do(func() {
 			InitRedis()
@@ -81,7 +93,12 @@ func GetRedisCli() *goredis.Client {
 	return redisCli
 }
 
+// PingRedis check whether the redis is reachable, used for health checks
+func PingRedis(ctx context.Context) error {
+	return GetRedisCli().Ping(ctx).Err()
+}
+
 // CloseRedis close redis
 func CloseRedis() error {
-	return goredis.Close(redisCli)
+	return goredis.CloseClient(redisCli)
 }
@@ -1,14 +1,17 @@
 package handler
 
 import (
+	"context"
 	"errors"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/go-dev-frame/sponge/pkg/copier"
+	"github.com/go-dev-frame/sponge/pkg/gin/conditional"
 	"github.com/go-dev-frame/sponge/pkg/gin/middleware"
 	"github.com/go-dev-frame/sponge/pkg/gin/response"
 	"github.com/go-dev-frame/sponge/pkg/logger"
+	"github.com/go-dev-frame/sponge/pkg/sgorm/optlock"
 	"github.com/go-dev-frame/sponge/pkg/utils"
 
 	"github.com/go-dev-frame/sponge/internal/cache"
@@ -118,6 +121,7 @@ func (h *userExampleHandler) DeleteByID(c *gin.Context) {
 // @Produce json
 // @Param id path string true "id"
 // @Param data body types.UpdateUserExampleByIDRequest true "userExample information"
+// @Param If-Match header string false "ETag of userExample, the update is rejected with 412 if the userExample has been modified"
 // @Success 200 {object} types.UpdateUserExampleByIDReply{}
 // @Router /api/v1/userExample/{id} [put]
 // @Security BearerAuth
@@ -146,8 +150,17 @@ func (h *userExampleHandler) UpdateByID(c *gin.Context) {
 	// Note: if copier.Copy cannot assign a value to a field, add it here
 
 	ctx := middleware.WrapCtx(c)
+	ctx, isAbort = h.checkIfMatch(ctx, c, id)
+	if isAbort {
+		return
+	}
 	err = h.iDao.UpdateByID(ctx, userExample)
 	if err != nil {
+		if errors.Is(err, optlock.ErrConflict) {
+			logger.Warn("UpdateByID conflict", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			conditional.PreconditionFailed(c)
+			return
+		}
 		logger.Error("UpdateByID error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
 		response.Output(c, ecode.InternalServerError.ToHTTPCode())
 		return
@@ -161,9 +174,11 @@ func (h *userExampleHandler) UpdateByID(c *gin.Context) {
 // @Description Gets detailed information of a userExample specified by the given id in the path.
 // @Tags userExample
 // @Param id path string true "id"
+// @Param If-None-Match header string false "ETag of userExample, respond 304 if the userExample has not been modified"
 // @Accept json
 // @Produce json
 // @Success 200 {object} types.GetUserExampleByIDReply{}
+// @Header 200 {string} ETag "version of userExample, used by If-Match of update"
 // @Router /api/v1/userExample/{id} [get]
 // @Security BearerAuth
 func (h *userExampleHandler) GetByID(c *gin.Context) {
@@ -186,6 +201,10 @@ func (h *userExampleHandler) GetByID(c *gin.Context) {
 		return
 	}
 
+	if conditional.NotModified(c, userExampleETag(userExample)) {
+		return
+	}
+
 	data := &types.UserExampleObjDetail{}
 	err = copier.Copy(data, userExample)
 	if err != nil {
@@ -247,6 +266,37 @@ func getUserExampleIDFromPath(c *gin.Context) (string, uint64, bool) {
 	return idStr, id, false
 }
 
+// checkIfMatch checks the If-Match of request against the current userExample, and sets the version of
+// userExample to ctx, the dao updates the record only if it has not been modified by others since then.
+// It responds 412 if the userExample has been modified or deleted, and responds 500 if it fails to get
+// the userExample, the request is aborted in both cases.
+func (h *userExampleHandler) checkIfMatch(ctx context.Context, c *gin.Context, id uint64) (context.Context, bool) {
+	if !conditional.HasIfMatch(c) {
+		return ctx, false
+	}
+	userExample, err := h.iDao.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, database.ErrRecordNotFound) {
+			logger.Warn("GetByID not found", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			conditional.PreconditionFailed(c)
+		} else {
+			logger.Error("GetByID error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		}
+		return ctx, true
+	}
+	if !conditional.IfMatch(c, userExampleETag(userExample)) {
+		conditional.PreconditionFailed(c)
+		return ctx, true
+	}
+	return optlock.Expect(ctx, "updated_at", userExample.UpdatedAt), false
+}
+
+// userExampleETag the ETag of userExample is derived from id and updated_at
+func userExampleETag(userExample *model.UserExample) string {
+	return conditional.ETag(userExample.ID, userExample.UpdatedAt)
+}
+
 func convertUserExample(userExample *model.UserExample) (*types.UserExampleObjDetail, error) {
 	data := &types.UserExampleObjDetail{}
 	err := copier.Copy(data, userExample)
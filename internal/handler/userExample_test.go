@@ -17,6 +17,7 @@ import (
 	"github.com/go-dev-frame/sponge/internal/cache"
 	"github.com/go-dev-frame/sponge/internal/dao"
 	"github.com/go-dev-frame/sponge/internal/database"
+	"github.com/go-dev-frame/sponge/internal/ecode"
 	"github.com/go-dev-frame/sponge/internal/model"
 	"github.com/go-dev-frame/sponge/internal/types"
 )
@@ -188,6 +189,31 @@ func Test_userExampleHandler_UpdateByID(t *testing.T) {
 	// update error test
 	err = httpcli.Put(result, h.GetRequestURL("UpdateByID", 111), testData)
 	assert.Error(t, err)
+
+	updateIfMatch := func(id uint64) int {
+		resp, err := httpcli.New().SetURL(h.GetRequestURL("UpdateByID", id)).
+			SetHeader("If-Match", `"stale"`).SetBody(testData).PUT()
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// the userExample has been modified since the ETag was read
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").
+		WithArgs(testData.ID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testData.ID))
+	assert.Equal(t, http.StatusPreconditionFailed, updateIfMatch(testData.ID))
+
+	// the userExample has been deleted since the ETag was read
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").
+		WithArgs(112, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	assert.Equal(t, http.StatusPreconditionFailed, updateIfMatch(112))
+
+	// get userExample error test
+	assert.Equal(t, http.StatusInternalServerError, updateIfMatch(113))
 }
 
 func Test_userExampleHandler_GetByID(t *testing.T) {
@@ -258,6 +284,33 @@ func Test_userExampleHandler_List(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func Test_userExampleHandler_Engine(t *testing.T) {
+	h := newUserExampleHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.UserExample)
+
+	// the requests are served by the gin engine in memory, the routing, parameters binding and
+	// the code of response are checked together, routes are the same as newUserExampleHandler.
+	rows := sqlmock.NewRows([]string{"id"}).
+		AddRow(testData.ID)
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").
+		WithArgs(testData.ID, 1).
+		WillReturnRows(rows)
+	h.GET("/userExample/:id", testData.ID).Do().
+		AssertStatus(t, http.StatusOK).
+		AssertCode(t, 0).
+		AssertJSON(t, "data.userExample.id", testData.ID)
+
+	// invalid id
+	h.GET("/userExample/:id", 0).Do().AssertCode(t, ecode.InvalidParams.Code())
+	h.DELETE("/userExample/:id", 0).Do().AssertCode(t, ecode.InvalidParams.Code())
+
+	// invalid json body
+	h.POST("/userExample").WithJSON("{").Do().AssertCode(t, ecode.InvalidParams.Code())
+	h.PUT("/userExample/:id", testData.ID).WithJSON("{").Do().AssertCode(t, ecode.InvalidParams.Code())
+	h.POST("/userExample/list").WithJSON("{").Do().AssertCode(t, ecode.InvalidParams.Code())
+}
+
 func TestNewUserExampleHandler(t *testing.T) {
 	defer func() {
 		recover()
@@ -0,0 +1,48 @@
+// Package job is the scheduled jobs of the cron service, each job is registered in the init
+// function of its file, e.g. userExample.go.
+package job
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// Job a scheduled job
+type Job struct {
+	Name string // job name, it must be unique
+
+	// cron expression with seconds, e.g. "0 */5 * * * *" means every five minutes,
+	// the descriptors such as "@every 10s" and "@daily" are also supported
+	Schedule string
+
+	Timeout   time.Duration // timeout of a run, the ctx of Fn is canceled after the timeout, if 0 means not set
+	Singleton bool          // if true, a run is skipped when the previous run of the job is not finished
+
+	Fn func(ctx context.Context) error // job function, it should return as soon as possible when ctx is canceled
+}
+
+var jobs = make(map[string]Job)
+
+// register the job, it is called in the init function of job files
+func register(job Job) {
+	if job.Name == "" || job.Fn == nil {
+		panic("the name and function of job cannot be empty")
+	}
+	if _, ok := jobs[job.Name]; ok {
+		panic("the job " + job.Name + " is registered repeatedly")
+	}
+	jobs[job.Name] = job
+}
+
+// Jobs returns the registered jobs sorted by name
+func Jobs() []Job {
+	list := make([]Job, 0, len(jobs))
+	for _, job := range jobs {
+		list = append(list, job)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
@@ -0,0 +1,36 @@
+package job
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobs(t *testing.T) {
+	register(Job{Name: "job.test2", Schedule: "@every 1s", Fn: func(ctx context.Context) error { return nil }})
+	register(Job{Name: "job.test1", Schedule: "@every 1s", Fn: func(ctx context.Context) error { return nil }})
+	defer func() {
+		delete(jobs, "job.test1")
+		delete(jobs, "job.test2")
+	}()
+
+	var names []string
+	for _, job := range Jobs() {
+		names = append(names, job.Name)
+	}
+	assert.Subset(t, names, []string{"job.test1", "job.test2"})
+	assert.Less(t, indexOf(names, "job.test1"), indexOf(names, "job.test2"))
+
+	assert.Panics(t, func() { register(Job{Name: "job.test1", Fn: func(ctx context.Context) error { return nil }}) })
+	assert.Panics(t, func() { register(Job{Name: "job.test3"}) })
+}
+
+func indexOf(names []string, name string) int {
+	for i, n := range names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
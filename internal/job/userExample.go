@@ -0,0 +1,29 @@
+package job
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-dev-frame/sponge/pkg/logger"
+)
+
+func init() {
+	register(Job{
+		Name:      "userExampleStat",
+		Schedule:  "0 */5 * * * *",
+		Timeout:   time.Minute,
+		Singleton: true,
+		Fn:        userExampleStat,
+	})
+}
+
+// userExampleStat the job userExampleStat, if an error is returned, the run is recorded as failure,
+// the job is run again at the next scheduled time.
+func userExampleStat(ctx context.Context) error {
+	logger.Info("run job", logger.String("job", "userExampleStat"))
+
+	// fill in the business logic code here, e.g. count the data of yesterday and save it to database,
+	// the job should check ctx.Done() in long loops, so that it can be stopped by the timeout
+
+	return nil
+}
@@ -44,4 +44,7 @@ var UserExampleColumnNames = map[string]bool{
 	"login_at":   true,
 }
 
+// UserExampleSearchColumns columns searched by the q parameter of list api, they are the columns of FULLTEXT index
+var UserExampleSearchColumns = []string{}
+
 // delete the templates code end
@@ -26,10 +26,12 @@ var (
 	// if you have other group routes you can define them here
 	// example:
 	//     apiV2RouterFns []func(r *gin.RouterGroup)
+
+	engineRouterFns []func(r *gin.Engine) // routes that are not in a group, e.g. the static files of frontend
 )
 
-// NewRouter create a new router
-func NewRouter() *gin.Engine {
+// NewRouter create a new router, healthOpts are the dependency probes of health check, e.g. database, redis
+func NewRouter(healthOpts ...handlerfunc.HealthOption) *gin.Engine {
 	r := gin.New()
 
 	r.Use(gin.Recovery())
@@ -94,9 +96,10 @@ func NewRouter() *gin.Engine {
 		prof.Register(r, prof.WithIOWaitTime())
 	}
 
-	r.GET("/health", handlerfunc.CheckHealth)
+	r.GET("/health", handlerfunc.CheckHealthWithProbes(healthOpts...)) // /health?verbose=true lists the status of dependencies
 	r.GET("/ping", handlerfunc.Ping)
 	r.GET("/codes", handlerfunc.ListCodes)
+	r.GET("/codes/catalog", handlerfunc.ListCodesCatalog)
 
 	if config.Get().App.Env != "prod" {
 		r.GET("/config", gin.WrapF(errcode.ShowConfig([]byte(config.Show()))))
@@ -112,6 +115,10 @@ func NewRouter() *gin.Engine {
 	// example:
 	//    registerRouters(r, "/api/v2", apiV2RouteFns, middleware.Auth())
 
+	for _, fn := range engineRouterFns {
+		fn(r)
+	}
+
 	return r
 }
 
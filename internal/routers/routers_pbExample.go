@@ -28,8 +28,8 @@ var (
 	allMiddlewareFns = []func(c *middlewareConfig){}
 )
 
-// NewRouter_pbExample create a new router
-func NewRouter_pbExample() *gin.Engine { //nolint
+// NewRouter_pbExample create a new router, healthOpts are the dependency probes of health check, e.g. database, redis
+func NewRouter_pbExample(healthOpts ...handlerfunc.HealthOption) *gin.Engine { //nolint
 	r := gin.New()
 
 	r.Use(gin.Recovery())
@@ -94,9 +94,10 @@ func NewRouter_pbExample() *gin.Engine { //nolint
 		prof.Register(r, prof.WithIOWaitTime())
 	}
 
-	r.GET("/health", handlerfunc.CheckHealth)
+	r.GET("/health", handlerfunc.CheckHealthWithProbes(healthOpts...)) // /health?verbose=true lists the status of dependencies
 	r.GET("/ping", handlerfunc.Ping)
 	r.GET("/codes", handlerfunc.ListCodes)
+	r.GET("/codes/catalog", handlerfunc.ListCodesCatalog)
 
 	if config.Get().App.Env != "prod" {
 		r.GET("/config", gin.WrapF(errcode.ShowConfig([]byte(config.Show()))))
@@ -10,6 +10,7 @@ import (
 
 	"github.com/go-dev-frame/sponge/internal/config"
 	"github.com/go-dev-frame/sponge/pkg/grpc/grpccli"
+	"github.com/go-dev-frame/sponge/pkg/grpc/interceptor"
 	"github.com/go-dev-frame/sponge/pkg/logger"
 )
 
@@ -50,8 +51,11 @@ func NewServerNameExampleRPCConn() {
 	//	isUseDiscover = true
 	//	endpoint = discoveryEndpoint
 	//	cliOptions = append(cliOptions, discoverOption)
-	//	cliOptions = append(cliOptions, grpccli.WithEnableLoadBalance()) // load balance
+	//	cliOptions = append(cliOptions, grpccli.WithLoadBalancePolicy(grpcClientCfg.LoadBalance)) // load balance
 	//}
+	if !isUseDiscover && grpcClientCfg.LoadBalance != "" {
+		cliOptions = append(cliOptions, grpccli.WithLoadBalancePolicy(grpcClientCfg.LoadBalance))
+	}
 
 	// secure
 	cliOptions = append(cliOptions, grpccli.WithSecure(
@@ -81,6 +85,31 @@ func NewServerNameExampleRPCConn() {
 	if grpcClientCfg.Timeout > 0 {
 		cliOptions = append(cliOptions, grpccli.WithTimeout(time.Second*time.Duration(grpcClientCfg.Timeout)))
 	}
+	if len(grpcClientCfg.MethodTimeouts) > 0 {
+		methodTimeouts := make(map[string]time.Duration, len(grpcClientCfg.MethodTimeouts))
+		for _, method := range grpcClientCfg.MethodTimeouts {
+			methodTimeouts[method.Name] = time.Millisecond * time.Duration(method.Timeout)
+		}
+		cliOptions = append(cliOptions, grpccli.WithMethodTimeouts(methodTimeouts))
+	}
+
+	// keepalive
+	if grpcClientCfg.Keepalive.Time > 0 {
+		cliOptions = append(cliOptions, grpccli.WithKeepalive(
+			time.Second*time.Duration(grpcClientCfg.Keepalive.Time),
+			time.Second*time.Duration(grpcClientCfg.Keepalive.Timeout),
+			grpcClientCfg.Keepalive.PermitWithoutStream,
+		))
+	}
+
+	// retry
+	if grpcClientCfg.Retry.Enable {
+		retryOptions, err := retryPolicyOptions(grpcClientCfg.Retry)
+		if err != nil {
+			panic(fmt.Sprintf("invalid retry settings of grpc service '%s': %v", serverName, err))
+		}
+		cliOptions = append(cliOptions, grpccli.WithRetryPolicy(retryOptions...))
+	}
 
 	msg := "dial grpc server"
 	if isUseDiscover {
@@ -115,7 +144,38 @@ func CloseServerNameExampleRPCConn() error {
 	return serverNameExampleConn.Close()
 }
 
-// discovery service with consul or etcd or nacos, select one of them to use
+// convert the retry settings in configuration file to retry policies, durations are in milliseconds
+func retryPolicyOptions(retry config.Retry) ([]interceptor.RetryPolicyOption, error) {
+	retryableCodes, err := interceptor.ParseCodes(retry.RetryableCodes...)
+	if err != nil {
+		return nil, err
+	}
+	opts := []interceptor.RetryPolicyOption{
+		interceptor.WithDefaultRetryPolicy(interceptor.RetryPolicy{
+			MaxAttempts:    retry.MaxAttempts,
+			InitialBackoff: time.Duration(retry.InitialBackoff) * time.Millisecond,
+			MaxBackoff:     time.Duration(retry.MaxBackoff) * time.Millisecond,
+			RetryableCodes: retryableCodes,
+		}),
+	}
+
+	for _, method := range retry.Methods {
+		retryableCodes, err = interceptor.ParseCodes(method.RetryableCodes...)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, interceptor.WithMethodRetryPolicy(method.Name, interceptor.RetryPolicy{
+			MaxAttempts:    method.MaxAttempts,
+			InitialBackoff: time.Duration(method.InitialBackoff) * time.Millisecond,
+			MaxBackoff:     time.Duration(method.MaxBackoff) * time.Millisecond,
+			RetryableCodes: retryableCodes,
+			HedgingDelay:   time.Duration(method.HedgingDelay) * time.Millisecond,
+		}))
+	}
+	return opts, nil
+}
+
+// discovery service with consul or etcd or nacos or kubernetes, select one of them to use
 //func discoverService(cfg *config.Config, grpcClientCfg config.GrpcClient) (grpccli.Option, string) {
 //	var (
 //		endpoint      string
@@ -153,6 +213,14 @@ func CloseServerNameExampleRPCConn() error {
 //		}
 //		iDiscovery := nacos.New(cli)
 //		grpcCliOption = grpccli.WithDiscovery(iDiscovery)
+//
+//	case "kubernetes":
+//		endpoint = "discovery:///" + grpcClientCfg.Host // format: discovery:///serviceName or discovery:///serviceName.namespace
+//		iDiscovery, err := kubernetes.New()
+//		if err != nil {
+//			panic(fmt.Sprintf("kubernetes.New error: %v", err))
+//		}
+//		grpcCliOption = grpccli.WithDiscovery(iDiscovery)
 //	}
 //
 //	return grpcCliOption, endpoint
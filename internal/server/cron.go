@@ -0,0 +1,415 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/robfig/cron/v3"
+
+	"github.com/go-dev-frame/sponge/pkg/app"
+	"github.com/go-dev-frame/sponge/pkg/logger"
+
+	"github.com/go-dev-frame/sponge/internal/config"
+	"github.com/go-dev-frame/sponge/internal/job"
+)
+
+var _ app.IServer = (*cronServer)(nil)
+
+// trigger types and results of job runs
+const (
+	jobTriggerSchedule = "schedule"
+	jobTriggerManual   = "manual"
+
+	jobResultSuccess = "success"
+	jobResultFailure = "failure"
+	jobResultSkipped = "skipped" // the previous run of singleton job is not finished
+	jobResultPaused  = "paused"
+)
+
+var (
+	jobRunCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cron_job_runs_total",
+			Help: "Total number of job runs, trigger is schedule or manual, result is success, failure, skipped or paused.",
+		},
+		[]string{"job", "trigger", "result"},
+	)
+
+	jobDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "cron_job_duration_seconds",
+			Help:    "Duration of job runs in seconds.",
+			Buckets: []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300, 1800},
+		},
+		[]string{"job"},
+	)
+
+	jobLastRun = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "cron_job_last_run_timestamp_seconds",
+			Help: "Unix timestamp of the last run of jobs.",
+		},
+		[]string{"job"},
+	)
+
+	jobRunning = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "cron_job_running",
+			Help: "Number of running runs of jobs.",
+		},
+		[]string{"job"},
+	)
+
+	registerJobMetricsOnce sync.Once
+)
+
+// the parser of job schedules, the seconds field is required
+var jobScheduleParser = cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+type cronServer struct {
+	cfg   config.Cron
+	jobs  map[string]*cronJob
+	names []string // sorted job names
+	cron  *cron.Cron
+
+	httpServer *http.Server // metrics, health check and admin api, nil if not enabled
+
+	// the running jobs are canceled by cancel after the shutdown timeout
+	ctx      context.Context
+	cancel   context.CancelFunc
+	mu       sync.Mutex
+	stopping bool
+	wg       sync.WaitGroup // running jobs
+	done     chan struct{}
+}
+
+type cronJob struct {
+	job.Job
+	schedule cron.Schedule
+	entryID  cron.EntryID
+
+	paused  atomic.Bool
+	running atomic.Int32
+
+	mu           sync.Mutex
+	lastRun      time.Time
+	lastDuration time.Duration
+	lastError    string
+}
+
+// Start cron service, run the jobs by their schedules until the service is stopped
+func (s *cronServer) Start() error {
+	for _, name := range s.names {
+		j := s.jobs[name]
+		schedule, err := jobScheduleParser.Parse(j.Schedule)
+		if err != nil {
+			return fmt.Errorf("invalid schedule %q of job %s: %v", j.Schedule, name, err)
+		}
+		j.schedule = schedule
+		j.entryID = s.cron.Schedule(schedule, cron.FuncJob(func() { s.run(j, jobTriggerSchedule) }))
+	}
+	s.cron.Start()
+
+	if s.httpServer != nil {
+		go func() {
+			if err := s.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Error("cron http server error", logger.Err(err), logger.String("addr", s.httpServer.Addr))
+			}
+		}()
+	}
+
+	<-s.done
+	return nil
+}
+
+// Stop cron service, stop scheduling and wait for the running jobs, the jobs that are not finished
+// after the shutdown timeout are canceled
+func (s *cronServer) Stop() error {
+	s.mu.Lock()
+	s.stopping = true
+	s.mu.Unlock()
+	defer close(s.done)
+	defer s.cancel()
+
+	s.cron.Stop()
+	finished := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(finished)
+	}()
+
+	timeout := time.Duration(s.cfg.ShutdownTimeout) * time.Second
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	select {
+	case <-finished:
+	case <-time.After(timeout):
+		s.cancel()
+		select {
+		case <-finished:
+		case <-time.After(3 * time.Second):
+			logger.Warn("cron service is stopped before the running jobs are finished")
+		}
+	}
+
+	if s.httpServer != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		return s.httpServer.Shutdown(ctx)
+	}
+	return nil
+}
+
+// String comment
+func (s *cronServer) String() string {
+	return fmt.Sprintf("cron service runs jobs [%s]", strings.Join(s.names, ","))
+}
+
+// NewCronServer creates a new cron server, the jobs are run by their schedules, the schedule, timeout
+// and pause state of jobs can be overridden by the configuration, the jobs can be listed, triggered,
+// paused and resumed by the admin api if the http port is set.
+func NewCronServer(cfg config.Cron, opts ...CronOption) app.IServer {
+	o := defaultCronOptions()
+	o.apply(opts...)
+	registerJobMetricsOnce.Do(func() {
+		prometheus.MustRegister(jobRunCount, jobDuration, jobLastRun, jobRunning)
+	})
+
+	s := &cronServer{
+		cfg:  cfg,
+		jobs: make(map[string]*cronJob, len(o.jobs)),
+		cron: cron.New(cron.WithParser(jobScheduleParser)),
+		done: make(chan struct{}),
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+
+	for _, jb := range o.jobs {
+		s.jobs[jb.Name] = &cronJob{Job: jb}
+		s.names = append(s.names, jb.Name)
+	}
+	sort.Strings(s.names)
+	for _, c := range cfg.Jobs {
+		j, ok := s.jobs[c.Name]
+		if !ok {
+			logger.Warn("the job in configuration is not registered", logger.String("job", c.Name))
+			continue
+		}
+		if c.Schedule != "" {
+			j.Schedule = c.Schedule
+		}
+		if c.Timeout > 0 {
+			j.Timeout = time.Duration(c.Timeout) * time.Second
+		}
+		j.paused.Store(c.Paused)
+	}
+
+	if cfg.HTTPPort > 0 {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.Handler())
+		mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"status":"UP"}`))
+		})
+		mux.HandleFunc("GET /jobs", s.auth(s.listJobs))
+		mux.HandleFunc("POST /jobs/{name}/trigger", s.auth(s.triggerJob))
+		mux.HandleFunc("POST /jobs/{name}/pause", s.auth(s.pauseJob))
+		mux.HandleFunc("POST /jobs/{name}/resume", s.auth(s.resumeJob))
+		s.httpServer = &http.Server{
+			Addr:              ":" + strconv.Itoa(cfg.HTTPPort),
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+	}
+
+	return s
+}
+
+// run the job, the paused job is not run by schedule, but it can be triggered manually
+func (s *cronServer) run(j *cronJob, trigger string) {
+	if trigger == jobTriggerSchedule && j.paused.Load() {
+		jobRunCount.WithLabelValues(j.Name, trigger, jobResultPaused).Inc()
+		return
+	}
+	if j.Singleton {
+		if !j.running.CompareAndSwap(0, 1) {
+			jobRunCount.WithLabelValues(j.Name, trigger, jobResultSkipped).Inc()
+			logger.Warn("the previous run of job is not finished, skip this run", logger.String("job", j.Name),
+				logger.String("trigger", trigger))
+			return
+		}
+	} else {
+		j.running.Add(1)
+	}
+	defer j.running.Add(-1)
+
+	s.mu.Lock()
+	if s.stopping {
+		s.mu.Unlock()
+		return
+	}
+	s.wg.Add(1)
+	s.mu.Unlock()
+	defer s.wg.Done()
+
+	ctx := s.ctx
+	if j.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.Timeout)
+		defer cancel()
+	}
+
+	jobRunning.WithLabelValues(j.Name).Inc()
+	begin := time.Now()
+	err := callJob(ctx, j.Fn)
+	duration := time.Since(begin)
+	jobRunning.WithLabelValues(j.Name).Dec()
+
+	result := jobResultSuccess
+	if err != nil {
+		result = jobResultFailure
+		logger.Error("run job failed", logger.String("job", j.Name), logger.String("trigger", trigger),
+			logger.Duration("duration", duration), logger.Err(err))
+	} else {
+		logger.Info("run job successfully", logger.String("job", j.Name), logger.String("trigger", trigger),
+			logger.Duration("duration", duration))
+	}
+	jobRunCount.WithLabelValues(j.Name, trigger, result).Inc()
+	jobDuration.WithLabelValues(j.Name).Observe(duration.Seconds())
+	jobLastRun.WithLabelValues(j.Name).Set(float64(begin.Unix()))
+
+	j.mu.Lock()
+	j.lastRun = begin
+	j.lastDuration = duration
+	j.lastError = ""
+	if err != nil {
+		j.lastError = err.Error()
+	}
+	j.mu.Unlock()
+}
+
+// callJob calls the job function, the panic is converted to error
+func callJob(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("panic: %v", e)
+		}
+	}()
+	return fn(ctx)
+}
+
+type cronJobInfo struct {
+	Name         string `json:"name"`
+	Schedule     string `json:"schedule"`
+	Timeout      int64  `json:"timeout"` // unit(second)
+	Singleton    bool   `json:"singleton"`
+	Paused       bool   `json:"paused"`
+	Running      int32  `json:"running"`
+	NextRun      string `json:"nextRun,omitempty"`
+	LastRun      string `json:"lastRun,omitempty"`
+	LastDuration int64  `json:"lastDuration"` // unit(millisecond)
+	LastError    string `json:"lastError,omitempty"`
+}
+
+func (s *cronServer) jobInfo(j *cronJob) cronJobInfo {
+	info := cronJobInfo{
+		Name:      j.Name,
+		Schedule:  j.Schedule,
+		Timeout:   int64(j.Timeout / time.Second),
+		Singleton: j.Singleton,
+		Paused:    j.paused.Load(),
+		Running:   j.running.Load(),
+	}
+	if next := s.cron.Entry(j.entryID).Next; !next.IsZero() {
+		info.NextRun = next.Format(time.RFC3339)
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if !j.lastRun.IsZero() {
+		info.LastRun = j.lastRun.Format(time.RFC3339)
+	}
+	info.LastDuration = j.lastDuration.Milliseconds()
+	info.LastError = j.lastError
+	return info
+}
+
+// auth checks the admin token of request if it is set
+func (s *cronServer) auth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.cfg.AdminToken != "" {
+			token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(token), []byte(s.cfg.AdminToken)) != 1 {
+				writeJobResponse(w, http.StatusUnauthorized, "unauthorized", nil)
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+// GET /jobs
+func (s *cronServer) listJobs(w http.ResponseWriter, r *http.Request) {
+	infos := make([]cronJobInfo, 0, len(s.names))
+	for _, name := range s.names {
+		infos = append(infos, s.jobInfo(s.jobs[name]))
+	}
+	writeJobResponse(w, http.StatusOK, "ok", infos)
+}
+
+// POST /jobs/{name}/trigger, the job is run immediately in the background
+func (s *cronServer) triggerJob(w http.ResponseWriter, r *http.Request) {
+	j, ok := s.jobs[r.PathValue("name")]
+	if !ok {
+		writeJobResponse(w, http.StatusNotFound, "job not found", nil)
+		return
+	}
+	if j.Singleton && j.running.Load() > 0 {
+		writeJobResponse(w, http.StatusConflict, "the job is running", nil)
+		return
+	}
+	go s.run(j, jobTriggerManual)
+	writeJobResponse(w, http.StatusOK, "ok", nil)
+}
+
+// POST /jobs/{name}/pause
+func (s *cronServer) pauseJob(w http.ResponseWriter, r *http.Request) {
+	s.setPaused(w, r.PathValue("name"), true)
+}
+
+// POST /jobs/{name}/resume
+func (s *cronServer) resumeJob(w http.ResponseWriter, r *http.Request) {
+	s.setPaused(w, r.PathValue("name"), false)
+}
+
+func (s *cronServer) setPaused(w http.ResponseWriter, name string, paused bool) {
+	j, ok := s.jobs[name]
+	if !ok {
+		writeJobResponse(w, http.StatusNotFound, "job not found", nil)
+		return
+	}
+	j.paused.Store(paused)
+	logger.Info("set job paused", logger.String("job", name), logger.Bool("paused", paused))
+	writeJobResponse(w, http.StatusOK, "ok", s.jobInfo(j))
+}
+
+func writeJobResponse(w http.ResponseWriter, status int, msg string, data interface{}) {
+	code := 0
+	if status != http.StatusOK {
+		code = status
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": code, "msg": msg, "data": data})
+}
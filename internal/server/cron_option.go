@@ -0,0 +1,31 @@
+package server
+
+import (
+	"github.com/go-dev-frame/sponge/internal/job"
+)
+
+// CronOption setting up cron
+type CronOption func(*cronOptions)
+
+type cronOptions struct {
+	jobs []job.Job
+}
+
+func defaultCronOptions() *cronOptions {
+	return &cronOptions{
+		jobs: job.Jobs(),
+	}
+}
+
+func (o *cronOptions) apply(opts ...CronOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WithCronJobs setting up the jobs, default is the jobs registered in internal/job
+func WithCronJobs(jobs ...job.Job) CronOption {
+	return func(o *cronOptions) {
+		o.jobs = jobs
+	}
+}
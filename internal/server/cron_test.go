@@ -0,0 +1,153 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-dev-frame/sponge/pkg/utils"
+
+	"github.com/go-dev-frame/sponge/internal/config"
+	"github.com/go-dev-frame/sponge/internal/job"
+)
+
+func doJobRequest(t *testing.T, method string, url string, token string) (int, map[string]interface{}) {
+	req, _ := http.NewRequest(method, url, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if !assert.NoError(t, err) {
+		return 0, nil
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	result := make(map[string]interface{})
+	_ = json.Unmarshal(body, &result)
+	return resp.StatusCode, result
+}
+
+func TestCronServer(t *testing.T) {
+	var count, failCount atomic.Int32
+	release := make(chan struct{})
+	port, _ := utils.GetAvailablePort()
+	cfg := config.Cron{
+		HTTPPort:        port,
+		AdminToken:      "123456",
+		ShutdownTimeout: 1,
+		Jobs: []config.CronJob{
+			{Name: "cron.fail", Schedule: "@every 1s", Paused: true},
+			{Name: "cron.unknown"},
+		},
+	}
+	s := NewCronServer(cfg, WithCronJobs(
+		job.Job{Name: "cron.count", Schedule: "* * * * * *", Fn: func(ctx context.Context) error {
+			count.Add(1)
+			return nil
+		}},
+		job.Job{Name: "cron.fail", Schedule: "0 0 0 1 1 *", Fn: func(ctx context.Context) error {
+			failCount.Add(1)
+			return errors.New("run error")
+		}},
+		job.Job{Name: "cron.slow", Schedule: "0 0 0 1 1 *", Singleton: true, Timeout: 10 * time.Second, Fn: func(ctx context.Context) error {
+			select {
+			case <-release:
+			case <-ctx.Done():
+			}
+			return ctx.Err()
+		}},
+	)).(*cronServer)
+	assert.Equal(t, "cron service runs jobs [cron.count,cron.fail,cron.slow]", s.String())
+	assert.Equal(t, "@every 1s", s.jobs["cron.fail"].Schedule)
+	assert.True(t, s.jobs["cron.fail"].paused.Load())
+
+	go func() { assert.NoError(t, s.Start()) }()
+	time.Sleep(1500 * time.Millisecond)
+	assert.Greater(t, count.Load(), int32(0))
+	assert.Equal(t, int32(0), failCount.Load()) // paused
+
+	baseURL := fmt.Sprintf("http://127.0.0.1:%d/jobs", port)
+	code, _ := doJobRequest(t, http.MethodGet, baseURL, "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, result := doJobRequest(t, http.MethodGet, baseURL, cfg.AdminToken)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, result["data"], 3)
+
+	// trigger the paused job manually
+	code, _ = doJobRequest(t, http.MethodPost, baseURL+"/cron.fail/trigger", cfg.AdminToken)
+	assert.Equal(t, http.StatusOK, code)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int32(1), failCount.Load())
+	info := s.jobInfo(s.jobs["cron.fail"])
+	assert.Equal(t, "run error", info.LastError)
+	assert.NotEmpty(t, info.LastRun)
+	assert.NotEmpty(t, info.NextRun)
+
+	// resume and pause
+	code, result = doJobRequest(t, http.MethodPost, baseURL+"/cron.fail/resume", cfg.AdminToken)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, false, result["data"].(map[string]interface{})["paused"])
+	code, _ = doJobRequest(t, http.MethodPost, baseURL+"/cron.count/pause", cfg.AdminToken)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = doJobRequest(t, http.MethodPost, baseURL+"/cron.unknown/pause", cfg.AdminToken)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	// the singleton job cannot be triggered while it is running
+	code, _ = doJobRequest(t, http.MethodPost, baseURL+"/cron.slow/trigger", cfg.AdminToken)
+	assert.Equal(t, http.StatusOK, code)
+	time.Sleep(100 * time.Millisecond)
+	code, _ = doJobRequest(t, http.MethodPost, baseURL+"/cron.slow/trigger", cfg.AdminToken)
+	assert.Equal(t, http.StatusConflict, code)
+	s.run(s.jobs["cron.slow"], jobTriggerManual) // skipped
+	close(release)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int32(0), s.jobs["cron.slow"].running.Load())
+
+	for _, path := range []string{"/health", "/metrics"} {
+		code, _ = doJobRequest(t, http.MethodGet, fmt.Sprintf("http://127.0.0.1:%d%s", port, path), "")
+		assert.Equal(t, http.StatusOK, code)
+	}
+
+	assert.NoError(t, s.Stop())
+}
+
+func TestCronServerStop(t *testing.T) {
+	var canceled atomic.Bool
+	s := NewCronServer(config.Cron{ShutdownTimeout: 1}, WithCronJobs(
+		job.Job{Name: "cron.block", Schedule: "0 0 0 1 1 *", Fn: func(ctx context.Context) error {
+			<-ctx.Done()
+			canceled.Store(true)
+			return ctx.Err()
+		}},
+		job.Job{Name: "cron.panic", Schedule: "0 0 0 1 1 *", Fn: func(ctx context.Context) error {
+			panic("oops")
+		}},
+	)).(*cronServer)
+	go func() { _ = s.Start() }()
+	time.Sleep(100 * time.Millisecond)
+
+	s.run(s.jobs["cron.panic"], jobTriggerManual)
+	assert.Equal(t, "panic: oops", s.jobs["cron.panic"].lastError)
+
+	// the running job is canceled after the shutdown timeout
+	go s.run(s.jobs["cron.block"], jobTriggerManual)
+	time.Sleep(100 * time.Millisecond)
+	assert.NoError(t, s.Stop())
+	assert.True(t, canceled.Load())
+}
+
+func TestCronServerInvalidSchedule(t *testing.T) {
+	s := NewCronServer(config.Cron{}, WithCronJobs(
+		job.Job{Name: "cron.invalid", Schedule: "*/5 * * * *", Fn: func(ctx context.Context) error { return nil }},
+	))
+	assert.Error(t, s.Start())
+	assert.NoError(t, s.Stop())
+}
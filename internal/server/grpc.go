@@ -10,6 +10,7 @@ import (
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
 	"google.golang.org/grpc/status"
 
 	"github.com/go-dev-frame/sponge/pkg/app"
@@ -17,6 +18,7 @@ import (
 	"github.com/go-dev-frame/sponge/pkg/grpc/gtls"
 	"github.com/go-dev-frame/sponge/pkg/grpc/interceptor"
 	"github.com/go-dev-frame/sponge/pkg/grpc/metrics"
+	grpcsrv "github.com/go-dev-frame/sponge/pkg/grpc/server"
 	"github.com/go-dev-frame/sponge/pkg/logger"
 	"github.com/go-dev-frame/sponge/pkg/prof"
 	"github.com/go-dev-frame/sponge/pkg/servicerd/registry"
@@ -26,11 +28,17 @@ import (
 	"github.com/go-dev-frame/sponge/internal/service"
 )
 
-var _ app.IServer = (*grpcServer)(nil)
+var (
+	_ app.IServer   = (*grpcServer)(nil)
+	_ app.Registrar = (*grpcServer)(nil)
+)
 
 var (
 	defaultTokenAppID  = "grpc"
 	defaultTokenAppKey = "mko09ijn"
+
+	// the maximum size of request message in bytes, the larger messages are rejected before unmarshal
+	defaultMaxRecvMsgSize = 4 << 20
 )
 
 type grpcServer struct {
@@ -42,20 +50,13 @@ type grpcServer struct {
 	httpServer                      *http.Server
 	registerMetricsMuxAndMethodFunc func() error
 
-	iRegistry registry.Registry
-	instance  *registry.ServiceInstance
+	registrar *registry.Registrar // registered by app after the server is ready
+
+	healthChecker *grpcsrv.HealthChecker
 }
 
 // Start grpc service
 func (s *grpcServer) Start() error {
-	// registration Services
-	if s.iRegistry != nil {
-		ctx, _ := context.WithTimeout(context.Background(), 5*time.Second) //nolint
-		if err := s.iRegistry.Register(ctx, s.instance); err != nil {
-			return err
-		}
-	}
-
 	if s.registerMetricsMuxAndMethodFunc != nil {
 		if err := s.registerMetricsMuxAndMethodFunc(); err != nil {
 			return err
@@ -78,6 +79,10 @@ func (s *grpcServer) Start() error {
 		}()
 	}
 
+	if s.healthChecker != nil {
+		s.healthChecker.Start()
+	}
+
 	listen := metrics.NewCustomListener(s.listen, metrics.WithConnectionsLogger(logger.Get()), metrics.WithConnectionsGauge())
 	if err := s.server.Serve(listen); err != nil { // block
 		return err
@@ -88,15 +93,16 @@ func (s *grpcServer) Start() error {
 
 // Stop grpc service
 func (s *grpcServer) Stop() error {
-	if s.iRegistry != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-		go func() {
-			_ = s.iRegistry.Deregister(ctx, s.instance)
-			cancel()
-		}()
-		<-ctx.Done()
+	// set the health status to NOT_SERVING first, so that load balancers stop routing requests to this instance
+	if s.healthChecker != nil {
+		s.healthChecker.Shutdown()
 	}
 
+	// the service has been deregistered by app before stopping, this is for stopping without app
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	_ = s.Deregister(ctx)
+	cancel()
+
 	s.server.GracefulStop()
 
 	if s.httpServer != nil {
@@ -109,6 +115,22 @@ func (s *grpcServer) Stop() error {
 	return nil
 }
 
+// Register the service to the registry, it is called by app after all servers are ready.
+func (s *grpcServer) Register(ctx context.Context) error {
+	if s.registrar == nil {
+		return nil
+	}
+	return s.registrar.Register(ctx)
+}
+
+// Deregister the service from the registry, it is called by app before stopping servers.
+func (s *grpcServer) Deregister(ctx context.Context) error {
+	if s.registrar == nil {
+		return nil
+	}
+	return s.registrar.Deregister(ctx)
+}
+
 // String comment
 func (s *grpcServer) String() string {
 	return "grpc service address " + s.addr
@@ -211,6 +233,13 @@ func (s *grpcServer) unaryServerOptions() grpc.ServerOption {
 		))
 	}
 
+	// validate interceptor, the request messages are validated by their validate rules before calling methods,
+	// it is optional because the generated service methods have called req.Validate(), remove the calls if it is used
+	//unaryServerInterceptors = append(unaryServerInterceptors, interceptor.UnaryServerValidate(
+	//interceptor.WithMethodMaxRequestSize("/api.user.v1.User/Upload", 1<<20), // maximum request size of the method in bytes
+	//interceptor.WithRequireDeadline(), // reject the requests without deadline
+	//))
+
 	// trace interceptor
 	if config.Get().App.EnableTrace {
 		unaryServerInterceptors = append(unaryServerInterceptors, interceptor.UnaryServerTracing())
@@ -272,6 +301,9 @@ func (s *grpcServer) streamServerOptions() grpc.ServerOption {
 		))
 	}
 
+	// validate interceptor, each received message is validated by its validate rules, it is optional
+	//streamServerInterceptors = append(streamServerInterceptors, interceptor.StreamServerValidate())
+
 	// trace interceptor
 	if config.Get().App.EnableTrace {
 		streamServerInterceptors = append(streamServerInterceptors, interceptor.StreamServerTracing())
@@ -288,6 +320,7 @@ func (s *grpcServer) setOptions() []grpc.ServerOption {
 		options = append(options, secureOption)
 	}
 
+	options = append(options, grpc.MaxRecvMsgSize(defaultMaxRecvMsgSize))
 	options = append(options, s.unaryServerOptions())
 	options = append(options, s.streamServerOptions())
 
@@ -315,7 +348,8 @@ func (s *grpcServer) addHTTPRouter() {
 	if s.mux == nil {
 		s.mux = http.NewServeMux()
 	}
-	s.mux.HandleFunc("/codes", errcode.ListGRPCErrCodes) // error codes router
+	s.mux.HandleFunc("/codes", errcode.ListGRPCErrCodes)               // error codes router
+	s.mux.HandleFunc("/codes/catalog", errcode.ListCodeCatalogHandler) // error codes catalog router
 
 	cfgStr := config.Show()
 	s.mux.HandleFunc("/config", errcode.ShowConfig([]byte(cfgStr))) // config router
@@ -327,9 +361,10 @@ func NewGRPCServer(addr string, opts ...GrpcOption) app.IServer {
 	o := defaultGrpcOptions()
 	o.apply(opts...)
 	s := &grpcServer{
-		addr:      addr,
-		iRegistry: o.iRegistry,
-		instance:  o.instance,
+		addr: addr,
+	}
+	if o.iRegistry != nil {
+		s.registrar = registry.NewRegistrar(o.iRegistry, o.instance)
 	}
 	s.addHTTPRouter()
 	if config.Get().App.EnableHTTPProfile {
@@ -343,5 +378,16 @@ func NewGRPCServer(addr string, opts ...GrpcOption) app.IServer {
 
 	s.server = grpc.NewServer(s.setOptions()...)
 	service.RegisterAllService(s.server) // register for all services
+
+	// register health service, the status of services is updated by the dependency probes
+	if !config.Get().Grpc.DisableHealth {
+		healthOptions := append([]grpcsrv.HealthOption{grpcsrv.WithHealthLogger(logger.Get())}, o.healthProbes...)
+		s.healthChecker = grpcsrv.NewHealthChecker(healthOptions...)
+		s.healthChecker.Register(s.server)
+	}
+	// register reflection service, used by tools such as grpcurl
+	if config.Get().Grpc.EnableReflection {
+		reflection.Register(s.server)
+	}
 	return s
 }
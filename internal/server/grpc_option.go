@@ -1,6 +1,9 @@
 package server
 
 import (
+	"context"
+
+	grpcsrv "github.com/go-dev-frame/sponge/pkg/grpc/server"
 	"github.com/go-dev-frame/sponge/pkg/servicerd/registry"
 )
 
@@ -8,14 +11,16 @@ import (
 type GrpcOption func(*grpcOptions)
 
 type grpcOptions struct {
-	instance  *registry.ServiceInstance
-	iRegistry registry.Registry
+	instance     *registry.ServiceInstance
+	iRegistry    registry.Registry
+	healthProbes []grpcsrv.HealthOption
 }
 
 func defaultGrpcOptions() *grpcOptions {
 	return &grpcOptions{
-		instance:  nil,
-		iRegistry: nil,
+		instance:     nil,
+		iRegistry:    nil,
+		healthProbes: nil,
 	}
 }
 
@@ -32,3 +37,11 @@ func WithGrpcRegistry(iRegistry registry.Registry, instance *registry.ServiceIns
 		o.instance = instance
 	}
 }
+
+// WithGrpcHealthProbe add a dependency probe, e.g. database, redis, the grpc health status of
+// services is set to NOT_SERVING if the probe fails, invalid when grpc.disableHealth is true
+func WithGrpcHealthProbe(name string, probe func(ctx context.Context) error) GrpcOption {
+	return func(o *grpcOptions) {
+		o.healthProbes = append(o.healthProbes, grpcsrv.WithHealthProbe(name, probe))
+	}
+}
@@ -30,6 +30,7 @@ func TestGRPCServer(t *testing.T) {
 	config.Get().App.EnableLimit = true
 	config.Get().App.EnableCircuitBreaker = true
 	config.Get().Grpc.EnableToken = true
+	config.Get().Grpc.EnableReflection = true
 
 	port, _ := utils.GetAvailablePort()
 	addr := fmt.Sprintf(":%d", port)
@@ -38,6 +39,7 @@ func TestGRPCServer(t *testing.T) {
 	utils.SafeRunWithTimeout(time.Second*2, func(cancel context.CancelFunc) {
 		server := NewGRPCServer(addr,
 			WithGrpcRegistry(nil, instance),
+			WithGrpcHealthProbe("database", func(ctx context.Context) error { return nil }),
 		)
 		assert.NotNil(t, server)
 		cancel()
@@ -65,8 +67,7 @@ func TestGRPCServerMock(t *testing.T) {
 
 	s := &grpcServer{
 		addr:      addr,
-		iRegistry: o.iRegistry,
-		instance:  o.instance,
+		registrar: registry.NewRegistrar(o.iRegistry, o.instance),
 	}
 
 	s.listen, err = net.Listen("tcp", addr)
@@ -82,6 +83,7 @@ func TestGRPCServerMock(t *testing.T) {
 
 	str := s.String()
 	assert.NotEmpty(t, str)
+	assert.NoError(t, s.Register(context.Background()))
 	err = s.Start()
 	assert.NoError(t, err)
 	err = s.Stop()
@@ -16,25 +16,20 @@ import (
 	"github.com/go-dev-frame/sponge/internal/routers"
 )
 
-var _ app.IServer = (*httpServer)(nil)
+var (
+	_ app.IServer   = (*httpServer)(nil)
+	_ app.Registrar = (*httpServer)(nil)
+)
 
 type httpServer struct {
 	addr   string
 	server *httpsrv.Server
 
-	instance  *registry.ServiceInstance
-	iRegistry registry.Registry
+	registrar *registry.Registrar // registered by app after the server is ready
 }
 
 // Start http service
 func (s *httpServer) Start() error {
-	if s.iRegistry != nil {
-		ctx, _ := context.WithTimeout(context.Background(), 5*time.Second) //nolint
-		if err := s.iRegistry.Register(ctx, s.instance); err != nil {
-			return err
-		}
-	}
-
 	if err := s.server.Run(); err != nil {
 		return fmt.Errorf("run %s service error: %v", s.server.Scheme(), err)
 	}
@@ -43,19 +38,32 @@ func (s *httpServer) Start() error {
 
 // Stop http service
 func (s *httpServer) Stop() error {
-	if s.iRegistry != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-		go func() {
-			_ = s.iRegistry.Deregister(ctx, s.instance)
-			cancel()
-		}()
-		<-ctx.Done()
-	}
+	// the service has been deregistered by app before stopping, this is for stopping without app
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	_ = s.Deregister(ctx)
+	cancel()
 
-	ctx, _ := context.WithTimeout(context.Background(), 3*time.Second) //nolint
+	ctx, cancel = context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
 	return s.server.Shutdown(ctx)
 }
 
+// Register the service to the registry, it is called by app after all servers are ready.
+func (s *httpServer) Register(ctx context.Context) error {
+	if s.registrar == nil {
+		return nil
+	}
+	return s.registrar.Register(ctx)
+}
+
+// Deregister the service from the registry, it is called by app before stopping servers.
+func (s *httpServer) Deregister(ctx context.Context) error {
+	if s.registrar == nil {
+		return nil
+	}
+	return s.registrar.Deregister(ctx)
+}
+
 // String comment
 func (s *httpServer) String() string {
 	return s.server.Scheme() + " service address is " + s.addr
@@ -78,7 +86,17 @@ func newServer(server *http.Server, tls config.TLS) *httpsrv.Server {
 	case httpsrv.ModeTLSExternal:
 		c = httpsrv.New(server, httpsrv.NewTLSExternalConfig(tls.CertFile, tls.KeyFile))
 	default:
-		c = httpsrv.New(server) // default is http, no tls
+		return httpsrv.New(server) // default is http, no tls
+	}
+
+	// also listen on the http port, redirect to https or serve over plain http
+	if tls.HTTPPort > 0 {
+		httpAddr := fmt.Sprintf(":%d", tls.HTTPPort)
+		if tls.HTTPRedirect {
+			c.EnableHTTPRedirect(httpAddr)
+		} else {
+			c.EnableHTTP(httpAddr)
+		}
 	}
 	return c
 }
@@ -94,7 +112,7 @@ func NewHTTPServer(addr string, opts ...HTTPOption) app.IServer {
 		gin.SetMode(gin.DebugMode)
 	}
 
-	router := routers.NewRouter()
+	router := routers.NewRouter(o.healthProbes...)
 	server := &http.Server{
 		Addr:    addr,
 		Handler: router,
@@ -104,12 +122,14 @@ func NewHTTPServer(addr string, opts ...HTTPOption) app.IServer {
 		MaxHeaderBytes: 1 << 20,
 	}
 
-	return &httpServer{
-		addr:      addr,
-		server:    newServer(server, o.tls),
-		iRegistry: o.iRegistry,
-		instance:  o.instance,
+	s := &httpServer{
+		addr:   addr,
+		server: newServer(server, o.tls).SetMaxConns(o.maxConns).SetMaxRequests(o.maxRequests),
 	}
+	if o.iRegistry != nil {
+		s.registrar = registry.NewRegistrar(o.iRegistry, o.instance)
+	}
+	return s
 }
 
 // delete the templates code start
@@ -125,7 +145,7 @@ func NewHTTPServer_pbExample(addr string, opts ...HTTPOption) app.IServer { //no
 		gin.SetMode(gin.DebugMode)
 	}
 
-	router := routers.NewRouter_pbExample()
+	router := routers.NewRouter_pbExample(o.healthProbes...)
 	server := &http.Server{
 		Addr:    addr,
 		Handler: router,
@@ -135,12 +155,14 @@ func NewHTTPServer_pbExample(addr string, opts ...HTTPOption) app.IServer { //no
 		MaxHeaderBytes: 1 << 20,
 	}
 
-	return &httpServer{
-		addr:      addr,
-		server:    newServer(server, o.tls),
-		iRegistry: o.iRegistry,
-		instance:  o.instance,
+	s := &httpServer{
+		addr:   addr,
+		server: newServer(server, o.tls).SetMaxConns(o.maxConns).SetMaxRequests(o.maxRequests),
+	}
+	if o.iRegistry != nil {
+		s.registrar = registry.NewRegistrar(o.iRegistry, o.instance)
 	}
+	return s
 }
 
 // delete the templates code end
@@ -1,6 +1,9 @@
 package server
 
 import (
+	"context"
+
+	"github.com/go-dev-frame/sponge/pkg/gin/handlerfunc"
 	"github.com/go-dev-frame/sponge/pkg/servicerd/registry"
 
 	"github.com/go-dev-frame/sponge/internal/config"
@@ -10,10 +13,13 @@ import (
 type HTTPOption func(*httpOptions)
 
 type httpOptions struct {
-	isProd    bool
-	instance  *registry.ServiceInstance
-	iRegistry registry.Registry
-	tls       config.TLS
+	isProd       bool
+	instance     *registry.ServiceInstance
+	iRegistry    registry.Registry
+	tls          config.TLS
+	maxConns     int
+	maxRequests  int
+	healthProbes []handlerfunc.HealthOption
 }
 
 func defaultHTTPOptions() *httpOptions {
@@ -51,3 +57,20 @@ func WithHTTPTLS(tls config.TLS) HTTPOption {
 		o.tls = tls
 	}
 }
+
+// WithHTTPConnLimit setting up the maximum number of concurrent connections and requests, the requests
+// over the limits are rejected with 503 and Retry-After, 0 means no limit
+func WithHTTPConnLimit(maxConns int, maxRequests int) HTTPOption {
+	return func(o *httpOptions) {
+		o.maxConns = maxConns
+		o.maxRequests = maxRequests
+	}
+}
+
+// WithHTTPHealthProbe add a dependency probe of health check, e.g. database, redis, the status of
+// /health is DOWN and the http status code is 503 if the probe fails
+func WithHTTPHealthProbe(name string, probe func(ctx context.Context) error) HTTPOption {
+	return func(o *httpOptions) {
+		o.healthProbes = append(o.healthProbes, handlerfunc.WithHealthProbe(name, probe))
+	}
+}
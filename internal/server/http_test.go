@@ -37,6 +37,7 @@ func TestHTTPServer(t *testing.T) {
 		server := NewHTTPServer(addr,
 			WithHTTPIsProd(true),
 			WithHTTPRegistry(&iRegistry{}, &registry.ServiceInstance{}),
+			WithHTTPConnLimit(100, 100),
 		)
 		assert.NotNil(t, server)
 		cancel()
@@ -51,6 +52,7 @@ func TestHTTPServer(t *testing.T) {
 		server := NewHTTPServer_pbExample(addr,
 			WithHTTPIsProd(true),
 			WithHTTPRegistry(&iRegistry{}, &registry.ServiceInstance{}),
+			WithHTTPConnLimit(100, 100),
 		)
 		assert.NotNil(t, server)
 		cancel()
@@ -82,8 +84,7 @@ func TestHTTPServerMock(t *testing.T) {
 	}
 	s := &httpServer{
 		addr:      addr,
-		instance:  &registry.ServiceInstance{},
-		iRegistry: &iRegistry{},
+		registrar: registry.NewRegistrar(&iRegistry{}, &registry.ServiceInstance{}),
 	}
 	server := &http.Server{
 		Addr:           addr,
@@ -99,6 +100,7 @@ func TestHTTPServerMock(t *testing.T) {
 
 	str := s.String()
 	assert.NotEmpty(t, str)
+	assert.NoError(t, s.Register(context.Background()))
 	err = s.Start()
 	assert.NoError(t, err)
 	err = s.Stop()
@@ -151,6 +153,17 @@ func Test_newServer(t *testing.T) {
 			},
 			scheme: "https",
 		},
+		{
+			name: "tls_external_with_http_redirect",
+			tls: config.TLS{
+				EnableMode:   "external",
+				CertFile:     "cert.pem",
+				KeyFile:      "key.pem",
+				HTTPPort:     8081,
+				HTTPRedirect: true,
+			},
+			scheme: "https",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,238 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/go-dev-frame/sponge/pkg/app"
+	"github.com/go-dev-frame/sponge/pkg/kafka"
+	"github.com/go-dev-frame/sponge/pkg/logger"
+	"github.com/go-dev-frame/sponge/pkg/mq/consumer"
+
+	"github.com/go-dev-frame/sponge/internal/config"
+)
+
+var _ app.IServer = (*workerServer)(nil)
+
+type workerServer struct {
+	cfg     config.Worker
+	topics  []string // business topics and the retry topic
+	options []consumer.Option
+	handler consumer.Handler
+
+	httpServer *http.Server // metrics and health check, nil if not enabled
+
+	// consuming is stopped by cancel, the messages being handled are canceled by handleCancel after the shutdown timeout
+	ctx          context.Context
+	cancel       context.CancelFunc
+	handleCtx    context.Context
+	handleCancel context.CancelFunc
+	done         chan struct{}
+}
+
+// Start worker service, consume messages until the service is stopped
+func (s *workerServer) Start() error {
+	defer close(s.done)
+
+	kafkaCfg := s.cfg.Kafka
+	offsetsInitial := sarama.OffsetNewest
+	if kafkaCfg.OffsetsInitial == "oldest" {
+		offsetsInitial = sarama.OffsetOldest
+	}
+	consumerGroup, err := kafka.InitConsumerGroup(kafkaCfg.Addrs, kafkaCfg.GroupID,
+		kafka.ConsumerWithOffsetsInitial(offsetsInitial),
+		kafka.ConsumerWithZapLogger(logger.Get()),
+	)
+	if err != nil {
+		return fmt.Errorf("init kafka consumer group error: %v", err)
+	}
+	defer consumerGroup.Close() //nolint
+
+	// the failed messages are published to the retry topic or dead-letter topic
+	producer, err := kafka.InitSyncProducer(kafkaCfg.Addrs)
+	if err != nil {
+		return fmt.Errorf("init kafka producer error: %v", err)
+	}
+	defer producer.Close() //nolint
+
+	options := append([]consumer.Option{consumer.WithPublisher(kafkaPublisher(producer))}, s.options...)
+	group := consumer.NewGroup(kafkaCfg.GroupID, s.handler, options...)
+
+	if s.httpServer != nil {
+		go func() {
+			if err := s.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Error("worker http server error", logger.Err(err), logger.String("addr", s.httpServer.Addr))
+			}
+		}()
+	}
+
+	consumerGroup.ConsumeCustomLoop(s.ctx, s.topics, &workerHandler{ctx: s.handleCtx, group: group})
+	return nil
+}
+
+// Stop worker service, stop consuming and wait for the messages being handled, the unfinished
+// messages are not committed, they are consumed again after the service restarts
+func (s *workerServer) Stop() error {
+	s.cancel()
+	defer s.handleCancel()
+
+	timeout := time.Duration(s.cfg.ShutdownTimeout) * time.Second
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	select {
+	case <-s.done:
+	case <-time.After(timeout):
+		s.handleCancel()
+		select {
+		case <-s.done:
+		case <-time.After(3 * time.Second):
+			logger.Warn("worker service is stopped before the messages being handled are finished")
+		}
+	}
+
+	if s.httpServer != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		return s.httpServer.Shutdown(ctx)
+	}
+	return nil
+}
+
+// String comment
+func (s *workerServer) String() string {
+	return fmt.Sprintf("worker service consumes topics [%s] of kafka, group id is %s",
+		strings.Join(s.topics, ","), s.cfg.Kafka.GroupID)
+}
+
+// NewWorkerServer creates a new worker server, the messages of topics are consumed from kafka by consumer group,
+// a failed message is published to the retry topic and handled again after the backoff, after the maximum
+// attempts, it is published to the dead-letter topic.
+func NewWorkerServer(cfg config.Worker, opts ...WorkerOption) app.IServer {
+	o := defaultWorkerOptions()
+	o.apply(opts...)
+
+	retryTopic := cfg.RetryTopic
+	if retryTopic == "" {
+		retryTopic = cfg.Kafka.GroupID + ".retry"
+	}
+	options := []consumer.Option{
+		consumer.WithConcurrency(cfg.Concurrency),
+		consumer.WithMaxAttempts(cfg.MaxAttempts),
+		consumer.WithRetryBackoff(time.Duration(cfg.InitialBackoff)*time.Millisecond, time.Duration(cfg.MaxBackoff)*time.Millisecond),
+		consumer.WithHandleTimeout(time.Duration(cfg.HandleTimeout) * time.Millisecond),
+		consumer.WithRetryTopic(retryTopic),
+		consumer.WithLogger(logger.Get()),
+	}
+	if cfg.DeadLetterTopic != "" {
+		options = append(options, consumer.WithDeadLetterTopic(cfg.DeadLetterTopic))
+	}
+	if o.deadLetterHandler != nil {
+		options = append(options, consumer.WithDeadLetterHandler(o.deadLetterHandler))
+	}
+
+	s := &workerServer{
+		cfg:     cfg,
+		topics:  append(append([]string{}, o.topics...), retryTopic),
+		options: options,
+		handler: o.handler,
+		done:    make(chan struct{}),
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	s.handleCtx, s.handleCancel = context.WithCancel(context.Background())
+
+	if cfg.HTTPPort > 0 {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.Handler())
+		mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"status":"UP"}`))
+		})
+		s.httpServer = &http.Server{
+			Addr:              ":" + strconv.Itoa(cfg.HTTPPort),
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+	}
+
+	return s
+}
+
+// workerHandler handles the messages of claimed partitions in order, the offset of a message is
+// marked after it is handled or routed to the retry or dead-letter topic
+type workerHandler struct {
+	ctx   context.Context
+	group *consumer.Group
+}
+
+// Setup is run at the beginning of a new session, before ConsumeClaim
+func (h *workerHandler) Setup(sarama.ConsumerGroupSession) error { return nil }
+
+// Cleanup is run at the end of a session, once all ConsumeClaim goroutines have exited
+func (h *workerHandler) Cleanup(sarama.ConsumerGroupSession) error { return nil }
+
+// ConsumeClaim consumes the messages of a partition
+func (h *workerHandler) ConsumeClaim(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for {
+		select {
+		case <-sess.Context().Done():
+			return nil
+		case m, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
+			headers := make(map[string]string, len(m.Headers))
+			for _, header := range m.Headers {
+				headers[string(header.Key)] = string(header.Value)
+			}
+			msg := &consumer.Message{Topic: m.Topic, Key: string(m.Key), Body: m.Value, Headers: headers}
+			if !h.process(sess.Context(), msg) {
+				// the offset is not marked, the message is consumed again in the next session
+				return nil
+			}
+			sess.MarkMessage(m, "")
+		}
+	}
+}
+
+// process the message until it is handled or routed to the retry or dead-letter topic, e.g. the publishing
+// fails when kafka is unavailable, it returns false if the session or the service is stopped
+func (h *workerHandler) process(sessCtx context.Context, msg *consumer.Message) bool {
+	for {
+		err := h.group.Process(h.ctx, msg)
+		if err == nil {
+			return true
+		}
+		if h.ctx.Err() != nil {
+			return false
+		}
+		logger.Warn("process message error, try again later", logger.Err(err), logger.String("topic", msg.Topic), logger.String("key", msg.Key))
+		select {
+		case <-time.After(time.Second):
+		case <-sessCtx.Done():
+			return false
+		}
+	}
+}
+
+func kafkaPublisher(producer *kafka.SyncProducer) consumer.Publisher {
+	return consumer.PublisherFunc(func(ctx context.Context, topic string, msg *consumer.Message) error {
+		pm := &sarama.ProducerMessage{Topic: topic, Value: sarama.ByteEncoder(msg.Body)}
+		if msg.Key != "" {
+			pm.Key = sarama.StringEncoder(msg.Key)
+		}
+		for k, v := range msg.Headers {
+			pm.Headers = append(pm.Headers, sarama.RecordHeader{Key: []byte(k), Value: []byte(v)})
+		}
+		_, _, err := producer.SendMessage(pm)
+		return err
+	})
+}
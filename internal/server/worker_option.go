@@ -0,0 +1,47 @@
+package server
+
+import (
+	"context"
+
+	"github.com/go-dev-frame/sponge/pkg/mq/consumer"
+
+	"github.com/go-dev-frame/sponge/internal/worker"
+)
+
+// WorkerOption setting up worker
+type WorkerOption func(*workerOptions)
+
+type workerOptions struct {
+	topics            []string
+	handler           consumer.Handler
+	deadLetterHandler func(ctx context.Context, msg *consumer.Message, err error) error
+}
+
+func defaultWorkerOptions() *workerOptions {
+	return &workerOptions{
+		topics:  worker.Topics(),
+		handler: worker.Handle,
+	}
+}
+
+func (o *workerOptions) apply(opts ...WorkerOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WithWorkerHandler setting up the topics and the handler of messages, default is the handlers registered in internal/worker
+func WithWorkerHandler(topics []string, handler consumer.Handler) WorkerOption {
+	return func(o *workerOptions) {
+		o.topics = topics
+		o.handler = handler
+	}
+}
+
+// WithWorkerDeadLetterHandler setting up the function called when a message fails after the maximum attempts,
+// e.g. save it to database for manual processing, it is called after the message is published to the dead-letter topic
+func WithWorkerDeadLetterHandler(fn func(ctx context.Context, msg *consumer.Message, err error) error) WorkerOption {
+	return func(o *workerOptions) {
+		o.deadLetterHandler = fn
+	}
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-dev-frame/sponge/pkg/mq/consumer"
+	"github.com/go-dev-frame/sponge/pkg/utils"
+
+	"github.com/go-dev-frame/sponge/internal/config"
+)
+
+func TestWorkerServer(t *testing.T) {
+	port, _ := utils.GetAvailablePort()
+	cfg := config.Worker{
+		HTTPPort:        port,
+		ShutdownTimeout: 1,
+		Kafka:           config.Kafka{Addrs: []string{"127.0.0.1:1"}, GroupID: "serverNameExample"},
+	}
+	server := NewWorkerServer(cfg,
+		WithWorkerHandler([]string{"order.created"}, func(ctx context.Context, msg *consumer.Message) error { return nil }),
+		WithWorkerDeadLetterHandler(func(ctx context.Context, msg *consumer.Message, err error) error { return nil }),
+	)
+	assert.Contains(t, server.String(), "order.created,serverNameExample.retry")
+
+	// kafka is unavailable
+	assert.Error(t, server.Start())
+	assert.NoError(t, server.Stop())
+}
+
+func TestWorkerServerHTTP(t *testing.T) {
+	port, _ := utils.GetAvailablePort()
+	s := NewWorkerServer(config.Worker{HTTPPort: port}).(*workerServer)
+	go func() { _ = s.httpServer.ListenAndServe() }()
+	time.Sleep(100 * time.Millisecond)
+
+	for _, path := range []string{"/health", "/metrics"} {
+		resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d%s", port, path))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		_, _ = io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+	}
+
+	close(s.done)
+	assert.NoError(t, s.Stop())
+}
+
+type fakeSession struct {
+	ctx    context.Context
+	mu     sync.Mutex
+	marked []int64
+}
+
+func (s *fakeSession) Claims() map[string][]int32               { return nil }
+func (s *fakeSession) MemberID() string                         { return "" }
+func (s *fakeSession) GenerationID() int32                      { return 0 }
+func (s *fakeSession) MarkOffset(string, int32, int64, string)  {}
+func (s *fakeSession) Commit()                                  {}
+func (s *fakeSession) ResetOffset(string, int32, int64, string) {}
+func (s *fakeSession) Context() context.Context                 { return s.ctx }
+func (s *fakeSession) MarkMessage(msg *sarama.ConsumerMessage, metadata string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.marked = append(s.marked, msg.Offset)
+}
+
+type fakeClaim struct {
+	messages chan *sarama.ConsumerMessage
+}
+
+func (c *fakeClaim) Topic() string                            { return "order.created" }
+func (c *fakeClaim) Partition() int32                         { return 0 }
+func (c *fakeClaim) InitialOffset() int64                     { return 0 }
+func (c *fakeClaim) HighWaterMarkOffset() int64               { return 0 }
+func (c *fakeClaim) Messages() <-chan *sarama.ConsumerMessage { return c.messages }
+
+func TestWorkerHandler(t *testing.T) {
+	var headers []string
+	group := consumer.NewGroup("order", func(ctx context.Context, msg *consumer.Message) error {
+		headers = append(headers, msg.Headers["trace"])
+		if msg.Key == "error" {
+			return errors.New("handle error")
+		}
+		return nil
+	}, consumer.WithMaxAttempts(1))
+
+	sess := &fakeSession{ctx: context.Background()}
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 3)}
+	claim.messages <- &sarama.ConsumerMessage{Topic: "order.created", Offset: 1,
+		Headers: []*sarama.RecordHeader{{Key: []byte("trace"), Value: []byte("abc")}}}
+	claim.messages <- &sarama.ConsumerMessage{Topic: "order.created", Key: []byte("error"), Offset: 2} // routed to dead-letter
+	claim.messages <- &sarama.ConsumerMessage{Topic: "order.created", Offset: 3}
+	close(claim.messages)
+
+	h := &workerHandler{ctx: context.Background(), group: group}
+	assert.NoError(t, h.Setup(sess))
+	assert.NoError(t, h.ConsumeClaim(sess, claim))
+	assert.NoError(t, h.Cleanup(sess))
+	assert.Equal(t, []int64{1, 2, 3}, sess.marked)
+	assert.Equal(t, []string{"abc", "", ""}, headers)
+
+	// the message is not marked if the service is stopped while it is waiting for the retry backoff
+	ctx, cancel := context.WithCancel(context.Background())
+	sess = &fakeSession{ctx: ctx}
+	claim = &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 1)}
+	notBefore := fmt.Sprintf("%d", time.Now().Add(time.Hour).UnixMilli())
+	claim.messages <- &sarama.ConsumerMessage{Topic: "order.retry", Offset: 4, Headers: []*sarama.RecordHeader{
+		{Key: []byte(consumer.HeaderNotBefore), Value: []byte(notBefore)},
+		{Key: []byte(consumer.HeaderAttempt), Value: []byte("1")},
+	}}
+	h = &workerHandler{ctx: ctx, group: group}
+	time.AfterFunc(50*time.Millisecond, cancel)
+	assert.NoError(t, h.ConsumeClaim(sess, claim))
+	assert.Empty(t, sess.marked)
+}
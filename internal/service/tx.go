@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/go-dev-frame/sponge/internal/database"
+)
+
+// Tx a database transaction, the daos of models executing in the transaction are got by
+// the methods of Tx, e.g. tx.UserExampleDao(), they are only valid in the function of WithTx.
+type Tx struct {
+	DB *gorm.DB // the db of transaction, it is used for the operations that are not provided by dao
+}
+
+// WithTx execute fn in a transaction, the transaction is committed if fn returns nil,
+// otherwise it is rolled back, if fn panics, the transaction is rolled back and the panic is propagated.
+//
+// Example:
+//
+//	err := WithTx(ctx, func(tx *Tx) error {
+//		if err := tx.UserExampleDao().Create(ctx, user); err != nil {
+//			return err
+//		}
+//		return tx.OrderDao().Create(ctx, order)
+//	})
+func WithTx(ctx context.Context, fn func(tx *Tx) error) error {
+	return withTx(ctx, database.GetDB(), fn)
+}
+
+func withTx(ctx context.Context, db *gorm.DB, fn func(tx *Tx) error) error {
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&Tx{DB: tx})
+	})
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-dev-frame/sponge/pkg/gotest"
+)
+
+func Test_withTx(t *testing.T) {
+	d := gotest.NewDao(nil, nil)
+	defer d.Close()
+
+	// commit
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectCommit()
+	err := withTx(context.Background(), d.DB, func(tx *Tx) error {
+		return tx.DB.Exec("UPDATE user_example SET name = ? WHERE id = ?", "foo", 1).Error
+	})
+	assert.NoError(t, err)
+
+	// rollback
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectRollback()
+	err = withTx(context.Background(), d.DB, func(tx *Tx) error {
+		if err := tx.DB.Exec("UPDATE user_example SET name = ? WHERE id = ?", "foo", 1).Error; err != nil {
+			return err
+		}
+		return errors.New("business error")
+	})
+	assert.EqualError(t, err, "business error")
+
+	// rollback when panic
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectRollback()
+	assert.Panics(t, func() {
+		_ = withTx(context.Background(), d.DB, func(tx *Tx) error {
+			panic("oops")
+		})
+	})
+
+	assert.NoError(t, d.SQLMock.ExpectationsWereMet())
+}
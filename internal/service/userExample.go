@@ -46,6 +46,11 @@ func NewUserExampleServer() serverNameExampleV1.UserExampleServer {
 	}
 }
 
+// UserExampleDao get the dao of userExample executing in the transaction, see WithTx
+func (tx *Tx) UserExampleDao() dao.UserExampleDao {
+	return dao.NewUserExampleDao(tx.DB, cache.NewUserExampleCache(database.GetCacheType())).WithTx(tx.DB)
+}
+
 // Create a new userExample
 func (s *userExample) Create(ctx context.Context, req *serverNameExampleV1.CreateUserExampleRequest) (*serverNameExampleV1.CreateUserExampleReply, error) {
 	err := req.Validate()
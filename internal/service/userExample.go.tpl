@@ -46,6 +46,11 @@ func New{{.TableNameCamel}}Server() serverNameExampleV1.{{.TableNameCamel}}Serve
 	}
 }
 
+// {{.TableNameCamel}}Dao get the dao of {{.TableNameCamelFCL}} executing in the transaction, see WithTx
+func (tx *Tx) {{.TableNameCamel}}Dao() dao.{{.TableNameCamel}}Dao {
+	return dao.New{{.TableNameCamel}}Dao(tx.DB, cache.New{{.TableNameCamel}}Cache(database.GetCacheType())).WithTx(tx.DB)
+}
+
 // Create a new {{.TableNameCamelFCL}}
 func (s *{{.TableNameCamelFCL}}) Create(ctx context.Context, req *serverNameExampleV1.Create{{.TableNameCamel}}Request) (*serverNameExampleV1.Create{{.TableNameCamel}}Reply, error) {
 	err := req.Validate()
@@ -0,0 +1,24 @@
+package worker
+
+import (
+	"context"
+
+	"github.com/go-dev-frame/sponge/pkg/logger"
+	"github.com/go-dev-frame/sponge/pkg/mq/consumer"
+)
+
+func init() {
+	register("userExample.created", handleUserExampleCreated)
+}
+
+// handleUserExampleCreated handle the message of topic userExample.created, if an error is returned,
+// the message is retried after the backoff, and routed to the dead-letter topic after the maximum attempts.
+func handleUserExampleCreated(ctx context.Context, msg *consumer.Message) error {
+	logger.Info("handle message", logger.String("topic", msg.Topic), logger.String("key", msg.Key),
+		logger.Int("attempt", msg.Attempt()), logger.Int("size", len(msg.Body)))
+
+	// fill in the business logic code here, e.g. decode the message body and save it to database,
+	// the handler should be idempotent, the message may be delivered more than once
+
+	return nil
+}
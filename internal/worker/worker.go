@@ -0,0 +1,47 @@
+// Package worker is the handlers of the messages consumed by the worker service, the handler of
+// each topic is registered in the init function of its file, e.g. userExample.go.
+package worker
+
+import (
+	"context"
+	"sort"
+
+	"github.com/go-dev-frame/sponge/pkg/logger"
+	"github.com/go-dev-frame/sponge/pkg/mq/consumer"
+)
+
+var topicHandlers = make(map[string]consumer.Handler)
+
+// register the handler of topic, it is called in the init function of handler files
+func register(topic string, handler consumer.Handler) {
+	if _, ok := topicHandlers[topic]; ok {
+		panic("the handler of topic " + topic + " is registered repeatedly")
+	}
+	topicHandlers[topic] = handler
+}
+
+// Topics returns the topics of registered handlers
+func Topics() []string {
+	topics := make([]string, 0, len(topicHandlers))
+	for topic := range topicHandlers {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// Handle dispatches the message to the handler of its topic, the message consumed from the retry
+// queue is dispatched by its original topic, the message of unknown topic is discarded.
+func Handle(ctx context.Context, msg *consumer.Message) error {
+	topic := msg.Topic
+	if original := msg.Headers[consumer.HeaderOriginalTopic]; original != "" {
+		topic = original
+	}
+
+	handler, ok := topicHandlers[topic]
+	if !ok {
+		logger.Warn("no handler of topic, the message is discarded", logger.String("topic", topic), logger.String("key", msg.Key))
+		return nil
+	}
+	return handler(ctx, msg)
+}
@@ -0,0 +1,37 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-dev-frame/sponge/pkg/mq/consumer"
+)
+
+func TestHandle(t *testing.T) {
+	var topics []string
+	register("worker.test", func(ctx context.Context, msg *consumer.Message) error {
+		topics = append(topics, msg.Topic)
+		if msg.Key == "error" {
+			return errors.New("handle error")
+		}
+		return nil
+	})
+	defer delete(topicHandlers, "worker.test")
+	assert.Contains(t, Topics(), "worker.test")
+	assert.Panics(t, func() { register("worker.test", nil) })
+
+	ctx := context.Background()
+	assert.NoError(t, Handle(ctx, &consumer.Message{Topic: "worker.test"}))
+	assert.Error(t, Handle(ctx, &consumer.Message{Topic: "worker.test", Key: "error"}))
+
+	// the message of retry queue is dispatched by its original topic
+	msg := &consumer.Message{Topic: "serverNameExample.retry", Headers: map[string]string{consumer.HeaderOriginalTopic: "worker.test"}}
+	assert.NoError(t, Handle(ctx, msg))
+	assert.Equal(t, []string{"worker.test", "worker.test", "serverNameExample.retry"}, topics)
+
+	// unknown topic
+	assert.NoError(t, Handle(ctx, &consumer.Message{Topic: "unknown"}))
+}
@@ -3,10 +3,13 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
+	"time"
 
 	"golang.org/x/sync/errgroup"
 
@@ -27,44 +30,150 @@ type Close func() error
 type App struct {
 	servers []IServer
 	closes  []Close
+
+	health        *health // probe server, nil if not enabled
+	shutdownDelay time.Duration
+	upgrader      *Upgrader // graceful binary upgrade, nil if not enabled
+
+	readinessChecks []namedCheck
+	checkTimeout    time.Duration
+	afterReadyHooks []Hook
+	beforeStopHooks []Hook
+	hookTimeout     time.Duration
+	hookMu          sync.Mutex // the after ready and before stop hooks are not called at the same time
+
+	serverOpts map[IServer]*serverOptions // dependencies and timeouts of servers
+	ordered    bool                       // servers are stopped by the app in reverse dependency order
+	dependedOn map[IServer]bool
+	sorted     []IServer                 // servers in dependency order
+	sortErr    error                     // invalid dependencies
+	started    map[IServer]chan struct{} // closed when the server is started
+	done       chan struct{}             // closed when the app is stopping
+	doneOnce   sync.Once
+
+	mu   sync.Mutex
+	errs []error // errors of servers
 }
 
-// New create an app
-func New(servers []IServer, closes []Close) *App {
-	return &App{
-		servers: servers,
-		closes:  closes,
+// New create an app, use WithHealthServer to expose kubernetes probes.
+func New(servers []IServer, closes []Close, opts ...Option) *App {
+	o := defaultOptions()
+	o.apply(opts...)
+
+	a := &App{
+		servers:       servers,
+		closes:        closes,
+		shutdownDelay: o.shutdownDelay,
+		upgrader:      o.upgrader,
+		serverOpts:    o.servers,
+		ordered:       len(o.servers) > 0,
+		dependedOn:    make(map[IServer]bool),
+		started:       make(map[IServer]chan struct{}, len(servers)),
+		done:          make(chan struct{}),
+
+		readinessChecks: o.readinessChecks,
+		checkTimeout:    o.checkTimeout,
+		afterReadyHooks: o.afterReadyHooks,
+		beforeStopHooks: o.beforeStopHooks,
+		hookTimeout:     o.hookTimeout,
 	}
+	for _, so := range o.servers {
+		for _, dep := range so.dependsOn {
+			a.dependedOn[dep] = true
+		}
+	}
+	a.sorted, a.sortErr = sortServers(servers, o.servers)
+	for _, s := range servers {
+		a.started[s] = make(chan struct{})
+		// the servers are registered after the app is ready, and deregistered before the servers are stopped
+		if r, ok := s.(Registrar); ok {
+			a.afterReadyHooks = append(a.afterReadyHooks, r.Register)
+			a.beforeStopHooks = append(a.beforeStopHooks, r.Deregister)
+		}
+	}
+	if o.healthAddr != "" {
+		a.health = newHealth(o.healthAddr, servers, o)
+	}
+	return a
 }
 
-// Run servers
+// Run servers, servers are started in dependency order if declared by WithDependency,
+// panics with the errors of all failed servers.
 func (a *App) Run() {
+	if a.sortErr != nil {
+		panic(a.sortErr)
+	}
+
 	// ctx will be notified whenever an error occurs in one of the goroutines
 	eg, ctx := errgroup.WithContext(context.Background())
 
 	// start all servers
-	for _, server := range a.servers {
+	for _, server := range a.sorted {
 		s := server
 		eg.Go(func() error {
-			fmt.Println(s.String())
-			return s.Start()
+			if err := a.startServer(ctx, s); err != nil {
+				a.addError(err)
+				return err
+			}
+			return nil
+		})
+	}
+
+	// start probe server
+	if a.health != nil {
+		eg.Go(func() error {
+			fmt.Println("health probe address is " + a.health.server.Addr)
+			return a.health.start()
 		})
 	}
 
+	// call the after ready hooks, e.g. registering the service
+	eg.Go(func() error {
+		if err := a.afterReady(ctx); err != nil {
+			a.addError(err)
+			return err
+		}
+		return nil
+	})
+
+	// notify the parent process after all servers are started
+	if a.upgrader != nil {
+		go a.notifyReady()
+	}
+
 	// watch and stop app
 	eg.Go(func() error {
 		return a.watch(ctx)
 	})
 
 	if err := eg.Wait(); err != nil {
+		if errs := a.serverErrors(); errs != nil {
+			panic(errs)
+		}
 		panic(err)
 	}
 }
 
+func (a *App) addError(err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.errs = append(a.errs, err)
+}
+
+// serverErrors returns the aggregated errors of the servers.
+func (a *App) serverErrors() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return errors.Join(a.errs...)
+}
+
 // watch the os signal and the ctx signal from the errgroup, and stop the service if either signal is triggered
 func (a *App) watch(ctx context.Context) error {
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGTRAP)
+	if a.upgrader != nil && upgradeSignal != nil {
+		signal.Notify(sig, upgradeSignal)
+	}
 	profile := prof.NewProfile()
 
 	for {
@@ -75,6 +184,18 @@ func (a *App) watch(ctx context.Context) error {
 
 		case sigType := <-sig: // system notification signal
 			fmt.Printf("received system notification signal: %s\n", sigType.String())
+			if a.upgrader != nil && sigType == upgradeSignal {
+				if err := a.upgrader.Upgrade(); err != nil {
+					fmt.Printf("upgrade app error: %v\n", err)
+					continue
+				}
+				// the new process is ready, drain in-flight requests and exit
+				if err := a.stop(); err != nil {
+					return err
+				}
+				fmt.Println("upgrade app successfully")
+				return nil
+			}
 			switch sigType {
 			case syscall.SIGTRAP:
 				profile.StartOrStop() // start or stop sampling profile
@@ -89,12 +210,44 @@ func (a *App) watch(ctx context.Context) error {
 	}
 }
 
+// notifyReady waits for all servers to be started, and notifies the parent process of the upgrade.
+func (a *App) notifyReady() {
+	for _, s := range a.sorted {
+		select {
+		case <-a.started[s]:
+		case <-a.done:
+			return
+		}
+	}
+	if err := a.upgrader.Ready(); err != nil {
+		fmt.Printf("notify parent process error: %v\n", err)
+	}
+}
+
 // stopping services and releasing resources
 func (a *App) stop() error {
+	a.doneOnce.Do(func() { close(a.done) })
+
+	if a.health != nil {
+		// report not ready first, the probe server is stopped after all servers are stopped
+		a.health.shuttingDown.Store(true)
+		defer func() { _ = a.health.stop() }()
+	}
+	// deregister the service before stopping the servers, so that no new requests are routed to them
+	a.beforeStop()
+	if (a.health != nil || len(a.beforeStopHooks) > 0) && a.shutdownDelay > 0 {
+		time.Sleep(a.shutdownDelay)
+	}
+
+	var stopErr error
+	if a.ordered {
+		stopErr = a.stopServers()
+	}
+
 	for _, closeFn := range a.closes {
 		if err := closeFn(); err != nil {
-			return err
+			return errors.Join(stopErr, err)
 		}
 	}
-	return nil
+	return stopErr
 }
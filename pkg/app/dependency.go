@@ -0,0 +1,159 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	defaultStartTimeout = 30 * time.Second
+	defaultStopTimeout  = 10 * time.Second
+	readyPollInterval   = 100 * time.Millisecond
+)
+
+// sortServers returns the servers in dependency order, dependencies first.
+func sortServers(servers []IServer, deps map[IServer]*serverOptions) ([]IServer, error) {
+	registered := make(map[IServer]bool, len(servers))
+	for _, s := range servers {
+		registered[s] = true
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[IServer]int, len(servers))
+	sorted := make([]IServer, 0, len(servers))
+	var path []string
+
+	var visit func(s IServer) error
+	visit = func(s IServer) error {
+		switch state[s] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("dependency cycle detected: %s -> %s", strings.Join(path, " -> "), s.String())
+		}
+		state[s] = visiting
+		path = append(path, s.String())
+		if so, ok := deps[s]; ok {
+			for _, dep := range so.dependsOn {
+				if !registered[dep] {
+					return fmt.Errorf("server '%s' depends on '%s' which is not registered", s.String(), dep.String())
+				}
+				if err := visit(dep); err != nil {
+					return err
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[s] = visited
+		sorted = append(sorted, s)
+		return nil
+	}
+
+	for _, s := range servers {
+		if err := visit(s); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
+
+// serverOptions returns the options of server s with default timeouts.
+func (a *App) serverOptions(s IServer) serverOptions {
+	var so serverOptions
+	if v, ok := a.serverOpts[s]; ok {
+		so = *v
+	}
+	if so.startTimeout <= 0 {
+		so.startTimeout = defaultStartTimeout
+	}
+	if so.stopTimeout <= 0 {
+		so.stopTimeout = defaultStopTimeout
+	}
+	return so
+}
+
+// startServer starts server s after its dependencies are started, returns when s stops running.
+func (a *App) startServer(ctx context.Context, s IServer) error {
+	so := a.serverOptions(s)
+	for _, dep := range so.dependsOn {
+		select {
+		case <-a.started[dep]:
+		case <-ctx.Done(): // another server failed
+			return nil
+		case <-a.done: // app is stopping
+			return nil
+		}
+	}
+
+	fmt.Println(s.String())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.Start()
+	}()
+
+	readier, isReadier := s.(Readier)
+	if !isReadier && !a.dependedOn[s] {
+		close(a.started[s])
+		if err := <-errCh; err != nil {
+			return fmt.Errorf("server '%s' error: %w", s.String(), err)
+		}
+		return nil
+	}
+
+	// wait for the server to be started
+	timer := time.NewTimer(so.startTimeout)
+	defer timer.Stop()
+	ticker := time.NewTicker(readyPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-errCh:
+			if err != nil {
+				return fmt.Errorf("server '%s' error: %w", s.String(), err)
+			}
+			close(a.started[s]) // initializer finished
+			return nil
+		case <-ticker.C:
+			if isReadier && readier.Ready(ctx) == nil {
+				close(a.started[s])
+				if err := <-errCh; err != nil {
+					return fmt.Errorf("server '%s' error: %w", s.String(), err)
+				}
+				return nil
+			}
+		case <-timer.C:
+			return fmt.Errorf("server '%s' is not started within %v", s.String(), so.startTimeout)
+		case <-a.done:
+			return <-errCh
+		}
+	}
+}
+
+// stopServers stops the servers in reverse dependency order, each within its stop timeout.
+func (a *App) stopServers() error {
+	var errs []error
+	for i := len(a.sorted) - 1; i >= 0; i-- {
+		s := a.sorted[i]
+		timeout := a.serverOptions(s).stopTimeout
+
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- s.Stop()
+		}()
+		select {
+		case err := <-errCh:
+			if err != nil {
+				errs = append(errs, fmt.Errorf("stop server '%s' error: %w", s.String(), err))
+			}
+		case <-time.After(timeout):
+			errs = append(errs, fmt.Errorf("stop server '%s' timeout after %v", s.String(), timeout))
+		}
+	}
+	return errors.Join(errs...)
+}
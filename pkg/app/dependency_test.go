@@ -0,0 +1,172 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) add(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string{}, r.events...)
+}
+
+// orderServer blocks in Start until Stop is called if blocking is true, otherwise it is an initializer.
+type orderServer struct {
+	name     string
+	blocking bool
+	startErr error
+	stopWait time.Duration
+	rec      *recorder
+	stopCh   chan struct{}
+	once     sync.Once
+}
+
+func newOrderServer(name string, blocking bool, rec *recorder) *orderServer {
+	return &orderServer{name: name, blocking: blocking, rec: rec, stopCh: make(chan struct{})}
+}
+
+func (s *orderServer) Start() error {
+	s.rec.add("start " + s.name)
+	if s.startErr != nil {
+		return s.startErr
+	}
+	if s.blocking {
+		<-s.stopCh
+	}
+	return nil
+}
+
+func (s *orderServer) Stop() error {
+	time.Sleep(s.stopWait)
+	s.rec.add("stop " + s.name)
+	s.once.Do(func() { close(s.stopCh) })
+	return nil
+}
+
+func (s *orderServer) String() string {
+	return s.name
+}
+
+func TestSortServers(t *testing.T) {
+	rec := &recorder{}
+	db, cache, web := newOrderServer("db", false, rec), newOrderServer("cache", false, rec), newOrderServer("web", true, rec)
+
+	o := defaultOptions()
+	o.apply(WithDependency(web, db, cache), WithDependency(cache, db))
+	sorted, err := sortServers([]IServer{web, cache, db}, o.servers)
+	assert.NoError(t, err)
+	assert.Equal(t, []IServer{db, cache, web}, sorted)
+
+	o.apply(WithDependency(db, web))
+	_, err = sortServers([]IServer{web, cache, db}, o.servers)
+	assert.ErrorContains(t, err, "dependency cycle detected")
+
+	o = defaultOptions()
+	o.apply(WithDependency(web, db))
+	_, err = sortServers([]IServer{web}, o.servers)
+	assert.ErrorContains(t, err, "not registered")
+}
+
+func TestApp_DependencyOrder(t *testing.T) {
+	rec := &recorder{}
+	db, web := newOrderServer("db", false, rec), newOrderServer("web", true, rec)
+	a := New([]IServer{web, db}, nil, WithDependency(web, db), WithStopTimeout(web, time.Second))
+	go a.Run()
+	time.Sleep(300 * time.Millisecond)
+
+	assert.NoError(t, a.stopServers())
+	assert.Equal(t, []string{"start db", "start web", "stop web", "stop db"}, rec.get())
+}
+
+func TestApp_StartServer(t *testing.T) {
+	rec := &recorder{}
+
+	t.Run("dependency not started", func(t *testing.T) {
+		db, web := newOrderServer("db", true, rec), newOrderServer("web", true, rec)
+		a := New([]IServer{web, db}, nil, WithDependency(web, db), WithStartTimeout(db, 200*time.Millisecond))
+		err := a.startServer(context.Background(), db)
+		assert.ErrorContains(t, err, "is not started within")
+		_ = db.Stop()
+	})
+
+	t.Run("start error", func(t *testing.T) {
+		db := newOrderServer("db", false, rec)
+		db.startErr = errors.New("connection refused")
+		a := New([]IServer{db}, nil)
+		err := a.startServer(context.Background(), db)
+		assert.ErrorContains(t, err, "connection refused")
+	})
+
+	t.Run("readier", func(t *testing.T) {
+		s := &readyServer{}
+		a := New([]IServer{s}, nil, WithStartTimeout(s, time.Second))
+		go func() {
+			time.Sleep(200 * time.Millisecond)
+			s.ready.Store(true)
+		}()
+		assert.NoError(t, a.startServer(context.Background(), s))
+		select {
+		case <-a.started[s]:
+		default:
+			t.Error("expected server to be started")
+		}
+	})
+}
+
+func TestApp_StopTimeout(t *testing.T) {
+	rec := &recorder{}
+	slow, fast := newOrderServer("slow", false, rec), newOrderServer("fast", false, rec)
+	slow.stopWait = 500 * time.Millisecond
+	a := New([]IServer{slow, fast}, []Close{func() error { return fmt.Errorf("close db error") }},
+		WithDependency(slow, fast), WithStopTimeout(slow, 100*time.Millisecond))
+
+	err := a.stop()
+	assert.ErrorContains(t, err, "stop server 'slow' timeout")
+	assert.ErrorContains(t, err, "close db error")
+}
+
+func TestApp_AggregatedErrors(t *testing.T) {
+	rec := &recorder{}
+	db, cache, web := newOrderServer("db", false, rec), newOrderServer("cache", true, rec), newOrderServer("web", true, rec)
+	db.startErr = errors.New("db unavailable")
+	a := New([]IServer{web, cache, db}, nil,
+		WithDependency(web, cache),
+		WithStartTimeout(cache, 100*time.Millisecond),
+	)
+
+	errCh := make(chan error, 1)
+	go func() {
+		defer func() {
+			e := recover()
+			err, _ := e.(error)
+			errCh <- err
+			_ = cache.Stop()
+		}()
+		a.Run()
+	}()
+
+	select {
+	case err := <-errCh:
+		assert.ErrorContains(t, err, "db unavailable")
+	case <-time.After(3 * time.Second):
+		t.Fatal("expected Run to panic")
+	}
+}
@@ -0,0 +1,173 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Readier is implemented by servers that can report whether they are ready to receive traffic,
+// servers that do not implement it are considered ready once started.
+type Readier interface {
+	Ready(ctx context.Context) error
+}
+
+// HealthCheck checks a dependency of the app, e.g. database or cache, returns nil if healthy.
+type HealthCheck func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check HealthCheck
+}
+
+// health serves kubernetes probes:
+//
+//	/healthz  liveness, fails only if a liveness check fails, a failed liveness probe restarts the container
+//	/readyz   readiness, fails if a server or readiness check is not ready, or the app is shutting down
+//	/startupz startup, succeeds once the app has been ready for the first time
+type health struct {
+	server *http.Server
+
+	servers         []IServer
+	readinessChecks []namedCheck
+	livenessChecks  []namedCheck
+	checkTimeout    time.Duration
+
+	started      atomic.Bool
+	shuttingDown atomic.Bool
+}
+
+func newHealth(addr string, servers []IServer, o *options) *health {
+	h := &health{
+		servers:         servers,
+		readinessChecks: o.readinessChecks,
+		livenessChecks:  o.livenessChecks,
+		checkTimeout:    o.checkTimeout,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", h.handleLiveness)
+	mux.HandleFunc("/readyz", h.handleReadiness)
+	mux.HandleFunc("/startupz", h.handleStartup)
+	h.server = &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	return h
+}
+
+func (h *health) start() error {
+	if err := h.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("[health server] listen and serve error: %v", err)
+	}
+	return nil
+}
+
+func (h *health) stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	return h.server.Shutdown(ctx)
+}
+
+type probeResult struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+func (h *health) handleLiveness(w http.ResponseWriter, r *http.Request) {
+	checks, ok := runChecks(r.Context(), h.livenessChecks, h.checkTimeout)
+	writeProbe(w, ok, "", checks)
+}
+
+func (h *health) handleReadiness(w http.ResponseWriter, r *http.Request) {
+	if h.shuttingDown.Load() {
+		writeProbe(w, false, "shutting down", nil)
+		return
+	}
+	checks, ok := h.checkReady(r.Context())
+	writeProbe(w, ok, "", checks)
+}
+
+func (h *health) handleStartup(w http.ResponseWriter, r *http.Request) {
+	if h.started.Load() {
+		writeProbe(w, true, "", nil)
+		return
+	}
+	checks, ok := h.checkReady(r.Context())
+	writeProbe(w, ok, "", checks)
+}
+
+// checkReady checks the servers implementing Readier and the readiness checks.
+func (h *health) checkReady(ctx context.Context) (map[string]string, bool) {
+	results, ok := runChecks(ctx, readinessChecks(h.servers, h.readinessChecks), h.checkTimeout)
+	if ok {
+		h.started.Store(true)
+	}
+	return results, ok
+}
+
+// readinessChecks returns the checks of the servers implementing Readier and the readiness checks.
+func readinessChecks(servers []IServer, readinessChecks []namedCheck) []namedCheck {
+	var checks []namedCheck
+	for _, s := range servers {
+		if readier, ok := s.(Readier); ok {
+			checks = append(checks, namedCheck{name: s.String(), check: readier.Ready})
+		}
+	}
+	return append(checks, readinessChecks...)
+}
+
+// runChecks runs the checks concurrently, the result of each check is "ok" or the error message.
+func runChecks(ctx context.Context, checks []namedCheck, timeout time.Duration) (map[string]string, bool) {
+	if len(checks) == 0 {
+		return nil, true
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		ok      = true
+		results = make(map[string]string, len(checks))
+	)
+	for _, c := range checks {
+		wg.Add(1)
+		go func(c namedCheck) {
+			defer wg.Done()
+			err := c.check(ctx)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				ok = false
+				results[c.name] = err.Error()
+			} else {
+				results[c.name] = "ok"
+			}
+		}(c)
+	}
+	wg.Wait()
+	return results, ok
+}
+
+func writeProbe(w http.ResponseWriter, ok bool, status string, checks map[string]string) {
+	code := http.StatusOK
+	if status == "" {
+		status = "ok"
+	}
+	if !ok {
+		code = http.StatusServiceUnavailable
+		if status == "ok" {
+			status = "unavailable"
+		}
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(probeResult{Status: status, Checks: checks})
+}
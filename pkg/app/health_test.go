@@ -0,0 +1,79 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type readyServer struct {
+	httpServer2
+	ready atomic.Bool
+}
+
+func (s *readyServer) Ready(ctx context.Context) error {
+	if !s.ready.Load() {
+		return errors.New("not started")
+	}
+	return nil
+}
+
+func probe(h *health, path string) int {
+	rec := httptest.NewRecorder()
+	h.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec.Code
+}
+
+func TestHealth(t *testing.T) {
+	s := &readyServer{}
+	var dbErr error
+	o := defaultOptions()
+	o.apply(
+		WithReadinessCheck("db", func(ctx context.Context) error { return dbErr }),
+		WithLivenessCheck("deadlock", func(ctx context.Context) error { return nil }),
+		WithHealthCheckTimeout(time.Second),
+	)
+	h := newHealth(":0", []IServer{s, &httpServer{}}, o)
+
+	assert.Equal(t, http.StatusOK, probe(h, "/healthz"))
+	assert.Equal(t, http.StatusServiceUnavailable, probe(h, "/startupz"))
+	assert.Equal(t, http.StatusServiceUnavailable, probe(h, "/readyz"))
+
+	s.ready.Store(true)
+	assert.Equal(t, http.StatusOK, probe(h, "/readyz"))
+	assert.Equal(t, http.StatusOK, probe(h, "/startupz"))
+
+	dbErr = errors.New("connection refused")
+	assert.Equal(t, http.StatusServiceUnavailable, probe(h, "/readyz"))
+	assert.Equal(t, http.StatusOK, probe(h, "/startupz")) // started once
+	dbErr = nil
+
+	h.shuttingDown.Store(true)
+	assert.Equal(t, http.StatusServiceUnavailable, probe(h, "/readyz"))
+	assert.Equal(t, http.StatusOK, probe(h, "/healthz"))
+}
+
+func TestAppWithHealthServer(t *testing.T) {
+	s := &httpServer2{}
+	a := New([]IServer{s}, []Close{s.Stop},
+		WithHealthServer("127.0.0.1:0"),
+		WithShutdownDelay(10*time.Millisecond),
+	)
+	assert.NotNil(t, a.health)
+	go func() {
+		_ = a.health.start()
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	assert.Equal(t, http.StatusOK, probe(a.health, "/readyz"))
+	assert.NoError(t, a.stop())
+	assert.Equal(t, http.StatusServiceUnavailable, probe(a.health, "/readyz"))
+
+	assert.Nil(t, New(nil, nil).health)
+}
@@ -0,0 +1,92 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const defaultHookTimeout = 10 * time.Second
+
+// Hook is called at a stage of the app lifecycle, e.g. registering the service to the registry.
+type Hook func(ctx context.Context) error
+
+// Registrar is implemented by servers that register themselves to a service registry, the app registers
+// them after all servers are ready and deregisters them before stopping any server, so that no traffic
+// is routed to instances that are starting or stopping.
+type Registrar interface {
+	Register(ctx context.Context) error
+	Deregister(ctx context.Context) error
+}
+
+// afterReady waits for all servers to be started and the readiness checks to pass, then calls the after ready hooks,
+// it returns nil without calling the hooks if the app is stopping.
+func (a *App) afterReady(ctx context.Context) error {
+	if len(a.afterReadyHooks) == 0 {
+		return nil
+	}
+
+	for _, s := range a.sorted {
+		select {
+		case <-a.started[s]:
+		case <-ctx.Done():
+			return nil
+		case <-a.done:
+			return nil
+		}
+	}
+
+	// not ready instances are not registered, wait until the dependencies (e.g. database) are available
+	checks := readinessChecks(a.servers, a.readinessChecks)
+	ticker := time.NewTicker(readyPollInterval)
+	defer ticker.Stop()
+	for notified := false; ; {
+		results, ok := runChecks(ctx, checks, a.checkTimeout)
+		if ok {
+			break
+		}
+		if !notified {
+			notified = true
+			fmt.Printf("waiting for the app to be ready, checks: %v\n", results)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		case <-a.done:
+			return nil
+		}
+	}
+
+	// stop waits for the hooks to be finished, so that the registered instances are always deregistered
+	a.hookMu.Lock()
+	defer a.hookMu.Unlock()
+	select {
+	case <-a.done:
+		return nil
+	default:
+	}
+	for _, hook := range a.afterReadyHooks {
+		if err := a.runHook(hook); err != nil {
+			return fmt.Errorf("after ready hook error: %w", err)
+		}
+	}
+	return nil
+}
+
+// beforeStop calls the before stop hooks, the errors are printed and do not prevent the app from stopping.
+func (a *App) beforeStop() {
+	a.hookMu.Lock()
+	defer a.hookMu.Unlock()
+	for _, hook := range a.beforeStopHooks {
+		if err := a.runHook(hook); err != nil {
+			fmt.Printf("before stop hook error: %v\n", err)
+		}
+	}
+}
+
+func (a *App) runHook(hook Hook) error {
+	ctx, cancel := context.WithTimeout(context.Background(), a.hookTimeout)
+	defer cancel()
+	return hook(ctx)
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type registrarServer struct {
+	*orderServer
+}
+
+func (s *registrarServer) Register(_ context.Context) error {
+	s.rec.add("register " + s.name)
+	return nil
+}
+
+func (s *registrarServer) Deregister(_ context.Context) error {
+	s.rec.add("deregister " + s.name)
+	return nil
+}
+
+func TestApp_Hooks(t *testing.T) {
+	rec := &recorder{}
+	s := &registrarServer{newOrderServer("grpc", true, rec)}
+
+	var ready atomic.Bool
+	a := New([]IServer{s}, nil,
+		WithStopTimeout(s, time.Second),
+		WithReadinessCheck("database", func(ctx context.Context) error {
+			if !ready.Load() {
+				return errors.New("not ready")
+			}
+			return nil
+		}),
+		WithAfterReady(func(ctx context.Context) error {
+			rec.add("after ready")
+			return nil
+		}),
+		WithBeforeStop(func(ctx context.Context) error {
+			rec.add("before stop")
+			return errors.New("mock before stop error")
+		}),
+		WithHookTimeout(time.Second),
+		WithShutdownDelay(time.Millisecond*10),
+	)
+	go a.Run()
+
+	// not registered until the readiness checks pass
+	time.Sleep(time.Millisecond * 300)
+	assert.Equal(t, []string{"start grpc"}, rec.get())
+	ready.Store(true)
+	time.Sleep(time.Millisecond * 300)
+	assert.Equal(t, []string{"start grpc", "after ready", "register grpc"}, rec.get())
+
+	// deregistered before stopping the servers
+	assert.NoError(t, a.stop())
+	assert.Equal(t, []string{"start grpc", "after ready", "register grpc", "before stop", "deregister grpc", "stop grpc"}, rec.get())
+}
+
+func TestApp_afterReady(t *testing.T) {
+	s := newOrderServer("http", true, &recorder{})
+	a := New([]IServer{s}, nil, WithAfterReady(func(ctx context.Context) error {
+		return errors.New("mock register error")
+	}))
+	close(a.started[s])
+	err := a.afterReady(context.Background())
+	assert.Error(t, err)
+	t.Log(err)
+
+	// the hooks are not called if the app is stopping
+	var called atomic.Bool
+	a = New([]IServer{s}, nil, WithAfterReady(func(ctx context.Context) error {
+		called.Store(true)
+		return nil
+	}))
+	a.doneOnce.Do(func() { close(a.done) })
+	assert.NoError(t, a.afterReady(context.Background()))
+	close(a.started[s])
+	assert.NoError(t, a.afterReady(context.Background()))
+	assert.False(t, called.Load())
+
+	// no hooks
+	a = New([]IServer{s}, nil)
+	assert.NoError(t, a.afterReady(context.Background()))
+}
@@ -0,0 +1,156 @@
+package app
+
+import (
+	"time"
+)
+
+// Option set options.
+type Option func(*options)
+
+type options struct {
+	healthAddr      string
+	readinessChecks []namedCheck
+	livenessChecks  []namedCheck
+	checkTimeout    time.Duration
+	shutdownDelay   time.Duration
+	servers         map[IServer]*serverOptions
+	upgrader        *Upgrader
+	afterReadyHooks []Hook
+	beforeStopHooks []Hook
+	hookTimeout     time.Duration
+}
+
+func (o *options) apply(opts ...Option) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+func defaultOptions() *options {
+	return &options{
+		checkTimeout: 3 * time.Second,
+		hookTimeout:  defaultHookTimeout,
+	}
+}
+
+// WithHealthServer enables the probe server listening on addr, which serves /healthz, /readyz and /startupz,
+// if addr is empty, the probe server is not started.
+func WithHealthServer(addr string) Option {
+	return func(o *options) {
+		o.healthAddr = addr
+	}
+}
+
+// WithReadinessCheck adds a readiness check, /readyz fails if the check returns an error,
+// e.g. checking the database connection.
+func WithReadinessCheck(name string, check HealthCheck) Option {
+	return func(o *options) {
+		o.readinessChecks = append(o.readinessChecks, namedCheck{name: name, check: check})
+	}
+}
+
+// WithLivenessCheck adds a liveness check, /healthz fails if the check returns an error,
+// only use it for unrecoverable states, a failed liveness probe restarts the container.
+func WithLivenessCheck(name string, check HealthCheck) Option {
+	return func(o *options) {
+		o.livenessChecks = append(o.livenessChecks, namedCheck{name: name, check: check})
+	}
+}
+
+// WithHealthCheckTimeout sets the timeout of running the checks of a probe, default is 3s.
+func WithHealthCheckTimeout(d time.Duration) Option {
+	return func(o *options) {
+		if d > 0 {
+			o.checkTimeout = d
+		}
+	}
+}
+
+// WithShutdownDelay sets how long to wait after /readyz reports not ready or the before stop hooks are called
+// before stopping the servers, giving the load balancer (e.g. kubernetes endpoints) or the clients watching
+// the registry time to stop sending new requests, default is 0.
+func WithShutdownDelay(d time.Duration) Option {
+	return func(o *options) {
+		o.shutdownDelay = d
+	}
+}
+
+// WithUpgrader enables graceful binary upgrades, on receiving SIGUSR2, a new process is started
+// from the executable with the listeners created by upgrader.Listen, once the new process is ready,
+// the app stops and drains in-flight requests, see NewUpgrader.
+func WithUpgrader(u *Upgrader) Option {
+	return func(o *options) {
+		o.upgrader = u
+	}
+}
+
+// WithAfterReady adds a hook called once all servers are started and the readiness checks pass,
+// e.g. registering the service to the registry, the app stops if the hook returns an error.
+func WithAfterReady(hook Hook) Option {
+	return func(o *options) {
+		o.afterReadyHooks = append(o.afterReadyHooks, hook)
+	}
+}
+
+// WithBeforeStop adds a hook called when the app starts shutting down, before the shutdown delay
+// and stopping the servers, e.g. deregistering the service from the registry.
+func WithBeforeStop(hook Hook) Option {
+	return func(o *options) {
+		o.beforeStopHooks = append(o.beforeStopHooks, hook)
+	}
+}
+
+// WithHookTimeout sets the timeout of each hook, default is 10s.
+func WithHookTimeout(d time.Duration) Option {
+	return func(o *options) {
+		if d > 0 {
+			o.hookTimeout = d
+		}
+	}
+}
+
+type serverOptions struct {
+	dependsOn    []IServer
+	startTimeout time.Duration
+	stopTimeout  time.Duration
+}
+
+func (o *options) server(s IServer) *serverOptions {
+	if o.servers == nil {
+		o.servers = make(map[IServer]*serverOptions)
+	}
+	so, ok := o.servers[s]
+	if !ok {
+		so = &serverOptions{}
+		o.servers[s] = so
+	}
+	return so
+}
+
+// WithDependency declares that server s is started after all servers in dependsOn are started,
+// and stopped before them. A server is considered started when it implements Readier and is ready,
+// or its Start method returns nil (e.g. an initializer of database or cache).
+//
+// Once dependencies or timeouts are declared, the app stops the servers in reverse dependency order
+// before calling the closes, so the closes should only release other resources.
+func WithDependency(s IServer, dependsOn ...IServer) Option {
+	return func(o *options) {
+		so := o.server(s)
+		so.dependsOn = append(so.dependsOn, dependsOn...)
+	}
+}
+
+// WithStartTimeout sets the maximum time for server s to be started, default is 30s,
+// it only applies to servers that implement Readier or are depended on by other servers.
+func WithStartTimeout(s IServer, d time.Duration) Option {
+	return func(o *options) {
+		o.server(s).startTimeout = d
+	}
+}
+
+// WithStopTimeout sets the maximum time to wait for the Stop method of server s to return, default is 10s.
+func WithStopTimeout(s IServer, d time.Duration) Option {
+	return func(o *options) {
+		o.server(s).stopTimeout = d
+	}
+}
@@ -0,0 +1,262 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	envInheritedListeners = "SPONGE_INHERITED_LISTENERS" // network:addr of the inherited listeners, fd starts from 3
+	envUpgradeReadyFD     = "SPONGE_UPGRADE_READY_FD"    // fd used to notify the parent process that the new process is ready
+)
+
+// UpgraderOption set upgraderOptions.
+type UpgraderOption func(*upgraderOptions)
+
+type upgraderOptions struct {
+	readyTimeout time.Duration
+	reusePort    bool
+}
+
+func (o *upgraderOptions) apply(opts ...UpgraderOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+func defaultUpgraderOptions() *upgraderOptions {
+	return &upgraderOptions{
+		readyTimeout: time.Minute,
+	}
+}
+
+// WithUpgraderReadyTimeout sets the maximum time to wait for the new process to be ready, default is 1m.
+func WithUpgraderReadyTimeout(d time.Duration) UpgraderOption {
+	return func(o *upgraderOptions) {
+		o.readyTimeout = d
+	}
+}
+
+// WithUpgraderReusePort sets SO_REUSEPORT on new listeners, so that another process
+// (e.g. started by systemd or a deploy script) can also bind the same address.
+func WithUpgraderReusePort() UpgraderOption {
+	return func(o *upgraderOptions) {
+		o.reusePort = true
+	}
+}
+
+// ------------------------------------------------------------------------------------------
+
+type namedListener struct {
+	key      string // network:addr
+	listener net.Listener
+}
+
+// Upgrader supports graceful binary upgrades (tableflip-style): the listen sockets are passed
+// to a new process started from the executable on disk, once the new process is ready,
+// the old process stops accepting and drains in-flight requests, no connections are dropped.
+//
+// Servers must create listeners with Upgrader.Listen, the upgrade is triggered by SIGUSR2
+// when the upgrader is added to the app with WithUpgrader.
+type Upgrader struct {
+	readyTimeout time.Duration
+	reusePort    bool
+
+	mu        sync.Mutex
+	inherited map[string]net.Listener // listeners inherited from the parent process and not yet used
+	listeners []namedListener         // listeners passed to the new process
+	readyFile *os.File                // notify the parent process, nil if not started by an upgrade
+	upgrading bool
+
+	exit     chan struct{} // closed when the new process is ready
+	exitOnce sync.Once
+}
+
+// NewUpgrader creates an upgrader, the listeners inherited from the parent process are loaded.
+func NewUpgrader(opts ...UpgraderOption) (*Upgrader, error) {
+	o := defaultUpgraderOptions()
+	o.apply(opts...)
+
+	u := &Upgrader{
+		readyTimeout: o.readyTimeout,
+		reusePort:    o.reusePort,
+		inherited:    make(map[string]net.Listener),
+		exit:         make(chan struct{}),
+	}
+	if u.readyTimeout <= 0 {
+		u.readyTimeout = time.Minute
+	}
+
+	if keys := os.Getenv(envInheritedListeners); keys != "" {
+		for i, key := range strings.Split(keys, ",") {
+			f := os.NewFile(uintptr(3+i), key)
+			l, err := net.FileListener(f)
+			_ = f.Close()
+			if err != nil {
+				return nil, fmt.Errorf("inherit listener '%s' error: %v", key, err)
+			}
+			u.inherited[key] = l
+		}
+	}
+	if fd := os.Getenv(envUpgradeReadyFD); fd != "" {
+		n, err := strconv.Atoi(fd)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", envUpgradeReadyFD, err)
+		}
+		u.readyFile = os.NewFile(uintptr(n), "upgrade-ready")
+	}
+	_ = os.Unsetenv(envInheritedListeners)
+	_ = os.Unsetenv(envUpgradeReadyFD)
+
+	return u, nil
+}
+
+// Listen returns the listener inherited from the parent process if exists, otherwise creates a new listener,
+// e.g. ln, _ := upgrader.Listen("tcp", ":8080"); httpServer.Serve(ln)
+func (u *Upgrader) Listen(network string, addr string) (net.Listener, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	key := network + ":" + addr
+	l, ok := u.inherited[key]
+	if ok {
+		delete(u.inherited, key)
+	} else {
+		var err error
+		l, err = listenConfig(u.reusePort).Listen(context.Background(), network, addr)
+		if err != nil {
+			return nil, err
+		}
+	}
+	u.listeners = append(u.listeners, namedListener{key: key, listener: l})
+	return l, nil
+}
+
+// HasParent returns true if the process is started by an upgrade.
+func (u *Upgrader) HasParent() bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.readyFile != nil
+}
+
+// Ready notifies the parent process that the new process is ready to serve, and closes the inherited
+// listeners that are not used, it is called by the app after all servers are started.
+func (u *Upgrader) Ready() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	for key, l := range u.inherited {
+		_ = l.Close()
+		delete(u.inherited, key)
+	}
+	if u.readyFile == nil {
+		return nil
+	}
+	_, err := u.readyFile.Write([]byte{1})
+	_ = u.readyFile.Close()
+	u.readyFile = nil
+	return err
+}
+
+// Exit returns a channel that is closed when the new process is ready, the old process should stop.
+func (u *Upgrader) Exit() <-chan struct{} {
+	return u.exit
+}
+
+// Upgrade starts a new process with the listeners, and waits for it to be ready,
+// the old process should stop after Upgrade returns nil.
+func (u *Upgrader) Upgrade() error {
+	if !upgradeSupported {
+		return errors.New("graceful upgrade is not supported on this platform")
+	}
+
+	u.mu.Lock()
+	if u.upgrading {
+		u.mu.Unlock()
+		return errors.New("upgrade is in progress")
+	}
+	u.upgrading = true
+	listeners := append([]namedListener{}, u.listeners...)
+	u.mu.Unlock()
+	defer func() {
+		u.mu.Lock()
+		u.upgrading = false
+		u.mu.Unlock()
+	}()
+
+	var keys []string
+	var files []*os.File
+	defer func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}()
+	for _, nl := range listeners {
+		fl, ok := nl.listener.(interface{ File() (*os.File, error) })
+		if !ok {
+			return fmt.Errorf("listener '%s' can not be passed to the new process", nl.key)
+		}
+		f, err := fl.File()
+		if err != nil {
+			return fmt.Errorf("get file of listener '%s' error: %v", nl.key, err)
+		}
+		keys = append(keys, nl.key)
+		files = append(files, f)
+	}
+
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer readyR.Close()
+
+	exe, err := os.Executable()
+	if err != nil {
+		_ = readyW.Close()
+		return err
+	}
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.Env = append(os.Environ(),
+		envInheritedListeners+"="+strings.Join(keys, ","),
+		envUpgradeReadyFD+"="+strconv.Itoa(3+len(files)),
+	)
+	cmd.ExtraFiles = append(files, readyW)
+	err = cmd.Start()
+	_ = readyW.Close()
+	if err != nil {
+		return fmt.Errorf("start new process error: %v", err)
+	}
+
+	readyCh := make(chan error, 1)
+	go func() {
+		b := make([]byte, 1)
+		_, err := readyR.Read(b) // EOF if the new process exits before ready
+		readyCh <- err
+	}()
+
+	select {
+	case err = <-readyCh:
+		if err != nil {
+			_ = cmd.Process.Kill()
+			_ = cmd.Wait()
+			return fmt.Errorf("new process exited before ready: %v", err)
+		}
+	case <-time.After(u.readyTimeout):
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return fmt.Errorf("new process is not ready within %v", u.readyTimeout)
+	}
+
+	go func() { _ = cmd.Wait() }() // release resources if the new process exits before the old one
+	u.exitOnce.Do(func() { close(u.exit) })
+	return nil
+}
@@ -0,0 +1,82 @@
+//go:build linux || darwin || freebsd || openbsd || netbsd
+// +build linux darwin freebsd openbsd netbsd
+
+package app
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const envUpgradeTestChild = "SPONGE_UPGRADE_TEST_CHILD"
+
+func TestMain(m *testing.M) {
+	if addr := os.Getenv(envUpgradeTestChild); addr != "" {
+		runUpgradeChild(addr)
+		return
+	}
+	os.Exit(m.Run())
+}
+
+// runUpgradeChild is the new process started by Upgrade in TestUpgrader_Upgrade.
+func runUpgradeChild(addr string) {
+	u, err := NewUpgrader()
+	if err != nil || !u.HasParent() {
+		os.Exit(1)
+	}
+	ln, err := u.Listen("tcp", addr)
+	if err != nil {
+		os.Exit(1)
+	}
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("new process"))
+	})}
+	go func() { _ = server.Serve(ln) }()
+	_ = u.Ready()
+	time.Sleep(2 * time.Second)
+	os.Exit(0)
+}
+
+func TestUpgrader_Upgrade(t *testing.T) {
+	u, err := NewUpgrader(WithUpgraderReadyTimeout(10 * time.Second))
+	assert.NoError(t, err)
+	assert.False(t, u.HasParent())
+
+	ln, err := u.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer ln.Close()
+
+	t.Setenv(envUpgradeTestChild, "127.0.0.1:0") // the address requested by the old process
+	assert.NoError(t, u.Upgrade())
+	select {
+	case <-u.Exit():
+	default:
+		t.Fatal("expected exit channel to be closed")
+	}
+
+	// the new process serves on the socket inherited from the old process
+	resp, err := http.Get("http://" + ln.Addr().String())
+	if !assert.NoError(t, err) {
+		return
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	assert.Equal(t, "new process", string(body))
+}
+
+func TestUpgrader_UpgradeFailed(t *testing.T) {
+	u, err := NewUpgrader(WithUpgraderReadyTimeout(5*time.Second), WithUpgraderReusePort())
+	assert.NoError(t, err)
+	ln, err := u.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer ln.Close()
+
+	t.Setenv(envUpgradeTestChild, "invalid-addr") // the new process fails to listen and exits before ready
+	assert.ErrorContains(t, u.Upgrade(), "exited before ready")
+	assert.NoError(t, u.Ready())
+}
@@ -0,0 +1,18 @@
+//go:build windows
+// +build windows
+
+package app
+
+import (
+	"net"
+	"os"
+)
+
+const upgradeSupported = false
+
+// upgradeSignal is not supported on windows.
+var upgradeSignal os.Signal
+
+func listenConfig(_ bool) *net.ListenConfig {
+	return &net.ListenConfig{}
+}
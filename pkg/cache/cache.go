@@ -27,6 +27,7 @@ var (
 type Cache interface {
 	Set(ctx context.Context, key string, val interface{}, expiration time.Duration) error
 	Get(ctx context.Context, key string, val interface{}) error
+	GetWithTTL(ctx context.Context, key string, val interface{}) (time.Duration, error)
 	MultiSet(ctx context.Context, valMap map[string]interface{}, expiration time.Duration) error
 	MultiGet(ctx context.Context, keys []string, valueMap interface{}) error
 	Del(ctx context.Context, keys ...string) error
@@ -43,6 +44,11 @@ func Get(ctx context.Context, key string, val interface{}) error {
 	return DefaultClient.Get(ctx, key, val)
 }
 
+// GetWithTTL get data and its remaining time to live, the ttl is negative if the key has no expiration
+func GetWithTTL(ctx context.Context, key string, val interface{}) (time.Duration, error) {
+	return DefaultClient.GetWithTTL(ctx, key, val)
+}
+
 // MultiSet multiple set data
 func MultiSet(ctx context.Context, valMap map[string]interface{}, expiration time.Duration) error {
 	return DefaultClient.MultiSet(ctx, valMap, expiration)
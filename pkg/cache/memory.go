@@ -179,6 +179,24 @@ func (m *memoryCache) Get(_ context.Context, key string, val interface{}) error
 	return nil
 }
 
+// GetWithTTL get data and its remaining time to live, the ttl is negative if the key has no expiration
+func (m *memoryCache) GetWithTTL(ctx context.Context, key string, val interface{}) (time.Duration, error) {
+	err := m.Get(ctx, key, val)
+	if err != nil {
+		return 0, err
+	}
+
+	cacheKey, _ := BuildCacheKey(m.KeyPrefix, key)
+	ttl, ok := m.client.GetTTL(cacheKey)
+	if !ok {
+		return 0, nil // expired just now
+	}
+	if ttl == 0 {
+		return -1, nil // no expiration
+	}
+	return ttl, nil
+}
+
 // Del delete data
 func (m *memoryCache) Del(_ context.Context, keys ...string) error {
 	if len(keys) == 0 {
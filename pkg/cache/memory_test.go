@@ -72,6 +72,10 @@ func TestMemoryCache(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, testData.Name, val.Name)
 
+	ttl, err := iCache.GetWithTTL(c.Ctx, key, val)
+	assert.NoError(t, err)
+	assert.True(t, ttl > 0 && ttl <= time.Minute)
+
 	err = iCache.Del(c.Ctx, key)
 	assert.NoError(t, err)
 
@@ -91,6 +95,11 @@ func TestMemoryCache(t *testing.T) {
 
 	err = iCache.SetCacheWithNotFound(c.Ctx, "not_found")
 	assert.NoError(t, err)
+	time.Sleep(time.Millisecond)
+	_, err = iCache.GetWithTTL(c.Ctx, "not_found", val)
+	assert.ErrorIs(t, err, ErrPlaceholder)
+	_, err = iCache.GetWithTTL(c.Ctx, "no_key", val)
+	assert.ErrorIs(t, err, CacheNotFound)
 }
 
 func TestMemoryCacheError(t *testing.T) {
@@ -19,7 +19,7 @@ var CacheNotFound = redis.Nil
 
 // redisCache redis cache object
 type redisCache struct {
-	client            *redis.Client
+	client            redis.UniversalClient
 	KeyPrefix         string
 	encoding          encoding.Encoding
 	DefaultExpireTime time.Duration
@@ -27,7 +27,7 @@ type redisCache struct {
 }
 
 // NewRedisCache new a cache, client parameter can be passed in for unit testing
-func NewRedisCache(client *redis.Client, keyPrefix string, encode encoding.Encoding, newObject func() interface{}) Cache {
+func NewRedisCache(client redis.UniversalClient, keyPrefix string, encode encoding.Encoding, newObject func() interface{}) Cache {
 	return &redisCache{
 		client:    client,
 		KeyPrefix: keyPrefix,
@@ -86,6 +86,35 @@ func (c *redisCache) Get(ctx context.Context, key string, val interface{}) error
 	return nil
 }
 
+// GetWithTTL get one value and its remaining time to live, the ttl is negative if the key has no expiration
+func (c *redisCache) GetWithTTL(ctx context.Context, key string, val interface{}) (time.Duration, error) {
+	cacheKey, err := BuildCacheKey(c.KeyPrefix, key)
+	if err != nil {
+		return 0, fmt.Errorf("BuildCacheKey error: %v, key=%s", err, key)
+	}
+
+	// get the value and ttl in one round trip, the errors are returned by the commands
+	pipe := c.client.Pipeline()
+	getCmd := pipe.Get(ctx, cacheKey)
+	ttlCmd := pipe.PTTL(ctx, cacheKey)
+	_, _ = pipe.Exec(ctx)
+
+	dataBytes, err := getCmd.Bytes()
+	if err != nil {
+		return 0, err
+	}
+	if len(dataBytes) == 0 || bytes.Equal(dataBytes, NotFoundPlaceholderBytes) {
+		return 0, ErrPlaceholder
+	}
+	err = encoding.Unmarshal(c.encoding, dataBytes, val)
+	if err != nil {
+		return 0, fmt.Errorf("encoding.Unmarshal error: %v, key=%s, cacheKey=%s, type=%T, json=%s ",
+			err, key, cacheKey, val, dataBytes)
+	}
+
+	return ttlCmd.Result()
+}
+
 // MultiSet set multiple values
 func (c *redisCache) MultiSet(ctx context.Context, valueMap map[string]interface{}, expiration time.Duration) error {
 	if len(valueMap) == 0 {
@@ -286,6 +315,35 @@ func (c *redisClusterCache) Get(ctx context.Context, key string, val interface{}
 	return nil
 }
 
+// GetWithTTL get one value and its remaining time to live, the ttl is negative if the key has no expiration
+func (c *redisClusterCache) GetWithTTL(ctx context.Context, key string, val interface{}) (time.Duration, error) {
+	cacheKey, err := BuildCacheKey(c.KeyPrefix, key)
+	if err != nil {
+		return 0, fmt.Errorf("BuildCacheKey error: %v, key=%s", err, key)
+	}
+
+	// get the value and ttl in one round trip, the errors are returned by the commands
+	pipe := c.client.Pipeline()
+	getCmd := pipe.Get(ctx, cacheKey)
+	ttlCmd := pipe.PTTL(ctx, cacheKey)
+	_, _ = pipe.Exec(ctx)
+
+	dataBytes, err := getCmd.Bytes()
+	if err != nil {
+		return 0, err
+	}
+	if len(dataBytes) == 0 || bytes.Equal(dataBytes, NotFoundPlaceholderBytes) {
+		return 0, ErrPlaceholder
+	}
+	err = encoding.Unmarshal(c.encoding, dataBytes, val)
+	if err != nil {
+		return 0, fmt.Errorf("encoding.Unmarshal error: %v, key=%s, cacheKey=%s, type=%T, json=%s ",
+			err, key, cacheKey, val, dataBytes)
+	}
+
+	return ttlCmd.Result()
+}
+
 // MultiSet set multiple values
 func (c *redisClusterCache) MultiSet(ctx context.Context, valueMap map[string]interface{}, expiration time.Duration) error {
 	if len(valueMap) == 0 {
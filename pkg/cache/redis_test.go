@@ -71,6 +71,10 @@ func TestRedisCache(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, testData.Name, val.Name)
 
+	ttl, err := iCache.GetWithTTL(c.Ctx, key, val)
+	assert.NoError(t, err)
+	assert.True(t, ttl > 0 && ttl <= time.Minute)
+
 	err = iCache.Del(c.Ctx, key)
 	assert.NoError(t, err)
 
@@ -90,6 +94,10 @@ func TestRedisCache(t *testing.T) {
 	assert.Equal(t, len(c.TestDataSlice), len(vals))
 	err = iCache.SetCacheWithNotFound(c.Ctx, "not_found")
 	assert.NoError(t, err)
+	_, err = iCache.GetWithTTL(c.Ctx, "not_found", val)
+	assert.ErrorIs(t, err, ErrPlaceholder)
+	_, err = iCache.GetWithTTL(c.Ctx, "no_key", val)
+	assert.ErrorIs(t, err, CacheNotFound)
 }
 
 func TestRedisCacheError(t *testing.T) {
@@ -150,6 +158,10 @@ func TestRedisClusterCache(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, testData.Name, val.Name)
 
+	ttl, err := iCache.GetWithTTL(c.Ctx, key, val)
+	assert.NoError(t, err)
+	assert.True(t, ttl > 0 && ttl <= time.Minute)
+
 	err = iCache.Del(c.Ctx, key)
 	assert.NoError(t, err)
 
@@ -167,6 +179,10 @@ func TestRedisClusterCache(t *testing.T) {
 	assert.Equal(t, len(c.TestDataSlice), len(vals))
 	err = iCache.SetCacheWithNotFound(c.Ctx, "not_found")
 	assert.NoError(t, err)
+	_, err = iCache.GetWithTTL(c.Ctx, "not_found", val)
+	assert.ErrorIs(t, err, ErrPlaceholder)
+	_, err = iCache.GetWithTTL(c.Ctx, "no_key", val)
+	assert.ErrorIs(t, err, CacheNotFound)
 }
 
 func TestRedisClusterCacheError(t *testing.T) {
@@ -0,0 +1,94 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/viper"
+)
+
+// sources of configuration values
+const (
+	sourceDefault = "default"
+	sourceFile    = "file"
+	sourceRemote  = "remote"
+	sourceEnv     = "env"
+	sourceFlag    = "flag"
+)
+
+var sensitiveKeys = []string{"password", "pwd", "secret", "token"}
+
+// writeDiagnostics writes a table of all effective values and their sources, sensitive values are masked,
+// secret references are shown as they are, e.g. ${secret:vault:kv/data/app#db_password}.
+func writeDiagnostics(w io.Writer, vp *viper.Viper, sources map[string]string, hiddenFields ...string) {
+	keys := vp.AllKeys()
+	sort.Strings(keys)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "KEY\tVALUE\tSOURCE")
+	for _, key := range keys {
+		value := maskValue(key, vp.Get(key), hiddenFields)
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\n", key, formatValue(value), sources[key])
+	}
+	_ = tw.Flush()
+}
+
+// maskValue masks the value if the key is sensitive, values in maps and slices are masked by their keys.
+func maskValue(key string, value interface{}, hiddenFields []string) interface{} {
+	name := strings.ToLower(key[strings.LastIndex(key, ".")+1:])
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			m[k] = maskValue(k, val, hiddenFields)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, val := range v {
+			s[i] = maskValue(key, val, hiddenFields)
+		}
+		return s
+	case string:
+		if strings.Contains(v, "${secret:") || strings.Contains(v, "${env:") {
+			return v
+		}
+		if isSensitiveKey(name, hiddenFields) {
+			return "******"
+		}
+		if strings.Contains(v, "@") && strings.Contains(v, ":") {
+			return replaceDSN(v)
+		}
+	}
+	return value
+}
+
+func isSensitiveKey(name string, hiddenFields []string) bool {
+	for _, field := range hiddenFields {
+		if strings.EqualFold(strings.Trim(field, `"`), name) {
+			return true
+		}
+	}
+	for _, k := range sensitiveKeys {
+		if strings.Contains(name, k) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatValue(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(value)
+		if err == nil {
+			return string(data)
+		}
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,280 @@
+package conf
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// DefaultEnvPrefix is the default prefix of environment variables that override configuration values.
+const DefaultEnvPrefix = "SPONGE"
+
+// LoadOption set loadOptions.
+type LoadOption func(*loadOptions)
+
+type loadOptions struct {
+	defaults   map[string]interface{}
+	configFile string
+	remote     func() (format string, data []byte, err error)
+	envPrefix  string
+	flagSet    *flag.FlagSet
+	reloads    []func()
+
+	strict            bool
+	diagnosticsWriter io.Writer
+	hiddenFields      []string
+}
+
+func (o *loadOptions) apply(opts ...LoadOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+func defaultLoadOptions() *loadOptions {
+	return &loadOptions{
+		envPrefix: DefaultEnvPrefix,
+	}
+}
+
+// WithDefaults sets the default values, keys are configuration paths, e.g. "http.port".
+func WithDefaults(defaults map[string]interface{}) LoadOption {
+	return func(o *loadOptions) {
+		o.defaults = defaults
+	}
+}
+
+// WithConfigFile sets the configuration file, including yaml, toml, json, etc.
+func WithConfigFile(configFile string) LoadOption {
+	return func(o *loadOptions) {
+		o.configFile = configFile
+	}
+}
+
+// WithRemote sets the function to get configuration data from a configuration center,
+// format is the data format, such as "yaml", "json", "toml", e.g. nacoscli.GetConfig.
+func WithRemote(fn func() (format string, data []byte, err error)) LoadOption {
+	return func(o *loadOptions) {
+		o.remote = fn
+	}
+}
+
+// WithEnvPrefix sets the prefix of environment variables, default is SPONGE,
+// an empty prefix disables environment variable overrides.
+func WithEnvPrefix(prefix string) LoadOption {
+	return func(o *loadOptions) {
+		o.envPrefix = prefix
+	}
+}
+
+// WithFlags sets the parsed command line flags, a flag named by a configuration path
+// (e.g. -http.port=8080) overrides the value, only the flags that are defined in fs and
+// set on the command line are applied, flags are not registered for configuration paths.
+func WithFlags(fs *flag.FlagSet) LoadOption {
+	return func(o *loadOptions) {
+		o.flagSet = fs
+	}
+}
+
+// WithWatch turns on listening for configuration file changes, all sources are loaded
+// again when the file changes, and then the reloads are called.
+func WithWatch(reloads ...func()) LoadOption {
+	return func(o *loadOptions) {
+		o.reloads = reloads
+	}
+}
+
+// WithStrict returns an error if there are keys that do not match any field of the struct,
+// by default a warning is printed.
+func WithStrict() LoadOption {
+	return func(o *loadOptions) {
+		o.strict = true
+	}
+}
+
+// WithDiagnostics writes a table of all effective values and their sources (default, file, remote, env, flag)
+// to w after loading, sensitive values such as password, secret and token are masked, hiddenFields are
+// the names of other fields to be masked.
+func WithDiagnostics(w io.Writer, hiddenFields ...string) LoadOption {
+	return func(o *loadOptions) {
+		o.diagnosticsWriter = w
+		o.hiddenFields = hiddenFields
+	}
+}
+
+// Load merges multiple configuration sources to struct, the precedence from low to high is:
+//
+//	defaults < configuration file < remote configuration < environment variables < command line flags
+//
+// Environment variables and flags override keys that exist in the lower sources, the name of
+// an environment variable is the prefix and the upper case key path joined by "_", e.g.
+// SPONGE_HTTP_PORT overrides http.port, SPONGE_REDIS_READTIMEOUT overrides redis.readTimeout.
+// Secret references in values are resolved after merging, see RegisterSecretProvider,
+// and then the values are checked by the validate tag of struct fields, see Validate.
+func Load(obj interface{}, opts ...LoadOption) error {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("obj must be a non-nil pointer")
+	}
+
+	o := defaultLoadOptions()
+	o.apply(opts...)
+
+	vp, sources, err := o.load()
+	if err != nil {
+		return err
+	}
+	if o.diagnosticsWriter != nil {
+		writeDiagnostics(o.diagnosticsWriter, vp, sources, o.hiddenFields...)
+	}
+	err = o.decode(vp, obj)
+	if err != nil {
+		return err
+	}
+
+	if len(o.reloads) > 0 && o.configFile != "" {
+		o.watch(vp, obj)
+	}
+
+	return nil
+}
+
+// decode unmarshals the values to obj, resolves secret references, and checks the values.
+func (o *loadOptions) decode(vp *viper.Viper, obj interface{}) error {
+	err := vp.Unmarshal(obj)
+	if err != nil {
+		return err
+	}
+
+	if unknown := unknownKeys(obj, vp.AllKeys()); len(unknown) > 0 {
+		if o.strict {
+			return fmt.Errorf("unknown configuration keys: %s", strings.Join(unknown, ", "))
+		}
+		fmt.Println("[conf] warning: unknown configuration keys: " + strings.Join(unknown, ", "))
+	}
+
+	err = resolveSecrets(obj)
+	if err != nil {
+		return err
+	}
+	return Validate(obj)
+}
+
+// load reads all sources into a new viper instance, returns the source of each key.
+func (o *loadOptions) load() (*viper.Viper, map[string]string, error) {
+	vp := viper.New()
+	sources := make(map[string]string)
+
+	for key, value := range o.defaults {
+		vp.SetDefault(key, value)
+	}
+	for _, key := range vp.AllKeys() {
+		sources[key] = sourceDefault
+	}
+
+	if o.configFile != "" {
+		configFile, err := filepath.Abs(o.configFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		vp.SetConfigFile(configFile)
+		if err = vp.ReadInConfig(); err != nil {
+			return nil, nil, err
+		}
+		for _, key := range vp.AllKeys() {
+			if vp.InConfig(key) {
+				sources[key] = sourceFile
+			}
+		}
+	}
+
+	if o.remote != nil {
+		format, data, err := o.remote()
+		if err != nil {
+			return nil, nil, fmt.Errorf("get remote configuration error: %v", err)
+		}
+		remote := viper.New()
+		remote.SetConfigType(format)
+		if err = remote.ReadConfig(bytes.NewReader(data)); err != nil {
+			return nil, nil, fmt.Errorf("parse remote configuration error: %v", err)
+		}
+		if err = vp.MergeConfigMap(remote.AllSettings()); err != nil {
+			return nil, nil, err
+		}
+		for _, key := range remote.AllKeys() {
+			sources[key] = sourceRemote
+		}
+	}
+
+	keys := make(map[string]bool)
+	for _, key := range vp.AllKeys() {
+		keys[key] = true
+	}
+
+	if o.envPrefix != "" {
+		for key := range keys {
+			if value, ok := os.LookupEnv(envName(o.envPrefix, key)); ok {
+				vp.Set(key, value)
+				sources[key] = sourceEnv
+			}
+		}
+	}
+
+	if o.flagSet != nil {
+		o.flagSet.Visit(func(f *flag.Flag) {
+			key := strings.ToLower(f.Name)
+			if !keys[key] {
+				return
+			}
+			if getter, ok := f.Value.(flag.Getter); ok {
+				vp.Set(key, getter.Get())
+			} else {
+				vp.Set(key, f.Value.String())
+			}
+			sources[key] = sourceFlag
+		})
+	}
+
+	return vp, sources, nil
+}
+
+// listening for configuration file updates, the sources are loaded again
+func (o *loadOptions) watch(vp *viper.Viper, obj interface{}) {
+	vp.WatchConfig()
+
+	// Note: OnConfigChange is called twice on Windows
+	vp.OnConfigChange(func(e fsnotify.Event) {
+		newVp, _, err := o.load()
+		if err != nil {
+			fmt.Println("conf.Load error: ", err)
+			return
+		}
+
+		t := reflect.TypeOf(obj).Elem()
+		v := reflect.New(t)
+		err = o.decode(newVp, v.Interface())
+		if err != nil {
+			fmt.Println("conf.Load error: ", err)
+			return
+		}
+		oldObj := copyObj(obj)
+		reflect.ValueOf(obj).Elem().Set(v.Elem())
+		notifyChanges(oldObj, obj)
+		for _, reload := range o.reloads {
+			reload()
+		}
+	})
+}
+
+// envName returns the environment variable name of the key, e.g. SPONGE_HTTP_PORT for http.port.
+func envName(prefix string, key string) string {
+	return strings.ToUpper(prefix + "_" + strings.ReplaceAll(key, ".", "_"))
+}
@@ -0,0 +1,129 @@
+package conf
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type loadConfig struct {
+	App struct {
+		Name string
+		Env  string
+	}
+	HTTP struct {
+		Port         int
+		ReadTimeout  int
+		AllowOrigins []string
+	}
+	Redis struct {
+		Dsn string
+	}
+}
+
+const loadConfigData = `
+app:
+  name: "serverNameExample"
+  env: "dev"
+http:
+  port: 8080
+  readTimeout: 3
+`
+
+func TestLoad(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "app.yml")
+	_ = os.WriteFile(configFile, []byte(loadConfigData), 0666)
+
+	t.Setenv("SPONGE_HTTP_PORT", "9090")
+	t.Setenv("SPONGE_HTTP_READTIMEOUT", "5")
+	t.Setenv("SPONGE_HTTP_ALLOWORIGINS", "a.com,b.com")
+	t.Setenv("SPONGE_UNKNOWN", "ignored")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Int("http.readTimeout", 0, "read timeout")
+	fs.String("version", "", "ignored, not a configuration key")
+	_ = fs.Parse([]string{"-http.readTimeout=10", "-version=v1.0.0"})
+
+	remote := func() (string, []byte, error) {
+		return "json", []byte(`{"app":{"env":"prod"}}`), nil
+	}
+
+	config := &loadConfig{}
+	err := Load(config,
+		WithDefaults(map[string]interface{}{"redis.dsn": "127.0.0.1:6379", "http.allowOrigins": []string{}}),
+		WithConfigFile(configFile),
+		WithRemote(remote),
+		WithFlags(fs),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Redis.Dsn != "127.0.0.1:6379" {
+		t.Errorf("expected default value, got %s", config.Redis.Dsn)
+	}
+	if config.App.Name != "serverNameExample" {
+		t.Errorf("expected value of file, got %s", config.App.Name)
+	}
+	if config.App.Env != "prod" {
+		t.Errorf("expected remote value to override file, got %s", config.App.Env)
+	}
+	if config.HTTP.Port != 9090 {
+		t.Errorf("expected env value to override file, got %d", config.HTTP.Port)
+	}
+	if len(config.HTTP.AllowOrigins) != 2 {
+		t.Errorf("expected env value to override default, got %v", config.HTTP.AllowOrigins)
+	}
+	if config.HTTP.ReadTimeout != 10 {
+		t.Errorf("expected flag value to override env, got %d", config.HTTP.ReadTimeout)
+	}
+
+	// disable environment variables
+	config = &loadConfig{}
+	err = Load(config, WithConfigFile(configFile), WithEnvPrefix(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.HTTP.Port != 8080 {
+		t.Errorf("expected value of file, got %d", config.HTTP.Port)
+	}
+}
+
+func TestLoadWatch(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "app.yml")
+	_ = os.WriteFile(configFile, []byte(loadConfigData), 0666)
+	t.Setenv("SPONGE_APP_ENV", "test")
+
+	changed := make(chan struct{}, 2)
+	config := &loadConfig{}
+	err := Load(config, WithConfigFile(configFile), WithWatch(func() { changed <- struct{}{} }))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	_ = os.WriteFile(configFile, []byte(loadConfigData+"\nredis:\n  dsn: \"127.0.0.1:6379\"\n"), 0666)
+	select {
+	case <-changed:
+	case <-time.After(3 * time.Second):
+		t.Fatal("expected reload to be called")
+	}
+	if config.Redis.Dsn != "127.0.0.1:6379" || config.App.Env != "test" {
+		t.Errorf("expected all sources to be loaded again, got %+v", config)
+	}
+}
+
+func TestLoadErr(t *testing.T) {
+	if err := Load(nil); err == nil {
+		t.Error("expected an error for nil obj")
+	}
+	if err := Load(&loadConfig{}, WithConfigFile("notfound.yml")); err == nil {
+		t.Error("expected an error for missing file")
+	}
+	remote := func() (string, []byte, error) { return "json", []byte("{"), nil }
+	if err := Load(&loadConfig{}, WithRemote(remote)); err == nil {
+		t.Error("expected an error for invalid remote data")
+	}
+}
@@ -15,7 +15,8 @@ import (
 	"github.com/spf13/viper"
 )
 
-// Parse configuration files to struct, including yaml, toml, json, etc., and turn on listening for configuration file changes if fs is not empty
+// Parse configuration files to struct, including yaml, toml, json, etc., and turn on listening for configuration file changes if fs is not empty,
+// secret references such as ${env:DB_PASS} and ${secret:vault:kv/data/app#db_password} in values are resolved
 func Parse(configFile string, obj interface{}, reloads ...func()) error {
 	v := reflect.ValueOf(obj)
 	if v.Kind() != reflect.Ptr || v.IsNil() {
@@ -45,6 +46,14 @@ func Parse(configFile string, obj interface{}, reloads ...func()) error {
 	if err != nil {
 		return err
 	}
+	err = resolveSecrets(obj)
+	if err != nil {
+		return err
+	}
+	err = Validate(obj)
+	if err != nil {
+		return err
+	}
 
 	if len(reloads) > 0 {
 		watchConfig(obj, reloads...)
@@ -61,7 +70,15 @@ func ParseConfigData(data []byte, format string, obj interface{}) error {
 		return err
 	}
 
-	return viper.Unmarshal(obj)
+	err = viper.Unmarshal(obj)
+	if err != nil {
+		return err
+	}
+	err = resolveSecrets(obj)
+	if err != nil {
+		return err
+	}
+	return Validate(obj)
 }
 
 // listening for profile updates
@@ -70,17 +87,30 @@ func watchConfig(obj interface{}, reloads ...func()) {
 
 	// Note: OnConfigChange is called twice on Windows
 	viper.OnConfigChange(func(e fsnotify.Event) {
+		// decode into a new object, the object in use is replaced only if all steps succeed,
+		// otherwise the previous configuration is kept
 		t := reflect.TypeOf(obj).Elem()
 		v := reflect.New(t)
-		reflect.ValueOf(obj).Elem().Set(v.Elem()) // reset object
+		newObj := v.Interface()
 
-		err := viper.Unmarshal(obj)
-		if err != nil {
-			fmt.Println("viper.Unmarshal error: ", err)
-		} else {
-			for _, reload := range reloads {
-				reload()
-			}
+		if err := viper.Unmarshal(newObj); err != nil {
+			fmt.Println("viper.Unmarshal error, keep the previous configuration: ", err)
+			return
+		}
+		if err := resolveSecrets(newObj); err != nil {
+			fmt.Println("conf.resolveSecrets error, keep the previous configuration: ", err)
+			return
+		}
+		if err := Validate(newObj); err != nil {
+			fmt.Println("conf.Validate error, keep the previous configuration: ", err)
+			return
+		}
+
+		oldObj := copyObj(obj)
+		reflect.ValueOf(obj).Elem().Set(v.Elem())
+		notifyChanges(oldObj, obj)
+		for _, reload := range reloads {
+			reload()
 		}
 	})
 }
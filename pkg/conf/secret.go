@@ -0,0 +1,260 @@
+package conf
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// secret references in string values, e.g. ${env:DB_PASS}, ${secret:vault:kv/data/app#db_password}
+var secretRefRegexp = regexp.MustCompile(`\$\{(env|secret):([^}]+)\}`)
+
+const secretCacheTTL = 5 * time.Minute
+
+// SecretProvider gets secrets from a secret store such as Vault or KMS.
+type SecretProvider interface {
+	// GetSecret returns the secret of ref, ref is the part after "${secret:<name>:", e.g. "kv/data/app#db_password".
+	GetSecret(ctx context.Context, ref string) (string, error)
+}
+
+// SecretProviderFunc is an adapter to use a function as a SecretProvider.
+type SecretProviderFunc func(ctx context.Context, ref string) (string, error)
+
+// GetSecret calls f(ctx, ref).
+func (f SecretProviderFunc) GetSecret(ctx context.Context, ref string) (string, error) {
+	return f(ctx, ref)
+}
+
+type secretEntry struct {
+	value     string
+	expiresAt time.Time
+}
+
+var (
+	secretMu        sync.RWMutex
+	secretProviders = map[string]SecretProvider{
+		"vault": NewVaultProvider("", ""),
+	}
+	secretCache sync.Map // provider:ref -> secretEntry
+)
+
+// RegisterSecretProvider registers a secret provider, the secret references ${secret:<name>:<ref>} in
+// configuration values are resolved by it, the built-in provider "vault" can be replaced.
+func RegisterSecretProvider(name string, provider SecretProvider) {
+	secretMu.Lock()
+	defer secretMu.Unlock()
+	secretProviders[name] = provider
+}
+
+func getSecretProvider(name string) (SecretProvider, bool) {
+	secretMu.RLock()
+	defer secretMu.RUnlock()
+	p, ok := secretProviders[name]
+	return p, ok
+}
+
+// resolveSecrets replaces the secret references in all string values of obj, the errors
+// of all values that can not be resolved are returned together.
+func resolveSecrets(obj interface{}) error {
+	var errs []error
+	walkStrings(reflect.ValueOf(obj), "", func(path string, s string) (string, bool) {
+		if !strings.Contains(s, "${") {
+			return s, false
+		}
+		resolved, err := resolveString(s)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("resolve '%s' error: %v", path, err))
+			return s, false
+		}
+		return resolved, true
+	})
+	return errors.Join(errs...)
+}
+
+// resolveString replaces the secret references in s.
+func resolveString(s string) (string, error) {
+	var err error
+	out := secretRefRegexp.ReplaceAllStringFunc(s, func(match string) string {
+		if err != nil {
+			return match
+		}
+		sub := secretRefRegexp.FindStringSubmatch(match)
+		var value string
+		switch sub[1] {
+		case "env":
+			var ok bool
+			value, ok = os.LookupEnv(sub[2])
+			if !ok {
+				err = fmt.Errorf("environment variable %s is not set", sub[2])
+			}
+		case "secret":
+			value, err = getSecret(sub[2])
+		}
+		return value
+	})
+	return out, err
+}
+
+// getSecret gets the secret of "<provider>:<ref>", the secret is cached for a while.
+func getSecret(providerRef string) (string, error) {
+	if v, ok := secretCache.Load(providerRef); ok {
+		entry := v.(secretEntry)
+		if time.Now().Before(entry.expiresAt) {
+			return entry.value, nil
+		}
+	}
+
+	name, ref, ok := strings.Cut(providerRef, ":")
+	if !ok || ref == "" {
+		return "", fmt.Errorf("invalid secret reference '%s', the format is ${secret:<provider>:<ref>}", providerRef)
+	}
+	provider, ok := getSecretProvider(name)
+	if !ok {
+		return "", fmt.Errorf("secret provider '%s' is not registered", name)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	value, err := provider.GetSecret(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("get secret '%s' error: %v", providerRef, err)
+	}
+	secretCache.Store(providerRef, secretEntry{value: value, expiresAt: time.Now().Add(secretCacheTTL)})
+	return value, nil
+}
+
+// walkStrings calls fn with the path of each settable string value in v, including values in
+// structs, slices, arrays and maps, the value is replaced if fn returns true.
+func walkStrings(v reflect.Value, path string, fn func(path string, s string) (string, bool)) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		if v.Kind() == reflect.Interface && v.Elem().Kind() == reflect.String {
+			if s, ok := fn(path, v.Elem().String()); ok && v.CanSet() {
+				v.Set(reflect.ValueOf(s))
+			}
+			return
+		}
+		walkStrings(v.Elem(), path, fn)
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			if t.Field(i).IsExported() {
+				walkStrings(v.Field(i), joinPath(path, t.Field(i).Name), fn)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			walkStrings(v.Index(i), fmt.Sprintf("%s[%d]", path, i), fn)
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			elem := v.MapIndex(key)
+			elemPath := joinPath(path, fmt.Sprint(key.Interface()))
+			if elem.Kind() == reflect.Interface && !elem.IsNil() {
+				elem = elem.Elem()
+			}
+			switch elem.Kind() {
+			case reflect.String:
+				if s, ok := fn(elemPath, elem.String()); ok {
+					v.SetMapIndex(key, reflect.ValueOf(s).Convert(v.Type().Elem()))
+				}
+			default:
+				// map values are not addressable, copy them to set nested values
+				cp := reflect.New(elem.Type()).Elem()
+				cp.Set(elem)
+				walkStrings(cp, elemPath, fn)
+				v.SetMapIndex(key, cp)
+			}
+		}
+	case reflect.String:
+		if s, ok := fn(path, v.String()); ok && v.CanSet() {
+			v.SetString(s)
+		}
+	}
+}
+
+func joinPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// ------------------------------------------------------------------------------------------
+
+// VaultProvider gets secrets from the KV secrets engine of HashiCorp Vault,
+// the ref is "<path>#<field>", e.g. kv/data/app#db_password.
+type VaultProvider struct {
+	addr   string
+	token  string
+	client *http.Client
+}
+
+// NewVaultProvider creates a Vault secret provider, if addr or token is empty,
+// the environment variable VAULT_ADDR or VAULT_TOKEN is used when getting secrets.
+func NewVaultProvider(addr string, token string) *VaultProvider {
+	return &VaultProvider{
+		addr:   addr,
+		token:  token,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// GetSecret gets the field of the secret in path, both KV version 1 and 2 are supported.
+func (p *VaultProvider) GetSecret(ctx context.Context, ref string) (string, error) {
+	addr, token := p.addr, p.token
+	if addr == "" {
+		addr = os.Getenv("VAULT_ADDR")
+	}
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+	if addr == "" {
+		return "", errors.New("vault address is not set, set VAULT_ADDR or use NewVaultProvider")
+	}
+	path, field, ok := strings.Cut(ref, "#")
+	if !ok || field == "" {
+		return "", fmt.Errorf("invalid vault reference '%s', the format is <path>#<field>", ref)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(addr, "/")+"/v1/"+strings.TrimLeft(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close() //nolint
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	var result struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	data := result.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok { // KV version 2
+		data = nested
+	}
+	value, ok := data[field]
+	if !ok {
+		return "", fmt.Errorf("field '%s' not found in '%s'", field, path)
+	}
+	return fmt.Sprint(value), nil
+}
@@ -0,0 +1,155 @@
+package conf
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type secretConfig struct {
+	Database struct {
+		Dsn      string
+		Password string
+	}
+	GrpcClient []struct {
+		Name  string
+		Token string
+	}
+	Labels map[string]interface{}
+}
+
+func TestResolveSecrets(t *testing.T) {
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/kv/data/app" || r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"data":{"db_password":"vault-pass"}}}`))
+	}))
+	defer vault.Close()
+	t.Setenv("VAULT_ADDR", vault.URL)
+	t.Setenv("VAULT_TOKEN", "root")
+	t.Setenv("DB_PASS", "env-pass")
+
+	calls := 0
+	RegisterSecretProvider("test", SecretProviderFunc(func(ctx context.Context, ref string) (string, error) {
+		calls++
+		return "token-" + ref, nil
+	}))
+
+	data := `
+database:
+  dsn: "root:${env:DB_PASS}@(127.0.0.1:3306)/account"
+  password: "${secret:vault:kv/data/app#db_password}"
+grpcClient:
+  - name: "user"
+    token: "${secret:test:user}"
+  - name: "order"
+    token: "${secret:test:user}"
+labels:
+  team: "${env:DB_PASS}"
+`
+	configFile := filepath.Join(t.TempDir(), "app.yml")
+	_ = os.WriteFile(configFile, []byte(data), 0666)
+
+	config := &secretConfig{}
+	err := Load(config, WithConfigFile(configFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Database.Dsn != "root:env-pass@(127.0.0.1:3306)/account" {
+		t.Errorf("unexpected dsn: %s", config.Database.Dsn)
+	}
+	if config.Database.Password != "vault-pass" {
+		t.Errorf("unexpected password: %s", config.Database.Password)
+	}
+	if config.GrpcClient[0].Token != "token-user" || config.GrpcClient[1].Token != "token-user" {
+		t.Errorf("unexpected tokens: %+v", config.GrpcClient)
+	}
+	if calls != 1 {
+		t.Errorf("expected secret to be cached, got %d calls", calls)
+	}
+	if config.Labels["team"] != "env-pass" {
+		t.Errorf("unexpected label: %v", config.Labels["team"])
+	}
+}
+
+func TestResolveSecretsErr(t *testing.T) {
+	RegisterSecretProvider("failed", SecretProviderFunc(func(ctx context.Context, ref string) (string, error) {
+		return "", errors.New("access denied")
+	}))
+
+	data := []byte(`
+database:
+  dsn: "root:${env:NOT_EXIST_PASS}@(127.0.0.1:3306)/account"
+  password: "${secret:failed:db}"
+grpcClient:
+  - token: "${secret:unknown:user}"
+`)
+	err := ParseConfigData(data, "yaml", &secretConfig{})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, s := range []string{"Database.Dsn", "NOT_EXIST_PASS", "Database.Password", "access denied", "GrpcClient[0].Token", "not registered"} {
+		if !strings.Contains(err.Error(), s) {
+			t.Errorf("expected error to contain %q, got %v", s, err)
+		}
+	}
+
+	_, err = NewVaultProvider("", "").GetSecret(context.Background(), "kv/data/app")
+	if err == nil {
+		t.Error("expected an error for invalid reference")
+	}
+}
+
+func TestParseReloadSecretErr(t *testing.T) {
+	t.Setenv("RELOAD_DB_PASS", "env-pass")
+	configFile := filepath.Join(t.TempDir(), "reload_secret.yml")
+	_ = os.WriteFile(configFile, []byte("database:\n  dsn: \"root:${env:RELOAD_DB_PASS}@(127.0.0.1:3306)/account\"\n"), 0666)
+
+	config := &secretConfig{}
+	reloaded := make(chan struct{}, 2)
+	err := Parse(configFile, config, func() { reloaded <- struct{}{} })
+	if err != nil {
+		t.Fatal(err)
+	}
+	dsn := "root:env-pass@(127.0.0.1:3306)/account"
+	if config.Database.Dsn != dsn {
+		t.Fatalf("unexpected dsn: %s", config.Database.Dsn)
+	}
+
+	// the secret can not be resolved, the previous configuration is kept
+	time.Sleep(time.Millisecond * 100)
+	replaceFile(t, configFile, "database:\n  dsn: \"root:${env:RELOAD_NOT_EXIST_PASS}@(127.0.0.1:3306)/account\"\n")
+	time.Sleep(time.Millisecond * 500)
+	if len(reloaded) > 0 || config.Database.Dsn != dsn {
+		t.Fatalf("expected the previous configuration to be kept, got dsn %s", config.Database.Dsn)
+	}
+
+	replaceFile(t, configFile, "database:\n  dsn: \"root:${env:RELOAD_DB_PASS}@(127.0.0.1:3307)/account\"\n")
+	select {
+	case <-reloaded:
+	case <-time.After(time.Second * 2):
+		t.Fatal("expected the configuration to be reloaded")
+	}
+	if config.Database.Dsn != "root:env-pass@(127.0.0.1:3307)/account" {
+		t.Errorf("unexpected dsn: %s", config.Database.Dsn)
+	}
+}
+
+// replaceFile replaces the content of file by renaming, so the watcher never reads a truncated file.
+func replaceFile(t *testing.T, file string, content string) {
+	tmpFile := file + ".tmp"
+	if err := os.WriteFile(tmpFile, []byte(content), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmpFile, file); err != nil {
+		t.Fatal(err)
+	}
+}
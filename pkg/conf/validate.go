@@ -0,0 +1,128 @@
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	validateOnce sync.Once
+	validate     *validator.Validate
+)
+
+func getValidate() *validator.Validate {
+	validateOnce.Do(func() {
+		validate = validator.New()
+		validate.RegisterTagNameFunc(configFieldName)
+	})
+	return validate
+}
+
+// Validate checks the configuration values by the rules in the validate tag of struct fields,
+// e.g. `validate:"required"`, `validate:"min=1,max=65535"`, `validate:"oneof=dev test prod"`,
+// see https://github.com/go-playground/validator for all rules, the errors of all invalid values
+// are returned together.
+func Validate(obj interface{}) error {
+	v := reflect.ValueOf(obj)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	err := getValidate().Struct(obj)
+	if err == nil {
+		return nil
+	}
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return err
+	}
+
+	var msgs []string
+	for _, e := range validationErrs {
+		path := e.Namespace()
+		if i := strings.Index(path, "."); i >= 0 {
+			path = path[i+1:] // remove the name of the root struct
+		}
+		rule := e.Tag()
+		if e.Param() != "" {
+			rule += "=" + e.Param()
+		}
+		if e.Tag() == "required" {
+			msgs = append(msgs, fmt.Sprintf("    %s: is required", path))
+		} else {
+			msgs = append(msgs, fmt.Sprintf("    %s: value '%v' does not satisfy '%s'", path, e.Value(), rule))
+		}
+	}
+	return fmt.Errorf("invalid configuration:\n%s", strings.Join(msgs, "\n"))
+}
+
+// unknownKeys returns the keys that do not match any field of obj, keys under maps
+// and interfaces are allowed.
+func unknownKeys(obj interface{}, keys []string) []string {
+	t := reflect.TypeOf(obj)
+	var unknown []string
+	for _, key := range keys {
+		if !isKnownKey(t, strings.Split(key, ".")) {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+func isKnownKey(t reflect.Type, path []string) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if len(path) == 0 {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.Map, reflect.Interface:
+		return true
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if field.Anonymous && strings.Contains(field.Tag.Get("mapstructure"), ",squash") {
+				if isKnownKey(field.Type, path) {
+					return true
+				}
+				continue
+			}
+			if strings.EqualFold(configFieldName(field), path[0]) || strings.EqualFold(field.Name, path[0]) {
+				return isKnownKey(field.Type, path[1:])
+			}
+		}
+	}
+	return false
+}
+
+// configFieldName returns the name of the field in configuration files.
+func configFieldName(field reflect.StructField) string {
+	for _, tag := range []string{"yaml", "mapstructure", "json"} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
@@ -0,0 +1,126 @@
+package conf
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type validatedConfig struct {
+	App struct {
+		Name string `yaml:"name" validate:"required"`
+		Env  string `yaml:"env" validate:"oneof=dev test prod"`
+	} `yaml:"app"`
+	HTTP struct {
+		Port int `yaml:"port" validate:"min=1,max=65535"`
+	} `yaml:"http"`
+	Database struct {
+		Password string `yaml:"password"`
+		Dsn      string `yaml:"dsn"`
+	} `yaml:"database"`
+	Labels map[string]string `yaml:"labels"`
+}
+
+func TestValidate(t *testing.T) {
+	config := &validatedConfig{}
+	config.App.Env = "staging"
+	config.HTTP.Port = 70000
+	err := Validate(config)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, s := range []string{"app.name: is required", "app.env: value 'staging' does not satisfy 'oneof=dev test prod'", "http.port: value '70000' does not satisfy 'max=65535'"} {
+		if !strings.Contains(err.Error(), s) {
+			t.Errorf("expected error to contain %q, got %v", s, err)
+		}
+	}
+
+	config.App.Name = "serverNameExample"
+	config.App.Env = "prod"
+	config.HTTP.Port = 8080
+	if err = Validate(config); err != nil {
+		t.Error(err)
+	}
+	if err = Validate(&map[string]interface{}{}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLoadStrict(t *testing.T) {
+	data := `
+app:
+  name: "serverNameExample"
+  env: "prod"
+  versoin: "v1.0.0"
+http:
+  port: 8080
+database:
+  password: "123456"
+  dsn: "root:123456@(127.0.0.1:3306)/account"
+labels:
+  team: "user"
+`
+	configFile := filepath.Join(t.TempDir(), "app.yml")
+	_ = os.WriteFile(configFile, []byte(data), 0666)
+	t.Setenv("SPONGE_HTTP_PORT", "9090")
+
+	buf := &bytes.Buffer{}
+	err := Load(&validatedConfig{}, WithConfigFile(configFile), WithDiagnostics(buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	t.Log("\n" + out)
+	for _, s := range []string{"KEY", "http.port", "9090", "env", "labels.team", "file"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("expected diagnostics to contain %q", s)
+		}
+	}
+	if strings.Contains(out, "123456") {
+		t.Error("expected password to be masked")
+	}
+
+	err = Load(&validatedConfig{}, WithConfigFile(configFile), WithStrict())
+	if err == nil || !strings.Contains(err.Error(), "app.versoin") {
+		t.Errorf("expected unknown key error, got %v", err)
+	}
+
+	_ = os.WriteFile(configFile, []byte("app:\n  env: \"prod\"\nhttp:\n  port: 0\n"), 0666)
+	err = Load(&validatedConfig{}, WithConfigFile(configFile))
+	if err == nil || !strings.Contains(err.Error(), "app.name: is required") {
+		t.Errorf("expected validation error, got %v", err)
+	}
+}
+
+func TestParseReloadInvalid(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "reload_validate.yml")
+	_ = os.WriteFile(configFile, []byte("app:\n  name: \"serverNameExample\"\n  env: \"prod\"\nhttp:\n  port: 8080\n"), 0666)
+
+	config := &validatedConfig{}
+	reloaded := make(chan struct{}, 2)
+	err := Parse(configFile, config, func() { reloaded <- struct{}{} })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the value is invalid, the previous configuration is kept instead of a zeroed one
+	time.Sleep(time.Millisecond * 100)
+	replaceFile(t, configFile, "app:\n  name: \"serverNameExample\"\n  env: \"prod\"\nhttp:\n  port: 0\n")
+	time.Sleep(time.Millisecond * 500)
+	if len(reloaded) > 0 || config.HTTP.Port != 8080 || config.App.Name != "serverNameExample" {
+		t.Fatalf("expected the previous configuration to be kept, got %+v", config)
+	}
+
+	replaceFile(t, configFile, "app:\n  name: \"serverNameExample\"\n  env: \"prod\"\nhttp:\n  port: 9090\n")
+	select {
+	case <-reloaded:
+	case <-time.After(time.Second * 2):
+		t.Fatal("expected the configuration to be reloaded")
+	}
+	if config.HTTP.Port != 9090 {
+		t.Errorf("unexpected port: %d", config.HTTP.Port)
+	}
+}
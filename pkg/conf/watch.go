@@ -0,0 +1,131 @@
+package conf
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// ChangeHandler is called with the old and new values when the value of a watched path changes.
+type ChangeHandler func(oldValue interface{}, newValue interface{})
+
+type subscriber struct {
+	id   int
+	path []string
+	fn   ChangeHandler
+}
+
+var (
+	subscriberMu sync.Mutex
+	subscriberID int
+	subscribers  []subscriber
+)
+
+// Watch subscribes to changes of the configuration path, such as "grpcClient", "http.port",
+// fn is called with the old and new values after the configuration is reloaded, a path is
+// changed if any value under it is changed. It takes effect when listening for configuration
+// file changes is turned on (Parse with reloads, or Load with WithWatch), returns a function
+// to cancel the subscription.
+func Watch(path string, fn ChangeHandler) (cancel func()) {
+	subscriberMu.Lock()
+	defer subscriberMu.Unlock()
+
+	subscriberID++
+	id := subscriberID
+	subscribers = append(subscribers, subscriber{id: id, path: splitPath(path), fn: fn})
+
+	return func() {
+		subscriberMu.Lock()
+		defer subscriberMu.Unlock()
+		for i, s := range subscribers {
+			if s.id == id {
+				subscribers = append(subscribers[:i:i], subscribers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// notifyChanges calls the subscribers whose path values are different in oldObj and newObj.
+func notifyChanges(oldObj interface{}, newObj interface{}) {
+	subscriberMu.Lock()
+	subs := append([]subscriber{}, subscribers...)
+	subscriberMu.Unlock()
+
+	for _, s := range subs {
+		oldValue, ok1 := lookupPath(reflect.ValueOf(oldObj), s.path)
+		newValue, ok2 := lookupPath(reflect.ValueOf(newObj), s.path)
+		if !ok1 && !ok2 {
+			continue // the path does not belong to this configuration
+		}
+		if !reflect.DeepEqual(oldValue, newValue) {
+			s.fn(oldValue, newValue)
+		}
+	}
+}
+
+// copyObj returns a shallow copy of the struct that obj points to, the values decoded
+// into obj are newly allocated each time, so the copy keeps the old values after reloading.
+func copyObj(obj interface{}) interface{} {
+	v := reflect.ValueOf(obj).Elem()
+	cp := reflect.New(v.Type())
+	cp.Elem().Set(v)
+	return cp.Interface()
+}
+
+// lookupPath returns the value of path in v, field names and map keys are case-insensitive,
+// the same as decoding configuration.
+func lookupPath(v reflect.Value, path []string) (interface{}, bool) {
+	for _, name := range path {
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return nil, false
+			}
+			v = v.Elem()
+		}
+
+		switch v.Kind() {
+		case reflect.Struct:
+			t := v.Type()
+			found := false
+			for i := 0; i < v.NumField(); i++ {
+				field := t.Field(i)
+				tag, _, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+				if field.IsExported() && (strings.EqualFold(field.Name, name) || (tag != "" && strings.EqualFold(tag, name))) {
+					v = v.Field(i)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, false
+			}
+		case reflect.Map:
+			found := false
+			for _, key := range v.MapKeys() {
+				if key.Kind() == reflect.String && strings.EqualFold(key.String(), name) {
+					v = v.MapIndex(key)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, false
+			}
+		default:
+			return nil, false
+		}
+	}
+
+	if !v.IsValid() || !v.CanInterface() {
+		return nil, false
+	}
+	return v.Interface(), true
+}
+
+func splitPath(path string) []string {
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, ".")
+}
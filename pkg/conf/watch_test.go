@@ -0,0 +1,96 @@
+package conf
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type watchedConfig struct {
+	HTTP struct {
+		Port int
+	}
+	GrpcClient []struct {
+		Name string
+		Host string
+	} `mapstructure:"grpcClient"`
+}
+
+func TestWatch(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "app.yml")
+	_ = os.WriteFile(configFile, []byte("http:\n  port: 8080\ngrpcClient:\n  - name: user\n    host: 127.0.0.1\n"), 0666)
+
+	type change struct{ oldValue, newValue interface{} }
+	portCh := make(chan change, 2)
+	clientCh := make(chan change, 2)
+	cancelPort := Watch("http.port", func(oldValue, newValue interface{}) {
+		portCh <- change{oldValue, newValue}
+	})
+	cancelClient := Watch("grpcClient", func(oldValue, newValue interface{}) {
+		clientCh <- change{oldValue, newValue}
+	})
+	defer cancelClient()
+
+	reloaded := make(chan struct{}, 2)
+	config := &watchedConfig{}
+	err := Load(config, WithConfigFile(configFile), WithWatch(func() { reloaded <- struct{}{} }))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// only the grpc client is changed
+	time.Sleep(100 * time.Millisecond)
+	_ = os.WriteFile(configFile, []byte("http:\n  port: 8080\ngrpcClient:\n  - name: user\n    host: 192.168.1.10\n"), 0666)
+	select {
+	case <-reloaded:
+	case <-time.After(3 * time.Second):
+		t.Fatal("expected reload to be called")
+	}
+	select {
+	case c := <-clientCh:
+		oldValue := c.oldValue.([]struct {
+			Name string
+			Host string
+		})
+		newValue := c.newValue.([]struct {
+			Name string
+			Host string
+		})
+		if oldValue[0].Host != "127.0.0.1" || newValue[0].Host != "192.168.1.10" {
+			t.Errorf("unexpected values: %v -> %v", oldValue, newValue)
+		}
+	default:
+		t.Error("expected grpcClient handler to be called")
+	}
+	select {
+	case c := <-portCh:
+		t.Errorf("unexpected http.port change: %v -> %v", c.oldValue, c.newValue)
+	default:
+	}
+
+	// cancel the subscription
+	cancelPort()
+	notifyChanges(&watchedConfig{}, config)
+	select {
+	case <-portCh:
+		t.Error("expected subscription to be canceled")
+	default:
+	}
+}
+
+func Test_lookupPath(t *testing.T) {
+	conf := map[string]interface{}{
+		"redis": map[string]interface{}{"dsn": "127.0.0.1:6379"},
+	}
+	if v, ok := lookupPath(reflect.ValueOf(&conf), splitPath("Redis.DSN")); !ok || v != "127.0.0.1:6379" {
+		t.Errorf("unexpected value: %v", v)
+	}
+	if _, ok := lookupPath(reflect.ValueOf(&conf), splitPath("redis.dsn.host")); ok {
+		t.Error("expected path not found")
+	}
+	if _, ok := lookupPath(reflect.ValueOf(&watchedConfig{}), splitPath("http.host")); ok {
+		t.Error("expected path not found")
+	}
+}
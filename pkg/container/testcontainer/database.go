@@ -0,0 +1,221 @@
+package testcontainer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/go-dev-frame/sponge/pkg/sgorm"
+	"github.com/go-dev-frame/sponge/pkg/sgorm/dbclose"
+	"github.com/go-dev-frame/sponge/pkg/sgorm/migrate"
+	"github.com/go-dev-frame/sponge/pkg/sgorm/mysql"
+	"github.com/go-dev-frame/sponge/pkg/sgorm/postgresql"
+	"github.com/go-dev-frame/sponge/pkg/sgorm/sqlite"
+)
+
+const (
+	defaultMysqlImage    = "mysql:8.0"
+	defaultPostgresImage = "postgres:16-alpine"
+	defaultPassword      = "123456"
+)
+
+// Database a disposable database for test
+type Database struct {
+	Driver string
+	DSN    string // dsn of mysql and postgresql, file path of sqlite
+	DB     *gorm.DB
+
+	container *Container
+	tmpDir    string
+}
+
+// NewDatabase create a disposable database by driver name, the drivers are mysql, tidb, postgresql and sqlite,
+// tidb uses the mysql container, sqlite uses the file in temporary directory and does not require docker.
+func NewDatabase(ctx context.Context, driver string, opts ...Option) (*Database, error) {
+	switch strings.ToLower(driver) {
+	case sgorm.DBDriverMysql, sgorm.DBDriverTidb:
+		return NewMySQL(ctx, opts...)
+	case sgorm.DBDriverPostgresql:
+		return NewPostgres(ctx, opts...)
+	case sgorm.DBDriverSqlite:
+		return NewSQLite(ctx, opts...)
+	}
+	return nil, fmt.Errorf("unsupported database driver '%s'", driver)
+}
+
+// NewMySQL start a mysql container and connect to it
+func NewMySQL(ctx context.Context, opts ...Option) (*Database, error) {
+	o := defaultOptions()
+	o.apply(opts...)
+	if o.image == "" {
+		o.image = defaultMysqlImage
+	}
+	o.env["MYSQL_ROOT_PASSWORD"] = defaultPassword
+	o.env["MYSQL_DATABASE"] = o.dbName
+
+	return newContainerDatabase(ctx, sgorm.DBDriverMysql, o, "3306", func(addr string) (string, error) {
+		dsn := fmt.Sprintf("root:%s@(%s)/%s?charset=utf8mb4&parseTime=true&loc=Local", defaultPassword, addr, o.dbName)
+		return dsn, nil
+	}, func(dsn string) (*gorm.DB, error) {
+		return mysql.Init(dsn)
+	})
+}
+
+// NewPostgres start a postgresql container and connect to it
+func NewPostgres(ctx context.Context, opts ...Option) (*Database, error) {
+	o := defaultOptions()
+	o.apply(opts...)
+	if o.image == "" {
+		o.image = defaultPostgresImage
+	}
+	o.env["POSTGRES_USER"] = "postgres"
+	o.env["POSTGRES_PASSWORD"] = defaultPassword
+	o.env["POSTGRES_DB"] = o.dbName
+
+	return newContainerDatabase(ctx, sgorm.DBDriverPostgresql, o, "5432", func(addr string) (string, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return "", err
+		}
+		dsn := fmt.Sprintf("host=%s port=%s user=postgres password=%s dbname=%s sslmode=disable",
+			host, port, defaultPassword, o.dbName)
+		return dsn, nil
+	}, func(dsn string) (*gorm.DB, error) {
+		return postgresql.Init(dsn)
+	})
+}
+
+// NewSQLite create a sqlite database file in temporary directory, it is removed by Close
+func NewSQLite(_ context.Context, opts ...Option) (*Database, error) {
+	o := defaultOptions()
+	o.apply(opts...)
+
+	tmpDir, err := os.MkdirTemp("", "testcontainer_sqlite_")
+	if err != nil {
+		return nil, err
+	}
+	dbFile := filepath.Join(tmpDir, o.dbName+".db")
+	d := &Database{Driver: sgorm.DBDriverSqlite, DSN: dbFile, tmpDir: tmpDir}
+
+	d.DB, err = sqlite.Init(dbFile)
+	if err != nil {
+		_ = d.Close()
+		return nil, err
+	}
+	if err = LoadSchema(d.DB, o.schemas...); err != nil {
+		_ = d.Close()
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func newContainerDatabase(ctx context.Context, driver string, o *options, port string,
+	getDsn func(addr string) (string, error), connect func(dsn string) (*gorm.DB, error)) (*Database, error) {
+	c, err := runContainer(ctx, o)
+	if err != nil {
+		return nil, err
+	}
+	d := &Database{Driver: driver, container: c}
+
+	err = waitFor(ctx, o.startTimeout, func() error {
+		addr, err := c.Endpoint(ctx, port)
+		if err != nil {
+			return err
+		}
+		d.DSN, err = getDsn(addr)
+		if err != nil {
+			return err
+		}
+		db, err := connect(d.DSN)
+		if err != nil {
+			return err
+		}
+		if err = pingDB(ctx, db); err != nil {
+			_ = dbclose.Close(db)
+			return err
+		}
+		d.DB = db
+		return nil
+	})
+	if err != nil {
+		_ = d.Close()
+		return nil, fmt.Errorf("connect to %s container error: %v", driver, err)
+	}
+
+	if err = LoadSchema(d.DB, o.schemas...); err != nil {
+		_ = d.Close()
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// Close close the connection and remove the container or temporary file
+func (d *Database) Close() error {
+	var errs []string
+	if d.DB != nil {
+		if err := dbclose.Close(d.DB); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if d.container != nil {
+		if err := d.container.Terminate(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if d.tmpDir != "" {
+		if err := os.RemoveAll(d.tmpDir); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("close database error: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// LoadSchema load the schema to database, the path is a sql file or a directory of migration files,
+// the non-existent directory is ignored, so the migrations directory of project can be used before it has files.
+func LoadSchema(db *gorm.DB, paths ...string) error {
+	for _, path := range paths {
+		fi, err := os.Stat(path)
+		if err != nil {
+			if os.IsNotExist(err) && filepath.Ext(path) != ".sql" {
+				continue
+			}
+			return err
+		}
+
+		if fi.IsDir() {
+			if err = migrate.Up(db, os.DirFS(path)); err != nil {
+				return fmt.Errorf("apply migrations in '%s' error: %v", path, err)
+			}
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for _, statement := range migrate.SplitStatements(string(data)) {
+			if err = db.Exec(statement).Error; err != nil {
+				return fmt.Errorf("execute sql file '%s' error: %v", path, err)
+			}
+		}
+	}
+	return nil
+}
+
+func pingDB(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
@@ -0,0 +1,94 @@
+package testcontainer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type user struct {
+	ID   uint64 `gorm:"primaryKey"`
+	Name string
+}
+
+func writeFile(t *testing.T, file string, content string) {
+	if err := os.WriteFile(file, []byte(content), 0666); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewSQLite(t *testing.T) {
+	dir := t.TempDir()
+	sqlFile := filepath.Join(dir, "user.sql")
+	writeFile(t, sqlFile, "CREATE TABLE user (\n  id INTEGER PRIMARY KEY,\n  name TEXT\n);\nINSERT INTO user (id, name) VALUES (1, 'foo');\n")
+	migrationDir := filepath.Join(dir, "migrations")
+	_ = os.Mkdir(migrationDir, 0766)
+	writeFile(t, filepath.Join(migrationDir, "1_add_user.up.sql"), "INSERT INTO user (id, name) VALUES (2, 'bar');")
+	writeFile(t, filepath.Join(migrationDir, "1_add_user.down.sql"), "DELETE FROM user WHERE id = 2;")
+
+	d, err := NewDatabase(context.Background(), "sqlite",
+		WithSchema(sqlFile, migrationDir, filepath.Join(dir, "not_exist")), WithDatabaseName("foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "foo.db", filepath.Base(d.DSN))
+
+	var users []*user
+	err = d.DB.Table("user").Order("id").Find(&users).Error
+	assert.NoError(t, err)
+	if assert.Len(t, users, 2) {
+		assert.Equal(t, "bar", users[1].Name)
+	}
+
+	assert.NoError(t, d.Close())
+	_, err = os.Stat(d.DSN)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestNewSQLite_Error(t *testing.T) {
+	_, err := NewDatabase(context.Background(), "unknown")
+	assert.Error(t, err)
+
+	// sql file not found
+	_, err = NewSQLite(context.Background(), WithSchema("not_exist.sql"))
+	assert.Error(t, err)
+
+	// invalid statement
+	sqlFile := filepath.Join(t.TempDir(), "invalid.sql")
+	writeFile(t, sqlFile, "CREATE TABLE;")
+	_, err = NewSQLite(context.Background(), WithSchema(sqlFile))
+	assert.Error(t, err)
+
+	// invalid migration file name
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "add_user.up.sql"), "SELECT 1;")
+	_, err = NewSQLite(context.Background(), WithSchema(dir))
+	assert.Error(t, err)
+}
+
+func TestNewMySQL(t *testing.T) {
+	skipIfNoDocker(t)
+
+	d, err := NewDatabase(context.Background(), "mysql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { assert.NoError(t, d.Close()) }()
+	assert.NoError(t, d.DB.AutoMigrate(&user{}))
+	assert.NoError(t, d.DB.Create(&user{Name: "foo"}).Error)
+}
+
+func TestNewPostgres(t *testing.T) {
+	skipIfNoDocker(t)
+
+	d, err := NewDatabase(context.Background(), "postgresql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { assert.NoError(t, d.Close()) }()
+	assert.NoError(t, d.DB.AutoMigrate(&user{}))
+	assert.NoError(t, d.DB.Create(&user{Name: "foo"}).Error)
+}
@@ -0,0 +1,84 @@
+package testcontainer
+
+import "time"
+
+// Option set the options of container, database and redis.
+type Option func(*options)
+
+type options struct {
+	image        string
+	env          map[string]string
+	cmd          []string
+	schemas      []string
+	dbName       string
+	startTimeout time.Duration
+	useDocker    bool
+}
+
+func defaultOptions() *options {
+	return &options{
+		env:          map[string]string{},
+		dbName:       "test",
+		startTimeout: defaultStartTimeout,
+	}
+}
+
+func (o *options) apply(opts ...Option) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WithImage set the image of container, e.g. mysql:5.7, default images are mysql:8.0, postgres:16-alpine, redis:7-alpine
+func WithImage(image string) Option {
+	return func(o *options) {
+		o.image = image
+	}
+}
+
+// WithEnv set the environment variable of container
+func WithEnv(key string, value string) Option {
+	return func(o *options) {
+		o.env[key] = value
+	}
+}
+
+// WithCmd set the command arguments of container
+func WithCmd(args ...string) Option {
+	return func(o *options) {
+		o.cmd = args
+	}
+}
+
+// WithSchema load the schema after the database is ready, the path is a sql file or a directory of migration files,
+// the sql file is executed statement by statement, the migration files are applied by pkg/sgorm/migrate.
+func WithSchema(paths ...string) Option {
+	return func(o *options) {
+		o.schemas = append(o.schemas, paths...)
+	}
+}
+
+// WithDatabaseName set the database name, default is test
+func WithDatabaseName(name string) Option {
+	return func(o *options) {
+		if name != "" {
+			o.dbName = name
+		}
+	}
+}
+
+// WithStartTimeout set the timeout of waiting for the container ready, default is 2 minutes
+func WithStartTimeout(d time.Duration) Option {
+	return func(o *options) {
+		if d > 0 {
+			o.startTimeout = d
+		}
+	}
+}
+
+// WithDocker use the redis container instead of in-memory redis in NewRedis
+func WithDocker() Option {
+	return func(o *options) {
+		o.useDocker = true
+	}
+}
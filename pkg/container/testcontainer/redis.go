@@ -0,0 +1,90 @@
+package testcontainer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+const defaultRedisImage = "redis:7-alpine"
+
+// Redis a disposable redis for test, it is in-memory redis by default, and it is redis container if WithDocker is set
+type Redis struct {
+	Addr   string
+	Client *redis.Client
+
+	mr        *miniredis.Miniredis
+	container *Container
+}
+
+// NewRedis create a disposable redis
+func NewRedis(ctx context.Context, opts ...Option) (*Redis, error) {
+	o := defaultOptions()
+	o.apply(opts...)
+
+	if !o.useDocker {
+		mr, err := miniredis.Run()
+		if err != nil {
+			return nil, err
+		}
+		return &Redis{
+			Addr:   mr.Addr(),
+			Client: redis.NewClient(&redis.Options{Addr: mr.Addr()}),
+			mr:     mr,
+		}, nil
+	}
+
+	if o.image == "" {
+		o.image = defaultRedisImage
+	}
+	c, err := runContainer(ctx, o)
+	if err != nil {
+		return nil, err
+	}
+	r := &Redis{container: c}
+
+	err = waitFor(ctx, o.startTimeout, func() error {
+		addr, err := c.Endpoint(ctx, "6379")
+		if err != nil {
+			return err
+		}
+		client := redis.NewClient(&redis.Options{Addr: addr})
+		if err = client.Ping(ctx).Err(); err != nil {
+			_ = client.Close()
+			return err
+		}
+		r.Addr, r.Client = addr, client
+		return nil
+	})
+	if err != nil {
+		_ = r.Close()
+		return nil, fmt.Errorf("connect to redis container error: %v", err)
+	}
+
+	return r, nil
+}
+
+// Close close the client and stop the redis
+func (r *Redis) Close() error {
+	var errs []string
+	if r.Client != nil {
+		if err := r.Client.Close(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if r.mr != nil {
+		r.mr.Close()
+	}
+	if r.container != nil {
+		if err := r.container.Terminate(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("close redis error: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
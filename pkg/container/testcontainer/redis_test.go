@@ -0,0 +1,43 @@
+package testcontainer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRedis(t *testing.T) {
+	ctx := context.Background()
+	r, err := NewRedis(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, r.Client.Set(ctx, "foo", "bar", 0).Err())
+	assert.Equal(t, "bar", r.Client.Get(ctx, "foo").Val())
+	assert.NoError(t, r.Close())
+}
+
+func TestNewRedis_Docker(t *testing.T) {
+	skipIfNoDocker(t)
+
+	ctx := context.Background()
+	r, err := NewRedis(ctx, WithDocker())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { assert.NoError(t, r.Close()) }()
+	assert.NoError(t, r.Client.Ping(ctx).Err())
+}
+
+func TestNewRedis_DockerError(t *testing.T) {
+	if IsDockerAvailable() {
+		t.Skip("docker is available")
+	}
+	_, err := NewRedis(context.Background(), WithDocker())
+	assert.Error(t, err)
+	_, err = NewMySQL(context.Background())
+	assert.Error(t, err)
+	_, err = NewPostgres(context.Background())
+	assert.Error(t, err)
+}
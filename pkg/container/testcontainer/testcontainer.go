@@ -0,0 +1,150 @@
+// Package testcontainer provides disposable mysql, postgresql and redis for the integration tests of dao code,
+// the containers are started by the docker command and removed after the test, the embedded sqlite and in-memory
+// redis are used when docker is not required, the schema is loaded from the sql files or migration directory of project.
+package testcontainer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+)
+
+const defaultStartTimeout = 2 * time.Minute
+
+// Container a docker container started by Run
+type Container struct {
+	ID    string
+	Image string
+
+	host string
+}
+
+// IsDockerAvailable check whether the docker command exists and the docker daemon is running
+func IsDockerAvailable() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := docker(ctx, "info", "--format", "{{.ServerVersion}}")
+	return err == nil
+}
+
+// Run start a container in background, all the exposed ports of image are published to random host ports,
+// the container is removed automatically after it stopped, call Terminate to stop it, WithImage overrides the image.
+func Run(ctx context.Context, image string, opts ...Option) (*Container, error) {
+	o := defaultOptions()
+	o.apply(opts...)
+	if o.image == "" {
+		o.image = image
+	}
+	return runContainer(ctx, o)
+}
+
+func runContainer(ctx context.Context, o *options) (*Container, error) {
+	if o.image == "" {
+		return nil, errors.New("image is empty")
+	}
+
+	args := []string{"run", "-d", "--rm", "-P"}
+	keys := make([]string, 0, len(o.env))
+	for k := range o.env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		args = append(args, "-e", k+"="+o.env[k])
+	}
+	args = append(args, o.image)
+	args = append(args, o.cmd...)
+
+	out, err := docker(ctx, args...)
+	if err != nil {
+		return nil, fmt.Errorf("run container '%s' error: %v", o.image, err)
+	}
+
+	return &Container{
+		ID:    strings.TrimSpace(out),
+		Image: o.image,
+		host:  dockerHost(),
+	}, nil
+}
+
+// Endpoint get the host address of the container port, e.g. Endpoint(ctx, "3306") --> 127.0.0.1:32768
+func (c *Container) Endpoint(ctx context.Context, port string) (string, error) {
+	if !strings.Contains(port, "/") {
+		port += "/tcp"
+	}
+	out, err := docker(ctx, "port", c.ID, port)
+	if err != nil {
+		return "", fmt.Errorf("get port %s of container '%s' error: %v", port, c.Image, err)
+	}
+
+	// output example: 0.0.0.0:32768\n[::]:32768
+	line := strings.TrimSpace(strings.Split(strings.TrimSpace(out), "\n")[0])
+	_, hostPort, err := net.SplitHostPort(line)
+	if err != nil {
+		return "", fmt.Errorf("parse port of container '%s' error: %v", c.Image, err)
+	}
+	return net.JoinHostPort(c.host, hostPort), nil
+}
+
+// Terminate stop and remove the container
+func (c *Container) Terminate() error {
+	if c == nil || c.ID == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	_, err := docker(ctx, "rm", "-f", "-v", c.ID)
+	return err
+}
+
+// the host of published ports, it is the host of DOCKER_HOST when docker daemon is remote
+func dockerHost() string {
+	if u, err := url.Parse(os.Getenv("DOCKER_HOST")); err == nil && u.Scheme == "tcp" && u.Hostname() != "" {
+		return u.Hostname()
+	}
+	return "127.0.0.1"
+}
+
+func docker(ctx context.Context, args ...string) (string, error) {
+	cmdName, err := exec.LookPath("docker")
+	if err != nil {
+		return "", err
+	}
+
+	var stdout, stderr strings.Builder
+	cmd := exec.CommandContext(ctx, cmdName, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err = cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", errors.New(msg)
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
+
+// call fn until it returns nil or timeout, it is used to wait for the service in container is ready
+func waitFor(ctx context.Context, timeout time.Duration, fn func() error) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("wait for ready timeout, last error: %v", err)
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
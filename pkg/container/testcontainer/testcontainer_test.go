@@ -0,0 +1,67 @@
+package testcontainer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func skipIfNoDocker(t *testing.T) {
+	if !IsDockerAvailable() {
+		t.Skip("docker is not available")
+	}
+}
+
+func TestRun(t *testing.T) {
+	skipIfNoDocker(t)
+
+	ctx := context.Background()
+	c, err := Run(ctx, defaultRedisImage, WithCmd("redis-server", "--save", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { assert.NoError(t, c.Terminate()) }()
+
+	addr, err := c.Endpoint(ctx, "6379")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, addr)
+
+	_, err = c.Endpoint(ctx, "1234")
+	assert.Error(t, err)
+}
+
+func TestRun_Error(t *testing.T) {
+	_, err := Run(context.Background(), "")
+	assert.Error(t, err)
+
+	var c *Container
+	assert.NoError(t, c.Terminate())
+}
+
+func Test_dockerHost(t *testing.T) {
+	t.Setenv("DOCKER_HOST", "tcp://192.168.1.10:2375")
+	assert.Equal(t, "192.168.1.10", dockerHost())
+	t.Setenv("DOCKER_HOST", "unix:///var/run/docker.sock")
+	assert.Equal(t, "127.0.0.1", dockerHost())
+}
+
+func Test_waitFor(t *testing.T) {
+	count := 0
+	err := waitFor(context.Background(), 3*time.Second, func() error {
+		count++
+		if count < 2 {
+			return errors.New("not ready")
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	err = waitFor(context.Background(), 100*time.Millisecond, func() error {
+		return errors.New("not ready")
+	})
+	assert.Error(t, err)
+}
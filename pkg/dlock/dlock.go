@@ -1,7 +1,19 @@
 // Package dlock provides distributed locking primitives, supports redis and etcd.
 package dlock
 
-import "context"
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrNotHeld is returned when releasing or renewing a lock that is not held by the locker.
+	ErrNotHeld = errors.New("dlock: lock is not held")
+	// ErrLockLost is passed to the lost callback when the lease of a lock can not be renewed.
+	ErrLockLost = errors.New("dlock: lock lost")
+	// ErrStaleToken is returned when the fencing token is less than the largest token seen by the resource.
+	ErrStaleToken = errors.New("dlock: stale fencing token")
+)
 
 // Locker is the interface that wraps the basic locking operations.
 type Locker interface {
@@ -10,3 +22,24 @@ type Locker interface {
 	TryLock(ctx context.Context) (bool, error)
 	Close() error
 }
+
+// FencedLocker is a Locker that returns a fencing token each time the lock is acquired, the token increases
+// monotonically, resources protected by the lock should reject the requests whose token is less than the largest
+// token they have seen, so that a client whose lock has expired (e.g. paused by GC) can not corrupt the resource.
+type FencedLocker interface {
+	Locker
+	// LockWithToken blocks until the lock is acquired, returns the fencing token.
+	LockWithToken(ctx context.Context) (int64, error)
+	// TryLockWithToken tries to acquire the lock without blocking, returns the fencing token if acquired.
+	TryLockWithToken(ctx context.Context) (int64, bool, error)
+	// Token returns the fencing token of the held lock, 0 means the lock is not held.
+	Token() int64
+}
+
+// RWLocker is a distributed read-write lock, the lock can be held by any number of readers or a single writer.
+type RWLocker interface {
+	FencedLocker
+	RLock(ctx context.Context) error
+	RUnlock(ctx context.Context) error
+	TryRLock(ctx context.Context) (bool, error)
+}
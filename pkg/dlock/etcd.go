@@ -3,6 +3,7 @@ package dlock
 import (
 	"context"
 	"errors"
+	"sync"
 	"time"
 
 	clientv3 "go.etcd.io/etcd/client/v3"
@@ -11,9 +12,13 @@ import (
 
 var defaultTTL = 15 // seconds
 
+// EtcdLock implements FencedLocker using etcd, the lease of the lock is renewed by the etcd session automatically.
 type EtcdLock struct {
 	session *concurrency.Session
 	mutex   *concurrency.Mutex
+
+	mu    sync.Mutex
+	token int64
 }
 
 // NewEtcd creates a new etcd locker with the given key and ttl.
@@ -52,24 +57,63 @@ func NewEtcd(client *clientv3.Client, key string, ttl int) (Locker, error) {
 
 // Lock blocks until the lock is acquired or the context is canceled.
 func (l *EtcdLock) Lock(ctx context.Context) error {
-	return l.mutex.Lock(ctx)
+	_, err := l.LockWithToken(ctx)
+	return err
 }
 
 // Unlock releases the lock.
 func (l *EtcdLock) Unlock(ctx context.Context) error {
+	l.mu.Lock()
+	l.token = 0
+	l.mu.Unlock()
 	return l.mutex.Unlock(ctx)
 }
 
 // TryLock tries to acquire the lock without blocking.
 func (l *EtcdLock) TryLock(ctx context.Context) (bool, error) {
+	_, ok, err := l.TryLockWithToken(ctx)
+	return ok, err
+}
+
+// LockWithToken blocks until the lock is acquired or the context is canceled, the fencing token
+// is the revision of etcd when the lock is acquired, it increases monotonically.
+func (l *EtcdLock) LockWithToken(ctx context.Context) (int64, error) {
+	if err := l.mutex.Lock(ctx); err != nil {
+		return 0, err
+	}
+	return l.setToken(), nil
+}
+
+// TryLockWithToken tries to acquire the lock without blocking, returns the fencing token if acquired.
+func (l *EtcdLock) TryLockWithToken(ctx context.Context) (int64, bool, error) {
 	err := l.mutex.TryLock(ctx)
 	if err == nil {
-		return true, nil
+		return l.setToken(), true, nil
 	}
 	if err == concurrency.ErrLocked {
-		return false, nil
+		return 0, false, nil
 	}
-	return false, err
+	return 0, false, err
+}
+
+func (l *EtcdLock) setToken() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.token = l.mutex.Header().Revision
+	return l.token
+}
+
+// Token returns the fencing token of the held lock, 0 means the lock is not held.
+func (l *EtcdLock) Token() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.token
+}
+
+// Done returns a channel that is closed when the lease of the session is lost (e.g. etcd is unreachable
+// longer than the ttl) or the locker is closed, the lock is no longer held then.
+func (l *EtcdLock) Done() <-chan struct{} {
+	return l.session.Done()
 }
 
 // Close releases the lock and the etcd session.
@@ -0,0 +1,73 @@
+package dlock
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// lease renews a held lock in the background until it is stopped or lost.
+type lease struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// startLease calls renew every 1/3 ttl, renew returns false if the lock is no longer held,
+// onLost is called when the lock is not held or has not been renewed successfully within the ttl.
+func startLease(ttl time.Duration, renew func(ctx context.Context) (bool, error), onLost func(err error)) *lease {
+	ctx, cancel := context.WithCancel(context.Background())
+	l := &lease{cancel: cancel, done: make(chan struct{})}
+
+	go func() {
+		var lostErr error
+		defer func() {
+			close(l.done) // closed before calling onLost, so that the lock can be released in onLost
+			if lostErr != nil && onLost != nil {
+				onLost(lostErr)
+			}
+		}()
+
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+		lastRenewed := time.Now()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			renewCtx, renewCancel := context.WithTimeout(ctx, ttl/3)
+			ok, err := renew(renewCtx)
+			renewCancel()
+			if ctx.Err() != nil {
+				return // stopped while renewing
+			}
+
+			switch {
+			case err == nil && ok:
+				lastRenewed = time.Now()
+				continue
+			case err == nil && !ok:
+				lostErr = fmt.Errorf("%w: %v", ErrLockLost, ErrNotHeld)
+			case time.Since(lastRenewed) >= ttl:
+				lostErr = fmt.Errorf("%w: %v", ErrLockLost, err)
+			default:
+				continue // retry on the next tick, the lock is still valid
+			}
+			return
+		}
+	}()
+
+	return l
+}
+
+// stop stops renewing and waits for the goroutine to exit.
+func (l *lease) stop() {
+	if l == nil {
+		return
+	}
+	l.cancel()
+	<-l.done
+}
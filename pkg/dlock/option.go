@@ -0,0 +1,54 @@
+package dlock
+
+import "time"
+
+// Option set the options of the fenced lock and read-write lock.
+type Option func(*options)
+
+type options struct {
+	ttl           time.Duration
+	retryInterval time.Duration
+	autoRenew     bool
+	onLost        func(err error)
+}
+
+func defaultOptions() *options {
+	return &options{
+		ttl:           8 * time.Second,
+		retryInterval: 100 * time.Millisecond,
+	}
+}
+
+func (o *options) apply(opts ...Option) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WithTTL set the ttl of the lock, the lock is released automatically after the ttl if it is not renewed, default is 8s
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		if ttl > 0 {
+			o.ttl = ttl
+		}
+	}
+}
+
+// WithRetryInterval set the interval of retrying to acquire the lock when blocking, default is 100ms
+func WithRetryInterval(d time.Duration) Option {
+	return func(o *options) {
+		if d > 0 {
+			o.retryInterval = d
+		}
+	}
+}
+
+// WithAutoRenew renew the lease of the held lock every 1/3 ttl in a background goroutine until it is released,
+// onLost is called if the lock is lost, e.g. the lock has expired or can not be renewed within the ttl,
+// the business logic protected by the lock should be stopped in onLost, it can be nil.
+func WithAutoRenew(onLost func(err error)) Option {
+	return func(o *options) {
+		o.autoRenew = true
+		o.onLost = onLost
+	}
+}
@@ -0,0 +1,319 @@
+package dlock
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/go-dev-frame/sponge/pkg/krand"
+)
+
+// KEYS[1] writer key, KEYS[2] readers hash (reader -> expiration time in ms), KEYS[3] fencing token counter
+// the expired readers are deleted before checking, because the fields of hash can not expire separately
+const pruneReadersScript = `
+local now = tonumber(ARGV[3])
+local readers = redis.call("HGETALL", KEYS[2])
+for i = 1, #readers, 2 do
+	if tonumber(readers[i + 1]) <= now then
+		redis.call("HDEL", KEYS[2], readers[i])
+	end
+end
+`
+
+var (
+	// ARGV[1] owner, ARGV[2] ttl in ms, ARGV[3] now in ms
+	lockScript = redis.NewScript(pruneReadersScript + `
+if redis.call("EXISTS", KEYS[1]) == 1 or redis.call("HLEN", KEYS[2]) > 0 then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+return redis.call("INCR", KEYS[3])
+`)
+
+	// ARGV[1] owner, ARGV[2] ttl in ms, ARGV[3] now in ms
+	rLockScript = redis.NewScript(pruneReadersScript + `
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return 0
+end
+redis.call("HSET", KEYS[2], ARGV[1], now + tonumber(ARGV[2]))
+if redis.call("PTTL", KEYS[2]) < tonumber(ARGV[2]) then
+	redis.call("PEXPIRE", KEYS[2], ARGV[2])
+end
+return 1
+`)
+
+	// ARGV[1] owner
+	unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+	// ARGV[1] owner, ARGV[2] ttl in ms
+	renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+	// ARGV[1] owner
+	rUnlockScript = redis.NewScript(`
+return redis.call("HDEL", KEYS[2], ARGV[1])
+`)
+
+	// ARGV[1] owner, ARGV[2] ttl in ms, ARGV[3] now in ms
+	rRenewScript = redis.NewScript(`
+local expireAt = tonumber(redis.call("HGET", KEYS[2], ARGV[1]))
+local now = tonumber(ARGV[3])
+if expireAt == nil or expireAt <= now then
+	return 0
+end
+redis.call("HSET", KEYS[2], ARGV[1], now + tonumber(ARGV[2]))
+if redis.call("PTTL", KEYS[2]) < tonumber(ARGV[2]) then
+	redis.call("PEXPIRE", KEYS[2], ARGV[2])
+end
+return 1
+`)
+)
+
+// RedisRWLock implements FencedLocker and RWLocker using Redis lua scripts, the lock is held by a single writer
+// or any number of readers, a fencing token is returned each time the write lock is acquired.
+// The keys of a lock use the same hash tag, so it can be used with redis cluster.
+// Note: a RedisRWLock instance represents one holder, create an instance for each holder.
+type RedisRWLock struct {
+	client redis.UniversalClient
+	keys   []string // writer key, readers key, fencing token key
+	owner  string
+	opts   *options
+
+	mu         sync.Mutex
+	token      int64
+	isReading  bool
+	writeLease *lease
+	readLease  *lease
+}
+
+// NewRedisFencedLock creates a new lock that returns fencing tokens, the lock is exclusive.
+func NewRedisFencedLock(client redis.UniversalClient, key string, opts ...Option) (FencedLocker, error) {
+	return newRedisRWLock(client, key, opts...)
+}
+
+// NewRedisRWLock creates a new read-write lock, Lock and Unlock are the operations of the write lock.
+// Note: readers take precedence, the writer waits until all readers release the lock.
+func NewRedisRWLock(client redis.UniversalClient, key string, opts ...Option) (RWLocker, error) {
+	return newRedisRWLock(client, key, opts...)
+}
+
+func newRedisRWLock(client redis.UniversalClient, key string, opts ...Option) (*RedisRWLock, error) {
+	if client == nil {
+		return nil, errors.New("redis client is nil")
+	}
+	if key == "" {
+		return nil, errors.New("key is empty")
+	}
+	o := defaultOptions()
+	o.apply(opts...)
+
+	tag := "{" + key + "}"
+	return &RedisRWLock{
+		client: client,
+		keys:   []string{tag, tag + ":readers", tag + ":fencing"},
+		owner:  krand.NewStringID() + krand.String(krand.R_All, 8),
+		opts:   o,
+	}, nil
+}
+
+// TryLockWithToken tries to acquire the write lock without blocking, returns the fencing token if acquired.
+func (l *RedisRWLock) TryLockWithToken(ctx context.Context) (int64, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	token, err := lockScript.Run(ctx, l.client, l.keys, l.owner, l.ttlMilli(), time.Now().UnixMilli()).Int64()
+	if err != nil || token == 0 {
+		return 0, false, err
+	}
+
+	l.token = token
+	if l.opts.autoRenew {
+		l.writeLease = startLease(l.opts.ttl, func(ctx context.Context) (bool, error) {
+			n, err := renewScript.Run(ctx, l.client, l.keys, l.owner, l.ttlMilli()).Int64()
+			return n == 1, err
+		}, l.opts.onLost)
+	}
+	return token, true, nil
+}
+
+// LockWithToken blocks until the write lock is acquired or the context is canceled, returns the fencing token.
+func (l *RedisRWLock) LockWithToken(ctx context.Context) (int64, error) {
+	for {
+		token, ok, err := l.TryLockWithToken(ctx)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			return token, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(l.opts.retryInterval):
+		}
+	}
+}
+
+// TryLock tries to acquire the write lock without blocking.
+func (l *RedisRWLock) TryLock(ctx context.Context) (bool, error) {
+	_, ok, err := l.TryLockWithToken(ctx)
+	return ok, err
+}
+
+// Lock blocks until the write lock is acquired or the context is canceled.
+func (l *RedisRWLock) Lock(ctx context.Context) error {
+	_, err := l.LockWithToken(ctx)
+	return err
+}
+
+// Unlock releases the write lock, ErrNotHeld is returned if the lock is not held, e.g. expired.
+func (l *RedisRWLock) Unlock(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.writeLease.stop()
+	l.writeLease = nil
+	l.token = 0
+
+	n, err := unlockScript.Run(ctx, l.client, l.keys, l.owner).Int64()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotHeld
+	}
+	return nil
+}
+
+// Token returns the fencing token of the held write lock, 0 means the write lock is not held.
+func (l *RedisRWLock) Token() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.token
+}
+
+// TryRLock tries to acquire the read lock without blocking.
+func (l *RedisRWLock) TryRLock(ctx context.Context) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	n, err := rLockScript.Run(ctx, l.client, l.keys, l.owner, l.ttlMilli(), time.Now().UnixMilli()).Int64()
+	if err != nil || n == 0 {
+		return false, err
+	}
+
+	l.isReading = true
+	if l.opts.autoRenew && l.readLease == nil {
+		l.readLease = startLease(l.opts.ttl, func(ctx context.Context) (bool, error) {
+			n, err := rRenewScript.Run(ctx, l.client, l.keys, l.owner, l.ttlMilli(), time.Now().UnixMilli()).Int64()
+			return n == 1, err
+		}, l.opts.onLost)
+	}
+	return true, nil
+}
+
+// RLock blocks until the read lock is acquired or the context is canceled.
+func (l *RedisRWLock) RLock(ctx context.Context) error {
+	for {
+		ok, err := l.TryRLock(ctx)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(l.opts.retryInterval):
+		}
+	}
+}
+
+// RUnlock releases the read lock, ErrNotHeld is returned if the lock is not held, e.g. expired.
+func (l *RedisRWLock) RUnlock(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.readLease.stop()
+	l.readLease = nil
+	l.isReading = false
+
+	n, err := rUnlockScript.Run(ctx, l.client, l.keys, l.owner).Int64()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotHeld
+	}
+	return nil
+}
+
+// Close stops renewing and releases the held locks.
+func (l *RedisRWLock) Close() error {
+	l.mu.Lock()
+	isWriter, isReader := l.token > 0, l.isReading
+	l.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	var errs []error
+	if isWriter {
+		if err := l.Unlock(ctx); err != nil && !errors.Is(err, ErrNotHeld) {
+			errs = append(errs, err)
+		}
+	}
+	if isReader {
+		if err := l.RUnlock(ctx); err != nil && !errors.Is(err, ErrNotHeld) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (l *RedisRWLock) ttlMilli() int64 {
+	return l.opts.ttl.Milliseconds()
+}
+
+// ---------------------------------------------------------------------------------------------
+
+// the largest token is kept, the token equal to it is accepted, so that the holder can write multiple times
+var verifyTokenScript = redis.NewScript(`
+local current = tonumber(redis.call("GET", KEYS[1]) or "0")
+local token = tonumber(ARGV[1])
+if token < current then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[1])
+return 1
+`)
+
+// VerifyToken verifies the fencing token for the resource stored in redis, key is the key of the largest token seen
+// by the resource, ErrStaleToken is returned if the token is less than the largest token, it should be called before
+// writing the resource. For resources in databases, verify the token by conditional updates, e.g.
+//
+//	UPDATE t SET data = ?, fencing_token = ? WHERE id = ? AND fencing_token <= ?
+func VerifyToken(ctx context.Context, client redis.UniversalClient, key string, token int64) error {
+	n, err := verifyTokenScript.Run(ctx, client, []string{key}, token).Int64()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrStaleToken
+	}
+	return nil
+}
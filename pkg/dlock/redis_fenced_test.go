@@ -0,0 +1,146 @@
+package dlock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMiniRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return mr, client
+}
+
+func TestRedisFencedLock(t *testing.T) {
+	_, client := newMiniRedis(t)
+	ctx := context.Background()
+
+	_, err := NewRedisFencedLock(nil, "test")
+	assert.Error(t, err)
+	_, err = NewRedisFencedLock(client, "")
+	assert.Error(t, err)
+
+	l1, err := NewRedisFencedLock(client, "order:1")
+	require.NoError(t, err)
+	l2, err := NewRedisFencedLock(client, "order:1", WithRetryInterval(10*time.Millisecond))
+	require.NoError(t, err)
+
+	token1, err := l1.LockWithToken(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, token1, l1.Token())
+	_, ok, err := l2.TryLockWithToken(ctx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.ErrorIs(t, l2.Unlock(ctx), ErrNotHeld)
+
+	// blocks until l1 is released
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = l1.Unlock(ctx)
+	}()
+	token2, err := l2.LockWithToken(ctx)
+	require.NoError(t, err)
+	assert.Greater(t, token2, token1)
+	assert.Equal(t, int64(0), l1.Token())
+
+	// the resource rejects the stale token
+	require.NoError(t, VerifyToken(ctx, client, "order:1:token", token2))
+	require.NoError(t, VerifyToken(ctx, client, "order:1:token", token2))
+	assert.ErrorIs(t, VerifyToken(ctx, client, "order:1:token", token1), ErrStaleToken)
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, l1.Lock(timeoutCtx), context.DeadlineExceeded)
+
+	require.NoError(t, l2.Close())
+	ok, err = l1.TryLock(ctx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestRedisFencedLockAutoRenew(t *testing.T) {
+	mr, client := newMiniRedis(t)
+	ctx := context.Background()
+
+	lost := make(chan error, 1)
+	l, err := NewRedisFencedLock(client, "job", WithTTL(150*time.Millisecond), WithAutoRenew(func(err error) {
+		lost <- err
+	}))
+	require.NoError(t, err)
+	require.NoError(t, l.Lock(ctx))
+
+	// renewed before expiration
+	time.Sleep(200 * time.Millisecond)
+	mr.FastForward(100 * time.Millisecond)
+	assert.True(t, mr.Exists("{job}"))
+	select {
+	case err = <-lost:
+		t.Fatalf("unexpected lost: %v", err)
+	default:
+	}
+
+	// the lock is taken away
+	mr.Del("{job}")
+	select {
+	case err = <-lost:
+		assert.ErrorIs(t, err, ErrLockLost)
+	case <-time.After(time.Second):
+		t.Fatal("lost callback is not called")
+	}
+	assert.ErrorIs(t, l.Unlock(ctx), ErrNotHeld)
+}
+
+func TestRedisRWLock(t *testing.T) {
+	_, client := newMiniRedis(t)
+	ctx := context.Background()
+
+	newLock := func(opts ...Option) RWLocker {
+		l, err := NewRedisRWLock(client, "config", opts...)
+		require.NoError(t, err)
+		return l
+	}
+	reader1, reader2, writer := newLock(), newLock(WithAutoRenew(nil)), newLock()
+
+	// readers share the lock
+	require.NoError(t, reader1.RLock(ctx))
+	ok, err := reader2.TryRLock(ctx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = writer.TryLock(ctx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, reader1.RUnlock(ctx))
+	require.NoError(t, reader2.RUnlock(ctx))
+	assert.ErrorIs(t, reader2.RUnlock(ctx), ErrNotHeld)
+
+	// the writer excludes readers
+	token, ok, err := writer.TryLockWithToken(ctx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Greater(t, token, int64(0))
+	ok, err = reader1.TryRLock(ctx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	require.NoError(t, writer.Close())
+
+	// the expired reader does not block the writer
+	reader := newLock(WithTTL(50 * time.Millisecond))
+	require.NoError(t, reader.RLock(ctx))
+	time.Sleep(60 * time.Millisecond)
+	ok, err = writer.TryLock(ctx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, errors.Is(reader.RUnlock(ctx), ErrNotHeld))
+	require.NoError(t, writer.Unlock(ctx))
+}
@@ -0,0 +1,648 @@
+package encoding
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	errAvroInvalidData = errors.New("avro: invalid data")
+	timeType           = reflect.TypeOf(time.Time{})
+)
+
+// AvroEncoding avro binary format, see https://avro.apache.org/docs/current/specification/,
+// the avro schema is derived from the type of value, the field name is the same as json tag,
+// the writer and reader must use the same type (or the types with the same schema), the schema
+// can be obtained by AvroSchema.
+//
+// type mapping:
+//
+//	bool --> boolean
+//	int8, int16, int32, uint8, uint16 --> int
+//	int, int64, uint32, uint, uint64 --> long
+//	float32 --> float, float64 --> double
+//	string --> string, []byte --> bytes, [N]byte --> fixed
+//	time.Time --> long (logicalType timestamp-millis)
+//	slice, array --> array, map[string]T --> map, struct --> record
+//	pointer --> union ["null", T]
+//	interface --> union ["null", T], T is the type of dynamic value, decoding into interface requires a non-nil pointer value
+type AvroEncoding struct{}
+
+// Marshal avro encode
+func (a AvroEncoding) Marshal(v interface{}) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, errors.New("avro: can not marshal nil pointer")
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil, errors.New("avro: can not marshal nil value")
+	}
+
+	e := &avroEncoder{}
+	err := e.encode(rv)
+	return e.buf, err
+}
+
+// Unmarshal avro decode
+func (a AvroEncoding) Unmarshal(data []byte, value interface{}) error {
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return ErrNotAPointer
+	}
+	rv = rv.Elem()
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		rv = rv.Elem()
+	}
+
+	d := &avroDecoder{data: data}
+	if err := d.decode(rv); err != nil {
+		return err
+	}
+	if d.pos != len(d.data) {
+		return fmt.Errorf("avro: %d bytes of data remaining after decoding", len(d.data)-d.pos)
+	}
+	return nil
+}
+
+// ------------------------------------------------------------------------------------------
+
+type avroField struct {
+	name  string
+	index []int
+}
+
+var avroFieldsCache sync.Map // reflect.Type --> []avroField
+
+// get the fields of struct, the rules are similar to encoding/json, the field name is the json tag,
+// the field with tag "-" and unexported field are ignored, the embedded struct without name is flattened.
+func getAvroFields(t reflect.Type) []avroField {
+	if fields, ok := avroFieldsCache.Load(t); ok {
+		return fields.([]avroField)
+	}
+
+	var fields []avroField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if sf.Anonymous && name == "" && sf.Type.Kind() == reflect.Struct && sf.Type != timeType {
+			for _, f := range getAvroFields(sf.Type) {
+				fields = append(fields, avroField{name: f.name, index: append([]int{i}, f.index...)})
+			}
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		fields = append(fields, avroField{name: name, index: []int{i}})
+	}
+
+	avroFieldsCache.Store(t, fields)
+	return fields
+}
+
+// ------------------------------------------------------------------------------------------
+
+type avroEncoder struct {
+	buf []byte
+}
+
+func (e *avroEncoder) writeLong(n int64) {
+	e.buf = binary.AppendUvarint(e.buf, uint64((n<<1)^(n>>63))) // zigzag
+}
+
+func (e *avroEncoder) writeBytes(b []byte) {
+	e.writeLong(int64(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+// nolint
+func (e *avroEncoder) encode(v reflect.Value) error {
+	t := v.Type()
+	if t == timeType {
+		e.writeLong(v.Interface().(time.Time).UnixMilli())
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			e.buf = append(e.buf, 1)
+		} else {
+			e.buf = append(e.buf, 0)
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.writeLong(v.Int())
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n := v.Uint()
+		if n > math.MaxInt64 {
+			return fmt.Errorf("avro: value %d of type %s overflows long", n, t)
+		}
+		e.writeLong(int64(n))
+
+	case reflect.Float32:
+		e.buf = binary.LittleEndian.AppendUint32(e.buf, math.Float32bits(float32(v.Float())))
+
+	case reflect.Float64:
+		e.buf = binary.LittleEndian.AppendUint64(e.buf, math.Float64bits(v.Float()))
+
+	case reflect.String:
+		e.writeLong(int64(v.Len()))
+		e.buf = append(e.buf, v.String()...)
+
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			e.writeBytes(v.Bytes())
+			return nil
+		}
+		return e.encodeArray(v)
+
+	case reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 { // fixed
+			for i := 0; i < v.Len(); i++ {
+				e.buf = append(e.buf, byte(v.Index(i).Uint()))
+			}
+			return nil
+		}
+		return e.encodeArray(v)
+
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return fmt.Errorf("avro: unsupported map key type %s", t.Key())
+		}
+		if v.Len() > 0 {
+			e.writeLong(int64(v.Len()))
+			iter := v.MapRange()
+			for iter.Next() {
+				e.writeLong(int64(iter.Key().Len()))
+				e.buf = append(e.buf, iter.Key().String()...)
+				if err := e.encode(iter.Value()); err != nil {
+					return err
+				}
+			}
+		}
+		e.writeLong(0)
+
+	case reflect.Struct:
+		for _, f := range getAvroFields(t) {
+			if err := e.encode(v.FieldByIndex(f.index)); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Ptr:
+		if v.IsNil() {
+			e.writeLong(0)
+			return nil
+		}
+		e.writeLong(1)
+		return e.encode(v.Elem())
+
+	case reflect.Interface: // union ["null", T]
+		if v.IsNil() {
+			e.writeLong(0)
+			return nil
+		}
+		elem := v.Elem()
+		if elem.Kind() == reflect.Ptr {
+			return e.encode(elem)
+		}
+		e.writeLong(1)
+		return e.encode(elem)
+
+	default:
+		return fmt.Errorf("avro: unsupported type %s", t)
+	}
+
+	return nil
+}
+
+func (e *avroEncoder) encodeArray(v reflect.Value) error {
+	if v.Len() > 0 {
+		e.writeLong(int64(v.Len()))
+		for i := 0; i < v.Len(); i++ {
+			if err := e.encode(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	}
+	e.writeLong(0)
+	return nil
+}
+
+// ------------------------------------------------------------------------------------------
+
+type avroDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *avroDecoder) readLong() (int64, error) {
+	u, n := binary.Uvarint(d.data[d.pos:])
+	if n <= 0 {
+		return 0, errAvroInvalidData
+	}
+	d.pos += n
+	return int64(u>>1) ^ -int64(u&1), nil
+}
+
+func (d *avroDecoder) readFixed(size int) ([]byte, error) {
+	if size < 0 || size > len(d.data)-d.pos {
+		return nil, errAvroInvalidData
+	}
+	b := d.data[d.pos : d.pos+size]
+	d.pos += size
+	return b, nil
+}
+
+func (d *avroDecoder) readBytes() ([]byte, error) {
+	n, err := d.readLong()
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 || n > int64(len(d.data)-d.pos) {
+		return nil, errAvroInvalidData
+	}
+	return d.readFixed(int(n))
+}
+
+// read the item count of block, the count is negative if it is followed by the byte size of block
+func (d *avroDecoder) readBlockCount() (int, error) {
+	n, err := d.readLong()
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		n = -n
+		if _, err = d.readLong(); err != nil {
+			return 0, err
+		}
+	}
+	if n > int64(len(d.data)-d.pos) {
+		return 0, errAvroInvalidData
+	}
+	return int(n), nil
+}
+
+// nolint
+func (d *avroDecoder) decode(v reflect.Value) error {
+	t := v.Type()
+	if t == timeType {
+		n, err := d.readLong()
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(time.UnixMilli(n)))
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		b, err := d.readFixed(1)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b[0] != 0)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := d.readLong()
+		if err != nil {
+			return err
+		}
+		if v.OverflowInt(n) {
+			return fmt.Errorf("avro: value %d overflows %s", n, t)
+		}
+		v.SetInt(n)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := d.readLong()
+		if err != nil {
+			return err
+		}
+		if n < 0 || v.OverflowUint(uint64(n)) {
+			return fmt.Errorf("avro: value %d overflows %s", n, t)
+		}
+		v.SetUint(uint64(n))
+
+	case reflect.Float32:
+		b, err := d.readFixed(4)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(float64(math.Float32frombits(binary.LittleEndian.Uint32(b))))
+
+	case reflect.Float64:
+		b, err := d.readFixed(8)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(math.Float64frombits(binary.LittleEndian.Uint64(b)))
+
+	case reflect.String:
+		b, err := d.readBytes()
+		if err != nil {
+			return err
+		}
+		v.SetString(string(b))
+
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			b, err := d.readBytes()
+			if err != nil {
+				return err
+			}
+			v.SetBytes(append([]byte{}, b...))
+			return nil
+		}
+		v.Set(reflect.MakeSlice(t, 0, 0))
+		return d.decodeBlocks(func() error {
+			v.Set(reflect.Append(v, reflect.Zero(t.Elem())))
+			return d.decode(v.Index(v.Len() - 1))
+		})
+
+	case reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			b, err := d.readFixed(v.Len())
+			if err != nil {
+				return err
+			}
+			reflect.Copy(v, reflect.ValueOf(b))
+			return nil
+		}
+		i := 0
+		return d.decodeBlocks(func() error {
+			if i >= v.Len() {
+				return fmt.Errorf("avro: too many items for %s", t)
+			}
+			i++
+			return d.decode(v.Index(i - 1))
+		})
+
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return fmt.Errorf("avro: unsupported map key type %s", t.Key())
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(t))
+		}
+		return d.decodeBlocks(func() error {
+			key, err := d.readBytes()
+			if err != nil {
+				return err
+			}
+			k := reflect.ValueOf(string(key)).Convert(t.Key())
+			val := reflect.New(t.Elem()).Elem()
+			if existing := v.MapIndex(k); existing.IsValid() {
+				val.Set(existing) // e.g. the non-nil pointer value of interface
+			}
+			if err = d.decode(val); err != nil {
+				return err
+			}
+			v.SetMapIndex(k, val)
+			return nil
+		})
+
+	case reflect.Struct:
+		for _, f := range getAvroFields(t) {
+			if err := d.decode(v.FieldByIndex(f.index)); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Ptr:
+		index, err := d.readLong()
+		if err != nil {
+			return err
+		}
+		switch index {
+		case 0:
+			v.Set(reflect.Zero(t))
+		case 1:
+			if v.IsNil() {
+				v.Set(reflect.New(t.Elem()))
+			}
+			return d.decode(v.Elem())
+		default:
+			return fmt.Errorf("avro: invalid union index %d", index)
+		}
+
+	case reflect.Interface:
+		// the concrete type is unknown, only the non-nil pointer value can be decoded
+		elem := v.Elem()
+		if !elem.IsValid() || elem.Kind() != reflect.Ptr || elem.IsNil() {
+			return fmt.Errorf("avro: can not unmarshal into %s without a non-nil pointer value", t)
+		}
+		index, err := d.readLong()
+		if err != nil {
+			return err
+		}
+		switch index {
+		case 0:
+			v.Set(reflect.Zero(t))
+		case 1:
+			return d.decode(elem.Elem())
+		default:
+			return fmt.Errorf("avro: invalid union index %d", index)
+		}
+
+	default:
+		return fmt.Errorf("avro: unsupported type %s", t)
+	}
+
+	return nil
+}
+
+func (d *avroDecoder) decodeBlocks(decodeItem func() error) error {
+	for {
+		n, err := d.readBlockCount()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return nil
+		}
+		for i := 0; i < n; i++ {
+			if err = decodeItem(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// ------------------------------------------------------------------------------------------
+
+// AvroSchema get the avro schema (json format) of value, it is used by other languages to decode
+// the data encoded by AvroEncoding, the schema of interface field is derived from its dynamic value.
+func AvroSchema(v interface{}) (string, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() || rv.Kind() == reflect.Ptr {
+		return "", errors.New("avro: can not get schema of nil value")
+	}
+
+	sb := &avroSchemaBuilder{named: map[reflect.Type]string{}, names: map[string]bool{}}
+	schema, err := sb.schema(rv.Type(), rv)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(schema)
+	return string(data), err
+}
+
+type avroSchemaBuilder struct {
+	named map[reflect.Type]string // type --> name of record or fixed
+	names map[string]bool
+}
+
+func (sb *avroSchemaBuilder) newName(name string) string {
+	if name == "" {
+		name = "record"
+	}
+	newName := name
+	for i := 2; sb.names[newName]; i++ {
+		newName = name + strconv.Itoa(i)
+	}
+	sb.names[newName] = true
+	return newName
+}
+
+// the value v may be invalid if there is no value, it is only used to get the dynamic type of interface
+//
+// nolint
+func (sb *avroSchemaBuilder) schema(t reflect.Type, v reflect.Value) (interface{}, error) {
+	if t == timeType {
+		return map[string]interface{}{"type": "long", "logicalType": "timestamp-millis"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean", nil
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return "int", nil
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return "long", nil
+	case reflect.Float32:
+		return "float", nil
+	case reflect.Float64:
+		return "double", nil
+	case reflect.String:
+		return "string", nil
+
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			if t.Kind() == reflect.Slice {
+				return "bytes", nil
+			}
+			if name, ok := sb.named[t]; ok {
+				return name, nil
+			}
+			name := sb.newName("fixed" + strconv.Itoa(t.Len()))
+			sb.named[t] = name
+			return map[string]interface{}{"type": "fixed", "name": name, "size": t.Len()}, nil
+		}
+		items, err := sb.schema(t.Elem(), firstElem(v))
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "array", "items": items}, nil
+
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("avro: unsupported map key type %s", t.Key())
+		}
+		var elem reflect.Value
+		if v.IsValid() && v.Len() > 0 {
+			iter := v.MapRange()
+			iter.Next()
+			elem = iter.Value()
+		}
+		values, err := sb.schema(t.Elem(), elem)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "map", "values": values}, nil
+
+	case reflect.Struct:
+		if name, ok := sb.named[t]; ok {
+			return name, nil
+		}
+		name := sb.newName(t.Name())
+		sb.named[t] = name
+
+		fields := []interface{}{}
+		for _, f := range getAvroFields(t) {
+			var fv reflect.Value
+			if v.IsValid() {
+				fv = v.FieldByIndex(f.index)
+			}
+			fieldSchema, err := sb.schema(t.FieldByIndex(f.index).Type, fv)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, map[string]interface{}{"name": f.name, "type": fieldSchema})
+		}
+		return map[string]interface{}{"type": "record", "name": name, "fields": fields}, nil
+
+	case reflect.Ptr:
+		if t.Elem().Kind() == reflect.Ptr {
+			return nil, fmt.Errorf("avro: unsupported type %s", t)
+		}
+		var elem reflect.Value
+		if v.IsValid() && !v.IsNil() {
+			elem = v.Elem()
+		}
+		elemSchema, err := sb.schema(t.Elem(), elem)
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{"null", elemSchema}, nil
+
+	case reflect.Interface:
+		if !v.IsValid() || v.IsNil() {
+			return nil, fmt.Errorf("avro: can not get schema of %s without a non-nil value", t)
+		}
+		elem := v.Elem()
+		if elem.Kind() == reflect.Ptr {
+			return sb.schema(elem.Type(), elem)
+		}
+		elemSchema, err := sb.schema(elem.Type(), elem)
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{"null", elemSchema}, nil
+	}
+
+	return nil, fmt.Errorf("avro: unsupported type %s", t)
+}
+
+func firstElem(v reflect.Value) reflect.Value {
+	if v.IsValid() && v.Len() > 0 {
+		return v.Index(0)
+	}
+	return reflect.Value{}
+}
@@ -0,0 +1,175 @@
+package encoding
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type avroBase struct {
+	ID        uint64    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type avroNode struct {
+	Name string    `json:"name"`
+	Next *avroNode `json:"next"`
+}
+
+type avroObj struct {
+	avroBase
+	Name     string                 `json:"name"`
+	Age      int8                   `json:"age,omitempty"`
+	Score    float32                `json:"score"`
+	Rate     float64                `json:"rate"`
+	Enabled  bool                   `json:"enabled"`
+	Data     []byte                 `json:"data"`
+	Hash     [4]byte                `json:"hash"`
+	Tags     []string               `json:"tags"`
+	Attrs    map[string]int         `json:"attrs"`
+	Node     *avroNode              `json:"node"`
+	Nil      *avroNode              `json:"nil"`
+	Items    [2]avroNode            `json:"items"`
+	Any      interface{}            `json:"any"`
+	Ignore   string                 `json:"-"`
+	internal string                 //nolint
+	Extra    map[string]interface{} `json:"extra"`
+}
+
+func newAvroObj() *avroObj {
+	return &avroObj{
+		avroBase: avroBase{ID: 1, CreatedAt: time.UnixMilli(time.Now().UnixMilli())},
+		Name:     "foo",
+		Age:      -18,
+		Score:    99.5,
+		Rate:     0.123456789,
+		Enabled:  true,
+		Data:     []byte("hello"),
+		Hash:     [4]byte{1, 2, 3, 4},
+		Tags:     []string{"a", "b"},
+		Attrs:    map[string]int{"x": 1, "y": -2},
+		Node:     &avroNode{Name: "n1", Next: &avroNode{Name: "n2"}},
+		Items:    [2]avroNode{{Name: "i1"}, {Name: "i2"}},
+		Any:      &avroNode{Name: "any"},
+		Ignore:   "ignore",
+		Extra:    map[string]interface{}{"k": "v"},
+	}
+}
+
+func TestAvroEncoding(t *testing.T) {
+	e := AvroEncoding{}
+	o1 := newAvroObj()
+	data, err := e.Marshal(o1)
+	require.NoError(t, err)
+
+	o2 := &avroObj{Any: &avroNode{}, Extra: map[string]interface{}{}}
+	o2.Extra["k"] = new(string)
+	err = e.Unmarshal(data, o2)
+	require.NoError(t, err)
+	assert.Equal(t, o1.ID, o2.ID)
+	assert.True(t, o1.CreatedAt.Equal(o2.CreatedAt))
+	assert.Equal(t, o1.Name, o2.Name)
+	assert.Equal(t, o1.Age, o2.Age)
+	assert.Equal(t, o1.Score, o2.Score)
+	assert.Equal(t, o1.Rate, o2.Rate)
+	assert.Equal(t, o1.Enabled, o2.Enabled)
+	assert.Equal(t, o1.Data, o2.Data)
+	assert.Equal(t, o1.Hash, o2.Hash)
+	assert.Equal(t, o1.Tags, o2.Tags)
+	assert.Equal(t, o1.Attrs, o2.Attrs)
+	assert.Equal(t, o1.Node, o2.Node)
+	assert.Nil(t, o2.Nil)
+	assert.Equal(t, o1.Items, o2.Items)
+	assert.Equal(t, o1.Any, o2.Any)
+	assert.Empty(t, o2.Ignore)
+
+	// decode into pointer of pointer
+	var o3 *avroObj
+	err = e.Unmarshal(data, &o3)
+	assert.Error(t, err) // interface field without value
+	o3 = &avroObj{Any: &avroNode{}}
+	delete(o1.Extra, "k")
+	data, err = e.Marshal(o1)
+	require.NoError(t, err)
+	err = e.Unmarshal(data, &o3)
+	assert.NoError(t, err)
+	assert.Equal(t, "any", o3.Any.(*avroNode).Name)
+
+	// nil interface
+	o1.Any = nil
+	data, err = e.Marshal(o1)
+	require.NoError(t, err)
+	err = e.Unmarshal(data, o3)
+	assert.NoError(t, err)
+	assert.Nil(t, o3.Any)
+
+	// scalar value
+	data, err = e.Marshal(int64(-12345))
+	require.NoError(t, err)
+	var n int64
+	assert.NoError(t, e.Unmarshal(data, &n))
+	assert.Equal(t, int64(-12345), n)
+}
+
+func TestAvroEncodingError(t *testing.T) {
+	e := AvroEncoding{}
+
+	_, err := e.Marshal(nil)
+	assert.Error(t, err)
+	_, err = e.Marshal((*avroObj)(nil))
+	assert.Error(t, err)
+	_, err = e.Marshal(make(chan string))
+	assert.Error(t, err)
+	_, err = e.Marshal(map[int]string{1: "a"})
+	assert.Error(t, err)
+	_, err = e.Marshal(uint64(1 << 63))
+	assert.Error(t, err)
+
+	assert.Error(t, e.Unmarshal([]byte{2}, avroObj{}))
+	var n int8
+	assert.Error(t, e.Unmarshal([]byte{0xfe, 0x04}, &n)) // 300 overflows int8
+	var u uint
+	assert.Error(t, e.Unmarshal([]byte{1}, &u)) // -1
+	var s string
+	assert.Error(t, e.Unmarshal([]byte{10, 'a'}, &s))
+	assert.Error(t, e.Unmarshal([]byte{2, 'a', 'b'}, &s)) // remaining data
+	var ss []string
+	assert.Error(t, e.Unmarshal([]byte{100}, &ss))
+	var p *avroNode
+	assert.Error(t, e.Unmarshal([]byte{2, 'a', 4}, &p))
+	assert.Error(t, e.Unmarshal(nil, &p))
+}
+
+func TestAvroSchema(t *testing.T) {
+	schema, err := AvroSchema(newAvroObj())
+	require.NoError(t, err)
+	t.Log(schema)
+
+	m := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal([]byte(schema), &m))
+	assert.Equal(t, "record", m["type"])
+	assert.Equal(t, "avroObj", m["name"])
+	fields := m["fields"].([]interface{})
+	names := []string{}
+	for _, f := range fields {
+		names = append(names, f.(map[string]interface{})["name"].(string))
+	}
+	assert.Equal(t, []string{"id", "createdAt", "name", "age", "score", "rate", "enabled", "data", "hash",
+		"tags", "attrs", "node", "nil", "items", "any", "extra"}, names)
+	assert.Contains(t, schema, `{"name":"next","type":["null","avroNode"]}`)
+	assert.Contains(t, schema, `{"logicalType":"timestamp-millis","type":"long"}`)
+	assert.Contains(t, schema, `{"name":"hash","type":{"name":"fixed4","size":4,"type":"fixed"}}`)
+	assert.Contains(t, schema, `{"name":"extra","type":{"type":"map","values":["null","string"]}}`)
+
+	_, err = AvroSchema(nil)
+	assert.Error(t, err)
+	_, err = AvroSchema(&avroObj{})
+	assert.Error(t, err) // interface without value
+	_, err = AvroSchema(make(chan int))
+	assert.Error(t, err)
+	_, err = AvroSchema(map[int]string{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,33 @@
+package encoding
+
+import (
+	"reflect"
+
+	ucodec "github.com/ugorji/go/codec"
+)
+
+var cborHandle = newCBORHandle()
+
+func newCBORHandle() *ucodec.CborHandle {
+	h := &ucodec.CborHandle{}
+	// the field name is the same as json tag, e.g. `json:"name"`, if codec tag is not set
+	h.TypeInfos = ucodec.NewTypeInfos([]string{"codec", "json"})
+	// decode map into map[string]interface{} instead of map[interface{}]interface{}
+	h.MapType = reflect.TypeOf(map[string]interface{}(nil))
+	return h
+}
+
+// CBOREncoding cbor format, see https://www.rfc-editor.org/rfc/rfc8949.html
+type CBOREncoding struct{}
+
+// Marshal cbor encode
+func (c CBOREncoding) Marshal(v interface{}) ([]byte, error) {
+	var buf []byte
+	err := ucodec.NewEncoderBytes(&buf, cborHandle).Encode(v)
+	return buf, err
+}
+
+// Unmarshal cbor decode
+func (c CBOREncoding) Unmarshal(data []byte, value interface{}) error {
+	return ucodec.NewDecoderBytes(data, cborHandle).Decode(value)
+}
@@ -1,4 +1,4 @@
-// Package encoding Provides encoding and decoding of json, protobuf and gob.
+// Package encoding Provides encoding and decoding of json, protobuf, gob, msgpack, cbor and avro.
 package encoding
 
 import (
@@ -47,6 +47,29 @@ func TestEncoding(t *testing.T) {
 
 	err = xEncoding(MsgPackEncoding{})
 	assert.NoError(t, err)
+
+	err = xEncoding(MsgPackJSONTagEncoding{})
+	assert.NoError(t, err)
+
+	err = xEncoding(CBOREncoding{})
+	assert.NoError(t, err)
+
+	err = xEncoding(AvroEncoding{})
+	assert.NoError(t, err)
+}
+
+func TestJSONTagEncoding(t *testing.T) {
+	o := &obj{ID: 1, Name: "foo"}
+	encodings := []Encoding{MsgPackJSONTagEncoding{}, CBOREncoding{}}
+	for _, e := range encodings {
+		data, err := e.Marshal(o)
+		assert.NoError(t, err)
+		m := map[string]interface{}{}
+		err = e.Unmarshal(data, &m)
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", m["name"])
+		assert.Contains(t, m, "id")
+	}
 }
 
 func TestEncodingError(t *testing.T) {
@@ -83,6 +106,15 @@ func TestEncodingError(t *testing.T) {
 	// pack error test
 	err = msgE.Unmarshal([]byte("foo"), nil)
 	assert.Error(t, err)
+
+	msgJE := MsgPackJSONTagEncoding{}
+	err = msgJE.Unmarshal([]byte("foo"), nil)
+	assert.Error(t, err)
+
+	cborE := CBOREncoding{}
+	// cbor error test
+	err = cborE.Unmarshal([]byte{0xff}, &obj{})
+	assert.Error(t, err)
 }
 
 type codec struct{}
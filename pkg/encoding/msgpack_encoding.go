@@ -1,6 +1,10 @@
 package encoding
 
-import "github.com/vmihailenco/msgpack"
+import (
+	"bytes"
+
+	"github.com/vmihailenco/msgpack"
+)
 
 // MsgPackEncoding msgpack format
 type MsgPackEncoding struct{}
@@ -19,3 +23,19 @@ func (mp MsgPackEncoding) Unmarshal(data []byte, value interface{}) error {
 	}
 	return nil
 }
+
+// MsgPackJSONTagEncoding msgpack format, the field name is the same as json tag if msgpack tag is not set,
+// the data structure is consistent with json, it is suitable for http api.
+type MsgPackJSONTagEncoding struct{}
+
+// Marshal msgpack encode
+func (mp MsgPackJSONTagEncoding) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	err := msgpack.NewEncoder(&buf).UseJSONTag(true).Encode(v)
+	return buf.Bytes(), err
+}
+
+// Unmarshal msgpack decode
+func (mp MsgPackJSONTagEncoding) Unmarshal(data []byte, value interface{}) error {
+	return msgpack.NewDecoder(bytes.NewReader(data)).UseJSONTag(true).Decode(value)
+}
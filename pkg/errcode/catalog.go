@@ -0,0 +1,164 @@
+package errcode
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// CodeTypeHTTP http error code
+	CodeTypeHTTP = "http"
+	// CodeTypeGRPC grpc error code
+	CodeTypeGRPC = "grpc"
+)
+
+// CodeInfo error code info with the mappings, it is used to export the error codes for clients, e.g. frontend.
+// HTTPStatus is the standard http status code when the error is converted by ErrToHTTP,
+// GRPCCode is the standard grpc code name when the rpc status is converted by ToRPCErr.
+type CodeInfo struct {
+	Name       string `json:"name,omitempty"` // variable name of the error code, it is empty if unknown
+	Type       string `json:"type"`           // http or grpc
+	Code       int    `json:"code"`
+	Msg        string `json:"msg"`
+	HTTPStatus int    `json:"httpStatus"`
+	GRPCCode   string `json:"grpcCode,omitempty"`
+}
+
+// NewHTTPCodeInfo create http error code info
+func NewHTTPCodeInfo(name string, code int, msg string) *CodeInfo {
+	e := &Error{code: code, msg: msg}
+	return &CodeInfo{
+		Name:       name,
+		Type:       CodeTypeHTTP,
+		Code:       code,
+		Msg:        msg,
+		HTTPStatus: e.ToHTTPCode(),
+	}
+}
+
+// NewGRPCCodeInfo create grpc error code info
+func NewGRPCCodeInfo(name string, code int, msg string) *CodeInfo {
+	s := &RPCStatus{status: status.New(codes.Code(code), msg)}
+	grpcCode := s.ToRPCCode()
+	info := &CodeInfo{
+		Name:       name,
+		Type:       CodeTypeGRPC,
+		Code:       code,
+		Msg:        msg,
+		HTTPStatus: convertToHTTPCode(codes.Code(code)),
+	}
+	if grpcCode <= codes.Unauthenticated {
+		info.GRPCCode = grpcCode.String()
+	}
+	return info
+}
+
+// ListCodeCatalog list all registered http and grpc error codes with the mappings, sorted by type and code
+func ListCodeCatalog() []*CodeInfo {
+	infos := []*CodeInfo{}
+	for _, ei := range getErrorInfo(httpErrCodes) {
+		infos = append(infos, NewHTTPCodeInfo("", ei.Code, ei.Msg))
+	}
+	for _, ei := range getErrorInfo(grpcErrCodes) {
+		infos = append(infos, NewGRPCCodeInfo("", ei.Code, ei.Msg))
+	}
+	return infos
+}
+
+// ListCodeCatalogHandler list all registered error codes with the mappings, http handle func
+func ListCodeCatalogHandler(w http.ResponseWriter, _ *http.Request) {
+	jsonData, err := json.Marshal(ListCodeCatalog())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(jsonData)
+}
+
+// GetSystemCodeInfo get the code info of system level error code by variable name, e.g. InvalidParams, StatusNotFound
+func GetSystemCodeInfo(name string) (*CodeInfo, bool) {
+	if e, ok := systemHTTPCodes()[name]; ok {
+		return NewHTTPCodeInfo(name, e.Code(), e.Msg()), true
+	}
+	if s, ok := systemGRPCCodes()[name]; ok {
+		return NewGRPCCodeInfo(name, int(s.Code()), s.Msg()), true
+	}
+	return nil, false
+}
+
+// SortCodeInfos sort code infos by type and code
+func SortCodeInfos(infos []*CodeInfo) {
+	sort.SliceStable(infos, func(i, j int) bool {
+		if infos[i].Type != infos[j].Type {
+			return infos[i].Type > infos[j].Type // http first
+		}
+		return infos[i].Code < infos[j].Code
+	})
+}
+
+func systemHTTPCodes() map[string]*Error {
+	return map[string]*Error{
+		"Success":             Success,
+		"InvalidParams":       InvalidParams,
+		"Unauthorized":        Unauthorized,
+		"InternalServerError": InternalServerError,
+		"NotFound":            NotFound,
+		"Timeout":             Timeout,
+		"TooManyRequests":     TooManyRequests,
+		"Forbidden":           Forbidden,
+		"LimitExceed":         LimitExceed,
+		"DeadlineExceeded":    DeadlineExceeded,
+		"AccessDenied":        AccessDenied,
+		"MethodNotAllowed":    MethodNotAllowed,
+		"ServiceUnavailable":  ServiceUnavailable,
+		"Canceled":            Canceled,
+		"Unknown":             Unknown,
+		"PermissionDenied":    PermissionDenied,
+		"ResourceExhausted":   ResourceExhausted,
+		"FailedPrecondition":  FailedPrecondition,
+		"Aborted":             Aborted,
+		"OutOfRange":          OutOfRange,
+		"Unimplemented":       Unimplemented,
+		"DataLoss":            DataLoss,
+		"StatusBadGateway":    StatusBadGateway,
+		"AlreadyExists":       AlreadyExists,
+		"Conflict":            Conflict,
+		"TooEarly":            TooEarly,
+	}
+}
+
+func systemGRPCCodes() map[string]*RPCStatus {
+	return map[string]*RPCStatus{
+		"StatusSuccess":             StatusSuccess,
+		"StatusCanceled":            StatusCanceled,
+		"StatusUnknown":             StatusUnknown,
+		"StatusInvalidParams":       StatusInvalidParams,
+		"StatusDeadlineExceeded":    StatusDeadlineExceeded,
+		"StatusNotFound":            StatusNotFound,
+		"StatusAlreadyExists":       StatusAlreadyExists,
+		"StatusPermissionDenied":    StatusPermissionDenied,
+		"StatusResourceExhausted":   StatusResourceExhausted,
+		"StatusFailedPrecondition":  StatusFailedPrecondition,
+		"StatusAborted":             StatusAborted,
+		"StatusOutOfRange":          StatusOutOfRange,
+		"StatusUnimplemented":       StatusUnimplemented,
+		"StatusInternalServerError": StatusInternalServerError,
+		"StatusServiceUnavailable":  StatusServiceUnavailable,
+		"StatusDataLoss":            StatusDataLoss,
+		"StatusUnauthorized":        StatusUnauthorized,
+		"StatusTimeout":             StatusTimeout,
+		"StatusTooManyRequests":     StatusTooManyRequests,
+		"StatusForbidden":           StatusForbidden,
+		"StatusLimitExceed":         StatusLimitExceed,
+		"StatusMethodNotAllowed":    StatusMethodNotAllowed,
+		"StatusAccessDenied":        StatusAccessDenied,
+		"StatusConflict":            StatusConflict,
+	}
+}
@@ -0,0 +1,57 @@
+package errcode
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCodeInfo(t *testing.T) {
+	info := NewHTTPCodeInfo("NotFound", NotFound.Code(), NotFound.Msg())
+	assert.Equal(t, CodeTypeHTTP, info.Type)
+	assert.Equal(t, http.StatusNotFound, info.HTTPStatus)
+	assert.Equal(t, "", info.GRPCCode)
+
+	info = NewHTTPCodeInfo("ErrCreateUser", HCode(1)+1, "failed to create user")
+	assert.Equal(t, http.StatusInternalServerError, info.HTTPStatus)
+
+	info = NewGRPCCodeInfo("StatusNotFound", int(StatusNotFound.Code()), StatusNotFound.Msg())
+	assert.Equal(t, CodeTypeGRPC, info.Type)
+	assert.Equal(t, http.StatusNotFound, info.HTTPStatus)
+	assert.Equal(t, "NotFound", info.GRPCCode)
+
+	info = NewGRPCCodeInfo("StatusCreateUser", int(RCode(1)+1), "failed to create user")
+	assert.Equal(t, http.StatusInternalServerError, info.HTTPStatus)
+	assert.Equal(t, "", info.GRPCCode)
+}
+
+func TestListCodeCatalog(t *testing.T) {
+	infos := ListCodeCatalog()
+	assert.Greater(t, len(infos), 0)
+	assert.Equal(t, CodeTypeHTTP, infos[0].Type)
+	assert.Equal(t, CodeTypeGRPC, infos[len(infos)-1].Type)
+
+	SortCodeInfos(infos)
+	assert.Equal(t, CodeTypeHTTP, infos[0].Type)
+
+	w := httptest.NewRecorder()
+	ListCodeCatalogHandler(w, httptest.NewRequest(http.MethodGet, "/codes/catalog", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"grpcCode":"InvalidArgument"`)
+}
+
+func TestGetSystemCodeInfo(t *testing.T) {
+	info, ok := GetSystemCodeInfo("InvalidParams")
+	assert.True(t, ok)
+	assert.Equal(t, InvalidParams.Code(), info.Code)
+	assert.Equal(t, http.StatusBadRequest, info.HTTPStatus)
+
+	info, ok = GetSystemCodeInfo("StatusUnauthorized")
+	assert.True(t, ok)
+	assert.Equal(t, "Unauthenticated", info.GRPCCode)
+
+	_, ok = GetSystemCodeInfo("foo")
+	assert.False(t, ok)
+}
@@ -47,6 +47,83 @@ func NewResponser(isFromRPC bool, httpErrors []*Error, rpcStatus []*RPCStatus) R
 	}
 }
 
+// NewGatewayResponser creates a new responser for the http interface translated from the grpc service
+// in grpc-gateway style, the standard grpc codes and the system status codes are converted to the
+// corresponding standard http codes, the service-defined codes are returned with http code 200,
+// so the grpc and http interfaces share the same error codes.
+func NewGatewayResponser() Responser {
+	return &gatewayResponse{}
+}
+
+type gatewayResponse struct {
+	defaultResponse
+}
+
+// ParamError response parameter error information with http code 400
+func (resp *gatewayResponse) ParamError(c *gin.Context, _ error) {
+	resp.response(c, http.StatusBadRequest, InvalidParams.Code(), InvalidParams.Msg(), struct{}{})
+}
+
+// Error response error information, if return true, means that the error code is converted to a standard http code
+func (resp *gatewayResponse) Error(c *gin.Context, err error) bool {
+	st, ok := status.FromError(err)
+	if !ok { // not a grpc error
+		resp.response(c, http.StatusInternalServerError, int(codes.Unknown), err.Error(), struct{}{})
+		return true
+	}
+
+	msg := strings.ReplaceAll(st.Message(), ToHTTPCodeLabel, "")
+	httpCode := gatewayHTTPCode(st.Code())
+	resp.response(c, httpCode, int(st.Code()), msg, struct{}{})
+	return httpCode != http.StatusOK
+}
+
+// gatewayHTTPCode converts the grpc code to http code, the mapping of standard grpc codes is the same as grpc-gateway.
+func gatewayHTTPCode(code codes.Code) int {
+	// system status codes to standard grpc codes
+	if code > codes.Unauthenticated {
+		switch code {
+		case StatusTimeout.status.Code():
+			return http.StatusRequestTimeout
+		case StatusTooManyRequests.status.Code():
+			return http.StatusTooManyRequests
+		case StatusForbidden.status.Code():
+			return http.StatusForbidden
+		}
+		code = (&RPCStatus{status: status.New(code, "")}).ToRPCCode()
+	}
+
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499 // client closed request
+	case codes.Unknown, codes.Internal, codes.DataLoss:
+		return http.StatusInternalServerError
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	}
+
+	// service-defined codes
+	return http.StatusOK
+}
+
 type defaultResponse struct {
 	isFromRPC  bool // error comes from grpc, if not, default is from http
 	httpErrors map[int]*Error
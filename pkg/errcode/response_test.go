@@ -323,3 +323,51 @@ func BenchmarkName(b *testing.B) {
 		}
 	}
 }
+
+func TestGatewayResponse(t *testing.T) {
+	serverAddr, requestAddr := utils.GetLocalHTTPAddrPairs()
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	resp := NewGatewayResponser()
+	serviceStatus := NewRPCStatus(40001, "user not found")
+
+	r.GET("/ping", func(c *gin.Context) { resp.Success(c, "ping") })
+	r.GET("/params", func(c *gin.Context) { resp.ParamError(c, errors.New("id is required")) })
+	r.GET("/unknown", func(c *gin.Context) { resp.Error(c, errors.New("unknown error")) })
+	r.GET("/src_notfound", func(c *gin.Context) { resp.Error(c, status.Error(codes.NotFound, "not found")) })
+	r.GET("/notfound", func(c *gin.Context) { resp.Error(c, StatusNotFound.Err()) })
+	r.GET("/conflict", func(c *gin.Context) { resp.Error(c, StatusConflict.Err()) })
+	r.GET("/forbidden", func(c *gin.Context) { resp.Error(c, StatusForbidden.Err()) })
+	r.GET("/deadline", func(c *gin.Context) { resp.Error(c, StatusDeadlineExceeded.ToRPCErr()) })
+	r.GET("/mark_limit", func(c *gin.Context) { resp.Error(c, StatusLimitExceed.ErrToHTTP()) })
+	r.GET("/service", func(c *gin.Context) { resp.Error(c, serviceStatus.Err()) })
+	go func() {
+		_ = r.Run(serverAddr)
+	}()
+	time.Sleep(time.Millisecond * 200)
+
+	tests := map[string]int{
+		"/ping":         http.StatusOK,
+		"/params":       http.StatusBadRequest,
+		"/unknown":      http.StatusInternalServerError,
+		"/src_notfound": http.StatusNotFound,
+		"/notfound":     http.StatusNotFound,
+		"/conflict":     http.StatusConflict,
+		"/forbidden":    http.StatusForbidden,
+		"/deadline":     http.StatusGatewayTimeout,
+		"/mark_limit":   http.StatusTooManyRequests,
+		"/service":      http.StatusOK,
+	}
+	for path, code := range tests {
+		result, err := http.Get(requestAddr + path)
+		assert.NoError(t, err)
+		assert.Equal(t, code, result.StatusCode, path)
+		_ = result.Body.Close()
+	}
+
+	result, err := http.Get(requestAddr + "/service")
+	assert.NoError(t, err)
+	data, _ := io.ReadAll(result.Body)
+	_ = result.Body.Close()
+	assert.Contains(t, string(data), `"code":40001`)
+}
@@ -0,0 +1,3 @@
+// Package annotations is the go code generated from third_party/gin/annotations.proto, it defines the
+// proto options of protoc-gen-go-gin, e.g. option (gin.api_version) = "v2";
+package annotations
@@ -0,0 +1,177 @@
+// Package apikey issues, rotates and verifies the API keys of public APIs, the plaintext key is returned
+// to the caller only once when it is issued, only the hash of the secret is saved, and the gin middleware
+// verifies the key of each request by the Store.
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultPrefix the default prefix of key, e.g. sk_<key id>_<secret>
+	DefaultPrefix = "sk"
+
+	keyIDLen  = 16
+	secretLen = 32
+	alphabet  = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+)
+
+var (
+	// ErrInvalidKey the key format is invalid or the secret does not match
+	ErrInvalidKey = errors.New("apikey: invalid key")
+	// ErrKeyNotFound the key id does not exist, it is returned by Store
+	ErrKeyNotFound = errors.New("apikey: key not found")
+	// ErrKeyExpired the key has expired
+	ErrKeyExpired = errors.New("apikey: key expired")
+	// ErrKeyRevoked the key has been revoked
+	ErrKeyRevoked = errors.New("apikey: key revoked")
+	// ErrInsufficientScope the key does not have the required scopes
+	ErrInsufficientScope = errors.New("apikey: insufficient scope")
+)
+
+// Key is the metadata of API key saved in the store, the secret is not saved.
+type Key struct {
+	KeyID      string    // public id of key, it is used to find the key in the store
+	SecretHash string    // hash of the current secret
+	Owner      string    // owner of key, e.g. user id or app id
+	Scopes     []string  // scopes of key, * means all scopes
+	ExpiredAt  time.Time // zero value means never expires
+	Revoked    bool
+
+	// the previous secret is still valid until PrevExpiredAt after rotation, so that the callers
+	// can switch to the new key without downtime
+	PrevSecretHash string
+	PrevExpiredAt  time.Time
+}
+
+// Verify check the secret of request, the key must not be revoked or expired.
+func (k *Key) Verify(secret string) error {
+	if k.Revoked {
+		return ErrKeyRevoked
+	}
+	now := time.Now()
+	if !k.ExpiredAt.IsZero() && now.After(k.ExpiredAt) {
+		return ErrKeyExpired
+	}
+
+	hash := Hash(secret)
+	if equal(hash, k.SecretHash) {
+		return nil
+	}
+	if k.PrevSecretHash != "" && now.Before(k.PrevExpiredAt) && equal(hash, k.PrevSecretHash) {
+		return nil
+	}
+	return ErrInvalidKey
+}
+
+// HasScopes check whether the key has all the scopes.
+func (k *Key) HasScopes(scopes ...string) bool {
+	owned := make(map[string]bool, len(k.Scopes))
+	for _, scope := range k.Scopes {
+		if scope == "*" {
+			return true
+		}
+		owned[scope] = true
+	}
+	for _, scope := range scopes {
+		if !owned[scope] {
+			return false
+		}
+	}
+	return true
+}
+
+// Generate issues a new API key, the key is in the format <prefix>_<key id>_<secret>, save the key id and
+// hash to the store, and return the key to the caller, it can not be recovered from the hash.
+func Generate(prefix string) (key string, keyID string, hash string, err error) {
+	keyID, err = randomString(keyIDLen)
+	if err != nil {
+		return "", "", "", err
+	}
+	key, hash, err = Rotate(prefix, keyID)
+	if err != nil {
+		return "", "", "", err
+	}
+	return key, keyID, hash, nil
+}
+
+// Rotate generates a new secret of the key id, the key id is unchanged, save the hash to the store.
+func Rotate(prefix string, keyID string) (key string, hash string, err error) {
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	if !isAlphanumeric(prefix) {
+		return "", "", errors.New("apikey: the prefix must be alphanumeric")
+	}
+	if !isAlphanumeric(keyID) {
+		return "", "", errors.New("apikey: the key id must be alphanumeric")
+	}
+
+	secret, err := randomString(secretLen)
+	if err != nil {
+		return "", "", err
+	}
+	return prefix + "_" + keyID + "_" + secret, Hash(secret), nil
+}
+
+// Parse the key into prefix, key id and secret.
+func Parse(key string) (prefix string, keyID string, secret string, err error) {
+	parts := strings.Split(key, "_")
+	if len(parts) != 3 {
+		return "", "", "", ErrInvalidKey
+	}
+	for _, part := range parts {
+		if !isAlphanumeric(part) {
+			return "", "", "", ErrInvalidKey
+		}
+	}
+	return parts[0], parts[1], parts[2], nil
+}
+
+// Hash returns the sha256 hex of secret, the secret is random with high entropy, so a fast hash is enough.
+func Hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func equal(a string, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+func randomString(n int) (string, error) {
+	buf := make([]byte, 0, n)
+	b := make([]byte, n*2)
+	for len(buf) < n {
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		for _, v := range b {
+			if v >= 248 { // reject to keep the distribution uniform, 248 = 62*4
+				continue
+			}
+			buf = append(buf, alphabet[v%62])
+			if len(buf) == n {
+				break
+			}
+		}
+	}
+	return string(buf), nil
+}
+
+func isAlphanumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte(alphabet, s[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
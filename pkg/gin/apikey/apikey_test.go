@@ -0,0 +1,189 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	key, keyID, hash, err := Generate("")
+	require.NoError(t, err)
+	assert.Len(t, keyID, keyIDLen)
+	assert.True(t, strings.HasPrefix(key, DefaultPrefix+"_"+keyID+"_"))
+
+	prefix, id, secret, err := Parse(key)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultPrefix, prefix)
+	assert.Equal(t, keyID, id)
+	assert.Len(t, secret, secretLen)
+	assert.Equal(t, hash, Hash(secret))
+	assert.NotContains(t, hash, secret)
+
+	key2, keyID2, _, err := Generate("pk")
+	require.NoError(t, err)
+	assert.NotEqual(t, keyID, keyID2)
+	assert.True(t, strings.HasPrefix(key2, "pk_"))
+
+	_, _, _, err = Generate("p_k")
+	assert.Error(t, err)
+}
+
+func TestRotate(t *testing.T) {
+	key, keyID, hash, err := Generate("sk")
+	require.NoError(t, err)
+	newKey, newHash, err := Rotate("sk", keyID)
+	require.NoError(t, err)
+	assert.NotEqual(t, key, newKey)
+	assert.NotEqual(t, hash, newHash)
+	_, id, _, _ := Parse(newKey)
+	assert.Equal(t, keyID, id)
+
+	_, _, err = Rotate("sk", "")
+	assert.Error(t, err)
+}
+
+func TestParse(t *testing.T) {
+	for _, key := range []string{"", "sk", "sk_abc", "sk_abc_", "sk_a-b_c", "sk_a_b_c", "sk_abc_def "} {
+		_, _, _, err := Parse(key)
+		assert.ErrorIs(t, err, ErrInvalidKey, key)
+	}
+}
+
+func TestKey_Verify(t *testing.T) {
+	k := &Key{KeyID: "id", SecretHash: Hash("secret")}
+	assert.NoError(t, k.Verify("secret"))
+	assert.ErrorIs(t, k.Verify("other"), ErrInvalidKey)
+
+	k.ExpiredAt = time.Now().Add(-time.Second)
+	assert.ErrorIs(t, k.Verify("secret"), ErrKeyExpired)
+	k.ExpiredAt = time.Now().Add(time.Hour)
+	assert.NoError(t, k.Verify("secret"))
+
+	// the previous secret is valid in the grace period
+	k.PrevSecretHash, k.PrevExpiredAt = Hash("old"), time.Now().Add(time.Minute)
+	assert.NoError(t, k.Verify("old"))
+	k.PrevExpiredAt = time.Now().Add(-time.Second)
+	assert.ErrorIs(t, k.Verify("old"), ErrInvalidKey)
+
+	k.Revoked = true
+	assert.ErrorIs(t, k.Verify("secret"), ErrKeyRevoked)
+}
+
+func TestKey_HasScopes(t *testing.T) {
+	k := &Key{Scopes: []string{"read", "write"}}
+	assert.True(t, k.HasScopes())
+	assert.True(t, k.HasScopes("read"))
+	assert.True(t, k.HasScopes("read", "write"))
+	assert.False(t, k.HasScopes("read", "admin"))
+
+	k.Scopes = []string{"*"}
+	assert.True(t, k.HasScopes("admin"))
+}
+
+type memStore map[string]*Key
+
+func (s memStore) GetByKeyID(_ context.Context, keyID string) (*Key, error) {
+	if keyID == "broken" {
+		return nil, errors.New("db error")
+	}
+	k, ok := s[keyID]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return k, nil
+}
+
+func newTestEngine(store Store, opts ...AuthOption) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	g := r.Group("/", Auth(store, opts...))
+	g.GET("/ping", func(c *gin.Context) {
+		k, _ := FromContext(c)
+		c.String(http.StatusOK, k.Owner)
+	})
+	g.GET("/admin", RequireScopes("admin"), func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	return r
+}
+
+func doRequest(r http.Handler, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuth(t *testing.T) {
+	key, keyID, hash, err := Generate("sk")
+	require.NoError(t, err)
+	store := memStore{keyID: {KeyID: keyID, SecretHash: hash, Owner: "foo", Scopes: []string{"read"}}}
+	var verified int
+	r := newTestEngine(store, WithOnVerified(func(c *gin.Context, key *Key) { verified++ }))
+
+	w := doRequest(r, "/ping", map[string]string{HeaderAPIKey: key})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "foo", w.Body.String())
+	assert.Equal(t, 1, verified)
+
+	w = doRequest(r, "/ping", map[string]string{"Authorization": "ApiKey " + key})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = doRequest(r, "/admin", map[string]string{HeaderAPIKey: key})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// invalid keys
+	unknownKey, _, _, _ := Generate("sk")
+	_, _, secret, _ := Parse(unknownKey)
+	for _, k := range []string{"", "invalid", unknownKey, "sk_" + keyID + "_" + secret} {
+		w = doRequest(r, "/ping", map[string]string{HeaderAPIKey: k})
+		assert.Equal(t, http.StatusUnauthorized, w.Code, k)
+	}
+
+	w = doRequest(r, "/ping", map[string]string{HeaderAPIKey: "sk_broken_" + secret})
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	store[keyID].Revoked = true
+	w = doRequest(r, "/ping", map[string]string{HeaderAPIKey: key})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, 3, verified) // the request of /admin is verified before checking the scopes
+}
+
+func TestAuth_Options(t *testing.T) {
+	key, keyID, hash, err := Generate("pk")
+	require.NoError(t, err)
+	store := memStore{keyID: {KeyID: keyID, SecretHash: hash, Scopes: []string{"read"}}}
+
+	r := newTestEngine(store, WithHeader("X-Key"), WithPrefix("pk"), WithScopes("read"), WithReturnErrReason())
+	w := doRequest(r, "/ping", map[string]string{"X-Key": key})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	r = newTestEngine(store, WithPrefix("sk"), WithReturnErrReason())
+	w = doRequest(r, "/ping", map[string]string{HeaderAPIKey: key})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), ErrInvalidKey.Error())
+
+	r = newTestEngine(store, WithScopes("write"))
+	w = doRequest(r, "/ping", map[string]string{HeaderAPIKey: key})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestRequireScopes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/admin", RequireScopes("admin"), func(c *gin.Context) {})
+	w := doRequest(r, "/admin", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
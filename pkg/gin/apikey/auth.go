@@ -0,0 +1,168 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/go-dev-frame/sponge/pkg/errcode"
+	"github.com/go-dev-frame/sponge/pkg/gin/response"
+)
+
+const (
+	// HeaderAPIKey the default header of API key
+	HeaderAPIKey = "X-API-Key"
+
+	// the Authorization header is also supported, the value is "ApiKey <key>"
+	authorizationPrefix = "ApiKey "
+
+	ctxKey = "apikey"
+)
+
+// Store find the key by key id, ErrKeyNotFound is returned if the key id does not exist.
+type Store interface {
+	GetByKeyID(ctx context.Context, keyID string) (*Key, error)
+}
+
+// AuthOption set the auth options.
+type AuthOption func(*authOptions)
+
+type authOptions struct {
+	header            string
+	prefix            string
+	scopes            []string
+	isReturnErrReason bool
+	onVerified        func(c *gin.Context, key *Key)
+}
+
+func defaultAuthOptions() *authOptions {
+	return &authOptions{
+		header: HeaderAPIKey,
+	}
+}
+
+func (o *authOptions) apply(opts ...AuthOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WithHeader set the header of API key, default is X-API-Key.
+func WithHeader(header string) AuthOption {
+	return func(o *authOptions) {
+		if header != "" {
+			o.header = header
+		}
+	}
+}
+
+// WithPrefix only accept the keys with the prefix, default accepts any prefix.
+func WithPrefix(prefix string) AuthOption {
+	return func(o *authOptions) {
+		o.prefix = prefix
+	}
+}
+
+// WithScopes set the scopes required by all routes of the middleware.
+func WithScopes(scopes ...string) AuthOption {
+	return func(o *authOptions) {
+		o.scopes = scopes
+	}
+}
+
+// WithReturnErrReason set return error reason
+func WithReturnErrReason() AuthOption {
+	return func(o *authOptions) {
+		o.isReturnErrReason = true
+	}
+}
+
+// WithOnVerified set the callback after the key is verified, e.g. record the last used time of key.
+func WithOnVerified(fn func(c *gin.Context, key *Key)) AuthOption {
+	return func(o *authOptions) {
+		o.onVerified = fn
+	}
+}
+
+func responseErr(c *gin.Context, e *errcode.Error, isReturnErrReason bool, err error) {
+	if isReturnErrReason {
+		e = e.RewriteMsg(e.Msg() + ", " + err.Error())
+	}
+	response.Out(c, e)
+	c.Abort()
+}
+
+// Auth verify the API key of request by the store, the key is saved in the context, get it by FromContext.
+func Auth(store Store, opts ...AuthOption) gin.HandlerFunc {
+	o := defaultAuthOptions()
+	o.apply(opts...)
+
+	return func(c *gin.Context) {
+		key := c.GetHeader(o.header)
+		if key == "" {
+			key = strings.TrimPrefix(c.GetHeader("Authorization"), authorizationPrefix)
+		}
+
+		prefix, keyID, secret, err := Parse(key)
+		if err == nil && o.prefix != "" && prefix != o.prefix {
+			err = ErrInvalidKey
+		}
+		if err != nil {
+			responseErr(c, errcode.Unauthorized, o.isReturnErrReason, err)
+			return
+		}
+
+		k, err := store.GetByKeyID(c.Request.Context(), keyID)
+		if err != nil {
+			if errors.Is(err, ErrKeyNotFound) {
+				responseErr(c, errcode.Unauthorized, o.isReturnErrReason, ErrInvalidKey)
+				return
+			}
+			responseErr(c, errcode.InternalServerError, false, err)
+			return
+		}
+		if err = k.Verify(secret); err != nil {
+			responseErr(c, errcode.Unauthorized, o.isReturnErrReason, err)
+			return
+		}
+		if !k.HasScopes(o.scopes...) {
+			responseErr(c, errcode.Forbidden, o.isReturnErrReason, ErrInsufficientScope)
+			return
+		}
+
+		c.Set(ctxKey, k)
+		if o.onVerified != nil {
+			o.onVerified(c, k)
+		}
+		c.Next()
+	}
+}
+
+// RequireScopes check the scopes of the key verified by Auth, it is used for the routes which
+// require more scopes than the group.
+func RequireScopes(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		k, ok := FromContext(c)
+		if !ok {
+			responseErr(c, errcode.Unauthorized, false, ErrInvalidKey)
+			return
+		}
+		if !k.HasScopes(scopes...) {
+			responseErr(c, errcode.Forbidden, false, ErrInsufficientScope)
+			return
+		}
+		c.Next()
+	}
+}
+
+// FromContext get the key verified by Auth from gin context.
+func FromContext(c *gin.Context) (*Key, bool) {
+	v, exists := c.Get(ctxKey)
+	if !exists {
+		return nil, false
+	}
+	k, ok := v.(*Key)
+	return k, ok
+}
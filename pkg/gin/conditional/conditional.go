@@ -0,0 +1,102 @@
+// Package conditional is the helpers of http conditional requests used by the generated handlers,
+// the detail api responds the ETag of record and 304 for If-None-Match, and the update api rejects
+// the request with 412 if the record has been modified since the If-Match ETag was read.
+package conditional
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/go-dev-frame/sponge/pkg/gin/response"
+)
+
+// headers of conditional requests, see RFC 9110
+const (
+	HeaderETag        = "ETag"
+	HeaderIfMatch     = "If-Match"
+	HeaderIfNoneMatch = "If-None-Match"
+)
+
+// ETag returns a strong ETag of record derived from its id and version, version is usually the
+// updated_at (time.Time) or version column of record, e.g. "5f2b0c3a9e8d7c61".
+func ETag(id interface{}, version interface{}) string {
+	if t, ok := version.(time.Time); ok {
+		version = t.UnixNano()
+	}
+	h := fnv.New64a()
+	_, _ = fmt.Fprintf(h, "%v:%v", id, version)
+	return `"` + strconv.FormatUint(h.Sum64(), 16) + `"`
+}
+
+// SetETag set the ETag header of response.
+func SetETag(c *gin.Context, etag string) {
+	c.Header(HeaderETag, etag)
+}
+
+// NotModified set the ETag header, and responds 304 if the If-None-Match of request matches etag,
+// the handler returns without writing the body when it is true.
+func NotModified(c *gin.Context, etag string) bool {
+	SetETag(c, etag)
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		return false
+	}
+	if !match(c.GetHeader(HeaderIfNoneMatch), etag, false) {
+		return false
+	}
+	c.AbortWithStatus(http.StatusNotModified)
+	return true
+}
+
+// HasIfMatch reports whether the request has the If-Match header.
+func HasIfMatch(c *gin.Context) bool {
+	return c.GetHeader(HeaderIfMatch) != ""
+}
+
+// IfMatch reports whether the If-Match of request matches etag by strong comparison, it is true if
+// the request has no If-Match header, "*" matches any etag.
+func IfMatch(c *gin.Context, etag string) bool {
+	ifMatch := c.GetHeader(HeaderIfMatch)
+	if ifMatch == "" {
+		return true
+	}
+	return match(ifMatch, etag, true)
+}
+
+// PreconditionFailed responds 412, the record has been modified or deleted since the If-Match ETag was read.
+func PreconditionFailed(c *gin.Context) {
+	response.Output(c, http.StatusPreconditionFailed)
+}
+
+// match reports whether the list of etags in header matches etag, the weak etags (W/"xxx") never
+// match in strong comparison.
+func match(header string, etag string, strong bool) bool {
+	if header == "" || etag == "" {
+		return false
+	}
+	if strong && strings.HasPrefix(etag, "W/") {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, v := range strings.Split(header, ",") {
+		v = strings.TrimSpace(v)
+		if v == "*" {
+			return true
+		}
+		if strings.HasPrefix(v, "W/") {
+			if strong {
+				continue
+			}
+			v = v[2:]
+		}
+		if v == etag {
+			return true
+		}
+	}
+	return false
+}
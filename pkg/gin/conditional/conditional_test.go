@@ -0,0 +1,94 @@
+package conditional
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-dev-frame/sponge/pkg/gin/response"
+)
+
+func TestETag(t *testing.T) {
+	now := time.Now()
+	etag := ETag(1, now)
+	assert.Regexp(t, `^"[0-9a-f]+"$`, etag)
+	assert.Equal(t, etag, ETag(uint64(1), now.UTC()))
+	assert.NotEqual(t, etag, ETag(2, now))
+	assert.NotEqual(t, etag, ETag(1, now.Add(time.Microsecond)))
+	assert.NotEqual(t, ETag("1", 1), ETag("1", 2))
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		header string
+		etag   string
+		strong bool
+		want   bool
+	}{
+		{"", `"a"`, true, false},
+		{`"a"`, `"a"`, true, true},
+		{`"b", "a"`, `"a"`, true, true},
+		{`"b"`, `"a"`, true, false},
+		{`*`, `"a"`, true, true},
+		{`W/"a"`, `"a"`, true, false},
+		{`W/"a"`, `"a"`, false, true},
+		{`"a"`, `W/"a"`, false, true},
+		{`"a"`, `W/"a"`, true, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, match(tt.header, tt.etag, tt.strong), tt)
+	}
+}
+
+func TestConditionalRequests(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	etag := ETag(1, time.Now())
+	r := gin.New()
+	r.GET("/user/:id", func(c *gin.Context) {
+		if NotModified(c, etag) {
+			return
+		}
+		response.Success(c)
+	})
+	r.PUT("/user/:id", func(c *gin.Context) {
+		if !IfMatch(c, etag) {
+			PreconditionFailed(c)
+			return
+		}
+		response.Success(c)
+	})
+
+	do := func(method string, header string, value string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, "/user/1", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodGet, "", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, etag, w.Header().Get(HeaderETag))
+
+	w = do(http.MethodGet, HeaderIfNoneMatch, etag)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Equal(t, etag, w.Header().Get(HeaderETag))
+
+	w = do(http.MethodGet, HeaderIfNoneMatch, `"other"`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = do(http.MethodPut, "", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = do(http.MethodPut, HeaderIfMatch, etag)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = do(http.MethodPut, HeaderIfMatch, `"other"`)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	assert.Contains(t, w.Body.String(), "Precondition Failed")
+}
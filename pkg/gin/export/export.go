@@ -0,0 +1,191 @@
+// Package export is to stream the rows of table to the response as csv or xlsx file, the rows are written
+// as they come, so the large list can be exported without loading all the rows into memory.
+package export
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// FormatCSV csv format
+	FormatCSV = "csv"
+	// FormatXLSX excel format
+	FormatXLSX = "xlsx"
+
+	// TimeLayout the layout of time value
+	TimeLayout = "2006-01-02 15:04:05"
+)
+
+// ErrUnsupportedFormat the format is not csv or xlsx
+var ErrUnsupportedFormat = errors.New("unsupported export format, only csv and xlsx are supported")
+
+var contentTypes = map[string]string{
+	FormatCSV:  "text/csv; charset=utf-8",
+	FormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// IsSupported check whether the format is supported.
+func IsSupported(format string) bool {
+	_, ok := contentTypes[strings.ToLower(format)]
+	return ok
+}
+
+// ContentType get the content type of format.
+func ContentType(format string) string {
+	return contentTypes[strings.ToLower(format)]
+}
+
+// Writer write the rows to csv or xlsx file.
+type Writer struct {
+	csv  *csv.Writer
+	xlsx *xlsxWriter
+	rows int
+}
+
+// NewWriter create a writer of format, the csv file starts with UTF-8 BOM so that it can be opened by excel
+// directly, Close must be called to flush the data.
+func NewWriter(w io.Writer, format string) (*Writer, error) {
+	switch strings.ToLower(format) {
+	case FormatCSV:
+		if _, err := w.Write([]byte("\xEF\xBB\xBF")); err != nil {
+			return nil, err
+		}
+		return &Writer{csv: csv.NewWriter(w)}, nil
+	case FormatXLSX:
+		xw, err := newXLSXWriter(w)
+		if err != nil {
+			return nil, err
+		}
+		return &Writer{xlsx: xw}, nil
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// New set the response headers of attachment file and create a writer of response body,
+// the file name is without extension, e.g. users --> users.csv.
+func New(c *gin.Context, format string, filename string) (*Writer, error) {
+	format = strings.ToLower(format)
+	if !IsSupported(format) {
+		return nil, ErrUnsupportedFormat
+	}
+
+	filename += "." + format
+	c.Header("Content-Type", ContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"; filename*=UTF-8''%s`,
+		strings.ReplaceAll(filename, `"`, ""), url.PathEscape(filename)))
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+	return NewWriter(c.Writer, format)
+}
+
+// Write write a row.
+func (w *Writer) Write(record []string) error {
+	w.rows++
+	if w.xlsx != nil {
+		return w.xlsx.write(record)
+	}
+	escaped := make([]string, len(record))
+	for i, v := range record {
+		escaped[i] = escapeFormula(v)
+	}
+	return w.csv.Write(escaped)
+}
+
+// WriteSQLRows write all the rows of sql query, the values are converted by FormatValue, it returns the number of rows.
+func (w *Writer) WriteSQLRows(rows *sql.Rows) (int, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	for rows.Next() {
+		if err = rows.Scan(pointers...); err != nil {
+			return count, err
+		}
+		record := make([]string, len(values))
+		for i, v := range values {
+			record[i] = FormatValue(v)
+		}
+		if err = w.Write(record); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, rows.Err()
+}
+
+// Rows the number of rows written, including the header.
+func (w *Writer) Rows() int {
+	return w.rows
+}
+
+// Close flush the data and finish the file, the underlying writer is not closed.
+func (w *Writer) Close() error {
+	if w.xlsx != nil {
+		return w.xlsx.close()
+	}
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+// FormatValue convert the value of database to string, the time is formatted by TimeLayout.
+func FormatValue(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case []byte:
+		return string(value)
+	case time.Time:
+		if value.IsZero() {
+			return ""
+		}
+		return value.Format(TimeLayout)
+	case *time.Time:
+		if value == nil || value.IsZero() {
+			return ""
+		}
+		return value.Format(TimeLayout)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(value), 'f', -1, 32)
+	case fmt.Stringer:
+		return value.String()
+	}
+	return fmt.Sprint(v)
+}
+
+// the cell starts with = + - @ is executed as formula by excel, prefix ' to prevent csv injection,
+// the numbers are not changed.
+func escapeFormula(s string) string {
+	if s == "" {
+		return s
+	}
+	switch s[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		if _, err := strconv.ParseFloat(s, 64); err == nil {
+			return s
+		}
+		return "'" + s
+	}
+	return s
+}
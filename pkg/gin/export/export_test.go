@@ -0,0 +1,209 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var testRecords = [][]string{
+	{"id", "name", "remark"},
+	{"1", "foo", "=1+1"},
+	{"-2", "<bar> & \"baz\"", " a\nb "},
+}
+
+func TestWriter_CSV(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w, err := NewWriter(buf, "CSV")
+	require.NoError(t, err)
+	for _, record := range testRecords {
+		require.NoError(t, w.Write(record))
+	}
+	require.NoError(t, w.Close())
+	assert.Equal(t, 3, w.Rows())
+
+	data := buf.Bytes()
+	assert.True(t, bytes.HasPrefix(data, []byte("\xEF\xBB\xBF")))
+	records, err := csv.NewReader(bytes.NewReader(data[3:])).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, "'=1+1", records[1][2]) // csv injection is escaped
+	assert.Equal(t, "-2", records[2][0])
+	assert.Equal(t, testRecords[2][1:], records[2][1:])
+	assert.Equal(t, "=1+1", testRecords[1][2]) // the record of caller is not changed
+}
+
+func TestWriter_XLSX(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w, err := NewWriter(buf, FormatXLSX)
+	require.NoError(t, err)
+	for _, record := range testRecords {
+		require.NoError(t, w.Write(record))
+	}
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, testRecords, readXLSX(t, buf.Bytes()))
+}
+
+func readXLSX(t *testing.T, data []byte) [][]string {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	names := map[string]*zip.File{}
+	for _, f := range zr.File {
+		names[f.Name] = f
+	}
+	for _, f := range xlsxFiles {
+		require.Contains(t, names, f.name)
+	}
+	require.Contains(t, names, "xl/worksheets/sheet1.xml")
+
+	rc, err := names["xl/worksheets/sheet1.xml"].Open()
+	require.NoError(t, err)
+	defer rc.Close()
+	content, err := io.ReadAll(rc)
+	require.NoError(t, err)
+
+	sheet := struct {
+		Rows []struct {
+			R     string `xml:"r,attr"`
+			Cells []struct {
+				R    string `xml:"r,attr"`
+				Text string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}{}
+	require.NoError(t, xml.Unmarshal(content, &sheet))
+	var records [][]string
+	for _, row := range sheet.Rows {
+		var record []string
+		for _, c := range row.Cells {
+			record = append(record, c.Text)
+		}
+		records = append(records, record)
+	}
+	assert.Equal(t, "C3", sheet.Rows[2].Cells[2].R)
+	return records
+}
+
+func TestNew(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	r.GET("/export", func(c *gin.Context) {
+		w, err := New(c, c.Query("format"), "用户")
+		if err != nil {
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+		for _, record := range testRecords {
+			_ = w.Write(record)
+		}
+		_ = w.Close()
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/export?format=xlsx", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, ContentType(FormatXLSX), rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Header().Get("Content-Disposition"), `filename*=UTF-8''%E7%94%A8%E6%88%B7.xlsx`)
+	assert.Equal(t, testRecords, readXLSX(t, rec.Body.Bytes()))
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/export?format=csv", nil))
+	assert.Equal(t, ContentType(FormatCSV), rec.Header().Get("Content-Type"))
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/export?format=pdf", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	_, err := NewWriter(io.Discard, "pdf")
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestWriter_WriteSQLRows(t *testing.T) {
+	type user struct {
+		ID        uint64
+		Name      string
+		Score     float64
+		CreatedAt time.Time
+		Remark    *string
+	}
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "export.db")),
+		&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&user{}))
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, db.Create([]*user{{Name: "foo", Score: 1.5, CreatedAt: createdAt}, {Name: "bar"}}).Error)
+
+	rows, err := db.Model(&user{}).Select("id", "name", "score", "created_at", "remark").Order("id").Rows()
+	require.NoError(t, err)
+	defer rows.Close()
+
+	buf := &bytes.Buffer{}
+	w, err := NewWriter(buf, FormatCSV)
+	require.NoError(t, err)
+	count, err := w.WriteSQLRows(rows)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	assert.Equal(t, 2, count)
+
+	records, err := csv.NewReader(bytes.NewReader(buf.Bytes()[3:])).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1", "foo", "1.5", "2024-01-02 03:04:05", ""}, records[0])
+}
+
+func TestFormatValue(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	var nilTime *time.Time
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{nil, ""},
+		{"foo", "foo"},
+		{[]byte("bar"), "bar"},
+		{now, "2024-01-02 03:04:05"},
+		{&now, "2024-01-02 03:04:05"},
+		{nilTime, ""},
+		{time.Time{}, ""},
+		{1.50, "1.5"},
+		{float32(2.5), "2.5"},
+		{int64(10), "10"},
+		{true, "true"},
+		{time.Second, "1s"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, FormatValue(tt.value))
+	}
+}
+
+func Test_columnName(t *testing.T) {
+	assert.Equal(t, "A", columnName(0))
+	assert.Equal(t, "Z", columnName(25))
+	assert.Equal(t, "AA", columnName(26))
+	assert.Equal(t, "AZ", columnName(51))
+	assert.Equal(t, "BA", columnName(52))
+	assert.Equal(t, "ZZ", columnName(701))
+	assert.Equal(t, "AAA", columnName(702))
+}
+
+func Test_escapeFormula(t *testing.T) {
+	assert.Equal(t, "", escapeFormula(""))
+	assert.Equal(t, "foo", escapeFormula("foo"))
+	assert.Equal(t, "'=SUM(A1)", escapeFormula("=SUM(A1)"))
+	assert.Equal(t, "'@foo", escapeFormula("@foo"))
+	assert.Equal(t, "'-foo", escapeFormula("-foo"))
+	assert.Equal(t, "-1.5", escapeFormula("-1.5"))
+	assert.Equal(t, "+1", escapeFormula("+1"))
+}
@@ -15,8 +15,9 @@ import (
 
 // CheckHealthReply check health result
 type CheckHealthReply struct {
-	Status   string `json:"status"`
-	Hostname string `json:"hostname"`
+	Status       string              `json:"status"`
+	Hostname     string              `json:"hostname"`
+	Dependencies []*DependencyStatus `json:"dependencies,omitempty"` // listed in verbose mode of CheckHealthWithProbes
 }
 
 // CheckHealth check healthy.
@@ -57,6 +58,19 @@ func ListCodes(c *gin.Context) {
 	c.JSON(http.StatusOK, errcode.ListHTTPErrCodes())
 }
 
+// ListCodesCatalog list all error codes with the mappings of http status code and grpc code,
+// it is used to generate the error code constants for clients, e.g. frontend.
+// @Summary list error codes catalog
+// @Description Returns all registered http and grpc error codes with messages, http status code and grpc code mappings
+// @Tags system
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} errcode.CodeInfo "List of error codes catalog"
+// @Router /codes/catalog [get]
+func ListCodesCatalog(c *gin.Context) {
+	c.JSON(http.StatusOK, errcode.ListCodeCatalog())
+}
+
 // BrowserRefresh solve vue using history route 404 problem, for system file
 func BrowserRefresh(path string) func(c *gin.Context) {
 	return func(c *gin.Context) {
@@ -21,6 +21,7 @@ func TestCommonHandlers(t *testing.T) {
 	r.GET("/health", CheckHealth)
 	r.GET("/ping", Ping)
 	r.GET("/codes", ListCodes)
+	r.GET("/codes/catalog", ListCodesCatalog)
 
 	go func() {
 		_ = r.Run(serverAddr)
@@ -36,6 +37,9 @@ func TestCommonHandlers(t *testing.T) {
 	resp, err = http.Get(requestAddr + "/codes")
 	assert.NoError(t, err)
 	assert.NotNil(t, resp)
+	resp, err = http.Get(requestAddr + "/codes/catalog")
+	assert.NoError(t, err)
+	assert.NotNil(t, resp)
 	time.Sleep(time.Second)
 }
 
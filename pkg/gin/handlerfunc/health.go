@@ -0,0 +1,233 @@
+package handlerfunc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/go-dev-frame/sponge/pkg/utils"
+)
+
+const (
+	statusUp   = "UP"
+	statusDown = "DOWN"
+)
+
+// HealthProbe checks whether a dependency is reachable, e.g. database, redis, message queue.
+type HealthProbe func(ctx context.Context) error
+
+type healthProbe struct {
+	name  string
+	probe HealthProbe
+}
+
+// HealthOption set the options of health check handler.
+type HealthOption func(*healthOptions)
+
+type healthOptions struct {
+	probes   []healthProbe
+	cacheTTL time.Duration
+	timeout  time.Duration
+	verbose  bool
+}
+
+func defaultHealthOptions() *healthOptions {
+	return &healthOptions{
+		cacheTTL: 5 * time.Second,
+		timeout:  3 * time.Second,
+	}
+}
+
+func (o *healthOptions) apply(opts ...HealthOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WithHealthProbe add a dependency probe, the status is DOWN and the http status code is 503 if the probe fails.
+func WithHealthProbe(name string, probe HealthProbe) HealthOption {
+	return func(o *healthOptions) {
+		if probe != nil {
+			o.probes = append(o.probes, healthProbe{name: name, probe: probe})
+		}
+	}
+}
+
+// WithHealthCacheTTL set the duration of caching probe results, requests within the duration
+// do not probe dependencies again, default is 5s, 0 means no cache.
+func WithHealthCacheTTL(d time.Duration) HealthOption {
+	return func(o *healthOptions) {
+		if d >= 0 {
+			o.cacheTTL = d
+		}
+	}
+}
+
+// WithHealthTimeout set the timeout of each probe, default is 3s.
+func WithHealthTimeout(d time.Duration) HealthOption {
+	return func(o *healthOptions) {
+		if d > 0 {
+			o.timeout = d
+		}
+	}
+}
+
+// WithHealthVerbose always list the status of each dependency, otherwise they are listed
+// only if the request has query parameter verbose=true.
+func WithHealthVerbose() HealthOption {
+	return func(o *healthOptions) {
+		o.verbose = true
+	}
+}
+
+// DependencyStatus is the probe result of a dependency.
+type DependencyStatus struct {
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`  // UP or DOWN
+	Latency   string    `json:"latency"` // e.g. 1.2ms
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+type healthChecker struct {
+	o *healthOptions
+
+	mu        sync.Mutex
+	results   []*DependencyStatus
+	checkedAt time.Time
+}
+
+// CheckHealthWithProbes returns the health check handler with dependency probes, the probes are run
+// concurrently and their results are cached. If any probe fails, the status is DOWN and the http status
+// code is 503, so that the load balancer or kubernetes can remove the instance.
+func CheckHealthWithProbes(opts ...HealthOption) gin.HandlerFunc {
+	o := defaultHealthOptions()
+	o.apply(opts...)
+	hc := &healthChecker{o: o}
+
+	return func(c *gin.Context) {
+		results := hc.check(c.Request.Context())
+
+		reply := CheckHealthReply{Status: statusUp, Hostname: utils.GetHostname()}
+		code := http.StatusOK
+		for _, result := range results {
+			if result.Status != statusUp {
+				reply.Status = statusDown
+				code = http.StatusServiceUnavailable
+				break
+			}
+		}
+		if verbose, _ := strconv.ParseBool(c.Query("verbose")); verbose || o.verbose {
+			reply.Dependencies = results
+		}
+
+		c.JSON(code, reply)
+	}
+}
+
+// check returns the cached results if they are not expired, otherwise probes all dependencies,
+// concurrent requests wait for the same probing instead of probing dependencies repeatedly.
+func (hc *healthChecker) check(ctx context.Context) []*DependencyStatus {
+	if len(hc.o.probes) == 0 {
+		return nil
+	}
+
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	if hc.results != nil && time.Since(hc.checkedAt) < hc.o.cacheTTL {
+		return hc.results
+	}
+
+	results := make([]*DependencyStatus, len(hc.o.probes))
+	var wg sync.WaitGroup
+	for i, p := range hc.o.probes {
+		wg.Add(1)
+		go func(i int, p healthProbe) {
+			defer wg.Done()
+			results[i] = runProbe(context.WithoutCancel(ctx), p, hc.o.timeout)
+		}(i, p)
+	}
+	wg.Wait()
+
+	hc.results = results
+	hc.checkedAt = time.Now()
+	return results
+}
+
+func runProbe(ctx context.Context, p healthProbe, timeout time.Duration) (result *DependencyStatus) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	result = &DependencyStatus{Name: p.name, Status: statusUp, CheckedAt: start}
+	defer func() {
+		if e := recover(); e != nil {
+			result.Status = statusDown
+			result.Error = "probe panic"
+		}
+		result.Latency = time.Since(start).String()
+	}()
+
+	if err := p.probe(ctx); err != nil {
+		result.Status = statusDown
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Pinger is the database that can be pinged, e.g. *sql.DB.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// SQLProbe returns the probe of pinging database, e.g. mysql, postgresql.
+func SQLProbe(db Pinger) HealthProbe {
+	return func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	}
+}
+
+// RedisProbe returns the probe of pinging redis.
+func RedisProbe(cli redis.UniversalClient) HealthProbe {
+	return func(ctx context.Context) error {
+		return cli.Ping(ctx).Err()
+	}
+}
+
+// MQProbe returns the probe of message queue connectivity, isConnected reports whether
+// the connection is available, e.g. the CheckConnected method of rabbitmq connection.
+func MQProbe(isConnected func() bool) HealthProbe {
+	return func(ctx context.Context) error {
+		if !isConnected() {
+			return errors.New("not connected")
+		}
+		return nil
+	}
+}
+
+// TCPProbe returns the probe of dialing the addresses, it is healthy if any address is reachable,
+// e.g. the brokers of kafka.
+func TCPProbe(addrs ...string) HealthProbe {
+	return func(ctx context.Context) error {
+		var errs []error
+		for _, addr := range addrs {
+			conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+			if err == nil {
+				_ = conn.Close()
+				return nil
+			}
+			errs = append(errs, err)
+		}
+		if len(errs) == 0 {
+			return errors.New("no address")
+		}
+		return errors.Join(errs...)
+	}
+}
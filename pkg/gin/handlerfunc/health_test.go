@@ -0,0 +1,123 @@
+package handlerfunc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func requestHealth(t *testing.T, r *gin.Engine, path string) (int, *CheckHealthReply) {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	r.ServeHTTP(w, req)
+	reply := &CheckHealthReply{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), reply))
+	return w.Code, reply
+}
+
+func TestCheckHealthWithProbes(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+
+	var redisDown atomic.Bool
+	redisDown.Store(true)
+	r := gin.New()
+	r.GET("/health", CheckHealthWithProbes(
+		WithHealthProbe("database", func(ctx context.Context) error { return nil }),
+		WithHealthProbe("redis", func(ctx context.Context) error {
+			if redisDown.Load() {
+				return errors.New("connection refused")
+			}
+			return nil
+		}),
+		WithHealthCacheTTL(0),
+		WithHealthTimeout(time.Second),
+	))
+
+	code, reply := requestHealth(t, r, "/health")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, statusDown, reply.Status)
+	assert.Empty(t, reply.Dependencies)
+
+	code, reply = requestHealth(t, r, "/health?verbose=true")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Len(t, reply.Dependencies, 2)
+	assert.Equal(t, "database", reply.Dependencies[0].Name)
+	assert.Equal(t, statusUp, reply.Dependencies[0].Status)
+	assert.NotEmpty(t, reply.Dependencies[0].Latency)
+	assert.Equal(t, "redis", reply.Dependencies[1].Name)
+	assert.Equal(t, statusDown, reply.Dependencies[1].Status)
+	assert.Equal(t, "connection refused", reply.Dependencies[1].Error)
+
+	redisDown.Store(false)
+	code, reply = requestHealth(t, r, "/health")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, statusUp, reply.Status)
+}
+
+func TestCheckHealthWithProbesCache(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+
+	var count int32
+	r := gin.New()
+	r.GET("/health", CheckHealthWithProbes(
+		WithHealthProbe("database", func(ctx context.Context) error {
+			atomic.AddInt32(&count, 1)
+			return nil
+		}),
+		WithHealthProbe("panic", func(ctx context.Context) error { panic("foo") }),
+		WithHealthProbe("nil", nil),
+		WithHealthCacheTTL(time.Minute),
+		WithHealthVerbose(),
+	))
+
+	for i := 0; i < 3; i++ {
+		code, reply := requestHealth(t, r, "/health")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Len(t, reply.Dependencies, 2)
+		assert.Equal(t, "probe panic", reply.Dependencies[1].Error)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+
+	// no probes
+	r = gin.New()
+	r.GET("/health", CheckHealthWithProbes())
+	code, reply := requestHealth(t, r, "/health?verbose=true")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, statusUp, reply.Status)
+}
+
+type pinger struct{ err error }
+
+func (p *pinger) PingContext(ctx context.Context) error { return p.err }
+
+func TestProbes(t *testing.T) {
+	ctx := context.Background()
+
+	assert.NoError(t, SQLProbe(&pinger{})(ctx))
+	assert.Error(t, SQLProbe(&pinger{err: errors.New("ping error")})(ctx))
+
+	cli := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", DialTimeout: 100 * time.Millisecond})
+	defer cli.Close()
+	assert.Error(t, RedisProbe(cli)(ctx))
+
+	assert.NoError(t, MQProbe(func() bool { return true })(ctx))
+	assert.Error(t, MQProbe(func() bool { return false })(ctx))
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer l.Close()
+	assert.NoError(t, TCPProbe("127.0.0.1:1", l.Addr().String())(ctx))
+	l.Close()
+	assert.Error(t, TCPProbe(l.Addr().String())(ctx))
+	assert.Error(t, TCPProbe()(ctx))
+}
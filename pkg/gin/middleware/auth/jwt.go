@@ -27,17 +27,24 @@ var (
 	customExpire        time.Duration
 	customIssuer        string
 
-	errOption = errors.New("jwt option is nil, please initialize first, call middleware.InitAuth()")
+	customRefreshExpire   = defaultRefreshExpire
+	customRevocationStore RevocationStore
+
+	errOption          = errors.New("jwt option is nil, please initialize first, call middleware.InitAuth()")
+	errRevocationStore = errors.New("revocation store is nil, please set it by WithInitAuthRevocationStore()")
 )
 
 type initAuthOptions struct {
-	issuer        string
-	signingMethod *SigningMethodHMAC
+	issuer          string
+	signingMethod   *SigningMethodHMAC
+	refreshExpire   time.Duration
+	revocationStore RevocationStore
 }
 
 func defaultInitAuthOptions() *initAuthOptions {
 	return &initAuthOptions{
 		signingMethod: HS256,
+		refreshExpire: defaultRefreshExpire,
 	}
 }
 
@@ -64,6 +71,23 @@ func WithInitAuthIssuer(issuer string) InitAuthOption {
 	}
 }
 
+// WithInitAuthRefreshExpire set the expiration of refresh token, default is 7 days
+func WithInitAuthRefreshExpire(d time.Duration) InitAuthOption {
+	return func(o *initAuthOptions) {
+		if d > 0 {
+			o.refreshExpire = d
+		}
+	}
+}
+
+// WithInitAuthRevocationStore set the revocation store of tokens, the revoked tokens are rejected
+// by the Auth middleware, e.g. NewRedisRevocationStore(redisClient)
+func WithInitAuthRevocationStore(store RevocationStore) InitAuthOption {
+	return func(o *initAuthOptions) {
+		o.revocationStore = store
+	}
+}
+
 // InitAuth initializes jwt options.
 func InitAuth(signingKey []byte, expire time.Duration, opts ...InitAuthOption) {
 	o := defaultInitAuthOptions()
@@ -73,6 +97,8 @@ func InitAuth(signingKey []byte, expire time.Duration, opts ...InitAuthOption) {
 	customExpire = expire
 	customSigningMethod = o.signingMethod
 	customIssuer = o.issuer
+	customRefreshExpire = o.refreshExpire
+	customRevocationStore = o.revocationStore
 }
 
 // GenerateTokenOption set the jwt options.
@@ -205,6 +231,23 @@ func Auth(opts ...AuthOption) gin.HandlerFunc {
 			c.Abort()
 			return
 		}
+		if isRefreshToken(claims) {
+			response.Out(c, responseUnauthorized(o.isReturnErrReason, ErrRefreshTokenUsed.Error()))
+			c.Abort()
+			return
+		}
+		// check whether the token has been revoked, e.g. logout
+		isRevoked, err := IsTokenRevoked(c.Request.Context(), claims)
+		if err != nil {
+			response.Out(c, responseUnauthorized(o.isReturnErrReason, err.Error()))
+			c.Abort()
+			return
+		}
+		if isRevoked {
+			response.Out(c, responseUnauthorized(o.isReturnErrReason, ErrTokenRevoked.Error()))
+			c.Abort()
+			return
+		}
 		// extra verify function
 		if o.extraVerifyFn != nil {
 			if err = o.extraVerifyFn(claims, c); err != nil {
@@ -0,0 +1,198 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/go-dev-frame/sponge/pkg/jwt"
+	"github.com/go-dev-frame/sponge/pkg/krand"
+)
+
+const (
+	// the custom field of refresh token, it is used to distinguish the refresh token from the access token
+	tokenTypeField   = "__token_type"
+	tokenTypeRefresh = "refresh"
+
+	defaultRefreshExpire = 7 * 24 * time.Hour
+)
+
+var (
+	// ErrTokenRevoked the token has been revoked, e.g. logout, or the refresh token has been used
+	ErrTokenRevoked = errors.New("token has been revoked")
+	// ErrNotRefreshToken the token is not a refresh token
+	ErrNotRefreshToken = errors.New("token is not a refresh token")
+	// ErrRefreshTokenUsed the token is a refresh token, it can not be used as an access token
+	ErrRefreshTokenUsed = errors.New("refresh token can not be used as access token")
+)
+
+// TokenPair access token and refresh token, they have the same jwt id
+type TokenPair struct {
+	AccessToken  string    `json:"accessToken"`
+	RefreshToken string    `json:"refreshToken"`
+	JwtID        string    `json:"jwtID"`
+	ExpiresAt    time.Time `json:"expiresAt"` // expiration time of access token
+}
+
+// GenerateTokenPair generates an access token and a refresh token with the given uid and options,
+// the expiration of refresh token is set by WithInitAuthRefreshExpire, default is 7 days.
+func GenerateTokenPair(uid string, opts ...GenerateTokenOption) (*TokenPair, error) {
+	if customSigningMethod == nil || len(customSigningKey) == 0 {
+		panic(errOption)
+	}
+
+	o := &generateTokenOptions{}
+	o.apply(opts...)
+	return generateTokenPair(uid, o.fields)
+}
+
+func generateTokenPair(uid string, fields map[string]interface{}) (*TokenPair, error) {
+	jwtID := krand.NewStringID()
+	now := time.Now()
+
+	accessToken, err := generateToken(uid, fields, jwtID, now, customExpire)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshFields := make(map[string]interface{}, len(fields)+1)
+	for k, v := range fields {
+		refreshFields[k] = v
+	}
+	refreshFields[tokenTypeField] = tokenTypeRefresh
+	refreshToken, err := generateToken(uid, refreshFields, jwtID, now, customRefreshExpire)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		JwtID:        jwtID,
+		ExpiresAt:    now.Add(customExpire),
+	}, nil
+}
+
+func generateToken(uid string, fields map[string]interface{}, jwtID string, now time.Time, expire time.Duration) (string, error) {
+	claimsOpts := []jwt.RegisteredClaimsOption{
+		jwt.WithJwtID(jwtID),
+		jwt.WithIssuedAt(now),
+		jwt.WithDeadline(now.Add(expire)),
+	}
+	if customIssuer != "" {
+		claimsOpts = append(claimsOpts, jwt.WithIssuer(customIssuer))
+	}
+
+	_, token, err := jwt.GenerateToken(uid,
+		jwt.WithGenerateTokenSignKey(customSigningKey),
+		jwt.WithGenerateTokenSignMethod(customSigningMethod),
+		jwt.WithGenerateTokenFields(fields),
+		jwt.WithGenerateTokenClaims(claimsOpts...),
+	)
+	return token, err
+}
+
+// RotateTokens use the refresh token to get a new token pair, the refresh token can only be used once,
+// the old token pair is revoked, if the refresh token is used again (e.g. it is stolen), ErrTokenRevoked
+// is returned, and you need to login again. The revocation store must be set by WithInitAuthRevocationStore,
+// otherwise an error is returned, because a used refresh token could not be rejected.
+func RotateTokens(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	if customRevocationStore == nil {
+		return nil, errRevocationStore
+	}
+
+	claims, err := ParseToken(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	if !isRefreshToken(claims) {
+		return nil, ErrNotRefreshToken
+	}
+
+	ok, err := customRevocationStore.Revoke(ctx, claims.ID, revocationTTL(claims))
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrTokenRevoked
+	}
+
+	fields := make(map[string]interface{}, len(claims.Fields))
+	for k, v := range claims.Fields {
+		if k != tokenTypeField {
+			fields[k] = v
+		}
+	}
+	return generateTokenPair(claims.UID, fields)
+}
+
+// RevokeToken add the jwt id of token (access token or refresh token) to the revocation list,
+// the tokens with the same jwt id are rejected by the Auth middleware, it is used for logout.
+func RevokeToken(ctx context.Context, token string) error {
+	claims, err := ParseToken(token)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil // expired token no longer needs to be revoked
+		}
+		return err
+	}
+	return RevokeClaims(ctx, claims)
+}
+
+// RevokeClaims add the jwt id of claims to the revocation list.
+func RevokeClaims(ctx context.Context, claims *jwt.Claims) error {
+	if customRevocationStore == nil {
+		return errRevocationStore
+	}
+	if claims.ID == "" {
+		return errors.New("jwt id is empty, the token can not be revoked")
+	}
+	ttl := revocationTTL(claims)
+	if ttl <= 0 {
+		return nil
+	}
+	_, err := customRevocationStore.Revoke(ctx, claims.ID, ttl)
+	return err
+}
+
+// Logout revoke the token of current request, it must be used after the Auth middleware.
+func Logout(c *gin.Context) error {
+	claims, ok := GetClaims(c)
+	if !ok {
+		return errors.New("not found claims in context, the Auth middleware is required")
+	}
+	return RevokeClaims(c.Request.Context(), claims)
+}
+
+// IsTokenRevoked check whether the jwt id of claims has been revoked, it always returns false
+// if the revocation store is not set.
+func IsTokenRevoked(ctx context.Context, claims *jwt.Claims) (bool, error) {
+	if customRevocationStore == nil || claims.ID == "" {
+		return false, nil
+	}
+	return customRevocationStore.IsRevoked(ctx, claims.ID)
+}
+
+func isRefreshToken(claims *jwt.Claims) bool {
+	tokenType, _ := claims.GetString(tokenTypeField)
+	return tokenType == tokenTypeRefresh
+}
+
+// the jwt id is revoked until all tokens of the token pair expire
+func revocationTTL(claims *jwt.Claims) time.Duration {
+	var expiresAt time.Time
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+	if claims.IssuedAt != nil {
+		if t := claims.IssuedAt.Add(customRefreshExpire); t.After(expiresAt) {
+			expiresAt = t
+		}
+	}
+	if expiresAt.IsZero() {
+		return customRefreshExpire
+	}
+	return time.Until(expiresAt)
+}
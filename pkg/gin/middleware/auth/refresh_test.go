@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-dev-frame/sponge/pkg/errcode"
+	"github.com/go-dev-frame/sponge/pkg/gin/response"
+)
+
+func newRefreshTestRouter() *gin.Engine {
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	r.GET("/user", Auth(WithReturnErrReason()), func(c *gin.Context) {
+		claims, _ := GetClaims(c)
+		response.Success(c, claims.UID)
+	})
+	r.POST("/logout", Auth(), func(c *gin.Context) {
+		if err := Logout(c); err != nil {
+			response.Error(c, errcode.InternalServerError.RewriteMsg(err.Error()))
+			return
+		}
+		response.Success(c)
+	})
+	return r
+}
+
+func doRequest(r *gin.Engine, method string, token string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, map[string]string{http.MethodGet: "/user", http.MethodPost: "/logout"}[method], nil)
+	req.Header.Set(HeaderAuthorizationKey, "Bearer "+token)
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func isAuthorized(w *httptest.ResponseRecorder) bool {
+	return w.Code == http.StatusOK && !compareMsgFn(w.Body.String())
+}
+
+func testTokenPair(t *testing.T, store RevocationStore) {
+	InitAuth(jwtSignKey, time.Minute, WithInitAuthRefreshExpire(time.Hour), WithInitAuthRevocationStore(store))
+	defer InitAuth(jwtSignKey, time.Minute*10)
+	r := newRefreshTestRouter()
+	ctx := context.Background()
+
+	tokens, err := GenerateTokenPair(uid, WithGenerateTokenFields(fields))
+	require.NoError(t, err)
+	assert.NotEmpty(t, tokens.JwtID)
+	assert.True(t, tokens.ExpiresAt.After(time.Now()))
+
+	// the access token is accepted, the refresh token is rejected
+	assert.True(t, isAuthorized(doRequest(r, http.MethodGet, tokens.AccessToken)))
+	w := doRequest(r, http.MethodGet, tokens.RefreshToken)
+	assert.False(t, isAuthorized(w))
+	assert.Contains(t, w.Body.String(), ErrRefreshTokenUsed.Error())
+
+	// the access token can not be used to rotate tokens
+	_, err = RotateTokens(ctx, tokens.AccessToken)
+	assert.ErrorIs(t, err, ErrNotRefreshToken)
+
+	// rotate tokens, the old token pair is revoked
+	newTokens, err := RotateTokens(ctx, tokens.RefreshToken)
+	require.NoError(t, err)
+	assert.NotEqual(t, tokens.JwtID, newTokens.JwtID)
+	assert.False(t, isAuthorized(doRequest(r, http.MethodGet, tokens.AccessToken)))
+	assert.True(t, isAuthorized(doRequest(r, http.MethodGet, newTokens.AccessToken)))
+	claims, err := ParseToken(newTokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, uid, claims.UID)
+	name, _ := claims.GetString("name")
+	assert.Equal(t, fields["name"], name)
+	assert.False(t, isRefreshToken(claims))
+
+	// the used refresh token can not be used again
+	_, err = RotateTokens(ctx, tokens.RefreshToken)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+
+	// logout, both the access token and refresh token are revoked
+	assert.True(t, isAuthorized(doRequest(r, http.MethodPost, newTokens.AccessToken)))
+	assert.False(t, isAuthorized(doRequest(r, http.MethodGet, newTokens.AccessToken)))
+	_, err = RotateTokens(ctx, newTokens.RefreshToken)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+
+	// revoke token directly
+	tokens, err = GenerateTokenPair(uid)
+	require.NoError(t, err)
+	assert.NoError(t, RevokeToken(ctx, tokens.RefreshToken))
+	assert.False(t, isAuthorized(doRequest(r, http.MethodGet, tokens.AccessToken)))
+	isRevoked, err := IsTokenRevoked(ctx, claims)
+	assert.NoError(t, err)
+	assert.True(t, isRevoked)
+	assert.Error(t, RevokeToken(ctx, "invalid token"))
+}
+
+func TestTokenPair_MemoryStore(t *testing.T) {
+	testTokenPair(t, NewMemoryRevocationStore())
+}
+
+func TestTokenPair_RedisStore(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	testTokenPair(t, NewRedisRevocationStore(client))
+	assert.NotEmpty(t, mr.Keys())
+	for _, key := range mr.Keys() {
+		assert.Contains(t, key, DefaultRevocationKeyPrefix)
+		assert.Greater(t, mr.TTL(key), time.Minute*50)
+	}
+}
+
+func TestTokenPair_WithoutStore(t *testing.T) {
+	InitAuth(jwtSignKey, time.Minute)
+	defer InitAuth(jwtSignKey, time.Minute*10)
+	ctx := context.Background()
+
+	tokens, err := GenerateTokenPair(uid)
+	require.NoError(t, err)
+
+	// the refresh token can not be rotated or revoked without revocation store, otherwise it could be replayed
+	_, err = RotateTokens(ctx, tokens.RefreshToken)
+	assert.Error(t, err)
+	assert.Error(t, RevokeToken(ctx, tokens.AccessToken))
+}
+
+func TestMemoryRevocationStore(t *testing.T) {
+	store := NewMemoryRevocationStore()
+	ctx := context.Background()
+
+	ok, err := store.Revoke(ctx, "id1", time.Millisecond*100)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, _ = store.Revoke(ctx, "id1", time.Millisecond*100)
+	assert.False(t, ok)
+	isRevoked, _ := store.IsRevoked(ctx, "id1")
+	assert.True(t, isRevoked)
+	isRevoked, _ = store.IsRevoked(ctx, "id2")
+	assert.False(t, isRevoked)
+
+	// expired
+	time.Sleep(time.Millisecond * 150)
+	isRevoked, _ = store.IsRevoked(ctx, "id1")
+	assert.False(t, isRevoked)
+	ok, _ = store.Revoke(ctx, "id1", time.Minute)
+	assert.True(t, ok)
+
+	// clean expired items
+	s := store.(*memoryRevocationStore)
+	s.items["id3"] = time.Now().Add(-time.Second)
+	s.lastClean = time.Now().Add(-time.Hour)
+	_, _ = store.Revoke(ctx, "id4", time.Minute)
+	assert.Len(t, s.items, 2)
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RevocationStore the revocation list of tokens, the revoked jwt id is rejected by the Auth middleware.
+type RevocationStore interface {
+	// Revoke add the jwt id to the revocation list until the expiration,
+	// return false if the jwt id has already been revoked.
+	Revoke(ctx context.Context, jwtID string, expiration time.Duration) (bool, error)
+	// IsRevoked check whether the jwt id has been revoked.
+	IsRevoked(ctx context.Context, jwtID string) (bool, error)
+}
+
+// ------------------------------------------------------------------------------------------
+
+type memoryRevocationStore struct {
+	mu        sync.Mutex
+	items     map[string]time.Time // jwt id --> expiration time
+	lastClean time.Time
+}
+
+// NewMemoryRevocationStore create a revocation store in local memory, it is only suitable for a single instance service.
+func NewMemoryRevocationStore() RevocationStore {
+	return &memoryRevocationStore{
+		items:     make(map[string]time.Time),
+		lastClean: time.Now(),
+	}
+}
+
+func (s *memoryRevocationStore) Revoke(_ context.Context, jwtID string, expiration time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastClean) > time.Minute {
+		for id, expiredAt := range s.items {
+			if now.After(expiredAt) {
+				delete(s.items, id)
+			}
+		}
+		s.lastClean = now
+	}
+
+	if expiredAt, ok := s.items[jwtID]; ok && now.Before(expiredAt) {
+		return false, nil
+	}
+	s.items[jwtID] = now.Add(expiration)
+	return true, nil
+}
+
+func (s *memoryRevocationStore) IsRevoked(_ context.Context, jwtID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiredAt, ok := s.items[jwtID]
+	if !ok {
+		return false, nil
+	}
+	if time.Now().After(expiredAt) {
+		delete(s.items, jwtID)
+		return false, nil
+	}
+	return true, nil
+}
+
+// ------------------------------------------------------------------------------------------
+
+// DefaultRevocationKeyPrefix default key prefix of revoked jwt id in redis
+const DefaultRevocationKeyPrefix = "jwt:revoked:"
+
+type redisRevocationStore struct {
+	client    redis.UniversalClient
+	keyPrefix string
+}
+
+// NewRedisRevocationStore create a revocation store in redis, the revoked jwt id is shared by all instances of service,
+// the key of revoked jwt id is keyPrefix+jwtID, it expires automatically, default keyPrefix is "jwt:revoked:".
+func NewRedisRevocationStore(client redis.UniversalClient, keyPrefix ...string) RevocationStore {
+	prefix := DefaultRevocationKeyPrefix
+	if len(keyPrefix) > 0 && keyPrefix[0] != "" {
+		prefix = keyPrefix[0]
+	}
+	return &redisRevocationStore{
+		client:    client,
+		keyPrefix: prefix,
+	}
+}
+
+func (s *redisRevocationStore) Revoke(ctx context.Context, jwtID string, expiration time.Duration) (bool, error) {
+	return s.client.SetNX(ctx, s.keyPrefix+jwtID, 1, expiration).Result()
+}
+
+func (s *redisRevocationStore) IsRevoked(ctx context.Context, jwtID string) (bool, error) {
+	n, err := s.client.Exists(ctx, s.keyPrefix+jwtID).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
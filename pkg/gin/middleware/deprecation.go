@@ -0,0 +1,246 @@
+package middleware
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/go-dev-frame/sponge/pkg/gin/response"
+)
+
+// headers of deprecated routes, see RFC 9745 (Deprecation) and RFC 8594 (Sunset)
+const (
+	HeaderDeprecation = "Deprecation"
+	HeaderSunset      = "Sunset"
+	HeaderLink        = "Link"
+)
+
+var (
+	deprecatedRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "gin",
+			Name:      "deprecated_route_requests_total",
+			Help:      "Total number of requests to deprecated routes, result is served or gone (rejected after sunset).",
+		}, []string{"method", "route", "result"},
+	)
+
+	deprecatedSunset = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "gin",
+			Name:      "deprecated_route_sunset_timestamp_seconds",
+			Help:      "Unix timestamp of the sunset of deprecated routes, 0 means the sunset is not scheduled.",
+		}, []string{"method", "route"},
+	)
+
+	registerDeprecationOnce sync.Once
+)
+
+func registerDeprecationMetrics() {
+	registerDeprecationOnce.Do(func() {
+		prometheus.MustRegister(deprecatedRequests, deprecatedSunset)
+	})
+}
+
+// DeprecatedRoute describes a deprecated route and its retirement plan.
+type DeprecatedRoute struct {
+	Method string `json:"method"` // http method, empty means all methods
+	Path   string `json:"path"`   // full path of the gin route, e.g. /api/v1/user/:id
+
+	DeprecatedAt time.Time `json:"deprecatedAt"` // when the route is deprecated, zero means it is deprecated without a date
+	SunsetAt     time.Time `json:"sunsetAt"`     // when the route will be removed, zero means not scheduled
+	Link         string    `json:"link"`         // documentation of the deprecation, e.g. migration guide
+	Successor    string    `json:"successor"`    // the route or documentation of the new version
+}
+
+func (d DeprecatedRoute) key() string {
+	return strings.ToUpper(d.Method) + " " + d.Path
+}
+
+func (d DeprecatedRoute) methodLabel() string {
+	if d.Method == "" {
+		return "ANY"
+	}
+	return strings.ToUpper(d.Method)
+}
+
+// setHeaders sets the Deprecation, Sunset and Link headers of response.
+func (d DeprecatedRoute) setHeaders(h http.Header) {
+	if d.DeprecatedAt.IsZero() {
+		h.Set(HeaderDeprecation, "true")
+	} else {
+		h.Set(HeaderDeprecation, "@"+strconv.FormatInt(d.DeprecatedAt.Unix(), 10))
+	}
+	if !d.SunsetAt.IsZero() {
+		h.Set(HeaderSunset, d.SunsetAt.UTC().Format(http.TimeFormat))
+	}
+	if d.Link != "" {
+		h.Add(HeaderLink, `<`+d.Link+`>; rel="deprecation"; type="text/html"`)
+	}
+	if d.Successor != "" {
+		h.Add(HeaderLink, `<`+d.Successor+`>; rel="successor-version"`)
+	}
+}
+
+// DeprecationRegistry holds the deprecated routes, routes can be added or removed at runtime,
+// e.g. loaded from the configuration.
+type DeprecationRegistry struct {
+	mu     sync.RWMutex
+	routes map[string]DeprecatedRoute
+}
+
+// NewDeprecationRegistry creates a registry of deprecated routes.
+func NewDeprecationRegistry(routes ...DeprecatedRoute) *DeprecationRegistry {
+	r := &DeprecationRegistry{routes: make(map[string]DeprecatedRoute)}
+	r.Add(routes...)
+	return r
+}
+
+// Add marks the routes as deprecated, the route with the same method and path is replaced.
+func (r *DeprecationRegistry) Add(routes ...DeprecatedRoute) {
+	registerDeprecationMetrics()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, d := range routes {
+		r.routes[d.key()] = d
+		var sunset float64
+		if !d.SunsetAt.IsZero() {
+			sunset = float64(d.SunsetAt.Unix())
+		}
+		deprecatedSunset.WithLabelValues(d.methodLabel(), d.Path).Set(sunset)
+	}
+}
+
+// Remove unmarks the deprecated route, method is empty means the route of all methods.
+func (r *DeprecationRegistry) Remove(method string, path string) {
+	d := DeprecatedRoute{Method: method, Path: path}
+	r.mu.Lock()
+	delete(r.routes, d.key())
+	r.mu.Unlock()
+	deprecatedSunset.DeleteLabelValues(d.methodLabel(), d.Path)
+}
+
+// Get returns the deprecated route matching the method and full path, the route declared with
+// the method takes precedence over the route of all methods.
+func (r *DeprecationRegistry) Get(method string, path string) (DeprecatedRoute, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if d, ok := r.routes[strings.ToUpper(method)+" "+path]; ok {
+		return d, true
+	}
+	d, ok := r.routes[" "+path]
+	return d, ok
+}
+
+// List returns all deprecated routes sorted by path and method, e.g. for an admin api.
+func (r *DeprecationRegistry) List() []DeprecatedRoute {
+	r.mu.RLock()
+	routes := make([]DeprecatedRoute, 0, len(r.routes))
+	for _, d := range r.routes {
+		routes = append(routes, d)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+// ------------------------------------------------------------------------------------------
+
+// DeprecationOption set the deprecation options.
+type DeprecationOption func(*deprecationOptions)
+
+type deprecationOptions struct {
+	goneAfterSunset bool
+	onRequest       func(c *gin.Context, route DeprecatedRoute)
+}
+
+func defaultDeprecationOptions() *deprecationOptions {
+	return &deprecationOptions{}
+}
+
+func (o *deprecationOptions) apply(opts ...DeprecationOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WithDeprecationGoneAfterSunset reject the requests of the route with 410 after its sunset time.
+func WithDeprecationGoneAfterSunset() DeprecationOption {
+	return func(o *deprecationOptions) {
+		o.goneAfterSunset = true
+	}
+}
+
+// WithDeprecationHook set the hook called on each request of deprecated routes, e.g. log the
+// client to notify it to migrate.
+func WithDeprecationHook(fn func(c *gin.Context, route DeprecatedRoute)) DeprecationOption {
+	return func(o *deprecationOptions) {
+		o.onRequest = fn
+	}
+}
+
+// Deprecation adds the Deprecation, Sunset and Link headers to the responses of the routes in the
+// registry, and counts the requests of each deprecated route in the metric
+// gin_deprecated_route_requests_total, so that the usage can be observed before retiring the route.
+// The route is matched by the full path of gin (c.FullPath()), use it as a global middleware.
+func Deprecation(registry *DeprecationRegistry, opts ...DeprecationOption) gin.HandlerFunc {
+	o := defaultDeprecationOptions()
+	o.apply(opts...)
+
+	return func(c *gin.Context) {
+		d, ok := registry.Get(c.Request.Method, c.FullPath())
+		if !ok {
+			c.Next()
+			return
+		}
+		handleDeprecatedRoute(c, d, o)
+	}
+}
+
+// Deprecated marks a single route as deprecated, use it as the middleware of the route, e.g.
+// r.GET("/api/v1/user/:id", middleware.Deprecated(route), handler).
+func Deprecated(route DeprecatedRoute, opts ...DeprecationOption) gin.HandlerFunc {
+	o := defaultDeprecationOptions()
+	o.apply(opts...)
+	registerDeprecationMetrics()
+	if route.Path != "" && !route.SunsetAt.IsZero() {
+		deprecatedSunset.WithLabelValues(route.methodLabel(), route.Path).Set(float64(route.SunsetAt.Unix()))
+	}
+
+	return func(c *gin.Context) {
+		d := route
+		if d.Path == "" {
+			d.Path = c.FullPath()
+		}
+		handleDeprecatedRoute(c, d, o)
+	}
+}
+
+func handleDeprecatedRoute(c *gin.Context, d DeprecatedRoute, o *deprecationOptions) {
+	d.setHeaders(c.Writer.Header())
+	if o.onRequest != nil {
+		o.onRequest(c, d)
+	}
+
+	if o.goneAfterSunset && !d.SunsetAt.IsZero() && time.Now().After(d.SunsetAt) {
+		deprecatedRequests.WithLabelValues(d.methodLabel(), d.Path, "gone").Inc()
+		response.Output(c, http.StatusGone, "the api has been retired")
+		c.Abort()
+		return
+	}
+
+	deprecatedRequests.WithLabelValues(d.methodLabel(), d.Path, "served").Inc()
+	c.Next()
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-dev-frame/sponge/pkg/gin/response"
+)
+
+func doDeprecationRequest(r *gin.Engine, method string, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, nil)
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestDeprecation(t *testing.T) {
+	deprecatedAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	sunsetAt := time.Date(2099, 6, 30, 0, 0, 0, 0, time.UTC)
+	registry := NewDeprecationRegistry(
+		DeprecatedRoute{
+			Method:       http.MethodGet,
+			Path:         "/api/v1/user/:id",
+			DeprecatedAt: deprecatedAt,
+			SunsetAt:     sunsetAt,
+			Link:         "https://example.com/migrate",
+			Successor:    "/api/v2/user/:id",
+		},
+		DeprecatedRoute{Path: "/api/v1/order", SunsetAt: time.Now().Add(-time.Hour)},
+	)
+
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	var hooked []string
+	r.Use(Deprecation(registry, WithDeprecationGoneAfterSunset(), WithDeprecationHook(func(c *gin.Context, route DeprecatedRoute) {
+		hooked = append(hooked, route.Path)
+	})))
+	handler := func(c *gin.Context) { response.Success(c) }
+	r.GET("/api/v1/user/:id", handler)
+	r.DELETE("/api/v1/user/:id", handler)
+	r.POST("/api/v1/order", handler)
+	r.GET("/api/v2/user/:id", handler)
+
+	w := doDeprecationRequest(r, http.MethodGet, "/api/v1/user/1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "@1735689600", w.Header().Get(HeaderDeprecation))
+	assert.Equal(t, "Tue, 30 Jun 2099 00:00:00 GMT", w.Header().Get(HeaderSunset))
+	assert.Equal(t, []string{
+		`<https://example.com/migrate>; rel="deprecation"; type="text/html"`,
+		`</api/v2/user/:id>; rel="successor-version"`,
+	}, w.Header().Values(HeaderLink))
+	assert.Equal(t, float64(1), testutil.ToFloat64(deprecatedRequests.WithLabelValues("GET", "/api/v1/user/:id", "served")))
+	assert.Equal(t, float64(sunsetAt.Unix()), testutil.ToFloat64(deprecatedSunset.WithLabelValues("GET", "/api/v1/user/:id")))
+
+	// other methods and routes are not deprecated
+	w = doDeprecationRequest(r, http.MethodDelete, "/api/v1/user/1")
+	assert.Empty(t, w.Header().Get(HeaderDeprecation))
+	w = doDeprecationRequest(r, http.MethodGet, "/api/v2/user/1")
+	assert.Empty(t, w.Header().Get(HeaderDeprecation))
+
+	// rejected after sunset
+	w = doDeprecationRequest(r, http.MethodPost, "/api/v1/order")
+	assert.Equal(t, http.StatusGone, w.Code)
+	assert.Equal(t, "true", w.Header().Get(HeaderDeprecation))
+	assert.Equal(t, float64(1), testutil.ToFloat64(deprecatedRequests.WithLabelValues("ANY", "/api/v1/order", "gone")))
+	assert.Equal(t, []string{"/api/v1/user/:id", "/api/v1/order"}, hooked)
+
+	// routes can be changed at runtime
+	assert.Len(t, registry.List(), 2)
+	registry.Remove("", "/api/v1/order")
+	w = doDeprecationRequest(r, http.MethodPost, "/api/v1/order")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get(HeaderDeprecation))
+	assert.Len(t, registry.List(), 1)
+}
+
+func TestDeprecated(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	r.GET("/api/v1/ping", Deprecated(DeprecatedRoute{Successor: "/api/v2/ping"}), func(c *gin.Context) {
+		response.Success(c)
+	})
+
+	w := doDeprecationRequest(r, http.MethodGet, "/api/v1/ping")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "true", w.Header().Get(HeaderDeprecation))
+	assert.Empty(t, w.Header().Get(HeaderSunset))
+	assert.Equal(t, `</api/v2/ping>; rel="successor-version"`, w.Header().Get(HeaderLink))
+	assert.Equal(t, float64(1), testutil.ToFloat64(deprecatedRequests.WithLabelValues("ANY", "/api/v1/ping", "served")))
+}
@@ -180,20 +180,7 @@ func Logging(opts ...Option) gin.HandlerFunc {
 			bodyField = zap.ByteString("body", getRequestBody(&buf, o.maxLength))
 		}
 
-		reqID := ""
-		if o.requestIDFrom == 1 {
-			if v, isExist := c.Get(ContextRequestIDKey); isExist {
-				if requestID, ok := v.(string); ok {
-					reqID = requestID
-				}
-			}
-		} else if o.requestIDFrom == 2 {
-			reqID = c.Request.Header.Get(HeaderXRequestIDKey)
-		}
-		reqIDField := zap.Skip()
-		if reqID != "" {
-			reqIDField = zap.String(ContextRequestIDKey, reqID)
-		}
+		reqIDField, traceparentField := o.requestIDFields(c)
 
 		// print input information before processing
 		o.log.Info("<<<<",
@@ -202,6 +189,7 @@ func Logging(opts ...Option) gin.HandlerFunc {
 			sizeField,
 			bodyField,
 			reqIDField,
+			traceparentField,
 		)
 
 		c.Request.Body = io.NopCloser(&buf)
@@ -223,6 +211,7 @@ func Logging(opts ...Option) gin.HandlerFunc {
 			zap.Int("size", newWriter.body.Len()),
 			zap.ByteString("body", getResponseBody(newWriter.body, o.maxLength)),
 			reqIDField,
+			traceparentField,
 		}
 		if printErrorBySpecifiedCodes[httpCode] {
 			o.log.WithOptions(zap.AddStacktrace(zap.PanicLevel)).Error(">>>>", fields...)
@@ -232,6 +221,30 @@ func Logging(opts ...Option) gin.HandlerFunc {
 	}
 }
 
+// the fields of request id and W3C traceparent, they are used to correlate logs across services
+func (o *options) requestIDFields(c *gin.Context) (zap.Field, zap.Field) {
+	reqID, traceparent := "", ""
+	if o.requestIDFrom == 1 {
+		if v, isExist := c.Get(ContextRequestIDKey); isExist {
+			if requestID, ok := v.(string); ok {
+				reqID = requestID
+			}
+		}
+		traceparent = c.GetString(ContextTraceparentKey)
+	} else if o.requestIDFrom == 2 {
+		reqID = c.Request.Header.Get(HeaderXRequestIDKey)
+		traceparent = c.Request.Header.Get(HeaderTraceparentKey)
+	}
+	reqIDField, traceparentField := zap.Skip(), zap.Skip()
+	if reqID != "" {
+		reqIDField = zap.String(ContextRequestIDKey, reqID)
+	}
+	if traceparent != "" {
+		traceparentField = zap.String(ContextTraceparentKey, traceparent)
+	}
+	return reqIDField, traceparentField
+}
+
 // SimpleLog print response info
 func SimpleLog(opts ...Option) gin.HandlerFunc {
 	o := defaultOptions()
@@ -246,20 +259,7 @@ func SimpleLog(opts ...Option) gin.HandlerFunc {
 			return
 		}
 
-		reqID := ""
-		if o.requestIDFrom == 1 {
-			if v, isExist := c.Get(ContextRequestIDKey); isExist {
-				if requestID, ok := v.(string); ok {
-					reqID = requestID
-				}
-			}
-		} else if o.requestIDFrom == 2 {
-			reqID = c.Request.Header.Get(HeaderXRequestIDKey)
-		}
-		reqIDField := zap.Skip()
-		if reqID != "" {
-			reqIDField = zap.String(ContextRequestIDKey, reqID)
-		}
+		reqIDField, traceparentField := o.requestIDFields(c)
 
 		// processing requests
 		c.Next()
@@ -273,6 +273,7 @@ func SimpleLog(opts ...Option) gin.HandlerFunc {
 			zap.Int64("time_us", time.Since(start).Microseconds()),
 			zap.Int("size", c.Writer.Size()),
 			reqIDField,
+			traceparentField,
 		}
 		if printErrorBySpecifiedCodes[httpCode] {
 			o.log.WithOptions(zap.AddStacktrace(zap.PanicLevel)).Error("Gin response", fields...)
@@ -1,8 +1,10 @@
 // Package metrics is gin metrics library, collect five metrics, "uptime", "http_request_count_total",
-// "http_request_duration_seconds", "http_request_size_bytes", "http_response_size_bytes".
+// "http_request_duration_seconds", "http_request_size_bytes", "http_response_size_bytes",
+// and RED (rate, errors, duration) metrics per route, latencies are attached with trace ID exemplars.
 package metrics
 
 import (
+	"context"
 	"net/http"
 	"strconv"
 	"time"
@@ -10,6 +12,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var (
@@ -56,11 +59,41 @@ var (
 			Help:      "HTTP response sizes in bytes.",
 		}, labels,
 	)
+
+	// RED metrics per route, the route is the path template, e.g. /api/v1/user/:id
+	routeLabels = []string{"route", "method"}
+
+	routeReqCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_route_requests_total",
+			Help:      "Total number of HTTP requests per route.",
+		}, routeLabels,
+	)
+
+	routeErrCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_route_errors_total",
+			Help:      "Total number of failed HTTP requests per route.",
+		}, routeLabels,
+	)
+
+	routeDuration *prometheus.HistogramVec
 )
 
 // init registers the prometheus metrics
-func initPrometheus() {
-	prometheus.MustRegister(uptime, reqCount, reqDuration, reqSizeBytes, respSizeBytes)
+func initPrometheus(o *options) {
+	routeDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_route_duration_seconds",
+			Help:      "HTTP request latencies in seconds per route.",
+			Buckets:   o.durationBuckets,
+		}, routeLabels,
+	)
+	prometheus.MustRegister(uptime, reqCount, reqDuration, reqSizeBytes, respSizeBytes,
+		routeReqCount, routeErrCount, routeDuration)
 	go recordUptime()
 }
 
@@ -96,12 +129,41 @@ func calcRequestSize(r *http.Request) float64 {
 	return float64(size)
 }
 
+// traceExemplar returns the trace ID of the sampled span in ctx as exemplar, nil if there is no sampled span.
+func traceExemplar(ctx context.Context) prometheus.Labels {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() || !sc.IsSampled() {
+		return nil
+	}
+	return prometheus.Labels{"trace_id": sc.TraceID().String()}
+}
+
+func observe(o prometheus.Observer, v float64, exemplar prometheus.Labels) {
+	if eo, ok := o.(prometheus.ExemplarObserver); ok && exemplar != nil {
+		eo.ObserveWithExemplar(v, exemplar)
+		return
+	}
+	o.Observe(v)
+}
+
+func inc(c prometheus.Counter, exemplar prometheus.Labels) {
+	if ea, ok := c.(prometheus.ExemplarAdder); ok && exemplar != nil {
+		ea.AddWithExemplar(1, exemplar)
+		return
+	}
+	c.Inc()
+}
+
 // ------------------------------------------------------------------------------------------
 
-// metricsHandler wrappers the standard http.Handler to gin.HandlerFunc
+// metricsHandler wrappers the standard http.Handler to gin.HandlerFunc,
+// exemplars are exposed in the OpenMetrics format.
 func metricsHandler() gin.HandlerFunc {
+	handler := promhttp.InstrumentMetricHandler(
+		prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{EnableOpenMetrics: true}),
+	)
 	return func(c *gin.Context) {
-		handler := promhttp.Handler()
 		handler.ServeHTTP(c.Writer, c.Request)
 	}
 }
@@ -112,7 +174,7 @@ func Metrics(r *gin.Engine, opts ...Option) gin.HandlerFunc {
 	o.apply(opts...)
 
 	// init prometheus
-	initPrometheus()
+	initPrometheus(o)
 
 	r.GET(o.metricsPath, metricsHandler())
 
@@ -133,10 +195,25 @@ func Metrics(r *gin.Engine, opts ...Option) gin.HandlerFunc {
 			respSize = 0
 		}
 
+		duration := time.Since(start).Seconds()
+		exemplar := traceExemplar(c.Request.Context())
+
 		lvs := []string{strconv.Itoa(c.Writer.Status()), c.Request.URL.Path, c.Request.Method}
 		reqCount.WithLabelValues(lvs...).Inc()
-		reqDuration.WithLabelValues(lvs...).Observe(time.Since(start).Seconds())
+		observe(reqDuration.WithLabelValues(lvs...), duration, exemplar)
 		reqSizeBytes.WithLabelValues(lvs...).Observe(calcRequestSize(c.Request))
 		respSizeBytes.WithLabelValues(lvs...).Observe(float64(respSize))
+
+		// requests not matching any route are grouped, to avoid high cardinality
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		routeLvs := []string{route, c.Request.Method}
+		routeReqCount.WithLabelValues(routeLvs...).Inc()
+		if o.isError(c.Writer.Status()) {
+			inc(routeErrCount.WithLabelValues(routeLvs...), exemplar)
+		}
+		observe(routeDuration.WithLabelValues(routeLvs...), duration, exemplar)
 	}
 }
@@ -1,12 +1,16 @@
 package metrics
 
 import (
+	"context"
+	"io"
 	"net/http"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/go-dev-frame/sponge/pkg/gin/handlerfunc"
 	"github.com/go-dev-frame/sponge/pkg/utils"
@@ -22,11 +26,16 @@ func TestMetrics(t *testing.T) {
 		WithIgnoreStatusCodes(http.StatusNotFound),
 		WithIgnoreRequestPaths("/hello-ignore"),
 		WithIgnoreRequestMethods(http.MethodDelete),
+		WithDurationBuckets(0.01, 0.1, 1),
+		WithErrorStatus(func(statusCode int) bool { return statusCode >= 500 }),
 	))
 	r.GET("ping", handlerfunc.Ping)
 	r.GET("/hello", func(c *gin.Context) {
 		c.String(200, "[get] hello")
 	})
+	r.GET("/user/:id", func(c *gin.Context) {
+		c.String(500, "internal error")
+	})
 
 	go func() {
 		err := r.Run(serverAddr)
@@ -42,7 +51,37 @@ func TestMetrics(t *testing.T) {
 	resp, err = http.Get(requestAddr + "/hello")
 	assert.NoError(t, err)
 	assert.NotNil(t, resp)
-	resp, err = http.Get(requestAddr + "/metrics")
+	resp, err = http.Get(requestAddr + "/user/1")
 	assert.NoError(t, err)
 	assert.NotNil(t, resp)
+
+	req, _ := http.NewRequest(http.MethodGet, requestAddr+"/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	data, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	assert.Contains(t, string(data), `gin_http_route_requests_total{method="GET",route="/user/:id"} 1`)
+	assert.Contains(t, string(data), `gin_http_route_errors_total{method="GET",route="/user/:id"} 1`)
+	assert.Contains(t, string(data), `gin_http_route_duration_seconds_bucket{method="GET",route="/hello",le="0.01"}`)
+}
+
+func TestTraceExemplar(t *testing.T) {
+	assert.Nil(t, traceExemplar(context.Background()))
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{1},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+	exemplar := traceExemplar(ctx)
+	assert.Equal(t, sc.TraceID().String(), exemplar["trace_id"])
+
+	h := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "test_duration_seconds"})
+	observe(h, 0.1, exemplar)
+	observe(h, 0.2, nil)
+	c := prometheus.NewCounter(prometheus.CounterOpts{Name: "test_errors_total"})
+	inc(c, exemplar)
+	inc(c, nil)
 }
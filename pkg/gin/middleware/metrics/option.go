@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"net/http"
 	"strings"
 )
 
@@ -12,8 +13,13 @@ type options struct {
 	ignoreStatusCodes    map[int]struct{}
 	ignoreRequestPaths   map[string]struct{}
 	ignoreRequestMethods map[string]struct{}
+	durationBuckets      []float64
+	isError              func(statusCode int) bool
 }
 
+// DefaultDurationBuckets are the buckets of the route latency histogram, in seconds, from 1ms to 10s.
+var DefaultDurationBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
 // defaultOptions default value
 func defaultOptions() *options {
 	return &options{
@@ -21,6 +27,10 @@ func defaultOptions() *options {
 		ignoreStatusCodes:    nil,
 		ignoreRequestPaths:   nil,
 		ignoreRequestMethods: nil,
+		durationBuckets:      DefaultDurationBuckets,
+		isError: func(statusCode int) bool {
+			return statusCode >= http.StatusInternalServerError
+		},
 	}
 }
 
@@ -70,6 +80,24 @@ func WithIgnoreRequestMethods(methods ...string) Option {
 	}
 }
 
+// WithDurationBuckets set the buckets of the route latency histogram, in seconds, default is DefaultDurationBuckets
+func WithDurationBuckets(buckets ...float64) Option {
+	return func(o *options) {
+		if len(buckets) > 0 {
+			o.durationBuckets = buckets
+		}
+	}
+}
+
+// WithErrorStatus set the function to decide whether a response status code is an error, default is status code >= 500
+func WithErrorStatus(fn func(statusCode int) bool) Option {
+	return func(o *options) {
+		if fn != nil {
+			o.isError = fn
+		}
+	}
+}
+
 func (o *options) isIgnoreCodeStatus(statusCode int) bool {
 	if o.ignoreStatusCodes == nil {
 		return false
@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/go-dev-frame/sponge/pkg/encoding"
+	"github.com/go-dev-frame/sponge/pkg/gin/response"
+)
+
+// content types supported by Negotiate
+const (
+	ContentTypeJSON    = "application/json"
+	ContentTypeMsgPack = "application/msgpack"
+	ContentTypeCBOR    = "application/cbor"
+	ContentTypeAvro    = "application/avro"
+)
+
+// the default maximum size of request body that is converted to json
+const defaultNegotiateMaxBodySize = 10 << 20
+
+// NegotiateOption set the negotiate options.
+type NegotiateOption func(*negotiateOptions)
+
+type negotiateOptions struct {
+	encodings    map[string]encoding.Encoding // content type --> encoding
+	responseOnly map[string]bool              // the content types that can not be used to decode request body
+	maxBodySize  int64                        // the maximum size of request body that is converted to json
+}
+
+func defaultNegotiateOptions() *negotiateOptions {
+	return &negotiateOptions{
+		encodings: map[string]encoding.Encoding{
+			ContentTypeMsgPack:      encoding.MsgPackJSONTagEncoding{},
+			"application/x-msgpack": encoding.MsgPackJSONTagEncoding{},
+			ContentTypeCBOR:         encoding.CBOREncoding{},
+			ContentTypeAvro:         encoding.AvroEncoding{},
+			"avro/binary":           encoding.AvroEncoding{},
+		},
+		// avro data can not be decoded without the type of request
+		responseOnly: map[string]bool{
+			ContentTypeAvro: true,
+			"avro/binary":   true,
+		},
+		maxBodySize: defaultNegotiateMaxBodySize,
+	}
+}
+
+func (o *negotiateOptions) apply(opts ...NegotiateOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WithNegotiateEncoding add or replace the encoding of content type, the encoding must support decoding
+// into interface{} if it is used for request body, e.g. WithNegotiateEncoding("application/x-gob", myEncoding)
+func WithNegotiateEncoding(contentType string, e encoding.Encoding) NegotiateOption {
+	return func(o *negotiateOptions) {
+		if contentType == "" || contentType == ContentTypeJSON || e == nil {
+			return
+		}
+		o.encodings[contentType] = e
+		delete(o.responseOnly, contentType)
+	}
+}
+
+// WithNegotiateMaxBodySize set the maximum size of request body of msgpack or cbor in bytes, the larger
+// request body is rejected with 413, default is 10MB.
+func WithNegotiateMaxBodySize(size int64) NegotiateOption {
+	return func(o *negotiateOptions) {
+		if size > 0 {
+			o.maxBodySize = size
+		}
+	}
+}
+
+// Negotiate negotiate the encoding of request and response by the header Content-Type and Accept,
+// the default supported content types are application/json, application/msgpack (application/x-msgpack),
+// application/cbor and application/avro (avro/binary, response only).
+//
+// request: the body of msgpack or cbor is converted to json, the handler still binds it by c.ShouldBindJSON,
+// the avro request body is rejected with 415, because the schema of request is unknown, the request body larger
+// than the maximum size (WithNegotiateMaxBodySize) is rejected with 413.
+//
+// response: the response of pkg/gin/response (e.g. response.Success, response.Error) is encoded by the
+// encoding of Accept directly, json is used if Accept is not matched, if Accept is empty or */*, the encoding
+// of request Content-Type is used. Other responses such as c.JSON are not changed.
+func Negotiate(opts ...NegotiateOption) gin.HandlerFunc {
+	o := defaultNegotiateOptions()
+	o.apply(opts...)
+
+	// json is preferred when Accept matches multiple content types, e.g. application/*
+	offered := make([]string, 0, len(o.encodings)+1)
+	for contentType := range o.encodings {
+		offered = append(offered, contentType)
+	}
+	sort.Strings(offered)
+	offered = append([]string{ContentTypeJSON}, offered...)
+
+	return func(c *gin.Context) {
+		contentType := c.ContentType()
+		if e, ok := o.encodings[contentType]; ok && c.Request.Body != nil && c.Request.Body != http.NoBody {
+			if o.responseOnly[contentType] {
+				response.Output(c, http.StatusUnsupportedMediaType)
+				c.Abort()
+				return
+			}
+			if err := transcodeRequestBody(c, e, o.maxBodySize); err != nil {
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					response.Output(c, http.StatusRequestEntityTooLarge)
+					c.Abort()
+					return
+				}
+				response.Output(c, http.StatusBadRequest, err.Error())
+				c.Abort()
+				return
+			}
+		}
+
+		var responseContentType string
+		if accept := c.GetHeader("Accept"); accept == "" || accept == "*/*" {
+			responseContentType = contentType
+		} else {
+			responseContentType = c.NegotiateFormat(offered...)
+		}
+		if e, ok := o.encodings[responseContentType]; ok {
+			response.SetEncoding(c, responseContentType, e)
+		}
+		c.Writer.Header().Add("Vary", "Accept")
+
+		c.Next()
+	}
+}
+
+// convert the request body to json, so that the handler can bind it as json
+func transcodeRequestBody(c *gin.Context, e encoding.Encoding, maxBodySize int64) error {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBodySize))
+	_ = c.Request.Body.Close()
+	if err != nil {
+		return err
+	}
+
+	var v interface{}
+	if len(body) > 0 {
+		if err = e.Unmarshal(body, &v); err != nil {
+			return err
+		}
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	c.Request.Body = io.NopCloser(bytes.NewReader(data))
+	c.Request.ContentLength = int64(len(data))
+	c.Request.Header.Set("Content-Type", ContentTypeJSON)
+	return nil
+}
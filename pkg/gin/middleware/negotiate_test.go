@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-dev-frame/sponge/pkg/encoding"
+	"github.com/go-dev-frame/sponge/pkg/gin/response"
+)
+
+type negotiateUser struct {
+	ID   uint64 `json:"id"`
+	Name string `json:"name"`
+}
+
+type negotiateResult struct {
+	Code int            `json:"code"`
+	Msg  string         `json:"msg"`
+	Data *negotiateUser `json:"data"`
+}
+
+func newNegotiateRouter(opts ...NegotiateOption) *gin.Engine {
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	r.Use(Negotiate(opts...))
+	r.POST("/user", func(c *gin.Context) {
+		form := &negotiateUser{}
+		if err := c.ShouldBindJSON(form); err != nil {
+			response.Output(c, http.StatusBadRequest)
+			return
+		}
+		response.Success(c, form)
+	})
+	r.GET("/raw", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"name": "raw"})
+	})
+	return r
+}
+
+func doNegotiateRequest(r http.Handler, method string, path string, contentType string, accept string, body []byte) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, bytes.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestNegotiate(t *testing.T) {
+	r := newNegotiateRouter()
+	user := &negotiateUser{ID: 1, Name: "foo"}
+
+	cases := []struct {
+		name        string
+		contentType string
+		accept      string
+		reqEncoding encoding.Encoding
+		respType    string
+		respEncode  encoding.Encoding
+	}{
+		{"json", ContentTypeJSON, "", encoding.JSONEncoding{}, ContentTypeJSON, encoding.JSONEncoding{}},
+		{"msgpack", ContentTypeMsgPack, "", encoding.MsgPackJSONTagEncoding{}, ContentTypeMsgPack, encoding.MsgPackJSONTagEncoding{}},
+		{"x-msgpack", "application/x-msgpack", "*/*", encoding.MsgPackJSONTagEncoding{}, "application/x-msgpack", encoding.MsgPackJSONTagEncoding{}},
+		{"cbor", ContentTypeCBOR, ContentTypeCBOR, encoding.CBOREncoding{}, ContentTypeCBOR, encoding.CBOREncoding{}},
+		{"json to avro", ContentTypeJSON, ContentTypeAvro, encoding.JSONEncoding{}, ContentTypeAvro, encoding.AvroEncoding{}},
+		{"cbor to msgpack", ContentTypeCBOR, "application/msgpack;q=0.9, application/json;q=0.8", encoding.CBOREncoding{}, ContentTypeMsgPack, encoding.MsgPackJSONTagEncoding{}},
+		{"msgpack to json", ContentTypeMsgPack, "application/*", encoding.MsgPackJSONTagEncoding{}, ContentTypeJSON, encoding.JSONEncoding{}},
+		{"not matched", ContentTypeCBOR, "text/html", encoding.CBOREncoding{}, ContentTypeJSON, encoding.JSONEncoding{}},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := tt.reqEncoding.Marshal(user)
+			require.NoError(t, err)
+			w := doNegotiateRequest(r, http.MethodPost, "/user", tt.contentType, tt.accept, body)
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Contains(t, w.Header().Get("Content-Type"), tt.respType)
+			assert.Equal(t, "Accept", w.Header().Get("Vary"))
+
+			result := &negotiateResult{}
+			err = tt.respEncode.Unmarshal(w.Body.Bytes(), result)
+			require.NoError(t, err)
+			assert.Equal(t, 0, result.Code)
+			assert.Equal(t, user, result.Data)
+		})
+	}
+
+	// the response of c.JSON is not changed
+	w := doNegotiateRequest(r, http.MethodGet, "/raw", "", ContentTypeMsgPack, nil)
+	assert.Contains(t, w.Header().Get("Content-Type"), ContentTypeJSON)
+
+	// avro request body is not supported
+	body, _ := encoding.AvroEncoding{}.Marshal(user)
+	w = doNegotiateRequest(r, http.MethodPost, "/user", ContentTypeAvro, "", body)
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+
+	// invalid request body
+	w = doNegotiateRequest(r, http.MethodPost, "/user", ContentTypeCBOR, "", []byte{0xff})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestWithNegotiateEncoding(t *testing.T) {
+	r := newNegotiateRouter(
+		WithNegotiateEncoding("application/x-gob", encoding.GobEncoding{}),
+		WithNegotiateEncoding(ContentTypeAvro, encoding.MsgPackJSONTagEncoding{}),
+		WithNegotiateEncoding("", nil),
+	)
+	user := &negotiateUser{ID: 1, Name: "foo"}
+
+	// the avro content type is replaced by msgpack, it can be used for request body
+	body, _ := encoding.MsgPackJSONTagEncoding{}.Marshal(user)
+	w := doNegotiateRequest(r, http.MethodPost, "/user", ContentTypeAvro, "", body)
+	assert.Equal(t, http.StatusOK, w.Code)
+	result := &negotiateResult{}
+	err := encoding.MsgPackJSONTagEncoding{}.Unmarshal(w.Body.Bytes(), result)
+	assert.NoError(t, err)
+	assert.Equal(t, user, result.Data)
+}
+
+func TestWithNegotiateMaxBodySize(t *testing.T) {
+	user := &negotiateUser{ID: 1, Name: "foo"}
+	body, err := encoding.MsgPackJSONTagEncoding{}.Marshal(user)
+	require.NoError(t, err)
+
+	r := newNegotiateRouter(WithNegotiateMaxBodySize(int64(len(body))))
+	w := doNegotiateRequest(r, http.MethodPost, "/user", ContentTypeMsgPack, "", body)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// the request body larger than the maximum size
+	r = newNegotiateRouter(WithNegotiateMaxBodySize(int64(len(body)-1)), WithNegotiateMaxBodySize(0))
+	w = doNegotiateRequest(r, http.MethodPost, "/user", ContentTypeMsgPack, "", body)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	w = doNegotiateRequest(r, http.MethodPost, "/user", ContentTypeJSON, "", []byte(`{"id":1,"name":"foo"}`))
+	assert.Equal(t, http.StatusOK, w.Code) // json request body is not converted
+
+	// default maximum size
+	body, err = encoding.CBOREncoding{}.Marshal(map[string]string{"name": strings.Repeat("a", defaultNegotiateMaxBodySize)})
+	require.NoError(t, err)
+	w = doNegotiateRequest(newNegotiateRouter(), http.MethodPost, "/user", ContentTypeCBOR, "", body)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
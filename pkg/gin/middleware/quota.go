@@ -0,0 +1,487 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/go-dev-frame/sponge/pkg/gin/response"
+)
+
+// HeaderAPIKey header api key
+const HeaderAPIKey = "X-API-Key"
+
+// quota periods, the usage is accumulated in a period and reset in the next period.
+const (
+	QuotaPeriodHour  = "hour"
+	QuotaPeriodDay   = "day"
+	QuotaPeriodMonth = "month"
+)
+
+// ErrQuotaExceeded is returned when the usage of the api key exceeds its quota.
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// Usage is the usage of an api key in a period.
+type Usage struct {
+	Requests      int64 `json:"requests"`
+	RequestBytes  int64 `json:"requestBytes"`
+	ResponseBytes int64 `json:"responseBytes"`
+}
+
+func (u Usage) add(other Usage) Usage {
+	return Usage{
+		Requests:      u.Requests + other.Requests,
+		RequestBytes:  u.RequestBytes + other.RequestBytes,
+		ResponseBytes: u.ResponseBytes + other.ResponseBytes,
+	}
+}
+
+// Exceeds reports whether the usage reaches the limit, a zero field of limit means unlimited.
+func (u Usage) Exceeds(limit Usage) bool {
+	return (limit.Requests > 0 && u.Requests >= limit.Requests) ||
+		(limit.RequestBytes > 0 && u.RequestBytes >= limit.RequestBytes) ||
+		(limit.ResponseBytes > 0 && u.ResponseBytes >= limit.ResponseBytes)
+}
+
+// UsageStore is the storage of the usage of api keys.
+type UsageStore interface {
+	// Incr adds the usages of the keys in the period, returns the accumulated usages of the keys.
+	Incr(ctx context.Context, period string, usages map[string]Usage) (map[string]Usage, error)
+	// Get returns the accumulated usage of the key in the period.
+	Get(ctx context.Context, period string, key string) (Usage, error)
+}
+
+// ------------------------------------------------------------------------------------------
+
+type memoryUsageStore struct {
+	mu     sync.Mutex
+	period string
+	usages map[string]Usage
+}
+
+// NewMemoryUsageStore creates a usage store in memory, only the usage of the latest period is kept,
+// it is suitable for a single instance service.
+func NewMemoryUsageStore() UsageStore {
+	return &memoryUsageStore{usages: make(map[string]Usage)}
+}
+
+func (s *memoryUsageStore) Incr(_ context.Context, period string, usages map[string]Usage) (map[string]Usage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if period < s.period {
+		return nil, nil // the period has passed, the usages are discarded
+	}
+	if period != s.period {
+		s.period = period
+		s.usages = make(map[string]Usage)
+	}
+	totals := make(map[string]Usage, len(usages))
+	for key, u := range usages {
+		s.usages[key] = s.usages[key].add(u)
+		totals[key] = s.usages[key]
+	}
+	return totals, nil
+}
+
+func (s *memoryUsageStore) Get(_ context.Context, period string, key string) (Usage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if period != s.period {
+		return Usage{}, nil
+	}
+	return s.usages[key], nil
+}
+
+type redisUsageStore struct {
+	client     redis.UniversalClient
+	prefix     string
+	expiration time.Duration
+}
+
+// NewRedisUsageStore creates a usage store in redis, the usage is shared by all instances of the service,
+// the usage of a period is kept for expiration, default is 35 days.
+func NewRedisUsageStore(client redis.UniversalClient, expiration time.Duration) UsageStore {
+	if expiration <= 0 {
+		expiration = 35 * 24 * time.Hour
+	}
+	return &redisUsageStore{client: client, prefix: "quota:usage:", expiration: expiration}
+}
+
+func (s *redisUsageStore) redisKey(period string, key string) string {
+	return s.prefix + period + ":" + key
+}
+
+func (s *redisUsageStore) Incr(ctx context.Context, period string, usages map[string]Usage) (map[string]Usage, error) {
+	type result struct {
+		key                          string
+		requests, reqBytes, rspBytes *redis.IntCmd
+	}
+	results := make([]result, 0, len(usages))
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for key, u := range usages {
+			k := s.redisKey(period, key)
+			results = append(results, result{
+				key:      key,
+				requests: pipe.HIncrBy(ctx, k, "requests", u.Requests),
+				reqBytes: pipe.HIncrBy(ctx, k, "requestBytes", u.RequestBytes),
+				rspBytes: pipe.HIncrBy(ctx, k, "responseBytes", u.ResponseBytes),
+			})
+			pipe.Expire(ctx, k, s.expiration)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	totals := make(map[string]Usage, len(results))
+	for _, r := range results {
+		totals[r.key] = Usage{Requests: r.requests.Val(), RequestBytes: r.reqBytes.Val(), ResponseBytes: r.rspBytes.Val()}
+	}
+	return totals, nil
+}
+
+func (s *redisUsageStore) Get(ctx context.Context, period string, key string) (Usage, error) {
+	values, err := s.client.HGetAll(ctx, s.redisKey(period, key)).Result()
+	if err != nil {
+		return Usage{}, err
+	}
+	parse := func(field string) int64 {
+		n, _ := strconv.ParseInt(values[field], 10, 64)
+		return n
+	}
+	return Usage{Requests: parse("requests"), RequestBytes: parse("requestBytes"), ResponseBytes: parse("responseBytes")}, nil
+}
+
+// ------------------------------------------------------------------------------------------
+
+// QuotaOption set the quota options.
+type QuotaOption func(*quotaOptions)
+
+type quotaOptions struct {
+	extractor     func(c *gin.Context) string
+	store         UsageStore
+	period        string
+	flushInterval time.Duration
+	limit         func(key string) Usage
+	onExceeded    func(c *gin.Context, key string, used Usage, limit Usage)
+	onReport      func(period string, usages map[string]Usage)
+	log           *zap.Logger
+}
+
+func defaultQuotaOptions() *quotaOptions {
+	return &quotaOptions{
+		extractor: func(c *gin.Context) string {
+			return c.GetHeader(HeaderAPIKey)
+		},
+		period:        QuotaPeriodDay,
+		flushInterval: time.Second,
+		log:           defaultLogger,
+	}
+}
+
+func (o *quotaOptions) apply(opts ...QuotaOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WithQuotaKeyExtractor set the function to extract the api key from request, default is header X-API-Key,
+// the request without api key is not accounted.
+func WithQuotaKeyExtractor(fn func(c *gin.Context) string) QuotaOption {
+	return func(o *quotaOptions) {
+		if fn != nil {
+			o.extractor = fn
+		}
+	}
+}
+
+// WithQuotaStore set the usage store, default is memory, use NewRedisUsageStore to share the usage among instances.
+func WithQuotaStore(store UsageStore) QuotaOption {
+	return func(o *quotaOptions) {
+		if store != nil {
+			o.store = store
+		}
+	}
+}
+
+// WithQuotaPeriod set the period of quota, hour, day or month, default is day.
+func WithQuotaPeriod(period string) QuotaOption {
+	return func(o *quotaOptions) {
+		switch period {
+		case QuotaPeriodHour, QuotaPeriodDay, QuotaPeriodMonth:
+			o.period = period
+		}
+	}
+}
+
+// WithQuotaFlushInterval set the interval of flushing the local usage to the store, default is 1s.
+func WithQuotaFlushInterval(d time.Duration) QuotaOption {
+	return func(o *quotaOptions) {
+		if d > 0 {
+			o.flushInterval = d
+		}
+	}
+}
+
+// WithQuotaLimit set the function to get the quota of the api key in a period, e.g. from the plan of user,
+// the request is rejected with 429 when the usage reaches the quota, a zero field means unlimited.
+func WithQuotaLimit(fn func(key string) Usage) QuotaOption {
+	return func(o *quotaOptions) {
+		o.limit = fn
+	}
+}
+
+// WithQuotaExceeded set the hook called when the request is rejected for exceeding the quota, e.g. notify the user.
+func WithQuotaExceeded(fn func(c *gin.Context, key string, used Usage, limit Usage)) QuotaOption {
+	return func(o *quotaOptions) {
+		o.onExceeded = fn
+	}
+}
+
+// WithQuotaReport set the hook called after each flush with the usages added in the flush, e.g. for billing.
+func WithQuotaReport(fn func(period string, usages map[string]Usage)) QuotaOption {
+	return func(o *quotaOptions) {
+		o.onReport = fn
+	}
+}
+
+// WithQuotaLog set log
+func WithQuotaLog(log *zap.Logger) QuotaOption {
+	return func(o *quotaOptions) {
+		if log != nil {
+			o.log = log
+		}
+	}
+}
+
+// QuotaAccounter records the usage (requests, request bytes and response bytes) of each api key, the usage
+// is accumulated locally and flushed to the store periodically, so the store is not accessed on every request.
+type QuotaAccounter struct {
+	opts *quotaOptions
+
+	mu      sync.Mutex
+	period  string
+	pending map[string]Usage // usages not flushed yet in the current period
+	totals  map[string]Usage // usages in the store at the last flush or load
+	flushMu sync.Mutex
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewQuotaAccounter creates a quota accounter and starts flushing the usage periodically, call Close to
+// stop it and flush the remaining usage.
+func NewQuotaAccounter(opts ...QuotaOption) *QuotaAccounter {
+	o := defaultQuotaOptions()
+	o.apply(opts...)
+	if o.store == nil {
+		o.store = NewMemoryUsageStore()
+	}
+
+	a := &QuotaAccounter{
+		opts:    o,
+		period:  quotaPeriodOf(o.period, time.Now()),
+		pending: make(map[string]Usage),
+		totals:  make(map[string]Usage),
+		done:    make(chan struct{}),
+	}
+	go a.loop()
+	return a
+}
+
+func (a *QuotaAccounter) loop() {
+	ticker := time.NewTicker(a.opts.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.done:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := a.Flush(ctx); err != nil {
+				a.opts.log.Warn("flush quota usage error", zap.Error(err))
+			}
+			cancel()
+		}
+	}
+}
+
+// rotate starts a new period if the current one has passed, the pending usages of the previous period
+// are flushed in background, it must be called with a.mu held.
+func (a *QuotaAccounter) rotate(now time.Time) {
+	period := quotaPeriodOf(a.opts.period, now)
+	if period == a.period {
+		return
+	}
+	if len(a.pending) > 0 {
+		go a.flushPeriod(a.period, a.pending)
+	}
+	a.period = period
+	a.pending = make(map[string]Usage)
+	a.totals = make(map[string]Usage)
+}
+
+// Usage returns the usage of the api key in the current period, including the usage not flushed yet.
+func (a *QuotaAccounter) Usage(ctx context.Context, key string) (Usage, error) {
+	a.mu.Lock()
+	a.rotate(time.Now())
+	period := a.period
+	total, ok := a.totals[key]
+	pending := a.pending[key]
+	a.mu.Unlock()
+	if ok {
+		return total.add(pending), nil
+	}
+
+	total, err := a.opts.store.Get(ctx, period, key)
+	if err != nil {
+		return Usage{}, err
+	}
+	a.mu.Lock()
+	if a.period == period {
+		if _, ok = a.totals[key]; !ok {
+			a.totals[key] = total
+		}
+		total = a.totals[key]
+		pending = a.pending[key]
+	}
+	a.mu.Unlock()
+	return total.add(pending), nil
+}
+
+// Record adds the usage of the api key in the current period.
+func (a *QuotaAccounter) Record(key string, u Usage) {
+	a.mu.Lock()
+	a.rotate(time.Now())
+	a.pending[key] = a.pending[key].add(u)
+	a.mu.Unlock()
+}
+
+// Flush writes the local usage to the store immediately.
+func (a *QuotaAccounter) Flush(ctx context.Context) error {
+	a.mu.Lock()
+	a.rotate(time.Now())
+	period, pending := a.period, a.pending
+	a.pending = make(map[string]Usage)
+	a.mu.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+	return a.flushUsages(ctx, period, pending)
+}
+
+func (a *QuotaAccounter) flushPeriod(period string, usages map[string]Usage) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := a.flushUsages(ctx, period, usages); err != nil {
+		a.opts.log.Warn("flush quota usage error", zap.String("period", period), zap.Error(err))
+	}
+}
+
+func (a *QuotaAccounter) flushUsages(ctx context.Context, period string, usages map[string]Usage) error {
+	a.flushMu.Lock()
+	defer a.flushMu.Unlock()
+
+	totals, err := a.opts.store.Incr(ctx, period, usages)
+	a.mu.Lock()
+	if a.period == period {
+		if err != nil {
+			// keep the usages, they are retried in the next flush
+			for key, u := range usages {
+				a.pending[key] = a.pending[key].add(u)
+			}
+		} else {
+			for key, total := range totals {
+				a.totals[key] = total
+			}
+		}
+	}
+	a.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if a.opts.onReport != nil {
+		a.opts.onReport(period, usages)
+	}
+	return nil
+}
+
+// Close stops flushing periodically and flushes the remaining usage.
+func (a *QuotaAccounter) Close() error {
+	var err error
+	a.closeOnce.Do(func() {
+		close(a.done)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		err = a.Flush(ctx)
+	})
+	return err
+}
+
+// Quota records the usage of each api key by the accounter, and rejects the request with 429 when the usage
+// of the api key reaches its quota set by WithQuotaLimit, the request without api key is passed directly.
+func Quota(a *QuotaAccounter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := a.opts.extractor(c)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		if a.opts.limit != nil {
+			limit := a.opts.limit(key)
+			used, err := a.Usage(c.Request.Context(), key)
+			if err != nil {
+				// the store is unavailable, prefer to serve the request rather than reject it
+				a.opts.log.Warn("get quota usage error", zap.String("key", key), zap.Error(err))
+			} else {
+				if limit.Requests > 0 {
+					remaining := limit.Requests - used.Requests
+					if remaining < 0 {
+						remaining = 0
+					}
+					c.Header("X-Quota-Limit", strconv.FormatInt(limit.Requests, 10))
+					c.Header("X-Quota-Remaining", strconv.FormatInt(remaining, 10))
+				}
+				if used.Exceeds(limit) {
+					if a.opts.onExceeded != nil {
+						a.opts.onExceeded(c, key, used, limit)
+					}
+					response.Output(c, http.StatusTooManyRequests, ErrQuotaExceeded.Error())
+					c.Abort()
+					return
+				}
+			}
+		}
+
+		c.Next()
+
+		u := Usage{Requests: 1}
+		if c.Request.ContentLength > 0 {
+			u.RequestBytes = c.Request.ContentLength
+		}
+		if size := c.Writer.Size(); size > 0 {
+			u.ResponseBytes = int64(size)
+		}
+		a.Record(key, u)
+	}
+}
+
+func quotaPeriodOf(period string, t time.Time) string {
+	t = t.UTC()
+	switch period {
+	case QuotaPeriodHour:
+		return t.Format("2006010215")
+	case QuotaPeriodMonth:
+		return t.Format("200601")
+	}
+	return t.Format("20060102")
+}
@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-dev-frame/sponge/pkg/gin/response"
+)
+
+func newQuotaRouter(a *QuotaAccounter) *gin.Engine {
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	r.Use(Quota(a))
+	r.POST("/quota", func(c *gin.Context) {
+		response.Success(c, "hello")
+	})
+	return r
+}
+
+func doQuotaRequest(r *gin.Engine, key string, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/quota", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(HeaderAPIKey, key)
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestQuota(t *testing.T) {
+	var mu sync.Mutex
+	var exceeded []string
+	reported := make(map[string]Usage)
+	a := NewQuotaAccounter(
+		WithQuotaLimit(func(key string) Usage {
+			if key == "free" {
+				return Usage{Requests: 2}
+			}
+			return Usage{}
+		}),
+		WithQuotaExceeded(func(c *gin.Context, key string, used Usage, limit Usage) {
+			exceeded = append(exceeded, key)
+		}),
+		WithQuotaReport(func(period string, usages map[string]Usage) {
+			mu.Lock()
+			defer mu.Unlock()
+			for k, u := range usages {
+				reported[k] = reported[k].add(u)
+			}
+		}),
+		WithQuotaFlushInterval(time.Hour),
+	)
+	defer a.Close()
+	r := newQuotaRouter(a)
+
+	for i := 0; i < 2; i++ {
+		w := doQuotaRequest(r, "free", "abc")
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+	w := doQuotaRequest(r, "free", "abc")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "0", w.Header().Get("X-Quota-Remaining"))
+	assert.Equal(t, []string{"free"}, exceeded)
+
+	for i := 0; i < 3; i++ {
+		w = doQuotaRequest(r, "paid", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+	w = doQuotaRequest(r, "", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	used, err := a.Usage(context.Background(), "free")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), used.Requests)
+	assert.Equal(t, int64(6), used.RequestBytes)
+	assert.Greater(t, used.ResponseBytes, int64(0))
+
+	assert.NoError(t, a.Flush(context.Background()))
+	mu.Lock()
+	assert.Equal(t, int64(2), reported["free"].Requests)
+	assert.Equal(t, int64(3), reported["paid"].Requests)
+	mu.Unlock()
+
+	// the usage is read from the store after flushing
+	used, err = a.Usage(context.Background(), "paid")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), used.Requests)
+	stored, _ := a.opts.store.Get(context.Background(), a.period, "paid")
+	assert.Equal(t, int64(3), stored.Requests)
+}
+
+func TestQuotaRedisStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	store := NewRedisUsageStore(client, time.Hour)
+
+	// two instances share the usage in redis
+	a1 := NewQuotaAccounter(WithQuotaStore(store), WithQuotaPeriod(QuotaPeriodHour),
+		WithQuotaLimit(func(key string) Usage { return Usage{Requests: 3} }),
+		WithQuotaFlushInterval(time.Hour))
+	a2 := NewQuotaAccounter(WithQuotaStore(store), WithQuotaPeriod(QuotaPeriodHour),
+		WithQuotaLimit(func(key string) Usage { return Usage{Requests: 3} }),
+		WithQuotaFlushInterval(time.Hour))
+	r1, r2 := newQuotaRouter(a1), newQuotaRouter(a2)
+
+	assert.Equal(t, http.StatusOK, doQuotaRequest(r1, "k", "").Code)
+	assert.Equal(t, http.StatusOK, doQuotaRequest(r1, "k", "").Code)
+	assert.NoError(t, a1.Close())
+
+	assert.Equal(t, http.StatusOK, doQuotaRequest(r2, "k", "").Code)
+	assert.Equal(t, http.StatusTooManyRequests, doQuotaRequest(r2, "k", "").Code)
+	assert.NoError(t, a2.Close())
+
+	u, err := store.Get(context.Background(), quotaPeriodOf(QuotaPeriodHour, time.Now()), "k")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), u.Requests)
+	assert.True(t, mr.TTL("quota:usage:"+quotaPeriodOf(QuotaPeriodHour, time.Now())+":k") > 0)
+
+	// the usage is kept and retried when the store is unavailable
+	a3 := NewQuotaAccounter(WithQuotaStore(store), WithQuotaFlushInterval(time.Hour))
+	a3.Record("k", Usage{Requests: 1})
+	mr.Close()
+	assert.Error(t, a3.Flush(context.Background()))
+	a3.mu.Lock()
+	assert.Equal(t, int64(1), a3.pending["k"].Requests)
+	a3.mu.Unlock()
+}
+
+type errUsageStore struct{}
+
+func (errUsageStore) Incr(context.Context, string, map[string]Usage) (map[string]Usage, error) {
+	return nil, errors.New("unavailable")
+}
+func (errUsageStore) Get(context.Context, string, string) (Usage, error) {
+	return Usage{}, errors.New("unavailable")
+}
+
+func TestQuotaStoreUnavailable(t *testing.T) {
+	a := NewQuotaAccounter(WithQuotaStore(errUsageStore{}), WithQuotaLimit(func(string) Usage { return Usage{Requests: 1} }))
+	defer a.Close()
+	w := doQuotaRequest(newQuotaRouter(a), "k", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestUsageExceeds(t *testing.T) {
+	assert.False(t, Usage{Requests: 10}.Exceeds(Usage{}))
+	assert.True(t, Usage{Requests: 10}.Exceeds(Usage{Requests: 10}))
+	assert.True(t, Usage{ResponseBytes: 10}.Exceeds(Usage{Requests: 10, ResponseBytes: 5}))
+	assert.False(t, Usage{RequestBytes: 4}.Exceeds(Usage{RequestBytes: 5}))
+
+	assert.Equal(t, "2024010203", quotaPeriodOf(QuotaPeriodHour, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)))
+	assert.Equal(t, "20240102", quotaPeriodOf(QuotaPeriodDay, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)))
+	assert.Equal(t, "202401", quotaPeriodOf(QuotaPeriodMonth, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)))
+}
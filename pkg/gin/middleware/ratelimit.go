@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
 
 	"github.com/go-dev-frame/sponge/pkg/gin/response"
 	rl "github.com/go-dev-frame/sponge/pkg/shield/ratelimit"
@@ -23,6 +24,10 @@ type rateLimitOptions struct {
 	bucket       int
 	cpuThreshold int64
 	cpuQuota     float64
+
+	redisClient redis.UniversalClient
+	clusterName string
+	callerKey   func(c *gin.Context) string
 }
 
 func defaultRatelimitOptions() *rateLimitOptions {
@@ -67,19 +72,52 @@ func WithCPUQuota(quota float64) RateLimitOption {
 	}
 }
 
+// WithCluster coordinate the rate limit among the replicas of service by redis, name is shared by the replicas.
+func WithCluster(client redis.UniversalClient, name string) RateLimitOption {
+	return func(o *rateLimitOptions) {
+		o.redisClient = client
+		o.clusterName = name
+	}
+}
+
+// WithCallerKey get the caller of request for the fairness among callers, e.g. user id or client ip,
+// it works with WithCluster.
+func WithCallerKey(fn func(c *gin.Context) string) RateLimitOption {
+	return func(o *rateLimitOptions) {
+		o.callerKey = fn
+	}
+}
+
 // RateLimit an adaptive rate limiter middleware
 func RateLimit(opts ...RateLimitOption) gin.HandlerFunc {
 	o := defaultRatelimitOptions()
 	o.apply(opts...)
-	limiter := rl.NewLimiter(
+	localOpts := []rl.Option{
 		rl.WithWindow(o.window),
 		rl.WithBucket(o.bucket),
 		rl.WithCPUThreshold(o.cpuThreshold),
 		rl.WithCPUQuota(o.cpuQuota),
-	)
+	}
+
+	var allow func(c *gin.Context) (rl.DoneFunc, error)
+	if o.redisClient != nil {
+		limiter, err := rl.NewClusterLimiter(o.redisClient, o.clusterName, rl.WithLocalOptions(localOpts...))
+		if err != nil {
+			panic("new cluster limiter error: " + err.Error())
+		}
+		allow = func(c *gin.Context) (rl.DoneFunc, error) {
+			if o.callerKey != nil {
+				return limiter.AllowCaller(o.callerKey(c))
+			}
+			return limiter.Allow()
+		}
+	} else {
+		limiter := rl.NewLimiter(localOpts...)
+		allow = func(c *gin.Context) (rl.DoneFunc, error) { return limiter.Allow() }
+	}
 
 	return func(c *gin.Context) {
-		done, err := limiter.Allow()
+		done, err := allow(c)
 		if err != nil {
 			response.Output(c, http.StatusTooManyRequests, err.Error())
 			c.Abort()
@@ -3,12 +3,16 @@ package middleware
 import (
 	"math/rand"
 	"net/http"
+	"net/http/httptest"
 	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
 
 	"github.com/go-dev-frame/sponge/pkg/gin/response"
 	"github.com/go-dev-frame/sponge/pkg/httpcli"
@@ -77,3 +81,27 @@ func TestRateLimiter(t *testing.T) {
 			time.Now().Format(time.RFC3339Nano), success, failures)
 	}
 }
+
+func TestRateLimitCluster(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	r.Use(RateLimit(
+		WithCluster(client, "user-service"),
+		WithCallerKey(func(c *gin.Context) string { return c.ClientIP() }),
+	))
+	r.GET("/hello", func(c *gin.Context) {
+		response.Success(c, "hello")
+	})
+
+	for i := 0; i < 10; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/hello", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+	keys, _ := mr.HKeys("ratelimit:user-service:nodes")
+	assert.Len(t, keys, 1)
+}
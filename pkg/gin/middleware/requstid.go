@@ -3,19 +3,33 @@ package middleware
 import (
 	"context"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/metadata"
 
+	"github.com/go-dev-frame/sponge/pkg/httpcli"
 	"github.com/go-dev-frame/sponge/pkg/krand"
 )
 
+func init() {
+	// the requests of httpcli carry the request id and traceparent of their context
+	httpcli.RegisterHeaderInjector(InjectRequestIDHeader)
+}
+
 var (
 	// ContextRequestIDKey request id for context
 	ContextRequestIDKey = "request_id"
 
 	// HeaderXRequestIDKey header request id key
 	HeaderXRequestIDKey = "X-Request-Id"
+
+	// ContextTraceparentKey W3C traceparent for context
+	ContextTraceparentKey = "traceparent"
+
+	// HeaderTraceparentKey W3C traceparent header key
+	HeaderTraceparentKey = "traceparent"
 )
 
 // RequestIDOption set the request id  options.
@@ -24,6 +38,7 @@ type RequestIDOption func(*requestIDOptions)
 type requestIDOptions struct {
 	contextRequestIDKey string
 	headerXRequestIDKey string
+	fromHeaders         []string
 }
 
 func defaultRequestIDOptions() *requestIDOptions {
@@ -68,6 +83,16 @@ func WithHeaderRequestIDKey(key string) RequestIDOption {
 	}
 }
 
+// WithRequestIDFromHeaders accept the existing request id from the headers in order, the first non-empty
+// value is used, e.g. WithRequestIDFromHeaders("X-Request-ID", "X-Correlation-ID", "traceparent"),
+// the trace-id of W3C traceparent header is used as the request id. The header of request id
+// is always checked first.
+func WithRequestIDFromHeaders(headers ...string) RequestIDOption {
+	return func(o *requestIDOptions) {
+		o.fromHeaders = append(o.fromHeaders, headers...)
+	}
+}
+
 // CtxKeyString for context.WithValue key type
 type CtxKeyString string
 
@@ -83,18 +108,34 @@ func RequestID(opts ...RequestIDOption) gin.HandlerFunc {
 	o.apply(opts...)
 	o.setRequestIDKey()
 
+	fromHeaders := append([]string{HeaderXRequestIDKey}, o.fromHeaders...)
+
 	return func(c *gin.Context) {
-		// Check for incoming header, use it if exists
-		requestID := c.Request.Header.Get(HeaderXRequestIDKey)
+		// Check for incoming headers, use it if exists
+		requestID := ""
+		for _, header := range fromHeaders {
+			requestID = headerRequestID(c.Request.Header, header)
+			if requestID != "" {
+				break
+			}
+		}
 
 		// Create request id
 		if requestID == "" {
 			requestID = krand.String(krand.R_All, 10)
-			c.Request.Header.Set(HeaderXRequestIDKey, requestID)
 		}
+		c.Request.Header.Set(HeaderXRequestIDKey, requestID)
 
-		// Expose it for use in the application
+		// Expose it for use in the application, also in the context of request
+		ctx := context.WithValue(c.Request.Context(), ContextRequestIDKey, requestID) //nolint
 		c.Set(ContextRequestIDKey, requestID)
+		if traceparent := c.Request.Header.Get(HeaderTraceparentKey); traceparent != "" {
+			if _, _, ok := ParseTraceparent(traceparent); ok {
+				ctx = context.WithValue(ctx, ContextTraceparentKey, traceparent) //nolint
+				c.Set(ContextTraceparentKey, traceparent)
+			}
+		}
+		c.Request = c.Request.WithContext(ctx)
 
 		// Set X-Request-Id header
 		c.Writer.Header().Set(HeaderXRequestIDKey, requestID)
@@ -103,6 +144,45 @@ func RequestID(opts ...RequestIDOption) gin.HandlerFunc {
 	}
 }
 
+func headerRequestID(header http.Header, key string) string {
+	value := header.Get(key)
+	if value == "" || !strings.EqualFold(key, HeaderTraceparentKey) {
+		return value
+	}
+	traceID, _, _ := ParseTraceparent(value)
+	return traceID
+}
+
+// ParseTraceparent parse W3C traceparent header, format is version-traceid-parentid-flags,
+// e.g. 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+func ParseTraceparent(traceparent string) (traceID string, parentID string, ok bool) {
+	ss := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(ss) < 4 || len(ss[0]) != 2 || ss[0] == "ff" || len(ss[1]) != 32 || len(ss[2]) != 16 || len(ss[3]) != 2 {
+		return "", "", false
+	}
+	if ss[0] == "00" && len(ss) != 4 {
+		return "", "", false
+	}
+	for _, s := range ss[:4] {
+		if !isLowerHex(s) {
+			return "", "", false
+		}
+	}
+	if strings.Trim(ss[1], "0") == "" || strings.Trim(ss[2], "0") == "" {
+		return "", "", false // all zeros is invalid
+	}
+	return ss[1], ss[2], true
+}
+
+func isLowerHex(s string) bool {
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
 // GCtxRequestID get request id from gin.Context
 func GCtxRequestID(c *gin.Context) string {
 	if v, isExist := c.Get(ContextRequestIDKey); isExist {
@@ -118,6 +198,11 @@ func GCtxRequestIDField(c *gin.Context) zap.Field {
 	return zap.String(ContextRequestIDKey, GCtxRequestID(c))
 }
 
+// GCtxTraceparent get W3C traceparent from gin.Context
+func GCtxTraceparent(c *gin.Context) string {
+	return c.GetString(ContextTraceparentKey)
+}
+
 // HeaderRequestID get request id from the header
 func HeaderRequestID(c *gin.Context) string {
 	return c.Request.Header.Get(HeaderXRequestIDKey)
@@ -136,7 +221,10 @@ var RequestHeaderKey = "request_header_key"
 // WrapCtx wrap context, put the Keys and Header of gin.Context into context
 func WrapCtx(c *gin.Context) context.Context {
 	ctx := context.WithValue(c.Request.Context(), ContextRequestIDKey, c.GetString(ContextRequestIDKey)) //nolint
-	return context.WithValue(ctx, RequestHeaderKey, c.Request.Header)                                    //nolint
+	if traceparent := c.GetString(ContextTraceparentKey); traceparent != "" {
+		ctx = context.WithValue(ctx, ContextTraceparentKey, traceparent) //nolint
+	}
+	return context.WithValue(ctx, RequestHeaderKey, c.Request.Header) //nolint
 }
 
 // AdaptCtx adapt context, if ctx is gin.Context, return gin.Context and context of the transformation
@@ -167,6 +255,66 @@ func CtxRequestIDField(ctx context.Context) zap.Field {
 	return zap.String(ContextRequestIDKey, CtxRequestID(ctx))
 }
 
+// CtxTraceparent get W3C traceparent from context.Context
+func CtxTraceparent(ctx context.Context) string {
+	v := ctx.Value(ContextTraceparentKey)
+	if str, ok := v.(string); ok {
+		return str
+	}
+	return ""
+}
+
+// outgoingRequestID get the request id and traceparent from context, fall back to the gin.Context in context.
+func outgoingRequestID(ctx context.Context) (requestID string, traceparent string) {
+	requestID, traceparent = CtxRequestID(ctx), CtxTraceparent(ctx)
+	if requestID == "" || traceparent == "" {
+		if c, ok := ctx.Value(gin.ContextKey).(*gin.Context); ok {
+			if requestID == "" {
+				requestID = GCtxRequestID(c)
+			}
+			if traceparent == "" {
+				traceparent = GCtxTraceparent(c)
+			}
+		}
+	}
+	return requestID, traceparent
+}
+
+// InjectRequestIDHeader set the request id and traceparent of context into the header of outgoing http request,
+// so that the downstream services can correlate with the current request, the existing headers are not overridden,
+// it is called automatically by httpcli.
+func InjectRequestIDHeader(ctx context.Context, header http.Header) {
+	if ctx == nil {
+		return
+	}
+	requestID, traceparent := outgoingRequestID(ctx)
+	if requestID != "" && header.Get(HeaderXRequestIDKey) == "" {
+		header.Set(HeaderXRequestIDKey, requestID)
+	}
+	if traceparent != "" && header.Get(HeaderTraceparentKey) == "" {
+		header.Set(HeaderTraceparentKey, traceparent)
+	}
+}
+
+// OutgoingRequestIDCtx put the request id and traceparent of context into the metadata of outgoing rpc request,
+// the key of request id is the same as grpc interceptor, default is request_id, it is called automatically by
+// the client interceptors UnaryClientRequestID and StreamClientRequestID of pkg/grpc/interceptor.
+func OutgoingRequestIDCtx(ctx context.Context) context.Context {
+	requestID, traceparent := outgoingRequestID(ctx)
+	if requestID == "" && traceparent == "" {
+		return ctx
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	if requestID != "" && len(md.Get(ContextRequestIDKey)) == 0 {
+		md.Set(ContextRequestIDKey, requestID)
+	}
+	if traceparent != "" && len(md.Get(HeaderTraceparentKey)) == 0 {
+		md.Set(HeaderTraceparentKey, traceparent)
+	}
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
 // GetFromHeader get value from header
 func GetFromHeader(ctx context.Context, key string) string {
 	header, ok := ctx.Value(RequestHeaderKey).(http.Header)
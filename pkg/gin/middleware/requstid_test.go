@@ -3,13 +3,16 @@ package middleware
 import (
 	"context"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/go-dev-frame/sponge/pkg/httpcli"
 	"github.com/go-dev-frame/sponge/pkg/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
 )
 
 func runRequestIDHTTPServer(fn func(c *gin.Context)) string {
@@ -109,3 +112,121 @@ func TestRequestIDKeyOptions(t *testing.T) {
 	assert.Equal(t, "my_req_id", ContextRequestIDKey)
 	assert.Equal(t, "My-X-Req-Id", HeaderXRequestIDKey)
 }
+
+func TestRequestIDFromHeaders(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	r.Use(RequestID(WithRequestIDFromHeaders("X-Correlation-ID", HeaderTraceparentKey)))
+	r.Use(Logging(WithRequestIDFromContext()))
+	var gotID, gotTP string
+	var ctx context.Context
+	r.GET("/ping", func(c *gin.Context) {
+		gotID, gotTP = GCtxRequestID(c), GCtxTraceparent(c)
+		ctx = WrapCtx(c)
+		c.String(200, "pong")
+	})
+	tp := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	req := httptest.NewRequest("GET", "/ping", nil)
+	req.Header.Set(HeaderTraceparentKey, tp)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", gotID)
+	assert.Equal(t, tp, gotTP)
+	assert.Equal(t, gotID, w.Header().Get(HeaderXRequestIDKey))
+	assert.Equal(t, tp, CtxTraceparent(ctx))
+
+	header := http.Header{}
+	InjectRequestIDHeader(ctx, header)
+	assert.Equal(t, gotID, header.Get(HeaderXRequestIDKey))
+	assert.Equal(t, tp, header.Get(HeaderTraceparentKey))
+	md, _ := metadata.FromOutgoingContext(OutgoingRequestIDCtx(ctx))
+	assert.Equal(t, []string{gotID}, md.Get(ContextRequestIDKey))
+	assert.Equal(t, []string{tp}, md.Get(HeaderTraceparentKey))
+
+	req = httptest.NewRequest("GET", "/ping", nil)
+	req.Header.Set("X-Correlation-ID", "corr-1")
+	req.Header.Set(HeaderTraceparentKey, tp)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "corr-1", gotID)
+
+	req = httptest.NewRequest("GET", "/ping", nil)
+	req.Header.Set(HeaderXRequestIDKey, "rid-1")
+	req.Header.Set(HeaderTraceparentKey, "invalid")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "rid-1", gotID)
+	assert.Equal(t, "", gotTP)
+
+	req = httptest.NewRequest("GET", "/ping", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Len(t, gotID, 10)
+}
+
+func TestRequestIDPropagation(t *testing.T) {
+	var got []http.Header
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Clone())
+	}))
+	defer downstream.Close()
+
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	r.Use(RequestID())
+	r.GET("/ping", func(c *gin.Context) {
+		// the context of gin.Context, request, and wrapped by WrapCtx
+		for _, ctx := range []context.Context{c, c.Request.Context(), WrapCtx(c)} {
+			_, err := httpcli.New().SetURL(downstream.URL).SetContext(ctx).GET()
+			assert.NoError(t, err)
+		}
+		// the headers of request take precedence
+		_, err := httpcli.New().SetURL(downstream.URL).SetContext(c).SetHeaders(map[string]string{
+			HeaderXRequestIDKey:  "rid-2",
+			HeaderTraceparentKey: "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+		}).GET()
+		assert.NoError(t, err)
+		c.String(200, "pong")
+	})
+	tp := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	req := httptest.NewRequest("GET", "/ping", nil)
+	req.Header.Set(HeaderXRequestIDKey, "rid-1")
+	req.Header.Set(HeaderTraceparentKey, tp)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !assert.Len(t, got, 4) {
+		return
+	}
+	for _, header := range got[:3] {
+		assert.Equal(t, "rid-1", header.Get(HeaderXRequestIDKey))
+		assert.Equal(t, tp, header.Get(HeaderTraceparentKey))
+	}
+	assert.Equal(t, []string{"rid-2"}, got[3].Values(HeaderXRequestIDKey))
+	assert.Equal(t, []string{"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"}, got[3].Values(HeaderTraceparentKey))
+
+	// no request id in context
+	got = nil
+	_, err := httpcli.New().SetURL(downstream.URL).SetContext(context.Background()).GET()
+	assert.NoError(t, err)
+	assert.Equal(t, "", got[0].Get(HeaderXRequestIDKey))
+}
+
+func TestParseTraceparent(t *testing.T) {
+	traceID, parentID, ok := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	assert.True(t, ok)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceID)
+	assert.Equal(t, "00f067aa0ba902b7", parentID)
+	_, _, ok = ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-future")
+	assert.True(t, ok)
+	for _, v := range []string{
+		"", "invalid",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01",
+	} {
+		_, _, ok = ParseTraceparent(v)
+		assert.False(t, ok, v)
+	}
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/go-dev-frame/sponge/pkg/gin/response"
+	"github.com/go-dev-frame/sponge/pkg/sgorm/tenant"
+)
+
+// HeaderTenantIDKey header tenant id key
+const HeaderTenantIDKey = "X-Tenant-ID"
+
+// TenantOption set the tenant options.
+type TenantOption func(*tenantOptions)
+
+type tenantOptions struct {
+	extractor  func(c *gin.Context) string
+	isOptional bool
+}
+
+func defaultTenantOptions() *tenantOptions {
+	return &tenantOptions{
+		extractor: func(c *gin.Context) string {
+			return c.GetHeader(HeaderTenantIDKey)
+		},
+	}
+}
+
+func (o *tenantOptions) apply(opts ...TenantOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WithTenantHeader set the header key of tenant id, default is X-Tenant-ID
+func WithTenantHeader(key string) TenantOption {
+	return func(o *tenantOptions) {
+		if key == "" {
+			return
+		}
+		o.extractor = func(c *gin.Context) string {
+			return c.GetHeader(key)
+		}
+	}
+}
+
+// WithTenantExtractor set the function to extract tenant id from request, e.g. from the claims of jwt,
+// subdomain or path parameter.
+func WithTenantExtractor(fn func(c *gin.Context) string) TenantOption {
+	return func(o *tenantOptions) {
+		if fn != nil {
+			o.extractor = fn
+		}
+	}
+}
+
+// WithTenantOptional the request without tenant id is not rejected.
+func WithTenantOptional() TenantOption {
+	return func(o *tenantOptions) {
+		o.isOptional = true
+	}
+}
+
+// Tenant extract the tenant id from request and inject it into the context of request, the queries of
+// gorm with the context (db.WithContext(ctx)) are scoped by the tenant plugin of pkg/sgorm/tenant,
+// the request without tenant id is rejected with 400 by default.
+func Tenant(opts ...TenantOption) gin.HandlerFunc {
+	o := defaultTenantOptions()
+	o.apply(opts...)
+
+	return func(c *gin.Context) {
+		tenantID := o.extractor(c)
+		if tenantID == "" {
+			if !o.isOptional {
+				response.Output(c, http.StatusBadRequest, "tenant id is required")
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
+		c.Request = c.Request.WithContext(tenant.NewContext(c.Request.Context(), tenantID))
+		c.Next()
+	}
+}
+
+// GetTenantID get the tenant id of request, it must be used after the Tenant middleware.
+func GetTenantID(c *gin.Context) (string, bool) {
+	return tenant.FromContext(c.Request.Context())
+}
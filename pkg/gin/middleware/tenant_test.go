@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-dev-frame/sponge/pkg/gin/response"
+	"github.com/go-dev-frame/sponge/pkg/sgorm/tenant"
+)
+
+func newTenantRouter(opts ...TenantOption) *gin.Engine {
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	r.Use(Tenant(opts...))
+	r.GET("/tenant", func(c *gin.Context) {
+		id1, _ := GetTenantID(c)
+		id2, _ := tenant.FromContext(WrapCtx(c))
+		response.Success(c, id1+","+id2)
+	})
+	return r
+}
+
+func doTenantRequest(r *gin.Engine, header string, value string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/tenant", nil)
+	if header != "" {
+		req.Header.Set(header, value)
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestTenant(t *testing.T) {
+	r := newTenantRouter()
+	w := doTenantRequest(r, HeaderTenantIDKey, "t1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"t1,t1"`)
+	w = doTenantRequest(r, "", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	r = newTenantRouter(WithTenantHeader("X-Org"), WithTenantOptional())
+	w = doTenantRequest(r, "X-Org", "t2")
+	assert.Contains(t, w.Body.String(), `"t2,t2"`)
+	w = doTenantRequest(r, "", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `","`)
+
+	r = newTenantRouter(WithTenantExtractor(func(c *gin.Context) string { return c.Query("tenant") }))
+	w = httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/tenant?tenant=t3", nil)
+	r.ServeHTTP(w, req)
+	assert.Contains(t, w.Body.String(), `"t3,t3"`)
+}
@@ -36,6 +36,7 @@ func New(r *gin.Engine, opts ...Option) *Proxy {
 	{
 		managerGroup.POST("/add", gin.WrapF(manager.HandleAddBackends))
 		managerGroup.POST("/remove", gin.WrapF(manager.HandleRemoveBackends))
+		managerGroup.POST("/drain", gin.WrapF(manager.HandleDrainBackends))
 		managerGroup.GET("/list", gin.WrapF(manager.HandleListBackends))
 		managerGroup.GET("", gin.WrapF(manager.HandleGetBackend))
 	}
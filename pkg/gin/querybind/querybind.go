@@ -0,0 +1,271 @@
+// Package querybind is the parsers of query parameters used by the code generated by protoc-gen-go-gin,
+// it binds the repeated fields, nested messages and well-known types of protobuf message over GET requests.
+package querybind
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// Values returns the values of the first name found in query, the name with suffix [] is also matched, e.g. ids[]=1&ids[]=2.
+func Values(values url.Values, names ...string) []string {
+	for _, name := range names {
+		if vs, ok := values[name]; ok && len(vs) > 0 {
+			return vs
+		}
+		if vs, ok := values[name+"[]"]; ok && len(vs) > 0 {
+			return vs
+		}
+	}
+	return nil
+}
+
+// Error returns the error of parsing query parameter.
+func Error(name string, err error) error {
+	return fmt.Errorf("invalid query parameter '%s', %v", name, err)
+}
+
+func parseOne[T any](vs []string, parse func(string) (T, error)) (T, error) {
+	var zero T
+	if len(vs) == 0 {
+		return zero, nil
+	}
+	return parse(vs[0])
+}
+
+func parseAll[T any](vs []string, parse func(string) (T, error)) ([]T, error) {
+	list := make([]T, 0, len(vs))
+	for _, s := range vs {
+		v, err := parse(s)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+	return list, nil
+}
+
+func parseString(s string) (string, error) { return s, nil }
+
+func parseBool(s string) (bool, error) {
+	if s == "" {
+		return true, nil // e.g. ?deleted
+	}
+	return strconv.ParseBool(s)
+}
+
+func parseInt32(s string) (int32, error) {
+	v, err := strconv.ParseInt(s, 10, 32)
+	return int32(v), err
+}
+
+func parseInt64(s string) (int64, error) { return strconv.ParseInt(s, 10, 64) }
+
+func parseUint32(s string) (uint32, error) {
+	v, err := strconv.ParseUint(s, 10, 32)
+	return uint32(v), err
+}
+
+func parseUint64(s string) (uint64, error) { return strconv.ParseUint(s, 10, 64) }
+
+func parseFloat32(s string) (float32, error) {
+	v, err := strconv.ParseFloat(s, 32)
+	return float32(v), err
+}
+
+func parseFloat64(s string) (float64, error) { return strconv.ParseFloat(s, 64) }
+
+// bytes are encoded in base64, standard or url-safe, with or without padding
+func parseBytes(s string) ([]byte, error) {
+	s = strings.TrimRight(s, "=")
+	if strings.ContainsAny(s, "-_") {
+		return base64.RawURLEncoding.DecodeString(s)
+	}
+	return base64.RawStdEncoding.DecodeString(s)
+}
+
+// String returns the first value.
+func String(vs []string) (string, error) { return parseOne(vs, parseString) }
+
+// Strings returns all values.
+func Strings(vs []string) ([]string, error) { return parseAll(vs, parseString) }
+
+// Bool parses the first value, empty value is true.
+func Bool(vs []string) (bool, error) { return parseOne(vs, parseBool) }
+
+// Bools parses all values.
+func Bools(vs []string) ([]bool, error) { return parseAll(vs, parseBool) }
+
+// Int32 parses the first value.
+func Int32(vs []string) (int32, error) { return parseOne(vs, parseInt32) }
+
+// Int32s parses all values.
+func Int32s(vs []string) ([]int32, error) { return parseAll(vs, parseInt32) }
+
+// Int64 parses the first value.
+func Int64(vs []string) (int64, error) { return parseOne(vs, parseInt64) }
+
+// Int64s parses all values.
+func Int64s(vs []string) ([]int64, error) { return parseAll(vs, parseInt64) }
+
+// Uint32 parses the first value.
+func Uint32(vs []string) (uint32, error) { return parseOne(vs, parseUint32) }
+
+// Uint32s parses all values.
+func Uint32s(vs []string) ([]uint32, error) { return parseAll(vs, parseUint32) }
+
+// Uint64 parses the first value.
+func Uint64(vs []string) (uint64, error) { return parseOne(vs, parseUint64) }
+
+// Uint64s parses all values.
+func Uint64s(vs []string) ([]uint64, error) { return parseAll(vs, parseUint64) }
+
+// Float32 parses the first value.
+func Float32(vs []string) (float32, error) { return parseOne(vs, parseFloat32) }
+
+// Float32s parses all values.
+func Float32s(vs []string) ([]float32, error) { return parseAll(vs, parseFloat32) }
+
+// Float64 parses the first value.
+func Float64(vs []string) (float64, error) { return parseOne(vs, parseFloat64) }
+
+// Float64s parses all values.
+func Float64s(vs []string) ([]float64, error) { return parseAll(vs, parseFloat64) }
+
+// Bytes parses the first value encoded in base64.
+func Bytes(vs []string) ([]byte, error) { return parseOne(vs, parseBytes) }
+
+// BytesList parses all values encoded in base64.
+func BytesList(vs []string) ([][]byte, error) { return parseAll(vs, parseBytes) }
+
+func enumParser[T ~int32](valueMap map[string]int32) func(string) (T, error) {
+	return func(s string) (T, error) {
+		if v, ok := valueMap[s]; ok {
+			return T(v), nil
+		}
+		v, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("unknown enum value %q", s)
+		}
+		return T(v), nil
+	}
+}
+
+// Enum parses the first value, the value is the name or number of enum, valueMap is
+// the generated map of enum names to numbers, e.g. Status_value.
+func Enum[T ~int32](vs []string, valueMap map[string]int32) (T, error) {
+	return parseOne(vs, enumParser[T](valueMap))
+}
+
+// Enums parses all values, the value is the name or number of enum.
+func Enums[T ~int32](vs []string, valueMap map[string]int32) ([]T, error) {
+	return parseAll(vs, enumParser[T](valueMap))
+}
+
+// Timestamp parses the first value, the format is RFC3339 (e.g. 2024-01-02T15:04:05Z),
+// date (e.g. 2024-01-02) or unix seconds (e.g. 1704207845).
+func Timestamp(vs []string) (*timestamppb.Timestamp, error) {
+	return parseOne(vs, func(s string) (*timestamppb.Timestamp, error) {
+		if sec, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return timestamppb.New(time.Unix(sec, 0)), nil
+		}
+		for _, layout := range []string{time.RFC3339Nano, time.DateTime, time.DateOnly} {
+			if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+				return timestamppb.New(t), nil
+			}
+		}
+		return nil, fmt.Errorf("invalid time %q, the format is RFC3339, date or unix seconds", s)
+	})
+}
+
+// Duration parses the first value, e.g. 1.5s, 1h30m.
+func Duration(vs []string) (*durationpb.Duration, error) {
+	return parseOne(vs, func(s string) (*durationpb.Duration, error) {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, err
+		}
+		return durationpb.New(d), nil
+	})
+}
+
+// FieldMask parses all values, the paths are separated by comma, e.g. ?mask=name,age or ?mask=name&mask=age.
+func FieldMask(vs []string) (*fieldmaskpb.FieldMask, error) {
+	fm := &fieldmaskpb.FieldMask{}
+	for _, s := range vs {
+		for _, path := range strings.Split(s, ",") {
+			if path = strings.TrimSpace(path); path != "" {
+				fm.Paths = append(fm.Paths, path)
+			}
+		}
+	}
+	return fm, nil
+}
+
+func wrap[T any, W any](vs []string, parse func(string) (T, error), fn func(T) W) (W, error) {
+	v, err := parseOne(vs, parse)
+	if err != nil {
+		var zero W
+		return zero, err
+	}
+	return fn(v), nil
+}
+
+// DoubleValue parses the first value to google.protobuf.DoubleValue.
+func DoubleValue(vs []string) (*wrapperspb.DoubleValue, error) {
+	return wrap(vs, parseFloat64, wrapperspb.Double)
+}
+
+// FloatValue parses the first value to google.protobuf.FloatValue.
+func FloatValue(vs []string) (*wrapperspb.FloatValue, error) {
+	return wrap(vs, parseFloat32, wrapperspb.Float)
+}
+
+// Int64Value parses the first value to google.protobuf.Int64Value.
+func Int64Value(vs []string) (*wrapperspb.Int64Value, error) {
+	return wrap(vs, parseInt64, wrapperspb.Int64)
+}
+
+// UInt64Value parses the first value to google.protobuf.UInt64Value.
+func UInt64Value(vs []string) (*wrapperspb.UInt64Value, error) {
+	return wrap(vs, parseUint64, wrapperspb.UInt64)
+}
+
+// Int32Value parses the first value to google.protobuf.Int32Value.
+func Int32Value(vs []string) (*wrapperspb.Int32Value, error) {
+	return wrap(vs, parseInt32, wrapperspb.Int32)
+}
+
+// UInt32Value parses the first value to google.protobuf.UInt32Value.
+func UInt32Value(vs []string) (*wrapperspb.UInt32Value, error) {
+	return wrap(vs, parseUint32, wrapperspb.UInt32)
+}
+
+// BoolValue parses the first value to google.protobuf.BoolValue.
+func BoolValue(vs []string) (*wrapperspb.BoolValue, error) {
+	return wrap(vs, parseBool, wrapperspb.Bool)
+}
+
+// StringValue parses the first value to google.protobuf.StringValue.
+func StringValue(vs []string) (*wrapperspb.StringValue, error) {
+	return wrap(vs, parseString, wrapperspb.String)
+}
+
+// BytesValue parses the first value encoded in base64 to google.protobuf.BytesValue.
+func BytesValue(vs []string) (*wrapperspb.BytesValue, error) {
+	return wrap(vs, parseBytes, wrapperspb.Bytes)
+}
+
+// Ptr returns the pointer of v, it is used for the proto3 optional fields.
+func Ptr[T any](v T) *T {
+	return &v
+}
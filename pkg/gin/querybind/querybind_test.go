@@ -0,0 +1,167 @@
+package querybind
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type status int32
+
+var statusValue = map[string]int32{
+	"STATUS_UNKNOWN": 0,
+	"STATUS_ACTIVE":  1,
+}
+
+func TestValues(t *testing.T) {
+	values, _ := url.ParseQuery("ids[]=1&ids[]=2&filter.userName=foo&empty=")
+	assert.Equal(t, []string{"1", "2"}, Values(values, "ids"))
+	assert.Equal(t, []string{"foo"}, Values(values, "filter.user_name", "filter.userName"))
+	assert.Equal(t, []string{""}, Values(values, "empty"))
+	assert.Nil(t, Values(values, "notFound"))
+
+	err := Error("ids", assert.AnError)
+	assert.Contains(t, err.Error(), "ids")
+}
+
+func TestScalars(t *testing.T) {
+	s, err := String([]string{"a", "b"})
+	assert.NoError(t, err)
+	assert.Equal(t, "a", s)
+	ss, err := Strings([]string{"a", "b"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, ss)
+
+	b, err := Bool([]string{""})
+	assert.NoError(t, err)
+	assert.True(t, b)
+	bs, err := Bools([]string{"true", "0"})
+	assert.NoError(t, err)
+	assert.Equal(t, []bool{true, false}, bs)
+	_, err = Bool([]string{"foo"})
+	assert.Error(t, err)
+
+	i32, err := Int32([]string{"-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(-1), i32)
+	_, err = Int32([]string{"4294967296"})
+	assert.Error(t, err)
+	i32s, err := Int32s([]string{"1", "2"})
+	assert.NoError(t, err)
+	assert.Equal(t, []int32{1, 2}, i32s)
+
+	i64s, err := Int64s([]string{"1", "2"})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 2}, i64s)
+	_, err = Int64s([]string{"1", "x"})
+	assert.Error(t, err)
+	i64, err := Int64(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), i64)
+
+	u32, err := Uint32([]string{"1"})
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(1), u32)
+	_, err = Uint32s([]string{"-1"})
+	assert.Error(t, err)
+	u64s, err := Uint64s([]string{"1"})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{1}, u64s)
+	u64, err := Uint64([]string{"2"})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), u64)
+
+	f32, err := Float32([]string{"1.5"})
+	assert.NoError(t, err)
+	assert.Equal(t, float32(1.5), f32)
+	f32s, err := Float32s([]string{"1.5"})
+	assert.NoError(t, err)
+	assert.Equal(t, []float32{1.5}, f32s)
+	f64, err := Float64([]string{"2.5"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2.5, f64)
+	f64s, err := Float64s([]string{"2.5"})
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{2.5}, f64s)
+
+	bt, err := Bytes([]string{"aGVsbG8="})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("hello"), bt)
+	bl, err := BytesList([]string{"aGVsbG8", "-_8"})
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("hello"), {0xfb, 0xff}}, bl)
+}
+
+func TestEnum(t *testing.T) {
+	v, err := Enum[status]([]string{"STATUS_ACTIVE"}, statusValue)
+	assert.NoError(t, err)
+	assert.Equal(t, status(1), v)
+
+	vs, err := Enums[status]([]string{"STATUS_ACTIVE", "0"}, statusValue)
+	assert.NoError(t, err)
+	assert.Equal(t, []status{1, 0}, vs)
+
+	_, err = Enum[status]([]string{"ACTIVE"}, statusValue)
+	assert.Error(t, err)
+}
+
+func TestWellKnownTypes(t *testing.T) {
+	ts, err := Timestamp([]string{"1704207845"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1704207845), ts.GetSeconds())
+	ts, err = Timestamp([]string{"2024-01-02T15:04:05Z"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1704207845), ts.GetSeconds())
+	ts, err = Timestamp([]string{"2024-01-02"})
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.Local).Unix(), ts.GetSeconds())
+	_, err = Timestamp([]string{"foo"})
+	assert.Error(t, err)
+
+	d, err := Duration([]string{"1m30s"})
+	assert.NoError(t, err)
+	assert.Equal(t, 90*time.Second, d.AsDuration())
+	_, err = Duration([]string{"foo"})
+	assert.Error(t, err)
+
+	fm, err := FieldMask([]string{"name, age", "email"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"name", "age", "email"}, fm.GetPaths())
+
+	dv, err := DoubleValue([]string{"1.5"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1.5, dv.GetValue())
+	fv, err := FloatValue([]string{"1.5"})
+	assert.NoError(t, err)
+	assert.Equal(t, float32(1.5), fv.GetValue())
+	i64v, err := Int64Value([]string{"-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-1), i64v.GetValue())
+	u64v, err := UInt64Value([]string{"1"})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), u64v.GetValue())
+	i32v, err := Int32Value([]string{"10"})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(10), i32v.GetValue())
+	_, err = Int32Value([]string{"x"})
+	assert.Error(t, err)
+	u32v, err := UInt32Value([]string{"1"})
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(1), u32v.GetValue())
+	bv, err := BoolValue([]string{"false"})
+	assert.NoError(t, err)
+	assert.False(t, bv.GetValue())
+	sv, err := StringValue([]string{"foo"})
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", sv.GetValue())
+	btv, err := BytesValue([]string{"aGVsbG8="})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("hello"), btv.GetValue())
+}
+
+func TestPtr(t *testing.T) {
+	p := Ptr("foo")
+	assert.Equal(t, "foo", *p)
+}
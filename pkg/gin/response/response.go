@@ -6,9 +6,13 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/go-dev-frame/sponge/pkg/encoding"
 	"github.com/go-dev-frame/sponge/pkg/errcode"
 )
 
+// the key of response encoding in gin context
+const encodingKey = "response_encoding"
+
 // Result output data format
 type Result struct {
 	Code int         `json:"code"`
@@ -38,7 +42,7 @@ func respJSONWithStatusCode(c *gin.Context, code int, msg string, data ...interf
 		firstData = data[0]
 	}
 	resp := newResp(code, msg, firstData)
-	c.JSON(code, resp)
+	render(c, code, resp)
 }
 
 // Output return standard HTTP status codes and message, parameter code is HTTP status code
@@ -109,7 +113,7 @@ func respJSONWith200(c *gin.Context, code int, msg string, data ...interface{})
 		firstData = data[0]
 	}
 	resp := newResp(code, msg, firstData)
-	c.JSON(http.StatusOK, resp)
+	render(c, http.StatusOK, resp)
 }
 
 // Success return success
@@ -121,3 +125,28 @@ func Success(c *gin.Context, data ...interface{}) {
 func Error(c *gin.Context, err *errcode.Error, data ...interface{}) {
 	respJSONWith200(c, err.Code(), err.Msg(), data...)
 }
+
+type responseEncoding struct {
+	contentType string
+	encoding    encoding.Encoding
+}
+
+// SetEncoding set the encoding of response for current request, the response is encoded by the encoding
+// instead of json, it is usually called by the middleware.Negotiate, e.g. contentType is application/msgpack.
+func SetEncoding(c *gin.Context, contentType string, e encoding.Encoding) {
+	c.Set(encodingKey, &responseEncoding{contentType: contentType, encoding: e})
+}
+
+// if the encoding of response is set and the encoding is successful, use it, otherwise use json
+func render(c *gin.Context, code int, resp *Result) {
+	if v, ok := c.Get(encodingKey); ok {
+		if re, ok := v.(*responseEncoding); ok {
+			data, err := re.encoding.Marshal(resp)
+			if err == nil {
+				c.Data(code, re.contentType, data)
+				return
+			}
+		}
+	}
+	c.JSON(code, resp)
+}
@@ -3,12 +3,14 @@ package response
 import (
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/go-dev-frame/sponge/pkg/encoding"
 	"github.com/go-dev-frame/sponge/pkg/errcode"
 	"github.com/go-dev-frame/sponge/pkg/httpcli"
 	"github.com/go-dev-frame/sponge/pkg/utils"
@@ -98,3 +100,31 @@ func TestRespond(t *testing.T) {
 		assert.Error(t, err)
 	}
 }
+
+func TestSetEncoding(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	r.GET("/msgpack", func(c *gin.Context) {
+		SetEncoding(c, "application/msgpack", encoding.MsgPackJSONTagEncoding{})
+		Success(c, gin.H{"foo": "bar"})
+	})
+	r.GET("/fallback", func(c *gin.Context) {
+		SetEncoding(c, "application/avro", encoding.AvroEncoding{})
+		Output(c, http.StatusOK, make(chan int)) // avro encoding failed, fallback to json
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/msgpack", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, "application/msgpack", w.Header().Get("Content-Type"))
+	result := &Result{}
+	err := encoding.MsgPackJSONTagEncoding{}.Unmarshal(w.Body.Bytes(), result)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", result.Msg)
+	assert.Equal(t, map[string]interface{}{"foo": "bar"}, result.Data)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/fallback", nil)
+	r.ServeHTTP(w, req)
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+}
@@ -0,0 +1,98 @@
+package gobash
+
+import (
+	"context"
+	"go/build"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// LookPath search for the executable file in the directories of PATH environment variable, if not found,
+// search in the directories of go install (GOBIN and GOPATH/bin), which are often not in PATH on windows.
+func LookPath(name string) (string, error) {
+	file, err := exec.LookPath(name)
+	if err == nil {
+		return file, nil
+	}
+
+	if runtime.GOOS == "windows" && filepath.Ext(name) == "" {
+		name += ".exe"
+	}
+	for _, dir := range GoBinDirs() {
+		file := filepath.Join(dir, name)
+		if fi, e := os.Stat(file); e == nil && !fi.IsDir() {
+			return file, nil
+		}
+	}
+	return "", err
+}
+
+// GoBinDirs the directories of the binaries installed by go install, GOBIN or the bin of each GOPATH
+func GoBinDirs() []string {
+	if gobin := os.Getenv("GOBIN"); gobin != "" {
+		return []string{gobin}
+	}
+	var dirs []string
+	for _, p := range filepath.SplitList(build.Default.GOPATH) {
+		if p != "" {
+			dirs = append(dirs, filepath.Join(p, "bin"))
+		}
+	}
+	return dirs
+}
+
+// PathWithGoBin the value of PATH environment variable appended with the directories of go install,
+// the command such as protoc can find the plugins installed by go install.
+func PathWithGoBin() string {
+	paths := filepath.SplitList(os.Getenv("PATH"))
+	exists := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		exists[p] = true
+	}
+	for _, dir := range GoBinDirs() {
+		if !exists[dir] {
+			paths = append(paths, dir)
+		}
+	}
+	return strings.Join(paths, string(os.PathListSeparator))
+}
+
+// TerminalOption set the options of RunTerminal.
+type TerminalOption func(*exec.Cmd)
+
+// WithDir set the working directory of command
+func WithDir(dir string) TerminalOption {
+	return func(cmd *exec.Cmd) {
+		cmd.Dir = dir
+	}
+}
+
+// WithEnv add environment variables of command, e.g. WithEnv("CGO_ENABLED=0", "GOOS=linux")
+func WithEnv(env ...string) TerminalOption {
+	return func(cmd *exec.Cmd) {
+		cmd.Env = append(cmd.Env, env...)
+	}
+}
+
+// RunTerminal execute the command with the standard input, output and error of current process, it blocks until
+// the command exits or ctx is done, suitable for long-running or interactive commands, such as running a service.
+// The command is searched by LookPath, and PATH of command is appended with the directories of go install.
+func RunTerminal(ctx context.Context, name string, args []string, opts ...TerminalOption) error {
+	cmdName, err := LookPath(name)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, cmdName, args...)
+	cmd.Env = append(os.Environ(), "PATH="+PathWithGoBin())
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	for _, opt := range opts {
+		opt(cmd)
+	}
+	return cmd.Run()
+}
@@ -0,0 +1,55 @@
+package gobash
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookPath(t *testing.T) {
+	file, err := LookPath("go")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, file)
+
+	_, err = LookPath("not-exist-command")
+	assert.Error(t, err)
+
+	// found in GOBIN which is not in PATH
+	dir := t.TempDir()
+	name := "sponge-test-tool"
+	fileName := name
+	if runtime.GOOS == "windows" {
+		fileName += ".exe"
+	}
+	_ = os.WriteFile(filepath.Join(dir, fileName), []byte("#!/bin/sh\n"), 0755)
+	t.Setenv("GOBIN", dir)
+	file, err = LookPath(name)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, fileName), file)
+	assert.Equal(t, []string{dir}, GoBinDirs())
+	assert.True(t, strings.HasSuffix(PathWithGoBin(), string(os.PathListSeparator)+dir))
+
+	t.Setenv("PATH", os.Getenv("PATH")+string(os.PathListSeparator)+dir)
+	assert.Equal(t, os.Getenv("PATH"), PathWithGoBin())
+}
+
+func TestRunTerminal(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	dir := t.TempDir()
+	err := RunTerminal(ctx, "go", []string{"env", "GOOS"}, WithDir(dir), WithEnv("GOOS=linux"))
+	assert.NoError(t, err)
+
+	err = RunTerminal(ctx, "go", []string{"unknown-command"})
+	assert.Error(t, err)
+
+	err = RunTerminal(ctx, "not-exist-command", nil)
+	assert.Error(t, err)
+}
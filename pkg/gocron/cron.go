@@ -2,12 +2,15 @@
 package gocron
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
 )
 
 var (
@@ -16,8 +19,39 @@ var (
 	nameID = sync.Map{}
 	// id and task name mapping, used in log printing
 	idName = sync.Map{}
+	// task name and task mapping, used to catch up the missed runs
+	nameTask = sync.Map{}
+
+	// the cluster settings of the latest Init, captured by the tasks when they are added
+	cl = &cluster{zapLogger: zap.NewNop()}
 )
 
+// cluster is the settings shared by the replicas of a service.
+type cluster struct {
+	elector   Elector
+	store     Store
+	zapLogger *zap.Logger
+}
+
+func (cl *cluster) isLeader() bool {
+	return cl.elector == nil || cl.elector.IsLeader()
+}
+
+// MisfirePolicy is the policy of the runs missed when the service is down or there is no leader,
+// it takes effect only when the store is set by WithStore.
+type MisfirePolicy int
+
+const (
+	// MisfireSkip skips the missed runs, it is the default policy
+	MisfireSkip MisfirePolicy = iota
+	// MisfireRunOnce runs the task once if there are missed runs
+	MisfireRunOnce
+	// MisfireRunAll runs the task for each missed run, up to maxCatchUpRuns times
+	MisfireRunAll
+)
+
+const maxCatchUpRuns = 100
+
 // Task scheduled task
 type Task struct {
 	// seconds (0-59) minutes (0- 59) hours (0-23) days (1-31) months (1-12) weeks (0-6)
@@ -28,6 +62,10 @@ type Task struct {
 	Name      string // task name
 	Fn        func() // task function
 	IsRunOnce bool   // if the task is only run once
+
+	MisfirePolicy MisfirePolicy // policy of the missed runs, default is MisfireSkip
+
+	schedule cron.Schedule
 }
 
 // Init initialize and start timed tasks
@@ -47,11 +85,39 @@ func Init(opts ...Option) error {
 	}
 
 	c = cron.New(cronOpts...)
+	newCl := &cluster{elector: o.elector, store: o.store, zapLogger: o.zapLog}
+	if newCl.zapLogger == nil {
+		newCl.zapLogger = zap.NewNop()
+	}
+	cl = newCl
+	registerMetrics()
+	if newCl.elector != nil {
+		err := newCl.elector.Start(func(isLeader bool) {
+			if isLeader {
+				isLeaderGauge.Set(1)
+				newCl.zapLogger.Info("cron_became leader, start executing tasks")
+				go catchUpAll(newCl)
+			} else {
+				isLeaderGauge.Set(0)
+				newCl.zapLogger.Warn("cron_lost leadership, stop executing tasks")
+			}
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		isLeaderGauge.Set(1)
+	}
 	c.Start()
 
 	return nil
 }
 
+// IsLeader returns whether the current replica executes the tasks, it is always true if the elector is not set.
+func IsLeader() bool {
+	return cl.isLeader()
+}
+
 // Run the tasks
 func Run(tasks ...*Task) error {
 	if c == nil {
@@ -70,13 +136,18 @@ func Run(tasks ...*Task) error {
 			continue
 		}
 
-		id, err := c.AddFunc(task.TimeSpec, task.Fn)
+		id, err := c.AddFunc(task.TimeSpec, wrapTask(cl, task))
 		if err != nil {
 			errs = append(errs, fmt.Sprintf("run task '%s' error: %v", task.Name, err))
 			continue
 		}
+		task.schedule = c.Entry(id).Schedule
 		idName.Store(id, task.Name)
 		nameID.Store(task.Name, id)
+		nameTask.Store(task.Name, task)
+		if cl.isLeader() {
+			go catchUp(cl, task)
+		}
 	}
 
 	if len(errs) > 0 {
@@ -126,14 +197,104 @@ func DeleteTask(name string) {
 		c.Remove(entryID)
 		nameID.Delete(name)
 		idName.Delete(entryID)
+		nameTask.Delete(name)
 	}
 }
 
-// Stop all scheduled tasks
+// Stop all scheduled tasks, and stop campaigning for leadership
 func Stop() {
 	if c != nil {
 		c.Stop()
 	}
+	if cl.elector != nil {
+		_ = cl.elector.Close()
+	}
+}
+
+// wrapTask executes the task only on the leader, records the metrics and the last run time.
+func wrapTask(cl *cluster, task *Task) func() {
+	fn := task.Fn
+	return func() {
+		if !cl.isLeader() {
+			taskRunCount.WithLabelValues(task.Name, resultSkipped).Inc()
+			return
+		}
+
+		begin := time.Now()
+		defer func() {
+			taskDuration.WithLabelValues(task.Name).Observe(time.Since(begin).Seconds())
+			taskLastRun.WithLabelValues(task.Name).Set(float64(begin.Unix()))
+			if cl.store != nil {
+				ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+				if err := cl.store.SetLastRun(ctx, task.Name, begin); err != nil {
+					cl.zapLogger.Warn("cron_save last run time error", zap.String("task", task.Name), zap.Error(err))
+				}
+				cancel()
+			}
+			if e := recover(); e != nil {
+				taskRunCount.WithLabelValues(task.Name, resultFailure).Inc()
+				panic(e) // logged by the recover of cron
+			}
+			taskRunCount.WithLabelValues(task.Name, resultSuccess).Inc()
+		}()
+
+		fn()
+	}
+}
+
+func catchUpAll(cl *cluster) {
+	nameTask.Range(func(key, value interface{}) bool {
+		catchUp(cl, value.(*Task))
+		return true
+	})
+}
+
+// catchUp runs the task according to its misfire policy if there are runs missed since the last run.
+func catchUp(cl *cluster, task *Task) {
+	if cl.store == nil || task.MisfirePolicy == MisfireSkip || task.schedule == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	lastRun, err := cl.store.GetLastRun(ctx, task.Name)
+	cancel()
+	if err != nil {
+		cl.zapLogger.Warn("cron_get last run time error", zap.String("task", task.Name), zap.Error(err))
+		return
+	}
+	missed := missedRuns(task.schedule, lastRun, time.Now())
+	if missed == 0 {
+		return
+	}
+	if task.MisfirePolicy == MisfireRunOnce {
+		missed = 1
+	}
+
+	cl.zapLogger.Info("cron_catch up missed runs", zap.String("task", task.Name), zap.Int("runs", missed),
+		zap.Time("lastRun", lastRun))
+	run := wrapTask(cl, task)
+	for i := 0; i < missed && cl.isLeader(); i++ {
+		func() {
+			defer func() {
+				if e := recover(); e != nil {
+					cl.zapLogger.Error("cron_panic", zap.String("task", task.Name), zap.Any("err", e))
+				}
+			}()
+			run()
+		}()
+	}
+}
+
+// missedRuns returns the number of runs scheduled in (lastRun, now], zero lastRun means the task has never run.
+func missedRuns(schedule cron.Schedule, lastRun time.Time, now time.Time) int {
+	if lastRun.IsZero() {
+		return 0
+	}
+	n := 0
+	for next := schedule.Next(lastRun); !next.IsZero() && !next.After(now) && n < maxCatchUpRuns; next = schedule.Next(next) {
+		n++
+	}
+	return n
 }
 
 // EverySecond every second size (1~59)
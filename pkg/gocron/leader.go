@@ -0,0 +1,214 @@
+package gocron
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+
+	"github.com/go-dev-frame/sponge/pkg/dlock"
+	"github.com/go-dev-frame/sponge/pkg/krand"
+)
+
+// Elector elects a leader among the replicas of a service, only the leader executes the tasks.
+type Elector interface {
+	// Start campaigns for leadership in the background until Close is called,
+	// onChange is called when the leadership of the current replica changes.
+	Start(onChange func(isLeader bool)) error
+	// IsLeader returns whether the current replica is the leader.
+	IsLeader() bool
+	// Close stops campaigning and resigns the leadership.
+	Close() error
+}
+
+// ---------------------------------------------------------------------------------------------
+
+// RedisElector is an Elector based on the redis lock, the leader holds the lock and renews its lease,
+// the other replicas try to acquire the lock every 1/3 ttl.
+type RedisElector struct {
+	locker   dlock.FencedLocker
+	ttl      time.Duration
+	isLeader atomic.Bool
+	lost     chan error
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewRedisElector creates a redis elector, key is the lock key shared by the replicas, ttl is the time the
+// leadership is kept after the leader crashes, default is 10s.
+func NewRedisElector(client redis.UniversalClient, key string, ttl time.Duration) (*RedisElector, error) {
+	if ttl <= 0 {
+		ttl = 10 * time.Second
+	}
+	e := &RedisElector{ttl: ttl, lost: make(chan error, 1)}
+
+	locker, err := dlock.NewRedisFencedLock(client, key, dlock.WithTTL(ttl), dlock.WithAutoRenew(func(err error) {
+		select {
+		case e.lost <- err:
+		default:
+		}
+	}))
+	if err != nil {
+		return nil, err
+	}
+	e.locker = locker
+	return e, nil
+}
+
+// Start campaigns for leadership in the background.
+func (e *RedisElector) Start(onChange func(isLeader bool)) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	e.cancel = cancel
+
+	setLeader := func(isLeader bool) {
+		if e.isLeader.Swap(isLeader) != isLeader && onChange != nil {
+			onChange(isLeader)
+		}
+	}
+
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		ticker := time.NewTicker(e.ttl / 3)
+		defer ticker.Stop()
+
+		for {
+			if !e.isLeader.Load() {
+				ok, err := e.locker.TryLock(ctx)
+				if err == nil && ok {
+					setLeader(true)
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-e.lost:
+				_ = e.locker.Unlock(ctx)
+				setLeader(false)
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return nil
+}
+
+// IsLeader returns whether the current replica is the leader.
+func (e *RedisElector) IsLeader() bool {
+	return e.isLeader.Load()
+}
+
+// Close stops campaigning and releases the lock if it is the leader.
+func (e *RedisElector) Close() error {
+	if e.cancel != nil {
+		e.cancel()
+	}
+	e.wg.Wait()
+	if e.isLeader.Swap(false) {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		return e.locker.Unlock(ctx)
+	}
+	return nil
+}
+
+// ---------------------------------------------------------------------------------------------
+
+// EtcdElector is an Elector based on the etcd election, the leadership is kept by the lease of the etcd session.
+type EtcdElector struct {
+	client   *clientv3.Client
+	key      string
+	ttl      int
+	isLeader atomic.Bool
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewEtcdElector creates an etcd elector, key is the election prefix shared by the replicas,
+// ttl is the ttl of the session lease in seconds, default is 10.
+func NewEtcdElector(client *clientv3.Client, key string, ttl int) (*EtcdElector, error) {
+	if client == nil {
+		return nil, errors.New("etcd client is nil")
+	}
+	if key == "" {
+		return nil, errors.New("key is empty")
+	}
+	if ttl <= 0 {
+		ttl = 10
+	}
+	return &EtcdElector{client: client, key: key, ttl: ttl}, nil
+}
+
+// Start campaigns for leadership in the background.
+func (e *EtcdElector) Start(onChange func(isLeader bool)) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	e.cancel = cancel
+	id := krand.NewStringID()
+
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		for ctx.Err() == nil {
+			e.campaign(ctx, id, onChange)
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second): // campaign again after the session is lost
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (e *EtcdElector) campaign(ctx context.Context, id string, onChange func(isLeader bool)) {
+	session, err := concurrency.NewSession(e.client, concurrency.WithTTL(e.ttl), concurrency.WithContext(ctx))
+	if err != nil {
+		return
+	}
+	defer session.Close() //nolint
+
+	election := concurrency.NewElection(session, e.key)
+	if err = election.Campaign(ctx, id); err != nil {
+		return
+	}
+
+	e.isLeader.Store(true)
+	if onChange != nil {
+		onChange(true)
+	}
+
+	select {
+	case <-session.Done(): // the lease is lost
+	case <-ctx.Done():
+		resignCtx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		_ = election.Resign(resignCtx)
+		cancel()
+	}
+
+	e.isLeader.Store(false)
+	if onChange != nil {
+		onChange(false)
+	}
+}
+
+// IsLeader returns whether the current replica is the leader.
+func (e *EtcdElector) IsLeader() bool {
+	return e.isLeader.Load()
+}
+
+// Close stops campaigning and resigns the leadership.
+func (e *EtcdElector) Close() error {
+	if e.cancel != nil {
+		e.cancel()
+	}
+	e.wg.Wait()
+	return nil
+}
@@ -0,0 +1,102 @@
+package gocron
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/robfig/cron/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRedisClient(t *testing.T) *redis.Client {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func TestRedisElector(t *testing.T) {
+	client := newRedisClient(t)
+
+	var changes atomic.Int32
+	e1, err := NewRedisElector(client, "test:leader", 300*time.Millisecond)
+	require.NoError(t, err)
+	e2, err := NewRedisElector(client, "test:leader", 300*time.Millisecond)
+	require.NoError(t, err)
+	onChange := func(isLeader bool) { changes.Add(1) }
+	require.NoError(t, e1.Start(onChange))
+	assert.Eventually(t, e1.IsLeader, time.Second, 10*time.Millisecond)
+	require.NoError(t, e2.Start(onChange))
+	time.Sleep(200 * time.Millisecond)
+	assert.False(t, e2.IsLeader())
+
+	// the other replica becomes the leader after the leader exits
+	require.NoError(t, e1.Close())
+	assert.False(t, e1.IsLeader())
+	assert.Eventually(t, e2.IsLeader, time.Second, 10*time.Millisecond)
+	require.NoError(t, e2.Close())
+	assert.Equal(t, int32(2), changes.Load())
+}
+
+func TestNewEtcdElector(t *testing.T) {
+	_, err := NewEtcdElector(nil, "test", 10)
+	assert.Error(t, err)
+}
+
+func TestRedisStore(t *testing.T) {
+	s := NewRedisStore(newRedisClient(t), "")
+	ctx := context.Background()
+
+	lastRun, err := s.GetLastRun(ctx, "task1")
+	require.NoError(t, err)
+	assert.True(t, lastRun.IsZero())
+
+	now := time.Now()
+	require.NoError(t, s.SetLastRun(ctx, "task1", now))
+	lastRun, err = s.GetLastRun(ctx, "task1")
+	require.NoError(t, err)
+	assert.Equal(t, now.UnixMilli(), lastRun.UnixMilli())
+}
+
+func TestMissedRuns(t *testing.T) {
+	schedule := cron.Every(time.Minute)
+	now := time.Now()
+	assert.Equal(t, 0, missedRuns(schedule, time.Time{}, now))
+	assert.Equal(t, 0, missedRuns(schedule, now.Add(-30*time.Second), now))
+	assert.Equal(t, 3, missedRuns(schedule, now.Add(-3*time.Minute), now))
+	assert.Equal(t, maxCatchUpRuns, missedRuns(schedule, now.Add(-24*time.Hour), now))
+}
+
+func TestMisfireCatchUp(t *testing.T) {
+	client := newRedisClient(t)
+	s := NewRedisStore(client, "test:last_run")
+	ctx := context.Background()
+	require.NoError(t, s.SetLastRun(ctx, "catchUpAll", time.Now().Add(-3*time.Hour)))
+	require.NoError(t, s.SetLastRun(ctx, "catchUpOnce", time.Now().Add(-3*time.Hour)))
+	elector, err := NewRedisElector(client, "test:cron", time.Second)
+	require.NoError(t, err)
+
+	var allCount, onceCount atomic.Int32
+	require.NoError(t, Init(WithLog(nil), WithStore(s), WithElector(elector)))
+	defer Stop()
+	err = Run(
+		&Task{Name: "catchUpAll", TimeSpec: EveryHour(1), Fn: func() { allCount.Add(1) }, MisfirePolicy: MisfireRunAll},
+		&Task{Name: "catchUpOnce", TimeSpec: EveryHour(1), Fn: func() { onceCount.Add(1) }, MisfirePolicy: MisfireRunOnce},
+	)
+	require.NoError(t, err)
+
+	// caught up after becoming the leader
+	assert.Eventually(t, func() bool { return allCount.Load() == 3 && onceCount.Load() == 1 }, 2*time.Second, 10*time.Millisecond)
+	lastRun, err := s.GetLastRun(ctx, "catchUpAll")
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), lastRun, time.Second)
+	DeleteTask("catchUpAll")
+	DeleteTask("catchUpOnce")
+}
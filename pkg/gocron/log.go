@@ -17,6 +17,9 @@ type options struct {
 	isOnlyPrintError bool // default false
 
 	granularity int // 0: second, 1: minute
+
+	elector Elector
+	store   Store
 }
 
 func defaultOptions() *options {
@@ -59,6 +62,22 @@ func WithLog(log *zap.Logger, isOnlyPrintError ...bool) Option {
 	}
 }
 
+// WithElector set the leader elector, in multi-replica deployments, only the leader executes the tasks,
+// e.g. NewRedisElector, NewEtcdElector
+func WithElector(e Elector) Option {
+	return func(o *options) {
+		o.elector = e
+	}
+}
+
+// WithStore set the store of the last run time of tasks, it is required by the misfire policies
+// of tasks to catch up the missed runs, e.g. NewRedisStore
+func WithStore(s Store) Option {
+	return func(o *options) {
+		o.store = s
+	}
+}
+
 type zapLog struct {
 	zapLog           *zap.Logger
 	isOnlyPrintError bool
@@ -0,0 +1,56 @@
+package gocron
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// results of running tasks
+const (
+	resultSuccess = "success"
+	resultFailure = "failure"
+	resultSkipped = "skipped" // not the leader
+)
+
+var (
+	taskRunCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gocron_task_runs_total",
+			Help: "Total number of task runs, result is success, failure or skipped.",
+		},
+		[]string{"task", "result"},
+	)
+
+	taskDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "gocron_task_duration_seconds",
+			Help:    "Duration of task runs in seconds.",
+			Buckets: []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300},
+		},
+		[]string{"task"},
+	)
+
+	taskLastRun = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gocron_task_last_run_timestamp_seconds",
+			Help: "Unix timestamp of the last run of tasks.",
+		},
+		[]string{"task"},
+	)
+
+	isLeaderGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "gocron_is_leader",
+			Help: "Whether the current replica is the leader that executes tasks, 1 is the leader.",
+		},
+	)
+
+	registerOnce sync.Once
+)
+
+func registerMetrics() {
+	registerOnce.Do(func() {
+		prometheus.MustRegister(taskRunCount, taskDuration, taskLastRun, isLeaderGauge)
+	})
+}
@@ -0,0 +1,55 @@
+package gocron
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Store persists the last run time of tasks, it is used to catch up the missed runs after restarting
+// or switching the leader.
+type Store interface {
+	// GetLastRun returns the last run time of the task, zero time means the task has never run.
+	GetLastRun(ctx context.Context, name string) (time.Time, error)
+	// SetLastRun sets the last run time of the task.
+	SetLastRun(ctx context.Context, name string, t time.Time) error
+}
+
+// RedisStore is a Store based on redis hash, the field is the task name, the value is the last run time
+// in unix milliseconds.
+type RedisStore struct {
+	client redis.UniversalClient
+	key    string
+}
+
+// NewRedisStore creates a redis store, key is the key of the redis hash, default is "gocron:last_run".
+func NewRedisStore(client redis.UniversalClient, key string) *RedisStore {
+	if key == "" {
+		key = "gocron:last_run"
+	}
+	return &RedisStore{client: client, key: key}
+}
+
+// GetLastRun returns the last run time of the task.
+func (s *RedisStore) GetLastRun(ctx context.Context, name string) (time.Time, error) {
+	val, err := s.client.HGet(ctx, s.key, name).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+	ms, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(ms), nil
+}
+
+// SetLastRun sets the last run time of the task.
+func (s *RedisStore) SetLastRun(ctx context.Context, name string, t time.Time) error {
+	return s.client.HSet(ctx, s.key, name, t.UnixMilli()).Err()
+}
@@ -4,16 +4,22 @@ package goredis
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
-	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 )
 
 // Client is a redis client
 type Client = redis.Client
 
+// ClusterClient is a redis cluster client
+type ClusterClient = redis.ClusterClient
+
+// UniversalClient is the common interface of single, sentinel and cluster clients
+type UniversalClient = redis.UniversalClient
+
 const (
 	// ErrRedisNotFound not exist in redis
 	ErrRedisNotFound = redis.Nil
@@ -21,6 +27,68 @@ const (
 	DefaultRedisName = "default"
 )
 
+// deployment modes of redis
+const (
+	ModeSingle   = "single"
+	ModeSentinel = "sentinel"
+	ModeCluster  = "cluster"
+)
+
+// Config the connection settings of redis, the fields used depend on the mode.
+type Config struct {
+	Mode       string   // single (default), sentinel or cluster
+	Dsn        string   // used in single mode, e.g. default:123456@127.0.0.1:6379/0
+	Addrs      []string // addresses of sentinels in sentinel mode, or addresses of nodes in cluster mode
+	MasterName string   // name of master, used in sentinel mode
+	Username   string   // used in sentinel and cluster mode
+	Password   string   // used in sentinel and cluster mode
+	DB         int      // used in sentinel mode
+}
+
+// InitByConfig connecting to redis in the mode of config, the returned client is *Client in single and
+// sentinel mode, and *ClusterClient in cluster mode.
+func InitByConfig(cfg *Config, opts ...Option) (UniversalClient, error) {
+	if cfg == nil {
+		return nil, errors.New("redis config is nil")
+	}
+
+	switch strings.ToLower(cfg.Mode) {
+	case "", ModeSingle:
+		if cfg.Dsn == "" {
+			return nil, errors.New("redis dsn is empty in single mode")
+		}
+		return Init(cfg.Dsn, opts...)
+
+	case ModeSentinel:
+		if cfg.MasterName == "" || len(cfg.Addrs) == 0 {
+			return nil, errors.New("redis masterName and addrs are required in sentinel mode")
+		}
+		o := defaultOptions()
+		o.apply(opts...)
+		// the sentinel options passed by caller take precedence
+		opts = append([]Option{WithSentinelOptions(&redis.FailoverOptions{
+			MasterName:    cfg.MasterName,
+			SentinelAddrs: cfg.Addrs,
+			Username:      cfg.Username,
+			Password:      cfg.Password,
+			DB:            cfg.DB,
+			DialTimeout:   o.dialTimeout,
+			ReadTimeout:   o.readTimeout,
+			WriteTimeout:  o.writeTimeout,
+			TLSConfig:     o.tlsConfig,
+		})}, opts...)
+		return InitSentinel(cfg.MasterName, cfg.Addrs, cfg.Username, cfg.Password, opts...)
+
+	case ModeCluster:
+		if len(cfg.Addrs) == 0 {
+			return nil, errors.New("redis addrs are required in cluster mode")
+		}
+		return InitCluster(cfg.Addrs, cfg.Username, cfg.Password, opts...)
+	}
+
+	return nil, fmt.Errorf("unsupported redis mode %s, support single, sentinel and cluster", cfg.Mode)
+}
+
 // Init connecting to redis
 // dsn supported formats.
 // (1) no password, no db: localhost:6379
@@ -43,11 +111,8 @@ func Init(dsn string, opts ...Option) (*redis.Client, error) {
 
 	rdb := redis.NewClient(opt)
 
-	if o.tracerProvider != nil {
-		err = redisotel.InstrumentTracing(rdb, redisotel.WithTracerProvider(o.tracerProvider))
-		if err != nil {
-			return nil, err
-		}
+	if err = o.instrument(rdb); err != nil {
+		return nil, err
 	}
 
 	ctx, _ := context.WithTimeout(context.Background(), 15*time.Second) //nolint
@@ -78,11 +143,8 @@ func InitSingle(addr string, password string, db int, opts ...Option) (*redis.Cl
 
 	rdb := redis.NewClient(opt)
 
-	if o.tracerProvider != nil {
-		err := redisotel.InstrumentTracing(rdb, redisotel.WithTracerProvider(o.tracerProvider))
-		if err != nil {
-			return nil, err
-		}
+	if err := o.instrument(rdb); err != nil {
+		return nil, err
 	}
 
 	ctx, _ := context.WithTimeout(context.Background(), 15*time.Second) //nolint
@@ -114,11 +176,8 @@ func InitSentinel(masterName string, addrs []string, username string, password s
 
 	rdb := redis.NewFailoverClient(opt)
 
-	if o.tracerProvider != nil {
-		err := redisotel.InstrumentTracing(rdb, redisotel.WithTracerProvider(o.tracerProvider))
-		if err != nil {
-			return nil, err
-		}
+	if err := o.instrument(rdb); err != nil {
+		return nil, err
 	}
 
 	ctx, _ := context.WithTimeout(context.Background(), 15*time.Second) //nolint
@@ -149,11 +208,8 @@ func InitCluster(addrs []string, username string, password string, opts ...Optio
 
 	clusterRdb := redis.NewClusterClient(opt)
 
-	if o.tracerProvider != nil {
-		err := redisotel.InstrumentTracing(clusterRdb, redisotel.WithTracerProvider(o.tracerProvider))
-		if err != nil {
-			return nil, err
-		}
+	if err := o.instrument(clusterRdb); err != nil {
+		return nil, err
 	}
 
 	ctx, _ := context.WithTimeout(context.Background(), 15*time.Second) //nolint
@@ -224,3 +280,17 @@ func CloseCluster(clusterRdb *redis.ClusterClient) error {
 
 	return nil
 }
+
+// CloseClient close the client returned by InitByConfig
+func CloseClient(rdb UniversalClient) error {
+	if rdb == nil {
+		return nil
+	}
+
+	err := rdb.Close()
+	if err != nil && errors.Is(err, redis.ErrClosed) {
+		return err
+	}
+
+	return nil
+}
@@ -1,11 +1,14 @@
 package goredis
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
 
 	"github.com/alicebob/miniredis/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -119,3 +122,73 @@ func TestInitCluster(t *testing.T) {
 	assert.Nil(t, err)
 	assert.NotNil(t, clusterRdb)
 }
+
+func TestInitByConfig(t *testing.T) {
+	redisServer, _ := miniredis.Run()
+	defer redisServer.Close()
+	addr := redisServer.Addr()
+
+	rdb, err := InitByConfig(&Config{Dsn: addr}, WithDialTimeout(time.Second))
+	assert.NoError(t, err)
+	_, ok := rdb.(*Client)
+	assert.True(t, ok)
+	assert.NoError(t, CloseClient(rdb))
+
+	rdb, err = InitByConfig(&Config{Mode: ModeSentinel, MasterName: "mymaster", Addrs: []string{addr}, DB: 1},
+		WithDialTimeout(time.Second))
+	t.Log(err)
+	assert.NotNil(t, rdb)
+	_ = CloseClient(rdb)
+
+	rdb, err = InitByConfig(&Config{Mode: ModeCluster, Addrs: []string{addr}}, WithDialTimeout(time.Second))
+	t.Log(err)
+	_, ok = rdb.(*ClusterClient)
+	assert.True(t, ok)
+	_ = CloseClient(rdb)
+
+	// error test
+	configs := []*Config{
+		nil,
+		{Mode: ModeSingle},
+		{Mode: ModeSentinel, Addrs: []string{addr}},
+		{Mode: ModeSentinel, MasterName: "mymaster"},
+		{Mode: ModeCluster},
+		{Mode: "unknown", Dsn: addr},
+	}
+	for _, cfg := range configs {
+		_, err = InitByConfig(cfg)
+		assert.Error(t, err)
+	}
+
+	assert.NoError(t, CloseClient(nil))
+}
+
+func TestWithMetrics(t *testing.T) {
+	redisServer, _ := miniredis.Run()
+	defer redisServer.Close()
+
+	rdb, err := InitByConfig(&Config{Dsn: redisServer.Addr()}, WithMetrics("test_metrics"))
+	assert.NoError(t, err)
+	defer CloseClient(rdb)
+
+	ctx := context.Background()
+	assert.NoError(t, rdb.Set(ctx, "foo", "bar", time.Minute).Err())
+	assert.ErrorIs(t, rdb.Get(ctx, "not_found").Err(), redis.Nil)
+	assert.Error(t, rdb.Incr(ctx, "foo").Err())
+	_, err = rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Get(ctx, "foo")
+		return nil
+	})
+	assert.NoError(t, err)
+
+	assert.Greater(t, testutil.CollectAndCount(commandDuration), 0)
+	assert.Equal(t, float64(0), testutil.ToFloat64(commandErrors.WithLabelValues("test_metrics", "get")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(commandErrors.WithLabelValues("test_metrics", "incr")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(commandErrors.WithLabelValues("test_metrics", "pipeline")))
+	assert.Greater(t, testutil.CollectAndCount(poolCollector), 0)
+
+	// the default name
+	o := defaultOptions()
+	o.apply(WithMetrics(""))
+	assert.Equal(t, DefaultRedisName, o.metricsName)
+}
@@ -0,0 +1,139 @@
+package goredis
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	commandDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "redis_client_command_duration_seconds",
+			Help:    "Duration of redis commands in seconds, the command of pipeline is pipeline.",
+			Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		},
+		[]string{"name", "command"},
+	)
+
+	commandErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "redis_client_command_errors_total",
+			Help: "Total number of failed redis commands, redis.Nil is not counted.",
+		},
+		[]string{"name", "command"},
+	)
+
+	dialErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "redis_client_dial_errors_total",
+			Help: "Total number of failed dials to redis.",
+		},
+		[]string{"name"},
+	)
+
+	poolCollector = &poolStatsCollector{
+		clients:     make(map[string]func() *redis.PoolStats),
+		hits:        prometheus.NewDesc("redis_client_pool_hits_total", "Number of times a free connection was found in the pool.", []string{"name"}, nil),
+		misses:      prometheus.NewDesc("redis_client_pool_misses_total", "Number of times a free connection was not found in the pool.", []string{"name"}, nil),
+		timeouts:    prometheus.NewDesc("redis_client_pool_timeouts_total", "Number of times a wait timeout occurred.", []string{"name"}, nil),
+		connections: prometheus.NewDesc("redis_client_pool_connections", "Number of connections in the pool, state is total, idle or stale.", []string{"name", "state"}, nil),
+	}
+
+	registerOnce sync.Once
+)
+
+func registerMetrics() {
+	registerOnce.Do(func() {
+		prometheus.MustRegister(commandDuration, commandErrors, dialErrors, poolCollector)
+	})
+}
+
+// poolStatsCollector collects the pool stats of clients when prometheus scrapes.
+type poolStatsCollector struct {
+	mu      sync.RWMutex
+	clients map[string]func() *redis.PoolStats
+
+	hits        *prometheus.Desc
+	misses      *prometheus.Desc
+	timeouts    *prometheus.Desc
+	connections *prometheus.Desc
+}
+
+func (c *poolStatsCollector) add(name string, stats func() *redis.PoolStats) {
+	c.mu.Lock()
+	c.clients[name] = stats
+	c.mu.Unlock()
+}
+
+// Describe implements prometheus.Collector
+func (c *poolStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.timeouts
+	ch <- c.connections
+}
+
+// Collect implements prometheus.Collector
+func (c *poolStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for name, fn := range c.clients {
+		s := fn()
+		if s == nil {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(s.Hits), name)
+		ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(s.Misses), name)
+		ch <- prometheus.MustNewConstMetric(c.timeouts, prometheus.CounterValue, float64(s.Timeouts), name)
+		ch <- prometheus.MustNewConstMetric(c.connections, prometheus.GaugeValue, float64(s.TotalConns), name, "total")
+		ch <- prometheus.MustNewConstMetric(c.connections, prometheus.GaugeValue, float64(s.IdleConns), name, "idle")
+		ch <- prometheus.MustNewConstMetric(c.connections, prometheus.GaugeValue, float64(s.StaleConns), name, "stale")
+	}
+}
+
+// metricsHook records the latency and errors of commands.
+type metricsHook struct {
+	name string
+}
+
+func (h *metricsHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := next(ctx, network, addr)
+		if err != nil {
+			dialErrors.WithLabelValues(h.name).Inc()
+		}
+		return conn, err
+	}
+}
+
+func (h *metricsHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		h.observe(cmd.Name(), start, err)
+		return err
+	}
+}
+
+func (h *metricsHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		h.observe("pipeline", start, err)
+		return err
+	}
+}
+
+func (h *metricsHook) observe(command string, start time.Time, err error) {
+	commandDuration.WithLabelValues(h.name, command).Observe(time.Since(start).Seconds())
+	if err != nil && !errors.Is(err, redis.Nil) {
+		commandErrors.WithLabelValues(h.name, command).Inc()
+	}
+}
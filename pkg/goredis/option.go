@@ -4,6 +4,7 @@ import (
 	"crypto/tls"
 	"time"
 
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 	"go.opentelemetry.io/otel/sdk/trace"
 )
@@ -29,6 +30,9 @@ type options struct {
 	// deprecated: use tp instead
 	enableTrace    bool
 	tracerProvider *trace.TracerProvider
+
+	enableMetrics bool
+	metricsName   string // label of the metrics to distinguish the clients
 }
 
 func (o *options) apply(opts ...Option) {
@@ -59,6 +63,19 @@ func WithTracing(tp *trace.TracerProvider) Option {
 	}
 }
 
+// WithMetrics register the prometheus metrics of command latency, command errors and connection pool,
+// name is the label to distinguish the clients, if empty, it is "default". The metrics of pool stats
+// are replaced by the latest client if the name is the same.
+func WithMetrics(name string) Option {
+	return func(o *options) {
+		o.enableMetrics = true
+		o.metricsName = name
+		if o.metricsName == "" {
+			o.metricsName = DefaultRedisName
+		}
+	}
+}
+
 // WithDialTimeout set dail timeout
 func WithDialTimeout(t time.Duration) Option {
 	return func(o *options) {
@@ -107,3 +124,21 @@ func WithClusterOptions(opt *redis.ClusterOptions) Option {
 		o.clusterOptions = opt
 	}
 }
+
+// instrument add the tracing and metrics hooks to the client.
+func (o *options) instrument(rdb redis.UniversalClient) error {
+	if o.tracerProvider != nil {
+		err := redisotel.InstrumentTracing(rdb, redisotel.WithTracerProvider(o.tracerProvider))
+		if err != nil {
+			return err
+		}
+	}
+
+	if o.enableMetrics {
+		registerMetrics()
+		rdb.AddHook(&metricsHook{name: o.metricsName})
+		poolCollector.add(o.metricsName, rdb.PoolStats)
+	}
+
+	return nil
+}
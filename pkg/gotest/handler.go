@@ -18,7 +18,7 @@ type Handler struct {
 	MockDao  *Dao
 	IHandler interface{}
 
-	Engine      *gin.Engine
+	Engine      *gin.Engine // set by GoRunHTTPServer or SetupRouter, it serves the requests built by Handler.NewRequest
 	HTTPServer  *http.Server
 	httpAddr    string
 	requestAddr string
@@ -72,6 +72,7 @@ func (h *Handler) GoRunHTTPServer(fns []RouterInfo) {
 		h.routers[strings.ToLower(fn.FuncName)] = fn
 	}
 
+	h.Engine = r
 	h.HTTPServer = &http.Server{
 		Addr:    h.httpAddr,
 		Handler: r,
@@ -84,6 +85,21 @@ func (h *Handler) GoRunHTTPServer(fns []RouterInfo) {
 	}()
 }
 
+// SetupRouter create the gin engine and register the router functions to the group path, it is the same as
+// the routers of service, e.g. h.SetupRouter("/api/v1", func(group *gin.RouterGroup) { userExampleRouter(group, iHandler) }),
+// the requests built by Handler.GET, Handler.POST etc. are served by the engine in memory, no port is listened.
+func (h *Handler) SetupRouter(groupPath string, fns ...func(group *gin.RouterGroup)) *gin.Engine {
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	r.Use(gin.Recovery())
+	group := r.Group(groupPath)
+	for _, fn := range fns {
+		fn(group)
+	}
+	h.Engine = r
+	return r
+}
+
 // GetRequestURL get request url from name
 func (h *Handler) GetRequestURL(funcName string, pathVal ...interface{}) string {
 	fn, ok := h.routers[strings.ToLower(funcName)]
@@ -91,12 +107,17 @@ func (h *Handler) GetRequestURL(funcName string, pathVal ...interface{}) string
 		return ""
 	}
 
-	varCount := strings.Count(fn.Path, "/:")
+	return h.requestAddr + fillPath(fn.Path, pathVal...)
+}
+
+// replace the path parameters with values in order, e.g. /user/:id --> /user/1
+func fillPath(path string, pathVal ...interface{}) string {
+	varCount := strings.Count(path, "/:")
 	if varCount == 0 || varCount != len(pathVal) {
-		return h.requestAddr + "/" + strings.TrimLeft(fn.Path, "/")
+		return "/" + strings.TrimLeft(path, "/")
 	}
 
-	ss := strings.Split(fn.Path, "/")
+	ss := strings.Split(path, "/")
 	var subPaths []string
 	j := 0
 	for _, s := range ss {
@@ -109,7 +130,7 @@ func (h *Handler) GetRequestURL(funcName string, pathVal ...interface{}) string
 			}
 		}
 	}
-	return h.requestAddr + "/" + strings.TrimLeft(strings.Join(subPaths, "/"), "/")
+	return "/" + strings.TrimLeft(strings.Join(subPaths, "/"), "/")
 }
 
 // Close handler
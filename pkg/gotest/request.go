@@ -0,0 +1,229 @@
+package gotest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Request http request of handler test, it is served by Handler.Engine in memory, so the routing,
+// middlewares, parameters binding and response of handler are tested together.
+type Request struct {
+	h      *Handler
+	method string
+	path   string
+	query  url.Values
+	header http.Header
+	body   []byte
+	err    error
+}
+
+// NewRequest create a request, the path parameters are replaced with pathVal in order,
+// e.g. NewRequest(http.MethodGet, "/api/v1/user/:id", 1) --> GET /api/v1/user/1
+func (h *Handler) NewRequest(method string, path string, pathVal ...interface{}) *Request {
+	return &Request{
+		h:      h,
+		method: method,
+		path:   fillPath(path, pathVal...),
+		query:  url.Values{},
+		header: http.Header{},
+	}
+}
+
+// GET create a GET request
+func (h *Handler) GET(path string, pathVal ...interface{}) *Request {
+	return h.NewRequest(http.MethodGet, path, pathVal...)
+}
+
+// POST create a POST request
+func (h *Handler) POST(path string, pathVal ...interface{}) *Request {
+	return h.NewRequest(http.MethodPost, path, pathVal...)
+}
+
+// PUT create a PUT request
+func (h *Handler) PUT(path string, pathVal ...interface{}) *Request {
+	return h.NewRequest(http.MethodPut, path, pathVal...)
+}
+
+// PATCH create a PATCH request
+func (h *Handler) PATCH(path string, pathVal ...interface{}) *Request {
+	return h.NewRequest(http.MethodPatch, path, pathVal...)
+}
+
+// DELETE create a DELETE request
+func (h *Handler) DELETE(path string, pathVal ...interface{}) *Request {
+	return h.NewRequest(http.MethodDelete, path, pathVal...)
+}
+
+// WithQuery add a query parameter
+func (r *Request) WithQuery(key string, value interface{}) *Request {
+	r.query.Add(key, fmt.Sprintf("%v", value))
+	return r
+}
+
+// WithHeader set a header
+func (r *Request) WithHeader(key string, value string) *Request {
+	r.header.Set(key, value)
+	return r
+}
+
+// WithToken set the header Authorization: Bearer token
+func (r *Request) WithToken(token string) *Request {
+	return r.WithHeader("Authorization", "Bearer "+token)
+}
+
+// WithJSON set the body of request, the obj is encoded as json, the []byte and string are sent as is
+func (r *Request) WithJSON(obj interface{}) *Request {
+	switch v := obj.(type) {
+	case []byte:
+		r.body = v
+	case string:
+		r.body = []byte(v)
+	default:
+		r.body, r.err = json.Marshal(obj)
+	}
+	r.header.Set("Content-Type", "application/json")
+	return r
+}
+
+// Do send the request to Handler.Engine and get the response
+func (r *Request) Do() *Response {
+	if r.err != nil {
+		return &Response{err: r.err}
+	}
+	if r.h.Engine == nil {
+		return &Response{err: fmt.Errorf("gin engine is nil, call SetupRouter or GoRunHTTPServer first")}
+	}
+
+	target := r.path
+	if len(r.query) > 0 {
+		target += "?" + r.query.Encode()
+	}
+	req := httptest.NewRequest(r.method, target, bytes.NewReader(r.body))
+	for k, v := range r.header {
+		req.Header[k] = v
+	}
+
+	w := httptest.NewRecorder()
+	r.h.Engine.ServeHTTP(w, req)
+	return &Response{
+		StatusCode: w.Code,
+		Header:     w.Header(),
+		Body:       w.Body.Bytes(),
+	}
+}
+
+// Response http response of handler test
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+
+	err error
+}
+
+// Err the error of building request
+func (r *Response) Err() error {
+	return r.err
+}
+
+// Decode decode the json body to obj
+func (r *Response) Decode(obj interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	return json.Unmarshal(r.Body, obj)
+}
+
+// JSONValue get the value of json body by path, the keys are separated by dot, the index of array is number,
+// e.g. data.list.0.id, the numbers are float64, the same as json.Unmarshal to interface{}.
+func (r *Response) JSONValue(path string) (interface{}, error) {
+	var v interface{}
+	if err := r.Decode(&v); err != nil {
+		return nil, err
+	}
+	if path == "" {
+		return v, nil
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]interface{}:
+			value, ok := node[key]
+			if !ok {
+				return nil, fmt.Errorf("json path '%s' not found, key '%s' does not exist", path, key)
+			}
+			v = value
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, fmt.Errorf("json path '%s' not found, index '%s' is out of range", path, key)
+			}
+			v = node[i]
+		default:
+			return nil, fmt.Errorf("json path '%s' not found, '%s' is not object or array", path, key)
+		}
+	}
+	return v, nil
+}
+
+// AssertStatus check the http status code
+func (r *Response) AssertStatus(t testing.TB, code int) *Response {
+	t.Helper()
+	if r.err != nil {
+		t.Errorf("request error: %v", r.err)
+		return r
+	}
+	if r.StatusCode != code {
+		t.Errorf("status code is %d, expected %d, body: %s", r.StatusCode, code, r.Body)
+	}
+	return r
+}
+
+// AssertCode check the field code of json body, it is the code of ecode returned by response.Error and response.Success,
+// e.g. AssertCode(t, ecode.InvalidParams.Code())
+func (r *Response) AssertCode(t testing.TB, code int) *Response {
+	t.Helper()
+	return r.AssertJSON(t, "code", code)
+}
+
+// AssertJSON check the value of json body by path, see JSONValue for the path, the expected value is compared
+// after json encoding, so the number types are not distinguished.
+func (r *Response) AssertJSON(t testing.TB, path string, expected interface{}) *Response {
+	t.Helper()
+	actual, err := r.JSONValue(path)
+	if err != nil {
+		t.Errorf("%v, body: %s", err, r.Body)
+		return r
+	}
+
+	var want interface{}
+	data, err := json.Marshal(expected)
+	if err == nil {
+		err = json.Unmarshal(data, &want)
+	}
+	if err != nil {
+		t.Errorf("json encode expected value error: %v", err)
+		return r
+	}
+	if !reflect.DeepEqual(actual, want) {
+		t.Errorf("json path '%s' is %v, expected %v, body: %s", path, actual, want, r.Body)
+	}
+	return r
+}
+
+// AssertJSONExists check the path exists in json body
+func (r *Response) AssertJSONExists(t testing.TB, path string) *Response {
+	t.Helper()
+	if _, err := r.JSONValue(path); err != nil {
+		t.Errorf("%v, body: %s", err, r.Body)
+	}
+	return r
+}
@@ -0,0 +1,115 @@
+package gotest
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// record the errors of assertion instead of failing the test
+type mockT struct {
+	testing.TB
+	errs []string
+}
+
+func (m *mockT) Helper() {}
+
+func (m *mockT) Errorf(format string, args ...interface{}) {
+	m.errs = append(m.errs, fmt.Sprintf(format, args...))
+}
+
+func newRouterHandler() *Handler {
+	h := newHandler()
+	h.SetupRouter("/api/v1", func(group *gin.RouterGroup) {
+		g := group.Group("/user")
+		g.POST("/", func(c *gin.Context) {
+			form := &struct {
+				Name string `json:"name" binding:"required"`
+			}{}
+			if err := c.ShouldBindJSON(form); err != nil {
+				c.JSON(http.StatusOK, gin.H{"code": 100001, "msg": "Invalid Parameter", "data": gin.H{}})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "ok", "data": gin.H{"id": 1, "name": form.Name}})
+		})
+		g.GET("/:id", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "ok", "data": gin.H{
+				"id":    c.Param("id"),
+				"token": c.GetHeader("Authorization"),
+				"list":  []gin.H{{"page": c.Query("page")}},
+			}})
+		})
+		g.PUT("/:id", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+		g.PATCH("/:id", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+		g.DELETE("/:id", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	})
+	return h
+}
+
+func TestHandler_Request(t *testing.T) {
+	h := newRouterHandler()
+	defer h.Close()
+
+	h.POST("/api/v1/user/").WithJSON(map[string]string{"name": "foo"}).Do().
+		AssertStatus(t, http.StatusOK).
+		AssertCode(t, 0).
+		AssertJSON(t, "data.id", 1).
+		AssertJSON(t, "data.name", "foo")
+
+	// binding error
+	h.POST("/api/v1/user/").WithJSON(`{}`).Do().AssertCode(t, 100001)
+
+	resp := h.GET("/api/v1/user/:id", 10).WithQuery("page", 2).WithToken("abc").Do().
+		AssertJSON(t, "data.id", "10").
+		AssertJSON(t, "data.token", "Bearer abc").
+		AssertJSON(t, "data.list.0.page", "2").
+		AssertJSONExists(t, "msg")
+	result := &struct {
+		Code int `json:"code"`
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}{}
+	assert.NoError(t, resp.Decode(result))
+	assert.Equal(t, "10", result.Data.ID)
+
+	h.PUT("/api/v1/user/:id", 1).Do().AssertStatus(t, http.StatusNoContent)
+	h.PATCH("/api/v1/user/:id", 1).Do().AssertStatus(t, http.StatusNoContent)
+	h.DELETE("/api/v1/user/:id", 1).Do().AssertStatus(t, http.StatusNoContent)
+	h.GET("/api/v1/not_found").Do().AssertStatus(t, http.StatusNotFound)
+}
+
+func TestResponse_AssertError(t *testing.T) {
+	h := newRouterHandler()
+	defer h.Close()
+
+	m := &mockT{}
+	h.GET("/api/v1/user/:id", 10).Do().
+		AssertStatus(m, http.StatusCreated).
+		AssertCode(m, 1).
+		AssertJSON(m, "data.id", 10).
+		AssertJSON(m, "data.list.1.page", "").
+		AssertJSON(m, "data.id.foo", "").
+		AssertJSONExists(m, "data.foo")
+	assert.Len(t, m.errs, 6)
+
+	m = &mockT{}
+	resp := h.POST("/api/v1/user/").WithJSON(func() {}).Do()
+	assert.Error(t, resp.Err())
+	resp.AssertStatus(m, http.StatusOK).AssertJSON(m, "code", 0)
+	assert.Len(t, m.errs, 2)
+
+	m = &mockT{}
+	h.Engine = nil
+	h.GET("/api/v1/user/:id", 10).Do().AssertStatus(m, http.StatusOK)
+	assert.Len(t, m.errs, 1)
+}
+
+func Test_fillPath(t *testing.T) {
+	assert.Equal(t, "/user", fillPath("user"))
+	assert.Equal(t, "/user/1/book/2", fillPath("/user/:id/book/:bid", 1, 2))
+	assert.Equal(t, "/user/:id", fillPath("/user/:id"))
+}
@@ -4,8 +4,10 @@ package grpccli
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/balancer/leastrequest"
 	"google.golang.org/grpc/credentials/insecure"
 
 	"github.com/go-dev-frame/sponge/pkg/grpc/gtls"
@@ -32,7 +34,16 @@ func NewClient(endpoint string, opts ...Option) (*grpc.ClientConn, error) {
 
 	// load balance option
 	if o.enableLoadBalance {
-		clientOptions = append(clientOptions, grpc.WithDefaultServiceConfig(`{"loadBalancingConfig": [{"round_robin":{}}]}`))
+		serviceConfig, err := loadBalanceServiceConfig(o.loadBalancePolicy)
+		if err != nil {
+			return nil, err
+		}
+		clientOptions = append(clientOptions, grpc.WithDefaultServiceConfig(serviceConfig))
+	}
+
+	// keepalive option
+	if o.keepaliveParams != nil {
+		clientOptions = append(clientOptions, grpc.WithKeepaliveParams(*o.keepaliveParams))
 	}
 
 	// secure option
@@ -67,6 +78,19 @@ func Dial(_ context.Context, endpoint string, opts ...Option) (*grpc.ClientConn,
 	return NewClient(endpoint, opts...)
 }
 
+func loadBalanceServiceConfig(policy string) (string, error) {
+	switch policy {
+	case "", LoadBalanceRoundRobin:
+		policy = LoadBalanceRoundRobin
+	case LoadBalanceLeastRequest:
+		policy = leastrequest.Name
+	case LoadBalancePickFirst:
+	default:
+		return "", fmt.Errorf("unsupported load balancing policy '%s'", policy)
+	}
+	return fmt.Sprintf(`{"loadBalancingConfig": [{"%s":{}}]}`, policy), nil
+}
+
 func secureOption(o *options) (grpc.DialOption, error) {
 	switch o.secureType {
 	case secureOneWay: // server side certification
@@ -110,7 +134,9 @@ func unaryClientOptions(o *options) grpc.DialOption {
 
 	unaryClientInterceptors = append(unaryClientInterceptors, interceptor.UnaryClientRecovery())
 
-	if o.requestTimeout > 0 {
+	if len(o.methodTimeouts) > 0 {
+		unaryClientInterceptors = append(unaryClientInterceptors, interceptor.UnaryClientMethodTimeout(o.requestTimeout, o.methodTimeouts))
+	} else if o.requestTimeout > 0 {
 		unaryClientInterceptors = append(unaryClientInterceptors, interceptor.UnaryClientTimeout(o.requestTimeout))
 	}
 
@@ -138,7 +164,9 @@ func unaryClientOptions(o *options) grpc.DialOption {
 	//}
 
 	// retry
-	if o.enableRetry {
+	if len(o.retryPolicyOptions) > 0 {
+		unaryClientInterceptors = append(unaryClientInterceptors, interceptor.UnaryClientRetryPolicy(o.retryPolicyOptions...))
+	} else if o.enableRetry {
 		unaryClientInterceptors = append(unaryClientInterceptors, interceptor.UnaryClientRetry())
 	}
 
@@ -34,6 +34,22 @@ func TestNewClient2(t *testing.T) {
 	time.Sleep(time.Millisecond * 50)
 }
 
+func TestNewClientWithLoadBalancePolicy(t *testing.T) {
+	for _, policy := range []string{"", LoadBalanceRoundRobin, LoadBalanceLeastRequest, LoadBalancePickFirst} {
+		conn, err := NewClient("localhost:8282",
+			WithLoadBalancePolicy(policy),
+			WithKeepalive(time.Second*30, time.Second*10, false),
+			WithTimeout(time.Second),
+			WithMethodTimeouts(map[string]time.Duration{"/api.user.v1.User/GetByID": time.Millisecond * 500}),
+		)
+		assert.NoError(t, err)
+		_ = conn.Close()
+	}
+
+	_, err := NewClient("localhost:8282", WithLoadBalancePolicy("unknown"))
+	assert.Error(t, err)
+}
+
 func Test_unaryClientOptions(t *testing.T) {
 	o := &options{
 		enableToken:          true,
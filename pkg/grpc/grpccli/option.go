@@ -5,7 +5,9 @@ import (
 
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
 
+	"github.com/go-dev-frame/sponge/pkg/grpc/interceptor"
 	"github.com/go-dev-frame/sponge/pkg/servicerd/registry"
 )
 
@@ -14,12 +16,21 @@ var (
 	secureTwoWay = "two-way"
 )
 
+// load balancing policies
+const (
+	LoadBalanceRoundRobin   = "round_robin"
+	LoadBalanceLeastRequest = "least_request"
+	LoadBalancePickFirst    = "pick_first"
+)
+
 // Option grpc dial options
 type Option func(*options)
 
 // options grpc dial options
 type options struct {
-	requestTimeout time.Duration // request timeout, valid only for unary
+	requestTimeout  time.Duration               // request timeout, valid only for unary
+	methodTimeouts  map[string]time.Duration    // request timeouts of methods, valid only for unary
+	keepaliveParams *keepalive.ClientParameters // keepalive parameters, if nil means use the default of grpc
 
 	// secure setting
 	secureType string // secure type "","one-way","two-way"
@@ -36,13 +47,15 @@ type options struct {
 	// interceptor setting
 	enableLog            bool // whether to turn on the log
 	log                  *zap.Logger
-	enableRequestID      bool               // whether to turn on the request id
-	enableTrace          bool               // whether to turn on tracing
-	enableMetrics        bool               // whether to turn on metrics
-	enableRetry          bool               // whether to turn on retry
-	enableLoadBalance    bool               // whether to turn on load balance
-	enableCircuitBreaker bool               // whether to turn on circuit breaker
-	discovery            registry.Discovery // if not nil means use service discovery
+	enableRequestID      bool                            // whether to turn on the request id
+	enableTrace          bool                            // whether to turn on tracing
+	enableMetrics        bool                            // whether to turn on metrics
+	enableRetry          bool                            // whether to turn on retry
+	retryPolicyOptions   []interceptor.RetryPolicyOption // retry policies of methods, if not empty, used instead of the default retry
+	enableLoadBalance    bool                            // whether to turn on load balance
+	loadBalancePolicy    string                          // load balancing policy, default is round_robin
+	enableCircuitBreaker bool                            // whether to turn on circuit breaker
+	discovery            registry.Discovery              // if not nil means use service discovery
 
 	discoveryInsecure bool
 
@@ -83,6 +96,33 @@ func WithTimeout(d time.Duration) Option {
 	}
 }
 
+// WithMethodTimeouts set request timeouts of methods, the key is the full method name,
+// e.g. /api.user.v1.User/GetByID, or the service name for all methods of the service,
+// e.g. /api.user.v1.User, the other methods use the timeout of WithTimeout, valid only for unary
+func WithMethodTimeouts(methodTimeouts map[string]time.Duration) Option {
+	return func(o *options) {
+		if o.methodTimeouts == nil {
+			o.methodTimeouts = make(map[string]time.Duration, len(methodTimeouts))
+		}
+		for name, d := range methodTimeouts {
+			o.methodTimeouts[name] = d
+		}
+	}
+}
+
+// WithKeepalive set keepalive parameters, a ping is sent to server after pingTime of no activity,
+// the connection is closed if the ping ack is not received within timeout,
+// if permitWithoutStream is true, pings are sent even if there are no active streams
+func WithKeepalive(pingTime time.Duration, timeout time.Duration, permitWithoutStream bool) Option {
+	return func(o *options) {
+		o.keepaliveParams = &keepalive.ClientParameters{
+			Time:                pingTime,
+			Timeout:             timeout,
+			PermitWithoutStream: permitWithoutStream,
+		}
+	}
+}
+
 // WithEnableRequestID enable request id
 func WithEnableRequestID() Option {
 	return func(o *options) {
@@ -123,6 +163,15 @@ func WithEnableLoadBalance() Option {
 	}
 }
 
+// WithLoadBalancePolicy enable load balance with the policy, supported round_robin, least_request, pick_first,
+// if policy is empty, round_robin is used
+func WithLoadBalancePolicy(policy string) Option {
+	return func(o *options) {
+		o.enableLoadBalance = true
+		o.loadBalancePolicy = policy
+	}
+}
+
 // WithEnableRetry enable registry
 func WithEnableRetry() Option {
 	return func(o *options) {
@@ -130,6 +179,15 @@ func WithEnableRetry() Option {
 	}
 }
 
+// WithRetryPolicy enable retry with per-method policies, supports exponential backoff and hedged requests,
+// valid only for unary, streams are retried only by WithEnableRetry,
+// e.g. WithRetryPolicy(interceptor.WithMethodRetryPolicy("/api.user.v1.User/GetByID", policy))
+func WithRetryPolicy(opts ...interceptor.RetryPolicyOption) Option {
+	return func(o *options) {
+		o.retryPolicyOptions = append(o.retryPolicyOptions, opts...)
+	}
+}
+
 // WithEnableCircuitBreaker enable circuit breaker
 func WithEnableCircuitBreaker() Option {
 	return func(o *options) {
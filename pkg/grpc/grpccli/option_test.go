@@ -43,6 +43,30 @@ func TestWithEnableLoadBalance(t *testing.T) {
 	assert.Equal(t, true, o.enableLoadBalance)
 }
 
+func TestWithLoadBalancePolicy(t *testing.T) {
+	opt := WithLoadBalancePolicy(LoadBalanceLeastRequest)
+	o := new(options)
+	o.apply(opt)
+	assert.Equal(t, true, o.enableLoadBalance)
+	assert.Equal(t, LoadBalanceLeastRequest, o.loadBalancePolicy)
+}
+
+func TestWithMethodTimeouts(t *testing.T) {
+	opt := WithMethodTimeouts(map[string]time.Duration{"/api.user.v1.User/GetByID": time.Second})
+	o := new(options)
+	o.apply(opt, WithMethodTimeouts(map[string]time.Duration{"/api.user.v1.User": time.Second * 2}))
+	assert.Len(t, o.methodTimeouts, 2)
+}
+
+func TestWithKeepalive(t *testing.T) {
+	opt := WithKeepalive(time.Second*30, time.Second*10, true)
+	o := new(options)
+	o.apply(opt)
+	assert.Equal(t, time.Second*30, o.keepaliveParams.Time)
+	assert.Equal(t, time.Second*10, o.keepaliveParams.Timeout)
+	assert.Equal(t, true, o.keepaliveParams.PermitWithoutStream)
+}
+
 func TestWithEnableRequestID(t *testing.T) {
 	opt := WithEnableRequestID()
 	o := new(options)
@@ -73,6 +97,14 @@ func TestWithEnableRetry(t *testing.T) {
 	assert.Equal(t, true, o.enableRetry)
 }
 
+func TestWithRetryPolicy(t *testing.T) {
+	opt := WithRetryPolicy(interceptor.WithDefaultRetryPolicy(interceptor.RetryPolicy{MaxAttempts: 3}))
+	o := new(options)
+	o.apply(opt)
+	assert.Len(t, o.retryPolicyOptions, 1)
+	assert.NotNil(t, unaryClientOptions(o))
+}
+
 func TestWithEnableTrace(t *testing.T) {
 	opt := WithEnableTrace()
 	o := new(options)
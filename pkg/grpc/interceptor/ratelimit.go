@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/redis/go-redis/v9"
 	"google.golang.org/grpc"
 
 	"github.com/go-dev-frame/sponge/pkg/errcode"
@@ -24,6 +25,10 @@ type ratelimitOptions struct {
 	bucket       int
 	cpuThreshold int64
 	cpuQuota     float64
+
+	redisClient redis.UniversalClient
+	clusterName string
+	callerKey   func(ctx context.Context) string
 }
 
 func defaultRatelimitOptions() *ratelimitOptions {
@@ -68,19 +73,55 @@ func WithCPUQuota(quota float64) RatelimitOption {
 	}
 }
 
-// UnaryServerRateLimit server-side unary circuit breaker interceptor
-func UnaryServerRateLimit(opts ...RatelimitOption) grpc.UnaryServerInterceptor {
-	o := defaultRatelimitOptions()
-	o.apply(opts...)
-	limiter := rl.NewLimiter(
+// WithRatelimitCluster coordinate the rate limit among the replicas of service by redis, name is shared by the replicas.
+func WithRatelimitCluster(client redis.UniversalClient, name string) RatelimitOption {
+	return func(o *ratelimitOptions) {
+		o.redisClient = client
+		o.clusterName = name
+	}
+}
+
+// WithRatelimitCallerKey get the caller of request for the fairness among callers, e.g. user id or app id in metadata,
+// it works with WithRatelimitCluster.
+func WithRatelimitCallerKey(fn func(ctx context.Context) string) RatelimitOption {
+	return func(o *ratelimitOptions) {
+		o.callerKey = fn
+	}
+}
+
+func newRateLimitAllow(o *ratelimitOptions) func(ctx context.Context) (rl.DoneFunc, error) {
+	localOpts := []rl.Option{
 		rl.WithWindow(o.window),
 		rl.WithBucket(o.bucket),
 		rl.WithCPUThreshold(o.cpuThreshold),
 		rl.WithCPUQuota(o.cpuQuota),
-	)
+	}
+
+	if o.redisClient == nil {
+		limiter := rl.NewLimiter(localOpts...)
+		return func(ctx context.Context) (rl.DoneFunc, error) { return limiter.Allow() }
+	}
+
+	limiter, err := rl.NewClusterLimiter(o.redisClient, o.clusterName, rl.WithLocalOptions(localOpts...))
+	if err != nil {
+		panic("new cluster limiter error: " + err.Error())
+	}
+	return func(ctx context.Context) (rl.DoneFunc, error) {
+		if o.callerKey != nil {
+			return limiter.AllowCaller(o.callerKey(ctx))
+		}
+		return limiter.Allow()
+	}
+}
+
+// UnaryServerRateLimit server-side unary circuit breaker interceptor
+func UnaryServerRateLimit(opts ...RatelimitOption) grpc.UnaryServerInterceptor {
+	o := defaultRatelimitOptions()
+	o.apply(opts...)
+	allow := newRateLimitAllow(o)
 
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
-		done, err := limiter.Allow()
+		done, err := allow(ctx)
 		if err != nil {
 			return nil, errcode.StatusLimitExceed.ToRPCErr(err.Error())
 		}
@@ -95,15 +136,14 @@ func UnaryServerRateLimit(opts ...RatelimitOption) grpc.UnaryServerInterceptor {
 func StreamServerRateLimit(opts ...RatelimitOption) grpc.StreamServerInterceptor {
 	o := defaultRatelimitOptions()
 	o.apply(opts...)
-	limiter := rl.NewLimiter(
-		rl.WithWindow(o.window),
-		rl.WithBucket(o.bucket),
-		rl.WithCPUThreshold(o.cpuThreshold),
-		rl.WithCPUQuota(o.cpuQuota),
-	)
+	allow := newRateLimitAllow(o)
 
 	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
-		done, err := limiter.Allow()
+		ctx := context.Background()
+		if ss != nil {
+			ctx = ss.Context()
+		}
+		done, err := allow(ctx)
 		if err != nil {
 			return errcode.StatusLimitExceed.ToRPCErr(err.Error())
 		}
@@ -5,6 +5,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc"
 )
@@ -35,3 +37,31 @@ func TestStreamServerRateLimit(t *testing.T) {
 	err := interceptor(nil, nil, nil, handler)
 	assert.NoError(t, err)
 }
+
+func TestServerRateLimitCluster(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	unaryInterceptor := UnaryServerRateLimit(
+		WithRatelimitCluster(client, "user-service"),
+		WithRatelimitCallerKey(func(ctx context.Context) string { return "app1" }),
+	)
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	}
+	_, err := unaryInterceptor(context.Background(), nil, nil, handler)
+	assert.NoError(t, err)
+
+	streamInterceptor := StreamServerRateLimit(WithRatelimitCluster(client, "user-service"))
+	err = streamInterceptor(nil, nil, nil, func(srv interface{}, stream grpc.ServerStream) error {
+		return nil
+	})
+	assert.NoError(t, err)
+
+	keys, _ := mr.HKeys("ratelimit:user-service:nodes")
+	assert.Len(t, keys, 2)
+
+	defer func() { assert.NotNil(t, recover()) }()
+	UnaryServerRateLimit(WithRatelimitCluster(client, ""))
+}
@@ -9,6 +9,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
 
+	"github.com/go-dev-frame/sponge/pkg/gin/middleware"
 	"github.com/go-dev-frame/sponge/pkg/krand"
 )
 
@@ -54,6 +55,7 @@ func ClientCtxRequestIDField(ctx context.Context) zap.Field {
 // UnaryClientRequestID client-side request_id unary interceptor
 func UnaryClientRequestID() grpc.UnaryClientInterceptor {
 	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx = middleware.OutgoingRequestIDCtx(ctx) // propagate the request id and traceparent of context
 		requestID := ClientCtxRequestID(ctx)
 		if requestID == "" {
 			requestID = krand.String(krand.R_All, 10)
@@ -67,6 +69,7 @@ func UnaryClientRequestID() grpc.UnaryClientInterceptor {
 func StreamClientRequestID() grpc.StreamClientInterceptor {
 	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
 		streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx = middleware.OutgoingRequestIDCtx(ctx) // propagate the request id and traceparent of context
 		requestID := ClientCtxRequestID(ctx)
 		if requestID == "" {
 			requestID = krand.String(krand.R_All, 10)
@@ -5,19 +5,23 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"net/http/httptest"
 	"reflect"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/runtime/protoimpl"
 
+	"github.com/go-dev-frame/sponge/pkg/gin/middleware"
 	"github.com/go-dev-frame/sponge/pkg/utils"
 )
 
@@ -530,6 +534,56 @@ func TestUnaryClientRequestID(t *testing.T) {
 	_ = sayHelloMethod(context.Background(), cli)
 }
 
+func TestClientRequestIDPropagation(t *testing.T) {
+	var mu sync.Mutex
+	var got []metadata.MD
+	record := func(ctx context.Context) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		mu.Lock()
+		got = append(got, md)
+		mu.Unlock()
+	}
+	addr := newRPCServer(
+		[]grpc.UnaryServerInterceptor{func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			record(ctx)
+			return handler(ctx, req)
+		}},
+		[]grpc.StreamServerInterceptor{func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			record(ss.Context())
+			return handler(srv, ss)
+		}},
+	)
+	time.Sleep(time.Millisecond * 200)
+	cli := newRPCClient(addr,
+		[]grpc.UnaryClientInterceptor{UnaryClientRequestID()},
+		[]grpc.StreamClientInterceptor{StreamClientRequestID()},
+	)
+
+	tp := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/", nil)
+	c.Set(middleware.ContextRequestIDKey, "rid-1")
+	c.Set(middleware.ContextTraceparentKey, tp)
+
+	// the request id and traceparent of gin.Context, and of the context derived from it
+	ctx, cancel := context.WithTimeout(c, time.Second*3)
+	defer cancel()
+	assert.NoError(t, sayHelloMethod(c, cli))
+	assert.NoError(t, discussHelloMethod(ctx, cli))
+	// the request id of outgoing metadata takes precedence
+	assert.NoError(t, sayHelloMethod(metadata.AppendToOutgoingContext(ctx, middleware.ContextRequestIDKey, "rid-2"), cli))
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !assert.Len(t, got, 3) {
+		return
+	}
+	for i, want := range []string{"rid-1", "rid-1", "rid-2"} {
+		assert.Equal(t, []string{want}, got[i].Get(middleware.ContextRequestIDKey))
+		assert.Equal(t, []string{tp}, got[i].Get(middleware.HeaderTraceparentKey))
+	}
+}
+
 func TestUnaryServerRequestID(t *testing.T) {
 	addr := newUnaryRPCServer(UnaryServerRequestID())
 	time.Sleep(time.Millisecond * 200)
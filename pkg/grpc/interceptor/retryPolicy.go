@@ -0,0 +1,263 @@
+package interceptor
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// ---------------------------------- client interceptor ----------------------------------
+
+// RetryPolicy is the retry policy of grpc methods, zero values of a method policy are inherited
+// from the default policy.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first request, max 10
+	MaxAttempts int
+	// InitialBackoff is the backoff before the first retry, the backoff is multiplied by BackoffMultiplier
+	// for each retry, and a random jitter is applied
+	InitialBackoff time.Duration
+	// MaxBackoff is the maximum backoff
+	MaxBackoff time.Duration
+	// BackoffMultiplier is the multiplier of backoff, default is 2
+	BackoffMultiplier float64
+	// RetryableCodes are the status codes that trigger a retry, default is codes.Unavailable
+	RetryableCodes []codes.Code
+	// HedgingDelay, if greater than 0, a hedged request is sent every HedgingDelay without waiting for
+	// the previous requests, until a response succeeds or MaxAttempts requests are sent, the first
+	// successful response is used and the others are canceled, use it only for idempotent methods.
+	HedgingDelay time.Duration
+}
+
+func defaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:       3,
+		InitialBackoff:    100 * time.Millisecond,
+		MaxBackoff:        time.Second,
+		BackoffMultiplier: 2,
+		RetryableCodes:    []codes.Code{codes.Unavailable},
+	}
+}
+
+// inherit fills the zero values of the policy with the values of the parent policy
+func (p RetryPolicy) inherit(parent RetryPolicy) RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = parent.MaxAttempts
+	}
+	if p.MaxAttempts > 10 {
+		p.MaxAttempts = 10
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = parent.InitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = parent.MaxBackoff
+	}
+	if p.MaxBackoff < p.InitialBackoff {
+		p.MaxBackoff = p.InitialBackoff
+	}
+	if p.BackoffMultiplier < 1 {
+		p.BackoffMultiplier = parent.BackoffMultiplier
+	}
+	if len(p.RetryableCodes) == 0 {
+		p.RetryableCodes = parent.RetryableCodes
+	}
+	if p.HedgingDelay <= 0 {
+		p.HedgingDelay = parent.HedgingDelay
+	}
+	return p
+}
+
+func (p RetryPolicy) isRetryable(err error) bool {
+	code := status.Code(err)
+	for _, c := range p.RetryableCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the backoff before the retry, attempt starts from 1, the backoff is randomized
+// between 0 and the exponential backoff, so that clients do not retry at the same time.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := float64(p.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		d *= p.BackoffMultiplier
+		if d > float64(p.MaxBackoff) {
+			d = float64(p.MaxBackoff)
+			break
+		}
+	}
+	return time.Duration(rand.Float64() * d) //nolint
+}
+
+// RetryPolicyOption set the retry policy options.
+type RetryPolicyOption func(*retryPolicyOptions)
+
+type retryPolicyOptions struct {
+	defaultPolicy *RetryPolicy
+	methods       map[string]RetryPolicy
+}
+
+func (o *retryPolicyOptions) apply(opts ...RetryPolicyOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WithDefaultRetryPolicy set the retry policy of all methods that have no method policy,
+// if not set, only the methods with method policies are retried.
+func WithDefaultRetryPolicy(policy RetryPolicy) RetryPolicyOption {
+	return func(o *retryPolicyOptions) {
+		o.defaultPolicy = &policy
+	}
+}
+
+// WithMethodRetryPolicy set the retry policy of a method, name is the full method name,
+// e.g. /api.user.v1.User/GetByID, or the service name for all methods of the service,
+// e.g. /api.user.v1.User
+func WithMethodRetryPolicy(name string, policy RetryPolicy) RetryPolicyOption {
+	return func(o *retryPolicyOptions) {
+		if o.methods == nil {
+			o.methods = make(map[string]RetryPolicy)
+		}
+		o.methods[strings.TrimSuffix(name, "/")] = policy
+	}
+}
+
+// policy returns the retry policy of the method, false if the method is not retried
+func (o *retryPolicyOptions) policy(method string) (RetryPolicy, bool) {
+	parent := defaultRetryPolicy()
+	if o.defaultPolicy != nil {
+		parent = o.defaultPolicy.inherit(parent)
+	}
+
+	if p, ok := o.methods[method]; ok {
+		return p.inherit(parent), true
+	}
+	if i := strings.LastIndex(method, "/"); i > 0 {
+		if p, ok := o.methods[method[:i]]; ok {
+			return p.inherit(parent), true
+		}
+	}
+	if o.defaultPolicy != nil {
+		return parent, true
+	}
+	return RetryPolicy{}, false
+}
+
+// ParseCodes converts status code names to codes, e.g. "UNAVAILABLE", "deadline_exceeded", used for
+// the retryable codes in configuration files.
+func ParseCodes(names ...string) ([]codes.Code, error) {
+	var cs []codes.Code
+	for _, name := range names {
+		var c codes.Code
+		if err := c.UnmarshalJSON([]byte(`"` + strings.ToUpper(strings.TrimSpace(name)) + `"`)); err != nil {
+			return nil, fmt.Errorf("invalid grpc status code '%s'", name)
+		}
+		cs = append(cs, c)
+	}
+	return cs, nil
+}
+
+// UnaryClientRetryPolicy client-side retry unary interceptor with per-method policies, failed requests
+// with retryable codes are retried with exponential backoff, or hedged if HedgingDelay is set.
+func UnaryClientRetryPolicy(opts ...RetryPolicyOption) grpc.UnaryClientInterceptor {
+	o := &retryPolicyOptions{}
+	o.apply(opts...)
+
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		p, ok := o.policy(method)
+		if !ok || p.MaxAttempts <= 1 {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		if msg, isProto := reply.(proto.Message); isProto && p.HedgingDelay > 0 {
+			return hedge(ctx, p, method, req, msg, cc, invoker, opts...)
+		}
+		return retry(ctx, p, method, req, reply, cc, invoker, opts...)
+	}
+}
+
+func retry(ctx context.Context, p RetryPolicy, method string, req, reply interface{},
+	cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	var err error
+	for attempt := 0; attempt < p.MaxAttempts; attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(p.backoff(attempt))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			case <-timer.C:
+			}
+		}
+
+		err = invoker(ctx, method, req, reply, cc, opts...)
+		if err == nil || !p.isRetryable(err) || ctx.Err() != nil {
+			return err
+		}
+	}
+	return err
+}
+
+type hedgeResult struct {
+	reply proto.Message
+	err   error
+}
+
+// hedge sends a request every HedgingDelay until a response succeeds, each request has its own reply,
+// the successful reply is merged into reply, if a request fails with a retryable code and no request
+// is in flight, the next request is sent immediately.
+func hedge(ctx context.Context, p RetryPolicy, method string, req interface{}, reply proto.Message,
+	cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // cancel the requests in flight
+
+	results := make(chan hedgeResult, p.MaxAttempts)
+	sent, done := 0, 0
+	send := func() {
+		sent++
+		r := reply.ProtoReflect().New().Interface()
+		go func() {
+			err := invoker(ctx, method, req, r, cc, opts...)
+			results <- hedgeResult{reply: r, err: err}
+		}()
+	}
+
+	send()
+	timer := time.NewTimer(p.HedgingDelay)
+	defer timer.Stop()
+
+	var err error
+	for done < sent {
+		select {
+		case <-timer.C:
+			if sent < p.MaxAttempts {
+				send()
+				timer.Reset(p.HedgingDelay)
+			}
+		case result := <-results:
+			done++
+			if result.err == nil {
+				proto.Merge(reply, result.reply)
+				return nil
+			}
+			err = result.err
+			if !p.isRetryable(err) || ctx.Err() != nil {
+				return err
+			}
+			if done == sent && sent < p.MaxAttempts {
+				send()
+				timer.Reset(p.HedgingDelay)
+			}
+		}
+	}
+	return err
+}
@@ -0,0 +1,123 @@
+package interceptor
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func failingInvoker(failures int32, code codes.Code, calls *int32) grpc.UnaryInvoker {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		if atomic.AddInt32(calls, 1) <= failures {
+			return status.Error(code, "failed")
+		}
+		reply.(*wrapperspb.StringValue).Value = "ok"
+		return nil
+	}
+}
+
+func TestUnaryClientRetryPolicy(t *testing.T) {
+	fn := UnaryClientRetryPolicy(
+		WithDefaultRetryPolicy(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}),
+		WithMethodRetryPolicy("/api.user.v1.User/Create", RetryPolicy{MaxAttempts: 1}),
+		WithMethodRetryPolicy("/api.order.v1.Order", RetryPolicy{MaxAttempts: 5, RetryableCodes: []codes.Code{codes.Internal}}),
+	)
+
+	// retried by default policy
+	var calls int32
+	reply := &wrapperspb.StringValue{}
+	err := fn(context.Background(), "/api.user.v1.User/GetByID", nil, reply, nil, failingInvoker(2, codes.Unavailable, &calls))
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", reply.Value)
+	assert.Equal(t, int32(3), calls)
+
+	// max attempts reached
+	calls = 0
+	err = fn(context.Background(), "/api.user.v1.User/GetByID", nil, &wrapperspb.StringValue{}, nil, failingInvoker(5, codes.Unavailable, &calls))
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, int32(3), calls)
+
+	// not retryable code
+	calls = 0
+	err = fn(context.Background(), "/api.user.v1.User/GetByID", nil, &wrapperspb.StringValue{}, nil, failingInvoker(5, codes.InvalidArgument, &calls))
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, int32(1), calls)
+
+	// method policy, not retried
+	calls = 0
+	err = fn(context.Background(), "/api.user.v1.User/Create", nil, &wrapperspb.StringValue{}, nil, failingInvoker(5, codes.Unavailable, &calls))
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), calls)
+
+	// service policy
+	calls = 0
+	err = fn(context.Background(), "/api.order.v1.Order/Get", nil, &wrapperspb.StringValue{}, nil, failingInvoker(4, codes.Internal, &calls))
+	assert.NoError(t, err)
+	assert.Equal(t, int32(5), calls)
+
+	// no policy
+	fn = UnaryClientRetryPolicy(WithMethodRetryPolicy("/api.user.v1.User/Create", RetryPolicy{}))
+	calls = 0
+	err = fn(context.Background(), "/api.user.v1.User/GetByID", nil, &wrapperspb.StringValue{}, nil, failingInvoker(5, codes.Unavailable, &calls))
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), calls)
+}
+
+func TestUnaryClientRetryPolicyHedging(t *testing.T) {
+	fn := UnaryClientRetryPolicy(
+		WithMethodRetryPolicy("/api.user.v1.User/GetByID", RetryPolicy{MaxAttempts: 3, HedgingDelay: 10 * time.Millisecond}),
+	)
+
+	// the first request is slow, the hedged request succeeds
+	var calls int32
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		n := atomic.AddInt32(&calls, 1)
+		if n == 1 {
+			select {
+			case <-ctx.Done():
+				return status.Error(codes.Canceled, ctx.Err().Error())
+			case <-time.After(time.Second):
+			}
+		}
+		reply.(*wrapperspb.StringValue).Value = "ok"
+		return nil
+	}
+	reply := &wrapperspb.StringValue{}
+	start := time.Now()
+	err := fn(context.Background(), "/api.user.v1.User/GetByID", nil, reply, nil, invoker)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", reply.Value)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	// all requests fail
+	calls = 0
+	err = fn(context.Background(), "/api.user.v1.User/GetByID", nil, &wrapperspb.StringValue{}, nil, failingInvoker(5, codes.Unavailable, &calls))
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}.inherit(defaultRetryPolicy())
+	for attempt := 1; attempt < 5; attempt++ {
+		d := p.backoff(attempt)
+		assert.LessOrEqual(t, d, 300*time.Millisecond)
+		assert.GreaterOrEqual(t, d, time.Duration(0))
+	}
+}
+
+func TestParseCodes(t *testing.T) {
+	cs, err := ParseCodes("UNAVAILABLE", "deadline_exceeded")
+	assert.NoError(t, err)
+	assert.Equal(t, []codes.Code{codes.Unavailable, codes.DeadlineExceeded}, cs)
+
+	_, err = ParseCodes("unknown_code")
+	assert.Error(t, err)
+}
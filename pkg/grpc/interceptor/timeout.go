@@ -2,6 +2,7 @@ package interceptor
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"google.golang.org/grpc"
@@ -23,6 +24,36 @@ func UnaryClientTimeout(d time.Duration) grpc.UnaryClientInterceptor {
 	}
 }
 
+// UnaryClientMethodTimeout client-side timeout unary interceptor with per-method timeouts, the key of
+// methodTimeouts is the full method name, e.g. /api.user.v1.User/GetByID, or the service name for
+// all methods of the service, e.g. /api.user.v1.User, the other methods use timeout d, if d is 0,
+// there is no timeout for them
+func UnaryClientMethodTimeout(d time.Duration, methodTimeouts map[string]time.Duration) grpc.UnaryClientInterceptor {
+	timeouts := make(map[string]time.Duration, len(methodTimeouts))
+	for name, timeout := range methodTimeouts {
+		timeouts[strings.TrimSuffix(name, "/")] = timeout
+	}
+
+	return func(ctx context.Context, method string, req, resp interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		timeout, ok := timeouts[method]
+		if !ok {
+			timeout = d
+			if i := strings.LastIndex(method, "/"); i > 0 {
+				if t, ok := timeouts[method[:i]]; ok {
+					timeout = t
+				}
+			}
+		}
+		if timeout <= 0 {
+			return invoker(ctx, method, req, resp, cc, opts...)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return invoker(ctx, method, req, resp, cc, opts...)
+	}
+}
+
 // StreamClientTimeout server-side timeout  interceptor
 func StreamClientTimeout(d time.Duration) grpc.StreamClientInterceptor {
 	if d < time.Millisecond {
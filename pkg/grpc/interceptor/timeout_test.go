@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
 )
 
 func TestUnaryClientTimeout(t *testing.T) {
@@ -18,6 +19,36 @@ func TestUnaryClientTimeout(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestUnaryClientMethodTimeout(t *testing.T) {
+	interceptor := UnaryClientMethodTimeout(time.Second, map[string]time.Duration{
+		"/api.user.v1.User/GetByID": time.Millisecond * 100,
+		"/api.order.v1.Order/":      time.Millisecond * 200,
+	})
+
+	getTimeout := func(method string) time.Duration {
+		var timeout time.Duration
+		_ = interceptor(context.Background(), method, nil, nil, nil,
+			func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				if deadline, ok := ctx.Deadline(); ok {
+					timeout = time.Until(deadline)
+				}
+				return nil
+			})
+		return timeout
+	}
+
+	assert.LessOrEqual(t, getTimeout("/api.user.v1.User/GetByID"), time.Millisecond*100)
+	assert.Greater(t, getTimeout("/api.user.v1.User/List"), time.Millisecond*200)
+	timeout := getTimeout("/api.order.v1.Order/Create")
+	assert.True(t, timeout > time.Millisecond*100 && timeout <= time.Millisecond*200)
+
+	// no timeout for the other methods
+	interceptor = UnaryClientMethodTimeout(0, map[string]time.Duration{"/api.user.v1.User/GetByID": time.Second})
+	assert.Equal(t, time.Duration(0), getTimeout("/api.user.v1.User/List"))
+	err := interceptor(context.Background(), "/test", nil, nil, nil, unaryClientInvoker)
+	assert.NoError(t, err)
+}
+
 func TestStreamClientTimeout(t *testing.T) {
 	interceptor := StreamClientTimeout(time.Millisecond)
 	assert.NotNil(t, interceptor)
@@ -0,0 +1,172 @@
+package interceptor
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/go-dev-frame/sponge/pkg/errcode"
+)
+
+// ---------------------------------- server interceptor ----------------------------------
+
+// MessageValidator validates a request message, e.g. protovalidate
+type MessageValidator func(msg proto.Message) error
+
+// ValidateOption set the validate options.
+type ValidateOption func(*validateOptions)
+
+type validateOptions struct {
+	maxRequestSize        int
+	methodMaxRequestSizes map[string]int
+	requireDeadline       bool
+	deadlineIgnoreMethods map[string]struct{}
+	validators            []MessageValidator
+}
+
+func defaultValidateOptions() *validateOptions {
+	return &validateOptions{
+		methodMaxRequestSizes: make(map[string]int),
+		deadlineIgnoreMethods: make(map[string]struct{}),
+	}
+}
+
+func (o *validateOptions) apply(opts ...ValidateOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WithMaxRequestSize set the maximum size of request messages in bytes, 0 means no limit, default is 0,
+// the size is checked after the message is received and unmarshaled, so use grpc.MaxRecvMsgSize when creating
+// the server to limit the size of all messages, this option is used for the smaller limits of some methods.
+func WithMaxRequestSize(size int) ValidateOption {
+	return func(o *validateOptions) {
+		o.maxRequestSize = size
+	}
+}
+
+// WithMethodMaxRequestSize set the maximum size of request messages of a method in bytes,
+// it overrides WithMaxRequestSize, method is the full method name, e.g. /api.user.v1.User/Upload
+func WithMethodMaxRequestSize(method string, size int) ValidateOption {
+	return func(o *validateOptions) {
+		o.methodMaxRequestSizes[method] = size
+	}
+}
+
+// WithRequireDeadline reject the requests without deadline, ignoreMethods are the full method names
+// that are allowed to be called without deadline, e.g. /api.user.v1.User/Watch
+func WithRequireDeadline(ignoreMethods ...string) ValidateOption {
+	return func(o *validateOptions) {
+		o.requireDeadline = true
+		for _, method := range ignoreMethods {
+			o.deadlineIgnoreMethods[method] = struct{}{}
+		}
+	}
+}
+
+// WithMessageValidator add a validator of request messages, e.g. protovalidate:
+//
+//	WithMessageValidator(func(msg proto.Message) error { return protovalidate.Validate(msg) })
+func WithMessageValidator(validators ...MessageValidator) ValidateOption {
+	return func(o *validateOptions) {
+		o.validators = append(o.validators, validators...)
+	}
+}
+
+func (o *validateOptions) checkDeadline(ctx context.Context, method string) error {
+	if !o.requireDeadline {
+		return nil
+	}
+	if _, ok := o.deadlineIgnoreMethods[method]; ok {
+		return nil
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		return errcode.StatusInvalidParams.ToRPCErr("deadline is required, please set a timeout for the request")
+	}
+	return nil
+}
+
+func (o *validateOptions) checkMessage(method string, req interface{}) error {
+	msg, ok := req.(proto.Message)
+	if !ok {
+		return nil
+	}
+
+	maxSize := o.maxRequestSize
+	if size, ok := o.methodMaxRequestSizes[method]; ok {
+		maxSize = size
+	}
+	if maxSize > 0 {
+		if size := proto.Size(msg); size > maxSize {
+			return errcode.StatusResourceExhausted.ToRPCErr(
+				fmt.Sprintf("request size %d bytes exceeds the maximum %d bytes", size, maxSize))
+		}
+	}
+
+	// the rules generated by protoc-gen-validate, ValidateAll returns all violations
+	var err error
+	switch v := req.(type) {
+	case interface{ ValidateAll() error }:
+		err = v.ValidateAll()
+	case interface{ Validate() error }:
+		err = v.Validate()
+	}
+	if err != nil {
+		return errcode.StatusInvalidParams.ToRPCErr(err.Error())
+	}
+
+	for _, validate := range o.validators {
+		if err = validate(msg); err != nil {
+			return errcode.StatusInvalidParams.ToRPCErr(err.Error())
+		}
+	}
+	return nil
+}
+
+// UnaryServerValidate server-side validate unary interceptor, it rejects the requests that exceed the maximum size,
+// have no deadline (if required), or violate the validate rules of messages, validate rules are checked by the
+// Validate or ValidateAll methods generated by protoc-gen-validate and the validators of WithMessageValidator,
+// invalid requests are returned with errcode.StatusInvalidParams, too large requests with errcode.StatusResourceExhausted.
+func UnaryServerValidate(opts ...ValidateOption) grpc.UnaryServerInterceptor {
+	o := defaultValidateOptions()
+	o.apply(opts...)
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := o.checkDeadline(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		if err := o.checkMessage(info.FullMethod, req); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerValidate server-side validate stream interceptor, each received message is checked.
+func StreamServerValidate(opts ...ValidateOption) grpc.StreamServerInterceptor {
+	o := defaultValidateOptions()
+	o.apply(opts...)
+
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := o.checkDeadline(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, &validateServerStream{ServerStream: ss, method: info.FullMethod, o: o})
+	}
+}
+
+type validateServerStream struct {
+	grpc.ServerStream
+	method string
+	o      *validateOptions
+}
+
+func (s *validateServerStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return s.o.checkMessage(s.method, m)
+}
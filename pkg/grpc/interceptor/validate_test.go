@@ -0,0 +1,115 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// request message with the Validate method generated by protoc-gen-validate
+type validatedRequest struct {
+	*wrapperspb.StringValue
+}
+
+func (r validatedRequest) Validate() error {
+	if r.Value == "" {
+		return errors.New("invalid StringValue.Value: value length must be at least 1 runes")
+	}
+	return nil
+}
+
+func TestUnaryServerValidate(t *testing.T) {
+	fn := UnaryServerValidate(
+		WithMaxRequestSize(10),
+		WithMethodMaxRequestSize("/api.user.v1.User/Upload", 100),
+		WithMessageValidator(func(msg proto.Message) error {
+			if strings.Contains(msg.(validatedRequest).Value, "admin") {
+				return errors.New("name must not contain admin")
+			}
+			return nil
+		}),
+	)
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+	info := &grpc.UnaryServerInfo{FullMethod: "/api.user.v1.User/Create"}
+
+	reply, err := fn(context.Background(), validatedRequest{wrapperspb.String("foo")}, info, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", reply)
+
+	_, err = fn(context.Background(), validatedRequest{wrapperspb.String("")}, info, handler)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Contains(t, err.Error(), "value length must be at least 1 runes")
+
+	_, err = fn(context.Background(), validatedRequest{wrapperspb.String("admin")}, info, handler)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	largeReq := validatedRequest{wrapperspb.String(strings.Repeat("a", 50))}
+	_, err = fn(context.Background(), largeReq, info, handler)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	_, err = fn(context.Background(), largeReq, &grpc.UnaryServerInfo{FullMethod: "/api.user.v1.User/Upload"}, handler)
+	assert.NoError(t, err)
+
+	// not proto messages are not checked
+	_, err = fn(context.Background(), "foo", info, handler)
+	assert.NoError(t, err)
+}
+
+func TestUnaryServerValidateDeadline(t *testing.T) {
+	fn := UnaryServerValidate(WithRequireDeadline("/api.user.v1.User/Watch"))
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+	req := wrapperspb.String("foo")
+
+	_, err := fn(context.Background(), req, &grpc.UnaryServerInfo{FullMethod: "/api.user.v1.User/Create"}, handler)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = fn(ctx, req, &grpc.UnaryServerInfo{FullMethod: "/api.user.v1.User/Create"}, handler)
+	assert.NoError(t, err)
+
+	_, err = fn(context.Background(), req, &grpc.UnaryServerInfo{FullMethod: "/api.user.v1.User/Watch"}, handler)
+	assert.NoError(t, err)
+}
+
+type recvServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+	msg string
+}
+
+func (s *recvServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *recvServerStream) RecvMsg(m interface{}) error {
+	m.(validatedRequest).Value = s.msg
+	return nil
+}
+
+func TestStreamServerValidate(t *testing.T) {
+	fn := StreamServerValidate(WithMaxRequestSize(10))
+	info := &grpc.StreamServerInfo{FullMethod: "/api.user.v1.User/Upload"}
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		return stream.RecvMsg(validatedRequest{&wrapperspb.StringValue{}})
+	}
+
+	err := fn(nil, &recvServerStream{ctx: context.Background(), msg: "foo"}, info, handler)
+	assert.NoError(t, err)
+	err = fn(nil, &recvServerStream{ctx: context.Background(), msg: ""}, info, handler)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	err = fn(nil, &recvServerStream{ctx: context.Background(), msg: strings.Repeat("a", 50)}, info, handler)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	fn = StreamServerValidate(WithRequireDeadline())
+	err = fn(nil, &recvServerStream{ctx: context.Background(), msg: "foo"}, info, handler)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,219 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthPB "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// HealthProbe checks whether a dependency is reachable, e.g. database, redis
+type HealthProbe func(ctx context.Context) error
+
+type healthProbe struct {
+	name     string
+	probe    HealthProbe
+	services []string
+}
+
+// HealthOption set health checker options.
+type HealthOption func(*healthOptions)
+
+type healthOptions struct {
+	probes   []healthProbe
+	interval time.Duration
+	timeout  time.Duration
+	log      *zap.Logger
+}
+
+func defaultHealthOptions() *healthOptions {
+	return &healthOptions{
+		interval: 10 * time.Second,
+		timeout:  3 * time.Second,
+	}
+}
+
+func (o *healthOptions) apply(opts ...HealthOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WithHealthProbe add a dependency probe, if the probe fails, the status of services is set to NOT_SERVING,
+// services are the full names of grpc services, e.g. api.user.v1.User, if empty, the probe applies to
+// all services and the overall status of server.
+func WithHealthProbe(name string, probe HealthProbe, services ...string) HealthOption {
+	return func(o *healthOptions) {
+		if probe != nil {
+			o.probes = append(o.probes, healthProbe{name: name, probe: probe, services: services})
+		}
+	}
+}
+
+// WithHealthInterval set the interval of probing, default is 10s
+func WithHealthInterval(d time.Duration) HealthOption {
+	return func(o *healthOptions) {
+		if d > 0 {
+			o.interval = d
+		}
+	}
+}
+
+// WithHealthTimeout set the timeout of each probe, default is 3s
+func WithHealthTimeout(d time.Duration) HealthOption {
+	return func(o *healthOptions) {
+		if d > 0 {
+			o.timeout = d
+		}
+	}
+}
+
+// WithHealthLogger set the logger for printing status changes
+func WithHealthLogger(log *zap.Logger) HealthOption {
+	return func(o *healthOptions) {
+		o.log = log
+	}
+}
+
+// HealthChecker is the standard grpc health service, the status of each service is updated by
+// dependency probes, so that load balancers can drain unhealthy instances.
+type HealthChecker struct {
+	server   *health.Server
+	services []string // "" is the overall status of server
+
+	o        *healthOptions
+	statuses map[string]healthPB.HealthCheckResponse_ServingStatus
+
+	done chan struct{}
+	once sync.Once
+}
+
+// NewHealthChecker create a health checker
+func NewHealthChecker(opts ...HealthOption) *HealthChecker {
+	o := defaultHealthOptions()
+	o.apply(opts...)
+	return &HealthChecker{
+		server:   health.NewServer(),
+		services: []string{""},
+		o:        o,
+		statuses: make(map[string]healthPB.HealthCheckResponse_ServingStatus),
+		done:     make(chan struct{}),
+	}
+}
+
+// Register the health service to srv, it must be called after all services are registered.
+func (h *HealthChecker) Register(srv *grpc.Server) {
+	for name := range srv.GetServiceInfo() {
+		if name != healthPB.Health_ServiceDesc.ServiceName {
+			h.services = append(h.services, name)
+		}
+	}
+	healthPB.RegisterHealthServer(srv, h.server)
+}
+
+// Start probing dependencies, the status of services is set before returning.
+func (h *HealthChecker) Start() {
+	h.check()
+	if len(h.o.probes) == 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(h.o.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				h.check()
+			case <-h.done:
+				return
+			}
+		}
+	}()
+}
+
+// Shutdown stop probing and set the status of all services to NOT_SERVING, it is called
+// before stopping the server, so that no new requests are routed to it.
+func (h *HealthChecker) Shutdown() {
+	h.once.Do(func() {
+		close(h.done)
+		h.server.Shutdown()
+	})
+}
+
+func (h *HealthChecker) check() {
+	errs := make([]error, len(h.o.probes))
+	var wg sync.WaitGroup
+	for i, p := range h.o.probes {
+		wg.Add(1)
+		go func(i int, p healthProbe) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), h.o.timeout)
+			defer cancel()
+			errs[i] = p.probe(ctx)
+		}(i, p)
+	}
+	wg.Wait()
+
+	for _, service := range h.services {
+		status := healthPB.HealthCheckResponse_SERVING
+		var failed []string
+		for i, p := range h.o.probes {
+			if errs[i] != nil && p.appliesTo(service) {
+				status = healthPB.HealthCheckResponse_NOT_SERVING
+				failed = append(failed, p.name)
+			}
+		}
+		h.setStatus(service, status, failed, errs)
+	}
+}
+
+func (h *HealthChecker) setStatus(service string, status healthPB.HealthCheckResponse_ServingStatus, failed []string, errs []error) {
+	select {
+	case <-h.done:
+		return // do not resume the status after shutdown
+	default:
+	}
+
+	if old, ok := h.statuses[service]; ok && old == status {
+		return
+	}
+	h.statuses[service] = status
+	h.server.SetServingStatus(service, status)
+
+	if h.o.log != nil {
+		if status == healthPB.HealthCheckResponse_SERVING {
+			h.o.log.Info("grpc health status changed", zap.String("service", service), zap.String("status", status.String()))
+		} else {
+			h.o.log.Warn("grpc health status changed", zap.String("service", service), zap.String("status", status.String()),
+				zap.Strings("failedProbes", failed), zap.Errors("errors", nonNilErrors(errs)))
+		}
+	}
+}
+
+// appliesTo returns whether the probe affects the status of service
+func (p healthProbe) appliesTo(service string) bool {
+	if len(p.services) == 0 {
+		return true
+	}
+	for _, s := range p.services {
+		if s == service {
+			return true
+		}
+	}
+	return false
+}
+
+func nonNilErrors(errs []error) []error {
+	var out []error
+	for _, err := range errs {
+		if err != nil {
+			out = append(out, err)
+		}
+	}
+	return out
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthPB "google.golang.org/grpc/health/grpc_health_v1"
+	reflectionPB "google.golang.org/grpc/reflection/grpc_reflection_v1"
+
+	"github.com/go-dev-frame/sponge/pkg/logger"
+	"github.com/go-dev-frame/sponge/pkg/utils"
+)
+
+func TestHealthChecker(t *testing.T) {
+	var dbDown, cacheDown atomic.Bool
+	checker := NewHealthChecker(
+		WithHealthProbe("database", func(ctx context.Context) error {
+			if dbDown.Load() {
+				return errors.New("database is unreachable")
+			}
+			return nil
+		}),
+		WithHealthProbe("cache", func(ctx context.Context) error {
+			if cacheDown.Load() {
+				return errors.New("cache is unreachable")
+			}
+			return nil
+		}, "api.user.v1.User"),
+		WithHealthInterval(50*time.Millisecond),
+		WithHealthTimeout(time.Second),
+		WithHealthLogger(logger.Get()),
+	)
+
+	port, _ := utils.GetAvailablePort()
+	srv, err := Run(port, func(s *grpc.Server) {
+		s.RegisterService(&grpc.ServiceDesc{ServiceName: "api.user.v1.User", HandlerType: (*interface{})(nil)}, struct{}{})
+		s.RegisterService(&grpc.ServiceDesc{ServiceName: "api.order.v1.Order", HandlerType: (*interface{})(nil)}, struct{}{})
+	}, WithHealthChecker(checker), WithReflection())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient(fmt.Sprintf("localhost:%d", port), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := healthPB.NewHealthClient(conn)
+
+	expectStatus := func(service string, want healthPB.HealthCheckResponse_ServingStatus) {
+		t.Helper()
+		var got healthPB.HealthCheckResponse_ServingStatus
+		for i := 0; i < 20; i++ {
+			resp, err := client.Check(context.Background(), &healthPB.HealthCheckRequest{Service: service})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got = resp.Status; got == want {
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		t.Errorf("service %q: expected status %v, got %v", service, want, got)
+	}
+
+	expectStatus("", healthPB.HealthCheckResponse_SERVING)
+	expectStatus("api.user.v1.User", healthPB.HealthCheckResponse_SERVING)
+
+	cacheDown.Store(true)
+	expectStatus("api.user.v1.User", healthPB.HealthCheckResponse_NOT_SERVING)
+	expectStatus("api.order.v1.Order", healthPB.HealthCheckResponse_SERVING)
+	expectStatus("", healthPB.HealthCheckResponse_SERVING)
+
+	dbDown.Store(true)
+	expectStatus("api.order.v1.Order", healthPB.HealthCheckResponse_NOT_SERVING)
+	expectStatus("", healthPB.HealthCheckResponse_NOT_SERVING)
+
+	dbDown.Store(false)
+	cacheDown.Store(false)
+	expectStatus("api.user.v1.User", healthPB.HealthCheckResponse_SERVING)
+	expectStatus("", healthPB.HealthCheckResponse_SERVING)
+
+	checker.Shutdown()
+	expectStatus("", healthPB.HealthCheckResponse_NOT_SERVING)
+
+	// reflection is enabled
+	stream, err := reflectionPB.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = stream.Send(&reflectionPB.ServerReflectionRequest{MessageRequest: &reflectionPB.ServerReflectionRequest_ListServices{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.GetListServicesResponse().GetService()) < 3 {
+		t.Errorf("expected services to be listed, got %v", resp.GetListServicesResponse())
+	}
+}
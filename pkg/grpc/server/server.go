@@ -7,6 +7,7 @@ import (
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/reflection"
 
 	"github.com/go-dev-frame/sponge/pkg/grpc/metrics"
 )
@@ -28,6 +29,9 @@ type options struct {
 
 	isShowConnections bool
 	connectionOptions []metrics.ConnectionOption
+
+	enableReflection bool
+	healthChecker    *HealthChecker
 }
 
 func defaultServerOptions() *options {
@@ -76,6 +80,20 @@ func WithStatConnections(opts ...metrics.ConnectionOption) Option {
 	}
 }
 
+// WithReflection enable server reflection, it is used by tools such as grpcurl, it is recommended to disable it in production
+func WithReflection() Option {
+	return func(o *options) {
+		o.enableReflection = true
+	}
+}
+
+// WithHealthChecker register the grpc health service, the status of services is updated by the probes of checker
+func WithHealthChecker(checker *HealthChecker) Option {
+	return func(o *options) {
+		o.healthChecker = checker
+	}
+}
+
 func customInterceptorOptions(o *options) []grpc.ServerOption {
 	var opts []grpc.ServerOption
 
@@ -116,6 +134,14 @@ func Run(port int, registerFn RegisterFn, options ...Option) (*grpc.Server, erro
 	// register object to the server
 	registerFn(srv)
 
+	if o.enableReflection {
+		reflection.Register(srv)
+	}
+	if o.healthChecker != nil {
+		o.healthChecker.Register(srv)
+		o.healthChecker.Start()
+	}
+
 	// register service address to Consul/ETCD/Nacos/Zookeeper...
 	if o.serviceRegisterFn != nil {
 		if err = o.serviceRegisterFn(); err != nil {
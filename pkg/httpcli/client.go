@@ -0,0 +1,437 @@
+package httpcli
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/go-dev-frame/sponge/pkg/container/group"
+	"github.com/go-dev-frame/sponge/pkg/shield/circuitbreaker"
+)
+
+const tracerName = "github.com/go-dev-frame/sponge/pkg/httpcli"
+
+// ErrCircuitOpen the request is rejected by the circuit breaker of the host
+var ErrCircuitOpen = circuitbreaker.ErrNotAllowed
+
+// RoundTripFunc send a http request and return the response
+type RoundTripFunc func(req *http.Request) (*http.Response, error)
+
+// Middleware wraps the sending of a http request, it is called for each attempt including retries,
+// e.g. add sign header before the request, record log after the response.
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+// RetryCondition decide whether to retry the request by the response or error
+type RetryCondition func(req *http.Request, resp *http.Response, err error) bool
+
+// ClientOption set options of client.
+type ClientOption func(*clientOptions)
+
+type clientOptions struct {
+	timeout   time.Duration
+	transport http.RoundTripper
+
+	maxRetries     int
+	minBackoff     time.Duration
+	maxBackoff     time.Duration
+	retryCondition RetryCondition
+
+	enableBreaker  bool
+	breakerOptions []circuitbreaker.Option
+	failedCodes    map[int]struct{}
+
+	enableTrace    bool
+	tracerProvider trace.TracerProvider
+	propagators    propagation.TextMapPropagator
+
+	middlewares []Middleware
+}
+
+func defaultClientOptions() *clientOptions {
+	return &clientOptions{
+		timeout:        defaultTimeout,
+		minBackoff:     100 * time.Millisecond,
+		maxBackoff:     3 * time.Second,
+		retryCondition: DefaultRetryCondition,
+		failedCodes: map[int]struct{}{
+			http.StatusInternalServerError: {},
+			http.StatusBadGateway:          {},
+			http.StatusServiceUnavailable:  {},
+			http.StatusGatewayTimeout:      {},
+		},
+	}
+}
+
+func (o *clientOptions) apply(opts ...ClientOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WithClientTimeout set timeout of each attempt, default 30s
+func WithClientTimeout(t time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		if t > 0 {
+			o.timeout = t
+		}
+	}
+}
+
+// WithTransport set the transport of client, default is a clone of http.DefaultTransport
+func WithTransport(transport http.RoundTripper) ClientOption {
+	return func(o *clientOptions) {
+		o.transport = transport
+	}
+}
+
+// WithRetry set the max number of retries, the interval of retries is exponential backoff with full jitter,
+// between minBackoff and maxBackoff, the Retry-After header of response is respected.
+func WithRetry(maxRetries int, minBackoff time.Duration, maxBackoff time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		o.maxRetries = maxRetries
+		if minBackoff > 0 {
+			o.minBackoff = minBackoff
+		}
+		if maxBackoff >= o.minBackoff {
+			o.maxBackoff = maxBackoff
+		}
+	}
+}
+
+// WithRetryCondition set the condition of retry, default is DefaultRetryCondition
+func WithRetryCondition(fn RetryCondition) ClientOption {
+	return func(o *clientOptions) {
+		if fn != nil {
+			o.retryCondition = fn
+		}
+	}
+}
+
+// WithCircuitBreaker enable the circuit breaker of each host, the request is marked failed
+// if there is an error or the http code is 500, 502, 503, 504.
+func WithCircuitBreaker(opts ...circuitbreaker.Option) ClientOption {
+	return func(o *clientOptions) {
+		o.enableBreaker = true
+		o.breakerOptions = opts
+	}
+}
+
+// WithFailedCodes add http codes that mark the request failed of circuit breaker
+func WithFailedCodes(codes ...int) ClientOption {
+	return func(o *clientOptions) {
+		for _, code := range codes {
+			o.failedCodes[code] = struct{}{}
+		}
+	}
+}
+
+// WithTracing enable tracing, a client span is created for each request, and the trace context
+// is propagated to the server by the request headers, the global tracer provider and propagators are used by default.
+func WithTracing(tp ...trace.TracerProvider) ClientOption {
+	return func(o *clientOptions) {
+		o.enableTrace = true
+		if len(tp) > 0 && tp[0] != nil {
+			o.tracerProvider = tp[0]
+		}
+	}
+}
+
+// WithTracePropagators set the propagators of tracing, default is the global propagators
+func WithTracePropagators(propagators propagation.TextMapPropagator) ClientOption {
+	return func(o *clientOptions) {
+		o.propagators = propagators
+	}
+}
+
+// WithMiddlewares set the middlewares of client, the first middleware is the outermost
+func WithMiddlewares(middlewares ...Middleware) ClientOption {
+	return func(o *clientOptions) {
+		o.middlewares = append(o.middlewares, middlewares...)
+	}
+}
+
+// DefaultRetryCondition retry the idempotent requests if there is an error or the http code is 429, 502, 503, 504
+func DefaultRetryCondition(req *http.Request, resp *http.Response, err error) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+	default:
+		return false
+	}
+	if err != nil {
+		return !errors.Is(err, context.Canceled)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// -----------------------------------------------------------------------------------------
+
+// ClientStats statistics of client, including the usage of connection pool
+type ClientStats struct {
+	Requests        int64 // number of requests, the retries are not included
+	Attempts        int64 // number of attempts, including the retries
+	Retries         int64 // number of retries
+	Failures        int64 // number of failed requests after retries
+	BreakerRejected int64 // number of attempts rejected by circuit breaker
+	InFlight        int64 // number of requests in progress
+
+	NewConns     int64 // number of new connections
+	ReusedConns  int64 // number of connections reused from the pool
+	IdleConns    int64 // number of reused connections that were idle in the pool
+	ConnIdleTime int64 // total idle time of reused connections, in milliseconds
+}
+
+type clientStats struct {
+	requests, attempts, retries, failures, breakerRejected, inFlight int64
+	newConns, reusedConns, idleConns, connIdleTime                   int64
+}
+
+// Client is a resilient http client with retry, circuit breaker, tracing, statistics and middlewares,
+// it is safe for concurrent use, it should be created once and reused.
+type Client struct {
+	opts       *clientOptions
+	httpClient *http.Client
+	breakers   *group.Group
+	tracer     trace.Tracer
+	roundTrip  RoundTripFunc
+	stats      *clientStats
+	randMu     sync.Mutex
+	rand       *rand.Rand
+}
+
+// NewClient create a resilient http client
+func NewClient(opts ...ClientOption) *Client {
+	o := defaultClientOptions()
+	o.apply(opts...)
+	if o.transport == nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.MaxIdleConnsPerHost = 100
+		o.transport = transport
+	}
+
+	c := &Client{
+		opts:       o,
+		httpClient: &http.Client{Timeout: o.timeout, Transport: o.transport},
+		stats:      &clientStats{},
+		rand:       rand.New(rand.NewSource(time.Now().UnixNano())), //nolint
+	}
+	if o.enableBreaker {
+		c.breakers = group.NewGroup(func() interface{} {
+			return circuitbreaker.NewBreaker(o.breakerOptions...)
+		})
+	}
+	if o.enableTrace {
+		if o.tracerProvider == nil {
+			o.tracerProvider = otel.GetTracerProvider()
+		}
+		if o.propagators == nil {
+			o.propagators = otel.GetTextMapPropagator()
+		}
+		c.tracer = o.tracerProvider.Tracer(tracerName)
+	}
+
+	c.roundTrip = c.send
+	for i := len(o.middlewares) - 1; i >= 0; i-- {
+		c.roundTrip = o.middlewares[i](c.roundTrip)
+	}
+	return c
+}
+
+// HTTPClient get the underlying http client, the retry, circuit breaker and middlewares are not used by it
+func (c *Client) HTTPClient() *http.Client {
+	return c.httpClient
+}
+
+// NewRequest create a new Request that is sent by the client
+func (c *Client) NewRequest() *Request {
+	return &Request{client: c}
+}
+
+// Stats get the statistics of client
+func (c *Client) Stats() ClientStats {
+	return ClientStats{
+		Requests:        atomic.LoadInt64(&c.stats.requests),
+		Attempts:        atomic.LoadInt64(&c.stats.attempts),
+		Retries:         atomic.LoadInt64(&c.stats.retries),
+		Failures:        atomic.LoadInt64(&c.stats.failures),
+		BreakerRejected: atomic.LoadInt64(&c.stats.breakerRejected),
+		InFlight:        atomic.LoadInt64(&c.stats.inFlight),
+		NewConns:        atomic.LoadInt64(&c.stats.newConns),
+		ReusedConns:     atomic.LoadInt64(&c.stats.reusedConns),
+		IdleConns:       atomic.LoadInt64(&c.stats.idleConns),
+		ConnIdleTime:    atomic.LoadInt64(&c.stats.connIdleTime),
+	}
+}
+
+// Do send a http request, the request is retried according to the retry options,
+// the request body must be rewindable (req.GetBody is not nil) for retrying.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	atomic.AddInt64(&c.stats.requests, 1)
+	atomic.AddInt64(&c.stats.inFlight, 1)
+	defer atomic.AddInt64(&c.stats.inFlight, -1)
+
+	ctx := req.Context()
+	var span trace.Span
+	if c.tracer != nil {
+		ctx, span = c.tracer.Start(ctx, "HTTP "+req.Method, trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("http.method", req.Method),
+				attribute.String("http.url", req.URL.String()),
+				attribute.String("net.peer.name", req.URL.Host),
+			))
+		defer span.End()
+	}
+
+	var (
+		resp    *http.Response
+		err     error
+		attempt int
+	)
+	for ; ; attempt++ {
+		r := req.Clone(ctx)
+		if attempt > 0 {
+			if err = c.wait(ctx, attempt, resp); err != nil {
+				resp = nil
+				break
+			}
+			if req.Body != nil && req.Body != http.NoBody {
+				if r.Body, err = req.GetBody(); err != nil {
+					resp = nil
+					break
+				}
+			}
+			atomic.AddInt64(&c.stats.retries, 1)
+		}
+		if c.opts.propagators != nil {
+			c.opts.propagators.Inject(ctx, propagation.HeaderCarrier(r.Header))
+		}
+
+		resp, err = c.roundTrip(r)
+		if errors.Is(err, ErrCircuitOpen) || attempt >= c.opts.maxRetries || !c.canRewind(req) ||
+			!c.opts.retryCondition(r, resp, err) {
+			break
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			_ = resp.Body.Close()
+		}
+	}
+
+	if err != nil {
+		atomic.AddInt64(&c.stats.failures, 1)
+	}
+	if span != nil {
+		span.SetAttributes(attribute.Int("http.retry_count", attempt))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		} else {
+			span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+			if resp.StatusCode >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+			}
+		}
+	}
+	return resp, err
+}
+
+func (c *Client) canRewind(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// send the request by the circuit breaker of host, and record the usage of connections
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	atomic.AddInt64(&c.stats.attempts, 1)
+
+	var breaker circuitbreaker.CircuitBreaker
+	if c.breakers != nil {
+		breaker = c.breakers.Get(req.URL.Host).(circuitbreaker.CircuitBreaker)
+		if err := breaker.Allow(); err != nil {
+			// NOTE: when client reject request locally, keep adding counter let the drop ratio higher.
+			breaker.MarkFailed()
+			atomic.AddInt64(&c.stats.breakerRejected, 1)
+			return nil, err
+		}
+	}
+
+	clientTrace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if !info.Reused {
+				atomic.AddInt64(&c.stats.newConns, 1)
+				return
+			}
+			atomic.AddInt64(&c.stats.reusedConns, 1)
+			if info.WasIdle {
+				atomic.AddInt64(&c.stats.idleConns, 1)
+				atomic.AddInt64(&c.stats.connIdleTime, info.IdleTime.Milliseconds())
+			}
+		},
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), clientTrace))
+
+	resp, err := c.httpClient.Do(req)
+	if breaker != nil {
+		if err != nil {
+			breaker.MarkFailed()
+		} else if _, ok := c.opts.failedCodes[resp.StatusCode]; ok {
+			breaker.MarkFailed()
+		} else {
+			breaker.MarkSuccess()
+		}
+	}
+	return resp, err
+}
+
+// wait before retrying, the interval is exponential backoff with full jitter
+func (c *Client) wait(ctx context.Context, attempt int, lastResp *http.Response) error {
+	backoff := c.opts.minBackoff << uint(attempt-1)
+	if backoff > c.opts.maxBackoff || backoff <= 0 {
+		backoff = c.opts.maxBackoff
+	}
+	c.randMu.Lock()
+	interval := c.opts.minBackoff + time.Duration(c.rand.Int63n(int64(backoff-c.opts.minBackoff)+1))
+	c.randMu.Unlock()
+	if d := retryAfter(lastResp); d > interval {
+		interval = d
+		if interval > c.opts.maxBackoff {
+			interval = c.opts.maxBackoff
+		}
+	}
+
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// parse the Retry-After header, only the delay seconds is supported
+func retryAfter(resp *http.Response) time.Duration {
+	if resp == nil {
+		return 0
+	}
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,266 @@
+package httpcli
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/go-dev-frame/sponge/pkg/shield/circuitbreaker"
+)
+
+// the first failTimes requests return the statusCode, then return 200
+func newFlakyServer(failTimes int32, statusCode int) (*httptest.Server, *int32) {
+	var count int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if atomic.AddInt32(&count, 1) <= failTimes {
+			w.WriteHeader(statusCode)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":0,"msg":"ok","data":` + strconv.Quote(string(body)) + `}`))
+	}))
+	return srv, &count
+}
+
+func TestClient_Retry(t *testing.T) {
+	srv, count := newFlakyServer(2, http.StatusServiceUnavailable)
+	defer srv.Close()
+
+	c := NewClient(WithRetry(3, time.Millisecond, 5*time.Millisecond))
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	resp, err := c.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(3), atomic.LoadInt32(count))
+
+	stats := c.Stats()
+	assert.Equal(t, int64(1), stats.Requests)
+	assert.Equal(t, int64(3), stats.Attempts)
+	assert.Equal(t, int64(2), stats.Retries)
+	assert.Equal(t, int64(0), stats.Failures)
+	assert.Equal(t, int64(0), stats.InFlight)
+	assert.Equal(t, stats.Attempts, stats.NewConns+stats.ReusedConns)
+
+	// exceed the max retries
+	srv2, count2 := newFlakyServer(10, http.StatusBadGateway)
+	defer srv2.Close()
+	req, _ = http.NewRequest(http.MethodGet, srv2.URL, nil)
+	resp, err = c.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	assert.Equal(t, int32(4), atomic.LoadInt32(count2))
+}
+
+func TestClient_RetryWithBody(t *testing.T) {
+	srv, count := newFlakyServer(1, http.StatusServiceUnavailable)
+	defer srv.Close()
+
+	// POST is not retried by default
+	c := NewClient(WithRetry(2, time.Millisecond, 5*time.Millisecond))
+	req, _ := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("foo"))
+	resp, err := c.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(count))
+
+	// custom retry condition, the body is sent again
+	atomic.StoreInt32(count, 0)
+	c = NewClient(
+		WithRetry(2, time.Millisecond, 5*time.Millisecond),
+		WithRetryCondition(func(req *http.Request, resp *http.Response, err error) bool {
+			return err != nil || resp.StatusCode >= http.StatusInternalServerError
+		}),
+	)
+	req, _ = http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("foo"))
+	resp, err = c.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), `"data":"foo"`)
+	assert.Equal(t, int32(2), atomic.LoadInt32(count))
+}
+
+func TestClient_RetryAfter(t *testing.T) {
+	var count int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&count, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c := NewClient(WithRetry(1, time.Millisecond, 200*time.Millisecond))
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	start := time.Now()
+	resp, err := c.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	elapsed := time.Since(start)
+	assert.GreaterOrEqual(t, elapsed, 200*time.Millisecond) // Retry-After is limited by maxBackoff
+	assert.Less(t, elapsed, time.Second)
+
+	// cancel the request when waiting for retry
+	atomic.StoreInt32(&count, 0)
+	c = NewClient(WithRetry(1, time.Second, 2*time.Second))
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	resp, err = c.Do(req)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Nil(t, resp)
+	assert.Equal(t, int64(1), c.Stats().Failures)
+}
+
+func TestClient_CircuitBreaker(t *testing.T) {
+	srv, _ := newFlakyServer(1000, http.StatusInternalServerError)
+	defer srv.Close()
+
+	c := NewClient(WithCircuitBreaker(circuitbreaker.WithRequest(5), circuitbreaker.WithSuccess(0.9)))
+	var rejected int
+	for i := 0; i < 100; i++ {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+		resp, err := c.Do(req)
+		if err != nil {
+			assert.True(t, errors.Is(err, ErrCircuitOpen))
+			rejected++
+			continue
+		}
+		_ = resp.Body.Close()
+	}
+	assert.Greater(t, rejected, 0)
+	assert.Equal(t, int64(rejected), c.Stats().BreakerRejected)
+
+	// the breaker of other host is not affected
+	srv2, _ := newFlakyServer(0, http.StatusOK)
+	defer srv2.Close()
+	req, _ := http.NewRequest(http.MethodGet, srv2.URL, nil)
+	resp, err := c.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	// custom failed codes
+	srv3, _ := newFlakyServer(1000, http.StatusTooManyRequests)
+	defer srv3.Close()
+	c = NewClient(WithCircuitBreaker(circuitbreaker.WithRequest(5), circuitbreaker.WithSuccess(0.9)),
+		WithFailedCodes(http.StatusTooManyRequests))
+	rejected = 0
+	for i := 0; i < 100; i++ {
+		req, _ := http.NewRequest(http.MethodGet, srv3.URL, nil)
+		resp, err := c.Do(req)
+		if err != nil {
+			rejected++
+			continue
+		}
+		_ = resp.Body.Close()
+	}
+	assert.Greater(t, rejected, 0)
+}
+
+func TestClient_Middlewares(t *testing.T) {
+	srv, _ := newFlakyServer(1, http.StatusServiceUnavailable)
+	defer srv.Close()
+
+	var order []string
+	newMiddleware := func(name string) Middleware {
+		return func(next RoundTripFunc) RoundTripFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				order = append(order, name+"-before")
+				req.Header.Set("X-"+name, "1")
+				resp, err := next(req)
+				order = append(order, name+"-after")
+				return resp, err
+			}
+		}
+	}
+
+	c := NewClient(
+		WithRetry(1, time.Millisecond, time.Millisecond),
+		WithMiddlewares(newMiddleware("m1"), newMiddleware("m2")),
+	)
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	resp, err := c.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, []string{
+		"m1-before", "m2-before", "m2-after", "m1-after", // first attempt
+		"m1-before", "m2-before", "m2-after", "m1-after", // retry
+	}, order)
+	assert.Empty(t, req.Header.Get("X-m1")) // the original request is not modified
+}
+
+func TestClient_Tracing(t *testing.T) {
+	var traceparent atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent.Store(r.Header.Get("traceparent"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	c := NewClient(WithTracing(tp), WithTracePropagators(propagation.TraceContext{}))
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	resp, err := c.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "HTTP GET", spans[0].Name)
+	assert.Contains(t, traceparent.Load().(string), spans[0].SpanContext.TraceID().String())
+}
+
+func TestClient_Request(t *testing.T) {
+	srv, count := newFlakyServer(1, http.StatusServiceUnavailable)
+	defer srv.Close()
+
+	c := NewClient(WithClientTimeout(time.Second), WithRetry(1, time.Millisecond, time.Millisecond))
+	assert.Equal(t, time.Second, c.HTTPClient().Timeout)
+
+	resp, err := c.NewRequest().SetURL(srv.URL).SetContext(context.Background()).GET()
+	require.NoError(t, err)
+	data, err := resp.ReadBody()
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"msg":"ok"`)
+
+	atomic.StoreInt32(count, 0)
+	result := &StdResult{}
+	err = Get(result, srv.URL, WithClient(c), WithContext(context.Background()))
+	require.NoError(t, err)
+	assert.Equal(t, "ok", result.Msg)
+
+	// the body of POST is sent again with custom retry condition
+	atomic.StoreInt32(count, 0)
+	c = NewClient(WithRetry(1, time.Millisecond, time.Millisecond),
+		WithRetryCondition(func(req *http.Request, resp *http.Response, err error) bool {
+			return err == nil && resp.StatusCode == http.StatusServiceUnavailable
+		}))
+	result = &StdResult{}
+	err = Post(result, srv.URL, "bar", WithClient(c))
+	require.NoError(t, err)
+	assert.Equal(t, "bar", result.Data)
+}
@@ -3,12 +3,14 @@ package httpcli
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	json "github.com/bytedance/sonic"
@@ -16,6 +18,34 @@ import (
 
 const defaultTimeout = 30 * time.Second
 
+// HeaderInjector set the headers of outgoing request from its context, e.g. request id, traceparent
+type HeaderInjector func(ctx context.Context, header http.Header)
+
+var (
+	injectorsMu     sync.RWMutex
+	headerInjectors []HeaderInjector
+)
+
+// RegisterHeaderInjector register a header injector, it is called with the context of every request after
+// the headers of request are set, the injector should not override the existing headers,
+// e.g. pkg/gin/middleware registers the propagation of request id and traceparent.
+func RegisterHeaderInjector(injector HeaderInjector) {
+	if injector == nil {
+		return
+	}
+	injectorsMu.Lock()
+	headerInjectors = append(headerInjectors, injector)
+	injectorsMu.Unlock()
+}
+
+func injectHeader(ctx context.Context, header http.Header) {
+	injectorsMu.RLock()
+	defer injectorsMu.RUnlock()
+	for _, injector := range headerInjectors {
+		injector(ctx, header)
+	}
+}
+
 // Request HTTP request
 type Request struct {
 	customRequest func(req *http.Request, data *bytes.Buffer) // used to define HEADER, e.g. to add sign, etc.
@@ -25,6 +55,8 @@ type Request struct {
 	bodyJSON      interface{}            // JSON marshal body data
 	timeout       time.Duration          // Client timeout
 	headers       map[string]string
+	client        *Client         // if not nil, the request is sent by the client
+	ctx           context.Context // context of request, e.g. propagate trace, cancel request
 
 	request  *http.Request
 	response *Response
@@ -52,6 +84,7 @@ func (req *Request) Reset() {
 	req.bodyJSON = nil
 	req.timeout = 0
 	req.headers = nil
+	req.ctx = nil
 
 	req.request = nil
 	req.response = nil
@@ -131,6 +164,19 @@ func (req *Request) SetHeaders(headers map[string]string) *Request {
 	return req
 }
 
+// SetClient set the client to send the request, retry, circuit breaker, tracing and middlewares
+// of the client are used, and the timeout of client is used instead of SetTimeout.
+func (req *Request) SetClient(c *Client) *Request {
+	req.client = c
+	return req
+}
+
+// SetContext set the context of request
+func (req *Request) SetContext(ctx context.Context) *Request {
+	req.ctx = ctx
+	return req
+}
+
 // CustomRequest customize request, e.g. add sign, set header, etc.
 func (req *Request) CustomRequest(f func(req *http.Request, data *bytes.Buffer)) *Request {
 	req.customRequest = f
@@ -240,7 +286,11 @@ func (req *Request) push() (*Response, error) {
 }
 
 func (req *Request) send(body io.Reader, buf *bytes.Buffer) (*Response, error) {
-	req.request, req.err = http.NewRequest(req.method, req.url, body)
+	ctx := req.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	req.request, req.err = http.NewRequestWithContext(ctx, req.method, req.url, body)
 	if req.err != nil {
 		return nil, req.err
 	}
@@ -254,14 +304,18 @@ func (req *Request) send(body io.Reader, buf *bytes.Buffer) (*Response, error) {
 			req.request.Header.Add(k, v)
 		}
 	}
+	injectHeader(ctx, req.request.Header)
 
-	if req.timeout < 1 {
-		req.timeout = defaultTimeout
-	}
-
-	client := http.Client{Timeout: req.timeout}
 	resp := new(Response)
-	resp.Response, resp.err = client.Do(req.request)
+	if req.client != nil {
+		resp.Response, resp.err = req.client.Do(req.request)
+	} else {
+		if req.timeout < 1 {
+			req.timeout = defaultTimeout
+		}
+		client := http.Client{Timeout: req.timeout}
+		resp.Response, resp.err = client.Do(req.request)
+	}
 
 	req.response = resp
 	req.err = resp.err
@@ -333,6 +387,8 @@ type options struct {
 	params  map[string]interface{}
 	headers map[string]string
 	timeout time.Duration
+	client  *Client
+	ctx     context.Context
 }
 
 func (o *options) apply(opts ...Option) {
@@ -366,39 +422,53 @@ func WithTimeout(t time.Duration) Option {
 	}
 }
 
+// WithClient set the client to send the request, see NewClient
+func WithClient(c *Client) Option {
+	return func(o *options) {
+		o.client = c
+	}
+}
+
+// WithContext set the context of request
+func WithContext(ctx context.Context) Option {
+	return func(o *options) {
+		o.ctx = ctx
+	}
+}
+
 // Get request, return custom json format
 func Get(result interface{}, urlStr string, opts ...Option) error {
 	o := defaultOptions()
 	o.apply(opts...)
-	return gDo("GET", result, urlStr, o.params, o.headers, o.timeout)
+	return gDo("GET", result, urlStr, o)
 }
 
 // Delete request, return custom json format
 func Delete(result interface{}, urlStr string, opts ...Option) error {
 	o := defaultOptions()
 	o.apply(opts...)
-	return gDo("DELETE", result, urlStr, o.params, o.headers, o.timeout)
+	return gDo("DELETE", result, urlStr, o)
 }
 
 // Post request, return custom json format
 func Post(result interface{}, urlStr string, body interface{}, opts ...Option) error {
 	o := defaultOptions()
 	o.apply(opts...)
-	return do("POST", result, urlStr, body, o.params, o.headers, o.timeout)
+	return do("POST", result, urlStr, body, o)
 }
 
 // Put request, return custom json format
 func Put(result interface{}, urlStr string, body interface{}, opts ...Option) error {
 	o := defaultOptions()
 	o.apply(opts...)
-	return do("PUT", result, urlStr, body, o.params, o.headers, o.timeout)
+	return do("PUT", result, urlStr, body, o)
 }
 
 // Patch request, return custom json format
 func Patch(result interface{}, urlStr string, body interface{}, opts ...Option) error {
 	o := defaultOptions()
 	o.apply(opts...)
-	return do("PATCH", result, urlStr, body, o.params, o.headers, o.timeout)
+	return do("PATCH", result, urlStr, body, o)
 }
 
 var requestErr = func(err error) error { return fmt.Errorf("request error, err=%v", err) }
@@ -414,18 +484,18 @@ var notOKErr = func(resp *Response) error {
 	return fmt.Errorf("statusCode=%d, body=%s", resp.StatusCode, body)
 }
 
-func do(method string, result interface{}, urlStr string, body interface{}, params KV, headers map[string]string, timeout time.Duration) error {
+func do(method string, result interface{}, urlStr string, body interface{}, o *options) error {
 	if result == nil {
 		return fmt.Errorf("'result' can not be nil")
 	}
 
-	req := &Request{}
+	req := &Request{client: o.client, ctx: o.ctx}
 	req.SetURL(urlStr)
 	req.SetContentType("application/json")
-	req.SetParams(params)
-	req.SetHeaders(headers)
+	req.SetParams(o.params)
+	req.SetHeaders(o.headers)
 	req.SetBody(body)
-	req.SetTimeout(timeout)
+	req.SetTimeout(o.timeout)
 
 	var resp *Response
 	var err error
@@ -454,12 +524,12 @@ func do(method string, result interface{}, urlStr string, body interface{}, para
 	return nil
 }
 
-func gDo(method string, result interface{}, urlStr string, params KV, headers map[string]string, timeout time.Duration) error {
-	req := &Request{}
+func gDo(method string, result interface{}, urlStr string, o *options) error {
+	req := &Request{client: o.client, ctx: o.ctx}
 	req.SetURL(urlStr)
-	req.SetParams(params)
-	req.SetHeaders(headers)
-	req.SetTimeout(timeout)
+	req.SetParams(o.params)
+	req.SetHeaders(o.headers)
+	req.SetTimeout(o.timeout)
 
 	var resp *Response
 	var err error
@@ -720,11 +720,11 @@ func TestError(t *testing.T) {
 	err = notOKErr(resp)
 	assert.Error(t, err)
 
-	err = do(http.MethodPost, nil, "", nil, nil, nil, 0)
+	err = do(http.MethodPost, nil, "", nil, defaultOptions())
 	assert.Error(t, err)
-	err = do(http.MethodPost, &StdResult{}, "http://127.0.0.1:0", nil, KV{"foo": "bar"}, nil, 0)
+	err = do(http.MethodPost, &StdResult{}, "http://127.0.0.1:0", nil, &options{params: KV{"foo": "bar"}})
 	assert.Error(t, err)
 
-	err = gDo(http.MethodGet, nil, "http://127.0.0.1:0", nil, nil, 0)
+	err = gDo(http.MethodGet, nil, "http://127.0.0.1:0", defaultOptions())
 	assert.Error(t, err)
 }
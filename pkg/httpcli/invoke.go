@@ -0,0 +1,152 @@
+package httpcli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// APIError the error of api response whose code is not 0, the format of error message is the same as
+// errcode.Error, so the error code can be parsed by errcode.ParseError, e.g. errcode.Is(err, ecode.ErrNotFound)
+type APIError struct {
+	HTTPStatus int    // http status code of response
+	Code       int    // error code of response
+	Msg        string // error message of response
+}
+
+// Error return error message
+func (e *APIError) Error() string {
+	return fmt.Sprintf("code = %d, msg = %s", e.Code, e.Msg)
+}
+
+// Invoke send a request to the api whose response format is {"code":0, "msg":"ok", "data":{}}, it is used by the
+// typed http client generated from proto file. The request in is encoded as query parameters if the method is GET or
+// DELETE, otherwise it is encoded as json body, the data of response is decoded to reply, if the code of response is
+// not 0, *APIError is returned. The options WithParams, WithHeaders, WithTimeout and WithClient are supported.
+func Invoke(ctx context.Context, method string, urlStr string, in interface{}, reply interface{}, opts ...Option) error {
+	o := defaultOptions()
+	o.apply(opts...)
+	if ctx == nil {
+		ctx = o.ctx
+	}
+
+	req := &Request{client: o.client, ctx: ctx}
+	req.SetParams(o.params)
+	req.SetHeaders(o.headers)
+	req.SetTimeout(o.timeout)
+
+	switch method {
+	case http.MethodGet, http.MethodDelete:
+		query, err := encodeQuery(in)
+		if err != nil {
+			return err
+		}
+		if query != "" {
+			if strings.Contains(urlStr, "?") {
+				urlStr += "&" + query
+			} else {
+				urlStr += "?" + query
+			}
+		}
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		req.SetContentType("application/json")
+		if in != nil {
+			req.SetBody(in)
+		}
+	default:
+		return errors.New("unsupported method " + method)
+	}
+	req.SetURL(urlStr)
+
+	resp, err := req.Do(method, nil)
+	if err != nil {
+		return requestErr(err)
+	}
+	defer resp.Body.Close() //nolint
+
+	body, err := resp.ReadBody()
+	if err != nil {
+		return requestErr(err)
+	}
+	result := &struct {
+		Code int             `json:"code"`
+		Msg  string          `json:"msg"`
+		Data json.RawMessage `json:"data"`
+	}{Code: -1}
+	if err = json.Unmarshal(body, result); err != nil || result.Code == -1 {
+		if resp.StatusCode != http.StatusOK {
+			return notOKErr(resp)
+		}
+		if err == nil {
+			err = errors.New("not found field 'code'")
+		}
+		return jsonParseErr(err)
+	}
+	if result.Code != 0 {
+		return &APIError{HTTPStatus: resp.StatusCode, Code: result.Code, Msg: result.Msg}
+	}
+
+	if reply == nil || len(result.Data) == 0 || bytes.Equal(result.Data, []byte("null")) {
+		return nil
+	}
+	if err = json.Unmarshal(result.Data, reply); err != nil {
+		return jsonParseErr(err)
+	}
+	return nil
+}
+
+// encode the fields of struct to query parameters by json tag, the nested objects are ignored,
+// the slice is encoded as repeated parameters, e.g. ids=1&ids=2
+func encodeQuery(in interface{}) (string, error) {
+	if in == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(in)
+	if err != nil {
+		return "", err
+	}
+
+	fields := map[string]interface{}{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber() // avoid losing the precision of int64
+	if err = decoder.Decode(&fields); err != nil {
+		return "", fmt.Errorf("the request must be a struct or map, %v", err)
+	}
+
+	values := url.Values{}
+	for k, v := range fields {
+		switch val := v.(type) {
+		case []interface{}:
+			for _, item := range val {
+				if s, ok := queryValue(item); ok {
+					values.Add(k, s)
+				}
+			}
+		default:
+			if s, ok := queryValue(val); ok {
+				values.Set(k, s)
+			}
+		}
+	}
+	return values.Encode(), nil
+}
+
+func queryValue(v interface{}) (string, bool) {
+	switch val := v.(type) {
+	case string:
+		return val, true
+	case json.Number:
+		return val.String(), true
+	case bool:
+		if val {
+			return "true", true
+		}
+		return "false", true
+	}
+	return "", false // null or nested object
+}
@@ -0,0 +1,127 @@
+package httpcli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type invokeRequest struct {
+	ID    uint64   `json:"id"`
+	Name  string   `json:"name"`
+	Tags  []string `json:"tags"`
+	Valid bool     `json:"valid"`
+	Inner *struct {
+		Foo string `json:"foo"`
+	} `json:"inner"`
+}
+
+type invokeReply struct {
+	ID     uint64 `json:"id"`
+	Method string `json:"method"`
+	Query  string `json:"query"`
+	Body   string `json:"body"`
+	Token  string `json:"token"`
+}
+
+func newInvokeServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/user/1":
+			body, _ := io.ReadAll(r.Body)
+			reply := &invokeReply{
+				ID:     1,
+				Method: r.Method,
+				Query:  r.URL.RawQuery,
+				Body:   string(body),
+				Token:  r.Header.Get("Authorization"),
+			}
+			data, _ := json.Marshal(reply)
+			_, _ = w.Write([]byte(`{"code":0,"msg":"ok","data":` + string(data) + `}`))
+		case "/api/v1/user/2":
+			_, _ = w.Write([]byte(`{"code":0,"msg":"ok","data":{}}`))
+		case "/api/v1/user/404":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"code":200404,"msg":"not found user","data":{}}`))
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte(`bad gateway`))
+		}
+	}))
+}
+
+func TestInvoke(t *testing.T) {
+	srv := newInvokeServer()
+	defer srv.Close()
+
+	ctx := context.Background()
+	in := &invokeRequest{ID: 18446744073709551615, Name: "foo bar", Tags: []string{"a", "b"}, Valid: true}
+
+	// query parameters
+	reply := &invokeReply{}
+	err := Invoke(ctx, http.MethodGet, srv.URL+"/api/v1/user/1", in, reply, WithHeaders(map[string]string{"Authorization": "Bearer token"}))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), reply.ID)
+	assert.Equal(t, http.MethodGet, reply.Method)
+	assert.Equal(t, "id=18446744073709551615&name=foo+bar&tags=a&tags=b&valid=true", reply.Query)
+	assert.Equal(t, "Bearer token", reply.Token)
+
+	reply = &invokeReply{}
+	err = Invoke(ctx, http.MethodDelete, srv.URL+"/api/v1/user/1?foo=bar", &invokeRequest{}, reply, WithParams(KV{"page": 1}))
+	require.NoError(t, err)
+	assert.Equal(t, "foo=bar&id=0&name=&valid=false&page=1", reply.Query)
+
+	// json body
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodPatch} {
+		reply = &invokeReply{}
+		err = Invoke(ctx, method, srv.URL+"/api/v1/user/1", in, reply, WithClient(NewClient()))
+		require.NoError(t, err)
+		assert.Equal(t, method, reply.Method)
+		assert.Empty(t, reply.Query)
+		assert.Equal(t, `{"id":18446744073709551615,"name":"foo bar","tags":["a","b"],"valid":true,"inner":null}`, reply.Body)
+	}
+
+	// empty data
+	reply = &invokeReply{}
+	err = Invoke(ctx, http.MethodGet, srv.URL+"/api/v1/user/2", nil, reply)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), reply.ID)
+	err = Invoke(ctx, http.MethodPost, srv.URL+"/api/v1/user/2", nil, nil)
+	require.NoError(t, err)
+}
+
+func TestInvokeError(t *testing.T) {
+	srv := newInvokeServer()
+	defer srv.Close()
+
+	ctx := context.Background()
+	reply := &invokeReply{}
+
+	err := Invoke(ctx, http.MethodGet, srv.URL+"/api/v1/user/404", nil, reply)
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusNotFound, apiErr.HTTPStatus)
+	assert.Equal(t, 200404, apiErr.Code)
+	assert.Equal(t, "code = 200404, msg = not found user", err.Error())
+
+	err = Invoke(ctx, http.MethodGet, srv.URL+"/unknown", nil, reply)
+	assert.Error(t, err)
+	assert.False(t, errors.As(err, &apiErr))
+
+	err = Invoke(ctx, http.MethodHead, srv.URL+"/api/v1/user/1", nil, reply)
+	assert.Error(t, err)
+
+	err = Invoke(ctx, http.MethodGet, srv.URL+"/api/v1/user/1", "not a struct", reply)
+	assert.Error(t, err)
+
+	err = Invoke(ctx, http.MethodGet, "http://127.0.0.1:0", nil, reply)
+	assert.Error(t, err)
+}
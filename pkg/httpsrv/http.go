@@ -4,7 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 )
 
 // TLSer abstract different TLS operation schemes
@@ -19,6 +25,15 @@ type Server struct {
 	server *http.Server // server is the HTTP server instance.
 
 	tlser TLSer
+
+	httpServer   *http.Server  // Optional: plain http or redirect server, only used in https mode.
+	drainTimeout time.Duration // Maximum time to wait for in-flight requests when stopping.
+
+	maxConns    int           // Maximum number of concurrent connections, 0 means no limit.
+	maxRequests int           // Maximum number of concurrent requests, 0 means no limit.
+	retryAfter  time.Duration // Retry-After of the requests rejected by the limits.
+	limiters    []*limiter
+	installOnce sync.Once
 }
 
 // New returns a new Server with TLSer injected.
@@ -34,9 +49,91 @@ func New(server *http.Server, tlser ...TLSer) *Server {
 	}
 
 	return &Server{
-		scheme: scheme,
-		server: server,
-		tlser:  tlsMode,
+		scheme:       scheme,
+		server:       server,
+		tlser:        tlsMode,
+		drainTimeout: 10 * time.Second,
+		retryAfter:   time.Second,
+	}
+}
+
+// EnableHTTP also serves the handler over plain http on httpAddr, only valid in https mode.
+func (s *Server) EnableHTTP(httpAddr string) *Server {
+	if s.server != nil {
+		s.httpServer = s.newHTTPServer(httpAddr, s.server.Handler)
+	}
+	return s
+}
+
+// EnableHTTPRedirect listens on httpAddr and redirects http requests to https, only valid in https mode.
+// Note: in Let's Encrypt mode, use WithTLSEncryptEnableRedirect instead, which also handles ACME challenges.
+func (s *Server) EnableHTTPRedirect(httpAddr string) *Server {
+	if s.server != nil {
+		s.httpServer = s.newHTTPServer(httpAddr, redirectHandler(s.server.Addr))
+	}
+	return s
+}
+
+// SetDrainTimeout sets the maximum time to wait for in-flight requests when stopping, default is 10s.
+func (s *Server) SetDrainTimeout(d time.Duration) *Server {
+	if d > 0 {
+		s.drainTimeout = d
+	}
+	return s
+}
+
+// SetMaxConns sets the maximum number of concurrent connections, the requests of the connections accepted
+// over the limit are rejected with 503 and Retry-After, and the connections are closed after the response,
+// default is 0 means no limit.
+func (s *Server) SetMaxConns(n int) *Server {
+	if n > 0 {
+		s.maxConns = n
+	}
+	return s
+}
+
+// SetMaxRequests sets the maximum number of requests handled concurrently, the requests over the limit
+// are rejected with 503 and Retry-After, default is 0 means no limit.
+func (s *Server) SetMaxRequests(n int) *Server {
+	if n > 0 {
+		s.maxRequests = n
+	}
+	return s
+}
+
+// SetRetryAfter sets the Retry-After header of the requests rejected by the limits, it is rounded up
+// to seconds, default is 1s.
+func (s *Server) SetRetryAfter(d time.Duration) *Server {
+	if d > 0 {
+		s.retryAfter = d
+	}
+	return s
+}
+
+// installLimiters tracks the connections and requests of servers, and applies the limits,
+// the metrics are exposed by the default prometheus registry.
+func (s *Server) installLimiters() {
+	s.installOnce.Do(func() {
+		for _, server := range []*http.Server{s.server, s.httpServer} {
+			if server == nil {
+				continue
+			}
+			l := newLimiter(server.Addr, s.maxConns, s.maxRequests, s.retryAfter)
+			l.install(server)
+			s.limiters = append(s.limiters, l)
+		}
+	})
+}
+
+func (s *Server) newHTTPServer(addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadTimeout:       s.server.ReadTimeout,
+		ReadHeaderTimeout: s.server.ReadHeaderTimeout,
+		WriteTimeout:      s.server.WriteTimeout,
+		IdleTimeout:       s.server.IdleTimeout,
+		MaxHeaderBytes:    s.server.MaxHeaderBytes,
 	}
 }
 
@@ -55,13 +152,58 @@ func (s *Server) Run() error {
 	if err := s.validate(); err != nil {
 		return err
 	}
+	s.installLimiters()
 
 	// no TLS mode specified, run in http mode.
 	if s.tlser == nil {
 		return s.runHTTP()
 	}
 
-	return s.tlser.Run(s.server)
+	if s.httpServer == nil {
+		return s.tlser.Run(s.server)
+	}
+
+	// listen on both http and https, if one of them stops, stop the other.
+	errCh := make(chan error, 2)
+	go func() {
+		errCh <- listenAndServe(s.httpServer)
+	}()
+	go func() {
+		errCh <- s.tlser.Run(s.server)
+	}()
+
+	err := <-errCh
+	ctx, cancel := context.WithTimeout(context.Background(), s.drainTimeout)
+	defer cancel()
+	_ = s.Shutdown(ctx)
+	if err2 := <-errCh; err == nil {
+		err = err2
+	}
+	return err
+}
+
+// RunWithSignal starts the server, and gracefully shuts it down when receiving SIGINT or SIGTERM,
+// in-flight requests are drained within the drain timeout.
+func (s *Server) RunWithSignal() error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.Run()
+	}()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sig)
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-sig:
+	}
+
+	if err := s.Stop(); err != nil {
+		return err
+	}
+	return <-errCh
 }
 
 // Shutdown gracefully shuts down the server and releases resources.
@@ -69,12 +211,25 @@ func (s *Server) Shutdown(ctx context.Context) error {
 	if s.server == nil {
 		return nil
 	}
-	return s.server.Shutdown(ctx)
+	s.installLimiters() // wait for the installation if the server is starting
+	for _, l := range s.limiters {
+		l.startDraining()
+	}
+	var errs []error
+	if s.httpServer != nil {
+		errs = append(errs, s.httpServer.Shutdown(ctx))
+	}
+	errs = append(errs, s.server.Shutdown(ctx))
+	return errors.Join(errs...)
 }
 
 // runHTTP starts the server in http mode, without TLS.
 func (s *Server) runHTTP() error {
-	if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+	return listenAndServe(s.server)
+}
+
+func listenAndServe(server *http.Server) error {
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return fmt.Errorf("[http server] listen and serve error: %v", err)
 	}
 	return nil
@@ -84,3 +239,47 @@ func (s *Server) runHTTP() error {
 func (s *Server) Scheme() string {
 	return s.scheme
 }
+
+// Start starts the server, implements the app.IServer interface.
+func (s *Server) Start() error {
+	return s.Run()
+}
+
+// Stop gracefully shuts down the server within the drain timeout, implements the app.IServer interface.
+func (s *Server) Stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.drainTimeout)
+	defer cancel()
+	return s.Shutdown(ctx)
+}
+
+// String returns the server address, implements the app.IServer interface.
+func (s *Server) String() string {
+	if s.server == nil {
+		return s.scheme + " service"
+	}
+	str := s.scheme + " service address is " + s.server.Addr
+	if s.tlser != nil && s.httpServer != nil {
+		str += ", http address is " + s.httpServer.Addr
+	}
+	return str
+}
+
+// redirectHandler redirects http requests to the https server listening on httpsAddr.
+func redirectHandler(httpsAddr string) http.Handler {
+	_, port, _ := net.SplitHostPort(httpsAddr)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+
+		code := http.StatusPermanentRedirect // keep method and body
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			code = http.StatusMovedPermanently
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), code)
+	})
+}
@@ -2,11 +2,20 @@ package httpsrv
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/go-dev-frame/sponge/pkg/app"
+	"github.com/go-dev-frame/sponge/pkg/utils"
 )
 
+var _ app.IServer = (*Server)(nil)
+
 func TestServer_New(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -136,3 +145,90 @@ func (m *mockTLSer) Validate() error {
 func (m *mockTLSer) Run(server *http.Server) error {
 	return m.runError
 }
+
+func TestServer_DualListen(t *testing.T) {
+	certFile, keyFile := writeTestCert(t, t.TempDir())
+	httpsPort, _ := utils.GetAvailablePort()
+	httpPort, _ := utils.GetAvailablePort()
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+	})
+	client := &http.Client{
+		Timeout:       2 * time.Second,
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+
+	t.Run("redirect", func(t *testing.T) {
+		server := &http.Server{Addr: fmt.Sprintf("localhost:%d", httpsPort), Handler: handler}
+		srv := New(server, NewTLSExternalConfig(certFile, keyFile)).
+			EnableHTTPRedirect(fmt.Sprintf("localhost:%d", httpPort)).
+			SetDrainTimeout(time.Second)
+		errCh := make(chan error, 1)
+		go func() { errCh <- srv.Start() }()
+		time.Sleep(200 * time.Millisecond)
+
+		resp, err := client.Get(fmt.Sprintf("http://localhost:%d/hello?name=foo", httpPort))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		want := fmt.Sprintf("https://localhost:%d/hello?name=foo", httpsPort)
+		if resp.StatusCode != http.StatusMovedPermanently || resp.Header.Get("Location") != want {
+			t.Errorf("expected redirect to %s, got %d %s", want, resp.StatusCode, resp.Header.Get("Location"))
+		}
+		if !strings.Contains(srv.String(), "http address is") {
+			t.Errorf("unexpected String(): %s", srv.String())
+		}
+
+		if err = srv.Stop(); err != nil {
+			t.Errorf("Stop() error = %v", err)
+		}
+		if err = <-errCh; err != nil {
+			t.Errorf("Start() error = %v", err)
+		}
+	})
+
+	t.Run("plain http", func(t *testing.T) {
+		server := &http.Server{Addr: fmt.Sprintf("localhost:%d", httpsPort), Handler: handler}
+		srv := New(server, NewTLSExternalConfig(certFile, keyFile)).EnableHTTP(fmt.Sprintf("localhost:%d", httpPort))
+		errCh := make(chan error, 1)
+		go func() { errCh <- srv.Run() }()
+		time.Sleep(200 * time.Millisecond)
+
+		resp, err := client.Get(fmt.Sprintf("http://localhost:%d/", httpPort))
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if string(body) != "hello" {
+			t.Errorf("expected hello, got %s", body)
+		}
+
+		_ = srv.Stop()
+		<-errCh
+	})
+
+	t.Run("listen error stops the other server", func(t *testing.T) {
+		server := &http.Server{Addr: fmt.Sprintf("localhost:%d", httpsPort), Handler: handler}
+		srv := New(server, NewTLSExternalConfig(certFile, keyFile)).EnableHTTP("invalid-addr")
+		errCh := make(chan error, 1)
+		go func() { errCh <- srv.Run() }()
+		select {
+		case err := <-errCh:
+			if err == nil {
+				t.Error("expected an error")
+			}
+		case <-time.After(3 * time.Second):
+			t.Error("expected Run() to return")
+		}
+	})
+}
+
+func TestRedirectHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	redirectHandler(":443").ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "http://example.com/api/v1/user", nil))
+	if rec.Code != http.StatusPermanentRedirect || rec.Header().Get("Location") != "https://example.com/api/v1/user" {
+		t.Errorf("unexpected redirect %d %s", rec.Code, rec.Header().Get("Location"))
+	}
+}
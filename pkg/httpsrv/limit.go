@@ -0,0 +1,158 @@
+package httpsrv
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// reasons of rejected requests
+const (
+	rejectReasonConns    = "connections"
+	rejectReasonRequests = "requests"
+)
+
+var (
+	activeConnsGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "httpsrv_connections_active",
+			Help: "Number of open connections of http server.",
+		},
+		[]string{"addr"},
+	)
+
+	drainingConnsGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "httpsrv_connections_draining",
+			Help: "Number of connections that are not closed yet after the http server starts shutting down.",
+		},
+		[]string{"addr"},
+	)
+
+	inFlightRequestsGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "httpsrv_requests_in_flight",
+			Help: "Number of requests being handled by http server.",
+		},
+		[]string{"addr"},
+	)
+
+	rejectedCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "httpsrv_rejected_total",
+			Help: "Total number of requests rejected with 503, reason is connections or requests.",
+		},
+		[]string{"addr", "reason"},
+	)
+
+	registerMetricsOnce sync.Once
+)
+
+type overConnsKey struct{}
+
+// limiter tracks the connections and requests of an http server, and rejects the requests over the limits
+// with 503 and Retry-After.
+type limiter struct {
+	addr        string
+	maxConns    int64
+	maxRequests int64
+	retryAfter  string
+
+	conns    atomic.Int64
+	requests atomic.Int64
+	draining atomic.Bool
+}
+
+func newLimiter(addr string, maxConns int, maxRequests int, retryAfter time.Duration) *limiter {
+	seconds := int64((retryAfter + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return &limiter{
+		addr:        addr,
+		maxConns:    int64(maxConns),
+		maxRequests: int64(maxRequests),
+		retryAfter:  strconv.FormatInt(seconds, 10),
+	}
+}
+
+// install the hooks of connections and the handler of limits into server, the hooks set by the
+// caller are still called.
+func (l *limiter) install(server *http.Server) {
+	registerMetricsOnce.Do(func() {
+		prometheus.MustRegister(activeConnsGauge, drainingConnsGauge, inFlightRequestsGauge, rejectedCounter)
+	})
+
+	connContext := server.ConnContext
+	server.ConnContext = func(ctx context.Context, c net.Conn) context.Context {
+		if connContext != nil {
+			ctx = connContext(ctx, c)
+		}
+		n := l.conns.Add(1)
+		activeConnsGauge.WithLabelValues(l.addr).Set(float64(n))
+		if l.maxConns > 0 && n > l.maxConns {
+			// the requests of the connection are rejected, and the connection is closed after the response
+			ctx = context.WithValue(ctx, overConnsKey{}, true)
+		}
+		return ctx
+	}
+
+	connState := server.ConnState
+	server.ConnState = func(c net.Conn, state http.ConnState) {
+		if state == http.StateClosed || state == http.StateHijacked {
+			n := l.conns.Add(-1)
+			activeConnsGauge.WithLabelValues(l.addr).Set(float64(n))
+			if l.draining.Load() {
+				drainingConnsGauge.WithLabelValues(l.addr).Set(float64(n))
+			}
+		}
+		if connState != nil {
+			connState(c, state)
+		}
+	}
+
+	server.Handler = l.handler(server.Handler)
+}
+
+func (l *limiter) handler(next http.Handler) http.Handler {
+	if next == nil {
+		next = http.DefaultServeMux
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if over, _ := r.Context().Value(overConnsKey{}).(bool); over {
+			w.Header().Set("Connection", "close")
+			l.reject(w, rejectReasonConns)
+			return
+		}
+
+		n := l.requests.Add(1)
+		defer func() {
+			inFlightRequestsGauge.WithLabelValues(l.addr).Set(float64(l.requests.Add(-1)))
+		}()
+		if l.maxRequests > 0 && n > l.maxRequests {
+			l.reject(w, rejectReasonRequests)
+			return
+		}
+		inFlightRequestsGauge.WithLabelValues(l.addr).Set(float64(n))
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (l *limiter) reject(w http.ResponseWriter, reason string) {
+	rejectedCounter.WithLabelValues(l.addr, reason).Inc()
+	w.Header().Set("Retry-After", l.retryAfter)
+	http.Error(w, "service unavailable, too many "+reason, http.StatusServiceUnavailable)
+}
+
+// startDraining marks the server is shutting down, the connections not closed yet are counted as draining.
+func (l *limiter) startDraining() {
+	l.draining.Store(true)
+	drainingConnsGauge.WithLabelValues(l.addr).Set(float64(l.conns.Load()))
+}
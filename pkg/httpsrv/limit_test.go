@@ -0,0 +1,138 @@
+package httpsrv
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/go-dev-frame/sponge/pkg/utils"
+)
+
+func runLimitServer(t *testing.T, handler http.Handler, setup func(s *Server)) (*Server, string) {
+	port, _ := utils.GetAvailablePort()
+	addr := fmt.Sprintf("127.0.0.1:%d", port)
+	srv := New(&http.Server{Addr: addr, Handler: handler})
+	setup(srv)
+	go func() {
+		_ = srv.Run()
+	}()
+	time.Sleep(200 * time.Millisecond)
+	return srv, "http://" + addr
+}
+
+func TestServer_MaxRequests(t *testing.T) {
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			<-release
+		}
+		_, _ = w.Write([]byte("ok"))
+	})
+	srv, baseURL := runLimitServer(t, handler, func(s *Server) {
+		s.SetMaxRequests(1).SetRetryAfter(1500 * time.Millisecond)
+	})
+	defer srv.Stop() //nolint
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		resp, err := http.Get(baseURL + "/slow")
+		if err != nil {
+			t.Errorf("request error: %v", err)
+			return
+		}
+		_, _ = io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	resp, err := http.Get(baseURL + "/fast")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("status code = %d, want 503", resp.StatusCode)
+	}
+	if v := resp.Header.Get("Retry-After"); v != "2" {
+		t.Errorf("Retry-After = %s, want 2", v)
+	}
+	l := srv.limiters[0]
+	if v := testutil.ToFloat64(rejectedCounter.WithLabelValues(l.addr, rejectReasonRequests)); v != 1 {
+		t.Errorf("rejected requests = %v, want 1", v)
+	}
+
+	close(release)
+	wg.Wait()
+	resp, err = http.Get(baseURL + "/fast")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status code = %d, want 200", resp.StatusCode)
+	}
+}
+
+func TestServer_MaxConns(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+	srv, baseURL := runLimitServer(t, handler, func(s *Server) {
+		s.SetMaxConns(1)
+	})
+
+	// the first client keeps the connection alive
+	client1 := &http.Client{Transport: &http.Transport{}}
+	resp, err := client1.Get(baseURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status code = %d, want 200", resp.StatusCode)
+	}
+
+	client2 := &http.Client{Transport: &http.Transport{}}
+	resp, err = client2.Get(baseURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || !resp.Close {
+		t.Errorf("status code = %d, close = %v, want 503 and close", resp.StatusCode, resp.Close)
+	}
+	if v := resp.Header.Get("Retry-After"); v != "1" {
+		t.Errorf("Retry-After = %s, want 1", v)
+	}
+
+	// the rejected connection is closed, the connection of client1 is draining when shutting down
+	time.Sleep(100 * time.Millisecond)
+	l := srv.limiters[0]
+	if n := l.conns.Load(); n != 1 {
+		t.Errorf("active connections = %d, want 1", n)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err = srv.Shutdown(ctx); err != nil {
+		t.Error(err)
+	}
+	time.Sleep(100 * time.Millisecond) // the state of closed connections is set asynchronously
+	if v := testutil.ToFloat64(drainingConnsGauge.WithLabelValues(l.addr)); v != 0 {
+		t.Errorf("draining connections = %v, want 0", v)
+	}
+	if v := testutil.ToFloat64(activeConnsGauge.WithLabelValues(l.addr)); v != 0 {
+		t.Errorf("active connections = %v, want 0", v)
+	}
+}
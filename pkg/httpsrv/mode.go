@@ -10,6 +10,10 @@ const (
 	ModeTLSEncrypt Mode = "encrypt"
 	// ModeTLSExternal runs the server using user-provided TLS certificate and key files.
 	ModeTLSExternal Mode = "external"
+	// ModeTLSFile runs the server using TLS certificate and key files, and reloads them when the files change.
+	ModeTLSFile Mode = "file"
+	// ModeTLSSecret runs the server using TLS certificate from a secret store (Vault PKI, AWS Secrets Manager), and renews it before expiry.
+	ModeTLSSecret Mode = "secret"
 	// ModeRemoteAPI runs the server using remote API to manage TLS certificate.
 	ModeRemoteAPI = "remote-api"
 )
@@ -17,6 +17,7 @@ type tlsEncryptOptions struct {
 	cacheDir       string
 	httpAddr       string
 	enableRedirect bool
+	clientAuth     *ClientAuth
 }
 
 func (o *tlsEncryptOptions) apply(opts ...TLSEncryptOption) {
@@ -52,16 +53,24 @@ func WithTLSEncryptEnableRedirect(httpAddr ...string) TLSEncryptOption {
 	}
 }
 
+// WithTLSEncryptClientAuth sets client certificate verification (mutual TLS), see NewClientAuth.
+func WithTLSEncryptClientAuth(clientAuth *ClientAuth) TLSEncryptOption {
+	return func(o *tlsEncryptOptions) {
+		o.clientAuth = clientAuth
+	}
+}
+
 // ------------------------------------------------------------------------------------------
 
 var _ TLSer = (*TLSAutoEncryptConfig)(nil)
 
 type TLSAutoEncryptConfig struct {
-	domain         string // The domain to request a certificate for in production mode.
-	email          string // Used for Let's Encrypt account registration and important notices.
-	cacheDir       string // Directory to store Let's Encrypt certificates.
-	httpAddr       string // Listen address for the HTTP redirect service (defaults to :80).
-	enableRedirect bool   // Enable HTTP-to-HTTPS redirect service (default: false).
+	domain         string      // The domain to request a certificate for in production mode.
+	email          string      // Used for Let's Encrypt account registration and important notices.
+	cacheDir       string      // Directory to store Let's Encrypt certificates.
+	httpAddr       string      // Listen address for the HTTP redirect service (defaults to :80).
+	enableRedirect bool        // Enable HTTP-to-HTTPS redirect service (default: false).
+	clientAuth     *ClientAuth // Optional: client certificate verification.
 
 	m              *autocert.Manager // Manages certificates automatically.
 	redirectServer *http.Server      // The HTTP redirect server.
@@ -77,6 +86,7 @@ func NewTLSEAutoEncryptConfig(domain string, email string, opts ...TLSEncryptOpt
 		cacheDir:       o.cacheDir,
 		httpAddr:       o.httpAddr,
 		enableRedirect: o.enableRedirect,
+		clientAuth:     o.clientAuth,
 	}
 }
 
@@ -105,6 +115,9 @@ func (c *TLSAutoEncryptConfig) Run(server *http.Server) error {
 	}
 	c.m = m
 	server.TLSConfig = m.TLSConfig()
+	if err := c.clientAuth.configure(server); err != nil {
+		return err
+	}
 
 	if c.enableRedirect {
 		go func() {
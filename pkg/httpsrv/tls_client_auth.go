@@ -0,0 +1,350 @@
+package httpsrv
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/ocsp"
+)
+
+// ClientAuthOption set clientAuthOptions.
+type ClientAuthOption func(*clientAuthOptions)
+
+type clientAuthOptions struct {
+	caPool      *x509.CertPool
+	optional    bool
+	allowedSANs []string
+	crlFiles    []string
+	ocsp        bool
+	ocspStrict  bool
+	ocspTimeout time.Duration
+}
+
+func (o *clientAuthOptions) apply(opts ...ClientAuthOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+func defaultClientAuthOptions() *clientAuthOptions {
+	return &clientAuthOptions{
+		ocspTimeout: 3 * time.Second,
+	}
+}
+
+// WithClientAuthCAPool sets the CA pool for verifying client certificates, the CAs in caFiles are added to it.
+func WithClientAuthCAPool(pool *x509.CertPool) ClientAuthOption {
+	return func(o *clientAuthOptions) {
+		o.caPool = pool
+	}
+}
+
+// WithClientAuthOptional only verifies the client certificate if the client provides one,
+// by default a valid client certificate is required.
+func WithClientAuthOptional() ClientAuthOption {
+	return func(o *clientAuthOptions) {
+		o.optional = true
+	}
+}
+
+// WithClientAuthAllowedSANs sets the subject alternative names allowed in client certificates, a DNS name,
+// IP address, email or URI (e.g. spiffe://cluster.local/ns/default/sa/user) of the certificate must match one of them,
+// DNS names support a leading wildcard such as *.svc.cluster.local. By default, any verified certificate is allowed.
+func WithClientAuthAllowedSANs(sans ...string) ClientAuthOption {
+	return func(o *clientAuthOptions) {
+		o.allowedSANs = sans
+	}
+}
+
+// WithClientAuthCRLFiles sets the certificate revocation list files (PEM or DER), revoked client certificates are rejected.
+func WithClientAuthCRLFiles(files ...string) ClientAuthOption {
+	return func(o *clientAuthOptions) {
+		o.crlFiles = files
+	}
+}
+
+// WithClientAuthOCSP enables checking client certificates against the OCSP responder in the certificate,
+// if strict is false, the certificate is allowed when the responder is unavailable (soft fail).
+func WithClientAuthOCSP(strict bool) ClientAuthOption {
+	return func(o *clientAuthOptions) {
+		o.ocsp = true
+		o.ocspStrict = strict
+	}
+}
+
+// WithClientAuthOCSPTimeout sets the timeout of an OCSP request, default is 3s.
+func WithClientAuthOCSPTimeout(d time.Duration) ClientAuthOption {
+	return func(o *clientAuthOptions) {
+		o.ocspTimeout = d
+	}
+}
+
+// ------------------------------------------------------------------------------------------
+
+// ClientAuth verifies client certificates (mutual TLS), it can be added to any TLSer,
+// e.g. WithTLSExternalClientAuth, or applied to a custom tls.Config.
+type ClientAuth struct {
+	caFiles     []string
+	caPool      *x509.CertPool
+	optional    bool
+	allowedSANs []string
+	crlFiles    []string
+	ocsp        bool
+	ocspStrict  bool
+	ocspTimeout time.Duration
+
+	revoked    map[string]struct{} // issuer|serial of revoked certificates loaded from CRL files
+	ocspClient *http.Client
+	ocspCache  sync.Map // issuer|serial -> ocspCacheEntry
+}
+
+type ocspCacheEntry struct {
+	status     int
+	nextUpdate time.Time
+}
+
+// NewClientAuth creates a client certificate verifier, caFiles are PEM files of the CAs that issue client certificates.
+func NewClientAuth(caFiles []string, opts ...ClientAuthOption) *ClientAuth {
+	o := defaultClientAuthOptions()
+	o.apply(opts...)
+	return &ClientAuth{
+		caFiles:     caFiles,
+		caPool:      o.caPool,
+		optional:    o.optional,
+		allowedSANs: o.allowedSANs,
+		crlFiles:    o.crlFiles,
+		ocsp:        o.ocsp,
+		ocspStrict:  o.ocspStrict,
+		ocspTimeout: o.ocspTimeout,
+	}
+}
+
+// Validate checks the settings.
+func (a *ClientAuth) Validate() error {
+	if len(a.caFiles) == 0 && a.caPool == nil {
+		return errors.New("client CA files or CA pool must be specified for client auth")
+	}
+	if a.ocspTimeout <= 0 {
+		a.ocspTimeout = 3 * time.Second
+	}
+	return nil
+}
+
+// Apply loads the CA and CRL files, and sets client certificate verification on the tls config.
+func (a *ClientAuth) Apply(tlsConfig *tls.Config) error {
+	if err := a.Validate(); err != nil {
+		return err
+	}
+
+	pool := a.caPool
+	if pool == nil {
+		pool = x509.NewCertPool()
+	} else {
+		pool = pool.Clone()
+	}
+	for _, file := range a.caFiles {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read client CA file: %v", err)
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return fmt.Errorf("no valid certificate found in client CA file '%s'", file)
+		}
+	}
+
+	revoked, err := loadCRLFiles(a.crlFiles)
+	if err != nil {
+		return err
+	}
+	a.revoked = revoked
+	a.ocspClient = &http.Client{Timeout: a.ocspTimeout}
+
+	tlsConfig.ClientCAs = pool
+	tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	if a.optional {
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	tlsConfig.VerifyPeerCertificate = a.verifyPeerCertificate
+
+	// ACME tls-alpn-01 challenges (Let's Encrypt) are validated without client certificates
+	if containsString(tlsConfig.NextProtos, acme.ALPNProto) {
+		acmeConfig := tlsConfig.Clone()
+		acmeConfig.ClientAuth = tls.NoClientCert
+		acmeConfig.VerifyPeerCertificate = nil
+		tlsConfig.GetConfigForClient = func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			if len(hello.SupportedProtos) == 1 && hello.SupportedProtos[0] == acme.ALPNProto {
+				return acmeConfig, nil
+			}
+			return nil, nil
+		}
+	}
+	return nil
+}
+
+// configure sets client certificate verification on the server, a is allowed to be nil.
+func (a *ClientAuth) configure(server *http.Server) error {
+	if a == nil {
+		return nil
+	}
+	if server.TLSConfig == nil {
+		server.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	return a.Apply(server.TLSConfig)
+}
+
+// verifyPeerCertificate checks the verified client certificate chain against the allowed SANs, CRLs and OCSP.
+func (a *ClientAuth) verifyPeerCertificate(_ [][]byte, verifiedChains [][]*x509.Certificate) error {
+	if len(verifiedChains) == 0 || len(verifiedChains[0]) == 0 {
+		return nil // no client certificate in optional mode
+	}
+	chain := verifiedChains[0]
+	leaf := chain[0]
+
+	if len(a.allowedSANs) > 0 && !a.matchSAN(leaf) {
+		return fmt.Errorf("client certificate '%s' is not allowed", leaf.Subject.String())
+	}
+
+	for i := 0; i < len(chain)-1; i++ {
+		cert, issuer := chain[i], chain[i+1]
+		key := certKey(issuer, cert)
+		if _, ok := a.revoked[key]; ok {
+			return fmt.Errorf("client certificate '%s' has been revoked", cert.Subject.String())
+		}
+		if a.ocsp && len(cert.OCSPServer) > 0 {
+			if err := a.checkOCSP(cert, issuer); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (a *ClientAuth) matchSAN(cert *x509.Certificate) bool {
+	var sans []string
+	sans = append(sans, cert.DNSNames...)
+	sans = append(sans, cert.EmailAddresses...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	for _, u := range cert.URIs {
+		sans = append(sans, u.String())
+	}
+
+	for _, allowed := range a.allowedSANs {
+		for _, san := range sans {
+			if strings.EqualFold(allowed, san) {
+				return true
+			}
+			if strings.HasPrefix(allowed, "*.") {
+				if i := strings.Index(san, "."); i > 0 && strings.EqualFold(allowed[1:], san[i:]) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func (a *ClientAuth) checkOCSP(cert *x509.Certificate, issuer *x509.Certificate) error {
+	key := certKey(issuer, cert)
+	if v, ok := a.ocspCache.Load(key); ok {
+		entry := v.(ocspCacheEntry)
+		if time.Now().Before(entry.nextUpdate) {
+			return ocspStatusError(cert, entry.status)
+		}
+	}
+
+	resp, err := a.queryOCSP(cert, issuer)
+	if err != nil {
+		if a.ocspStrict {
+			return fmt.Errorf("check OCSP of client certificate '%s' error: %v", cert.Subject.String(), err)
+		}
+		return nil
+	}
+	nextUpdate := resp.NextUpdate
+	if nextUpdate.IsZero() {
+		nextUpdate = time.Now().Add(time.Hour)
+	}
+	a.ocspCache.Store(key, ocspCacheEntry{status: resp.Status, nextUpdate: nextUpdate})
+	return ocspStatusError(cert, resp.Status)
+}
+
+func (a *ClientAuth) queryOCSP(cert *x509.Certificate, issuer *x509.Certificate) (*ocsp.Response, error) {
+	req, err := ocsp.CreateRequest(cert, issuer, nil)
+	if err != nil {
+		return nil, err
+	}
+	var lastErr error
+	for _, server := range cert.OCSPServer {
+		httpResp, err := a.ocspClient.Post(server, "application/ocsp-request", bytes.NewReader(req))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		body, err := io.ReadAll(httpResp.Body)
+		_ = httpResp.Body.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if httpResp.StatusCode != http.StatusOK {
+			lastErr = fmt.Errorf("unexpected status: %s", httpResp.Status)
+			continue
+		}
+		return ocsp.ParseResponseForCert(body, cert, issuer)
+	}
+	return nil, lastErr
+}
+
+func ocspStatusError(cert *x509.Certificate, status int) error {
+	if status == ocsp.Revoked {
+		return fmt.Errorf("client certificate '%s' has been revoked", cert.Subject.String())
+	}
+	return nil
+}
+
+func loadCRLFiles(files []string) (map[string]struct{}, error) {
+	revoked := make(map[string]struct{})
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CRL file: %v", err)
+		}
+		if block, _ := pem.Decode(data); block != nil {
+			data = block.Bytes
+		}
+		crl, err := x509.ParseRevocationList(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CRL file '%s': %v", file, err)
+		}
+		for _, entry := range crl.RevokedCertificateEntries {
+			revoked[string(crl.RawIssuer)+"|"+entry.SerialNumber.String()] = struct{}{}
+		}
+	}
+	return revoked, nil
+}
+
+// certKey identifies a certificate by its issuer and serial number.
+func certKey(issuer *x509.Certificate, cert *x509.Certificate) string {
+	return string(issuer.RawSubject) + "|" + cert.SerialNumber.String()
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,190 @@
+package httpsrv
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	file string
+}
+
+func newTestCA(t *testing.T, dir string) *testCA {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	file := filepath.Join(dir, "ca.pem")
+	_ = os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	return &testCA{cert: cert, key: key, file: file}
+}
+
+func (ca *testCA) issue(t *testing.T, serial int64, dnsName string) tls.Certificate {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: dnsName},
+		DNSNames:     []string{dnsName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func (ca *testCA) writeCRL(t *testing.T, dir string, serials ...int64) string {
+	var entries []x509.RevocationListEntry
+	for _, serial := range serials {
+		entries = append(entries, x509.RevocationListEntry{SerialNumber: big.NewInt(serial), RevocationTime: time.Now()})
+	}
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:                    big.NewInt(1),
+		ThisUpdate:                time.Now(),
+		NextUpdate:                time.Now().Add(time.Hour),
+		RevokedCertificateEntries: entries,
+	}, ca.cert, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "crl.pem")
+	_ = os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der}), 0600)
+	return file
+}
+
+func requestWithClientCert(t *testing.T, clientAuth *ClientAuth, certs ...tls.Certificate) error {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	ts.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+	if err := clientAuth.Apply(ts.TLS); err != nil {
+		t.Fatalf("Apply() failed: %v", err)
+	}
+	ts.StartTLS()
+	defer ts.Close()
+
+	client := ts.Client()
+	client.Transport.(*http.Transport).TLSClientConfig.Certificates = certs
+	resp, err := client.Get(ts.URL)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	return nil
+}
+
+func TestClientAuth(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, dir)
+	otherCA := newTestCA(t, t.TempDir())
+	clientCert := ca.issue(t, 10, "order.svc.cluster.local")
+	revokedCert := ca.issue(t, 11, "user.svc.cluster.local")
+	crlFile := ca.writeCRL(t, dir, 11)
+
+	t.Run("require client certificate", func(t *testing.T) {
+		clientAuth := NewClientAuth([]string{ca.file})
+		if err := requestWithClientCert(t, clientAuth, clientCert); err != nil {
+			t.Errorf("expected request to succeed, got %v", err)
+		}
+		if err := requestWithClientCert(t, clientAuth); err == nil {
+			t.Error("expected an error without client certificate")
+		}
+		if err := requestWithClientCert(t, clientAuth, otherCA.issue(t, 10, "order.svc.cluster.local")); err == nil {
+			t.Error("expected an error for certificate issued by unknown CA")
+		}
+	})
+
+	t.Run("optional client certificate", func(t *testing.T) {
+		clientAuth := NewClientAuth([]string{ca.file}, WithClientAuthOptional())
+		if err := requestWithClientCert(t, clientAuth); err != nil {
+			t.Errorf("expected request to succeed, got %v", err)
+		}
+		if err := requestWithClientCert(t, clientAuth, clientCert); err != nil {
+			t.Errorf("expected request to succeed, got %v", err)
+		}
+	})
+
+	t.Run("allowed SANs", func(t *testing.T) {
+		clientAuth := NewClientAuth([]string{ca.file}, WithClientAuthAllowedSANs("*.svc.cluster.local"))
+		if err := requestWithClientCert(t, clientAuth, clientCert); err != nil {
+			t.Errorf("expected request to succeed, got %v", err)
+		}
+		clientAuth = NewClientAuth([]string{ca.file}, WithClientAuthAllowedSANs("user.svc.cluster.local", "spiffe://cluster.local/ns/default/sa/user"))
+		if err := requestWithClientCert(t, clientAuth, clientCert); err == nil {
+			t.Error("expected an error for SAN not allowed")
+		}
+	})
+
+	t.Run("CRL", func(t *testing.T) {
+		clientAuth := NewClientAuth([]string{ca.file}, WithClientAuthCRLFiles(crlFile))
+		if err := requestWithClientCert(t, clientAuth, clientCert); err != nil {
+			t.Errorf("expected request to succeed, got %v", err)
+		}
+		if err := requestWithClientCert(t, clientAuth, revokedCert); err == nil {
+			t.Error("expected an error for revoked certificate")
+		}
+	})
+
+	t.Run("invalid settings", func(t *testing.T) {
+		if err := NewClientAuth(nil).Apply(&tls.Config{}); err == nil {
+			t.Error("expected an error without CA")
+		}
+		if err := NewClientAuth([]string{filepath.Join(dir, "notfound.pem")}).Apply(&tls.Config{}); err == nil {
+			t.Error("expected an error for missing CA file")
+		}
+		if err := NewClientAuth([]string{ca.file}, WithClientAuthCRLFiles(ca.file)).Apply(&tls.Config{}); err == nil {
+			t.Error("expected an error for invalid CRL file")
+		}
+	})
+}
+
+func TestClientAuth_configure(t *testing.T) {
+	var clientAuth *ClientAuth
+	server := &http.Server{}
+	if err := clientAuth.configure(server); err != nil || server.TLSConfig != nil {
+		t.Error("expected nil client auth to be ignored")
+	}
+
+	ca := newTestCA(t, t.TempDir())
+	clientAuth = NewClientAuth([]string{ca.file})
+	if err := clientAuth.configure(server); err != nil {
+		t.Fatal(err)
+	}
+	if server.TLSConfig.ClientAuth != tls.RequireAndVerifyClientCert || server.TLSConfig.ClientCAs == nil {
+		t.Error("expected client certificate to be required")
+	}
+
+	config := NewTLSExternalConfig("cert.pem", "key.pem", WithTLSExternalClientAuth(clientAuth))
+	if config.clientAuth != clientAuth {
+		t.Error("expected client auth to be set")
+	}
+}
@@ -6,17 +6,47 @@ import (
 	"net/http"
 )
 
+// TLSExternalOption set tlsExternalOptions.
+type TLSExternalOption func(*tlsExternalOptions)
+
+type tlsExternalOptions struct {
+	clientAuth *ClientAuth
+}
+
+func (o *tlsExternalOptions) apply(opts ...TLSExternalOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+func defaultTLSExternalOptions() *tlsExternalOptions {
+	return &tlsExternalOptions{}
+}
+
+// WithTLSExternalClientAuth set client certificate verification (mutual TLS), see NewClientAuth.
+func WithTLSExternalClientAuth(clientAuth *ClientAuth) TLSExternalOption {
+	return func(o *tlsExternalOptions) {
+		o.clientAuth = clientAuth
+	}
+}
+
+// -------------------------------------------------------------------------------------------
+
 var _ TLSer = (*TLSExternalConfig)(nil)
 
 type TLSExternalConfig struct {
-	certFile string
-	keyFile  string
+	certFile   string
+	keyFile    string
+	clientAuth *ClientAuth
 }
 
-func NewTLSExternalConfig(certFile, keyFile string) *TLSExternalConfig {
+func NewTLSExternalConfig(certFile, keyFile string, opts ...TLSExternalOption) *TLSExternalConfig {
+	o := defaultTLSExternalOptions()
+	o.apply(opts...)
 	return &TLSExternalConfig{
-		certFile: certFile,
-		keyFile:  keyFile,
+		certFile:   certFile,
+		keyFile:    keyFile,
+		clientAuth: o.clientAuth,
 	}
 }
 
@@ -31,6 +61,10 @@ func (c *TLSExternalConfig) Validate() error {
 }
 
 func (c *TLSExternalConfig) Run(server *http.Server) error {
+	if err := c.clientAuth.configure(server); err != nil {
+		return err
+	}
+
 	if err := server.ListenAndServeTLS(c.certFile, c.keyFile); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return fmt.Errorf("[https server] listen and serve TLS error: %v", err)
 	}
@@ -0,0 +1,191 @@
+package httpsrv
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TLSFileOption set tlsFileOptions.
+type TLSFileOption func(*tlsFileOptions)
+
+type tlsFileOptions struct {
+	watchInterval time.Duration
+	onReload      func(err error)
+	clientAuth    *ClientAuth
+}
+
+func (o *tlsFileOptions) apply(opts ...TLSFileOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+func defaultTLSFileOptions() *tlsFileOptions {
+	return &tlsFileOptions{
+		watchInterval: 10 * time.Second,
+	}
+}
+
+// WithTLSFileWatchInterval set the interval for checking whether the cert and key files have changed.
+func WithTLSFileWatchInterval(d time.Duration) TLSFileOption {
+	return func(o *tlsFileOptions) {
+		o.watchInterval = d
+	}
+}
+
+// WithTLSFileOnReload set the callback function called after each reload attempt,
+// err is nil if the new certificate is in use.
+func WithTLSFileOnReload(fn func(err error)) TLSFileOption {
+	return func(o *tlsFileOptions) {
+		o.onReload = fn
+	}
+}
+
+// WithTLSFileClientAuth set client certificate verification (mutual TLS), see NewClientAuth.
+func WithTLSFileClientAuth(clientAuth *ClientAuth) TLSFileOption {
+	return func(o *tlsFileOptions) {
+		o.clientAuth = clientAuth
+	}
+}
+
+// -------------------------------------------------------------------------------------------
+
+var _ TLSer = (*TLSFileConfig)(nil)
+
+// TLSFileConfig serves the certificate and key from disk, and reloads them when the files change,
+// e.g. renewed by cert-manager or certbot, new connections use the new certificate without restarting the server.
+//
+// The files are checked by polling their modification time and size, which also works when
+// the files are replaced by symlink swapping (e.g. kubernetes secret volumes).
+type TLSFileConfig struct {
+	certFile      string
+	keyFile       string
+	watchInterval time.Duration
+	onReload      func(err error)
+	clientAuth    *ClientAuth
+
+	cert    atomic.Pointer[tls.Certificate]
+	mu      sync.Mutex
+	certSig string // modification time and size of the loaded files
+}
+
+func NewTLSFileConfig(certFile, keyFile string, opts ...TLSFileOption) *TLSFileConfig {
+	o := defaultTLSFileOptions()
+	o.apply(opts...)
+	return &TLSFileConfig{
+		certFile:      certFile,
+		keyFile:       keyFile,
+		watchInterval: o.watchInterval,
+		onReload:      o.onReload,
+		clientAuth:    o.clientAuth,
+	}
+}
+
+func (c *TLSFileConfig) Validate() error {
+	if c.certFile == "" {
+		return errors.New("cert file must be specified in file mode")
+	}
+	if c.keyFile == "" {
+		return errors.New("key file must be specified in file mode")
+	}
+	if c.watchInterval <= 0 {
+		c.watchInterval = 10 * time.Second
+	}
+	return nil
+}
+
+// GetCertificate returns the certificate currently in use, it can be used as tls.Config.GetCertificate.
+func (c *TLSFileConfig) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cert := c.cert.Load()
+	if cert == nil {
+		return nil, errors.New("certificate is not loaded")
+	}
+	return cert, nil
+}
+
+// Reload loads the cert and key files if they have changed since the last load,
+// the certificate in use is kept if the new files are invalid.
+func (c *TLSFileConfig) Reload() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sig, err := c.fileSignature()
+	if err != nil {
+		return err
+	}
+	if sig == c.certSig && c.cert.Load() != nil {
+		return nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("load cert and key files error: %v", err)
+	}
+	c.cert.Store(&cert)
+	c.certSig = sig
+	return nil
+}
+
+func (c *TLSFileConfig) fileSignature() (string, error) {
+	var sig string
+	for _, file := range []string{c.certFile, c.keyFile} {
+		fi, err := os.Stat(file)
+		if err != nil {
+			return "", err
+		}
+		sig += fmt.Sprintf("%d-%d;", fi.ModTime().UnixNano(), fi.Size())
+	}
+	return sig, nil
+}
+
+func (c *TLSFileConfig) watch(done <-chan struct{}) {
+	ticker := time.NewTicker(c.watchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			c.mu.Lock()
+			sig, err := c.fileSignature()
+			changed := err != nil || sig != c.certSig
+			c.mu.Unlock()
+			if !changed {
+				continue
+			}
+			err = c.Reload()
+			if c.onReload != nil {
+				c.onReload(err)
+			}
+		}
+	}
+}
+
+func (c *TLSFileConfig) Run(server *http.Server) error {
+	if err := c.Reload(); err != nil {
+		return err
+	}
+
+	if server.TLSConfig == nil {
+		server.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	server.TLSConfig.GetCertificate = c.GetCertificate
+	if err := c.clientAuth.configure(server); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go c.watch(done)
+
+	if err := server.ListenAndServeTLS("", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("[https server] listen and serve TLS error: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package httpsrv
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestCert generates a self-signed certificate into dir, returns the cert and key file paths.
+func writeTestCert(t *testing.T, dir string) (string, string) {
+	config := NewTLSSelfSignedConfig(WithTLSSelfSignedCacheDir(dir))
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.createCert(); err != nil {
+		t.Fatalf("createCert() failed: %v", err)
+	}
+	return config.certFile, config.keyFile
+}
+
+func TestTLSFileConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		certFile  string
+		keyFile   string
+		wantError bool
+	}{
+		{name: "valid configuration", certFile: "cert.pem", keyFile: "key.pem", wantError: false},
+		{name: "missing certificate file", certFile: "", keyFile: "key.pem", wantError: true},
+		{name: "missing key file", certFile: "cert.pem", keyFile: "", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := NewTLSFileConfig(tt.certFile, tt.keyFile, WithTLSFileWatchInterval(0))
+			err := config.Validate()
+			if (err != nil) != tt.wantError {
+				t.Errorf("Validate() error = %v, wantError %v", err, tt.wantError)
+			}
+			if err == nil && config.watchInterval != 10*time.Second {
+				t.Errorf("expected default watch interval, got %v", config.watchInterval)
+			}
+		})
+	}
+}
+
+func TestTLSFileConfig_Reload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCert(t, dir)
+
+	config := NewTLSFileConfig(certFile, keyFile)
+	if _, err := config.GetCertificate(nil); err == nil {
+		t.Error("expected an error before the certificate is loaded")
+	}
+	if err := config.Reload(); err != nil {
+		t.Fatalf("Reload() failed: %v", err)
+	}
+	cert1, _ := config.GetCertificate(nil)
+
+	// unchanged files are not reloaded
+	if err := config.Reload(); err != nil {
+		t.Fatalf("Reload() failed: %v", err)
+	}
+	if cert, _ := config.GetCertificate(nil); cert != cert1 {
+		t.Error("expected certificate to be unchanged")
+	}
+
+	// invalid files keep the certificate in use
+	if err := os.WriteFile(keyFile, []byte("invalid"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Reload(); err == nil {
+		t.Error("expected an error for invalid key file")
+	}
+	if cert, _ := config.GetCertificate(nil); cert != cert1 {
+		t.Error("expected certificate in use to be kept")
+	}
+}
+
+func TestTLSFileConfig_Run(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCert(t, dir)
+
+	reloaded := make(chan error, 10)
+	config := NewTLSFileConfig(certFile, keyFile,
+		WithTLSFileWatchInterval(20*time.Millisecond),
+		WithTLSFileOnReload(func(err error) { reloaded <- err }),
+	)
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := lis.Addr().String()
+	_ = lis.Close()
+
+	server := &http.Server{
+		Addr: addr,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("OK"))
+		}),
+	}
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- config.Run(server)
+	}()
+
+	peerCert := func() []byte {
+		var conn *tls.Conn
+		var err error
+		for i := 0; i < 50; i++ {
+			conn, err = tls.Dial("tcp", addr, &tls.Config{InsecureSkipVerify: true}) //nolint
+			if err == nil {
+				break
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		if err != nil {
+			t.Fatalf("dial error: %v", err)
+		}
+		defer conn.Close()
+		return conn.ConnectionState().PeerCertificates[0].Raw
+	}
+
+	before := peerCert()
+
+	// renew the certificate files, new connections get the new certificate
+	time.Sleep(10 * time.Millisecond) // make sure the modification time changes
+	newCertFile, newKeyFile := writeTestCert(t, filepath.Join(dir, "new"))
+	for src, dst := range map[string]string{newCertFile: certFile, newKeyFile: keyFile} {
+		data, _ := os.ReadFile(src)
+		if err = os.WriteFile(dst, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deadline := time.After(3 * time.Second)
+	for {
+		select {
+		case err = <-reloaded:
+			if err != nil {
+				continue // the cert may be written before the key
+			}
+		case <-deadline:
+			t.Fatal("expected certificate to be reloaded")
+		}
+		break
+	}
+	if after := peerCert(); bytes.Equal(before, after) {
+		t.Error("expected the new certificate to be served")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_ = server.Shutdown(ctx)
+	if err = <-errChan; err != nil {
+		t.Errorf("Run() returned unexpected error: %v", err)
+	}
+}
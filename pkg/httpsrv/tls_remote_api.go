@@ -1,6 +1,8 @@
 package httpsrv
 
 import (
+	"crypto/sha256"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -8,16 +10,23 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
+var errCertNotModified = errors.New("certificate not modified")
+
 // TLSRemoteAPIOption set tlsRemoteAPIOptions.
 type TLSRemoteAPIOption func(*tlsRemoteAPIOptions)
 
 type tlsRemoteAPIOptions struct {
-	headers  map[string]string
-	timeout  time.Duration
-	cacheDir string
+	headers         map[string]string
+	timeout         time.Duration
+	cacheDir        string
+	refreshInterval time.Duration
+	onRefresh       func(changed bool, err error)
+	clientAuth      *ClientAuth
 }
 
 func (o *tlsRemoteAPIOptions) apply(opts ...TLSRemoteAPIOption) {
@@ -28,8 +37,9 @@ func (o *tlsRemoteAPIOptions) apply(opts ...TLSRemoteAPIOption) {
 
 func defaultTLSRemoteAPIOptions() *tlsRemoteAPIOptions {
 	return &tlsRemoteAPIOptions{
-		timeout:  5 * time.Second,
-		cacheDir: "configs/remote_api_certs",
+		timeout:         5 * time.Second,
+		cacheDir:        "configs/remote_api_certs",
+		refreshInterval: time.Hour,
 	}
 }
 
@@ -54,6 +64,29 @@ func WithTLSRemoteAPICacheDir(cacheDir string) TLSRemoteAPIOption {
 	}
 }
 
+// WithTLSRemoteAPIRefreshInterval set the interval for downloading the certificate again in the background,
+// the new certificate is used for new connections if it has changed, default is 1 hour, 0 means disabled.
+func WithTLSRemoteAPIRefreshInterval(d time.Duration) TLSRemoteAPIOption {
+	return func(o *tlsRemoteAPIOptions) {
+		o.refreshInterval = d
+	}
+}
+
+// WithTLSRemoteAPIOnRefresh set the callback function called after each background refresh,
+// changed is true if the new certificate is in use.
+func WithTLSRemoteAPIOnRefresh(fn func(changed bool, err error)) TLSRemoteAPIOption {
+	return func(o *tlsRemoteAPIOptions) {
+		o.onRefresh = fn
+	}
+}
+
+// WithTLSRemoteAPIClientAuth set client certificate verification (mutual TLS), see NewClientAuth.
+func WithTLSRemoteAPIClientAuth(clientAuth *ClientAuth) TLSRemoteAPIOption {
+	return func(o *tlsRemoteAPIOptions) {
+		o.clientAuth = clientAuth
+	}
+}
+
 // -------------------------------------------------------------------------------------------
 
 var _ TLSer = (*TLSRemoteAPIConfig)(nil)
@@ -68,17 +101,28 @@ type TLSRemoteAPIConfig struct {
 	certFile string // Cached certificate file path
 	keyFile  string // Cached private key file path
 
-	httpClient *http.Client // Internal HTTP client
+	refreshInterval time.Duration                 // Optional: background refresh interval
+	onRefresh       func(changed bool, err error) // Optional: refresh callback
+	clientAuth      *ClientAuth                   // Optional: client certificate verification
+
+	httpClient *http.Client                    // Internal HTTP client
+	cert       atomic.Pointer[tls.Certificate] // Certificate in use
+	mu         sync.Mutex                      // Serializes refreshing
+	etag       string                          // ETag of the last downloaded response
+	certHash   [sha256.Size]byte               // Hash of the certificate and key in use
 }
 
 func NewTLSRemoteAPIConfig(url string, opts ...TLSRemoteAPIOption) *TLSRemoteAPIConfig {
 	o := defaultTLSRemoteAPIOptions()
 	o.apply(opts...)
 	return &TLSRemoteAPIConfig{
-		url:      url,
-		headers:  o.headers,
-		timeout:  o.timeout,
-		cacheDir: o.cacheDir,
+		url:             url,
+		headers:         o.headers,
+		timeout:         o.timeout,
+		cacheDir:        o.cacheDir,
+		refreshInterval: o.refreshInterval,
+		onRefresh:       o.onRefresh,
+		clientAuth:      o.clientAuth,
 	}
 }
 
@@ -117,22 +161,120 @@ func (c *TLSRemoteAPIConfig) Run(server *http.Server) error {
 	}
 	if err == nil {
 		// write cert and key to file
-		_ = os.MkdirAll(c.cacheDir, 0760)
-		if err = os.WriteFile(c.certFile, certData, 0640); err != nil {
-			return fmt.Errorf("failed to write cert file: %v", err)
-		}
-		if err = os.WriteFile(c.keyFile, keyData, 0640); err != nil {
-			return fmt.Errorf("failed to write key file: %v", err)
+		if err = c.writeFiles(certData, keyData); err != nil {
+			return err
 		}
 	}
 
-	if err = server.ListenAndServeTLS(c.certFile, c.keyFile); err != nil && !errors.Is(err, http.ErrServerClosed) {
+	// use the downloaded cert, or the cached cert if downloading failed
+	certData, err = os.ReadFile(c.certFile)
+	if err != nil {
+		return fmt.Errorf("failed to read cert file: %v", err)
+	}
+	keyData, err = os.ReadFile(c.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to read key file: %v", err)
+	}
+	if _, err = c.setCert(certData, keyData); err != nil {
+		return err
+	}
+
+	if server.TLSConfig == nil {
+		server.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	server.TLSConfig.GetCertificate = c.GetCertificate
+	if err := c.clientAuth.configure(server); err != nil {
+		return err
+	}
+
+	if c.refreshInterval > 0 {
+		done := make(chan struct{})
+		defer close(done)
+		go c.refreshLoop(done)
+	}
+
+	if err = server.ListenAndServeTLS("", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return fmt.Errorf("[https server] listen and serve TLS error: %v", err)
 	}
 
 	return nil
 }
 
+// GetCertificate returns the certificate currently in use, it can be used as tls.Config.GetCertificate.
+func (c *TLSRemoteAPIConfig) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cert := c.cert.Load()
+	if cert == nil {
+		return nil, errors.New("certificate is not loaded")
+	}
+	return cert, nil
+}
+
+// Refresh downloads the certificate, and swaps the certificate in use if it has changed,
+// the certificate in use is kept if the new one is invalid.
+func (c *TLSRemoteAPIConfig) Refresh() (changed bool, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	certData, keyData, err := c.downloadFile()
+	if err != nil {
+		if errors.Is(err, errCertNotModified) {
+			return false, nil
+		}
+		return false, err
+	}
+	changed, err = c.setCert(certData, keyData)
+	if err != nil {
+		c.etag = "" // download again next time
+		return false, err
+	}
+	if !changed {
+		return false, nil
+	}
+	return true, c.writeFiles(certData, keyData)
+}
+
+func (c *TLSRemoteAPIConfig) refreshLoop(done <-chan struct{}) {
+	ticker := time.NewTicker(c.refreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			changed, err := c.Refresh()
+			if c.onRefresh != nil {
+				c.onRefresh(changed, err)
+			}
+		}
+	}
+}
+
+// setCert parses the cert and key, and uses them if they are different from those in use.
+func (c *TLSRemoteAPIConfig) setCert(certData []byte, keyData []byte) (bool, error) {
+	hash := sha256.Sum256(append(append([]byte{}, certData...), keyData...))
+	if hash == c.certHash && c.cert.Load() != nil {
+		return false, nil
+	}
+	cert, err := tls.X509KeyPair(certData, keyData)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse cert and key: %v", err)
+	}
+	c.cert.Store(&cert)
+	c.certHash = hash
+	return true, nil
+}
+
+func (c *TLSRemoteAPIConfig) writeFiles(certData []byte, keyData []byte) error {
+	_ = os.MkdirAll(c.cacheDir, 0760)
+	if err := os.WriteFile(c.certFile, certData, 0640); err != nil {
+		return fmt.Errorf("failed to write cert file: %v", err)
+	}
+	if err := os.WriteFile(c.keyFile, keyData, 0640); err != nil {
+		return fmt.Errorf("failed to write key file: %v", err)
+	}
+	return nil
+}
+
 type TLSRemoteAPIResponse struct {
 	CertFile []byte `json:"cert_file"`
 	KeyFile  []byte `json:"key_file"`
@@ -146,11 +288,17 @@ func (c *TLSRemoteAPIConfig) downloadFile() (certData []byte, keyData []byte, er
 	for k, v := range c.headers {
 		req.Header.Set(k, v)
 	}
+	if c.etag != "" {
+		req.Header.Set("If-None-Match", c.etag)
+	}
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, nil, err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, nil, errCertNotModified
+	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		return nil, nil, fmt.Errorf("unexpected status: %s", resp.Status)
 	}
@@ -163,6 +311,7 @@ func (c *TLSRemoteAPIConfig) downloadFile() (certData []byte, keyData []byte, er
 	if err = json.Unmarshal(b, &apiResponse); err != nil {
 		return nil, nil, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
+	c.etag = resp.Header.Get("ETag")
 
 	return apiResponse.CertFile, apiResponse.KeyFile, nil
 }
@@ -3,10 +3,12 @@ package httpsrv
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 )
@@ -256,6 +258,93 @@ func TestTLSRemoteAPIConfig_Run(t *testing.T) {
 	}
 }
 
+func TestTLSRemoteAPIConfig_Refresh(t *testing.T) {
+	cert1, key1 := readTestCert(t)
+	cert2, key2 := readTestCert(t)
+
+	var mu sync.Mutex
+	version, downloads := 1, 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		etag := fmt.Sprintf(`"v%d"`, version)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		downloads++
+		response := TLSRemoteAPIResponse{CertFile: []byte(cert1), KeyFile: []byte(key1)}
+		if version == 2 {
+			response = TLSRemoteAPIResponse{CertFile: []byte(cert2), KeyFile: []byte(key2)}
+		}
+		w.Header().Set("ETag", etag)
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	defer ts.Close()
+
+	tempDir := t.TempDir()
+	refreshed := make(chan bool, 100)
+	config := NewTLSRemoteAPIConfig(ts.URL,
+		WithTLSRemoteAPICacheDir(tempDir),
+		WithTLSRemoteAPIRefreshInterval(20*time.Millisecond),
+		WithTLSRemoteAPIOnRefresh(func(changed bool, err error) {
+			if err != nil {
+				t.Errorf("refresh error: %v", err)
+			}
+			refreshed <- changed
+		}),
+	)
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate() failed: %v", err)
+	}
+
+	server := &http.Server{Addr: "localhost:0"}
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- config.Run(server)
+	}()
+
+	// unchanged certificate is not downloaded again
+	if changed := <-refreshed; changed {
+		t.Error("expected certificate to be unchanged")
+	}
+	first, err := config.GetCertificate(nil)
+	if err != nil {
+		t.Fatalf("GetCertificate() failed: %v", err)
+	}
+
+	mu.Lock()
+	version = 2
+	mu.Unlock()
+	deadline := time.After(3 * time.Second)
+	for changed := false; !changed; {
+		select {
+		case changed = <-refreshed:
+		case <-deadline:
+			t.Fatal("expected certificate to be refreshed")
+		}
+	}
+	second, _ := config.GetCertificate(nil)
+	if second == first {
+		t.Error("expected certificate to be swapped")
+	}
+	if data, _ := os.ReadFile(filepath.Join(tempDir, "cert.pem")); string(data) != cert2 {
+		t.Error("expected cached cert file to be updated")
+	}
+	mu.Lock()
+	if downloads != 2 {
+		t.Errorf("expected 2 downloads, got %d", downloads)
+	}
+	mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_ = server.Shutdown(ctx)
+	if err = <-errChan; err != nil {
+		t.Errorf("Run() returned unexpected error: %v", err)
+	}
+}
+
 func TestTLSRemoteAPIConfig_Interface(t *testing.T) {
 	var _ TLSer = (*TLSRemoteAPIConfig)(nil)
 
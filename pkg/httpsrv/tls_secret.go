@@ -0,0 +1,254 @@
+package httpsrv
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CertBundle is a certificate and private key fetched from a secret store.
+type CertBundle struct {
+	CertPEM []byte // certificate chain in PEM format, leaf certificate first
+	KeyPEM  []byte // private key in PEM format
+
+	// LeaseDuration is the validity of the secret lease, 0 means the lease is unlimited,
+	// the bundle is renewed before the lease or the certificate expires, whichever comes first.
+	LeaseDuration time.Duration
+}
+
+// CertSource fetches certificates from a secret store, e.g. HashiCorp Vault or AWS Secrets Manager.
+type CertSource interface {
+	Fetch(ctx context.Context) (*CertBundle, error)
+}
+
+// TLSSecretOption set tlsSecretOptions.
+type TLSSecretOption func(*tlsSecretOptions)
+
+type tlsSecretOptions struct {
+	renewBefore     time.Duration
+	refreshInterval time.Duration
+	retryInterval   time.Duration
+	timeout         time.Duration
+	onRenew         func(err error)
+	clientAuth      *ClientAuth
+}
+
+func (o *tlsSecretOptions) apply(opts ...TLSSecretOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+func defaultTLSSecretOptions() *tlsSecretOptions {
+	return &tlsSecretOptions{
+		retryInterval: 30 * time.Second,
+		timeout:       10 * time.Second,
+	}
+}
+
+// WithTLSSecretRenewBefore set how long before expiry the certificate is renewed,
+// default is renewing after two-thirds of the validity period has elapsed.
+func WithTLSSecretRenewBefore(d time.Duration) TLSSecretOption {
+	return func(o *tlsSecretOptions) {
+		o.renewBefore = d
+	}
+}
+
+// WithTLSSecretRefreshInterval set the maximum interval between two fetches, used to pick up
+// certificates rotated in the secret store before expiry, default is 0 (disabled).
+func WithTLSSecretRefreshInterval(d time.Duration) TLSSecretOption {
+	return func(o *tlsSecretOptions) {
+		o.refreshInterval = d
+	}
+}
+
+// WithTLSSecretRetryInterval set the interval for retrying after a failed renewal.
+func WithTLSSecretRetryInterval(d time.Duration) TLSSecretOption {
+	return func(o *tlsSecretOptions) {
+		o.retryInterval = d
+	}
+}
+
+// WithTLSSecretTimeout set the timeout of fetching a certificate.
+func WithTLSSecretTimeout(d time.Duration) TLSSecretOption {
+	return func(o *tlsSecretOptions) {
+		o.timeout = d
+	}
+}
+
+// WithTLSSecretOnRenew set the callback function called after each renewal attempt,
+// err is nil if the new certificate is in use.
+func WithTLSSecretOnRenew(fn func(err error)) TLSSecretOption {
+	return func(o *tlsSecretOptions) {
+		o.onRenew = fn
+	}
+}
+
+// WithTLSSecretClientAuth set client certificate verification (mutual TLS), see NewClientAuth.
+func WithTLSSecretClientAuth(clientAuth *ClientAuth) TLSSecretOption {
+	return func(o *tlsSecretOptions) {
+		o.clientAuth = clientAuth
+	}
+}
+
+// -------------------------------------------------------------------------------------------
+
+var _ TLSer = (*TLSSecretConfig)(nil)
+
+// TLSSecretConfig fetches the certificate from a secret store, and renews it in the background
+// before the certificate or the secret lease expires, new connections use the renewed certificate.
+type TLSSecretConfig struct {
+	source          CertSource
+	renewBefore     time.Duration
+	refreshInterval time.Duration
+	retryInterval   time.Duration
+	timeout         time.Duration
+	onRenew         func(err error)
+	clientAuth      *ClientAuth
+
+	cert    atomic.Pointer[tls.Certificate]
+	mu      sync.Mutex
+	renewAt time.Time
+}
+
+// NewTLSSecretConfig creates a TLSer with certificates from the source, see NewVaultPKISource
+// and NewAWSSecretsManagerSource.
+func NewTLSSecretConfig(source CertSource, opts ...TLSSecretOption) *TLSSecretConfig {
+	o := defaultTLSSecretOptions()
+	o.apply(opts...)
+	return &TLSSecretConfig{
+		source:          source,
+		renewBefore:     o.renewBefore,
+		refreshInterval: o.refreshInterval,
+		retryInterval:   o.retryInterval,
+		timeout:         o.timeout,
+		onRenew:         o.onRenew,
+		clientAuth:      o.clientAuth,
+	}
+}
+
+func (c *TLSSecretConfig) Validate() error {
+	if c.source == nil {
+		return errors.New("certificate source must be specified in secret mode")
+	}
+	if c.retryInterval <= 0 {
+		c.retryInterval = 30 * time.Second
+	}
+	if c.timeout <= 0 {
+		c.timeout = 10 * time.Second
+	}
+	return nil
+}
+
+// GetCertificate returns the certificate currently in use, it can be used as tls.Config.GetCertificate.
+func (c *TLSSecretConfig) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cert := c.cert.Load()
+	if cert == nil {
+		return nil, errors.New("certificate is not loaded")
+	}
+	return cert, nil
+}
+
+// Renew fetches the certificate from the source and uses it for new connections,
+// the certificate in use is kept if fetching fails.
+func (c *TLSSecretConfig) Renew(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	bundle, err := c.source.Fetch(ctx)
+	if err != nil {
+		return fmt.Errorf("fetch certificate error: %v", err)
+	}
+	cert, err := tls.X509KeyPair(bundle.CertPEM, bundle.KeyPEM)
+	if err != nil {
+		return fmt.Errorf("parse certificate error: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("parse certificate error: %v", err)
+	}
+	cert.Leaf = leaf
+
+	now := time.Now()
+	notBefore, notAfter := leaf.NotBefore, leaf.NotAfter
+	if bundle.LeaseDuration > 0 && now.Add(bundle.LeaseDuration).Before(notAfter) {
+		notBefore, notAfter = now, now.Add(bundle.LeaseDuration)
+	}
+	c.cert.Store(&cert)
+	c.mu.Lock()
+	c.renewAt = c.nextRenewal(now, notBefore, notAfter)
+	c.mu.Unlock()
+	return nil
+}
+
+// nextRenewal returns the renewal time of a certificate valid in [notBefore, notAfter].
+func (c *TLSSecretConfig) nextRenewal(now time.Time, notBefore time.Time, notAfter time.Time) time.Time {
+	renewAt := notBefore.Add(notAfter.Sub(notBefore) * 2 / 3)
+	if c.renewBefore > 0 {
+		renewAt = notAfter.Add(-c.renewBefore)
+	}
+	if c.refreshInterval > 0 && now.Add(c.refreshInterval).Before(renewAt) {
+		renewAt = now.Add(c.refreshInterval)
+	}
+	if renewAt.Before(now) {
+		renewAt = now.Add(c.retryInterval)
+	}
+	return renewAt
+}
+
+// untilRenewal returns the duration until the next scheduled renewal.
+func (c *TLSSecretConfig) untilRenewal() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return time.Until(c.renewAt)
+}
+
+func (c *TLSSecretConfig) renewLoop(ctx context.Context) {
+	timer := time.NewTimer(c.untilRenewal())
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			err := c.Renew(ctx)
+			if c.onRenew != nil {
+				c.onRenew(err)
+			}
+			next := c.untilRenewal()
+			if err != nil {
+				next = c.retryInterval
+			}
+			timer.Reset(next)
+		}
+	}
+}
+
+func (c *TLSSecretConfig) Run(server *http.Server) error {
+	if err := c.Renew(context.Background()); err != nil {
+		return err
+	}
+
+	if server.TLSConfig == nil {
+		server.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	server.TLSConfig.GetCertificate = c.GetCertificate
+	if err := c.clientAuth.configure(server); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.renewLoop(ctx)
+
+	if err := server.ListenAndServeTLS("", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("[https server] listen and serve TLS error: %v", err)
+	}
+	return nil
+}
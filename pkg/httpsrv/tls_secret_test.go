@@ -0,0 +1,217 @@
+package httpsrv
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func readTestCert(t *testing.T) (string, string) {
+	certFile, keyFile := writeTestCert(t, t.TempDir())
+	cert, _ := os.ReadFile(certFile)
+	key, _ := os.ReadFile(keyFile)
+	return string(cert), string(key)
+}
+
+type mockCertSource struct {
+	cert, key string
+	lease     time.Duration
+	err       error
+	calls     atomic.Int32
+}
+
+func (m *mockCertSource) Fetch(ctx context.Context) (*CertBundle, error) {
+	m.calls.Add(1)
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &CertBundle{CertPEM: []byte(m.cert), KeyPEM: []byte(m.key), LeaseDuration: m.lease}, nil
+}
+
+func TestTLSSecretConfig_Renew(t *testing.T) {
+	cert, key := readTestCert(t)
+
+	t.Run("renew before lease expiry", func(t *testing.T) {
+		config := NewTLSSecretConfig(&mockCertSource{cert: cert, key: key, lease: 3 * time.Hour})
+		if err := config.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		if err := config.Renew(context.Background()); err != nil {
+			t.Fatalf("Renew() failed: %v", err)
+		}
+		if c, err := config.GetCertificate(nil); err != nil || c.Leaf == nil {
+			t.Fatalf("expected certificate to be loaded, got %v", err)
+		}
+		if d := config.untilRenewal(); d < 115*time.Minute || d > 2*time.Hour {
+			t.Errorf("expected renewal after 2/3 of the lease, got %v", d)
+		}
+	})
+
+	t.Run("renew before", func(t *testing.T) {
+		config := NewTLSSecretConfig(&mockCertSource{cert: cert, key: key, lease: 3 * time.Hour},
+			WithTLSSecretRenewBefore(time.Hour))
+		_ = config.Renew(context.Background())
+		if d := config.untilRenewal(); d < 115*time.Minute || d > 2*time.Hour {
+			t.Errorf("expected renewal 1h before expiry, got %v", d)
+		}
+	})
+
+	t.Run("refresh interval", func(t *testing.T) {
+		config := NewTLSSecretConfig(&mockCertSource{cert: cert, key: key}, WithTLSSecretRefreshInterval(time.Minute))
+		_ = config.Renew(context.Background())
+		if d := config.untilRenewal(); d > time.Minute {
+			t.Errorf("expected renewal within refresh interval, got %v", d)
+		}
+	})
+
+	t.Run("failure keeps certificate", func(t *testing.T) {
+		source := &mockCertSource{cert: cert, key: key}
+		config := NewTLSSecretConfig(source)
+		_ = config.Renew(context.Background())
+		before, _ := config.GetCertificate(nil)
+
+		source.err = errors.New("unavailable")
+		if err := config.Renew(context.Background()); err == nil {
+			t.Error("expected an error")
+		}
+		source.err, source.key = nil, "invalid"
+		if err := config.Renew(context.Background()); err == nil {
+			t.Error("expected an error for invalid key")
+		}
+		if after, _ := config.GetCertificate(nil); after != before {
+			t.Error("expected certificate in use to be kept")
+		}
+	})
+
+	if err := NewTLSSecretConfig(nil).Validate(); err == nil {
+		t.Error("expected an error for nil source")
+	}
+}
+
+func TestTLSSecretConfig_RenewLoop(t *testing.T) {
+	cert, key := readTestCert(t)
+	source := &mockCertSource{cert: cert, key: key}
+	renewed := make(chan error, 10)
+	config := NewTLSSecretConfig(source,
+		WithTLSSecretRefreshInterval(20*time.Millisecond),
+		WithTLSSecretOnRenew(func(err error) { renewed <- err }),
+	)
+	if err := config.Renew(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go config.renewLoop(ctx)
+
+	select {
+	case err := <-renewed:
+		if err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected certificate to be renewed")
+	}
+	if source.calls.Load() < 2 {
+		t.Errorf("expected source to be fetched again, got %d calls", source.calls.Load())
+	}
+}
+
+func TestVaultPKISource_Fetch(t *testing.T) {
+	cert, key := readTestCert(t)
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/pki_int/issue/web" || r.Header.Get("X-Vault-Token") != "s.token" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		var params map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&params)
+		if params["common_name"] != "example.com" || params["alt_names"] != "a.example.com,b.example.com" || params["ttl"] != "24h0m0s" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		resp := map[string]interface{}{
+			"lease_duration": 86400,
+			"data": map[string]interface{}{
+				"certificate": cert,
+				"private_key": key,
+				"issuing_ca":  cert,
+			},
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer vault.Close()
+
+	source := NewVaultPKISource(vault.URL, "s.token", "web", "example.com",
+		WithVaultPKIMount("/pki_int/"),
+		WithVaultPKIAltNames("a.example.com", "b.example.com"),
+		WithVaultPKITTL(24*time.Hour),
+		WithVaultPKINamespace("ns1"),
+	)
+	bundle, err := source.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch() failed: %v", err)
+	}
+	if bundle.LeaseDuration != 24*time.Hour || string(bundle.KeyPEM) != key || len(bundle.CertPEM) <= len(cert) {
+		t.Errorf("unexpected bundle, lease %v", bundle.LeaseDuration)
+	}
+
+	config := NewTLSSecretConfig(source)
+	if err = config.Renew(context.Background()); err != nil {
+		t.Errorf("Renew() failed: %v", err)
+	}
+
+	_, err = NewVaultPKISource(vault.URL, "bad-token", "web", "example.com").Fetch(context.Background())
+	if err == nil {
+		t.Error("expected an error for forbidden request")
+	}
+	if _, err = NewVaultPKISource("", "", "", "").Fetch(context.Background()); err == nil {
+		t.Error("expected an error for empty parameters")
+	}
+}
+
+func mockSecretValue(value string, err error) SecretValueGetter {
+	return func(ctx context.Context, secretID string, versionStage string) (string, error) {
+		if secretID != "prod/tls" || versionStage != "AWSCURRENT" {
+			return "", errors.New("secret not found")
+		}
+		return value, err
+	}
+}
+
+func TestAWSSecretsManagerSource_Fetch(t *testing.T) {
+	cert, key := readTestCert(t)
+	value, _ := json.Marshal(map[string]string{"tls.crt": cert, "tls.key": key})
+	secret := string(value)
+
+	source := NewAWSSecretsManagerSource(mockSecretValue(secret, nil), "prod/tls",
+		WithAWSSecretsManagerKeys("tls.crt", "tls.key"))
+	bundle, err := source.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch() failed: %v", err)
+	}
+	if string(bundle.CertPEM) != cert || string(bundle.KeyPEM) != key {
+		t.Error("unexpected bundle")
+	}
+
+	// default keys not found
+	if _, err = NewAWSSecretsManagerSource(mockSecretValue(secret, nil), "prod/tls").Fetch(context.Background()); err == nil {
+		t.Error("expected an error for missing keys")
+	}
+	if _, err = NewAWSSecretsManagerSource(mockSecretValue("", nil), "prod/tls").Fetch(context.Background()); err == nil {
+		t.Error("expected an error for empty secret string")
+	}
+	if _, err = NewAWSSecretsManagerSource(mockSecretValue("", errors.New("denied")), "prod/tls").Fetch(context.Background()); err == nil {
+		t.Error("expected an error from getter")
+	}
+	if _, err = NewAWSSecretsManagerSource(nil, "").Fetch(context.Background()); err == nil {
+		t.Error("expected an error for nil getter")
+	}
+}
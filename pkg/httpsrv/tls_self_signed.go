@@ -24,6 +24,7 @@ type tlsSelfSignedOptions struct {
 	cacheDir       string
 	expirationDays int
 	wanIPs         []string // IP addresses to include in the certificate.
+	clientAuth     *ClientAuth
 }
 
 func (o *tlsSelfSignedOptions) apply(opts ...TLSSelfSignedOption) {
@@ -60,6 +61,13 @@ func WithTLSSelfSignedWanIPs(wanIPs ...string) TLSSelfSignedOption {
 	}
 }
 
+// WithTLSSelfSignedClientAuth sets client certificate verification (mutual TLS), see NewClientAuth.
+func WithTLSSelfSignedClientAuth(clientAuth *ClientAuth) TLSSelfSignedOption {
+	return func(o *tlsSelfSignedOptions) {
+		o.clientAuth = clientAuth
+	}
+}
+
 // ------------------------------------------------------------------------------------------
 
 var _ TLSer = (*TLSSelfSignedConfig)(nil)
@@ -70,6 +78,7 @@ type TLSSelfSignedConfig struct {
 	keyFile        string
 	expirationDays int
 	wanIPs         []string // IP addresses to include in the certificate.
+	clientAuth     *ClientAuth
 }
 
 func NewTLSSelfSignedConfig(opts ...TLSSelfSignedOption) *TLSSelfSignedConfig {
@@ -81,6 +90,7 @@ func NewTLSSelfSignedConfig(opts ...TLSSelfSignedOption) *TLSSelfSignedConfig {
 		keyFile:        filepath.Join(o.cacheDir, "key.pem"),
 		expirationDays: o.expirationDays,
 		wanIPs:         o.wanIPs,
+		clientAuth:     o.clientAuth,
 	}
 }
 
@@ -201,6 +211,9 @@ func (c *TLSSelfSignedConfig) Run(server *http.Server) error {
 	if err := c.generateCert(); err != nil {
 		return err
 	}
+	if err := c.clientAuth.configure(server); err != nil {
+		return err
+	}
 
 	if err := server.ListenAndServeTLS(c.certFile, c.keyFile); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return fmt.Errorf("[https server] listen and serve TLS error: %v", err)
@@ -0,0 +1,196 @@
+package httpsrv
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// VaultPKIOption set vaultPKIOptions.
+type VaultPKIOption func(*vaultPKIOptions)
+
+type vaultPKIOptions struct {
+	mount      string
+	altNames   []string
+	ipSANs     []string
+	ttl        time.Duration
+	namespace  string
+	httpClient *http.Client
+}
+
+func (o *vaultPKIOptions) apply(opts ...VaultPKIOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+func defaultVaultPKIOptions() *vaultPKIOptions {
+	return &vaultPKIOptions{
+		mount:      "pki",
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// WithVaultPKIMount set the mount path of the PKI secrets engine, default is pki.
+func WithVaultPKIMount(mount string) VaultPKIOption {
+	return func(o *vaultPKIOptions) {
+		o.mount = strings.Trim(mount, "/")
+	}
+}
+
+// WithVaultPKIAltNames set the subject alternative names (DNS names) of the certificate.
+func WithVaultPKIAltNames(names ...string) VaultPKIOption {
+	return func(o *vaultPKIOptions) {
+		o.altNames = names
+	}
+}
+
+// WithVaultPKIIPSANs set the IP subject alternative names of the certificate.
+func WithVaultPKIIPSANs(ips ...string) VaultPKIOption {
+	return func(o *vaultPKIOptions) {
+		o.ipSANs = ips
+	}
+}
+
+// WithVaultPKITTL set the requested validity of the certificate, default is the TTL of the role.
+func WithVaultPKITTL(ttl time.Duration) VaultPKIOption {
+	return func(o *vaultPKIOptions) {
+		o.ttl = ttl
+	}
+}
+
+// WithVaultPKINamespace set the vault enterprise namespace.
+func WithVaultPKINamespace(namespace string) VaultPKIOption {
+	return func(o *vaultPKIOptions) {
+		o.namespace = namespace
+	}
+}
+
+// WithVaultPKIHTTPClient set the http client used to request vault, e.g. with custom CA.
+func WithVaultPKIHTTPClient(client *http.Client) VaultPKIOption {
+	return func(o *vaultPKIOptions) {
+		if client != nil {
+			o.httpClient = client
+		}
+	}
+}
+
+// -------------------------------------------------------------------------------------------
+
+var _ CertSource = (*VaultPKISource)(nil)
+
+// VaultPKISource issues certificates from the HashiCorp Vault PKI secrets engine.
+type VaultPKISource struct {
+	addr       string
+	token      string
+	role       string
+	commonName string
+
+	mount      string
+	altNames   []string
+	ipSANs     []string
+	ttl        time.Duration
+	namespace  string
+	httpClient *http.Client
+}
+
+// NewVaultPKISource creates a certificate source, addr is the vault address e.g. https://vault:8200,
+// token is a vault token allowed to update <mount>/issue/<role>.
+func NewVaultPKISource(addr string, token string, role string, commonName string, opts ...VaultPKIOption) *VaultPKISource {
+	o := defaultVaultPKIOptions()
+	o.apply(opts...)
+	return &VaultPKISource{
+		addr:       strings.TrimRight(addr, "/"),
+		token:      token,
+		role:       role,
+		commonName: commonName,
+		mount:      o.mount,
+		altNames:   o.altNames,
+		ipSANs:     o.ipSANs,
+		ttl:        o.ttl,
+		namespace:  o.namespace,
+		httpClient: o.httpClient,
+	}
+}
+
+type vaultIssueResponse struct {
+	LeaseDuration int64 `json:"lease_duration"`
+	Data          struct {
+		Certificate string   `json:"certificate"`
+		PrivateKey  string   `json:"private_key"`
+		CAChain     []string `json:"ca_chain"`
+		IssuingCA   string   `json:"issuing_ca"`
+	} `json:"data"`
+	Errors []string `json:"errors"`
+}
+
+// Fetch issues a new certificate, implements the CertSource interface.
+func (s *VaultPKISource) Fetch(ctx context.Context) (*CertBundle, error) {
+	if s.addr == "" || s.token == "" || s.role == "" || s.commonName == "" {
+		return nil, errors.New("vault addr, token, role and common name must be specified")
+	}
+
+	params := map[string]string{"common_name": s.commonName}
+	if len(s.altNames) > 0 {
+		params["alt_names"] = strings.Join(s.altNames, ",")
+	}
+	if len(s.ipSANs) > 0 {
+		params["ip_sans"] = strings.Join(s.ipSANs, ",")
+	}
+	if s.ttl > 0 {
+		params["ttl"] = s.ttl.String()
+	}
+	body, _ := json.Marshal(params)
+
+	url := fmt.Sprintf("%s/v1/%s/issue/%s", s.addr, s.mount, s.role)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", s.token)
+	req.Header.Set("Content-Type", "application/json")
+	if s.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", s.namespace)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var issueResp vaultIssueResponse
+	_ = json.Unmarshal(b, &issueResp)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if len(issueResp.Errors) > 0 {
+			return nil, fmt.Errorf("unexpected status: %s, %s", resp.Status, strings.Join(issueResp.Errors, "; "))
+		}
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	if issueResp.Data.Certificate == "" || issueResp.Data.PrivateKey == "" {
+		return nil, errors.New("certificate or private key not found in vault response")
+	}
+
+	chain := []string{issueResp.Data.Certificate}
+	if len(issueResp.Data.CAChain) > 0 {
+		chain = append(chain, issueResp.Data.CAChain...)
+	} else if issueResp.Data.IssuingCA != "" {
+		chain = append(chain, issueResp.Data.IssuingCA)
+	}
+
+	return &CertBundle{
+		CertPEM:       []byte(strings.Join(chain, "\n")),
+		KeyPEM:        []byte(issueResp.Data.PrivateKey),
+		LeaseDuration: time.Duration(issueResp.LeaseDuration) * time.Second,
+	}, nil
+}
@@ -6,6 +6,8 @@ import (
 	"errors"
 	"os"
 	"strings"
+
+	"github.com/go-dev-frame/sponge/pkg/struct2dto"
 )
 
 // Args  convert arguments
@@ -16,6 +18,9 @@ type Args struct {
 	Name      string // name of structure
 	SubStruct bool   // are sub-structures separated
 	Tags      string // add additional tags, multiple tags separated by commas
+	// output language, default is go struct, the front-end DTO definitions are generated if it is
+	// typescript, swift or kotlin, the property names are the camel case of json or yaml keys
+	Language string
 
 	tags          []string //nolint
 	convertFloats bool
@@ -46,6 +51,12 @@ func (j *Args) checkValid() error {
 		j.Name = "GenerateName"
 	}
 
+	if j.Language != "" && j.Language != "go" {
+		if _, err := struct2dto.ParseLanguage(j.Language); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -73,5 +84,9 @@ func Convert(args *Args) (string, error) {
 		return "", err
 	}
 
+	if args.Language != "" && args.Language != "go" {
+		return struct2dto.Convert(string(output), args.Language)
+	}
+
 	return string(output), nil
 }
@@ -78,4 +78,33 @@ age: 10`,
 	arg = &Args{Format: "yaml", InputFile: "notfound.yaml"}
 	_, err = Convert(arg)
 	assert.Error(t, err)
+	arg = &Args{Format: "json", Data: `{"name":"foo"}`, Language: "java"}
+	_, err = Convert(arg)
+	assert.Error(t, err)
+}
+
+func TestConvertToDTO(t *testing.T) {
+	for _, language := range []string{"typescript", "swift", "kotlin"} {
+		t.Run(language, func(t *testing.T) {
+			got, err := Convert(&Args{
+				InputFile: "test.json",
+				Format:    "json",
+				SubStruct: true,
+				Language:  language,
+			})
+			assert.NoError(t, err)
+			assert.Contains(t, got, "GenerateNameItem")
+			t.Log(got)
+		})
+	}
+
+	got, err := Convert(&Args{
+		Data:     "user_name: foo\nage: 10",
+		Format:   "yaml",
+		Name:     "User",
+		Language: "typescript",
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, got, "export interface User {")
+	assert.Contains(t, got, "  userName: string; // json: user_name")
 }
@@ -149,9 +149,9 @@ func (c *ConsumerGroup) ConsumeCustom(ctx context.Context, topics []string, hand
 
 func (c *ConsumerGroup) Close() error {
 	if c == nil || c.Group == nil {
-		return c.Group.Close()
+		return nil
 	}
-	return nil
+	return c.Group.Close()
 }
 
 type defaultConsumerHandler struct {
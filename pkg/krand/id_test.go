@@ -0,0 +1,141 @@
+package krand
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewULID(t *testing.T) {
+	ids := make([]string, 1000)
+	for i := range ids {
+		ids[i] = NewULID()
+		assert.Len(t, ids[i], 26)
+	}
+	assert.True(t, sort.StringsAreSorted(ids))
+	assert.Equal(t, len(ids), len(uniqueStrings(ids)))
+
+	tm, err := ParseULIDTime(ids[0])
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), tm, time.Second)
+
+	_, err = ParseULIDTime("foo")
+	assert.Error(t, err)
+	_, err = ParseULIDTime("8ZZZZZZZZZZZZZZZZZZZZZZZZZ")
+	assert.Error(t, err)
+
+	// overflow of random part in the same millisecond
+	g := &ulidGenerator{}
+	id1 := g.next(1000)
+	for i := range g.entropy {
+		g.entropy[i] = 0xff
+	}
+	id2 := g.next(1000)
+	assert.Greater(t, encodeULID(id2), encodeULID(id1))
+	assert.Equal(t, int64(1001), g.lastMs)
+	assert.Equal(t, "00000000000000000000000000", encodeULID([16]byte{}))
+}
+
+func TestNewKSUID(t *testing.T) {
+	ids := make([]string, 100)
+	for i := range ids {
+		ids[i] = NewKSUID()
+		assert.Len(t, ids[i], 27)
+	}
+	assert.Equal(t, len(ids), len(uniqueStrings(ids)))
+
+	tm, err := ParseKSUIDTime(ids[0])
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), tm, 2*time.Second)
+
+	var maxID [20]byte
+	for i := range maxID {
+		maxID[i] = 0xff
+	}
+	assert.Equal(t, "aWgEPTl1tmebfsQzFP4bxwgy80V", encodeKSUID(maxID))
+	assert.Equal(t, "000000000000000000000000000", encodeKSUID([20]byte{}))
+	assert.Less(t, encodeKSUID([20]byte{0, 0, 0, 1}), encodeKSUID([20]byte{0, 0, 0, 2}))
+
+	_, err = ParseKSUIDTime("foo")
+	assert.Error(t, err)
+	_, err = ParseKSUIDTime("00000000000000000000000000-")
+	assert.Error(t, err)
+	_, err = ParseKSUIDTime("zzzzzzzzzzzzzzzzzzzzzzzzzzz")
+	assert.Error(t, err)
+}
+
+func TestSnowflake(t *testing.T) {
+	_, err := NewSnowflake(-1)
+	assert.Error(t, err)
+	_, err = NewSnowflake(MaxWorkerID + 1)
+	assert.Error(t, err)
+
+	epoch := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	s, err := NewSnowflake(5, WithSnowflakeEpoch(epoch), WithSnowflakeBackwardTolerance(time.Millisecond))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), s.WorkerID())
+
+	var (
+		mu  sync.Mutex
+		ids = map[int64]bool{}
+		wg  sync.WaitGroup
+	)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			last := int64(0)
+			for j := 0; j < 5000; j++ {
+				id, err := s.NextID()
+				assert.NoError(t, err)
+				assert.Greater(t, id, last)
+				last = id
+				mu.Lock()
+				ids[id] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, ids, 20000)
+
+	id, err := s.NextID()
+	assert.NoError(t, err)
+	tm, workerID, _ := ParseSnowflake(id, epoch)
+	assert.WithinDuration(t, time.Now(), tm, time.Second)
+	assert.Equal(t, int64(5), workerID)
+
+	// clock moved backwards
+	s.lastMs = time.Now().UnixMilli() + 1000
+	_, err = s.NextID()
+	assert.True(t, errors.Is(err, ErrClockBackwards))
+	s.lastMs = time.Now().UnixMilli() + 1
+	_, err = s.NextID()
+	assert.NoError(t, err)
+}
+
+func TestNewSnowflakeID(t *testing.T) {
+	assert.Error(t, InitSnowflake(1024))
+	assert.NoError(t, InitSnowflake(7))
+	defer func() { _ = InitSnowflake(0) }()
+
+	id1, err := NewSnowflakeID()
+	assert.NoError(t, err)
+	id2, err := NewSnowflakeID()
+	assert.NoError(t, err)
+	assert.Greater(t, id2, id1)
+	_, workerID, _ := ParseSnowflake(id2)
+	assert.Equal(t, int64(7), workerID)
+}
+
+func uniqueStrings(ss []string) map[string]struct{} {
+	m := make(map[string]struct{}, len(ss))
+	for _, s := range ss {
+		m[s] = struct{}{}
+	}
+	return m
+}
@@ -0,0 +1,66 @@
+package krand
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+)
+
+const (
+	// the epoch of KSUID timestamp, 2014-05-13T16:53:20Z
+	ksuidEpoch = 1400000000
+	ksuidLen   = 27
+
+	// base62 alphabet in ascii order, the encoded KSUID is lexicographically sortable
+	ksuidAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	// the alphabet of big.Int.Text(62)
+	bigAlphabet = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+)
+
+// NewKSUID Generate a KSUID, 32 bits of second timestamp + 128 bits of random, encoded in 27 bytes of base62,
+// the IDs are sortable by creation time in seconds, and the collision probability is lower than ULID.
+// example: 2aBZ0jI5xKqP8m3vR7tY1wE9sLc
+func NewKSUID() string {
+	var id [20]byte
+	binary.BigEndian.PutUint32(id[:4], uint32(time.Now().Unix()-ksuidEpoch))
+	_, _ = crand.Read(id[4:])
+	return encodeKSUID(id)
+}
+
+func encodeKSUID(id [20]byte) string {
+	s := new(big.Int).SetBytes(id[:]).Text(62)
+	buf := make([]byte, ksuidLen)
+	padding := ksuidLen - len(s)
+	for i := 0; i < padding; i++ {
+		buf[i] = '0'
+	}
+	for i := 0; i < len(s); i++ {
+		buf[padding+i] = ksuidAlphabet[strings.IndexByte(bigAlphabet, s[i])]
+	}
+	return string(buf)
+}
+
+// ParseKSUIDTime returns the creation time of KSUID.
+func ParseKSUIDTime(id string) (time.Time, error) {
+	if len(id) != ksuidLen {
+		return time.Time{}, errors.New("invalid ksuid length")
+	}
+	buf := make([]byte, ksuidLen)
+	for i := 0; i < ksuidLen; i++ {
+		v := strings.IndexByte(ksuidAlphabet, id[i])
+		if v < 0 {
+			return time.Time{}, errors.New("invalid ksuid character")
+		}
+		buf[i] = bigAlphabet[v]
+	}
+	n, ok := new(big.Int).SetString(string(buf), 62)
+	if !ok || n.BitLen() > 160 {
+		return time.Time{}, errors.New("invalid ksuid value")
+	}
+	var id20 [20]byte
+	n.FillBytes(id20[:])
+	return time.Unix(int64(binary.BigEndian.Uint32(id20[:4]))+ksuidEpoch, 0), nil
+}
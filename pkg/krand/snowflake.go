@@ -0,0 +1,177 @@
+package krand
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	workerIDBits = 10
+	sequenceBits = 12
+
+	// MaxWorkerID is the maximum worker id of snowflake, the worker id range is 0~1023
+	MaxWorkerID  = -1 ^ (-1 << workerIDBits)
+	maxSequence  = -1 ^ (-1 << sequenceBits)
+	timestampMax = -1 ^ (-1 << (63 - workerIDBits - sequenceBits))
+)
+
+var (
+	// ErrClockBackwards is returned when the clock moves backwards more than the tolerance.
+	ErrClockBackwards = errors.New("krand: clock moved backwards")
+
+	// default epoch of snowflake, 2024-01-01T00:00:00Z
+	defaultSnowflakeEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	defaultSnowflake, _ = NewSnowflake(0)
+	defaultSnowflakeMu  sync.RWMutex
+)
+
+// SnowflakeOption set the snowflake options.
+type SnowflakeOption func(*snowflakeOptions)
+
+type snowflakeOptions struct {
+	epoch             time.Time
+	backwardTolerance time.Duration
+}
+
+func defaultSnowflakeOptions() *snowflakeOptions {
+	return &snowflakeOptions{
+		epoch:             defaultSnowflakeEpoch,
+		backwardTolerance: 10 * time.Millisecond,
+	}
+}
+
+func (o *snowflakeOptions) apply(opts ...SnowflakeOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WithSnowflakeEpoch set the epoch of timestamp, default is 2024-01-01T00:00:00Z,
+// the IDs can be generated for about 69 years since the epoch.
+func WithSnowflakeEpoch(epoch time.Time) SnowflakeOption {
+	return func(o *snowflakeOptions) {
+		if !epoch.IsZero() && epoch.Before(time.Now()) {
+			o.epoch = epoch
+		}
+	}
+}
+
+// WithSnowflakeBackwardTolerance set the tolerance of clock moving backwards, it waits until the clock catches
+// up if the clock moves backwards within the tolerance, otherwise ErrClockBackwards is returned, default is 10ms.
+func WithSnowflakeBackwardTolerance(d time.Duration) SnowflakeOption {
+	return func(o *snowflakeOptions) {
+		if d >= 0 {
+			o.backwardTolerance = d
+		}
+	}
+}
+
+// Snowflake is the generator of snowflake ID, 41 bits of millisecond timestamp + 10 bits of worker id +
+// 12 bits of sequence, each worker generates up to 4096 IDs per millisecond. The worker id must be unique
+// among instances, it can be allocated by pkg/krand/workerid.
+type Snowflake struct {
+	workerID int64
+	epoch    int64 // unix milliseconds
+	opts     *snowflakeOptions
+
+	mu       sync.Mutex
+	lastMs   int64
+	sequence int64
+}
+
+// NewSnowflake creates a snowflake generator of the worker id, range 0~1023.
+func NewSnowflake(workerID int64, opts ...SnowflakeOption) (*Snowflake, error) {
+	if workerID < 0 || workerID > MaxWorkerID {
+		return nil, fmt.Errorf("worker id %d out of range 0~%d", workerID, MaxWorkerID)
+	}
+	o := defaultSnowflakeOptions()
+	o.apply(opts...)
+	return &Snowflake{
+		workerID: workerID,
+		epoch:    o.epoch.UnixMilli(),
+		opts:     o,
+	}, nil
+}
+
+// NextID generates a snowflake ID.
+func (s *Snowflake) NextID() (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UnixMilli()
+	if now < s.lastMs {
+		backward := time.Duration(s.lastMs-now) * time.Millisecond
+		if backward > s.opts.backwardTolerance {
+			return 0, fmt.Errorf("%w %v", ErrClockBackwards, backward)
+		}
+		time.Sleep(backward)
+		now = s.waitNextMs(s.lastMs - 1)
+	}
+
+	if now == s.lastMs {
+		s.sequence = (s.sequence + 1) & maxSequence
+		if s.sequence == 0 {
+			now = s.waitNextMs(s.lastMs) // the sequence is exhausted in the millisecond
+		}
+	} else {
+		s.sequence = 0
+	}
+	s.lastMs = now
+
+	elapsed := now - s.epoch
+	if elapsed > timestampMax {
+		return 0, errors.New("krand: snowflake timestamp overflow, the epoch is too early")
+	}
+	return elapsed<<(workerIDBits+sequenceBits) | s.workerID<<sequenceBits | s.sequence, nil
+}
+
+func (s *Snowflake) waitNextMs(lastMs int64) int64 {
+	now := time.Now().UnixMilli()
+	for now <= lastMs {
+		time.Sleep(100 * time.Microsecond)
+		now = time.Now().UnixMilli()
+	}
+	return now
+}
+
+// WorkerID returns the worker id of generator.
+func (s *Snowflake) WorkerID() int64 {
+	return s.workerID
+}
+
+// ParseSnowflake parses the creation time, worker id and sequence of snowflake ID, epoch is the epoch
+// of generator, the default epoch is used if it is zero.
+func ParseSnowflake(id int64, epoch ...time.Time) (t time.Time, workerID int64, sequence int64) {
+	e := defaultSnowflakeEpoch
+	if len(epoch) > 0 && !epoch[0].IsZero() {
+		e = epoch[0]
+	}
+	ms := id>>(workerIDBits+sequenceBits) + e.UnixMilli()
+	return time.UnixMilli(ms), id >> sequenceBits & MaxWorkerID, id & maxSequence
+}
+
+// InitSnowflake set the worker id of the default snowflake generator used by NewSnowflakeID,
+// it is called once at startup, the worker id is 0 if it is not called.
+func InitSnowflake(workerID int64, opts ...SnowflakeOption) error {
+	s, err := NewSnowflake(workerID, opts...)
+	if err != nil {
+		return err
+	}
+	defaultSnowflakeMu.Lock()
+	defaultSnowflake = s
+	defaultSnowflakeMu.Unlock()
+	return nil
+}
+
+// NewSnowflakeID Generate a snowflake ID by the default generator, it is sortable by creation time,
+// an error is returned if the clock moves backwards more than the tolerance.
+// example: 1234567890123456789
+func NewSnowflakeID() (int64, error) {
+	defaultSnowflakeMu.RLock()
+	s := defaultSnowflake
+	defaultSnowflakeMu.RUnlock()
+	return s.NextID()
+}
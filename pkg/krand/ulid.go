@@ -0,0 +1,103 @@
+package krand
+
+import (
+	crand "crypto/rand"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// crockford's base32 alphabet, the encoded ULID is lexicographically sortable
+const ulidAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+var ulidGen = &ulidGenerator{}
+
+type ulidGenerator struct {
+	mu      sync.Mutex
+	lastMs  int64
+	entropy [10]byte
+}
+
+// NewULID Generate a ULID, 48 bits of millisecond timestamp + 80 bits of random, encoded in 26 bytes,
+// the IDs generated in the same millisecond are monotonically increasing, so they are sortable by creation time.
+// example: 01HN3Z5R7ZQK4X9T2M8W6V0B1C
+func NewULID() string {
+	id := ulidGen.next(time.Now().UnixMilli())
+	return encodeULID(id)
+}
+
+func (g *ulidGenerator) next(ms int64) [16]byte {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if ms <= g.lastMs && g.incrEntropy() {
+		ms = g.lastMs // clock moved backwards or the same millisecond, keep monotonic
+	} else {
+		if ms <= g.lastMs {
+			ms = g.lastMs + 1 // the random part overflows, use the next millisecond
+		}
+		g.lastMs = ms
+		_, _ = crand.Read(g.entropy[:])
+	}
+
+	var id [16]byte
+	for i := 0; i < 6; i++ {
+		id[i] = byte(ms >> (40 - 8*i))
+	}
+	copy(id[6:], g.entropy[:])
+	return id
+}
+
+// incrEntropy increments the random part by 1, returns false if it overflows.
+func (g *ulidGenerator) incrEntropy() bool {
+	for i := len(g.entropy) - 1; i >= 0; i-- {
+		g.entropy[i]++
+		if g.entropy[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func encodeULID(id [16]byte) string {
+	// 128 bits are encoded in 26 characters of 5 bits, the first character has only 3 bits
+	var buf [26]byte
+	var acc uint64
+	bits := 2 // pad 2 zero bits at the beginning, 130 bits in total
+	pos := 0
+	for _, b := range id {
+		acc = acc<<8 | uint64(b)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			buf[pos] = ulidAlphabet[(acc>>uint(bits))&0x1f]
+			pos++
+		}
+	}
+	return string(buf[:])
+}
+
+// ParseULIDTime returns the creation time of ULID.
+func ParseULIDTime(id string) (time.Time, error) {
+	if len(id) != 26 {
+		return time.Time{}, errors.New("invalid ulid length")
+	}
+	// the first 10 characters are the 48 bits timestamp, the first character has 3 bits
+	var ms int64
+	for i := 0; i < 10; i++ {
+		v := strings.IndexByte(ulidAlphabet, upperCase(id[i]))
+		if v < 0 || (i == 0 && v > 7) {
+			return time.Time{}, errors.New("invalid ulid character")
+		}
+		ms = ms<<5 | int64(v)
+	}
+	return time.UnixMilli(ms), nil
+}
+
+func upperCase(c byte) byte {
+	if c >= 'a' && c <= 'z' {
+		return c - 'a' + 'A'
+	}
+	return c
+}
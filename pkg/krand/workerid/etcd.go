@@ -0,0 +1,106 @@
+package workerid
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// EtcdAllocator allocates the worker id by the key bound to a lease of etcd, the key is deleted
+// when the lease expires.
+type EtcdAllocator struct {
+	client *clientv3.Client
+	o      *options
+
+	mu      sync.Mutex
+	id      int64
+	leaseID clientv3.LeaseID
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// NewEtcdAllocator creates a worker id allocator of etcd.
+func NewEtcdAllocator(client *clientv3.Client, opts ...Option) (*EtcdAllocator, error) {
+	if client == nil {
+		return nil, errors.New("etcd client is nil")
+	}
+	o := defaultOptions()
+	o.apply(opts...)
+	return &EtcdAllocator{client: client, o: o}, nil
+}
+
+// Allocate returns the first worker id which is not held by other instances, the lease is kept alive in background.
+func (a *EtcdAllocator) Allocate(ctx context.Context) (int64, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.leaseID != 0 {
+		return a.id, nil
+	}
+
+	lease, err := a.client.Grant(ctx, int64(a.o.ttl.Seconds()))
+	if err != nil {
+		return 0, err
+	}
+
+	for id := int64(0); id <= a.o.maxWorkerID; id++ {
+		key := a.o.key(id)
+		resp, err := a.client.Txn(ctx).
+			If(clientv3.Compare(clientv3.CreateRevision(key), "=", 0)).
+			Then(clientv3.OpPut(key, a.o.value, clientv3.WithLease(lease.ID))).
+			Commit()
+		if err != nil {
+			_, _ = a.client.Revoke(context.Background(), lease.ID)
+			return 0, err
+		}
+		if resp.Succeeded {
+			if err = a.keepAlive(lease.ID); err != nil {
+				_, _ = a.client.Revoke(context.Background(), lease.ID)
+				return 0, err
+			}
+			a.id, a.leaseID = id, lease.ID
+			return id, nil
+		}
+	}
+
+	_, _ = a.client.Revoke(context.Background(), lease.ID)
+	return 0, ErrNoWorkerID
+}
+
+func (a *EtcdAllocator) keepAlive(leaseID clientv3.LeaseID) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := a.client.KeepAlive(ctx, leaseID)
+	if err != nil {
+		cancel()
+		return err
+	}
+	a.cancel = cancel
+	a.done = make(chan struct{})
+
+	go func() {
+		defer close(a.done)
+		for range ch { //nolint
+		}
+		// the channel is closed when the context is canceled or the lease can not be kept alive
+		if ctx.Err() == nil {
+			a.o.lost(errors.New("the lease of etcd expired"))
+		}
+	}()
+	return nil
+}
+
+// Release the worker id by revoking the lease.
+func (a *EtcdAllocator) Release(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.leaseID == 0 {
+		return nil
+	}
+
+	a.cancel()
+	<-a.done
+	_, err := a.client.Revoke(ctx, a.leaseID)
+	a.leaseID = 0
+	return err
+}
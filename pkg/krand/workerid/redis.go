@@ -0,0 +1,121 @@
+package workerid
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// renew the key only if it is held by the value
+var renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+// release the key only if it is held by the value
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// RedisAllocator allocates the worker id by SET NX of redis, the key expires if it is not renewed.
+type RedisAllocator struct {
+	client redis.UniversalClient
+	o      *options
+
+	mu     sync.Mutex
+	id     int64
+	held   bool
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewRedisAllocator creates a worker id allocator of redis.
+func NewRedisAllocator(client redis.UniversalClient, opts ...Option) (*RedisAllocator, error) {
+	if client == nil {
+		return nil, errors.New("redis client is nil")
+	}
+	o := defaultOptions()
+	o.apply(opts...)
+	return &RedisAllocator{client: client, o: o}, nil
+}
+
+// Allocate returns the first worker id which is not held by other instances, the lease is renewed in background.
+func (a *RedisAllocator) Allocate(ctx context.Context) (int64, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.held {
+		return a.id, nil
+	}
+
+	for id := int64(0); id <= a.o.maxWorkerID; id++ {
+		ok, err := a.client.SetNX(ctx, a.o.key(id), a.o.value, a.o.ttl).Result()
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			a.id, a.held = id, true
+			a.startRenew(id)
+			return id, nil
+		}
+	}
+	return 0, ErrNoWorkerID
+}
+
+func (a *RedisAllocator) startRenew(id int64) {
+	ctx, cancel := context.WithCancel(context.Background())
+	a.cancel = cancel
+	a.done = make(chan struct{})
+
+	go func() {
+		defer close(a.done)
+		ticker := time.NewTicker(a.o.ttl / 3)
+		defer ticker.Stop()
+		lastRenewed := time.Now()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			renewCtx, renewCancel := context.WithTimeout(ctx, a.o.ttl/3)
+			n, err := renewScript.Run(renewCtx, a.client, []string{a.o.key(id)}, a.o.value, a.o.ttl.Milliseconds()).Int()
+			renewCancel()
+			if ctx.Err() != nil {
+				return
+			}
+
+			switch {
+			case err == nil && n == 1:
+				lastRenewed = time.Now()
+			case err == nil:
+				a.o.lost(errors.New("the worker id is held by others"))
+				return
+			case time.Since(lastRenewed) >= a.o.ttl:
+				a.o.lost(err)
+				return
+			}
+		}
+	}()
+}
+
+// Release the worker id and stop renewing.
+func (a *RedisAllocator) Release(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.held {
+		return nil
+	}
+
+	a.cancel()
+	<-a.done
+	a.held = false
+	return releaseScript.Run(ctx, a.client, []string{a.o.key(a.id)}, a.o.value).Err()
+}
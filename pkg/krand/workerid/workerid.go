@@ -0,0 +1,117 @@
+// Package workerid allocates the unique worker id of snowflake for each instance, supports redis and etcd,
+// the worker id is held by a lease which is renewed in the background, and it is released when the instance exits.
+package workerid
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/go-dev-frame/sponge/pkg/krand"
+)
+
+var (
+	// ErrNoWorkerID is returned when all worker ids are held by other instances.
+	ErrNoWorkerID = errors.New("workerid: no available worker id")
+	// ErrLeaseLost is passed to the lost callback when the lease of worker id can not be renewed,
+	// the worker id may be allocated to other instances, the generator should stop generating IDs.
+	ErrLeaseLost = errors.New("workerid: lease lost")
+)
+
+// Allocator allocates a unique worker id among instances.
+type Allocator interface {
+	// Allocate returns a worker id which is not held by other instances.
+	Allocate(ctx context.Context) (int64, error)
+	// Release the worker id, so that it can be allocated by other instances.
+	Release(ctx context.Context) error
+}
+
+// Option set the allocator options.
+type Option func(*options)
+
+type options struct {
+	prefix      string
+	maxWorkerID int64
+	ttl         time.Duration
+	onLost      func(err error)
+	value       string // the holder of worker id
+}
+
+func defaultOptions() *options {
+	hostname, _ := os.Hostname()
+	return &options{
+		prefix:      "sponge/workerid",
+		maxWorkerID: krand.MaxWorkerID,
+		ttl:         30 * time.Second,
+		value:       hostname + "_" + strconv.Itoa(os.Getpid()) + "_" + krand.String(krand.R_All, 8),
+	}
+}
+
+func (o *options) apply(opts ...Option) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WithPrefix set the key prefix of worker ids, the services which share the same prefix get different
+// worker ids, default is sponge/workerid.
+func WithPrefix(prefix string) Option {
+	return func(o *options) {
+		if prefix != "" {
+			o.prefix = prefix
+		}
+	}
+}
+
+// WithMaxWorkerID set the maximum worker id, range 0~1023, default is 1023.
+func WithMaxWorkerID(id int64) Option {
+	return func(o *options) {
+		if id >= 0 && id <= krand.MaxWorkerID {
+			o.maxWorkerID = id
+		}
+	}
+}
+
+// WithTTL set the ttl of lease, the lease is renewed every 1/3 ttl, default is 30s.
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		if ttl >= time.Second {
+			o.ttl = ttl
+		}
+	}
+}
+
+// WithOnLost set the callback when the lease of worker id is lost, e.g. redis or etcd is unreachable
+// longer than the ttl, the err wraps ErrLeaseLost.
+func WithOnLost(fn func(err error)) Option {
+	return func(o *options) {
+		o.onLost = fn
+	}
+}
+
+func (o *options) key(id int64) string {
+	return fmt.Sprintf("%s/%d", o.prefix, id)
+}
+
+func (o *options) lost(err error) {
+	if o.onLost != nil {
+		o.onLost(fmt.Errorf("%w: %v", ErrLeaseLost, err))
+	}
+}
+
+// InitSnowflake allocates a worker id and initializes the default snowflake generator of krand with it,
+// the worker id should be released by the allocator when the instance exits.
+func InitSnowflake(ctx context.Context, allocator Allocator, opts ...krand.SnowflakeOption) (int64, error) {
+	id, err := allocator.Allocate(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if err = krand.InitSnowflake(id, opts...); err != nil {
+		_ = allocator.Release(ctx)
+		return 0, err
+	}
+	return id, nil
+}
@@ -0,0 +1,135 @@
+package workerid
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/go-dev-frame/sponge/pkg/etcdcli"
+	"github.com/go-dev-frame/sponge/pkg/krand"
+)
+
+func TestRedisAllocator(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	ctx := context.Background()
+
+	_, err := NewRedisAllocator(nil)
+	assert.Error(t, err)
+
+	a1, err := NewRedisAllocator(client, WithPrefix("test/workerid"), WithMaxWorkerID(1), WithTTL(3*time.Second))
+	assert.NoError(t, err)
+	a2, _ := NewRedisAllocator(client, WithPrefix("test/workerid"), WithMaxWorkerID(1))
+	a3, _ := NewRedisAllocator(client, WithPrefix("test/workerid"), WithMaxWorkerID(1))
+
+	id, err := a1.Allocate(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), id)
+	id, err = a1.Allocate(ctx) // allocated already
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), id)
+
+	id, err = a2.Allocate(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), id)
+
+	_, err = a3.Allocate(ctx)
+	assert.True(t, errors.Is(err, ErrNoWorkerID))
+
+	// the lease is renewed, the key does not expire after 4s
+	mr.FastForward(2 * time.Second)
+	time.Sleep(1100 * time.Millisecond)
+	mr.FastForward(2 * time.Second)
+	assert.True(t, mr.Exists("test/workerid/0"))
+
+	assert.NoError(t, a1.Release(ctx))
+	assert.NoError(t, a1.Release(ctx))
+	assert.False(t, mr.Exists("test/workerid/0"))
+
+	id, err = a3.Allocate(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), id)
+	assert.NoError(t, a2.Release(ctx))
+	assert.NoError(t, a3.Release(ctx))
+}
+
+func TestRedisAllocatorLost(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	ctx := context.Background()
+
+	lost := make(chan error, 1)
+	a, _ := NewRedisAllocator(client, WithTTL(time.Second), WithOnLost(func(err error) { lost <- err }))
+	_, err := a.Allocate(ctx)
+	assert.NoError(t, err)
+
+	mr.Set("sponge/workerid/0", "others")
+	select {
+	case err = <-lost:
+		assert.True(t, errors.Is(err, ErrLeaseLost))
+	case <-time.After(2 * time.Second):
+		t.Fatal("lease lost is not notified")
+	}
+	assert.NoError(t, a.Release(ctx))
+	v, _ := mr.Get("sponge/workerid/0")
+	assert.Equal(t, "others", v) // not released by the allocator
+}
+
+func TestInitSnowflake(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	ctx := context.Background()
+
+	mr.Set("sponge/workerid/0", "others")
+	a, _ := NewRedisAllocator(client)
+	id, err := InitSnowflake(ctx, a)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), id)
+	defer func() { _ = krand.InitSnowflake(0) }()
+
+	sid, err := krand.NewSnowflakeID()
+	assert.NoError(t, err)
+	_, workerID, _ := krand.ParseSnowflake(sid)
+	assert.Equal(t, int64(1), workerID)
+	assert.NoError(t, a.Release(ctx))
+}
+
+func TestEtcdAllocator(t *testing.T) {
+	_, err := NewEtcdAllocator(nil)
+	assert.Error(t, err)
+
+	cli, err := etcdcli.Init([]string{"127.0.0.1:2379"},
+		etcdcli.WithDialTimeout(time.Second*2),
+		etcdcli.WithAutoSyncInterval(0),
+		etcdcli.WithLog(zap.NewNop()),
+	)
+	if err != nil {
+		t.Log(err)
+		return
+	}
+	defer cli.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	a1, _ := NewEtcdAllocator(cli, WithPrefix("test/workerid"), WithMaxWorkerID(1))
+	a2, _ := NewEtcdAllocator(cli, WithPrefix("test/workerid"), WithMaxWorkerID(1))
+	id1, err := a1.Allocate(ctx)
+	if err != nil {
+		t.Log(err)
+		return
+	}
+	id2, err := a2.Allocate(ctx)
+	assert.NoError(t, err)
+	assert.NotEqual(t, id1, id2)
+	assert.NoError(t, a1.Release(ctx))
+	assert.NoError(t, a2.Release(ctx))
+}
@@ -0,0 +1,129 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/time/rate"
+)
+
+const redactedValue = "******"
+
+// wrapCore wraps the core with redaction, rate limiting and sampling, a log entry is sampled first,
+// then rate limited, and the sensitive fields are redacted before writing.
+func (o *options) wrapCore(core zapcore.Core) zapcore.Core {
+	if len(o.redactFields) > 0 {
+		core = newRedactCore(core, o.redactFields)
+	}
+	if o.errorRateLimit > 0 {
+		core = newRateLimitCore(core, zapcore.ErrorLevel, o.errorRateLimit)
+	}
+	if o.samplingFirst > 0 {
+		core = zapcore.NewSamplerWithOptions(core, o.samplingTick, o.samplingFirst, o.samplingThereafter)
+	}
+	return core
+}
+
+// ------------------------------------------------------------------------------------------
+
+// redactCore replaces the values of the configured fields with ******, field names are case-insensitive,
+// only top-level fields are matched, the fields inside objects logged by Any are not.
+type redactCore struct {
+	zapcore.Core
+	fields map[string]struct{}
+}
+
+func newRedactCore(core zapcore.Core, fields []string) zapcore.Core {
+	m := make(map[string]struct{}, len(fields))
+	for _, field := range fields {
+		m[strings.ToLower(field)] = struct{}{}
+	}
+	return &redactCore{Core: core, fields: m}
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(c.redact(fields)), fields: c.fields}
+}
+
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, c.redact(fields))
+}
+
+func (c *redactCore) redact(fields []zapcore.Field) []zapcore.Field {
+	var redacted []zapcore.Field
+	for i, field := range fields {
+		if _, ok := c.fields[strings.ToLower(field.Key)]; !ok {
+			continue
+		}
+		if redacted == nil { // copy on write, the fields of caller are not modified
+			redacted = make([]zapcore.Field, len(fields))
+			copy(redacted, fields)
+		}
+		redacted[i] = String(field.Key, redactedValue)
+	}
+	if redacted == nil {
+		return fields
+	}
+	return redacted
+}
+
+// ------------------------------------------------------------------------------------------
+
+// rateLimitCore limits the number of entries per second at or above the level, e.g. error storms,
+// the excess entries are dropped, and the number of dropped entries is logged when logging resumes.
+type rateLimitCore struct {
+	zapcore.Core
+	level   zapcore.Level
+	limiter *rate.Limiter
+	dropped *atomic.Int64
+}
+
+func newRateLimitCore(core zapcore.Core, level zapcore.Level, perSecond float64) zapcore.Core {
+	burst := int(perSecond)
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimitCore{
+		Core:    core,
+		level:   level,
+		limiter: rate.NewLimiter(rate.Limit(perSecond), burst),
+		dropped: &atomic.Int64{},
+	}
+}
+
+// With the limiter is shared, so that the limit applies to all child loggers
+func (c *rateLimitCore) With(fields []zapcore.Field) zapcore.Core {
+	return &rateLimitCore{Core: c.Core.With(fields), level: c.level, limiter: c.limiter, dropped: c.dropped}
+}
+
+func (c *rateLimitCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if ent.Level < c.level || !c.Enabled(ent.Level) {
+		return c.Core.Check(ent, ce)
+	}
+	if !c.limiter.Allow() {
+		c.dropped.Add(1)
+		return ce
+	}
+
+	if n := c.dropped.Swap(0); n > 0 {
+		summary := zapcore.Entry{
+			Level:   zapcore.WarnLevel,
+			Time:    time.Now(),
+			Message: fmt.Sprintf("%d %s logs were dropped by rate limiting", n, c.level.String()),
+		}
+		if sce := c.Core.Check(summary, nil); sce != nil {
+			sce.Write()
+		}
+	}
+	return c.Core.Check(ent, ce)
+}
@@ -0,0 +1,98 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRedactCore(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	o := defaultOptions()
+	o.apply(WithRedactFields("Password", "token"))
+	l := zap.New(o.wrapCore(core))
+
+	fields := []Field{String("password", "123456"), String("name", "foo")}
+	l.With(String("Token", "abc")).Info("login", fields...)
+	l.Sugar().Infow("login", "password", "123456")
+
+	entries := logs.AllUntimed()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 logs, got %d", len(entries))
+	}
+	m := entries[0].ContextMap()
+	if m["password"] != redactedValue || m["Token"] != redactedValue || m["name"] != "foo" {
+		t.Errorf("unexpected fields %v", m)
+	}
+	if entries[1].ContextMap()["password"] != redactedValue {
+		t.Errorf("unexpected fields %v", entries[1].ContextMap())
+	}
+	if fields[0].String != "123456" {
+		t.Error("fields of caller should not be modified")
+	}
+}
+
+func TestRateLimitCore(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	o := defaultOptions()
+	o.apply(WithErrorRateLimit(2))
+	l := zap.New(o.wrapCore(core))
+
+	for i := 0; i < 10; i++ {
+		l.Error("db error")
+		l.Info("info is not limited")
+	}
+	if n := logs.FilterMessage("db error").Len(); n != 2 {
+		t.Errorf("expected 2 error logs, got %d", n)
+	}
+	if n := logs.FilterMessage("info is not limited").Len(); n != 10 {
+		t.Errorf("expected 10 info logs, got %d", n)
+	}
+
+	time.Sleep(600 * time.Millisecond)
+	l.Error("db error")
+	found := false
+	for _, entry := range logs.All() {
+		if strings.Contains(entry.Message, "8 error logs were dropped") {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected the number of dropped logs to be logged")
+	}
+}
+
+func TestSampling(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	o := defaultOptions()
+	o.apply(WithSampling(time.Minute, 3, 5))
+	l := zap.New(o.wrapCore(core))
+
+	for i := 0; i < 20; i++ {
+		l.Info("repetitive message")
+	}
+	l.Info("other message")
+	if n := logs.FilterMessage("repetitive message").Len(); n != 6 { // 3 first, then the 8th, 13th and 18th
+		t.Errorf("expected 6 logs, got %d", n)
+	}
+	if n := logs.FilterMessage("other message").Len(); n != 1 {
+		t.Errorf("expected 1 log, got %d", n)
+	}
+}
+
+func TestInitWithCores(t *testing.T) {
+	_, err := Init(
+		WithSampling(0, 100, 100),
+		WithErrorRateLimit(10),
+		WithRedactFields("password"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Info("login", String("password", "123456"))
+	Error("error")
+}
@@ -3,6 +3,7 @@
 // Support for terminal printing and log saving.
 // Support for automatic log file cutting.
 // Support for json format and console log format output.
+// Support for sampling repetitive logs, rate limiting error logs and redacting sensitive fields.
 // Supports Debug, Info, Warn, Error, Panic, Fatal, also supports fmt.Printf-like log printing, Debugf, Infof, Warnf, Errorf, Panicf, Fatalf.
 package logger
 
@@ -45,6 +46,7 @@ func getSugaredLogger() *zap.SugaredLogger {
 // print the info level log in the terminal, example: Init(WithLevel("info"))
 // print the json format, debug level log in the terminal, example: Init(WithFormat("json"))
 // log with hooks, example: Init(WithHooks(func(zapcore.Entry) error{return nil}))
+// log with sampling, rate limiting and redaction, example: Init(WithSampling(time.Second, 100, 100), WithErrorRateLimit(50), WithRedactFields("password"))
 // output the log to the file out.log, using the default cut log-related parameters, debug-level log, example: Init(WithSave())
 // output the log to the specified file, custom set the log file cut log parameters, json format, debug level log, example:
 // Init(
@@ -79,6 +81,8 @@ func Init(opts ...Option) (*zap.Logger, error) {
 		str = fmt.Sprintf("initialize logger finish, config is output to 'file', format=%s, level=%s, file=%s", encoding, levelName, o.fileConfig.filename)
 	}
 
+	zapLog = zapLog.WithOptions(zap.WrapCore(o.wrapCore))
+
 	if len(o.hooks) > 0 {
 		zapLog = zapLog.WithOptions(zap.Hooks(o.hooks...))
 	}
@@ -2,6 +2,7 @@ package logger
 
 import (
 	"strings"
+	"time"
 
 	"go.uber.org/zap/zapcore"
 )
@@ -27,6 +28,12 @@ type options struct {
 	fileConfig *fileOptions
 
 	hooks []func(zapcore.Entry) error
+
+	samplingTick       time.Duration
+	samplingFirst      int
+	samplingThereafter int
+	errorRateLimit     float64
+	redactFields       []string
 }
 
 func defaultOptions() *options {
@@ -87,6 +94,36 @@ func WithHooks(hooks ...func(zapcore.Entry) error) Option {
 	}
 }
 
+// WithSampling set sampling of repetitive logs, the first logs with the same level and message are logged
+// in each tick, after that, every thereafter-th log is logged, e.g. WithSampling(time.Second, 100, 100),
+// default is no sampling.
+func WithSampling(tick time.Duration, first int, thereafter int) Option {
+	return func(o *options) {
+		if tick <= 0 {
+			tick = time.Second
+		}
+		o.samplingTick = tick
+		o.samplingFirst = first
+		o.samplingThereafter = thereafter
+	}
+}
+
+// WithErrorRateLimit set the maximum number of error logs per second, the excess logs are dropped,
+// and the number of dropped logs is logged when logging resumes, default is no limit.
+func WithErrorRateLimit(perSecond float64) Option {
+	return func(o *options) {
+		o.errorRateLimit = perSecond
+	}
+}
+
+// WithRedactFields set the names of sensitive fields, their values are replaced with ******,
+// e.g. WithRedactFields("password", "token"), field names are case-insensitive.
+func WithRedactFields(fields ...string) Option {
+	return func(o *options) {
+		o.redactFields = fields
+	}
+}
+
 // ------------------------------------------------------------------------------------------
 
 type fileOptions struct {
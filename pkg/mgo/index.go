@@ -0,0 +1,195 @@
+package mgo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// IndexTagName the struct tag name of index definition, the settings are separated by comma,
+// multiple indexes of one field are separated by semicolon, the supported settings are:
+//
+//	index          single field index, e.g. `mgo:"index"`
+//	index:name     the fields with the same index name are combined into a compound index in the order of fields, e.g. `mgo:"index:idx_name_age"`
+//	unique         unique index, e.g. `mgo:"index,unique"` or `mgo:"unique"`
+//	desc           descending order, default is ascending order, e.g. `mgo:"index,desc"`
+//	sparse         sparse index, only the documents that contain the field are indexed
+//	expire:seconds ttl index, the documents are deleted after the specified seconds, e.g. `mgo:"index,expire:3600"`
+const IndexTagName = "mgo"
+
+type indexDefinition struct {
+	name      string
+	keys      bson.D
+	isUnique  bool
+	isSparse  bool
+	expire    int32
+	hasExpire bool
+}
+
+// ParseIndexes parse the index models from the mgo tag of struct fields, the field name is the name of bson tag,
+// the fields of embedded struct (e.g. mgo.Model) are also parsed.
+func ParseIndexes(model interface{}) ([]mongo.IndexModel, error) {
+	typ := reflect.TypeOf(model)
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("model must be a struct or pointer to struct, got %T", model)
+	}
+
+	var definitions []*indexDefinition
+	namedDefinitions := map[string]*indexDefinition{}
+	err := parseIndexFields(typ, func(fieldName string, setting string) error {
+		def, err := parseIndexSetting(fieldName, setting)
+		if err != nil || def == nil {
+			return err
+		}
+		if def.name == "" {
+			definitions = append(definitions, def)
+			return nil
+		}
+		if d, ok := namedDefinitions[def.name]; ok {
+			d.keys = append(d.keys, def.keys...)
+			d.isUnique = d.isUnique || def.isUnique
+			d.isSparse = d.isSparse || def.isSparse
+			if def.hasExpire {
+				d.expire, d.hasExpire = def.expire, true
+			}
+			return nil
+		}
+		namedDefinitions[def.name] = def
+		definitions = append(definitions, def)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	indexModels := make([]mongo.IndexModel, 0, len(definitions))
+	for _, def := range definitions {
+		opts := options.Index()
+		if def.name != "" {
+			opts.SetName(def.name)
+		}
+		if def.isUnique {
+			opts.SetUnique(true)
+		}
+		if def.isSparse {
+			opts.SetSparse(true)
+		}
+		if def.hasExpire {
+			opts.SetExpireAfterSeconds(def.expire)
+		}
+		indexModels = append(indexModels, mongo.IndexModel{Keys: def.keys, Options: opts})
+	}
+	return indexModels, nil
+}
+
+func parseIndexFields(typ reflect.Type, fn func(fieldName string, setting string) error) error {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		bsonTag := field.Tag.Get("bson")
+		fieldName, bsonOpts, _ := strings.Cut(bsonTag, ",")
+		if fieldName == "-" {
+			continue
+		}
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() == reflect.Struct && (strings.Contains(bsonOpts, "inline") || (field.Anonymous && fieldName == "")) {
+			if err := parseIndexFields(fieldType, fn); err != nil {
+				return err
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		tag, ok := field.Tag.Lookup(IndexTagName)
+		if !ok {
+			continue
+		}
+		if fieldName == "" {
+			fieldName = strings.ToLower(field.Name) // the default field name of bson
+		}
+		for _, setting := range strings.Split(tag, ";") {
+			if err := fn(fieldName, setting); err != nil {
+				return fmt.Errorf("field %s: %v", field.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func parseIndexSetting(fieldName string, setting string) (*indexDefinition, error) {
+	setting = strings.TrimSpace(setting)
+	if setting == "" {
+		return nil, nil
+	}
+
+	def := &indexDefinition{}
+	order := 1
+	isIndex := false
+	for _, item := range strings.Split(setting, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(item), ":")
+		switch strings.ToLower(key) {
+		case "index":
+			isIndex = true
+			def.name = value
+		case "unique":
+			isIndex = true
+			def.isUnique = true
+		case "desc":
+			order = -1
+		case "sparse":
+			def.isSparse = true
+		case "expire":
+			seconds, err := strconv.ParseInt(value, 10, 32)
+			if err != nil || seconds < 0 {
+				return nil, fmt.Errorf("invalid expire seconds '%s'", value)
+			}
+			def.expire, def.hasExpire = int32(seconds), true
+		default:
+			return nil, fmt.Errorf("unknown index setting '%s'", item)
+		}
+	}
+	if !isIndex {
+		return nil, fmt.Errorf("index setting '%s' must contain index or unique", setting)
+	}
+
+	def.keys = bson.D{{Key: fieldName, Value: order}}
+	return def, nil
+}
+
+// CreateIndexes create the indexes of collection from the mgo tag of model struct fields,
+// it is usually called at startup, creating an index that already exists with the same options has no effect.
+//
+// Example:
+//
+//	type User struct {
+//		mgo.Model `bson:",inline"`
+//		Name      string `bson:"name" mgo:"index:idx_name_age"`
+//		Age       int    `bson:"age" mgo:"index:idx_name_age,desc"`
+//		Email     string `bson:"email" mgo:"unique"`
+//	}
+//	err := mgo.CreateIndexes(ctx, db.Collection("user"), &User{})
+func CreateIndexes(ctx context.Context, collection *mongo.Collection, model interface{}) error {
+	indexModels, err := ParseIndexes(model)
+	if err != nil {
+		return err
+	}
+	if len(indexModels) == 0 {
+		return nil
+	}
+	_, err = collection.Indexes().CreateMany(ctx, indexModels)
+	return err
+}
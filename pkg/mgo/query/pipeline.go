@@ -0,0 +1,182 @@
+package query
+
+import (
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// PipelineRecordsField the field name of records in the result of paging pipeline
+	PipelineRecordsField = "records"
+	// PipelineTotalField the field name of total in the result of paging pipeline
+	PipelineTotalField = "total"
+)
+
+// Pipeline aggregation pipeline builder, the stages are appended in the order of calling
+type Pipeline struct {
+	stages mongo.Pipeline
+}
+
+// NewPipeline create an aggregation pipeline builder, the stages are the initial stages of pipeline
+func NewPipeline(stages ...bson.D) *Pipeline {
+	p := &Pipeline{stages: mongo.Pipeline{}}
+	return p.AddStages(stages...)
+}
+
+// AddStages append custom stages, e.g. bson.D{{"$addFields", bson.M{"fullName": "$name"}}}
+func (p *Pipeline) AddStages(stages ...bson.D) *Pipeline {
+	for _, stage := range stages {
+		if len(stage) > 0 {
+			p.stages = append(p.stages, stage)
+		}
+	}
+	return p
+}
+
+// Match append $match stage, the empty filter is ignored
+func (p *Pipeline) Match(filter bson.M) *Pipeline {
+	if len(filter) == 0 {
+		return p
+	}
+	return p.AddStages(bson.D{{Key: "$match", Value: filter}})
+}
+
+// Lookup append $lookup stage, left outer join to another collection
+func (p *Pipeline) Lookup(from string, localField string, foreignField string, as string) *Pipeline {
+	return p.AddStages(bson.D{{Key: "$lookup", Value: bson.D{
+		{Key: "from", Value: from},
+		{Key: "localField", Value: localField},
+		{Key: "foreignField", Value: foreignField},
+		{Key: "as", Value: as},
+	}}})
+}
+
+// Unwind append $unwind stage, the documents without the array field are preserved
+func (p *Pipeline) Unwind(field string) *Pipeline {
+	return p.AddStages(bson.D{{Key: "$unwind", Value: bson.D{
+		{Key: "path", Value: "$" + field},
+		{Key: "preserveNullAndEmptyArrays", Value: true},
+	}}})
+}
+
+// Group append $group stage, id is the group key, e.g. "$gender", fields are the accumulators,
+// e.g. bson.M{"count": bson.M{"$sum": 1}}
+func (p *Pipeline) Group(id interface{}, fields bson.M) *Pipeline {
+	group := bson.D{{Key: "_id", Value: id}}
+	for _, k := range sortedKeys(fields) {
+		group = append(group, bson.E{Key: k, Value: fields[k]})
+	}
+	return p.AddStages(bson.D{{Key: "$group", Value: group}})
+}
+
+// Project append $project stage, e.g. bson.M{"name": 1, "password": 0}
+func (p *Pipeline) Project(fields bson.M) *Pipeline {
+	if len(fields) == 0 {
+		return p
+	}
+	return p.AddStages(bson.D{{Key: "$project", Value: fields}})
+}
+
+// Sort append $sort stage, the empty sort is ignored
+func (p *Pipeline) Sort(sort bson.D) *Pipeline {
+	if len(sort) == 0 {
+		return p
+	}
+	return p.AddStages(bson.D{{Key: "$sort", Value: sort}})
+}
+
+// Skip append $skip stage
+func (p *Pipeline) Skip(skip int) *Pipeline {
+	if skip <= 0 {
+		return p
+	}
+	return p.AddStages(bson.D{{Key: "$skip", Value: int64(skip)}})
+}
+
+// Limit append $limit stage
+func (p *Pipeline) Limit(limit int) *Pipeline {
+	if limit <= 0 {
+		return p
+	}
+	return p.AddStages(bson.D{{Key: "$limit", Value: int64(limit)}})
+}
+
+// Page append $sort, $skip and $limit stages
+func (p *Pipeline) Page(page *Page) *Pipeline {
+	return p.Sort(page.Sort()).Skip(page.Skip()).Limit(page.Limit())
+}
+
+// Build get the aggregation pipeline
+func (p *Pipeline) Build() mongo.Pipeline {
+	return p.stages
+}
+
+// BuildWithTotal get the paging aggregation pipeline, the records of the page and the total number of
+// documents are returned in one document by $facet, decode the result by PipelineResult.
+func (p *Pipeline) BuildWithTotal(page *Page) mongo.Pipeline {
+	records := NewPipeline().Page(page).Build()
+	stages := append(mongo.Pipeline{}, p.stages...)
+	return append(stages, bson.D{{Key: "$facet", Value: bson.D{
+		{Key: PipelineRecordsField, Value: records},
+		{Key: PipelineTotalField, Value: mongo.Pipeline{{{Key: "$count", Value: PipelineTotalField}}}},
+	}}})
+}
+
+// PipelineResult the result of paging aggregation pipeline built by BuildWithTotal
+type PipelineResult struct {
+	Records []bson.Raw `bson:"records"`
+	Total   []struct {
+		Total int64 `bson:"total"`
+	} `bson:"total"`
+}
+
+// GetTotal get the total number of documents
+func (r *PipelineResult) GetTotal() int64 {
+	if len(r.Total) == 0 {
+		return 0
+	}
+	return r.Total[0].Total
+}
+
+// DecodeRecords decode the records to results, results must be a pointer to slice
+func (r *PipelineResult) DecodeRecords(results interface{}) error {
+	raw, err := bson.Marshal(bson.M{PipelineRecordsField: r.Records})
+	if err != nil {
+		return err
+	}
+	return bson.Raw(raw).Lookup(PipelineRecordsField).Unmarshal(results)
+}
+
+// ConvertToPipeline convert the query parameters to aggregation pipeline builder, the columns are converted to $match stage,
+// filterFn is used to adjust the filter before matching, e.g. mgo.ExcludeDeleted, can be nil.
+//
+// Example:
+//
+//	pipeline, err := params.ConvertToPipeline(mgo.ExcludeDeleted, query.WithWhitelistNames(model.UserExampleColumnNames))
+//	pipeline.Lookup("order", "_id", "user_id", "orders").BuildWithTotal(params.ConvertToPageInfo())
+func (p *Params) ConvertToPipeline(filterFn func(filter bson.M) bson.M, opts ...RulerOption) (*Pipeline, error) {
+	filter, err := p.ConvertToMongoFilter(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if filterFn != nil {
+		filter = filterFn(filter)
+	}
+	return NewPipeline().Match(filter), nil
+}
+
+// ConvertToPageInfo converted to page info
+func (p *Params) ConvertToPageInfo() *Page {
+	return NewPage(p.Page, p.Limit, p.Sort)
+}
+
+func sortedKeys(m bson.M) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,92 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestPipeline(t *testing.T) {
+	pipeline := NewPipeline(bson.D{}).
+		Match(bson.M{"age": bson.M{"$gt": 18}}).
+		Match(nil).
+		Lookup("order", "_id", "user_id", "orders").
+		Unwind("orders").
+		Group("$gender", bson.M{"total": bson.M{"$sum": 1}, "avgAge": bson.M{"$avg": "$age"}}).
+		Project(bson.M{"total": 1}).
+		Project(nil).
+		Page(NewPage(1, 10, "-total")).
+		Build()
+
+	assert.Equal(t, 8, len(pipeline))
+	assert.Equal(t, "$match", pipeline[0][0].Key)
+	assert.Equal(t, bson.D{{Key: "_id", Value: "$gender"}, {Key: "avgAge", Value: bson.M{"$avg": "$age"}},
+		{Key: "total", Value: bson.M{"$sum": 1}}}, pipeline[3][0].Value)
+	assert.Equal(t, bson.D{{Key: "$sort", Value: bson.D{{Key: "total", Value: -1}}}}, pipeline[5])
+	assert.Equal(t, bson.D{{Key: "$skip", Value: int64(10)}}, pipeline[6])
+	assert.Equal(t, bson.D{{Key: "$limit", Value: int64(10)}}, pipeline[7])
+
+	pipeline = NewPipeline().Sort(nil).Skip(0).Limit(0).Build()
+	assert.Equal(t, 0, len(pipeline))
+}
+
+func TestPipeline_BuildWithTotal(t *testing.T) {
+	p := NewPipeline().Match(bson.M{"name": "foo"})
+	pipeline := p.BuildWithTotal(NewPage(0, 20, ""))
+	assert.Equal(t, 2, len(pipeline))
+	assert.Equal(t, 1, len(p.Build()))
+
+	facet := pipeline[1][0].Value.(bson.D)
+	assert.Equal(t, PipelineRecordsField, facet[0].Key)
+	assert.Equal(t, mongo.Pipeline{
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: -1}}}},
+		{{Key: "$limit", Value: int64(20)}},
+	}, facet[0].Value)
+	assert.Equal(t, PipelineTotalField, facet[1].Key)
+}
+
+func TestPipelineResult(t *testing.T) {
+	type record struct {
+		Name string `bson:"name"`
+	}
+	data, err := bson.Marshal(bson.M{
+		PipelineRecordsField: []bson.M{{"name": "foo"}, {"name": "bar"}},
+		PipelineTotalField:   []bson.M{{PipelineTotalField: 12}},
+	})
+	assert.NoError(t, err)
+
+	result := &PipelineResult{}
+	err = bson.Unmarshal(data, result)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(12), result.GetTotal())
+
+	var records []*record
+	err = result.DecodeRecords(&records)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(records))
+	assert.Equal(t, "bar", records[1].Name)
+
+	assert.Equal(t, int64(0), (&PipelineResult{}).GetTotal())
+}
+
+func TestParams_ConvertToPipeline(t *testing.T) {
+	params := &Params{
+		Page:    0,
+		Limit:   10,
+		Sort:    "-age",
+		Columns: []Column{{Name: "name", Value: "foo"}},
+	}
+	excludeDeleted := func(filter bson.M) bson.M {
+		filter["deleted_at"] = bson.M{"$exists": false}
+		return filter
+	}
+	p, err := params.ConvertToPipeline(excludeDeleted, WithWhitelistNames(map[string]bool{"name": true}))
+	assert.NoError(t, err)
+	pipeline := p.BuildWithTotal(params.ConvertToPageInfo())
+	assert.Equal(t, bson.D{{Key: "$match", Value: bson.M{"name": "foo", "deleted_at": bson.M{"$exists": false}}}}, pipeline[0])
+
+	_, err = params.ConvertToPipeline(nil, WithWhitelistNames(map[string]bool{"age": true}))
+	assert.Error(t, err)
+}
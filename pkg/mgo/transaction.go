@@ -0,0 +1,43 @@
+package mgo
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SessionContext the context of session, the operations using it are executed in the transaction
+type SessionContext = mongo.SessionContext
+
+// WithTransaction execute fn in a multi-document transaction, the transaction is committed if fn returns nil,
+// otherwise it is aborted, the transient errors are retried automatically by the driver.
+// Note: fn may be called multiple times, and the transaction requires a replica set or sharded cluster.
+//
+// Example:
+//
+//	err := mgo.WithTransaction(ctx, db, func(sessCtx mgo.SessionContext) error {
+//		if _, err := db.Collection("order").InsertOne(sessCtx, order); err != nil {
+//			return err
+//		}
+//		_, err := db.Collection("stock").UpdateOne(sessCtx, filter, update)
+//		return err
+//	})
+func WithTransaction(ctx context.Context, db *mongo.Database, fn func(sessCtx SessionContext) error,
+	opts ...*options.TransactionOptions) error {
+	session, err := db.Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessCtx)
+	}, opts...)
+	return err
+}
+
+// WithTxOption set option for transaction, e.g. WithTxOption().SetReadConcern(readconcern.Snapshot())
+func WithTxOption() *options.TransactionOptions {
+	return options.Transaction()
+}
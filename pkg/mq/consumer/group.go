@@ -0,0 +1,303 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Option set the options of Group.
+type Option func(*options)
+
+type options struct {
+	concurrency       int
+	maxAttempts       int
+	initialBackoff    time.Duration
+	maxBackoff        time.Duration
+	handleTimeout     time.Duration
+	retryTopic        string
+	deadLetterTopic   string
+	publisher         Publisher
+	deadLetterHandler func(ctx context.Context, msg *Message, err error) error
+	zapLogger         *zap.Logger
+}
+
+func defaultOptions() *options {
+	return &options{
+		concurrency:    1,
+		maxAttempts:    3,
+		initialBackoff: time.Second,
+		maxBackoff:     time.Minute,
+	}
+}
+
+func (o *options) apply(opts ...Option) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WithConcurrency set the maximum number of messages handled concurrently, default is 1
+func WithConcurrency(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.concurrency = n
+		}
+	}
+}
+
+// WithMaxAttempts set the maximum number of attempts of handling a message, including the first attempt,
+// the message is routed to the dead-letter queue after the maximum attempts, default is 3
+func WithMaxAttempts(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.maxAttempts = n
+		}
+	}
+}
+
+// WithRetryBackoff set the exponential backoff delay of retries, default is 1s and 1m
+func WithRetryBackoff(initialBackoff time.Duration, maxBackoff time.Duration) Option {
+	return func(o *options) {
+		if initialBackoff > 0 {
+			o.initialBackoff = initialBackoff
+		}
+		if maxBackoff > 0 {
+			o.maxBackoff = maxBackoff
+		}
+	}
+}
+
+// WithHandleTimeout set the timeout of handling a message, 0 means no timeout, default is 0
+func WithHandleTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.handleTimeout = d
+	}
+}
+
+// WithPublisher set the publisher of retry and dead-letter queues, failed messages are published to the retry queue
+// and handled again when they are consumed from it, if it is not set, failed messages are retried in process.
+func WithPublisher(p Publisher) Option {
+	return func(o *options) {
+		o.publisher = p
+	}
+}
+
+// WithRetryTopic set the topic of the retry queue, default is "<group name>.retry"
+func WithRetryTopic(topic string) Option {
+	return func(o *options) {
+		o.retryTopic = topic
+	}
+}
+
+// WithDeadLetterTopic set the topic of the dead-letter queue, default is "<group name>.dlq"
+func WithDeadLetterTopic(topic string) Option {
+	return func(o *options) {
+		o.deadLetterTopic = topic
+	}
+}
+
+// WithDeadLetterHandler set the function called when a message fails after the maximum attempts,
+// e.g. save it to database, it is called after the message is published to the dead-letter queue.
+func WithDeadLetterHandler(fn func(ctx context.Context, msg *Message, err error) error) Option {
+	return func(o *options) {
+		o.deadLetterHandler = fn
+	}
+}
+
+// WithLogger set the logger of Group
+func WithLogger(l *zap.Logger) Option {
+	return func(o *options) {
+		if l != nil {
+			o.zapLogger = l
+		}
+	}
+}
+
+// --------------------------------------------------------------------------------------
+
+// Group handles the messages consumed from message queues, it is not bound to a specific broker, the messages are
+// passed by Process or Submit in the handler of broker consumers (e.g. kafka.ConsumerGroup, rabbitmq.Consumer).
+//
+// A failed message is published to the retry queue with the number of attempts and the time it can be handled
+// again in the headers, the retry queue should be consumed by the same Group, the message is handled after the
+// backoff delay, after the maximum attempts, the message is published to the dead-letter queue, if neither
+// the publisher nor the dead-letter handler is set, the message is dropped after logging the error.
+type Group struct {
+	name    string
+	handler Handler
+	opts    *options
+	sem     chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewGroup creates a consumer group, name is used as the label of metrics and the prefix of default topics.
+func NewGroup(name string, handler Handler, opts ...Option) *Group {
+	o := defaultOptions()
+	o.apply(opts...)
+	if o.zapLogger == nil {
+		o.zapLogger, _ = zap.NewProduction()
+	}
+	if o.retryTopic == "" {
+		o.retryTopic = name + ".retry"
+	}
+	if o.deadLetterTopic == "" {
+		o.deadLetterTopic = name + ".dlq"
+	}
+	registerMetrics()
+
+	return &Group{
+		name:    name,
+		handler: handler,
+		opts:    o,
+		sem:     make(chan struct{}, o.concurrency),
+	}
+}
+
+// Process handles the message synchronously, it blocks while the concurrency limit is reached, returns nil
+// if the message is handled successfully or routed to the retry or dead-letter queue, the message can be
+// acknowledged then, otherwise it should not be acknowledged, so that it is redelivered.
+func (g *Group) Process(ctx context.Context, msg *Message) error {
+	select {
+	case g.sem <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-g.sem }()
+
+	return g.process(ctx, msg)
+}
+
+// Submit handles the message asynchronously, it blocks only while the concurrency limit is reached,
+// done is called with the result of Process after the message is handled, e.g. acknowledge the message.
+func (g *Group) Submit(ctx context.Context, msg *Message, done func(err error)) error {
+	select {
+	case g.sem <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	g.wg.Add(1)
+	go func() {
+		defer func() {
+			<-g.sem
+			g.wg.Done()
+		}()
+		err := g.process(ctx, msg)
+		if done != nil {
+			done(err)
+		}
+	}()
+	return nil
+}
+
+// Wait waits for the submitted messages to be handled.
+func (g *Group) Wait() {
+	g.wg.Wait()
+}
+
+// SetLag reports the number of messages not yet consumed of the topic, e.g. the difference between
+// the high water mark offset and the consumed offset of kafka partitions.
+func (g *Group) SetLag(topic string, lag int64) {
+	consumerLag.WithLabelValues(g.name, topic).Set(float64(lag))
+}
+
+func (g *Group) process(ctx context.Context, msg *Message) error {
+	inFlight.WithLabelValues(g.name).Inc()
+	defer inFlight.WithLabelValues(g.name).Dec()
+
+	topic := msg.Topic
+	if original := msg.Headers[HeaderOriginalTopic]; original != "" {
+		topic = original
+	}
+
+	for {
+		// the retried message is handled after the backoff delay
+		if d := time.Until(msg.NotBefore()); d > 0 {
+			select {
+			case <-time.After(d):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		begin := time.Now()
+		err := g.handle(ctx, msg)
+		handleDuration.WithLabelValues(g.name, topic).Observe(time.Since(begin).Seconds())
+		if err == nil {
+			messageCount.WithLabelValues(g.name, topic, resultSuccess).Inc()
+			return nil
+		}
+
+		attempt := msg.Attempt() + 1
+		next := msg.clone()
+		next.Headers[HeaderAttempt] = strconv.Itoa(attempt)
+		next.Headers[HeaderError] = err.Error()
+		next.Headers[HeaderOriginalTopic] = topic
+
+		if attempt >= g.opts.maxAttempts {
+			messageCount.WithLabelValues(g.name, topic, resultDeadLetter).Inc()
+			g.opts.zapLogger.Error("[consumer] handle message failed, reach the maximum attempts", zap.Error(err),
+				zap.String("group", g.name), zap.String("topic", topic), zap.String("key", msg.Key), zap.Int("attempts", attempt))
+			delete(next.Headers, HeaderNotBefore)
+			return g.deadLetter(ctx, next, err)
+		}
+
+		messageCount.WithLabelValues(g.name, topic, resultRetry).Inc()
+		g.opts.zapLogger.Warn("[consumer] handle message error, retry later", zap.Error(err),
+			zap.String("group", g.name), zap.String("topic", topic), zap.String("key", msg.Key), zap.Int("attempts", attempt))
+		next.Headers[HeaderNotBefore] = strconv.FormatInt(time.Now().Add(g.backoff(attempt)).UnixMilli(), 10)
+
+		if g.opts.publisher == nil {
+			msg = next // retry in process
+			continue
+		}
+		if err = g.opts.publisher.Publish(ctx, g.opts.retryTopic, next); err != nil {
+			return fmt.Errorf("publish message to retry topic %s error: %w", g.opts.retryTopic, err)
+		}
+		return nil
+	}
+}
+
+func (g *Group) handle(ctx context.Context, msg *Message) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("panic: %v", e)
+		}
+	}()
+
+	if g.opts.handleTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.opts.handleTimeout)
+		defer cancel()
+	}
+	return g.handler(ctx, msg)
+}
+
+func (g *Group) deadLetter(ctx context.Context, msg *Message, handleErr error) error {
+	if g.opts.publisher != nil {
+		if err := g.opts.publisher.Publish(ctx, g.opts.deadLetterTopic, msg); err != nil {
+			return fmt.Errorf("publish message to dead-letter topic %s error: %w", g.opts.deadLetterTopic, err)
+		}
+	}
+	if g.opts.deadLetterHandler != nil {
+		if err := g.opts.deadLetterHandler(ctx, msg, handleErr); err != nil {
+			return errors.Join(errors.New("dead-letter handler error"), err)
+		}
+	}
+	return nil
+}
+
+func (g *Group) backoff(attempt int) time.Duration {
+	d := float64(g.opts.initialBackoff) * math.Pow(2, float64(attempt-1))
+	if d > float64(g.opts.maxBackoff) {
+		return g.opts.maxBackoff
+	}
+	return time.Duration(d)
+}
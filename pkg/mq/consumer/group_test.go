@@ -0,0 +1,157 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryPublisher struct {
+	mu       sync.Mutex
+	messages map[string][]*Message
+}
+
+func (p *memoryPublisher) Publish(ctx context.Context, topic string, msg *Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.messages == nil {
+		p.messages = make(map[string][]*Message)
+	}
+	p.messages[topic] = append(p.messages[topic], msg)
+	return nil
+}
+
+func (p *memoryPublisher) pop(topic string) *Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.messages[topic]) == 0 {
+		return nil
+	}
+	msg := p.messages[topic][0]
+	p.messages[topic] = p.messages[topic][1:]
+	return msg
+}
+
+func TestGroupRetryQueue(t *testing.T) {
+	var calls atomic.Int32
+	handler := func(ctx context.Context, msg *Message) error {
+		calls.Add(1)
+		return errors.New("db unavailable")
+	}
+	publisher := &memoryPublisher{}
+	g := NewGroup("order", handler,
+		WithPublisher(publisher),
+		WithMaxAttempts(3),
+		WithRetryBackoff(10*time.Millisecond, 20*time.Millisecond),
+	)
+	ctx := context.Background()
+
+	// the first delivery fails and is published to the retry queue
+	require.NoError(t, g.Process(ctx, &Message{Topic: "order.created", Key: "1", Body: []byte("foo")}))
+	retryMsg := publisher.pop("order.retry")
+	require.NotNil(t, retryMsg)
+	assert.Equal(t, 1, retryMsg.Attempt())
+	assert.Equal(t, "order.created", retryMsg.Headers[HeaderOriginalTopic])
+	assert.Equal(t, "db unavailable", retryMsg.Headers[HeaderError])
+	assert.True(t, retryMsg.NotBefore().After(time.Now()))
+
+	// consumed from the retry queue, handled after the backoff delay
+	retryMsg.Topic = "order.retry"
+	begin := time.Now()
+	require.NoError(t, g.Process(ctx, retryMsg))
+	assert.GreaterOrEqual(t, time.Since(begin), 5*time.Millisecond)
+	retryMsg = publisher.pop("order.retry")
+	require.NotNil(t, retryMsg)
+	assert.Equal(t, 2, retryMsg.Attempt())
+
+	// routed to the dead-letter queue after the maximum attempts
+	require.NoError(t, g.Process(ctx, retryMsg))
+	assert.Nil(t, publisher.pop("order.retry"))
+	dlqMsg := publisher.pop("order.dlq")
+	require.NotNil(t, dlqMsg)
+	assert.Equal(t, 3, dlqMsg.Attempt())
+	assert.Equal(t, []byte("foo"), dlqMsg.Body)
+	assert.Equal(t, int32(3), calls.Load())
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(messageCount.WithLabelValues("order", "order.created", resultRetry)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(messageCount.WithLabelValues("order", "order.created", resultDeadLetter)))
+}
+
+func TestGroupRetryInProcess(t *testing.T) {
+	var calls atomic.Int32
+	handler := func(ctx context.Context, msg *Message) error {
+		if calls.Add(1) == 1 {
+			panic("nil pointer")
+		}
+		if msg.Attempt() < 2 {
+			return errors.New("timeout")
+		}
+		return nil
+	}
+	g := NewGroup("user", handler, WithRetryBackoff(time.Millisecond, time.Millisecond))
+	require.NoError(t, g.Process(context.Background(), &Message{Topic: "user.created"}))
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Equal(t, float64(1), testutil.ToFloat64(messageCount.WithLabelValues("user", "user.created", resultSuccess)))
+
+	// dead-letter handler
+	var deadErr error
+	g = NewGroup("user2", func(ctx context.Context, msg *Message) error { return errors.New("invalid message") },
+		WithMaxAttempts(1),
+		WithDeadLetterHandler(func(ctx context.Context, msg *Message, err error) error {
+			deadErr = err
+			return nil
+		}),
+	)
+	require.NoError(t, g.Process(context.Background(), &Message{Topic: "user.created"}))
+	assert.EqualError(t, deadErr, "invalid message")
+}
+
+func TestGroupConcurrency(t *testing.T) {
+	var running, maxRunning atomic.Int32
+	handler := func(ctx context.Context, msg *Message) error {
+		n := running.Add(1)
+		for {
+			m := maxRunning.Load()
+			if n <= m || maxRunning.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		running.Add(-1)
+		return nil
+	}
+	g := NewGroup("concurrency", handler, WithConcurrency(3), WithHandleTimeout(time.Second))
+
+	var done atomic.Int32
+	for i := 0; i < 10; i++ {
+		err := g.Submit(context.Background(), &Message{Topic: "test"}, func(err error) {
+			assert.NoError(t, err)
+			done.Add(1)
+		})
+		require.NoError(t, err)
+	}
+	g.Wait()
+	assert.Equal(t, int32(10), done.Load())
+	assert.Equal(t, int32(3), maxRunning.Load())
+
+	g.SetLag("test", 5)
+	assert.Equal(t, float64(5), testutil.ToFloat64(consumerLag.WithLabelValues("concurrency", "test")))
+}
+
+func TestMarshal(t *testing.T) {
+	msg := &Message{Topic: "test", Key: "1", Body: []byte("foo"), Headers: map[string]string{HeaderAttempt: "2"}}
+	data, err := Marshal(msg)
+	require.NoError(t, err)
+	actual, err := Unmarshal(data)
+	require.NoError(t, err)
+	assert.Equal(t, msg, actual)
+	assert.Equal(t, 2, actual.Attempt())
+	assert.True(t, (&Message{}).NotBefore().IsZero())
+}
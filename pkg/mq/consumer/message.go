@@ -0,0 +1,85 @@
+// Package consumer provides a consumer group abstraction for message queues (e.g. kafka, rabbitmq), it supports
+// concurrency control, retries with backoff via retry queues, dead-letter routing after the maximum attempts,
+// and prometheus metrics.
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// The headers of messages used for retries and dead-letter routing.
+const (
+	// HeaderAttempt the number of failed attempts of the message
+	HeaderAttempt = "x-attempt"
+	// HeaderNotBefore the message is not handled before the time, unix milliseconds
+	HeaderNotBefore = "x-not-before"
+	// HeaderError the error of the last failed attempt
+	HeaderError = "x-error"
+	// HeaderOriginalTopic the topic where the message was first consumed
+	HeaderOriginalTopic = "x-original-topic"
+)
+
+// Message is a message consumed from a message queue.
+type Message struct {
+	Topic   string            `json:"topic"`
+	Key     string            `json:"key,omitempty"`
+	Body    []byte            `json:"body"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// Attempt returns the number of failed attempts of the message, it is 0 for the first delivery.
+func (m *Message) Attempt() int {
+	n, _ := strconv.Atoi(m.Headers[HeaderAttempt])
+	return n
+}
+
+// NotBefore returns the time before which the message should not be handled, zero means no limit.
+func (m *Message) NotBefore() time.Time {
+	ms, err := strconv.ParseInt(m.Headers[HeaderNotBefore], 10, 64)
+	if err != nil || ms <= 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
+// Marshal encodes the message with headers, it is used to publish messages to the retry and dead-letter
+// queues of brokers whose consumers only receive the message body, e.g. rabbitmq.Consumer.
+func Marshal(msg *Message) ([]byte, error) {
+	return json.Marshal(msg)
+}
+
+// Unmarshal decodes the message encoded by Marshal.
+func Unmarshal(data []byte) (*Message, error) {
+	msg := &Message{}
+	err := json.Unmarshal(data, msg)
+	return msg, err
+}
+
+func (m *Message) clone() *Message {
+	headers := make(map[string]string, len(m.Headers)+3)
+	for k, v := range m.Headers {
+		headers[k] = v
+	}
+	return &Message{Topic: m.Topic, Key: m.Key, Body: m.Body, Headers: headers}
+}
+
+// Handler handles a message, if an error is returned, the message is retried.
+type Handler func(ctx context.Context, msg *Message) error
+
+// Publisher publishes messages to retry and dead-letter queues, topic is the kafka topic or rabbitmq routing key,
+// the headers of msg must be kept, for brokers supporting delayed messages (e.g. rabbitmq delayed message exchange),
+// the delay can be calculated by msg.NotBefore().
+type Publisher interface {
+	Publish(ctx context.Context, topic string, msg *Message) error
+}
+
+// PublisherFunc is an adapter to use a function as Publisher.
+type PublisherFunc func(ctx context.Context, topic string, msg *Message) error
+
+// Publish calls f(ctx, topic, msg).
+func (f PublisherFunc) Publish(ctx context.Context, topic string, msg *Message) error {
+	return f(ctx, topic, msg)
+}
@@ -0,0 +1,57 @@
+package consumer
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// results of handling messages
+const (
+	resultSuccess    = "success"
+	resultRetry      = "retry"
+	resultDeadLetter = "dead_letter"
+)
+
+var (
+	messageCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "mq_consumer_messages_total",
+			Help: "Total number of handled messages, result is success, retry or dead_letter.",
+		},
+		[]string{"group", "topic", "result"},
+	)
+
+	handleDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "mq_consumer_handle_duration_seconds",
+			Help:    "Duration of handling a message in seconds.",
+			Buckets: []float64{.001, .005, .01, .05, .1, .5, 1, 5, 10, 30},
+		},
+		[]string{"group", "topic"},
+	)
+
+	inFlight = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "mq_consumer_in_flight",
+			Help: "Number of messages being handled.",
+		},
+		[]string{"group"},
+	)
+
+	consumerLag = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "mq_consumer_lag",
+			Help: "Number of messages not yet consumed, reported by Group.SetLag.",
+		},
+		[]string{"group", "topic"},
+	)
+
+	registerOnce sync.Once
+)
+
+func registerMetrics() {
+	registerOnce.Do(func() {
+		prometheus.MustRegister(messageCount, handleDuration, inFlight, consumerLag)
+	})
+}
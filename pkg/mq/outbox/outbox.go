@@ -0,0 +1,111 @@
+// Package outbox implements the transactional outbox pattern based on gorm, messages are written to the outbox table
+// in the same transaction as the business data, and published to message queues (e.g. rabbitmq, kafka) by the relay
+// worker with at-least-once semantics.
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/go-dev-frame/sponge/pkg/krand"
+)
+
+// TableName the name of the outbox table
+const TableName = "outbox_messages"
+
+// Status of outbox messages
+const (
+	// StatusPending the message is waiting to be published
+	StatusPending = 0
+	// StatusSent the message has been published
+	StatusSent = 1
+	// StatusFailed the message failed to be published after the maximum number of attempts
+	StatusFailed = 2
+)
+
+// Message is a message in the outbox table.
+type Message struct {
+	ID          uint64            `gorm:"column:id;AUTO_INCREMENT;primary_key" json:"id"`
+	Topic       string            `gorm:"column:topic;type:varchar(255);not null" json:"topic"`
+	DedupKey    string            `gorm:"column:dedup_key;type:varchar(128);uniqueIndex;not null" json:"dedupKey"`
+	Payload     []byte            `gorm:"column:payload" json:"payload"`
+	Headers     map[string]string `gorm:"column:headers;type:text;serializer:json" json:"headers"`
+	Status      int               `gorm:"column:status;not null;default:0;index:idx_outbox_status_next_retry_at,priority:1" json:"status"`
+	Attempts    int               `gorm:"column:attempts;not null;default:0" json:"attempts"`
+	NextRetryAt time.Time         `gorm:"column:next_retry_at;index:idx_outbox_status_next_retry_at,priority:2" json:"nextRetryAt"`
+	LastError   string            `gorm:"column:last_error;type:varchar(1024)" json:"lastError"`
+	CreatedAt   time.Time         `gorm:"column:created_at" json:"createdAt"`
+	SentAt      *time.Time        `gorm:"column:sent_at" json:"sentAt"`
+}
+
+// TableName get the table name of outbox messages
+func (m *Message) TableName() string {
+	return TableName
+}
+
+// NewMessage creates an outbox message, topic is the destination of the message, e.g. kafka topic or rabbitmq
+// routing key, dedupKey is the deduplication key, e.g. "order:1001:created", messages with the same key are saved
+// only once, and consumers can use it to process messages idempotently, if it is empty, a unique key is generated.
+func NewMessage(topic string, dedupKey string, payload []byte, headers ...map[string]string) *Message {
+	m := &Message{
+		Topic:    topic,
+		DedupKey: dedupKey,
+		Payload:  payload,
+	}
+	if len(headers) > 0 {
+		m.Headers = headers[0]
+	}
+	return m
+}
+
+// AutoMigrate creates or updates the outbox table.
+func AutoMigrate(db *gorm.DB) error {
+	return db.AutoMigrate(&Message{})
+}
+
+// Save writes messages to the outbox table, tx should be the transaction of the business data, so that the messages
+// are published if and only if the transaction is committed, messages whose dedup key already exists are ignored.
+//
+//	err := db.Transaction(func(tx *gorm.DB) error {
+//		if err := tx.Create(order).Error; err != nil {
+//			return err
+//		}
+//		return outbox.Save(tx, outbox.NewMessage("order.created", "order:"+orderID, data))
+//	})
+func Save(tx *gorm.DB, messages ...*Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	for _, m := range messages {
+		if m.DedupKey == "" {
+			m.DedupKey = krand.NewSeriesID()
+		}
+		m.Status = StatusPending
+		if m.NextRetryAt.IsZero() {
+			m.NextRetryAt = now
+		}
+	}
+
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "dedup_key"}},
+		DoNothing: true,
+	}).Create(&messages).Error
+}
+
+// Publisher publishes outbox messages to message queues.
+type Publisher interface {
+	Publish(ctx context.Context, msg *Message) error
+}
+
+// PublisherFunc is an adapter to use a function as Publisher.
+type PublisherFunc func(ctx context.Context, msg *Message) error
+
+// Publish calls f(ctx, msg).
+func (f PublisherFunc) Publish(ctx context.Context, msg *Message) error {
+	return f(ctx, msg)
+}
@@ -0,0 +1,145 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type order struct {
+	ID   uint64 `gorm:"primary_key"`
+	Name string
+}
+
+func newTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "outbox.db")),
+		&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, AutoMigrate(db))
+	require.NoError(t, db.AutoMigrate(&order{}))
+	return db
+}
+
+func TestSave(t *testing.T) {
+	db := newTestDB(t)
+
+	// committed with the business data
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&order{Name: "foo"}).Error; err != nil {
+			return err
+		}
+		return Save(tx,
+			NewMessage("order.created", "order:1", []byte("foo"), map[string]string{"traceID": "123"}),
+			NewMessage("order.created", "", []byte("bar")),
+		)
+	})
+	require.NoError(t, err)
+
+	// rolled back with the business data
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := Save(tx, NewMessage("order.created", "order:2", []byte("baz"))); err != nil {
+			return err
+		}
+		return errors.New("create order error")
+	})
+	assert.Error(t, err)
+
+	// duplicate dedup key is ignored
+	require.NoError(t, Save(db, NewMessage("order.created", "order:1", []byte("foo2"))))
+	assert.NoError(t, Save(db))
+
+	var messages []*Message
+	require.NoError(t, db.Order("id").Find(&messages).Error)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "order:1", messages[0].DedupKey)
+	assert.Equal(t, []byte("foo"), messages[0].Payload)
+	assert.Equal(t, map[string]string{"traceID": "123"}, messages[0].Headers)
+	assert.Equal(t, StatusPending, messages[0].Status)
+	assert.NotEmpty(t, messages[1].DedupKey)
+}
+
+func TestRelay(t *testing.T) {
+	db := newTestDB(t)
+	for _, key := range []string{"1", "2", "3"} {
+		require.NoError(t, Save(db, NewMessage("test", key, []byte(key))))
+	}
+
+	var mu sync.Mutex
+	var published []string
+	failures := map[string]int{"2": 1, "3": 100} // message 2 fails once, message 3 always fails
+	publisher := PublisherFunc(func(ctx context.Context, msg *Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if failures[msg.DedupKey] > 0 {
+			failures[msg.DedupKey]--
+			return errors.New("broker unavailable")
+		}
+		published = append(published, msg.DedupKey)
+		return nil
+	})
+
+	relay := NewRelay(db, publisher,
+		WithBatchSize(2),
+		WithPollInterval(10*time.Millisecond),
+		WithMaxAttempts(3),
+		WithRetryBackoff(time.Millisecond, 10*time.Millisecond),
+	)
+	relay.Start()
+	defer relay.Stop()
+
+	countStatus := func(status int) int64 {
+		var n int64
+		_ = db.Model(&Message{}).Where("status = ?", status).Count(&n).Error
+		return n
+	}
+	assert.Eventually(t, func() bool { return countStatus(StatusSent) == 2 && countStatus(StatusFailed) == 1 },
+		3*time.Second, 10*time.Millisecond)
+	mu.Lock()
+	assert.Equal(t, []string{"1", "2"}, published)
+	mu.Unlock()
+
+	var failed Message
+	require.NoError(t, db.Where("dedup_key = ?", "3").First(&failed).Error)
+	assert.Equal(t, 3, failed.Attempts)
+	assert.Equal(t, "broker unavailable", failed.LastError)
+
+	// republish the failed message
+	mu.Lock()
+	failures["3"] = 0
+	mu.Unlock()
+	require.NoError(t, Retry(context.Background(), db))
+	assert.Eventually(t, func() bool { return countStatus(StatusSent) == 3 }, 3*time.Second, 10*time.Millisecond)
+}
+
+func TestRelayRetention(t *testing.T) {
+	db := newTestDB(t)
+	require.NoError(t, Save(db, NewMessage("test", "1", []byte("1"))))
+	relay := NewRelay(db, PublisherFunc(func(ctx context.Context, msg *Message) error { return nil }),
+		WithRetention(time.Millisecond))
+
+	n, err := relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	time.Sleep(5 * time.Millisecond)
+	require.NoError(t, relay.cleanup(context.Background()))
+
+	var count int64
+	require.NoError(t, db.Model(&Message{}).Count(&count).Error)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestRelayBackoff(t *testing.T) {
+	relay := &Relay{opts: defaultRelayOptions()}
+	assert.Equal(t, time.Second, relay.backoff(1))
+	assert.Equal(t, 4*time.Second, relay.backoff(3))
+	assert.Equal(t, 5*time.Minute, relay.backoff(20))
+}
@@ -0,0 +1,273 @@
+package outbox
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RelayOption set the relay options.
+type RelayOption func(*relayOptions)
+
+type relayOptions struct {
+	batchSize      int
+	pollInterval   time.Duration
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	retention      time.Duration
+	zapLogger      *zap.Logger
+}
+
+func defaultRelayOptions() *relayOptions {
+	return &relayOptions{
+		batchSize:      100,
+		pollInterval:   time.Second,
+		maxAttempts:    10,
+		initialBackoff: time.Second,
+		maxBackoff:     5 * time.Minute,
+	}
+}
+
+func (o *relayOptions) apply(opts ...RelayOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WithBatchSize set the maximum number of messages published in a batch, default is 100
+func WithBatchSize(size int) RelayOption {
+	return func(o *relayOptions) {
+		if size > 0 {
+			o.batchSize = size
+		}
+	}
+}
+
+// WithPollInterval set the interval of polling the outbox table when there are no pending messages, default is 1s
+func WithPollInterval(d time.Duration) RelayOption {
+	return func(o *relayOptions) {
+		if d > 0 {
+			o.pollInterval = d
+		}
+	}
+}
+
+// WithMaxAttempts set the maximum number of publishing attempts, the message is marked as failed after
+// the maximum attempts, 0 means unlimited, default is 10
+func WithMaxAttempts(attempts int) RelayOption {
+	return func(o *relayOptions) {
+		o.maxAttempts = attempts
+	}
+}
+
+// WithRetryBackoff set the exponential backoff delay of republishing failed messages, default is 1s and 5m
+func WithRetryBackoff(initialBackoff time.Duration, maxBackoff time.Duration) RelayOption {
+	return func(o *relayOptions) {
+		if initialBackoff > 0 {
+			o.initialBackoff = initialBackoff
+		}
+		if maxBackoff > 0 {
+			o.maxBackoff = maxBackoff
+		}
+	}
+}
+
+// WithRetention set the retention period of sent messages, sent messages older than the period are deleted,
+// 0 means sent messages are kept, default is 0
+func WithRetention(d time.Duration) RelayOption {
+	return func(o *relayOptions) {
+		o.retention = d
+	}
+}
+
+// WithRelayLogger set the logger of relay
+func WithRelayLogger(l *zap.Logger) RelayOption {
+	return func(o *relayOptions) {
+		if l != nil {
+			o.zapLogger = l
+		}
+	}
+}
+
+// --------------------------------------------------------------------------------------
+
+// Relay polls the pending messages of the outbox table and publishes them by the publisher, a message is marked as
+// sent only after it is published successfully, so a message may be published more than once (e.g. the process
+// exits after publishing but before marking), consumers should process messages idempotently by the dedup key.
+// Multiple relays can run in different instances, the pending messages are locked by SELECT ... FOR UPDATE SKIP LOCKED
+// (mysql 8.0+, postgresql), the order of messages is not guaranteed when a message is republished.
+type Relay struct {
+	db         *gorm.DB
+	publisher  Publisher
+	skipLocked bool
+	opts       *relayOptions
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewRelay creates a relay worker of the outbox table, call Start to run it.
+func NewRelay(db *gorm.DB, publisher Publisher, opts ...RelayOption) *Relay {
+	o := defaultRelayOptions()
+	o.apply(opts...)
+	if o.zapLogger == nil {
+		o.zapLogger, _ = zap.NewProduction()
+	}
+
+	return &Relay{
+		db:         db,
+		publisher:  publisher,
+		skipLocked: db.Dialector.Name() != "sqlite", // sqlite locks the whole database in a transaction
+		opts:       o,
+	}
+}
+
+// Start runs the relay in the background until Stop is called.
+func (r *Relay) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.loop(ctx)
+	}()
+
+	r.opts.zapLogger.Info("[outbox] relay started", zap.Int("batchSize", r.opts.batchSize),
+		zap.String("pollInterval", r.opts.pollInterval.String()))
+}
+
+// Stop stops the relay and waits for the publishing batch to finish.
+func (r *Relay) Stop() {
+	if r.cancel != nil {
+		r.cancel()
+	}
+	r.wg.Wait()
+}
+
+func (r *Relay) loop(ctx context.Context) {
+	var lastCleanup time.Time
+	for {
+		n, err := r.RelayOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.opts.zapLogger.Warn("[outbox] relay messages error", zap.Error(err))
+		}
+
+		if r.opts.retention > 0 && time.Since(lastCleanup) > time.Minute {
+			lastCleanup = time.Now()
+			if err = r.cleanup(ctx); err != nil && ctx.Err() == nil {
+				r.opts.zapLogger.Warn("[outbox] delete sent messages error", zap.Error(err))
+			}
+		}
+
+		// there may be more pending messages, continue without waiting
+		if err == nil && n >= r.opts.batchSize {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.opts.pollInterval):
+		}
+	}
+}
+
+// RelayOnce publishes a batch of pending messages, returns the number of messages processed.
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	var count int
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Where("status = ? AND next_retry_at <= ?", StatusPending, time.Now()).
+			Order("id").Limit(r.opts.batchSize)
+		if r.skipLocked {
+			query = query.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+		}
+		var messages []*Message
+		if err := query.Find(&messages).Error; err != nil {
+			return err
+		}
+
+		for _, m := range messages {
+			if ctx.Err() != nil {
+				break // the messages not published are kept pending
+			}
+			count++
+			if err := r.publisher.Publish(ctx, m); err != nil {
+				if err = r.markRetry(tx, m, err); err != nil {
+					return err
+				}
+				continue
+			}
+			now := time.Now()
+			if err := tx.Model(m).Updates(map[string]interface{}{
+				"status":   StatusSent,
+				"attempts": m.Attempts + 1,
+				"sent_at":  &now,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return count, err
+}
+
+func (r *Relay) markRetry(tx *gorm.DB, m *Message, publishErr error) error {
+	attempts := m.Attempts + 1
+	status := StatusPending
+	if r.opts.maxAttempts > 0 && attempts >= r.opts.maxAttempts {
+		status = StatusFailed
+		r.opts.zapLogger.Error("[outbox] publish message failed, reach the maximum attempts", zap.Error(publishErr),
+			zap.Uint64("id", m.ID), zap.String("topic", m.Topic), zap.String("dedupKey", m.DedupKey), zap.Int("attempts", attempts))
+	} else {
+		r.opts.zapLogger.Warn("[outbox] publish message error, retry later", zap.Error(publishErr),
+			zap.Uint64("id", m.ID), zap.String("topic", m.Topic), zap.Int("attempts", attempts))
+	}
+
+	lastError := publishErr.Error()
+	if len(lastError) > 1024 {
+		lastError = lastError[:1024]
+	}
+	return tx.Model(m).Updates(map[string]interface{}{
+		"status":        status,
+		"attempts":      attempts,
+		"next_retry_at": time.Now().Add(r.backoff(attempts)),
+		"last_error":    lastError,
+	}).Error
+}
+
+func (r *Relay) backoff(attempts int) time.Duration {
+	d := float64(r.opts.initialBackoff) * math.Pow(2, float64(attempts-1))
+	if d > float64(r.opts.maxBackoff) {
+		return r.opts.maxBackoff
+	}
+	return time.Duration(d)
+}
+
+func (r *Relay) cleanup(ctx context.Context) error {
+	return r.db.WithContext(ctx).Where("status = ? AND sent_at < ?", StatusSent, time.Now().Add(-r.opts.retention)).
+		Delete(&Message{}).Error
+}
+
+// Retry resets the failed messages to pending, so that they are published again, e.g. after fixing the message
+// queue, if ids is empty, all failed messages are reset.
+func Retry(ctx context.Context, db *gorm.DB, ids ...uint64) error {
+	query := db.WithContext(ctx).Model(&Message{}).Where("status = ?", StatusFailed)
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	return query.Updates(map[string]interface{}{
+		"status":        StatusPending,
+		"attempts":      0,
+		"next_retry_at": time.Now(),
+	}).Error
+}
@@ -0,0 +1,175 @@
+package proxykit
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// ErrAccessDenied is returned when the client IP is rejected by the access rules of a route.
+var ErrAccessDenied = errors.New("access denied")
+
+// AccessConfig defines the IP based access rules of a route, the entries are CIDRs (10.0.0.0/8)
+// or single IPs (192.168.1.10), the rules are evaluated before selecting a backend.
+type AccessConfig struct {
+	Allow []string `yaml:"allow" json:"allow"` // if not empty, only the client IPs in the list are allowed
+	Deny  []string `yaml:"deny" json:"deny"`   // the client IPs in the list are denied, takes precedence over allow
+
+	// TrustedProxies are the proxies in front of the gateway, when the request comes from a trusted
+	// proxy, the client IP is the rightmost untrusted address of X-Forwarded-For, otherwise the
+	// X-Forwarded-For header is ignored, so that clients can not spoof their address.
+	TrustedProxies []string `yaml:"trustedProxies" json:"trustedProxies"`
+}
+
+// IsZero reports whether no access rule is configured.
+func (c AccessConfig) IsZero() bool {
+	return len(c.Allow) == 0 && len(c.Deny) == 0
+}
+
+// AccessControl checks the client IP of requests against the allow and deny lists.
+type AccessControl struct {
+	allow   []*net.IPNet
+	deny    []*net.IPNet
+	trusted []*net.IPNet
+}
+
+// NewAccessControl creates the access control of a route, return nil if no access rule is configured.
+func NewAccessControl(cfg AccessConfig) (*AccessControl, error) {
+	allow, err := parseCIDRs(cfg.Allow)
+	if err != nil {
+		return nil, fmt.Errorf("allow: %v", err)
+	}
+	deny, err := parseCIDRs(cfg.Deny)
+	if err != nil {
+		return nil, fmt.Errorf("deny: %v", err)
+	}
+	trusted, err := parseCIDRs(cfg.TrustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("trustedProxies: %v", err)
+	}
+	if cfg.IsZero() {
+		return nil, nil
+	}
+	return &AccessControl{allow: allow, deny: deny, trusted: trusted}, nil
+}
+
+// WithAccessControl set the IP allow and deny lists of the route, denied requests receive 403.
+func WithAccessControl(cfg AccessConfig) ProxyOption {
+	return func(o *proxyOptions) {
+		o.access = cfg
+	}
+}
+
+// ClientIP returns the IP of the client, X-Forwarded-For is only used when the request
+// comes from a trusted proxy, return nil if the address can not be parsed.
+func (a *AccessControl) ClientIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if a == nil || ip == nil || !containsIP(a.trusted, ip) {
+		return ip
+	}
+
+	// walk from right to left, the rightmost address not added by a trusted proxy is the client
+	var hops []string
+	for _, v := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(v, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			return ip // malformed header, fall back to the last valid address
+		}
+		ip = hop
+		if !containsIP(a.trusted, hop) {
+			break
+		}
+	}
+	return ip
+}
+
+// Check reports whether the request is allowed, if not, it returns the reason.
+func (a *AccessControl) Check(r *http.Request) (ip net.IP, reason string, ok bool) {
+	ip = a.ClientIP(r)
+	if a == nil {
+		return ip, "", true
+	}
+	if ip == nil {
+		return nil, "invalid client address", false
+	}
+	for _, n := range a.deny {
+		if n.Contains(ip) {
+			return ip, "matched deny rule " + n.String(), false
+		}
+	}
+	if len(a.allow) > 0 && !containsIP(a.allow, ip) {
+		return ip, "not in allow list", false
+	}
+	return ip, "", true
+}
+
+// allowRequest checks the request and writes 403 if it is denied.
+func (a *AccessControl) allowRequest(w http.ResponseWriter, r *http.Request, route string) bool {
+	if a == nil {
+		return true
+	}
+	ip, reason, ok := a.Check(r)
+	if ok {
+		return true
+	}
+
+	log.Warn("[Proxy] access denied",
+		zap.String("route", route),
+		zap.String("client_ip", ip.String()),
+		zap.String("remote_addr", r.RemoteAddr),
+		zap.String("method", r.Method),
+		zap.String("uri", r.RequestURI),
+		zap.String("reason", reason),
+	)
+	if isGRPCRequest(r) {
+		writeGRPCError(w, grpcCodePermissionDenied, ErrAccessDenied.Error())
+		return false
+	}
+	http.Error(w, ErrAccessDenied.Error(), http.StatusForbidden)
+	return false
+}
+
+func parseCIDRs(entries []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, e := range entries {
+		e = strings.TrimSpace(e)
+		if !strings.Contains(e, "/") {
+			ip := net.ParseIP(e)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP '%s'", e)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(e)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR '%s'", e)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package proxykit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestNewAccessControl(t *testing.T) {
+	t.Parallel()
+	if a, err := NewAccessControl(AccessConfig{TrustedProxies: []string{"10.0.0.1"}}); err != nil || a != nil {
+		t.Errorf("expected nil access control without rules, got %v %v", a, err)
+	}
+	invalid := []AccessConfig{
+		{Allow: []string{"10.0.0.0/33"}},
+		{Deny: []string{"not-an-ip"}},
+		{Allow: []string{"10.0.0.1"}, TrustedProxies: []string{"1.2.3"}},
+	}
+	for _, cfg := range invalid {
+		if _, err := NewAccessControl(cfg); err == nil {
+			t.Errorf("expected error for %+v", cfg)
+		}
+	}
+
+	cfg := &Config{Routes: []RouteConfig{{PrefixPath: "/api/", Access: AccessConfig{Deny: []string{"x"}}}}}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "access") {
+		t.Errorf("expected access validation error, got %v", err)
+	}
+}
+
+func TestAccessControl_Check(t *testing.T) {
+	t.Parallel()
+	a, err := NewAccessControl(AccessConfig{
+		Allow:          []string{"10.0.0.0/8", "192.168.1.10", "2001:db8::/32"},
+		Deny:           []string{"10.0.0.5"},
+		TrustedProxies: []string{"172.16.0.0/12"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		xff        []string
+		wantIP     string
+		wantOK     bool
+	}{
+		{"allowed cidr", "10.1.2.3:1234", nil, "10.1.2.3", true},
+		{"allowed single ip", "192.168.1.10:1234", nil, "192.168.1.10", true},
+		{"allowed ipv6", "[2001:db8::1]:1234", nil, "2001:db8::1", true},
+		{"deny takes precedence", "10.0.0.5:1234", nil, "10.0.0.5", false},
+		{"not in allow list", "8.8.8.8:1234", nil, "8.8.8.8", false},
+		{"spoofed header from untrusted client", "8.8.8.8:1234", []string{"10.1.2.3"}, "8.8.8.8", false},
+		{"header from trusted proxy", "172.16.0.1:1234", []string{"8.8.8.8, 10.1.2.3"}, "10.1.2.3", true},
+		{"skip trusted hops", "172.16.0.1:1234", []string{"10.1.2.3", "172.16.0.2"}, "10.1.2.3", true},
+		{"rightmost untrusted hop", "172.16.0.1:1234", []string{"10.1.2.3, 8.8.8.8"}, "8.8.8.8", false},
+		{"trusted proxy without header", "172.16.0.1:1234", nil, "172.16.0.1", false},
+		{"invalid remote addr", "invalid", nil, "<nil>", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = tt.remoteAddr
+		for _, v := range tt.xff {
+			r.Header.Add("X-Forwarded-For", v)
+		}
+		ip, reason, ok := a.Check(r)
+		if ip.String() != tt.wantIP || ok != tt.wantOK {
+			t.Errorf("%s: expected %s %v, got %s %v (%s)", tt.name, tt.wantIP, tt.wantOK, ip, ok, reason)
+		}
+		if !ok && reason == "" {
+			t.Errorf("%s: expected a reason for the denied request", tt.name)
+		}
+	}
+
+	// nil access control allows all
+	var none *AccessControl
+	if _, _, ok := none.Check(httptest.NewRequest(http.MethodGet, "/", nil)); !ok {
+		t.Error("expected nil access control to allow")
+	}
+}
+
+func TestProxy_AccessControl(t *testing.T) {
+	t.Parallel()
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer backend.Close()
+	backendURL, _ := url.Parse(backend.URL)
+
+	proxy, err := NewProxy(&mockBalancer{backend: NewBackend("", backendURL)},
+		WithAccessControl(AccessConfig{Allow: []string{"192.0.2.0/24"}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = NewProxy(&mockBalancer{}, WithAccessControl(AccessConfig{Allow: []string{"x"}})); err == nil {
+		t.Error("expected NewProxy to reject invalid access config")
+	}
+
+	// the remote address of httptest.NewRequest is 192.0.2.1
+	rr := httptest.NewRecorder()
+	proxy.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", rr.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "203.0.113.1:1234"
+	rr = httptest.NewRecorder()
+	proxy.ServeHTTP(rr, req)
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("expected 403, got %d", rr.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/svc.Greeter/Hello", nil)
+	req.RemoteAddr = "203.0.113.1:1234"
+	req.ProtoMajor = 2
+	req.Header.Set("Content-Type", "application/grpc")
+	rr = httptest.NewRecorder()
+	proxy.ServeHTTP(rr, req)
+	if rr.Header().Get("Grpc-Status") != "7" {
+		t.Errorf("expected grpc status 7, got '%s'", rr.Header().Get("Grpc-Status"))
+	}
+}
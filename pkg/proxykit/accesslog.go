@@ -0,0 +1,193 @@
+package proxykit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// access log formats supported.
+const (
+	AccessLogFormatJSON     = "json"
+	AccessLogFormatCombined = "combined" // apache combined log format followed by route, backend, latency and retries
+)
+
+// AccessLogConfig defines the access log settings in the configuration file, access logs are written to stdout.
+type AccessLogConfig struct {
+	Enable  bool    `yaml:"enable" json:"enable"`
+	Format  string  `yaml:"format" json:"format"`   // json or combined, default is json
+	Percent float64 `yaml:"percent" json:"percent"` // percentage of requests to log, 0~100, 0 means all requests are logged
+}
+
+// AccessLogEntry is a record of a proxied request.
+type AccessLogEntry struct {
+	Time       time.Time `json:"time"`
+	RemoteAddr string    `json:"remote_addr"`
+	Method     string    `json:"method"`
+	URI        string    `json:"uri"`
+	Proto      string    `json:"proto"`
+	Route      string    `json:"route"`
+	Backend    string    `json:"backend"`
+	Status     int       `json:"status"`
+	Bytes      int64     `json:"bytes"`
+	LatencyMs  float64   `json:"latency_ms"`
+	Retries    int       `json:"retries"` // number of times the request was re-sent upstream
+	Referer    string    `json:"referer"`
+	UserAgent  string    `json:"user_agent"`
+}
+
+// AccessLogOption set the access log options.
+type AccessLogOption func(*accessLogOptions)
+
+type accessLogOptions struct {
+	format  string
+	percent float64
+}
+
+func defaultAccessLogOptions() *accessLogOptions {
+	return &accessLogOptions{
+		format:  AccessLogFormatJSON,
+		percent: 100,
+	}
+}
+
+func (o *accessLogOptions) apply(opts ...AccessLogOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WithAccessLogFormat set the access log format, json or combined.
+func WithAccessLogFormat(format string) AccessLogOption {
+	return func(o *accessLogOptions) {
+		if format == AccessLogFormatJSON || format == AccessLogFormatCombined {
+			o.format = format
+		}
+	}
+}
+
+// WithAccessLogSampling set the percentage (0~100) of requests to log,
+// responses with status code 5xx are always logged.
+func WithAccessLogSampling(percent float64) AccessLogOption {
+	return func(o *accessLogOptions) {
+		if percent > 0 && percent <= 100 {
+			o.percent = percent
+		}
+	}
+}
+
+// AccessLogger writes an access log line for each sampled request, it is safe for concurrent use
+// and can be shared by multiple routes.
+type AccessLogger struct {
+	mu      sync.Mutex
+	w       io.Writer
+	format  string
+	percent float64
+}
+
+// NewAccessLogger creates an access logger writing to w.
+func NewAccessLogger(w io.Writer, opts ...AccessLogOption) *AccessLogger {
+	o := defaultAccessLogOptions()
+	o.apply(opts...)
+	return &AccessLogger{
+		w:       w,
+		format:  o.format,
+		percent: o.percent,
+	}
+}
+
+// WithAccessLog writes the access logs of the route requests to l.
+func WithAccessLog(l *AccessLogger) ProxyOption {
+	return func(o *proxyOptions) {
+		o.accessLog = l
+	}
+}
+
+func (l *AccessLogger) sampled(status int) bool {
+	if l.percent >= 100 || status >= http.StatusInternalServerError {
+		return true
+	}
+	return rand.Float64()*100 < l.percent //nolint
+}
+
+// Log writes the entry in the configured format.
+func (l *AccessLogger) Log(entry *AccessLogEntry) {
+	var line []byte
+	if l.format == AccessLogFormatCombined {
+		line = []byte(formatCombined(entry))
+	} else {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return
+		}
+		line = append(data, '\n')
+	}
+
+	l.mu.Lock()
+	_, _ = l.w.Write(line)
+	l.mu.Unlock()
+}
+
+// record builds and writes the entry of a proxied request if it is sampled.
+func (l *AccessLogger) record(r *http.Request, route string, backend string, rec *responseRecorder, start time.Time, retries int) {
+	if l == nil || !l.sampled(rec.status) {
+		return
+	}
+	l.Log(&AccessLogEntry{
+		Time:       start,
+		RemoteAddr: r.RemoteAddr,
+		Method:     r.Method,
+		URI:        r.RequestURI,
+		Proto:      r.Proto,
+		Route:      route,
+		Backend:    backend,
+		Status:     rec.status,
+		Bytes:      rec.bytes,
+		LatencyMs:  float64(time.Since(start).Microseconds()) / 1000,
+		Retries:    retries,
+		Referer:    r.Referer(),
+		UserAgent:  r.UserAgent(),
+	})
+}
+
+// formatCombined formats the entry as:
+// host - - [time] "method uri proto" status bytes "referer" "user-agent" "route" "backend" latency_ms retries
+func formatCombined(e *AccessLogEntry) string {
+	host, _, err := net.SplitHostPort(e.RemoteAddr)
+	if err != nil {
+		host = e.RemoteAddr
+	}
+	return fmt.Sprintf("%s - - [%s] \"%s %s %s\" %d %s %s %s %s %s %s %d\n",
+		dashIfEmpty(host),
+		e.Time.Format("02/Jan/2006:15:04:05 -0700"),
+		e.Method, e.URI, e.Proto,
+		e.Status,
+		dashIfEmpty(bytesString(e.Bytes)),
+		strconv.Quote(dashIfEmpty(e.Referer)),
+		strconv.Quote(dashIfEmpty(e.UserAgent)),
+		strconv.Quote(e.Route),
+		strconv.Quote(dashIfEmpty(e.Backend)),
+		strconv.FormatFloat(e.LatencyMs, 'f', 3, 64),
+		e.Retries,
+	)
+}
+
+func bytesString(n int64) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.FormatInt(n, 10)
+}
+
+func dashIfEmpty(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,116 @@
+package proxykit
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestProxy_AccessLog(t *testing.T) {
+	t.Parallel()
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer upstream.Close()
+	u, _ := url.Parse(upstream.URL)
+	backend := NewBackend("/api", u)
+
+	newRequest := func(path string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("User-Agent", "test-agent")
+		return req
+	}
+
+	t.Run("JSON", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		proxy, _ := NewProxy(&mockBalancer{backend: backend}, WithAccessLog(NewAccessLogger(buf)))
+		proxy.route = "/api/"
+		proxy.ServeHTTP(httptest.NewRecorder(), newRequest("/api/users?id=1"))
+
+		var entry AccessLogEntry
+		if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+			t.Fatalf("expected a json line, got '%s': %v", buf.String(), err)
+		}
+		if entry.Route != "/api/" || entry.Backend != upstream.URL || entry.Status != http.StatusOK ||
+			entry.Bytes != 5 || entry.URI != "/api/users?id=1" || entry.UserAgent != "test-agent" {
+			t.Errorf("unexpected entry: %+v", entry)
+		}
+	})
+
+	t.Run("Combined", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		proxy, _ := NewProxy(&mockBalancer{backend: backend},
+			WithAccessLog(NewAccessLogger(buf, WithAccessLogFormat(AccessLogFormatCombined))))
+		proxy.route = "/api/"
+		proxy.ServeHTTP(httptest.NewRecorder(), newRequest("/api/users"))
+
+		line := buf.String()
+		want := `"GET /api/users HTTP/1.1" 200 5 "-" "test-agent" "/api/" "` + upstream.URL + `"`
+		if !strings.HasPrefix(line, "192.0.2.1 - - [") || !strings.Contains(line, want) || !strings.HasSuffix(line, " 0\n") {
+			t.Errorf("unexpected combined line '%s'", line)
+		}
+	})
+
+	t.Run("Sampling", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		proxy, _ := NewProxy(&mockBalancer{backend: backend},
+			WithAccessLog(NewAccessLogger(buf, WithAccessLogSampling(0.0001))))
+		for i := 0; i < 20; i++ {
+			proxy.ServeHTTP(httptest.NewRecorder(), newRequest("/api/users"))
+		}
+		if n := strings.Count(buf.String(), "\n"); n > 1 {
+			t.Errorf("expected successful requests to be sampled, got %d lines", n)
+		}
+
+		// server errors are always logged
+		buf.Reset()
+		proxy, _ = NewProxy(&mockBalancer{err: ErrNoHealthyBackends},
+			WithAccessLog(NewAccessLogger(buf, WithAccessLogSampling(0.0001))))
+		proxy.ServeHTTP(httptest.NewRecorder(), newRequest("/api/users"))
+		if !strings.Contains(buf.String(), `"status":503`) {
+			t.Errorf("expected 503 to be logged, got '%s'", buf.String())
+		}
+	})
+}
+
+func TestApplyConfig_AccessLog(t *testing.T) {
+	t.Parallel()
+	m := NewRouteManager()
+	cfg := &Config{
+		AccessLog: AccessLogConfig{Enable: true, Format: AccessLogFormatCombined},
+		Routes: []RouteConfig{
+			{PrefixPath: "/a", Targets: []string{"http://localhost:18081"}},
+			{PrefixPath: "/b", Targets: []string{"http://localhost:18082"}, DisableAccessLog: true},
+		},
+	}
+	if err := m.ApplyConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	routeA, _ := m.GetRoute("/a/")
+	routeB, _ := m.GetRoute("/b/")
+	if routeA.Proxy.accessLog == nil || routeA.Proxy.accessLog.format != AccessLogFormatCombined {
+		t.Error("expected access log of route /a/ to be enabled")
+	}
+	if routeB.Proxy.accessLog != nil {
+		t.Error("expected access log of route /b/ to be disabled")
+	}
+
+	// changing the access log settings rebuilds the routes
+	cfg.AccessLog.Enable = false
+	if err := m.ApplyConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	routeA, _ = m.GetRoute("/a/")
+	if routeA.Proxy.accessLog != nil {
+		t.Error("expected access log of route /a/ to be disabled")
+	}
+
+	cfg.AccessLog = AccessLogConfig{Enable: true, Format: "xml"}
+	if err := m.ApplyConfig(cfg); err == nil {
+		t.Error("expected an error for unsupported format")
+	}
+}
@@ -0,0 +1,116 @@
+package proxykit
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultAffinityCookieName is the default name of the session affinity cookie.
+const DefaultAffinityCookieName = "X-Backend-Affinity"
+
+// StickySessionConfig defines the cookie based session affinity settings.
+type StickySessionConfig struct {
+	Enable     bool          `yaml:"enable" json:"enable"`
+	Secret     string        `yaml:"secret" json:"secret"`         // key used to sign the cookie, required
+	CookieName string        `yaml:"cookieName" json:"cookieName"` // default is X-Backend-Affinity
+	MaxAge     time.Duration `yaml:"maxAge" json:"maxAge"`         // default is session cookie
+	Path       string        `yaml:"path" json:"path"`             // default is /
+	Secure     bool          `yaml:"secure" json:"secure"`
+}
+
+// WithStickySession enables cookie based session affinity, the proxy sets a signed cookie on
+// the first response, subsequent requests with that cookie are routed to the same backend
+// while it stays healthy, otherwise the balancer picks a new backend and the cookie is replaced.
+func WithStickySession(cfg StickySessionConfig) ProxyOption {
+	return func(o *proxyOptions) {
+		cfg.Enable = true
+		o.sticky = &cfg
+	}
+}
+
+type affinity struct {
+	secret     []byte
+	cookieName string
+	maxAge     int
+	path       string
+	secure     bool
+}
+
+func newAffinity(cfg *StickySessionConfig) *affinity {
+	if cfg == nil || !cfg.Enable {
+		return nil
+	}
+	a := &affinity{
+		secret:     []byte(cfg.Secret),
+		cookieName: cfg.CookieName,
+		maxAge:     int(cfg.MaxAge.Seconds()),
+		path:       cfg.Path,
+		secure:     cfg.Secure,
+	}
+	if a.cookieName == "" {
+		a.cookieName = DefaultAffinityCookieName
+	}
+	if a.path == "" {
+		a.path = "/"
+	}
+	return a
+}
+
+// backendID returns an opaque identifier of the backend, so the backend address is not exposed.
+func backendID(b *Backend) string {
+	sum := sha256.Sum256([]byte(b.URL.String()))
+	return hex.EncodeToString(sum[:8])
+}
+
+func (a *affinity) sign(id string) string {
+	mac := hmac.New(sha256.New, a.secret)
+	mac.Write([]byte(id))
+	return id + "." + hex.EncodeToString(mac.Sum(nil))
+}
+
+// verify returns the backend id if the cookie value has a valid signature.
+func (a *affinity) verify(value string) (string, bool) {
+	id, _, ok := strings.Cut(value, ".")
+	if !ok || id == "" {
+		return "", false
+	}
+	if !hmac.Equal([]byte(value), []byte(a.sign(id))) {
+		return "", false
+	}
+	return id, true
+}
+
+// pick returns the available backend bound to the request cookie, or nil if there is none.
+func (a *affinity) pick(r *http.Request, balancer Balancer) *Backend {
+	c, err := r.Cookie(a.cookieName)
+	if err != nil {
+		return nil
+	}
+	id, ok := a.verify(c.Value)
+	if !ok {
+		return nil
+	}
+	for _, b := range balancer.GetBackends() {
+		if b.IsAvailable() && backendID(b) == id {
+			return b
+		}
+	}
+	return nil
+}
+
+// bind sets the affinity cookie for the selected backend on the response.
+func (a *affinity) bind(w http.ResponseWriter, b *Backend) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     a.cookieName,
+		Value:    a.sign(backendID(b)),
+		Path:     a.path,
+		MaxAge:   a.maxAge,
+		Secure:   a.secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
@@ -0,0 +1,100 @@
+package proxykit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestAffinity_SignVerify(t *testing.T) {
+	t.Parallel()
+	a := newAffinity(&StickySessionConfig{Enable: true, Secret: "secret"})
+	if a.cookieName != DefaultAffinityCookieName || a.path != "/" {
+		t.Errorf("unexpected defaults: %+v", a)
+	}
+
+	value := a.sign("abc")
+	if id, ok := a.verify(value); !ok || id != "abc" {
+		t.Errorf("expected valid signature, got id=%s ok=%v", id, ok)
+	}
+	for _, v := range []string{"", "abc", "abc.123", "xyz" + value[3:]} {
+		if _, ok := a.verify(v); ok {
+			t.Errorf("expected invalid signature for '%s'", v)
+		}
+	}
+
+	other := newAffinity(&StickySessionConfig{Enable: true, Secret: "other"})
+	if _, ok := other.verify(value); ok {
+		t.Error("expected signature from another secret to be invalid")
+	}
+
+	if newAffinity(nil) != nil || newAffinity(&StickySessionConfig{}) != nil {
+		t.Error("expected nil affinity when disabled")
+	}
+}
+
+func TestProxy_StickySession(t *testing.T) {
+	t.Parallel()
+
+	if _, err := NewProxy(NewRoundRobin(nil), WithStickySession(StickySessionConfig{})); err == nil {
+		t.Error("expected an error when secret is empty")
+	}
+
+	newServer := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(name))
+		}))
+	}
+	s1, s2 := newServer("s1"), newServer("s2")
+	defer s1.Close()
+	defer s2.Close()
+	u1, _ := url.Parse(s1.URL)
+	u2, _ := url.Parse(s2.URL)
+	b1, b2 := NewBackend("", u1), NewBackend("", u2)
+
+	proxy, err := NewProxy(NewRoundRobin([]*Backend{b1, b2}), WithStickySession(StickySessionConfig{Secret: "secret"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// first request sets the cookie
+	rr := httptest.NewRecorder()
+	proxy.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	first := rr.Body.String()
+	cookies := rr.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != DefaultAffinityCookieName {
+		t.Fatalf("expected affinity cookie, got %v", cookies)
+	}
+
+	// subsequent requests with the cookie go to the same backend
+	for i := 0; i < 4; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(cookies[0])
+		rr = httptest.NewRecorder()
+		proxy.ServeHTTP(rr, req)
+		if rr.Body.String() != first {
+			t.Fatalf("expected sticky backend '%s', got '%s'", first, rr.Body.String())
+		}
+		if len(rr.Result().Cookies()) != 0 {
+			t.Error("expected no new cookie for a bound request")
+		}
+	}
+
+	// the bound backend becomes unhealthy, the request is rebalanced and rebound
+	bound := b1
+	if first == "s2" {
+		bound = b2
+	}
+	bound.SetHealthy(false)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookies[0])
+	rr = httptest.NewRecorder()
+	proxy.ServeHTTP(rr, req)
+	if rr.Body.String() == first {
+		t.Error("expected request to be routed to another backend")
+	}
+	if len(rr.Result().Cookies()) != 1 {
+		t.Error("expected a new affinity cookie")
+	}
+}
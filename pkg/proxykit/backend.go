@@ -32,9 +32,13 @@ func DefaultTransport() *http.Transport {
 // Backend encapsulates the backend server information.
 type Backend struct {
 	URL             *url.URL
+	route           string // prefix path of the route, used as metrics label
 	isHealthy       atomic.Bool
+	isDraining      atomic.Bool
 	activeConns     atomic.Int64
+	limiter         atomic.Pointer[Limiter]
 	proxy           *httputil.ReverseProxy
+	isGRPC          bool          // proxy gRPC traffic over HTTP/2
 	stopHealthCheck chan struct{} // Used to stop the health check goroutine
 	stopOnce        sync.Once     // Ensures stop is called only once
 }
@@ -58,6 +62,7 @@ func NewBackend(prefixPath string, u *url.URL) *Backend {
 
 	// Use the optimized Transport
 	proxy.Transport = DefaultTransport()
+	proxy.ErrorHandler = handleUpstreamError(u.Host)
 
 	originalDirector := proxy.Director
 	proxy.Director = func(req *http.Request) {
@@ -69,11 +74,12 @@ func NewBackend(prefixPath string, u *url.URL) *Backend {
 
 	b := &Backend{
 		URL:             u,
+		route:           prefixPath,
 		proxy:           proxy,
 		stopHealthCheck: make(chan struct{}),
 	}
 
-	b.isHealthy.Store(true) // initialize as healthy by default
+	b.SetHealthy(true) // initialize as healthy by default
 	return b
 }
 
@@ -84,22 +90,54 @@ func (b *Backend) StopHealthCheck() {
 	})
 }
 
+// SetLimit sets the concurrency and rate caps for this backend, a zero config removes the limit.
+func (b *Backend) SetLimit(cfg LimitConfig) {
+	b.limiter.Store(NewLimiter(cfg))
+}
+
 func (b *Backend) SetHealthy(healthy bool) {
 	b.isHealthy.Store(healthy)
+	var v float64
+	if healthy {
+		v = 1
+	}
+	backendHealthy.WithLabelValues(b.route, b.URL.String()).Set(v)
 }
 
 func (b *Backend) IsHealthy() bool {
 	return b.isHealthy.Load()
 }
 
+// SetDraining marks the backend as draining, a draining backend is not selected for new requests,
+// and the in-flight requests are not affected.
+func (b *Backend) SetDraining(draining bool) {
+	b.isDraining.Store(draining)
+	var v float64
+	if draining {
+		v = 1
+	}
+	backendDraining.WithLabelValues(b.route, b.URL.String()).Set(v)
+}
+
+func (b *Backend) IsDraining() bool {
+	return b.isDraining.Load()
+}
+
+// IsAvailable reports whether the backend can be selected for new requests, it is healthy and not draining.
+func (b *Backend) IsAvailable() bool {
+	return b.IsHealthy() && !b.IsDraining()
+}
+
 func (b *Backend) GetActiveConns() int64 {
 	return b.activeConns.Load()
 }
 
 func (b *Backend) IncrementActiveConns() {
-	b.activeConns.Add(1)
+	n := b.activeConns.Add(1)
+	activeConnections.WithLabelValues(b.route, b.URL.String()).Set(float64(n))
 }
 
 func (b *Backend) DecrementActiveConns() {
-	b.activeConns.Add(-1)
+	n := b.activeConns.Add(-1)
+	activeConnections.WithLabelValues(b.route, b.URL.String()).Set(float64(n))
 }
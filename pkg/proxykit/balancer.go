@@ -49,7 +49,7 @@ func (r *RoundRobin) Next(_ *http.Request) (*Backend, error) {
 func (r *RoundRobin) getHealthy() []*Backend {
 	var healthy []*Backend
 	for _, b := range r.backends {
-		if b.IsHealthy() {
+		if b.IsAvailable() {
 			healthy = append(healthy, b)
 		}
 	}
@@ -104,7 +104,7 @@ func (lc *LeastConnections) Next(_ *http.Request) (*Backend, error) {
 	found := false
 
 	for _, b := range lc.backends {
-		if b.IsHealthy() {
+		if b.IsAvailable() {
 			found = true
 			conn := b.GetActiveConns()
 			if conn < minSize {
@@ -171,7 +171,7 @@ func (h *IPHash) Next(r *http.Request) (*Backend, error) {
 func (h *IPHash) getHealthy() []*Backend {
 	var healthy []*Backend
 	for _, b := range h.backends {
-		if b.IsHealthy() {
+		if b.IsAvailable() {
 			healthy = append(healthy, b)
 		}
 	}
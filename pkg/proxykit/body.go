@@ -0,0 +1,230 @@
+package proxykit
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrRequestBodyTooLarge is returned when the request body exceeds the maximum size of the route.
+	ErrRequestBodyTooLarge = errors.New("request body too large")
+	// ErrResponseBodyTooLarge is returned when the backend response body exceeds the maximum size of the route.
+	ErrResponseBodyTooLarge = errors.New("response body too large")
+)
+
+// BodyConfig defines the body size limits, buffering and slow client timeouts of a route,
+// a zero value means no limit, the request body is streamed to the backend, and the response
+// body is copied to the client through the default buffer.
+type BodyConfig struct {
+	MaxRequestBodySize  int64 `yaml:"maxRequestBodySize" json:"maxRequestBodySize"`   // requests with larger body are rejected with 413
+	MaxResponseBodySize int64 `yaml:"maxResponseBodySize" json:"maxResponseBodySize"` // responses with larger body are rejected with 502 or aborted
+
+	// BufferRequest reads the whole request body before forwarding, so that slow uploads do not
+	// hold backend connections, it requires MaxRequestBodySize to bound the memory usage.
+	BufferRequest bool `yaml:"bufferRequest" json:"bufferRequest"`
+	// Streaming flushes every chunk of the response body to the client immediately, it suits
+	// large downloads and server-sent events, it can not be used together with BufferRequest.
+	Streaming bool `yaml:"streaming" json:"streaming"`
+
+	ReadTimeout  time.Duration `yaml:"readTimeout" json:"readTimeout"`   // maximum duration of reading the request from client
+	WriteTimeout time.Duration `yaml:"writeTimeout" json:"writeTimeout"` // maximum duration of writing the response to client
+}
+
+// IsZero reports whether the config does not change the default behavior.
+func (c BodyConfig) IsZero() bool {
+	return c == BodyConfig{}
+}
+
+// Validate checks the body settings.
+func (c BodyConfig) Validate() error {
+	if c.MaxRequestBodySize < 0 || c.MaxResponseBodySize < 0 {
+		return errors.New("body size must not be negative")
+	}
+	if c.ReadTimeout < 0 || c.WriteTimeout < 0 {
+		return errors.New("body timeout must not be negative")
+	}
+	if c.BufferRequest && c.MaxRequestBodySize == 0 {
+		return errors.New("maxRequestBodySize must be specified when bufferRequest is enabled")
+	}
+	if c.BufferRequest && c.Streaming {
+		return errors.New("bufferRequest and streaming can not be enabled at the same time")
+	}
+	return nil
+}
+
+// WithBodyConfig set the body size limits, buffering and slow client timeouts of the route.
+func WithBodyConfig(cfg BodyConfig) ProxyOption {
+	return func(o *proxyOptions) {
+		o.body = cfg
+	}
+}
+
+// prepare applies the slow client timeouts and the request body limit, if it returns false,
+// the error response has been written to the client.
+func (c *BodyConfig) prepare(w http.ResponseWriter, r *http.Request) (*limitedBody, bool) {
+	if c == nil {
+		return nil, true
+	}
+
+	// the deadlines are set on the client connection, they are ignored if the writer does not support them
+	rc := http.NewResponseController(w)
+	now := time.Now()
+	if c.ReadTimeout > 0 {
+		_ = rc.SetReadDeadline(now.Add(c.ReadTimeout))
+	}
+	if c.WriteTimeout > 0 {
+		_ = rc.SetWriteDeadline(now.Add(c.WriteTimeout))
+	}
+
+	if c.MaxRequestBodySize <= 0 || r.Body == nil || r.Body == http.NoBody {
+		return nil, true
+	}
+	if r.ContentLength > c.MaxRequestBodySize {
+		writeBodyError(w, r, http.StatusRequestEntityTooLarge, ErrRequestBodyTooLarge)
+		return nil, false
+	}
+	body := &limitedBody{ReadCloser: r.Body, remaining: c.MaxRequestBodySize}
+	r.Body = body
+	if !c.BufferRequest {
+		return body, true
+	}
+
+	data, err := io.ReadAll(body)
+	_ = body.Close()
+	if err != nil {
+		if body.exceeded.Load() {
+			writeBodyError(w, r, http.StatusRequestEntityTooLarge, ErrRequestBodyTooLarge)
+		} else {
+			writeBodyError(w, r, http.StatusRequestTimeout, err)
+		}
+		return nil, false
+	}
+	r.Body = io.NopCloser(bytes.NewReader(data))
+	r.ContentLength = int64(len(data))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	return nil, true
+}
+
+// wrap returns the writer used to copy the backend response to the client.
+func (c *BodyConfig) wrap(w http.ResponseWriter, reqBody *limitedBody) http.ResponseWriter {
+	if c == nil || (c.MaxResponseBodySize <= 0 && !c.Streaming && reqBody == nil) {
+		return w
+	}
+	return &bodyWriter{ResponseWriter: w, maxSize: c.MaxResponseBodySize, streaming: c.Streaming, reqBody: reqBody}
+}
+
+// limitedBody returns an error when more than remaining bytes are read.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	exceeded  atomic.Bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, ErrRequestBodyTooLarge
+	}
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		b.exceeded.Store(true)
+		return n - 1, ErrRequestBodyTooLarge
+	}
+	return n, err
+}
+
+// bodyWriter limits the size of the response body, flushes every write in streaming mode,
+// and converts the bad gateway response caused by an oversized request body into 413.
+type bodyWriter struct {
+	http.ResponseWriter
+	maxSize   int64
+	streaming bool
+	reqBody   *limitedBody
+
+	written     int64
+	wroteHeader bool
+	aborted     bool
+}
+
+func (w *bodyWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	if code == http.StatusBadGateway && w.reqBody != nil && w.reqBody.exceeded.Load() {
+		w.writeError(http.StatusRequestEntityTooLarge, ErrRequestBodyTooLarge)
+		return
+	}
+	if w.maxSize > 0 {
+		if n, err := strconv.ParseInt(w.Header().Get("Content-Length"), 10, 64); err == nil && n > w.maxSize {
+			w.writeError(http.StatusBadGateway, ErrResponseBodyTooLarge)
+			return
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *bodyWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.aborted {
+		return 0, ErrResponseBodyTooLarge
+	}
+	if w.maxSize > 0 && w.written+int64(len(p)) > w.maxSize {
+		// the status has been sent, the only way to tell the client is aborting the connection
+		w.aborted = true
+		log.Printf("[Proxy] response body exceeds %d bytes, abort the response", w.maxSize)
+		return 0, ErrResponseBodyTooLarge
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.written += int64(n)
+	if err == nil && w.streaming {
+		err = http.NewResponseController(w.ResponseWriter).Flush()
+	}
+	return n, err
+}
+
+// writeError replaces the backend response with an error, the following writes of the backend body are rejected.
+func (w *bodyWriter) writeError(code int, err error) {
+	w.aborted = true
+	h := w.Header()
+	for k := range h {
+		delete(h, k)
+	}
+	msg := err.Error() + "\n"
+	h.Set("Content-Type", "text/plain; charset=utf-8")
+	h.Set("Content-Length", strconv.Itoa(len(msg)))
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.ResponseWriter.WriteHeader(code)
+	_, _ = io.WriteString(w.ResponseWriter, msg)
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap is used by http.ResponseController to access the underlying writer (e.g. Flush).
+func (w *bodyWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func writeBodyError(w http.ResponseWriter, r *http.Request, code int, err error) {
+	if isGRPCRequest(r) {
+		grpcCode := grpcCodeResourceExhausted
+		if code == http.StatusRequestTimeout {
+			grpcCode = grpcCodeDeadlineExceeded
+		}
+		writeGRPCError(w, grpcCode, err.Error())
+		return
+	}
+	http.Error(w, err.Error(), code)
+}
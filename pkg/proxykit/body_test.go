@@ -0,0 +1,249 @@
+package proxykit
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBodyConfig_Validate(t *testing.T) {
+	t.Parallel()
+	valid := []BodyConfig{
+		{},
+		{MaxRequestBodySize: 10, MaxResponseBodySize: 10, Streaming: true},
+		{MaxRequestBodySize: 10, BufferRequest: true, ReadTimeout: time.Second, WriteTimeout: time.Second},
+	}
+	for _, c := range valid {
+		if err := c.Validate(); err != nil {
+			t.Errorf("expected %+v to be valid, got %v", c, err)
+		}
+	}
+	invalid := []BodyConfig{
+		{MaxRequestBodySize: -1},
+		{MaxResponseBodySize: -1},
+		{ReadTimeout: -time.Second},
+		{BufferRequest: true},
+		{MaxRequestBodySize: 10, BufferRequest: true, Streaming: true},
+	}
+	for _, c := range invalid {
+		if err := c.Validate(); err == nil {
+			t.Errorf("expected %+v to be invalid", c)
+		}
+	}
+
+	if _, err := NewProxy(&mockBalancer{}, WithBodyConfig(BodyConfig{BufferRequest: true})); err == nil {
+		t.Error("expected NewProxy to reject invalid body config")
+	}
+	cfg := &Config{Routes: []RouteConfig{{PrefixPath: "/api/", Body: BodyConfig{MaxRequestBodySize: -1}}}}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "body") {
+		t.Errorf("expected body validation error, got %v", err)
+	}
+}
+
+func TestProxy_RequestBodyLimit(t *testing.T) {
+	t.Parallel()
+
+	var called atomic.Int32
+	var lastContentLength atomic.Int64
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called.Add(1)
+		lastContentLength.Store(r.ContentLength)
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	defer backend.Close()
+	backendURL, _ := url.Parse(backend.URL)
+
+	newRequest := func(body string, chunked bool) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader(body))
+		if chunked {
+			req.ContentLength = -1
+		}
+		return req
+	}
+
+	for _, buffer := range []bool{false, true} {
+		proxy, err := NewProxy(&mockBalancer{backend: NewBackend("", backendURL)},
+			WithBodyConfig(BodyConfig{MaxRequestBodySize: 5, BufferRequest: buffer}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		proxy.ServeHTTP(rr, newRequest("hello", true))
+		if rr.Code != http.StatusOK || rr.Body.String() != "hello" {
+			t.Errorf("buffer=%v: expected 200 'hello', got %d '%s'", buffer, rr.Code, rr.Body.String())
+		}
+		if buffer && lastContentLength.Load() != 5 {
+			t.Errorf("expected buffered request to have content length 5, got %d", lastContentLength.Load())
+		}
+
+		// the declared length exceeds the limit, the backend is not called
+		before := called.Load()
+		rr = httptest.NewRecorder()
+		proxy.ServeHTTP(rr, newRequest("hello world", false))
+		if rr.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("buffer=%v: expected 413, got %d", buffer, rr.Code)
+		}
+		if called.Load() != before {
+			t.Errorf("buffer=%v: expected backend not to be called", buffer)
+		}
+
+		// the length is unknown, the body is cut off when it exceeds the limit
+		rr = httptest.NewRecorder()
+		proxy.ServeHTTP(rr, newRequest("hello world", true))
+		if rr.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("buffer=%v: expected 413 for chunked body, got %d '%s'", buffer, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+func TestProxy_ResponseBodyLimit(t *testing.T) {
+	t.Parallel()
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := strings.Repeat("a", 100)
+		if r.URL.Query().Get("chunked") != "" {
+			// no content length, the size is only known while copying
+			for i := 0; i < 10; i++ {
+				_, _ = io.WriteString(w, body[:10])
+				w.(http.Flusher).Flush()
+			}
+			return
+		}
+		if r.URL.Query().Get("small") != "" {
+			body = "ok"
+		}
+		w.Header().Set("Content-Length", fmt.Sprint(len(body)))
+		_, _ = io.WriteString(w, body)
+	}))
+	defer backend.Close()
+	backendURL, _ := url.Parse(backend.URL)
+
+	proxy, _ := NewProxy(&mockBalancer{backend: NewBackend("", backendURL)},
+		WithBodyConfig(BodyConfig{MaxResponseBodySize: 50}))
+	server := httptest.NewServer(proxy)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/?small=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "ok" {
+		t.Errorf("expected 200 'ok', got %d '%s'", resp.StatusCode, body)
+	}
+
+	resp, err = http.Get(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway || !strings.Contains(string(body), ErrResponseBodyTooLarge.Error()) {
+		t.Errorf("expected 502 for declared oversized body, got %d '%s'", resp.StatusCode, body)
+	}
+
+	// the response is aborted when the copied size exceeds the limit
+	resp, err = http.Get(server.URL + "/?chunked=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err == nil {
+		t.Error("expected error reading the aborted response")
+	}
+	if len(body) > 50 {
+		t.Errorf("expected at most 50 bytes, got %d", len(body))
+	}
+}
+
+func TestProxy_Streaming(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "first\n")
+		w.(http.Flusher).Flush()
+		<-release
+		_, _ = io.WriteString(w, "second\n")
+	}))
+	defer backend.Close()
+	defer close(release)
+	backendURL, _ := url.Parse(backend.URL)
+
+	proxy, _ := NewProxy(&mockBalancer{backend: NewBackend("", backendURL)},
+		WithBodyConfig(BodyConfig{Streaming: true}))
+	server := httptest.NewServer(proxy)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	lineCh := make(chan string, 1)
+	go func() {
+		line, _ := bufio.NewReader(resp.Body).ReadString('\n')
+		lineCh <- line
+	}()
+	select {
+	case line := <-lineCh:
+		if line != "first\n" {
+			t.Errorf("expected 'first', got '%s'", line)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the first chunk to be flushed before the backend finishes")
+	}
+}
+
+func TestProxy_SlowClient(t *testing.T) {
+	t.Parallel()
+
+	var called atomic.Int32
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called.Add(1)
+	}))
+	defer backend.Close()
+	backendURL, _ := url.Parse(backend.URL)
+
+	proxy, _ := NewProxy(&mockBalancer{backend: NewBackend("", backendURL)},
+		WithBodyConfig(BodyConfig{MaxRequestBodySize: 100, BufferRequest: true, ReadTimeout: 100 * time.Millisecond}))
+	server := httptest.NewServer(proxy)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	// declare 10 bytes but only send 2 bytes, and never send the rest
+	_, _ = io.WriteString(conn, "POST / HTTP/1.1\r\nHost: example.com\r\nContent-Length: 10\r\n\r\nhi")
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestTimeout {
+		t.Errorf("expected 408, got %d", resp.StatusCode)
+	}
+	if called.Load() != 0 {
+		t.Error("expected the slow request not to reach the backend")
+	}
+}
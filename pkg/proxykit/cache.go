@@ -0,0 +1,588 @@
+package proxykit
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/go-dev-frame/sponge/pkg/goredis"
+)
+
+// cache stores supported in the configuration file.
+const (
+	CacheStoreMemory = "memory"
+	CacheStoreRedis  = "redis"
+)
+
+// CacheHeader is set on the responses of cacheable requests, the value is HIT, STALE or MISS.
+const CacheHeader = "X-Cache"
+
+// ErrCacheMiss is returned by the cache store when the key is not found or expired.
+var ErrCacheMiss = errors.New("cache miss")
+
+// CacheConfig defines the response cache of a route in the configuration file, only GET requests
+// are cached, and the Cache-Control directives of the request and the response are respected.
+type CacheConfig struct {
+	Enable   bool   `yaml:"enable" json:"enable"`
+	Store    string `yaml:"store" json:"store"`       // memory or redis, default is memory
+	RedisDsn string `yaml:"redisDsn" json:"redisDsn"` // e.g. default:123456@192.168.1.10:6379/0, required when store is redis
+
+	TTL                  time.Duration `yaml:"ttl" json:"ttl"`                                   // used when the response has no max-age, default is 60s
+	StaleWhileRevalidate time.Duration `yaml:"staleWhileRevalidate" json:"staleWhileRevalidate"` // serve the expired response while refreshing it in background
+	KeyHeaders           []string      `yaml:"keyHeaders" json:"keyHeaders"`                     // request headers added to the cache key besides path and query
+	MaxBodySize          int64         `yaml:"maxBodySize" json:"maxBodySize"`                   // responses with larger body are not cached, default is 1MB
+	MaxEntries           int           `yaml:"maxEntries" json:"maxEntries"`                     // capacity of the memory store, default is 10000
+}
+
+// Validate checks the cache settings.
+func (c CacheConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	switch c.Store {
+	case "", CacheStoreMemory:
+	case CacheStoreRedis:
+		if c.RedisDsn == "" {
+			return errors.New("redisDsn must be specified when store is redis")
+		}
+	default:
+		return fmt.Errorf("unsupported store '%s'", c.Store)
+	}
+	if c.TTL < 0 || c.StaleWhileRevalidate < 0 {
+		return errors.New("cache duration must not be negative")
+	}
+	if c.MaxBodySize < 0 || c.MaxEntries < 0 {
+		return errors.New("maxBodySize and maxEntries must not be negative")
+	}
+	return nil
+}
+
+// CacheEntry is a cached response.
+type CacheEntry struct {
+	Status     int         `json:"status"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	StoredAt   time.Time   `json:"storedAt"`
+	FreshUntil time.Time   `json:"freshUntil"` // the entry is served directly before this time
+	StaleUntil time.Time   `json:"staleUntil"` // the entry is served while being refreshed before this time
+}
+
+// CacheStore is the storage of the response cache.
+type CacheStore interface {
+	// Get returns the entry of the key, return ErrCacheMiss if it is not found.
+	Get(ctx context.Context, key string) (*CacheEntry, error)
+	// Set saves the entry, it is removed after ttl.
+	Set(ctx context.Context, key string, entry *CacheEntry, ttl time.Duration) error
+}
+
+// ------------------------------------------------------------------------------------------
+
+type memoryCacheItem struct {
+	entry    *CacheEntry
+	expireAt time.Time
+}
+
+type memoryCacheStore struct {
+	mu         sync.Mutex
+	items      map[string]memoryCacheItem
+	maxEntries int
+}
+
+// NewMemoryCacheStore creates an in-memory cache store, when the number of entries reaches
+// maxEntries, an expired entry or else a random entry is evicted, default is 10000.
+func NewMemoryCacheStore(maxEntries int) CacheStore {
+	if maxEntries <= 0 {
+		maxEntries = 10000
+	}
+	return &memoryCacheStore{items: make(map[string]memoryCacheItem), maxEntries: maxEntries}
+}
+
+func (s *memoryCacheStore) Get(_ context.Context, key string) (*CacheEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.items[key]
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	if time.Now().After(item.expireAt) {
+		delete(s.items, key)
+		return nil, ErrCacheMiss
+	}
+	return item.entry, nil
+}
+
+func (s *memoryCacheStore) Set(_ context.Context, key string, entry *CacheEntry, ttl time.Duration) error {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.items[key]; !ok && len(s.items) >= s.maxEntries {
+		var victim string
+		for k, item := range s.items {
+			victim = k
+			if now.After(item.expireAt) {
+				break
+			}
+		}
+		delete(s.items, victim)
+	}
+	s.items[key] = memoryCacheItem{entry: entry, expireAt: now.Add(ttl)}
+	return nil
+}
+
+type redisCacheStore struct {
+	client redis.UniversalClient
+}
+
+// NewRedisCacheStore creates a cache store backed by redis, the entries can be shared by gateway instances.
+func NewRedisCacheStore(client redis.UniversalClient) CacheStore {
+	return &redisCacheStore{client: client}
+}
+
+func (s *redisCacheStore) Get(ctx context.Context, key string) (*CacheEntry, error) {
+	data, err := s.client.Get(ctx, key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrCacheMiss
+		}
+		return nil, err
+	}
+	entry := &CacheEntry{}
+	if err = json.Unmarshal(data, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (s *redisCacheStore) Set(ctx context.Context, key string, entry *CacheEntry, ttl time.Duration) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, key, data, ttl).Err()
+}
+
+var (
+	cacheRedisMu      sync.Mutex
+	cacheRedisClients = make(map[string]*redis.Client)
+)
+
+// getCacheRedisClient returns the redis client of the dsn, routes with the same dsn share one client.
+func getCacheRedisClient(dsn string) (*redis.Client, error) {
+	cacheRedisMu.Lock()
+	defer cacheRedisMu.Unlock()
+	if client, ok := cacheRedisClients[dsn]; ok {
+		return client, nil
+	}
+	client, err := goredis.Init(dsn)
+	if err != nil {
+		return nil, err
+	}
+	cacheRedisClients[dsn] = client
+	return client, nil
+}
+
+// ------------------------------------------------------------------------------------------
+
+// CacheOption set the response cache options.
+type CacheOption func(*cacheOptions)
+
+type cacheOptions struct {
+	ttl         time.Duration
+	swr         time.Duration
+	keyHeaders  []string
+	maxBodySize int64
+	timeout     time.Duration
+}
+
+func defaultCacheOptions() *cacheOptions {
+	return &cacheOptions{
+		ttl:         time.Minute,
+		maxBodySize: 1 << 20,
+		timeout:     time.Second,
+	}
+}
+
+func (o *cacheOptions) apply(opts ...CacheOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WithCacheTTL set the fresh duration of responses without max-age or s-maxage.
+func WithCacheTTL(d time.Duration) CacheOption {
+	return func(o *cacheOptions) {
+		if d > 0 {
+			o.ttl = d
+		}
+	}
+}
+
+// WithCacheStaleWhileRevalidate set how long an expired response is still served while it is refreshed
+// in background, the stale-while-revalidate directive of the response takes precedence.
+func WithCacheStaleWhileRevalidate(d time.Duration) CacheOption {
+	return func(o *cacheOptions) {
+		if d > 0 {
+			o.swr = d
+		}
+	}
+}
+
+// WithCacheKeyHeaders set the request headers added to the cache key, e.g. Accept-Encoding, Accept-Language,
+// responses that vary on headers not in the key are not cached.
+func WithCacheKeyHeaders(headers ...string) CacheOption {
+	return func(o *cacheOptions) {
+		o.keyHeaders = headers
+	}
+}
+
+// WithCacheMaxBodySize set the maximum response body size to cache.
+func WithCacheMaxBodySize(size int64) CacheOption {
+	return func(o *cacheOptions) {
+		if size > 0 {
+			o.maxBodySize = size
+		}
+	}
+}
+
+// WithCacheStoreTimeout set the timeout of reading and writing the cache store, default is 1s.
+func WithCacheStoreTimeout(d time.Duration) CacheOption {
+	return func(o *cacheOptions) {
+		if d > 0 {
+			o.timeout = d
+		}
+	}
+}
+
+// ResponseCache caches the responses of GET requests, the cache key consists of the path, the sorted
+// query and the values of the key headers. A response is cached unless the request or the response
+// forbids it by Cache-Control, and its fresh duration is taken from s-maxage or max-age of the response.
+type ResponseCache struct {
+	store       CacheStore
+	ttl         time.Duration
+	swr         time.Duration
+	keyHeaders  []string
+	maxBodySize int64
+	timeout     time.Duration
+
+	refreshing sync.Map // keys being refreshed in background
+}
+
+// NewResponseCache creates a response cache on the store.
+func NewResponseCache(store CacheStore, opts ...CacheOption) (*ResponseCache, error) {
+	if store == nil {
+		return nil, errors.New("cache store cannot be nil")
+	}
+	o := defaultCacheOptions()
+	o.apply(opts...)
+
+	keyHeaders := make([]string, 0, len(o.keyHeaders))
+	for _, h := range o.keyHeaders {
+		keyHeaders = append(keyHeaders, http.CanonicalHeaderKey(strings.TrimSpace(h)))
+	}
+	sort.Strings(keyHeaders)
+
+	return &ResponseCache{
+		store:       store,
+		ttl:         o.ttl,
+		swr:         o.swr,
+		keyHeaders:  keyHeaders,
+		maxBodySize: o.maxBodySize,
+		timeout:     o.timeout,
+	}, nil
+}
+
+// NewResponseCacheFromConfig creates a response cache by the configuration, return nil if it is not enabled.
+func NewResponseCacheFromConfig(cfg CacheConfig) (*ResponseCache, error) {
+	if !cfg.Enable {
+		return nil, nil
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	var store CacheStore
+	if cfg.Store == CacheStoreRedis {
+		client, err := getCacheRedisClient(cfg.RedisDsn)
+		if err != nil {
+			return nil, fmt.Errorf("connect to redis error: %v", err)
+		}
+		store = NewRedisCacheStore(client)
+	} else {
+		store = NewMemoryCacheStore(cfg.MaxEntries)
+	}
+	return NewResponseCache(store,
+		WithCacheTTL(cfg.TTL),
+		WithCacheStaleWhileRevalidate(cfg.StaleWhileRevalidate),
+		WithCacheKeyHeaders(cfg.KeyHeaders...),
+		WithCacheMaxBodySize(cfg.MaxBodySize),
+	)
+}
+
+// WithResponseCache caches the responses of the route GET requests.
+func WithResponseCache(c *ResponseCache) ProxyOption {
+	return func(o *proxyOptions) {
+		o.cache = c
+	}
+}
+
+type cacheRefreshKey struct{}
+
+// serve writes the cached response of the request, if it returns true, the request is done. Otherwise,
+// the returned writer (nil if the request is not cacheable) captures the backend response for saving.
+func (c *ResponseCache) serve(w http.ResponseWriter, r *http.Request, p *Proxy) (*cacheWriter, bool) {
+	if c == nil || r.Method != http.MethodGet {
+		return nil, false
+	}
+	reqCC := parseCacheControl(r.Header.Get("Cache-Control"))
+	if _, ok := reqCC["no-store"]; ok || (r.Header.Get("Authorization") != "" && !c.isKeyHeader("Authorization")) {
+		cacheRequests.WithLabelValues(p.route, "bypass").Inc()
+		return nil, false
+	}
+
+	key := c.key(r)
+	_, noCache := reqCC["no-cache"]
+	isRefresh := r.Context().Value(cacheRefreshKey{}) != nil
+	if !noCache && !isRefresh && reqCC["max-age"] != "0" {
+		ctx, cancel := context.WithTimeout(r.Context(), c.timeout)
+		entry, err := c.store.Get(ctx, key)
+		cancel()
+		if err != nil && !errors.Is(err, ErrCacheMiss) {
+			log.Printf("[Proxy] get cache of route '%s' error: %v", p.route, err)
+		}
+		now := time.Now()
+		if entry != nil && now.Before(entry.StaleUntil) {
+			result := "HIT"
+			if !now.Before(entry.FreshUntil) {
+				result = "STALE"
+				c.refresh(r, key, p)
+			}
+			cacheRequests.WithLabelValues(p.route, strings.ToLower(result)).Inc()
+			writeCacheEntry(w, entry, result, now)
+			return nil, true
+		}
+	}
+
+	if !isRefresh {
+		cacheRequests.WithLabelValues(p.route, "miss").Inc()
+		w.Header().Set(CacheHeader, "MISS")
+	}
+	return &cacheWriter{cache: c, key: key, route: p.route}, false
+}
+
+// refresh sends the request to the backend in background and saves the response,
+// only one refresh of the same key is in flight.
+func (c *ResponseCache) refresh(r *http.Request, key string, p *Proxy) {
+	if _, loaded := c.refreshing.LoadOrStore(key, struct{}{}); loaded {
+		return
+	}
+	ctx := context.WithValue(context.WithoutCancel(r.Context()), cacheRefreshKey{}, true)
+	req := r.Clone(ctx)
+	req.Body = http.NoBody
+	go func() {
+		defer c.refreshing.Delete(key)
+		p.ServeHTTP(discardResponseWriter{header: make(http.Header)}, req)
+	}()
+}
+
+func (c *ResponseCache) isKeyHeader(name string) bool {
+	i := sort.SearchStrings(c.keyHeaders, name)
+	return i < len(c.keyHeaders) && c.keyHeaders[i] == name
+}
+
+func (c *ResponseCache) key(r *http.Request) string {
+	h := sha256.New()
+	h.Write([]byte(r.Host))
+	h.Write([]byte{0})
+	h.Write([]byte(r.URL.Path))
+	h.Write([]byte{0})
+	h.Write([]byte(sortedQuery(r.URL.RawQuery)))
+	for _, name := range c.keyHeaders {
+		h.Write([]byte{0})
+		h.Write([]byte(name + ":" + strings.Join(r.Header.Values(name), ",")))
+	}
+	return "proxykit:cache:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// save stores the response if it is cacheable.
+func (c *ResponseCache) save(cw *cacheWriter) {
+	if cw.failed || !cacheableStatus(cw.status) {
+		return
+	}
+	h := cw.header
+	respCC := parseCacheControl(strings.Join(h.Values("Cache-Control"), ","))
+	for _, d := range []string{"no-store", "no-cache", "private"} {
+		if _, ok := respCC[d]; ok {
+			return
+		}
+	}
+	if len(h.Values("Set-Cookie")) > 0 {
+		return
+	}
+	for _, v := range h.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			if name == "*" || (name != "" && !c.isKeyHeader(name)) {
+				return
+			}
+		}
+	}
+
+	ttl := c.ttl
+	if v, ok := respCC["s-maxage"]; ok {
+		ttl = parseSeconds(v)
+	} else if v, ok := respCC["max-age"]; ok {
+		ttl = parseSeconds(v)
+	}
+	if ttl <= 0 {
+		return
+	}
+	swr := c.swr
+	if v, ok := respCC["stale-while-revalidate"]; ok {
+		swr = parseSeconds(v)
+	}
+
+	h.Del(CacheHeader)
+	now := time.Now()
+	entry := &CacheEntry{
+		Status:     cw.status,
+		Header:     h,
+		Body:       cw.body.Bytes(),
+		StoredAt:   now,
+		FreshUntil: now.Add(ttl),
+		StaleUntil: now.Add(ttl + swr),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	if err := c.store.Set(ctx, cw.key, entry, ttl+swr); err != nil {
+		log.Printf("[Proxy] save cache of route '%s' error: %v", cw.route, err)
+	}
+}
+
+// cacheWriter copies the backend response to the client and keeps a copy for the cache.
+type cacheWriter struct {
+	http.ResponseWriter
+	cache *ResponseCache
+	key   string
+	route string
+
+	status      int
+	header      http.Header
+	body        bytes.Buffer
+	wroteHeader bool
+	failed      bool // the body is too large or not completely written
+}
+
+// wrap sets the writer of the client, it returns w if the request is not cacheable.
+func (cw *cacheWriter) wrap(w http.ResponseWriter) http.ResponseWriter {
+	if cw == nil {
+		return w
+	}
+	cw.ResponseWriter = w
+	return cw
+}
+
+func (cw *cacheWriter) WriteHeader(code int) {
+	if !cw.wroteHeader && code >= 200 {
+		cw.wroteHeader = true
+		cw.status = code
+		cw.header = cw.Header().Clone()
+	}
+	cw.ResponseWriter.WriteHeader(code)
+}
+
+func (cw *cacheWriter) Write(p []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	n, err := cw.ResponseWriter.Write(p)
+	if err != nil || n < len(p) {
+		cw.failed = true
+	}
+	if !cw.failed {
+		if int64(cw.body.Len()+n) > cw.cache.maxBodySize {
+			cw.failed = true
+			cw.body = bytes.Buffer{}
+		} else {
+			cw.body.Write(p[:n])
+		}
+	}
+	return n, err
+}
+
+// Unwrap is used by http.ResponseController to access the underlying writer (e.g. Flush).
+func (cw *cacheWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// save stores the captured response, it does nothing if the request is not cacheable.
+func (cw *cacheWriter) save() {
+	if cw == nil || !cw.wroteHeader {
+		return
+	}
+	cw.cache.save(cw)
+}
+
+func writeCacheEntry(w http.ResponseWriter, entry *CacheEntry, result string, now time.Time) {
+	h := w.Header()
+	for k, v := range entry.Header {
+		h[k] = append([]string(nil), v...)
+	}
+	h.Set(CacheHeader, result)
+	h.Set("Age", strconv.Itoa(int(now.Sub(entry.StoredAt).Seconds())))
+	w.WriteHeader(entry.Status)
+	_, _ = w.Write(entry.Body)
+}
+
+func cacheableStatus(code int) bool {
+	switch code {
+	case http.StatusOK, http.StatusNonAuthoritativeInfo, http.StatusNoContent,
+		http.StatusMovedPermanently, http.StatusNotFound, http.StatusGone:
+		return true
+	}
+	return false
+}
+
+// parseCacheControl returns the directives of Cache-Control, the names are in lower case.
+func parseCacheControl(v string) map[string]string {
+	directives := make(map[string]string)
+	for _, part := range strings.Split(v, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(part, "=")
+		directives[strings.ToLower(strings.TrimSpace(name))] = strings.Trim(strings.TrimSpace(value), `"`)
+	}
+	return directives
+}
+
+func parseSeconds(v string) time.Duration {
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return time.Duration(n) * time.Second
+}
+
+func sortedQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return rawQuery
+	}
+	return values.Encode() // Encode sorts by key
+}
@@ -0,0 +1,309 @@
+package proxykit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestCacheConfig_Validate(t *testing.T) {
+	t.Parallel()
+	valid := []CacheConfig{
+		{},
+		{Enable: true},
+		{Enable: true, Store: CacheStoreRedis, RedisDsn: "localhost:6379", TTL: time.Second},
+		{Store: "unknown"}, // not enabled
+	}
+	for _, c := range valid {
+		if err := c.Validate(); err != nil {
+			t.Errorf("expected %+v to be valid, got %v", c, err)
+		}
+	}
+	invalid := []CacheConfig{
+		{Enable: true, Store: "unknown"},
+		{Enable: true, Store: CacheStoreRedis},
+		{Enable: true, TTL: -time.Second},
+		{Enable: true, MaxEntries: -1},
+	}
+	for _, c := range invalid {
+		if err := c.Validate(); err == nil {
+			t.Errorf("expected %+v to be invalid", c)
+		}
+	}
+
+	cfg := &Config{Routes: []RouteConfig{{PrefixPath: "/api/", Cache: CacheConfig{Enable: true, Store: "x"}}}}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "cache") {
+		t.Errorf("expected cache validation error, got %v", err)
+	}
+	if c, err := NewResponseCacheFromConfig(CacheConfig{}); c != nil || err != nil {
+		t.Errorf("expected nil cache when not enabled, got %v %v", c, err)
+	}
+	if _, err := NewResponseCache(nil); err == nil {
+		t.Error("expected error for nil store")
+	}
+}
+
+func testCacheStore(t *testing.T, store CacheStore) {
+	ctx := context.Background()
+	if _, err := store.Get(ctx, "k"); !errors.Is(err, ErrCacheMiss) {
+		t.Fatalf("expected ErrCacheMiss, got %v", err)
+	}
+	entry := &CacheEntry{Status: 200, Header: http.Header{"Content-Type": {"text/plain"}}, Body: []byte("hello"), StoredAt: time.Now()}
+	if err := store.Set(ctx, "k", entry, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.Get(ctx, "k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != 200 || string(got.Body) != "hello" || got.Header.Get("Content-Type") != "text/plain" {
+		t.Errorf("unexpected entry %+v", got)
+	}
+}
+
+func TestMemoryCacheStore(t *testing.T) {
+	t.Parallel()
+	store := NewMemoryCacheStore(2)
+	testCacheStore(t, store)
+
+	ctx := context.Background()
+	_ = store.Set(ctx, "expired", &CacheEntry{}, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if _, err := store.Get(ctx, "expired"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("expected expired entry to be missed, got %v", err)
+	}
+
+	_ = store.Set(ctx, "a", &CacheEntry{}, time.Minute)
+	_ = store.Set(ctx, "b", &CacheEntry{}, time.Minute)
+	if n := len(store.(*memoryCacheStore).items); n != 2 {
+		t.Errorf("expected 2 entries after eviction, got %d", n)
+	}
+}
+
+func TestRedisCacheStore(t *testing.T) {
+	t.Parallel()
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	testCacheStore(t, NewRedisCacheStore(client))
+	if ttl := mr.TTL("k"); ttl != time.Minute {
+		t.Errorf("expected ttl 1m, got %v", ttl)
+	}
+
+	c, err := NewResponseCacheFromConfig(CacheConfig{Enable: true, Store: CacheStoreRedis, RedisDsn: mr.Addr()})
+	if err != nil || c == nil {
+		t.Fatalf("expected redis response cache, got %v", err)
+	}
+	c2, _ := NewResponseCacheFromConfig(CacheConfig{Enable: true, Store: CacheStoreRedis, RedisDsn: mr.Addr()})
+	if c.store.(*redisCacheStore).client != c2.store.(*redisCacheStore).client {
+		t.Error("expected routes with the same dsn to share the redis client")
+	}
+}
+
+func TestParseCacheControl(t *testing.T) {
+	t.Parallel()
+	cc := parseCacheControl(`public, Max-Age=60, s-maxage="120", no-transform`)
+	if cc["max-age"] != "60" || cc["s-maxage"] != "120" {
+		t.Errorf("unexpected directives %v", cc)
+	}
+	if _, ok := cc["no-transform"]; !ok {
+		t.Errorf("expected no-transform directive, got %v", cc)
+	}
+	if parseSeconds("x") != 0 || parseSeconds("-1") != 0 || parseSeconds("5") != 5*time.Second {
+		t.Error("unexpected parseSeconds result")
+	}
+	if sortedQuery("b=2&a=1") != sortedQuery("a=1&b=2") {
+		t.Error("expected the query order not to change the key")
+	}
+}
+
+func newCacheTestProxy(t *testing.T, handler http.HandlerFunc, opts ...CacheOption) *Proxy {
+	backend := httptest.NewServer(handler)
+	t.Cleanup(backend.Close)
+	backendURL, _ := url.Parse(backend.URL)
+	cache, err := NewResponseCache(NewMemoryCacheStore(0), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy, err := NewProxy(&mockBalancer{backend: NewBackend("", backendURL)}, WithResponseCache(cache))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return proxy
+}
+
+func doCacheRequest(proxy http.Handler, method string, target string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rr := httptest.NewRecorder()
+	proxy.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestProxy_ResponseCache(t *testing.T) {
+	t.Parallel()
+
+	var called atomic.Int32
+	proxy := newCacheTestProxy(t, func(w http.ResponseWriter, r *http.Request) {
+		n := called.Add(1)
+		switch r.URL.Path {
+		case "/nostore":
+			w.Header().Set("Cache-Control", "no-store")
+		case "/cookie":
+			w.Header().Set("Set-Cookie", "a=b")
+		case "/error":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/vary":
+			w.Header().Set("Vary", "Accept-Language")
+		}
+		_, _ = fmt.Fprintf(w, "%s %d", r.Header.Get("Accept-Language"), n)
+	}, WithCacheKeyHeaders("accept-language"))
+
+	rr := doCacheRequest(proxy, http.MethodGet, "/api?b=2&a=1", nil)
+	if rr.Header().Get(CacheHeader) != "MISS" || rr.Body.String() != " 1" {
+		t.Fatalf("expected MISS ' 1', got %s '%s'", rr.Header().Get(CacheHeader), rr.Body.String())
+	}
+	rr = doCacheRequest(proxy, http.MethodGet, "/api?a=1&b=2", nil)
+	if rr.Header().Get(CacheHeader) != "HIT" || rr.Body.String() != " 1" || rr.Header().Get("Age") == "" {
+		t.Errorf("expected HIT ' 1' with Age, got %s '%s'", rr.Header().Get(CacheHeader), rr.Body.String())
+	}
+	if called.Load() != 1 {
+		t.Errorf("expected backend to be called once, got %d", called.Load())
+	}
+
+	// the key headers are part of the key, and responses varying on them are cached
+	for i := 0; i < 2; i++ {
+		rr = doCacheRequest(proxy, http.MethodGet, "/vary", http.Header{"Accept-Language": {"en"}})
+	}
+	if rr.Header().Get(CacheHeader) != "HIT" || rr.Body.String() != "en 2" {
+		t.Errorf("expected HIT 'en 2', got %s '%s'", rr.Header().Get(CacheHeader), rr.Body.String())
+	}
+	rr = doCacheRequest(proxy, http.MethodGet, "/vary", http.Header{"Accept-Language": {"fr"}})
+	if rr.Header().Get(CacheHeader) != "MISS" || rr.Body.String() != "fr 3" {
+		t.Errorf("expected MISS 'fr 3', got %s '%s'", rr.Header().Get(CacheHeader), rr.Body.String())
+	}
+
+	// request directives
+	before := called.Load()
+	rr = doCacheRequest(proxy, http.MethodGet, "/api?a=1&b=2", http.Header{"Cache-Control": {"no-cache"}})
+	if rr.Header().Get(CacheHeader) != "MISS" || called.Load() != before+1 {
+		t.Errorf("expected no-cache request to reach the backend, got %s", rr.Header().Get(CacheHeader))
+	}
+	rr = doCacheRequest(proxy, http.MethodGet, "/api?a=1&b=2", nil)
+	if rr.Body.String() != fmt.Sprintf(" %d", before+1) {
+		t.Errorf("expected the no-cache request to update the entry, got '%s'", rr.Body.String())
+	}
+	for _, h := range []http.Header{{"Cache-Control": {"no-store"}}, {"Authorization": {"Bearer x"}}} {
+		rr = doCacheRequest(proxy, http.MethodGet, "/api?a=1&b=2", h)
+		if rr.Header().Get(CacheHeader) != "" {
+			t.Errorf("expected %v to bypass the cache, got %s", h, rr.Header().Get(CacheHeader))
+		}
+	}
+
+	// responses which can not be cached
+	for _, path := range []string{"/nostore", "/cookie", "/error"} {
+		before = called.Load()
+		doCacheRequest(proxy, http.MethodGet, path, nil)
+		rr = doCacheRequest(proxy, http.MethodGet, path, nil)
+		if rr.Header().Get(CacheHeader) != "MISS" || called.Load() != before+2 {
+			t.Errorf("%s: expected the response not to be cached", path)
+		}
+	}
+
+	// only GET requests are cached
+	before = called.Load()
+	doCacheRequest(proxy, http.MethodPost, "/api?a=1&b=2", nil)
+	if called.Load() != before+1 {
+		t.Error("expected POST request to reach the backend")
+	}
+}
+
+func TestProxy_ResponseCacheVary(t *testing.T) {
+	t.Parallel()
+	var called atomic.Int32
+	proxy := newCacheTestProxy(t, func(w http.ResponseWriter, r *http.Request) {
+		called.Add(1)
+		w.Header().Set("Vary", "Accept-Encoding")
+	})
+	doCacheRequest(proxy, http.MethodGet, "/", nil)
+	doCacheRequest(proxy, http.MethodGet, "/", nil)
+	if called.Load() != 2 {
+		t.Errorf("expected response varying on a header not in the key not to be cached, got %d calls", called.Load())
+	}
+}
+
+func TestProxy_ResponseCacheMaxBodySize(t *testing.T) {
+	t.Parallel()
+	var called atomic.Int32
+	proxy := newCacheTestProxy(t, func(w http.ResponseWriter, r *http.Request) {
+		called.Add(1)
+		_, _ = w.Write([]byte(strings.Repeat("a", 20)))
+	}, WithCacheMaxBodySize(10))
+	doCacheRequest(proxy, http.MethodGet, "/", nil)
+	rr := doCacheRequest(proxy, http.MethodGet, "/", nil)
+	if called.Load() != 2 || rr.Body.Len() != 20 {
+		t.Errorf("expected large response not to be cached, got %d calls", called.Load())
+	}
+}
+
+func TestProxy_ResponseCacheStaleWhileRevalidate(t *testing.T) {
+	t.Parallel()
+	var called atomic.Int32
+	proxy := newCacheTestProxy(t, func(w http.ResponseWriter, r *http.Request) {
+		n := called.Add(1)
+		if r.URL.Path == "/expired" {
+			w.Header().Set("Cache-Control", "max-age=0")
+		} else {
+			w.Header().Set("Cache-Control", "max-age=1, stale-while-revalidate=10")
+		}
+		_, _ = fmt.Fprint(w, n)
+	})
+
+	doCacheRequest(proxy, http.MethodGet, "/", nil)
+	time.Sleep(1100 * time.Millisecond)
+
+	rr := doCacheRequest(proxy, http.MethodGet, "/", nil)
+	if rr.Header().Get(CacheHeader) != "STALE" || rr.Body.String() != "1" {
+		t.Fatalf("expected STALE '1', got %s '%s'", rr.Header().Get(CacheHeader), rr.Body.String())
+	}
+
+	// the entry is refreshed in background
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		rr = doCacheRequest(proxy, http.MethodGet, "/", nil)
+		if rr.Body.String() == "2" && rr.Header().Get(CacheHeader) == "HIT" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected refreshed entry, got %s '%s'", rr.Header().Get(CacheHeader), rr.Body.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if called.Load() != 2 {
+		t.Errorf("expected one background refresh, got %d calls", called.Load())
+	}
+
+	// max-age=0 is not cached
+	doCacheRequest(proxy, http.MethodGet, "/expired", nil)
+	rr = doCacheRequest(proxy, http.MethodGet, "/expired", nil)
+	if rr.Header().Get(CacheHeader) != "MISS" {
+		t.Errorf("expected max-age=0 response not to be cached, got %s", rr.Header().Get(CacheHeader))
+	}
+}
@@ -0,0 +1,391 @@
+package proxykit
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"sync"
+
+	"github.com/spf13/viper"
+
+	"github.com/go-dev-frame/sponge/pkg/conf"
+)
+
+// balancer types supported in the configuration file.
+const (
+	BalancerRoundRobin       = "round_robin"
+	BalancerLeastConnections = "least_conn"
+	BalancerIPHash           = "ip_hash"
+)
+
+// protocols supported in the configuration file.
+const (
+	ProtocolHTTP = "http"
+	ProtocolGRPC = "grpc"
+)
+
+// Config is the declarative gateway configuration.
+type Config struct {
+	AccessLog AccessLogConfig `yaml:"accessLog" json:"accessLog"` // access log of all routes
+	Routes    []RouteConfig   `yaml:"routes" json:"routes"`
+}
+
+// RouteConfig declares a route and its backends.
+type RouteConfig struct {
+	PrefixPath   string              `yaml:"prefixPath" json:"prefixPath"`
+	Protocol     string              `yaml:"protocol" json:"protocol"` // http or grpc, default is http
+	Balancer     string              `yaml:"balancer" json:"balancer"` // round_robin, least_conn, ip_hash, default is round_robin
+	Targets      []string            `yaml:"targets" json:"targets"`
+	HealthCheck  HealthCheckConfig   `yaml:"healthCheck" json:"healthCheck"`
+	Limit        LimitConfig         `yaml:"limit" json:"limit"`               // caps shared by the whole route
+	BackendLimit LimitConfig         `yaml:"backendLimit" json:"backendLimit"` // caps applied to each backend
+	TLS          BackendTLSConfig    `yaml:"tls" json:"tls"`                   // TLS settings used to connect to backends
+	Sticky       StickySessionConfig `yaml:"sticky" json:"sticky"`             // cookie based session affinity
+	Shadow       ShadowConfig        `yaml:"shadow" json:"shadow"`             // mirror requests to a secondary backend set
+	Split        SplitConfig         `yaml:"split" json:"split"`               // canary or A/B traffic splitting
+	Body         BodyConfig          `yaml:"body" json:"body"`                 // body size limits, buffering and slow client timeouts
+	Access       AccessConfig        `yaml:"access" json:"access"`             // client IP allow and deny lists
+	Cache        CacheConfig         `yaml:"cache" json:"cache"`               // response cache of GET requests
+	ErrorPage    ErrorPageConfig     `yaml:"errorPage" json:"errorPage"`       // custom error pages and upstream error translation
+
+	DisableAccessLog bool `yaml:"disableAccessLog" json:"disableAccessLog"` // turn off the access log of the route
+
+	accessLog AccessLogConfig // access log settings of the whole config, compared when reloading
+}
+
+// settingsEqual reports whether two route configs are identical apart from their targets.
+func (c RouteConfig) settingsEqual(other RouteConfig) bool {
+	c.Targets, other.Targets = nil, nil
+	return reflect.DeepEqual(c, other)
+}
+
+// Validate checks the configuration.
+func (c *Config) Validate() error {
+	if f := c.AccessLog.Format; f != "" && f != AccessLogFormatJSON && f != AccessLogFormatCombined {
+		return fmt.Errorf("accessLog: unsupported format '%s'", f)
+	}
+	if c.AccessLog.Percent < 0 || c.AccessLog.Percent > 100 {
+		return errors.New("accessLog: percent must be in range 0~100")
+	}
+	seen := make(map[string]struct{}, len(c.Routes))
+	for i, r := range c.Routes {
+		if r.PrefixPath == "" {
+			return fmt.Errorf("routes[%d]: prefixPath must be specified", i)
+		}
+		p := normalizePrefixPath(r.PrefixPath)
+		if _, ok := seen[p]; ok {
+			return fmt.Errorf("routes[%d]: duplicate prefixPath '%s'", i, p)
+		}
+		seen[p] = struct{}{}
+		if _, err := NewBalancer(r.Balancer, nil); err != nil {
+			return fmt.Errorf("routes[%d]: %v", i, err)
+		}
+		if r.Protocol != "" && r.Protocol != ProtocolHTTP && r.Protocol != ProtocolGRPC {
+			return fmt.Errorf("routes[%d]: unsupported protocol '%s'", i, r.Protocol)
+		}
+		if len(r.Split.Targets) > 0 {
+			if _, err := NewBalancer(r.Split.Balancer, nil); err != nil {
+				return fmt.Errorf("routes[%d]: split: %v", i, err)
+			}
+			if r.Split.Percent < 0 || r.Split.Percent > 100 {
+				return fmt.Errorf("routes[%d]: split.percent must be in range 0~100", i)
+			}
+		}
+		if r.Shadow.Percent < 0 || r.Shadow.Percent > 100 {
+			return fmt.Errorf("routes[%d]: shadow.percent must be in range 0~100", i)
+		}
+		if r.Sticky.Enable && r.Sticky.Secret == "" {
+			return fmt.Errorf("routes[%d]: sticky.secret must be specified", i)
+		}
+		if err := r.Body.Validate(); err != nil {
+			return fmt.Errorf("routes[%d]: body: %v", i, err)
+		}
+		if _, err := NewAccessControl(r.Access); err != nil {
+			return fmt.Errorf("routes[%d]: access: %v", i, err)
+		}
+		if _, err := NewErrorPages(r.ErrorPage); err != nil {
+			return fmt.Errorf("routes[%d]: errorPage: %v", i, err)
+		}
+		if err := r.Cache.Validate(); err != nil {
+			return fmt.Errorf("routes[%d]: cache: %v", i, err)
+		}
+		for _, t := range r.Targets {
+			if _, err := url.Parse(t); err != nil {
+				return fmt.Errorf("routes[%d]: invalid target '%s': %v", i, t, err)
+			}
+		}
+	}
+	return nil
+}
+
+// NewBalancer creates a balancer by type name.
+func NewBalancer(kind string, backends []*Backend) (Balancer, error) {
+	switch kind {
+	case "", BalancerRoundRobin:
+		return NewRoundRobin(backends), nil
+	case BalancerLeastConnections:
+		return NewLeastConnections(backends), nil
+	case BalancerIPHash:
+		return NewIPHash(backends), nil
+	}
+	return nil, fmt.Errorf("unsupported balancer type '%s'", kind)
+}
+
+var applyMu sync.Mutex
+
+// LoadConfig parses the gateway configuration file (yaml, json, toml), applies the declared
+// routes to the route manager, and watches the file for changes, each change is validated and
+// then applied at runtime: new routes and backends are added, missing ones are removed.
+// The returned config is a copy of the configuration loaded at startup, it is not changed by
+// reloading, use ParseConfigFile to read the current configuration.
+//
+// Note: the file is loaded through pkg/conf, which uses the global viper instance,
+// so do not use it together with another conf.Parse call that watches a different file.
+func LoadConfig(configFile string, m *RouteManager) (*Config, error) {
+	if m == nil {
+		return nil, errors.New("route manager cannot be nil")
+	}
+
+	cfg := &Config{}
+	err := conf.Parse(configFile, cfg, func() {
+		if err := m.ApplyConfig(cfg); err != nil {
+			log.Printf("[Config] reload '%s' error: %v", configFile, err)
+			return
+		}
+		log.Printf("[Config] reloaded '%s' successfully", configFile)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = m.ApplyConfig(cfg); err != nil {
+		return nil, err
+	}
+	return cfg.clone(), nil
+}
+
+// clone returns a copy of the configuration, the routes are not shared with the original.
+func (c *Config) clone() *Config {
+	cp := *c
+	cp.Routes = append([]RouteConfig(nil), c.Routes...)
+	return &cp
+}
+
+// ParseConfigFile parses the gateway configuration file without watching it, it does not
+// touch the global viper instance, and can be used to reload the configuration on demand.
+func ParseConfigFile(configFile string) (*Config, error) {
+	v := viper.New()
+	v.SetConfigFile(configFile)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, err
+	}
+	cfg := &Config{}
+	if err := v.Unmarshal(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+type builtRoute struct {
+	route    *Route
+	backends []*Backend // backends that start health checks after the route is registered
+}
+
+type syncedRoute struct {
+	route  *Route
+	config RouteConfig
+}
+
+// ApplyConfig reconciles the routes of the manager with the configuration. Routes whose settings
+// are unchanged only have their backends synchronized, routes with changed settings are rebuilt,
+// and routes that are no longer declared are removed. The rebuilt routes are created before the
+// route table is changed, and then the table is swapped at once, so requests never get 404 during
+// reloading, and the table is unchanged if any route fails to be created.
+func (m *RouteManager) ApplyConfig(cfg *Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	applyMu.Lock()
+	defer applyMu.Unlock()
+
+	declared := make(map[string]struct{}, len(cfg.Routes))
+	var built []builtRoute
+	var synced []syncedRoute
+	for _, rc := range cfg.Routes {
+		rc.PrefixPath = normalizePrefixPath(rc.PrefixPath)
+		rc.accessLog = cfg.AccessLog
+		declared[rc.PrefixPath] = struct{}{}
+
+		route, exists := m.GetRoute(rc.PrefixPath)
+		if exists && route.config != nil && route.config.settingsEqual(rc) {
+			synced = append(synced, syncedRoute{route: route, config: rc})
+			continue
+		}
+		route, backends, err := newRouteFromConfig(rc)
+		if err != nil {
+			return err
+		}
+		built = append(built, builtRoute{route: route, backends: backends})
+	}
+
+	m.mu.Lock()
+	routes := make(map[string]*Route, len(m.routes)+len(built))
+	var removed []*Route
+	for prefixPath, route := range m.routes {
+		if _, ok := declared[prefixPath]; !ok && route.config != nil {
+			removed = append(removed, route)
+			continue
+		}
+		routes[prefixPath] = route
+	}
+	for _, b := range built {
+		if route, exists := routes[b.route.PrefixPath]; exists {
+			removed = append(removed, route)
+		}
+		routes[b.route.PrefixPath] = b.route
+	}
+	m.routes = routes
+	m.mu.Unlock()
+
+	for _, route := range removed {
+		route.close()
+		if _, ok := declared[route.PrefixPath]; !ok {
+			log.Printf("[Manager] removed route for prefix: %s", route.PrefixPath)
+		}
+	}
+	for _, b := range built {
+		StartHealthChecks(b.backends, b.route.config.HealthCheck)
+		log.Printf("[Manager] added new route for prefix: %s", b.route.PrefixPath)
+	}
+	for _, s := range synced {
+		s.route.syncTargets(s.config)
+	}
+
+	return nil
+}
+
+// newRouteFromConfig creates the route declared in the configuration, the route is not registered
+// in the manager, and the health checks of the returned backends are not started.
+func newRouteFromConfig(rc RouteConfig) (*Route, []*Backend, error) {
+	backends, err := newBackendsFromConfig(rc, rc.Targets)
+	if err != nil {
+		return nil, nil, err
+	}
+	balancer, err := NewBalancer(rc.Balancer, backends)
+	if err != nil {
+		return nil, nil, err
+	}
+	var canaryBackends []*Backend
+	if len(rc.Split.Targets) > 0 {
+		if canaryBackends, err = newBackendsFromConfig(rc, rc.Split.Targets); err != nil {
+			return nil, nil, err
+		}
+		canary, err := NewBalancer(rc.Split.Balancer, canaryBackends)
+		if err != nil {
+			return nil, nil, err
+		}
+		if balancer, err = NewSplitBalancer(balancer, canary, rc.Split.Percent, rc.Split.Rules...); err != nil {
+			return nil, nil, err
+		}
+	}
+	opts := []ProxyOption{WithRouteLimit(rc.Limit), WithBodyConfig(rc.Body), WithAccessControl(rc.Access), WithErrorPages(rc.ErrorPage)}
+	if rc.Sticky.Enable {
+		opts = append(opts, WithStickySession(rc.Sticky))
+	}
+	if len(rc.Shadow.Targets) > 0 && rc.Shadow.Percent > 0 {
+		shadowBackends, err := ParseBackends(rc.PrefixPath, rc.Shadow.Targets)
+		if err != nil {
+			return nil, nil, err
+		}
+		opts = append(opts, WithShadow(NewShadow(shadowBackends, rc.Shadow.Percent,
+			WithShadowTimeout(rc.Shadow.Timeout), WithShadowMaxBodySize(rc.Shadow.MaxBodySize))))
+	}
+	if rc.Cache.Enable {
+		cache, err := NewResponseCacheFromConfig(rc.Cache)
+		if err != nil {
+			return nil, nil, fmt.Errorf("route '%s': cache: %v", rc.PrefixPath, err)
+		}
+		opts = append(opts, WithResponseCache(cache))
+	}
+	if rc.accessLog.Enable && !rc.DisableAccessLog {
+		opts = append(opts, WithAccessLog(NewAccessLogger(os.Stdout,
+			WithAccessLogFormat(rc.accessLog.Format), WithAccessLogSampling(rc.accessLog.Percent))))
+	}
+	route, err := newRoute(rc.PrefixPath, balancer, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	route.config = &rc
+	return route, append(backends, canaryBackends...), nil
+}
+
+// syncTargets adds and removes backends so that the route matches the declared targets,
+// the canary targets are unchanged here, otherwise the route would have been rebuilt.
+func (r *Route) syncTargets(rc RouteConfig) {
+	targets := append(append([]string{}, rc.Targets...), rc.Split.Targets...)
+	r.setTargets(targets, rc.HealthCheck, func(targets []string) ([]*Backend, error) {
+		return newBackendsFromConfig(rc, targets)
+	})
+	r.mu.Lock()
+	r.config = &rc
+	r.mu.Unlock()
+}
+
+// setTargets replaces the backends of the route with the given targets, existing backends
+// are kept, new backends are created by newBackends and start health checks.
+func (r *Route) setTargets(targets []string, hc HealthCheckConfig, newBackends func([]string) ([]*Backend, error)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var added []string
+	for _, t := range targets {
+		if !containsTarget(r.Backends, t) && !containsString(added, t) {
+			added = append(added, t)
+		}
+	}
+	backends, err := newBackends(added)
+	if err != nil {
+		log.Printf("[Manager] error creating backends for route '%s': %v", r.PrefixPath, err)
+		return
+	}
+
+	var kept []*Backend
+	for _, b := range r.Backends {
+		if containsString(targets, b.URL.String()) {
+			kept = append(kept, b)
+			continue
+		}
+		b.StopHealthCheck()
+		r.Balancer.RemoveBackend(b)
+		deleteBackendMetrics(r.PrefixPath, b.URL.String())
+		log.Printf("[Manager] removed backend '%s' from route '%s'", b.URL.String(), r.PrefixPath)
+	}
+	for _, b := range backends {
+		r.Balancer.AddBackend(b)
+		kept = append(kept, b)
+		log.Printf("[Manager] added backend '%s' to route '%s'", b.URL.String(), r.PrefixPath)
+	}
+	StartHealthChecks(backends, hc)
+	r.Backends = kept
+}
+
+func newBackendsFromConfig(rc RouteConfig, targets []string) ([]*Backend, error) {
+	parse := ParseBackends
+	if rc.Protocol == ProtocolGRPC {
+		parse = ParseGRPCBackends
+	}
+	backends, err := parse(rc.PrefixPath, targets)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range backends {
+		b.SetLimit(rc.BackendLimit)
+		if err = b.SetTLSConfig(rc.TLS); err != nil {
+			return nil, fmt.Errorf("backend '%s': %v", b.URL.String(), err)
+		}
+	}
+	return backends, nil
+}
@@ -0,0 +1,234 @@
+package proxykit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testGatewayConfig = `
+routes:
+  - prefixPath: /user
+    balancer: least_conn
+    targets:
+      - http://localhost:18081
+      - http://localhost:18082
+    healthCheck:
+      interval: 10s
+      timeout: 2s
+    limit:
+      maxConcurrent: 100
+  - prefixPath: /order/
+    targets:
+      - http://localhost:18083
+`
+
+func TestConfig_Validate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"valid", Config{Routes: []RouteConfig{{PrefixPath: "/api", Balancer: BalancerIPHash}}}, false},
+		{"empty prefix", Config{Routes: []RouteConfig{{}}}, true},
+		{"duplicate prefix", Config{Routes: []RouteConfig{{PrefixPath: "/api"}, {PrefixPath: "/api/"}}}, true},
+		{"unknown balancer", Config{Routes: []RouteConfig{{PrefixPath: "/api", Balancer: "random"}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRouteManager_ApplyConfig(t *testing.T) {
+	t.Parallel()
+	m := NewRouteManager()
+
+	cfg := &Config{Routes: []RouteConfig{
+		{PrefixPath: "/a", Targets: []string{"http://localhost:18081", "http://localhost:18082"}},
+		{PrefixPath: "/b", Targets: []string{"http://localhost:18083"}},
+	}}
+	if err := m.ApplyConfig(cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	routeA, ok := m.GetRoute("/a/")
+	if !ok || len(routeA.Backends) != 2 {
+		t.Fatal("expected route /a/ with 2 backends")
+	}
+	keep := routeA.Backends[0]
+
+	// remove a target, add a target, drop route /b, keep route /a instance
+	cfg = &Config{Routes: []RouteConfig{
+		{PrefixPath: "/a", Targets: []string{"http://localhost:18081", "http://localhost:18084"}},
+	}}
+	if err := m.ApplyConfig(cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	routeA2, _ := m.GetRoute("/a/")
+	if routeA2 != routeA {
+		t.Error("expected route with unchanged settings to be kept")
+	}
+	if len(routeA2.Backends) != 2 || routeA2.Backends[0] != keep ||
+		!containsTarget(routeA2.Balancer.GetBackends(), "http://localhost:18084") ||
+		containsTarget(routeA2.Balancer.GetBackends(), "http://localhost:18082") {
+		t.Errorf("unexpected backends after sync: %v", routeA2.Backends)
+	}
+	if _, ok := m.GetRoute("/b/"); ok {
+		t.Error("expected route /b/ to be removed")
+	}
+
+	// changed settings rebuild the route
+	cfg.Routes[0].Balancer = BalancerIPHash
+	if err := m.ApplyConfig(cfg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	routeA3, _ := m.GetRoute("/a/")
+	if routeA3 == routeA2 {
+		t.Error("expected route with changed settings to be rebuilt")
+	}
+	if _, ok := routeA3.Balancer.(*IPHash); !ok {
+		t.Errorf("expected IPHash balancer, got %T", routeA3.Balancer)
+	}
+
+	// invalid config is rejected without changes
+	if err := m.ApplyConfig(&Config{Routes: []RouteConfig{{PrefixPath: "/a", Balancer: "x"}}}); err == nil {
+		t.Error("expected an error for invalid config")
+	}
+	if _, ok := m.GetRoute("/a/"); !ok {
+		t.Error("expected route /a/ to be kept after invalid config")
+	}
+
+	// a route that fails to be created leaves the route table unchanged
+	err := m.ApplyConfig(&Config{Routes: []RouteConfig{
+		{PrefixPath: "/a", Targets: []string{"http://localhost:18081"}},
+		{PrefixPath: "/c", Targets: []string{"https://localhost:18086"}, TLS: BackendTLSConfig{CAFile: "not-exist.pem"}},
+	}})
+	if err == nil {
+		t.Error("expected an error for invalid TLS config")
+	}
+	if routeA4, _ := m.GetRoute("/a/"); routeA4 != routeA3 || len(routeA4.Backends) != 2 {
+		t.Error("expected route /a/ to be unchanged after a failed reload")
+	}
+	if _, ok := m.GetRoute("/c/"); ok {
+		t.Error("expected route /c/ not to be added after a failed reload")
+	}
+}
+
+func TestRouteManager_ServeHTTP(t *testing.T) {
+	t.Parallel()
+	newServer := func(body string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(body + r.URL.Path))
+		}))
+	}
+	s1, s2 := newServer("api:"), newServer("api-v2:")
+	defer s1.Close()
+	defer s2.Close()
+
+	m := NewRouteManager()
+	err := m.ApplyConfig(&Config{Routes: []RouteConfig{
+		{PrefixPath: "/api", Targets: []string{s1.URL}},
+		{PrefixPath: "/api/v2", Targets: []string{s2.URL}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path     string
+		wantCode int
+		wantBody string
+	}{
+		{"/api/users", http.StatusOK, "api:/users"},
+		{"/api/v2/users", http.StatusOK, "api-v2:/users"},
+		{"/other", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		m.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rr.Code != tt.wantCode {
+			t.Errorf("%s: expected status %d, got %d", tt.path, tt.wantCode, rr.Code)
+		}
+		if tt.wantBody != "" && rr.Body.String() != tt.wantBody {
+			t.Errorf("%s: expected body '%s', got '%s'", tt.path, tt.wantBody, rr.Body.String())
+		}
+	}
+}
+
+func TestParseConfigFile(t *testing.T) {
+	t.Parallel()
+	configFile := filepath.Join(t.TempDir(), "gateway.yml")
+	if err := os.WriteFile(configFile, []byte(testGatewayConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := ParseConfigFile(configFile)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(cfg.Routes) != 2 || cfg.Routes[0].Limit.MaxConcurrent != 100 {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+
+	if _, err = ParseConfigFile(filepath.Join(t.TempDir(), "not-exist.yml")); err == nil {
+		t.Error("expected an error for missing file")
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "gateway.yml")
+	if err := os.WriteFile(configFile, []byte(testGatewayConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadConfig(configFile, nil); err == nil {
+		t.Error("expected an error when route manager is nil")
+	}
+
+	m := NewRouteManager()
+	cfg, err := LoadConfig(configFile, m)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(cfg.Routes) != 2 {
+		t.Fatalf("expected 2 routes, got %d", len(cfg.Routes))
+	}
+	if cfg.Routes[0].HealthCheck.Interval != 10*time.Second {
+		t.Errorf("expected health check interval 10s, got %v", cfg.Routes[0].HealthCheck.Interval)
+	}
+	route, ok := m.GetRoute("/user/")
+	if !ok {
+		t.Fatal("expected route /user/")
+	}
+	if _, ok = route.Balancer.(*LeastConnections); !ok {
+		t.Errorf("expected LeastConnections balancer, got %T", route.Balancer)
+	}
+	if _, ok = m.GetRoute("/order/"); !ok {
+		t.Fatal("expected route /order/")
+	}
+
+	// hot reload
+	newConfig := "routes:\n  - prefixPath: /user\n    targets: [\"http://localhost:18085\"]\n"
+	if err = os.WriteFile(configFile, []byte(newConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, exists := m.GetRoute("/order/"); !exists {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if _, exists := m.GetRoute("/order/"); exists {
+		t.Error("expected route /order/ to be removed after reload")
+	}
+	if len(cfg.Routes) != 2 {
+		t.Errorf("expected the returned config to be unchanged by reloading, got %d routes", len(cfg.Routes))
+	}
+}
@@ -0,0 +1,163 @@
+package proxykit
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/go-dev-frame/sponge/pkg/servicerd/registry"
+)
+
+// DiscoveryOption set the discovery options.
+type DiscoveryOption func(*discoveryOptions)
+
+type discoveryOptions struct {
+	scheme       string
+	healthCheck  HealthCheckConfig
+	backendLimit LimitConfig
+	tls          BackendTLSConfig
+	keepOnEmpty  bool
+}
+
+func defaultDiscoveryOptions() *discoveryOptions {
+	return &discoveryOptions{
+		scheme: "http",
+	}
+}
+
+func (o *discoveryOptions) apply(opts ...DiscoveryOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WithDiscoveryScheme set the endpoint scheme to select from the service instances, default is http,
+// if the scheme is grpc, the discovered backends proxy gRPC traffic.
+func WithDiscoveryScheme(scheme string) DiscoveryOption {
+	return func(o *discoveryOptions) {
+		o.scheme = scheme
+	}
+}
+
+// WithDiscoveryHealthCheck set the health check config of discovered backends.
+func WithDiscoveryHealthCheck(cfg HealthCheckConfig) DiscoveryOption {
+	return func(o *discoveryOptions) {
+		o.healthCheck = cfg
+	}
+}
+
+// WithDiscoveryBackendLimit set the caps applied to each discovered backend.
+func WithDiscoveryBackendLimit(cfg LimitConfig) DiscoveryOption {
+	return func(o *discoveryOptions) {
+		o.backendLimit = cfg
+	}
+}
+
+// WithDiscoveryTLS set the TLS settings used to connect to discovered backends.
+func WithDiscoveryTLS(cfg BackendTLSConfig) DiscoveryOption {
+	return func(o *discoveryOptions) {
+		o.tls = cfg
+	}
+}
+
+// WithDiscoveryKeepOnEmpty keeps the current backends when the instance list is empty, e.g. to ride out
+// a transient registry failure, by default all backends are removed and the route responds 503.
+func WithDiscoveryKeepOnEmpty() DiscoveryOption {
+	return func(o *discoveryOptions) {
+		o.keepOnEmpty = true
+	}
+}
+
+// WatchDiscovery keeps the backends of the route in sync with the instances of serviceName
+// registered in etcd, consul or nacos, until ctx is canceled. Instances are watched with the
+// servicerd discovery, the endpoint matching the scheme (e.g. http://127.0.0.1:8080?isSecure=false)
+// of each instance is used as backend target, a secure endpoint is proxied with https.
+func (r *Route) WatchDiscovery(ctx context.Context, d registry.Discovery, serviceName string, opts ...DiscoveryOption) error {
+	if d == nil {
+		return errors.New("discovery cannot be nil")
+	}
+	o := defaultDiscoveryOptions()
+	o.apply(opts...)
+
+	w, err := d.Watch(ctx, serviceName)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		defer func() { _ = w.Stop() }()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+
+			instances, err := w.Next()
+			if err != nil {
+				if errors.Is(err, context.Canceled) || ctx.Err() != nil {
+					return
+				}
+				log.Printf("[Discovery] watch service '%s' error: %v", serviceName, err)
+				time.Sleep(time.Second)
+				continue
+			}
+			r.updateFromInstances(instances, o)
+		}
+	}()
+
+	return nil
+}
+
+func (r *Route) updateFromInstances(instances []*registry.ServiceInstance, o *discoveryOptions) {
+	targets := instancesToTargets(instances, o.scheme)
+	if len(targets) == 0 {
+		if o.keepOnEmpty {
+			log.Printf("[Discovery] no instances of route '%s', keep the current backends", r.PrefixPath)
+			return
+		}
+		log.Printf("[Discovery] no instances of route '%s', remove all backends", r.PrefixPath)
+	}
+
+	r.setTargets(targets, o.healthCheck, func(added []string) ([]*Backend, error) {
+		parse := ParseBackends
+		if o.scheme == ProtocolGRPC {
+			parse = ParseGRPCBackends
+		}
+		backends, err := parse(r.PrefixPath, added)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range backends {
+			b.SetLimit(o.backendLimit)
+			if err = b.SetTLSConfig(o.tls); err != nil {
+				return nil, err
+			}
+		}
+		return backends, nil
+	})
+}
+
+func instancesToTargets(instances []*registry.ServiceInstance, scheme string) []string {
+	var targets []string
+	for _, in := range instances {
+		for _, e := range in.Endpoints {
+			u, err := url.Parse(e)
+			if err != nil || u.Scheme != scheme || u.Host == "" {
+				continue
+			}
+			targetScheme := "http"
+			if secure, _ := strconv.ParseBool(u.Query().Get("isSecure")); secure {
+				targetScheme = "https"
+			}
+			target := targetScheme + "://" + u.Host
+			if !containsString(targets, target) {
+				targets = append(targets, target)
+			}
+			break
+		}
+	}
+	return targets
+}
@@ -0,0 +1,144 @@
+package proxykit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-dev-frame/sponge/pkg/servicerd/registry"
+)
+
+type mockDiscovery struct {
+	ch chan []*registry.ServiceInstance
+}
+
+func (d *mockDiscovery) GetService(_ context.Context, _ string) ([]*registry.ServiceInstance, error) {
+	return nil, nil
+}
+
+func (d *mockDiscovery) Watch(ctx context.Context, _ string) (registry.Watcher, error) {
+	return &mockWatcher{ctx: ctx, ch: d.ch}, nil
+}
+
+type mockWatcher struct {
+	ctx context.Context
+	ch  chan []*registry.ServiceInstance
+}
+
+func (w *mockWatcher) Next() ([]*registry.ServiceInstance, error) {
+	select {
+	case <-w.ctx.Done():
+		return nil, w.ctx.Err()
+	case ins := <-w.ch:
+		return ins, nil
+	}
+}
+
+func (w *mockWatcher) Stop() error { return nil }
+
+func TestInstancesToTargets(t *testing.T) {
+	t.Parallel()
+	instances := []*registry.ServiceInstance{
+		registry.NewServiceInstance("1", "user", []string{"grpc://127.0.0.1:8282", "http://127.0.0.1:8080?isSecure=false"}),
+		registry.NewServiceInstance("2", "user", []string{"http://127.0.0.2:8080?isSecure=true"}),
+		registry.NewServiceInstance("3", "user", []string{"grpc://127.0.0.3:8282"}),
+		registry.NewServiceInstance("4", "user", []string{"http://127.0.0.1:8080"}),
+	}
+	got := instancesToTargets(instances, "http")
+	want := []string{"http://127.0.0.1:8080", "https://127.0.0.2:8080"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestRoute_WatchDiscovery(t *testing.T) {
+	t.Parallel()
+	m := NewRouteManager()
+	route, _ := m.AddRoute("/user", NewRoundRobin(nil))
+
+	if err := route.WatchDiscovery(context.Background(), nil, "user"); err == nil {
+		t.Error("expected an error when discovery is nil")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d := &mockDiscovery{ch: make(chan []*registry.ServiceInstance)}
+	if err := route.WatchDiscovery(ctx, d, "user", WithDiscoveryBackendLimit(LimitConfig{MaxConcurrent: 10})); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	waitTargets := func(want ...string) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			route.mu.RLock()
+			n := len(route.Backends)
+			ok := n == len(want)
+			for _, w := range want {
+				ok = ok && containsTarget(route.Backends, w)
+			}
+			route.mu.RUnlock()
+			if ok && len(route.Balancer.GetBackends()) == len(want) {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("backends not synchronized, want %v", want)
+	}
+
+	d.ch <- []*registry.ServiceInstance{
+		registry.NewServiceInstance("1", "user", []string{"http://127.0.0.1:8080"}),
+		registry.NewServiceInstance("2", "user", []string{"http://127.0.0.2:8080"}),
+	}
+	waitTargets("http://127.0.0.1:8080", "http://127.0.0.2:8080")
+
+	d.ch <- []*registry.ServiceInstance{
+		registry.NewServiceInstance("2", "user", []string{"http://127.0.0.2:8080"}),
+		registry.NewServiceInstance("3", "user", []string{"http://127.0.0.3:8080"}),
+	}
+	waitTargets("http://127.0.0.2:8080", "http://127.0.0.3:8080")
+
+	route.mu.RLock()
+	if route.Backends[0].limiter.Load() == nil {
+		t.Error("expected backend limit to be applied")
+	}
+	route.mu.RUnlock()
+
+	// empty instance list removes all backends, the route responds 503
+	d.ch <- []*registry.ServiceInstance{}
+	waitTargets()
+	rr := httptest.NewRecorder()
+	m.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/user/1", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", rr.Code)
+	}
+}
+
+func TestRoute_WatchDiscoveryKeepOnEmpty(t *testing.T) {
+	t.Parallel()
+	m := NewRouteManager()
+	route, _ := m.AddRoute("/order", NewRoundRobin(nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d := &mockDiscovery{ch: make(chan []*registry.ServiceInstance)}
+	if err := route.WatchDiscovery(ctx, d, "order", WithDiscoveryKeepOnEmpty()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	d.ch <- []*registry.ServiceInstance{
+		registry.NewServiceInstance("1", "order", []string{"http://127.0.0.1:8080"}),
+	}
+	// the channel is unbuffered, the second send returns after the first list is handled
+	d.ch <- []*registry.ServiceInstance{}
+	d.ch <- []*registry.ServiceInstance{}
+
+	route.mu.RLock()
+	defer route.mu.RUnlock()
+	if len(route.Backends) != 1 || !containsTarget(route.Backends, "http://127.0.0.1:8080") {
+		t.Errorf("expected the current backends to be kept, got %v", route.Backends)
+	}
+}
@@ -0,0 +1,109 @@
+package proxykit
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+const defaultDrainTimeout = 30 * time.Second
+
+// interval of checking whether the in-flight requests of a draining backend have finished
+var drainCheckInterval = 100 * time.Millisecond
+
+// DrainRequest is for the management API of draining backends.
+type DrainRequest struct {
+	PrefixPath string        `json:"prefixPath"`
+	Targets    []string      `json:"targets"`
+	Timeout    time.Duration `json:"timeout"` // maximum time to wait for the in-flight requests, default is 30s
+}
+
+// HandleDrainBackends handles the HTTP request to drain backends of a route, the draining backends
+// receive no new requests, and are removed after their in-flight requests finish or the timeout expires.
+func (m *RouteManager) HandleDrainBackends(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req DrainRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Bad Request: Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	route, exists := m.GetRoute(req.PrefixPath)
+	if !exists {
+		http.Error(w, "Not Found: Prefix path does not exist", http.StatusNotFound)
+		return
+	}
+	drainingCount := route.DrainBackends(req.Targets, req.Timeout)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"message": "Backends draining started", "drainingCount": drainingCount})
+}
+
+// DrainBackends marks the backends of targets as draining, each of them is removed from the route after
+// its active connections reach zero or the timeout expires, returns the number of backends starting draining.
+func (route *Route) DrainBackends(targets []string, timeout time.Duration) int {
+	if timeout <= 0 {
+		timeout = defaultDrainTimeout
+	}
+
+	route.mu.RLock()
+	var backends []*Backend
+	for _, b := range route.Backends {
+		if containsString(targets, b.URL.String()) && !b.IsDraining() {
+			b.SetDraining(true)
+			backends = append(backends, b)
+			log.Printf("[Manager] draining backend '%s' of route '%s', active connections: %d",
+				b.URL.String(), route.PrefixPath, b.GetActiveConns())
+		}
+	}
+	route.mu.RUnlock()
+
+	for _, b := range backends {
+		go route.waitDrained(b, timeout)
+	}
+	return len(backends)
+}
+
+func (route *Route) waitDrained(b *Backend, timeout time.Duration) {
+	ticker := time.NewTicker(drainCheckInterval)
+	defer ticker.Stop()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+loop:
+	for b.GetActiveConns() > 0 {
+		select {
+		case <-ticker.C:
+		case <-timer.C:
+			log.Printf("[Manager] draining backend '%s' timed out after %s, active connections: %d",
+				b.URL.String(), timeout, b.GetActiveConns())
+			break loop
+		}
+	}
+
+	route.mu.Lock()
+	defer route.mu.Unlock()
+	// the draining is canceled by adding the backend again
+	if !b.IsDraining() {
+		return
+	}
+	var updatedBackends []*Backend
+	removed := false
+	for _, backend := range route.Backends {
+		if backend == b {
+			removed = true
+			continue
+		}
+		updatedBackends = append(updatedBackends, backend)
+	}
+	// the backend has been removed by the management API
+	if !removed {
+		return
+	}
+	route.Backends = updatedBackends
+	b.StopHealthCheck()
+	route.Balancer.RemoveBackend(b)
+	deleteBackendMetrics(route.PrefixPath, b.URL.String())
+	log.Printf("[Manager] removed drained backend '%s' from route '%s'", b.URL.String(), route.PrefixPath)
+}
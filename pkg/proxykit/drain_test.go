@@ -0,0 +1,197 @@
+package proxykit
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newDrainTestRoute(t *testing.T, handler http.HandlerFunc) (*RouteManager, *Route) {
+	s1 := httptest.NewServer(handler)
+	s2 := httptest.NewServer(handler)
+	t.Cleanup(s1.Close)
+	t.Cleanup(s2.Close)
+
+	backends, err := ParseBackends("/drain", []string{s1.URL, s2.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewRouteManager()
+	route, err := m.AddRoute("/drain", NewRoundRobin(backends))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m, route
+}
+
+func doDrainRequest(m *RouteManager, req DrainRequest) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(req)
+	rr := httptest.NewRecorder()
+	m.HandleDrainBackends(rr, httptest.NewRequest(http.MethodPost, "/endpoints/drain", bytes.NewReader(body)))
+	return rr
+}
+
+// sends a slow request in background, returns the backend serving it
+func startSlowRequest(t *testing.T, m *RouteManager, route *Route, wg *sync.WaitGroup) *Backend {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/drain/slow", nil))
+	}()
+	for i := 0; i < 100; i++ {
+		for _, b := range route.Backends {
+			if b.GetActiveConns() == 1 {
+				return b
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("the slow request is not in flight")
+	return nil
+}
+
+func waitBackendsCount(route *Route, n int) bool {
+	for i := 0; i < 200; i++ {
+		route.mu.RLock()
+		count := len(route.Backends)
+		route.mu.RUnlock()
+		if count == n {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+func TestRoute_DrainBackends(t *testing.T) {
+	release := make(chan struct{})
+	m, route := newDrainTestRoute(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			<-release
+		}
+		_, _ = w.Write([]byte(r.Host))
+	})
+
+	var wg sync.WaitGroup
+	drained := startSlowRequest(t, m, route, &wg)
+
+	rr := doDrainRequest(m, DrainRequest{PrefixPath: "/drain/", Targets: []string{drained.URL.String()}, Timeout: 5 * time.Second})
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"drainingCount":1`) {
+		t.Fatalf("unexpected response: %d %s", rr.Code, rr.Body.String())
+	}
+	if !drained.IsDraining() || drained.IsAvailable() {
+		t.Error("the backend should be draining and not available")
+	}
+
+	// new requests are not routed to the draining backend
+	for i := 0; i < 4; i++ {
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/drain/fast", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rec.Code)
+		}
+		if rec.Body.String() == drained.URL.Host {
+			t.Error("request was routed to the draining backend")
+		}
+	}
+	if waitBackendsCount(route, 1) {
+		t.Fatal("the backend is removed before the in-flight request finishes")
+	}
+
+	// the backend is removed after the in-flight request finishes
+	close(release)
+	wg.Wait()
+	if !waitBackendsCount(route, 1) {
+		t.Fatal("the drained backend is not removed")
+	}
+	if containsTarget(route.Balancer.GetBackends(), drained.URL.String()) {
+		t.Error("the drained backend is not removed from balancer")
+	}
+}
+
+func TestRoute_DrainBackends_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	m, route := newDrainTestRoute(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			<-release
+		}
+	})
+
+	var wg sync.WaitGroup
+	drained := startSlowRequest(t, m, route, &wg)
+	if n := route.DrainBackends([]string{drained.URL.String()}, 200*time.Millisecond); n != 1 {
+		t.Fatalf("expected 1 draining backend, got %d", n)
+	}
+	// draining again is ignored
+	if n := route.DrainBackends([]string{drained.URL.String()}, 200*time.Millisecond); n != 0 {
+		t.Fatalf("expected 0 draining backend, got %d", n)
+	}
+	if !waitBackendsCount(route, 1) {
+		t.Fatal("the draining backend is not removed after timeout")
+	}
+}
+
+func TestRoute_DrainBackends_Cancel(t *testing.T) {
+	release := make(chan struct{})
+	m, route := newDrainTestRoute(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			<-release
+		}
+	})
+
+	var wg sync.WaitGroup
+	drained := startSlowRequest(t, m, route, &wg)
+	route.DrainBackends([]string{drained.URL.String()}, 5*time.Second)
+
+	// adding the draining backend again cancels the draining
+	body, _ := json.Marshal(ManagementRequest{PrefixPath: "/drain/", Targets: []string{drained.URL.String()}})
+	rr := httptest.NewRecorder()
+	m.HandleAddBackends(rr, httptest.NewRequest(http.MethodPost, "/endpoints/add", bytes.NewReader(body)))
+	respBody, _ := io.ReadAll(rr.Body)
+	if !strings.Contains(string(respBody), `"addedCount":1`) {
+		t.Fatalf("unexpected response: %s", respBody)
+	}
+	if drained.IsDraining() {
+		t.Error("the draining is not canceled")
+	}
+
+	close(release)
+	wg.Wait()
+	time.Sleep(3 * drainCheckInterval)
+	if len(route.Backends) != 2 {
+		t.Errorf("expected 2 backends, got %d", len(route.Backends))
+	}
+}
+
+func TestRouteManager_HandleDrainBackends(t *testing.T) {
+	m, _ := newDrainTestRoute(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	rr := httptest.NewRecorder()
+	m.HandleDrainBackends(rr, httptest.NewRequest(http.MethodGet, "/endpoints/drain", nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected %d, got %d", http.StatusMethodNotAllowed, rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	m.HandleDrainBackends(rr, httptest.NewRequest(http.MethodPost, "/endpoints/drain", strings.NewReader(`{invalid`)))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+
+	rr = doDrainRequest(m, DrainRequest{PrefixPath: "/foo/", Targets: []string{"http://b1.com"}})
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected %d, got %d", http.StatusNotFound, rr.Code)
+	}
+
+	rr = doDrainRequest(m, DrainRequest{PrefixPath: "/drain/", Targets: []string{"http://b1.com"}})
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"drainingCount":0`) {
+		t.Errorf("unexpected response: %d %s", rr.Code, rr.Body.String())
+	}
+}
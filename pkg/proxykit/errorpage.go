@@ -0,0 +1,430 @@
+package proxykit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"github.com/go-dev-frame/sponge/pkg/errcode"
+)
+
+const (
+	// the upstream error bodies larger than this size are passed through without translation
+	maxTranslateBodySize = 64 << 10
+	// the text bodies longer than this length are truncated when used as the message
+	maxTranslateMsgLen = 256
+)
+
+// ErrorPageConfig defines the error responses of a route, the custom pages replace the 502, 503
+// and 504 responses generated by the gateway, and the translation wraps the error responses in the
+// sponge ecode envelope {"code":100023,"msg":"Bad Gateway","data":{}}, so that the errors of the
+// gateway and the backends look the same as the errors of sponge services to API consumers.
+type ErrorPageConfig struct {
+	Pages []ErrorPage `yaml:"pages" json:"pages"`
+
+	// Translate converts the error responses (status >= 400) of the route into the ecode envelope,
+	// the responses that are already in the envelope are unchanged, the message is taken from the
+	// msg, message or error field of a JSON body, or from a plain text body, otherwise the message
+	// of the ecode is used.
+	Translate bool `yaml:"translate" json:"translate"`
+}
+
+// ErrorPage is the response of a gateway error, the body is either a template or a static file.
+type ErrorPage struct {
+	Status int `yaml:"status" json:"status"` // 502, 503 or 504
+
+	// Template is a text/template of the response body, the fields are .Status, .Code, .Message,
+	// .Method, .Path and .RequestID, and {{json .Message}} quotes a value as JSON string.
+	Template string `yaml:"template" json:"template"`
+	// File is the path of a static page, it is read once when the route is created.
+	File string `yaml:"file" json:"file"`
+	// ContentType of the response, default is application/json for template, and is detected
+	// by the file extension for file.
+	ContentType string `yaml:"contentType" json:"contentType"`
+}
+
+// IsZero reports whether the config does not change the error responses.
+func (c ErrorPageConfig) IsZero() bool {
+	return len(c.Pages) == 0 && !c.Translate
+}
+
+// WithErrorPages set the custom error pages and the upstream error translation of the route.
+func WithErrorPages(cfg ErrorPageConfig) ProxyOption {
+	return func(o *proxyOptions) {
+		o.errorPage = cfg
+	}
+}
+
+// ErrorPages renders the error responses of a route.
+type ErrorPages struct {
+	pages     map[int]*errorPage
+	translate bool
+}
+
+type errorPage struct {
+	contentType string
+	tmpl        *template.Template // nil means the static body is used
+	body        []byte
+}
+
+// errorEnvelope is the same as the response format of sponge services.
+type errorEnvelope struct {
+	Code int         `json:"code"`
+	Msg  string      `json:"msg"`
+	Data interface{} `json:"data"`
+}
+
+// errorPageData is the data of error page templates.
+type errorPageData struct {
+	Status    int
+	Code      int
+	Message   string
+	Method    string
+	Path      string
+	RequestID string
+}
+
+var errorPageFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// NewErrorPages creates the error pages of a route, return nil if the config does not change the error responses.
+func NewErrorPages(cfg ErrorPageConfig) (*ErrorPages, error) {
+	if cfg.IsZero() {
+		return nil, nil
+	}
+
+	e := &ErrorPages{pages: make(map[int]*errorPage, len(cfg.Pages)), translate: cfg.Translate}
+	for _, p := range cfg.Pages {
+		switch p.Status {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		default:
+			return nil, fmt.Errorf("unsupported status %d, must be 502, 503 or 504", p.Status)
+		}
+		if _, ok := e.pages[p.Status]; ok {
+			return nil, fmt.Errorf("duplicate page of status %d", p.Status)
+		}
+		if (p.Template == "") == (p.File == "") {
+			return nil, fmt.Errorf("page of status %d: one of template and file must be specified", p.Status)
+		}
+
+		page := &errorPage{contentType: p.ContentType}
+		if p.Template != "" {
+			tmpl, err := template.New(strconv.Itoa(p.Status)).Funcs(errorPageFuncs).Parse(p.Template)
+			if err != nil {
+				return nil, fmt.Errorf("page of status %d: %v", p.Status, err)
+			}
+			page.tmpl = tmpl
+			if page.contentType == "" {
+				page.contentType = "application/json; charset=utf-8"
+			}
+		} else {
+			body, err := os.ReadFile(p.File)
+			if err != nil {
+				return nil, fmt.Errorf("page of status %d: %v", p.Status, err)
+			}
+			page.body = body
+			if page.contentType == "" {
+				page.contentType = mime.TypeByExtension(filepath.Ext(p.File))
+			}
+			if page.contentType == "" {
+				page.contentType = http.DetectContentType(body)
+			}
+		}
+		e.pages[p.Status] = page
+	}
+	return e, nil
+}
+
+// wrap returns the writer that renders the error responses, it returns w if nothing is configured
+// or the request is gRPC, whose errors are carried by the grpc-status trailer.
+func (e *ErrorPages) wrap(w http.ResponseWriter, r *http.Request) http.ResponseWriter {
+	if e == nil || isGRPCRequest(r) {
+		return w
+	}
+	return &errorWriter{ResponseWriter: w, pages: e, r: r}
+}
+
+// errorWriter replaces the gateway errors with the custom pages, and buffers the error responses
+// to translate them into the ecode envelope when the response is finished.
+type errorWriter struct {
+	http.ResponseWriter
+	pages *ErrorPages
+	r     *http.Request
+
+	gatewayCode int // status of the error response generated by the gateway, 0 means none
+	gatewayMsg  string
+	wroteHeader bool
+	discard     bool // the custom page has been written, the original body is dropped
+	buffering   bool
+	status      int
+	body        bytes.Buffer
+}
+
+// markGatewayError marks the following error response as generated by the gateway, so that
+// it can be replaced by the custom page.
+func (ew *errorWriter) markGatewayError(code int, msg string) {
+	ew.gatewayCode = code
+	ew.gatewayMsg = msg
+}
+
+func (ew *errorWriter) WriteHeader(code int) {
+	if code < 200 {
+		ew.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if ew.wroteHeader {
+		return
+	}
+	ew.wroteHeader = true
+	if code != ew.gatewayCode {
+		// the gateway error is replaced by another response, e.g. 413 of the oversized request body
+		ew.gatewayCode, ew.gatewayMsg = 0, ""
+	}
+
+	if ew.gatewayCode != 0 {
+		if page, ok := ew.pages.pages[code]; ok && ew.writePage(page, code) {
+			ew.discard = true
+			return
+		}
+	}
+	h := ew.Header()
+	if ew.pages.translate && code >= http.StatusBadRequest && h.Get("Content-Encoding") == "" {
+		if n, err := strconv.ParseInt(h.Get("Content-Length"), 10, 64); err != nil || n <= maxTranslateBodySize {
+			ew.buffering = true
+			ew.status = code
+			return
+		}
+	}
+	ew.ResponseWriter.WriteHeader(code)
+}
+
+func (ew *errorWriter) Write(p []byte) (int, error) {
+	if !ew.wroteHeader {
+		ew.WriteHeader(http.StatusOK)
+	}
+	if ew.discard {
+		return len(p), nil
+	}
+	if !ew.buffering {
+		return ew.ResponseWriter.Write(p)
+	}
+	if ew.body.Len()+len(p) <= maxTranslateBodySize {
+		return ew.body.Write(p)
+	}
+
+	// the body is too large to be translated, send the original response
+	ew.buffering = false
+	ew.ResponseWriter.WriteHeader(ew.status)
+	if _, err := ew.ResponseWriter.Write(ew.body.Bytes()); err != nil {
+		return 0, err
+	}
+	ew.body = bytes.Buffer{}
+	return ew.ResponseWriter.Write(p)
+}
+
+// Flush does nothing while buffering, otherwise the buffered status would be sent as 200.
+func (ew *errorWriter) Flush() {
+	if ew.buffering || ew.discard {
+		return
+	}
+	_ = http.NewResponseController(ew.ResponseWriter).Flush()
+}
+
+// Unwrap is used by http.ResponseController to access the underlying writer (e.g. deadlines).
+func (ew *errorWriter) Unwrap() http.ResponseWriter {
+	return ew.ResponseWriter
+}
+
+// finish writes the buffered error response in the ecode envelope.
+func (ew *errorWriter) finish() {
+	if !ew.buffering {
+		return
+	}
+	ew.buffering = false
+
+	h := ew.Header()
+	body := ew.body.Bytes()
+	if isEcodeEnvelope(h.Get("Content-Type"), body) {
+		ew.ResponseWriter.WriteHeader(ew.status)
+		_, _ = ew.ResponseWriter.Write(body)
+		return
+	}
+
+	e := ecodeOfStatus(ew.status)
+	msg := ew.gatewayMsg
+	if msg == "" {
+		msg = upstreamErrorMsg(h.Get("Content-Type"), body)
+	}
+	if msg == "" {
+		msg = e.Msg()
+	}
+	data, _ := json.Marshal(errorEnvelope{Code: e.Code(), Msg: msg, Data: struct{}{}})
+	ew.writeBody(ew.status, "application/json; charset=utf-8", data)
+}
+
+// finishErrorWriter finishes w if it is an errorWriter, it can be called more than once.
+func finishErrorWriter(w http.ResponseWriter) {
+	if ew, ok := w.(*errorWriter); ok {
+		ew.finish()
+	}
+}
+
+// writePage writes the custom page, it returns false if the template fails to execute.
+func (ew *errorWriter) writePage(page *errorPage, code int) bool {
+	body := page.body
+	if page.tmpl != nil {
+		var buf bytes.Buffer
+		err := page.tmpl.Execute(&buf, errorPageData{
+			Status:    code,
+			Code:      ecodeOfStatus(code).Code(),
+			Message:   ew.gatewayMsg,
+			Method:    ew.r.Method,
+			Path:      ew.r.URL.Path,
+			RequestID: ew.r.Header.Get("X-Request-Id"),
+		})
+		if err != nil {
+			log.Printf("[Proxy] execute error page of status %d error: %v", code, err)
+			return false
+		}
+		body = buf.Bytes()
+	}
+	ew.writeBody(code, page.contentType, body)
+	return true
+}
+
+func (ew *errorWriter) writeBody(code int, contentType string, body []byte) {
+	h := ew.Header()
+	h.Del("Content-Encoding")
+	h.Del("Transfer-Encoding")
+	h.Set("Content-Type", contentType)
+	h.Set("Content-Length", strconv.Itoa(len(body)))
+	ew.ResponseWriter.WriteHeader(code)
+	_, _ = ew.ResponseWriter.Write(body)
+}
+
+// writeGatewayError writes an error generated by the gateway, the custom page of the
+// status is used if the route has one.
+func writeGatewayError(w http.ResponseWriter, code int, msg string) {
+	if ew := findErrorWriter(w); ew != nil {
+		ew.markGatewayError(code, msg)
+	}
+	http.Error(w, msg, code)
+}
+
+// handleUpstreamError is the error handler of backends, the request timeouts receive 504,
+// the other errors (e.g. connection refused) receive 502.
+func handleUpstreamError(host string) func(http.ResponseWriter, *http.Request, error) {
+	return func(w http.ResponseWriter, r *http.Request, err error) {
+		log.Printf("[Proxy] backend %s error: %v", host, err)
+		code := http.StatusBadGateway
+		var netErr net.Error
+		if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+			code = http.StatusGatewayTimeout
+		}
+		if ew := findErrorWriter(w); ew != nil {
+			ew.markGatewayError(code, ecodeOfStatus(code).Msg())
+		}
+		w.WriteHeader(code)
+	}
+}
+
+// findErrorWriter returns the errorWriter in the chain of writers, nil if the route has no error pages.
+func findErrorWriter(w http.ResponseWriter) *errorWriter {
+	for {
+		switch v := w.(type) {
+		case *errorWriter:
+			return v
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = v.Unwrap()
+		default:
+			return nil
+		}
+	}
+}
+
+// ecodeOfStatus returns the sponge ecode corresponding to the http status.
+func ecodeOfStatus(code int) *errcode.Error {
+	switch code {
+	case http.StatusBadRequest:
+		return errcode.InvalidParams
+	case http.StatusUnauthorized:
+		return errcode.Unauthorized
+	case http.StatusForbidden:
+		return errcode.Forbidden
+	case http.StatusNotFound:
+		return errcode.NotFound
+	case http.StatusMethodNotAllowed:
+		return errcode.MethodNotAllowed
+	case http.StatusRequestTimeout:
+		return errcode.Timeout
+	case http.StatusConflict:
+		return errcode.Conflict
+	case http.StatusTooEarly:
+		return errcode.TooEarly
+	case http.StatusTooManyRequests:
+		return errcode.TooManyRequests
+	case http.StatusNotImplemented:
+		return errcode.Unimplemented
+	case http.StatusBadGateway:
+		return errcode.StatusBadGateway
+	case http.StatusServiceUnavailable:
+		return errcode.ServiceUnavailable
+	case http.StatusGatewayTimeout:
+		return errcode.DeadlineExceeded
+	}
+	if code >= http.StatusInternalServerError {
+		return errcode.InternalServerError
+	}
+	return errcode.Unknown
+}
+
+// isEcodeEnvelope reports whether the body is already in the form of {"code":0,"msg":"","data":{}}.
+func isEcodeEnvelope(contentType string, body []byte) bool {
+	if !strings.Contains(contentType, "json") {
+		return false
+	}
+	var v struct {
+		Code *json.Number `json:"code"`
+		Msg  *string      `json:"msg"`
+	}
+	return json.Unmarshal(body, &v) == nil && v.Code != nil && v.Msg != nil
+}
+
+// upstreamErrorMsg extracts the message from the error body of backend, return empty if not found.
+func upstreamErrorMsg(contentType string, body []byte) string {
+	if strings.Contains(contentType, "json") {
+		var m map[string]interface{}
+		if json.Unmarshal(body, &m) != nil {
+			return ""
+		}
+		for _, key := range []string{"msg", "message", "error"} {
+			if s, ok := m[key].(string); ok && s != "" {
+				return s
+			}
+		}
+		return ""
+	}
+	if !strings.HasPrefix(contentType, "text/plain") || !utf8.Valid(body) {
+		return ""
+	}
+	msg := strings.TrimSpace(string(body))
+	if utf8.RuneCountInString(msg) > maxTranslateMsgLen {
+		msg = string([]rune(msg)[:maxTranslateMsgLen]) + "..."
+	}
+	return msg
+}
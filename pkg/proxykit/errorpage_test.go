@@ -0,0 +1,186 @@
+package proxykit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewErrorPages(t *testing.T) {
+	t.Parallel()
+	if e, err := NewErrorPages(ErrorPageConfig{}); err != nil || e != nil {
+		t.Errorf("expected nil error pages without config, got %v %v", e, err)
+	}
+	invalid := []ErrorPageConfig{
+		{Pages: []ErrorPage{{Status: 500, Template: "x"}}},
+		{Pages: []ErrorPage{{Status: 502}}},
+		{Pages: []ErrorPage{{Status: 502, Template: "x", File: "x.html"}}},
+		{Pages: []ErrorPage{{Status: 502, Template: "x"}, {Status: 502, Template: "y"}}},
+		{Pages: []ErrorPage{{Status: 503, Template: "{{.Message"}}},
+		{Pages: []ErrorPage{{Status: 504, File: "not-exist.html"}}},
+	}
+	for _, cfg := range invalid {
+		if _, err := NewErrorPages(cfg); err == nil {
+			t.Errorf("expected error for %+v", cfg)
+		}
+	}
+
+	cfg := &Config{Routes: []RouteConfig{{PrefixPath: "/api/", ErrorPage: ErrorPageConfig{Pages: []ErrorPage{{Status: 404, Template: "x"}}}}}}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "errorPage") {
+		t.Errorf("expected errorPage validation error, got %v", err)
+	}
+}
+
+func newErrorPageProxy(t *testing.T, handler http.HandlerFunc, cfg ErrorPageConfig) *Proxy {
+	var targets []string
+	if handler != nil {
+		s := httptest.NewServer(handler)
+		t.Cleanup(s.Close)
+		targets = append(targets, s.URL)
+	}
+	backends, err := ParseBackends("/", targets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewProxy(NewRoundRobin(backends), WithErrorPages(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestProxy_ErrorPages(t *testing.T) {
+	t.Parallel()
+	file := filepath.Join(t.TempDir(), "502.html")
+	if err := os.WriteFile(file, []byte("<h1>upstream is down</h1>"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := ErrorPageConfig{Pages: []ErrorPage{
+		{Status: 502, File: file},
+		{Status: 503, Template: `{"code":{{.Code}},"msg":{{json .Message}},"path":"{{.Path}}","requestId":"{{.RequestID}}"}`},
+		{Status: 504, Template: `timeout {{.Status}}`, ContentType: "text/plain"},
+	}}
+
+	t.Run("503 no backend", func(t *testing.T) {
+		p := newErrorPageProxy(t, nil, cfg)
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		req.Header.Set("X-Request-Id", "abc")
+		rr := httptest.NewRecorder()
+		p.ServeHTTP(rr, req)
+		want := `{"code":100013,"msg":"service not available","path":"/users","requestId":"abc"}`
+		if rr.Code != http.StatusServiceUnavailable || rr.Body.String() != want {
+			t.Errorf("unexpected response: %d %s", rr.Code, rr.Body.String())
+		}
+		if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+			t.Errorf("unexpected content type %s", ct)
+		}
+	})
+
+	t.Run("502 backend down", func(t *testing.T) {
+		s := httptest.NewServer(http.NotFoundHandler())
+		s.Close()
+		backends, _ := ParseBackends("/", []string{s.URL})
+		p, err := NewProxy(NewRoundRobin(backends), WithErrorPages(cfg))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		p.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rr.Code != http.StatusBadGateway || rr.Body.String() != "<h1>upstream is down</h1>" {
+			t.Errorf("unexpected response: %d %s", rr.Code, rr.Body.String())
+		}
+		if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+			t.Errorf("unexpected content type %s", ct)
+		}
+	})
+
+	t.Run("504 upstream timeout", func(t *testing.T) {
+		p := newErrorPageProxy(t, func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-time.After(time.Second):
+			case <-r.Context().Done():
+			}
+		}, cfg)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		rr := httptest.NewRecorder()
+		p.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+		if rr.Code != http.StatusGatewayTimeout || rr.Body.String() != "timeout 504" {
+			t.Errorf("unexpected response: %d %s", rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("upstream 503 is not replaced", func(t *testing.T) {
+		p := newErrorPageProxy(t, func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "maintenance", http.StatusServiceUnavailable)
+		}, cfg)
+		rr := httptest.NewRecorder()
+		p.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rr.Code != http.StatusServiceUnavailable || rr.Body.String() != "maintenance\n" {
+			t.Errorf("unexpected response: %d %s", rr.Code, rr.Body.String())
+		}
+	})
+}
+
+func TestProxy_TranslateErrors(t *testing.T) {
+	t.Parallel()
+	largeBody := strings.Repeat("x", maxTranslateBodySize+1)
+	p := newErrorPageProxy(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/text":
+			http.Error(w, "database is down", http.StatusInternalServerError)
+		case "/json":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"user not found"}`))
+		case "/envelope":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"code":20101,"msg":"invalid name","data":{}}`))
+		case "/html":
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte("<html>bad gateway</html>"))
+		case "/large":
+			w.Header().Set("Content-Type", "text/plain")
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(largeBody))
+		default:
+			_, _ = w.Write([]byte("ok"))
+		}
+	}, ErrorPageConfig{Translate: true})
+
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"/text", http.StatusInternalServerError, `{"code":100003,"msg":"database is down","data":{}}`},
+		{"/json", http.StatusNotFound, `{"code":100004,"msg":"user not found","data":{}}`},
+		{"/envelope", http.StatusBadRequest, `{"code":20101,"msg":"invalid name","data":{}}`},
+		{"/html", http.StatusBadGateway, `{"code":100023,"msg":"Bad Gateway","data":{}}`},
+		{"/large", http.StatusInternalServerError, largeBody},
+		{"/ok", http.StatusOK, "ok"},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		p.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rr.Code != tt.wantStatus || rr.Body.String() != tt.wantBody {
+			t.Errorf("%s: unexpected response: %d %.100s", tt.path, rr.Code, rr.Body.String())
+		}
+	}
+
+	// the gateway errors without custom page are translated too
+	p = newErrorPageProxy(t, nil, ErrorPageConfig{Translate: true})
+	rr := httptest.NewRecorder()
+	p.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	want := `{"code":100013,"msg":"service not available","data":{}}`
+	if rr.Code != http.StatusServiceUnavailable || rr.Body.String() != want {
+		t.Errorf("unexpected response: %d %s", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,114 @@
+package proxykit
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// gRPC status codes used by the proxy, see https://grpc.github.io/grpc/core/md_doc_statuscodes.html
+const (
+	grpcCodeDeadlineExceeded  = 4
+	grpcCodePermissionDenied  = 7
+	grpcCodeResourceExhausted = 8
+	grpcCodeUnavailable       = 14
+)
+
+// ParseGRPCBackends converts a list of URL strings into gRPC backends, http:// targets are
+// connected with HTTP/2 cleartext (h2c), https:// targets with HTTP/2 over TLS.
+func ParseGRPCBackends(prefixPath string, targets []string) ([]*Backend, error) {
+	var backends []*Backend
+	for _, t := range targets {
+		u, err := url.Parse(t)
+		if err != nil {
+			return nil, err
+		}
+		backends = append(backends, NewGRPCBackend(prefixPath, u))
+	}
+	return backends, nil
+}
+
+// NewGRPCBackend creates a backend for proxying gRPC traffic. The request path (/package.Service/Method)
+// is forwarded unchanged, prefixPath is only used to identify the route. Responses are flushed
+// immediately, trailers (grpc-status, grpc-message) are propagated, and each RPC is load balanced
+// independently.
+func NewGRPCBackend(prefixPath string, u *url.URL) *Backend {
+	var tlsConfig *tls.Config // h2c for http:// targets
+	if u.Scheme == "https" {
+		tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	proxy := &httputil.ReverseProxy{
+		Director: func(req *http.Request) {
+			req.URL.Scheme = u.Scheme
+			req.URL.Host = u.Host
+			req.Header.Set("X-Forwarded-Host", req.Host)
+			req.Header.Set("X-Origin-Host", u.Host)
+		},
+		Transport:     newGRPCTransport(tlsConfig),
+		FlushInterval: -1,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			log.Printf("[Proxy] grpc backend %s error: %v", u.Host, err)
+			writeGRPCError(w, grpcCodeUnavailable, "upstream unavailable")
+		},
+	}
+
+	b := &Backend{
+		URL:             u,
+		route:           prefixPath,
+		proxy:           proxy,
+		isGRPC:          true,
+		stopHealthCheck: make(chan struct{}),
+	}
+	b.SetHealthy(true)
+	return b
+}
+
+// newGRPCTransport returns an HTTP/2 transport, if tlsConfig is nil, connections use h2c,
+// which is only used for http:// targets.
+func newGRPCTransport(tlsConfig *tls.Config) http.RoundTripper {
+	if tlsConfig != nil {
+		tlsConfig = tlsConfig.Clone()
+		tlsConfig.NextProtos = []string{http2.NextProtoTLS}
+		return &http2.Transport{
+			TLSClientConfig: tlsConfig,
+			ReadIdleTimeout: 30 * time.Second,
+		}
+	}
+
+	return &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+		ReadIdleTimeout: 30 * time.Second,
+	}
+}
+
+// GRPCHandler wraps the handler so that it accepts HTTP/2 cleartext (h2c) connections,
+// which is required for proxying gRPC on a listener without TLS.
+func GRPCHandler(h http.Handler) http.Handler {
+	return h2c.NewHandler(h, &http2.Server{})
+}
+
+func isGRPCRequest(r *http.Request) bool {
+	return r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc")
+}
+
+// writeGRPCError writes a trailers-only gRPC response with the given status.
+func writeGRPCError(w http.ResponseWriter, code int, msg string) {
+	h := w.Header()
+	h.Set("Content-Type", "application/grpc")
+	h.Set("Grpc-Status", strconv.Itoa(code))
+	h.Set("Grpc-Message", msg)
+	w.WriteHeader(http.StatusOK)
+}
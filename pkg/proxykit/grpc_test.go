@@ -0,0 +1,171 @@
+package proxykit
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"golang.org/x/net/http2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func startGRPCServer(t *testing.T) (string, *health.Server) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+	return "http://" + lis.Addr().String(), healthServer
+}
+
+func dialProxy(t *testing.T, handler http.Handler) healthpb.HealthClient {
+	proxyServer := httptest.NewServer(GRPCHandler(handler))
+	t.Cleanup(proxyServer.Close)
+
+	conn, err := grpc.NewClient(proxyServer.Listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return healthpb.NewHealthClient(conn)
+}
+
+func TestGRPCProxy(t *testing.T) {
+	t.Parallel()
+	target1, hs1 := startGRPCServer(t)
+	target2, hs2 := startGRPCServer(t)
+	hs1.SetServingStatus("svc", healthpb.HealthCheckResponse_SERVING)
+	hs2.SetServingStatus("svc", healthpb.HealthCheckResponse_SERVING)
+
+	m := NewRouteManager()
+	err := m.ApplyConfig(&Config{Routes: []RouteConfig{{
+		PrefixPath: "/grpc.health.v1.Health/",
+		Protocol:   ProtocolGRPC,
+		Targets:    []string{target1, target2},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := dialProxy(t, m)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// unary calls are balanced per RPC over the same client connection
+	for i := 0; i < 4; i++ {
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: "svc"})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if resp.Status != healthpb.HealthCheckResponse_SERVING {
+			t.Errorf("unexpected status %v", resp.Status)
+		}
+	}
+
+	// error status in trailers is propagated
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: "unknown"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound, got %v", err)
+	}
+
+	// server streaming
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: "svc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("unexpected status %v", resp.Status)
+	}
+}
+
+func TestGRPCProxy_Errors(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	t.Run("No Backends", func(t *testing.T) {
+		proxy, _ := NewProxy(NewRoundRobin(nil))
+		_, err := dialProxy(t, proxy).Check(ctx, &healthpb.HealthCheckRequest{})
+		if status.Code(err) != codes.Unavailable {
+			t.Errorf("expected Unavailable, got %v", err)
+		}
+	})
+
+	t.Run("Upstream Down", func(t *testing.T) {
+		backends, _ := ParseGRPCBackends("", []string{"http://127.0.0.1:1"})
+		proxy, _ := NewProxy(NewRoundRobin(backends))
+		_, err := dialProxy(t, proxy).Check(ctx, &healthpb.HealthCheckRequest{})
+		if status.Code(err) != codes.Unavailable {
+			t.Errorf("expected Unavailable, got %v", err)
+		}
+	})
+
+	t.Run("Limit Exceeded", func(t *testing.T) {
+		target, _ := startGRPCServer(t)
+		backends, _ := ParseGRPCBackends("", []string{target})
+		proxy, _ := NewProxy(NewRoundRobin(backends), WithRouteLimit(LimitConfig{RPS: 1, Burst: 1}))
+		client := dialProxy(t, proxy)
+		_, _ = client.Check(ctx, &healthpb.HealthCheckRequest{})
+		_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+		if status.Code(err) != codes.ResourceExhausted {
+			t.Errorf("expected ResourceExhausted, got %v", err)
+		}
+	})
+}
+
+func TestGRPCProxy_TLSBackend(t *testing.T) {
+	t.Parallel()
+	server := grpc.NewServer()
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+	healthServer.SetServingStatus("svc", healthpb.HealthCheckResponse_SERVING)
+	ts := httptest.NewUnstartedServer(server)
+	ts.EnableHTTP2 = true
+	ts.StartTLS()
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	u, _ := url.Parse(ts.URL)
+
+	// https:// targets are connected with TLS, the certificate is verified by default
+	backend := NewGRPCBackend("", u)
+	transport, ok := backend.proxy.Transport.(*http2.Transport)
+	if !ok || transport.AllowHTTP || transport.TLSClientConfig == nil {
+		t.Fatal("expected HTTP/2 over TLS transport for https target")
+	}
+	proxy, _ := NewProxy(&mockBalancer{backend: backend})
+	_, err := dialProxy(t, proxy).Check(ctx, &healthpb.HealthCheckRequest{Service: "svc"})
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("expected Unavailable for untrusted certificate, got %v", err)
+	}
+
+	// trust the certificate of test server
+	backend = NewGRPCBackend("", u)
+	backend.proxy.Transport.(*http2.Transport).TLSClientConfig.RootCAs = ts.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+	proxy, _ = NewProxy(&mockBalancer{backend: backend})
+	resp, err := dialProxy(t, proxy).Check(ctx, &healthpb.HealthCheckRequest{Service: "svc"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("unexpected status %v", resp.Status)
+	}
+}
@@ -0,0 +1,131 @@
+package proxykit
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+var (
+	// ErrTooManyRequests is returned when a route or backend limit is exceeded.
+	ErrTooManyRequests = errors.New("too many requests")
+)
+
+// LimitConfig defines the concurrency and rate caps applied to a route or a backend,
+// a zero value means no limit.
+type LimitConfig struct {
+	MaxConcurrent int     `yaml:"maxConcurrent" json:"maxConcurrent"` // maximum number of in-flight requests
+	RPS           float64 `yaml:"rps" json:"rps"`                     // maximum requests per second
+	Burst         int     `yaml:"burst" json:"burst"`                 // maximum burst size for rps, default is rps rounded up
+}
+
+// IsZero reports whether the config does not limit anything.
+func (c LimitConfig) IsZero() bool {
+	return c.MaxConcurrent <= 0 && c.RPS <= 0
+}
+
+// Limiter caps the number of in-flight requests and the request rate.
+type Limiter struct {
+	maxConcurrent int64
+	inFlight      atomic.Int64
+	rps           *rate.Limiter
+}
+
+// NewLimiter creates a new limiter, return nil if the config does not limit anything.
+func NewLimiter(cfg LimitConfig) *Limiter {
+	if cfg.IsZero() {
+		return nil
+	}
+
+	l := &Limiter{maxConcurrent: int64(cfg.MaxConcurrent)}
+	if cfg.RPS > 0 {
+		burst := cfg.Burst
+		if burst <= 0 {
+			burst = int(math.Ceil(cfg.RPS))
+		}
+		l.rps = rate.NewLimiter(rate.Limit(cfg.RPS), burst)
+	}
+	return l
+}
+
+// Acquire tries to take a slot, if successful, the returned release function must be called
+// when the request is finished, otherwise it returns the suggested time to retry.
+func (l *Limiter) Acquire() (release func(), retryAfter time.Duration, ok bool) {
+	return acquireLimits(l)
+}
+
+// acquireLimits takes a slot of each limiter, nil limiters are skipped. The in-flight caps of all
+// limiters are checked before any rate token is taken, and the rate tokens already taken are returned
+// if a limiter rejects the request, so a rejected request does not consume the rate budget.
+func acquireLimits(limiters ...*Limiter) (release func(), retryAfter time.Duration, ok bool) {
+	var held []*Limiter // limiters whose in-flight slot is taken
+	releaseSlots := func() {
+		for _, l := range held {
+			l.inFlight.Add(-1)
+		}
+	}
+
+	for _, l := range limiters {
+		if l == nil || l.maxConcurrent <= 0 {
+			continue
+		}
+		if l.inFlight.Add(1) > l.maxConcurrent {
+			l.inFlight.Add(-1)
+			releaseSlots()
+			return nil, time.Second, false
+		}
+		held = append(held, l)
+	}
+
+	// the reservations are made and canceled at the same time, so that the canceled tokens are restored
+	now := time.Now()
+	var reservations []*rate.Reservation
+	for _, l := range limiters {
+		if l == nil || l.rps == nil {
+			continue
+		}
+		r := l.rps.ReserveN(now, 1)
+		if delay := r.DelayFrom(now); delay > 0 {
+			r.CancelAt(now)
+			for _, taken := range reservations {
+				taken.CancelAt(now)
+			}
+			releaseSlots()
+			return nil, delay, false
+		}
+		reservations = append(reservations, r)
+	}
+
+	var once atomic.Bool
+	return func() {
+		if once.CompareAndSwap(false, true) {
+			releaseSlots()
+		}
+	}, 0, true
+}
+
+// InFlight returns the number of requests currently holding a slot.
+func (l *Limiter) InFlight() int64 {
+	if l == nil {
+		return 0
+	}
+	return l.inFlight.Load()
+}
+
+func writeTooManyRequests(w http.ResponseWriter, r *http.Request, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	if isGRPCRequest(r) {
+		writeGRPCError(w, grpcCodeResourceExhausted, ErrTooManyRequests.Error())
+		return
+	}
+	http.Error(w, ErrTooManyRequests.Error(), http.StatusTooManyRequests)
+}
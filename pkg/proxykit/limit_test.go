@@ -0,0 +1,156 @@
+package proxykit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestNewLimiter(t *testing.T) {
+	t.Parallel()
+	if l := NewLimiter(LimitConfig{}); l != nil {
+		t.Error("expected nil limiter for zero config")
+	}
+
+	// nil limiter never rejects
+	var l *Limiter
+	release, _, ok := l.Acquire()
+	if !ok {
+		t.Fatal("expected nil limiter to accept")
+	}
+	release()
+}
+
+func TestLimiter_MaxConcurrent(t *testing.T) {
+	t.Parallel()
+	l := NewLimiter(LimitConfig{MaxConcurrent: 2})
+
+	r1, _, ok1 := l.Acquire()
+	r2, _, ok2 := l.Acquire()
+	_, retryAfter, ok3 := l.Acquire()
+	if !ok1 || !ok2 {
+		t.Fatal("expected first two acquires to succeed")
+	}
+	if ok3 {
+		t.Fatal("expected third acquire to be rejected")
+	}
+	if retryAfter <= 0 {
+		t.Errorf("expected positive retryAfter, got %v", retryAfter)
+	}
+	if n := l.InFlight(); n != 2 {
+		t.Errorf("expected 2 in-flight, got %d", n)
+	}
+
+	r1()
+	r1() // release is idempotent
+	if n := l.InFlight(); n != 1 {
+		t.Errorf("expected 1 in-flight, got %d", n)
+	}
+	r2()
+	if _, _, ok := l.Acquire(); !ok {
+		t.Error("expected acquire to succeed after release")
+	}
+}
+
+func TestLimiter_RPS(t *testing.T) {
+	t.Parallel()
+	l := NewLimiter(LimitConfig{RPS: 1, Burst: 1})
+
+	if _, _, ok := l.Acquire(); !ok {
+		t.Fatal("expected first acquire to succeed")
+	}
+	_, retryAfter, ok := l.Acquire()
+	if ok {
+		t.Fatal("expected second acquire to be rejected")
+	}
+	if retryAfter <= 0 || retryAfter > time.Second {
+		t.Errorf("unexpected retryAfter %v", retryAfter)
+	}
+}
+
+func TestAcquireLimits(t *testing.T) {
+	t.Parallel()
+	route := NewLimiter(LimitConfig{RPS: 1, Burst: 1})
+	backend := NewLimiter(LimitConfig{MaxConcurrent: 1})
+
+	// the request rejected by the backend in-flight cap does not take the route rate token
+	hold, _, _ := backend.Acquire()
+	if _, _, ok := acquireLimits(route, backend); ok {
+		t.Fatal("expected acquire to be rejected by the backend limit")
+	}
+	hold()
+	release, _, ok := acquireLimits(route, backend)
+	if !ok {
+		t.Fatal("expected the route rate token to be kept")
+	}
+	release()
+
+	// the rate token taken is returned if another limiter rejects the request
+	route = NewLimiter(LimitConfig{RPS: 1, Burst: 1})
+	backend = NewLimiter(LimitConfig{RPS: 1, Burst: 1})
+	if _, _, ok = backend.Acquire(); !ok {
+		t.Fatal("expected acquire to succeed")
+	}
+	if _, _, ok = acquireLimits(route, backend); ok {
+		t.Fatal("expected acquire to be rejected by the backend rate")
+	}
+	if _, _, ok = route.Acquire(); !ok {
+		t.Error("expected the route rate token to be returned")
+	}
+	if n := backend.InFlight(); n != 0 {
+		t.Errorf("expected 0 in-flight, got %d", n)
+	}
+}
+
+func TestProxy_Limits(t *testing.T) {
+	t.Parallel()
+
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backendServer.Close()
+	backendURL, _ := url.Parse(backendServer.URL)
+
+	t.Run("Route Limit", func(t *testing.T) {
+		backend := NewBackend("", backendURL)
+		proxy, _ := NewProxy(&mockBalancer{backend: backend}, WithRouteLimit(LimitConfig{RPS: 1, Burst: 1}))
+
+		rr := httptest.NewRecorder()
+		proxy.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/foo", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+
+		rr = httptest.NewRecorder()
+		proxy.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/foo", nil))
+		if rr.Code != http.StatusTooManyRequests {
+			t.Fatalf("expected status %d, got %d", http.StatusTooManyRequests, rr.Code)
+		}
+		if rr.Header().Get("Retry-After") == "" {
+			t.Error("expected Retry-After header")
+		}
+	})
+
+	t.Run("Backend Limit", func(t *testing.T) {
+		backend := NewBackend("", backendURL)
+		backend.SetLimit(LimitConfig{MaxConcurrent: 1})
+		proxy, _ := NewProxy(&mockBalancer{backend: backend})
+
+		// occupy the only slot
+		release, _, _ := backend.limiter.Load().Acquire()
+		rr := httptest.NewRecorder()
+		proxy.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/foo", nil))
+		if rr.Code != http.StatusTooManyRequests {
+			t.Fatalf("expected status %d, got %d", http.StatusTooManyRequests, rr.Code)
+		}
+
+		release()
+		rr = httptest.NewRecorder()
+		proxy.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/foo", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+	})
+}
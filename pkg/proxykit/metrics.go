@@ -0,0 +1,133 @@
+package proxykit
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	metricsNamespace = "proxykit"
+
+	requestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "requests_total",
+			Help:      "Total number of proxied requests.",
+		}, []string{"route", "backend", "method", "code"},
+	)
+
+	requestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "request_duration_seconds",
+			Help:      "Latencies of proxied requests in seconds.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "backend"},
+	)
+
+	activeConnections = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "backend_active_connections",
+			Help:      "Current number of in-flight requests per backend.",
+		}, []string{"route", "backend"},
+	)
+
+	backendHealthy = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "backend_healthy",
+			Help:      "Health status of the backend, 1 is healthy and 0 is unhealthy.",
+		}, []string{"route", "backend"},
+	)
+
+	backendDraining = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "backend_draining",
+			Help:      "Draining status of the backend, 1 is draining and receives no new requests.",
+		}, []string{"route", "backend"},
+	)
+
+	cacheRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "cache_requests_total",
+			Help:      "Total number of cacheable requests by result, the result is hit, stale, miss or bypass.",
+		}, []string{"route", "result"},
+	)
+
+	metricsOnce sync.Once
+)
+
+// RegisterMetrics registers the proxy metrics to the given registerer,
+// if reg is nil, the prometheus default registerer is used.
+func RegisterMetrics(reg prometheus.Registerer) error {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	for _, c := range []prometheus.Collector{requestsTotal, requestDuration, activeConnections, backendHealthy, backendDraining, cacheRequests} {
+		if err := reg.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MetricsHandler registers the proxy metrics to the prometheus default registerer (only once),
+// and returns the handler for exporting metrics, e.g. mux.Handle("/metrics", proxykit.MetricsHandler())
+func MetricsHandler() http.Handler {
+	metricsOnce.Do(func() {
+		if err := RegisterMetrics(nil); err != nil {
+			log.Printf("[Metrics] register metrics error: %v", err)
+		}
+	})
+	return promhttp.Handler()
+}
+
+func observeRequest(route string, backend string, method string, code int, elapsed time.Duration) {
+	requestsTotal.WithLabelValues(route, backend, method, strconv.Itoa(code)).Inc()
+	if backend != "" {
+		requestDuration.WithLabelValues(route, backend).Observe(elapsed.Seconds())
+	}
+}
+
+func deleteBackendMetrics(route string, backend string) {
+	activeConnections.DeleteLabelValues(route, backend)
+	backendHealthy.DeleteLabelValues(route, backend)
+	backendDraining.DeleteLabelValues(route, backend)
+}
+
+// ------------------------------------------------------------------------------------------
+
+// responseRecorder records the status code and body size written to the client.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func newResponseRecorder(w http.ResponseWriter) *responseRecorder {
+	return &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+}
+
+func (r *responseRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap is used by http.ResponseController to access the underlying writer (e.g. Flush).
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,102 @@
+package proxykit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRegisterMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	if err := RegisterMetrics(reg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := RegisterMetrics(reg); err == nil {
+		t.Error("expected an error when registering twice")
+	}
+
+	rr := httptest.NewRecorder()
+	MetricsHandler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+}
+
+func TestProxy_Metrics(t *testing.T) {
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer backendServer.Close()
+	backendURL, _ := url.Parse(backendServer.URL)
+
+	m := NewRouteManager()
+	backend := NewBackend("/metrics-test/", backendURL)
+	route, err := m.AddRoute("/metrics-test/", NewRoundRobin([]*Backend{backend}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	route.Proxy.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/metrics-test/foo", nil))
+
+	count := testutil.ToFloat64(requestsTotal.WithLabelValues("/metrics-test/", backendURL.String(), http.MethodGet, "201"))
+	if count != 1 {
+		t.Errorf("expected request count 1, got %v", count)
+	}
+	if v := testutil.ToFloat64(backendHealthy.WithLabelValues("/metrics-test/", backendURL.String())); v != 1 {
+		t.Errorf("expected backend healthy gauge 1, got %v", v)
+	}
+	if v := testutil.ToFloat64(activeConnections.WithLabelValues("/metrics-test/", backendURL.String())); v != 0 {
+		t.Errorf("expected active connections gauge 0, got %v", v)
+	}
+
+	backend.SetHealthy(false)
+	if v := testutil.ToFloat64(backendHealthy.WithLabelValues("/metrics-test/", backendURL.String())); v != 0 {
+		t.Errorf("expected backend healthy gauge 0, got %v", v)
+	}
+}
+
+func TestProxy_Tracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	oldTP, oldPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() {
+		otel.SetTracerProvider(oldTP)
+		otel.SetTextMapPropagator(oldPropagator)
+	}()
+
+	var traceparent string
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+	}))
+	defer backendServer.Close()
+	backendURL, _ := url.Parse(backendServer.URL)
+
+	proxy, _ := NewProxy(&mockBalancer{backend: NewBackend("", backendURL)})
+	req := httptest.NewRequest(http.MethodGet, "/foo", nil)
+	proxy.ServeHTTP(httptest.NewRecorder(), req)
+
+	if traceparent == "" {
+		t.Fatal("expected traceparent header to be propagated upstream")
+	}
+	if req.Header.Get("traceparent") != "" {
+		t.Error("expected incoming request headers not to be modified")
+	}
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if !strings.Contains(traceparent, spans[0].SpanContext.TraceID().String()) {
+		t.Errorf("traceparent '%s' does not match span trace id", traceparent)
+	}
+}
@@ -3,38 +3,208 @@ package proxykit
 import (
 	"errors"
 	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	oteltrace "go.opentelemetry.io/otel/trace"
 )
 
+const tracerName = "proxykit"
+
 // Proxy is a reverse proxy that implements the http.Handler interface.
 type Proxy struct {
-	balancer Balancer
+	balancer  Balancer
+	limiter   *Limiter
+	affinity  *affinity
+	shadow    *Shadow
+	accessLog *AccessLogger
+	body      *BodyConfig    // nil means no body limits and timeouts
+	access    *AccessControl // nil means all client IPs are allowed
+	cache     *ResponseCache // nil means responses are not cached
+	errors    *ErrorPages    // nil means the error responses are unchanged
+	route     string         // prefix path of the route, used as metrics label and span name
+}
+
+// ProxyOption set the proxy options.
+type ProxyOption func(*proxyOptions)
+
+type proxyOptions struct {
+	limit     LimitConfig
+	sticky    *StickySessionConfig
+	shadow    *Shadow
+	accessLog *AccessLogger
+	body      BodyConfig
+	access    AccessConfig
+	cache     *ResponseCache
+	errorPage ErrorPageConfig
+}
+
+func defaultProxyOptions() *proxyOptions {
+	return &proxyOptions{}
+}
+
+func (o *proxyOptions) apply(opts ...ProxyOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WithRouteLimit set the concurrency and rate caps shared by all backends of the route.
+func WithRouteLimit(cfg LimitConfig) ProxyOption {
+	return func(o *proxyOptions) {
+		o.limit = cfg
+	}
 }
 
 // NewProxy creates a new reverse proxy instance.
-func NewProxy(balancer Balancer) (*Proxy, error) {
+func NewProxy(balancer Balancer, opts ...ProxyOption) (*Proxy, error) {
 	if balancer == nil {
 		return nil, errors.New("balancer cannot be nil")
 	}
-	return &Proxy{
-		balancer: balancer,
-	}, nil
+	o := defaultProxyOptions()
+	o.apply(opts...)
+	if o.sticky != nil && o.sticky.Enable && o.sticky.Secret == "" {
+		return nil, errors.New("sticky session secret cannot be empty")
+	}
+	if err := o.body.Validate(); err != nil {
+		return nil, err
+	}
+	access, err := NewAccessControl(o.access)
+	if err != nil {
+		return nil, err
+	}
+	errorPages, err := NewErrorPages(o.errorPage)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Proxy{
+		balancer:  balancer,
+		limiter:   NewLimiter(o.limit),
+		affinity:  newAffinity(o.sticky),
+		shadow:    o.shadow,
+		accessLog: o.accessLog,
+		access:    access,
+		cache:     o.cache,
+		errors:    errorPages,
+	}
+	if !o.body.IsZero() {
+		p.body = &o.body
+	}
+	return p, nil
 }
 
 // ServeHTTP handles incoming HTTP requests and forwards them to the backend
 // selected by the load balancer.
 func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	// Select a healthy backend according to the load balancing strategy.
-	backend, err := p.balancer.Next(r)
+	start := time.Now()
+	rec := newResponseRecorder(w)
+	var backendLabel string
+	var retries int
+	defer func() {
+		observeRequest(p.route, backendLabel, r.Method, rec.status, time.Since(start))
+		p.accessLog.record(r, p.route, backendLabel, rec, start, retries)
+	}()
+
+	// Render the custom error pages and translate the error responses if configured.
+	w = p.errors.wrap(rec, r)
+	defer finishErrorWriter(w)
+
+	// Reject the client IPs denied by the access rules before any other work.
+	if !p.access.allowRequest(w, r, p.route) {
+		return
+	}
+
+	// Serve GET requests from the response cache, the backend is only called on a miss.
+	cw, served := p.cache.serve(w, r, p)
+	if served {
+		return
+	}
+
+	// Apply the slow client timeouts and reject the oversized request body before taking any slot.
+	reqBody, ok := p.body.prepare(w, r)
+	if !ok {
+		return
+	}
+
+	backend, err := p.selectBackend(w, r)
 	if err != nil {
 		log.Printf("[Proxy] error selecting backend: %v", err)
-		http.Error(w, "service not available", http.StatusServiceUnavailable)
+		if isGRPCRequest(r) {
+			writeGRPCError(w, grpcCodeUnavailable, "service not available")
+			return
+		}
+		writeGatewayError(w, http.StatusServiceUnavailable, "service not available")
+		return
+	}
+	backendLabel = backend.URL.String()
+
+	// Apply the route and backend level limits together, a request rejected by one of them
+	// does not consume the rate budget of the other.
+	release, retryAfter, ok := acquireLimits(p.limiter, backend.limiter.Load())
+	if !ok {
+		writeTooManyRequests(w, r, retryAfter)
 		return
 	}
+	defer release()
+
+	// Duplicate the request to the shadow backends if configured.
+	p.shadow.mirror(r)
 
 	// Increase the active connection count, and ensure it is decremented
 	// when the request completes.
 	backend.IncrementActiveConns()
 	defer backend.DecrementActiveConns()
 
-	backend.proxy.ServeHTTP(w, r)
+	r, span := p.startSpan(r, backend)
+	defer func() {
+		spanStatus, spanMessage := semconv.SpanStatusFromHTTPStatusCode(rec.status)
+		span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(rec.status)...)
+		span.SetStatus(spanStatus, spanMessage)
+		span.End()
+	}()
+
+	backend.proxy.ServeHTTP(cw.wrap(p.body.wrap(w, reqBody)), r)
+	cw.save()
+	finishErrorWriter(w) // write the translated response before the span records the status
+}
+
+// selectBackend returns the backend bound by the session affinity cookie if it is still healthy,
+// otherwise selects a healthy backend according to the load balancing strategy.
+func (p *Proxy) selectBackend(w http.ResponseWriter, r *http.Request) (*Backend, error) {
+	if p.affinity == nil {
+		return p.balancer.Next(r)
+	}
+
+	if backend := p.affinity.pick(r, p.balancer); backend != nil {
+		return backend, nil
+	}
+	backend, err := p.balancer.Next(r)
+	if err != nil {
+		return nil, err
+	}
+	p.affinity.bind(w, backend)
+	return backend, nil
+}
+
+// startSpan creates a client span for the upstream request and injects the trace context into
+// the outgoing headers, if no tracer provider is set, the global no-op provider is used.
+func (p *Proxy) startSpan(r *http.Request, backend *Backend) (*http.Request, oteltrace.Span) {
+	propagator := otel.GetTextMapPropagator()
+	ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "proxy "+p.route,
+		oteltrace.WithSpanKind(oteltrace.SpanKindClient),
+		oteltrace.WithAttributes(semconv.HTTPClientAttributesFromHTTPRequest(r)...),
+		oteltrace.WithAttributes(semconv.NetPeerNameKey.String(backend.URL.Host)),
+	)
+	if !span.SpanContext().IsValid() {
+		return r, span
+	}
+
+	r = r.WithContext(ctx)
+	r.Header = r.Header.Clone()
+	propagator.Inject(ctx, propagation.HeaderCarrier(r.Header))
+	return r, span
 }
@@ -14,6 +14,9 @@ type ManagementRequest struct {
 	PrefixPath  string            `json:"prefixPath"`
 	Targets     []string          `json:"targets"`
 	HealthCheck HealthCheckConfig `json:"healthCheck"`
+	Limit       LimitConfig       `json:"limit"`    // caps applied to each added backend
+	TLS         BackendTLSConfig  `json:"tls"`      // TLS settings used to connect to each added backend
+	Protocol    string            `json:"protocol"` // http or grpc, default is http
 }
 
 // Route holds all components for a specific routing rule.
@@ -23,6 +26,8 @@ type Route struct {
 	Balancer   Balancer
 	Proxy      *Proxy
 	mu         sync.RWMutex
+
+	config *RouteConfig // set when the route is declared in the configuration file
 }
 
 // RouteManager manages all routing rules.
@@ -39,13 +44,8 @@ func NewRouteManager() *RouteManager {
 }
 
 // AddRoute adds a new routing rule and configures its proxy to strip the given prefix.
-func (m *RouteManager) AddRoute(prefixPath string, balancer Balancer) (*Route, error) {
-	if !strings.HasPrefix(prefixPath, "/") {
-		prefixPath = "/" + prefixPath
-	}
-	if !strings.HasSuffix(prefixPath, "/") {
-		prefixPath = prefixPath + "/"
-	}
+func (m *RouteManager) AddRoute(prefixPath string, balancer Balancer, opts ...ProxyOption) (*Route, error) {
+	prefixPath = normalizePrefixPath(prefixPath)
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -54,21 +54,88 @@ func (m *RouteManager) AddRoute(prefixPath string, balancer Balancer) (*Route, e
 		return nil, fmt.Errorf("route for prefix '%s' already exists", prefixPath)
 	}
 
-	proxy, err := NewProxy(balancer)
+	route, err := newRoute(prefixPath, balancer, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	m.routes[prefixPath] = route
+	log.Printf("[Manager] added new route for prefix: %s", prefixPath)
+	return route, nil
+}
+
+// newRoute creates a route whose proxy strips the given prefix, the route is not registered in the manager.
+func newRoute(prefixPath string, balancer Balancer, opts ...ProxyOption) (*Route, error) {
+	proxy, err := NewProxy(balancer, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create proxy for '%s': %w", prefixPath, err)
 	}
+	proxy.route = prefixPath
 
-	route := &Route{
+	return &Route{
 		PrefixPath: prefixPath,
 		Backends:   balancer.GetBackends(),
 		Balancer:   balancer,
 		Proxy:      proxy,
+	}, nil
+}
+
+// RemoveRoute removes a routing rule and stops the health checks of its backends.
+func (m *RouteManager) RemoveRoute(prefixPath string) bool {
+	prefixPath = normalizePrefixPath(prefixPath)
+
+	m.mu.Lock()
+	route, exists := m.routes[prefixPath]
+	delete(m.routes, prefixPath)
+	m.mu.Unlock()
+	if !exists {
+		return false
 	}
 
-	m.routes[prefixPath] = route
-	log.Printf("[Manager] added new route for prefix: %s", prefixPath)
-	return route, nil
+	route.close()
+	log.Printf("[Manager] removed route for prefix: %s", prefixPath)
+	return true
+}
+
+// close stops the health checks of the backends of a route that is no longer registered.
+func (r *Route) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, b := range r.Backends {
+		b.StopHealthCheck()
+		deleteBackendMetrics(r.PrefixPath, b.URL.String())
+	}
+}
+
+// ServeHTTP dispatches the request to the route with the longest matching prefix path,
+// so routes added or removed at runtime take effect without re-registering handlers.
+func (m *RouteManager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.RLock()
+	var matched *Route
+	for prefixPath, route := range m.routes {
+		if strings.HasPrefix(r.URL.Path, prefixPath) || r.URL.Path+"/" == prefixPath {
+			if matched == nil || len(prefixPath) > len(matched.PrefixPath) {
+				matched = route
+			}
+		}
+	}
+	m.mu.RUnlock()
+
+	if matched == nil {
+		http.NotFound(w, r)
+		return
+	}
+	matched.Proxy.ServeHTTP(w, r)
+}
+
+func (m *RouteManager) prefixPaths() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	paths := make([]string, 0, len(m.routes))
+	for p := range m.routes {
+		paths = append(paths, p)
+	}
+	return paths
 }
 
 // GetRoute safely retrieves a route.
@@ -100,7 +167,13 @@ func (m *RouteManager) HandleAddBackends(w http.ResponseWriter, r *http.Request)
 	defer route.mu.Unlock()
 	addedCount := 0
 	for _, targetStr := range req.Targets {
-		if containsTarget(route.Backends, targetStr) {
+		if b := findBackend(route.Backends, targetStr); b != nil {
+			// adding a draining backend again cancels the draining, e.g. the backend is restarted in place
+			if b.IsDraining() {
+				b.SetDraining(false)
+				addedCount++
+				log.Printf("[Manager] canceled draining of backend '%s' in route '%s'", targetStr, route.PrefixPath)
+			}
 			continue
 		}
 		targetURL, err := url.Parse(targetStr)
@@ -108,7 +181,17 @@ func (m *RouteManager) HandleAddBackends(w http.ResponseWriter, r *http.Request)
 			log.Printf("[Manager] error parsing target URL '%s': %v", targetStr, err)
 			continue
 		}
-		backend := NewBackend(req.PrefixPath, targetURL)
+		var backend *Backend
+		if req.Protocol == ProtocolGRPC {
+			backend = NewGRPCBackend(req.PrefixPath, targetURL)
+		} else {
+			backend = NewBackend(req.PrefixPath, targetURL)
+		}
+		backend.SetLimit(req.Limit)
+		if err = backend.SetTLSConfig(req.TLS); err != nil {
+			log.Printf("[Manager] error setting TLS config for target '%s': %v", targetStr, err)
+			continue
+		}
 		route.Backends = append(route.Backends, backend)
 		route.Balancer.AddBackend(backend)
 		StartHealthChecks([]*Backend{backend}, req.HealthCheck)
@@ -143,6 +226,7 @@ func (m *RouteManager) HandleRemoveBackends(w http.ResponseWriter, r *http.Reque
 		if containsString(req.Targets, backend.URL.String()) {
 			backend.StopHealthCheck()
 			route.Balancer.RemoveBackend(backend)
+			deleteBackendMetrics(route.PrefixPath, backend.URL.String())
 			removedCount++
 			log.Printf("[Manager] removed backend '%s' from route '%s'", backend.URL.String(), route.PrefixPath)
 		} else {
@@ -171,7 +255,7 @@ func (m *RouteManager) HandleGetBackend(w http.ResponseWriter, r *http.Request)
 	defer route.mu.RUnlock()
 	for _, b := range route.Backends {
 		if b.URL.String() == target {
-			_ = json.NewEncoder(w).Encode(map[string]interface{}{"target": target, "healthy": b.IsHealthy()})
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"target": target, "healthy": b.IsHealthy(), "draining": b.IsDraining(), "activeConns": b.GetActiveConns()})
 			return
 		}
 	}
@@ -194,23 +278,29 @@ func (m *RouteManager) HandleListBackends(w http.ResponseWriter, r *http.Request
 	route.mu.RLock()
 	defer route.mu.RUnlock()
 	type targetStatus struct {
-		Target  string `json:"target"`
-		Healthy bool   `json:"healthy"`
+		Target      string `json:"target"`
+		Healthy     bool   `json:"healthy"`
+		Draining    bool   `json:"draining"`
+		ActiveConns int64  `json:"activeConns"`
 	}
 	var statuses []targetStatus
 	for _, b := range route.Backends {
-		statuses = append(statuses, targetStatus{Target: b.URL.String(), Healthy: b.IsHealthy()})
+		statuses = append(statuses, targetStatus{Target: b.URL.String(), Healthy: b.IsHealthy(), Draining: b.IsDraining(), ActiveConns: b.GetActiveConns()})
 	}
 	_ = json.NewEncoder(w).Encode(map[string]interface{}{"prefixPath": prefixPath, "targets": statuses})
 }
 
 func containsTarget(backends []*Backend, targetStr string) bool {
+	return findBackend(backends, targetStr) != nil
+}
+
+func findBackend(backends []*Backend, targetStr string) *Backend {
 	for _, b := range backends {
 		if b.URL.String() == targetStr {
-			return true
+			return b
 		}
 	}
-	return false
+	return nil
 }
 
 func containsString(slice []string, str string) bool {
@@ -223,11 +313,15 @@ func containsString(slice []string, str string) bool {
 }
 
 func AnyRelativePath(prefixPath string) string {
+	return normalizePrefixPath(prefixPath) + "*path"
+}
+
+func normalizePrefixPath(prefixPath string) string {
 	if !strings.HasPrefix(prefixPath, "/") {
 		prefixPath = "/" + prefixPath
 	}
 	if !strings.HasSuffix(prefixPath, "/") {
 		prefixPath = prefixPath + "/"
 	}
-	return prefixPath + "*path"
+	return prefixPath
 }
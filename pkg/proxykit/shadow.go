@@ -0,0 +1,172 @@
+package proxykit
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// ShadowHeader is set on mirrored requests so that shadow backends can identify them.
+const ShadowHeader = "X-Shadow-Request"
+
+// ShadowConfig defines the traffic mirroring settings of a route in the configuration file.
+type ShadowConfig struct {
+	Targets     []string      `yaml:"targets" json:"targets"`         // backends receiving the mirrored requests
+	Percent     float64       `yaml:"percent" json:"percent"`         // percentage of requests to mirror, 0~100
+	Timeout     time.Duration `yaml:"timeout" json:"timeout"`         // timeout of a mirrored request, default is 10s
+	MaxBodySize int64         `yaml:"maxBodySize" json:"maxBodySize"` // requests with larger body are not mirrored, default is 1MB
+}
+
+// ShadowOption set the shadow options.
+type ShadowOption func(*shadowOptions)
+
+type shadowOptions struct {
+	timeout     time.Duration
+	maxBodySize int64
+	maxInFlight int
+}
+
+func defaultShadowOptions() *shadowOptions {
+	return &shadowOptions{
+		timeout:     10 * time.Second,
+		maxBodySize: 1 << 20,
+		maxInFlight: 100,
+	}
+}
+
+func (o *shadowOptions) apply(opts ...ShadowOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WithShadowTimeout set the timeout of a mirrored request.
+func WithShadowTimeout(d time.Duration) ShadowOption {
+	return func(o *shadowOptions) {
+		if d > 0 {
+			o.timeout = d
+		}
+	}
+}
+
+// WithShadowMaxBodySize set the maximum request body size to mirror,
+// requests with larger body or unknown length are not mirrored.
+func WithShadowMaxBodySize(size int64) ShadowOption {
+	return func(o *shadowOptions) {
+		if size > 0 {
+			o.maxBodySize = size
+		}
+	}
+}
+
+// WithShadowMaxInFlight set the maximum number of concurrent mirrored requests,
+// requests exceeding the limit are not mirrored.
+func WithShadowMaxInFlight(n int) ShadowOption {
+	return func(o *shadowOptions) {
+		if n > 0 {
+			o.maxInFlight = n
+		}
+	}
+}
+
+// Shadow asynchronously duplicates a percentage of requests to a secondary backend set,
+// the responses of mirrored requests are discarded and never affect the client.
+type Shadow struct {
+	balancer    Balancer
+	percent     float64
+	timeout     time.Duration
+	maxBodySize int64
+	sem         chan struct{}
+}
+
+// NewShadow creates a traffic mirror to the backends, percent is in range 0~100.
+func NewShadow(backends []*Backend, percent float64, opts ...ShadowOption) *Shadow {
+	o := defaultShadowOptions()
+	o.apply(opts...)
+	return &Shadow{
+		balancer:    NewRoundRobin(backends),
+		percent:     percent,
+		timeout:     o.timeout,
+		maxBodySize: o.maxBodySize,
+		sem:         make(chan struct{}, o.maxInFlight),
+	}
+}
+
+// WithShadow mirrors a percentage of the route requests to the shadow backends.
+func WithShadow(s *Shadow) ProxyOption {
+	return func(o *proxyOptions) {
+		o.shadow = s
+	}
+}
+
+// GetBackends returns the shadow backends.
+func (s *Shadow) GetBackends() []*Backend {
+	return s.balancer.GetBackends()
+}
+
+func (s *Shadow) sampled() bool {
+	if s.percent <= 0 {
+		return false
+	}
+	return s.percent >= 100 || rand.Float64()*100 < s.percent //nolint
+}
+
+// mirror sends a copy of the request to a shadow backend in the background, the body of the
+// original request is buffered and restored so that it can still be sent to the primary backend.
+func (s *Shadow) mirror(r *http.Request) {
+	if s == nil || !s.sampled() {
+		return
+	}
+	if r.Body != nil && r.Body != http.NoBody && (r.ContentLength < 0 || r.ContentLength > s.maxBodySize) {
+		return
+	}
+	backend, err := s.balancer.Next(r)
+	if err != nil {
+		return
+	}
+
+	select {
+	case s.sem <- struct{}{}:
+	default:
+		return // too many mirrored requests in flight, drop
+	}
+
+	var body []byte
+	if r.Body != nil && r.Body != http.NoBody {
+		body, err = io.ReadAll(r.Body)
+		_ = r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			<-s.sem
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), s.timeout)
+	req := r.Clone(ctx)
+	req.Header.Set(ShadowHeader, "true")
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	if len(body) == 0 {
+		req.Body = http.NoBody
+	}
+
+	go func() {
+		defer func() {
+			cancel()
+			<-s.sem
+		}()
+		backend.proxy.ServeHTTP(discardResponseWriter{header: make(http.Header)}, req)
+	}()
+}
+
+// discardResponseWriter drops the response of a mirrored request.
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (w discardResponseWriter) Header() http.Header         { return w.header }
+func (w discardResponseWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w discardResponseWriter) WriteHeader(int)             {}
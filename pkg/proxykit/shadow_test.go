@@ -0,0 +1,100 @@
+package proxykit
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProxy_Shadow(t *testing.T) {
+	t.Parallel()
+
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write([]byte("primary:" + string(body)))
+	}))
+	defer primary.Close()
+
+	type mirrored struct {
+		body   string
+		header string
+	}
+	mirroredCh := make(chan mirrored, 10)
+	staging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mirroredCh <- mirrored{body: string(body), header: r.Header.Get(ShadowHeader)}
+		time.Sleep(50 * time.Millisecond) // slow shadow backend must not delay the client
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer staging.Close()
+
+	primaryURL, _ := url.Parse(primary.URL)
+	stagingBackends, _ := ParseBackends("", []string{staging.URL})
+
+	t.Run("Mirror All", func(t *testing.T) {
+		proxy, _ := NewProxy(&mockBalancer{backend: NewBackend("", primaryURL)},
+			WithShadow(NewShadow(stagingBackends, 100)))
+
+		rr := httptest.NewRecorder()
+		proxy.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/foo", strings.NewReader("hello")))
+		if rr.Code != http.StatusOK || rr.Body.String() != "primary:hello" {
+			t.Fatalf("unexpected primary response %d '%s'", rr.Code, rr.Body.String())
+		}
+
+		select {
+		case m := <-mirroredCh:
+			if m.body != "hello" {
+				t.Errorf("expected mirrored body 'hello', got '%s'", m.body)
+			}
+			if m.header != "true" {
+				t.Errorf("expected %s header", ShadowHeader)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("expected request to be mirrored")
+		}
+	})
+
+	t.Run("Mirror None", func(t *testing.T) {
+		proxy, _ := NewProxy(&mockBalancer{backend: NewBackend("", primaryURL)},
+			WithShadow(NewShadow(stagingBackends, 0)))
+		proxy.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/foo", nil))
+		select {
+		case <-mirroredCh:
+			t.Fatal("expected request not to be mirrored")
+		case <-time.After(200 * time.Millisecond):
+		}
+	})
+
+	t.Run("Body Too Large", func(t *testing.T) {
+		proxy, _ := NewProxy(&mockBalancer{backend: NewBackend("", primaryURL)},
+			WithShadow(NewShadow(stagingBackends, 100, WithShadowMaxBodySize(2))))
+		rr := httptest.NewRecorder()
+		proxy.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/foo", strings.NewReader("hello")))
+		if rr.Body.String() != "primary:hello" {
+			t.Fatalf("unexpected primary response '%s'", rr.Body.String())
+		}
+		select {
+		case <-mirroredCh:
+			t.Fatal("expected request with large body not to be mirrored")
+		case <-time.After(200 * time.Millisecond):
+		}
+	})
+}
+
+func TestShadow_Sampled(t *testing.T) {
+	t.Parallel()
+	s := NewShadow(nil, 30)
+	n := 0
+	for i := 0; i < 10000; i++ {
+		if s.sampled() {
+			n++
+		}
+	}
+	if n < 2500 || n > 3500 {
+		t.Errorf("expected about 30%% sampled, got %d/10000", n)
+	}
+}
@@ -0,0 +1,181 @@
+package proxykit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"sync/atomic"
+)
+
+// SplitRule routes the matching requests to the canary group, Header or Cookie specifies the name
+// to match, if Value is empty, the presence of the header or cookie is enough to match.
+type SplitRule struct {
+	Header string `yaml:"header" json:"header"`
+	Cookie string `yaml:"cookie" json:"cookie"`
+	Value  string `yaml:"value" json:"value"`
+}
+
+func (rule SplitRule) match(r *http.Request) bool {
+	var value string
+	var found bool
+	switch {
+	case rule.Header != "":
+		values := r.Header.Values(rule.Header)
+		if len(values) > 0 {
+			value, found = values[0], true
+		}
+	case rule.Cookie != "":
+		if c, err := r.Cookie(rule.Cookie); err == nil {
+			value, found = c.Value, true
+		}
+	}
+	if !found {
+		return false
+	}
+	return rule.Value == "" || rule.Value == value
+}
+
+// SplitConfig defines the canary group of a route in the configuration file.
+type SplitConfig struct {
+	Targets  []string    `yaml:"targets" json:"targets"`   // backends of the canary group
+	Balancer string      `yaml:"balancer" json:"balancer"` // balancer of the canary group, default is round_robin
+	Percent  float64     `yaml:"percent" json:"percent"`   // percentage of requests routed to the canary group, 0~100
+	Rules    []SplitRule `yaml:"rules" json:"rules"`       // requests matching any rule are always routed to the canary group
+}
+
+var _ Balancer = (*SplitBalancer)(nil)
+
+// SplitBalancer splits the traffic of a route between a primary and a canary backend group,
+// requests matching a rule go to the canary group, the others are split by percentage.
+// If a group has no healthy backend, the request falls back to the other group.
+type SplitBalancer struct {
+	primary Balancer
+	canary  Balancer
+	rules   []SplitRule
+	percent atomic.Uint64 // math.Float64bits of the canary percentage
+}
+
+// NewSplitBalancer creates a balancer that routes percent (0~100) of requests to the canary group.
+func NewSplitBalancer(primary Balancer, canary Balancer, percent float64, rules ...SplitRule) (*SplitBalancer, error) {
+	if primary == nil || canary == nil {
+		return nil, errors.New("primary and canary balancer cannot be nil")
+	}
+	s := &SplitBalancer{primary: primary, canary: canary, rules: rules}
+	if err := s.SetPercent(percent); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// SetPercent changes the percentage of requests routed to the canary group at runtime.
+func (s *SplitBalancer) SetPercent(percent float64) error {
+	if percent < 0 || percent > 100 || math.IsNaN(percent) {
+		return fmt.Errorf("percent must be in range 0~100, got %v", percent)
+	}
+	s.percent.Store(math.Float64bits(percent))
+	return nil
+}
+
+// Percent returns the percentage of requests routed to the canary group.
+func (s *SplitBalancer) Percent() float64 {
+	return math.Float64frombits(s.percent.Load())
+}
+
+// Next implement the Balancer interface
+func (s *SplitBalancer) Next(r *http.Request) (*Backend, error) {
+	first, second := s.primary, s.canary
+	if s.toCanary(r) {
+		first, second = s.canary, s.primary
+	}
+	b, err := first.Next(r)
+	if err == nil {
+		return b, nil
+	}
+	return second.Next(r)
+}
+
+func (s *SplitBalancer) toCanary(r *http.Request) bool {
+	for _, rule := range s.rules {
+		if rule.match(r) {
+			return true
+		}
+	}
+	percent := s.Percent()
+	if percent <= 0 {
+		return false
+	}
+	return percent >= 100 || rand.Float64()*100 < percent //nolint
+}
+
+// GetBackends implement the Balancer interface, returns backends of both groups.
+func (s *SplitBalancer) GetBackends() []*Backend {
+	return append(s.primary.GetBackends(), s.canary.GetBackends()...)
+}
+
+// GetCanaryBackends returns backends of the canary group.
+func (s *SplitBalancer) GetCanaryBackends() []*Backend {
+	return s.canary.GetBackends()
+}
+
+// AddBackend implement the Balancer interface, the backend is added to the primary group.
+func (s *SplitBalancer) AddBackend(b *Backend) {
+	s.primary.AddBackend(b)
+}
+
+// AddCanaryBackend adds the backend to the canary group.
+func (s *SplitBalancer) AddCanaryBackend(b *Backend) {
+	s.canary.AddBackend(b)
+}
+
+// RemoveBackend implement the Balancer interface, the backend is removed from both groups.
+func (s *SplitBalancer) RemoveBackend(b *Backend) {
+	s.primary.RemoveBackend(b)
+	s.canary.RemoveBackend(b)
+}
+
+// SplitRequest is for the management API of traffic splitting.
+type SplitRequest struct {
+	PrefixPath string  `json:"prefixPath"`
+	Percent    float64 `json:"percent"`
+}
+
+// HandleSplit handles the HTTP request to get (GET) or change (POST) the canary percentage of a route.
+func (m *RouteManager) HandleSplit(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var req SplitRequest
+	switch r.Method {
+	case http.MethodGet:
+		req.PrefixPath = r.URL.Query().Get("prefixPath")
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Bad Request: Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	route, exists := m.GetRoute(req.PrefixPath)
+	if !exists {
+		http.Error(w, "Not Found: Prefix path does not exist", http.StatusNotFound)
+		return
+	}
+	splitter, ok := route.Balancer.(*SplitBalancer)
+	if !ok {
+		http.Error(w, "Bad Request: Traffic splitting is not enabled for this route", http.StatusBadRequest)
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		if err := splitter.SetPercent(req.Percent); err != nil {
+			http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("[Manager] set canary percent of route '%s' to %v", route.PrefixPath, req.Percent)
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"prefixPath": route.PrefixPath, "percent": splitter.Percent()})
+}
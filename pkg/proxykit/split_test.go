@@ -0,0 +1,160 @@
+package proxykit
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSplitBalancer(t *testing.T) {
+	t.Parallel()
+	v1 := newTestBackend(t, "http://v1:8080", true, 0)
+	v2 := newTestBackend(t, "http://v2:8080", true, 0)
+
+	_, err := NewSplitBalancer(NewRoundRobin(nil), nil, 0)
+	if err == nil {
+		t.Error("expected an error when canary is nil")
+	}
+	_, err = NewSplitBalancer(NewRoundRobin(nil), NewRoundRobin(nil), 101)
+	if err == nil {
+		t.Error("expected an error when percent is out of range")
+	}
+
+	s, err := NewSplitBalancer(NewRoundRobin([]*Backend{v1}), NewRoundRobin([]*Backend{v2}), 0,
+		SplitRule{Header: "X-Beta", Value: "true"}, SplitRule{Cookie: "canary"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	next := func(r *http.Request) *Backend {
+		b, err := s.Next(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if next(req) != v1 {
+		t.Error("expected primary backend for percent 0")
+	}
+
+	req.Header.Set("X-Beta", "true")
+	if next(req) != v2 {
+		t.Error("expected canary backend for matching header")
+	}
+	req.Header.Set("X-Beta", "false")
+	if next(req) != v1 {
+		t.Error("expected primary backend for non-matching header value")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "canary", Value: "any"})
+	if next(req) != v2 {
+		t.Error("expected canary backend for matching cookie")
+	}
+
+	_ = s.SetPercent(100)
+	if next(httptest.NewRequest(http.MethodGet, "/", nil)) != v2 {
+		t.Error("expected canary backend for percent 100")
+	}
+
+	_ = s.SetPercent(5)
+	n := 0
+	for i := 0; i < 10000; i++ {
+		if next(httptest.NewRequest(http.MethodGet, "/", nil)) == v2 {
+			n++
+		}
+	}
+	if n < 300 || n > 700 {
+		t.Errorf("expected about 5%% canary traffic, got %d/10000", n)
+	}
+
+	// fall back to the other group when no backend is healthy
+	_ = s.SetPercent(100)
+	v2.SetHealthy(false)
+	if next(httptest.NewRequest(http.MethodGet, "/", nil)) != v1 {
+		t.Error("expected fallback to primary backend")
+	}
+
+	if len(s.GetBackends()) != 2 || len(s.GetCanaryBackends()) != 1 {
+		t.Error("unexpected backends")
+	}
+	s.RemoveBackend(v2)
+	if len(s.GetCanaryBackends()) != 0 {
+		t.Error("expected canary backend to be removed")
+	}
+}
+
+func TestRouteManager_HandleSplit(t *testing.T) {
+	t.Parallel()
+	m := NewRouteManager()
+	err := m.ApplyConfig(&Config{Routes: []RouteConfig{
+		{PrefixPath: "/api", Targets: []string{"http://v1:8080"}, Split: SplitConfig{Targets: []string{"http://v2:8080"}, Percent: 5}},
+		{PrefixPath: "/plain", Targets: []string{"http://v1:8080"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	do := func(method string, target string, body interface{}) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		if body != nil {
+			_ = json.NewEncoder(&buf).Encode(body)
+		}
+		rr := httptest.NewRecorder()
+		m.HandleSplit(rr, httptest.NewRequest(method, target, &buf))
+		return rr
+	}
+
+	rr := do(http.MethodGet, "/endpoints/split?prefixPath=/api/", nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	var resp struct {
+		Percent float64 `json:"percent"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &resp)
+	if resp.Percent != 5 {
+		t.Errorf("expected percent 5, got %v", resp.Percent)
+	}
+
+	rr = do(http.MethodPost, "/endpoints/split", SplitRequest{PrefixPath: "/api/", Percent: 50})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	route, _ := m.GetRoute("/api/")
+	if p := route.Balancer.(*SplitBalancer).Percent(); p != 50 {
+		t.Errorf("expected percent 50, got %v", p)
+	}
+
+	if rr = do(http.MethodPost, "/endpoints/split", SplitRequest{PrefixPath: "/api/", Percent: 200}); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+	if rr = do(http.MethodPost, "/endpoints/split", SplitRequest{PrefixPath: "/plain/", Percent: 10}); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+	if rr = do(http.MethodGet, "/endpoints/split?prefixPath=/none/", nil); rr.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, rr.Code)
+	}
+	if rr = do(http.MethodDelete, "/endpoints/split", nil); rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status %d, got %d", http.StatusMethodNotAllowed, rr.Code)
+	}
+
+	// reapplying the same config keeps the canary backends
+	err = m.ApplyConfig(&Config{Routes: []RouteConfig{
+		{PrefixPath: "/api", Targets: []string{"http://v1:8080", "http://v3:8080"}, Split: SplitConfig{Targets: []string{"http://v2:8080"}, Percent: 5}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	route, _ = m.GetRoute("/api/")
+	if n := len(route.Balancer.(*SplitBalancer).GetCanaryBackends()); n != 1 {
+		t.Errorf("expected 1 canary backend, got %d", n)
+	}
+	if n := len(route.Backends); n != 3 {
+		t.Errorf("expected 3 backends, got %d", n)
+	}
+}
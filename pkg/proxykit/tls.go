@@ -0,0 +1,89 @@
+package proxykit
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/go-dev-frame/sponge/pkg/httpsrv"
+)
+
+// BackendTLSConfig defines the TLS settings used when connecting to a backend.
+type BackendTLSConfig struct {
+	CAFile             string `yaml:"caFile" json:"caFile"`                         // CA bundle used to verify the backend certificate, default is system pool
+	CertFile           string `yaml:"certFile" json:"certFile"`                     // client certificate presented to the backend (mTLS)
+	KeyFile            string `yaml:"keyFile" json:"keyFile"`                       // client private key presented to the backend (mTLS)
+	ServerName         string `yaml:"serverName" json:"serverName"`                 // SNI and verification name, default is backend host
+	InsecureSkipVerify bool   `yaml:"insecureSkipVerify" json:"insecureSkipVerify"` // skip verification of the backend certificate
+}
+
+// IsZero reports whether no TLS settings are configured.
+func (c BackendTLSConfig) IsZero() bool {
+	return c == BackendTLSConfig{}
+}
+
+// Build converts the settings to a *tls.Config.
+func (c BackendTLSConfig) Build() (*tls.Config, error) {
+	cfg := &tls.Config{
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify, //nolint
+		MinVersion:         tls.VersionTLS12,
+	}
+
+	if c.CAFile != "" {
+		caData, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA file error: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caData) {
+			return nil, fmt.Errorf("no valid certificate found in CA file %s", c.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if c.CertFile != "" || c.KeyFile != "" {
+		if c.CertFile == "" || c.KeyFile == "" {
+			return nil, errors.New("both certFile and keyFile must be specified for client certificate")
+		}
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate error: %v", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+// SetTLSConfig sets the TLS settings for connections to the backend,
+// it should be called before the backend starts receiving traffic.
+func (b *Backend) SetTLSConfig(cfg BackendTLSConfig) error {
+	if cfg.IsZero() {
+		return nil
+	}
+	tlsConfig, err := cfg.Build()
+	if err != nil {
+		return err
+	}
+	if b.isGRPC {
+		b.proxy.Transport = newGRPCTransport(tlsConfig)
+		return nil
+	}
+	transport := DefaultTransport()
+	transport.TLSClientConfig = tlsConfig
+	b.proxy.Transport = transport
+	return nil
+}
+
+// ListenAndServe starts the proxy server, if tlser is not nil, TLS is terminated
+// at the proxy using the given httpsrv TLSer (self-signed, Let's Encrypt, external, remote API).
+func ListenAndServe(server *http.Server, tlser httpsrv.TLSer) error {
+	if tlser == nil {
+		return httpsrv.New(server).Run()
+	}
+	return httpsrv.New(server, tlser).Run()
+}
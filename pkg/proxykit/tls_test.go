@@ -0,0 +1,104 @@
+package proxykit
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBackendTLSConfig_Build(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+
+	t.Run("Zero Config", func(t *testing.T) {
+		if !(BackendTLSConfig{}).IsZero() {
+			t.Error("expected zero config")
+		}
+		cfg, err := BackendTLSConfig{ServerName: "example.com"}.Build()
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if cfg.ServerName != "example.com" {
+			t.Errorf("expected server name 'example.com', got '%s'", cfg.ServerName)
+		}
+	})
+
+	t.Run("Missing CA File", func(t *testing.T) {
+		_, err := BackendTLSConfig{CAFile: filepath.Join(dir, "not-exist.pem")}.Build()
+		if err == nil {
+			t.Error("expected an error for missing CA file")
+		}
+	})
+
+	t.Run("Invalid CA File", func(t *testing.T) {
+		caFile := filepath.Join(dir, "invalid.pem")
+		_ = os.WriteFile(caFile, []byte("invalid"), 0600)
+		_, err := BackendTLSConfig{CAFile: caFile}.Build()
+		if err == nil {
+			t.Error("expected an error for invalid CA file")
+		}
+	})
+
+	t.Run("Cert Without Key", func(t *testing.T) {
+		_, err := BackendTLSConfig{CertFile: "cert.pem"}.Build()
+		if err == nil {
+			t.Error("expected an error when key file is missing")
+		}
+	})
+}
+
+func TestBackend_SetTLSConfig(t *testing.T) {
+	t.Parallel()
+
+	backendServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello from tls backend"))
+	}))
+	defer backendServer.Close()
+	backendURL, _ := url.Parse(backendServer.URL)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: backendServer.Certificate().Raw})
+	if err := os.WriteFile(caFile, caData, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Unknown Authority", func(t *testing.T) {
+		backend := NewBackend("", backendURL)
+		proxy, _ := NewProxy(&mockBalancer{backend: backend})
+		rr := httptest.NewRecorder()
+		proxy.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rr.Code != http.StatusBadGateway {
+			t.Errorf("expected status %d, got %d", http.StatusBadGateway, rr.Code)
+		}
+	})
+
+	t.Run("Custom CA", func(t *testing.T) {
+		backend := NewBackend("", backendURL)
+		if err := backend.SetTLSConfig(BackendTLSConfig{CAFile: caFile, ServerName: "example.com"}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		proxy, _ := NewProxy(&mockBalancer{backend: backend})
+		rr := httptest.NewRecorder()
+		proxy.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rr.Code != http.StatusOK {
+			t.Errorf("expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+	})
+
+	t.Run("Skip Verify", func(t *testing.T) {
+		backend := NewBackend("", backendURL)
+		if err := backend.SetTLSConfig(BackendTLSConfig{InsecureSkipVerify: true}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		proxy, _ := NewProxy(&mockBalancer{backend: backend})
+		rr := httptest.NewRecorder()
+		proxy.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rr.Body.String() != "hello from tls backend" {
+			t.Errorf("unexpected body '%s'", rr.Body.String())
+		}
+	})
+}
@@ -0,0 +1,159 @@
+package replacer
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// directives of conditional blocks, the directive occupies a whole line of comment,
+// e.g. "// sponge:if db" in go code, "# sponge:if db" in yaml, shell and makefile.
+const (
+	directivePrefix = "sponge:"
+	directiveIf     = "if"
+	directiveElse   = "else"
+	directiveEnd    = "end"
+)
+
+type conditionalBlock struct {
+	line      int  // line number of the if directive
+	parentOn  bool // whether the parent block is kept
+	condition bool // result of the condition
+	isElse    bool // whether it is in the else branch
+}
+
+func (b *conditionalBlock) isKept() bool {
+	if b.isElse {
+		return b.parentOn && !b.condition
+	}
+	return b.parentOn && b.condition
+}
+
+// RenderConditionalBlocks keeps or removes the conditional blocks of data according to features,
+// the lines of directives are removed, the blocks can be nested, e.g.
+//
+//	// sponge:if db
+//	database.InitDB()
+//	// sponge:else
+//	logger.Info("no database")
+//	// sponge:end
+//
+// The conditions of if directive:
+//
+//	name          the feature is enabled, its value is not empty and not false
+//	!name         the feature is not enabled
+//	name=v1|v2    one of the values of feature (separated by commas) is v1 or v2, e.g. feature=redis
+//	name!=v1|v2   none of the values of feature is v1 or v2
+func RenderConditionalBlocks(data []byte, features map[string]string) ([]byte, error) {
+	if !bytes.Contains(data, []byte(directivePrefix)) {
+		return data, nil
+	}
+
+	var stack []*conditionalBlock
+	isKept := func() bool {
+		if len(stack) == 0 {
+			return true
+		}
+		return stack[len(stack)-1].isKept()
+	}
+
+	out := make([]byte, 0, len(data))
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	for i, line := range lines {
+		lineNo := i + 1
+		directive, expr, ok := parseDirective(line)
+		if !ok {
+			if isKept() {
+				out = append(out, line...)
+			}
+			continue
+		}
+
+		switch directive {
+		case directiveIf:
+			condition, err := evalCondition(expr, features)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNo, err)
+			}
+			stack = append(stack, &conditionalBlock{line: lineNo, parentOn: isKept(), condition: condition})
+		case directiveElse:
+			if len(stack) == 0 || stack[len(stack)-1].isElse {
+				return nil, fmt.Errorf("line %d: unexpected %s%s", lineNo, directivePrefix, directiveElse)
+			}
+			stack[len(stack)-1].isElse = true
+		case directiveEnd:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: unexpected %s%s", lineNo, directivePrefix, directiveEnd)
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("line %d: %s%s is not closed by %s%s",
+			stack[len(stack)-1].line, directivePrefix, directiveIf, directivePrefix, directiveEnd)
+	}
+	return out, nil
+}
+
+// parseDirective returns the directive and its expression if the line is a comment of directive.
+func parseDirective(line []byte) (string, string, bool) {
+	s := strings.TrimSpace(string(line))
+	switch {
+	case strings.HasPrefix(s, "//"):
+		s = s[2:]
+	case strings.HasPrefix(s, "#"):
+		s = s[1:]
+	default:
+		return "", "", false
+	}
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, directivePrefix) {
+		return "", "", false
+	}
+	s = s[len(directivePrefix):]
+
+	directive, expr, _ := strings.Cut(s, " ")
+	switch directive {
+	case directiveIf, directiveElse, directiveEnd:
+		return directive, strings.TrimSpace(expr), true
+	}
+	return "", "", false
+}
+
+func evalCondition(expr string, features map[string]string) (bool, error) {
+	if expr == "" {
+		return false, fmt.Errorf("missing condition of %s%s", directivePrefix, directiveIf)
+	}
+
+	if name, values, ok := strings.Cut(expr, "!="); ok {
+		return !hasFeatureValue(features, strings.TrimSpace(name), values), nil
+	}
+	if name, values, ok := strings.Cut(expr, "="); ok {
+		return hasFeatureValue(features, strings.TrimSpace(name), values), nil
+	}
+	if name, ok := strings.CutPrefix(expr, "!"); ok {
+		return !isFeatureEnabled(features, strings.TrimSpace(name)), nil
+	}
+	return isFeatureEnabled(features, expr), nil
+}
+
+func isFeatureEnabled(features map[string]string, name string) bool {
+	value := strings.TrimSpace(features[name])
+	return value != "" && !strings.EqualFold(value, "false")
+}
+
+func hasFeatureValue(features map[string]string, name string, values string) bool {
+	for _, v := range strings.Split(features[name], ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		for _, want := range strings.Split(values, "|") {
+			if strings.EqualFold(v, strings.TrimSpace(want)) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+package replacer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const conditionalCode = `package initial
+
+import (
+	// sponge:if db
+	"example/internal/database"
+	// sponge:end
+)
+
+func InitApp() {
+	// sponge:if db
+	database.InitDB()
+	// sponge:if feature=redis|memory
+	database.InitCache()
+	// sponge:else
+	// no cache
+	// sponge:end
+	// sponge:end
+	// sponge:if db!=mongodb
+	migrate()
+	// sponge:end
+	// sponge:unknown is not a directive
+}
+`
+
+func TestRenderConditionalBlocks(t *testing.T) {
+	out, err := RenderConditionalBlocks([]byte(conditionalCode), map[string]string{"db": "mysql", "feature": "outbox, redis"})
+	assert.NoError(t, err)
+	assert.Equal(t, `package initial
+
+import (
+	"example/internal/database"
+)
+
+func InitApp() {
+	database.InitDB()
+	database.InitCache()
+	migrate()
+	// sponge:unknown is not a directive
+}
+`, string(out))
+
+	out, err = RenderConditionalBlocks([]byte(conditionalCode), map[string]string{"db": "mongodb"})
+	assert.NoError(t, err)
+	assert.Equal(t, `package initial
+
+import (
+	"example/internal/database"
+)
+
+func InitApp() {
+	database.InitDB()
+	// no cache
+	// sponge:unknown is not a directive
+}
+`, string(out))
+
+	out, err = RenderConditionalBlocks([]byte(conditionalCode), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, `package initial
+
+import (
+)
+
+func InitApp() {
+	migrate()
+	// sponge:unknown is not a directive
+}
+`, string(out))
+
+	// yaml, shell and makefile
+	out, err = RenderConditionalBlocks([]byte("a: 1\n# sponge:if !db\nb: 2\n# sponge:end\n"), map[string]string{"db": "false"})
+	assert.NoError(t, err)
+	assert.Equal(t, "a: 1\nb: 2\n", string(out))
+
+	// no directive
+	data := []byte("package foo\n")
+	out, err = RenderConditionalBlocks(data, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, data, out)
+}
+
+func TestRenderConditionalBlocksError(t *testing.T) {
+	invalid := []string{
+		"// sponge:if\n// sponge:end\n",
+		"// sponge:if db\n",
+		"// sponge:else\n",
+		"// sponge:end\n",
+		"// sponge:if db\n// sponge:else\n// sponge:else\n// sponge:end\n",
+	}
+	for _, s := range invalid {
+		_, err := RenderConditionalBlocks([]byte(s), nil)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestSaveFilesWithFeatures(t *testing.T) {
+	dir := t.TempDir()
+	srcDir := filepath.Join(dir, "src")
+	require.NoError(t, os.MkdirAll(srcDir, 0o766))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "initApp.go"), []byte(conditionalCode), 0o666))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "invalid.go"), []byte("// sponge:if db\n"), 0o666))
+
+	r, err := New(srcDir)
+	require.NoError(t, err)
+	r.SetSubDirsAndFiles(nil, "src/initApp.go")
+	r.SetFeatures(map[string]string{"db": "mysql"})
+	r.SetReplacementFields([]Field{{Old: "example", New: "github.com/foo/bar"}})
+	require.NoError(t, r.SetOutputDir(filepath.Join(dir, "out")))
+	require.NoError(t, r.SaveFiles())
+	data, err := os.ReadFile(filepath.Join(dir, "out", "initApp.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"github.com/foo/bar/internal/database"`)
+	assert.NotContains(t, string(data), "sponge:if")
+	assert.Contains(t, string(data), "// no cache")
+
+	// the conditional blocks are not rendered without features
+	r, err = New(srcDir)
+	require.NoError(t, err)
+	r.SetSubDirsAndFiles(nil, "src/initApp.go", "src/invalid.go")
+	require.NoError(t, r.SetOutputDir(filepath.Join(dir, "out2")))
+	require.NoError(t, r.SaveFiles())
+	data, err = os.ReadFile(filepath.Join(dir, "out2", "initApp.go"))
+	require.NoError(t, err)
+	assert.Equal(t, conditionalCode, string(data))
+
+	r, err = New(srcDir)
+	require.NoError(t, err)
+	r.SetSubDirsAndFiles(nil, "src/invalid.go")
+	r.SetFeatures(nil)
+	require.NoError(t, r.SetOutputDir(filepath.Join(dir, "out3")))
+	assert.Error(t, r.SaveFiles())
+}
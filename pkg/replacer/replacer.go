@@ -30,6 +30,7 @@ type Replacer interface {
 	ReadFile(filename string) ([]byte, error)
 	GetFiles() []string
 	SaveTemplateFiles(m map[string]interface{}, parentDir ...string) error
+	SetFeatures(features map[string]string)
 }
 
 // replacerInfo replacer information
@@ -42,6 +43,8 @@ type replacerInfo struct {
 	ignoreDirs        []string // ignore processed subdirectories
 	replacementFields []Field  // characters to be replaced when converting from a template file to a new file
 	outPath           string   // the directory where the file is saved after replacement
+
+	features map[string]string // features selected in generation, they decide which conditional blocks are kept
 }
 
 // New create replacer with local directory
@@ -109,6 +112,17 @@ func (r *replacerInfo) SetReplacementFields(fields []Field) {
 	r.replacementFields = newFields
 }
 
+// SetFeatures set the features selected in generation, the conditional blocks of files (e.g. "// sponge:if db"
+// ... "// sponge:end") are kept or removed according to them before replacement, see RenderConditionalBlocks.
+// The files are saved as they are if SetFeatures is not called, SetFeatures(nil) means no feature is selected.
+// The value of feature is "true" for a switch, or the selected values separated by commas, e.g. {"db": "mysql"}.
+func (r *replacerInfo) SetFeatures(features map[string]string) {
+	if features == nil {
+		features = map[string]string{}
+	}
+	r.features = features
+}
+
 // GetFiles get files
 func (r *replacerInfo) GetFiles() []string {
 	return r.files
@@ -239,6 +253,14 @@ func (r *replacerInfo) SaveFiles() error {
 			return err
 		}
 
+		// keep or remove the conditional blocks
+		if r.features != nil {
+			data, err = RenderConditionalBlocks(data, r.features)
+			if err != nil {
+				return fmt.Errorf("render conditional blocks of %s error: %v", file, err)
+			}
+		}
+
 		// replace text content
 		for _, field := range r.replacementFields {
 			data = bytes.ReplaceAll(data, []byte(field.Old), []byte(field.New))
@@ -1,4 +1,4 @@
-// Package discovery is service discovery library, supports etcd, consul and nacos.
+// Package discovery is service discovery library, supports etcd, consul, nacos and kubernetes.
 package discovery
 
 import (
@@ -0,0 +1,332 @@
+package kubernetes
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the files of service account mounted in the pod
+const (
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+	tokenFile         = serviceAccountDir + "/token"
+	caFile            = serviceAccountDir + "/ca.crt"
+	namespaceFile     = serviceAccountDir + "/namespace"
+)
+
+// errResourceExpired the resource version of watch is too old, list again is required
+var errResourceExpired = errors.New("resource version expired")
+
+// a minimal client of kubernetes api server, only the list and watch of endpoints are needed
+type client struct {
+	host       string
+	token      string
+	tokenFile  string // the token of service account is rotated, it is read before each request
+	httpClient *http.Client
+}
+
+func newClient(o *options) (*client, error) {
+	c := &client{host: strings.TrimSuffix(o.apiServer, "/"), token: o.token, httpClient: o.httpClient}
+
+	if c.host == "" {
+		// in-cluster config
+		host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if host == "" || port == "" {
+			return nil, errors.New("kubernetes: not running in cluster, KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT " +
+				"are not set, the address of api server can be set by WithAPIServer")
+		}
+		c.host = "https://" + net.JoinHostPort(host, port)
+		if c.token == "" {
+			c.tokenFile = tokenFile
+		}
+		if c.httpClient == nil {
+			caData, err := os.ReadFile(caFile)
+			if err != nil {
+				return nil, fmt.Errorf("kubernetes: read ca file error, %v", err)
+			}
+			pool := x509.NewCertPool()
+			pool.AppendCertsFromPEM(caData)
+			c.httpClient = &http.Client{Transport: &http.Transport{
+				Proxy:               http.ProxyFromEnvironment,
+				TLSClientConfig:     &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12},
+				TLSHandshakeTimeout: 10 * time.Second,
+				IdleConnTimeout:     90 * time.Second,
+			}}
+		}
+	}
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{}
+	}
+	return c, nil
+}
+
+func (c *client) get(ctx context.Context, path string, query url.Values) (*http.Response, error) {
+	u := c.host + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	token := c.token
+	if c.tokenFile != "" {
+		data, err := os.ReadFile(c.tokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("kubernetes: read token file error, %v", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close() //nolint
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		if resp.StatusCode == http.StatusGone {
+			return nil, errResourceExpired
+		}
+		return nil, fmt.Errorf("kubernetes: GET %s, status code %d, %s", path, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
+
+// list the endpoints of service, return the instances of each object and the resource version of list
+func (c *client) list(ctx context.Context, res *resource) (map[string][]*instance, string, error) {
+	resp, err := c.get(ctx, res.path(), res.query())
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close() //nolint
+
+	list := &objectList{}
+	if err = json.NewDecoder(resp.Body).Decode(list); err != nil {
+		return nil, "", fmt.Errorf("kubernetes: decode list error, %v", err)
+	}
+	objects := make(map[string][]*instance, len(list.Items))
+	for _, item := range list.Items {
+		objects[item.Metadata.Name] = res.parse(item.Raw)
+	}
+	return objects, list.Metadata.ResourceVersion, nil
+}
+
+// watch the changes of endpoints after the resource version, the callback is called for each event,
+// it returns when the stream is closed by server or an error occurs.
+func (c *client) watch(ctx context.Context, res *resource, resourceVersion string,
+	fn func(eventType string, name string, ins []*instance)) (string, error) {
+	query := res.query()
+	query.Set("watch", "true")
+	query.Set("allowWatchBookmarks", "true")
+	query.Set("resourceVersion", resourceVersion)
+	query.Set("timeoutSeconds", strconv.Itoa(300+int(time.Now().UnixNano()%60))) // avoid all watchers reconnecting at the same time
+
+	resp, err := c.get(ctx, res.path(), query)
+	if err != nil {
+		return resourceVersion, err
+	}
+	defer resp.Body.Close() //nolint
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		event := &watchEvent{}
+		if err = decoder.Decode(event); err != nil {
+			if errors.Is(err, io.EOF) {
+				return resourceVersion, nil
+			}
+			return resourceVersion, err
+		}
+
+		obj := &object{}
+		if err = json.Unmarshal(event.Object, obj); err != nil {
+			return resourceVersion, fmt.Errorf("kubernetes: decode watch event error, %v", err)
+		}
+		switch event.Type {
+		case "ERROR":
+			status := &statusObject{}
+			_ = json.Unmarshal(event.Object, status)
+			if status.Code == http.StatusGone {
+				return resourceVersion, errResourceExpired
+			}
+			return resourceVersion, fmt.Errorf("kubernetes: watch error, %s", status.Message)
+		case "BOOKMARK":
+		case "ADDED", "MODIFIED", "DELETED":
+			fn(event.Type, obj.Metadata.Name, res.parse(event.Object))
+		}
+		if obj.Metadata.ResourceVersion != "" {
+			resourceVersion = obj.Metadata.ResourceVersion
+		}
+	}
+}
+
+// ------------------------------------------------------------------------------------------
+
+type objectMeta struct {
+	Name            string `json:"name"`
+	ResourceVersion string `json:"resourceVersion"`
+}
+
+type object struct {
+	Metadata objectMeta      `json:"metadata"`
+	Raw      json.RawMessage `json:"-"`
+}
+
+func (o *object) UnmarshalJSON(data []byte) error {
+	v := struct {
+		Metadata objectMeta `json:"metadata"`
+	}{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	o.Metadata = v.Metadata
+	o.Raw = append(o.Raw[:0], data...)
+	return nil
+}
+
+type objectList struct {
+	Metadata objectMeta `json:"metadata"`
+	Items    []object   `json:"items"`
+}
+
+type watchEvent struct {
+	Type   string          `json:"type"`
+	Object json.RawMessage `json:"object"`
+}
+
+type statusObject struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type objectReference struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+type endpointPort struct {
+	Name string `json:"name"`
+	Port int    `json:"port"`
+}
+
+// discovery.k8s.io/v1 EndpointSlice
+type endpointSlice struct {
+	AddressType string `json:"addressType"`
+	Endpoints   []struct {
+		Addresses  []string `json:"addresses"`
+		Conditions struct {
+			Ready       *bool `json:"ready"`
+			Terminating *bool `json:"terminating"`
+		} `json:"conditions"`
+		Hostname  string           `json:"hostname"`
+		NodeName  string           `json:"nodeName"`
+		Zone      string           `json:"zone"`
+		TargetRef *objectReference `json:"targetRef"`
+	} `json:"endpoints"`
+	Ports []endpointPort `json:"ports"`
+}
+
+// core/v1 Endpoints
+type endpoints struct {
+	Subsets []struct {
+		Addresses []struct {
+			IP        string           `json:"ip"`
+			Hostname  string           `json:"hostname"`
+			NodeName  string           `json:"nodeName"`
+			TargetRef *objectReference `json:"targetRef"`
+		} `json:"addresses"` // the not ready addresses are ignored
+		Ports []endpointPort `json:"ports"`
+	} `json:"subsets"`
+}
+
+// the ready address of service
+type instance struct {
+	ip       string
+	ports    []endpointPort
+	pod      string
+	hostname string
+	node     string
+	zone     string
+}
+
+// resource the endpoints of service, EndpointSlice or Endpoints
+type resource struct {
+	namespace    string
+	name         string
+	useEndpoints bool
+}
+
+func (r *resource) path() string {
+	if r.useEndpoints {
+		return "/api/v1/namespaces/" + r.namespace + "/endpoints"
+	}
+	return "/apis/discovery.k8s.io/v1/namespaces/" + r.namespace + "/endpointslices"
+}
+
+func (r *resource) query() url.Values {
+	query := url.Values{}
+	if r.useEndpoints {
+		query.Set("fieldSelector", "metadata.name="+r.name)
+	} else {
+		query.Set("labelSelector", "kubernetes.io/service-name="+r.name)
+	}
+	return query
+}
+
+func (r *resource) parse(data []byte) []*instance {
+	var ins []*instance
+	if r.useEndpoints {
+		ep := &endpoints{}
+		if json.Unmarshal(data, ep) != nil {
+			return nil
+		}
+		for _, subset := range ep.Subsets {
+			for _, addr := range subset.Addresses {
+				in := &instance{ip: addr.IP, ports: subset.Ports, hostname: addr.Hostname, node: addr.NodeName}
+				if addr.TargetRef != nil && addr.TargetRef.Kind == "Pod" {
+					in.pod = addr.TargetRef.Name
+				}
+				ins = append(ins, in)
+			}
+		}
+		return ins
+	}
+
+	slice := &endpointSlice{}
+	if json.Unmarshal(data, slice) != nil || slice.AddressType == "FQDN" {
+		return nil
+	}
+	for _, ep := range slice.Endpoints {
+		// nil ready is interpreted as ready
+		if ep.Conditions.Ready != nil && !*ep.Conditions.Ready {
+			continue
+		}
+		if ep.Conditions.Terminating != nil && *ep.Conditions.Terminating {
+			continue
+		}
+		for _, addr := range ep.Addresses {
+			in := &instance{ip: addr, ports: slice.Ports, hostname: ep.Hostname, node: ep.NodeName, zone: ep.Zone}
+			if ep.TargetRef != nil && ep.TargetRef.Kind == "Pod" {
+				in.pod = ep.TargetRef.Name
+			}
+			ins = append(ins, in)
+		}
+	}
+	return ins
+}
@@ -0,0 +1,203 @@
+// Package kubernetes is service discovery using the endpoints of kubernetes service, the grpc clients
+// can load balance across pods without etcd, consul and nacos when running in cluster.
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-dev-frame/sponge/pkg/servicerd/registry"
+)
+
+var (
+	_ registry.Registry  = (*Registry)(nil)
+	_ registry.Discovery = (*Registry)(nil)
+)
+
+type options struct {
+	namespace    string
+	apiServer    string
+	token        string
+	httpClient   *http.Client
+	portName     string
+	kind         string
+	useEndpoints bool
+}
+
+// Option is kubernetes option.
+type Option func(o *options)
+
+// WithNamespace with the default namespace of service, default is the namespace of current pod.
+func WithNamespace(namespace string) Option {
+	return func(o *options) { o.namespace = namespace }
+}
+
+// WithAPIServer with the address of api server, used when running out of cluster, e.g. http://127.0.0.1:8001 of kubectl proxy.
+func WithAPIServer(addr string) Option {
+	return func(o *options) { o.apiServer = addr }
+}
+
+// WithToken with the bearer token of api server, default is the token of service account.
+func WithToken(token string) Option {
+	return func(o *options) { o.token = token }
+}
+
+// WithHTTPClient with the http client of requesting api server.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) { o.httpClient = client }
+}
+
+// WithPortName with the port name of service, if not set, the port whose name contains
+// the kind (e.g. grpc) is used, and the metrics port is excluded.
+func WithPortName(name string) Option {
+	return func(o *options) { o.portName = name }
+}
+
+// WithDefaultKind with default kind option, it is the scheme of instance endpoint.
+func WithDefaultKind(kind string) Option {
+	return func(o *options) { o.kind = kind }
+}
+
+// WithEndpointsAPI watch the core/v1 Endpoints instead of discovery.k8s.io/v1 EndpointSlice, used for kubernetes < 1.21.
+func WithEndpointsAPI() Option {
+	return func(o *options) { o.useEndpoints = true }
+}
+
+// Registry is kubernetes registry, the service account of pod needs the permission
+// to list and watch endpointslices (or endpoints).
+type Registry struct {
+	opts options
+	cli  *client
+}
+
+// New a kubernetes registry, the in-cluster config is used by default.
+func New(opts ...Option) (*Registry, error) {
+	o := options{kind: "grpc"}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.namespace == "" {
+		o.namespace = "default"
+		if data, err := os.ReadFile(namespaceFile); err == nil && len(data) > 0 {
+			o.namespace = strings.TrimSpace(string(data))
+		}
+	}
+
+	cli, err := newClient(&o)
+	if err != nil {
+		return nil, err
+	}
+	return &Registry{opts: o, cli: cli}, nil
+}
+
+// Register the registration, the pods are registered by kubernetes service when they are ready, so nothing to do.
+func (r *Registry) Register(_ context.Context, _ *registry.ServiceInstance) error {
+	return nil
+}
+
+// Deregister the registration, the pods are deregistered by kubernetes service when they are terminating, so nothing to do.
+func (r *Registry) Deregister(_ context.Context, _ *registry.ServiceInstance) error {
+	return nil
+}
+
+// GetService return the ready instances of service, the format of service name is name or name.namespace,
+// e.g. user-svc, user-svc.default, user-svc.default.svc.cluster.local
+func (r *Registry) GetService(ctx context.Context, serviceName string) ([]*registry.ServiceInstance, error) {
+	res, err := r.resource(serviceName)
+	if err != nil {
+		return nil, err
+	}
+	objects, _, err := r.cli.list(ctx, res)
+	if err != nil {
+		return nil, err
+	}
+	return r.toServiceInstances(res.name, objects), nil
+}
+
+// Watch creates a watcher according to the service name.
+func (r *Registry) Watch(ctx context.Context, serviceName string) (registry.Watcher, error) {
+	res, err := r.resource(serviceName)
+	if err != nil {
+		return nil, err
+	}
+	return newWatcher(ctx, r, res), nil
+}
+
+func (r *Registry) resource(serviceName string) (*resource, error) {
+	ss := strings.Split(serviceName, ".")
+	if ss[0] == "" {
+		return nil, fmt.Errorf("kubernetes: invalid service name %q", serviceName)
+	}
+	res := &resource{name: ss[0], namespace: r.opts.namespace, useEndpoints: r.opts.useEndpoints}
+	if len(ss) > 1 && ss[1] != "" {
+		res.namespace = ss[1]
+	}
+	return res, nil
+}
+
+// select the port of instance
+func (r *Registry) selectPort(ports []endpointPort) (int, bool) {
+	if r.opts.portName != "" {
+		for _, p := range ports {
+			if p.Name == r.opts.portName {
+				return p.Port, true
+			}
+		}
+		return 0, false
+	}
+	if len(ports) == 1 {
+		return ports[0].Port, true
+	}
+	for _, p := range ports {
+		if strings.Contains(p.Name, r.opts.kind) && !strings.Contains(p.Name, "metrics") {
+			return p.Port, true
+		}
+	}
+	if len(ports) > 0 {
+		return ports[0].Port, true
+	}
+	return 0, false
+}
+
+func (r *Registry) toServiceInstances(name string, objects map[string][]*instance) []*registry.ServiceInstance {
+	var items []*registry.ServiceInstance
+	exists := map[string]struct{}{}
+	for _, ins := range objects {
+		for _, in := range ins {
+			port, ok := r.selectPort(in.ports)
+			if !ok {
+				continue
+			}
+			addr := net.JoinHostPort(in.ip, strconv.Itoa(port))
+			if _, ok = exists[addr]; ok {
+				continue // the same address may exist in multiple slices during updating
+			}
+			exists[addr] = struct{}{}
+
+			id := in.pod
+			if id == "" {
+				id = addr
+			}
+			md := map[string]string{}
+			for k, v := range map[string]string{"pod": in.pod, "hostname": in.hostname, "node": in.node, "zone": in.zone} {
+				if v != "" {
+					md[k] = v
+				}
+			}
+			items = append(items, &registry.ServiceInstance{
+				ID:        id,
+				Name:      name,
+				Metadata:  md,
+				Endpoints: []string{r.opts.kind + "://" + addr},
+			})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Endpoints[0] < items[j].Endpoints[0] })
+	return items
+}
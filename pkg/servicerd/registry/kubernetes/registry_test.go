@@ -0,0 +1,128 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-dev-frame/sponge/pkg/servicerd/registry"
+)
+
+const sliceTpl = `{
+	"metadata": {"name": "%s", "resourceVersion": "%s"},
+	"addressType": "IPv4",
+	"endpoints": [
+		{"addresses": ["%s"], "conditions": {"ready": true}, "nodeName": "node-1", "targetRef": {"kind": "Pod", "name": "user-0"}},
+		{"addresses": ["10.0.0.9"], "conditions": {"ready": false}, "targetRef": {"kind": "Pod", "name": "user-9"}}
+	],
+	"ports": [
+		{"name": "user-svc-http-port", "port": 8080},
+		{"name": "user-svc-grpc-metrics-port", "port": 8283},
+		{"name": "user-svc-grpc-port", "port": 8282}
+	]
+}`
+
+const endpointsTpl = `{
+	"metadata": {"name": "user-svc", "resourceVersion": "10"},
+	"subsets": [{
+		"addresses": [{"ip": "10.0.1.1", "targetRef": {"kind": "Pod", "name": "user-1"}}, {"ip": "10.0.1.2"}],
+		"notReadyAddresses": [{"ip": "10.0.1.3"}],
+		"ports": [{"name": "grpc", "port": 9000}]
+	}]
+}`
+
+func newTestServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/apis/discovery.k8s.io/v1/namespaces/default/endpointslices":
+			assert.Equal(t, "kubernetes.io/service-name=user-svc", r.URL.Query().Get("labelSelector"))
+			_, _ = fmt.Fprintf(w, `{"metadata": {"resourceVersion": "1"}, "items": [%s]}`, fmt.Sprintf(sliceTpl, "user-svc-abc", "1", "10.0.0.1"))
+		case "/api/v1/namespaces/default/endpoints":
+			assert.Equal(t, "metadata.name=user-svc", r.URL.Query().Get("fieldSelector"))
+			_, _ = fmt.Fprintf(w, `{"metadata": {"resourceVersion": "10"}, "items": [%s]}`, endpointsTpl)
+		default:
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message": "forbidden"}`))
+		}
+	}))
+}
+
+func TestNew(t *testing.T) {
+	_ = os.Unsetenv("KUBERNETES_SERVICE_HOST")
+	_, err := New()
+	assert.Error(t, err)
+
+	t.Setenv("KUBERNETES_SERVICE_HOST", "127.0.0.1")
+	t.Setenv("KUBERNETES_SERVICE_PORT", "6443")
+	_, err = New() // not found ca file
+	assert.Error(t, err)
+
+	r, err := New(WithAPIServer("http://127.0.0.1:8001"), WithNamespace("dev"), WithHTTPClient(http.DefaultClient),
+		WithToken("token"), WithPortName("grpc"), WithDefaultKind("grpc"), WithEndpointsAPI())
+	assert.NoError(t, err)
+	assert.Equal(t, "dev", r.opts.namespace)
+	assert.True(t, r.opts.useEndpoints)
+
+	instance := registry.NewServiceInstance("foo", "bar", []string{"grpc://127.0.0.1:8282"})
+	assert.NoError(t, r.Register(context.Background(), instance))
+	assert.NoError(t, r.Deregister(context.Background(), instance))
+}
+
+func TestRegistry_GetService(t *testing.T) {
+	ts := newTestServer(t)
+	defer ts.Close()
+
+	r, err := New(WithAPIServer(ts.URL), WithToken("token"), WithNamespace("default"))
+	assert.NoError(t, err)
+	instances, err := r.GetService(context.Background(), "user-svc")
+	assert.NoError(t, err)
+	assert.Len(t, instances, 1)
+	assert.Equal(t, "user-0", instances[0].ID)
+	assert.Equal(t, "user-svc", instances[0].Name)
+	assert.Equal(t, []string{"grpc://10.0.0.1:8282"}, instances[0].Endpoints)
+	assert.Equal(t, map[string]string{"pod": "user-0", "node": "node-1"}, instances[0].Metadata)
+
+	// specified port name
+	r.opts.portName = "user-svc-http-port"
+	instances, err = r.GetService(context.Background(), "user-svc.default.svc.cluster.local")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"grpc://10.0.0.1:8080"}, instances[0].Endpoints)
+	r.opts.portName = "not-exist"
+	instances, err = r.GetService(context.Background(), "user-svc")
+	assert.NoError(t, err)
+	assert.Len(t, instances, 0)
+
+	// core/v1 endpoints
+	r, _ = New(WithAPIServer(ts.URL), WithToken("token"), WithNamespace("default"), WithEndpointsAPI())
+	instances, err = r.GetService(context.Background(), "user-svc")
+	assert.NoError(t, err)
+	assert.Len(t, instances, 2)
+	assert.Equal(t, "user-1", instances[0].ID)
+	assert.Equal(t, "10.0.1.2:9000", instances[1].ID)
+
+	// error
+	_, err = r.GetService(context.Background(), "user-svc.other")
+	assert.Error(t, err)
+	_, err = r.GetService(context.Background(), "")
+	assert.Error(t, err)
+	_, err = r.Watch(context.Background(), ".default")
+	assert.Error(t, err)
+}
+
+func TestRegistry_selectPort(t *testing.T) {
+	r := &Registry{opts: options{kind: "grpc"}}
+	port, ok := r.selectPort([]endpointPort{{Name: "", Port: 8282}})
+	assert.True(t, ok)
+	assert.Equal(t, 8282, port)
+	port, ok = r.selectPort([]endpointPort{{Name: "http", Port: 8080}, {Name: "metrics", Port: 8283}})
+	assert.True(t, ok)
+	assert.Equal(t, 8080, port)
+	_, ok = r.selectPort(nil)
+	assert.False(t, ok)
+}
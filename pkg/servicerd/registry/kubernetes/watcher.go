@@ -0,0 +1,136 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/go-dev-frame/sponge/pkg/servicerd/registry"
+)
+
+var _ registry.Watcher = (*watcher)(nil)
+
+type watcher struct {
+	r   *Registry
+	res *resource
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu        sync.Mutex
+	objects   map[string][]*instance // object name --> ready instances
+	instances []*registry.ServiceInstance
+	changed   bool
+	err       error
+	event     chan struct{}
+}
+
+func newWatcher(ctx context.Context, r *Registry, res *resource) *watcher {
+	w := &watcher{
+		r:       r,
+		res:     res,
+		objects: map[string][]*instance{},
+		event:   make(chan struct{}, 1),
+	}
+	w.ctx, w.cancel = context.WithCancel(ctx)
+	go w.run()
+	return w
+}
+
+// list and watch the endpoints, list again if the watch is interrupted
+func (w *watcher) run() {
+	backoff := time.Second
+	for {
+		objects, resourceVersion, err := w.r.cli.list(w.ctx, w.res)
+		if err == nil {
+			backoff = time.Second
+			w.mu.Lock()
+			w.objects = objects
+			w.mu.Unlock()
+			w.update(nil)
+
+			for err == nil {
+				resourceVersion, err = w.r.cli.watch(w.ctx, w.res, resourceVersion, func(eventType string, name string, ins []*instance) {
+					w.mu.Lock()
+					if eventType == "DELETED" {
+						delete(w.objects, name)
+					} else {
+						w.objects[name] = ins
+					}
+					w.mu.Unlock()
+					w.update(nil)
+				})
+			}
+		}
+		if w.ctx.Err() != nil {
+			return
+		}
+		if !errors.Is(err, errResourceExpired) {
+			w.update(err)
+			select {
+			case <-w.ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			if backoff < 30*time.Second {
+				backoff *= 2
+			}
+		}
+	}
+}
+
+// notify Next if the instances are changed or an error occurs
+func (w *watcher) update(err error) {
+	w.mu.Lock()
+	if err != nil {
+		w.err = fmt.Errorf("kubernetes: watch service %s.%s error, %v", w.res.name, w.res.namespace, err)
+	} else {
+		instances := w.r.toServiceInstances(w.res.name, w.objects)
+		if reflect.DeepEqual(instances, w.instances) {
+			w.mu.Unlock()
+			return
+		}
+		w.instances = instances
+		w.changed = true
+	}
+	w.mu.Unlock()
+
+	select {
+	case w.event <- struct{}{}:
+	default:
+	}
+}
+
+// Next returns the ready instances of service when they are changed.
+func (w *watcher) Next() ([]*registry.ServiceInstance, error) {
+	select {
+	case <-w.ctx.Done():
+		return nil, w.ctx.Err()
+	case <-w.event:
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		err := w.err
+		w.err = nil
+		if w.changed { // the changed instances are returned by the next call
+			select {
+			case w.event <- struct{}{}:
+			default:
+			}
+		}
+		return nil, err
+	}
+	w.changed = false
+	return w.instances, nil
+}
+
+// Stop close the watcher.
+func (w *watcher) Stop() error {
+	w.cancel()
+	return nil
+}
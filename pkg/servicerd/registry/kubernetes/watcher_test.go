@@ -0,0 +1,104 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatcher(t *testing.T) {
+	var lists int32
+	events := make(chan string, 10)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("watch") != "true" {
+			n := atomic.AddInt32(&lists, 1)
+			_, _ = fmt.Fprintf(w, `{"metadata": {"resourceVersion": "%d"}, "items": [%s]}`, n,
+				fmt.Sprintf(sliceTpl, "user-svc-abc", "1", "10.0.0.1"))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case event := <-events:
+				if event == "" {
+					return // close the stream
+				}
+				_, _ = w.Write([]byte(event + "\n"))
+				w.(http.Flusher).Flush()
+			}
+		}
+	}))
+	defer ts.Close()
+
+	r, err := New(WithAPIServer(ts.URL), WithNamespace("default"))
+	assert.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	w, err := r.Watch(ctx, "user-svc")
+	assert.NoError(t, err)
+
+	// the first time to watch
+	instances, err := w.Next()
+	assert.NoError(t, err)
+	assert.Len(t, instances, 1)
+
+	// add a new slice
+	events <- `{"type": "ADDED", "object": ` + fmt.Sprintf(sliceTpl, "user-svc-def", "2", "10.0.0.2") + `}`
+	instances, err = w.Next()
+	assert.NoError(t, err)
+	assert.Len(t, instances, 2)
+	assert.Equal(t, "grpc://10.0.0.2:8282", instances[1].Endpoints[0])
+
+	// bookmark and the same instances are ignored, the deleted slice is removed
+	events <- `{"type": "BOOKMARK", "object": {"metadata": {"resourceVersion": "3"}}}`
+	events <- `{"type": "MODIFIED", "object": ` + fmt.Sprintf(sliceTpl, "user-svc-def", "4", "10.0.0.2") + `}`
+	events <- `{"type": "DELETED", "object": ` + fmt.Sprintf(sliceTpl, "user-svc-abc", "5", "10.0.0.1") + `}`
+	instances, err = w.Next()
+	assert.NoError(t, err)
+	assert.Len(t, instances, 1)
+	assert.Equal(t, "grpc://10.0.0.2:8282", instances[0].Endpoints[0])
+
+	// resource version expired, list again
+	events <- `{"type": "ERROR", "object": {"kind": "Status", "code": 410, "message": "too old resource version"}}`
+	instances, err = w.Next()
+	assert.NoError(t, err)
+	assert.Len(t, instances, 1)
+	assert.Equal(t, "grpc://10.0.0.1:8282", instances[0].Endpoints[0])
+	assert.Equal(t, int32(2), atomic.LoadInt32(&lists))
+
+	// the stream is closed by server, watch again
+	events <- ""
+	events <- `{"type": "ADDED", "object": ` + fmt.Sprintf(sliceTpl, "user-svc-ghi", "7", "10.0.0.3") + `}`
+	instances, err = w.Next()
+	assert.NoError(t, err)
+	assert.Len(t, instances, 2)
+
+	assert.NoError(t, w.Stop())
+	_, err = w.Next()
+	assert.Error(t, err)
+}
+
+func TestWatcher_Error(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	r, err := New(WithAPIServer(ts.URL))
+	assert.NoError(t, err)
+	w, err := r.Watch(context.Background(), "user-svc")
+	assert.NoError(t, err)
+	_, err = w.Next()
+	assert.Error(t, err)
+	t.Log(err)
+	_ = w.Stop()
+}
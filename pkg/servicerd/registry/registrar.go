@@ -0,0 +1,62 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var registerRetryInterval = time.Second
+
+// Registrar registers a service instance to the registry, it is used as the hooks of app,
+// the instance is registered after the servers are ready and deregistered before they are stopped.
+type Registrar struct {
+	registry Registry
+	instance *ServiceInstance
+
+	mu         sync.Mutex
+	registered bool
+}
+
+// NewRegistrar creates a registrar of the service instance.
+func NewRegistrar(registry Registry, instance *ServiceInstance) *Registrar {
+	return &Registrar{registry: registry, instance: instance}
+}
+
+// Register the service instance, retry until registered or ctx is done, e.g. the registry is temporarily unavailable.
+func (r *Registrar) Register(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.registered {
+		return nil
+	}
+
+	for {
+		err := r.registry.Register(ctx, r.instance)
+		if err == nil {
+			r.registered = true
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("register service %s error: %v", r.instance.Name, err)
+		case <-time.After(registerRetryInterval):
+		}
+	}
+}
+
+// Deregister the service instance, it does nothing if the instance is not registered.
+func (r *Registrar) Deregister(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.registered {
+		return nil
+	}
+
+	if err := r.registry.Deregister(ctx, r.instance); err != nil {
+		return fmt.Errorf("deregister service %s error: %v", r.instance.Name, err)
+	}
+	r.registered = false
+	return nil
+}
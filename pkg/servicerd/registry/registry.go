@@ -1,4 +1,4 @@
-// Package registry is service registry library, supports etcd, consul and nacos.
+// Package registry is service registry library, supports etcd, consul, nacos and kubernetes.
 package registry
 
 import "context"
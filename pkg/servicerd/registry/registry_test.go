@@ -1,7 +1,10 @@
 package registry
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -13,3 +16,49 @@ func TestNewServiceInstance(t *testing.T) {
 	)
 	assert.NotNil(t, s)
 }
+
+type fakeRegistry struct {
+	registerErrs int
+	registered   int
+	deregistered int
+}
+
+func (r *fakeRegistry) Register(_ context.Context, _ *ServiceInstance) error {
+	if r.registerErrs > 0 {
+		r.registerErrs--
+		return errors.New("registry is unavailable")
+	}
+	r.registered++
+	return nil
+}
+
+func (r *fakeRegistry) Deregister(_ context.Context, _ *ServiceInstance) error {
+	r.deregistered++
+	return nil
+}
+
+func TestRegistrar(t *testing.T) {
+	registerRetryInterval = time.Millisecond * 10
+	fr := &fakeRegistry{registerErrs: 2}
+	r := NewRegistrar(fr, NewServiceInstance("foo", "bar", []string{"grpc://127.0.0.1:8282"}))
+
+	// not registered, deregister does nothing
+	assert.NoError(t, r.Deregister(context.Background()))
+	assert.Equal(t, 0, fr.deregistered)
+
+	// retry until registered
+	assert.NoError(t, r.Register(context.Background()))
+	assert.NoError(t, r.Register(context.Background()))
+	assert.Equal(t, 1, fr.registered)
+	assert.NoError(t, r.Deregister(context.Background()))
+	assert.NoError(t, r.Deregister(context.Background()))
+	assert.Equal(t, 1, fr.deregistered)
+
+	// timeout
+	fr.registerErrs = 100
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	err := r.Register(ctx)
+	assert.Error(t, err)
+	t.Log(err)
+}
@@ -0,0 +1,181 @@
+// Package encrypt is encryption at rest for the sensitive columns of gorm, the struct fields tagged with
+// encrypt:"true" are encrypted by AES-GCM before writing and decrypted after reading, the ciphertext
+// contains the key id, so that the keys can be rotated, and the fields tagged with encrypt:"deterministic"
+// are encrypted deterministically, they can be used for equality queries.
+package encrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm/clause"
+)
+
+// the format of ciphertext is enc:<key id>:<base64(nonce + sealed data)>
+const prefix = "enc:"
+
+var (
+	// ErrInvalidCiphertext the ciphertext is not in the format of enc:<key id>:<data>
+	ErrInvalidCiphertext = errors.New("invalid ciphertext")
+	// ErrKeyNotFound the key id of ciphertext is not found
+	ErrKeyNotFound = errors.New("encryption key not found")
+)
+
+// Option set the encryptor options.
+type Option func(*options)
+
+type options struct {
+	decryptKeys map[string][]byte
+}
+
+func (o *options) apply(opts ...Option) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+func defaultOptions() *options {
+	return &options{
+		decryptKeys: map[string][]byte{},
+	}
+}
+
+// WithDecryptKey add the old key that is only used for decryption, it is used for key rotation,
+// the data encrypted by the old key can be read, and it is encrypted by the current key when it is written again.
+func WithDecryptKey(keyID string, key []byte) Option {
+	return func(o *options) {
+		o.decryptKeys[keyID] = key
+	}
+}
+
+type aeadKey struct {
+	aead   cipher.AEAD
+	macKey []byte // derived key used to generate the nonce of deterministic encryption
+}
+
+// Encryptor encrypt and decrypt the data by AES-GCM.
+type Encryptor struct {
+	keyID string
+	keys  map[string]*aeadKey // key id --> key
+}
+
+// New create an encryptor, the key is used for encryption and decryption, the length of key must be
+// 16, 24 or 32 bytes, the key id is saved in the ciphertext, it must not contain ':'.
+func New(keyID string, key []byte, opts ...Option) (*Encryptor, error) {
+	o := defaultOptions()
+	o.apply(opts...)
+	o.decryptKeys[keyID] = key
+
+	e := &Encryptor{keyID: keyID, keys: make(map[string]*aeadKey, len(o.decryptKeys))}
+	for id, k := range o.decryptKeys {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("invalid key id %q, it must not be empty or contain ':'", id)
+		}
+		block, err := aes.NewCipher(k)
+		if err != nil {
+			return nil, fmt.Errorf("key id %s: %v", id, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("key id %s: %v", id, err)
+		}
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte("sgorm encrypt deterministic nonce"))
+		e.keys[id] = &aeadKey{aead: aead, macKey: mac.Sum(nil)}
+	}
+	return e, nil
+}
+
+// KeyID the id of current key
+func (e *Encryptor) KeyID() string {
+	return e.keyID
+}
+
+// Encrypt encrypt the data with a random nonce, the same data is encrypted to different ciphertexts.
+func (e *Encryptor) Encrypt(data []byte) (string, error) {
+	k := e.keys[e.keyID]
+	nonce := make([]byte, k.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return e.seal(k, nonce, data), nil
+}
+
+// EncryptDeterministic encrypt the data with the nonce derived from data, the same data is always
+// encrypted to the same ciphertext by the same key, so the ciphertext can be used for equality queries,
+// but it reveals whether two values are equal.
+func (e *Encryptor) EncryptDeterministic(data []byte) string {
+	k := e.keys[e.keyID]
+	mac := hmac.New(sha256.New, k.macKey)
+	mac.Write(data)
+	return e.seal(k, mac.Sum(nil)[:k.aead.NonceSize()], data)
+}
+
+func (e *Encryptor) seal(k *aeadKey, nonce []byte, data []byte) string {
+	sealed := k.aead.Seal(nonce, nonce, data, []byte(e.keyID))
+	return prefix + e.keyID + ":" + base64.RawStdEncoding.EncodeToString(sealed)
+}
+
+// Decrypt decrypt the ciphertext by the key of key id in ciphertext.
+func (e *Encryptor) Decrypt(ciphertext string) ([]byte, error) {
+	keyID, data, ok := parseCiphertext(ciphertext)
+	if !ok {
+		return nil, ErrInvalidCiphertext
+	}
+	k, ok := e.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w, key id %s", ErrKeyNotFound, keyID)
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(data)
+	if err != nil || len(sealed) < k.aead.NonceSize() {
+		return nil, ErrInvalidCiphertext
+	}
+	nonceSize := k.aead.NonceSize()
+	return k.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], []byte(keyID))
+}
+
+// Reencrypt decrypt the ciphertext and encrypt it by the current key, it is used for key rotation.
+func (e *Encryptor) Reencrypt(ciphertext string, deterministic bool) (string, error) {
+	data, err := e.Decrypt(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	if deterministic {
+		return e.EncryptDeterministic(data), nil
+	}
+	return e.Encrypt(data)
+}
+
+// IsEncrypted check whether the value is a ciphertext, the value that is not encrypted (e.g. the data
+// written before the column is encrypted) is read as it is.
+func IsEncrypted(value string) bool {
+	_, _, ok := parseCiphertext(value)
+	return ok
+}
+
+// KeyIDOf get the key id of ciphertext, e.g. find the data that are not encrypted by the current key.
+func KeyIDOf(ciphertext string) (string, bool) {
+	keyID, _, ok := parseCiphertext(ciphertext)
+	return keyID, ok
+}
+
+func parseCiphertext(s string) (keyID string, data string, ok bool) {
+	if !strings.HasPrefix(s, prefix) {
+		return "", "", false
+	}
+	keyID, data, ok = strings.Cut(s[len(prefix):], ":")
+	return keyID, data, ok && keyID != ""
+}
+
+// Eq the equality condition of the column encrypted deterministically, e.g.
+// db.Where(enc.Eq("email", "foo@bar.com")).First(&user)
+func (e *Encryptor) Eq(column string, value string) clause.Expression {
+	return clause.Eq{Column: column, Value: e.EncryptDeterministic([]byte(value))}
+}
@@ -0,0 +1,94 @@
+package encrypt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	key1 = []byte("0123456789abcdef0123456789abcdef")
+	key2 = []byte("fedcba9876543210")
+)
+
+func TestEncryptor(t *testing.T) {
+	enc, err := New("k1", key1)
+	require.NoError(t, err)
+	assert.Equal(t, "k1", enc.KeyID())
+
+	c1, err := enc.Encrypt([]byte("foo"))
+	require.NoError(t, err)
+	c2, err := enc.Encrypt([]byte("foo"))
+	require.NoError(t, err)
+	assert.NotEqual(t, c1, c2)
+	assert.True(t, strings.HasPrefix(c1, "enc:k1:"))
+	assert.True(t, IsEncrypted(c1))
+	keyID, ok := KeyIDOf(c1)
+	assert.True(t, ok)
+	assert.Equal(t, "k1", keyID)
+
+	data, err := enc.Decrypt(c1)
+	require.NoError(t, err)
+	assert.Equal(t, "foo", string(data))
+
+	d1 := enc.EncryptDeterministic([]byte("foo"))
+	d2 := enc.EncryptDeterministic([]byte("foo"))
+	assert.Equal(t, d1, d2)
+	assert.NotEqual(t, d1, enc.EncryptDeterministic([]byte("bar")))
+	data, err = enc.Decrypt(d1)
+	require.NoError(t, err)
+	assert.Equal(t, "foo", string(data))
+
+	// invalid ciphertext
+	assert.False(t, IsEncrypted("foo"))
+	_, err = enc.Decrypt("foo")
+	assert.ErrorIs(t, err, ErrInvalidCiphertext)
+	_, err = enc.Decrypt("enc:k1:???")
+	assert.ErrorIs(t, err, ErrInvalidCiphertext)
+	_, err = enc.Decrypt("enc:k3:" + c1[len("enc:k1:"):])
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	_, err = enc.Decrypt(c1[:len(c1)-2] + "AA")
+	assert.Error(t, err)
+	_, err = enc.Decrypt("enc:k1:AAAA")
+	assert.ErrorIs(t, err, ErrInvalidCiphertext)
+}
+
+func TestEncryptor_Rotation(t *testing.T) {
+	old, err := New("k1", key1)
+	require.NoError(t, err)
+	c1, err := old.Encrypt([]byte("foo"))
+	require.NoError(t, err)
+
+	enc, err := New("k2", key2, WithDecryptKey("k1", key1))
+	require.NoError(t, err)
+	data, err := enc.Decrypt(c1)
+	require.NoError(t, err)
+	assert.Equal(t, "foo", string(data))
+
+	c2, err := enc.Reencrypt(c1, false)
+	require.NoError(t, err)
+	keyID, _ := KeyIDOf(c2)
+	assert.Equal(t, "k2", keyID)
+	c3, err := enc.Reencrypt(old.EncryptDeterministic([]byte("foo")), true)
+	require.NoError(t, err)
+	assert.Equal(t, enc.EncryptDeterministic([]byte("foo")), c3)
+	_, err = enc.Reencrypt("foo", false)
+	assert.Error(t, err)
+
+	// the data encrypted by new key can not be decrypted by old key
+	_, err = old.Decrypt(c2)
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+}
+
+func TestNew_Error(t *testing.T) {
+	_, err := New("k1", []byte("short"))
+	assert.Error(t, err)
+	_, err = New("", key1)
+	assert.Error(t, err)
+	_, err = New("k:1", key1)
+	assert.Error(t, err)
+	_, err = New("k1", key1, WithDecryptKey("k0", []byte("short")))
+	assert.Error(t, err)
+}
@@ -0,0 +1,334 @@
+package encrypt
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	pluginName = "sgorm:encrypt"
+
+	// TagName the struct tag of encrypted field, the value is true or deterministic, e.g.
+	// Phone string `gorm:"column:phone;type:varchar(255)" encrypt:"true"`
+	TagName = "encrypt"
+	// TagDeterministic the tag value of field encrypted deterministically
+	TagDeterministic = "deterministic"
+)
+
+// Register register the encrypt plugin to db, it is ignored if the plugin has been registered.
+func Register(db *gorm.DB, enc *Encryptor) error {
+	err := db.Use(NewPlugin(enc))
+	if errors.Is(err, gorm.ErrRegistered) {
+		return nil
+	}
+	return err
+}
+
+// Plugin the gorm plugin that encrypts the tagged fields before create and update, and decrypts them
+// after query, the supported field types are string, *string and []byte.
+//
+// the values of where conditions are not encrypted, use Encryptor.Eq for the deterministic fields,
+// and the results of Scan, Pluck, Row and Raw are not decrypted.
+type Plugin struct {
+	enc    *Encryptor
+	fields sync.Map // *schema.Schema --> []*encryptField
+}
+
+type encryptField struct {
+	*schema.Field
+	deterministic bool
+}
+
+// NewPlugin create an encrypt plugin.
+func NewPlugin(enc *Encryptor) *Plugin {
+	return &Plugin{enc: enc}
+}
+
+// Name plugin name
+func (p *Plugin) Name() string {
+	return pluginName
+}
+
+// Initialize register the callbacks, the values are encrypted before writing and restored after writing,
+// so the struct still holds the plaintext after create and update.
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	if p.enc == nil {
+		return errors.New("encryptor is nil")
+	}
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register(pluginName+":before_create", p.encrypt); err != nil {
+		return err
+	}
+	if err := cb.Create().After("gorm:create").Register(pluginName+":after_create", p.decrypt); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register(pluginName+":before_update", p.encrypt); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:update").Register(pluginName+":after_update", p.decrypt); err != nil {
+		return err
+	}
+	return cb.Query().After("gorm:query").Register(pluginName+":after_query", p.decrypt)
+}
+
+func (p *Plugin) encryptFields(s *schema.Schema) []*encryptField {
+	if s == nil {
+		return nil
+	}
+	if v, ok := p.fields.Load(s); ok {
+		return v.([]*encryptField)
+	}
+
+	var fields []*encryptField
+	for _, field := range s.Fields {
+		tag := field.Tag.Get(TagName)
+		if tag != "true" && tag != TagDeterministic {
+			continue
+		}
+		fields = append(fields, &encryptField{Field: field, deterministic: tag == TagDeterministic})
+	}
+	p.fields.Store(s, fields)
+	return fields
+}
+
+func (p *Plugin) encrypt(db *gorm.DB) {
+	if db.Error != nil {
+		return
+	}
+	stmt := db.Statement
+	fields := p.encryptFields(stmt.Schema)
+	if len(fields) == 0 {
+		return
+	}
+
+	switch dest := stmt.Dest.(type) {
+	case map[string]interface{}: // e.g. db.Model(&user).Updates(map), the map of caller is not changed
+		m, err := p.encryptMap(stmt.Schema, dest)
+		if err != nil {
+			_ = db.AddError(err)
+			return
+		}
+		stmt.Dest = m
+		return
+	case []map[string]interface{}:
+		ms := make([]map[string]interface{}, 0, len(dest))
+		for _, d := range dest {
+			m, err := p.encryptMap(stmt.Schema, d)
+			if err != nil {
+				_ = db.AddError(err)
+				return
+			}
+			ms = append(ms, m)
+		}
+		stmt.Dest = ms
+		return
+	}
+
+	encryptFn := func(f *encryptField, fv reflect.Value) error {
+		return p.encryptValue(f.deterministic, fv)
+	}
+	// e.g. db.Model(&user).Updates(User{...})
+	if dv := reflect.ValueOf(stmt.Dest); stmt.Model != nil && (dv.Kind() != reflect.Ptr || stmt.Dest != stmt.Model) {
+		dv = reflect.Indirect(dv)
+		if dv.Kind() == reflect.Struct && dv.Type() == stmt.Schema.ModelType {
+			cp := reflect.New(dv.Type()) // the value of caller is not changed
+			cp.Elem().Set(dv)
+			if err := p.walk(stmt, cp, fields, encryptFn); err != nil {
+				_ = db.AddError(err)
+				return
+			}
+			stmt.Dest = cp.Interface()
+			return
+		}
+	}
+	_ = db.AddError(p.walk(stmt, stmt.ReflectValue, fields, encryptFn))
+}
+
+func (p *Plugin) decrypt(db *gorm.DB) {
+	stmt := db.Statement
+	fields := p.encryptFields(stmt.Schema)
+	if len(fields) == 0 {
+		return
+	}
+	_ = db.AddError(p.walk(stmt, stmt.ReflectValue, fields, func(_ *encryptField, fv reflect.Value) error {
+		return p.decryptValue(fv)
+	}))
+}
+
+// call fn for the encrypted fields of the records
+func (p *Plugin) walk(stmt *gorm.Statement, rv reflect.Value, fields []*encryptField,
+	fn func(f *encryptField, fv reflect.Value) error) error {
+	handle := func(rv reflect.Value) error {
+		rv = reflect.Indirect(rv)
+		if rv.Kind() != reflect.Struct || rv.Type() != stmt.Schema.ModelType {
+			return nil
+		}
+		for _, f := range fields {
+			if err := fn(f, f.ReflectValueOf(stmt.Context, rv)); err != nil {
+				return fmt.Errorf("field %s: %w", f.Name, err)
+			}
+		}
+		return nil
+	}
+
+	rv = reflect.Indirect(rv)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if err := handle(rv.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		return handle(rv)
+	}
+	return nil
+}
+
+func (p *Plugin) encryptValue(deterministic bool, fv reflect.Value) error {
+	if !fv.CanSet() {
+		return nil
+	}
+	if fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			return nil
+		}
+		// point to a new value, the value pointed by caller is not changed
+		ptr := reflect.New(fv.Type().Elem())
+		ptr.Elem().Set(fv.Elem())
+		fv.Set(ptr)
+		fv = ptr.Elem()
+	}
+
+	var data []byte
+	switch {
+	case fv.Kind() == reflect.String:
+		data = []byte(fv.String())
+	case fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.Uint8:
+		if fv.IsNil() {
+			return nil
+		}
+		data = fv.Bytes()
+	default:
+		return fmt.Errorf("unsupported type %s", fv.Type())
+	}
+	// the empty value is not encrypted, so that it is still ignored by db.Updates(struct)
+	if len(data) == 0 || p.isCiphertext(data) { // e.g. create after update in db.Save
+		return nil
+	}
+
+	ciphertext, err := p.encryptData(deterministic, data)
+	if err != nil {
+		return err
+	}
+	if fv.Kind() == reflect.String {
+		fv.SetString(ciphertext)
+	} else {
+		fv.SetBytes([]byte(ciphertext))
+	}
+	return nil
+}
+
+func (p *Plugin) decryptValue(fv reflect.Value) error {
+	if !fv.CanSet() {
+		return nil
+	}
+	if fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			return nil
+		}
+		fv = fv.Elem()
+	}
+
+	var ciphertext string
+	switch {
+	case fv.Kind() == reflect.String:
+		ciphertext = fv.String()
+	case fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.Uint8:
+		ciphertext = string(fv.Bytes())
+	default:
+		return nil
+	}
+	if !IsEncrypted(ciphertext) { // the data written before the column is encrypted
+		return nil
+	}
+
+	data, err := p.enc.Decrypt(ciphertext)
+	if err != nil {
+		return err
+	}
+	if fv.Kind() == reflect.String {
+		fv.SetString(string(data))
+	} else {
+		fv.SetBytes(data)
+	}
+	return nil
+}
+
+func (p *Plugin) encryptMap(s *schema.Schema, m map[string]interface{}) (map[string]interface{}, error) {
+	fields := p.encryptFields(s)
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		out[k] = v
+		field := s.LookUpField(k)
+		if field == nil {
+			continue
+		}
+		for _, f := range fields {
+			if f.Field != field {
+				continue
+			}
+			value, err := p.encryptMapValue(f.deterministic, v)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", f.Name, err)
+			}
+			out[k] = value
+		}
+	}
+	return out, nil
+}
+
+func (p *Plugin) encryptMapValue(deterministic bool, v interface{}) (interface{}, error) {
+	var data []byte
+	switch value := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		data = []byte(value)
+	case *string:
+		if value == nil {
+			return nil, nil
+		}
+		data = []byte(*value)
+	case []byte:
+		data = value
+	default:
+		return nil, fmt.Errorf("unsupported type %T", v)
+	}
+	if len(data) == 0 || p.isCiphertext(data) {
+		return v, nil
+	}
+	return p.encryptData(deterministic, data)
+}
+
+// check whether the data has been encrypted by the encryptor, the ciphertext is authenticated by decrypting it,
+// the plaintext that only has the prefix of ciphertext (e.g. enc:k1:foo) is still encrypted.
+func (p *Plugin) isCiphertext(data []byte) bool {
+	if !IsEncrypted(string(data)) {
+		return false
+	}
+	_, err := p.enc.Decrypt(string(data))
+	return err == nil
+}
+
+func (p *Plugin) encryptData(deterministic bool, data []byte) (string, error) {
+	if deterministic {
+		return p.enc.EncryptDeterministic(data), nil
+	}
+	return p.enc.Encrypt(data)
+}
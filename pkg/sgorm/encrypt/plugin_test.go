@@ -0,0 +1,182 @@
+package encrypt
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type user struct {
+	ID     uint64 `gorm:"primaryKey"`
+	Name   string
+	Email  string  `encrypt:"deterministic"`
+	Phone  *string `encrypt:"true"`
+	Secret []byte  `encrypt:"true"`
+}
+
+// the raw value of table
+type rawUser struct {
+	ID     uint64
+	Name   string
+	Email  string
+	Phone  *string
+	Secret []byte
+}
+
+func newTestDB(t *testing.T, enc *Encryptor) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "encrypt.db")),
+		&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&user{}))
+	require.NoError(t, Register(db, enc))
+	require.NoError(t, Register(db, enc)) // registered repeatedly is ignored
+	return db
+}
+
+func getRawUser(t *testing.T, db *gorm.DB, id uint64) *rawUser {
+	raw := &rawUser{}
+	require.NoError(t, db.Table("users").Where("id = ?", id).Take(raw).Error)
+	return raw
+}
+
+func TestPlugin(t *testing.T) {
+	enc, err := New("k1", key1)
+	require.NoError(t, err)
+	db := newTestDB(t, enc)
+	assert.Equal(t, pluginName, NewPlugin(enc).Name())
+
+	// create, the struct still holds the plaintext
+	phone := "16000000001"
+	u := &user{Name: "foo", Email: "foo@bar.com", Phone: &phone, Secret: []byte("secret")}
+	require.NoError(t, db.Create(u).Error)
+	assert.Equal(t, "foo@bar.com", u.Email)
+	assert.Equal(t, "16000000001", *u.Phone)
+	assert.Equal(t, "16000000001", phone)
+	assert.Equal(t, "secret", string(u.Secret))
+
+	raw := getRawUser(t, db, u.ID)
+	assert.Equal(t, "foo", raw.Name)
+	assert.Equal(t, enc.EncryptDeterministic([]byte("foo@bar.com")), raw.Email)
+	assert.True(t, IsEncrypted(*raw.Phone))
+	assert.True(t, IsEncrypted(string(raw.Secret)))
+
+	// query
+	got := &user{}
+	require.NoError(t, db.Where(enc.Eq("email", "foo@bar.com")).First(got).Error)
+	assert.Equal(t, "foo@bar.com", got.Email)
+	assert.Equal(t, "16000000001", *got.Phone)
+	assert.Equal(t, "secret", string(got.Secret))
+	var users []*user
+	require.NoError(t, db.Create([]*user{{Name: "bar", Email: "bar@bar.com"}, {Name: "baz"}}).Error)
+	require.NoError(t, db.Order("id").Find(&users).Error)
+	require.Len(t, users, 3)
+	assert.Equal(t, "bar@bar.com", users[1].Email)
+	assert.Nil(t, users[1].Phone)
+	assert.Equal(t, "", users[2].Email) // the empty value is not encrypted
+	assert.Equal(t, "", getRawUser(t, db, users[2].ID).Email)
+
+	// update by struct, the value of caller is not changed
+	update := user{Email: "new@bar.com"}
+	require.NoError(t, db.Model(&user{ID: u.ID}).Updates(update).Error)
+	assert.Equal(t, "new@bar.com", update.Email)
+	raw = getRawUser(t, db, u.ID)
+	assert.Equal(t, enc.EncryptDeterministic([]byte("new@bar.com")), raw.Email)
+	assert.True(t, IsEncrypted(*raw.Phone)) // the zero value field is not updated
+
+	// update by map
+	m := map[string]interface{}{"email": "map@bar.com", "phone": "16000000002", "name": "foo2"}
+	require.NoError(t, db.Model(&user{ID: u.ID}).Updates(m).Error)
+	assert.Equal(t, "map@bar.com", m["email"])
+	require.NoError(t, db.Model(&user{ID: u.ID}).Update("Secret", []byte("secret2")).Error)
+	got = &user{}
+	require.NoError(t, db.First(got, u.ID).Error)
+	assert.Equal(t, "map@bar.com", got.Email)
+	assert.Equal(t, "16000000002", *got.Phone)
+	assert.Equal(t, "secret2", string(got.Secret))
+	assert.Equal(t, "foo2", got.Name)
+	assert.Equal(t, enc.EncryptDeterministic([]byte("map@bar.com")), getRawUser(t, db, u.ID).Email)
+
+	// save
+	got.Email = "save@bar.com"
+	require.NoError(t, db.Save(got).Error)
+	assert.Equal(t, "save@bar.com", got.Email)
+	assert.Equal(t, enc.EncryptDeterministic([]byte("save@bar.com")), getRawUser(t, db, u.ID).Email)
+
+	// the data written before the column is encrypted is read as it is
+	require.NoError(t, db.Table("users").Create(&rawUser{ID: 100, Email: "plain@bar.com"}).Error)
+	got = &user{}
+	require.NoError(t, db.First(got, 100).Error)
+	assert.Equal(t, "plain@bar.com", got.Email)
+
+	err = db.Model(&user{ID: u.ID}).Updates(map[string]interface{}{"email": 1}).Error
+	assert.Error(t, err)
+}
+
+func TestPlugin_PrefixedPlaintext(t *testing.T) {
+	enc, err := New("k1", key1)
+	require.NoError(t, err)
+	db := newTestDB(t, enc)
+
+	// the plaintext with the prefix of ciphertext is encrypted, it is not stored as it is
+	phone := "enc:k1:16000000001"
+	u := &user{Name: "foo", Email: "enc:k1:foo@bar.com", Phone: &phone, Secret: []byte("enc:unknown:secret")}
+	require.NoError(t, db.Create(u).Error)
+	assert.Equal(t, "enc:k1:foo@bar.com", u.Email)
+	raw := getRawUser(t, db, u.ID)
+	assert.Equal(t, enc.EncryptDeterministic([]byte("enc:k1:foo@bar.com")), raw.Email)
+	data, err := enc.Decrypt(*raw.Phone)
+	require.NoError(t, err)
+	assert.Equal(t, phone, string(data))
+	data, err = enc.Decrypt(string(raw.Secret))
+	require.NoError(t, err)
+	assert.Equal(t, "enc:unknown:secret", string(data))
+
+	err = db.Model(&user{ID: u.ID}).Updates(map[string]interface{}{"email": "enc:k1:map@bar.com"}).Error
+	require.NoError(t, err)
+	assert.Equal(t, enc.EncryptDeterministic([]byte("enc:k1:map@bar.com")), getRawUser(t, db, u.ID).Email)
+
+	got := &user{}
+	require.NoError(t, db.First(got, u.ID).Error)
+	assert.Equal(t, "enc:k1:map@bar.com", got.Email)
+	assert.Equal(t, phone, *got.Phone)
+	assert.Equal(t, "enc:unknown:secret", string(got.Secret))
+
+	// the ciphertext of encryptor is not encrypted again
+	ciphertext := enc.EncryptDeterministic([]byte("cipher@bar.com"))
+	require.NoError(t, db.Model(&user{ID: u.ID}).Updates(map[string]interface{}{"email": ciphertext}).Error)
+	assert.Equal(t, ciphertext, getRawUser(t, db, u.ID).Email)
+}
+
+func TestPlugin_Rotation(t *testing.T) {
+	old, err := New("k1", key1)
+	require.NoError(t, err)
+	db := newTestDB(t, old)
+	require.NoError(t, db.Create(&user{Name: "foo", Email: "foo@bar.com"}).Error)
+
+	enc, err := New("k2", key2, WithDecryptKey("k1", key1))
+	require.NoError(t, err)
+	db2, err := gorm.Open(sqlite.Open(db.Dialector.(*sqlite.Dialector).DSN),
+		&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, Register(db2, enc))
+
+	got := &user{}
+	require.NoError(t, db2.First(got).Error)
+	assert.Equal(t, "foo@bar.com", got.Email)
+	require.NoError(t, db2.Save(got).Error) // written by the new key
+	keyID, _ := KeyIDOf(getRawUser(t, db2, got.ID).Email)
+	assert.Equal(t, "k2", keyID)
+
+	// the key of ciphertext is not found
+	db3 := newTestDB(t, old)
+	require.NoError(t, db3.Table("users").Create(&rawUser{ID: 1, Email: getRawUser(t, db2, got.ID).Email}).Error)
+	err = db3.First(&user{}).Error
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	assert.Error(t, (&gorm.DB{Config: &gorm.Config{}}).Use(NewPlugin(nil)))
+}
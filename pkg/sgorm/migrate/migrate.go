@@ -0,0 +1,363 @@
+// Package migrate applies the sql migration files to the database, the migration files are compatible with
+// golang-migrate (<version>_<name>.up.sql and <version>_<name>.down.sql) and goose (<version>_<name>.sql with
+// the annotations -- +goose Up and -- +goose Down), the current version is recorded in the table schema_migrations
+// in the same way as golang-migrate.
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	gooseUp             = "-- +goose Up"
+	gooseDown           = "-- +goose Down"
+	gooseStatementBegin = "-- +goose StatementBegin"
+	gooseStatementEnd   = "-- +goose StatementEnd"
+)
+
+var (
+	migrateFileRegexp = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+	gooseFileRegexp   = regexp.MustCompile(`^(\d+)_(.+)\.sql$`)
+)
+
+// Migration is a version of database changes.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      []string // statements to apply the version
+	Down    []string // statements to roll back the version
+}
+
+// Option set the migrate options.
+type Option func(*options)
+
+type options struct {
+	tableName string
+	zapLogger *zap.Logger
+}
+
+func defaultOptions() *options {
+	return &options{
+		tableName: "schema_migrations",
+		zapLogger: zap.NewNop(),
+	}
+}
+
+func (o *options) apply(opts ...Option) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WithTableName set the table name of recording the version, default is schema_migrations
+func WithTableName(name string) Option {
+	return func(o *options) {
+		if name != "" {
+			o.tableName = name
+		}
+	}
+}
+
+// WithLogger set logger
+func WithLogger(l *zap.Logger) Option {
+	return func(o *options) {
+		if l != nil {
+			o.zapLogger = l
+		}
+	}
+}
+
+// Load read the migration files in the root directory of fsys, the migrations are sorted by version.
+func Load(fsys fs.FS) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	versions := map[int64]*Migration{}
+	getMigration := func(version string, name string) (*Migration, error) {
+		v, err := strconv.ParseInt(version, 10, 64)
+		if err != nil || v <= 0 {
+			return nil, fmt.Errorf("invalid migration version '%s'", version)
+		}
+		m, ok := versions[v]
+		if !ok {
+			m = &Migration{Version: v, Name: name}
+			versions[v] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("duplicate migration version %d, names '%s' and '%s'", v, m.Name, name)
+		}
+		return m, nil
+	}
+
+	for _, entry := range entries {
+		filename := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(filename, ".sql") {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, filename)
+		if err != nil {
+			return nil, err
+		}
+
+		if ss := migrateFileRegexp.FindStringSubmatch(filename); len(ss) == 4 {
+			m, err := getMigration(ss[1], ss[2])
+			if err != nil {
+				return nil, err
+			}
+			if ss[3] == "up" {
+				m.Up = SplitStatements(string(data))
+			} else {
+				m.Down = SplitStatements(string(data))
+			}
+			continue
+		}
+
+		if ss := gooseFileRegexp.FindStringSubmatch(filename); len(ss) == 3 {
+			up, down, err := parseGooseContent(string(data))
+			if err != nil {
+				return nil, fmt.Errorf("parse file '%s' error: %v", filename, err)
+			}
+			m, err := getMigration(ss[1], ss[2])
+			if err != nil {
+				return nil, err
+			}
+			m.Up, m.Down = up, down
+			continue
+		}
+
+		return nil, fmt.Errorf("invalid migration file name '%s', e.g. 20240101000000_create_user.up.sql", filename)
+	}
+
+	migrations := make([]*Migration, 0, len(versions))
+	for _, m := range versions {
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Up apply all the migrations whose version is greater than the current version.
+func Up(db *gorm.DB, fsys fs.FS, opts ...Option) error {
+	o := defaultOptions()
+	o.apply(opts...)
+
+	migrations, err := Load(fsys)
+	if err != nil {
+		return err
+	}
+	current, err := getVersion(db, o.tableName)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+		if err = execute(db, o.tableName, m.Version, m.Up); err != nil {
+			return fmt.Errorf("apply migration %d_%s error: %v", m.Version, m.Name, err)
+		}
+		o.zapLogger.Info("migration applied", zap.Int64("version", m.Version), zap.String("name", m.Name))
+	}
+
+	return nil
+}
+
+// Down roll back the latest applied migrations, steps <= 0 means roll back all the applied migrations.
+func Down(db *gorm.DB, fsys fs.FS, steps int, opts ...Option) error {
+	o := defaultOptions()
+	o.apply(opts...)
+
+	migrations, err := Load(fsys)
+	if err != nil {
+		return err
+	}
+	current, err := getVersion(db, o.tableName)
+	if err != nil {
+		return err
+	}
+
+	for i := len(migrations) - 1; i >= 0 && current > 0; i-- {
+		m := migrations[i]
+		if m.Version > current {
+			continue
+		}
+		if m.Version < current {
+			return fmt.Errorf("the current version %d is not found in the migration files", current)
+		}
+
+		var previous int64
+		if i > 0 {
+			previous = migrations[i-1].Version
+		}
+		if err = execute(db, o.tableName, previous, m.Down); err != nil {
+			return fmt.Errorf("roll back migration %d_%s error: %v", m.Version, m.Name, err)
+		}
+		o.zapLogger.Info("migration rolled back", zap.Int64("version", m.Version), zap.String("name", m.Name))
+
+		current = previous
+		steps--
+		if steps == 0 {
+			break
+		}
+	}
+
+	return nil
+}
+
+// Version get the current version, 0 means no migration has been applied,
+// dirty is true if the migration of the version failed.
+func Version(db *gorm.DB, opts ...Option) (version int64, dirty bool, err error) {
+	o := defaultOptions()
+	o.apply(opts...)
+
+	if err = createVersionTable(db, o.tableName); err != nil {
+		return 0, false, err
+	}
+	return readVersion(db, o.tableName)
+}
+
+// Force set the current version without running migrations and clear the dirty state,
+// it is used to recover after fixing a failed migration manually.
+func Force(db *gorm.DB, version int64, opts ...Option) error {
+	o := defaultOptions()
+	o.apply(opts...)
+
+	if err := createVersionTable(db, o.tableName); err != nil {
+		return err
+	}
+	return setVersion(db, o.tableName, version, false)
+}
+
+// the version is marked dirty before executing the statements, and it is cleared after all the statements succeed.
+func execute(db *gorm.DB, tableName string, version int64, statements []string) error {
+	if err := setVersion(db, tableName, version, true); err != nil {
+		return err
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return setVersion(db, tableName, version, false)
+}
+
+func getVersion(db *gorm.DB, tableName string) (int64, error) {
+	if err := createVersionTable(db, tableName); err != nil {
+		return 0, err
+	}
+	version, dirty, err := readVersion(db, tableName)
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, fmt.Errorf("database version %d is dirty, fix the database manually and then force the version", version)
+	}
+	return version, nil
+}
+
+func createVersionTable(db *gorm.DB, tableName string) error {
+	return db.Exec("CREATE TABLE IF NOT EXISTS " + tableName + " (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)").Error
+}
+
+func readVersion(db *gorm.DB, tableName string) (int64, bool, error) {
+	var row struct {
+		Version int64
+		Dirty   bool
+	}
+	result := db.Raw("SELECT version, dirty FROM " + tableName + " LIMIT 1").Scan(&row)
+	if result.Error != nil {
+		return 0, false, result.Error
+	}
+	return row.Version, row.Dirty, nil
+}
+
+func setVersion(db *gorm.DB, tableName string, version int64, dirty bool) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM " + tableName).Error; err != nil {
+			return err
+		}
+		if version <= 0 {
+			return nil
+		}
+		return tx.Exec("INSERT INTO "+tableName+" (version, dirty) VALUES (?, ?)", version, dirty).Error
+	})
+}
+
+func parseGooseContent(content string) (up []string, down []string, err error) {
+	upIndex := strings.Index(content, gooseUp)
+	if upIndex < 0 {
+		return nil, nil, errors.New("annotation '" + gooseUp + "' not found")
+	}
+	downIndex := strings.Index(content, gooseDown)
+	if downIndex < 0 {
+		return SplitStatements(content[upIndex+len(gooseUp):]), nil, nil
+	}
+	if downIndex < upIndex {
+		return nil, nil, errors.New("annotation '" + gooseDown + "' must be after '" + gooseUp + "'")
+	}
+	return SplitStatements(content[upIndex+len(gooseUp) : downIndex]), SplitStatements(content[downIndex+len(gooseDown):]), nil
+}
+
+// SplitStatements split the sql content into statements by the semicolon at the end of the line,
+// the statements between -- +goose StatementBegin and -- +goose StatementEnd are not split.
+func SplitStatements(content string) []string {
+	var statements []string
+	var buf strings.Builder
+	isInBlock := false
+
+	flush := func() {
+		statement := strings.TrimSpace(buf.String())
+		buf.Reset()
+		if statement != "" && !isOnlyComments(statement) {
+			statements = append(statements, statement)
+		}
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, gooseStatementBegin):
+			flush()
+			isInBlock = true
+			continue
+		case strings.HasPrefix(trimmed, gooseStatementEnd):
+			isInBlock = false
+			flush()
+			continue
+		case strings.HasPrefix(trimmed, "-- +goose"):
+			continue
+		}
+
+		buf.WriteString(line)
+		buf.WriteString("\n")
+		if !isInBlock && strings.HasSuffix(trimmed, ";") {
+			flush()
+		}
+	}
+	flush()
+
+	return statements
+}
+
+func isOnlyComments(statement string) bool {
+	for _, line := range strings.Split(statement, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
+	}
+	return true
+}
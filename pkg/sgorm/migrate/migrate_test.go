@@ -0,0 +1,124 @@
+package migrate
+
+import (
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var testFS = fstest.MapFS{
+	"20240101000000_create_user.up.sql":   {Data: []byte("CREATE TABLE user (id integer PRIMARY KEY, name text NOT NULL);\n")},
+	"20240101000000_create_user.down.sql": {Data: []byte("DROP TABLE user;\n")},
+	"20240102000000_add_user_age.sql": {Data: []byte(`-- +goose Up
+ALTER TABLE user ADD COLUMN age integer NOT NULL DEFAULT 0;
+-- comment of index
+CREATE INDEX idx_user_age ON user (age);
+
+-- +goose Down
+DROP INDEX idx_user_age;
+ALTER TABLE user DROP COLUMN age;
+`)},
+	"README.md": {Data: []byte("ignored")},
+}
+
+func newTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "migrate.db")),
+		&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	return db
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load(testFS)
+	require.NoError(t, err)
+	require.Len(t, migrations, 2)
+	assert.Equal(t, int64(20240101000000), migrations[0].Version)
+	assert.Equal(t, "create_user", migrations[0].Name)
+	assert.Len(t, migrations[0].Up, 1)
+	assert.Len(t, migrations[0].Down, 1)
+	assert.Equal(t, "add_user_age", migrations[1].Name)
+	assert.Len(t, migrations[1].Up, 2)
+	assert.Len(t, migrations[1].Down, 2)
+
+	_, err = Load(fstest.MapFS{"create_user.sql": {Data: []byte("")}})
+	assert.Error(t, err)
+	_, err = Load(fstest.MapFS{"1_create_user.sql": {Data: []byte("CREATE TABLE user (id integer);")}})
+	assert.Error(t, err)
+	_, err = Load(fstest.MapFS{
+		"1_create_user.up.sql":  {Data: []byte("")},
+		"1_create_order.up.sql": {Data: []byte("")},
+	})
+	assert.Error(t, err)
+}
+
+func TestUpAndDown(t *testing.T) {
+	db := newTestDB(t)
+
+	require.NoError(t, Up(db, testFS, WithLogger(nil)))
+	version, dirty, err := Version(db)
+	require.NoError(t, err)
+	assert.Equal(t, int64(20240102000000), version)
+	assert.False(t, dirty)
+	assert.True(t, db.Migrator().HasColumn("user", "age"))
+
+	// applied migrations are skipped
+	require.NoError(t, Up(db, testFS))
+
+	require.NoError(t, Down(db, testFS, 1))
+	version, _, err = Version(db)
+	require.NoError(t, err)
+	assert.Equal(t, int64(20240101000000), version)
+	assert.False(t, db.Migrator().HasColumn("user", "age"))
+
+	require.NoError(t, Down(db, testFS, 0))
+	version, _, err = Version(db)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), version)
+	assert.False(t, db.Migrator().HasTable("user"))
+}
+
+func TestUpDirty(t *testing.T) {
+	db := newTestDB(t)
+	fsys := fstest.MapFS{
+		"1_create_user.up.sql": {Data: []byte("CREATE TABLE user (id integer PRIMARY KEY);")},
+		"2_bad.up.sql":         {Data: []byte("ALTER TABLE not_exists ADD COLUMN age integer;")},
+	}
+
+	assert.Error(t, Up(db, fsys, WithTableName("versions")))
+	version, dirty, err := Version(db, WithTableName("versions"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), version)
+	assert.True(t, dirty)
+
+	// dirty version must be fixed first
+	assert.Error(t, Up(db, fsys, WithTableName("versions")))
+
+	require.NoError(t, Force(db, 1, WithTableName("versions")))
+	version, dirty, err = Version(db, WithTableName("versions"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), version)
+	assert.False(t, dirty)
+}
+
+func TestSplitStatements(t *testing.T) {
+	statements := SplitStatements(`-- only comment;
+CREATE TABLE a (id integer);
+
+-- +goose StatementBegin
+CREATE TRIGGER t AFTER INSERT ON a
+BEGIN
+  UPDATE a SET id = id;
+END;
+-- +goose StatementEnd
+INSERT INTO a VALUES (1)`)
+	require.Len(t, statements, 3)
+	assert.Equal(t, "CREATE TABLE a (id integer);", statements[0])
+	assert.Contains(t, statements[1], "UPDATE a SET id = id;\nEND;")
+	assert.Equal(t, "INSERT INTO a VALUES (1)", statements[2])
+}
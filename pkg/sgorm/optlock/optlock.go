@@ -0,0 +1,68 @@
+// Package optlock implements the optimistic locking of updates, the expected version of record
+// (e.g. the value of updated_at or version column read before) is carried by context, the update
+// succeeds only if the record has not been modified by others since it was read.
+package optlock
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrConflict the record has been modified (or deleted) since the expected version was read
+var ErrConflict = errors.New("optlock: the record has been modified")
+
+type expectKey struct{}
+
+type expectation struct {
+	column string
+	value  interface{}
+}
+
+// Expect returns a new context that the updates with it succeed only if the column of record still
+// equals value, e.g. Expect(ctx, "updated_at", record.UpdatedAt).
+func Expect(ctx context.Context, column string, value interface{}) context.Context {
+	return context.WithValue(ctx, expectKey{}, expectation{column: column, value: value})
+}
+
+// FromContext get the column and its expected value from ctx.
+func FromContext(ctx context.Context) (string, interface{}, bool) {
+	if ctx == nil {
+		return "", nil, false
+	}
+	e, ok := ctx.Value(expectKey{}).(expectation)
+	return e.column, e.value, ok
+}
+
+// Scope returns a gorm scope that adds the condition of expected version in ctx, it does nothing
+// if ctx has no expected version, e.g.
+//
+//	result := db.WithContext(ctx).Model(table).Scopes(optlock.Scope(ctx)).Updates(update)
+//	err := optlock.Check(ctx, result)
+func Scope(ctx context.Context) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		column, value, ok := FromContext(ctx)
+		if !ok {
+			return db
+		}
+		return db.Where(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: column}, Value: value})
+	}
+}
+
+// Check returns the error of update result, it returns ErrConflict if ctx has the expected version
+// and no record is updated.
+//
+// Note: mysql counts the changed rows only, the update with the same values in the same second of
+// updated_at (datetime without fractional seconds) is reported as conflict, use a version column
+// or datetime(3) to avoid it.
+func Check(ctx context.Context, result *gorm.DB) error {
+	if result.Error != nil {
+		return result.Error
+	}
+	if _, _, ok := FromContext(ctx); ok && result.RowsAffected == 0 {
+		return ErrConflict
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package optlock
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type user struct {
+	ID        uint64 `gorm:"primaryKey"`
+	Name      string
+	UpdatedAt time.Time
+}
+
+func TestOptimisticLock(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "optlock.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&user{}))
+	require.NoError(t, db.Create(&user{ID: 1, Name: "foo"}).Error)
+
+	var record user
+	require.NoError(t, db.First(&record, 1).Error)
+
+	update := func(ctx context.Context, name string) error {
+		result := db.WithContext(ctx).Model(&user{ID: 1}).Scopes(Scope(ctx)).Updates(map[string]interface{}{"name": name})
+		return Check(ctx, result)
+	}
+
+	// without expected version
+	_, _, ok := FromContext(context.Background())
+	assert.False(t, ok)
+	assert.NoError(t, update(context.Background(), "bar"))
+
+	// the record has been modified since it was read
+	ctx := Expect(context.Background(), "updated_at", record.UpdatedAt)
+	column, value, ok := FromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "updated_at", column)
+	assert.Equal(t, record.UpdatedAt, value)
+	assert.ErrorIs(t, update(ctx, "baz"), ErrConflict)
+
+	// the latest version
+	require.NoError(t, db.First(&record, 1).Error)
+	assert.Equal(t, "bar", record.Name)
+	ctx = Expect(context.Background(), "updated_at", record.UpdatedAt)
+	assert.NoError(t, update(ctx, "baz"))
+	require.NoError(t, db.First(&record, 1).Error)
+	assert.Equal(t, "baz", record.Name)
+
+	// the error of update is returned
+	ctx = Expect(context.Background(), "not_exist", 1)
+	err = update(ctx, "qux")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrConflict)
+}
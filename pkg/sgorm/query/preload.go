@@ -0,0 +1,124 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var defaultPreloadMaxDepth = 3
+
+var columnNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Preload an association of model to preload
+type Preload struct {
+	// name of association field, the nested association is separated by dot, e.g. Orders.Items
+	Name string `json:"name" form:"name"`
+	// columns of the associated table, empty means all columns, the foreign key column must be
+	// included, otherwise gorm cannot match the associated records
+	Columns []string `json:"columns" form:"columns"`
+}
+
+type preloadOptions struct {
+	maxDepth       int
+	whitelistNames map[string]bool
+}
+
+// PreloadOption set the parameters of preload options
+type PreloadOption func(*preloadOptions)
+
+func (o *preloadOptions) apply(opts ...PreloadOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WithPreloadMaxDepth set the max depth of nested association, default is 3, e.g. the depth of Orders.Items is 2
+func WithPreloadMaxDepth(depth int) PreloadOption {
+	return func(o *preloadOptions) {
+		if depth > 0 {
+			o.maxDepth = depth
+		}
+	}
+}
+
+// WithPreloadWhitelistNames set white list names of association, the nested association is the full name
+// such as Orders.Items, it is recommended when the preloads come from request
+func WithPreloadWhitelistNames(whitelistNames map[string]bool) PreloadOption {
+	return func(o *preloadOptions) {
+		o.whitelistNames = whitelistNames
+	}
+}
+
+// ParsePreloads parse preloads from string, associations are separated by semicolon, and the columns
+// after colon are separated by comma, e.g. "User:id,name;Orders.Items"
+func ParsePreloads(s string) []Preload {
+	var preloads []Preload
+	for _, item := range strings.Split(s, ";") {
+		name, columns, _ := strings.Cut(strings.TrimSpace(item), ":")
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		preload := Preload{Name: name}
+		for _, column := range strings.Split(columns, ",") {
+			if column = strings.TrimSpace(column); column != "" {
+				preload.Columns = append(preload.Columns, column)
+			}
+		}
+		preloads = append(preloads, preload)
+	}
+	return preloads
+}
+
+// PreloadScope convert preloads to gorm scope, e.g. db.Scopes(scope).Find(&records)
+func PreloadScope(preloads []Preload, opts ...PreloadOption) (func(db *gorm.DB) *gorm.DB, error) {
+	o := &preloadOptions{maxDepth: defaultPreloadMaxDepth}
+	o.apply(opts...)
+
+	for _, preload := range preloads {
+		if err := preload.checkValid(o); err != nil {
+			return nil, err
+		}
+	}
+
+	return func(db *gorm.DB) *gorm.DB {
+		for _, preload := range preloads {
+			if len(preload.Columns) == 0 {
+				db = db.Preload(preload.Name)
+				continue
+			}
+			columns := preload.Columns
+			db = db.Preload(preload.Name, func(tx *gorm.DB) *gorm.DB {
+				return tx.Select(columns)
+			})
+		}
+		return db
+	}, nil
+}
+
+func (p *Preload) checkValid(o *preloadOptions) error {
+	if p.Name == "" {
+		return fmt.Errorf("preload name cannot be empty")
+	}
+	names := strings.Split(p.Name, ".")
+	if len(names) > o.maxDepth {
+		return fmt.Errorf("the depth of preload %s exceeds the max depth %d", p.Name, o.maxDepth)
+	}
+	for _, name := range names {
+		if !columnNameRegexp.MatchString(name) {
+			return fmt.Errorf("invalid preload name %s", p.Name)
+		}
+	}
+	if o.whitelistNames != nil && !o.whitelistNames[p.Name] {
+		return fmt.Errorf("preload %s is not allowed", p.Name)
+	}
+	for _, column := range p.Columns {
+		if !columnNameRegexp.MatchString(column) {
+			return fmt.Errorf("invalid column %s of preload %s", column, p.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type preloadUser struct {
+	ID     uint64
+	Name   string
+	Orders []*preloadOrder `gorm:"foreignKey:UserID;references:ID"`
+}
+
+type preloadOrder struct {
+	ID     uint64
+	UserID uint64
+	Amount int
+	Items  []*preloadItem `gorm:"foreignKey:OrderID;references:ID"`
+}
+
+type preloadItem struct {
+	ID      uint64
+	OrderID uint64
+	Name    string
+}
+
+func TestParsePreloads(t *testing.T) {
+	preloads := ParsePreloads(" Orders:id, user_id ;Orders.Items;; ")
+	assert.Equal(t, []Preload{
+		{Name: "Orders", Columns: []string{"id", "user_id"}},
+		{Name: "Orders.Items"},
+	}, preloads)
+	assert.Nil(t, ParsePreloads(""))
+}
+
+func TestPreloadScope(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Skip(err)
+	}
+	err = db.AutoMigrate(&preloadUser{}, &preloadOrder{}, &preloadItem{})
+	assert.NoError(t, err)
+	db.Create(&preloadUser{ID: 1, Name: "foo", Orders: []*preloadOrder{
+		{ID: 1, Amount: 10, Items: []*preloadItem{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}},
+		{ID: 2, Amount: 20},
+	}})
+
+	scope, err := PreloadScope([]Preload{{Name: "Orders", Columns: []string{"id", "user_id"}}, {Name: "Orders.Items"}})
+	assert.NoError(t, err)
+	user := &preloadUser{}
+	err = db.Scopes(scope).First(user, 1).Error
+	assert.NoError(t, err)
+	assert.Len(t, user.Orders, 2)
+	assert.Equal(t, 0, user.Orders[0].Amount) // column is not selected
+	assert.Len(t, user.Orders[0].Items, 2)
+
+	scope, err = PreloadScope(nil)
+	assert.NoError(t, err)
+	user = &preloadUser{}
+	err = db.Scopes(scope).First(user, 1).Error
+	assert.NoError(t, err)
+	assert.Nil(t, user.Orders)
+}
+
+func TestPreloadScope_Error(t *testing.T) {
+	_, err := PreloadScope([]Preload{{Name: "Orders.Items"}}, WithPreloadMaxDepth(1))
+	assert.Error(t, err)
+	_, err = PreloadScope([]Preload{{Name: ""}})
+	assert.Error(t, err)
+	_, err = PreloadScope([]Preload{{Name: "Orders;drop"}})
+	assert.Error(t, err)
+	_, err = PreloadScope([]Preload{{Name: "Orders", Columns: []string{"id) or (1=1"}}})
+	assert.Error(t, err)
+
+	opt := WithPreloadWhitelistNames(map[string]bool{"Orders": true})
+	_, err = PreloadScope([]Preload{{Name: "Orders"}}, opt)
+	assert.NoError(t, err)
+	_, err = PreloadScope([]Preload{{Name: "Orders.Items"}}, opt)
+	assert.Error(t, err)
+}
@@ -2,10 +2,14 @@
 package query
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
 	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 const (
@@ -31,11 +35,19 @@ const (
 	IsNull = "isnull"
 	// IsNotNull is not null
 	IsNotNull = "isnotnull"
+	// Fulltext full-text search, the name can be multiple columns separated by comma, e.g. "title,content"
+	Fulltext = "fulltext"
 
 	// AND logic and
 	AND string = "and"
 	// OR logic or
 	OR string = "or"
+
+	// DialectMySQL mysql dialect, full-text search by MATCH AGAINST, requires a FULLTEXT index of the columns
+	DialectMySQL = "mysql"
+	// DialectPostgres postgresql dialect, full-text search by tsvector, an index on the same
+	// to_tsvector expression is recommended
+	DialectPostgres = "postgres"
 )
 
 var expMap = map[string]string{
@@ -50,6 +62,7 @@ var expMap = map[string]string{
 	NotIN:     " NOT IN ",
 	IsNull:    " IS NULL ",
 	IsNotNull: " IS NOT NULL ",
+	Fulltext:  fulltextExp,
 
 	"=":           " = ",
 	"!=":          " <> ",
@@ -79,11 +92,15 @@ var logicMap = map[string]string{
 	"or:)":  " OR ",
 }
 
+const fulltextExp = " FULLTEXT "
+
 // ---------------------------------------------------------------------------
 
 type rulerOptions struct {
 	whitelistNames map[string]bool
 	validateFn     func(columns []Column) error
+	searchColumns  []string
+	dialect        string
 }
 
 // RulerOption set the parameters of ruler options
@@ -109,6 +126,30 @@ func WithValidateFn(fn func(columns []Column) error) RulerOption {
 	}
 }
 
+// WithSearchColumns set the columns searched by the q parameter, e.g. the columns of fulltext index
+func WithSearchColumns(columns ...string) RulerOption {
+	return func(o *rulerOptions) {
+		o.searchColumns = columns
+	}
+}
+
+// WithDialect set the database dialect used to generate the full-text search expression,
+// e.g. db.Dialector.Name(), mysql and postgres are supported, other dialects fall back to LIKE
+func WithDialect(dialect string) RulerOption {
+	return func(o *rulerOptions) {
+		o.dialect = dialect
+	}
+}
+
+// WithDB set the dialect by the gorm db, it is the same as WithDialect(db.Dialector.Name())
+func WithDB(db *gorm.DB) RulerOption {
+	return func(o *rulerOptions) {
+		if db != nil && db.Dialector != nil {
+			o.dialect = db.Dialector.Name()
+		}
+	}
+}
+
 // -----------------------------------------------------------------------------
 
 // Params query parameters
@@ -116,6 +157,7 @@ type Params struct {
 	Page  int    `json:"page" form:"page" binding:"gte=0"`
 	Limit int    `json:"limit" form:"limit" binding:"gte=1"`
 	Sort  string `json:"sort,omitempty" form:"sort" binding:""`
+	Q     string `json:"q,omitempty" form:"q"` // full-text search keywords, searched in the columns set by WithSearchColumns
 
 	Columns []Column `json:"columns,omitempty" form:"columns"` // not required
 
@@ -126,7 +168,7 @@ type Params struct {
 // Column query info
 type Column struct {
 	Name  string      `json:"name" form:"name"`   // column name
-	Exp   string      `json:"exp" form:"exp"`     // expressions, default value is "=", support =, !=, >, >=, <, <=, like, in, notin, isnull, isnotnull
+	Exp   string      `json:"exp" form:"exp"`     // expressions, default value is "=", support =, !=, >, >=, <, <=, like, in, notin, isnull, isnotnull, fulltext
 	Value interface{} `json:"value" form:"value"` // column value
 	Logic string      `json:"logic" form:"logic"` // logical type, defaults to and when the value is null, with &(and), ||(or)
 }
@@ -188,6 +230,12 @@ func (c *Column) checkExp() (string, error) {
 		case " IS NULL ", " IS NOT NULL ":
 			c.Value = nil
 			symbol = ""
+		case fulltextExp:
+			val, ok1 := c.Value.(string)
+			if !ok1 || strings.TrimSpace(val) == "" {
+				return symbol, fmt.Errorf("invalid value type '%v'", c.Value)
+			}
+			c.Value = strings.TrimSpace(val)
 		}
 	} else {
 		return symbol, fmt.Errorf("unsupported exp type '%s'", c.Exp)
@@ -222,15 +270,18 @@ func (p *Params) ConvertToGormConditions(opts ...RulerOption) (string, []interfa
 	str := ""
 	args := []interface{}{}
 	l := len(p.Columns)
-	if l == 0 {
+	if l == 0 && p.Q == "" {
 		return "", nil, nil
 	}
 
 	isUseIN := true
-	if l == 1 {
+	if l <= 1 {
 		isUseIN = false
 	}
-	field := p.Columns[0].Name
+	field := ""
+	if l > 0 {
+		field = p.Columns[0].Name
+	}
 
 	o := rulerOptions{}
 	o.apply(opts...)
@@ -242,9 +293,17 @@ func (p *Params) ConvertToGormConditions(opts ...RulerOption) (string, []interfa
 	}
 
 	for i, column := range p.Columns {
+		isFulltext := expMap[strings.ToLower(column.Exp)] == fulltextExp
+
 		// check name
-		if column.Name == "" || (o.whitelistNames != nil && !o.whitelistNames[column.Name]) {
-			return "", nil, fmt.Errorf("field name '%s' is not allowed", column.Name)
+		names := []string{column.Name}
+		if isFulltext {
+			names = splitColumnNames(column.Name)
+		}
+		for _, name := range names {
+			if name == "" || (o.whitelistNames != nil && !o.whitelistNames[name]) {
+				return "", nil, fmt.Errorf("field name '%s' is not allowed", column.Name)
+			}
 		}
 
 		// check value
@@ -253,7 +312,7 @@ func (p *Params) ConvertToGormConditions(opts ...RulerOption) (string, []interfa
 			if v != " IS NULL " && v != " IS NOT NULL " {
 				return "", nil, fmt.Errorf("field 'value' cannot be nil")
 			}
-		} else {
+		} else if !isFulltext {
 			column.Value = convertValue(column.Value)
 		}
 
@@ -263,25 +322,29 @@ func (p *Params) ConvertToGormConditions(opts ...RulerOption) (string, []interfa
 			return "", nil, err
 		}
 
+		cond, values := column.Name+column.Exp+symbol, []interface{}{column.Value}
+		if isFulltext {
+			cond, values = fulltextCondition(names, column.Value.(string), o.dialect)
+		}
 		if i == l-1 { // ignore the logical type of the last column
 			switch column.Logic {
 			case "or:)", "and:)":
-				str += column.Name + column.Exp + symbol + " ) "
+				str += cond + " ) "
 			default:
-				str += column.Name + column.Exp + symbol
+				str += cond
 			}
 		} else {
 			switch column.Logic {
 			case "or:(", "and:(":
-				str += " ( " + column.Name + column.Exp + symbol + logicMap[column.Logic]
+				str += " ( " + cond + logicMap[column.Logic]
 			case "or:)", "and:)":
-				str += column.Name + column.Exp + symbol + " ) " + logicMap[column.Logic]
+				str += cond + " ) " + logicMap[column.Logic]
 			default:
-				str += column.Name + column.Exp + symbol + logicMap[column.Logic]
+				str += cond + logicMap[column.Logic]
 			}
 		}
 		if column.Value != nil {
-			args = append(args, column.Value)
+			args = append(args, values...)
 		}
 		// when multiple columns are the same, determine whether the use of IN
 		if isUseIN {
@@ -300,9 +363,89 @@ func (p *Params) ConvertToGormConditions(opts ...RulerOption) (string, []interfa
 		args = []interface{}{args}
 	}
 
+	// the search of q parameter is combined with the conditions of columns by AND
+	if q := strings.TrimSpace(p.Q); q != "" {
+		if len(o.searchColumns) == 0 {
+			return "", nil, errors.New("search by the q parameter is not supported")
+		}
+		cond, values := fulltextCondition(o.searchColumns, q, o.dialect)
+		if str == "" {
+			str = cond
+		} else {
+			str = "( " + str + " ) AND " + cond
+		}
+		args = append(args, values...)
+	}
+
 	return str, args, nil
 }
 
+// ConvertToGormOrder converted to the order of gorm, when the q parameter is set and sort is empty,
+// the records are ranked by the relevance of the search (mysql and postgres), otherwise it is the
+// same as the order of ConvertToPage, the result can be passed to db.Order directly.
+func (p *Params) ConvertToGormOrder(opts ...RulerOption) interface{} {
+	o := rulerOptions{}
+	o.apply(opts...)
+	q := strings.TrimSpace(p.Q)
+	if q == "" || p.Sort != "" || len(o.searchColumns) == 0 {
+		order, _, _ := p.ConvertToPage()
+		return order
+	}
+
+	var sql string
+	switch o.dialect {
+	case DialectMySQL:
+		sql = mysqlMatch(o.searchColumns) + " DESC"
+	case DialectPostgres:
+		sql = "ts_rank(" + postgresTsvector(o.searchColumns) + ", plainto_tsquery(?)) DESC"
+	default:
+		order, _, _ := p.ConvertToPage()
+		return order
+	}
+	return clause.OrderBy{Expression: clause.Expr{SQL: sql, Vars: []interface{}{q}, WithoutParentheses: true}}
+}
+
+// fulltextCondition returns the full-text search condition of the columns by dialect,
+// the dialects that do not support full-text search fall back to LIKE.
+func fulltextCondition(columns []string, q string, dialect string) (string, []interface{}) {
+	switch dialect {
+	case DialectMySQL:
+		return mysqlMatch(columns), []interface{}{q}
+	case DialectPostgres:
+		return postgresTsvector(columns) + " @@ plainto_tsquery(?)", []interface{}{q}
+	}
+
+	q = strings.ReplaceAll(q, "%", "\\%")
+	q = strings.ReplaceAll(q, "_", "\\_")
+	conds := make([]string, 0, len(columns))
+	values := make([]interface{}, 0, len(columns))
+	for _, c := range columns {
+		conds = append(conds, c+" LIKE ?")
+		values = append(values, "%"+q+"%")
+	}
+	return "( " + strings.Join(conds, " OR ") + " )", values
+}
+
+func mysqlMatch(columns []string) string {
+	return "MATCH(" + strings.Join(columns, ", ") + ") AGAINST(? IN NATURAL LANGUAGE MODE)"
+}
+
+func postgresTsvector(columns []string) string {
+	parts := make([]string, 0, len(columns))
+	for _, c := range columns {
+		parts = append(parts, "coalesce("+c+", '')")
+	}
+	return "to_tsvector(" + strings.Join(parts, " || ' ' || ") + ")"
+}
+
+func splitColumnNames(name string) []string {
+	var names []string
+	for _, n := range strings.Split(name, ",") {
+		names = append(names, strings.TrimSpace(n))
+	}
+	return names
+}
+
 // if the value is a string or an integer, if true means it is a string, otherwise it is an integer
 func convertValue(v interface{}) interface{} {
 	s, ok := v.(string)
@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func TestPage(t *testing.T) {
@@ -784,6 +786,77 @@ func TestConditions_ConvertToGormConditions_Error(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestParams_ConvertToGormConditions_Fulltext(t *testing.T) {
+	columns := []Column{
+		{Name: "status", Value: "1"},
+		{Name: "title, content", Exp: Fulltext, Value: " 2024 sponge "},
+	}
+
+	p := &Params{Columns: columns}
+	str, args, err := p.ConvertToGormConditions(WithDialect(DialectMySQL))
+	assert.NoError(t, err)
+	assert.Equal(t, "status = ? AND MATCH(title, content) AGAINST(? IN NATURAL LANGUAGE MODE)", str)
+	assert.Equal(t, []interface{}{1, "2024 sponge"}, args)
+
+	p = &Params{Columns: columns}
+	str, args, err = p.ConvertToGormConditions(WithDialect(DialectPostgres))
+	assert.NoError(t, err)
+	assert.Equal(t, "status = ? AND to_tsvector(coalesce(title, '') || ' ' || coalesce(content, '')) @@ plainto_tsquery(?)", str)
+	assert.Equal(t, []interface{}{1, "2024 sponge"}, args)
+
+	// other dialects fall back to LIKE
+	p = &Params{Columns: []Column{{Name: "title", Exp: Fulltext, Value: "50%_off"}}}
+	str, args, err = p.ConvertToGormConditions(WithDialect("sqlite"))
+	assert.NoError(t, err)
+	assert.Equal(t, "( title LIKE ? )", str)
+	assert.Equal(t, []interface{}{"%50\\%\\_off%"}, args)
+
+	// every column of fulltext is checked by whitelist
+	p = &Params{Columns: []Column{{Name: "title,password", Exp: Fulltext, Value: "foo"}}}
+	_, _, err = p.ConvertToGormConditions(WithWhitelistNames(map[string]bool{"title": true}))
+	assert.Error(t, err)
+	p = &Params{Columns: []Column{{Name: "title", Exp: Fulltext, Value: 1}}}
+	_, _, err = p.ConvertToGormConditions()
+	assert.Error(t, err)
+}
+
+func TestParams_Q(t *testing.T) {
+	opts := []RulerOption{WithSearchColumns("title", "content"), WithDialect(DialectMySQL)}
+
+	p := &Params{Q: "sponge"}
+	str, args, err := p.ConvertToGormConditions(opts...)
+	assert.NoError(t, err)
+	assert.Equal(t, "MATCH(title, content) AGAINST(? IN NATURAL LANGUAGE MODE)", str)
+	assert.Equal(t, []interface{}{"sponge"}, args)
+
+	p = &Params{Q: "sponge", Columns: []Column{{Name: "status", Value: 1}, {Name: "status", Value: 2}}}
+	str, args, err = p.ConvertToGormConditions(opts...)
+	assert.NoError(t, err)
+	assert.Equal(t, "( status IN (?) ) AND MATCH(title, content) AGAINST(? IN NATURAL LANGUAGE MODE)", str)
+	assert.Equal(t, 2, len(args))
+
+	// the search columns are not set
+	_, _, err = p.ConvertToGormConditions(WithDialect(DialectMySQL))
+	assert.Error(t, err)
+
+	// ranked by relevance
+	order := (&Params{Q: "sponge"}).ConvertToGormOrder(opts...)
+	orderBy, ok := order.(clause.OrderBy)
+	assert.True(t, ok)
+	assert.Equal(t, "MATCH(title, content) AGAINST(? IN NATURAL LANGUAGE MODE) DESC", orderBy.Expression.(clause.Expr).SQL)
+	order = (&Params{Q: "sponge"}).ConvertToGormOrder(WithSearchColumns("title"), WithDialect(DialectPostgres))
+	assert.Equal(t, "ts_rank(to_tsvector(coalesce(title, '')), plainto_tsquery(?)) DESC", order.(clause.OrderBy).Expression.(clause.Expr).SQL)
+
+	// sort is specified, or the dialect does not support ranking
+	assert.Equal(t, "name ASC", (&Params{Q: "sponge", Sort: "name"}).ConvertToGormOrder(opts...))
+	assert.Equal(t, "id DESC", (&Params{Q: "sponge"}).ConvertToGormOrder(WithSearchColumns("title")))
+	assert.Equal(t, "id DESC", (&Params{}).ConvertToGormOrder(opts...))
+
+	o := rulerOptions{}
+	o.apply(WithDB(nil), WithDB(&gorm.DB{Config: &gorm.Config{}}))
+	assert.Equal(t, "", o.dialect)
+}
+
 func TestConditions_ConvertToGorm(t *testing.T) {
 	c := Conditions{
 		Columns: []Column{
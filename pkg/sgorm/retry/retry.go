@@ -0,0 +1,353 @@
+// Package retry is a gorm plugin that retries the operations failed with transient errors, e.g. deadlock,
+// serialization failure, lock wait timeout and broken connection, the operations are only retried when they
+// are marked as retry-safe, so that the non-idempotent writes are not executed twice.
+package retry
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+)
+
+const pluginName = "sgorm:retry"
+
+// reasons of transient errors
+const (
+	ReasonDeadlock      = "deadlock"
+	ReasonSerialization = "serialization"
+	ReasonLockTimeout   = "lock_timeout"
+	ReasonConnection    = "connection"
+)
+
+// operations of the retry metrics
+const (
+	opCreate      = "create"
+	opQuery       = "query"
+	opUpdate      = "update"
+	opDelete      = "delete"
+	opRow         = "row"
+	opRaw         = "raw"
+	opTransaction = "transaction"
+)
+
+var (
+	retryCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sgorm_retries_total",
+			Help: "Total number of retries of database operations failed with transient errors.",
+		},
+		[]string{"operation", "reason"},
+	)
+
+	exhaustedCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sgorm_retry_exhausted_total",
+			Help: "Total number of retried database operations that still failed after all attempts.",
+		},
+		[]string{"operation", "reason"},
+	)
+
+	registerOnce sync.Once
+)
+
+func registerMetrics() {
+	registerOnce.Do(func() {
+		prometheus.MustRegister(retryCount, exhaustedCount)
+	})
+}
+
+type safeKey struct{}
+
+// SafeContext return a new context that the operations with it are retry-safe, e.g. idempotent writes
+// (update by primary key with absolute values, upsert, insert with unique request id) and queries.
+func SafeContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, safeKey{}, true)
+}
+
+func isSafe(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	safe, _ := ctx.Value(safeKey{}).(bool)
+	return safe
+}
+
+// Classify reports whether the error is transient, and returns the reason, e.g. deadlock.
+// The errors of mysql, postgresql (SQLSTATE) and sqlite, and the broken connections are recognized.
+func Classify(err error) (string, bool) {
+	if err == nil {
+		return "", false
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case 1213: // ER_LOCK_DEADLOCK
+			return ReasonDeadlock, true
+		case 1205: // ER_LOCK_WAIT_TIMEOUT
+			return ReasonLockTimeout, true
+		}
+		return "", false
+	}
+
+	var stateErr interface{ SQLState() string } // e.g. pgconn.PgError
+	if errors.As(err, &stateErr) {
+		state := stateErr.SQLState()
+		switch {
+		case state == "40P01": // deadlock_detected
+			return ReasonDeadlock, true
+		case state == "40001": // serialization_failure
+			return ReasonSerialization, true
+		case state == "55P03": // lock_not_available
+			return ReasonLockTimeout, true
+		case strings.HasPrefix(state, "08"), state == "57P01": // connection exception, admin_shutdown
+			return ReasonConnection, true
+		}
+		return "", false
+	}
+
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EPIPE) {
+		return ReasonConnection, true
+	}
+	var netErr *net.OpError
+	if errors.As(err, &netErr) {
+		return ReasonConnection, true
+	}
+
+	// sqlite: SQLITE_BUSY, SQLITE_LOCKED
+	if msg := err.Error(); strings.Contains(msg, "database is locked") || strings.Contains(msg, "database table is locked") {
+		return ReasonLockTimeout, true
+	}
+	return "", false
+}
+
+// Option set the retry options.
+type Option func(*options)
+
+type options struct {
+	maxAttempts int
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+	safeQueries bool
+	classify    func(err error) (string, bool)
+}
+
+func (o *options) apply(opts ...Option) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+func defaultOptions() *options {
+	return &options{
+		maxAttempts: 3,
+		minBackoff:  20 * time.Millisecond,
+		maxBackoff:  time.Second,
+		classify:    Classify,
+	}
+}
+
+// WithMaxAttempts set the maximum number of attempts including the first one, default is 3.
+func WithMaxAttempts(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.maxAttempts = n
+		}
+	}
+}
+
+// WithBackoff set the backoff between attempts, it starts from min and doubles after each attempt
+// up to max, with random jitter, default is 20ms ~ 1s.
+func WithBackoff(min time.Duration, max time.Duration) Option {
+	return func(o *options) {
+		if min > 0 {
+			o.minBackoff = min
+		}
+		if max >= o.minBackoff {
+			o.maxBackoff = max
+		}
+	}
+}
+
+// WithSafeQueries the queries (Find, First, Scan, Row, etc.) are retry-safe without SafeContext.
+func WithSafeQueries() Option {
+	return func(o *options) {
+		o.safeQueries = true
+	}
+}
+
+// WithClassifier set the function to check whether the error is transient, default is Classify.
+func WithClassifier(fn func(err error) (reason string, ok bool)) Option {
+	return func(o *options) {
+		if fn != nil {
+			o.classify = fn
+		}
+	}
+}
+
+// backoff returns the duration to wait before the next attempt, attempt starts from 1.
+func (o *options) backoff(attempt int) time.Duration {
+	d := o.minBackoff << (attempt - 1)
+	if d > o.maxBackoff || d <= 0 {
+		d = o.maxBackoff
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1)) //nolint
+}
+
+// wait sleeps for the backoff, return false if the context is done.
+func (o *options) wait(ctx context.Context, attempt int) bool {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	timer := time.NewTimer(o.backoff(attempt))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// Register register the retry plugin to db, it is ignored if the plugin has been registered.
+func Register(db *gorm.DB, opts ...Option) error {
+	err := db.Use(NewPlugin(opts...))
+	if errors.Is(err, gorm.ErrRegistered) {
+		return nil
+	}
+	return err
+}
+
+// --------------------------------------------------------------------------------
+
+// Plugin the gorm plugin of retrying transient errors, the statement is executed again with backoff when
+// it fails with a transient error, the context of statement is marked by SafeContext (or it is a query
+// with WithSafeQueries), and it is not in a transaction.
+//
+// The statements in a transaction are not retried, because the transaction is aborted by the deadlock,
+// note that gorm wraps create, update and delete in a transaction by default, set SkipDefaultTransaction
+// to retry them, or use Transaction to retry the whole transaction.
+type Plugin struct {
+	opts *options
+}
+
+// NewPlugin create a retry plugin.
+func NewPlugin(opts ...Option) *Plugin {
+	o := defaultOptions()
+	o.apply(opts...)
+	registerMetrics()
+	return &Plugin{opts: o}
+}
+
+// Name plugin name
+func (p *Plugin) Name() string {
+	return pluginName
+}
+
+// Initialize wrap the callbacks that execute the statements
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	callbacks := []struct {
+		operation string
+		name      string
+		processor interface {
+			Get(name string) func(*gorm.DB)
+			Replace(name string, fn func(*gorm.DB)) error
+		}
+	}{
+		{opCreate, "gorm:create", cb.Create()},
+		{opQuery, "gorm:query", cb.Query()},
+		{opUpdate, "gorm:update", cb.Update()},
+		{opDelete, "gorm:delete", cb.Delete()},
+		{opRow, "gorm:row", cb.Row()},
+		{opRaw, "gorm:raw", cb.Raw()},
+	}
+	for _, c := range callbacks {
+		fn := c.processor.Get(c.name)
+		if fn == nil {
+			continue
+		}
+		if err := c.processor.Replace(c.name, p.wrap(c.operation, fn)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Plugin) wrap(operation string, fn func(*gorm.DB)) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Error != nil || !p.isRetrySafe(db, operation) {
+			fn(db)
+			return
+		}
+
+		for attempt := 1; ; attempt++ {
+			fn(db)
+			if db.Error == nil {
+				return
+			}
+			reason, ok := p.opts.classify(db.Error)
+			if !ok {
+				return
+			}
+			if attempt >= p.opts.maxAttempts || !p.opts.wait(db.Statement.Context, attempt) {
+				exhaustedCount.WithLabelValues(operation, reason).Inc()
+				return
+			}
+			retryCount.WithLabelValues(operation, reason).Inc()
+			db.Error = nil
+			db.RowsAffected = 0
+		}
+	}
+}
+
+func (p *Plugin) isRetrySafe(db *gorm.DB, operation string) bool {
+	if db.DryRun {
+		return false
+	}
+	if _, inTx := db.Statement.ConnPool.(gorm.TxCommitter); inTx {
+		return false
+	}
+	if p.opts.safeQueries && (operation == opQuery || operation == opRow) {
+		return true
+	}
+	return isSafe(db.Statement.Context)
+}
+
+// Transaction executes fn in a transaction, the whole transaction is retried with backoff when it fails
+// with a transient error (e.g. deadlock or serialization failure), fn may be called more than once, so it
+// must not have side effects outside the transaction, e.g. sending messages.
+func Transaction(ctx context.Context, db *gorm.DB, fn func(tx *gorm.DB) error, opts ...Option) error {
+	o := defaultOptions()
+	o.apply(opts...)
+	registerMetrics()
+
+	for attempt := 1; ; attempt++ {
+		err := db.WithContext(ctx).Transaction(fn)
+		if err == nil {
+			return nil
+		}
+		reason, ok := o.classify(err)
+		if !ok {
+			return err
+		}
+		if attempt >= o.maxAttempts || !o.wait(ctx, attempt) {
+			exhaustedCount.WithLabelValues(opTransaction, reason).Inc()
+			return err
+		}
+		retryCount.WithLabelValues(opTransaction, reason).Inc()
+	}
+}
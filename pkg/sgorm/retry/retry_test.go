@@ -0,0 +1,176 @@
+package retry
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type user struct {
+	ID   uint64 `gorm:"primaryKey"`
+	Name string
+}
+
+type pgError struct{ code string }
+
+func (e *pgError) Error() string    { return "pg error " + e.code }
+func (e *pgError) SQLState() string { return e.code }
+
+var errDeadlock = &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}
+
+func newTestDB(t *testing.T, skipDefaultTransaction bool) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "retry.db")), &gorm.Config{
+		Logger:                 logger.Default.LogMode(logger.Silent),
+		SkipDefaultTransaction: skipDefaultTransaction,
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&user{}))
+	return db
+}
+
+// injectErrors makes the first n executions of the callback fail with err
+func injectErrors(t *testing.T, db *gorm.DB, name string, n int, err error) *int {
+	calls := 0
+	processor := db.Callback().Query()
+	if name == "gorm:update" {
+		processor = db.Callback().Update()
+	}
+	fn := processor.Get(name)
+	require.NoError(t, processor.Replace(name, func(db *gorm.DB) {
+		calls++
+		if calls <= n {
+			_ = db.AddError(err)
+			return
+		}
+		fn(db)
+	}))
+	return &calls
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		err    error
+		reason string
+		ok     bool
+	}{
+		{nil, "", false},
+		{errors.New("record not found"), "", false},
+		{errDeadlock, ReasonDeadlock, true},
+		{fmt.Errorf("wrap: %w", &mysql.MySQLError{Number: 1205}), ReasonLockTimeout, true},
+		{&mysql.MySQLError{Number: 1062}, "", false},
+		{&pgError{"40P01"}, ReasonDeadlock, true},
+		{&pgError{"40001"}, ReasonSerialization, true},
+		{&pgError{"08006"}, ReasonConnection, true},
+		{&pgError{"23505"}, "", false},
+		{driver.ErrBadConn, ReasonConnection, true},
+		{mysql.ErrInvalidConn, ReasonConnection, true},
+		{errors.New("database is locked"), ReasonLockTimeout, true},
+	}
+	for _, tt := range tests {
+		reason, ok := Classify(tt.err)
+		assert.Equal(t, tt.reason, reason, tt.err)
+		assert.Equal(t, tt.ok, ok, tt.err)
+	}
+}
+
+func TestPlugin(t *testing.T) {
+	db := newTestDB(t, true)
+	require.NoError(t, db.Create(&user{ID: 1, Name: "foo"}).Error)
+	calls := injectErrors(t, db, "gorm:query", 2, errDeadlock)
+	require.NoError(t, Register(db, WithBackoff(time.Millisecond, 5*time.Millisecond)))
+	require.NoError(t, Register(db)) // registered repeatedly is ignored
+
+	// not retry-safe
+	var u user
+	err := db.First(&u, 1).Error
+	assert.ErrorIs(t, err, errDeadlock)
+	assert.Equal(t, 1, *calls)
+
+	// retried until success
+	*calls = 0
+	before := testutil.ToFloat64(retryCount.WithLabelValues(opQuery, ReasonDeadlock))
+	err = db.WithContext(SafeContext(context.Background())).First(&u, 1).Error
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", u.Name)
+	assert.Equal(t, 3, *calls)
+	assert.Equal(t, before+2, testutil.ToFloat64(retryCount.WithLabelValues(opQuery, ReasonDeadlock)))
+
+	// attempts exhausted
+	*calls = -10
+	before = testutil.ToFloat64(exhaustedCount.WithLabelValues(opQuery, ReasonDeadlock))
+	err = db.WithContext(SafeContext(context.Background())).First(&u, 1).Error
+	assert.ErrorIs(t, err, errDeadlock)
+	assert.Equal(t, -7, *calls)
+	assert.Equal(t, before+1, testutil.ToFloat64(exhaustedCount.WithLabelValues(opQuery, ReasonDeadlock)))
+
+	// the errors that are not transient are not retried
+	*calls = 0
+	err = db.WithContext(SafeContext(context.Background())).First(&u, 100).Error
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	assert.Equal(t, 3, *calls)
+}
+
+func TestPlugin_SafeQueries(t *testing.T) {
+	db := newTestDB(t, true)
+	require.NoError(t, db.Create(&user{ID: 1, Name: "foo"}).Error)
+	calls := injectErrors(t, db, "gorm:query", 1, driver.ErrBadConn)
+	require.NoError(t, Register(db, WithSafeQueries(), WithBackoff(time.Millisecond, time.Millisecond)))
+
+	var users []user
+	assert.NoError(t, db.Find(&users).Error)
+	assert.Len(t, users, 1)
+	assert.Equal(t, 2, *calls)
+}
+
+func TestPlugin_Transaction(t *testing.T) {
+	// the update is in the default transaction of gorm, it is not retried
+	db := newTestDB(t, false)
+	require.NoError(t, db.Create(&user{ID: 1, Name: "foo"}).Error)
+	calls := injectErrors(t, db, "gorm:update", 1, errDeadlock)
+	require.NoError(t, Register(db, WithBackoff(time.Millisecond, time.Millisecond)))
+
+	ctx := SafeContext(context.Background())
+	err := db.WithContext(ctx).Model(&user{ID: 1}).Update("name", "bar").Error
+	assert.ErrorIs(t, err, errDeadlock)
+	assert.Equal(t, 1, *calls)
+
+	// the whole transaction is retried
+	*calls = 0
+	txCalls := 0
+	before := testutil.ToFloat64(retryCount.WithLabelValues(opTransaction, ReasonDeadlock))
+	err = Transaction(context.Background(), db, func(tx *gorm.DB) error {
+		txCalls++
+		return tx.Model(&user{ID: 1}).Update("name", "bar").Error
+	}, WithBackoff(time.Millisecond, time.Millisecond))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, txCalls)
+	assert.Equal(t, before+1, testutil.ToFloat64(retryCount.WithLabelValues(opTransaction, ReasonDeadlock)))
+	var u user
+	require.NoError(t, db.First(&u, 1).Error)
+	assert.Equal(t, "bar", u.Name)
+
+	// the context is done while waiting
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	txCalls = 0
+	start := time.Now()
+	err = Transaction(timeoutCtx, db, func(tx *gorm.DB) error {
+		txCalls++
+		return errDeadlock
+	}, WithMaxAttempts(5), WithBackoff(time.Second, time.Second))
+	assert.ErrorIs(t, err, errDeadlock)
+	assert.Equal(t, 1, txCalls)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
@@ -0,0 +1,102 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// DBManager manage the database connections of tenants, it is used for database per tenant,
+// the connection of tenant is opened on first use and reused later.
+type DBManager struct {
+	mu     sync.RWMutex
+	dbs    map[string]*gorm.DB // tenant id --> db
+	openFn func(tenantID string) (*gorm.DB, error)
+}
+
+// NewDBManager create a DBManager, openFn open the database connection of tenant, e.g.
+//
+//	func(tenantID string) (*gorm.DB, error) { return mysql.Init(fmt.Sprintf(dsnFormat, "tenant_"+tenantID)) }
+func NewDBManager(openFn func(tenantID string) (*gorm.DB, error)) *DBManager {
+	return &DBManager{
+		dbs:    make(map[string]*gorm.DB),
+		openFn: openFn,
+	}
+}
+
+// Get get the database of tenant from ctx, the returned db has been set with ctx.
+func (m *DBManager) Get(ctx context.Context) (*gorm.DB, error) {
+	tenantID, ok := FromContext(ctx)
+	if !ok {
+		return nil, ErrMissingTenant
+	}
+	db, err := m.GetByTenantID(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return db.WithContext(ctx), nil
+}
+
+// GetByTenantID get the database of tenant.
+func (m *DBManager) GetByTenantID(tenantID string) (*gorm.DB, error) {
+	if tenantID == "" {
+		return nil, ErrMissingTenant
+	}
+
+	m.mu.RLock()
+	db, ok := m.dbs[tenantID]
+	m.mu.RUnlock()
+	if ok {
+		return db, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if db, ok = m.dbs[tenantID]; ok {
+		return db, nil
+	}
+	db, err := m.openFn(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	m.dbs[tenantID] = db
+	return db, nil
+}
+
+// Remove close and remove the database of tenant, e.g. the tenant is deleted.
+func (m *DBManager) Remove(tenantID string) error {
+	m.mu.Lock()
+	db, ok := m.dbs[tenantID]
+	delete(m.dbs, tenantID)
+	m.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return closeDB(db)
+}
+
+// Close close the databases of all tenants.
+func (m *DBManager) Close() error {
+	m.mu.Lock()
+	dbs := m.dbs
+	m.dbs = make(map[string]*gorm.DB)
+	m.mu.Unlock()
+
+	var errs []error
+	for _, db := range dbs {
+		if err := closeDB(db); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func closeDB(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
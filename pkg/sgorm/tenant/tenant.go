@@ -0,0 +1,418 @@
+// Package tenant is a multi-tenancy layer of gorm, the tenant id is read from context, the queries are
+// scoped by the tenant id column (column mode) or the tables are switched to the schema of tenant (schema mode),
+// and the DBManager is used for the database per tenant.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// DefaultColumn default tenant id column
+const DefaultColumn = "tenant_id"
+
+const pluginName = "sgorm:tenant"
+
+var (
+	// ErrMissingTenant the tenant id is not found in context
+	ErrMissingTenant = errors.New("tenant id not found in context")
+	// ErrTenantMismatch the tenant id of record is different from the tenant id of context
+	ErrTenantMismatch = errors.New("tenant id of record does not match the tenant id of context")
+	// ErrRawSQL the raw sql is not scoped by tenant, it is rejected under the tenant scope if WithRejectRawSQL is set
+	ErrRawSQL = errors.New("raw sql is not scoped by tenant, use tenant.SkipContext to run it")
+)
+
+type tenantKey struct{}
+type skipKey struct{}
+
+// NewContext return a new context with the tenant id.
+func NewContext(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// FromContext get the tenant id from context.
+func FromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	tenantID, ok := ctx.Value(tenantKey{}).(string)
+	return tenantID, ok && tenantID != ""
+}
+
+// SkipContext return a new context that the queries are not scoped by tenant, e.g. admin or background job.
+func SkipContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipKey{}, true)
+}
+
+func isSkipped(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	skip, _ := ctx.Value(skipKey{}).(bool)
+	return skip
+}
+
+// Option set the tenant options.
+type Option func(*options)
+
+type options struct {
+	column       string
+	schemaFn     func(tenantID string) string
+	allowMissing bool
+	rejectRaw    bool
+	excludes     map[string]struct{}
+}
+
+func (o *options) apply(opts ...Option) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+func defaultOptions() *options {
+	return &options{
+		column:   DefaultColumn,
+		excludes: map[string]struct{}{},
+	}
+}
+
+// WithColumn set the tenant id column, default is tenant_id, the tables without this column are not scoped.
+func WithColumn(column string) Option {
+	return func(o *options) {
+		if column != "" {
+			o.column = column
+		}
+	}
+}
+
+// WithSchema switch to schema mode, the table is prefixed with the schema name of tenant,
+// e.g. func(tenantID string) string { return "tenant_" + tenantID } --> tenant_1.users
+func WithSchema(fn func(tenantID string) string) Option {
+	return func(o *options) {
+		o.schemaFn = fn
+	}
+}
+
+// WithAllowMissingTenant the queries without tenant id in context are not scoped,
+// by default ErrMissingTenant is returned.
+func WithAllowMissingTenant() Option {
+	return func(o *options) {
+		o.allowMissing = true
+	}
+}
+
+// WithRejectRawSQL the Raw and Exec sql with tenant id in context return ErrRawSQL, because they are not scoped,
+// run them with the context of SkipContext after the tenant condition is added manually.
+func WithRejectRawSQL() Option {
+	return func(o *options) {
+		o.rejectRaw = true
+	}
+}
+
+// WithExcludeTables the tables are shared by all tenants, they are not scoped.
+func WithExcludeTables(tables ...string) Option {
+	return func(o *options) {
+		for _, table := range tables {
+			o.excludes[table] = struct{}{}
+		}
+	}
+}
+
+// Register register the tenant plugin to db, it is ignored if the plugin has been registered.
+func Register(db *gorm.DB, opts ...Option) error {
+	err := db.Use(NewPlugin(opts...))
+	if errors.Is(err, gorm.ErrRegistered) {
+		return nil
+	}
+	return err
+}
+
+// Scope a gorm scope that filters the records by the tenant id of ctx, it is used without the plugin,
+// e.g. db.WithContext(ctx).Scopes(tenant.Scope(ctx)).Find(&users)
+func Scope(ctx context.Context, opts ...Option) func(db *gorm.DB) *gorm.DB {
+	o := defaultOptions()
+	o.apply(opts...)
+	return func(db *gorm.DB) *gorm.DB {
+		if isSkipped(ctx) {
+			return db
+		}
+		tenantID, ok := FromContext(ctx)
+		if !ok {
+			if !o.allowMissing {
+				_ = db.AddError(ErrMissingTenant)
+			}
+			return db
+		}
+		return db.Where(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: o.column}, Value: tenantID})
+	}
+}
+
+// --------------------------------------------------------------------------------
+
+// Plugin the gorm plugin of multi-tenancy, the tenant id is read from the context of statement,
+// so db.WithContext(ctx) is required.
+//
+// column mode (default): for the tables that have the tenant id column, the condition tenant_id = ?
+// is added to query, update and delete, and the tenant id is set to the records when creating.
+//
+// schema mode (WithSchema): the table is prefixed with the schema name of tenant, e.g. tenant_1.users.
+//
+// Raw and Exec sql, and the tables of Joins are not scoped, the tenant condition of them must be added manually,
+// use WithRejectRawSQL to reject the Raw and Exec sql with tenant id in context.
+type Plugin struct {
+	opts *options
+}
+
+// NewPlugin create a tenant plugin.
+func NewPlugin(opts ...Option) *Plugin {
+	o := defaultOptions()
+	o.apply(opts...)
+	return &Plugin{opts: o}
+}
+
+// Name plugin name
+func (p *Plugin) Name() string {
+	return pluginName
+}
+
+// Initialize register the callbacks
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register(pluginName+":create", p.beforeCreate); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("gorm:query").Register(pluginName+":query", p.scope); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register(pluginName+":update", p.beforeUpdate); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register(pluginName+":delete", p.scope); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("gorm:row").Register(pluginName+":row", p.scope); err != nil {
+		return err
+	}
+	return cb.Raw().Before("gorm:raw").Register(pluginName+":raw", p.raw)
+}
+
+// reject the raw sql with tenant id in context if WithRejectRawSQL is set, return true if it is rejected
+func (p *Plugin) rejectRawSQL(db *gorm.DB) bool {
+	stmt := db.Statement
+	if !p.opts.rejectRaw || db.Error != nil || stmt.SQL.Len() == 0 || isSkipped(stmt.Context) {
+		return false
+	}
+	if _, ok := FromContext(stmt.Context); !ok {
+		return false
+	}
+	_ = db.AddError(ErrRawSQL)
+	return true
+}
+
+func (p *Plugin) raw(db *gorm.DB) {
+	p.rejectRawSQL(db)
+}
+
+// get the tenant id of statement, ok is false if the statement is not scoped
+func (p *Plugin) tenantID(db *gorm.DB) (string, bool) {
+	stmt := db.Statement
+	if db.Error != nil || isSkipped(stmt.Context) {
+		return "", false
+	}
+	if _, ok := p.opts.excludes[stmt.Table]; ok || stmt.Table == "" {
+		return "", false
+	}
+	if p.opts.schemaFn == nil && p.columnField(stmt) == nil {
+		return "", false
+	}
+
+	tenantID, ok := FromContext(stmt.Context)
+	if !ok {
+		if !p.opts.allowMissing {
+			_ = db.AddError(fmt.Errorf("%w, table %s", ErrMissingTenant, stmt.Table))
+		}
+		return "", false
+	}
+	return tenantID, true
+}
+
+func (p *Plugin) columnField(stmt *gorm.Statement) *schema.Field {
+	if stmt.Schema == nil {
+		return nil
+	}
+	return stmt.Schema.LookUpField(p.opts.column)
+}
+
+func (p *Plugin) scope(db *gorm.DB) {
+	if p.rejectRawSQL(db) { // e.g. db.Raw(sql).Scan(&users)
+		return
+	}
+	tenantID, ok := p.tenantID(db)
+	if !ok {
+		return
+	}
+
+	if p.opts.schemaFn != nil {
+		p.switchSchema(db.Statement, tenantID)
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: p.opts.column}, Value: tenantID},
+	}})
+}
+
+func (p *Plugin) beforeCreate(db *gorm.DB) {
+	tenantID, ok := p.tenantID(db)
+	if !ok {
+		return
+	}
+
+	stmt := db.Statement
+	if p.opts.schemaFn != nil {
+		if table := p.switchSchema(stmt, tenantID); table != "" {
+			// some dialects (e.g. sqlite) build the insert clause with the table name instead of table expression
+			stmt.AddClause(clause.Insert{Table: clause.Table{Name: table}})
+		}
+		return
+	}
+	if err := p.setTenantID(stmt, tenantID); err != nil {
+		_ = db.AddError(err)
+		return
+	}
+
+	// the upsert (e.g. db.Save with primary key of other tenant) can only update the record of current tenant,
+	// note: mysql does not support the condition of ON DUPLICATE KEY UPDATE
+	if c, ok := stmt.Clauses["ON CONFLICT"]; ok {
+		if onConflict, ok := c.Expression.(clause.OnConflict); ok && !onConflict.DoNothing {
+			onConflict.Where.Exprs = append(onConflict.Where.Exprs,
+				clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: p.opts.column}, Value: tenantID})
+			stmt.AddClause(onConflict)
+		}
+	}
+}
+
+func (p *Plugin) beforeUpdate(db *gorm.DB) {
+	tenantID, ok := p.tenantID(db)
+	if !ok {
+		return
+	}
+
+	// prevent the record from being moved out of the tenant
+	if p.opts.schemaFn == nil {
+		var err error
+		if m, ok := db.Statement.Dest.(map[string]interface{}); ok { // e.g. db.Updates(map)
+			if value, ok := m[p.opts.column]; ok && fmt.Sprint(value) != tenantID {
+				err = ErrTenantMismatch
+			}
+		} else if db.Statement.Dest == db.Statement.Model { // e.g. db.Save(&user)
+			err = p.setTenantID(db.Statement, tenantID)
+		} else { // e.g. db.Model(&user).Updates(User{...})
+			err = p.checkStructTenantID(db.Statement, tenantID)
+		}
+		if err != nil {
+			_ = db.AddError(err)
+			return
+		}
+	}
+	p.scope(db)
+}
+
+// set the tenant id to the records, the records of other tenants are rejected
+func (p *Plugin) setTenantID(stmt *gorm.Statement, tenantID string) error {
+	if m, ok := stmt.Dest.(map[string]interface{}); ok {
+		return setMapTenantID(m, p.opts.column, tenantID)
+	}
+	if ms, ok := stmt.Dest.([]map[string]interface{}); ok {
+		for _, m := range ms {
+			if err := setMapTenantID(m, p.opts.column, tenantID); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	field := p.columnField(stmt)
+	rv := stmt.ReflectValue
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if err := setFieldTenantID(stmt.Context, field, reflect.Indirect(rv.Index(i)), tenantID); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		return setFieldTenantID(stmt.Context, field, rv, tenantID)
+	}
+	return nil
+}
+
+// check the tenant id of the struct to be updated, the zero value is not updated unless the column is selected
+func (p *Plugin) checkStructTenantID(stmt *gorm.Statement, tenantID string) error {
+	rv := reflect.Indirect(reflect.ValueOf(stmt.Dest))
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	// the struct may be different from the model, parse it in the same way as gorm
+	updatingStmt := &gorm.Statement{DB: stmt.DB}
+	if err := updatingStmt.Parse(stmt.Dest); err != nil {
+		return nil // the error is returned by the update of gorm
+	}
+	field := updatingStmt.Schema.LookUpField(p.opts.column)
+	if field == nil {
+		return nil
+	}
+
+	value, isZero := field.ValueOf(stmt.Context, rv)
+	if isZero {
+		if columns, _ := stmt.SelectAndOmitColumns(false, true); !columns[field.DBName] {
+			return nil
+		}
+	}
+	if fmt.Sprint(value) != tenantID {
+		return ErrTenantMismatch
+	}
+	return nil
+}
+
+func setFieldTenantID(ctx context.Context, field *schema.Field, rv reflect.Value, tenantID string) error {
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	value, isZero := field.ValueOf(ctx, rv)
+	if isZero {
+		return field.Set(ctx, rv, tenantID)
+	}
+	if fmt.Sprint(value) != tenantID {
+		return ErrTenantMismatch
+	}
+	return nil
+}
+
+func setMapTenantID(m map[string]interface{}, column string, tenantID string) error {
+	if value, ok := m[column]; ok && value != nil && fmt.Sprint(value) != "" && fmt.Sprint(value) != tenantID {
+		return ErrTenantMismatch
+	}
+	m[column] = tenantID
+	return nil
+}
+
+// prefix the table with the schema of tenant, the table set by db.Table("name") is also supported,
+// return the table with schema, it is empty if the table is not switched.
+func (p *Plugin) switchSchema(stmt *gorm.Statement, tenantID string) string {
+	schemaName := p.opts.schemaFn(tenantID)
+	if schemaName == "" {
+		return ""
+	}
+	if stmt.TableExpr != nil && stmt.TableExpr.SQL != stmt.Quote(stmt.Table) {
+		return "" // custom table expression, e.g. db.Table("users u") or db.Table("other.users")
+	}
+	table := schemaName + "." + stmt.Table
+	stmt.TableExpr = &clause.Expr{SQL: stmt.Quote(table)}
+	return table
+}
@@ -0,0 +1,301 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type order struct {
+	ID       uint64 `gorm:"primaryKey"`
+	TenantID string `gorm:"index"`
+	Name     string
+}
+
+type product struct { // shared by all tenants
+	ID   uint64 `gorm:"primaryKey"`
+	Name string
+}
+
+func newTestDB(t *testing.T, name string) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), name)),
+		&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	return db
+}
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	_, ok := FromContext(ctx)
+	assert.False(t, ok)
+	_, ok = FromContext(NewContext(ctx, ""))
+	assert.False(t, ok)
+	tenantID, ok := FromContext(NewContext(ctx, "t1"))
+	assert.True(t, ok)
+	assert.Equal(t, "t1", tenantID)
+	assert.True(t, isSkipped(SkipContext(ctx)))
+	assert.False(t, isSkipped(ctx))
+}
+
+func TestPlugin_Column(t *testing.T) {
+	db := newTestDB(t, "column.db")
+	require.NoError(t, db.AutoMigrate(&order{}, &product{}))
+	require.NoError(t, Register(db))
+	require.NoError(t, Register(db)) // registered repeatedly is ignored
+
+	ctx1 := NewContext(context.Background(), "t1")
+	ctx2 := NewContext(context.Background(), "t2")
+
+	// create, the tenant id is set automatically
+	o1 := &order{Name: "o1"}
+	require.NoError(t, db.WithContext(ctx1).Create(o1).Error)
+	assert.Equal(t, "t1", o1.TenantID)
+	require.NoError(t, db.WithContext(ctx1).Create([]*order{{Name: "o2"}, {Name: "o3"}}).Error)
+	require.NoError(t, db.WithContext(ctx2).Create(&order{Name: "o4"}).Error)
+	err := db.WithContext(ctx2).Create(&order{Name: "o5", TenantID: "t1"}).Error
+	assert.ErrorIs(t, err, ErrTenantMismatch)
+	require.NoError(t, db.WithContext(ctx1).Model(&order{}).Create(map[string]interface{}{"name": "o6"}).Error)
+
+	// query
+	var orders []*order
+	require.NoError(t, db.WithContext(ctx1).Find(&orders).Error)
+	assert.Len(t, orders, 4)
+	require.NoError(t, db.WithContext(ctx2).Find(&orders).Error)
+	assert.Len(t, orders, 1)
+	var total int64
+	require.NoError(t, db.WithContext(ctx1).Model(&order{}).Where("name <> ?", "o1").Count(&total).Error)
+	assert.Equal(t, int64(3), total)
+	err = db.WithContext(ctx2).Where("id = ?", o1.ID).First(&order{}).Error
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	require.NoError(t, db.WithContext(SkipContext(context.Background())).Find(&orders).Error)
+	assert.Len(t, orders, 5)
+
+	// missing tenant
+	err = db.WithContext(context.Background()).Find(&orders).Error
+	assert.ErrorIs(t, err, ErrMissingTenant)
+	err = db.Find(&orders).Error
+	assert.ErrorIs(t, err, ErrMissingTenant)
+
+	// the tables without tenant column are not scoped
+	require.NoError(t, db.Create(&product{Name: "p1"}).Error)
+	var products []*product
+	require.NoError(t, db.WithContext(ctx2).Find(&products).Error)
+	assert.Len(t, products, 1)
+
+	// update
+	result := db.WithContext(ctx2).Model(&order{}).Where("id = ?", o1.ID).Update("name", "changed")
+	require.NoError(t, result.Error)
+	assert.Equal(t, int64(0), result.RowsAffected)
+	result = db.WithContext(ctx1).Model(&order{ID: o1.ID}).Updates(map[string]interface{}{"name": "changed"})
+	require.NoError(t, result.Error)
+	assert.Equal(t, int64(1), result.RowsAffected)
+	err = db.WithContext(ctx1).Model(&order{ID: o1.ID}).Updates(map[string]interface{}{"tenant_id": "t2"}).Error
+	assert.ErrorIs(t, err, ErrTenantMismatch)
+	o := &order{ID: o1.ID, Name: "saved"}
+	require.NoError(t, db.WithContext(ctx1).Save(o).Error)
+	assert.Equal(t, "t1", o.TenantID)
+	result = db.WithContext(ctx2).Save(&order{ID: o1.ID, Name: "saved", TenantID: "t2"})
+	assert.Equal(t, int64(0), result.RowsAffected) // the record of other tenant is not updated
+
+	// delete
+	result = db.WithContext(ctx2).Where("id = ?", o1.ID).Delete(&order{})
+	require.NoError(t, result.Error)
+	assert.Equal(t, int64(0), result.RowsAffected)
+	result = db.WithContext(ctx1).Where("id = ?", o1.ID).Delete(&order{})
+	require.NoError(t, result.Error)
+	assert.Equal(t, int64(1), result.RowsAffected)
+}
+
+func TestPlugin_UpdateStruct(t *testing.T) {
+	db := newTestDB(t, "update_struct.db")
+	require.NoError(t, db.AutoMigrate(&order{}))
+	require.NoError(t, Register(db))
+	ctx1 := NewContext(context.Background(), "t1")
+
+	o1 := &order{Name: "o1"}
+	require.NoError(t, db.WithContext(ctx1).Create(o1).Error)
+
+	// the tenant id of struct is different from the context
+	err := db.WithContext(ctx1).Model(&order{ID: o1.ID}).Updates(order{Name: "moved", TenantID: "t2"}).Error
+	assert.ErrorIs(t, err, ErrTenantMismatch)
+	err = db.WithContext(ctx1).Model(&order{ID: o1.ID}).Updates(&order{Name: "moved", TenantID: "t2"}).Error
+	assert.ErrorIs(t, err, ErrTenantMismatch)
+	type orderUpdate struct { // the struct is different from the model
+		Name     string
+		TenantID string
+	}
+	err = db.WithContext(ctx1).Model(&order{ID: o1.ID}).Updates(orderUpdate{Name: "moved", TenantID: "t2"}).Error
+	assert.ErrorIs(t, err, ErrTenantMismatch)
+	// the zero tenant id is updated if it is selected
+	err = db.WithContext(ctx1).Model(&order{ID: o1.ID}).Select("*").Updates(order{ID: o1.ID, Name: "moved"}).Error
+	assert.ErrorIs(t, err, ErrTenantMismatch)
+	err = db.WithContext(ctx1).Model(&order{ID: o1.ID}).Select("name", "tenant_id").Updates(orderUpdate{Name: "moved"}).Error
+	assert.ErrorIs(t, err, ErrTenantMismatch)
+
+	var o order
+	require.NoError(t, db.WithContext(SkipContext(context.Background())).First(&o, o1.ID).Error)
+	assert.Equal(t, "o1", o.Name)
+	assert.Equal(t, "t1", o.TenantID)
+
+	// the tenant id of struct is zero or the same as the context
+	result := db.WithContext(ctx1).Model(&order{ID: o1.ID}).Updates(order{Name: "changed"})
+	require.NoError(t, result.Error)
+	assert.Equal(t, int64(1), result.RowsAffected)
+	result = db.WithContext(ctx1).Model(&order{ID: o1.ID}).Updates(orderUpdate{Name: "changed2", TenantID: "t1"})
+	require.NoError(t, result.Error)
+	assert.Equal(t, int64(1), result.RowsAffected)
+}
+
+func TestPlugin_RawSQL(t *testing.T) {
+	db := newTestDB(t, "raw.db")
+	require.NoError(t, db.AutoMigrate(&order{}))
+	require.NoError(t, Register(db, WithRejectRawSQL()))
+	ctx1 := NewContext(context.Background(), "t1")
+	require.NoError(t, db.WithContext(ctx1).Create(&order{Name: "o1"}).Error)
+
+	var orders []*order
+	err := db.WithContext(ctx1).Raw("SELECT * FROM orders").Scan(&orders).Error
+	assert.ErrorIs(t, err, ErrRawSQL)
+	_, err = db.WithContext(ctx1).Raw("SELECT count(*) FROM orders").Rows() //nolint
+	assert.ErrorIs(t, err, ErrRawSQL)
+	err = db.WithContext(ctx1).Exec("UPDATE orders SET tenant_id = ?", "t2").Error
+	assert.ErrorIs(t, err, ErrRawSQL)
+
+	// the queries built by gorm are not affected
+	require.NoError(t, db.WithContext(ctx1).Find(&orders).Error)
+	assert.Len(t, orders, 1)
+
+	// run the raw sql with skip context
+	skipCtx := SkipContext(ctx1)
+	require.NoError(t, db.WithContext(skipCtx).Raw("SELECT * FROM orders WHERE tenant_id = ?", "t1").Scan(&orders).Error)
+	assert.Len(t, orders, 1)
+	require.NoError(t, db.WithContext(skipCtx).Exec("UPDATE orders SET name = ? WHERE tenant_id = ?", "changed", "t1").Error)
+
+	// not rejected by default
+	db = newTestDB(t, "raw2.db")
+	require.NoError(t, db.AutoMigrate(&order{}))
+	require.NoError(t, Register(db))
+	require.NoError(t, db.WithContext(ctx1).Raw("SELECT * FROM orders").Scan(&orders).Error)
+	require.NoError(t, db.WithContext(ctx1).Exec("DELETE FROM orders").Error)
+}
+
+func TestPlugin_Options(t *testing.T) {
+	db := newTestDB(t, "options.db")
+	require.NoError(t, db.AutoMigrate(&order{}))
+	require.NoError(t, db.Use(NewPlugin(WithAllowMissingTenant(), WithExcludeTables("orders"))))
+	assert.Equal(t, pluginName, NewPlugin().Name())
+
+	require.NoError(t, db.Create(&order{Name: "o1", TenantID: "t1"}).Error)
+	var orders []*order
+	require.NoError(t, db.WithContext(NewContext(context.Background(), "t2")).Find(&orders).Error)
+	assert.Len(t, orders, 1)
+
+	db = newTestDB(t, "options2.db")
+	require.NoError(t, db.AutoMigrate(&order{}))
+	require.NoError(t, Register(db, WithColumn("name"), WithAllowMissingTenant()))
+	require.NoError(t, db.Create(&order{Name: "t1"}).Error)
+	require.NoError(t, db.Find(&orders).Error)
+	assert.Len(t, orders, 1)
+	require.NoError(t, db.WithContext(NewContext(context.Background(), "t2")).Find(&orders).Error)
+	assert.Len(t, orders, 0)
+}
+
+func TestPlugin_Schema(t *testing.T) {
+	db := newTestDB(t, "schema.db")
+	require.NoError(t, Register(db, WithSchema(func(tenantID string) string { return "tenant_" + tenantID })))
+	ctx := NewContext(context.Background(), "1")
+
+	sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.WithContext(ctx).Where("id = ?", 1).Find(&[]*product{})
+	})
+	assert.Contains(t, sql, "`tenant_1`.`products`")
+	sql = db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.WithContext(ctx).Table("products").Where("id = ?", 1).Find(&[]map[string]interface{}{})
+	})
+	assert.Contains(t, sql, "`tenant_1`.`products`")
+	sql = db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.WithContext(ctx).Create(&product{Name: "p1"})
+	})
+	assert.Contains(t, sql, "INSERT INTO `tenant_1`.`products`")
+	sql = db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.WithContext(ctx).Model(&product{ID: 1}).Update("name", "p2")
+	})
+	assert.Contains(t, sql, "UPDATE `tenant_1`.`products`")
+	sql = db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.WithContext(ctx).Delete(&product{ID: 1})
+	})
+	assert.Contains(t, sql, "DELETE FROM `tenant_1`.`products`")
+	sql = db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.WithContext(ctx).Table("other.products").Find(&[]*product{})
+	})
+	assert.NotContains(t, sql, "tenant_1")
+}
+
+func TestScope(t *testing.T) {
+	db := newTestDB(t, "scope.db")
+	require.NoError(t, db.AutoMigrate(&order{}))
+	require.NoError(t, db.Create([]*order{{Name: "o1", TenantID: "t1"}, {Name: "o2", TenantID: "t2"}}).Error)
+
+	ctx := NewContext(context.Background(), "t1")
+	var orders []*order
+	require.NoError(t, db.WithContext(ctx).Scopes(Scope(ctx)).Find(&orders).Error)
+	require.Len(t, orders, 1)
+	assert.Equal(t, "o1", orders[0].Name)
+
+	err := db.Scopes(Scope(context.Background())).Find(&orders).Error
+	assert.ErrorIs(t, err, ErrMissingTenant)
+	require.NoError(t, db.Scopes(Scope(context.Background(), WithAllowMissingTenant())).Find(&orders).Error)
+	assert.Len(t, orders, 2)
+	require.NoError(t, db.Scopes(Scope(SkipContext(context.Background()))).Find(&orders).Error)
+	assert.Len(t, orders, 2)
+}
+
+func TestDBManager(t *testing.T) {
+	dir := t.TempDir()
+	opened := 0
+	m := NewDBManager(func(tenantID string) (*gorm.DB, error) {
+		if tenantID == "invalid" {
+			return nil, fmt.Errorf("invalid tenant")
+		}
+		opened++
+		return gorm.Open(sqlite.Open(filepath.Join(dir, tenantID+".db")),
+			&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	})
+
+	ctx1 := NewContext(context.Background(), "t1")
+	db1, err := m.Get(ctx1)
+	require.NoError(t, err)
+	require.NoError(t, db1.AutoMigrate(&product{}))
+	require.NoError(t, db1.Create(&product{Name: "p1"}).Error)
+	db1, err = m.Get(ctx1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, opened)
+
+	db2, err := m.GetByTenantID("t2")
+	require.NoError(t, err)
+	require.NoError(t, db2.AutoMigrate(&product{}))
+	var total int64
+	require.NoError(t, db2.Model(&product{}).Count(&total).Error)
+	assert.Equal(t, int64(0), total)
+	require.NoError(t, db1.Model(&product{}).Count(&total).Error)
+	assert.Equal(t, int64(1), total)
+
+	_, err = m.Get(context.Background())
+	assert.ErrorIs(t, err, ErrMissingTenant)
+	_, err = m.GetByTenantID("invalid")
+	assert.Error(t, err)
+
+	assert.NoError(t, m.Remove("t2"))
+	assert.NoError(t, m.Remove("t3"))
+	_, err = m.GetByTenantID("t2")
+	require.NoError(t, err)
+	assert.Equal(t, 3, opened)
+	assert.NoError(t, m.Close())
+}
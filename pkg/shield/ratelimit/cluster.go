@@ -0,0 +1,323 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/go-dev-frame/sponge/pkg/krand"
+)
+
+var _ Limiter = &ClusterLimiter{}
+
+// ClusterOption set the options of cluster limiter.
+type ClusterOption func(*clusterOptions)
+
+type clusterOptions struct {
+	nodeID       string
+	syncInterval time.Duration
+	localOpts    []Option
+}
+
+func defaultClusterOptions() *clusterOptions {
+	hostname, _ := os.Hostname()
+	return &clusterOptions{
+		nodeID:       hostname + "-" + krand.String(krand.R_All, 8),
+		syncInterval: time.Second,
+	}
+}
+
+func (o *clusterOptions) apply(opts ...ClusterOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WithNodeID set the unique id of the replica, default is hostname with random suffix
+func WithNodeID(id string) ClusterOption {
+	return func(o *clusterOptions) {
+		if id != "" {
+			o.nodeID = id
+		}
+	}
+}
+
+// WithSyncInterval set the interval of reporting and fetching load signals, default is 1s,
+// the replica which has not reported for 3 intervals is removed from the cluster.
+func WithSyncInterval(d time.Duration) ClusterOption {
+	return func(o *clusterOptions) {
+		if d > 0 {
+			o.syncInterval = d
+		}
+	}
+}
+
+// WithLocalOptions set the options of the local bbr limiter, e.g. WithCPUThreshold
+func WithLocalOptions(opts ...Option) ClusterOption {
+	return func(o *clusterOptions) {
+		o.localOpts = append(o.localOpts, opts...)
+	}
+}
+
+// load signals of a replica, they are shared by redis hash
+type nodeStat struct {
+	CPU         int64 `json:"cpu"`
+	InFlight    int64 `json:"inFlight"`
+	MaxInFlight int64 `json:"maxInFlight"`
+	Time        int64 `json:"time"` // unix milliseconds of reporting
+}
+
+// ClusterStat the load signals of all the replicas
+type ClusterStat struct {
+	Nodes       int   // number of alive replicas
+	CPU         int64 // average cpu of replicas
+	InFlight    int64 // total in-flight requests of replicas
+	MaxInFlight int64 // global limit, the sum of max in-flight of replicas
+	Overloaded  bool  // average cpu exceeds the threshold
+	UpdatedAt   time.Time
+}
+
+// ClusterLimiter is an adaptive limiter coordinated by redis, each replica reports its load signals
+// (cpu, in-flight and max in-flight calculated by bbr) and reads the others periodically, so the replicas
+// converge on the same global limit. When the average cpu of cluster exceeds the threshold, each replica
+// admits at most an equal share of the global limit, and the local bbr limiter still works.
+//
+// AllowCaller provides fairness among the callers, when the limiter is dropping, only the callers whose
+// in-flight requests exceed the fair share (limit / active callers) are rejected, so a heavy caller
+// cannot starve the others.
+//
+// If redis is unavailable, the cluster stat expires after 3 sync intervals and the limiter falls back to local bbr.
+type ClusterLimiter struct {
+	local    *BBR
+	client   redis.UniversalClient
+	name     string
+	key      string
+	nodeID   string
+	interval time.Duration
+
+	stat atomic.Value // *ClusterStat
+
+	mu      sync.Mutex
+	callers map[string]int64 // in-flight requests of callers
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewClusterLimiter create a cluster limiter, name is shared by the replicas of the same service,
+// the load signals are stored in the redis hash key ratelimit:<name>:nodes.
+func NewClusterLimiter(client redis.UniversalClient, name string, opts ...ClusterOption) (*ClusterLimiter, error) {
+	if client == nil {
+		return nil, errors.New("redis client is nil")
+	}
+	if name == "" {
+		return nil, errors.New("name is empty")
+	}
+	o := defaultClusterOptions()
+	o.apply(opts...)
+	registerMetrics()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	l := &ClusterLimiter{
+		local:    NewLimiter(o.localOpts...),
+		client:   client,
+		name:     name,
+		key:      "ratelimit:" + name + ":nodes",
+		nodeID:   o.nodeID,
+		interval: o.syncInterval,
+		callers:  make(map[string]int64),
+		cancel:   cancel,
+	}
+
+	l.sync(ctx)
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		ticker := time.NewTicker(l.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				l.sync(ctx)
+			}
+		}
+	}()
+
+	return l, nil
+}
+
+// report the local load signals and fetch the signals of all the replicas
+func (l *ClusterLimiter) sync(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, l.interval)
+	defer cancel()
+
+	s := l.local.Stat()
+	data, _ := json.Marshal(&nodeStat{
+		CPU:         s.CPU,
+		InFlight:    s.InFlight,
+		MaxInFlight: s.MaxInFlight,
+		Time:        time.Now().UnixMilli(),
+	})
+
+	pipe := l.client.Pipeline()
+	pipe.HSet(ctx, l.key, l.nodeID, data)
+	pipe.PExpire(ctx, l.key, l.interval*10)
+	nodesCmd := pipe.HGetAll(ctx, l.key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return // keep the previous stat, it expires if redis is unavailable for a long time
+	}
+
+	now := time.Now()
+	expired := now.Add(-3 * l.interval).UnixMilli()
+	cs := &ClusterStat{UpdatedAt: now}
+	var staleNodes []string
+	for id, v := range nodesCmd.Val() {
+		ns := &nodeStat{}
+		if err := json.Unmarshal([]byte(v), ns); err != nil || ns.Time < expired {
+			staleNodes = append(staleNodes, id)
+			continue
+		}
+		cs.Nodes++
+		cs.CPU += ns.CPU
+		cs.InFlight += ns.InFlight
+		cs.MaxInFlight += ns.MaxInFlight
+	}
+	if len(staleNodes) > 0 {
+		_ = l.client.HDel(ctx, l.key, staleNodes...).Err()
+	}
+	if cs.Nodes > 0 {
+		cs.CPU /= int64(cs.Nodes)
+	}
+	cs.Overloaded = cs.CPU >= l.local.opts.CPUThreshold
+
+	l.stat.Store(cs)
+	setClusterMetrics(l.name, cs)
+}
+
+// ClusterStat get the latest stat of cluster, ok is false if the stat has expired
+func (l *ClusterLimiter) ClusterStat() (stat ClusterStat, ok bool) {
+	cs, _ := l.stat.Load().(*ClusterStat)
+	if cs == nil || cs.Nodes == 0 || time.Since(cs.UpdatedAt) > 3*l.interval {
+		return ClusterStat{}, false
+	}
+	return *cs, true
+}
+
+// LocalStat get the stat of the local bbr limiter
+func (l *ClusterLimiter) LocalStat() Stat {
+	return l.local.Stat()
+}
+
+// the max in-flight requests of the current replica when the cluster is overloaded
+func (l *ClusterLimiter) share() (int64, bool) {
+	cs, ok := l.ClusterStat()
+	if !ok || !cs.Overloaded {
+		return 0, false
+	}
+	share := cs.MaxInFlight / int64(cs.Nodes)
+	if share < 1 {
+		share = 1
+	}
+	return share, true
+}
+
+// the reason of dropping request, empty means not dropping
+func (l *ClusterLimiter) dropReason() string {
+	if l.local.shouldDrop() {
+		return resultRejectLocal
+	}
+	if share, ok := l.share(); ok {
+		inFlight := atomic.LoadInt64(&l.local.inFlight)
+		if inFlight > 1 && inFlight >= share {
+			return resultRejectCluster
+		}
+	}
+	return ""
+}
+
+// Allow checks all inbound traffic, it returns ErrLimitExceed if the local replica or the cluster is overloaded.
+func (l *ClusterLimiter) Allow() (DoneFunc, error) {
+	return l.AllowCaller("")
+}
+
+// AllowCaller checks the inbound traffic of the caller, e.g. user id, app id or client ip,
+// empty caller is the same as Allow.
+func (l *ClusterLimiter) AllowCaller(caller string) (DoneFunc, error) {
+	reason := l.dropReason()
+	if reason != "" {
+		if caller == "" || !l.isBelowFairShare(caller) {
+			requestCount.WithLabelValues(l.name, reason).Inc()
+			return nil, ErrLimitExceed
+		}
+	}
+
+	if caller != "" {
+		l.mu.Lock()
+		l.callers[caller]++
+		l.mu.Unlock()
+	}
+	requestCount.WithLabelValues(l.name, resultPass).Inc()
+
+	atomic.AddInt64(&l.local.inFlight, 1)
+	start := time.Now()
+	return func(DoneInfo) {
+		rt := time.Since(start).Milliseconds()
+		if rt <= 0 {
+			rt = 1
+		}
+		l.local.rtStat.Add(rt)
+		atomic.AddInt64(&l.local.inFlight, -1)
+		l.local.passStat.Add(1)
+
+		if caller != "" {
+			l.mu.Lock()
+			if l.callers[caller]--; l.callers[caller] <= 0 {
+				delete(l.callers, caller)
+			}
+			l.mu.Unlock()
+		}
+	}, nil
+}
+
+// the caller is admitted while dropping if its in-flight requests are below the fair share of limit
+func (l *ClusterLimiter) isBelowFairShare(caller string) bool {
+	limit := l.local.maxInFlight()
+	if share, ok := l.share(); ok && share < limit {
+		limit = share
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	active := int64(len(l.callers))
+	inFlight, ok := l.callers[caller]
+	if !ok {
+		active++
+	}
+	fairShare := limit / active
+	if fairShare < 1 {
+		fairShare = 1
+	}
+	return inFlight < fairShare
+}
+
+// Close stop synchronizing and remove the current replica from the cluster
+func (l *ClusterLimiter) Close() error {
+	l.cancel()
+	l.wg.Wait()
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	return l.client.HDel(ctx, l.key, l.nodeID).Err()
+}
+
+// NodeID get the id of the current replica
+func (l *ClusterLimiter) NodeID() string {
+	return l.nodeID
+}
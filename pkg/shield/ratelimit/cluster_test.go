@@ -0,0 +1,124 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestClusterLimiter(t *testing.T, client redis.UniversalClient, nodeID string, interval time.Duration) *ClusterLimiter {
+	l, err := NewClusterLimiter(client, "test", WithNodeID(nodeID), WithSyncInterval(interval),
+		WithLocalOptions(WithWindow(time.Second), WithBucket(10), WithCPUThreshold(800)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func TestNewClusterLimiter(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	_, err := NewClusterLimiter(nil, "test")
+	assert.Error(t, err)
+	_, err = NewClusterLimiter(client, "")
+	assert.Error(t, err)
+
+	l1 := newTestClusterLimiter(t, client, "node1", 50*time.Millisecond)
+	l2 := newTestClusterLimiter(t, client, "node2", 50*time.Millisecond)
+	assert.Equal(t, "node1", l1.NodeID())
+
+	time.Sleep(200 * time.Millisecond)
+	cs, ok := l1.ClusterStat()
+	assert.True(t, ok)
+	assert.Equal(t, 2, cs.Nodes)
+	assert.False(t, cs.Overloaded)
+	assert.Equal(t, int64(0), l1.LocalStat().InFlight)
+
+	done, err := l1.Allow()
+	assert.NoError(t, err)
+	done(DoneInfo{})
+
+	// the closed replica is removed from cluster
+	assert.NoError(t, l2.Close())
+	time.Sleep(200 * time.Millisecond)
+	cs, _ = l1.ClusterStat()
+	assert.Equal(t, 1, cs.Nodes)
+
+	// redis is unavailable, the cluster stat expires and falls back to local limiter
+	mr.Close()
+	time.Sleep(250 * time.Millisecond)
+	_, ok = l1.ClusterStat()
+	assert.False(t, ok)
+	done, err = l1.Allow()
+	assert.NoError(t, err)
+	done(DoneInfo{})
+	assert.Error(t, l1.Close())
+}
+
+func TestClusterLimiter_Drop(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	ctx := context.Background()
+
+	// the sync is triggered manually
+	l1 := newTestClusterLimiter(t, client, "node1", time.Hour)
+	defer l1.Close()
+	l2 := newTestClusterLimiter(t, client, "node2", time.Hour)
+	defer l2.Close()
+
+	// stale node is removed
+	mr.HSet(l1.key, "node3", `{"cpu":1000,"time":1}`)
+	mr.HSet(l1.key, "node4", `invalid`)
+
+	// the cluster is overloaded
+	l1.local.cpu = func() int64 { return 900 }
+	l2.local.cpu = func() int64 { return 900 }
+	l2.sync(ctx)
+	l1.sync(ctx)
+	cs, ok := l1.ClusterStat()
+	assert.True(t, ok)
+	assert.Equal(t, 2, cs.Nodes)
+	assert.Equal(t, int64(900), cs.CPU)
+	assert.True(t, cs.Overloaded)
+	keys, _ := mr.HKeys(l1.key)
+	assert.ElementsMatch(t, []string{"node1", "node2"}, keys)
+
+	// the local cpu is low, but the in-flight exceeds the share of global limit
+	l1.local.cpu = func() int64 { return 100 }
+	share, ok := l1.share()
+	assert.True(t, ok)
+	l1.local.inFlight = share + 10
+	assert.Equal(t, resultRejectCluster, l1.dropReason())
+	_, err := l1.Allow()
+	assert.ErrorIs(t, err, ErrLimitExceed)
+
+	// the heavy caller is rejected, the light caller is admitted
+	l1.callers["heavy"] = share + 10
+	_, err = l1.AllowCaller("heavy")
+	assert.ErrorIs(t, err, ErrLimitExceed)
+	done, err := l1.AllowCaller("light")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), l1.callers["light"])
+	done(DoneInfo{})
+	_, ok = l1.callers["light"]
+	assert.False(t, ok)
+
+	// the cluster is recovered
+	l1.local.inFlight = 0
+	l2.local.cpu = func() int64 { return 100 }
+	l2.sync(ctx)
+	l1.sync(ctx)
+	cs, _ = l1.ClusterStat()
+	assert.False(t, cs.Overloaded)
+	assert.Equal(t, "", l1.dropReason())
+	done, err = l1.AllowCaller("heavy")
+	assert.NoError(t, err)
+	done(DoneInfo{})
+}
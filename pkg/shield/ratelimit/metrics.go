@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// results of cluster limiter
+const (
+	resultPass          = "pass"
+	resultRejectLocal   = "reject_local"   // rejected by local bbr limiter
+	resultRejectCluster = "reject_cluster" // rejected by the share of global limit
+)
+
+var (
+	requestCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ratelimit_requests_total",
+			Help: "Total number of requests checked by cluster limiter, result is pass, reject_local or reject_cluster.",
+		},
+		[]string{"name", "result"},
+	)
+
+	clusterNodes = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "ratelimit_cluster_nodes",
+			Help: "Number of alive replicas in the cluster.",
+		},
+		[]string{"name"},
+	)
+
+	clusterCPU = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "ratelimit_cluster_cpu",
+			Help: "Average cpu usage of replicas, 1000 is 100%.",
+		},
+		[]string{"name"},
+	)
+
+	clusterInFlight = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "ratelimit_cluster_in_flight",
+			Help: "Total in-flight requests of replicas.",
+		},
+		[]string{"name"},
+	)
+
+	clusterLimit = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "ratelimit_cluster_max_in_flight",
+			Help: "Global limit of in-flight requests, the sum of max in-flight of replicas.",
+		},
+		[]string{"name"},
+	)
+
+	registerOnce sync.Once
+)
+
+func registerMetrics() {
+	registerOnce.Do(func() {
+		prometheus.MustRegister(requestCount, clusterNodes, clusterCPU, clusterInFlight, clusterLimit)
+	})
+}
+
+func setClusterMetrics(name string, cs *ClusterStat) {
+	clusterNodes.WithLabelValues(name).Set(float64(cs.Nodes))
+	clusterCPU.WithLabelValues(name).Set(float64(cs.CPU))
+	clusterInFlight.WithLabelValues(name).Set(float64(cs.InFlight))
+	clusterLimit.WithLabelValues(name).Set(float64(cs.MaxInFlight))
+}
@@ -3,6 +3,13 @@ package parser
 // NullStyle null type
 type NullStyle int
 
+// the generators of sortable id of primary key, the id is generated before creating a record
+const (
+	IDGeneratorULID      = "ulid"      // string primary key, 26 bytes, e.g. char(26)
+	IDGeneratorKSUID     = "ksuid"     // string primary key, 27 bytes, e.g. char(27)
+	IDGeneratorSnowflake = "snowflake" // integer primary key, e.g. bigint unsigned
+)
+
 // nolint
 const (
 	NullDisable NullStyle = iota
@@ -27,9 +34,16 @@ type options struct {
 	Package        string
 	GormType       bool
 	ForceTableName bool
-	IsEmbed        bool // is gorm.Model embedded
-	IsWebProto     bool // true: proto file include router path and swagger info, false: normal proto file without router and swagger
-	IsExtendedAPI  bool // true: extended api (9 api), false: basic api (5 api)
+	IsEmbed        bool            // is gorm.Model embedded
+	IsWebProto     bool            // true: proto file include router path and swagger info, false: normal proto file without router and swagger
+	IsExtendedAPI  bool            // true: extended api (9 api), false: basic api (5 api)
+	EncryptColumns map[string]bool // column name --> whether to encrypt deterministically
+	DTOLanguages   []string        // front-end languages of DTO code generated from model, e.g. typescript, swift, kotlin
+	IDGenerator    string          // generator of primary key, ulid, ksuid or snowflake, empty means auto increment
+	IsRelation     bool            // generate the associations of model by foreign keys
+	RelationSQL    []string        // ddl of the other tables associated with the parsed tables
+
+	relations map[string][]tmplRelation // table name --> associations of model
 
 	IsCustomTemplate bool // true: custom extend template, false: sponge template
 }
@@ -151,6 +165,40 @@ func WithExtendedAPI() Option {
 	}
 }
 
+// WithEncryptColumns set the columns encrypted at rest, the value is whether to encrypt deterministically,
+// the string fields of columns are tagged with encrypt:"true" or encrypt:"deterministic"
+func WithEncryptColumns(columns map[string]bool) Option {
+	return func(o *options) {
+		o.EncryptColumns = columns
+	}
+}
+
+// WithDTOLanguages set the front-end languages of DTO code generated from model, typescript, swift or kotlin,
+// the code of each language is in the result of ParseSQL with the key of language name
+func WithDTOLanguages(languages ...string) Option {
+	return func(o *options) {
+		o.DTOLanguages = append(o.DTOLanguages, languages...)
+	}
+}
+
+// WithIDGenerator set the generator of primary key instead of auto increment, ulid, ksuid or snowflake,
+// the model generates the id in the BeforeCreate hook of gorm if it is empty, it is invalid for mongodb.
+func WithIDGenerator(generator string) Option {
+	return func(o *options) {
+		o.IDGenerator = generator
+	}
+}
+
+// WithRelation generate the associations of model (belongs to, has one, has many, many to many) by foreign keys,
+// ddl is the sql of the other tables associated with the parsed tables, only the associations between the tables
+// in sql and ddl are generated, so the models of the associated tables must be generated too.
+func WithRelation(ddl ...string) Option {
+	return func(o *options) {
+		o.IsRelation = true
+		o.RelationSQL = append(o.RelationSQL, ddl...)
+	}
+}
+
 // WithCustomTemplate set custom template
 func WithCustomTemplate() Option {
 	return func(o *options) {
@@ -17,6 +17,8 @@ import (
 	"github.com/zhufuyi/sqlparser/dependency/mysql"
 	"github.com/zhufuyi/sqlparser/dependency/types"
 	"github.com/zhufuyi/sqlparser/parser"
+
+	"github.com/go-dev-frame/sponge/pkg/struct2dto"
 )
 
 const (
@@ -96,6 +98,12 @@ func ParseSQL(sql string, options ...Option) (map[string]string, error) {
 	tableNames := make([]string, 0, len(stmts))
 	primaryKeysCodes := make([]string, 0, len(stmts))
 	tableInfoCodes := make([]string, 0, len(stmts))
+	if opt.IsRelation && opt.DBDriver != DBDriverMongodb {
+		opt.relations, err = parseRelations(stmts, opt)
+		if err != nil {
+			return nil, err
+		}
+	}
 	for _, stmt := range stmts {
 		if ct, ok := stmt.(*ast.CreateTableStmt); ok {
 			code, err2 := makeCode(ct, opt)
@@ -145,6 +153,14 @@ func ParseSQL(sql string, options ...Option) (map[string]string, error) {
 		CodeTypeTableInfo: strings.Join(tableInfoCodes, " |||| "),
 	}
 
+	for _, language := range opt.DTOLanguages {
+		code, err := struct2dto.Convert(modelCode, language)
+		if err != nil {
+			return nil, err
+		}
+		codesMap[language] = code
+	}
+
 	return codesMap, nil
 }
 
@@ -160,6 +176,8 @@ type tmplData struct {
 	SubStructs      string // sub structs for model
 	ProtoSubStructs string // sub structs for protobuf
 	DBDriver        string
+	SearchColumns   []string       // columns of the first FULLTEXT index, searched by the q parameter
+	Relations       []tmplRelation // associations of model by foreign keys
 
 	CrudInfo *CrudInfo
 }
@@ -477,10 +495,13 @@ func makeCode(stmt *ast.CreateTableStmt, opt options) (*codeText, error) {
 		if con.Tp == ast.ConstraintPrimaryKey {
 			isPrimaryKey[con.Keys[0].Column.String()] = true
 		}
-		if con.Tp == ast.ConstraintForeignKey {
-			// TODO: foreign key support
+		if con.Tp == ast.ConstraintFulltext && len(data.SearchColumns) == 0 {
+			for _, key := range con.Keys {
+				data.SearchColumns = append(data.SearchColumns, key.Column.String())
+			}
 		}
 	}
+	data.Relations = opt.relations[data.RawTableName]
 
 	columnPrefix := opt.ColumnPrefix
 	for _, col := range stmt.Cols {
@@ -595,6 +616,14 @@ func makeCode(stmt *ast.CreateTableStmt, opt options) (*codeText, error) {
 					field.GoType = "bool" // rewritten type
 				}
 			}
+			if deterministic, ok := opt.EncryptColumns[colName]; ok && isEncryptableGoType(field.GoType) {
+				if deterministic {
+					tags = append(tags, "encrypt", "deterministic")
+				} else {
+					tags = append(tags, "encrypt", "true")
+				}
+				field.Tag = makeTagStr(tags)
+			}
 		}
 
 		data.Fields = append(data.Fields, field)
@@ -623,6 +652,14 @@ func makeCode(stmt *ast.CreateTableStmt, opt options) (*codeText, error) {
 	if err != nil {
 		return nil, err
 	}
+	if opt.IDGenerator != "" && opt.DBDriver != DBDriverMongodb {
+		idGeneratorCode, err := getIDGeneratorCode(data, opt.IsEmbed, opt.IDGenerator)
+		if err != nil {
+			return nil, err
+		}
+		modelStructCode += idGeneratorCode
+		importPaths = append(importPaths, "gorm.io/gorm", "github.com/go-dev-frame/sponge/pkg/krand")
+	}
 
 	updateFieldsCode, err := getUpdateFieldsCode(data, opt.IsEmbed)
 	if err != nil {
@@ -803,6 +840,60 @@ func getModelStructCode(data tmplData, importPaths []string, isEmbed bool, jsonN
 	return structCode, newImportPaths, nil
 }
 
+// the BeforeCreate hook of model, it generates the id of primary key if it is empty
+func getIDGeneratorCode(data tmplData, isEmbed bool, generator string) (string, error) {
+	var pk *tmplField
+	if isEmbed {
+		pk = &tmplField{Name: "ID", GoType: "uint64"} // the primary key of sgorm.Model
+	} else {
+		for i := range data.Fields {
+			if data.Fields[i].IsPrimaryKey || data.Fields[i].ColName == "id" {
+				pk = &data.Fields[i]
+				break
+			}
+		}
+		if pk == nil {
+			return "", fmt.Errorf("table %s has no primary key, the id generator %s can not be used", data.RawTableName, generator)
+		}
+	}
+	goType := pk.GoType
+	if pk.Name == "ID" && !isEmbed && !data.isCommonStyle(isEmbed) {
+		goType = "uint64" // the same as the type of ID field in model
+	}
+
+	tmplData := map[string]interface{}{
+		"TableName": data.TableName,
+		"FieldName": pk.Name,
+		"GoType":    goType,
+		"Generator": generator,
+		"IsString":  true,
+	}
+	switch generator {
+	case IDGeneratorULID, IDGeneratorKSUID:
+		if goType != "string" {
+			return "", fmt.Errorf("the primary key %s of table %s is %s, the id generator %s requires string type, e.g. char(%d)",
+				pk.ColName, data.RawTableName, goType, generator, map[string]int{IDGeneratorULID: 26, IDGeneratorKSUID: 27}[generator])
+		}
+		tmplData["NewFunc"] = map[string]string{IDGeneratorULID: "NewULID", IDGeneratorKSUID: "NewKSUID"}[generator]
+	case IDGeneratorSnowflake:
+		switch goType {
+		case "int64", "uint64":
+		default:
+			return "", fmt.Errorf("the primary key %s of table %s is %s, the id generator %s requires int64 or uint64 type, e.g. bigint",
+				pk.ColName, data.RawTableName, goType, generator)
+		}
+		tmplData["IsString"] = false
+	default:
+		return "", fmt.Errorf("unsupported id generator %s, support ulid, ksuid, snowflake", generator)
+	}
+
+	builder := strings.Builder{}
+	if err := idGeneratorTmpl.Execute(&builder, tmplData); err != nil {
+		return "", fmt.Errorf("idGeneratorTmpl.Execute error: %v", err)
+	}
+	return builder.String(), nil
+}
+
 func getTableColumnsCode(data tmplData, isEmbed bool) ([]byte, error) {
 	if data.DBDriver == DBDriverMongodb {
 		for _, field := range data.Fields {
@@ -1325,6 +1416,15 @@ func goTypeToProto(fields []tmplField, jsonNameType int, isCommonStyle bool) []t
 	return newFields
 }
 
+// the types of field that can be encrypted by the encrypt plugin of sgorm
+func isEncryptableGoType(goType string) bool {
+	switch goType {
+	case "string", "*string", "[]byte":
+		return true
+	}
+	return false
+}
+
 func makeTagStr(tags []string) string {
 	builder := strings.Builder{}
 	for i := 0; i < len(tags)/2; i++ {
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/jinzhu/inflection"
@@ -264,6 +265,93 @@ func TestParseSQLs(t *testing.T) {
 	}
 }
 
+func TestParseSQLWithIDGenerator(t *testing.T) {
+	ulidSQL := "CREATE TABLE `order_item` (`id` char(26) NOT NULL, `name` varchar(50) NOT NULL, PRIMARY KEY (`id`));"
+	codes, err := ParseSQL(ulidSQL, WithJSONTag(0), WithIDGenerator(IDGeneratorULID))
+	assert.NoError(t, err)
+	assert.Contains(t, codes[CodeTypeModel], "func (m *OrderItem) BeforeCreate(*gorm.DB) error {")
+	assert.Contains(t, codes[CodeTypeModel], "m.ID = krand.NewULID()")
+	assert.Contains(t, codes[CodeTypeModel], `"github.com/go-dev-frame/sponge/pkg/krand"`)
+
+	codes, err = ParseSQL(strings.ReplaceAll(ulidSQL, "char(26)", "char(27)"), WithIDGenerator(IDGeneratorKSUID))
+	assert.NoError(t, err)
+	assert.Contains(t, codes[CodeTypeModel], "m.ID = krand.NewKSUID()")
+
+	snowflakeSQL := "CREATE TABLE `user` (`id` bigint unsigned NOT NULL, `name` varchar(50) NOT NULL, PRIMARY KEY (`id`));"
+	codes, err = ParseSQL(snowflakeSQL, WithIDGenerator(IDGeneratorSnowflake))
+	assert.NoError(t, err)
+	assert.Contains(t, codes[CodeTypeModel], "id, err := krand.NewSnowflakeID()")
+	assert.Contains(t, codes[CodeTypeModel], "m.ID = uint64(id)")
+
+	codes, err = ParseSQL(snowflakeSQL, WithEmbed(), WithIDGenerator(IDGeneratorSnowflake))
+	assert.NoError(t, err)
+	assert.Contains(t, codes[CodeTypeModel], "m.ID = uint64(id)")
+
+	// the type of primary key does not match the generator
+	_, err = ParseSQL(snowflakeSQL, WithIDGenerator(IDGeneratorULID))
+	assert.Error(t, err)
+	_, err = ParseSQL(ulidSQL, WithIDGenerator(IDGeneratorSnowflake))
+	assert.Error(t, err)
+	_, err = ParseSQL(snowflakeSQL, WithEmbed(), WithIDGenerator(IDGeneratorKSUID))
+	assert.Error(t, err)
+	_, err = ParseSQL(snowflakeSQL, WithIDGenerator("uuid"))
+	assert.Error(t, err)
+	_, err = ParseSQL("CREATE TABLE `log` (`content` varchar(50) NOT NULL);", WithIDGenerator(IDGeneratorSnowflake))
+	assert.Error(t, err)
+}
+
+func TestParseSQLWithFulltextIndex(t *testing.T) {
+	sql := "CREATE TABLE `article` (`id` bigint unsigned NOT NULL, `title` varchar(100) NOT NULL, `content` text NOT NULL, " +
+		"PRIMARY KEY (`id`), FULLTEXT KEY `ft_title_content` (`title`, `content`));"
+	codes, err := ParseSQL(sql, WithDBDriver(DBDriverMysql))
+	assert.NoError(t, err)
+	assert.Contains(t, codes[CodeTypeModel], "var ArticleSearchColumns = []string{\n\t\"title\",\n\t\"content\",\n}")
+
+	codes, err = ParseSQL("CREATE TABLE `log` (`id` bigint unsigned NOT NULL, PRIMARY KEY (`id`));")
+	assert.NoError(t, err)
+	assert.Contains(t, codes[CodeTypeModel], "var LogSearchColumns = []string{}")
+}
+
+func TestParseSQLWithRelation(t *testing.T) {
+	sql := "CREATE TABLE `user` (`id` bigint unsigned NOT NULL AUTO_INCREMENT, `name` varchar(50) NOT NULL, PRIMARY KEY (`id`));\n" +
+		"CREATE TABLE `profile` (`id` bigint unsigned NOT NULL AUTO_INCREMENT, `user_id` bigint unsigned NOT NULL, `bio` text, " +
+		"PRIMARY KEY (`id`), UNIQUE KEY `uk_user_id` (`user_id`), CONSTRAINT `fk_profile_user` FOREIGN KEY (`user_id`) REFERENCES `user` (`id`));\n" +
+		"CREATE TABLE `order` (`id` bigint unsigned NOT NULL AUTO_INCREMENT, `user_id` bigint unsigned NOT NULL REFERENCES `user` (`id`), " +
+		"`parent_id` bigint unsigned DEFAULT NULL, PRIMARY KEY (`id`), FOREIGN KEY (`parent_id`) REFERENCES `order` (`id`));\n"
+	roleSQL := "CREATE TABLE `role` (`id` bigint unsigned NOT NULL AUTO_INCREMENT, `name` varchar(50) NOT NULL, PRIMARY KEY (`id`));\n" +
+		"CREATE TABLE `user_role` (`user_id` bigint unsigned NOT NULL, `role_id` bigint unsigned NOT NULL, `created_at` datetime DEFAULT NULL, " +
+		"PRIMARY KEY (`user_id`, `role_id`), FOREIGN KEY (`user_id`) REFERENCES `user` (`id`), FOREIGN KEY (`role_id`) REFERENCES `role` (`id`));"
+
+	codes, err := ParseSQL(sql, WithJSONTag(1), WithRelation(roleSQL))
+	assert.NoError(t, err)
+	model := strings.Join(strings.Fields(codes[CodeTypeModel]), " ") // ignore the alignment of fields
+	assert.Contains(t, model, "Profile *Profile `gorm:\"foreignKey:UserID;references:ID\" json:\"profile,omitempty\"` // has one Profile")
+	assert.Contains(t, model, "Orders []*Order `gorm:\"foreignKey:UserID;references:ID\" json:\"orders,omitempty\"` // has many Order")
+	assert.Contains(t, model, "User *User `gorm:\"foreignKey:UserID;references:ID\" json:\"user,omitempty\"` // belongs to User")
+	assert.Contains(t, model, "Parent *Order `gorm:\"foreignKey:ParentID;references:ID\" json:\"parent,omitempty\"` // belongs to Order")
+	assert.Contains(t, model, "Children []*Order `gorm:\"foreignKey:ParentID;references:ID\" json:\"children,omitempty\"` // has many Order")
+	assert.Contains(t, model, "Roles []*Role `gorm:\"many2many:user_role;foreignKey:ID;joinForeignKey:UserID;references:ID;joinReferences:RoleID\" json:\"roles,omitempty\"`")
+	assert.NotContains(t, model, "type Role struct") // the table in relation ddl is not generated
+	assert.NotContains(t, codes[CodeTypeHandler], "Orders")
+
+	// the associated table is not in sql
+	codes, err = ParseSQL("CREATE TABLE `book` (`id` bigint unsigned NOT NULL, `author_id` bigint unsigned NOT NULL, "+
+		"PRIMARY KEY (`id`), FOREIGN KEY (`author_id`) REFERENCES `author` (`id`));", WithRelation())
+	assert.NoError(t, err)
+	assert.NotContains(t, codes[CodeTypeModel], "*Author")
+
+	// the name conflicts with column, use the table name
+	codes, err = ParseSQL("CREATE TABLE `user` (`id` bigint unsigned NOT NULL, PRIMARY KEY (`id`));\n"+
+		"CREATE TABLE `post` (`id` bigint unsigned NOT NULL, `author` varchar(50), `author_id` bigint unsigned NOT NULL, "+
+		"PRIMARY KEY (`id`), FOREIGN KEY (`author_id`) REFERENCES `user` (`id`));", WithRelation())
+	assert.NoError(t, err)
+	model = strings.Join(strings.Fields(codes[CodeTypeModel]), " ")
+	assert.Contains(t, model, "User *User `gorm:\"foreignKey:AuthorID;references:ID\"` // belongs to User")
+
+	_, err = ParseSQL(sql, WithRelation("CREATE TABLE"))
+	assert.Error(t, err)
+}
+
 func Test_parseOption(t *testing.T) {
 	opts := []Option{
 		WithDBDriver("foo"),
@@ -279,6 +367,10 @@ func Test_parseOption(t *testing.T) {
 		WithGormType(),
 		WithForceTableName(),
 		WithEmbed(),
+		WithEncryptColumns(map[string]bool{"foo": true}),
+		WithDTOLanguages("typescript"),
+		WithIDGenerator(IDGeneratorULID),
+		WithRelation("foo"),
 	}
 	o := parseOption(opts)
 	assert.NotNil(t, o)
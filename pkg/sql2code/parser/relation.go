@@ -0,0 +1,253 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/jinzhu/inflection"
+	"github.com/zhufuyi/sqlparser/ast"
+	"github.com/zhufuyi/sqlparser/parser"
+)
+
+// tmplRelation association field of model
+type tmplRelation struct {
+	Name    string // field name, example: Orders
+	GoType  string // example: []*Order
+	Tag     string
+	Comment string
+}
+
+type foreignKey struct {
+	column      string
+	referTable  string
+	referColumn string
+}
+
+type relationTable struct {
+	rawName       string
+	structName    string
+	columns       []string
+	uniqueColumns map[string]bool // primary key or unique key of single column
+	pairColumns   map[string]bool // primary key or unique key of two columns, the key is column1,column2
+	foreignKeys   []foreignKey
+}
+
+// only the foreign key of single column is supported, the referenced column is id by default
+func newRelationTable(stmt *ast.CreateTableStmt, opt options) *relationTable {
+	t := &relationTable{
+		rawName:       stmt.Table.Name.String(),
+		uniqueColumns: make(map[string]bool),
+		pairColumns:   make(map[string]bool),
+	}
+	t.structName = toCamel(t.rawName)
+	if opt.TablePrefix != "" && strings.HasPrefix(t.rawName, opt.TablePrefix) {
+		t.structName = toCamel(t.rawName[len(opt.TablePrefix):])
+	}
+
+	addForeignKey := func(column string, refer *ast.ReferenceDef) {
+		if refer == nil || refer.Table == nil {
+			return
+		}
+		fk := foreignKey{column: column, referTable: refer.Table.Name.String(), referColumn: columnID}
+		if len(refer.IndexColNames) == 1 {
+			fk.referColumn = refer.IndexColNames[0].Column.Name.String()
+		} else if len(refer.IndexColNames) > 1 {
+			return
+		}
+		t.foreignKeys = append(t.foreignKeys, fk)
+	}
+
+	for _, con := range stmt.Constraints {
+		switch con.Tp {
+		case ast.ConstraintPrimaryKey, ast.ConstraintUniq, ast.ConstraintUniqKey, ast.ConstraintUniqIndex:
+			if len(con.Keys) == 1 {
+				t.uniqueColumns[con.Keys[0].Column.Name.String()] = true
+			} else if len(con.Keys) == 2 {
+				t.pairColumns[con.Keys[0].Column.Name.String()+","+con.Keys[1].Column.Name.String()] = true
+				t.pairColumns[con.Keys[1].Column.Name.String()+","+con.Keys[0].Column.Name.String()] = true
+			}
+		case ast.ConstraintForeignKey:
+			if len(con.Keys) == 1 {
+				addForeignKey(con.Keys[0].Column.Name.String(), con.Refer)
+			}
+		}
+	}
+	for _, col := range stmt.Cols {
+		colName := col.Name.Name.String()
+		t.columns = append(t.columns, colName)
+		for _, o := range col.Options {
+			switch o.Tp {
+			case ast.ColumnOptionPrimaryKey, ast.ColumnOptionUniqKey:
+				t.uniqueColumns[colName] = true
+			case ast.ColumnOptionReference:
+				addForeignKey(colName, o.Refer)
+			}
+		}
+	}
+
+	return t
+}
+
+// join table of many to many, it has two foreign keys referencing different tables, the two columns
+// are primary key or unique key, and the other columns are only id and time columns.
+func (t *relationTable) isJoinTable() bool {
+	if len(t.foreignKeys) != 2 || t.foreignKeys[0].referTable == t.foreignKeys[1].referTable ||
+		!t.pairColumns[t.foreignKeys[0].column+","+t.foreignKeys[1].column] {
+		return false
+	}
+	for _, col := range t.columns {
+		if col != t.foreignKeys[0].column && col != t.foreignKeys[1].column && !isIgnoreFields(col) {
+			return false
+		}
+	}
+	return true
+}
+
+type relationBuilder struct {
+	opt       options
+	relations map[string][]tmplRelation
+	names     map[string]map[string]bool // table name --> field names of model
+}
+
+// get the associations of models by foreign keys, the key of result is the raw table name
+func getRelations(stmts []*ast.CreateTableStmt, opt options) map[string][]tmplRelation {
+	tables := make([]*relationTable, 0, len(stmts))
+	tableMap := make(map[string]*relationTable, len(stmts))
+	b := &relationBuilder{
+		opt:       opt,
+		relations: make(map[string][]tmplRelation),
+		names:     make(map[string]map[string]bool),
+	}
+	for _, stmt := range stmts {
+		t := newRelationTable(stmt, opt)
+		if _, ok := tableMap[t.rawName]; ok {
+			continue
+		}
+		tables = append(tables, t)
+		tableMap[t.rawName] = t
+		b.names[t.rawName] = make(map[string]bool)
+		for _, col := range t.columns {
+			b.names[t.rawName][b.fieldName(col)] = true
+		}
+	}
+
+	for _, t := range tables {
+		isJoinTable := t.isJoinTable()
+		for _, fk := range t.foreignKeys {
+			parent, ok := tableMap[fk.referTable]
+			if !ok {
+				continue
+			}
+
+			// belongs to, the name is the foreign key without suffix _id, e.g. user_id --> User
+			column := b.trimColumnPrefix(fk.column)
+			if len(column) > 3 && strings.EqualFold(column[len(column)-3:], "_id") {
+				column = column[:len(column)-3]
+			}
+			name := toCamel(column)
+			b.add(t, []string{name, parent.structName}, "*"+parent.structName,
+				"foreignKey:"+b.fieldName(fk.column)+";references:"+b.fieldName(fk.referColumn),
+				"belongs to "+parent.structName)
+
+			if isJoinTable {
+				continue
+			}
+
+			// has one or has many
+			if t.uniqueColumns[fk.column] {
+				name = t.structName
+				if t == parent {
+					name = "Child"
+				}
+				b.add(parent, []string{name, toCamel(column) + name}, "*"+t.structName,
+					"foreignKey:"+b.fieldName(fk.column)+";references:"+b.fieldName(fk.referColumn),
+					"has one "+t.structName)
+			} else {
+				name = inflection.Plural(t.structName)
+				if t == parent {
+					name = "Children"
+				}
+				b.add(parent, []string{name, toCamel(column) + name}, "[]*"+t.structName,
+					"foreignKey:"+b.fieldName(fk.column)+";references:"+b.fieldName(fk.referColumn),
+					"has many "+t.structName)
+			}
+		}
+
+		// many to many
+		if isJoinTable {
+			fk1, fk2 := t.foreignKeys[0], t.foreignKeys[1]
+			t1, ok1 := tableMap[fk1.referTable]
+			t2, ok2 := tableMap[fk2.referTable]
+			if !ok1 || !ok2 {
+				continue
+			}
+			b.add(t1, []string{inflection.Plural(t2.structName)}, "[]*"+t2.structName,
+				"many2many:"+t.rawName+";foreignKey:"+b.fieldName(fk1.referColumn)+";joinForeignKey:"+b.fieldName(fk1.column)+
+					";references:"+b.fieldName(fk2.referColumn)+";joinReferences:"+b.fieldName(fk2.column),
+				"many to many "+t2.structName+" by "+t.rawName)
+			b.add(t2, []string{inflection.Plural(t1.structName)}, "[]*"+t1.structName,
+				"many2many:"+t.rawName+";foreignKey:"+b.fieldName(fk2.referColumn)+";joinForeignKey:"+b.fieldName(fk2.column)+
+					";references:"+b.fieldName(fk1.referColumn)+";joinReferences:"+b.fieldName(fk1.column),
+				"many to many "+t1.structName+" by "+t.rawName)
+		}
+	}
+
+	return b.relations
+}
+
+// add the association to model with the first name that does not conflict with other fields,
+// it is ignored if all names conflict
+func (b *relationBuilder) add(t *relationTable, names []string, goType string, gormTag string, comment string) {
+	for _, name := range names {
+		if b.names[t.rawName][name] {
+			continue
+		}
+		b.names[t.rawName][name] = true
+
+		tags := []string{"gorm", gormTag}
+		if b.opt.JSONTag {
+			jsonName := customToCamel(name)
+			if b.opt.JSONNamedType == 0 {
+				jsonName = customToSnake(name)
+			}
+			tags = append(tags, "json", jsonName+",omitempty")
+		}
+		b.relations[t.rawName] = append(b.relations[t.rawName], tmplRelation{
+			Name:    name,
+			GoType:  goType,
+			Tag:     makeTagStr(tags),
+			Comment: comment,
+		})
+		return
+	}
+}
+
+func (b *relationBuilder) trimColumnPrefix(column string) string {
+	if b.opt.ColumnPrefix != "" && strings.HasPrefix(column, b.opt.ColumnPrefix) {
+		return column[len(b.opt.ColumnPrefix):]
+	}
+	return column
+}
+
+// the field name of column in model, it is used in the gorm tag of association
+func (b *relationBuilder) fieldName(column string) string {
+	return toCamel(b.trimColumnPrefix(column))
+}
+
+// parse the associations of the tables in sql and the ddl of associated tables
+func parseRelations(stmts []ast.StmtNode, opt options) (map[string][]tmplRelation, error) {
+	for _, ddl := range opt.RelationSQL {
+		relationStmts, err := parser.New().Parse(ddl, opt.Charset, opt.Collation)
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, relationStmts...)
+	}
+
+	tables := make([]*ast.CreateTableStmt, 0, len(stmts))
+	for _, stmt := range stmts {
+		if ct, ok := stmt.(*ast.CreateTableStmt); ok {
+			tables = append(tables, ct)
+		}
+	}
+	return getRelations(tables, opt), nil
+}
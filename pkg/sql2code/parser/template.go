@@ -17,6 +17,11 @@ type {{.TableName}} struct {
 {{- range .Fields}}
 	{{.Name}} {{.GoType}} {{if .Tag}}` + "`{{.Tag}}`" + `{{end}}{{if .Comment}} // {{.Comment}}{{end}}
 {{- end}}
+{{- if .Relations}}
+{{range .Relations}}
+	{{.Name}} {{.GoType}} ` + "`{{.Tag}}`" + ` // {{.Comment}}
+{{- end}}
+{{- end}}
 }
 {{if .NameFunc}}
 // TableName table name
@@ -34,6 +39,36 @@ var {{.TableName}}ColumnNames = map[string]bool{
 	"{{.ColName}}": true,
 {{- end}}
 }
+{{- if ne .DBDriver "mongodb"}}
+
+// {{.TableName}}SearchColumns columns searched by the q parameter of list api, they are the columns of FULLTEXT index
+var {{.TableName}}SearchColumns = []string{
+{{- range .SearchColumns}}
+	"{{.}}",
+{{- end}}
+}
+{{- end}}
+`
+
+	idGeneratorTmpl    *template.Template
+	idGeneratorTmplRaw = `
+// BeforeCreate generate the primary key by {{.Generator}} if it is empty
+func (m *{{.TableName}}) BeforeCreate(*gorm.DB) error {
+{{- if .IsString}}
+	if m.{{.FieldName}} == "" {
+		m.{{.FieldName}} = krand.{{.NewFunc}}()
+	}
+{{- else}}
+	if m.{{.FieldName}} == 0 {
+		id, err := krand.NewSnowflakeID()
+		if err != nil {
+			return err
+		}
+		m.{{.FieldName}} = {{.GoType}}(id)
+	}
+{{- end}}
+	return nil
+}
 `
 
 	modelTmpl    *template.Template
@@ -746,6 +781,10 @@ func initTemplate() {
 		if err != nil {
 			errSum = errors.Wrap(err, "tableColumnsTmplRaw")
 		}
+		idGeneratorTmpl, err = template.New("idGenerator").Parse(idGeneratorTmplRaw)
+		if err != nil {
+			errSum = errors.Wrap(errSum, "idGeneratorTmplRaw:"+err.Error())
+		}
 		modelTmpl, err = template.New("goFile").Parse(modelTmplRaw)
 		if err != nil {
 			errSum = errors.Wrap(errSum, "modelTmplRaw:"+err.Error())
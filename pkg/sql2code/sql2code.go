@@ -11,6 +11,7 @@ import (
 
 	"github.com/go-dev-frame/sponge/pkg/gofile"
 	"github.com/go-dev-frame/sponge/pkg/sql2code/parser"
+	"github.com/go-dev-frame/sponge/pkg/struct2dto"
 	"github.com/go-dev-frame/sponge/pkg/utils"
 )
 
@@ -31,7 +32,7 @@ type Args struct {
 	JSONNamedType  int    // json field naming type, 0: snake case such as my_field_name, 1: camel sase, such as myFieldName
 	IsEmbed        bool   // is gorm.Model embedded
 	IsWebProto     bool   // proto file type, true: include router path and swagger info, false: normal proto file without router and swagger
-	CodeType       string // specify the different types of code to be generated, namely model (default), json, dao, handler, proto
+	CodeType       string // specify the different types of code to be generated, namely model (default), json, dao, handler, proto, typescript, swift, kotlin
 	ForceTableName bool
 	Charset        string
 	Collation      string
@@ -40,6 +41,20 @@ type Args struct {
 	NoNullType     bool
 	NullStyle      string
 	IsExtendedAPI  bool // true: generate extended api (9 api), false: generate basic api (5 api)
+	// columns encrypted at rest, multiple names separated by commas, the column with suffix ":deterministic"
+	// is encrypted deterministically for equality queries, e.g. phone,email:deterministic
+	EncryptColumns string
+	// front-end DTO code generated alongside model, multiple languages separated by commas, support typescript, swift, kotlin,
+	// the code of each language is in the result of Generate with the key of language name, e.g. codes["typescript"]
+	DTOLanguages string
+	// generator of primary key instead of auto increment, ulid, ksuid or snowflake, the id is generated
+	// in the BeforeCreate hook of model, the primary key of ulid and ksuid is string, e.g. char(26), char(27)
+	IDGenerator string
+	// generate the associations of model (belongs to, has one, has many, many to many) by foreign keys,
+	// the associated tables are the other tables in sql, or the tables of RelationTables in database
+	IsRelation     bool
+	RelationTables string // the associated tables in database, multiple names separated by commas
+	relationSQL    []string
 
 	IsCustomTemplate bool // whether to use custom template, default is false
 }
@@ -67,6 +82,11 @@ func (a *Args) checkValid() error {
 	if a.fieldTypes == nil {
 		a.fieldTypes = make(map[string]string)
 	}
+	switch a.IDGenerator {
+	case "", parser.IDGeneratorULID, parser.IDGeneratorKSUID, parser.IDGeneratorSnowflake:
+	default:
+		return fmt.Errorf("unsupported id generator %s, support ulid, ksuid, snowflake", a.IDGenerator)
+	}
 	return nil
 }
 
@@ -186,20 +206,88 @@ func setOptions(args *Args) []parser.Option {
 	if args.IsCustomTemplate {
 		opts = append(opts, parser.WithCustomTemplate())
 	}
+	if args.EncryptColumns != "" {
+		opts = append(opts, parser.WithEncryptColumns(parseEncryptColumns(args.EncryptColumns)))
+	}
+	if languages := parseDTOLanguages(args.DTOLanguages); len(languages) > 0 {
+		opts = append(opts, parser.WithDTOLanguages(languages...))
+	}
+	if args.IDGenerator != "" {
+		opts = append(opts, parser.WithIDGenerator(args.IDGenerator))
+	}
+	if args.IsRelation {
+		opts = append(opts, parser.WithRelation(args.relationSQL...))
+	}
 
 	return opts
 }
 
+// get the ddl of the associated tables in database, the current table is excluded
+func getRelationSQL(args *Args) ([]string, error) {
+	if args.SQL != "" || args.DDLFile != "" || args.DBDsn == "" {
+		return nil, nil
+	}
+	var ddls []string
+	for _, name := range strings.Split(args.RelationTables, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || name == args.DBTable {
+			continue
+		}
+		a := *args
+		a.DBTable = name
+		sql, _, err := getSQL(&a)
+		if err != nil {
+			return nil, err
+		}
+		ddls = append(ddls, sql)
+	}
+	return ddls, nil
+}
+
+// parse the columns, e.g. phone,email:deterministic --> {"phone": false, "email": true}
+func parseEncryptColumns(s string) map[string]bool {
+	columns := make(map[string]bool)
+	for _, column := range strings.Split(s, ",") {
+		name, mode, _ := strings.Cut(strings.TrimSpace(column), ":")
+		if name == "" {
+			continue
+		}
+		columns[name] = strings.EqualFold(mode, "deterministic")
+	}
+	return columns
+}
+
+// parse the languages of DTO code, the aliases such as ts are converted to the language names
+func parseDTOLanguages(s string) []string {
+	var languages []string
+	for _, v := range strings.Split(s, ",") {
+		if strings.TrimSpace(v) == "" {
+			continue
+		}
+		language, err := struct2dto.ParseLanguage(v)
+		if err != nil {
+			language = v // returns error when generating code
+		}
+		languages = append(languages, language)
+	}
+	return languages
+}
+
 // GenerateOne generate gorm code from sql, which can be obtained from parameters, files and db, with priority from highest to lowest
 func GenerateOne(args *Args) (string, error) {
+	if args.CodeType == "" {
+		args.CodeType = parser.CodeTypeModel // default is model code
+	}
+	if language, err := struct2dto.ParseLanguage(args.CodeType); err == nil {
+		args.CodeType = language
+		args.DTOLanguages = language
+	}
+
 	codes, err := Generate(args)
 	if err != nil {
 		return "", err
 	}
 
-	if args.CodeType == "" {
-		args.CodeType = parser.CodeTypeModel // default is model code
-	}
 	out, ok := codes[args.CodeType]
 	if !ok {
 		return "", fmt.Errorf("unknown code type %s", args.CodeType)
@@ -224,6 +312,11 @@ func Generate(args *Args) (map[string]string, error) {
 	if sql == "" {
 		return nil, fmt.Errorf("get sql from %s error, maybe the table %s doesn't exist", args.DBDriver, args.DBTable)
 	}
+	if args.IsRelation {
+		if args.relationSQL, err = getRelationSQL(args); err != nil {
+			return nil, err
+		}
+	}
 
 	opt := setOptions(args)
 
@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/go-dev-frame/sponge/pkg/sql2code/parser"
 )
 
 var sqlData = `
@@ -183,3 +185,55 @@ func Test_setOptions(t *testing.T) {
 	a.NullStyle = "default"
 	assert.NotNil(t, o)
 }
+
+func TestGenerate_EncryptColumns(t *testing.T) {
+	codes, err := Generate(&Args{SQL: sqlData, JSONTag: true, EncryptColumns: "name, email:deterministic,phone,"})
+	assert.NoError(t, err)
+	model := codes[parser.CodeTypeModel]
+	assert.Contains(t, model, `json:"name" encrypt:"true"`)
+	assert.Contains(t, model, `json:"email" encrypt:"deterministic"`)
+	assert.NotContains(t, model, `json:"phone" encrypt`) // not a string type
+
+	assert.Equal(t, map[string]bool{"a": false, "b": true}, parseEncryptColumns("a,b:Deterministic,,:x"))
+}
+
+func TestGenerate_DTOLanguages(t *testing.T) {
+	codes, err := Generate(&Args{SQL: sqlData, JSONTag: true, JSONNamedType: 1, DTOLanguages: "ts,swift, kotlin"})
+	assert.NoError(t, err)
+	assert.Contains(t, codes["typescript"], "export interface User {")
+	assert.Contains(t, codes["typescript"], "  createdAt?: string | null;")
+	assert.Contains(t, codes["swift"], "struct User: Codable {")
+	assert.Contains(t, codes["kotlin"], "data class User(")
+
+	code, err := GenerateOne(&Args{SQL: sqlData, JSONTag: true, CodeType: "kt"})
+	assert.NoError(t, err)
+	assert.Contains(t, code, `@SerialName("created_at")`)
+
+	_, err = Generate(&Args{SQL: sqlData, DTOLanguages: "java"})
+	assert.Error(t, err)
+}
+
+func TestGenerate_IDGenerator(t *testing.T) {
+	codes, err := Generate(&Args{SQL: sqlData, JSONTag: true, IDGenerator: parser.IDGeneratorSnowflake})
+	assert.NoError(t, err)
+	assert.Contains(t, codes[parser.CodeTypeModel], "krand.NewSnowflakeID()")
+
+	_, err = Generate(&Args{SQL: sqlData, IDGenerator: parser.IDGeneratorULID}) // the primary key is not string
+	assert.Error(t, err)
+	_, err = Generate(&Args{SQL: sqlData, IDGenerator: "uuid"})
+	assert.Error(t, err)
+}
+
+func TestGenerate_Relation(t *testing.T) {
+	sql := "CREATE TABLE `user` (`id` bigint unsigned NOT NULL AUTO_INCREMENT, PRIMARY KEY (`id`));\n" +
+		"CREATE TABLE `order` (`id` bigint unsigned NOT NULL AUTO_INCREMENT, `user_id` bigint unsigned NOT NULL, " +
+		"PRIMARY KEY (`id`), FOREIGN KEY (`user_id`) REFERENCES `user` (`id`));"
+	codes, err := Generate(&Args{SQL: sql, IsRelation: true, RelationTables: "user,order"})
+	assert.NoError(t, err)
+	assert.Contains(t, codes[parser.CodeTypeModel], "// has many Order")
+	assert.Contains(t, codes[parser.CodeTypeModel], "// belongs to User")
+
+	codes, err = Generate(&Args{SQL: sql})
+	assert.NoError(t, err)
+	assert.NotContains(t, codes[parser.CodeTypeModel], "belongs to")
+}
@@ -1,20 +1,40 @@
 package stat
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"time"
 )
 
-var (
-	cpuThreshold            = 0.8 // 80% CPU usage
-	memoryThreshold         = 0.8 // 80% memory usage
-	triggerInterval float64 = 900 // unit(s)
-)
-
 // AlarmOption set the alarm options field.
 type AlarmOption func(*alarmOptions)
 
-type alarmOptions struct{}
+type alarmOptions struct {
+	cpuThreshold       float64       // range 0 to 1
+	memoryThreshold    float64       // range 0 to 1
+	rssThreshold       uint64        // unit(M), 0 means disabled
+	goroutineThreshold int           // 0 means disabled
+	interval           time.Duration // minimum interval between two alarms
+
+	webhookURL     string
+	webhookHeaders map[string]string
+	httpClient     *http.Client
+	handler        func(ctx context.Context, alert *Alert) error
+
+	profileDir      string        // save profiles to disk when alarm is triggered, empty means disabled
+	profileDuration time.Duration // duration of cpu profile
+}
+
+func defaultAlarmOptions() *alarmOptions {
+	return &alarmOptions{
+		cpuThreshold:    0.8, // 80% CPU usage
+		memoryThreshold: 0.8, // 80% memory usage
+		interval:        15 * time.Minute,
+		httpClient:      &http.Client{Timeout: 5 * time.Second},
+		profileDuration: 30 * time.Second,
+	}
+}
 
 func (o *alarmOptions) apply(opts ...AlarmOption) {
 	for _, opt := range opts {
@@ -28,7 +48,7 @@ func WithCPUThreshold(threshold float64) AlarmOption {
 		if threshold < 0 || threshold >= 1.0 {
 			return
 		}
-		cpuThreshold = threshold
+		o.cpuThreshold = threshold
 	}
 }
 
@@ -38,74 +58,171 @@ func WithMemoryThreshold(threshold float64) AlarmOption {
 		if threshold < 0 || threshold >= 1.0 {
 			return
 		}
-		memoryThreshold = threshold
+		o.memoryThreshold = threshold
+	}
+}
+
+// WithRSSThreshold set the threshold of process physical memory, unit(M), it is useful in containers
+// where the memory limit is less than the system memory, default is 0 (disabled).
+func WithRSSThreshold(rss uint64) AlarmOption {
+	return func(o *alarmOptions) {
+		o.rssThreshold = rss
+	}
+}
+
+// WithGoroutineThreshold set the threshold of goroutines number, default is 0 (disabled).
+func WithGoroutineThreshold(n int) AlarmOption {
+	return func(o *alarmOptions) {
+		if n < 0 {
+			return
+		}
+		o.goroutineThreshold = n
+	}
+}
+
+// WithAlarmInterval set the minimum interval between two alarms, default is 15 minutes.
+func WithAlarmInterval(d time.Duration) AlarmOption {
+	return func(o *alarmOptions) {
+		if d < time.Second {
+			return
+		}
+		o.interval = d
+	}
+}
+
+// WithWebhook send the alert to url by POST request with json body when the alarm is triggered,
+// the headers are added to the request, e.g. Authorization.
+func WithWebhook(url string, headers ...map[string]string) AlarmOption {
+	return func(o *alarmOptions) {
+		o.webhookURL = url
+		o.webhookHeaders = map[string]string{}
+		for _, h := range headers {
+			for k, v := range h {
+				o.webhookHeaders[k] = v
+			}
+		}
+	}
+}
+
+// WithAlarmHandler set the handler of alert, e.g. sending message to IM or email.
+func WithAlarmHandler(handler func(ctx context.Context, alert *Alert) error) AlarmOption {
+	return func(o *alarmOptions) {
+		o.handler = handler
+	}
+}
+
+// WithProfileCapture save heap, goroutine and cpu profiles to dir when the alarm is triggered,
+// cpuDuration is the duration of cpu profile, default is 30s. If it is not set, the SIGTRAP signal is sent
+// to the process instead, and the profiles are sampled by pkg/prof if the signal is handled, e.g. pkg/app.
+func WithProfileCapture(dir string, cpuDuration time.Duration) AlarmOption {
+	return func(o *alarmOptions) {
+		o.profileDir = dir
+		if cpuDuration > 0 {
+			o.profileDuration = cpuDuration
+		}
 	}
 }
 
 type statGroup struct {
 	data    [3]*StatData
 	alarmAt time.Time
+	opts    *alarmOptions
 }
 
-func newStatGroup() *statGroup {
-	return &statGroup{data: [3]*StatData{}}
+func newStatGroup(opts *alarmOptions) *statGroup {
+	return &statGroup{data: [3]*StatData{}, opts: opts}
 }
 
-func (g *statGroup) check(sd *StatData) bool {
+// check returns the reasons of alarm if the average of the last 3 stat data exceeds the thresholds,
+// returns nil if the thresholds are not exceeded or it is within the alarm interval.
+func (g *statGroup) check(sd *StatData) []string {
 	if g.data[0] == nil {
 		g.data[0] = sd
-		return false
+		return nil
 	} else if g.data[1] == nil {
 		g.data[1] = g.data[0]
 		g.data[0] = sd
-		return false
+		return nil
 	}
 	g.data[2] = g.data[1]
 	g.data[1] = g.data[0]
 	g.data[0] = sd
 
-	if g.checkCPU(cpuThreshold) || g.checkMemory(memoryThreshold) {
-		if g.alarmAt.IsZero() {
-			g.alarmAt = time.Now()
-			return true
-		}
-		if time.Since(g.alarmAt).Seconds() >= triggerInterval {
-			g.alarmAt = time.Now()
-			return true
+	var reasons []string
+	for _, checkFn := range []func() (string, bool){
+		func() (string, bool) { return g.checkCPU(g.opts.cpuThreshold) },
+		func() (string, bool) { return g.checkMemory(g.opts.memoryThreshold) },
+		func() (string, bool) { return g.checkRSS(g.opts.rssThreshold) },
+		func() (string, bool) { return g.checkGoroutines(g.opts.goroutineThreshold) },
+	} {
+		if reason, ok := checkFn(); ok {
+			reasons = append(reasons, reason)
 		}
 	}
+	if len(reasons) == 0 {
+		return nil
+	}
+
+	if g.alarmAt.IsZero() || time.Since(g.alarmAt) >= g.opts.interval {
+		g.alarmAt = time.Now()
+		return reasons
+	}
 
-	return false
+	return nil
 }
 
-func (g *statGroup) checkCPU(threshold float64) bool {
+func (g *statGroup) checkCPU(threshold float64) (string, bool) {
 	if g.data[0].Sys.CPUCores == 0 {
-		return false
+		return "", false
 	}
 
 	// average cpu usage exceeds cpuCores*threshold
 	average := (g.data[0].Proc.CPUUsage + g.data[1].Proc.CPUUsage + g.data[2].Proc.CPUUsage) / 3
 	threshold = threshold * 100
 	if average >= threshold {
-		fmt.Printf("[cpu] processes cpu usage(%.f%%) exceeds %.f%%\n", average, threshold)
-		return true
+		return fmt.Sprintf("[cpu] processes cpu usage(%.f%%) exceeds %.f%%", average, threshold), true
 	}
 
-	return false
+	return "", false
 }
 
-func (g *statGroup) checkMemory(threshold float64) bool {
+func (g *statGroup) checkMemory(threshold float64) (string, bool) {
 	if g.data[0].Sys.MemTotal == 0 {
-		return false
+		return "", false
 	}
 
 	// processes occupying more than threshold of system memory
 	procAverage := (g.data[0].Proc.RSS + g.data[1].Proc.RSS + g.data[2].Proc.RSS) / 3
 	procAverageUsage := float64(procAverage) / float64(g.data[0].Sys.MemTotal)
 	if procAverageUsage >= threshold {
-		fmt.Printf("[memory] processes memory usage(%.f%%) exceeds %.f%%\n", procAverageUsage*100, threshold*100)
-		return true
+		return fmt.Sprintf("[memory] processes memory usage(%.f%%) exceeds %.f%%", procAverageUsage*100, threshold*100), true
+	}
+
+	return "", false
+}
+
+func (g *statGroup) checkRSS(threshold uint64) (string, bool) {
+	if threshold == 0 {
+		return "", false
+	}
+
+	procAverage := (g.data[0].Proc.RSS + g.data[1].Proc.RSS + g.data[2].Proc.RSS) / 3
+	if procAverage >= threshold {
+		return fmt.Sprintf("[rss] processes physical memory(%dM) exceeds %dM", procAverage, threshold), true
+	}
+
+	return "", false
+}
+
+func (g *statGroup) checkGoroutines(threshold int) (string, bool) {
+	if threshold == 0 {
+		return "", false
+	}
+
+	average := (g.data[0].Proc.Goroutines + g.data[1].Proc.Goroutines + g.data[2].Proc.Goroutines) / 3
+	if average >= threshold {
+		return fmt.Sprintf("[goroutine] number of goroutines(%d) exceeds %d", average, threshold), true
 	}
 
-	return false
+	return "", false
 }
@@ -1,9 +1,12 @@
 package stat
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
 	"time"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func Test_statGroup_check(t *testing.T) {
@@ -33,8 +36,8 @@ func Test_statGroup_check(t *testing.T) {
 		return
 	}
 
-	sg := newStatGroup()
-	triggerInterval = 1
+	sg := newStatGroup(defaultAlarmOptions())
+	sg.opts.interval = time.Second
 	for _, data := range sd {
 		isAlarm := sg.check(&StatData{
 			Sys:  data.System,
@@ -48,14 +51,44 @@ func Test_statGroup_check(t *testing.T) {
 }
 
 func Test_alarmOptions_apply(t *testing.T) {
-	t.Log(cpuThreshold, memoryThreshold)
-	ao := &alarmOptions{}
+	ao := defaultAlarmOptions()
+	t.Log(ao.cpuThreshold, ao.memoryThreshold)
 	ao.apply(
-		WithCPUThreshold(-0.5),   // invalid value
-		WithMemoryThreshold(1.5), // invalid value
+		WithCPUThreshold(-0.5),         // invalid value
+		WithMemoryThreshold(1.5),       // invalid value
+		WithGoroutineThreshold(-1),     // invalid value
+		WithAlarmInterval(time.Second), // invalid value
 
 		WithCPUThreshold(0.9),
 		WithMemoryThreshold(0.85),
+		WithRSSThreshold(1024),
+		WithGoroutineThreshold(10000),
+		WithAlarmInterval(time.Minute),
+		WithWebhook("http://127.0.0.1:8080/alarm", map[string]string{"Authorization": "Bearer token"}),
+		WithAlarmHandler(func(ctx context.Context, alert *Alert) error { return nil }),
+		WithProfileCapture("profiles", time.Second),
 	)
-	t.Log(cpuThreshold, memoryThreshold)
+	assert.Equal(t, 0.9, ao.cpuThreshold)
+	assert.Equal(t, 0.85, ao.memoryThreshold)
+	assert.Equal(t, uint64(1024), ao.rssThreshold)
+	assert.Equal(t, 10000, ao.goroutineThreshold)
+	assert.Equal(t, time.Minute, ao.interval)
+	assert.Equal(t, "Bearer token", ao.webhookHeaders["Authorization"])
+	assert.Equal(t, time.Second, ao.profileDuration)
+}
+
+func Test_statGroup_checkRSSAndGoroutines(t *testing.T) {
+	ao := defaultAlarmOptions()
+	ao.apply(WithRSSThreshold(100), WithGoroutineThreshold(1000))
+	sg := newStatGroup(ao)
+
+	sd := &StatData{Sys: System{CPUCores: 2, MemTotal: 8000}, Proc: Process{RSS: 200, Goroutines: 2000}}
+	assert.Nil(t, sg.check(sd))
+	assert.Nil(t, sg.check(sd))
+	reasons := sg.check(sd)
+	assert.Len(t, reasons, 2)
+	t.Log(reasons)
+
+	// within the alarm interval
+	assert.Nil(t, sg.check(sd))
 }
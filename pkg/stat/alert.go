@@ -0,0 +1,168 @@
+package stat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	hostname, _ = os.Hostname()
+	serverName  = strings.TrimSuffix(filepath.Base(os.Args[0]), filepath.Ext(os.Args[0]))
+
+	isCapturing atomic.Bool
+)
+
+// Alert is the notification of alarm, it is the json body of webhook.
+type Alert struct {
+	ServerName string    `json:"server_name"`
+	Hostname   string    `json:"hostname"`
+	PID        int       `json:"pid"`
+	Time       time.Time `json:"time"`
+	Reasons    []string  `json:"reasons"`            // thresholds exceeded
+	Data       *StatData `json:"data"`               // the latest stat data
+	Profiles   []string  `json:"profiles,omitempty"` // files of profiles, the cpu profile is written after the duration of sampling
+}
+
+func newAlert(reasons []string, data *StatData) *Alert {
+	return &Alert{
+		ServerName: serverName,
+		Hostname:   hostname,
+		PID:        os.Getpid(),
+		Time:       time.Now(),
+		Reasons:    reasons,
+		Data:       data,
+	}
+}
+
+// alarm captures profiles and sends the alert to log, webhook and handler.
+func alarm(reasons []string, data *StatData, ao *alarmOptions, fields ...zap.Field) {
+	alert := newAlert(reasons, data)
+
+	if ao.profileDir != "" {
+		profiles, err := captureProfiles(ao.profileDir, ao.profileDuration)
+		if err != nil {
+			zapLog.Warn("capture profiles error", zap.Error(err))
+		}
+		alert.Profiles = profiles
+	} else {
+		sendSystemSignForLinux()
+	}
+
+	fields = append(fields, zap.Strings("reasons", reasons), zap.Any("system", data.Sys), zap.Any("process", data.Proc))
+	if len(alert.Profiles) > 0 {
+		fields = append(fields, zap.Strings("profiles", alert.Profiles))
+	}
+	zapLog.Warn("stat alarm", fields...)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if ao.webhookURL != "" {
+		if err := sendWebhook(ctx, ao, alert); err != nil {
+			zapLog.Warn("send alarm webhook error", zap.String("url", ao.webhookURL), zap.Error(err))
+		}
+	}
+	if ao.handler != nil {
+		func() {
+			defer func() { _ = recover() }()
+			if err := ao.handler(ctx, alert); err != nil {
+				zapLog.Warn("alarm handler error", zap.Error(err))
+			}
+		}()
+	}
+}
+
+func sendWebhook(ctx context.Context, ao *alarmOptions, alert *Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ao.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range ao.webhookHeaders {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := ao.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("status code %d, %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return nil
+}
+
+// captureProfiles saves the heap and goroutine profiles to dir, and starts sampling the cpu profile
+// in background for the duration, returns the files of profiles.
+func captureProfiles(dir string, cpuDuration time.Duration) ([]string, error) {
+	if !isCapturing.CompareAndSwap(false, true) {
+		return nil, errors.New("the last capture is not finished")
+	}
+	if err := os.MkdirAll(dir, 0766); err != nil {
+		isCapturing.Store(false)
+		return nil, err
+	}
+
+	var (
+		files  []string
+		errs   []error
+		prefix = filepath.Join(dir, fmt.Sprintf("%s_%d_%s_", serverName, os.Getpid(), time.Now().Format("20060102T150405")))
+	)
+	for _, name := range []string{"heap", "goroutine"} {
+		file := prefix + name + ".pprof"
+		if err := writeProfile(name, file); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		files = append(files, file)
+	}
+
+	file := prefix + "cpu.pprof"
+	f, err := os.Create(file)
+	if err != nil {
+		isCapturing.Store(false)
+		return files, errors.Join(append(errs, err)...)
+	}
+	// an error is returned if the cpu profile is being sampled, e.g. by pkg/prof
+	if err = pprof.StartCPUProfile(f); err != nil {
+		_ = f.Close()
+		_ = os.Remove(file)
+		isCapturing.Store(false)
+		return files, errors.Join(append(errs, err)...)
+	}
+	go func() {
+		defer isCapturing.Store(false)
+		time.Sleep(cpuDuration)
+		pprof.StopCPUProfile()
+		_ = f.Close()
+	}()
+
+	return append(files, file), errors.Join(errs...)
+}
+
+func writeProfile(name string, file string) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close() //nolint
+	return pprof.Lookup(name).WriteTo(f, 0)
+}
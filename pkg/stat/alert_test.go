@@ -0,0 +1,78 @@
+package stat
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlarm(t *testing.T) {
+	alerts := make(chan *Alert, 2)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		alert := &Alert{}
+		_ = json.NewDecoder(r.Body).Decode(alert)
+		alerts <- alert
+	}))
+	defer ts.Close()
+
+	dir := t.TempDir()
+	ao := defaultAlarmOptions()
+	ao.apply(
+		WithWebhook(ts.URL, map[string]string{"Authorization": "Bearer token"}),
+		WithAlarmHandler(func(ctx context.Context, alert *Alert) error {
+			alerts <- alert
+			return nil
+		}),
+		WithProfileCapture(dir, time.Millisecond*200),
+	)
+
+	data := &StatData{Sys: System{CPUCores: 2}, Proc: Process{CPUUsage: 95, Goroutines: 10}}
+	alarm([]string{"[cpu] processes cpu usage(95%) exceeds 80%"}, data, ao)
+	for i := 0; i < 2; i++ {
+		select {
+		case alert := <-alerts:
+			assert.Equal(t, float64(95), alert.Data.Proc.CPUUsage)
+			assert.Len(t, alert.Reasons, 1)
+			assert.Len(t, alert.Profiles, 3) // heap, goroutine and cpu
+		case <-time.After(time.Second):
+			t.Fatal("timeout")
+		}
+	}
+
+	// capture is not finished
+	_, err := captureProfiles(dir, time.Second)
+	assert.Error(t, err)
+
+	time.Sleep(time.Millisecond * 300)
+	files, _ := os.ReadDir(dir)
+	assert.Len(t, files, 3)
+	for _, f := range files {
+		info, _ := f.Info()
+		assert.Greater(t, info.Size(), int64(0))
+	}
+}
+
+func Test_sendWebhook(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte("internal error"))
+	}))
+	defer ts.Close()
+
+	ao := defaultAlarmOptions()
+	ao.apply(WithWebhook(ts.URL))
+	err := sendWebhook(context.Background(), ao, newAlert(nil, &StatData{}))
+	assert.Error(t, err)
+	t.Log(err)
+
+	ao.apply(WithWebhook("http://127.0.0.1:1"))
+	err = sendWebhook(context.Background(), ao, newAlert(nil, &StatData{}))
+	assert.Error(t, err)
+}
@@ -25,6 +25,7 @@ type Option func(*options)
 
 type options struct {
 	enableAlarm   bool
+	alarmOpts     *alarmOptions
 	zapFields     []zap.Field
 	customHandler func(ctx context.Context, sd *StatData) error
 }
@@ -62,15 +63,17 @@ func WithPrintField(fields ...zap.Field) Option {
 	}
 }
 
-// WithAlarm enable alarm and notify, except windows
+// WithAlarm enable alarm and notify when the thresholds are exceeded, except windows,
+// the alert is logged, and sent to webhook or handler if they are set.
 func WithAlarm(opts ...AlarmOption) Option {
 	return func(o *options) {
 		if runtime.GOOS == "windows" {
 			return
 		}
-		ao := &alarmOptions{}
+		ao := defaultAlarmOptions()
 		ao.apply(opts...)
 		o.enableAlarm = true
+		o.alarmOpts = ao
 	}
 }
 
@@ -90,7 +93,7 @@ func Init(opts ...Option) {
 	go func() {
 		printTick := time.NewTicker(printInfoInterval)
 		defer printTick.Stop()
-		sg := newStatGroup()
+		sg := newStatGroup(o.alarmOpts)
 
 		for {
 			select {
@@ -115,14 +118,15 @@ func handleAlarm(sg *statGroup, data *StatData, o *options) {
 		return
 	}
 	if o.enableAlarm {
-		if sg.check(data) {
-			sendSystemSignForLinux()
+		if reasons := sg.check(data); len(reasons) > 0 {
+			go alarm(reasons, data, o.alarmOpts, o.zapFields...)
 		}
 	}
 }
 
 func handleCustom(data *StatData, o *options) {
-	ctx, _ := context.WithTimeout(context.Background(), printInfoInterval) //nolint
+	ctx, cancel := context.WithTimeout(context.Background(), printInfoInterval)
+	defer cancel()
 	defer func() { _ = recover() }()
 	err := o.customHandler(ctx, data)
 	if err != nil {
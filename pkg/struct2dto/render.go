@@ -0,0 +1,320 @@
+package struct2dto
+
+import (
+	"fmt"
+	"strings"
+)
+
+const header = "// Code generated by sponge. DO NOT EDIT.\n\n"
+
+// ------------------------------------------------------------------------------------------
+// typescript
+
+func tsType(t *fieldType) string {
+	switch t.kind {
+	case kindArray:
+		elem := tsType(t.elem)
+		if strings.ContainsAny(elem, " |") {
+			elem = "(" + elem + ")"
+		}
+		return elem + "[]"
+	case kindMap:
+		return "Record<string, " + tsType(t.elem) + ">"
+	case kindRef:
+		return t.name
+	case kindAny:
+		return "unknown"
+	}
+	switch t.name {
+	case basicString, basicTime:
+		return "string"
+	case basicBool:
+		return "boolean"
+	default:
+		return "number"
+	}
+}
+
+// the property names are camel case, if the json key is different, the response data needs to be
+// converted to camel case keys before use, e.g. by the interceptor of http client.
+func renderTypeScript(structs []*structType) string {
+	var sb strings.Builder
+	sb.WriteString(header)
+	for i, st := range structs {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		if st.comment != "" {
+			sb.WriteString("// " + st.comment + "\n")
+		}
+		if st.listOf != "" {
+			sb.WriteString("export type " + st.name + " = " + st.listOf + "[];\n")
+			continue
+		}
+		sb.WriteString("export interface " + st.name + " {\n")
+		for _, f := range st.fields {
+			line := "  " + f.name
+			if f.optional {
+				line += "?: " + tsType(f.typ) + " | null;"
+			} else {
+				line += ": " + tsType(f.typ) + ";"
+			}
+			sb.WriteString(line + lineComment(f) + "\n")
+		}
+		sb.WriteString("}\n")
+	}
+	return sb.String()
+}
+
+func lineComment(f *structField) string {
+	var ss []string
+	if f.comment != "" {
+		ss = append(ss, f.comment)
+	}
+	if f.jsonKey != f.name {
+		ss = append(ss, "json: "+f.jsonKey)
+	}
+	if len(ss) == 0 {
+		return ""
+	}
+	return " // " + strings.Join(ss, ", ")
+}
+
+// ------------------------------------------------------------------------------------------
+// swift
+
+var swiftKeywords = map[string]bool{
+	"associatedtype": true, "class": true, "deinit": true, "enum": true, "extension": true, "func": true,
+	"import": true, "init": true, "inout": true, "internal": true, "let": true, "operator": true,
+	"private": true, "protocol": true, "public": true, "static": true, "struct": true, "subscript": true,
+	"typealias": true, "var": true, "break": true, "case": true, "continue": true, "default": true,
+	"defer": true, "do": true, "else": true, "fallthrough": true, "for": true, "guard": true, "if": true,
+	"in": true, "repeat": true, "return": true, "switch": true, "where": true, "while": true, "as": true,
+	"catch": true, "false": true, "is": true, "nil": true, "rethrows": true, "super": true, "self": true,
+	"throw": true, "throws": true, "true": true, "try": true,
+}
+
+func swiftType(t *fieldType) string {
+	switch t.kind {
+	case kindArray:
+		return "[" + swiftType(t.elem) + "]"
+	case kindMap:
+		return "[String: " + swiftType(t.elem) + "]"
+	case kindRef:
+		return t.name
+	case kindAny:
+		return "JSONValue"
+	}
+	switch t.name {
+	case basicString, basicTime:
+		return "String"
+	case basicInt:
+		return "Int"
+	case basicLong:
+		return "Int64"
+	case basicFloat:
+		return "Float"
+	case basicDouble:
+		return "Double"
+	default:
+		return "Bool"
+	}
+}
+
+func usesAny(t *fieldType) bool {
+	if t.kind == kindAny {
+		return true
+	}
+	if t.elem != nil {
+		return usesAny(t.elem)
+	}
+	return false
+}
+
+func escapeKeyword(name string, keywords map[string]bool) string {
+	if keywords[name] {
+		return "`" + name + "`"
+	}
+	return name
+}
+
+// the json keys are mapped by CodingKeys if they are different from the property names
+func renderSwift(structs []*structType) string {
+	var sb strings.Builder
+	sb.WriteString(header)
+	sb.WriteString("import Foundation\n")
+	isUseAny := false
+	for _, st := range structs {
+		sb.WriteString("\n")
+		if st.comment != "" {
+			sb.WriteString("// " + st.comment + "\n")
+		}
+		if st.listOf != "" {
+			sb.WriteString("typealias " + st.name + " = [" + st.listOf + "]\n")
+			continue
+		}
+		sb.WriteString("struct " + st.name + ": Codable {\n")
+		isMapping := false
+		for _, f := range st.fields {
+			typ := swiftType(f.typ)
+			if f.optional {
+				typ += "?"
+			}
+			sb.WriteString(fmt.Sprintf("    var %s: %s%s\n", escapeKeyword(f.name, swiftKeywords), typ, comment(f.comment)))
+			if f.jsonKey != f.name {
+				isMapping = true
+			}
+			if usesAny(f.typ) {
+				isUseAny = true
+			}
+		}
+		if isMapping {
+			sb.WriteString("\n    enum CodingKeys: String, CodingKey {\n")
+			for _, f := range st.fields {
+				name := escapeKeyword(f.name, swiftKeywords)
+				if f.jsonKey != f.name {
+					sb.WriteString(fmt.Sprintf("        case %s = %q\n", name, f.jsonKey))
+				} else {
+					sb.WriteString("        case " + name + "\n")
+				}
+			}
+			sb.WriteString("    }\n")
+		}
+		sb.WriteString("}\n")
+	}
+	if isUseAny {
+		sb.WriteString(swiftJSONValue)
+	}
+	return sb.String()
+}
+
+func comment(s string) string {
+	if s == "" {
+		return ""
+	}
+	return " // " + s
+}
+
+// the value of any json type
+const swiftJSONValue = `
+enum JSONValue: Codable {
+    case string(String)
+    case number(Double)
+    case bool(Bool)
+    case object([String: JSONValue])
+    case array([JSONValue])
+    case null
+
+    init(from decoder: Decoder) throws {
+        let container = try decoder.singleValueContainer()
+        if container.decodeNil() {
+            self = .null
+        } else if let v = try? container.decode(Bool.self) {
+            self = .bool(v)
+        } else if let v = try? container.decode(Double.self) {
+            self = .number(v)
+        } else if let v = try? container.decode(String.self) {
+            self = .string(v)
+        } else if let v = try? container.decode([JSONValue].self) {
+            self = .array(v)
+        } else {
+            self = .object(try container.decode([String: JSONValue].self))
+        }
+    }
+
+    func encode(to encoder: Encoder) throws {
+        var container = encoder.singleValueContainer()
+        switch self {
+        case .string(let v): try container.encode(v)
+        case .number(let v): try container.encode(v)
+        case .bool(let v): try container.encode(v)
+        case .object(let v): try container.encode(v)
+        case .array(let v): try container.encode(v)
+        case .null: try container.encodeNil()
+        }
+    }
+}
+`
+
+// ------------------------------------------------------------------------------------------
+// kotlin
+
+var kotlinKeywords = map[string]bool{
+	"as": true, "break": true, "class": true, "continue": true, "do": true, "else": true, "false": true,
+	"for": true, "fun": true, "if": true, "in": true, "interface": true, "is": true, "null": true,
+	"object": true, "package": true, "return": true, "super": true, "this": true, "throw": true,
+	"true": true, "try": true, "typealias": true, "typeof": true, "val": true, "var": true, "when": true,
+	"while": true,
+}
+
+func kotlinType(t *fieldType) string {
+	switch t.kind {
+	case kindArray:
+		return "List<" + kotlinType(t.elem) + ">"
+	case kindMap:
+		return "Map<String, " + kotlinType(t.elem) + ">"
+	case kindRef:
+		return t.name
+	case kindAny:
+		return "JsonElement"
+	}
+	switch t.name {
+	case basicString, basicTime:
+		return "String"
+	case basicInt:
+		return "Int"
+	case basicLong:
+		return "Long"
+	case basicFloat:
+		return "Float"
+	case basicDouble:
+		return "Double"
+	default:
+		return "Boolean"
+	}
+}
+
+// the data classes are serialized by kotlinx.serialization, the json keys are mapped by @SerialName
+// if they are different from the property names
+func renderKotlin(structs []*structType) string {
+	var body strings.Builder
+	isMapping, isUseAny := false, false
+	for _, st := range structs {
+		body.WriteString("\n")
+		if st.comment != "" {
+			body.WriteString("// " + st.comment + "\n")
+		}
+		if st.listOf != "" {
+			body.WriteString("typealias " + st.name + " = List<" + st.listOf + ">\n")
+			continue
+		}
+		body.WriteString("@Serializable\ndata class " + st.name + "(\n")
+		for _, f := range st.fields {
+			if f.jsonKey != f.name {
+				isMapping = true
+				body.WriteString(fmt.Sprintf("    @SerialName(%q)\n", f.jsonKey))
+			}
+			if usesAny(f.typ) {
+				isUseAny = true
+			}
+			typ := kotlinType(f.typ)
+			if f.optional {
+				typ += "? = null"
+			}
+			body.WriteString(fmt.Sprintf("    val %s: %s,%s\n", escapeKeyword(f.name, kotlinKeywords), typ, comment(f.comment)))
+		}
+		body.WriteString(")\n")
+	}
+
+	var sb strings.Builder
+	sb.WriteString(header)
+	if isMapping {
+		sb.WriteString("import kotlinx.serialization.SerialName\n")
+	}
+	sb.WriteString("import kotlinx.serialization.Serializable\n")
+	if isUseAny {
+		sb.WriteString("import kotlinx.serialization.json.JsonElement\n")
+	}
+	sb.WriteString(body.String())
+	return sb.String()
+}
@@ -0,0 +1,398 @@
+// Package struct2dto is a library for converting go struct code to the DTO definitions of front-end
+// languages, supporting typescript, swift and kotlin, the property names are the camel case of json tags.
+package struct2dto
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// supported languages
+const (
+	TypeScript = "typescript"
+	Swift      = "swift"
+	Kotlin     = "kotlin"
+)
+
+// Languages supported languages
+var Languages = []string{TypeScript, Swift, Kotlin}
+
+var languageAliases = map[string]string{
+	"typescript": TypeScript,
+	"ts":         TypeScript,
+	"swift":      Swift,
+	"kotlin":     Kotlin,
+	"kt":         Kotlin,
+}
+
+var fileExts = map[string]string{
+	TypeScript: ".ts",
+	Swift:      ".swift",
+	Kotlin:     ".kt",
+}
+
+// ParseLanguage get the language name, the aliases ts and kt are supported
+func ParseLanguage(language string) (string, error) {
+	lang, ok := languageAliases[strings.ToLower(strings.TrimSpace(language))]
+	if !ok {
+		return "", fmt.Errorf("unsupported language %q, supported languages: %s", language, strings.Join(Languages, ", "))
+	}
+	return lang, nil
+}
+
+// FileExt get the file extension of language, e.g. .ts
+func FileExt(language string) string {
+	lang, _ := ParseLanguage(language)
+	return fileExts[lang]
+}
+
+// Convert the struct types in go code to the DTO definitions of the language, the package clause
+// of go code can be omitted, the declarations other than struct types are ignored.
+func Convert(goCode string, language string) (string, error) {
+	lang, err := ParseLanguage(language)
+	if err != nil {
+		return "", err
+	}
+	structs, err := parseStructs(goCode)
+	if err != nil {
+		return "", err
+	}
+	if len(structs) == 0 {
+		return "", errors.New("not found struct type in go code")
+	}
+
+	switch lang {
+	case Swift:
+		return renderSwift(structs), nil
+	case Kotlin:
+		return renderKotlin(structs), nil
+	default:
+		return renderTypeScript(structs), nil
+	}
+}
+
+// ------------------------------------------------------------------------------------------
+
+type typeKind int
+
+const (
+	kindBasic typeKind = iota
+	kindArray
+	kindMap
+	kindRef
+	kindAny
+)
+
+// basic types
+const (
+	basicString = "string"
+	basicInt    = "int"
+	basicLong   = "long"
+	basicFloat  = "float"
+	basicDouble = "double"
+	basicBool   = "bool"
+	basicTime   = "time"
+)
+
+type fieldType struct {
+	kind     typeKind
+	name     string // basic type name or referenced struct name
+	elem     *fieldType
+	optional bool
+}
+
+type structField struct {
+	name     string // camel case of json key
+	jsonKey  string
+	typ      *fieldType
+	optional bool
+	comment  string
+}
+
+type structType struct {
+	name    string
+	comment string
+	fields  []*structField
+	listOf  string // the type is a list of struct listOf, e.g. type Users []struct{...}
+}
+
+// the types of third party packages which are often used in model
+var knownTypes = map[string]*fieldType{
+	"time.Time":          {kind: kindBasic, name: basicTime},
+	"time.Duration":      {kind: kindBasic, name: basicLong},
+	"sql.NullString":     {kind: kindBasic, name: basicString, optional: true},
+	"sql.NullInt16":      {kind: kindBasic, name: basicInt, optional: true},
+	"sql.NullInt32":      {kind: kindBasic, name: basicInt, optional: true},
+	"sql.NullInt64":      {kind: kindBasic, name: basicLong, optional: true},
+	"sql.NullByte":       {kind: kindBasic, name: basicInt, optional: true},
+	"sql.NullFloat64":    {kind: kindBasic, name: basicDouble, optional: true},
+	"sql.NullBool":       {kind: kindBasic, name: basicBool, optional: true},
+	"sql.NullTime":       {kind: kindBasic, name: basicTime, optional: true},
+	"gorm.DeletedAt":     {kind: kindBasic, name: basicTime, optional: true},
+	"sgorm.Bool":         {kind: kindBasic, name: basicBool},
+	"sgorm.TinyBool":     {kind: kindBasic, name: basicBool},
+	"decimal.Decimal":    {kind: kindBasic, name: basicString},
+	"datatypes.Date":     {kind: kindBasic, name: basicTime},
+	"datatypes.JSON":     {kind: kindAny},
+	"json.RawMessage":    {kind: kindAny},
+	"primitive.ObjectID": {kind: kindBasic, name: basicString},
+}
+
+// the fields of embedded model structs
+var embeddedModels = map[string][]*structField{
+	"sgorm.Model": {
+		{name: "id", jsonKey: "id", typ: &fieldType{kind: kindBasic, name: basicLong}},
+		{name: "createdAt", jsonKey: "createdAt", typ: &fieldType{kind: kindBasic, name: basicTime}},
+		{name: "updatedAt", jsonKey: "updatedAt", typ: &fieldType{kind: kindBasic, name: basicTime}},
+	},
+	"sgorm.Model2": {
+		{name: "id", jsonKey: "id", typ: &fieldType{kind: kindBasic, name: basicLong}},
+		{name: "createdAt", jsonKey: "created_at", typ: &fieldType{kind: kindBasic, name: basicTime}},
+		{name: "updatedAt", jsonKey: "updated_at", typ: &fieldType{kind: kindBasic, name: basicTime}},
+	},
+	"gorm.Model": {
+		{name: "id", jsonKey: "ID", typ: &fieldType{kind: kindBasic, name: basicLong}},
+		{name: "createdAt", jsonKey: "CreatedAt", typ: &fieldType{kind: kindBasic, name: basicTime}},
+		{name: "updatedAt", jsonKey: "UpdatedAt", typ: &fieldType{kind: kindBasic, name: basicTime}},
+		{name: "deletedAt", jsonKey: "DeletedAt", typ: &fieldType{kind: kindBasic, name: basicTime}, optional: true},
+	},
+}
+
+func parseStructs(goCode string) ([]*structType, error) {
+	src := goCode
+	if !strings.HasPrefix(strings.TrimSpace(src), "package ") {
+		src = "package dto\n" + src
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("parse go code error, %v", err)
+	}
+
+	specs := map[string]*ast.StructType{}
+	var structs []*structType
+	for _, decl := range f.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts := spec.(*ast.TypeSpec)
+			doc := ts.Doc
+			if doc == nil {
+				doc = gd.Doc
+			}
+			st := &structType{name: ts.Name.Name, comment: commentText(doc)}
+
+			switch t := ts.Type.(type) {
+			case *ast.StructType:
+				specs[st.name] = t
+			case *ast.ArrayType: // e.g. the json array is converted to type GenerateName []struct{...}
+				elem, ok := t.Elt.(*ast.StructType)
+				if !ok {
+					continue
+				}
+				st.listOf = st.name + "Item"
+				specs[st.listOf] = elem
+				structs = append(structs, &structType{name: st.listOf})
+			default:
+				continue
+			}
+			structs = append(structs, st)
+		}
+	}
+
+	for _, st := range structs {
+		if st.listOf == "" {
+			st.fields = parseFields(specs[st.name], specs, map[string]bool{st.name: true})
+		}
+	}
+	return structs, nil
+}
+
+func parseFields(st *ast.StructType, specs map[string]*ast.StructType, visited map[string]bool) []*structField {
+	var fields []*structField
+	for _, field := range st.Fields.List {
+		key, omitempty, skip := parseTag(field)
+		if skip {
+			continue
+		}
+
+		// the fields of embedded struct are promoted to the outer struct
+		if len(field.Names) == 0 && key == "" {
+			name := typeName(field.Type)
+			if v, ok := embeddedModels[name]; ok {
+				fields = append(fields, v...)
+			} else if v, ok := specs[name]; ok && !visited[name] {
+				visited[name] = true
+				fields = append(fields, parseFields(v, specs, visited)...)
+			}
+			continue
+		}
+
+		var fieldNames []string
+		for _, n := range field.Names {
+			if n.IsExported() {
+				fieldNames = append(fieldNames, n.Name)
+			}
+		}
+		if len(field.Names) == 0 {
+			fieldNames = []string{typeName(field.Type)}
+		}
+
+		typ := resolveType(field.Type, specs)
+		comment := commentText(field.Comment)
+		if comment == "" {
+			comment = commentText(field.Doc)
+		}
+		for _, fieldName := range fieldNames {
+			jsonKey := key
+			if jsonKey == "" || len(fieldNames) > 1 {
+				jsonKey = fieldName
+			}
+			fields = append(fields, &structField{
+				name:     toCamel(jsonKey),
+				jsonKey:  jsonKey,
+				typ:      typ,
+				optional: omitempty || typ.optional,
+				comment:  comment,
+			})
+		}
+	}
+	return fields
+}
+
+// get the key and options from json tag, the yaml tag is used if there is no json tag
+func parseTag(field *ast.Field) (key string, omitempty bool, skip bool) {
+	if field.Tag == nil {
+		return "", false, false
+	}
+	tagValue, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return "", false, false
+	}
+	tag := reflect.StructTag(tagValue)
+	value, ok := tag.Lookup("json")
+	if !ok {
+		value, ok = tag.Lookup("yaml")
+	}
+	if !ok {
+		return "", false, false
+	}
+
+	ss := strings.Split(value, ",")
+	if ss[0] == "-" && len(ss) == 1 {
+		return "", false, true
+	}
+	for _, opt := range ss[1:] {
+		if opt == "omitempty" || opt == "omitzero" {
+			omitempty = true
+		}
+	}
+	return ss[0], omitempty, false
+}
+
+func resolveType(expr ast.Expr, specs map[string]*ast.StructType) *fieldType {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		ft := *resolveType(t.X, specs)
+		ft.optional = true
+		return &ft
+	case *ast.ArrayType:
+		if ident, ok := t.Elt.(*ast.Ident); ok && (ident.Name == "byte" || ident.Name == "uint8") {
+			return &fieldType{kind: kindBasic, name: basicString} // base64 string
+		}
+		return &fieldType{kind: kindArray, elem: resolveType(t.Elt, specs)}
+	case *ast.MapType:
+		return &fieldType{kind: kindMap, elem: resolveType(t.Value, specs)}
+	case *ast.InterfaceType:
+		return &fieldType{kind: kindAny}
+	case *ast.StructType:
+		return &fieldType{kind: kindAny}
+	case *ast.SelectorExpr:
+		if ft, ok := knownTypes[typeName(t)]; ok {
+			v := *ft
+			return &v
+		}
+		return &fieldType{kind: kindAny}
+	case *ast.Ident:
+		switch t.Name {
+		case "string":
+			return &fieldType{kind: kindBasic, name: basicString}
+		case "int", "int8", "int16", "int32", "uint", "uint8", "uint16", "uint32", "byte", "rune":
+			return &fieldType{kind: kindBasic, name: basicInt}
+		case "int64", "uint64", "uintptr":
+			return &fieldType{kind: kindBasic, name: basicLong}
+		case "float32":
+			return &fieldType{kind: kindBasic, name: basicFloat}
+		case "float64":
+			return &fieldType{kind: kindBasic, name: basicDouble}
+		case "bool":
+			return &fieldType{kind: kindBasic, name: basicBool}
+		case "any":
+			return &fieldType{kind: kindAny}
+		}
+		if _, ok := specs[t.Name]; ok {
+			return &fieldType{kind: kindRef, name: t.Name}
+		}
+	}
+	return &fieldType{kind: kindAny}
+}
+
+func typeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return typeName(t.X)
+	case *ast.Ident:
+		return t.Name
+	case *ast.SelectorExpr:
+		if x, ok := t.X.(*ast.Ident); ok {
+			return x.Name + "." + t.Sel.Name
+		}
+		return t.Sel.Name
+	}
+	return ""
+}
+
+func commentText(cg *ast.CommentGroup) string {
+	if cg == nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(cg.Text()), " ")
+}
+
+// convert the json key to camel case, e.g. user_name --> userName, ID --> id
+func toCamel(s string) string {
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return "_"
+	}
+
+	var sb strings.Builder
+	for i, word := range words {
+		runes := []rune(word)
+		if i == 0 {
+			if strings.ToUpper(word) == word {
+				runes = []rune(strings.ToLower(word))
+			} else {
+				runes[0] = unicode.ToLower(runes[0])
+			}
+		} else {
+			runes[0] = unicode.ToUpper(runes[0])
+		}
+		sb.WriteString(string(runes))
+	}
+	name := sb.String()
+	if unicode.IsDigit([]rune(name)[0]) {
+		name = "_" + name
+	}
+	return name
+}
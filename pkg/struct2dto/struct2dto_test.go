@@ -0,0 +1,129 @@
+package struct2dto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var goCode = `package model
+
+import (
+	"time"
+
+	"github.com/go-dev-frame/sponge/pkg/sgorm"
+)
+
+// User user info
+type User struct {
+	sgorm.Model ` + "`gorm:\"embedded\"`" + ` // embed id and time
+
+	Name      string            ` + "`json:\"name\"`" + `      // username
+	UserType  int               ` + "`json:\"user_type\"`" + `
+	Score     float64           ` + "`json:\"score,omitempty\"`" + `
+	Birthday  *time.Time        ` + "`json:\"birthday\"`" + `
+	IsVip     bool              ` + "`json:\"isVip\"`" + `
+	Tags      []string          ` + "`json:\"tags\"`" + `
+	Extra     map[string]any    ` + "`json:\"extra\"`" + `
+	Address   *Address          ` + "`json:\"address\"`" + `
+	Password  string            ` + "`json:\"-\"`" + `
+	Default   int64
+	internal  string
+}
+
+type Address struct {
+	City string ` + "`yaml:\"city\"`" + `
+	ID   uint64 ` + "`json:\"ID\"`" + `
+}
+
+// TableName table name
+func (m *User) TableName() string {
+	return "user"
+}
+`
+
+func TestConvert(t *testing.T) {
+	for _, lang := range []string{"ts", Swift, "kt"} {
+		t.Run(lang, func(t *testing.T) {
+			out, err := Convert(goCode, lang)
+			assert.NoError(t, err)
+			t.Log(out)
+		})
+	}
+
+	out, err := Convert(goCode, TypeScript)
+	assert.NoError(t, err)
+	assert.Contains(t, out, "export interface User {")
+	assert.Contains(t, out, "  id: number;")
+	assert.Contains(t, out, "  name: string; // username")
+	assert.Contains(t, out, "  userType: number; // json: user_type")
+	assert.Contains(t, out, "  score?: number | null;")
+	assert.Contains(t, out, "  birthday?: string | null;")
+	assert.Contains(t, out, "  extra: Record<string, unknown>;")
+	assert.Contains(t, out, "  address?: Address | null;")
+	assert.Contains(t, out, "  city: string;")
+	assert.NotContains(t, out, "password")
+	assert.NotContains(t, out, "internal")
+
+	out, err = Convert(goCode, Swift)
+	assert.NoError(t, err)
+	assert.Contains(t, out, "struct User: Codable {")
+	assert.Contains(t, out, "    var tags: [String]")
+	assert.Contains(t, out, "    var `default`: Int64")
+	assert.Contains(t, out, `        case userType = "user_type"`)
+	assert.Contains(t, out, "enum JSONValue: Codable {")
+
+	out, err = Convert(goCode, Kotlin)
+	assert.NoError(t, err)
+	assert.Contains(t, out, "import kotlinx.serialization.SerialName")
+	assert.Contains(t, out, "import kotlinx.serialization.json.JsonElement")
+	assert.Contains(t, out, "data class User(")
+	assert.Contains(t, out, "    @SerialName(\"user_type\")\n    val userType: Int,")
+	assert.Contains(t, out, "    val isVip: Boolean,")
+	assert.Contains(t, out, "    val address: Address? = null,")
+
+	// go code without package clause
+	out, err = Convert("type Foo struct {\n\tBar []byte `json:\"bar\"`\n}", TypeScript)
+	assert.NoError(t, err)
+	assert.Contains(t, out, "  bar: string;")
+
+	// list of struct
+	for lang, want := range map[string]string{
+		TypeScript: "export type Users = UsersItem[];",
+		Swift:      "typealias Users = [UsersItem]",
+		Kotlin:     "typealias Users = List<UsersItem>",
+	} {
+		out, err = Convert("type Users []struct {\n\tName string `json:\"name\"`\n}", lang)
+		assert.NoError(t, err)
+		assert.Contains(t, out, want)
+	}
+
+	_, err = Convert(goCode, "java")
+	assert.Error(t, err)
+	_, err = Convert("type Foo int", TypeScript)
+	assert.Error(t, err)
+	_, err = Convert("type Foo struct {", TypeScript)
+	assert.Error(t, err)
+}
+
+func TestFileExt(t *testing.T) {
+	assert.Equal(t, ".ts", FileExt("ts"))
+	assert.Equal(t, ".swift", FileExt(Swift))
+	assert.Equal(t, ".kt", FileExt(Kotlin))
+	assert.Equal(t, "", FileExt("java"))
+}
+
+func Test_toCamel(t *testing.T) {
+	tests := map[string]string{
+		"user_name": "userName",
+		"userName":  "userName",
+		"UserName":  "userName",
+		"ID":        "id",
+		"user-id":   "userId",
+		"2fa_code":  "_2faCode",
+		"__":        "_",
+	}
+	for s, want := range tests {
+		assert.Equal(t, want, toCamel(s), s)
+	}
+}
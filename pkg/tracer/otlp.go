@@ -0,0 +1,151 @@
+package tracer
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdkTrace "go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/grpc/credentials"
+)
+
+// OTLP protocols
+const (
+	OTLPProtocolGRPC = "grpc"
+	OTLPProtocolHTTP = "http" // http/protobuf
+)
+
+// OTLPOption set otlpOptions.
+type OTLPOption func(*otlpOptions)
+
+type otlpOptions struct {
+	insecure bool
+	headers  map[string]string
+	caFile   string
+	urlPath  string
+	timeout  time.Duration
+	gzip     bool
+}
+
+func (o *otlpOptions) apply(opts ...OTLPOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+func defaultOTLPOptions() *otlpOptions {
+	return &otlpOptions{
+		timeout: 10 * time.Second,
+	}
+}
+
+// WithOTLPInsecure set whether to disable TLS, e.g. when exporting to a local collector.
+func WithOTLPInsecure(insecure bool) OTLPOption {
+	return func(o *otlpOptions) {
+		o.insecure = insecure
+	}
+}
+
+// WithOTLPHeaders set headers sent with each export, e.g. the api key of vendors such as Honeycomb.
+func WithOTLPHeaders(headers map[string]string) OTLPOption {
+	return func(o *otlpOptions) {
+		o.headers = headers
+	}
+}
+
+// WithOTLPCAFile set the CA file for verifying the certificate of the collector, system CAs are used if empty.
+func WithOTLPCAFile(caFile string) OTLPOption {
+	return func(o *otlpOptions) {
+		o.caFile = caFile
+	}
+}
+
+// WithOTLPURLPath set the url path of the http protocol, default is /v1/traces.
+func WithOTLPURLPath(urlPath string) OTLPOption {
+	return func(o *otlpOptions) {
+		o.urlPath = urlPath
+	}
+}
+
+// WithOTLPTimeout set the timeout of each export, default is 10s.
+func WithOTLPTimeout(d time.Duration) OTLPOption {
+	return func(o *otlpOptions) {
+		o.timeout = d
+	}
+}
+
+// WithOTLPGzip set compressing the exported data with gzip.
+func WithOTLPGzip() OTLPOption {
+	return func(o *otlpOptions) {
+		o.gzip = true
+	}
+}
+
+// NewOTLPExporter use an OTLP collector as exporter, such as OpenTelemetry Collector, Jaeger, Tempo, Honeycomb,
+// protocol is grpc or http, default is grpc, endpoint is host:port, e.g. localhost:4317 for grpc, localhost:4318 for http.
+func NewOTLPExporter(protocol string, endpoint string, opts ...OTLPOption) (sdkTrace.SpanExporter, error) {
+	o := defaultOTLPOptions()
+	o.apply(opts...)
+
+	var tlsConfig *tls.Config
+	if !o.insecure && o.caFile != "" {
+		data, err := os.ReadFile(o.caFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA file error: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no valid certificate found in CA file '%s'", o.caFile)
+		}
+		tlsConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	switch strings.ToLower(protocol) {
+	case "", OTLPProtocolGRPC:
+		grpcOpts := []otlptracegrpc.Option{
+			otlptracegrpc.WithEndpoint(endpoint),
+			otlptracegrpc.WithTimeout(o.timeout),
+		}
+		if o.insecure {
+			grpcOpts = append(grpcOpts, otlptracegrpc.WithInsecure())
+		} else if tlsConfig != nil {
+			grpcOpts = append(grpcOpts, otlptracegrpc.WithTLSCredentials(credentials.NewTLS(tlsConfig)))
+		}
+		if len(o.headers) > 0 {
+			grpcOpts = append(grpcOpts, otlptracegrpc.WithHeaders(o.headers))
+		}
+		if o.gzip {
+			grpcOpts = append(grpcOpts, otlptracegrpc.WithCompressor("gzip"))
+		}
+		return otlptracegrpc.New(context.Background(), grpcOpts...)
+
+	case OTLPProtocolHTTP, "http/protobuf":
+		httpOpts := []otlptracehttp.Option{
+			otlptracehttp.WithEndpoint(endpoint),
+			otlptracehttp.WithTimeout(o.timeout),
+		}
+		if o.insecure {
+			httpOpts = append(httpOpts, otlptracehttp.WithInsecure())
+		} else if tlsConfig != nil {
+			httpOpts = append(httpOpts, otlptracehttp.WithTLSClientConfig(tlsConfig))
+		}
+		if len(o.headers) > 0 {
+			httpOpts = append(httpOpts, otlptracehttp.WithHeaders(o.headers))
+		}
+		if o.urlPath != "" {
+			httpOpts = append(httpOpts, otlptracehttp.WithURLPath(o.urlPath))
+		}
+		if o.gzip {
+			httpOpts = append(httpOpts, otlptracehttp.WithCompression(otlptracehttp.GzipCompression))
+		}
+		return otlptracehttp.New(context.Background(), httpOpts...)
+	}
+
+	return nil, fmt.Errorf("unsupported OTLP protocol '%s', only grpc and http are supported", protocol)
+}
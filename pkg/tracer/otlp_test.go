@@ -0,0 +1,64 @@
+package tracer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewOTLPExporter(t *testing.T) {
+	exporter, err := NewOTLPExporter(OTLPProtocolGRPC, "localhost:4317",
+		WithOTLPInsecure(true),
+		WithOTLPHeaders(map[string]string{"x-api-key": "foo"}),
+		WithOTLPTimeout(time.Second),
+		WithOTLPGzip(),
+	)
+	assert.NoError(t, err)
+	assert.NotNil(t, exporter)
+	_ = exporter.Shutdown(context.Background())
+
+	exporter, err = NewOTLPExporter(OTLPProtocolHTTP, "localhost:4318",
+		WithOTLPURLPath("/v1/traces"),
+		WithOTLPGzip(),
+	)
+	assert.NoError(t, err)
+	assert.NotNil(t, exporter)
+	_ = exporter.Shutdown(context.Background())
+
+	_, err = NewOTLPExporter("thrift", "localhost:4317")
+	assert.Error(t, err)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	_ = os.WriteFile(caFile, []byte("invalid"), 0600)
+	_, err = NewOTLPExporter(OTLPProtocolGRPC, "localhost:4317", WithOTLPCAFile(caFile))
+	assert.Error(t, err)
+	_, err = NewOTLPExporter(OTLPProtocolGRPC, "localhost:4317", WithOTLPCAFile("notfound.pem"))
+	assert.Error(t, err)
+}
+
+func Test_configOptions_newExporter(t *testing.T) {
+	o := &configOptions{}
+	o.apply(WithOTLP(OTLPProtocolHTTP, "localhost:4318", WithOTLPInsecure(true)))
+	exporter, err := o.newExporter("localhost", "6831")
+	assert.NoError(t, err)
+	assert.NotNil(t, exporter)
+
+	o = &configOptions{}
+	o.apply(WithExporter(ExporterOTLP))
+	_, err = o.newExporter("localhost", "6831")
+	assert.Error(t, err)
+
+	o = &configOptions{}
+	o.apply(WithExporter(ExporterConsole))
+	_, err = o.newExporter("localhost", "6831")
+	assert.NoError(t, err)
+
+	o = &configOptions{}
+	o.apply(WithExporter("zipkin"))
+	_, err = o.newExporter("localhost", "6831")
+	assert.Error(t, err)
+}
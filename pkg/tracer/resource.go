@@ -82,9 +82,12 @@ func NewResource(opts ...ResourceOption) *resource.Resource {
 		kvs = append(kvs, attribute.String(k, v))
 	}
 
+	// use the schema URL of the SDK, merging resources with different schema URLs fails
+	// when the SDK is upgraded to a version using another semconv package
+	def := resource.Default()
 	r, err := resource.Merge(
-		resource.Default(),
-		resource.NewWithAttributes(semconv.SchemaURL, kvs...),
+		def,
+		resource.NewWithAttributes(def.SchemaURL(), kvs...),
 	)
 	if err != nil {
 		panic(err)
@@ -0,0 +1,363 @@
+package tracer
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
+)
+
+// SamplerOption set samplerOptions.
+type SamplerOption func(*samplerOptions)
+
+type samplerOptions struct {
+	routeRateLimits map[string]float64
+	keepErrors      bool
+	slowThreshold   time.Duration
+	maxTraces       int
+	traceTimeout    time.Duration
+}
+
+func (o *samplerOptions) apply(opts ...SamplerOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+func defaultSamplerOptions() *samplerOptions {
+	return &samplerOptions{
+		maxTraces:    10000,
+		traceTimeout: 30 * time.Second,
+	}
+}
+
+// tail sampling is enabled if error or slow traces are kept
+func (o *samplerOptions) tailEnabled() bool {
+	return o.keepErrors || o.slowThreshold > 0
+}
+
+// WithRouteRateLimits set the maximum number of traces sampled per second of routes (span names of root spans),
+// e.g. {"/api/v1/user/:id": 10, "*": 100}, "*" applies to the other routes, the sampling rate is
+// not used for the routes that are rate limited. Each of the other routes has its own limiter up to 1000 routes,
+// the routes beyond that share one limiter, so that the span names with ids (e.g. /user/1) do not exhaust memory.
+func WithRouteRateLimits(limits map[string]float64) SamplerOption {
+	return func(o *samplerOptions) {
+		o.routeRateLimits = limits
+	}
+}
+
+// WithKeepErrors set whether to always keep the traces with errors, even if they are not sampled.
+func WithKeepErrors(keep bool) SamplerOption {
+	return func(o *samplerOptions) {
+		o.keepErrors = keep
+	}
+}
+
+// WithKeepSlow set keeping the traces that contain a span slower than threshold, even if they are not sampled,
+// 0 means disabled.
+func WithKeepSlow(threshold time.Duration) SamplerOption {
+	return func(o *samplerOptions) {
+		o.slowThreshold = threshold
+	}
+}
+
+// WithTailBuffer set the buffer of the traces that are not sampled, the traces are kept or dropped when
+// their root spans end, maxTraces is the maximum number of buffered traces, default is 10000, the oldest trace
+// is finished early when the buffer is full, timeout is the maximum time a trace is buffered, default is 30s.
+func WithTailBuffer(maxTraces int, timeout time.Duration) SamplerOption {
+	return func(o *samplerOptions) {
+		if maxTraces > 0 {
+			o.maxTraces = maxTraces
+		}
+		if timeout > 0 {
+			o.traceTimeout = timeout
+		}
+	}
+}
+
+// ------------------------------------------------------------------------------------------
+
+// the maximum number of limiters of the routes matched by "*"
+const maxRouteLimiters = 1000
+
+// headSampler samples root spans by route rate limits or ratio, the spans that are not sampled
+// are recorded without exporting if tail sampling is enabled.
+type headSampler struct {
+	ratio       trace.Sampler
+	limits      map[string]float64
+	limiters    sync.Map // span name -> *rate.Limiter
+	notSampled  trace.SamplingDecision
+	description string
+
+	mu          sync.Mutex
+	numLimiters int           // the number of limiters of the routes matched by "*"
+	maxLimiters int           // the maximum of numLimiters
+	overflow    *rate.Limiter // shared by the routes matched by "*" when numLimiters reaches maxLimiters
+}
+
+func newHeadSampler(fraction float64, o *samplerOptions) trace.Sampler {
+	notSampled := trace.Drop
+	if o.tailEnabled() {
+		notSampled = trace.RecordOnly
+	}
+	s := &headSampler{
+		ratio:       trace.TraceIDRatioBased(fraction),
+		limits:      o.routeRateLimits,
+		notSampled:  notSampled,
+		description: "HeadSampler{" + trace.TraceIDRatioBased(fraction).Description() + "}",
+		maxLimiters: maxRouteLimiters,
+	}
+	if limit, ok := o.routeRateLimits["*"]; ok {
+		s.overflow = newRouteLimiter(limit)
+	}
+
+	// spans are recorded if their local parents are recorded for tail sampling
+	return trace.ParentBased(s, trace.WithLocalParentNotSampled(recordOnlySampler{notSampled}))
+}
+
+func (s *headSampler) ShouldSample(p trace.SamplingParameters) trace.SamplingResult {
+	psc := oteltrace.SpanContextFromContext(p.ParentContext)
+
+	sampled := false
+	if limiter := s.limiter(p.Name); limiter != nil {
+		sampled = limiter.Allow()
+	} else {
+		sampled = s.ratio.ShouldSample(p).Decision == trace.RecordAndSample
+	}
+
+	if sampled {
+		return trace.SamplingResult{Decision: trace.RecordAndSample, Tracestate: psc.TraceState()}
+	}
+	return trace.SamplingResult{Decision: s.notSampled, Tracestate: psc.TraceState()}
+}
+
+func (s *headSampler) Description() string {
+	return s.description
+}
+
+// limiter returns the rate limiter of the route, nil if the route is not rate limited.
+func (s *headSampler) limiter(name string) *rate.Limiter {
+	limit, ok := s.limits[name]
+	if !ok {
+		if limit, ok = s.limits["*"]; !ok {
+			return nil
+		}
+	}
+	if v, ok := s.limiters.Load(name); ok {
+		return v.(*rate.Limiter)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if v, ok := s.limiters.Load(name); ok {
+		return v.(*rate.Limiter)
+	}
+	_, isRoute := s.limits[name]
+	if !isRoute {
+		if s.numLimiters >= s.maxLimiters {
+			return s.overflow
+		}
+		s.numLimiters++
+	}
+	limiter := newRouteLimiter(limit)
+	s.limiters.Store(name, limiter)
+	return limiter
+}
+
+func newRouteLimiter(limit float64) *rate.Limiter {
+	burst := int(limit)
+	if burst < 1 {
+		burst = 1
+	}
+	return rate.NewLimiter(rate.Limit(limit), burst)
+}
+
+type recordOnlySampler struct {
+	decision trace.SamplingDecision
+}
+
+func (s recordOnlySampler) ShouldSample(p trace.SamplingParameters) trace.SamplingResult {
+	return trace.SamplingResult{Decision: s.decision, Tracestate: oteltrace.SpanContextFromContext(p.ParentContext).TraceState()}
+}
+
+func (s recordOnlySampler) Description() string {
+	return "RecordOnlySampler"
+}
+
+// ------------------------------------------------------------------------------------------
+
+// lockedExporter serializes the exports of the batch span processor and the tail processor.
+type lockedExporter struct {
+	mu sync.Mutex
+	trace.SpanExporter
+}
+
+func (e *lockedExporter) ExportSpans(ctx context.Context, spans []trace.ReadOnlySpan) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.SpanExporter.ExportSpans(ctx, spans)
+}
+
+type tailTrace struct {
+	spans   []trace.ReadOnlySpan
+	keep    bool
+	created time.Time
+}
+
+// tailProcessor buffers the spans of the traces that are not sampled, when the local root span ends,
+// the trace is exported if it contains an error or slow span, otherwise it is dropped.
+type tailProcessor struct {
+	exporter      trace.SpanExporter
+	keepErrors    bool
+	slowThreshold time.Duration
+	maxTraces     int
+	traceTimeout  time.Duration
+
+	mu     sync.Mutex
+	traces map[oteltrace.TraceID]*tailTrace
+	order  []oteltrace.TraceID // buffered traces from oldest to newest
+
+	exportCh chan []trace.ReadOnlySpan
+	done     chan struct{}
+	wg       sync.WaitGroup
+	once     sync.Once
+}
+
+func newTailProcessor(exporter trace.SpanExporter, o *samplerOptions) *tailProcessor {
+	p := &tailProcessor{
+		exporter:      exporter,
+		keepErrors:    o.keepErrors,
+		slowThreshold: o.slowThreshold,
+		maxTraces:     o.maxTraces,
+		traceTimeout:  o.traceTimeout,
+		traces:        make(map[oteltrace.TraceID]*tailTrace),
+		exportCh:      make(chan []trace.ReadOnlySpan, 100),
+		done:          make(chan struct{}),
+	}
+	p.wg.Add(1)
+	go p.run()
+	return p
+}
+
+func (p *tailProcessor) OnStart(context.Context, trace.ReadWriteSpan) {}
+
+func (p *tailProcessor) OnEnd(s trace.ReadOnlySpan) {
+	sc := s.SpanContext()
+	if sc.IsSampled() {
+		return // exported by the batch span processor
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	traceID := sc.TraceID()
+	t, ok := p.traces[traceID]
+	if !ok {
+		if len(p.order) >= p.maxTraces {
+			p.finish(p.order[0])
+		}
+		t = &tailTrace{created: time.Now()}
+		p.traces[traceID] = t
+		p.order = append(p.order, traceID)
+	}
+	t.spans = append(t.spans, s)
+	if (p.keepErrors && s.Status().Code == codes.Error) ||
+		(p.slowThreshold > 0 && s.EndTime().Sub(s.StartTime()) >= p.slowThreshold) {
+		t.keep = true
+	}
+
+	// the local root span ends
+	if parent := s.Parent(); !parent.IsValid() || parent.IsRemote() {
+		p.finish(traceID)
+	}
+}
+
+// finish exports the trace if it is kept, and removes it from the buffer, the caller must hold p.mu.
+func (p *tailProcessor) finish(traceID oteltrace.TraceID) {
+	t, ok := p.traces[traceID]
+	if !ok {
+		return
+	}
+	delete(p.traces, traceID)
+	for i, id := range p.order {
+		if id == traceID {
+			p.order = append(p.order[:i], p.order[i+1:]...)
+			break
+		}
+	}
+	if t.keep {
+		select {
+		case p.exportCh <- t.spans:
+		default: // drop if the exporter can not keep up
+		}
+	}
+}
+
+func (p *tailProcessor) run() {
+	defer p.wg.Done()
+	ticker := time.NewTicker(p.traceTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case spans := <-p.exportCh:
+			p.export(spans)
+		case <-ticker.C:
+			p.expire(time.Now().Add(-p.traceTimeout))
+		case <-p.done:
+			for {
+				select {
+				case spans := <-p.exportCh:
+					p.export(spans)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// expire finishes the traces buffered before deadline, e.g. the root span is not ended.
+func (p *tailProcessor) expire(deadline time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for len(p.order) > 0 {
+		t := p.traces[p.order[0]]
+		if t.created.After(deadline) {
+			return
+		}
+		p.finish(p.order[0])
+	}
+}
+
+func (p *tailProcessor) export(spans []trace.ReadOnlySpan) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_ = p.exporter.ExportSpans(ctx, spans)
+}
+
+func (p *tailProcessor) Shutdown(ctx context.Context) error {
+	p.once.Do(func() {
+		p.expire(time.Now()) // export kept traces that are not finished
+		close(p.done)
+	})
+	finished := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *tailProcessor) ForceFlush(context.Context) error {
+	return nil
+}
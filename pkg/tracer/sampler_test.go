@@ -0,0 +1,165 @@
+package tracer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"golang.org/x/time/rate"
+)
+
+// spansExporter keeps the exported spans after shutdown
+type spansExporter struct {
+	*tracetest.InMemoryExporter
+}
+
+func newSpansExporter() *spansExporter {
+	return &spansExporter{tracetest.NewInMemoryExporter()}
+}
+
+func (e *spansExporter) Shutdown(context.Context) error {
+	return nil
+}
+
+func TestInitWithSampler_keepErrorsAndSlow(t *testing.T) {
+	exporter := newSpansExporter()
+	InitWithSampler(exporter, NewResource(), 0, WithKeepErrors(true), WithKeepSlow(50*time.Millisecond))
+	tracer := GetProvider().Tracer("test")
+
+	// normal trace is dropped
+	ctx, root := tracer.Start(context.Background(), "/api/v1/ok")
+	_, child := tracer.Start(ctx, "query")
+	child.End()
+	root.End()
+
+	// trace with an error in child span is kept
+	ctx, root = tracer.Start(context.Background(), "/api/v1/error")
+	_, child = tracer.Start(ctx, "query")
+	child.RecordError(errors.New("db error"))
+	child.SetStatus(codes.Error, "db error")
+	child.End()
+	root.End()
+
+	// slow trace is kept
+	_, root = tracer.Start(context.Background(), "/api/v1/slow")
+	time.Sleep(60 * time.Millisecond)
+	root.End()
+
+	assert.NoError(t, Close(context.Background()))
+	var names []string
+	for _, s := range exporter.GetSpans() {
+		names = append(names, s.Name)
+	}
+	assert.ElementsMatch(t, []string{"query", "/api/v1/error", "/api/v1/slow"}, names)
+}
+
+func TestInitWithSampler_routeRateLimits(t *testing.T) {
+	exporter := newSpansExporter()
+	InitWithSampler(exporter, NewResource(), 0, WithRouteRateLimits(map[string]float64{"/api/v1/user": 2, "*": 1}))
+	tracer := GetProvider().Tracer("test")
+
+	for i := 0; i < 10; i++ {
+		_, span := tracer.Start(context.Background(), "/api/v1/user")
+		span.End()
+		_, span = tracer.Start(context.Background(), "/api/v1/order")
+		span.End()
+	}
+
+	assert.NoError(t, Close(context.Background()))
+	counts := map[string]int{}
+	for _, s := range exporter.GetSpans() {
+		counts[s.Name]++
+	}
+	assert.Equal(t, 2, counts["/api/v1/user"])
+	assert.Equal(t, 1, counts["/api/v1/order"])
+}
+
+func Test_headSampler_limiters(t *testing.T) {
+	hs := &headSampler{limits: map[string]float64{"/api/v1/user": 2, "*": 1}, maxLimiters: 3, overflow: newRouteLimiter(1)}
+	userLimiter := hs.limiter("/api/v1/user")
+	assert.NotNil(t, userLimiter)
+	assert.Same(t, userLimiter, hs.limiter("/api/v1/user"))
+
+	// the routes matched by "*" have their own limiters until the maximum
+	var limiters []*rate.Limiter
+	for i := 0; i < 3; i++ {
+		limiter := hs.limiter(fmt.Sprintf("/api/v1/order/%d", i))
+		assert.NotSame(t, hs.overflow, limiter)
+		limiters = append(limiters, limiter)
+	}
+	assert.Same(t, limiters[0], hs.limiter("/api/v1/order/0"))
+
+	// the routes beyond the maximum share the overflow limiter
+	for i := 3; i < 100; i++ {
+		assert.Same(t, hs.overflow, hs.limiter(fmt.Sprintf("/api/v1/order/%d", i)))
+	}
+	assert.Equal(t, 3, hs.numLimiters)
+	n := 0
+	hs.limiters.Range(func(_, _ interface{}) bool {
+		n++
+		return true
+	})
+	assert.Equal(t, 4, n)
+	assert.Equal(t, 1, countAllowed(hs.limiter("/api/v1/order/10"), 10)+countAllowed(hs.limiter("/api/v1/order/11"), 10))
+
+	// the routes that are configured always have their own limiters
+	hs.limits["/api/v1/product"] = 1
+	assert.NotSame(t, hs.overflow, hs.limiter("/api/v1/product"))
+
+	// no limiter without "*"
+	hs = &headSampler{limits: map[string]float64{"/api/v1/user": 2}, maxLimiters: 3}
+	assert.Nil(t, hs.limiter("/api/v1/order"))
+}
+
+func countAllowed(limiter *rate.Limiter, n int) int {
+	allowed := 0
+	for i := 0; i < n; i++ {
+		if limiter.Allow() {
+			allowed++
+		}
+	}
+	return allowed
+}
+
+func Test_tailProcessor_buffer(t *testing.T) {
+	exporter := newSpansExporter()
+	InitWithSampler(exporter, NewResource(), 0, WithKeepErrors(true), WithTailBuffer(1, 20*time.Millisecond))
+	tracer := GetProvider().Tracer("test")
+
+	// the root span is not ended, the child span with an error is exported after timeout
+	ctx, root := tracer.Start(context.Background(), "/api/v1/pending")
+	_, child := tracer.Start(ctx, "query")
+	child.SetStatus(codes.Error, "db error")
+	child.End()
+	time.Sleep(100 * time.Millisecond)
+	assert.Len(t, exporter.GetSpans(), 1)
+	root.End()
+
+	// the oldest trace is finished when the buffer is full
+	ctx1, root1 := tracer.Start(context.Background(), "/api/v1/first")
+	_, child1 := tracer.Start(ctx1, "query1")
+	child1.SetStatus(codes.Error, "db error")
+	child1.End()
+	ctx2, root2 := tracer.Start(context.Background(), "/api/v1/second")
+	_, child2 := tracer.Start(ctx2, "query2")
+	child2.End()
+	root1.End()
+	root2.End()
+
+	assert.NoError(t, Close(context.Background()))
+	assert.Len(t, exporter.GetSpans(), 2) // query and query1
+}
+
+func TestInitWithConfigSampling(t *testing.T) {
+	InitWithConfig("foo", "dev", "v1.0.0", "127.0.0.1", "6831", 0.5,
+		WithExporter(ExporterConsole),
+		WithSampling(WithKeepErrors(true), WithRouteRateLimits(map[string]float64{"*": 10})),
+	)
+	assert.NotNil(t, GetProvider())
+	_ = Close(context.Background())
+}
@@ -3,6 +3,8 @@ package tracer
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/propagation"
@@ -24,11 +26,28 @@ func Init(exporter trace.SpanExporter, res *resource.Resource, fractions ...floa
 		}
 	}
 
-	tp = trace.NewTracerProvider(
+	InitWithSampler(exporter, res, fraction)
+}
+
+// InitWithSampler Initialize tracer with sampling options, fraction is the sampling rate of traces, the traces that
+// are not sampled are kept by WithKeepErrors or WithKeepSlow, and routes are rate limited by WithRouteRateLimits.
+func InitWithSampler(exporter trace.SpanExporter, res *resource.Resource, fraction float64, opts ...SamplerOption) {
+	o := defaultSamplerOptions()
+	o.apply(opts...)
+
+	var tpOpts []trace.TracerProviderOption
+	if o.tailEnabled() {
+		// the exporter is shared by the tail processor and the batch span processor
+		exporter = &lockedExporter{SpanExporter: exporter}
+		tpOpts = append(tpOpts, trace.WithSpanProcessor(newTailProcessor(exporter, o)))
+	}
+	tpOpts = append(tpOpts,
 		trace.WithBatcher(exporter),
 		trace.WithResource(res),
-		trace.WithSampler(trace.ParentBased(trace.TraceIDRatioBased(fraction))), // sampling rate
+		trace.WithSampler(newHeadSampler(fraction, o)), // sampling rate
 	)
+
+	tp = trace.NewTracerProvider(tpOpts...)
 	// register the TracerProvider as global so that any future imports of package go.opentelemetry.io/otel/trace will use it by default.
 	otel.SetTracerProvider(tp)
 	// propagation of context across processes
@@ -43,10 +62,62 @@ func Close(ctx context.Context) error {
 	return tp.Shutdown(ctx)
 }
 
+// exporter types of InitWithConfig
+const (
+	ExporterJaeger  = "jaeger"
+	ExporterOTLP    = "otlp"
+	ExporterConsole = "console"
+)
+
+// ConfigOption set configOptions of InitWithConfig.
+type ConfigOption func(*configOptions)
+
+type configOptions struct {
+	exporter       string
+	otlpProtocol   string
+	otlpEndpoint   string
+	otlpOptions    []OTLPOption
+	samplerOptions []SamplerOption
+}
+
+func (o *configOptions) apply(opts ...ConfigOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WithExporter set exporter type, jaeger, otlp or console, if empty, otlp is used when
+// the otlp endpoint is set, otherwise jaeger agent is used.
+func WithExporter(exporter string) ConfigOption {
+	return func(o *configOptions) {
+		o.exporter = exporter
+	}
+}
+
+// WithOTLP set the OTLP collector, used if the exporter type is otlp, see NewOTLPExporter.
+func WithOTLP(protocol string, endpoint string, opts ...OTLPOption) ConfigOption {
+	return func(o *configOptions) {
+		o.otlpProtocol = protocol
+		o.otlpEndpoint = endpoint
+		o.otlpOptions = opts
+	}
+}
+
+// WithSampling set the sampling options, such as WithKeepErrors, WithKeepSlow and WithRouteRateLimits.
+func WithSampling(opts ...SamplerOption) ConfigOption {
+	return func(o *configOptions) {
+		o.samplerOptions = opts
+	}
+}
+
 // InitWithConfig Initialize tracer according to configuration, fraction is fraction, default is 1.0, value >= 1.0 means all links are sampled,
-// value <= 0 means all are not sampled, 0 < value < 1 only samples percentage
+// value <= 0 means all are not sampled, 0 < value < 1 only samples percentage, the exporter is jaeger agent by default,
+// use WithExporter and WithOTLP to export to an OTLP collector.
 func InitWithConfig(appName string, appEnv string, appVersion string,
-	jaegerAgentHost string, jaegerAgentPort string, jaegerSamplingRate float64) {
+	jaegerAgentHost string, jaegerAgentPort string, jaegerSamplingRate float64, opts ...ConfigOption) {
+	o := &configOptions{}
+	o.apply(opts...)
+
 	res := NewResource(
 		WithServiceName(appName),
 		WithEnvironment(appEnv),
@@ -54,16 +125,44 @@ func InitWithConfig(appName string, appEnv string, appVersion string,
 	)
 
 	// initializing tracing
-	exporter, err := NewJaegerAgentExporter(jaegerAgentHost, jaegerAgentPort)
+	exporter, err := o.newExporter(jaegerAgentHost, jaegerAgentPort)
 	if err != nil {
 		panic("init trace error:" + err.Error())
 	}
 
-	Init(exporter, res, jaegerSamplingRate)
+	if jaegerSamplingRate <= 0 {
+		jaegerSamplingRate = 0
+	} else if jaegerSamplingRate > 1 {
+		jaegerSamplingRate = 1
+	}
+	InitWithSampler(exporter, res, jaegerSamplingRate, o.samplerOptions...)
 
 	SetTraceName(appName)
 }
 
+func (o *configOptions) newExporter(jaegerAgentHost string, jaegerAgentPort string) (trace.SpanExporter, error) {
+	exporter := strings.ToLower(o.exporter)
+	if exporter == "" {
+		exporter = ExporterJaeger
+		if o.otlpEndpoint != "" {
+			exporter = ExporterOTLP
+		}
+	}
+
+	switch exporter {
+	case ExporterJaeger:
+		return NewJaegerAgentExporter(jaegerAgentHost, jaegerAgentPort)
+	case ExporterOTLP:
+		if o.otlpEndpoint == "" {
+			return nil, fmt.Errorf("otlp endpoint is empty")
+		}
+		return NewOTLPExporter(o.otlpProtocol, o.otlpEndpoint, o.otlpOptions...)
+	case ExporterConsole:
+		return NewConsoleExporter()
+	}
+	return nil, fmt.Errorf("unsupported exporter '%s', only jaeger, otlp and console are supported", o.exporter)
+}
+
 // GetProvider get tracer provider
 func GetProvider() *trace.TracerProvider {
 	if tp == nil {
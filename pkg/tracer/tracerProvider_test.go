@@ -34,3 +34,12 @@ func TestInitWithConfig(t *testing.T) {
 	InitWithConfig("foo", "dev", "v1.0.0",
 		"127.0.0.1", "6831", 1.0)
 }
+
+func TestInitWithConfigOTLP(t *testing.T) {
+	InitWithConfig("foo", "dev", "v1.0.0",
+		"127.0.0.1", "6831", 1.0,
+		WithExporter(ExporterOTLP),
+		WithOTLP(OTLPProtocolGRPC, "127.0.0.1:4317", WithOTLPInsecure(true)),
+	)
+	_ = Close(context.Background())
+}
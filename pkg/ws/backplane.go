@@ -0,0 +1,87 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Backplane delivers the messages of a hub to the hubs of other instances.
+type Backplane interface {
+	// Publish publishes the message to all instances, including the current instance.
+	Publish(ctx context.Context, data []byte) error
+	// Subscribe receives the messages published by all instances until ctx is done or the backplane is closed.
+	Subscribe(ctx context.Context, handler func(data []byte)) error
+	// Close closes the backplane.
+	Close() error
+}
+
+// RedisBackplane is a backplane based on redis pub/sub.
+type RedisBackplane struct {
+	cli     redis.UniversalClient
+	channel string
+
+	mu     sync.Mutex
+	pubsub *redis.PubSub
+}
+
+// NewRedisBackplane creates a backplane based on redis pub/sub, all hubs that use the same channel share messages.
+func NewRedisBackplane(cli redis.UniversalClient, channel string) *RedisBackplane {
+	if channel == "" {
+		channel = "sponge:ws:hub"
+	}
+	return &RedisBackplane{cli: cli, channel: channel}
+}
+
+// Publish publishes the message to the channel.
+func (b *RedisBackplane) Publish(ctx context.Context, data []byte) error {
+	return b.cli.Publish(ctx, b.channel, data).Err()
+}
+
+// Subscribe subscribes the channel, handler is called in a separate goroutine.
+func (b *RedisBackplane) Subscribe(ctx context.Context, handler func(data []byte)) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.pubsub != nil {
+		return errors.New("redis backplane is already subscribed")
+	}
+
+	pubsub := b.cli.Subscribe(ctx, b.channel)
+	// wait for the subscription to be confirmed, so that no message is missed after returning
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return err
+	}
+	b.pubsub = pubsub
+
+	go func() {
+		ch := pubsub.Channel()
+		for {
+			select {
+			case msg, ok := <-ch:
+				if !ok {
+					return
+				}
+				handler([]byte(msg.Payload))
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Close closes the subscription, the redis client is not closed.
+func (b *RedisBackplane) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.pubsub == nil {
+		return nil
+	}
+	err := b.pubsub.Close()
+	b.pubsub = nil
+	return err
+}
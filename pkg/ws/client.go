@@ -2,8 +2,12 @@ package ws
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math/rand"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -21,13 +25,23 @@ type clientOptions struct {
 	dialer           *websocket.Dialer
 	requestHeader    http.Header
 	pingDialInterval time.Duration
+	pingWriteTimeout time.Duration
+	pongTimeout      time.Duration
+
+	reconnectInitialDelay time.Duration
+	reconnectMaxDelay     time.Duration
+	reconnectMaxAttempts  int
+	reconnectHooks        []func(c *Client) error
 
 	zapLogger *zap.Logger
 }
 
 func defaultClientOptions() *clientOptions {
 	return &clientOptions{
-		dialer: websocket.DefaultDialer,
+		dialer:                websocket.DefaultDialer,
+		pingWriteTimeout:      5 * time.Second,
+		reconnectInitialDelay: time.Second,
+		reconnectMaxDelay:     32 * time.Second,
 	}
 }
 
@@ -58,6 +72,54 @@ func WithPing(interval time.Duration) ClientOption {
 	}
 }
 
+// WithPongTimeout sets the timeout for receiving a pong message after sending a ping message,
+// if timeout, the connection is considered dead and reconnected, it is effective when WithPing is set
+// and the messages of the connection are read, because the pong handler is called when reading messages.
+func WithPongTimeout(timeout time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		o.pongTimeout = timeout
+	}
+}
+
+// WithPingWriteTimeout sets the timeout for writing a ping message, default is 5s.
+func WithPingWriteTimeout(timeout time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		if timeout > 0 {
+			o.pingWriteTimeout = timeout
+		}
+	}
+}
+
+// WithReconnectBackoff sets the exponential backoff delay of reconnection, the delay starts from initialDelay
+// and doubles after each failure until maxDelay, a random jitter is added to the delay, default is 1s and 32s.
+func WithReconnectBackoff(initialDelay time.Duration, maxDelay time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		if initialDelay > 0 {
+			o.reconnectInitialDelay = initialDelay
+		}
+		if maxDelay > 0 {
+			o.reconnectMaxDelay = maxDelay
+		}
+	}
+}
+
+// WithMaxReconnectAttempts sets the maximum number of reconnection attempts, 0 means unlimited, default is 0.
+func WithMaxReconnectAttempts(attempts int) ClientOption {
+	return func(o *clientOptions) {
+		o.reconnectMaxAttempts = attempts
+	}
+}
+
+// WithOnReconnect adds a hook that is called after reconnecting successfully, e.g. resubscribe the topics,
+// if the hook returns an error, the connection is closed and reconnected again.
+func WithOnReconnect(fn func(c *Client) error) ClientOption {
+	return func(o *clientOptions) {
+		if fn != nil {
+			o.reconnectHooks = append(o.reconnectHooks, fn)
+		}
+	}
+}
+
 // WithClientLogger sets the logger for the client.
 func WithClientLogger(l *zap.Logger) ClientOption {
 	return func(o *clientOptions) {
@@ -74,12 +136,24 @@ type Client struct {
 	dialer        *websocket.Dialer
 	requestHeader http.Header
 	url           string
-	conn          *websocket.Conn
 
-	pingInterval time.Duration
-	ctx          context.Context
-	cancel       context.CancelFunc
-	zapLogger    *zap.Logger
+	mu   sync.RWMutex
+	conn *websocket.Conn
+
+	pingInterval     time.Duration
+	pingWriteTimeout time.Duration
+	pongTimeout      time.Duration
+	lastPong         atomic.Int64 // unix nano
+
+	reconnectInitialDelay time.Duration
+	reconnectMaxDelay     time.Duration
+	reconnectMaxAttempts  int
+	reconnectHooks        []func(c *Client) error
+	reconnectMu           sync.Mutex
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	zapLogger *zap.Logger
 }
 
 // NewClient creates a new client.
@@ -96,10 +170,19 @@ func NewClient(url string, opts ...ClientOption) (*Client, error) {
 		url:           url,
 		dialer:        o.dialer,
 		requestHeader: o.requestHeader,
-		pingInterval:  o.pingDialInterval,
-		ctx:           ctx,
-		cancel:        cancel,
-		zapLogger:     o.zapLogger,
+
+		pingInterval:     o.pingDialInterval,
+		pingWriteTimeout: o.pingWriteTimeout,
+		pongTimeout:      o.pongTimeout,
+
+		reconnectInitialDelay: o.reconnectInitialDelay,
+		reconnectMaxDelay:     o.reconnectMaxDelay,
+		reconnectMaxAttempts:  o.reconnectMaxAttempts,
+		reconnectHooks:        o.reconnectHooks,
+
+		ctx:       ctx,
+		cancel:    cancel,
+		zapLogger: o.zapLogger,
 	}
 
 	err := c.connect()
@@ -111,6 +194,9 @@ func NewClient(url string, opts ...ClientOption) (*Client, error) {
 	if c.pingInterval > 0 {
 		c.ping()
 		fields = append(fields, zap.String("auto ping interval", fmt.Sprintf("%vs", c.pingInterval.Seconds())))
+		if c.pongTimeout > 0 {
+			fields = append(fields, zap.String("pong timeout", fmt.Sprintf("%vs", c.pongTimeout.Seconds())))
+		}
 	}
 
 	c.zapLogger.Info("connect websocket server success", fields...)
@@ -118,9 +204,14 @@ func NewClient(url string, opts ...ClientOption) (*Client, error) {
 	return c, nil
 }
 
-// GetConn returns the connection of the client.
+// GetConn returns the connection of the client, the connection is replaced after reconnecting,
+// so get it again after a read or write error.
 func (c *Client) GetConn() *websocket.Conn {
-	if c.conn == nil {
+	c.mu.RLock()
+	conn := c.conn
+	c.mu.RUnlock()
+
+	if conn == nil {
 		defer func() {
 			if e := recover(); e != nil {
 				c.zapLogger.Warn("connect websocket server error", zap.Any("err", e))
@@ -130,9 +221,12 @@ func (c *Client) GetConn() *websocket.Conn {
 		if err != nil {
 			panic(err)
 		}
+		c.mu.RLock()
+		conn = c.conn
+		c.mu.RUnlock()
 	}
 
-	return c.conn
+	return conn
 }
 
 // connect the websocket server.
@@ -141,32 +235,76 @@ func (c *Client) connect() error {
 	if err != nil {
 		return err
 	}
+	c.lastPong.Store(time.Now().UnixNano())
+	conn.SetPongHandler(func(string) error {
+		c.lastPong.Store(time.Now().UnixNano())
+		return nil
+	})
+
+	c.mu.Lock()
+	old := c.conn
 	c.conn = conn
+	c.mu.Unlock()
+	if old != nil {
+		_ = old.Close()
+	}
 	return nil
 }
 
-// TryReconnect tries to reconnect the websocket server.
+// TryReconnect tries to reconnect the websocket server with exponential backoff,
+// the hooks of WithOnReconnect are called after reconnecting successfully.
 func (c *Client) TryReconnect() error {
-	delay := 1 * time.Second
-	maxDelay := 32 * time.Second
-	for {
+	c.reconnectMu.Lock()
+	defer c.reconnectMu.Unlock()
+
+	delay := c.reconnectInitialDelay
+	for attempt := 1; ; attempt++ {
+		if c.reconnectMaxAttempts > 0 && attempt > c.reconnectMaxAttempts {
+			return fmt.Errorf("reconnect websocket server failed after %d attempts", c.reconnectMaxAttempts)
+		}
+
 		select {
 		case <-c.ctx.Done():
 			return c.ctx.Err()
-		case <-time.After(delay):
-			if err := c.connect(); err != nil {
-				if delay >= maxDelay {
-					delay = maxDelay
-					c.zapLogger.Warn("reconnect websocket server error", zap.Error(err), zap.String("server", c.url))
-					continue
-				}
-				delay *= 2
-				continue
+		case <-time.After(jitter(delay)):
+		}
+
+		err := c.connect()
+		if err == nil {
+			if err = c.runReconnectHooks(); err != nil {
+				_ = c.GetConn().Close()
 			}
-			c.zapLogger.Warn("reconnect websocket server success", zap.String("server", c.url))
-			return nil
+		}
+		if err != nil {
+			c.zapLogger.Warn("reconnect websocket server error", zap.Error(err),
+				zap.String("server", c.url), zap.Int("attempt", attempt))
+			if delay *= 2; delay > c.reconnectMaxDelay {
+				delay = c.reconnectMaxDelay
+			}
+			continue
+		}
+
+		c.zapLogger.Warn("reconnect websocket server success", zap.String("server", c.url), zap.Int("attempt", attempt))
+		return nil
+	}
+}
+
+func (c *Client) runReconnectHooks() error {
+	for _, fn := range c.reconnectHooks {
+		if err := fn(c); err != nil {
+			return errors.Join(errors.New("reconnect hook error"), err)
 		}
 	}
+	return nil
+}
+
+// jitter returns a random duration in [d/2, d).
+func jitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half))) //nolint
 }
 
 // ping websocket server, try to reconnect if connection failed.
@@ -191,7 +329,14 @@ func (c *Client) ping() {
 		for {
 			select {
 			case <-ticker.C:
-				if err := c.conn.WriteControl(websocket.PingMessage, pingData, time.Now().Add(5*time.Second)); err != nil {
+				conn := c.GetConn()
+				if c.pongTimeout > 0 && time.Since(time.Unix(0, c.lastPong.Load())) > c.pongTimeout+c.pingInterval {
+					c.zapLogger.Warn("no pong message received from server, the connection is dead",
+						zap.String("pong timeout", fmt.Sprintf("%vs", c.pongTimeout.Seconds())))
+					_ = conn.Close()
+					return
+				}
+				if err := conn.WriteControl(websocket.PingMessage, pingData, time.Now().Add(c.pingWriteTimeout)); err != nil {
 					c.zapLogger.Warn("ping server error", zap.Error(err))
 					return
 				}
@@ -215,8 +360,11 @@ func (c *Client) Close() error {
 	if c.cancel != nil {
 		c.cancel()
 	}
-	if c.conn != nil {
-		return c.conn.Close()
+	c.mu.RLock()
+	conn := c.conn
+	c.mu.RUnlock()
+	if conn != nil {
+		return conn.Close()
 	}
 
 	return nil
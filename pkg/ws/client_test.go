@@ -0,0 +1,116 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestWsServer(t *testing.T, loopFn LoopFn) string {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = NewServer(w, r, loopFn).Run(r.Context())
+	}))
+	t.Cleanup(srv.Close)
+	return "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+func TestClientReconnect(t *testing.T) {
+	var connections atomic.Int32
+	url := newTestWsServer(t, func(ctx context.Context, conn *Conn) {
+		// the first connection is closed by the server
+		if connections.Add(1) == 1 {
+			return
+		}
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+
+	var hookCalls atomic.Int32
+	c, err := NewClient(url,
+		WithPing(20*time.Millisecond),
+		WithReconnectBackoff(10*time.Millisecond, 50*time.Millisecond),
+		WithOnReconnect(func(c *Client) error {
+			if hookCalls.Add(1) == 1 {
+				return errors.New("resubscribe failed") // reconnect again
+			}
+			return c.GetConn().WriteMessage(1, []byte("subscribe"))
+		}),
+	)
+	require.NoError(t, err)
+	defer c.Close()
+
+	go func() {
+		for c.GetCtx().Err() == nil {
+			if _, _, err := c.GetConn().ReadMessage(); err != nil {
+				time.Sleep(10 * time.Millisecond)
+			}
+		}
+	}()
+
+	assert.Eventually(t, func() bool { return hookCalls.Load() >= 2 }, 3*time.Second, 10*time.Millisecond)
+	assert.GreaterOrEqual(t, connections.Load(), int32(3))
+}
+
+func TestClientPongTimeout(t *testing.T) {
+	var connections atomic.Int32
+	url := newTestWsServer(t, func(ctx context.Context, conn *Conn) {
+		connections.Add(1)
+		conn.SetPingHandler(func(string) error { return nil }) // never reply pong
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+
+	var reconnected atomic.Bool
+	c, err := NewClient(url,
+		WithPing(20*time.Millisecond),
+		WithPongTimeout(50*time.Millisecond),
+		WithReconnectBackoff(10*time.Millisecond, 10*time.Millisecond),
+		WithOnReconnect(func(c *Client) error {
+			reconnected.Store(true)
+			return nil
+		}),
+	)
+	require.NoError(t, err)
+	defer c.Close()
+
+	go func() {
+		for c.GetCtx().Err() == nil {
+			if _, _, err := c.GetConn().ReadMessage(); err != nil {
+				time.Sleep(10 * time.Millisecond)
+			}
+		}
+	}()
+
+	assert.Eventually(t, reconnected.Load, 3*time.Second, 10*time.Millisecond)
+	assert.GreaterOrEqual(t, connections.Load(), int32(2))
+}
+
+func TestClientMaxReconnectAttempts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = NewServer(w, r, func(ctx context.Context, conn *Conn) {}).Run(r.Context())
+	}))
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	c, err := NewClient(url, WithReconnectBackoff(time.Millisecond, 5*time.Millisecond), WithMaxReconnectAttempts(3))
+	require.NoError(t, err)
+	defer c.Close()
+	srv.Close()
+
+	err = c.TryReconnect()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "after 3 attempts")
+}
@@ -0,0 +1,568 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+
+	"github.com/go-dev-frame/sponge/pkg/krand"
+)
+
+var (
+	// ErrSessionClosed is returned when sending messages to a closed session.
+	ErrSessionClosed = errors.New("websocket session is closed")
+	// ErrSendQueueFull is returned when the message is dropped because the send queue of the session is full.
+	ErrSendQueueFull = errors.New("websocket send queue is full")
+)
+
+// SendQueuePolicy is the policy when the send queue of a session is full.
+type SendQueuePolicy int
+
+const (
+	// SendQueueClose closes the session, the client is too slow to receive messages, it is the default policy.
+	SendQueueClose SendQueuePolicy = iota
+	// SendQueueDropNewest drops the message that is being sent, and ErrSendQueueFull is returned.
+	SendQueueDropNewest
+	// SendQueueDropOldest drops the oldest message in the queue to make room for the message that is being sent.
+	SendQueueDropOldest
+)
+
+// MessageHandler is the function that is called for each message received from a session.
+type MessageHandler func(ctx context.Context, s *Session, messageType int, message []byte)
+
+// PresenceEvent is the event of a user going online or offline, a user is online if it has at least one session.
+type PresenceEvent struct {
+	UserID string
+	Online bool
+}
+
+// HubOption is a functional option for the Hub.
+type HubOption func(*hubOptions)
+
+type hubOptions struct {
+	backplane       Backplane
+	presenceHandler func(event PresenceEvent)
+	sendQueueSize   int
+	sendQueuePolicy SendQueuePolicy
+	writeTimeout    time.Duration
+	zapLogger       *zap.Logger
+}
+
+func defaultHubOptions() *hubOptions {
+	return &hubOptions{
+		sendQueueSize: 256,
+		writeTimeout:  10 * time.Second,
+	}
+}
+
+func (o *hubOptions) apply(opts ...HubOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WithHubBackplane sets the backplane for delivering messages between hub instances,
+// e.g. NewRedisBackplane, it is required for multi-instance deployments.
+func WithHubBackplane(b Backplane) HubOption {
+	return func(o *hubOptions) {
+		o.backplane = b
+	}
+}
+
+// WithPresenceHandler sets the function that is called when a user goes online or offline in this instance.
+func WithPresenceHandler(fn func(event PresenceEvent)) HubOption {
+	return func(o *hubOptions) {
+		o.presenceHandler = fn
+	}
+}
+
+// WithHubSendQueue sets the size of the send queue of each session and the policy when the queue is full,
+// it limits the memory used by slow clients, default size is 256, default policy is SendQueueClose.
+func WithHubSendQueue(size int, policy SendQueuePolicy) HubOption {
+	return func(o *hubOptions) {
+		if size > 0 {
+			o.sendQueueSize = size
+		}
+		o.sendQueuePolicy = policy
+	}
+}
+
+// WithHubWriteTimeout sets the timeout for writing a message to a connection, default is 10s.
+func WithHubWriteTimeout(d time.Duration) HubOption {
+	return func(o *hubOptions) {
+		if d > 0 {
+			o.writeTimeout = d
+		}
+	}
+}
+
+// WithHubLogger sets the logger for the hub.
+func WithHubLogger(l *zap.Logger) HubOption {
+	return func(o *hubOptions) {
+		if l != nil {
+			o.zapLogger = l
+		}
+	}
+}
+
+// --------------------------------------------------------------------------------------
+
+// Hub manages websocket sessions, supports rooms, broadcast, direct messages to users and presence tracking,
+// messages are delivered to the sessions of other instances by the backplane.
+type Hub struct {
+	id string // instance id, used to ignore the messages published by itself
+
+	mu       sync.RWMutex
+	sessions map[string]*Session            // session id -> session
+	users    map[string]map[string]*Session // user id -> sessions
+	rooms    map[string]map[string]*Session // room -> sessions
+
+	backplane       Backplane
+	presenceHandler func(event PresenceEvent)
+	sendQueueSize   int
+	sendQueuePolicy SendQueuePolicy
+	writeTimeout    time.Duration
+	zapLogger       *zap.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewHub creates a new hub.
+func NewHub(opts ...HubOption) (*Hub, error) {
+	o := defaultHubOptions()
+	o.apply(opts...)
+	if o.zapLogger == nil {
+		o.zapLogger, _ = zap.NewProduction()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	h := &Hub{
+		id:              krand.NewStringID(),
+		sessions:        make(map[string]*Session),
+		users:           make(map[string]map[string]*Session),
+		rooms:           make(map[string]map[string]*Session),
+		backplane:       o.backplane,
+		presenceHandler: o.presenceHandler,
+		sendQueueSize:   o.sendQueueSize,
+		sendQueuePolicy: o.sendQueuePolicy,
+		writeTimeout:    o.writeTimeout,
+		zapLogger:       o.zapLogger,
+		ctx:             ctx,
+		cancel:          cancel,
+	}
+
+	if h.backplane != nil {
+		if err := h.backplane.Subscribe(ctx, h.receiveEnvelope); err != nil {
+			cancel()
+			return nil, err
+		}
+	}
+
+	return h, nil
+}
+
+// Serve upgrades the http request to a websocket session of the user, and calls handler for each received message,
+// it blocks until the connection is closed, userID can be empty for anonymous users.
+func (h *Hub) Serve(w http.ResponseWriter, r *http.Request, userID string, handler MessageHandler, opts ...ServerOption) error {
+	loopFn := func(ctx context.Context, conn *Conn) {
+		s := h.register(conn, userID)
+		defer h.unregister(s)
+
+		for {
+			messageType, message, err := conn.ReadMessage()
+			if err != nil {
+				if !IsClientClose(err) && !errors.Is(err, net.ErrClosed) {
+					h.zapLogger.Warn("read websocket message error", zap.Error(err), zap.String("session", s.id))
+				}
+				return
+			}
+			if handler != nil {
+				handler(ctx, s, messageType, message)
+			}
+		}
+	}
+
+	return NewServer(w, r, loopFn, opts...).Run(r.Context())
+}
+
+func (h *Hub) register(conn *Conn, userID string) *Session {
+	s := &Session{
+		id:     krand.NewStringID(),
+		userID: userID,
+		conn:   conn,
+		hub:    h,
+		rooms:  make(map[string]struct{}),
+		send:   make(chan outMessage, h.sendQueueSize),
+		done:   make(chan struct{}),
+	}
+	go s.writeLoop()
+
+	h.mu.Lock()
+	h.sessions[s.id] = s
+	online := false
+	if userID != "" {
+		if h.users[userID] == nil {
+			h.users[userID] = make(map[string]*Session)
+			online = true
+		}
+		h.users[userID][s.id] = s
+	}
+	h.mu.Unlock()
+
+	if online && h.presenceHandler != nil {
+		h.presenceHandler(PresenceEvent{UserID: userID, Online: true})
+	}
+	return s
+}
+
+func (h *Hub) unregister(s *Session) {
+	s.close()
+
+	h.mu.Lock()
+	delete(h.sessions, s.id)
+	s.mu.Lock()
+	for room := range s.rooms {
+		h.removeFromRoom(room, s)
+	}
+	s.rooms = make(map[string]struct{})
+	s.mu.Unlock()
+	offline := false
+	if s.userID != "" {
+		delete(h.users[s.userID], s.id)
+		if len(h.users[s.userID]) == 0 {
+			delete(h.users, s.userID)
+			offline = true
+		}
+	}
+	h.mu.Unlock()
+
+	if offline && h.presenceHandler != nil {
+		h.presenceHandler(PresenceEvent{UserID: s.userID, Online: false})
+	}
+}
+
+// removeFromRoom the caller must hold h.mu
+func (h *Hub) removeFromRoom(room string, s *Session) {
+	delete(h.rooms[room], s.id)
+	if len(h.rooms[room]) == 0 {
+		delete(h.rooms, room)
+	}
+}
+
+// Broadcast sends the message to all sessions of all instances.
+func (h *Hub) Broadcast(messageType int, data []byte) error {
+	return h.dispatch(envelope{Kind: kindBroadcast, MessageType: messageType, Data: data})
+}
+
+// BroadcastToRoom sends the message to all sessions in the room of all instances.
+func (h *Hub) BroadcastToRoom(room string, messageType int, data []byte) error {
+	return h.dispatch(envelope{Kind: kindRoom, Target: room, MessageType: messageType, Data: data})
+}
+
+// SendToUser sends the message to all sessions of the user of all instances.
+func (h *Hub) SendToUser(userID string, messageType int, data []byte) error {
+	return h.dispatch(envelope{Kind: kindUser, Target: userID, MessageType: messageType, Data: data})
+}
+
+// IsOnline returns whether the user has sessions in this instance.
+func (h *Hub) IsOnline(userID string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.users[userID]) > 0
+}
+
+// OnlineUsers returns the users that have sessions in this instance.
+func (h *Hub) OnlineUsers() []string {
+	h.mu.RLock()
+	users := make([]string, 0, len(h.users))
+	for userID := range h.users {
+		users = append(users, userID)
+	}
+	h.mu.RUnlock()
+	sort.Strings(users)
+	return users
+}
+
+// RoomMembers returns the users in the room of this instance, anonymous sessions are not included.
+func (h *Hub) RoomMembers(room string) []string {
+	h.mu.RLock()
+	seen := make(map[string]struct{})
+	for _, s := range h.rooms[room] {
+		if s.userID != "" {
+			seen[s.userID] = struct{}{}
+		}
+	}
+	h.mu.RUnlock()
+
+	users := make([]string, 0, len(seen))
+	for userID := range seen {
+		users = append(users, userID)
+	}
+	sort.Strings(users)
+	return users
+}
+
+// SessionCount returns the number of sessions in this instance.
+func (h *Hub) SessionCount() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.sessions)
+}
+
+// Close closes all sessions and the backplane.
+func (h *Hub) Close() error {
+	h.cancel()
+
+	h.mu.RLock()
+	sessions := make([]*Session, 0, len(h.sessions))
+	for _, s := range h.sessions {
+		sessions = append(sessions, s)
+	}
+	h.mu.RUnlock()
+	for _, s := range sessions {
+		_ = s.Close()
+	}
+
+	if h.backplane != nil {
+		return h.backplane.Close()
+	}
+	return nil
+}
+
+// dispatch delivers the message to local sessions, and publishes it to other instances.
+func (h *Hub) dispatch(e envelope) error {
+	h.deliver(e)
+
+	if h.backplane == nil {
+		return nil
+	}
+	e.Instance = h.id
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return h.backplane.Publish(h.ctx, data)
+}
+
+func (h *Hub) receiveEnvelope(data []byte) {
+	var e envelope
+	if err := json.Unmarshal(data, &e); err != nil {
+		h.zapLogger.Warn("unmarshal backplane message error", zap.Error(err))
+		return
+	}
+	if e.Instance == h.id {
+		return // already delivered locally
+	}
+	h.deliver(e)
+}
+
+func (h *Hub) deliver(e envelope) {
+	var sessions []*Session
+	h.mu.RLock()
+	switch e.Kind {
+	case kindBroadcast:
+		for _, s := range h.sessions {
+			sessions = append(sessions, s)
+		}
+	case kindRoom:
+		for _, s := range h.rooms[e.Target] {
+			sessions = append(sessions, s)
+		}
+	case kindUser:
+		for _, s := range h.users[e.Target] {
+			sessions = append(sessions, s)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, s := range sessions {
+		if err := s.SendMessage(e.MessageType, e.Data); err != nil &&
+			!errors.Is(err, ErrSessionClosed) && !errors.Is(err, ErrSendQueueFull) {
+			h.zapLogger.Warn("send websocket message error", zap.Error(err), zap.String("session", s.id))
+		}
+	}
+}
+
+const (
+	kindBroadcast = "broadcast"
+	kindRoom      = "room"
+	kindUser      = "user"
+)
+
+// envelope is the message delivered between hub instances.
+type envelope struct {
+	Instance    string `json:"instance"`
+	Kind        string `json:"kind"`
+	Target      string `json:"target,omitempty"`
+	MessageType int    `json:"messageType"`
+	Data        []byte `json:"data"`
+}
+
+// --------------------------------------------------------------------------------------
+
+type outMessage struct {
+	messageType int
+	data        []byte
+}
+
+// Session is a websocket connection managed by the hub, messages are written by a dedicated goroutine,
+// so that the methods of Session are safe for concurrent use.
+type Session struct {
+	id     string
+	userID string
+	conn   *Conn
+	hub    *Hub
+
+	mu    sync.Mutex
+	rooms map[string]struct{}
+
+	send      chan outMessage
+	dropped   atomic.Int64
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// ID returns the id of the session.
+func (s *Session) ID() string {
+	return s.id
+}
+
+// UserID returns the user id of the session.
+func (s *Session) UserID() string {
+	return s.userID
+}
+
+// Conn returns the websocket connection, messages should be sent by Send or SendMessage instead of writing to it directly.
+func (s *Session) Conn() *Conn {
+	return s.conn
+}
+
+// Join joins the session to the room.
+func (s *Session) Join(room string) {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	select {
+	case <-s.done:
+		return // the session is unregistered
+	default:
+	}
+
+	if s.hub.rooms[room] == nil {
+		s.hub.rooms[room] = make(map[string]*Session)
+	}
+	s.hub.rooms[room][s.id] = s
+	s.mu.Lock()
+	s.rooms[room] = struct{}{}
+	s.mu.Unlock()
+}
+
+// Leave removes the session from the room.
+func (s *Session) Leave(room string) {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.removeFromRoom(room, s)
+	s.mu.Lock()
+	delete(s.rooms, room)
+	s.mu.Unlock()
+}
+
+// Rooms returns the rooms that the session has joined.
+func (s *Session) Rooms() []string {
+	s.mu.Lock()
+	rooms := make([]string, 0, len(s.rooms))
+	for room := range s.rooms {
+		rooms = append(rooms, room)
+	}
+	s.mu.Unlock()
+	sort.Strings(rooms)
+	return rooms
+}
+
+// Send sends a text message to the session.
+func (s *Session) Send(data []byte) error {
+	return s.SendMessage(websocket.TextMessage, data)
+}
+
+// SendMessage sends a message to the session without blocking, if the send queue is full,
+// it is handled by the SendQueuePolicy of the hub.
+func (s *Session) SendMessage(messageType int, data []byte) error {
+	select {
+	case <-s.done:
+		return ErrSessionClosed
+	default:
+	}
+
+	msg := outMessage{messageType: messageType, data: data}
+	select {
+	case s.send <- msg:
+		return nil
+	default:
+	}
+
+	switch s.hub.sendQueuePolicy {
+	case SendQueueDropNewest:
+		s.dropped.Add(1)
+		return ErrSendQueueFull
+
+	case SendQueueDropOldest:
+		select {
+		case <-s.send:
+			s.dropped.Add(1)
+		default:
+		}
+		select {
+		case s.send <- msg:
+			return nil
+		default: // the queue is filled by other senders
+			s.dropped.Add(1)
+			return ErrSendQueueFull
+		}
+
+	default:
+		s.hub.zapLogger.Warn("websocket send queue is full, close the slow session", zap.String("session", s.id))
+		_ = s.Close()
+		return ErrSessionClosed
+	}
+}
+
+// DroppedCount returns the number of messages dropped because the send queue is full.
+func (s *Session) DroppedCount() int64 {
+	return s.dropped.Load()
+}
+
+// Close closes the session.
+func (s *Session) Close() error {
+	s.close()
+	return s.conn.Close()
+}
+
+func (s *Session) close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+}
+
+func (s *Session) writeLoop() {
+	for {
+		select {
+		case msg := <-s.send:
+			_ = s.conn.SetWriteDeadline(time.Now().Add(s.hub.writeTimeout))
+			if err := s.conn.WriteMessage(msg.messageType, msg.data); err != nil {
+				_ = s.Close()
+				return
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package ws
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gorilla/websocket"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newHubServer(t *testing.T, hub *Hub) *httptest.Server {
+	handler := func(ctx context.Context, s *Session, messageType int, message []byte) {
+		// message format: join:room, leave:room, room:text, user:text, all:text
+		cmd, arg, _ := strings.Cut(string(message), ":")
+		switch cmd {
+		case "join":
+			s.Join(arg)
+			_ = s.Send([]byte("joined"))
+		case "leave":
+			s.Leave(arg)
+			_ = s.Send([]byte("left"))
+		case "room":
+			_ = hub.BroadcastToRoom("chat", messageType, []byte(arg))
+		case "user":
+			_ = hub.SendToUser("bob", messageType, []byte(arg))
+		case "all":
+			_ = hub.Broadcast(messageType, []byte(arg))
+		}
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = hub.Serve(w, r, r.URL.Query().Get("user"), handler)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func dialHub(t *testing.T, srv *httptest.Server, user string) *websocket.Conn {
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "?user=" + user
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func readText(t *testing.T, conn *websocket.Conn) string {
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, message, err := conn.ReadMessage()
+	require.NoError(t, err)
+	return string(message)
+}
+
+func writeText(t *testing.T, conn *websocket.Conn, text string) {
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(text)))
+}
+
+func TestHub(t *testing.T) {
+	var mu sync.Mutex
+	var events []PresenceEvent
+	hub, err := NewHub(WithPresenceHandler(func(event PresenceEvent) {
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+	}))
+	require.NoError(t, err)
+	defer hub.Close()
+	srv := newHubServer(t, hub)
+
+	alice := dialHub(t, srv, "alice")
+	bob1 := dialHub(t, srv, "bob")
+	bob2 := dialHub(t, srv, "bob")
+	assert.Eventually(t, func() bool { return hub.SessionCount() == 3 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"alice", "bob"}, hub.OnlineUsers())
+	assert.True(t, hub.IsOnline("bob"))
+
+	// room
+	writeText(t, alice, "join:chat")
+	assert.Equal(t, "joined", readText(t, alice))
+	writeText(t, bob1, "join:chat")
+	assert.Equal(t, "joined", readText(t, bob1))
+	assert.Equal(t, []string{"alice", "bob"}, hub.RoomMembers("chat"))
+	writeText(t, alice, "room:hello")
+	assert.Equal(t, "hello", readText(t, alice))
+	assert.Equal(t, "hello", readText(t, bob1))
+
+	// direct message to all sessions of a user
+	writeText(t, alice, "user:hi bob")
+	assert.Equal(t, "hi bob", readText(t, bob1))
+	assert.Equal(t, "hi bob", readText(t, bob2))
+
+	// broadcast
+	writeText(t, bob2, "all:everyone")
+	for _, conn := range []*websocket.Conn{alice, bob1, bob2} {
+		assert.Equal(t, "everyone", readText(t, conn))
+	}
+
+	writeText(t, alice, "leave:chat")
+	assert.Equal(t, "left", readText(t, alice))
+	assert.Equal(t, []string{"bob"}, hub.RoomMembers("chat"))
+
+	// presence
+	_ = bob1.Close()
+	_ = bob2.Close()
+	assert.Eventually(t, func() bool { return !hub.IsOnline("bob") }, time.Second, 10*time.Millisecond)
+	assert.Empty(t, hub.RoomMembers("chat"))
+	mu.Lock()
+	assert.Equal(t, []PresenceEvent{
+		{UserID: "alice", Online: true},
+		{UserID: "bob", Online: true},
+		{UserID: "bob", Online: false},
+	}, events)
+	mu.Unlock()
+}
+
+func TestHubRedisBackplane(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+	cli := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer cli.Close()
+
+	hub1, err := NewHub(WithHubBackplane(NewRedisBackplane(cli, "test:ws")))
+	require.NoError(t, err)
+	defer hub1.Close()
+	hub2, err := NewHub(WithHubBackplane(NewRedisBackplane(cli, "test:ws")))
+	require.NoError(t, err)
+	defer hub2.Close()
+
+	alice := dialHub(t, newHubServer(t, hub1), "alice")
+	bob := dialHub(t, newHubServer(t, hub2), "bob")
+	assert.Eventually(t, func() bool { return hub1.IsOnline("alice") && hub2.IsOnline("bob") }, time.Second, 10*time.Millisecond)
+
+	// sent from instance 1, received by the user of instance 2
+	writeText(t, alice, "user:hi bob")
+	assert.Equal(t, "hi bob", readText(t, bob))
+
+	// delivered only once to the sessions of the publishing instance
+	writeText(t, bob, "all:everyone")
+	assert.Equal(t, "everyone", readText(t, alice))
+	assert.Equal(t, "everyone", readText(t, bob))
+	_ = bob.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	_, _, err = bob.ReadMessage()
+	assert.Error(t, err)
+}
+
+func TestSessionSendQueuePolicy(t *testing.T) {
+	newSession := func(policy SendQueuePolicy) *Session {
+		hub, err := NewHub(WithHubSendQueue(2, policy))
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = hub.Close() })
+		conn := dialHub(t, newHubServer(t, hub), "alice")
+		return &Session{id: "1", conn: conn, hub: hub, rooms: make(map[string]struct{}),
+			send: make(chan outMessage, hub.sendQueueSize), done: make(chan struct{})}
+	}
+	queued := func(s *Session) []string {
+		var messages []string
+		for len(s.send) > 0 {
+			messages = append(messages, string((<-s.send).data))
+		}
+		return messages
+	}
+
+	s := newSession(SendQueueDropNewest)
+	for _, msg := range []string{"1", "2"} {
+		assert.NoError(t, s.Send([]byte(msg)))
+	}
+	assert.ErrorIs(t, s.Send([]byte("3")), ErrSendQueueFull)
+	assert.Equal(t, int64(1), s.DroppedCount())
+	assert.Equal(t, []string{"1", "2"}, queued(s))
+
+	s = newSession(SendQueueDropOldest)
+	for _, msg := range []string{"1", "2", "3", "4"} {
+		assert.NoError(t, s.Send([]byte(msg)))
+	}
+	assert.Equal(t, int64(2), s.DroppedCount())
+	assert.Equal(t, []string{"3", "4"}, queued(s))
+
+	s = newSession(SendQueueClose)
+	for _, msg := range []string{"1", "2"} {
+		assert.NoError(t, s.Send([]byte(msg)))
+	}
+	assert.ErrorIs(t, s.Send([]byte("3")), ErrSessionClosed)
+	assert.ErrorIs(t, s.Send([]byte("4")), ErrSessionClosed)
+}